@@ -46,6 +46,7 @@ Doing so will override the standard connectivity checks.`,
 
 Available settings:
      acceptingcontracts:   boolean
+     maxcontractbandwidth: filesize / hour
      maxduration:          blocks
      maxdownloadbatchsize: bytes
      maxrevisebatchsize:   bytes
@@ -63,18 +64,25 @@ Available settings:
      minstorageprice:           currency / TB / Month
      minuploadbandwidthprice:   currency / TB
 
+     minnftstorageprice: currency / TB / Month
+     nftretentionperiod: blocks
+     nftstoragepool:     boolean
+
      ephemeralaccountexpiry:     seconds
      maxephemeralaccountbalance: currency
      maxephemeralaccountrisk:    currency
 	 
-     registrysize:       filesize
-     customregistrypath: string
+     registrysize:        filesize
+     customregistrypath:  string
+     maxregistryentryttl: blocks
+     freeregistryreads:   int
 
 Currency units can be specified, e.g. 10SC; run 'siac help wallet' for details.
 
-Durations (maxduration and windowsize) must be specified in either blocks (b),
-hours (h), days (d), or weeks (w). A block is approximately 10 minutes, so one
-hour is six blocks, a day is 144 blocks, and a week is 1008 blocks.
+Durations (maxduration, maxregistryentryttl, nftretentionperiod and windowsize)
+must be specified in either blocks (b), hours (h), days (d), or weeks (w). A
+block is approximately 10 minutes, so one hour is six blocks, a day is 144
+blocks, and a week is 1008 blocks.
 
 Timeouts (ephemeralaccountexpiry) must be specified in either seconds (s),
 hours (h), days (d), or weeks (w). One hour is 3600 seconds, a day is 86400
@@ -94,8 +102,9 @@ To configure the host to accept new contracts, set acceptingcontracts to true:
 		Long: `Show host contracts sorted by expiration height.
 
 Available output types:
-     value:  show financial information
-     status: show status information
+     value:     show financial information
+     status:    show status information
+     bandwidth: show the bandwidth used since the host started
 `,
 		Run: wrap(hostcontractcmd),
 	}
@@ -214,6 +223,7 @@ func hostcmd() {
 
 Host Internal Settings:
 	acceptingcontracts:   %v
+	maxcontractbandwidth: %v / hour
 	maxdownloadbatchsize: %v
 	maxduration:          %v Weeks
 	maxrevisebatchsize:   %v
@@ -231,12 +241,16 @@ Host Internal Settings:
 	minstorageprice:           %v / TB / Month
 	minuploadbandwidthprice:   %v / TB
 
+	minnftstorageprice: %v / TB / Month
+	nftretentionperiod: %v Weeks
+
 	ephemeralaccountexpiry:     %vs
 	maxephemeralaccountbalance: %v
 	maxephemeralaccountrisk:    %v
 
 	registrysize:       %v
 	customregistrypath: %v
+	freeregistryreads:  %v / minute
 
 Host Financials:
 	Contract Count:               %v
@@ -269,6 +283,7 @@ RPC Stats:
 			es.Version,
 
 			yesNo(is.AcceptingContracts),
+			modules.FilesizeUnits(is.MaxContractBandwidth),
 			modules.FilesizeUnits(is.MaxDownloadBatchSize),
 			periodUnits(is.MaxDuration),
 			modules.FilesizeUnits(is.MaxReviseBatchSize),
@@ -286,11 +301,15 @@ RPC Stats:
 			currencyUnits(is.MinStoragePrice.Mul(modules.BlockBytesPerMonthTerabyte)),
 			currencyUnits(is.MinUploadBandwidthPrice.Mul(modules.BytesPerTerabyte)),
 
+			currencyUnits(is.MinNFTStoragePrice.Mul(modules.BlockBytesPerMonthTerabyte)),
+			periodUnits(is.NFTRetentionPeriod),
+
 			is.EphemeralAccountExpiry.Seconds(),
 			currencyUnits(is.MaxEphemeralAccountBalance),
 			currencyUnits(is.MaxEphemeralAccountRisk),
 			modules.FilesizeUnits(is.RegistrySize),
 			is.CustomRegistryPath,
+			is.FreeRegistryReads,
 
 			fm.ContractCount, currencyUnits(fm.ContractCompensation),
 			currencyUnits(fm.PotentialContractCompensation),
@@ -389,7 +408,7 @@ func hostconfigcmd(param, value string) {
 		value = c.String()
 
 	// currency/TB/month (convert to hastings/byte/block)
-	case "collateral", "minstorageprice":
+	case "collateral", "minstorageprice", "minnftstorageprice":
 		hastings, err := types.ParseCurrency(value)
 		if err != nil {
 			die("Could not parse "+param+":", err)
@@ -399,7 +418,7 @@ func hostconfigcmd(param, value string) {
 		value = c.String()
 
 	// bool (allow "yes" and "no")
-	case "acceptingcontracts":
+	case "acceptingcontracts", "nftstoragepool":
 		switch strings.ToLower(value) {
 		case "yes":
 			value = "true"
@@ -408,14 +427,14 @@ func hostconfigcmd(param, value string) {
 		}
 
 	// duration (convert to blocks)
-	case "maxduration", "windowsize":
+	case "maxduration", "maxregistryentryttl", "nftretentionperiod", "windowsize":
 		value, err = parsePeriod(value)
 		if err != nil {
 			die("Could not parse "+param+":", err)
 		}
 
 	// filesize (convert to bytes)
-	case "registrysize":
+	case "maxcontractbandwidth", "registrysize":
 		value, err = parseFilesize(value)
 		if err != nil {
 			die("Could not parse "+param+":", err)
@@ -429,7 +448,7 @@ func hostconfigcmd(param, value string) {
 		}
 
 	// other valid settings
-	case "maxdownloadbatchsize", "maxrevisebatchsize", "netaddress", "customregistrypath":
+	case "maxdownloadbatchsize", "maxrevisebatchsize", "netaddress", "customregistrypath", "freeregistryreads":
 
 	// invalid settings
 	default:
@@ -475,6 +494,12 @@ func hostcontractcmd() {
 			fmt.Fprintf(w, "%s\t%s\t%d\t%t\t%t\t%t\t%t\t%t\n", so.ObligationId, strings.TrimPrefix(so.ObligationStatus, "obligation"), so.ExpirationHeight, so.OriginConfirmed,
 				so.RevisionConstructed, so.RevisionConfirmed, so.ProofConstructed, so.ProofConfirmed)
 		}
+	case "bandwidth":
+		fmt.Fprintf(w, "Obligation ID\tObligation Status\tUploaded\tDownloaded\n")
+		for _, so := range cg.Contracts {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", so.ObligationId, strings.TrimPrefix(so.ObligationStatus, "obligation"),
+				modules.FilesizeUnits(so.UploadBandwidth), modules.FilesizeUnits(so.DownloadBandwidth))
+		}
 	default:
 		die("\"" + hostContractOutputType + "\" is not a format")
 	}
@@ -62,6 +62,7 @@ func printScoreBreakdown(info *api.HostdbHostsGET) {
 	fmt.Fprintf(w, "\t\tAge:\t %.3f\n", info.ScoreBreakdown.AgeAdjustment)
 	fmt.Fprintf(w, "\t\tBase Price:\t %.3f\n", info.ScoreBreakdown.BasePriceAdjustment)
 	fmt.Fprintf(w, "\t\tBurn:\t %.3f\n", info.ScoreBreakdown.BurnAdjustment)
+	fmt.Fprintf(w, "\t\tCapabilities:\t %.3f\n", info.ScoreBreakdown.CapabilityAdjustment)
 	fmt.Fprintf(w, "\t\tCollateral:\t %.3f\n", info.ScoreBreakdown.CollateralAdjustment/1e96)
 	fmt.Fprintf(w, "\t\tDuration:\t %.3f\n", info.ScoreBreakdown.DurationAdjustment)
 	fmt.Fprintf(w, "\t\tInteraction:\t %.3f\n", info.ScoreBreakdown.InteractionAdjustment)
@@ -419,6 +420,9 @@ func hostdbviewcmd(pubkey string) {
 	fmt.Fprintln(w, "\t\tDownload Price (1 TB):\t", currencyUnits(info.Entry.DownloadBandwidthPrice.Mul(modules.BytesPerTerabyte)))
 	fmt.Fprintln(w, "\t\tUpload Price (1 TB):\t", currencyUnits(info.Entry.UploadBandwidthPrice.Mul(modules.BytesPerTerabyte)))
 	fmt.Fprintln(w, "\t\tUnlock Hash:\t", info.Entry.UnlockHash)
+	fmt.Fprintln(w, "\n\t\tRegistry Entries Left:\t", info.Entry.RegistryEntriesLeft)
+	fmt.Fprintln(w, "\t\tMax Registry Entry TTL:\t", info.Entry.MaxRegistryEntryTTL)
+	fmt.Fprintln(w, "\t\tNFT Storage Pool:\t", info.Entry.NFTStoragePool)
 	fmt.Fprintln(w, "\n\t\tVersion:\t", info.Entry.Version)
 	fmt.Fprintln(w, "\t\tRevision Number:\t", info.Entry.RevisionNumber)
 	if err := w.Flush(); err != nil {
@@ -6,12 +6,49 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 
 	"go.sia.tech/siad/modules"
 )
 
+const (
+	// jsonOutputVersion is the version of the output printed by commands
+	// run with the --json flag. It is increased whenever a field of the
+	// output is removed or changes its meaning.
+	jsonOutputVersion = 1
+
+	// jsonAnnotation is the annotation of the commands which support the
+	// --json flag.
+	jsonAnnotation = "json"
+)
+
+type (
+	// jsonResponse is the envelope of the output printed by commands run
+	// with the --json flag. The output of a successful command is printed to
+	// stdout with Data set, errors are printed to stderr with Error set.
+	jsonResponse struct {
+		Version int         `json:"version"`
+		Data    interface{} `json:"data,omitempty"`
+		Error   string      `json:"error,omitempty"`
+	}
+
+	// jsonRenterAllowance is the output of `siac renter allowance --json`.
+	jsonRenterAllowance struct {
+		Allowance        modules.Allowance          `json:"allowance"`
+		FinancialMetrics modules.ContractorSpending `json:"financialmetrics"`
+	}
+
+	// jsonRenterDirectory is a directory listed by `siac renter ls --json`.
+	jsonRenterDirectory struct {
+		Directory modules.DirectoryInfo   `json:"directory"`
+		SubDirs   []modules.DirectoryInfo `json:"subdirs"`
+		Files     []modules.FileInfo      `json:"files"`
+	}
+)
+
 var (
 	jsonCmd = &cobra.Command{
 		Use:   "json",
@@ -97,3 +134,41 @@ func jsoncmd() {
 	}
 	fmt.Println(string(json))
 }
+
+// supportJSON marks the commands as supporting the --json flag.
+func supportJSON(cmds ...*cobra.Command) {
+	for _, cmd := range cmds {
+		if cmd.Annotations == nil {
+			cmd.Annotations = make(map[string]string)
+		}
+		cmd.Annotations[jsonAnnotation] = "true"
+	}
+}
+
+// checkJSONSupport dies if the --json flag is passed to a command which
+// doesn't support it.
+func checkJSONSupport(cmd *cobra.Command, _ []string) {
+	if jsonOutput && cmd.Annotations[jsonAnnotation] == "" {
+		die(fmt.Sprintf("'%v' doesn't support the --json flag", cmd.CommandPath()))
+	}
+}
+
+// printJSON prints the output of a command run with the --json flag to stdout.
+func printJSON(data interface{}) {
+	b, err := json.MarshalIndent(jsonResponse{Version: jsonOutputVersion, Data: data}, "", "  ")
+	if err != nil {
+		die("Could not marshal the json output:", err)
+	}
+	fmt.Println(string(b))
+}
+
+// printJSONError prints the error of a command run with the --json flag to
+// stderr.
+func printJSONError(msg string) {
+	b, err := json.Marshal(jsonResponse{Version: jsonOutputVersion, Error: strings.TrimSpace(msg)})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, msg)
+		return
+	}
+	fmt.Fprintln(os.Stderr, string(b))
+}
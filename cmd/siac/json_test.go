@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestJSONOutput tests that commands run with the --json flag print versioned
+// JSON to stdout and errors as JSON to stderr. The test isn't parallel since it
+// changes the global client and flags.
+func TestJSONOutput(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+
+	groupDir := siacTestDir(t.Name())
+	n, err := newTestNode(groupDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := n.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	oldClient := httpClient
+	httpClient = n.Client
+	jsonOutput = true
+	defer func() {
+		httpClient = oldClient
+		jsonOutput = false
+	}()
+
+	// runJSON runs a siac command and decodes its output.
+	runJSON := func(cmd func()) (resp jsonResponse, data json.RawMessage) {
+		c, err := newOutputCatcher()
+		if err != nil {
+			t.Fatal(err)
+		}
+		func() {
+			// Recover from the panic of die.
+			defer func() {
+				_ = recover()
+			}()
+			cmd()
+		}()
+		output, err := c.stop()
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Data = &data
+		if err := json.Unmarshal([]byte(output), &resp); err != nil {
+			t.Fatalf("output isn't json: %v\n%v", err, output)
+		}
+		if resp.Version != jsonOutputVersion {
+			t.Fatal("wrong version", resp.Version)
+		}
+		return resp, data
+	}
+
+	// Successful commands print their data.
+	resp, data := runJSON(renterallowancecmd)
+	if resp.Error != "" {
+		t.Fatal(resp.Error)
+	}
+	var allowance jsonRenterAllowance
+	if err := json.Unmarshal(data, &allowance); err != nil {
+		t.Fatal(err)
+	}
+	rg, err := n.RenterGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !allowance.Allowance.Funds.Equals(rg.Settings.Allowance.Funds) || allowance.Allowance.Period != rg.Settings.Allowance.Period {
+		t.Fatal("wrong allowance", allowance.Allowance)
+	}
+
+	resp, data = runJSON(func() { renterfileslistcmd(renterFilesListCmd, nil) })
+	if resp.Error != "" {
+		t.Fatal(resp.Error)
+	}
+	var dirs []jsonRenterDirectory
+	if err := json.Unmarshal(data, &dirs); err != nil {
+		t.Fatal(err)
+	}
+	if len(dirs) != 1 || !dirs[0].Directory.SiaPath.IsRoot() {
+		t.Fatal("wrong listing", dirs)
+	}
+
+	// Errors are printed as JSON.
+	resp, _ = runJSON(func() { rentercontractsviewcmd("unknown") })
+	if resp.Error != "Contract not found" {
+		t.Fatal("wrong error", resp.Error)
+	}
+
+	// Commands without JSON support fail.
+	resp, _ = runJSON(func() { checkJSONSupport(walletBalanceCmd, nil) })
+	if !strings.Contains(resp.Error, "doesn't support the --json flag") {
+		t.Fatal("wrong error", resp.Error)
+	}
+}
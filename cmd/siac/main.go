@@ -18,6 +18,7 @@ import (
 var (
 	// General Flags
 	alertSuppress bool
+	jsonOutput    bool   // Print machine-readable JSON instead of human output
 	siaDir        string // Path to sia data dir
 	verbose       bool   // Display additional information
 
@@ -48,6 +49,7 @@ var (
 	renterListRoot            bool   // List path start from root instead of the UserFolder.
 	renterRenameRoot          bool   // Rename files relative to root instead of the UserFolder.
 	renterShowHistory         bool   // Show download history in addition to download queue.
+	renterFullRecoveryScan    bool   // Recover contracts without a contract identifier.
 
 	// Renter Allowance Flags
 	allowanceFunds       string // amount of money to be used within a period
@@ -67,6 +69,18 @@ var (
 	allowanceMaxStoragePrice           string // max allowed price to store data on a host
 	allowanceMaxUploadBandwidthPrice   string // max allowed price to upload data to a host
 
+	allowanceNFTStorage string // whether the allowance is used to store NFT data
+
+	allowanceMinRegistryEntries  string // free registry entries required from hosts
+	allowanceMinRegistryEntryTTL string // registry entry ttl required from hosts
+	allowanceNFTStoragePool      string // whether hosts need to participate in the nft storage pool
+
+	allowanceNFTRepairThreshold   string // health score below which pinned nfts are repaired
+	allowanceMaxNFTRepairsPerHour string // max number of nft repairs started per hour
+	allowanceMaxNFTRepairSpending string // max amount spent on nft repairs per period
+
+	allowanceMaxTxnFeePerByte string // max txn fee per byte paid for contract formations and renewals
+
 	// Skykey Flags
 	skykeyID              string // ID used to identify a Skykey.
 	skykeyName            string // Name used to identify a Skykey.
@@ -131,7 +145,11 @@ func wrap(fn interface{}) func(*cobra.Command, []string) {
 // default error code, during tests it passes panic so that tests can catch the
 // panic and check printed errors
 func die(args ...interface{}) {
-	fmt.Fprintln(os.Stderr, args...)
+	if jsonOutput {
+		printJSONError(fmt.Sprintln(args...))
+	} else {
+		fmt.Fprintln(os.Stderr, args...)
+	}
 
 	if build.Release == "testing" {
 		// In testing pass panic that can be catched and the test can continue
@@ -262,7 +280,7 @@ func main() {
 
 		// Check for Critical Alerts
 		alerts, err := httpClient.DaemonAlertsGet()
-		if err == nil && len(alerts.CriticalAlerts) > 0 && !alertSuppress {
+		if err == nil && len(alerts.CriticalAlerts) > 0 && !alertSuppress && !jsonOutput {
 			printAlerts(alerts.CriticalAlerts, modules.SeverityCritical)
 			fmt.Println("------------------")
 			fmt.Printf("\n  The above %v critical alerts should be resolved ASAP\n\n", len(alerts.CriticalAlerts))
@@ -286,6 +304,8 @@ func initCmds() *cobra.Command {
 		Short: "siac v" + build.NodeVersion,
 		Long:  "siac v" + build.NodeVersion,
 		Run:   wrap(statuscmd),
+
+		PersistentPreRun: checkJSONSupport,
 	}
 
 	// create command tree (alphabetized by root command)
@@ -315,7 +335,8 @@ func initCmds() *cobra.Command {
 		renterCleanCmd, renterContractsCmd, renterContractsRecoveryScanProgressCmd, renterDownloadCancelCmd,
 		renterDownloadsCmd, renterExportCmd, renterFilesDeleteCmd, renterFilesDownloadCmd,
 		renterFilesListCmd, renterFilesRenameCmd, renterFilesUnstuckCmd, renterFilesUploadCmd,
-		renterFuseCmd, renterLostCmd, renterPricesCmd, renterRatelimitCmd, renterSetAllowanceCmd,
+		renterFuseCmd, renterLostCmd, renterNFTBundleExportCmd, renterNFTBundleImportCmd, renterNFTHealthCmd, renterPricesCmd,
+		renterRatelimitCmd, renterSetAllowanceCmd,
 		renterSetLocalPathCmd, renterTriggerContractRecoveryScanCmd, renterUploadsCmd, renterWorkersCmd,
 		renterHealthSummaryCmd)
 	renterWorkersCmd.AddCommand(renterWorkersAccountsCmd, renterWorkersDownloadsCmd, renterWorkersPriceTableCmd, renterWorkersReadJobsCmd, renterWorkersHasSectorJobSCmd, renterWorkersUploadsCmd, renterWorkersReadRegistryCmd, renterWorkersUpdateRegistryCmd)
@@ -323,7 +344,7 @@ func initCmds() *cobra.Command {
 	renterAllowanceCmd.AddCommand(renterAllowanceCancelCmd)
 	renterBubbleCmd.Flags().BoolVarP(&renterBubbleAll, "all", "A", false, "Bubble the entire directory tree")
 	renterContractsCmd.AddCommand(renterContractsViewCmd)
-	renterFilesUploadCmd.AddCommand(renterFilesUploadPauseCmd, renterFilesUploadResumeCmd)
+	renterFilesUploadCmd.AddCommand(renterFilesUploadPauseCmd, renterFilesUploadResumeCmd, renterFilesUploadScheduleCmd)
 
 	renterContractsCmd.Flags().BoolVarP(&renterAllContracts, "all", "A", false, "Show all expired contracts in addition to active contracts")
 	renterDownloadsCmd.Flags().BoolVarP(&renterShowHistory, "history", "H", false, "Show download history in addition to the download queue")
@@ -337,6 +358,7 @@ func initCmds() *cobra.Command {
 	renterFilesUploadCmd.Flags().StringVar(&parityPieces, "parity-pieces", "", "the number of parity pieces a files should be uploaded with")
 	renterExportCmd.AddCommand(renterExportContractTxnsCmd)
 	renterFilesRenameCmd.Flags().BoolVar(&renterRenameRoot, "root", false, "Rename files relative to root instead of the user homedir")
+	renterTriggerContractRecoveryScanCmd.Flags().BoolVar(&renterFullRecoveryScan, "full", false, "Also recover contracts without a contract identifier by matching them against all hosts in the hostdb")
 
 	renterSetAllowanceCmd.Flags().StringVar(&allowanceFunds, "amount", "", "amount of money in allowance, specified in currency units")
 	renterSetAllowanceCmd.Flags().StringVar(&allowancePeriod, "period", "", "period of allowance in blocks (b), hours (h), days (d) or weeks (w)")
@@ -352,6 +374,17 @@ func initCmds() *cobra.Command {
 	renterSetAllowanceCmd.Flags().StringVar(&allowanceMaxSectorAccessPrice, "max-sector-access-price", "", "the maximum price that the renter will pay to access a sector on a host")
 	renterSetAllowanceCmd.Flags().StringVar(&allowanceMaxStoragePrice, "max-storage-price", "", "the maximum price that the renter will pay to store data on a host")
 	renterSetAllowanceCmd.Flags().StringVar(&allowanceMaxUploadBandwidthPrice, "max-upload-bandwidth-price", "", "the maximum price that the renter will pay to upload data to a host")
+	renterSetAllowanceCmd.Flags().StringVar(&allowanceNFTStorage, "nft-storage", "", "whether the allowance is used to store NFT data, which uses the hosts' NFT storage prices and retention periods")
+	renterSetAllowanceCmd.Flags().StringVar(&allowanceMinRegistryEntries, "min-registry-entries", "", "the number of free registry entries a host needs to offer")
+	renterSetAllowanceCmd.Flags().StringVar(&allowanceMinRegistryEntryTTL, "min-registry-entry-ttl", "", "the duration a host needs to keep registry entries, in blocks (b), hours (h), days (d), or weeks (w)")
+	renterSetAllowanceCmd.Flags().StringVar(&allowanceNFTStoragePool, "nft-storage-pool", "", "whether hosts need to participate in the NFT storage pool")
+	renterSetAllowanceCmd.Flags().StringVar(&allowanceNFTRepairThreshold, "nft-repair-threshold", "", "the health score between 0 and 1 below which the data of a pinned NFT is repaired")
+	renterSetAllowanceCmd.Flags().StringVar(&allowanceMaxNFTRepairsPerHour, "max-nft-repairs-per-hour", "", "the number of repairs of pinned NFTs that are started at most within an hour")
+	renterSetAllowanceCmd.Flags().StringVar(&allowanceMaxNFTRepairSpending, "max-nft-repair-spending", "", "the amount of the allowance that is spent at most on repairing pinned NFTs within a period")
+	renterSetAllowanceCmd.Flags().StringVar(&allowanceMaxTxnFeePerByte, "max-txn-fee-per-byte", "", "the transaction fee per byte above which contract formations and renewals are deferred until the fees drop")
+
+	supportJSON(renterCmd, renterAllowanceCmd, renterBackupListCmd, renterContractsCmd, renterContractsViewCmd,
+		renterDownloadsCmd, renterFilesListCmd, renterNFTHealthCmd, renterPricesCmd, renterUploadsCmd)
 
 	renterFuseCmd.AddCommand(renterFuseMountCmd, renterFuseUnmountCmd)
 	renterFuseMountCmd.Flags().BoolVarP(&renterFuseMountAllowOther, "allow-other", "", false, "Allow users other than the user that mounted the fuse directory to access and use the fuse directory")
@@ -375,7 +408,7 @@ func initCmds() *cobra.Command {
 
 	root.AddCommand(walletCmd)
 	walletCmd.AddCommand(walletAddressCmd, walletAddressesCmd, walletBalanceCmd, walletBroadcastCmd, walletChangepasswordCmd,
-		walletInitCmd, walletInitSeedCmd, walletLoadCmd, walletLockCmd, walletSeedsCmd, walletSendCmd,
+		walletInitCmd, walletInitSeedCmd, walletLoadCmd, walletLockCmd, walletNFTMintCmd, walletSeedsCmd, walletSendCmd,
 		walletSignCmd, walletSweepCmd, walletTransactionsCmd, walletUnlockCmd)
 	walletInitCmd.Flags().BoolVarP(&initPassword, "password", "p", false, "Prompt for a custom password")
 	walletInitCmd.Flags().BoolVarP(&initForce, "force", "", false, "destroy the existing wallet and re-encrypt")
@@ -401,6 +434,7 @@ func initClient(root *cobra.Command, verbose *bool, client *client.Client, siaDi
 	root.PersistentFlags().StringVarP(siaDir, "sia-directory", "d", "", "location of the sia directory")
 	root.PersistentFlags().StringVarP(&client.UserAgent, "useragent", "", "Sia-Agent", "the useragent used by siac to connect to the daemon's API")
 	root.PersistentFlags().BoolVarP(alertSuppress, "alert-suppress", "s", false, "suppress siac alerts")
+	root.PersistentFlags().BoolVarP(&jsonOutput, "json", "", false, "print versioned JSON to stdout and errors as JSON to stderr, supported by the renter commands")
 }
 
 // setAPIPasswordIfNotSet sets API password if it was not set
@@ -7,6 +7,7 @@ import (
 	"bufio"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
@@ -22,6 +23,7 @@ import (
 
 	"gitlab.com/NebulousLabs/errors"
 	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/modules/renter/filesystem"
 	"go.sia.tech/siad/node/api"
@@ -232,6 +234,16 @@ For Example: 'siac renter upload pause 3h' would pause uploads for 3 hours.`,
 		Run:   wrap(renterfilesuploadresumecmd),
 	}
 
+	renterFilesUploadScheduleCmd = &cobra.Command{
+		Use:   "schedule [start-end]",
+		Short: "Only upload during certain hours of the day",
+		Long: `Restrict renter uploads and repairs to the hours from start up to end in the
+renter's local time zone. Outside of these hours the uploads are paused.
+For Example: 'siac renter upload schedule 22-6' would only upload at night.
+Use 'off' to upload at any time.`,
+		Run: wrap(renterfilesuploadschedulecmd),
+	}
+
 	renterPricesCmd = &cobra.Command{
 		Use:   "prices [amount] [period] [hosts] [renew window]",
 		Short: "Display the price of storage and bandwidth",
@@ -364,6 +376,37 @@ have a reasonable number (>30) of hosts in your hostdb.`,
 		Long:  "Display the renter's lost files",
 		Run:   wrap(renterlostcmd),
 	}
+
+	renterNFTBundleExportCmd = &cobra.Command{
+		Use:   "exportnftbundle [collection] [folder] [destination]",
+		Short: "Export the siafiles of an NFT collection",
+		Long: `Export the metadata of all siafiles within a folder, which store the data of
+an NFT collection, to a bundle file at the destination. The bundle can be
+imported by another renter to download and repair the data without uploading
+it again. It contains the encryption keys of the files, so anyone holding it
+can read the data. To export the root folder pass in '.' as the folder.`,
+		Run: wrap(renternftbundleexportcmd),
+	}
+
+	renterNFTBundleImportCmd = &cobra.Command{
+		Use:   "importnftbundle [source] [folder]",
+		Short: "Import the siafiles of an NFT collection",
+		Long: `Import the siafiles of an NFT bundle into the folder and bind them to the
+renter's contracts. Pieces stored on hosts the renter has no contract with are
+repaired onto the renter's hosts. To import into the root folder pass in '.'
+as the folder.`,
+		Run: wrap(renternftbundleimportcmd),
+	}
+
+	renterNFTHealthCmd = &cobra.Command{
+		Use:   "nfthealth [merkleroot]",
+		Short: "Display the health of a pinned NFT's data",
+		Long: `Display how safe the data of a pinned NFT is. The score is the fraction of
+the redundant pieces of the worst chunk that are stored on good hosts, 1 being
+fully redundant and 0 being at risk of loss. Lists what lowers the score and
+the status of every host storing pieces of the data.`,
+		Run: wrap(renternfthealthcmd),
+	}
 )
 
 // rentercleancmd cleans any lost files from the renter.
@@ -394,11 +437,19 @@ func rentercleancmd() {
 
 // rentercmd displays the renter's financial metrics and high level renter info
 func rentercmd() {
+	// Get Renter
+	rg, err := httpClient.RenterGet()
+	if jsonOutput {
+		if err != nil {
+			die("Could not get renter info:", err)
+		}
+		printJSON(rg)
+		return
+	}
+
 	// For UX formating
 	defer fmt.Println()
 
-	// Get Renter
-	rg, err := httpClient.RenterGet()
 	if errors.Contains(err, api.ErrAPICallNotRecognized) {
 		// Assume module is not loaded if status command is not recognized.
 		fmt.Printf("Renter:\n  Status: %s\n\n", moduleNotReadyStatus)
@@ -472,6 +523,7 @@ func rentercmd() {
 		fmt.Fprintf(w, "\nUploads Status\n")
 		fmt.Fprintf(w, "  Paused:\t%v\n", yesNo(rg.Settings.UploadsStatus.Paused))
 		fmt.Fprintf(w, "  Pause End Time:\t%v\n", pauseEndTime)
+		fmt.Fprintf(w, "  Schedule:\t%v\n", rg.Settings.UploadSchedule)
 	}
 
 	// Flush the writer
@@ -495,6 +547,121 @@ func renterlostcmd() {
 	}
 }
 
+// renternfthealthcmd is the handler for displaying the health of a pinned
+// NFT's data.
+func renternfthealthcmd(root string) {
+	var hash crypto.Hash
+	err := hash.LoadString(root)
+	if err != nil {
+		die("Could not parse merkle root:", err)
+	}
+	health, err := httpClient.RenterNFTHealthGet(hash)
+	if err != nil {
+		die("Could not get nft health:", err)
+	}
+	if jsonOutput {
+		printJSON(health)
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "NFT Health\n")
+	fmt.Fprintf(w, "  SiaPath:\t%v\n", health.SiaPath)
+	fmt.Fprintf(w, "  Score:\t%.2f\n", health.Score)
+	fmt.Fprintf(w, "  Health:\t%.2f%%\n", modules.HealthPercentage(health.Health))
+	fmt.Fprintf(w, "  Redundancy:\t%.2f\n", health.Redundancy)
+	fmt.Fprintf(w, "  Recoverable:\t%v\n", yesNo(health.Recoverable))
+	fmt.Fprintf(w, "  Stuck:\t%v\n", yesNo(health.Stuck))
+	fmt.Fprintf(w, "  Good Pieces:\t%v of %v (%v needed)\n", health.MinGoodPieces, health.NumPieces, health.MinPieces)
+	fmt.Fprintf(w, "  Source Available:\t%v\n", yesNo(health.SourceAvailable))
+	if len(health.Hosts) > 0 {
+		fmt.Fprintf(w, "\nHosts\n")
+		fmt.Fprintf(w, "  Host\tPieces\tContract\tGood For Renew\tOffline\tEnd Height\tLast Proof\tMissed Proofs\n")
+		for _, host := range health.Hosts {
+			fmt.Fprintf(w, "  %v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\n", host.HostPublicKey, host.Pieces, yesNo(host.HasContract), yesNo(host.GoodForRenew), yesNo(host.Offline), host.ContractEndHeight, host.LastProofHeight, host.MissedProofs)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		die(err)
+	}
+
+	if len(health.Reasons) == 0 {
+		return
+	}
+	fmt.Println("\nAttention Required:")
+	for _, reason := range health.Reasons {
+		fmt.Println("  -", reason)
+	}
+}
+
+// parseFolderSiaPath parses the siapath of a folder, '.' being the root
+// folder.
+func parseFolderSiaPath(folder string) modules.SiaPath {
+	if folder == "." {
+		return modules.RootSiaPath()
+	}
+	var siaPath modules.SiaPath
+	if err := siaPath.LoadString(folder); err != nil {
+		die("Unable to load siapath:", err)
+	}
+	return siaPath
+}
+
+// renternftbundleexportcmd is the handler for the command `siac renter
+// exportnftbundle`.
+func renternftbundleexportcmd(collection, folder, destination string) {
+	var siaPaths []modules.SiaPath
+	for _, dir := range getDir(parseFolderSiaPath(folder), false, true) {
+		for _, file := range dir.files {
+			siaPaths = append(siaPaths, file.SiaPath)
+		}
+	}
+	bundle, err := httpClient.RenterNFTBundleExportPost(collection, siaPaths)
+	if err != nil {
+		die("Could not export nft bundle:", err)
+	}
+	b, err := json.Marshal(bundle)
+	if err != nil {
+		die("Could not encode nft bundle:", err)
+	}
+	if err := ioutil.WriteFile(destination, b, 0600); err != nil {
+		die("Could not write nft bundle:", err)
+	}
+	fmt.Printf("Exported %v files of collection %q to %v\n", len(bundle.Files), collection, destination)
+}
+
+// renternftbundleimportcmd is the handler for the command `siac renter
+// importnftbundle`.
+func renternftbundleimportcmd(source, folder string) {
+	b, err := ioutil.ReadFile(source)
+	if err != nil {
+		die("Could not read nft bundle:", err)
+	}
+	var bundle modules.NFTBundle
+	if err := json.Unmarshal(b, &bundle); err != nil {
+		die("Could not decode nft bundle:", err)
+	}
+	imported, err := httpClient.RenterNFTBundleImportPost(bundle, parseFolderSiaPath(folder))
+	if err != nil {
+		die("Could not import nft bundle:", err)
+	}
+	if jsonOutput {
+		printJSON(imported)
+		return
+	}
+	fmt.Printf("Imported %v files of collection %q:\n", len(imported.SiaPaths), imported.Collection)
+	for _, siaPath := range imported.SiaPaths {
+		fmt.Println("  ", siaPath)
+	}
+	if len(imported.MissingHosts) == 0 {
+		return
+	}
+	fmt.Println("\nThe renter has no contract with these hosts storing pieces of the files:")
+	for _, host := range imported.MissingHosts {
+		fmt.Println("  ", host)
+	}
+}
+
 // renterhealthsummarycmd is the handler for displaying the overall health
 // summary for uploaded files.
 func renterhealthsummarycmd() {
@@ -523,6 +690,10 @@ func renteruploadscmd() {
 			filteredFiles = append(filteredFiles, fi)
 		}
 	}
+	if jsonOutput {
+		printJSON(api.RenterFiles{Files: filteredFiles})
+		return
+	}
 	if len(filteredFiles) == 0 {
 		fmt.Println("No files are uploading.")
 		return
@@ -541,6 +712,17 @@ func renterdownloadscmd() {
 	if err != nil {
 		die("Could not get download queue:", err)
 	}
+	if jsonOutput {
+		// Only include the download history if requested.
+		var downloads []api.DownloadInfo
+		for _, file := range queue.Downloads {
+			if !file.Completed || renterShowHistory {
+				downloads = append(downloads, file)
+			}
+		}
+		printJSON(api.RenterDownloadQueue{Downloads: downloads})
+		return
+	}
 	// Filter out files that have been downloaded.
 	var downloading []api.DownloadInfo
 	for _, file := range queue.Downloads {
@@ -584,6 +766,13 @@ func renterallowancecmd() {
 	if err != nil {
 		die("Could not get allowance:", err)
 	}
+	if jsonOutput {
+		printJSON(jsonRenterAllowance{
+			Allowance:        rg.Settings.Allowance,
+			FinancialMetrics: rg.FinancialMetrics,
+		})
+		return
+	}
 	allowance := rg.Settings.Allowance
 
 	// Show allowance info
@@ -597,6 +786,12 @@ func renterallowancecmd() {
   Period:               %v blocks
   Renew Window:         %v blocks
   Hosts:                %v
+  NFT Storage:          %v
+
+Required Host Capabilities:
+  Registry Entries:     %v
+  Registry Entry TTL:   %v blocks
+  NFT Storage Pool:     %v
 
 Expectations for period:
   Expected Storage:     %v
@@ -611,8 +806,10 @@ Price Protections:
   MaxSectorAccessPrice:      %v per million accesses
   MaxStoragePrice:           %v per TB per Month
   MaxUploadBandwidthPrice:   %v per TB
+  MaxTxnFeePerByte:          %v per KB
 `, currencyUnitsWithExchangeRate(allowance.Funds, rate), allowance.Period, allowance.RenewWindow,
-		allowance.Hosts,
+		allowance.Hosts, allowance.NFTStorage,
+		allowance.MinRegistryEntries, allowance.MinRegistryEntryTTL, allowance.NFTStoragePool,
 		modules.FilesizeUnits(allowance.ExpectedStorage),
 		modules.FilesizeUnits(allowance.ExpectedUpload*uint64(allowance.Period)),
 		modules.FilesizeUnits(allowance.ExpectedDownload*uint64(allowance.Period)),
@@ -622,7 +819,8 @@ Price Protections:
 		currencyUnits(allowance.MaxDownloadBandwidthPrice.Mul(modules.BytesPerTerabyte)),
 		currencyUnits(allowance.MaxSectorAccessPrice.Mul64(1e6)),
 		currencyUnits(allowance.MaxStoragePrice.Mul(modules.BlockBytesPerMonthTerabyte)),
-		currencyUnits(allowance.MaxUploadBandwidthPrice.Mul(modules.BytesPerTerabyte)))
+		currencyUnits(allowance.MaxUploadBandwidthPrice.Mul(modules.BytesPerTerabyte)),
+		currencyUnits(allowance.MaxTxnFeePerByte.Mul64(1e3)))
 
 	// Show detailed current Period spending metrics
 	renterallowancespending(rg)
@@ -862,6 +1060,90 @@ func rentersetallowancecmd(_ *cobra.Command, _ []string) {
 		req = req.WithMaxUploadBandwidthPrice(price)
 		changedFields++
 	}
+	// parse nftstorage
+	if allowanceNFTStorage != "" {
+		nftStorage, err := strconv.ParseBool(allowanceNFTStorage)
+		if err != nil {
+			die("Could not parse nft storage:", err)
+		}
+		req = req.WithNFTStorage(nftStorage)
+		changedFields++
+	}
+	// parse required host capabilities
+	if allowanceMinRegistryEntries != "" {
+		var entries uint64
+		_, err := fmt.Sscan(allowanceMinRegistryEntries, &entries)
+		if err != nil {
+			die("Could not parse min registry entries:", err)
+		}
+		req = req.WithMinRegistryEntries(entries)
+		changedFields++
+	}
+	if allowanceMinRegistryEntryTTL != "" {
+		blocks, err := parsePeriod(allowanceMinRegistryEntryTTL)
+		if err != nil {
+			die("Could not parse min registry entry ttl:", err)
+		}
+		var ttl types.BlockHeight
+		_, err = fmt.Sscan(blocks, &ttl)
+		if err != nil {
+			die("Could not parse min registry entry ttl:", err)
+		}
+		req = req.WithMinRegistryEntryTTL(ttl)
+		changedFields++
+	}
+	if allowanceNFTStoragePool != "" {
+		nftStoragePool, err := strconv.ParseBool(allowanceNFTStoragePool)
+		if err != nil {
+			die("Could not parse nft storage pool:", err)
+		}
+		req = req.WithNFTStoragePool(nftStoragePool)
+		changedFields++
+	}
+	// parse nft repair settings
+	if allowanceNFTRepairThreshold != "" {
+		threshold, err := strconv.ParseFloat(allowanceNFTRepairThreshold, 64)
+		if err != nil {
+			die("Could not parse nft repair threshold:", err)
+		}
+		req = req.WithNFTRepairThreshold(threshold)
+		changedFields++
+	}
+	if allowanceMaxNFTRepairsPerHour != "" {
+		var repairs uint64
+		_, err := fmt.Sscan(allowanceMaxNFTRepairsPerHour, &repairs)
+		if err != nil {
+			die("Could not parse max nft repairs per hour:", err)
+		}
+		req = req.WithMaxNFTRepairsPerHour(repairs)
+		changedFields++
+	}
+	if allowanceMaxNFTRepairSpending != "" {
+		spendingStr, err := types.ParseCurrency(allowanceMaxNFTRepairSpending)
+		if err != nil {
+			die("Could not parse max nft repair spending:", err)
+		}
+		var spending types.Currency
+		_, err = fmt.Sscan(spendingStr, &spending)
+		if err != nil {
+			die("Could not read max nft repair spending:", err)
+		}
+		req = req.WithMaxNFTRepairSpending(spending)
+		changedFields++
+	}
+	if allowanceMaxTxnFeePerByte != "" {
+		feeStr, err := types.ParseCurrency(allowanceMaxTxnFeePerByte)
+		if err != nil {
+			die("Could not parse max txn fee per byte:", err)
+		}
+		var fee types.Currency
+		_, err = fmt.Sscan(feeStr, &fee)
+		if err != nil {
+			die("Could not read max txn fee per byte:", err)
+		}
+		req = req.WithMaxTxnFeePerByte(fee)
+		changedFields++
+	}
 
 	// check if any fields were updated.
 	if changedFields == 0 {
@@ -1382,6 +1664,9 @@ func renterbackuplistcmd() {
 	ubs, err := httpClient.RenterBackups()
 	if err != nil {
 		die("Failed to retrieve backups", err)
+	} else if jsonOutput {
+		printJSON(ubs)
+		return
 	} else if len(ubs.Backups) == 0 {
 		fmt.Println("No uploaded backups.")
 		return
@@ -1400,6 +1685,20 @@ func renterbackuplistcmd() {
 // rentercontractscmd is the handler for the command `siac renter contracts`.
 // It lists the Renter's contracts.
 func rentercontractscmd() {
+	if jsonOutput {
+		var rc api.RenterContracts
+		var err error
+		if renterAllContracts {
+			rc, err = httpClient.RenterAllContractsGet()
+		} else {
+			rc, err = httpClient.RenterDisabledContractsGet()
+		}
+		if err != nil {
+			die("Could not get contracts:", err)
+		}
+		printJSON(rc)
+		return
+	}
 	rc, err := httpClient.RenterDisabledContractsGet()
 	if err != nil {
 		die("Could not get contracts:", err)
@@ -1516,6 +1815,15 @@ func rentercontractsviewcmd(cid string) {
 	contracts = append(contracts, rc.ExpiredContracts...)
 	contracts = append(contracts, rc.ExpiredRefreshedContracts...)
 
+	if jsonOutput {
+		for _, c := range contracts {
+			if c.ID.String() == cid {
+				printJSON(c)
+				return
+			}
+		}
+		die("Contract not found")
+	}
 	err = printContractInfo(cid, contracts)
 	if err != nil {
 		die(err)
@@ -1672,7 +1980,11 @@ func rentertriggercontractrecoveryrescancmd() {
 		fmt.Println("Scanned height:\t", crpg.ScannedHeight)
 		return
 	}
-	if err := httpClient.RenterInitContractRecoveryScanPost(); err != nil {
+	triggerScan := httpClient.RenterInitContractRecoveryScanPost
+	if renterFullRecoveryScan {
+		triggerScan = httpClient.RenterInitFullContractRecoveryScanPost
+	}
+	if err := triggerScan(); err != nil {
 		die("Failed to trigger recovery scan", err)
 	}
 	fmt.Println("Successfully triggered contract recovery scan.")
@@ -1726,7 +2038,10 @@ func renterfileslistcmd(cmd *cobra.Command, args []string) {
 		} else {
 			rf, err = httpClient.RenterFileGet(sp)
 		}
-		if err == nil {
+		if err == nil && jsonOutput {
+			printJSON(rf.File)
+			return
+		} else if err == nil {
 			json, err := json.MarshalIndent(rf.File, "", "  ")
 			if err != nil {
 				log.Fatal(err)
@@ -1751,6 +2066,19 @@ func renterfileslistcmd(cmd *cobra.Command, args []string) {
 		sort.Sort(bySiaPathFile(dirs[i].files))
 	}
 
+	if jsonOutput {
+		listing := make([]jsonRenterDirectory, 0, len(dirs))
+		for _, dir := range dirs {
+			listing = append(listing, jsonRenterDirectory{
+				Directory: dir.dir,
+				SubDirs:   dir.subDirs,
+				Files:     dir.files,
+			})
+		}
+		printJSON(listing)
+		return
+	}
+
 	// Get the total number of listings (subdirs and files).
 	root := dirs[0] // Root directory we are querying.
 	totalStored := root.dir.AggregateSize
@@ -2093,6 +2421,24 @@ func renterfilesuploadresumecmd() {
 	fmt.Println("Renter uploads have been resumed")
 }
 
+// renterfilesuploadschedulecmd is the handler for the command `siac renter
+// upload schedule`. It restricts renter uploads to the hours of the schedule.
+func renterfilesuploadschedulecmd(schedule string) {
+	us, err := modules.ParseUploadSchedule(schedule)
+	if err != nil {
+		die("Couldn't parse schedule:", err)
+	}
+	err = httpClient.RenterUploadSchedulePost(us)
+	if err != nil {
+		die("Could not set renter upload schedule:", err)
+	}
+	if !us.Enabled() {
+		fmt.Println("Renter upload schedule has been disabled")
+		return
+	}
+	fmt.Printf("Renter uploads have been scheduled from %v:00 to %v:00\n", us.Start, us.End)
+}
+
 // renterpricescmd is the handler for the command `siac renter prices`, which
 // displays the prices of various storage operations. The user can submit an
 // allowance to have the estimate reflect those settings or the user can submit
@@ -2141,6 +2487,10 @@ func renterpricescmd(cmd *cobra.Command, args []string) {
 	if err != nil {
 		die("Could not read the renter prices:", err)
 	}
+	if jsonOutput {
+		printJSON(rpg)
+		return
+	}
 	periodFactor := uint64(rpg.Allowance.Period / types.BlocksPerMonth)
 
 	// Display Estimate
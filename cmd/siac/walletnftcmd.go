@@ -0,0 +1,316 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/wallet"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	walletNFTMintCmd = &cobra.Command{
+		Use:   "nft-mint",
+		Short: "Mint an NFT interactively",
+		Long: `Walk through minting an NFT of a file step by step. The wizard asks for the
+file, previews the cost of the mint and of storing the file with the renter,
+asks for the destination and collection of the NFT and mints it once confirmed.
+It then waits for the mint to be confirmed by the network.`,
+		Run: wrap(walletnftmintcmd),
+	}
+)
+
+// nftMintPollInterval is the interval at which the mint wizard checks whether
+// the mint was confirmed.
+const nftMintPollInterval = 10 * time.Second
+
+// nftMintWizard reads the answers of the user of the mint wizard.
+type nftMintWizard struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// prompt asks a question and returns the trimmed answer, or def if the answer
+// is empty.
+func (w *nftMintWizard) prompt(question, def string) (string, error) {
+	if def != "" {
+		fmt.Fprintf(w.out, "%s [%s]: ", question, def)
+	} else {
+		fmt.Fprintf(w.out, "%s: ", question)
+	}
+	answer, err := w.in.ReadString('\n')
+	if err != nil && (err != io.EOF || answer == "") {
+		return "", err
+	}
+	if answer = strings.TrimSpace(answer); answer == "" {
+		return def, nil
+	}
+	return answer, nil
+}
+
+// confirm asks a yes or no question until it gets a valid answer.
+func (w *nftMintWizard) confirm(question string) (bool, error) {
+	for {
+		answer, err := w.prompt(question+" [y/n]", "")
+		if err != nil {
+			return false, err
+		}
+		switch strings.ToLower(answer) {
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+	}
+}
+
+// nftMintFile is the file an NFT is minted of.
+type nftMintFile struct {
+	path        string
+	root        crypto.Hash
+	contentType string
+	size        uint64
+}
+
+// readNFTMintFile reads the file at path and computes the merkle root and
+// content type of its data.
+func readNFTMintFile(path string) (nftMintFile, error) {
+	path = abs(path)
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nftMintFile{}, err
+	}
+	if len(data) == 0 {
+		return nftMintFile{}, errors.New("file is empty")
+	}
+	// DetectContentType may add parameters, like the charset of text, which
+	// mints can't commit to.
+	contentType := strings.TrimSpace(strings.Split(http.DetectContentType(data), ";")[0])
+	return nftMintFile{
+		path:        path,
+		root:        crypto.MerkleRoot(data),
+		contentType: contentType,
+		size:        uint64(len(data)),
+	}, nil
+}
+
+// nftMintCosts is the cost preview of the mint wizard.
+type nftMintCosts struct {
+	Lockup  types.Currency
+	Host    types.Currency
+	Custody types.Currency
+	Fee     types.Currency
+
+	// StorageMonth and Upload estimate the cost of storing the file with
+	// the renter. They are only set if the renter's prices are known.
+	StorageMonth types.Currency
+	Upload       types.Currency
+}
+
+// newNFTMintCosts estimates the costs of minting an NFT of size bytes with the
+// given fee per byte. prices may be nil if the renter isn't available.
+func newNFTMintCosts(size uint64, feePerByte types.Currency, prices *modules.RenterPriceEstimation) nftMintCosts {
+	costs := nftMintCosts{
+		Lockup:  types.NFTLockupAmount,
+		Host:    types.NFTHostAmount,
+		Custody: types.OneBaseUnit,
+		Fee:     feePerByte.Mul64(wallet.EstimatedNFTTransactionSize),
+	}
+	if prices != nil {
+		costs.StorageMonth = prices.StorageTerabyteMonth.Mul64(size).Div(modules.BytesPerTerabyte)
+		costs.Upload = prices.UploadTerabyte.Mul64(size).Div(modules.BytesPerTerabyte)
+	}
+	return costs
+}
+
+// Total returns the amount the mint spends from the wallet.
+func (c nftMintCosts) Total() types.Currency {
+	return c.Lockup.Add(c.Host).Add(c.Custody).Add(c.Fee)
+}
+
+// print writes the cost preview to out.
+func (c nftMintCosts) print(out io.Writer, renter bool) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\nCosts of the mint:")
+	fmt.Fprintf(w, "  Lockup (returned on liquidation):\t%v\n", currencyUnits(c.Lockup))
+	fmt.Fprintf(w, "  Storage pool:\t%v\n", currencyUnits(c.Host))
+	fmt.Fprintf(w, "  Custody output:\t%v\n", currencyUnits(c.Custody))
+	fmt.Fprintf(w, "  Transaction fee (estimate):\t%v\n", currencyUnits(c.Fee))
+	fmt.Fprintf(w, "  Total:\t%v\n", currencyUnits(c.Total()))
+	if renter {
+		fmt.Fprintln(w, "Storing the file with the renter (estimate):")
+		fmt.Fprintf(w, "  Upload:\t%v\n", currencyUnits(c.Upload))
+		fmt.Fprintf(w, "  Storage:\t%v / month\n", currencyUnits(c.StorageMonth))
+	} else {
+		fmt.Fprintln(w, "The renter's prices are unavailable, storage costs are not estimated.")
+	}
+	_ = w.Flush()
+}
+
+// walletnftmintcmd is the handler for the command `siac wallet nft-mint`. It
+// walks the user through minting an NFT of a file.
+func walletnftmintcmd() {
+	status, err := httpClient.WalletGet()
+	if err != nil {
+		die("Could not get wallet status:", err)
+	}
+	if !status.Unlocked {
+		die("The wallet needs to be unlocked to mint an NFT")
+	}
+	wiz := &nftMintWizard{in: bufio.NewReader(os.Stdin), out: os.Stdout}
+
+	// Select the file.
+	var file nftMintFile
+	for {
+		path, err := wiz.prompt("Path of the file to mint", "")
+		if err != nil {
+			die("Could not read answer:", err)
+		}
+		file, err = readNFTMintFile(path)
+		if err == nil {
+			break
+		}
+		fmt.Println("Could not read file:", err)
+	}
+	fmt.Printf("Merkle root: %v\nSize:        %v\n", file.root, sizeString(file.size))
+	for {
+		file.contentType, err = wiz.prompt("Content type", file.contentType)
+		if err != nil {
+			die("Could not read answer:", err)
+		}
+		if err = types.ValidateNFTContentType(file.contentType); err == nil {
+			break
+		}
+		fmt.Println("Invalid content type, it has to be a lowercase MIME type like image/png")
+	}
+
+	// Preview the costs.
+	fees, err := httpClient.TransactionPoolFeeGet()
+	if err != nil {
+		die("Could not get fee estimation:", err)
+	}
+	var prices *modules.RenterPriceEstimation
+	if rpg, err := httpClient.RenterPricesGet(modules.Allowance{}); err == nil {
+		prices = &rpg.RenterPriceEstimation
+	}
+	costs := newNFTMintCosts(file.size, fees.Maximum, prices)
+	costs.print(os.Stdout, prices != nil)
+	if status.ConfirmedSiacoinBalance.Cmp(costs.Total()) < 0 {
+		die("\nThe wallet's confirmed balance of", currencyUnits(status.ConfirmedSiacoinBalance), "doesn't cover the mint")
+	}
+	fmt.Println()
+
+	// Choose the destination and collection.
+	var dest types.UnlockHash
+	for {
+		addr, err := wiz.prompt("Destination address (empty for a new address of this wallet)", "")
+		if err != nil {
+			die("Could not read answer:", err)
+		}
+		if addr == "" {
+			break
+		}
+		if err = dest.LoadString(addr); err == nil {
+			break
+		}
+		fmt.Println("Invalid address:", err)
+	}
+	collection, err := wiz.prompt("Collection (empty to mint without a collection)", "")
+	if err != nil {
+		die("Could not read answer:", err)
+	}
+	var nonce uint64
+	if collection != "" {
+		if err := types.ValidateNFTCollection(collection); err != nil {
+			die("Invalid collection:", err)
+		}
+		for {
+			answer, err := wiz.prompt("Nonce, to mint the same file more than once in the collection", "0")
+			if err != nil {
+				die("Could not read answer:", err)
+			}
+			if nonce, err = strconv.ParseUint(answer, 10, 64); err == nil {
+				break
+			}
+			fmt.Println("Invalid nonce:", err)
+		}
+	}
+
+	// Confirm and mint.
+	fmt.Printf("\nMinting %v (%v, %v)\n", file.path, file.contentType, sizeString(file.size))
+	if dest != (types.UnlockHash{}) {
+		fmt.Println("  to", dest)
+	}
+	if collection != "" {
+		fmt.Printf("  in collection %q with nonce %v\n", collection, nonce)
+	}
+	fmt.Println("  for", currencyUnits(costs.Total()))
+	ok, err := wiz.confirm("Mint the NFT?")
+	if err != nil {
+		die("Could not read answer:", err)
+	}
+	if !ok {
+		fmt.Println("Mint aborted")
+		return
+	}
+	nft := types.NftCustody{
+		FileMerkleRoot: file.root,
+		ContentType:    file.contentType,
+		ContentLength:  file.size,
+	}
+	wnmp, err := httpClient.WalletNFTMintPost(nft, collection, nonce, dest)
+	if err != nil {
+		die("Could not mint NFT:", err)
+	}
+	if len(wnmp.TransactionIDs) == 0 {
+		die("Mint returned no transactions")
+	}
+	fmt.Println("Mint submitted")
+	if wnmp.NftID != (types.NftID{}) {
+		fmt.Println("NFT ID:", wnmp.NftID)
+	}
+
+	// Offer to store the file with the renter.
+	if prices != nil {
+		pin, err := wiz.confirm("Upload the file and keep it available with the renter?")
+		if err != nil {
+			die("Could not read answer:", err)
+		}
+		if pin {
+			if err := httpClient.RenterNFTPinPost(file.root, file.path); err != nil {
+				fmt.Println("Could not pin the NFT's data:", err)
+			} else {
+				fmt.Println("The NFT's data is pinned, see 'siac renter nfthealth", file.root.String()+"'")
+			}
+		}
+	}
+
+	// Track the mint until it's confirmed.
+	txid := wnmp.TransactionIDs[len(wnmp.TransactionIDs)-1]
+	fmt.Printf("Waiting for transaction %v to be confirmed, press Ctrl-C to stop waiting\n", txid)
+	for {
+		wtg, err := httpClient.WalletTransactionGet(txid)
+		if err != nil {
+			die("Could not get the mint transaction, it may have been dropped:", err)
+		}
+		if height := wtg.Transaction.ConfirmationHeight; height != types.BlockHeight(math.MaxUint64) {
+			fmt.Println("NFT minted in block", height)
+			return
+		}
+		time.Sleep(nftMintPollInterval)
+	}
+}
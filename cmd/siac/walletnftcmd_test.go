@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestNFTMintWizardPrompt tests that the mint wizard uses the defaults of
+// empty answers and repeats confirmations until they are answered.
+func TestNFTMintWizardPrompt(t *testing.T) {
+	var out strings.Builder
+	wiz := &nftMintWizard{
+		in:  bufio.NewReader(strings.NewReader("\n  answer  \nmaybe\nYes\nlast")),
+		out: &out,
+	}
+	if answer, err := wiz.prompt("q1", "def"); err != nil || answer != "def" {
+		t.Fatal("expected the default", answer, err)
+	}
+	if answer, err := wiz.prompt("q2", "def"); err != nil || answer != "answer" {
+		t.Fatal("expected the trimmed answer", answer, err)
+	}
+	if ok, err := wiz.confirm("q3"); err != nil || !ok {
+		t.Fatal("expected confirmation", ok, err)
+	}
+	if strings.Count(out.String(), "q3 [y/n]: ") != 2 {
+		t.Fatal("invalid answer wasn't asked again", out.String())
+	}
+	// The last line doesn't need a newline.
+	if answer, err := wiz.prompt("q4", ""); err != nil || answer != "last" {
+		t.Fatal("expected the last answer", answer, err)
+	}
+	if _, err := wiz.prompt("q5", "def"); err == nil {
+		t.Fatal("expected an error at the end of the input")
+	}
+}
+
+// TestReadNFTMintFile tests reading the file of the mint wizard.
+func TestReadNFTMintFile(t *testing.T) {
+	dir := build.TempDir("siac", t.Name())
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("\x89PNG\x0d\x0a\x1a\x0a" + strings.Repeat("a", 100))
+	path := filepath.Join(dir, "image.png")
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	file, err := readNFTMintFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.root != crypto.MerkleRoot(data) || file.size != uint64(len(data)) || file.contentType != "image/png" || file.path != path {
+		t.Fatal("unexpected file", file)
+	}
+	if err := types.ValidateNFTContentType(file.contentType); err != nil {
+		t.Fatal(err)
+	}
+
+	// Detected parameters are stripped.
+	if err := ioutil.WriteFile(path, []byte("plain text"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if file, err = readNFTMintFile(path); err != nil || file.contentType != "text/plain" {
+		t.Fatal("unexpected content type", file.contentType, err)
+	}
+
+	// Empty and missing files are rejected.
+	if err := ioutil.WriteFile(path, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readNFTMintFile(path); err == nil {
+		t.Fatal("empty file should be rejected")
+	}
+	if _, err := readNFTMintFile(filepath.Join(dir, "missing")); err == nil {
+		t.Fatal("missing file should be rejected")
+	}
+}
+
+// TestNFTMintCosts tests the cost preview of the mint wizard.
+func TestNFTMintCosts(t *testing.T) {
+	fee := types.NewCurrency64(10)
+	costs := newNFTMintCosts(1e6, fee, nil)
+	expected := types.NFTLockupAmount.Add(types.NFTHostAmount).Add(types.OneBaseUnit).Add(costs.Fee)
+	if !costs.Total().Equals(expected) || costs.Fee.IsZero() {
+		t.Fatal("wrong total", costs.Total(), expected)
+	}
+	if !costs.StorageMonth.IsZero() || !costs.Upload.IsZero() {
+		t.Fatal("storage costs shouldn't be estimated without prices")
+	}
+
+	// 1 MB costs a millionth of the price per TB.
+	prices := &modules.RenterPriceEstimation{
+		StorageTerabyteMonth: types.SiacoinPrecision.Mul64(1e6),
+		UploadTerabyte:       types.SiacoinPrecision.Mul64(2e6),
+	}
+	costs = newNFTMintCosts(1e6, fee, prices)
+	if !costs.StorageMonth.Equals(types.SiacoinPrecision) || !costs.Upload.Equals(types.SiacoinPrecision.Mul64(2)) {
+		t.Fatal("wrong storage costs", costs.StorageMonth, costs.Upload)
+	}
+	if !costs.Total().Equals(expected) {
+		t.Fatal("storage costs shouldn't be part of the mint", costs.Total())
+	}
+	var out strings.Builder
+	costs.print(&out, true)
+	if !strings.Contains(out.String(), currencyUnits(expected)) {
+		t.Fatal("preview doesn't show the total", out.String())
+	}
+}
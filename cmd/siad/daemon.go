@@ -1,10 +1,13 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"runtime/debug"
 	"strconv"
 	"strings"
 	"syscall"
@@ -17,9 +20,23 @@ import (
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/node/api/server"
+	"go.sia.tech/siad/node/api/webhook"
 	"go.sia.tech/siad/profile"
 )
 
+const (
+	// readOnlyModules are the modules run by a read-only node unless the
+	// --modules flag is set, and readOnlyAllowedModules are the modules a
+	// read-only node may run.
+	readOnlyModules        = "gct"
+	readOnlyAllowedModules = "gcte"
+
+	// readOnlyGCPercent is the GC target of read-only nodes. They collect
+	// garbage more often than the default of 100 to keep their memory
+	// footprint small, so that many of them can run on one machine.
+	readOnlyGCPercent = 50
+)
+
 // passwordPrompt securely reads a password from stdin.
 func passwordPrompt(prompt string) (string, error) {
 	fmt.Print(prompt)
@@ -41,6 +58,9 @@ func verifyAPISecurity(config Config) error {
 			}
 			return errors.New("you must pass --disable-api-security to bind Siad to a non-localhost address")
 		}
+		if config.Siad.S3Addr != "" && !modules.NetAddress(config.Siad.S3Addr).IsLoopback() {
+			return errors.New("you must pass --disable-api-security to bind the S3 gateway to a non-localhost address")
+		}
 		return nil
 	}
 
@@ -52,6 +72,26 @@ func verifyAPISecurity(config Config) error {
 	return nil
 }
 
+// verifyReadOnly checks that a read-only node doesn't run modules or services
+// which act on behalf of its owner.
+func verifyReadOnly(config Config) error {
+	if !config.Siad.ReadOnly {
+		return nil
+	}
+	for _, m := range config.Siad.Modules {
+		if !strings.ContainsRune(readOnlyAllowedModules, m) {
+			return fmt.Errorf("read-only nodes can only run the modules %q, not %q", readOnlyAllowedModules, m)
+		}
+	}
+	if config.Siad.LightWallet {
+		return errors.New("read-only nodes can't run a light wallet")
+	}
+	if config.Siad.S3Addr != "" || config.Siad.NFTGatewayAddr != "" {
+		return errors.New("read-only nodes can't serve the S3 or NFT gateway of the renter")
+	}
+	return nil
+}
+
 // processNetAddr adds a ':' to a bare integer, so that it is a proper port
 // number.
 func processNetAddr(addr string) string {
@@ -112,12 +152,19 @@ func processConfig(config Config) (Config, error) {
 	config.Siad.APIaddr = processNetAddr(config.Siad.APIaddr)
 	config.Siad.RPCaddr = processNetAddr(config.Siad.RPCaddr)
 	config.Siad.HostAddr = processNetAddr(config.Siad.HostAddr)
+	config.Siad.S3Addr = processNetAddr(config.Siad.S3Addr)
+	config.Siad.NFTGatewayAddr = processNetAddr(config.Siad.NFTGatewayAddr)
 	config.Siad.Modules, err1 = processModules(config.Siad.Modules)
 	if config.Siad.Profile != "" {
 		config.Siad.Profile, err2 = profile.ProcessProfileFlags(config.Siad.Profile)
 	}
 	err3 := verifyAPISecurity(config)
-	err := build.JoinErrors([]error{err1, err2, err3}, ", and ")
+	_, err4 := modules.ParseUploadSchedule(config.Siad.UploadSchedule)
+	var err5 error
+	if err1 == nil {
+		err5 = verifyReadOnly(config)
+	}
+	err := build.JoinErrors([]error{err1, err2, err3, err4, err5}, ", and ")
 	if err != nil {
 		return Config{}, err
 	}
@@ -195,6 +242,45 @@ func tryAutoUnlock(srv *server.Server) {
 	}
 }
 
+// applyRenterSettings applies the bandwidth limits and upload schedule set by
+// the flags to the server's renter.
+func applyRenterSettings(srv *server.Server, config Config) error {
+	if config.Siad.MaxUploadBPS < 0 && config.Siad.MaxDownloadBPS < 0 && config.Siad.UploadSchedule == "" {
+		return nil
+	}
+	settings, err := srv.RenterSettings()
+	if err != nil {
+		return err
+	}
+	if config.Siad.MaxUploadBPS >= 0 {
+		settings.MaxUploadSpeed = config.Siad.MaxUploadBPS
+	}
+	if config.Siad.MaxDownloadBPS >= 0 {
+		settings.MaxDownloadSpeed = config.Siad.MaxDownloadBPS
+	}
+	if config.Siad.UploadSchedule != "" {
+		settings.UploadSchedule, err = modules.ParseUploadSchedule(config.Siad.UploadSchedule)
+		if err != nil {
+			return err
+		}
+	}
+	return srv.SetRenterSettings(settings)
+}
+
+// startWebhooks loads the webhooks from the file set by the flags and starts
+// posting events to them.
+func startWebhooks(srv *server.Server, config Config) error {
+	b, err := ioutil.ReadFile(config.Siad.WebhookConfig)
+	if err != nil {
+		return errors.AddContext(err, "unable to read the webhook config")
+	}
+	var hooks []webhook.Config
+	if err := json.Unmarshal(b, &hooks); err != nil {
+		return errors.AddContext(err, "unable to parse the webhook config")
+	}
+	return srv.StartWebhooks(hooks)
+}
+
 // startDaemon uses the config parameters to initialize Sia modules and start
 // siad.
 func startDaemon(config Config) (err error) {
@@ -219,14 +305,49 @@ func startDaemon(config Config) (err error) {
 	// Create the node params by parsing the modules specified in the config.
 	nodeParams := parseModules(config)
 
+	// Read-only nodes trade some CPU time for a smaller heap.
+	if config.Siad.ReadOnly {
+		fmt.Println("Starting read-only node")
+		debug.SetGCPercent(readOnlyGCPercent)
+	}
+
 	// Start and run the server.
 	srv, err := server.New(config.Siad.APIaddr, config.Siad.RequiredUserAgent, config.APIPassword, nodeParams, loadStart)
 	if err != nil {
 		return err
 	}
 
-	// Attempt to auto-unlock the wallet using the SIA_WALLET_PASSWORD env variable
-	tryAutoUnlock(srv)
+	// Attempt to auto-unlock the wallet using the SIA_WALLET_PASSWORD env
+	// variable and apply the renter's bandwidth limits and upload schedule.
+	// Read-only nodes have neither a wallet nor a renter.
+	if !config.Siad.ReadOnly {
+		tryAutoUnlock(srv)
+		if err := applyRenterSettings(srv, config); err != nil {
+			fmt.Println("Failed to apply renter settings:", err)
+		}
+	}
+
+	// Start the renter's gateways if they were enabled.
+	if config.Siad.S3Addr != "" {
+		if err := srv.ServeS3Gateway(config.Siad.S3Addr); err != nil {
+			fmt.Println("Failed to start the S3 gateway:", err)
+		} else {
+			fmt.Println("S3 gateway listening on", config.Siad.S3Addr)
+		}
+	}
+	if config.Siad.NFTGatewayAddr != "" {
+		if err := srv.ServeNFTGateway(config.Siad.NFTGatewayAddr); err != nil {
+			fmt.Println("Failed to start the NFT gateway:", err)
+		} else {
+			fmt.Println("NFT gateway listening on", config.Siad.NFTGatewayAddr)
+		}
+	}
+
+	if config.Siad.WebhookConfig != "" {
+		if err := startWebhooks(srv, config); err != nil {
+			fmt.Println("Failed to start the webhooks:", err)
+		}
+	}
 
 	// listen for kill signals
 	sigChan := installKillSignalHandler()
@@ -257,6 +378,12 @@ func startDaemon(config Config) (err error) {
 
 // startDaemonCmd is a passthrough function for startDaemon.
 func startDaemonCmd(cmd *cobra.Command, _ []string) {
+	// Read-only nodes run the read-only modules unless the modules were set
+	// explicitly.
+	if globalConfig.Siad.ReadOnly && !cmd.Root().Flag("modules").Changed {
+		globalConfig.Siad.Modules = readOnlyModules
+	}
+
 	// Process the config variables after they are parsed by cobra.
 	config, err := processConfig(globalConfig)
 	if err != nil {
@@ -125,6 +125,55 @@ func TestUnitProcessConfig(t *testing.T) {
 	if err == nil {
 		t.Error("processModules didn't error on invalid module:", invalidModule)
 	}
+	config.Siad.Modules = ""
+	invalidSchedule := "22-24"
+	config.Siad.UploadSchedule = invalidSchedule
+	_, err = processConfig(config)
+	if err == nil {
+		t.Error("processConfig didn't error on invalid upload schedule:", invalidSchedule)
+	}
+	config.Siad.UploadSchedule = ""
+	config.Siad.APIaddr = "localhost:9980"
+	config.Siad.S3Addr = "9985"
+	_, err = processConfig(config)
+	if err == nil {
+		t.Error("processConfig didn't error on a non-localhost S3 gateway address")
+	}
+	config.Siad.S3Addr = "localhost:9985"
+	if _, err = processConfig(config); err != nil {
+		t.Error("processConfig failed with error:", err)
+	}
+}
+
+// TestVerifyReadOnly tests that read-only nodes can only run the read-only
+// modules and services.
+func TestVerifyReadOnly(t *testing.T) {
+	var config Config
+	config.Siad.Modules = "gctw"
+	if err := verifyReadOnly(config); err != nil {
+		t.Fatal("config without --read-only was rejected:", err)
+	}
+	config.Siad.ReadOnly = true
+	if err := verifyReadOnly(config); err == nil {
+		t.Fatal("read-only node with a wallet was accepted")
+	}
+	config.Siad.Modules = readOnlyModules
+	if err := verifyReadOnly(config); err != nil {
+		t.Fatal("read-only node was rejected:", err)
+	}
+	config.Siad.Modules = readOnlyAllowedModules
+	if err := verifyReadOnly(config); err != nil {
+		t.Fatal("read-only node with an explorer was rejected:", err)
+	}
+	config.Siad.LightWallet = true
+	if err := verifyReadOnly(config); err == nil {
+		t.Fatal("read-only light wallet was accepted")
+	}
+	config.Siad.LightWallet = false
+	config.Siad.S3Addr = "localhost:9985"
+	if err := verifyReadOnly(config); err == nil {
+		t.Fatal("read-only node with an S3 gateway was accepted")
+	}
 }
 
 // TestLoadAPIPassword tests the 'loadAPIPassword' function.
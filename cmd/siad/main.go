@@ -32,20 +32,36 @@ type Config struct {
 	// The Siad variables are referenced directly by cobra, and are set
 	// according to the flags.
 	Siad struct {
-		APIaddr       string
-		RPCaddr       string
-		HostAddr      string
-		SiaMuxTCPAddr string
-		SiaMuxWSAddr  string
-		AllowAPIBind  bool
+		APIaddr        string
+		RPCaddr        string
+		HostAddr       string
+		SiaMuxTCPAddr  string
+		SiaMuxWSAddr   string
+		S3Addr         string
+		NFTGatewayAddr string
+		AllowAPIBind   bool
 
 		Modules           string
 		NoBootstrap       bool
+		NFTSnapshot       bool
+		LightWallet       bool
+		ReadOnly          bool
 		UseUPNP           bool
 		RequiredUserAgent string
 		AuthenticateAPI   bool
 		TempPassword      bool
 
+		// The renter's bandwidth limits in bytes per second and upload
+		// schedule. A negative limit or an empty schedule keeps the renter's
+		// current setting.
+		MaxUploadBPS   int64
+		MaxDownloadBPS int64
+		UploadSchedule string
+
+		// WebhookConfig is the path of a JSON file containing the webhooks
+		// which receive events about the node.
+		WebhookConfig string
+
 		Profile    string
 		ProfileDir string
 
@@ -93,6 +109,8 @@ needed for the provided named module will be enabled.
 The following two commands are equivalent:
 	siad -M wallet
 	siad -M gctw
+Nodes started with --read-only run the gateway, consensus set and transaction pool
+by default and can only add the explorer.
 Below is a list of all the modules available.
 
 Gateway (g):
@@ -195,6 +213,9 @@ func main() {
 	root.Flags().StringVarP(&globalConfig.Siad.APIaddr, "api-addr", "", "localhost:9980", "which host:port the API server listens on")
 	root.Flags().StringVarP(&globalConfig.Siad.SiaDir, "sia-directory", "d", "", "location of the sia directory")
 	root.Flags().BoolVarP(&globalConfig.Siad.NoBootstrap, "no-bootstrap", "", false, "disable bootstrapping on this run")
+	root.Flags().BoolVarP(&globalConfig.Siad.NFTSnapshot, "nft-snapshot", "", false, "bootstrap the nft index from a snapshot instead of indexing the whole nft history")
+	root.Flags().BoolVarP(&globalConfig.Siad.LightWallet, "light-wallet", "", false, "follow only the addresses of the wallet instead of syncing the full blockchain, can't be combined with the host, renter, miner or explorer")
+	root.Flags().BoolVarP(&globalConfig.Siad.ReadOnly, "read-only", "", false, "run an indexing node with the gateway, consensus, nft index and transaction pool but no wallet, host or renter, and serve only the read-only API")
 	root.Flags().BoolVarP(&globalConfig.Siad.UseUPNP, "upnp", "", true, "use UPnP for port forwarding and external IP discovery")
 	root.Flags().StringVarP(&globalConfig.Siad.Profile, "profile", "", "", "enable profiling with flags 'cmt' for CPU, memory, trace")
 	root.Flags().StringVarP(&globalConfig.Siad.RPCaddr, "rpc-addr", "", ":9981", "which port the gateway listens on")
@@ -204,6 +225,12 @@ func main() {
 	root.Flags().BoolVarP(&globalConfig.Siad.AuthenticateAPI, "authenticate-api", "", true, "enable API password protection")
 	root.Flags().BoolVarP(&globalConfig.Siad.TempPassword, "temp-password", "", false, "enter a temporary API password during startup")
 	root.Flags().BoolVarP(&globalConfig.Siad.AllowAPIBind, "disable-api-security", "", false, "allow siad to listen on a non-localhost address (DANGEROUS)")
+	root.Flags().Int64VarP(&globalConfig.Siad.MaxUploadBPS, "max-upload-bps", "", -1, "limit the renter's upload bandwidth in bytes per second, 0 for no limit")
+	root.Flags().Int64VarP(&globalConfig.Siad.MaxDownloadBPS, "max-download-bps", "", -1, "limit the renter's download bandwidth in bytes per second, 0 for no limit")
+	root.Flags().StringVarP(&globalConfig.Siad.UploadSchedule, "upload-schedule", "", "", "only upload between the given hours of the day, e.g. '22-6', or 'off'")
+	root.Flags().StringVarP(&globalConfig.Siad.S3Addr, "s3-addr", "", "", "which host:port the renter's S3 gateway listens on, disabled if empty")
+	root.Flags().StringVarP(&globalConfig.Siad.NFTGatewayAddr, "nft-gateway-addr", "", "", "which host:port the read-only gateway serving pinned NFTs listens on, disabled if empty")
+	root.Flags().StringVarP(&globalConfig.Siad.WebhookConfig, "webhook-config", "", "", "path of a JSON file with the webhooks which receive events about nfts and contracts, disabled if empty")
 
 	// If globalConfig.Siad.SiaDir is not set, use the environment variable provided.
 	if globalConfig.Siad.SiaDir == "" {
@@ -43,6 +43,9 @@ func parseModules(config Config) node.NodeParams {
 	}
 	// Parse remaining fields.
 	params.Bootstrap = !config.Siad.NoBootstrap
+	params.NFTSnapshotBootstrap = config.Siad.NFTSnapshot
+	params.LightWallet = config.Siad.LightWallet
+	params.ReadOnly = config.Siad.ReadOnly
 	params.UseUPNP = config.Siad.UseUPNP
 	params.HostAddress = config.Siad.HostAddr
 	params.RPCAddress = config.Siad.RPCaddr
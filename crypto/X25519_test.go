@@ -16,3 +16,12 @@ func TestDeriveSharedSecret(t *testing.T) {
 		t.Fatal("shared secret should not match")
 	}
 }
+
+// TestX25519PublicKey tests that the public key of a secret key matches the
+// public key it was generated with.
+func TestX25519PublicKey(t *testing.T) {
+	sk, pk := GenerateX25519KeyPair()
+	if sk.PublicKey() != pk {
+		t.Fatal("public key does not match")
+	}
+}
@@ -0,0 +1,84 @@
+package crypto
+
+import (
+	"container/list"
+	"sync"
+)
+
+type (
+	// MerkleRootCache is an LRU cache for the Merkle roots of data. Computing
+	// the Merkle root of a sector requires hashing every segment of the
+	// sector, which is several times slower than hashing the sector as a
+	// whole. The cache is keyed by the hash of the data, so computing the
+	// root of data which was seen before only costs a single hash.
+	//
+	// This is useful for data which is hashed repeatedly, like sectors which
+	// are verified, uploaded and repaired or NFT content which is pinned
+	// again.
+	MerkleRootCache struct {
+		entries    map[Hash]*list.Element
+		lru        *list.List
+		maxEntries int
+
+		mu sync.Mutex
+	}
+
+	// merkleRootCacheEntry is an entry of the MerkleRootCache.
+	merkleRootCacheEntry struct {
+		key  Hash
+		root Hash
+	}
+)
+
+// NewMerkleRootCache creates a cache which holds the roots of up to maxEntries
+// pieces of data. A maxEntries of 0 disables the cache.
+func NewMerkleRootCache(maxEntries int) *MerkleRootCache {
+	return &MerkleRootCache{
+		entries:    make(map[Hash]*list.Element),
+		lru:        list.New(),
+		maxEntries: maxEntries,
+	}
+}
+
+// Len returns the number of cached roots.
+func (c *MerkleRootCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// MerkleRoot returns the Merkle root of b like MerkleRoot does. The root is
+// only computed if it isn't cached already. A nil cache computes the root
+// without caching it.
+func (c *MerkleRootCache) MerkleRoot(b []byte) Hash {
+	if c == nil || c.maxEntries <= 0 {
+		return MerkleRoot(b)
+	}
+	key := HashBytes(b)
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(e)
+		root := e.Value.(*merkleRootCacheEntry).root
+		c.mu.Unlock()
+		return root
+	}
+	c.mu.Unlock()
+
+	// Compute the root without holding the lock. Concurrent callers might
+	// compute the same root but that is harmless.
+	root := MerkleRoot(b)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(e)
+		return root
+	}
+	c.entries[key] = c.lru.PushFront(&merkleRootCacheEntry{key: key, root: root})
+	for c.lru.Len() > c.maxEntries {
+		e := c.lru.Back()
+		c.lru.Remove(e)
+		delete(c.entries, e.Value.(*merkleRootCacheEntry).key)
+	}
+	return root
+}
@@ -0,0 +1,136 @@
+package crypto
+
+import (
+	"sync"
+	"testing"
+
+	"gitlab.com/NebulousLabs/fastrand"
+)
+
+// benchSectorSize is the size of a sector in production builds. The crypto
+// package can't import modules.SectorSize.
+const benchSectorSize = 1 << 22
+
+// TestMerkleRootCache is a unit test for the MerkleRootCache.
+func TestMerkleRootCache(t *testing.T) {
+	t.Parallel()
+
+	c := NewMerkleRootCache(2)
+	data1 := fastrand.Bytes(1000)
+	data2 := fastrand.Bytes(1000)
+	data3 := fastrand.Bytes(1000)
+
+	// The roots should match MerkleRoot.
+	if c.MerkleRoot(data1) != MerkleRoot(data1) {
+		t.Fatal("wrong root")
+	}
+	if c.MerkleRoot(data2) != MerkleRoot(data2) {
+		t.Fatal("wrong root")
+	}
+	if c.Len() != 2 {
+		t.Fatal("expected 2 entries but got", c.Len())
+	}
+
+	// A cached root should be returned and marked as recently used.
+	if c.MerkleRoot(data1) != MerkleRoot(data1) {
+		t.Fatal("wrong root")
+	}
+	if c.Len() != 2 {
+		t.Fatal("expected 2 entries but got", c.Len())
+	}
+
+	// Adding a third root should evict the least recently used one.
+	if c.MerkleRoot(data3) != MerkleRoot(data3) {
+		t.Fatal("wrong root")
+	}
+	if c.Len() != 2 {
+		t.Fatal("expected 2 entries but got", c.Len())
+	}
+	if _, ok := c.entries[HashBytes(data2)]; ok {
+		t.Fatal("data2 should have been evicted")
+	}
+	if _, ok := c.entries[HashBytes(data1)]; !ok {
+		t.Fatal("data1 should still be cached")
+	}
+
+	// Data which differs in a single byte must not hit the cache.
+	data4 := append([]byte(nil), data1...)
+	data4[len(data4)-1]++
+	if c.MerkleRoot(data4) != MerkleRoot(data4) {
+		t.Fatal("wrong root")
+	}
+
+	// A disabled and a nil cache should still compute roots.
+	var nilCache *MerkleRootCache
+	if nilCache.MerkleRoot(data1) != MerkleRoot(data1) {
+		t.Fatal("wrong root")
+	}
+	disabled := NewMerkleRootCache(0)
+	if disabled.MerkleRoot(data1) != MerkleRoot(data1) {
+		t.Fatal("wrong root")
+	}
+	if disabled.Len() != 0 {
+		t.Fatal("disabled cache shouldn't cache roots")
+	}
+
+	// Use the cache concurrently.
+	var wg sync.WaitGroup
+	datas := [][]byte{data1, data2, data3, data4}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				data := datas[fastrand.Intn(len(datas))]
+				if c.MerkleRoot(data) != MerkleRoot(data) {
+					t.Error("wrong root")
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if c.Len() != 2 {
+		t.Fatal("expected 2 entries but got", c.Len())
+	}
+}
+
+// BenchmarkSectorHashing benchmarks the throughput of computing the Merkle
+// root of a sector with and without a MerkleRootCache.
+//
+// Results (goos: linux, goarch: amd64, cpu: Intel Xeon, 10/16/2026)
+//
+// BenchmarkSectorHashing/MerkleRoot   129 MB/s
+// BenchmarkSectorHashing/CacheMiss    106 MB/s
+// BenchmarkSectorHashing/CacheHit     707 MB/s
+func BenchmarkSectorHashing(b *testing.B) {
+	sector := fastrand.Bytes(benchSectorSize)
+
+	b.Run("MerkleRoot", func(b *testing.B) {
+		b.SetBytes(benchSectorSize)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = MerkleRoot(sector)
+		}
+	})
+	b.Run("CacheMiss", func(b *testing.B) {
+		c := NewMerkleRootCache(1)
+		sectors := [][]byte{sector, fastrand.Bytes(benchSectorSize)}
+		b.ResetTimer()
+		b.SetBytes(benchSectorSize)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = c.MerkleRoot(sectors[i%2])
+		}
+	})
+	b.Run("CacheHit", func(b *testing.B) {
+		c := NewMerkleRootCache(1)
+		_ = c.MerkleRoot(sector)
+		b.ResetTimer()
+		b.SetBytes(benchSectorSize)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = c.MerkleRoot(sector)
+		}
+	})
+}
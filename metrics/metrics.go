@@ -0,0 +1,220 @@
+// Package metrics contains the counters and gauges the modules use to
+// instrument their operations. All metrics are registered with a global
+// registry when they are created and exposed in the Prometheus text format by
+// the /metrics API endpoint.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	typeCounter = "counter"
+	typeGauge   = "gauge"
+)
+
+type (
+	// Counter is a metric whose value only increases.
+	Counter struct {
+		value uint64
+	}
+
+	// CounterVec is a family of counters which are distinguished by the value
+	// of a single label.
+	CounterVec struct {
+		staticLabel string
+
+		counters map[string]*Counter
+		mu       sync.Mutex
+	}
+
+	// Gauge is a metric whose value can go up and down.
+	Gauge struct {
+		bits uint64
+	}
+
+	// metric is a registered metric.
+	metric struct {
+		staticName string
+		staticHelp string
+		staticType string
+
+		// Exactly one of these is set.
+		staticCounter    *Counter
+		staticCounterVec *CounterVec
+		staticGauge      *Gauge
+	}
+
+	// registry contains all registered metrics.
+	registry struct {
+		metrics map[string]*metric
+		mu      sync.Mutex
+	}
+)
+
+// defaultRegistry is the registry all metrics are registered with.
+var defaultRegistry = &registry{
+	metrics: make(map[string]*metric),
+}
+
+// register adds a metric to the registry. Metrics are created when their
+// packages are initialized, so registering a name twice is a programming error.
+func (r *registry) register(m *metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.metrics[m.staticName]; exists {
+		panic("metric registered twice: " + m.staticName)
+	}
+	r.metrics[m.staticName] = m
+}
+
+// NewCounter creates and registers a counter.
+func NewCounter(name, help string) *Counter {
+	c := new(Counter)
+	defaultRegistry.register(&metric{
+		staticName:    name,
+		staticHelp:    help,
+		staticType:    typeCounter,
+		staticCounter: c,
+	})
+	return c
+}
+
+// NewCounterVec creates and registers a family of counters with the given
+// label.
+func NewCounterVec(name, help, label string) *CounterVec {
+	cv := &CounterVec{
+		staticLabel: label,
+		counters:    make(map[string]*Counter),
+	}
+	defaultRegistry.register(&metric{
+		staticName:       name,
+		staticHelp:       help,
+		staticType:       typeCounter,
+		staticCounterVec: cv,
+	})
+	return cv
+}
+
+// NewGauge creates and registers a gauge.
+func NewGauge(name, help string) *Gauge {
+	g := new(Gauge)
+	defaultRegistry.register(&metric{
+		staticName:  name,
+		staticHelp:  help,
+		staticType:  typeGauge,
+		staticGauge: g,
+	})
+	return g
+}
+
+// Add increases the counter by n.
+func (c *Counter) Add(n uint64) {
+	atomic.AddUint64(&c.value, n)
+}
+
+// Inc increases the counter by 1.
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+// Value returns the value of the counter.
+func (c *Counter) Value() uint64 {
+	return atomic.LoadUint64(&c.value)
+}
+
+// With returns the counter of the family with the given label value, creating
+// it if necessary.
+func (cv *CounterVec) With(value string) *Counter {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+	c, exists := cv.counters[value]
+	if !exists {
+		c = new(Counter)
+		cv.counters[value] = c
+	}
+	return c
+}
+
+// Add changes the value of the gauge by delta.
+func (g *Gauge) Add(delta float64) {
+	for {
+		old := atomic.LoadUint64(&g.bits)
+		updated := math.Float64bits(math.Float64frombits(old) + delta)
+		if atomic.CompareAndSwapUint64(&g.bits, old, updated) {
+			return
+		}
+	}
+}
+
+// Set sets the value of the gauge.
+func (g *Gauge) Set(value float64) {
+	atomic.StoreUint64(&g.bits, math.Float64bits(value))
+}
+
+// Value returns the value of the gauge.
+func (g *Gauge) Value() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&g.bits))
+}
+
+// labelEscaper escapes label values for the Prometheus text format.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// helpEscaper escapes help texts for the Prometheus text format.
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+// writeTo writes the metric in the Prometheus text format.
+func (m *metric) writeTo(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", m.staticName, helpEscaper.Replace(m.staticHelp), m.staticName, m.staticType)
+	if err != nil {
+		return err
+	}
+	switch {
+	case m.staticCounter != nil:
+		_, err = fmt.Fprintf(w, "%s %d\n", m.staticName, m.staticCounter.Value())
+	case m.staticGauge != nil:
+		_, err = fmt.Fprintf(w, "%s %g\n", m.staticName, m.staticGauge.Value())
+	case m.staticCounterVec != nil:
+		cv := m.staticCounterVec
+		cv.mu.Lock()
+		values := make([]string, 0, len(cv.counters))
+		for value := range cv.counters {
+			values = append(values, value)
+		}
+		cv.mu.Unlock()
+		sort.Strings(values)
+		for _, value := range values {
+			_, err = fmt.Fprintf(w, "%s{%s=\"%s\"} %d\n", m.staticName, cv.staticLabel, labelEscaper.Replace(value), cv.With(value).Value())
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return err
+}
+
+// WritePrometheus writes all registered metrics in the Prometheus text format,
+// sorted by name.
+func WritePrometheus(w io.Writer) error {
+	defaultRegistry.mu.Lock()
+	metrics := make([]*metric, 0, len(defaultRegistry.metrics))
+	for _, m := range defaultRegistry.metrics {
+		metrics = append(metrics, m)
+	}
+	defaultRegistry.mu.Unlock()
+	sort.Slice(metrics, func(i, j int) bool {
+		return metrics[i].staticName < metrics[j].staticName
+	})
+	for _, m := range metrics {
+		if err := m.writeTo(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestMetrics tests updating metrics and writing them in the Prometheus text
+// format.
+func TestMetrics(t *testing.T) {
+	c := NewCounter("test_counter_total", "A counter.")
+	cv := NewCounterVec("test_countervec_total", "A counter\nwith a label.", "outcome")
+	g := NewGauge("test_gauge", "A gauge.")
+
+	// Update the metrics from multiple threads.
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				c.Inc()
+				cv.With("success").Inc()
+				g.Add(0.5)
+			}
+		}()
+	}
+	wg.Wait()
+	cv.With(`fail"ure`).Add(3)
+	if c.Value() != 1000 || cv.With("success").Value() != 1000 || g.Value() != 500 {
+		t.Fatal("wrong values", c.Value(), cv.With("success").Value(), g.Value())
+	}
+	g.Set(-2.5)
+
+	var buf bytes.Buffer
+	if err := WritePrometheus(&buf); err != nil {
+		t.Fatal(err)
+	}
+	expected := `# HELP test_counter_total A counter.
+# TYPE test_counter_total counter
+test_counter_total 1000
+# HELP test_countervec_total A counter\nwith a label.
+# TYPE test_countervec_total counter
+test_countervec_total{outcome="fail\"ure"} 3
+test_countervec_total{outcome="success"} 1000
+# HELP test_gauge A gauge.
+# TYPE test_gauge gauge
+test_gauge -2.5
+`
+	if !strings.Contains(buf.String(), expected) {
+		t.Fatalf("unexpected output:\n%v", buf.String())
+	}
+
+	// Registering a metric twice panics.
+	defer func() {
+		if recover() == nil {
+			t.Fatal("registering a metric twice should panic")
+		}
+	}()
+	NewGauge("test_gauge", "A gauge.")
+}
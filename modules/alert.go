@@ -45,6 +45,10 @@ const (
 	// AlertIDRenterContractRenewalError is the id of the alert that is
 	// registered if at least once contract renewal or refresh failed
 	AlertIDRenterContractRenewalError = "contract-renewal-error"
+	// AlertIDRenterTxnFeesAboveCeiling is the id of the alert that is
+	// registered if contract maintenance defers formations and renewals
+	// because the transaction fees are above the allowance's ceiling.
+	AlertIDRenterTxnFeesAboveCeiling = "txn-fees-above-ceiling"
 	// AlertIDGatewayOffline is the id of the alert that is registered upon a
 	// call to 'gateway.Offline' if the value returned is 'false' and
 	// unregistered when it returns 'true'.
@@ -185,10 +185,141 @@ type (
 		Adjusted  types.Currency
 	}
 
+	// NFTIndexSnapshotEntry is a single key of a bucket of the NFT index.
+	NFTIndexSnapshotEntry struct {
+		Bucket []byte `json:"bucket"`
+		Key    []byte `json:"key"`
+		Value  []byte `json:"value"`
+	}
+
+	// NFTIndexSnapshot is a copy of the NFT index of the consensus set right
+	// after the block with id BlockID at Height was applied. Entries are
+	// ordered by bucket and key.
+	NFTIndexSnapshot struct {
+		Height  types.BlockHeight       `json:"height"`
+		BlockID types.BlockID           `json:"blockid"`
+		Entries []NFTIndexSnapshotEntry `json:"entries"`
+	}
+
+	// NFTIndexCheckpoint commits to the snapshot of the NFT index taken at
+	// Height by the id of the block at that height and the root of the
+	// snapshot. The NFT governance signs checkpoints, so that nodes can
+	// bootstrap their NFT index from a snapshot served by any peer.
+	NFTIndexCheckpoint struct {
+		Height     types.BlockHeight             `json:"height"`
+		BlockID    types.BlockID                 `json:"blockid"`
+		Root       crypto.Hash                   `json:"root"`
+		Signatures []NFTIndexCheckpointSignature `json:"signatures"`
+	}
+
+	// NFTIndexCheckpointSignature is the signature of a checkpoint by the
+	// NFT governance key with index PublicKeyIndex.
+	NFTIndexCheckpointSignature struct {
+		PublicKeyIndex uint64           `json:"publickeyindex"`
+		Signature      crypto.Signature `json:"signature"`
+	}
+
+	// A FilteredBlock is the part of a block a light client needs to follow
+	// a set of addresses. It contains the header of the block, the miner
+	// payouts and the transactions which spend from or send to one of the
+	// addresses together with proofs that they are part of the block, and
+	// the diffs of the block which affect the addresses.
+	FilteredBlock struct {
+		Header types.BlockHeader
+		Height types.BlockHeight
+
+		// NumLeaves is the number of leaves of the Merkle tree of the block.
+		// The leaves are the miner payouts of the block followed by its
+		// transactions.
+		NumLeaves    uint64
+		MinerPayouts []types.SiacoinOutput
+		PayoutProofs [][]crypto.Hash
+
+		// TransactionLeaves contains the index of the leaf of every
+		// transaction.
+		Transactions      []types.Transaction
+		TransactionLeaves []uint64
+		TransactionProofs [][]crypto.Hash
+
+		Diffs ConsensusChangeDiffs
+	}
+
+	// NFTConsensus is the view of the NFT index of a consensus set. Code
+	// which only queries NFTs depends on it instead of ConsensusSet, so that
+	// it can use an in-memory fake in tests, see siatest/nfttest.
+	NFTConsensus interface {
+		// ViewNFTCustody returns the output holding the custody of an NFT.
+		// Liquidated NFTs are held by types.LiquidatedNFTUnlockHash.
+		ViewNFTCustody(nft types.NftCustody) (types.SiacoinOutput, error)
+
+		// FindNFTsForAddress returns every NFT whose custody is held by an
+		// address.
+		FindNFTsForAddress(address types.UnlockHash) []types.NftCustody
+
+		// FindNFTEditions returns every edition minted of the data with the
+		// given merkle root together with its current owner.
+		FindNFTEditions(root crypto.Hash) []types.NftOwnershipStats
+
+		// ViewNFTTransferPolicy returns the transfer policy set by the mint
+		// of an NFT. NFTs minted without a policy are freely transferable.
+		ViewNFTTransferPolicy(nft types.NftCustody) types.NFTTransferPolicy
+
+		// ViewNFTLicense returns the license embedded in the mint of an
+		// NFT. An error is returned if the NFT was minted without a license.
+		ViewNFTLicense(nft types.NftCustody) (types.NFTLicense, error)
+
+		// ViewNFTStake returns the stake of an NFT. An error is returned if
+		// the NFT is not staked.
+		ViewNFTStake(nft types.NftCustody) (types.NFTStake, error)
+
+		// ViewNFTRootStake returns the stake accounted to the merkle root of
+		// the data of staked NFTs.
+		ViewNFTRootStake(root crypto.Hash) types.NFTRootStake
+
+		// ViewNFTInsurance returns the last insurance of an NFT. Insurances
+		// which were claimed or released are inactive. An error is returned
+		// if the NFT was never insured.
+		ViewNFTInsurance(nft types.NftCustody) (types.NFTInsurance, error)
+
+		// ViewNFTApproval returns the operator which is approved to transfer
+		// an NFT. An error is returned if no operator is approved.
+		ViewNFTApproval(nft types.NftCustody) (types.NFTApproval, error)
+
+		// ViewNFTDispute returns the history of the dispute flags of an NFT.
+		// An error is returned if the NFT was never flagged.
+		ViewNFTDispute(nft types.NftCustody) (types.NFTDispute, error)
+
+		// ViewNFTRootFrozen returns true if an NFT with the data of a merkle
+		// root is frozen by a dispute.
+		ViewNFTRootFrozen(root crypto.Hash) bool
+
+		// NFTDisputes returns the dispute flags of every NFT which was ever
+		// flagged.
+		NFTDisputes() ([]types.NFTDispute, error)
+
+		// ViewNFTLockup returns the lockup paid by the mint of an NFT and
+		// whether it was already reclaimed.
+		ViewNFTLockup(nft types.NftCustody) (types.NFTLockup, error)
+
+		// NFTStats returns aggregate statistics about the NFTs on the
+		// blockchain up to and including the current block.
+		NFTStats() types.NFTStats
+
+		// ViewNFTStorageEarmark returns the siacoins of the storage pool
+		// earmarked for storing the data of an NFT. An error is returned if
+		// no transaction of the NFT paid into the storage pool.
+		ViewNFTStorageEarmark(nft types.NftCustody) (types.NFTStorageEarmark, error)
+
+		// ViewNFTBridgeLock returns the bridge lock of an NFT. An error is
+		// returned if the NFT isn't locked by a bridge.
+		ViewNFTBridgeLock(nft types.NftCustody) (types.NFTBridgeLock, error)
+	}
+
 	// A ConsensusSet accepts blocks and builds an understanding of network
 	// consensus.
 	ConsensusSet interface {
 		Alerter
+		NFTConsensus
 
 		// AcceptBlock adds a block to consensus. An error will be returned if the
 		// block is invalid, has been seen before, is an orphan, or doesn't
@@ -250,6 +381,10 @@ type (
 		// Foundation UnlockHashes.
 		FoundationUnlockHashes() (primary, failsafe types.UnlockHash)
 
+		// ViewSiacoinOutput returns the unspent siacoin output with the
+		// given id.
+		ViewSiacoinOutput(types.SiacoinOutputID) (types.SiacoinOutput, error)
+
 		// TryTransactionSet checks whether the transaction set would be valid if
 		// it were added in the next block. A consensus change is returned
 		// detailing the diffs that would result from the application of the
@@ -261,16 +396,46 @@ type (
 		// not found in the subscriber database, no action is taken.
 		Unsubscribe(ConsensusSetSubscriber)
 
-		// View custody of an NFT on (our view of) current network consensus
-		// Abstraction for custody representation
-		ViewNFTCustody(nft types.NftCustody) (types.SiacoinOutput, error)
+		// NFTIndexSnapshot returns the snapshot of the NFT index which was
+		// taken at the given height. Snapshots are only kept for some
+		// heights.
+		NFTIndexSnapshot(height types.BlockHeight) (NFTIndexSnapshot, error)
 
-		// Find all NFTs currently in custody for a specific address on
-		// the blockchain
-		FindNFTsForAddress(address types.UnlockHash) []types.NftCustody
+		// AddNFTIndexCheckpoint stores a checkpoint which is signed by the
+		// NFT governance and commits to a snapshot of the consensus set, so
+		// that the snapshot is served to bootstrapping peers.
+		AddNFTIndexCheckpoint(cp NFTIndexCheckpoint) error
+	}
+
+	// A LightConsensusSet is a ConsensusSet which doesn't validate blocks.
+	// It only follows the transactions and outputs of the addresses it
+	// watches, which it requests from its peers.
+	LightConsensusSet interface {
+		ConsensusSet
+
+		// WatchAddresses adds addresses to the set of watched addresses.
+		// If any of them are new, the filtered blocks are downloaded again
+		// and subscribers receive a reorg to the new blocks.
+		WatchAddresses(addrs []types.UnlockHash) error
 	}
 )
 
+// Root returns the merkle root of the entries of the snapshot. Checkpoints
+// commit to snapshots by their root.
+func (s NFTIndexSnapshot) Root() crypto.Hash {
+	tree := crypto.NewTree()
+	for _, entry := range s.Entries {
+		tree.PushObject(entry)
+	}
+	return tree.Root()
+}
+
+// SigHash returns the hash of the checkpoint which the NFT governance keys
+// sign.
+func (cp NFTIndexCheckpoint) SigHash() crypto.Hash {
+	return crypto.HashAll(cp.Height, cp.BlockID, cp.Root)
+}
+
 // AppendDiffs appends a set of diffs to cc.
 func (cc *ConsensusChange) AppendDiffs(diffs ConsensusChangeDiffs) {
 	cc.SiacoinOutputDiffs = append(cc.SiacoinOutputDiffs, diffs.SiacoinOutputDiffs...)
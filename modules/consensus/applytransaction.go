@@ -214,9 +214,35 @@ func applySiafundOutputs(tx *bolt.Tx, pb *processedBlock, t types.Transaction) {
 // is the only recognized value.
 func applyArbitraryData(tx *bolt.Tx, pb *processedBlock, t types.Transaction) {
 	// NFT-specific arbitrary data
-	if types.IsNFTMintTransaction(t) || types.IsNFTTransferTransaction(t) || types.IsNFTLiquidationTransaction(t) {
+	lock, unlock := types.IsNFTBridgeLockTransaction(t), types.IsNFTBridgeUnlockTransaction(t)
+	if types.UpdatesNFTCustody(t) && !nftIndexBootstrapping(tx) {
 		nft, owner := types.ExtractNFTFromTransaction(t)
 		updateNFTCustody(tx, nft, owner)
+		if nft.HasContentCommitment() {
+			updateNFTContent(tx, nft)
+		}
+		if nft.HasIdentity() {
+			updateNFTIdentity(tx, nft)
+		}
+		if nft.IsEdition() {
+			updateNFTEdition(tx, nft)
+		}
+		if nft.HasTransferPolicy() {
+			updateNFTTransferPolicy(tx, nft)
+		}
+		if _, license, err := types.ParseNFTLicense(t.ArbitraryData[0]); err == nil {
+			updateNFTLicense(tx, nft, license)
+		}
+		if lock {
+			_, claim, _ := types.ParseNFTBridgeClaim(t.ArbitraryData[0])
+			updateNFTBridgeLock(tx, nft, types.NFTBridgeLock{
+				Claim:  claim,
+				Bridge: owner.UnlockHash,
+				Height: pb.Height,
+			})
+		} else if unlock {
+			removeNFTBridgeLock(tx, nft)
+		}
 	}
 	// No ArbitraryData values were recognized prior to the Foundation hardfork.
 	if pb.Height < types.FoundationHardforkHeight {
@@ -272,10 +298,203 @@ func transferFoundationOutputs(tx *bolt.Tx, currentHeight types.BlockHeight, new
 	}
 }
 
+// applyNFTLockup records the lockup of a newly minted NFT and marks the lockup
+// of an NFT as returned once a reclaim or liquidation mints it. It needs to be
+// called before the inputs of the transaction are spent.
+func applyNFTLockup(tx *bolt.Tx, pb *processedBlock, t types.Transaction) {
+	if types.IsNFTMintTransaction(t) {
+		nft, _ := types.ExtractNFTFromTransaction(t)
+		updateNFTLockup(tx, nft, types.NFTLockup{MintHeight: pb.Height})
+		return
+	}
+	if !types.IsNFTReclaimTransaction(t) && !types.IsNFTLiquidationTransaction(t) {
+		return
+	}
+	// Only transactions which mint coins return the lockup.
+	var inputSum types.Currency
+	for _, sci := range t.SiacoinInputs {
+		sco, err := getSiacoinOutput(tx, sci.ParentID)
+		if build.DEBUG && err != nil {
+			panic(err)
+		}
+		inputSum = inputSum.Add(sco.Value)
+	}
+	if inputSum.Cmp(t.SiacoinOutputSum()) >= 0 {
+		return
+	}
+	// NFTs minted before lockups were tracked have no lockup yet. Recording
+	// it as returned keeps the number of minted coins accounted for.
+	nft, _ := types.ExtractNFTFromTransaction(t)
+	lockup, _ := viewNFTLockupInternal(tx, nft)
+	lockup.Reclaimed = true
+	updateNFTLockup(tx, nft, lockup)
+}
+
+// applyNFTStake accounts the stake of stakes, unstakes and reward claims to
+// the merkle root of the staked data. Unstakes return the NFT's share of the
+// remaining stake and reward claims pay out a fraction of it.
+func applyNFTStake(tx *bolt.Tx, pb *processedBlock, t types.Transaction) {
+	switch {
+	case types.IsNFTStakeTransaction(t):
+		nft, _ := types.ExtractNFTFromTransaction(t)
+		stake := types.NFTStake{
+			Root:   viewNFTIdentityInternal(tx, nft).FileMerkleRoot,
+			Amount: t.SiacoinOutputs[1].Value,
+			Height: pb.Height,
+		}
+		rootStake := viewNFTRootStakeInternal(tx, stake.Root)
+		rootStake.Staked = rootStake.Staked.Add(stake.Amount)
+		rootStake.Remaining = rootStake.Remaining.Add(stake.Amount)
+		updateNFTStake(tx, nft, stake)
+		updateNFTRootStake(tx, stake.Root, rootStake)
+	case types.IsNFTUnstakeTransaction(t):
+		nft, _ := types.ExtractNFTFromTransaction(t)
+		stake, err := viewNFTStakeInternal(tx, nft)
+		if build.DEBUG && err != nil {
+			panic(err)
+		}
+		rootStake := viewNFTRootStakeInternal(tx, stake.Root)
+		share := rootStake.Share(stake.Amount)
+		rootStake.Staked = rootStake.Staked.Sub(stake.Amount)
+		rootStake.Remaining = rootStake.Remaining.Sub(share)
+		rootStake.Paid = rootStake.Paid.Add(share)
+		removeNFTStake(tx, nft)
+		updateNFTRootStake(tx, stake.Root, rootStake)
+	case types.IsNFTStakeRewardTransaction(t):
+		claim, err := types.ParseNFTStakeRewardClaim(t.ArbitraryData[0])
+		if build.DEBUG && err != nil {
+			panic(err)
+		}
+		rootStake := viewNFTRootStakeInternal(tx, claim.Root)
+		rootStake.Paid = rootStake.Paid.Add(rootStake.Reward())
+		rootStake.Remaining = rootStake.Remaining.Sub(rootStake.Reward())
+		rootStake.LastReward = pb.Height
+		updateNFTRootStake(tx, claim.Root, rootStake)
+	}
+}
+
+// applyNFTInsurance records insurances and updates them for challenges,
+// responses, claims and releases. Claims and releases deactivate the insurance
+// and account the minted collateral to the NFT.
+func applyNFTInsurance(tx *bolt.Tx, pb *processedBlock, t types.Transaction) {
+	if !types.IsNFTInsuranceTransaction(t) {
+		return
+	}
+	_, tag, nft, err := types.ParseNFTArbitraryData(t.ArbitraryData[0])
+	if build.DEBUG && err != nil {
+		panic(err)
+	}
+	insurance, _ := viewNFTInsuranceInternal(tx, nft)
+	switch {
+	case types.NFTTagEqual(tag, types.NFTInsureTag):
+		_, terms, err := types.ParseNFTInsuranceTerms(t.ArbitraryData[0])
+		if build.DEBUG && err != nil {
+			panic(err)
+		}
+		insurance = types.NFTInsurance{
+			Root:       viewNFTIdentityInternal(tx, nft).FileMerkleRoot,
+			Insurer:    terms.Insurer,
+			Collateral: t.SiacoinOutputs[0].Value,
+			Height:     pb.Height,
+			Expiry:     terms.Expiry,
+			Paid:       insurance.Paid,
+		}
+	case types.NFTTagEqual(tag, types.NFTInsuranceChallengeTag):
+		insurance.ChallengeHeight = pb.Height
+	case types.NFTTagEqual(tag, types.NFTInsuranceResponseTag):
+		insurance.ChallengeHeight = 0
+	case types.NFTTagEqual(tag, types.NFTInsuranceClaimTag), types.NFTTagEqual(tag, types.NFTInsuranceReleaseTag):
+		insurance.Paid = insurance.Paid.Add(insurance.Collateral)
+		insurance.Collateral = types.ZeroCurrency
+		insurance.ChallengeHeight = 0
+	}
+	updateNFTInsurance(tx, nft, insurance)
+}
+
+// applyNFTApproval records and revokes approvals. Transfers, liquidations and
+// bridge locks move the NFT away from the owner who approved the operator, so
+// they clear the approval of the NFT.
+func applyNFTApproval(tx *bolt.Tx, pb *processedBlock, t types.Transaction) {
+	switch {
+	case types.IsNFTRevokeApprovalTransaction(t), types.IsNFTTransferTransaction(t), types.IsNFTLiquidationTransaction(t), types.IsNFTBridgeLockTransaction(t):
+		nft, _ := types.ExtractNFTFromTransaction(t)
+		removeNFTApproval(tx, nft)
+	case types.IsNFTApproveTransaction(t):
+		nft, _ := types.ExtractNFTFromTransaction(t)
+		updateNFTApproval(tx, nft, types.NFTApproval{
+			Operator: t.SiacoinOutputs[1].UnlockHash,
+			Height:   pb.Height,
+		})
+	}
+}
+
+// applyNFTStorageEarmark earmarks the payment of an NFT transaction into the
+// storage pool for storing the data of its NFT.
+func applyNFTStorageEarmark(tx *bolt.Tx, pb *processedBlock, t types.Transaction) {
+	if !types.UpdatesNFTCustody(t) {
+		return
+	}
+	payment := nftStoragePoolPayment(t)
+	if payment.IsZero() {
+		return
+	}
+	nft, _ := types.ExtractNFTFromTransaction(t)
+	earmark, _ := viewNFTStorageEarmarkInternal(tx, nft)
+	switch {
+	case types.IsNFTMintTransaction(t):
+		earmark.Mint = earmark.Mint.Add(payment)
+	case isNFTTransferPayment(t):
+		earmark.Transfers = earmark.Transfers.Add(payment)
+	default:
+		earmark.TopUps = earmark.TopUps.Add(payment)
+	}
+	earmark.Height = pb.Height
+	updateNFTStorageEarmark(tx, nft, earmark)
+}
+
+// applyNFTDispute appends flags and unflags to the dispute history of their
+// NFT and counts the frozen NFTs of every merkle root.
+func applyNFTDispute(tx *bolt.Tx, pb *processedBlock, t types.Transaction) {
+	if !types.IsNFTDisputeTransaction(t) {
+		return
+	}
+	nft, reason, err := types.ParseNFTDisputeFlag(t.ArbitraryData[0])
+	if err != nil {
+		return
+	}
+	dispute, _ := viewNFTDisputeInternal(tx, nft)
+	dispute.ID = nft.Identifier()
+	frozen := types.IsNFTFreezeTransaction(t)
+	dispute.Flags = append(dispute.Flags, types.NFTDisputeFlag{
+		Frozen:        frozen,
+		Reason:        reason,
+		Height:        pb.Height,
+		TransactionID: t.ID(),
+	})
+	updateNFTDispute(tx, nft, dispute)
+
+	root := viewNFTIdentityInternal(tx, nft).FileMerkleRoot
+	count := viewNFTRootDisputesInternal(tx, root)
+	if frozen {
+		count++
+	} else if count > 0 {
+		count--
+	}
+	updateNFTRootDisputes(tx, root, count)
+}
+
 // applyTransaction applies the contents of a transaction to the ConsensusSet.
 // This produces a set of diffs, which are stored in the blockNode containing
 // the transaction. No verification is done by this function.
 func applyTransaction(tx *bolt.Tx, pb *processedBlock, t types.Transaction) {
+	if !nftIndexBootstrapping(tx) {
+		applyNFTLockup(tx, pb, t)
+		applyNFTStake(tx, pb, t)
+		applyNFTInsurance(tx, pb, t)
+		applyNFTApproval(tx, pb, t)
+		applyNFTStorageEarmark(tx, pb, t)
+		applyNFTDispute(tx, pb, t)
+	}
 	applySiacoinInputs(tx, pb, t)
 	applySiacoinOutputs(tx, pb, t)
 	applyFileContracts(tx, pb, t)
@@ -5,6 +5,7 @@ import (
 
 	"gitlab.com/NebulousLabs/bolt"
 	"gitlab.com/NebulousLabs/encoding"
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/types"
 )
 
@@ -975,3 +976,58 @@ func TestApplyArbitraryData(t *testing.T) {
 		t.Error("applying two updates did not apply only the first", newPrimary, newFailsafe)
 	}
 }
+
+// TestApplyNFTContent checks that the content commitment of a mint is stored
+// and returned together with the NFT.
+func TestApplyNFTContent(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	apply := func(txn types.Transaction) {
+		err := cst.cs.db.Update(func(tx *bolt.Tx) error {
+			applyArbitraryData(tx, &processedBlock{}, txn)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Mint an NFT which commits to its content.
+	nft := types.NftCustody{
+		FileMerkleRoot: crypto.HashBytes([]byte(t.Name())),
+		ContentType:    "text/plain",
+		ContentLength:  100,
+	}
+	owner := types.UnlockHash{1}
+	apply(types.Transaction{
+		SiacoinOutputs: []types.SiacoinOutput{{UnlockHash: owner, Value: types.OneBaseUnit}},
+		ArbitraryData:  [][]byte{types.NFTArbitraryData(types.NFTMintTag, nft)},
+	})
+	nfts := cst.cs.FindNFTsForAddress(owner)
+	if len(nfts) != 1 || nfts[0] != nft {
+		t.Fatal("expected minted nft with content but got", nfts)
+	}
+
+	// Transfer it. The content commitment should be kept.
+	newOwner := types.UnlockHash{2}
+	apply(types.Transaction{
+		SiacoinOutputs: []types.SiacoinOutput{{UnlockHash: newOwner, Value: types.OneBaseUnit}},
+		ArbitraryData:  [][]byte{types.NFTArbitraryData(types.NFTTransferTag, nft)},
+	})
+	nfts = cst.cs.FindNFTsForAddress(newOwner)
+	if len(nfts) != 1 || nfts[0] != nft {
+		t.Fatal("expected transferred nft with content but got", nfts)
+	}
+}
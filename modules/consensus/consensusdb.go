@@ -7,12 +7,15 @@ package consensus
 // ignored otherwise, which is suboptimal.
 
 import (
+	"bytes"
 	"fmt"
+	"sort"
 
 	"gitlab.com/NebulousLabs/bolt"
 
 	"gitlab.com/NebulousLabs/encoding"
 	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/types"
 )
@@ -73,11 +76,108 @@ var (
 	SiafundPool = []byte("SiafundPool")
 
 	// NFTCustodyPool
-	// The NFT Custody Pool maps the merkle root of every seen NFT to the current
+	// The NFT Custody Pool maps the identifier of every seen NFT to the current
 	// custodial output of that NFT, nil if unseen,
-	// and a special key value for liquidated
+	// and a special key value for liquidated.
+	// All NFT buckets are keyed by types.NftCustody.Identifier, which is the
+	// merkle root for legacy NFTs.
 	NFTCustodyPool = []byte("NFTCustodyPool")
 
+	// NFTContentPool maps the identifier of every NFT whose mint committed
+	// to its content to the content type and length of that NFT. It is
+	// created lazily so that existing databases don't need to be migrated.
+	NFTContentPool = []byte("NFTContentPool")
+
+	// NFTLockupPool maps the identifier of every NFT minted after lockups
+	// became reclaimable to the height of its mint and whether its lockup was
+	// already returned. Like NFTContentPool it is created lazily.
+	NFTLockupPool = []byte("NFTLockupPool")
+
+	// NFTBridgePool maps the identifier of every NFT which is currently
+	// locked by a cross-chain bridge to the lock. Like NFTContentPool it is
+	// created lazily.
+	NFTBridgePool = []byte("NFTBridgePool")
+
+	// NFTIdentityPool maps the NftID of every NFT minted with an identity to
+	// its merkle root, creator, collection and nonce. Like NFTContentPool it
+	// is created lazily.
+	NFTIdentityPool = []byte("NFTIdentityPool")
+
+	// NFTEditionPool maps the merkle root of every NFT minted as an edition,
+	// followed by the NftID of the edition, to the number of editions of its
+	// run. Keys start with the merkle root so that all editions of the same
+	// data can be found with a single prefix scan. Like NFTContentPool it is
+	// created lazily.
+	NFTEditionPool = []byte("NFTEditionPool")
+
+	// NFTPolicyPool maps the identifier of every NFT whose mint set a
+	// transfer policy to that policy. NFTs which are missing are freely
+	// transferable. Like NFTContentPool it is created lazily.
+	NFTPolicyPool = []byte("NFTPolicyPool")
+
+	// NFTLicensePool maps the identifier of every NFT whose mint embedded a
+	// license to that license. Like NFTContentPool it is created lazily.
+	NFTLicensePool = []byte("NFTLicensePool")
+
+	// NFTStakePool maps the identifier of every staked NFT to its stake.
+	// Like NFTContentPool it is created lazily.
+	NFTStakePool = []byte("NFTStakePool")
+
+	// NFTRootStakePool maps the merkle root of the data of staked NFTs to
+	// the stake accounted to that root. Like NFTContentPool it is created
+	// lazily.
+	NFTRootStakePool = []byte("NFTRootStakePool")
+
+	// NFTInsurancePool maps the identifier of every NFT which was ever
+	// insured to its last insurance. Like NFTContentPool it is created
+	// lazily.
+	NFTInsurancePool = []byte("NFTInsurancePool")
+
+	// NFTApprovalPool maps the identifier of every NFT with an approved
+	// operator to its approval. Like NFTContentPool it is created lazily.
+	NFTApprovalPool = []byte("NFTApprovalPool")
+
+	// NFTStorageEarmarkPool maps the identifier of every NFT whose
+	// transactions paid into the storage pool to the siacoins earmarked for
+	// storing its data. Like NFTContentPool it is created lazily.
+	NFTStorageEarmarkPool = []byte("NFTStorageEarmarkPool")
+
+	// NFTDisputePool maps the identifier of every NFT which was ever
+	// flagged as disputed to the history of its dispute flags. Like
+	// NFTContentPool it is created lazily.
+	NFTDisputePool = []byte("NFTDisputePool")
+
+	// NFTRootDisputePool maps the merkle root of the data of frozen NFTs to
+	// the number of frozen NFTs with that data. Like NFTContentPool it is
+	// created lazily.
+	NFTRootDisputePool = []byte("NFTRootDisputePool")
+
+	// NFTStatsPool maps the id of every block whose diffs were generated to
+	// the NFT statistics up to and including that block. Keying the
+	// statistics by block id makes them independent of reorgs.
+	NFTStatsPool = []byte("NFTStatsPool")
+
+	// NFTSnapshotPool maps heights to the snapshots of the NFT index which
+	// were taken at these heights. It also holds the latest checkpoint and
+	// the pending snapshot of a node bootstrapping its NFT index, see
+	// nftsnapshot.go. Like NFTContentPool it is created lazily.
+	NFTSnapshotPool = []byte("NFTSnapshotPool")
+
+	// NFTCheckpointPath maps the heights up to the checkpoint of the pending
+	// snapshot to the ids of the blocks the checkpoint commits to. Only
+	// nodes which bootstrap their NFT index create it.
+	NFTCheckpointPath = []byte("NFTCheckpointPath")
+
+	// NFTIndexDiffPool maps the id of every block which changed the NFT index
+	// to the NFT index diffs of the block, see nftdiffs.go. Like
+	// NFTContentPool it is created lazily.
+	NFTIndexDiffPool = []byte("NFTIndexDiffPool")
+
+	// NFTIndexJournal holds the prior values of the NFT index entries which
+	// were written by the block whose diffs are being generated. It only
+	// exists while the diffs of a block are generated.
+	NFTIndexJournal = []byte("NFTIndexJournal")
+
 	// FoundationUnlockHashes is a database bucket storing primary and failsafe
 	// Foundation UnlockHashes. It stores both the current values (keyed by
 	// "FoundationUnlockHashes") and the values at specific blocks (keyed by
@@ -89,12 +189,39 @@ var (
 	// FieldOakInit is a field in BucketOak that gets set to "true" after the
 	// oak initialization process has completed.
 	FieldOakInit = []byte("OakInit")
+
+	// FieldNFTStatsInit is a field in NFTStatsPool that gets set to
+	// ValueNFTStatsInit after the statistics of the blocks of the current path
+	// were computed.
+	FieldNFTStatsInit = []byte("NFTStatsInit")
+
+	// FieldNFTSnapshotPending is a field in NFTSnapshotPool that holds the
+	// snapshot a bootstrapping node installs at the snapshot's height.
+	FieldNFTSnapshotPending = []byte("Pending")
+
+	// FieldNFTSnapshotPendingHeight is a field in NFTSnapshotPool that holds
+	// the height of the pending snapshot.
+	FieldNFTSnapshotPendingHeight = []byte("PendingHeight")
+
+	// FieldNFTSnapshotPendingCheckpoint is a field in NFTSnapshotPool that
+	// holds the checkpoint of the pending snapshot.
+	FieldNFTSnapshotPendingCheckpoint = []byte("PendingCheckpoint")
+
+	// FieldNFTSnapshotCheckpoint is a field in NFTSnapshotPool that holds
+	// the latest checkpoint whose snapshot the node serves to its peers.
+	FieldNFTSnapshotCheckpoint = []byte("Checkpoint")
 )
 
 var (
 	// ValueOakInit is the value that the oak init field is set to if the oak
 	// difficulty adjustment fields have been correctly initialized.
 	ValueOakInit = []byte("true")
+
+	// ValueNFTStatsInit is the value that the NFT stats init field is set to
+	// once the NFT statistics have been initialized. It changes whenever the
+	// statistics gain new fields, so that statistics stored by older versions
+	// are recomputed.
+	ValueNFTStatsInit = []byte("storagepool")
 )
 
 // createConsensusObjects initializes the consensus portions of the database.
@@ -110,6 +237,20 @@ func (cs *ConsensusSet) createConsensusDB(tx *bolt.Tx) error {
 		SiafundOutputs,
 		SiafundPool,
 		NFTCustodyPool,
+		NFTContentPool,
+		NFTLockupPool,
+		NFTBridgePool,
+		NFTIdentityPool,
+		NFTEditionPool,
+		NFTPolicyPool,
+		NFTLicensePool,
+		NFTStakePool,
+		NFTRootStakePool,
+		NFTInsurancePool,
+		NFTApprovalPool,
+		NFTStorageEarmarkPool,
+		NFTDisputePool,
+		NFTRootDisputePool,
 	}
 	for _, bucket := range buckets {
 		_, err := tx.CreateBucket(bucket)
@@ -312,18 +453,23 @@ func getSiacoinOutput(tx *bolt.Tx, id types.SiacoinOutputID) (types.SiacoinOutpu
 	return sco, nil
 }
 
+// nftKey returns the key of an NFT in the NFT buckets.
+func nftKey(nft types.NftCustody) []byte {
+	id := nft.Identifier()
+	return id[:]
+}
+
 // Updates NFT Custody to unlock hash currently belonging to unspent NFT output
 // or to types.LiquidatedNFTUnlockHash for a liquidated NFT
 func updateNFTCustody(tx *bolt.Tx, nft types.NftCustody, owner types.SiacoinOutput) {
-	nftOutputs := tx.Bucket(NFTCustodyPool)
-	var id []byte = nft.FileMerkleRoot[:]
+	var id []byte = nftKey(nft)
 	var custody []byte = encoding.Marshal(owner)
 
 	if build.DEBUG {
 		fmt.Println("NFT Custody updated for", nft, "new owner:", owner, "bytes:", custody)
 	}
 
-	err := nftOutputs.Put(id, custody)
+	err := putNFTIndexEntry(tx, NFTCustodyPool, id, custody)
 
 	if err != nil && build.DEBUG {
 		s := fmt.Sprintf("Error updating custody %s", err)
@@ -331,12 +477,435 @@ func updateNFTCustody(tx *bolt.Tx, nft types.NftCustody, owner types.SiacoinOutp
 	}
 }
 
+// updateNFTContent stores the content commitment of a newly minted NFT.
+func updateNFTContent(tx *bolt.Tx, nft types.NftCustody) {
+	err := putNFTIndexEntry(tx, NFTContentPool, nftKey(nft), encoding.MarshalAll(nft.ContentType, nft.ContentLength))
+	if err != nil && build.DEBUG {
+		panic(fmt.Sprintf("Error updating nft content %s", err))
+	}
+}
+
+// updateNFTLockup stores the lockup of an NFT.
+func updateNFTLockup(tx *bolt.Tx, nft types.NftCustody, lockup types.NFTLockup) {
+	err := putNFTIndexEntry(tx, NFTLockupPool, nftKey(nft), encoding.Marshal(lockup))
+	if err != nil && build.DEBUG {
+		panic(fmt.Sprintf("Error updating nft lockup %s", err))
+	}
+}
+
+// viewNFTLockupInternal returns the lockup of an NFT. NFTs which were minted
+// before lockups were tracked have no lockup.
+func viewNFTLockupInternal(tx *bolt.Tx, nft types.NftCustody) (types.NFTLockup, error) {
+	b := tx.Bucket(NFTLockupPool)
+	if b == nil {
+		return types.NFTLockup{}, errNilItem
+	}
+	data := b.Get(nftKey(nft))
+	if data == nil {
+		return types.NFTLockup{}, errNilItem
+	}
+	var lockup types.NFTLockup
+	err := encoding.Unmarshal(data, &lockup)
+	if build.DEBUG && err != nil {
+		panic(err)
+	}
+	return lockup, nil
+}
+
+// updateNFTBridgeLock stores the bridge lock of an NFT.
+func updateNFTBridgeLock(tx *bolt.Tx, nft types.NftCustody, lock types.NFTBridgeLock) {
+	err := putNFTIndexEntry(tx, NFTBridgePool, nftKey(nft), encoding.Marshal(lock))
+	if err != nil && build.DEBUG {
+		panic(fmt.Sprintf("Error updating nft bridge lock %s", err))
+	}
+}
+
+// removeNFTBridgeLock removes the bridge lock of an NFT.
+func removeNFTBridgeLock(tx *bolt.Tx, nft types.NftCustody) {
+	if err := deleteNFTIndexEntry(tx, NFTBridgePool, nftKey(nft)); err != nil && build.DEBUG {
+		panic(fmt.Sprintf("Error removing nft bridge lock %s", err))
+	}
+}
+
+// viewNFTBridgeLockInternal returns the bridge lock of an NFT. errNilItem is
+// returned if the NFT isn't locked.
+func viewNFTBridgeLockInternal(tx *bolt.Tx, nft types.NftCustody) (types.NFTBridgeLock, error) {
+	b := tx.Bucket(NFTBridgePool)
+	if b == nil {
+		return types.NFTBridgeLock{}, errNilItem
+	}
+	data := b.Get(nftKey(nft))
+	if data == nil {
+		return types.NFTBridgeLock{}, errNilItem
+	}
+	var lock types.NFTBridgeLock
+	err := encoding.Unmarshal(data, &lock)
+	if build.DEBUG && err != nil {
+		panic(err)
+	}
+	return lock, nil
+}
+
+// ViewNFTBridgeLock returns the bridge lock of an NFT.
+func (cs *ConsensusSet) ViewNFTBridgeLock(nft types.NftCustody) (lock types.NFTBridgeLock, err error) {
+	err = cs.db.View(func(tx *bolt.Tx) error {
+		lock, err = viewNFTBridgeLockInternal(tx, nft)
+		return err
+	})
+	return
+}
+
+// ViewNFTLockup returns the lockup of an NFT.
+func (cs *ConsensusSet) ViewNFTLockup(nft types.NftCustody) (lockup types.NFTLockup, err error) {
+	err = cs.db.View(func(tx *bolt.Tx) error {
+		lockup, err = viewNFTLockupInternal(tx, nft)
+		return err
+	})
+	return
+}
+
+// viewNFTContentInternal adds the content commitment made by the NFT's mint to
+// the NFT. NFTs without a commitment are returned unchanged.
+func viewNFTContentInternal(tx *bolt.Tx, nft types.NftCustody) types.NftCustody {
+	b := tx.Bucket(NFTContentPool)
+	if b == nil {
+		return nft
+	}
+	data := b.Get(nftKey(nft))
+	if data == nil {
+		return nft
+	}
+	err := encoding.UnmarshalAll(data, &nft.ContentType, &nft.ContentLength)
+	if build.DEBUG && err != nil {
+		panic(err)
+	}
+	return nft
+}
+
+// updateNFTIdentity stores the identity of an NFT minted with an identity.
+func updateNFTIdentity(tx *bolt.Tx, nft types.NftCustody) {
+	err := putNFTIndexEntry(tx, NFTIdentityPool, nftKey(nft), encoding.MarshalAll(nft.FileMerkleRoot, nft.Creator, nft.Collection, nft.Nonce))
+	if err != nil && build.DEBUG {
+		panic(fmt.Sprintf("Error updating nft identity %s", err))
+	}
+}
+
+// viewNFTIdentityInternal adds the merkle root and identity of an NFT minted
+// with an identity to the NFT, including the number of editions of editions.
+// NFTs without an identity are returned unchanged.
+func viewNFTIdentityInternal(tx *bolt.Tx, nft types.NftCustody) types.NftCustody {
+	b := tx.Bucket(NFTIdentityPool)
+	if b == nil || nft.ID == (types.NftID{}) {
+		return nft
+	}
+	data := b.Get(nft.ID[:])
+	if data == nil {
+		return nft
+	}
+	err := encoding.UnmarshalAll(data, &nft.FileMerkleRoot, &nft.Creator, &nft.Collection, &nft.Nonce)
+	if build.DEBUG && err != nil {
+		panic(err)
+	}
+	if eb := tx.Bucket(NFTEditionPool); eb != nil {
+		if data := eb.Get(nftEditionKey(nft)); data != nil {
+			err = encoding.Unmarshal(data, &nft.Editions)
+			if build.DEBUG && err != nil {
+				panic(err)
+			}
+		}
+	}
+	return nft
+}
+
+// nftEditionKey returns the key of an edition in the NFTEditionPool.
+func nftEditionKey(nft types.NftCustody) []byte {
+	return append(append([]byte{}, nft.FileMerkleRoot[:]...), nft.ID[:]...)
+}
+
+// updateNFTEdition stores an NFT minted as an edition.
+func updateNFTEdition(tx *bolt.Tx, nft types.NftCustody) {
+	err := putNFTIndexEntry(tx, NFTEditionPool, nftEditionKey(nft), encoding.Marshal(nft.Editions))
+	if err != nil && build.DEBUG {
+		panic(fmt.Sprintf("Error updating nft edition %s", err))
+	}
+}
+
+// findNFTEditionsInternal returns all editions minted of the data with the
+// given merkle root, ordered by their NftID.
+func findNFTEditionsInternal(tx *bolt.Tx, root crypto.Hash) []types.NftCustody {
+	b := tx.Bucket(NFTEditionPool)
+	if b == nil {
+		return nil
+	}
+	var editions []types.NftCustody
+	c := b.Cursor()
+	for k, _ := c.Seek(root[:]); k != nil && bytes.HasPrefix(k, root[:]); k, _ = c.Next() {
+		var nft types.NftCustody
+		copy(nft.ID[:], k[len(root):])
+		editions = append(editions, viewNFTContentInternal(tx, viewNFTIdentityInternal(tx, nft)))
+	}
+	return editions
+}
+
+// FindNFTEditions returns every edition minted of the data with the given
+// merkle root together with its current owner. Editions are ordered by their
+// creator, collection and index.
+func (cs *ConsensusSet) FindNFTEditions(root crypto.Hash) []types.NftOwnershipStats {
+	var ret []types.NftOwnershipStats
+	_ = cs.db.View(func(tx *bolt.Tx) error {
+		for _, nft := range findNFTEditionsInternal(tx, root) {
+			owner, _ := viewNFTCustodyInternal(tx, nft)
+			ret = append(ret, types.NftOwnershipStats{Nft: nft, Owner: owner.UnlockHash})
+		}
+		return nil
+	})
+	sort.SliceStable(ret, func(i, j int) bool {
+		a, b := ret[i].Nft, ret[j].Nft
+		if a.Creator != b.Creator {
+			return bytes.Compare(a.Creator[:], b.Creator[:]) < 0
+		}
+		if a.Collection != b.Collection {
+			return a.Collection < b.Collection
+		}
+		return a.Edition() < b.Edition()
+	})
+	return ret
+}
+
+// updateNFTTransferPolicy stores the transfer policy of a newly minted NFT.
+func updateNFTTransferPolicy(tx *bolt.Tx, nft types.NftCustody) {
+	err := putNFTIndexEntry(tx, NFTPolicyPool, nftKey(nft), encoding.Marshal(nft.TransferPolicy))
+	if err != nil && build.DEBUG {
+		panic(fmt.Sprintf("Error updating nft transfer policy %s", err))
+	}
+}
+
+// viewNFTTransferPolicyInternal returns the transfer policy of an NFT. NFTs
+// minted without a policy are freely transferable.
+func viewNFTTransferPolicyInternal(tx *bolt.Tx, nft types.NftCustody) (policy types.NFTTransferPolicy) {
+	b := tx.Bucket(NFTPolicyPool)
+	if b == nil {
+		return
+	}
+	data := b.Get(nftKey(nft))
+	if data == nil {
+		return
+	}
+	err := encoding.Unmarshal(data, &policy)
+	if build.DEBUG && err != nil {
+		panic(err)
+	}
+	return
+}
+
+// ViewNFTTransferPolicy returns the transfer policy of an NFT.
+func (cs *ConsensusSet) ViewNFTTransferPolicy(nft types.NftCustody) (policy types.NFTTransferPolicy) {
+	_ = cs.db.View(func(tx *bolt.Tx) error {
+		policy = viewNFTTransferPolicyInternal(tx, nft)
+		return nil
+	})
+	return
+}
+
+// updateNFTLicense stores the license of a newly minted NFT.
+func updateNFTLicense(tx *bolt.Tx, nft types.NftCustody, license types.NFTLicense) {
+	err := putNFTIndexEntry(tx, NFTLicensePool, nftKey(nft), encoding.Marshal(license))
+	if err != nil && build.DEBUG {
+		panic(fmt.Sprintf("Error updating nft license %s", err))
+	}
+}
+
+// viewNFTLicenseInternal returns the license of an NFT.
+func viewNFTLicenseInternal(tx *bolt.Tx, nft types.NftCustody) (types.NFTLicense, error) {
+	b := tx.Bucket(NFTLicensePool)
+	if b == nil {
+		return types.NFTLicense{}, errNilItem
+	}
+	data := b.Get(nftKey(nft))
+	if data == nil {
+		return types.NFTLicense{}, errNilItem
+	}
+	var license types.NFTLicense
+	err := encoding.Unmarshal(data, &license)
+	if build.DEBUG && err != nil {
+		panic(err)
+	}
+	return license, nil
+}
+
+// ViewNFTLicense returns the license the mint of an NFT embedded. An error is
+// returned if the NFT was minted without a license.
+func (cs *ConsensusSet) ViewNFTLicense(nft types.NftCustody) (license types.NFTLicense, err error) {
+	err = cs.db.View(func(tx *bolt.Tx) error {
+		license, err = viewNFTLicenseInternal(tx, nft)
+		return err
+	})
+	return
+}
+
+// updateNFTStake stores the stake of an NFT.
+func updateNFTStake(tx *bolt.Tx, nft types.NftCustody, stake types.NFTStake) {
+	err := putNFTIndexEntry(tx, NFTStakePool, nftKey(nft), encoding.Marshal(stake))
+	if err != nil && build.DEBUG {
+		panic(fmt.Sprintf("Error updating nft stake %s", err))
+	}
+}
+
+// removeNFTStake removes the stake of an NFT.
+func removeNFTStake(tx *bolt.Tx, nft types.NftCustody) {
+	if err := deleteNFTIndexEntry(tx, NFTStakePool, nftKey(nft)); err != nil && build.DEBUG {
+		panic(fmt.Sprintf("Error removing nft stake %s", err))
+	}
+}
+
+// viewNFTStakeInternal returns the stake of an NFT. errNilItem is returned if
+// the NFT isn't staked.
+func viewNFTStakeInternal(tx *bolt.Tx, nft types.NftCustody) (types.NFTStake, error) {
+	b := tx.Bucket(NFTStakePool)
+	if b == nil {
+		return types.NFTStake{}, errNilItem
+	}
+	data := b.Get(nftKey(nft))
+	if data == nil {
+		return types.NFTStake{}, errNilItem
+	}
+	var stake types.NFTStake
+	err := encoding.Unmarshal(data, &stake)
+	if build.DEBUG && err != nil {
+		panic(err)
+	}
+	return stake, nil
+}
+
+// updateNFTRootStake stores the stake accounted to a merkle root.
+func updateNFTRootStake(tx *bolt.Tx, root crypto.Hash, stake types.NFTRootStake) {
+	err := putNFTIndexEntry(tx, NFTRootStakePool, root[:], encoding.Marshal(stake))
+	if err != nil && build.DEBUG {
+		panic(fmt.Sprintf("Error updating nft root stake %s", err))
+	}
+}
+
+// viewNFTRootStakeInternal returns the stake accounted to a merkle root. Roots
+// without stake return the zero value.
+func viewNFTRootStakeInternal(tx *bolt.Tx, root crypto.Hash) (stake types.NFTRootStake) {
+	b := tx.Bucket(NFTRootStakePool)
+	if b == nil {
+		return
+	}
+	data := b.Get(root[:])
+	if data == nil {
+		return
+	}
+	err := encoding.Unmarshal(data, &stake)
+	if build.DEBUG && err != nil {
+		panic(err)
+	}
+	return
+}
+
+// ViewNFTStake returns the stake of an NFT.
+func (cs *ConsensusSet) ViewNFTStake(nft types.NftCustody) (stake types.NFTStake, err error) {
+	err = cs.db.View(func(tx *bolt.Tx) error {
+		stake, err = viewNFTStakeInternal(tx, nft)
+		return err
+	})
+	return
+}
+
+// ViewNFTRootStake returns the stake accounted to a merkle root.
+func (cs *ConsensusSet) ViewNFTRootStake(root crypto.Hash) (stake types.NFTRootStake) {
+	_ = cs.db.View(func(tx *bolt.Tx) error {
+		stake = viewNFTRootStakeInternal(tx, root)
+		return nil
+	})
+	return
+}
+
+// updateNFTInsurance stores the insurance of an NFT.
+func updateNFTInsurance(tx *bolt.Tx, nft types.NftCustody, insurance types.NFTInsurance) {
+	err := putNFTIndexEntry(tx, NFTInsurancePool, nftKey(nft), encoding.Marshal(insurance))
+	if err != nil && build.DEBUG {
+		panic(fmt.Sprintf("Error updating nft insurance %s", err))
+	}
+}
+
+// viewNFTInsuranceInternal returns the last insurance of an NFT. errNilItem is
+// returned if the NFT was never insured.
+func viewNFTInsuranceInternal(tx *bolt.Tx, nft types.NftCustody) (types.NFTInsurance, error) {
+	b := tx.Bucket(NFTInsurancePool)
+	if b == nil {
+		return types.NFTInsurance{}, errNilItem
+	}
+	data := b.Get(nftKey(nft))
+	if data == nil {
+		return types.NFTInsurance{}, errNilItem
+	}
+	var insurance types.NFTInsurance
+	err := encoding.Unmarshal(data, &insurance)
+	if build.DEBUG && err != nil {
+		panic(err)
+	}
+	return insurance, nil
+}
+
+// ViewNFTInsurance returns the last insurance of an NFT.
+func (cs *ConsensusSet) ViewNFTInsurance(nft types.NftCustody) (insurance types.NFTInsurance, err error) {
+	err = cs.db.View(func(tx *bolt.Tx) error {
+		insurance, err = viewNFTInsuranceInternal(tx, nft)
+		return err
+	})
+	return
+}
+
+// updateNFTApproval stores the approval of an NFT.
+func updateNFTApproval(tx *bolt.Tx, nft types.NftCustody, approval types.NFTApproval) {
+	err := putNFTIndexEntry(tx, NFTApprovalPool, nftKey(nft), encoding.Marshal(approval))
+	if err != nil && build.DEBUG {
+		panic(fmt.Sprintf("Error updating nft approval %s", err))
+	}
+}
+
+// removeNFTApproval removes the approval of an NFT.
+func removeNFTApproval(tx *bolt.Tx, nft types.NftCustody) {
+	if err := deleteNFTIndexEntry(tx, NFTApprovalPool, nftKey(nft)); err != nil && build.DEBUG {
+		panic(fmt.Sprintf("Error removing nft approval %s", err))
+	}
+}
+
+// viewNFTApprovalInternal returns the approval of an NFT. errNilItem is
+// returned if no operator is approved for the NFT.
+func viewNFTApprovalInternal(tx *bolt.Tx, nft types.NftCustody) (types.NFTApproval, error) {
+	b := tx.Bucket(NFTApprovalPool)
+	if b == nil {
+		return types.NFTApproval{}, errNilItem
+	}
+	data := b.Get(nftKey(nft))
+	if data == nil {
+		return types.NFTApproval{}, errNilItem
+	}
+	var approval types.NFTApproval
+	err := encoding.Unmarshal(data, &approval)
+	if build.DEBUG && err != nil {
+		panic(err)
+	}
+	return approval, nil
+}
+
+// ViewNFTApproval returns the approval of an NFT.
+func (cs *ConsensusSet) ViewNFTApproval(nft types.NftCustody) (approval types.NFTApproval, err error) {
+	err = cs.db.View(func(tx *bolt.Tx) error {
+		approval, err = viewNFTApprovalInternal(tx, nft)
+		return err
+	})
+	return
+}
+
 // For a given NFT Custody marker, return the unspent output
 // currently containing ownership of this NFT
 // or empty unlock hash for liquidated/unminted NFTs
 func viewNFTCustodyInternal(tx *bolt.Tx, nft types.NftCustody) (types.SiacoinOutput, error) {
 	nftOutputs := tx.Bucket(NFTCustodyPool)
-	var id []byte = nft.FileMerkleRoot[:]
+	var id []byte = nftKey(nft)
 
 	var data []byte = nftOutputs.Get(id)
 	if data == nil {
@@ -366,19 +935,32 @@ func (cs *ConsensusSet) ViewNFTCustody(nft types.NftCustody) (ret types.SiacoinO
 func (cs *ConsensusSet) FindNFTsForAddress(address types.UnlockHash) []types.NftCustody {
 	var ret []types.NftCustody
 	cs.db.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket(NFTCustodyPool)
-
-		_ = b.ForEach(func(k []byte, data []byte) error {
-			var sco types.SiacoinOutput
-			encoding.Unmarshal(data, &sco)
-			if sco.UnlockHash == address {
-				var found types.NftCustody
-				fmt.Println("found custody", k, string(k))
+		ret = findNFTsForAddressInternal(tx, address)
+		return nil
+	})
+	return ret
+}
+
+// findNFTsForAddressInternal returns every NFT currently held in custody by an
+// address.
+func findNFTsForAddressInternal(tx *bolt.Tx, address types.UnlockHash) []types.NftCustody {
+	var ret []types.NftCustody
+	b := tx.Bucket(NFTCustodyPool)
+
+	_ = b.ForEach(func(k []byte, data []byte) error {
+		var sco types.SiacoinOutput
+		encoding.Unmarshal(data, &sco)
+		if sco.UnlockHash == address {
+			var found types.NftCustody
+			copy(found.ID[:], k)
+			if identified := viewNFTIdentityInternal(tx, found); identified.HasIdentity() {
+				found = identified
+			} else {
+				found = types.NftCustody{}
 				found.FileMerkleRoot.LoadFromBytes(k)
-				ret = append(ret, found)
 			}
-			return nil
-		})
+			ret = append(ret, viewNFTContentInternal(tx, found))
+		}
 		return nil
 	})
 	return ret
@@ -686,3 +1268,139 @@ func deleteDSCOBucket(tx *bolt.Tx, bh types.BlockHeight) {
 		panic(err)
 	}
 }
+
+// updateNFTStorageEarmark stores the storage pool earmark of an NFT.
+func updateNFTStorageEarmark(tx *bolt.Tx, nft types.NftCustody, earmark types.NFTStorageEarmark) {
+	err := putNFTIndexEntry(tx, NFTStorageEarmarkPool, nftKey(nft), encoding.Marshal(earmark))
+	if err != nil && build.DEBUG {
+		panic(fmt.Sprintf("Error updating nft storage earmark %s", err))
+	}
+}
+
+// viewNFTStorageEarmarkInternal returns the storage pool earmark of an NFT.
+// errNilItem is returned if no transaction of the NFT paid into the storage
+// pool.
+func viewNFTStorageEarmarkInternal(tx *bolt.Tx, nft types.NftCustody) (types.NFTStorageEarmark, error) {
+	b := tx.Bucket(NFTStorageEarmarkPool)
+	if b == nil {
+		return types.NFTStorageEarmark{}, errNilItem
+	}
+	data := b.Get(nftKey(nft))
+	if data == nil {
+		return types.NFTStorageEarmark{}, errNilItem
+	}
+	var earmark types.NFTStorageEarmark
+	err := encoding.Unmarshal(data, &earmark)
+	if build.DEBUG && err != nil {
+		panic(err)
+	}
+	return earmark, nil
+}
+
+// ViewNFTStorageEarmark returns the siacoins of the storage pool earmarked
+// for storing the data of an NFT.
+func (cs *ConsensusSet) ViewNFTStorageEarmark(nft types.NftCustody) (earmark types.NFTStorageEarmark, err error) {
+	err = cs.db.View(func(tx *bolt.Tx) error {
+		earmark, err = viewNFTStorageEarmarkInternal(tx, nft)
+		return err
+	})
+	return
+}
+
+// updateNFTDispute stores the dispute flags of an NFT.
+func updateNFTDispute(tx *bolt.Tx, nft types.NftCustody, dispute types.NFTDispute) {
+	err := putNFTIndexEntry(tx, NFTDisputePool, nftKey(nft), encoding.Marshal(dispute))
+	if err != nil && build.DEBUG {
+		panic(fmt.Sprintf("Error updating nft dispute %s", err))
+	}
+}
+
+// viewNFTDisputeInternal returns the dispute flags of an NFT. errNilItem is
+// returned if the NFT was never flagged.
+func viewNFTDisputeInternal(tx *bolt.Tx, nft types.NftCustody) (types.NFTDispute, error) {
+	b := tx.Bucket(NFTDisputePool)
+	if b == nil {
+		return types.NFTDispute{}, errNilItem
+	}
+	data := b.Get(nftKey(nft))
+	if data == nil {
+		return types.NFTDispute{}, errNilItem
+	}
+	var dispute types.NFTDispute
+	err := encoding.Unmarshal(data, &dispute)
+	if build.DEBUG && err != nil {
+		panic(err)
+	}
+	return dispute, nil
+}
+
+// updateNFTRootDisputes stores the number of frozen NFTs with the data of a
+// merkle root.
+func updateNFTRootDisputes(tx *bolt.Tx, root crypto.Hash, frozen uint64) {
+	var err error
+	if frozen == 0 {
+		err = deleteNFTIndexEntry(tx, NFTRootDisputePool, root[:])
+	} else {
+		err = putNFTIndexEntry(tx, NFTRootDisputePool, root[:], encoding.Marshal(frozen))
+	}
+	if err != nil && build.DEBUG {
+		panic(fmt.Sprintf("Error updating nft root disputes %s", err))
+	}
+}
+
+// viewNFTRootDisputesInternal returns the number of frozen NFTs with the data
+// of a merkle root.
+func viewNFTRootDisputesInternal(tx *bolt.Tx, root crypto.Hash) (frozen uint64) {
+	b := tx.Bucket(NFTRootDisputePool)
+	if b == nil {
+		return
+	}
+	data := b.Get(root[:])
+	if data == nil {
+		return
+	}
+	err := encoding.Unmarshal(data, &frozen)
+	if build.DEBUG && err != nil {
+		panic(err)
+	}
+	return
+}
+
+// ViewNFTDispute returns the dispute flags of an NFT.
+func (cs *ConsensusSet) ViewNFTDispute(nft types.NftCustody) (dispute types.NFTDispute, err error) {
+	err = cs.db.View(func(tx *bolt.Tx) error {
+		dispute, err = viewNFTDisputeInternal(tx, nft)
+		return err
+	})
+	return
+}
+
+// ViewNFTRootFrozen returns true if an NFT with the data of a merkle root is
+// frozen.
+func (cs *ConsensusSet) ViewNFTRootFrozen(root crypto.Hash) (frozen bool) {
+	_ = cs.db.View(func(tx *bolt.Tx) error {
+		frozen = viewNFTRootDisputesInternal(tx, root) > 0
+		return nil
+	})
+	return
+}
+
+// NFTDisputes returns the dispute flags of every NFT which was ever flagged,
+// ordered by identifier.
+func (cs *ConsensusSet) NFTDisputes() (disputes []types.NFTDispute, err error) {
+	err = cs.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(NFTDisputePool)
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(_, v []byte) error {
+			var dispute types.NFTDispute
+			if err := encoding.Unmarshal(v, &dispute); err != nil {
+				return err
+			}
+			disputes = append(disputes, dispute)
+			return nil
+		})
+	})
+	return
+}
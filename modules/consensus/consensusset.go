@@ -81,6 +81,10 @@ type ConsensusSet struct {
 	// whether the consensus set is synced with the network.
 	synced bool
 
+	// staticNFTSnapshotBootstrap is true if the consensus set bootstraps its
+	// NFT index from a snapshot during the initial blockchain download.
+	staticNFTSnapshotBootstrap bool
+
 	// Interfaces to abstract the dependencies of the ConsensusSet.
 	marshaler       marshaler
 	blockRuleHelper blockRuleHelper
@@ -123,6 +127,20 @@ func consensusSetBlockingStartup(gateway modules.Gateway, persistDir string, dep
 		persistDir: persistDir,
 	}
 	// Create the diffs for the genesis transaction outputs
+	genesisDiffs := genesisConsensusChangeDiffs()
+	cs.blockRoot.SiacoinOutputDiffs = genesisDiffs.SiacoinOutputDiffs
+	cs.blockRoot.SiafundOutputDiffs = genesisDiffs.SiafundOutputDiffs
+	// Initialize the consensus persistence structures.
+	err := cs.initPersist()
+	if err != nil {
+		return nil, err
+	}
+	return cs, nil
+}
+
+// genesisConsensusChangeDiffs returns the diffs which create the outputs of the
+// genesis block.
+func genesisConsensusChangeDiffs() (diffs modules.ConsensusChangeDiffs) {
 	for _, transaction := range types.GenesisBlock.Transactions {
 		// Create the diffs for the genesis siacoin outputs.
 		for i, siacoinOutput := range transaction.SiacoinOutputs {
@@ -132,7 +150,7 @@ func consensusSetBlockingStartup(gateway modules.Gateway, persistDir string, dep
 				ID:            scid,
 				SiacoinOutput: siacoinOutput,
 			}
-			cs.blockRoot.SiacoinOutputDiffs = append(cs.blockRoot.SiacoinOutputDiffs, scod)
+			diffs.SiacoinOutputDiffs = append(diffs.SiacoinOutputDiffs, scod)
 		}
 		// Create the diffs for the genesis siafund outputs.
 		for i, siafundOutput := range transaction.SiafundOutputs {
@@ -142,15 +160,10 @@ func consensusSetBlockingStartup(gateway modules.Gateway, persistDir string, dep
 				ID:            sfid,
 				SiafundOutput: siafundOutput,
 			}
-			cs.blockRoot.SiafundOutputDiffs = append(cs.blockRoot.SiafundOutputDiffs, sfod)
+			diffs.SiafundOutputDiffs = append(diffs.SiafundOutputDiffs, sfod)
 		}
 	}
-	// Initialize the consensus persistence structures.
-	err := cs.initPersist()
-	if err != nil {
-		return nil, err
-	}
-	return cs, nil
+	return diffs
 }
 
 // consensusSetAsyncStartup handles the async portion of NewCustomConsensusSet.
@@ -172,11 +185,15 @@ func consensusSetAsyncStartup(cs *ConsensusSet, bootstrap bool) error {
 	cs.gateway.RegisterRPC("SendBlocks", cs.rpcSendBlocks)
 	cs.gateway.RegisterRPC("RelayHeader", cs.threadedRPCRelayHeader)
 	cs.gateway.RegisterRPC("SendBlk", cs.rpcSendBlk)
+	cs.gateway.RegisterRPC("SendNFTSnapshot", cs.rpcSendNFTSnapshot)
+	cs.gateway.RegisterRPC("SendFilteredBlocks", cs.rpcSendFilteredBlocks)
 	cs.gateway.RegisterConnectCall("SendBlocks", cs.threadedReceiveBlocks)
 	err := cs.tg.OnStop(func() error {
 		cs.gateway.UnregisterRPC("SendBlocks")
 		cs.gateway.UnregisterRPC("RelayHeader")
 		cs.gateway.UnregisterRPC("SendBlk")
+		cs.gateway.UnregisterRPC("SendNFTSnapshot")
+		cs.gateway.UnregisterRPC("SendFilteredBlocks")
 		cs.gateway.UnregisterConnectCall("SendBlocks")
 		return nil
 	})
@@ -202,6 +219,20 @@ func New(gateway modules.Gateway, bootstrap bool, persistDir string) (*Consensus
 // there is an existing block database present in the persist directory, it
 // will be loaded.
 func NewCustomConsensusSet(gateway modules.Gateway, bootstrap bool, persistDir string, deps modules.Dependencies) (*ConsensusSet, <-chan error) {
+	return newCustomConsensusSet(gateway, bootstrap, false, persistDir, deps)
+}
+
+// NewNFTSnapshotConsensusSet returns a new ConsensusSet like
+// NewCustomConsensusSet, which bootstraps its NFT index from a snapshot of the
+// last NFT checkpoint during the initial blockchain download. The NFT history
+// before the checkpoint isn't indexed.
+func NewNFTSnapshotConsensusSet(gateway modules.Gateway, bootstrap bool, persistDir string, deps modules.Dependencies) (*ConsensusSet, <-chan error) {
+	return newCustomConsensusSet(gateway, bootstrap, true, persistDir, deps)
+}
+
+// newCustomConsensusSet creates a new ConsensusSet and starts its
+// non-blocking startup.
+func newCustomConsensusSet(gateway modules.Gateway, bootstrap, nftSnapshot bool, persistDir string, deps modules.Dependencies) (*ConsensusSet, <-chan error) {
 	// Handle blocking consensus startup first.
 	errChan := make(chan error, 1)
 	cs, err := consensusSetBlockingStartup(gateway, persistDir, deps)
@@ -209,6 +240,7 @@ func NewCustomConsensusSet(gateway modules.Gateway, bootstrap bool, persistDir s
 		errChan <- err
 		return nil, errChan
 	}
+	cs.staticNFTSnapshotBootstrap = nftSnapshot
 
 	// non-blocking consensus startup.
 	go func() {
@@ -413,6 +445,20 @@ func (cs *ConsensusSet) StorageProofSegment(fcid types.FileContractID) (index ui
 	return index, err
 }
 
+// ViewSiacoinOutput returns the unspent siacoin output with the given id.
+func (cs *ConsensusSet) ViewSiacoinOutput(id types.SiacoinOutputID) (sco types.SiacoinOutput, err error) {
+	if err := cs.tg.Add(); err != nil {
+		return types.SiacoinOutput{}, err
+	}
+	defer cs.tg.Done()
+
+	_ = cs.db.View(func(tx *bolt.Tx) error {
+		sco, err = getSiacoinOutput(tx, id)
+		return nil
+	})
+	return
+}
+
 // FoundationUnlockHashes returns the current primary and failsafe Foundation
 // UnlockHashes.
 func (cs *ConsensusSet) FoundationUnlockHashes() (primary, failsafe types.UnlockHash) {
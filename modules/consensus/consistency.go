@@ -160,7 +160,63 @@ func checkSiacoinCount(tx *bolt.Tx) {
 		manageErr(tx, err)
 	}
 
-	expectedSiacoins := types.CalculateNumSiacoins(blockHeight(tx))
+	// Add the lockups which were minted when they were returned. The coins
+	// paid into the lockup pool by the mint remain in an output.
+	var lockupSiacoins types.Currency
+	if b := tx.Bucket(NFTLockupPool); b != nil {
+		err = b.ForEach(func(_, lockupBytes []byte) error {
+			var lockup types.NFTLockup
+			err := encoding.Unmarshal(lockupBytes, &lockup)
+			if err != nil {
+				manageErr(tx, err)
+			}
+			if lockup.Reclaimed {
+				lockupSiacoins = lockupSiacoins.Add(types.NFTLockupAmount)
+			}
+			return nil
+		})
+		if err != nil {
+			manageErr(tx, err)
+		}
+	}
+
+	// Add the rewards and shares which were minted for staked merkle roots.
+	// The burned stakes remain in outputs of the staking pool.
+	var stakeSiacoins types.Currency
+	if b := tx.Bucket(NFTRootStakePool); b != nil {
+		err = b.ForEach(func(_, stakeBytes []byte) error {
+			var stake types.NFTRootStake
+			err := encoding.Unmarshal(stakeBytes, &stake)
+			if err != nil {
+				manageErr(tx, err)
+			}
+			stakeSiacoins = stakeSiacoins.Add(stake.Paid)
+			return nil
+		})
+		if err != nil {
+			manageErr(tx, err)
+		}
+	}
+
+	// Add the collateral which was minted by insurance claims and releases.
+	// The burned collateral remains in outputs of the insurance pool.
+	var insuranceSiacoins types.Currency
+	if b := tx.Bucket(NFTInsurancePool); b != nil {
+		err = b.ForEach(func(_, insuranceBytes []byte) error {
+			var insurance types.NFTInsurance
+			err := encoding.Unmarshal(insuranceBytes, &insurance)
+			if err != nil {
+				manageErr(tx, err)
+			}
+			insuranceSiacoins = insuranceSiacoins.Add(insurance.Paid)
+			return nil
+		})
+		if err != nil {
+			manageErr(tx, err)
+		}
+	}
+
+	expectedSiacoins := types.CalculateNumSiacoins(blockHeight(tx)).Add(lockupSiacoins).Add(stakeSiacoins).Add(insuranceSiacoins)
 	totalSiacoins := dscoSiacoins.Add(scoSiacoins).Add(fcSiacoins).Add(claimSiacoins)
 	if !totalSiacoins.Equals(expectedSiacoins) {
 		diagnostics := fmt.Sprintf("Wrong number of siacoins\nDsco: %v\nSco: %v\nFc: %v\nClaim: %v\n", dscoSiacoins, scoSiacoins, fcSiacoins, claimSiacoins)
@@ -215,6 +215,8 @@ func commitDiffSet(tx *bolt.Tx, pb *processedBlock, dir modules.DiffDirection) {
 	commitNodeDiffs(tx, pb, dir)
 	deleteObsoleteDelayedOutputMaps(tx, pb, dir)
 	commitFoundationUpdate(tx, pb, dir)
+	commitNFTIndexDiffs(tx, pb, dir)
+	commitNFTStats(tx, pb, dir)
 	updateCurrentPath(tx, pb, dir)
 }
 
@@ -235,11 +237,31 @@ func generateAndApplyDiff(tx *bolt.Tx, pb *processedBlock) error {
 	// applied.
 	createDSCOBucket(tx, pb.Height+types.MaturityDelay)
 
+	// A node bootstrapping its NFT index only accepts the blocks which the
+	// checkpoint of its pending snapshot commits to. The checkpoint is
+	// signed by the NFT governance, which vouches for the NFT rules of
+	// these blocks, since they can't be checked without the NFT index.
+	bootstrapping := nftIndexBootstrapping(tx)
+	if bootstrapping && !nftCheckpointPathContains(tx, pb) {
+		return errNFTCheckpointBlock
+	}
+
+	// Journal the writes of the block to the NFT index, so that its NFT
+	// index diffs can be stored once the block is applied.
+	if err := openNFTIndexJournal(tx); err != nil {
+		return err
+	}
+
 	// Validate and apply each transaction in the block. They cannot be
 	// validated all at once because some transactions may not be valid until
 	// previous transactions have been applied.
 	for _, txn := range pb.Block.Transactions {
-		err := validTransaction(tx, txn)
+		var err error
+		if bootstrapping {
+			err = validTransactionWithoutNFTIndex(tx, txn)
+		} else {
+			err = validTransaction(tx, txn)
+		}
 		if err != nil {
 			return err
 		}
@@ -252,6 +274,24 @@ func generateAndApplyDiff(tx *bolt.Tx, pb *processedBlock) error {
 	// the miner payouts and Foundation subsidy to the list of delayed outputs.
 	applyMaintenance(tx, pb)
 
+	// Store the NFT statistics of the block. They are computed from the
+	// block's diffs, so this has to happen after they were generated. Nodes
+	// bootstrapping their NFT index don't index the block, they install
+	// their snapshot at its height instead.
+	if bootstrapping {
+		if err := installNFTIndexSnapshot(tx, pb); err != nil {
+			return err
+		}
+	} else {
+		storeNFTStats(tx, pb)
+		if err := storeNFTIndexSnapshot(tx, pb); err != nil {
+			return err
+		}
+	}
+	if err := storeNFTIndexDiffs(tx, pb); err != nil {
+		return err
+	}
+
 	// DiffsGenerated are only set to true after the block has been fully
 	// validated and integrated. This is required to prevent later blocks from
 	// being accepted on top of an invalid block - if the consensus set ever
@@ -0,0 +1,294 @@
+package consensus
+
+// filteredblocks.go contains the SendFilteredBlocks RPC which serves light
+// consensus sets. Instead of full blocks, the RPC sends the headers of the
+// blocks on the current path together with the transactions and diffs which
+// affect a set of addresses given by the caller. Every transaction comes with a
+// proof that it is part of the Merkle tree of its block, so a light client
+// only has to trust the peer to not withhold transactions.
+
+import (
+	"time"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errFilteredBlockProof is returned when a transaction or miner payout of
+	// a filtered block isn't part of the Merkle tree of the block.
+	errFilteredBlockProof = errors.New("filtered block contains an invalid merkle proof")
+
+	// errFilteredBlockLayout is returned when the proofs of a filtered block
+	// don't match its payouts and transactions.
+	errFilteredBlockLayout = errors.New("filtered block has the wrong number of proofs")
+
+	// errTooManyFilterAddresses is returned when a peer requests filtered
+	// blocks for more addresses than a filter can hold.
+	errTooManyFilterAddresses = errors.New("too many addresses in filter")
+)
+
+var (
+	// maxFilterAddresses is the maximum number of addresses a peer can
+	// request filtered blocks for.
+	maxFilterAddresses = build.Select(build.Var{
+		Standard: int(100e3),
+		Dev:      int(100e3),
+		Testing:  int(1e3),
+	}).(int)
+
+	// MaxFilteredBlocks is the maximum number of filtered blocks which are
+	// sent in a single batch of the SendFilteredBlocks RPC.
+	MaxFilteredBlocks = build.Select(build.Var{
+		Standard: types.BlockHeight(100),
+		Dev:      types.BlockHeight(100),
+		Testing:  types.BlockHeight(10),
+	}).(types.BlockHeight)
+
+	// sendFilteredBlocksTimeout is the timeout for the SendFilteredBlocks RPC.
+	sendFilteredBlocksTimeout = build.Select(build.Var{
+		Standard: 180 * time.Second,
+		Dev:      40 * time.Second,
+		Testing:  5 * time.Second,
+	}).(time.Duration)
+)
+
+// blockMerkleProof returns the proof that the leaf at index is part of the
+// Merkle tree of the block. The leaves are the miner payouts of the block
+// followed by its transactions.
+func blockMerkleProof(b types.Block, index uint64) []crypto.Hash {
+	tree := crypto.NewTree()
+	if err := tree.SetIndex(index); err != nil {
+		build.Critical("unable to set index of block merkle tree:", err)
+	}
+	for _, payout := range b.MinerPayouts {
+		tree.PushObject(payout)
+	}
+	for _, txn := range b.Transactions {
+		tree.PushObject(txn)
+	}
+	_, _, proof, _, _ := tree.Prove()
+	if len(proof) == 0 {
+		return nil
+	}
+	hashes := make([]crypto.Hash, len(proof)-1)
+	for i, p := range proof[1:] {
+		hashes[i] = crypto.Hash(p)
+	}
+	return hashes
+}
+
+// verifyFilteredBlock checks that the miner payouts and transactions of a
+// filtered block are part of the Merkle tree committed to by its header.
+func verifyFilteredBlock(fb modules.FilteredBlock) error {
+	if len(fb.PayoutProofs) != len(fb.MinerPayouts) ||
+		len(fb.TransactionProofs) != len(fb.Transactions) ||
+		len(fb.TransactionLeaves) != len(fb.Transactions) {
+		return errFilteredBlockLayout
+	}
+	numPayouts := uint64(len(fb.MinerPayouts))
+	if numPayouts > fb.NumLeaves {
+		return errFilteredBlockLayout
+	}
+	for i, payout := range fb.MinerPayouts {
+		if !crypto.VerifySegment(encoding.Marshal(payout), fb.PayoutProofs[i], fb.NumLeaves, uint64(i), fb.Header.MerkleRoot) {
+			return errFilteredBlockProof
+		}
+	}
+	for i, txn := range fb.Transactions {
+		// Transactions can't take the place of a payout.
+		leaf := fb.TransactionLeaves[i]
+		if leaf < numPayouts || leaf >= fb.NumLeaves {
+			return errFilteredBlockLayout
+		}
+		if !crypto.VerifySegment(encoding.Marshal(txn), fb.TransactionProofs[i], fb.NumLeaves, leaf, fb.Header.MerkleRoot) {
+			return errFilteredBlockProof
+		}
+	}
+	return nil
+}
+
+// filterTransaction returns true if the transaction spends from or sends to
+// one of the addresses.
+func filterTransaction(t types.Transaction, addrs map[types.UnlockHash]struct{}) bool {
+	for _, sci := range t.SiacoinInputs {
+		if _, ok := addrs[sci.UnlockConditions.UnlockHash()]; ok {
+			return true
+		}
+	}
+	for _, sco := range t.SiacoinOutputs {
+		if _, ok := addrs[sco.UnlockHash]; ok {
+			return true
+		}
+	}
+	for _, sfi := range t.SiafundInputs {
+		if _, ok := addrs[sfi.UnlockConditions.UnlockHash()]; ok {
+			return true
+		}
+	}
+	for _, sfo := range t.SiafundOutputs {
+		if _, ok := addrs[sfo.UnlockHash]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// filterConsensusChangeDiffs returns the siacoin, delayed siacoin and siafund
+// output diffs which affect one of the addresses. Other diffs are dropped.
+func filterConsensusChangeDiffs(diffs modules.ConsensusChangeDiffs, addrs map[types.UnlockHash]struct{}) (filtered modules.ConsensusChangeDiffs) {
+	for _, diff := range diffs.SiacoinOutputDiffs {
+		if _, ok := addrs[diff.SiacoinOutput.UnlockHash]; ok {
+			filtered.SiacoinOutputDiffs = append(filtered.SiacoinOutputDiffs, diff)
+		}
+	}
+	for _, diff := range diffs.DelayedSiacoinOutputDiffs {
+		if _, ok := addrs[diff.SiacoinOutput.UnlockHash]; ok {
+			filtered.DelayedSiacoinOutputDiffs = append(filtered.DelayedSiacoinOutputDiffs, diff)
+		}
+	}
+	for _, diff := range diffs.SiafundOutputDiffs {
+		if _, ok := addrs[diff.SiafundOutput.UnlockHash]; ok {
+			filtered.SiafundOutputDiffs = append(filtered.SiafundOutputDiffs, diff)
+		}
+	}
+	return filtered
+}
+
+// filterBlock returns the filtered block of a processed block for a set of
+// addresses.
+func filterBlock(pb *processedBlock, addrs map[types.UnlockHash]struct{}) modules.FilteredBlock {
+	b := pb.Block
+	fb := modules.FilteredBlock{
+		Header:       b.Header(),
+		Height:       pb.Height,
+		NumLeaves:    uint64(len(b.MinerPayouts) + len(b.Transactions)),
+		MinerPayouts: b.MinerPayouts,
+		Diffs:        filterConsensusChangeDiffs(computeConsensusChangeDiffs(pb, true), addrs),
+	}
+	for i := range b.MinerPayouts {
+		fb.PayoutProofs = append(fb.PayoutProofs, blockMerkleProof(b, uint64(i)))
+	}
+	for i, txn := range b.Transactions {
+		if !filterTransaction(txn, addrs) {
+			continue
+		}
+		leaf := uint64(len(b.MinerPayouts) + i)
+		fb.Transactions = append(fb.Transactions, txn)
+		fb.TransactionLeaves = append(fb.TransactionLeaves, leaf)
+		fb.TransactionProofs = append(fb.TransactionProofs, blockMerkleProof(b, leaf))
+	}
+	return fb
+}
+
+// rpcSendFilteredBlocks is the receiving end of the SendFilteredBlocks RPC. It
+// works like SendBlocks, but after the known block IDs it reads a set of
+// addresses and sends filtered blocks for these addresses instead of full
+// blocks.
+func (cs *ConsensusSet) rpcSendFilteredBlocks(conn modules.PeerConn) error {
+	err := conn.SetDeadline(time.Now().Add(sendFilteredBlocksTimeout))
+	if err != nil {
+		return err
+	}
+	finishedChan := make(chan struct{})
+	defer close(finishedChan)
+	go func() {
+		select {
+		case <-cs.tg.StopChan():
+		case <-finishedChan:
+		}
+		conn.Close()
+	}()
+	err = cs.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer cs.tg.Done()
+
+	// Read the blocks known to the requester and the addresses of the
+	// filter.
+	var knownBlocks [32]types.BlockID
+	err = encoding.ReadObject(conn, &knownBlocks, 32*crypto.HashSize)
+	if err != nil {
+		return err
+	}
+	var addrs []types.UnlockHash
+	err = encoding.ReadObject(conn, &addrs, uint64(8+maxFilterAddresses*crypto.HashSize))
+	if err != nil {
+		return err
+	}
+	if len(addrs) > maxFilterAddresses {
+		return errTooManyFilterAddresses
+	}
+	filter := make(map[types.UnlockHash]struct{}, len(addrs))
+	for _, addr := range addrs {
+		filter[addr] = struct{}{}
+	}
+
+	// Find the most recent block from knownBlocks in the current path.
+	found := false
+	var start types.BlockHeight
+	cs.mu.RLock()
+	err = cs.db.View(func(tx *bolt.Tx) error {
+		height, known := latestKnownBlock(tx, knownBlocks)
+		found = known && height != blockHeight(tx)
+		start = height + 1
+		return nil
+	})
+	cs.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	if !found {
+		if err := encoding.WriteObject(conn, []modules.FilteredBlock{}); err != nil {
+			return err
+		}
+		return encoding.WriteObject(conn, false)
+	}
+
+	// Send the caller the filtered blocks they are missing. Batches are cut
+	// short once they exceed the size of a block.
+	moreAvailable := true
+	for moreAvailable {
+		var blocks []modules.FilteredBlock
+		cs.mu.RLock()
+		err = cs.db.View(func(tx *bolt.Tx) error {
+			height := blockHeight(tx)
+			var size uint64
+			i := start
+			for ; i <= height && i < start+MaxFilteredBlocks && size <= types.BlockSizeLimit; i++ {
+				id, err := getPath(tx, i)
+				if err != nil {
+					return err
+				}
+				pb, err := getBlockMap(tx, id)
+				if err != nil {
+					return err
+				}
+				fb := filterBlock(pb, filter)
+				size += uint64(len(encoding.Marshal(fb)))
+				blocks = append(blocks, fb)
+			}
+			moreAvailable = i <= height
+			start = i
+			return nil
+		})
+		cs.mu.RUnlock()
+		if err != nil {
+			return err
+		}
+		if err = encoding.WriteObject(conn, blocks); err != nil {
+			return err
+		}
+		if err = encoding.WriteObject(conn, moreAvailable); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,1192 @@
+package consensus
+
+// light.go contains the LightConsensusSet, a consensus set for wallets which
+// can't store or sync the full blockchain. Instead of blocks it downloads
+// filtered blocks for the addresses of its subscribers from its peers using
+// the SendFilteredBlocks RPC, see filteredblocks.go.
+//
+// The light consensus set verifies that the transactions of a filtered block
+// are part of the block, but it neither validates blocks nor chooses between
+// forks, it follows the chain of the peer it synced with last. The diffs of
+// filtered blocks are trusted as well, and a peer can withhold transactions.
+//
+// Only the transactions which spend from or send to a watched address are
+// part of a filtered block. The blocks sent to subscribers contain these
+// transactions and the miner payouts of the block, so their IDs differ from
+// the IDs of the blocks on the network, except for the genesis block, which
+// is always complete. Their parent IDs are the IDs of the parent blocks sent
+// to subscribers, so subscribers still see a connected chain. Operations which
+// need the ID of a block, like stake claims and responses to insurance
+// challenges, require a full node.
+//
+// The NFT index of the light consensus set only contains the NFTs that were
+// minted or transferred by transactions of filtered blocks. An NFT which
+// leaves the wallet in a transaction that doesn't involve any of its
+// addresses, e.g. a transfer by an approved operator, remains in the custody
+// of the wallet until the NFT is transferred to a watched address again.
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/demotemutex"
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/threadgroup"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
+	siasync "go.sia.tech/siad/sync"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// LightDatabaseFilename contains the filename of the database of the
+	// light consensus set.
+	LightDatabaseFilename = "light" + modules.ConsensusDir + ".db"
+	lightLogFile          = "light" + modules.ConsensusDir + ".log"
+)
+
+var (
+	lightDBMetadata = persist.Metadata{
+		Header:  "Light Consensus Set Database",
+		Version: "1.0.0",
+	}
+
+	// LightBlockMap is a database bucket of the light consensus set which
+	// maps the ids of the blocks in the current path to their filtered
+	// blocks.
+	LightBlockMap = []byte("LightBlockMap")
+
+	// LightAddresses is a database bucket of the light consensus set which
+	// contains the watched addresses.
+	LightAddresses = []byte("LightAddresses")
+
+	// LightChangeLog is a database bucket of the light consensus set which
+	// maps the sequence numbers of the consensus changes to the changes. The
+	// changes contain the filtered blocks they applied and reverted, since
+	// the filtered blocks of a block change when addresses are added.
+	LightChangeLog = []byte("LightChangeLog")
+
+	// LightChangeIDs is a database bucket of the light consensus set which
+	// maps the ids of the consensus changes to their sequence numbers.
+	LightChangeIDs = []byte("LightChangeIDs")
+
+	// LightMetadata is a database bucket of the light consensus set which
+	// contains the rescan flag.
+	LightMetadata = []byte("LightMetadata")
+
+	// FieldLightRescan is a field in LightMetadata which is set when
+	// addresses were added since the blocks were downloaded.
+	FieldLightRescan = []byte("Rescan")
+)
+
+var (
+	// errLightUnsupported is returned by the methods of the light consensus
+	// set which require the full blockchain.
+	errLightUnsupported = errors.New("not supported by a light consensus set")
+
+	// errLightParent is returned when a peer sends filtered blocks which
+	// don't extend the current path of the light consensus set.
+	errLightParent = errors.New("filtered blocks don't extend the current path")
+
+	// errLightDoubleSpend is returned when a transaction set spends the same
+	// output twice.
+	errLightDoubleSpend = errors.New("transaction set spends an output twice")
+
+	// errMissingSiafundOutput is returned when a transaction spends a
+	// siafund output which is unknown to the light consensus set.
+	errMissingSiafundOutput = errors.New("transaction spends a nonexisting siafund output")
+
+	// lightSyncInterval is the interval at which the light consensus set
+	// requests new filtered blocks from its peers.
+	lightSyncInterval = build.Select(build.Var{
+		Standard: 2 * time.Minute,
+		Dev:      20 * time.Second,
+		Testing:  time.Second,
+	}).(time.Duration)
+)
+
+type (
+	// lightBlock is a filtered block together with the changes it made to
+	// the NFT index. ParentID is the ID of the parent block as sent to
+	// subscribers.
+	lightBlock struct {
+		modules.FilteredBlock
+		NFTIndexDiffs []nftIndexDiff
+		ParentID      types.BlockID
+	}
+
+	// lightChangeNode is a consensus change of the light consensus set.
+	lightChangeNode struct {
+		Sequence uint64
+		Reverted []lightBlock
+		Applied  []lightBlock
+	}
+
+	// The LightConsensusSet follows the blockchain through filtered blocks of
+	// the addresses its subscribers watch. It implements
+	// modules.LightConsensusSet.
+	LightConsensusSet struct {
+		gateway     modules.Gateway
+		subscribers []modules.ConsensusSetSubscriber
+
+		// synced is true once filtered blocks were received from a peer.
+		synced bool
+
+		// syncChan wakes up the thread which downloads filtered blocks.
+		syncChan chan struct{}
+
+		db         *persist.BoltDatabase
+		staticDeps modules.Dependencies
+		log        *persist.Logger
+		mu         demotemutex.DemoteMutex
+		persistDir string
+		tg         threadgroup.ThreadGroup
+	}
+)
+
+// sequenceKey returns the key of a consensus change in the LightChangeLog.
+func sequenceKey(seq uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, seq)
+	return key
+}
+
+// ID returns the id of the consensus change. The sequence number is part of
+// the id, so changes which apply the same blocks have different ids.
+func (n lightChangeNode) ID() modules.ConsensusChangeID {
+	ids := make([]types.BlockID, 0, len(n.Reverted)+len(n.Applied))
+	for _, lb := range n.Reverted {
+		ids = append(ids, lb.Header.ID())
+	}
+	for _, lb := range n.Applied {
+		ids = append(ids, lb.Header.ID())
+	}
+	return modules.ConsensusChangeID(crypto.HashAll(n.Sequence, ids))
+}
+
+// block returns the block subscribers receive for the filtered block.
+func (lb lightBlock) block() types.Block {
+	if lb.Height == 0 {
+		return types.GenesisBlock
+	}
+	return types.Block{
+		ParentID:     lb.ParentID,
+		Nonce:        lb.Header.Nonce,
+		Timestamp:    lb.Header.Timestamp,
+		MinerPayouts: lb.MinerPayouts,
+		Transactions: lb.Transactions,
+	}
+}
+
+// genesisLightBlock returns the filtered block of the genesis block. It
+// contains all transactions and diffs of the genesis block.
+func genesisLightBlock() lightBlock {
+	b := types.GenesisBlock
+	fb := modules.FilteredBlock{
+		Header:       b.Header(),
+		NumLeaves:    uint64(len(b.MinerPayouts) + len(b.Transactions)),
+		MinerPayouts: b.MinerPayouts,
+		Transactions: b.Transactions,
+		Diffs:        genesisConsensusChangeDiffs(),
+	}
+	for i := range b.MinerPayouts {
+		fb.PayoutProofs = append(fb.PayoutProofs, blockMerkleProof(b, uint64(i)))
+	}
+	for i := range b.Transactions {
+		leaf := uint64(len(b.MinerPayouts) + i)
+		fb.TransactionLeaves = append(fb.TransactionLeaves, leaf)
+		fb.TransactionProofs = append(fb.TransactionProofs, blockMerkleProof(b, leaf))
+	}
+	return lightBlock{FilteredBlock: fb}
+}
+
+// getLightBlock returns the filtered block of a block in the current path.
+func getLightBlock(tx *bolt.Tx, id types.BlockID) (lb lightBlock, err error) {
+	data := tx.Bucket(LightBlockMap).Get(id[:])
+	if data == nil {
+		return lightBlock{}, errNilItem
+	}
+	err = encoding.Unmarshal(data, &lb)
+	return
+}
+
+// getLightChange returns the consensus change with the given sequence number.
+func getLightChange(tx *bolt.Tx, seq uint64) (n lightChangeNode, exists bool) {
+	data := tx.Bucket(LightChangeLog).Get(sequenceKey(seq))
+	if data == nil {
+		return lightChangeNode{}, false
+	}
+	if err := encoding.Unmarshal(data, &n); build.DEBUG && err != nil {
+		panic(err)
+	}
+	return n, true
+}
+
+// appendLightChange adds a consensus change to the change log.
+func appendLightChange(tx *bolt.Tx, reverted, applied []lightBlock) (lightChangeNode, error) {
+	cl := tx.Bucket(LightChangeLog)
+	n := lightChangeNode{
+		Reverted: reverted,
+		Applied:  applied,
+	}
+	if k, _ := cl.Cursor().Last(); k != nil {
+		n.Sequence = binary.BigEndian.Uint64(k) + 1
+	}
+	if err := cl.Put(sequenceKey(n.Sequence), encoding.Marshal(n)); err != nil {
+		return lightChangeNode{}, err
+	}
+	id := n.ID()
+	return n, tx.Bucket(LightChangeIDs).Put(id[:], sequenceKey(n.Sequence))
+}
+
+// lastLightChange returns the sequence number of the most recent consensus
+// change.
+func lastLightChange(tx *bolt.Tx) uint64 {
+	k, _ := tx.Bucket(LightChangeLog).Cursor().Last()
+	if k == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint64(k)
+}
+
+// watchedAddresses returns the addresses watched by the light consensus set.
+func watchedAddresses(tx *bolt.Tx) (addrs []types.UnlockHash) {
+	_ = tx.Bucket(LightAddresses).ForEach(func(k, _ []byte) error {
+		var addr types.UnlockHash
+		copy(addr[:], k)
+		addrs = append(addrs, addr)
+		return nil
+	})
+	return addrs
+}
+
+// applyLightDiffs applies the output diffs of a filtered block to the outputs
+// of the light consensus set. Unlike the full consensus set, the light
+// consensus set doesn't know every output, so the diffs are applied without
+// checking for existing outputs.
+func applyLightDiffs(tx *bolt.Tx, diffs modules.ConsensusChangeDiffs) error {
+	scos, sfos := tx.Bucket(SiacoinOutputs), tx.Bucket(SiafundOutputs)
+	for _, diff := range diffs.SiacoinOutputDiffs {
+		var err error
+		if diff.Direction == modules.DiffApply {
+			err = scos.Put(diff.ID[:], encoding.Marshal(diff.SiacoinOutput))
+		} else {
+			err = scos.Delete(diff.ID[:])
+		}
+		if err != nil {
+			return err
+		}
+	}
+	for _, diff := range diffs.SiafundOutputDiffs {
+		var err error
+		if diff.Direction == modules.DiffApply {
+			err = sfos.Put(diff.ID[:], encoding.Marshal(diff.SiafundOutput))
+		} else {
+			err = sfos.Delete(diff.ID[:])
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyLightBlock adds a filtered block to the current path and applies its
+// diffs and NFT transactions.
+func applyLightBlock(tx *bolt.Tx, fb modules.FilteredBlock) (lightBlock, error) {
+	lb := lightBlock{FilteredBlock: fb}
+	if fb.Height > 0 {
+		parent, err := getLightBlock(tx, fb.Header.ParentID)
+		if err != nil {
+			return lightBlock{}, err
+		}
+		lb.ParentID = parent.block().ID()
+	}
+	if err := applyLightDiffs(tx, fb.Diffs); err != nil {
+		return lightBlock{}, err
+	}
+	// Journal the writes to the NFT index, so that they can be reverted.
+	if err := openNFTIndexJournal(tx); err != nil {
+		return lightBlock{}, err
+	}
+	for _, t := range fb.Transactions {
+		if !types.UpdatesNFTCustody(t) {
+			continue
+		}
+		nft, owner := types.ExtractNFTFromTransaction(t)
+		updateNFTCustody(tx, nft, owner)
+		if nft.HasContentCommitment() {
+			updateNFTContent(tx, nft)
+		}
+		if nft.HasIdentity() {
+			updateNFTIdentity(tx, nft)
+		}
+		if nft.IsEdition() {
+			updateNFTEdition(tx, nft)
+		}
+		if nft.HasTransferPolicy() {
+			updateNFTTransferPolicy(tx, nft)
+		}
+		if _, license, err := types.ParseNFTLicense(t.ArbitraryData[0]); err == nil {
+			updateNFTLicense(tx, nft, license)
+		}
+	}
+	diffs, err := closeNFTIndexJournal(tx)
+	if err != nil {
+		return lightBlock{}, err
+	}
+	lb.NFTIndexDiffs = diffs
+	id := fb.Header.ID()
+	pushPath(tx, id)
+	return lb, tx.Bucket(LightBlockMap).Put(id[:], encoding.Marshal(lb))
+}
+
+// revertLightBlock removes the most recent filtered block from the current
+// path and reverts its diffs and NFT index changes.
+func revertLightBlock(tx *bolt.Tx) (lightBlock, error) {
+	id := currentBlockID(tx)
+	lb, err := getLightBlock(tx, id)
+	if err != nil {
+		return lightBlock{}, err
+	}
+	if err := applyLightDiffs(tx, invertConsensusChangeDiffs(lb.Diffs)); err != nil {
+		return lightBlock{}, err
+	}
+	if err := applyNFTIndexDiffs(tx, lb.NFTIndexDiffs, modules.DiffRevert); err != nil {
+		return lightBlock{}, err
+	}
+	popPath(tx)
+	return lb, tx.Bucket(LightBlockMap).Delete(id[:])
+}
+
+// initLightDB creates the buckets of the light consensus set and adds the
+// genesis block if the database is new.
+func initLightDB(tx *bolt.Tx) error {
+	if tx.Bucket(LightBlockMap) != nil {
+		return nil
+	}
+	buckets := [][]byte{
+		BlockHeight,
+		BlockPath,
+		LightBlockMap,
+		LightAddresses,
+		LightChangeLog,
+		LightChangeIDs,
+		LightMetadata,
+		SiacoinOutputs,
+		SiafundOutputs,
+		NFTCustodyPool,
+	}
+	for _, bucket := range buckets {
+		if _, err := tx.CreateBucket(bucket); err != nil {
+			return err
+		}
+	}
+
+	// Set the block height to -1, so the genesis block is at height 0.
+	underflow := types.BlockHeight(0)
+	err := tx.Bucket(BlockHeight).Put(BlockHeight, encoding.Marshal(underflow-1))
+	if err != nil {
+		return err
+	}
+	genesis, err := applyLightBlock(tx, genesisLightBlock().FilteredBlock)
+	if err != nil {
+		return err
+	}
+	_, err = appendLightChange(tx, nil, []lightBlock{genesis})
+	return err
+}
+
+// NewLightConsensusSet returns a new LightConsensusSet. It downloads filtered
+// blocks from the peers of the gateway once addresses are watched.
+func NewLightConsensusSet(gateway modules.Gateway, persistDir string, deps modules.Dependencies) (*LightConsensusSet, error) {
+	if gateway == nil {
+		return nil, errNilGateway
+	}
+	cs := &LightConsensusSet{
+		gateway:    gateway,
+		syncChan:   make(chan struct{}, 1),
+		staticDeps: deps,
+		persistDir: persistDir,
+	}
+	err := os.MkdirAll(persistDir, 0700)
+	if err != nil {
+		return nil, err
+	}
+	cs.log, err = persist.NewFileLogger(filepath.Join(persistDir, lightLogFile))
+	if err != nil {
+		return nil, err
+	}
+	err = cs.tg.AfterStop(func() error {
+		err := cs.log.Close()
+		if err != nil {
+			fmt.Println("Error shutting down light consensus set logger:", err)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	cs.db, err = persist.OpenDatabase(lightDBMetadata, filepath.Join(persistDir, LightDatabaseFilename))
+	if err != nil {
+		return nil, errors.AddContext(err, "error opening light consensus database")
+	}
+	err = cs.tg.AfterStop(func() error {
+		err := cs.db.Close()
+		if err != nil {
+			cs.log.Println("ERROR: Unable to close light consensus set database at shutdown:", err)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = cs.db.Update(initLightDB)
+	if err != nil {
+		return nil, err
+	}
+
+	// Request filtered blocks from new peers and wake up on relayed
+	// headers.
+	cs.gateway.RegisterRPC("RelayHeader", cs.rpcRelayHeader)
+	cs.gateway.RegisterConnectCall("SendFilteredBlocks", cs.threadedReceiveFilteredBlocks)
+	err = cs.tg.OnStop(func() error {
+		cs.gateway.UnregisterRPC("RelayHeader")
+		cs.gateway.UnregisterConnectCall("SendFilteredBlocks")
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	go cs.threadedSynchronize()
+	return cs, nil
+}
+
+// managedWakeSync wakes up the thread which downloads filtered blocks.
+func (cs *LightConsensusSet) managedWakeSync() {
+	select {
+	case cs.syncChan <- struct{}{}:
+	default:
+	}
+}
+
+// threadedSynchronize requests filtered blocks from the peers of the gateway
+// periodically and whenever it is woken up.
+func (cs *LightConsensusSet) threadedSynchronize() {
+	if err := cs.tg.Add(); err != nil {
+		return
+	}
+	defer cs.tg.Done()
+	for {
+		for _, p := range cs.gateway.Peers() {
+			if p.Inbound {
+				continue
+			}
+			err := cs.gateway.RPC(p.NetAddress, "SendFilteredBlocks", cs.managedReceiveFilteredBlocks)
+			if err == nil {
+				break
+			}
+			cs.log.Printf("WARN: failed to get filtered blocks from %v: %v", p.NetAddress, err)
+		}
+		select {
+		case <-cs.tg.StopChan():
+			return
+		case <-cs.syncChan:
+		case <-time.After(lightSyncInterval):
+		}
+	}
+}
+
+// rpcRelayHeader is the receiving end of the RelayHeader RPC. Light consensus
+// sets don't validate headers, a new header only triggers a sync.
+func (cs *LightConsensusSet) rpcRelayHeader(conn modules.PeerConn) error {
+	err := conn.SetDeadline(time.Now().Add(relayHeaderTimeout))
+	if err != nil {
+		return err
+	}
+	var h types.BlockHeader
+	err = encoding.ReadObject(conn, &h, types.BlockHeaderSize)
+	if err != nil {
+		return err
+	}
+	cs.managedWakeSync()
+	return nil
+}
+
+// threadedReceiveFilteredBlocks is the connect call of the SendFilteredBlocks
+// RPC.
+func (cs *LightConsensusSet) threadedReceiveFilteredBlocks(conn modules.PeerConn) error {
+	err := cs.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer cs.tg.Done()
+	return cs.managedReceiveFilteredBlocks(conn)
+}
+
+// managedReceiveFilteredBlocks is the calling end of the SendFilteredBlocks
+// RPC. After a rescan was requested, only the genesis block is sent as known
+// block so that the peer sends the whole chain again.
+func (cs *LightConsensusSet) managedReceiveFilteredBlocks(conn modules.PeerConn) error {
+	err := conn.SetDeadline(time.Now().Add(sendFilteredBlocksTimeout))
+	if err != nil {
+		return err
+	}
+	finishedChan := make(chan struct{})
+	defer close(finishedChan)
+	go func() {
+		select {
+		case <-cs.tg.StopChan():
+		case <-finishedChan:
+		}
+		conn.Close()
+	}()
+
+	var history [32]types.BlockID
+	var addrs []types.UnlockHash
+	var rescan bool
+	cs.mu.RLock()
+	err = cs.db.View(func(tx *bolt.Tx) error {
+		addrs = watchedAddresses(tx)
+		rescan = tx.Bucket(LightMetadata).Get(FieldLightRescan) != nil
+		if rescan {
+			history[31] = types.GenesisID
+		} else {
+			history = blockHistory(tx)
+		}
+		return nil
+	})
+	cs.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	if err := encoding.WriteObject(conn, history); err != nil {
+		return err
+	}
+	if err := encoding.WriteObject(conn, addrs); err != nil {
+		return err
+	}
+
+	moreAvailable := true
+	for moreAvailable {
+		var blocks []modules.FilteredBlock
+		if err := encoding.ReadObject(conn, &blocks, uint64(MaxFilteredBlocks)*types.BlockSizeLimit); err != nil {
+			return err
+		}
+		if err := encoding.ReadObject(conn, &moreAvailable, 1); err != nil {
+			return err
+		}
+		if err := cs.managedApplyFilteredBlocks(blocks, rescan, len(addrs)); err != nil {
+			return err
+		}
+		rescan = false
+	}
+
+	cs.mu.Lock()
+	cs.synced = true
+	cs.mu.Unlock()
+	return nil
+}
+
+// managedApplyFilteredBlocks verifies a batch of filtered blocks and replaces
+// the blocks of the current path from the height of the first filtered block
+// onwards with them. If the batch completes a rescan with numAddrs addresses
+// and no addresses were added in the meantime, the rescan flag is cleared.
+func (cs *LightConsensusSet) managedApplyFilteredBlocks(blocks []modules.FilteredBlock, rescan bool, numAddrs int) error {
+	for _, fb := range blocks {
+		if err := verifyFilteredBlock(fb); err != nil {
+			return err
+		}
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	var n lightChangeNode
+	err := cs.db.Update(func(tx *bolt.Tx) error {
+		if rescan && len(watchedAddresses(tx)) == numAddrs {
+			if err := tx.Bucket(LightMetadata).Delete(FieldLightRescan); err != nil {
+				return err
+			}
+		}
+		if len(blocks) == 0 {
+			return nil
+		}
+
+		// The blocks have to extend the current path at the height of the
+		// first block.
+		start := blocks[0].Height
+		if start == 0 {
+			return errLightParent
+		}
+		parent, err := getPath(tx, start-1)
+		if err != nil {
+			return errLightParent
+		}
+		for i, fb := range blocks {
+			if fb.Height != start+types.BlockHeight(i) || fb.Header.ParentID != parent {
+				return errLightParent
+			}
+			parent = fb.Header.ID()
+		}
+
+		var reverted, applied []lightBlock
+		for blockHeight(tx) >= start {
+			lb, err := revertLightBlock(tx)
+			if err != nil {
+				return err
+			}
+			reverted = append(reverted, lb)
+		}
+		for _, fb := range blocks {
+			lb, err := applyLightBlock(tx, fb)
+			if err != nil {
+				return err
+			}
+			applied = append(applied, lb)
+		}
+		n, err = appendLightChange(tx, reverted, applied)
+		return err
+	})
+	if err != nil || len(blocks) == 0 {
+		return err
+	}
+	if len(n.Reverted) > 0 {
+		cs.log.Println("Filtered blocks with re-org received:", n.ID(), len(n.Reverted))
+	}
+	cs.updateSubscribers(n)
+	return nil
+}
+
+// computeConsensusChange computes the consensus change of a change node.
+func (cs *LightConsensusSet) computeConsensusChange(tx *bolt.Tx, n lightChangeNode) modules.ConsensusChange {
+	cc := modules.ConsensusChange{
+		ID: n.ID(),
+	}
+	for _, lb := range n.Reverted {
+		cc.RevertedBlocks = append(cc.RevertedBlocks, lb.block())
+		diffs := invertConsensusChangeDiffs(lb.Diffs)
+		cc.RevertedDiffs = append(cc.RevertedDiffs, diffs)
+		cc.AppendDiffs(diffs)
+	}
+	for _, lb := range n.Applied {
+		cc.AppliedBlocks = append(cc.AppliedBlocks, lb.block())
+		cc.AppliedDiffs = append(cc.AppliedDiffs, lb.Diffs)
+		cc.AppendDiffs(lb.Diffs)
+	}
+	cc.BlockHeight = n.Applied[len(n.Applied)-1].Height
+	cc.Synced = cs.synced && n.Sequence == lastLightChange(tx)
+	cc.TryTransactionSet = cs.tryTransactionSet
+	return cc
+}
+
+// updateSubscribers sends a consensus change to all subscribers.
+func (cs *LightConsensusSet) updateSubscribers(n lightChangeNode) {
+	if len(cs.subscribers) == 0 {
+		return
+	}
+	var cc modules.ConsensusChange
+	_ = cs.db.View(func(tx *bolt.Tx) error {
+		cc = cs.computeConsensusChange(tx, n)
+		return nil
+	})
+	for _, subscriber := range cs.subscribers {
+		subscriber.ProcessConsensusChange(cc)
+	}
+}
+
+// ConsensusSetSubscribe adds a subscriber to the list of subscribers, and
+// gives them every consensus change that has occurred since the change with
+// the provided id.
+func (cs *LightConsensusSet) ConsensusSetSubscribe(subscriber modules.ConsensusSetSubscriber, start modules.ConsensusChangeID, cancel <-chan struct{}) error {
+	err := cs.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer cs.tg.Done()
+
+	// Find the sequence number of the first change the subscriber is
+	// missing.
+	var next uint64
+	err = cs.db.View(func(tx *bolt.Tx) error {
+		switch start {
+		case modules.ConsensusChangeBeginning:
+			next = 0
+		case modules.ConsensusChangeRecent:
+			next = lastLightChange(tx) + 1
+		default:
+			seq := tx.Bucket(LightChangeIDs).Get(start[:])
+			if seq == nil {
+				return modules.ErrInvalidConsensusChangeID
+			}
+			next = binary.BigEndian.Uint64(seq) + 1
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Send the missing changes in batches, the last batch is sent while
+	// holding the write lock so that no change is missed.
+	for {
+		cs.mu.Lock()
+		var caughtUp bool
+		err = cs.db.View(func(tx *bolt.Tx) error {
+			last := lastLightChange(tx)
+			for i := 0; i < 100 && next <= last; i++ {
+				select {
+				case <-cancel:
+					return siasync.ErrStopped
+				default:
+				}
+				n, _ := getLightChange(tx, next)
+				subscriber.ProcessConsensusChange(cs.computeConsensusChange(tx, n))
+				next++
+			}
+			caughtUp = next > last
+			return nil
+		})
+		if err != nil {
+			cs.mu.Unlock()
+			return err
+		}
+		if caughtUp {
+			for _, s := range cs.subscribers {
+				if s == subscriber {
+					build.Critical("refusing to double-subscribe subscriber")
+				}
+			}
+			cs.subscribers = append(cs.subscribers, subscriber)
+			cs.mu.Unlock()
+			return nil
+		}
+		cs.mu.Unlock()
+
+		select {
+		case <-cs.tg.StopChan():
+			return siasync.ErrStopped
+		default:
+		}
+	}
+}
+
+// Unsubscribe removes a subscriber from the list of subscribers.
+func (cs *LightConsensusSet) Unsubscribe(subscriber modules.ConsensusSetSubscriber) {
+	if cs.tg.Add() != nil {
+		return
+	}
+	defer cs.tg.Done()
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	for i := range cs.subscribers {
+		if cs.subscribers[i] == subscriber {
+			cs.subscribers[i] = nil
+			cs.subscribers = append(cs.subscribers[0:i], cs.subscribers[i+1:]...)
+			break
+		}
+	}
+}
+
+// WatchAddresses adds addresses to the set of watched addresses. If any of
+// them are new, the filtered blocks are downloaded again and subscribers
+// receive a reorg to the new blocks.
+func (cs *LightConsensusSet) WatchAddresses(addrs []types.UnlockHash) error {
+	if err := cs.tg.Add(); err != nil {
+		return err
+	}
+	defer cs.tg.Done()
+
+	var added bool
+	cs.mu.Lock()
+	err := cs.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(LightAddresses)
+		for _, addr := range addrs {
+			if b.Get(addr[:]) != nil {
+				continue
+			}
+			if err := b.Put(addr[:], []byte{}); err != nil {
+				return err
+			}
+			added = true
+		}
+		if !added {
+			return nil
+		}
+		return tx.Bucket(LightMetadata).Put(FieldLightRescan, []byte{1})
+	})
+	cs.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if added {
+		cs.managedWakeSync()
+	}
+	return nil
+}
+
+// tryTransactionSet checks the transactions against the outputs known to the
+// light consensus set. Outputs which aren't sent to a watched address are
+// unknown, so transactions spending them are rejected.
+func (cs *LightConsensusSet) tryTransactionSet(txns []types.Transaction) (cc modules.ConsensusChange, err error) {
+	err = cs.db.View(func(tx *bolt.Tx) error {
+		height := blockHeight(tx)
+		scos := make(map[types.SiacoinOutputID]types.SiacoinOutput)
+		sfos := make(map[types.SiafundOutputID]types.SiafundOutput)
+		spentSC := make(map[types.SiacoinOutputID]struct{})
+		spentSF := make(map[types.SiafundOutputID]struct{})
+		for _, t := range txns {
+			if err := t.StandaloneValid(height + 1); err != nil {
+				return err
+			}
+			var scInputs types.Currency
+			for _, sci := range t.SiacoinInputs {
+				if _, spent := spentSC[sci.ParentID]; spent {
+					return errLightDoubleSpend
+				}
+				sco, ok := scos[sci.ParentID]
+				if !ok {
+					var err error
+					if sco, err = getSiacoinOutput(tx, sci.ParentID); err != nil {
+						return errMissingSiacoinOutput
+					}
+				}
+				if sci.UnlockConditions.UnlockHash() != sco.UnlockHash {
+					return errWrongUnlockConditions
+				}
+				spentSC[sci.ParentID] = struct{}{}
+				scInputs = scInputs.Add(sco.Value)
+				cc.SiacoinOutputDiffs = append(cc.SiacoinOutputDiffs, modules.SiacoinOutputDiff{
+					Direction:     modules.DiffRevert,
+					ID:            sci.ParentID,
+					SiacoinOutput: sco,
+				})
+			}
+			if !scInputs.Equals(t.SiacoinOutputSum()) {
+				return errSiacoinInputOutputMismatch
+			}
+			for i, sco := range t.SiacoinOutputs {
+				id := t.SiacoinOutputID(uint64(i))
+				scos[id] = sco
+				cc.SiacoinOutputDiffs = append(cc.SiacoinOutputDiffs, modules.SiacoinOutputDiff{
+					Direction:     modules.DiffApply,
+					ID:            id,
+					SiacoinOutput: sco,
+				})
+			}
+
+			var sfInputs, sfOutputs types.Currency
+			for _, sfi := range t.SiafundInputs {
+				if _, spent := spentSF[sfi.ParentID]; spent {
+					return errLightDoubleSpend
+				}
+				sfo, ok := sfos[sfi.ParentID]
+				if !ok {
+					data := tx.Bucket(SiafundOutputs).Get(sfi.ParentID[:])
+					if data == nil || encoding.Unmarshal(data, &sfo) != nil {
+						return errMissingSiafundOutput
+					}
+				}
+				if sfi.UnlockConditions.UnlockHash() != sfo.UnlockHash {
+					return errWrongUnlockConditions
+				}
+				spentSF[sfi.ParentID] = struct{}{}
+				sfInputs = sfInputs.Add(sfo.Value)
+				cc.SiafundOutputDiffs = append(cc.SiafundOutputDiffs, modules.SiafundOutputDiff{
+					Direction:     modules.DiffRevert,
+					ID:            sfi.ParentID,
+					SiafundOutput: sfo,
+				})
+			}
+			for i, sfo := range t.SiafundOutputs {
+				id := t.SiafundOutputID(uint64(i))
+				sfos[id] = sfo
+				sfOutputs = sfOutputs.Add(sfo.Value)
+				cc.SiafundOutputDiffs = append(cc.SiafundOutputDiffs, modules.SiafundOutputDiff{
+					Direction:     modules.DiffApply,
+					ID:            id,
+					SiafundOutput: sfo,
+				})
+			}
+			if !sfInputs.Equals(sfOutputs) {
+				return errSiafundInputOutputMismatch
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return modules.ConsensusChange{}, err
+	}
+	return cc, nil
+}
+
+// TryTransactionSet checks whether the transaction set only spends known
+// outputs and returns the diffs it would cause.
+func (cs *LightConsensusSet) TryTransactionSet(txns []types.Transaction) (modules.ConsensusChange, error) {
+	if err := cs.tg.Add(); err != nil {
+		return modules.ConsensusChange{}, err
+	}
+	defer cs.tg.Done()
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+	return cs.tryTransactionSet(txns)
+}
+
+// LockedTryTransactionSet calls fn while under read-lock, passing it a
+// version of TryTransactionSet that can be called under read-lock.
+func (cs *LightConsensusSet) LockedTryTransactionSet(fn func(func(txns []types.Transaction) (modules.ConsensusChange, error)) error) error {
+	if err := cs.tg.Add(); err != nil {
+		return err
+	}
+	defer cs.tg.Done()
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+	return fn(cs.tryTransactionSet)
+}
+
+// Alerts implements the Alerter interface for the light consensus set.
+func (cs *LightConsensusSet) Alerts() (crit, err, warn, info []modules.Alert) {
+	return
+}
+
+// AcceptBlock returns an error, light consensus sets don't accept blocks.
+func (cs *LightConsensusSet) AcceptBlock(types.Block) error {
+	return errLightUnsupported
+}
+
+// BlockAtHeight returns the filtered block at a given height.
+func (cs *LightConsensusSet) BlockAtHeight(height types.BlockHeight) (block types.Block, exists bool) {
+	_ = cs.db.View(func(tx *bolt.Tx) error {
+		id, err := getPath(tx, height)
+		if err != nil {
+			return err
+		}
+		lb, err := getLightBlock(tx, id)
+		if err != nil {
+			return err
+		}
+		block, exists = lb.block(), true
+		return nil
+	})
+	return block, exists
+}
+
+// BlockByID returns the filtered block of the block with the given id.
+func (cs *LightConsensusSet) BlockByID(id types.BlockID) (block types.Block, height types.BlockHeight, exists bool) {
+	_ = cs.db.View(func(tx *bolt.Tx) error {
+		lb, err := getLightBlock(tx, id)
+		if err != nil {
+			return err
+		}
+		block, height, exists = lb.block(), lb.Height, true
+		return nil
+	})
+	return block, height, exists
+}
+
+// ChildTarget returns false, light consensus sets don't track targets.
+func (cs *LightConsensusSet) ChildTarget(types.BlockID) (types.Target, bool) {
+	return types.Target{}, false
+}
+
+// Close safely closes the light consensus set.
+func (cs *LightConsensusSet) Close() error {
+	return cs.tg.Stop()
+}
+
+// CurrentBlock returns the filtered block at the tip of the current path.
+func (cs *LightConsensusSet) CurrentBlock() (block types.Block) {
+	_ = cs.db.View(func(tx *bolt.Tx) error {
+		lb, err := getLightBlock(tx, currentBlockID(tx))
+		block = lb.block()
+		return err
+	})
+	return block
+}
+
+// Height returns the height of the current path.
+func (cs *LightConsensusSet) Height() (height types.BlockHeight) {
+	_ = cs.db.View(func(tx *bolt.Tx) error {
+		height = blockHeight(tx)
+		return nil
+	})
+	return height
+}
+
+// Synced returns true once filtered blocks were received from a peer.
+func (cs *LightConsensusSet) Synced() bool {
+	if err := cs.tg.Add(); err != nil {
+		return false
+	}
+	defer cs.tg.Done()
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+	return cs.synced
+}
+
+// InCurrentPath returns true if the block is part of the current path.
+func (cs *LightConsensusSet) InCurrentPath(id types.BlockID) (inPath bool) {
+	_ = cs.db.View(func(tx *bolt.Tx) error {
+		_, err := getLightBlock(tx, id)
+		inPath = err == nil
+		return nil
+	})
+	return inPath
+}
+
+// MinimumValidChildTimestamp returns false, light consensus sets don't track
+// timestamps.
+func (cs *LightConsensusSet) MinimumValidChildTimestamp(types.BlockID) (types.Timestamp, bool) {
+	return 0, false
+}
+
+// StorageProofSegment returns an error, light consensus sets don't track
+// file contracts.
+func (cs *LightConsensusSet) StorageProofSegment(types.FileContractID) (uint64, error) {
+	return 0, errLightUnsupported
+}
+
+// FoundationUnlockHashes returns the initial Foundation UnlockHashes, light
+// consensus sets don't follow updates to them.
+func (cs *LightConsensusSet) FoundationUnlockHashes() (primary, failsafe types.UnlockHash) {
+	return types.InitialFoundationUnlockHash, types.InitialFoundationFailsafeUnlockHash
+}
+
+// ViewSiacoinOutput returns the unspent siacoin output with the given id.
+// Light consensus sets only know the outputs of watched addresses.
+func (cs *LightConsensusSet) ViewSiacoinOutput(id types.SiacoinOutputID) (sco types.SiacoinOutput, err error) {
+	_ = cs.db.View(func(tx *bolt.Tx) error {
+		sco, err = getSiacoinOutput(tx, id)
+		return nil
+	})
+	return
+}
+
+// ViewNFTCustody returns the custody of an NFT.
+func (cs *LightConsensusSet) ViewNFTCustody(nft types.NftCustody) (ret types.SiacoinOutput, err error) {
+	_ = cs.db.View(func(tx *bolt.Tx) error {
+		ret, err = viewNFTCustodyInternal(tx, nft)
+		return nil
+	})
+	return
+}
+
+// FindNFTsForAddress returns every NFT in custody of an address.
+func (cs *LightConsensusSet) FindNFTsForAddress(address types.UnlockHash) (nfts []types.NftCustody) {
+	_ = cs.db.View(func(tx *bolt.Tx) error {
+		nfts = findNFTsForAddressInternal(tx, address)
+		return nil
+	})
+	return
+}
+
+// FindNFTEditions returns the known editions of the data with the given
+// merkle root together with their owners.
+func (cs *LightConsensusSet) FindNFTEditions(root crypto.Hash) (ret []types.NftOwnershipStats) {
+	_ = cs.db.View(func(tx *bolt.Tx) error {
+		for _, nft := range findNFTEditionsInternal(tx, root) {
+			owner, _ := viewNFTCustodyInternal(tx, nft)
+			ret = append(ret, types.NftOwnershipStats{Nft: nft, Owner: owner.UnlockHash})
+		}
+		return nil
+	})
+	return
+}
+
+// ViewNFTTransferPolicy returns the transfer policy of an NFT.
+func (cs *LightConsensusSet) ViewNFTTransferPolicy(nft types.NftCustody) (policy types.NFTTransferPolicy) {
+	_ = cs.db.View(func(tx *bolt.Tx) error {
+		policy = viewNFTTransferPolicyInternal(tx, nft)
+		return nil
+	})
+	return
+}
+
+// ViewNFTLicense returns the license the mint of an NFT embedded.
+func (cs *LightConsensusSet) ViewNFTLicense(nft types.NftCustody) (license types.NFTLicense, err error) {
+	err = cs.db.View(func(tx *bolt.Tx) error {
+		license, err = viewNFTLicenseInternal(tx, nft)
+		return err
+	})
+	return
+}
+
+// ViewNFTStake returns an error, light consensus sets don't track stakes.
+func (cs *LightConsensusSet) ViewNFTStake(types.NftCustody) (types.NFTStake, error) {
+	return types.NFTStake{}, errLightUnsupported
+}
+
+// ViewNFTRootStake returns an empty stake, light consensus sets don't track
+// stakes.
+func (cs *LightConsensusSet) ViewNFTRootStake(crypto.Hash) types.NFTRootStake {
+	return types.NFTRootStake{}
+}
+
+// ViewNFTInsurance returns an error, light consensus sets don't track
+// insurances.
+func (cs *LightConsensusSet) ViewNFTInsurance(types.NftCustody) (types.NFTInsurance, error) {
+	return types.NFTInsurance{}, errLightUnsupported
+}
+
+// ViewNFTApproval returns an error, light consensus sets don't track
+// approvals.
+func (cs *LightConsensusSet) ViewNFTApproval(types.NftCustody) (types.NFTApproval, error) {
+	return types.NFTApproval{}, errLightUnsupported
+}
+
+// ViewNFTDispute returns an error, light consensus sets don't track disputes.
+func (cs *LightConsensusSet) ViewNFTDispute(types.NftCustody) (types.NFTDispute, error) {
+	return types.NFTDispute{}, errLightUnsupported
+}
+
+// ViewNFTRootFrozen returns false, light consensus sets don't track disputes.
+func (cs *LightConsensusSet) ViewNFTRootFrozen(crypto.Hash) bool {
+	return false
+}
+
+// NFTDisputes returns an error, light consensus sets don't track disputes.
+func (cs *LightConsensusSet) NFTDisputes() ([]types.NFTDispute, error) {
+	return nil, errLightUnsupported
+}
+
+// ViewNFTLockup returns an error, light consensus sets don't track lockups.
+func (cs *LightConsensusSet) ViewNFTLockup(types.NftCustody) (types.NFTLockup, error) {
+	return types.NFTLockup{}, errLightUnsupported
+}
+
+// ViewNFTStorageEarmark returns an error, light consensus sets don't track
+// storage pool earmarks.
+func (cs *LightConsensusSet) ViewNFTStorageEarmark(types.NftCustody) (types.NFTStorageEarmark, error) {
+	return types.NFTStorageEarmark{}, errLightUnsupported
+}
+
+// NFTStats returns empty statistics at the current height, light consensus
+// sets don't see every NFT.
+func (cs *LightConsensusSet) NFTStats() types.NFTStats {
+	return types.NFTStats{Height: cs.Height()}
+}
+
+// ViewNFTBridgeLock returns an error, light consensus sets don't track bridge
+// locks.
+func (cs *LightConsensusSet) ViewNFTBridgeLock(types.NftCustody) (types.NFTBridgeLock, error) {
+	return types.NFTBridgeLock{}, errLightUnsupported
+}
+
+// NFTIndexSnapshot returns an error, light consensus sets don't take
+// snapshots.
+func (cs *LightConsensusSet) NFTIndexSnapshot(types.BlockHeight) (modules.NFTIndexSnapshot, error) {
+	return modules.NFTIndexSnapshot{}, errNFTSnapshotUnknown
+}
+
+// AddNFTIndexCheckpoint returns an error, light consensus sets don't take
+// snapshots.
+func (cs *LightConsensusSet) AddNFTIndexCheckpoint(modules.NFTIndexCheckpoint) error {
+	return errNFTSnapshotUnknown
+}
@@ -0,0 +1,242 @@
+package consensus
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/gateway"
+	"go.sia.tech/siad/modules/transactionpool"
+	"go.sia.tech/siad/modules/wallet"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+// TestLightConsensusSet tests following the chain of a full node with a light
+// consensus set and spending from a wallet which uses it.
+func TestLightConsensusSet(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Create a light wallet and connect it to the full node.
+	testdir := build.TempDir(modules.ConsensusDir, t.Name()+"-light")
+	g, err := gateway.New("localhost:0", false, filepath.Join(testdir, modules.GatewayDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := g.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	lcs, err := NewLightConsensusSet(g, filepath.Join(testdir, modules.ConsensusDir), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := lcs.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	tp, err := transactionpool.New(lcs, g, filepath.Join(testdir, modules.TransactionPoolDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := wallet.New(lcs, tp, filepath.Join(testdir, modules.WalletDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := crypto.GenerateSiaKey(crypto.TypeDefaultWallet)
+	if _, err := w.Encrypt(key); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Unlock(key); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Connect(cst.gateway.Address()); err != nil {
+		t.Fatal(err)
+	}
+	synced := func() error {
+		if lcs.Height() != cst.cs.Height() {
+			return errors.New("light consensus set isn't synced")
+		}
+		return nil
+	}
+	if err := build.Retry(100, 100*time.Millisecond, synced); err != nil {
+		t.Fatal(err)
+	}
+	if !lcs.InCurrentPath(cst.cs.CurrentBlock().ID()) {
+		t.Fatal("light consensus set follows a different chain")
+	}
+
+	// Send coins to the light wallet. They arrive once the block is synced.
+	uc, err := w.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	amount := types.SiacoinPrecision.Mul64(1000)
+	if _, err := cst.wallet.SendSiacoins(amount, uc.UnlockHash()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cst.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		balance, _, _, err := w.ConfirmedBalance()
+		if err != nil {
+			return err
+		}
+		if !balance.Equals(amount) {
+			return errors.New("light wallet didn't receive the coins")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The filtered block only contains the transaction of the light wallet
+	// and proves it.
+	b := cst.cs.CurrentBlock()
+	pb, err := cst.cs.dbGetBlockMap(b.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	fb := filterBlock(pb, map[types.UnlockHash]struct{}{uc.UnlockHash(): {}})
+	if len(fb.Transactions) != 1 || len(fb.Transactions) == len(b.Transactions) {
+		t.Fatal("unexpected number of filtered transactions", len(fb.Transactions), len(b.Transactions))
+	}
+	if err := verifyFilteredBlock(fb); err != nil {
+		t.Fatal(err)
+	}
+	fb.Transactions[0].ArbitraryData = [][]byte{{1}}
+	if err := verifyFilteredBlock(fb); !errors.Contains(err, errFilteredBlockProof) {
+		t.Fatal("expected errFilteredBlockProof but got", err)
+	}
+
+	// The light wallet can spend the coins.
+	dest := types.UnlockHash{1}
+	if _, err := w.SendSiacoins(amount.Div64(2), dest); err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		if len(cst.tpool.TransactionList()) == 0 {
+			return errors.New("transaction wasn't relayed to the full node")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cst.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		balance, _, _, err := w.ConfirmedBalance()
+		if err != nil {
+			return err
+		}
+		if balance.Cmp(amount.Div64(2)) >= 0 {
+			return errors.New("light wallet didn't spend the coins")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestLightNFTIndexDiffs tests that reverting a filtered block restores every
+// bucket of the NFT index the block wrote to.
+func TestLightNFTIndexDiffs(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	testdir := build.TempDir(modules.ConsensusDir, t.Name())
+	if err := os.MkdirAll(testdir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	db, err := persist.OpenDatabase(lightDBMetadata, filepath.Join(testdir, LightDatabaseFilename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if err := db.Update(initLightDB); err != nil {
+		t.Fatal(err)
+	}
+
+	// Apply a filtered block which mints an NFT with an identity.
+	_, pk := crypto.GenerateKeyPair()
+	nft := types.NftCustody{
+		FileMerkleRoot: crypto.HashBytes([]byte(t.Name())),
+		Creator:        pk,
+		Collection:     "art",
+	}
+	nft.ID = types.DeriveNftID(nft.Creator, nft.Collection, nft.FileMerkleRoot, nft.Nonce)
+	mint := types.Transaction{
+		SiacoinOutputs: []types.SiacoinOutput{
+			{UnlockHash: types.NFTLockupUnlockConditions.UnlockHash(), Value: types.NFTLockupAmount},
+			{UnlockHash: types.NFTStoragePoolUnlockConditions.UnlockHash(), Value: types.NFTHostAmount},
+			{UnlockHash: nft.CreatorUnlockHash(), Value: types.OneBaseUnit},
+		},
+		ArbitraryData: [][]byte{types.NFTArbitraryData(types.NFTMintTag, nft)},
+	}
+	fb := modules.FilteredBlock{
+		Header:       types.BlockHeader{ParentID: types.GenesisID},
+		Height:       1,
+		Transactions: []types.Transaction{mint},
+	}
+	indexed := func(tx *bolt.Tx, bucket []byte) bool {
+		b := tx.Bucket(bucket)
+		return b != nil && b.Get(nftKey(nft)) != nil
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := applyLightBlock(tx, fb); err != nil {
+			return err
+		}
+		if !indexed(tx, NFTCustodyPool) || !indexed(tx, NFTIdentityPool) {
+			t.Error("mint wasn't indexed")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Reverting the block removes the custody and the identity of the NFT.
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := revertLightBlock(tx); err != nil {
+			return err
+		}
+		if indexed(tx, NFTCustodyPool) || indexed(tx, NFTIdentityPool) {
+			t.Error("reverted mint is still indexed")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
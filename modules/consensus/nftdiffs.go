@@ -0,0 +1,178 @@
+package consensus
+
+import (
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+)
+
+// nftdiffs.go contains the diffs of the NFT index. The NFT index is written by
+// applyTransaction directly instead of through the diffs of a block, so while
+// the diffs of a block are generated every write to the index records the
+// prior value of its entry in a journal. Once the block is applied, the journal
+// becomes the NFT index diffs of the block, which are stored by block id and
+// committed whenever the block is reverted or applied again, like the block's
+// other diffs. Every write to the NFT index, to its snapshots and to the
+// NFT index of the light consensus set goes through putNFTIndexEntry and
+// deleteNFTIndexEntry, so that no write escapes the journal.
+
+// nftIndexDiff is the change of an entry of the NFT index by a block.
+type nftIndexDiff struct {
+	Bucket []byte
+	Key    []byte
+
+	// PriorExists and Prior are the entry before the block, Exists and
+	// Value the entry after the block.
+	PriorExists bool
+	Prior       []byte
+	Exists      bool
+	Value       []byte
+}
+
+// openNFTIndexJournal starts journaling the writes to the NFT index.
+func openNFTIndexJournal(tx *bolt.Tx) error {
+	if err := tx.DeleteBucket(NFTIndexJournal); err != nil && !errors.Contains(err, bolt.ErrBucketNotFound) {
+		return err
+	}
+	_, err := tx.CreateBucket(NFTIndexJournal)
+	return err
+}
+
+// journalNFTIndexEntry records the value of an entry of the NFT index before
+// it is written for the first time by the block whose diffs are generated.
+func journalNFTIndexEntry(tx *bolt.Tx, bucket, key []byte) error {
+	j := tx.Bucket(NFTIndexJournal)
+	if j == nil {
+		return nil // no block is generating its diffs
+	}
+	jk := encoding.MarshalAll(bucket, key)
+	if j.Get(jk) != nil {
+		return nil
+	}
+	var prior []byte
+	if b := tx.Bucket(bucket); b != nil {
+		prior = b.Get(key)
+	}
+	return j.Put(jk, encoding.MarshalAll(prior != nil, prior))
+}
+
+// putNFTIndexEntry writes an entry of the NFT index, creating its bucket if
+// necessary.
+func putNFTIndexEntry(tx *bolt.Tx, bucket, key, value []byte) error {
+	if err := journalNFTIndexEntry(tx, bucket, key); err != nil {
+		return err
+	}
+	b, err := tx.CreateBucketIfNotExists(bucket)
+	if err != nil {
+		return err
+	}
+	return b.Put(key, value)
+}
+
+// deleteNFTIndexEntry deletes an entry of the NFT index.
+func deleteNFTIndexEntry(tx *bolt.Tx, bucket, key []byte) error {
+	b := tx.Bucket(bucket)
+	if b == nil {
+		return nil
+	}
+	if err := journalNFTIndexEntry(tx, bucket, key); err != nil {
+		return err
+	}
+	return b.Delete(key)
+}
+
+// closeNFTIndexJournal stops journaling and returns the NFT index diffs of the
+// journaled writes.
+func closeNFTIndexJournal(tx *bolt.Tx) ([]nftIndexDiff, error) {
+	j := tx.Bucket(NFTIndexJournal)
+	if j == nil {
+		return nil, nil
+	}
+	var diffs []nftIndexDiff
+	err := j.ForEach(func(k, v []byte) error {
+		var diff nftIndexDiff
+		if err := encoding.UnmarshalAll(k, &diff.Bucket, &diff.Key); err != nil {
+			return err
+		}
+		if err := encoding.UnmarshalAll(v, &diff.PriorExists, &diff.Prior); err != nil {
+			return err
+		}
+		if b := tx.Bucket(diff.Bucket); b != nil {
+			if value := b.Get(diff.Key); value != nil {
+				diff.Exists, diff.Value = true, append([]byte(nil), value...)
+			}
+		}
+		diffs = append(diffs, diff)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to read nft index journal")
+	}
+	if err := tx.DeleteBucket(NFTIndexJournal); err != nil {
+		return nil, err
+	}
+	return diffs, nil
+}
+
+// storeNFTIndexDiffs turns the journal into the NFT index diffs of the block
+// pb and stops journaling.
+func storeNFTIndexDiffs(tx *bolt.Tx, pb *processedBlock) error {
+	diffs, err := closeNFTIndexJournal(tx)
+	if err != nil {
+		return err
+	}
+	if len(diffs) == 0 {
+		return nil
+	}
+	b, err := tx.CreateBucketIfNotExists(NFTIndexDiffPool)
+	if err != nil {
+		return errors.AddContext(err, "unable to create nft index diff bucket")
+	}
+	id := pb.Block.ID()
+	return b.Put(id[:], encoding.Marshal(diffs))
+}
+
+// commitNFTIndexDiffs applies or reverts the NFT index diffs of a block.
+// Blocks which were applied before their NFT index diffs were stored have
+// none.
+func commitNFTIndexDiffs(tx *bolt.Tx, pb *processedBlock, dir modules.DiffDirection) {
+	b := tx.Bucket(NFTIndexDiffPool)
+	if b == nil {
+		return
+	}
+	id := pb.Block.ID()
+	v := b.Get(id[:])
+	if v == nil {
+		return
+	}
+	var diffs []nftIndexDiff
+	if err := encoding.Unmarshal(v, &diffs); err != nil {
+		manageErr(tx, errors.AddContext(err, "unable to decode nft index diffs"))
+		return
+	}
+	if err := applyNFTIndexDiffs(tx, diffs, dir); err != nil {
+		manageErr(tx, errors.AddContext(err, "unable to commit nft index diff"))
+	}
+}
+
+// applyNFTIndexDiffs applies or reverts a set of NFT index diffs.
+func applyNFTIndexDiffs(tx *bolt.Tx, diffs []nftIndexDiff, dir modules.DiffDirection) error {
+	for _, diff := range diffs {
+		exists, value := diff.Exists, diff.Value
+		if dir == modules.DiffRevert {
+			exists, value = diff.PriorExists, diff.Prior
+		}
+		var err error
+		if exists {
+			err = putNFTIndexEntry(tx, diff.Bucket, diff.Key, value)
+		} else {
+			err = deleteNFTIndexEntry(tx, diff.Bucket, diff.Key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
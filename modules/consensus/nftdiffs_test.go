@@ -0,0 +1,96 @@
+package consensus
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/bolt"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/types"
+)
+
+// TestNFTIndexDiffs tests that reverting a block restores the NFT index of its
+// parent and that applying the block again restores its NFT index.
+func TestNFTIndexDiffs(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	indexRoot := func() (root crypto.Hash) {
+		_ = cst.cs.db.View(func(tx *bolt.Tx) error {
+			root = takeNFTIndexSnapshot(tx, currentProcessedBlock(tx)).Root()
+			return nil
+		})
+		return
+	}
+	blockNode := func(id types.BlockID) (pb *processedBlock) {
+		err := cst.cs.db.View(func(tx *bolt.Tx) (err error) {
+			pb, err = getBlockMap(tx, id)
+			return
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	// Mint an NFT and transfer it in the next block.
+	parent := blockNode(cst.cs.CurrentBlock().ID())
+	before := indexRoot()
+	uc, err := cst.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	nft := types.NftCustody{FileMerkleRoot: crypto.HashBytes([]byte(t.Name()))}
+	if _, err := cst.wallet.MintNFT(nft, uc.UnlockHash()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cst.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	minted := indexRoot()
+	if _, err := cst.wallet.TransferNFT(nft, types.UnlockHash{1}); err != nil {
+		t.Fatal(err)
+	}
+	transferBlock, err := cst.miner.AddBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	transferred := indexRoot()
+	if before == minted || minted == transferred {
+		t.Fatal("blocks didn't change the nft index")
+	}
+
+	// Reverting both blocks restores the index of their parent.
+	cst.cs.dbRevertToNode(parent)
+	if indexRoot() != before {
+		t.Fatal("reverting didn't restore the nft index")
+	}
+	if _, err := cst.cs.ViewNFTCustody(nft); err == nil {
+		t.Fatal("reverted mint is still indexed")
+	}
+
+	// Applying them again restores the index of the transfer.
+	if _, _, err := cst.cs.dbForkBlockchain(blockNode(transferBlock.ID())); err != nil {
+		t.Fatal(err)
+	}
+	if indexRoot() != transferred {
+		t.Fatal("applying didn't restore the nft index")
+	}
+	custody, err := cst.cs.ViewNFTCustody(nft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if custody.UnlockHash != (types.UnlockHash{1}) {
+		t.Fatal("nft wasn't transferred", custody.UnlockHash)
+	}
+}
@@ -0,0 +1,185 @@
+package consensus
+
+import (
+	"math"
+
+	"gitlab.com/NebulousLabs/bolt"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/types"
+)
+
+// nftrules.go schedules NFT validation rules which are introduced after
+// launch. Every rule is activated at a fixed height instead of applying to the
+// whole chain, so that blocks which were valid before a rule existed remain
+// valid. Rules are soft forks: they only ever reject transactions which used to
+// be valid.
+
+// nftRule identifies an NFT validation rule with an activation height.
+type nftRule int
+
+const (
+	// nftRuleRejectDuplicateMint rejects the mint of an NFT which was
+	// minted before.
+	nftRuleRejectDuplicateMint nftRule = iota
+
+	// nftRuleBridge allows bridge locks and unlocks. Before it activates,
+	// transactions with the bridge tags are rejected.
+	nftRuleBridge
+
+	// nftRuleIdentifiers allows NFTVersion4 entries, which reference NFTs
+	// by their NftID. Before it activates, these entries are rejected.
+	nftRuleIdentifiers
+
+	// nftRuleEditions allows edition mints, which use NFTVersion5. Before
+	// it activates, these mints are rejected.
+	nftRuleEditions
+
+	// nftRuleTransferPolicy allows mints with a transfer policy, which use
+	// NFTVersion6. Before it activates, these mints are rejected.
+	nftRuleTransferPolicy
+
+	// nftRuleStaking allows stakes, unstakes and stake reward claims.
+	// Before it activates, transactions with the staking tags are rejected.
+	nftRuleStaking
+
+	// nftRuleContentReferences allows mints with a content reference, which
+	// use NFTVersion8. Before it activates, these mints are rejected.
+	nftRuleContentReferences
+
+	// nftRuleInsurance allows insurances, challenges, responses, claims and
+	// releases. Before it activates, transactions with the insurance tags
+	// are rejected.
+	nftRuleInsurance
+
+	// nftRuleApprovals allows operator approvals and transfers by approved
+	// operators. Before it activates, transactions with the approval tag are
+	// rejected.
+	nftRuleApprovals
+
+	// nftRuleLockupGovernance requires the authorization of the governance
+	// for spends of the lockup pool and liquidations which return the
+	// lockup. Its activation height is types.NFTGovernanceHeight, since
+	// wallets need it to build these transactions.
+	nftRuleLockupGovernance
+
+	// nftRuleDisputes allows the governance to freeze disputed NFTs. Before
+	// it activates, transactions with the dispute tags are rejected.
+	nftRuleDisputes
+
+	// nftRuleCompositeTransfers allows transfers which pay additional
+	// siacoin outputs after the colored coin, so that a sale and its
+	// payments settle in one transaction. Before it activates, transfers
+	// have exactly two outputs.
+	nftRuleCompositeTransfers
+
+	// nftRuleLicenses allows mints with a license, which use NFTVersion12.
+	// Before it activates, these mints are rejected.
+	nftRuleLicenses
+
+	// nftRuleLockupReclaim allows owners to reclaim the vested lockup of
+	// their NFTs. Before it activates, transactions with the reclaim tag are
+	// rejected.
+	nftRuleLockupReclaim
+
+	// nftRuleStrictArbitraryData rejects transactions with malformed or
+	// misplaced NFT arbitrary data, see types.ValidateNFTTransaction.
+	// Before it activates, such entries are ignored by the NFT index, apart
+	// from legacy mints, transfers and liquidations, which are recognized
+	// as leniently as they were before the rule.
+	nftRuleStrictArbitraryData
+)
+
+// nftRuleNotScheduled is the activation height of rules which are not yet
+// scheduled on a network.
+const nftRuleNotScheduled = types.BlockHeight(math.MaxUint64)
+
+// nftRuleActivationHeights contains the height of the first block which is
+// validated using a rule. Rules that are missing are never active.
+var nftRuleActivationHeights = map[nftRule]types.BlockHeight{
+	nftRuleRejectDuplicateMint: build.Select(build.Var{
+		Dev:      types.BlockHeight(100),
+		Standard: nftRuleNotScheduled,
+		Testing:  types.BlockHeight(0),
+	}).(types.BlockHeight),
+	nftRuleBridge: build.Select(build.Var{
+		Dev:      types.BlockHeight(100),
+		Standard: nftRuleNotScheduled,
+		Testing:  types.BlockHeight(0),
+	}).(types.BlockHeight),
+	nftRuleIdentifiers: build.Select(build.Var{
+		Dev:      types.BlockHeight(100),
+		Standard: nftRuleNotScheduled,
+		Testing:  types.BlockHeight(0),
+	}).(types.BlockHeight),
+	nftRuleEditions: build.Select(build.Var{
+		Dev:      types.BlockHeight(100),
+		Standard: nftRuleNotScheduled,
+		Testing:  types.BlockHeight(0),
+	}).(types.BlockHeight),
+	nftRuleTransferPolicy: build.Select(build.Var{
+		Dev:      types.BlockHeight(100),
+		Standard: nftRuleNotScheduled,
+		Testing:  types.BlockHeight(0),
+	}).(types.BlockHeight),
+	nftRuleStaking: build.Select(build.Var{
+		Dev:      types.BlockHeight(100),
+		Standard: nftRuleNotScheduled,
+		Testing:  types.BlockHeight(0),
+	}).(types.BlockHeight),
+	nftRuleContentReferences: build.Select(build.Var{
+		Dev:      types.BlockHeight(100),
+		Standard: nftRuleNotScheduled,
+		Testing:  types.BlockHeight(0),
+	}).(types.BlockHeight),
+	nftRuleInsurance: build.Select(build.Var{
+		Dev:      types.BlockHeight(100),
+		Standard: nftRuleNotScheduled,
+		Testing:  types.BlockHeight(0),
+	}).(types.BlockHeight),
+	nftRuleApprovals: build.Select(build.Var{
+		Dev:      types.BlockHeight(100),
+		Standard: nftRuleNotScheduled,
+		Testing:  types.BlockHeight(0),
+	}).(types.BlockHeight),
+	nftRuleLockupGovernance: types.NFTGovernanceHeight,
+	nftRuleDisputes: build.Select(build.Var{
+		Dev:      types.BlockHeight(100),
+		Standard: nftRuleNotScheduled,
+		Testing:  types.BlockHeight(0),
+	}).(types.BlockHeight),
+	nftRuleCompositeTransfers: build.Select(build.Var{
+		Dev:      types.BlockHeight(100),
+		Standard: nftRuleNotScheduled,
+		Testing:  types.BlockHeight(0),
+	}).(types.BlockHeight),
+	nftRuleLicenses: build.Select(build.Var{
+		Dev:      types.BlockHeight(100),
+		Standard: nftRuleNotScheduled,
+		Testing:  types.BlockHeight(0),
+	}).(types.BlockHeight),
+	nftRuleLockupReclaim: build.Select(build.Var{
+		Dev:      types.BlockHeight(100),
+		Standard: nftRuleNotScheduled,
+		Testing:  types.BlockHeight(0),
+	}).(types.BlockHeight),
+	nftRuleStrictArbitraryData: build.Select(build.Var{
+		Dev:      types.BlockHeight(100),
+		Standard: nftRuleNotScheduled,
+		Testing:  types.BlockHeight(0),
+	}).(types.BlockHeight),
+}
+
+// nftRuleActive returns true if a rule applies to the block at the given
+// height.
+func nftRuleActive(rule nftRule, height types.BlockHeight) bool {
+	activation, ok := nftRuleActivationHeights[rule]
+	return ok && activation != nftRuleNotScheduled && height >= activation
+}
+
+// nftRuleActiveInternal returns true if a rule applies to transactions which
+// are validated against the current consensus state. These transactions go
+// into the block following the current block.
+func nftRuleActiveInternal(tx *bolt.Tx, rule nftRule) bool {
+	return nftRuleActive(rule, blockHeight(tx)+1)
+}
@@ -0,0 +1,197 @@
+package consensus
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// setNFTRuleActivationHeight changes the activation height of a rule for the
+// duration of a test. Tests using it can't run in parallel.
+func setNFTRuleActivationHeight(t *testing.T, rule nftRule, height types.BlockHeight) {
+	old, ok := nftRuleActivationHeights[rule]
+	nftRuleActivationHeights[rule] = height
+	t.Cleanup(func() {
+		if ok {
+			nftRuleActivationHeights[rule] = old
+		} else {
+			delete(nftRuleActivationHeights, rule)
+		}
+	})
+}
+
+// TestNFTRuleActive is a unit test for nftRuleActive.
+func TestNFTRuleActive(t *testing.T) {
+	const rule = nftRuleRejectDuplicateMint
+	setNFTRuleActivationHeight(t, rule, 10)
+	if nftRuleActive(rule, 9) {
+		t.Fatal("rule shouldn't be active before its activation height")
+	}
+	if !nftRuleActive(rule, 10) || !nftRuleActive(rule, 11) {
+		t.Fatal("rule should be active from its activation height")
+	}
+	if nftRuleActive(nftRule(-1), 10) {
+		t.Fatal("unknown rule shouldn't be active")
+	}
+	setNFTRuleActivationHeight(t, rule, nftRuleNotScheduled)
+	if nftRuleActive(rule, nftRuleNotScheduled) {
+		t.Fatal("unscheduled rule shouldn't be active")
+	}
+}
+
+// TestNFTRuleRejectDuplicateMint tests that a second mint of the same NFT is
+// only rejected once the rule is active.
+func TestNFTRuleRejectDuplicateMint(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	nft := types.NftCustody{FileMerkleRoot: crypto.HashBytes([]byte(t.Name()))}
+	mint := types.Transaction{
+		SiacoinOutputs: []types.SiacoinOutput{
+			{UnlockHash: types.NFTLockupUnlockConditions.UnlockHash(), Value: types.NFTLockupAmount},
+			{UnlockHash: types.NFTStoragePoolUnlockConditions.UnlockHash(), Value: types.NFTHostAmount},
+			{UnlockHash: types.UnlockHash{1}, Value: types.OneBaseUnit},
+		},
+		ArbitraryData: [][]byte{types.NFTArbitraryData(types.NFTMintTag, nft)},
+	}
+	validMint := func() (err error) {
+		_ = cst.cs.db.View(func(tx *bolt.Tx) error {
+			err = validNFTCustody(tx, mint)
+			return nil
+		})
+		return
+	}
+
+	// The first mint is valid regardless of the rule.
+	height := cst.cs.Height()
+	setNFTRuleActivationHeight(t, nftRuleIdentifiers, nftRuleNotScheduled)
+	setNFTRuleActivationHeight(t, nftRuleRejectDuplicateMint, height+1)
+	if err := validMint(); err != nil {
+		t.Fatal(err)
+	}
+	err = cst.cs.db.Update(func(tx *bolt.Tx) error {
+		applyArbitraryData(tx, &processedBlock{Height: height + 1}, mint)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Before the rule activates, minting the NFT again is valid.
+	setNFTRuleActivationHeight(t, nftRuleRejectDuplicateMint, height+2)
+	if err := validMint(); err != nil {
+		t.Fatal("duplicate mint should be valid before activation", err)
+	}
+
+	// Once it is active, the duplicate mint is rejected.
+	setNFTRuleActivationHeight(t, nftRuleRejectDuplicateMint, height+1)
+	if err := validMint(); !errors.Contains(err, errDuplicateNFTMint) {
+		t.Fatal("expected errDuplicateNFTMint but got", err)
+	}
+
+	// Duplicate mints are also rejected once identifiers are active.
+	setNFTRuleActivationHeight(t, nftRuleRejectDuplicateMint, nftRuleNotScheduled)
+	setNFTRuleActivationHeight(t, nftRuleIdentifiers, height+1)
+	if err := validMint(); !errors.Contains(err, errDuplicateNFTMint) {
+		t.Fatal("expected errDuplicateNFTMint but got", err)
+	}
+}
+
+// TestNFTRuleStrictArbitraryDataSync tests that a chain with a legacy mint
+// whose NFT arbitrary data has trailing bytes, which was valid before the
+// strict NFT data rule, can still be synced and indexes the mint like before.
+func TestNFTRuleStrictArbitraryDataSync(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	cst1, err := createConsensusSetTester(t.Name() + "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst1.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	cst2, err := blankConsensusSetTester(t.Name()+"2", modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst2.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	setNFTRuleActivationHeight(t, nftRuleStrictArbitraryData, nftRuleNotScheduled)
+
+	// Mine a block with the mint. The transaction pool refuses malformed NFT
+	// data, so the mint is added to the block directly.
+	root := crypto.HashBytes([]byte(t.Name()))
+	arb := append(append(append(types.PrefixNFTCustody[:], types.NFTMintTag...), root.String()...), "trailing"...)
+	owner := types.UnlockHash{1}
+	builder, err := cst1.wallet.StartTransaction()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := builder.FundSiacoins(types.NFTMintCost.Add(types.OneBaseUnit)); err != nil {
+		t.Fatal(err)
+	}
+	builder.AddArbitraryData(arb)
+	builder.AddSiacoinOutput(types.SiacoinOutput{UnlockHash: types.NFTLockupUnlockConditions.UnlockHash(), Value: types.NFTLockupAmount})
+	builder.AddSiacoinOutput(types.SiacoinOutput{UnlockHash: types.NFTStoragePoolUnlockConditions.UnlockHash(), Value: types.NFTHostAmount})
+	builder.AddSiacoinOutput(types.SiacoinOutput{UnlockHash: owner, Value: types.OneBaseUnit})
+	txnSet, err := builder.Sign(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, target, err := cst1.miner.BlockForWork()
+	if err != nil {
+		t.Fatal(err)
+	}
+	block.Transactions = append(block.Transactions, txnSet...)
+	block, _ = cst1.miner.SolveBlock(block, target)
+	if err := cst1.cs.AcceptBlock(block); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cst1.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The mint is indexed with the zero merkle root, as it was before the
+	// rule.
+	custody, err := cst1.cs.ViewNFTCustody(types.NftCustody{})
+	if err != nil || custody.UnlockHash != owner {
+		t.Fatal("legacy mint wasn't indexed", custody.UnlockHash, err)
+	}
+
+	// A new node syncs the chain and indexes the mint as well.
+	if err := cst2.gateway.Connect(cst1.gateway.Address()); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 50 && cst1.cs.dbCurrentBlockID() != cst2.cs.dbCurrentBlockID(); i++ {
+		time.Sleep(250 * time.Millisecond)
+	}
+	if cst1.cs.dbCurrentBlockID() != cst2.cs.dbCurrentBlockID() {
+		t.Fatal("chain with the legacy mint wasn't synced")
+	}
+	custody, err = cst2.cs.ViewNFTCustody(types.NftCustody{})
+	if err != nil || custody.UnlockHash != owner {
+		t.Fatal("synced legacy mint wasn't indexed", custody.UnlockHash, err)
+	}
+}
@@ -0,0 +1,614 @@
+package consensus
+
+import (
+	"bytes"
+	"sort"
+	"time"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// nftsnapshot.go contains snapshots of the NFT index, which let nodes that
+// don't need the NFT history, e.g. API servers, bootstrap their NFT index
+// instead of building it from the whole blockchain. Every node takes a
+// snapshot of its NFT index every nftSnapshotInterval blocks. Checkpoints
+// commit to the id of the block at their height and the root of the snapshot
+// taken at that height. They are signed by the NFT governance and added to a
+// node through the API, after which the node serves the checkpoint, the
+// headers of the blocks up to it and its snapshot to its peers.
+//
+// A bootstrapping node downloads these during the initial blockchain download
+// and verifies the signatures of the checkpoint, that the headers link the
+// genesis block to the checkpoint's block and that the snapshot matches the
+// checkpoint. Since every block id commits to the parent id and the
+// transactions of its block, the headers pin the blocks up to the checkpoint.
+// While bootstrapping, only these blocks are accepted, and they are applied
+// without checking the NFT rules of their transactions against the NFT index,
+// which isn't built, and without updating it. Everything else, e.g.
+// signatures, siacoins and file contracts, is validated as usual. Once the block of the checkpoint is
+// applied, the snapshot replaces the NFT index through the NFT index journal,
+// so that reverting the block restores the pending snapshot, and the
+// following blocks are validated and indexed as usual.
+
+var (
+	// errNFTSnapshotCheckpoint is returned when a snapshot doesn't match the
+	// checkpoint it was requested for.
+	errNFTSnapshotCheckpoint = errors.New("nft index snapshot doesn't match its checkpoint")
+
+	// errNFTSnapshotUnknown is returned when requesting a snapshot which
+	// wasn't taken or was already pruned.
+	errNFTSnapshotUnknown = errors.New("no nft index snapshot is stored for this height")
+
+	// errNFTCheckpointBlock is returned by a bootstrapping node for blocks
+	// which the checkpoint of its pending snapshot doesn't commit to, and
+	// when adding a checkpoint for a block which isn't in the current path.
+	errNFTCheckpointBlock = errors.New("block doesn't match the nft snapshot checkpoint at its height")
+
+	// errNFTCheckpointSignatures is returned when a checkpoint isn't signed
+	// by enough keys of the NFT governance.
+	errNFTCheckpointSignatures = errors.New("nft snapshot checkpoint isn't signed by the nft governance")
+
+	// errNFTCheckpointHeaders is returned when the headers sent with a
+	// snapshot don't link the genesis block to the block of its checkpoint.
+	errNFTCheckpointHeaders = errors.New("headers don't commit to the block of the nft snapshot checkpoint")
+
+	// errNFTIndexBootstrapping is returned when validating transactions
+	// while the NFT index is bootstrapped from a snapshot.
+	errNFTIndexBootstrapping = errors.New("transactions can't be validated while the nft index is bootstrapped")
+)
+
+var (
+	// nftSnapshotInterval is the number of blocks between two snapshots of
+	// the NFT index. Checkpoints are placed at multiples of the interval.
+	nftSnapshotInterval = build.Select(build.Var{
+		Dev:      types.BlockHeight(50),
+		Standard: types.BlockHeight(4320),
+		Testing:  types.BlockHeight(5),
+	}).(types.BlockHeight)
+
+	// nftSnapshotSizeLimit is the maximum size of a snapshot downloaded
+	// from a peer.
+	nftSnapshotSizeLimit = uint64(1 << 30)
+
+	// nftCheckpointSizeLimit is the maximum size of a checkpoint downloaded
+	// from a peer.
+	nftCheckpointSizeLimit = uint64(1 << 16)
+
+	// sendNFTSnapshotTimeout is the timeout for the SendNFTSnapshot RPC.
+	sendNFTSnapshotTimeout = build.Select(build.Var{
+		Standard: 10 * time.Minute,
+		Dev:      2 * time.Minute,
+		Testing:  5 * time.Second,
+	}).(time.Duration)
+
+	// nftIndexBuckets are the buckets which make up the NFT index. Besides
+	// these buckets a snapshot contains the NFT statistics of its block.
+	nftIndexBuckets = [][]byte{
+		NFTCustodyPool,
+		NFTContentPool,
+		NFTLockupPool,
+		NFTBridgePool,
+		NFTIdentityPool,
+		NFTEditionPool,
+		NFTPolicyPool,
+		NFTLicensePool,
+		NFTStakePool,
+		NFTRootStakePool,
+		NFTInsurancePool,
+		NFTApprovalPool,
+		NFTStorageEarmarkPool,
+		NFTDisputePool,
+		NFTRootDisputePool,
+	}
+)
+
+// getNFTIndexCheckpoint returns the latest checkpoint whose snapshot the node
+// serves. The second return value is false if there is no checkpoint.
+func getNFTIndexCheckpoint(tx *bolt.Tx) (cp modules.NFTIndexCheckpoint, ok bool) {
+	b := tx.Bucket(NFTSnapshotPool)
+	if b == nil {
+		return modules.NFTIndexCheckpoint{}, false
+	}
+	v := b.Get(FieldNFTSnapshotCheckpoint)
+	if v == nil || encoding.Unmarshal(v, &cp) != nil {
+		return modules.NFTIndexCheckpoint{}, false
+	}
+	return cp, true
+}
+
+// isNFTSnapshotCheckpoint returns true if the latest checkpoint is at the
+// given height.
+func isNFTSnapshotCheckpoint(tx *bolt.Tx, height types.BlockHeight) bool {
+	cp, ok := getNFTIndexCheckpoint(tx)
+	return ok && cp.Height == height
+}
+
+// verifyNFTIndexCheckpoint checks that a checkpoint is signed by at least as
+// many distinct NFT governance keys as the governance unlock conditions
+// require.
+func verifyNFTIndexCheckpoint(cp modules.NFTIndexCheckpoint) error {
+	governance := types.NFTGovernanceUnlockConditions
+	if len(governance.PublicKeys) == 0 {
+		return errors.AddContext(errNFTCheckpointSignatures, "there are no governance keys")
+	}
+	sigHash := cp.SigHash()
+	signed := make(map[uint64]struct{})
+	for _, sig := range cp.Signatures {
+		if sig.PublicKeyIndex >= uint64(len(governance.PublicKeys)) {
+			return errors.AddContext(errNFTCheckpointSignatures, "public key index is out of range")
+		}
+		if _, ok := signed[sig.PublicKeyIndex]; ok {
+			return errors.AddContext(errNFTCheckpointSignatures, "public key signed twice")
+		}
+		spk := governance.PublicKeys[sig.PublicKeyIndex]
+		if spk.Algorithm != types.SignatureEd25519 || len(spk.Key) != crypto.PublicKeySize {
+			return errors.AddContext(errNFTCheckpointSignatures, "unsupported public key")
+		}
+		var pk crypto.PublicKey
+		copy(pk[:], spk.Key)
+		if err := crypto.VerifyHash(sigHash, pk, sig.Signature); err != nil {
+			return errors.Compose(errNFTCheckpointSignatures, err)
+		}
+		signed[sig.PublicKeyIndex] = struct{}{}
+	}
+	if uint64(len(signed)) < governance.SignaturesRequired {
+		return errors.AddContext(errNFTCheckpointSignatures, "not enough signatures")
+	}
+	return nil
+}
+
+// verifyNFTCheckpointHeaders checks that the headers of the blocks from height
+// 1 up to the height of a checkpoint link the genesis block to the block of
+// the checkpoint.
+func verifyNFTCheckpointHeaders(headers []types.BlockHeader, cp modules.NFTIndexCheckpoint) error {
+	if types.BlockHeight(len(headers)) != cp.Height {
+		return errors.AddContext(errNFTCheckpointHeaders, "wrong number of headers")
+	}
+	parentID := types.GenesisID
+	for _, h := range headers {
+		if h.ParentID != parentID {
+			return errors.AddContext(errNFTCheckpointHeaders, "headers are not linked")
+		}
+		parentID = h.ID()
+	}
+	if parentID != cp.BlockID {
+		return errors.AddContext(errNFTCheckpointHeaders, "last header isn't the checkpoint's block")
+	}
+	return nil
+}
+
+// verifyNFTIndexSnapshot checks that a snapshot matches its checkpoint. The
+// entries of the snapshot have to be ordered, so that every checkpoint
+// matches exactly one snapshot, and only contain keys of the NFT index.
+func verifyNFTIndexSnapshot(s modules.NFTIndexSnapshot, cp modules.NFTIndexCheckpoint) error {
+	if s.Height != cp.Height || s.BlockID != cp.BlockID {
+		return errors.AddContext(errNFTSnapshotCheckpoint, "snapshot was taken at a different block")
+	}
+	for i, entry := range s.Entries {
+		if i > 0 && compareNFTSnapshotEntries(s.Entries[i-1], entry) >= 0 {
+			return errors.AddContext(errNFTSnapshotCheckpoint, "entries are not ordered")
+		}
+		if !isNFTIndexBucket(entry.Bucket) && !(bytes.Equal(entry.Bucket, NFTStatsPool) && bytes.Equal(entry.Key, s.BlockID[:])) {
+			return errors.AddContext(errNFTSnapshotCheckpoint, "entry doesn't belong to the nft index")
+		}
+	}
+	if s.Root() != cp.Root {
+		return errors.AddContext(errNFTSnapshotCheckpoint, "snapshot root doesn't match")
+	}
+	return nil
+}
+
+// isNFTIndexBucket returns true if the bucket is one of nftIndexBuckets.
+func isNFTIndexBucket(bucket []byte) bool {
+	for _, b := range nftIndexBuckets {
+		if bytes.Equal(b, bucket) {
+			return true
+		}
+	}
+	return false
+}
+
+// compareNFTSnapshotEntries orders snapshot entries by bucket and key.
+func compareNFTSnapshotEntries(a, b modules.NFTIndexSnapshotEntry) int {
+	if c := bytes.Compare(a.Bucket, b.Bucket); c != 0 {
+		return c
+	}
+	return bytes.Compare(a.Key, b.Key)
+}
+
+// takeNFTIndexSnapshot returns a snapshot of the NFT index after the block pb
+// was applied. The NFT statistics of the block need to be stored.
+func takeNFTIndexSnapshot(tx *bolt.Tx, pb *processedBlock) modules.NFTIndexSnapshot {
+	id := pb.Block.ID()
+	s := modules.NFTIndexSnapshot{
+		Height:  pb.Height,
+		BlockID: id,
+	}
+	copyBytes := func(b []byte) []byte { return append([]byte(nil), b...) }
+	for _, bucket := range nftIndexBuckets {
+		b := tx.Bucket(bucket)
+		if b == nil {
+			continue
+		}
+		_ = b.ForEach(func(k, v []byte) error {
+			s.Entries = append(s.Entries, modules.NFTIndexSnapshotEntry{
+				Bucket: copyBytes(bucket),
+				Key:    copyBytes(k),
+				Value:  copyBytes(v),
+			})
+			return nil
+		})
+	}
+	if b := tx.Bucket(NFTStatsPool); b != nil {
+		if v := b.Get(id[:]); v != nil {
+			s.Entries = append(s.Entries, modules.NFTIndexSnapshotEntry{
+				Bucket: copyBytes(NFTStatsPool),
+				Key:    copyBytes(id[:]),
+				Value:  copyBytes(v),
+			})
+		}
+	}
+	sort.Slice(s.Entries, func(i, j int) bool {
+		return compareNFTSnapshotEntries(s.Entries[i], s.Entries[j]) < 0
+	})
+	return s
+}
+
+// storeNFTIndexSnapshot takes a snapshot of the NFT index if the block pb is
+// at a multiple of nftSnapshotInterval. Only the latest snapshot and the
+// snapshot of the latest checkpoint are kept. The writes are journaled, so
+// that reverting the block restores the snapshot it pruned.
+func storeNFTIndexSnapshot(tx *bolt.Tx, pb *processedBlock) error {
+	if pb.Height == 0 || pb.Height%nftSnapshotInterval != 0 {
+		return nil
+	}
+	b := tx.Bucket(NFTSnapshotPool)
+	if b == nil {
+		return putNFTIndexEntry(tx, NFTSnapshotPool, encoding.Marshal(pb.Height), encoding.Marshal(takeNFTIndexSnapshot(tx, pb)))
+	}
+	var pruned [][]byte
+	err := b.ForEach(func(k, _ []byte) error {
+		var height types.BlockHeight
+		if len(k) != 8 || encoding.Unmarshal(k, &height) != nil {
+			return nil // not a snapshot
+		}
+		if !isNFTSnapshotCheckpoint(tx, height) {
+			pruned = append(pruned, append([]byte(nil), k...))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, k := range pruned {
+		if err := deleteNFTIndexEntry(tx, NFTSnapshotPool, k); err != nil {
+			return err
+		}
+	}
+	return putNFTIndexEntry(tx, NFTSnapshotPool, encoding.Marshal(pb.Height), encoding.Marshal(takeNFTIndexSnapshot(tx, pb)))
+}
+
+// getNFTIndexSnapshot returns the snapshot taken at the given height.
+func getNFTIndexSnapshot(tx *bolt.Tx, height types.BlockHeight) (s modules.NFTIndexSnapshot, err error) {
+	b := tx.Bucket(NFTSnapshotPool)
+	if b == nil {
+		return modules.NFTIndexSnapshot{}, errNFTSnapshotUnknown
+	}
+	v := b.Get(encoding.Marshal(height))
+	if v == nil {
+		return modules.NFTIndexSnapshot{}, errNFTSnapshotUnknown
+	}
+	err = encoding.Unmarshal(v, &s)
+	return
+}
+
+// pendingNFTSnapshotHeight returns the height of the pending snapshot of a
+// bootstrapping node. The second return value is false if there is no pending
+// snapshot.
+func pendingNFTSnapshotHeight(tx *bolt.Tx) (height types.BlockHeight, ok bool) {
+	b := tx.Bucket(NFTSnapshotPool)
+	if b == nil {
+		return 0, false
+	}
+	v := b.Get(FieldNFTSnapshotPendingHeight)
+	if v == nil || encoding.Unmarshal(v, &height) != nil {
+		return 0, false
+	}
+	return height, true
+}
+
+// nftIndexBootstrapping returns true if the next block is applied without
+// validating its transactions and without updating the NFT index, because it
+// is at or below the height of the pending snapshot.
+func nftIndexBootstrapping(tx *bolt.Tx) bool {
+	height, ok := pendingNFTSnapshotHeight(tx)
+	return ok && blockHeight(tx) < height
+}
+
+// nftCheckpointPathContains returns true if the checkpoint of the pending
+// snapshot commits to the block pb.
+func nftCheckpointPathContains(tx *bolt.Tx, pb *processedBlock) bool {
+	b := tx.Bucket(NFTCheckpointPath)
+	if b == nil {
+		return false
+	}
+	id := pb.Block.ID()
+	return bytes.Equal(b.Get(encoding.Marshal(pb.Height)), id[:])
+}
+
+// setPendingNFTSnapshot stores the snapshot a bootstrapping node installs once
+// the block at the snapshot's height is applied, together with its checkpoint
+// and the ids of the blocks the checkpoint commits to. The checkpoint, the
+// headers and the snapshot need to be verified by the caller.
+func setPendingNFTSnapshot(tx *bolt.Tx, cp modules.NFTIndexCheckpoint, headers []types.BlockHeader, s modules.NFTIndexSnapshot) error {
+	if err := tx.DeleteBucket(NFTCheckpointPath); err != nil && !errors.Contains(err, bolt.ErrBucketNotFound) {
+		return err
+	}
+	path, err := tx.CreateBucket(NFTCheckpointPath)
+	if err != nil {
+		return errors.AddContext(err, "unable to create nft checkpoint path bucket")
+	}
+	for i, h := range headers {
+		id := h.ID()
+		if err := path.Put(encoding.Marshal(types.BlockHeight(i+1)), id[:]); err != nil {
+			return err
+		}
+	}
+	b, err := tx.CreateBucketIfNotExists(NFTSnapshotPool)
+	if err != nil {
+		return errors.AddContext(err, "unable to create nft snapshot bucket")
+	}
+	err = b.Put(FieldNFTSnapshotPending, encoding.Marshal(s))
+	if err != nil {
+		return err
+	}
+	err = b.Put(FieldNFTSnapshotPendingCheckpoint, encoding.Marshal(cp))
+	if err != nil {
+		return err
+	}
+	return b.Put(FieldNFTSnapshotPendingHeight, encoding.Marshal(s.Height))
+}
+
+// installNFTIndexSnapshot replaces the NFT index with the pending snapshot
+// once the block at the snapshot's height was applied. The writes go through
+// the NFT index journal, so that reverting the block restores the previous
+// NFT index and the pending snapshot.
+func installNFTIndexSnapshot(tx *bolt.Tx, pb *processedBlock) error {
+	height, ok := pendingNFTSnapshotHeight(tx)
+	if !ok || pb.Height != height {
+		return nil
+	}
+	b := tx.Bucket(NFTSnapshotPool)
+	var s modules.NFTIndexSnapshot
+	if err := encoding.Unmarshal(b.Get(FieldNFTSnapshotPending), &s); err != nil {
+		return errors.AddContext(err, "unable to decode pending nft snapshot")
+	}
+	if pb.Block.ID() != s.BlockID {
+		return errNFTCheckpointBlock
+	}
+	cp := append([]byte(nil), b.Get(FieldNFTSnapshotPendingCheckpoint)...)
+
+	// Replace the NFT index.
+	for _, bucket := range nftIndexBuckets {
+		var keys [][]byte
+		if ib := tx.Bucket(bucket); ib != nil {
+			_ = ib.ForEach(func(k, _ []byte) error {
+				keys = append(keys, append([]byte(nil), k...))
+				return nil
+			})
+		}
+		for _, k := range keys {
+			if err := deleteNFTIndexEntry(tx, bucket, k); err != nil {
+				return err
+			}
+		}
+	}
+	for _, entry := range s.Entries {
+		if err := putNFTIndexEntry(tx, entry.Bucket, entry.Key, entry.Value); err != nil {
+			return err
+		}
+	}
+
+	// Keep the snapshot and its checkpoint, so that they can be served to
+	// other nodes, and stop bootstrapping.
+	if err := putNFTIndexEntry(tx, NFTSnapshotPool, encoding.Marshal(s.Height), encoding.Marshal(s)); err != nil {
+		return err
+	}
+	if len(cp) > 0 {
+		if err := putNFTIndexEntry(tx, NFTSnapshotPool, FieldNFTSnapshotCheckpoint, cp); err != nil {
+			return err
+		}
+	}
+	for _, field := range [][]byte{FieldNFTSnapshotPending, FieldNFTSnapshotPendingCheckpoint, FieldNFTSnapshotPendingHeight} {
+		if err := deleteNFTIndexEntry(tx, NFTSnapshotPool, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NFTIndexSnapshot returns the snapshot of the NFT index which was taken at the
+// given height.
+func (cs *ConsensusSet) NFTIndexSnapshot(height types.BlockHeight) (s modules.NFTIndexSnapshot, err error) {
+	if err := cs.tg.Add(); err != nil {
+		return modules.NFTIndexSnapshot{}, err
+	}
+	defer cs.tg.Done()
+	err = cs.db.View(func(tx *bolt.Tx) error {
+		s, err = getNFTIndexSnapshot(tx, height)
+		return err
+	})
+	return
+}
+
+// AddNFTIndexCheckpoint stores a checkpoint which is signed by the NFT
+// governance and commits to a snapshot of the consensus set. The snapshot has
+// to be stored, so checkpoints need to be added before the next snapshot is
+// taken. Checkpoints below the latest checkpoint are ignored.
+func (cs *ConsensusSet) AddNFTIndexCheckpoint(cp modules.NFTIndexCheckpoint) error {
+	if err := cs.tg.Add(); err != nil {
+		return err
+	}
+	defer cs.tg.Done()
+	if err := verifyNFTIndexCheckpoint(cp); err != nil {
+		return err
+	}
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	return cs.db.Update(func(tx *bolt.Tx) error {
+		if latest, ok := getNFTIndexCheckpoint(tx); ok && latest.Height > cp.Height {
+			return nil
+		}
+		if id, err := getPath(tx, cp.Height); err != nil || id != cp.BlockID {
+			return errNFTCheckpointBlock
+		}
+		s, err := getNFTIndexSnapshot(tx, cp.Height)
+		if err != nil {
+			return err
+		}
+		if err := verifyNFTIndexSnapshot(s, cp); err != nil {
+			return err
+		}
+		return tx.Bucket(NFTSnapshotPool).Put(FieldNFTSnapshotCheckpoint, encoding.Marshal(cp))
+	})
+}
+
+// rpcSendNFTSnapshot is an RPC that sends the latest checkpoint, the headers
+// of the blocks up to the checkpoint and the snapshot of the NFT index the
+// checkpoint commits to to the requesting peer.
+func (cs *ConsensusSet) rpcSendNFTSnapshot(conn modules.PeerConn) error {
+	err := conn.SetDeadline(time.Now().Add(sendNFTSnapshotTimeout))
+	if err != nil {
+		return err
+	}
+	finishedChan := make(chan struct{})
+	defer close(finishedChan)
+	go func() {
+		select {
+		case <-cs.tg.StopChan():
+		case <-finishedChan:
+		}
+		conn.Close()
+	}()
+	err = cs.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer cs.tg.Done()
+
+	// Lookup the checkpoint, the headers and the snapshot.
+	var cp modules.NFTIndexCheckpoint
+	var headers []types.BlockHeader
+	var s modules.NFTIndexSnapshot
+	cs.mu.RLock()
+	err = cs.db.View(func(tx *bolt.Tx) error {
+		var ok bool
+		cp, ok = getNFTIndexCheckpoint(tx)
+		if !ok {
+			return errNFTSnapshotUnknown
+		}
+		s, err = getNFTIndexSnapshot(tx, cp.Height)
+		if err != nil {
+			return err
+		}
+		headers = make([]types.BlockHeader, 0, cp.Height)
+		for height := types.BlockHeight(1); height <= cp.Height; height++ {
+			id, err := getPath(tx, height)
+			if err != nil {
+				return err
+			}
+			pb, err := getBlockMap(tx, id)
+			if err != nil {
+				return err
+			}
+			headers = append(headers, pb.Block.Header())
+		}
+		return nil
+	})
+	cs.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	// Encode and send them to the caller.
+	if err := encoding.WriteObject(conn, cp); err != nil {
+		return err
+	}
+	if err := encoding.WriteObject(conn, headers); err != nil {
+		return err
+	}
+	return encoding.WriteObject(conn, s)
+}
+
+// managedBootstrapNFTIndex downloads the latest checkpoint of a peer together
+// with the headers of the blocks up to it and its snapshot, and stores the
+// snapshot as the pending snapshot. Nodes which already applied the
+// checkpoint's block or have a pending snapshot don't need a snapshot.
+func (cs *ConsensusSet) managedBootstrapNFTIndex(addr modules.NetAddress) {
+	var height types.BlockHeight
+	var pending bool
+	cs.mu.RLock()
+	_ = cs.db.View(func(tx *bolt.Tx) error {
+		_, pending = pendingNFTSnapshotHeight(tx)
+		height = blockHeight(tx)
+		return nil
+	})
+	cs.mu.RUnlock()
+	if pending {
+		return
+	}
+
+	var cp modules.NFTIndexCheckpoint
+	var headers []types.BlockHeader
+	var s modules.NFTIndexSnapshot
+	var needed bool
+	err := cs.gateway.RPC(addr, "SendNFTSnapshot", func(conn modules.PeerConn) error {
+		if err := encoding.ReadObject(conn, &cp, nftCheckpointSizeLimit); err != nil {
+			return err
+		}
+		if err := verifyNFTIndexCheckpoint(cp); err != nil {
+			return err
+		}
+		if cp.Height <= height {
+			return nil // the checkpoint's block was already applied
+		}
+		if err := encoding.ReadObject(conn, &headers, 8+uint64(cp.Height)*types.BlockHeaderSize); err != nil {
+			return err
+		}
+		if err := verifyNFTCheckpointHeaders(headers, cp); err != nil {
+			return err
+		}
+		if err := encoding.ReadObject(conn, &s, nftSnapshotSizeLimit); err != nil {
+			return err
+		}
+		needed = true
+		return verifyNFTIndexSnapshot(s, cp)
+	})
+	if err != nil {
+		cs.log.Printf("WARN: failed to get nft index snapshot from peer %v: %v", addr, err)
+		return
+	}
+	if !needed {
+		return
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	err = cs.db.Update(func(tx *bolt.Tx) error {
+		if _, pending := pendingNFTSnapshotHeight(tx); pending || blockHeight(tx) >= cp.Height {
+			return nil // the checkpoint's block was applied in the meantime
+		}
+		return setPendingNFTSnapshot(tx, cp, headers, s)
+	})
+	if err != nil {
+		cs.log.Println("WARN: failed to store nft index snapshot:", err)
+		return
+	}
+	cs.log.Printf("INFO: bootstrapping nft index from the snapshot at height %v with %v entries", cp.Height, len(s.Entries))
+}
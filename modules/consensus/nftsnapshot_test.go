@@ -0,0 +1,330 @@
+package consensus
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// signNFTIndexCheckpoint signs a checkpoint with the governance keys with the
+// given indices. Indices which are out of range wrap around.
+func signNFTIndexCheckpoint(cp modules.NFTIndexCheckpoint, indices ...uint64) modules.NFTIndexCheckpoint {
+	_, keys := types.GenerateDeterministicMultisig(2, 3, types.NFTGovernanceTestingSalt)
+	cp.Signatures = nil
+	for _, i := range indices {
+		cp.Signatures = append(cp.Signatures, modules.NFTIndexCheckpointSignature{
+			PublicKeyIndex: i,
+			Signature:      crypto.SignHash(cp.SigHash(), keys[i%uint64(len(keys))]),
+		})
+	}
+	return cp
+}
+
+// TestNFTIndexSnapshot tests taking, verifying and installing snapshots of the
+// NFT index.
+func TestNFTIndexSnapshot(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Mint an NFT, so that the snapshot isn't empty, and mine until the next
+	// snapshot is taken.
+	uc, err := cst.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	nft := types.NftCustody{FileMerkleRoot: crypto.HashBytes([]byte(t.Name()))}
+	if _, err := cst.wallet.MintNFT(nft, uc.UnlockHash()); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i == 0 || cst.cs.Height()%nftSnapshotInterval != 0; i++ {
+		if _, err := cst.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Only the latest snapshot is kept.
+	height := cst.cs.Height()
+	s, err := cst.cs.NFTIndexSnapshot(height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _ := cst.cs.BlockAtHeight(height)
+	if s.Height != height || s.BlockID != id.ID() {
+		t.Fatal("snapshot was taken at the wrong block", s.Height, s.BlockID)
+	}
+	if _, err := cst.cs.NFTIndexSnapshot(height - nftSnapshotInterval); !errors.Contains(err, errNFTSnapshotUnknown) {
+		t.Fatal("expected errNFTSnapshotUnknown but got", err)
+	}
+
+	// Reverting the block of the snapshot restores the snapshot it pruned,
+	// applying it again prunes it again.
+	var parent, current *processedBlock
+	err = cst.cs.db.View(func(tx *bolt.Tx) (err error) {
+		current = currentProcessedBlock(tx)
+		parent, err = getBlockMap(tx, current.Block.ParentID)
+		return
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cst.cs.dbRevertToNode(parent)
+	if _, err := cst.cs.NFTIndexSnapshot(height - nftSnapshotInterval); err != nil {
+		t.Fatal("reverting didn't restore the pruned snapshot", err)
+	}
+	if _, err := cst.cs.NFTIndexSnapshot(height); !errors.Contains(err, errNFTSnapshotUnknown) {
+		t.Fatal("expected errNFTSnapshotUnknown but got", err)
+	}
+	if _, _, err := cst.cs.dbForkBlockchain(current); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cst.cs.NFTIndexSnapshot(height - nftSnapshotInterval); !errors.Contains(err, errNFTSnapshotUnknown) {
+		t.Fatal("expected errNFTSnapshotUnknown but got", err)
+	}
+
+	// Checkpoints need to be signed by enough distinct governance keys.
+	cp := modules.NFTIndexCheckpoint{Height: s.Height, BlockID: s.BlockID, Root: s.Root()}
+	for _, indices := range [][]uint64{nil, {0}, {1, 1}, {0, 3}} {
+		if err := verifyNFTIndexCheckpoint(signNFTIndexCheckpoint(cp, indices...)); !errors.Contains(err, errNFTCheckpointSignatures) {
+			t.Fatal("expected errNFTCheckpointSignatures but got", err, indices)
+		}
+	}
+	forged := signNFTIndexCheckpoint(cp, 0, 2)
+	forged.Root = crypto.Hash{1}
+	if err := verifyNFTIndexCheckpoint(forged); !errors.Contains(err, errNFTCheckpointSignatures) {
+		t.Fatal("expected errNFTCheckpointSignatures but got", err)
+	}
+	cp = signNFTIndexCheckpoint(cp, 0, 2)
+	if err := verifyNFTIndexCheckpoint(cp); err != nil {
+		t.Fatal(err)
+	}
+
+	// The snapshot matches the checkpoint, tampered snapshots don't.
+	if err := verifyNFTIndexSnapshot(s, cp); err != nil {
+		t.Fatal(err)
+	}
+	tampered := s
+	tampered.Entries = append(append([]modules.NFTIndexSnapshotEntry(nil), s.Entries...), modules.NFTIndexSnapshotEntry{
+		Bucket: NFTCustodyPool,
+		Key:    []byte{0xff},
+		Value:  []byte{1},
+	})
+	if err := verifyNFTIndexSnapshot(tampered, cp); !errors.Contains(err, errNFTSnapshotCheckpoint) {
+		t.Fatal("expected errNFTSnapshotCheckpoint but got", err)
+	}
+	tampered.Entries[len(tampered.Entries)-1].Bucket = SiacoinOutputs
+	if err := verifyNFTIndexSnapshot(tampered, cp); !errors.Contains(err, errNFTSnapshotCheckpoint) {
+		t.Fatal("expected errNFTSnapshotCheckpoint but got", err)
+	}
+
+	// The headers of the chain link the genesis block to the checkpoint's
+	// block.
+	var headers []types.BlockHeader
+	for i := types.BlockHeight(1); i <= cp.Height; i++ {
+		b, _ := cst.cs.BlockAtHeight(i)
+		headers = append(headers, b.Header())
+	}
+	if err := verifyNFTCheckpointHeaders(headers, cp); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyNFTCheckpointHeaders(headers[1:], cp); !errors.Contains(err, errNFTCheckpointHeaders) {
+		t.Fatal("expected errNFTCheckpointHeaders but got", err)
+	}
+	forkedHeaders := append([]types.BlockHeader(nil), headers...)
+	forkedHeaders[0].Nonce[0]++
+	if err := verifyNFTCheckpointHeaders(forkedHeaders, cp); !errors.Contains(err, errNFTCheckpointHeaders) {
+		t.Fatal("expected errNFTCheckpointHeaders but got", err)
+	}
+
+	// Only checkpoints of the current path are added.
+	wrongBlock := cp
+	wrongBlock.BlockID = types.BlockID{1}
+	if err := cst.cs.AddNFTIndexCheckpoint(signNFTIndexCheckpoint(wrongBlock, 0, 1)); !errors.Contains(err, errNFTCheckpointBlock) {
+		t.Fatal("expected errNFTCheckpointBlock but got", err)
+	}
+	if err := cst.cs.AddNFTIndexCheckpoint(cp); err != nil {
+		t.Fatal(err)
+	}
+
+	// A node which bootstraps from the snapshot installs it once the block
+	// of the snapshot is applied.
+	bootstrap := func(name string, headers []types.BlockHeader, s modules.NFTIndexSnapshot) (*consensusSetTester, error) {
+		cst2, err := blankConsensusSetTester(name, modules.ProdDependencies)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = cst2.cs.db.Update(func(tx *bolt.Tx) error {
+			return setPendingNFTSnapshot(tx, cp, headers, s)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := types.BlockHeight(1); i <= s.Height; i++ {
+			b, _ := cst.cs.BlockAtHeight(i)
+			if err := cst2.cs.AcceptBlock(b); err != nil {
+				return cst2, err
+			}
+		}
+		return cst2, nil
+	}
+	cst2, err := bootstrap(t.Name()+"-bootstrap", headers, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst2.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	s2, err := cst2.cs.NFTIndexSnapshot(s.Height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s2.Root() != s.Root() {
+		t.Fatal("installed snapshot doesn't match")
+	}
+	indexRoot := func(cst *consensusSetTester) (root crypto.Hash) {
+		_ = cst.cs.db.View(func(tx *bolt.Tx) error {
+			root = takeNFTIndexSnapshot(tx, &processedBlock{Height: s.Height, Block: id}).Root()
+			return nil
+		})
+		return
+	}
+	checkInstalled := func() {
+		err = cst2.cs.db.View(func(tx *bolt.Tx) error {
+			if _, ok := pendingNFTSnapshotHeight(tx); ok {
+				t.Error("snapshot is still pending")
+			}
+			if served, ok := getNFTIndexCheckpoint(tx); !ok || served.SigHash() != cp.SigHash() {
+				t.Error("checkpoint isn't served")
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if indexRoot(cst2) != s.Root() {
+			t.Fatal("nft index doesn't match the snapshot")
+		}
+	}
+	checkInstalled()
+	if _, err := cst2.cs.ViewNFTCustody(nft); err != nil {
+		t.Fatal("minted nft isn't indexed", err)
+	}
+
+	// Reverting the block of the snapshot restores the pending snapshot and
+	// applying it again installs the snapshot again.
+	var installed *processedBlock
+	err = cst2.cs.db.View(func(tx *bolt.Tx) (err error) {
+		installed = currentProcessedBlock(tx)
+		parent, err = getBlockMap(tx, installed.Block.ParentID)
+		return
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cst2.cs.dbRevertToNode(parent)
+	err = cst2.cs.db.View(func(tx *bolt.Tx) error {
+		if height, ok := pendingNFTSnapshotHeight(tx); !ok || height != s.Height {
+			t.Error("reverting didn't restore the pending snapshot")
+		}
+		if _, ok := getNFTIndexCheckpoint(tx); ok {
+			t.Error("checkpoint of the reverted snapshot is still served")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cst2.cs.ViewNFTCustody(nft); err == nil {
+		t.Fatal("reverted snapshot is still indexed")
+	}
+	if _, _, err := cst2.cs.dbForkBlockchain(installed); err != nil {
+		t.Fatal(err)
+	}
+	checkInstalled()
+
+	// Blocks which the checkpoint doesn't commit to are rejected, and
+	// transactions aren't validated while bootstrapping.
+	cst3, err := bootstrap(t.Name()+"-mismatch", forkedHeaders, s)
+	defer func() {
+		if err := cst3.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if !errors.Contains(err, errNFTCheckpointBlock) {
+		t.Fatal("expected errNFTCheckpointBlock but got", err)
+	}
+	if _, err := cst3.cs.TryTransactionSet([]types.Transaction{{}}); !errors.Contains(err, errNFTIndexBootstrapping) {
+		t.Fatal("expected errNFTIndexBootstrapping but got", err)
+	}
+
+	// Snapshots which don't belong to the checkpoint's block are rejected
+	// once that block is applied.
+	s.BlockID = types.BlockID{1}
+	cst4, err := bootstrap(t.Name()+"-wrongsnapshot", headers, s)
+	defer func() {
+		if err := cst4.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if !errors.Contains(err, errNFTCheckpointBlock) {
+		t.Fatal("expected errNFTCheckpointBlock but got", err)
+	}
+}
+
+// TestNFTIndexBootstrapValidation tests that blocks applied while the NFT
+// index is bootstrapped are validated apart from the NFT rules, even if the
+// checkpoint commits to them.
+func TestNFTIndexBootstrapValidation(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := blankConsensusSetTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Mine a block which spends an output that doesn't exist and bootstrap
+	// from a checkpoint at that block.
+	block, target, err := cst.miner.BlockForWork()
+	if err != nil {
+		t.Fatal(err)
+	}
+	block.Transactions = append(block.Transactions, types.Transaction{
+		SiacoinInputs: []types.SiacoinInput{{ParentID: types.SiacoinOutputID{1}}},
+	})
+	block, _ = cst.miner.SolveBlock(block, target)
+	cp := modules.NFTIndexCheckpoint{Height: 1, BlockID: block.ID()}
+	s := modules.NFTIndexSnapshot{Height: 1, BlockID: block.ID()}
+	err = cst.cs.db.Update(func(tx *bolt.Tx) error {
+		return setPendingNFTSnapshot(tx, cp, []types.BlockHeader{block.Header()}, s)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cst.cs.AcceptBlock(block); !errors.Contains(err, errMissingSiacoinOutput) {
+		t.Fatal("expected errMissingSiacoinOutput but got", err)
+	}
+}
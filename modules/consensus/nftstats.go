@@ -0,0 +1,218 @@
+package consensus
+
+import (
+	"bytes"
+	"fmt"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// nftstats.go contains the aggregate NFT statistics. The statistics of a block
+// are computed from the statistics of its parent and the block's diffs, and
+// stored by block id so that reverting a block doesn't require reverting them.
+
+// blockNFTStats computes the NFT statistics of a block from the statistics of
+// its parent. The diffs of the block need to be generated.
+func blockNFTStats(parent types.NFTStats, pb *processedBlock) types.NFTStats {
+	stats := parent
+	stats.Height = pb.Height
+	stats.BlockTransfers = 0
+
+	// The outputs spent by the block are needed to find the transactions
+	// which returned a lockup and the payouts of the storage pool.
+	spent := make(map[types.SiacoinOutputID]types.SiacoinOutput)
+	for _, scod := range pb.SiacoinOutputDiffs {
+		if scod.Direction == modules.DiffRevert {
+			spent[scod.ID] = scod.SiacoinOutput
+		}
+	}
+
+	lockupPool := types.NFTLockupUnlockConditions.UnlockHash()
+	storagePool := types.NFTStoragePoolUnlockConditions.UnlockHash()
+	for _, t := range pb.Block.Transactions {
+		for _, sco := range t.SiacoinOutputs {
+			if sco.UnlockHash == lockupPool {
+				stats.LockupPoolSiacoins = stats.LockupPoolSiacoins.Add(sco.Value)
+			}
+		}
+
+		// Account the payments into and out of the storage pool.
+		payment := nftStoragePoolPayment(t)
+		switch {
+		case types.IsNFTMintTransaction(t):
+			stats.StoragePoolMintInflows = stats.StoragePoolMintInflows.Add(payment)
+		case isNFTTransferPayment(t):
+			stats.StoragePoolTransferInflows = stats.StoragePoolTransferInflows.Add(payment)
+		default:
+			stats.StoragePoolTopUpInflows = stats.StoragePoolTopUpInflows.Add(payment)
+		}
+		stats.StoragePoolSiacoins = stats.StoragePoolSiacoins.Add(payment)
+		for _, sci := range t.SiacoinInputs {
+			sco, ok := spent[sci.ParentID]
+			if !ok || sco.UnlockHash != storagePool {
+				continue
+			}
+			stats.StoragePoolOutflows = stats.StoragePoolOutflows.Add(sco.Value)
+			if stats.StoragePoolSiacoins.Cmp(sco.Value) >= 0 {
+				stats.StoragePoolSiacoins = stats.StoragePoolSiacoins.Sub(sco.Value)
+			} else {
+				stats.StoragePoolSiacoins = types.ZeroCurrency
+			}
+		}
+
+		liquidation := types.IsNFTLiquidationTransaction(t)
+		switch {
+		case types.IsNFTMintTransaction(t):
+			stats.Minted++
+		case types.IsNFTTransferTransaction(t):
+			stats.Transfers++
+			stats.BlockTransfers++
+		case liquidation:
+			stats.Liquidated++
+		}
+
+		// Like in applyNFTLockup, only reclaims and liquidations which mint
+		// coins return the lockup.
+		if !liquidation && !types.IsNFTReclaimTransaction(t) {
+			continue
+		}
+		var inputSum types.Currency
+		for _, sci := range t.SiacoinInputs {
+			inputSum = inputSum.Add(spent[sci.ParentID].Value)
+		}
+		if inputSum.Cmp(t.SiacoinOutputSum()) >= 0 {
+			continue
+		}
+		// Lockups of NFTs minted before the lockup pool existed were never
+		// paid into it.
+		if stats.LockupPoolSiacoins.Cmp(types.NFTLockupAmount) >= 0 {
+			stats.LockupPoolSiacoins = stats.LockupPoolSiacoins.Sub(types.NFTLockupAmount)
+		} else {
+			stats.LockupPoolSiacoins = types.ZeroCurrency
+		}
+	}
+	if stats.Minted >= stats.Liquidated {
+		stats.Active = stats.Minted - stats.Liquidated
+	}
+	return stats
+}
+
+// nftStoragePoolPayment returns the siacoins a transaction pays into the
+// storage pool.
+func nftStoragePoolPayment(t types.Transaction) (payment types.Currency) {
+	storagePool := types.NFTStoragePoolUnlockConditions.UnlockHash()
+	for _, sco := range t.SiacoinOutputs {
+		if sco.UnlockHash == storagePool {
+			payment = payment.Add(sco.Value)
+		}
+	}
+	return
+}
+
+// isNFTTransferPayment returns true if the storage pool payment of a
+// transaction is the cost of a transfer. Bridge locks pay the same cost as
+// transfers.
+func isNFTTransferPayment(t types.Transaction) bool {
+	return types.IsNFTTransferTransaction(t) || types.IsNFTBridgeLockTransaction(t)
+}
+
+// getNFTStats returns the NFT statistics up to and including the block with
+// the given id. Blocks without statistics, like the genesis block, return the
+// zero value.
+func getNFTStats(tx *bolt.Tx, id types.BlockID) (stats types.NFTStats) {
+	b := tx.Bucket(NFTStatsPool)
+	if b == nil {
+		return
+	}
+	data := b.Get(id[:])
+	if data == nil {
+		return
+	}
+	err := encoding.Unmarshal(data, &stats)
+	if build.DEBUG && err != nil {
+		panic(err)
+	}
+	return
+}
+
+// storeNFTStats computes and stores the NFT statistics of a block whose diffs
+// have been generated.
+func storeNFTStats(tx *bolt.Tx, pb *processedBlock) {
+	stats := blockNFTStats(getNFTStats(tx, pb.Block.ParentID), pb)
+	id := pb.Block.ID()
+	b, err := tx.CreateBucketIfNotExists(NFTStatsPool)
+	if err == nil {
+		err = b.Put(id[:], encoding.Marshal(stats))
+	}
+	if err != nil && build.DEBUG {
+		panic(fmt.Sprintf("Error updating nft stats %s", err))
+	}
+}
+
+// commitNFTStats stores the NFT statistics of a block that is applied but
+// doesn't have statistics yet, which is the case for blocks that were last
+// applied before the database was initialized.
+func commitNFTStats(tx *bolt.Tx, pb *processedBlock, dir modules.DiffDirection) {
+	if dir != modules.DiffApply || nftIndexBootstrapping(tx) {
+		return
+	}
+	id := pb.Block.ID()
+	if b := tx.Bucket(NFTStatsPool); b != nil && b.Get(id[:]) != nil {
+		return
+	}
+	storeNFTStats(tx, pb)
+}
+
+// initNFTStats computes the NFT statistics of the blocks of the current path
+// of databases which were created before the statistics were tracked, or
+// before the statistics gained their current fields.
+func (cs *ConsensusSet) initNFTStats(tx *bolt.Tx) error {
+	b := tx.Bucket(NFTStatsPool)
+	if b != nil && bytes.Equal(b.Get(FieldNFTStatsInit), ValueNFTStatsInit) {
+		return nil
+	}
+	// Statistics stored in an older format can't be decoded, so they are
+	// dropped, including those of blocks which aren't on the current path.
+	if b != nil {
+		if err := tx.DeleteBucket(NFTStatsPool); err != nil {
+			return errors.AddContext(err, "unable to drop outdated nft stats")
+		}
+	}
+	b, err := tx.CreateBucket(NFTStatsPool)
+	if err != nil {
+		return errors.AddContext(err, "unable to create nft stats bucket")
+	}
+	height := blockHeight(tx)
+	for i := types.BlockHeight(1); i <= height; i++ {
+		id, err := getPath(tx, i)
+		if err != nil {
+			return errors.AddContext(err, "unable to find block at height")
+		}
+		pb, err := getBlockMap(tx, id)
+		if err != nil {
+			return errors.AddContext(err, "unable to find block from id")
+		}
+		storeNFTStats(tx, pb)
+	}
+	err = b.Put(FieldNFTStatsInit, ValueNFTStatsInit)
+	if err != nil {
+		return errors.AddContext(err, "unable to mark nft stats as initialized")
+	}
+	return nil
+}
+
+// NFTStats returns the NFT statistics of the current block.
+func (cs *ConsensusSet) NFTStats() (stats types.NFTStats) {
+	_ = cs.db.View(func(tx *bolt.Tx) error {
+		stats = getNFTStats(tx, currentBlockID(tx))
+		stats.Height = blockHeight(tx)
+		return nil
+	})
+	return
+}
@@ -0,0 +1,202 @@
+package consensus
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestBlockNFTStats tests that the NFT statistics of a block are computed from
+// its transactions and the statistics of its parent.
+func TestBlockNFTStats(t *testing.T) {
+	nft := types.NftCustody{FileMerkleRoot: crypto.Hash{1}}
+	lockupPool := types.NFTLockupUnlockConditions.UnlockHash()
+	storagePool := types.NFTStoragePoolUnlockConditions.UnlockHash()
+	input := types.SiacoinOutputID{2}
+	poolInput := types.SiacoinOutputID{5}
+
+	pb := &processedBlock{
+		Height: 5,
+		Block: types.Block{
+			Transactions: []types.Transaction{
+				{
+					SiacoinOutputs: []types.SiacoinOutput{
+						{UnlockHash: types.UnlockHash{3}, Value: types.OneBaseUnit},
+						{UnlockHash: lockupPool, Value: types.NFTLockupAmount},
+						{UnlockHash: storagePool, Value: types.NFTHostAmount},
+					},
+					ArbitraryData: [][]byte{types.NFTArbitraryData(types.NFTMintTag, nft)},
+				},
+				{
+					SiacoinOutputs: []types.SiacoinOutput{{UnlockHash: storagePool, Value: types.NFTTransferCost}},
+					ArbitraryData:  [][]byte{types.NFTArbitraryData(types.NFTTransferTag, nft)},
+				},
+				{
+					// A liquidation which mints the lockup.
+					SiacoinInputs:  []types.SiacoinInput{{ParentID: input}},
+					SiacoinOutputs: []types.SiacoinOutput{{UnlockHash: types.UnlockHash{4}, Value: types.NFTLockupAmount.Add(types.OneBaseUnit)}},
+					ArbitraryData:  [][]byte{types.NFTArbitraryData(types.NFTLiquidationTag, nft)},
+				},
+				{
+					// A top-up of the storage pool.
+					SiacoinOutputs: []types.SiacoinOutput{{UnlockHash: storagePool, Value: types.OneBaseUnit}},
+				},
+				{
+					// A payout of the storage pool.
+					SiacoinInputs:  []types.SiacoinInput{{ParentID: poolInput}},
+					SiacoinOutputs: []types.SiacoinOutput{{UnlockHash: types.UnlockHash{6}, Value: types.NFTTransferCost}},
+				},
+			},
+		},
+		SiacoinOutputDiffs: []modules.SiacoinOutputDiff{{
+			Direction:     modules.DiffRevert,
+			ID:            input,
+			SiacoinOutput: types.SiacoinOutput{Value: types.OneBaseUnit},
+		}, {
+			Direction:     modules.DiffRevert,
+			ID:            poolInput,
+			SiacoinOutput: types.SiacoinOutput{UnlockHash: storagePool, Value: types.NFTTransferCost},
+		}},
+	}
+	parent := types.NFTStats{
+		Height:             4,
+		Minted:             3,
+		Active:             3,
+		Transfers:          7,
+		BlockTransfers:     2,
+		LockupPoolSiacoins: types.NFTLockupAmount,
+	}
+
+	stats := blockNFTStats(parent, pb)
+	if stats.Height != 5 || stats.Minted != 4 || stats.Liquidated != 1 || stats.Active != 3 {
+		t.Fatalf("wrong counts %+v", stats)
+	}
+	if stats.Transfers != 8 || stats.BlockTransfers != 1 {
+		t.Fatalf("wrong transfers %+v", stats)
+	}
+	if !stats.LockupPoolSiacoins.Equals(types.NFTLockupAmount) {
+		t.Fatal("wrong lockup pool siacoins", stats.LockupPoolSiacoins)
+	}
+	if !stats.StoragePoolSiacoins.Equals(types.NFTHostAmount.Add(types.OneBaseUnit)) {
+		t.Fatal("wrong storage pool siacoins", stats.StoragePoolSiacoins)
+	}
+	if !stats.StoragePoolMintInflows.Equals(types.NFTHostAmount) || !stats.StoragePoolTransferInflows.Equals(types.NFTTransferCost) || !stats.StoragePoolTopUpInflows.Equals(types.OneBaseUnit) {
+		t.Fatalf("wrong storage pool inflows %+v", stats)
+	}
+	if !stats.StoragePoolOutflows.Equals(types.NFTTransferCost) || !stats.StoragePoolSolvent() {
+		t.Fatalf("wrong storage pool outflows %+v", stats)
+	}
+
+	// A liquidation which doesn't mint coins doesn't return a lockup.
+	pb.SiacoinOutputDiffs[0].SiacoinOutput.Value = types.NFTLockupAmount.Add(types.OneBaseUnit)
+	stats = blockNFTStats(parent, pb)
+	if !stats.LockupPoolSiacoins.Equals(types.NFTLockupAmount.Mul64(2)) {
+		t.Fatal("wrong lockup pool siacoins", stats.LockupPoolSiacoins)
+	}
+}
+
+// TestNFTStorageEarmark tests that the storage pool payments of NFT
+// transactions are earmarked for their NFT.
+func TestNFTStorageEarmark(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	nft := types.NftCustody{FileMerkleRoot: crypto.Hash{1}}
+	storagePool := types.NFTStoragePoolUnlockConditions.UnlockHash()
+	mint := types.Transaction{
+		SiacoinOutputs: []types.SiacoinOutput{{UnlockHash: storagePool, Value: types.NFTHostAmount}},
+		ArbitraryData:  [][]byte{types.NFTArbitraryData(types.NFTMintTag, nft)},
+	}
+	transfer := types.Transaction{
+		SiacoinOutputs: []types.SiacoinOutput{{UnlockHash: storagePool, Value: types.NFTTransferCost}},
+		ArbitraryData:  [][]byte{types.NFTArbitraryData(types.NFTTransferTag, nft)},
+	}
+	err = cst.cs.db.Update(func(tx *bolt.Tx) error {
+		applyNFTStorageEarmark(tx, &processedBlock{Height: 3}, mint)
+		applyNFTStorageEarmark(tx, &processedBlock{Height: 4}, transfer)
+		applyNFTStorageEarmark(tx, &processedBlock{Height: 5}, transfer)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	earmark, err := cst.cs.ViewNFTStorageEarmark(nft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !earmark.Mint.Equals(types.NFTHostAmount) || !earmark.Transfers.Equals(types.NFTTransferCost.Mul64(2)) || !earmark.TopUps.IsZero() || earmark.Height != 5 {
+		t.Fatalf("wrong earmark %+v", earmark)
+	}
+	if !earmark.Total().Equals(types.NFTHostAmount.Add(types.NFTTransferCost.Mul64(2))) {
+		t.Fatal("wrong earmark total", earmark.Total())
+	}
+
+	// NFTs which never paid into the pool have no earmark.
+	if _, err := cst.cs.ViewNFTStorageEarmark(types.NftCustody{FileMerkleRoot: crypto.Hash{2}}); !errors.Contains(err, errNilItem) {
+		t.Fatal("expected errNilItem but got", err)
+	}
+}
+
+// TestNFTStatsInit tests that the NFT statistics follow the current block and
+// are computed for databases which predate them.
+func TestNFTStatsInit(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	if stats := cst.cs.NFTStats(); stats.Height != cst.cs.Height() {
+		t.Fatal("stats don't match the current height", stats.Height, cst.cs.Height())
+	}
+
+	// Remove the statistics and initialize them again.
+	err = cst.cs.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket(NFTStatsPool); err != nil {
+			return err
+		}
+		return cst.cs.initNFTStats(tx)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cst.cs.db.View(func(tx *bolt.Tx) error {
+		for i := types.BlockHeight(1); i <= blockHeight(tx); i++ {
+			id, err := getPath(tx, i)
+			if err != nil {
+				return err
+			}
+			if stats := getNFTStats(tx, id); stats.Height != i {
+				t.Errorf("wrong stats at height %v: %+v", i, stats)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
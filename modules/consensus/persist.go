@@ -60,6 +60,13 @@ func (cs *ConsensusSet) loadDB() error {
 			return err
 		}
 
+		// Compute the NFT statistics of the current path if the database
+		// predates them.
+		err = cs.initNFTStats(tx)
+		if err != nil {
+			return err
+		}
+
 		// Check that the genesis block is correct - typically only incorrect
 		// in the event of developer binaries vs. release binaires.
 		genesisID, err := getPath(tx, 0)
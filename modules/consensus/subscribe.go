@@ -15,41 +15,48 @@ import (
 // computeConsensusChangeDiffs computes the ConsensusChangeDiffs for the
 // provided block.
 func computeConsensusChangeDiffs(pb *processedBlock, apply bool) modules.ConsensusChangeDiffs {
+	diffs := modules.ConsensusChangeDiffs{
+		SiacoinOutputDiffs:        pb.SiacoinOutputDiffs,
+		FileContractDiffs:         pb.FileContractDiffs,
+		SiafundOutputDiffs:        pb.SiafundOutputDiffs,
+		DelayedSiacoinOutputDiffs: pb.DelayedSiacoinOutputDiffs,
+		SiafundPoolDiffs:          pb.SiafundPoolDiffs,
+	}
 	if apply {
-		return modules.ConsensusChangeDiffs{
-			SiacoinOutputDiffs:        pb.SiacoinOutputDiffs,
-			FileContractDiffs:         pb.FileContractDiffs,
-			SiafundOutputDiffs:        pb.SiafundOutputDiffs,
-			DelayedSiacoinOutputDiffs: pb.DelayedSiacoinOutputDiffs,
-			SiafundPoolDiffs:          pb.SiafundPoolDiffs,
-		}
+		return diffs
 	}
+	return invertConsensusChangeDiffs(diffs)
+}
+
+// invertConsensusChangeDiffs returns the diffs which revert the diffs of a
+// block.
+func invertConsensusChangeDiffs(diffs modules.ConsensusChangeDiffs) modules.ConsensusChangeDiffs {
 	// The order of the diffs needs to be flipped and the direction of the
 	// diffs also needs to be flipped.
 	cd := modules.ConsensusChangeDiffs{
-		SiacoinOutputDiffs:        make([]modules.SiacoinOutputDiff, len(pb.SiacoinOutputDiffs)),
-		FileContractDiffs:         make([]modules.FileContractDiff, len(pb.FileContractDiffs)),
-		SiafundOutputDiffs:        make([]modules.SiafundOutputDiff, len(pb.SiafundOutputDiffs)),
-		DelayedSiacoinOutputDiffs: make([]modules.DelayedSiacoinOutputDiff, len(pb.DelayedSiacoinOutputDiffs)),
-		SiafundPoolDiffs:          make([]modules.SiafundPoolDiff, len(pb.SiafundPoolDiffs)),
+		SiacoinOutputDiffs:        make([]modules.SiacoinOutputDiff, len(diffs.SiacoinOutputDiffs)),
+		FileContractDiffs:         make([]modules.FileContractDiff, len(diffs.FileContractDiffs)),
+		SiafundOutputDiffs:        make([]modules.SiafundOutputDiff, len(diffs.SiafundOutputDiffs)),
+		DelayedSiacoinOutputDiffs: make([]modules.DelayedSiacoinOutputDiff, len(diffs.DelayedSiacoinOutputDiffs)),
+		SiafundPoolDiffs:          make([]modules.SiafundPoolDiff, len(diffs.SiafundPoolDiffs)),
 	}
-	for i, d := range pb.SiacoinOutputDiffs {
+	for i, d := range diffs.SiacoinOutputDiffs {
 		d.Direction = !d.Direction
 		cd.SiacoinOutputDiffs[len(cd.SiacoinOutputDiffs)-i-1] = d
 	}
-	for i, d := range pb.FileContractDiffs {
+	for i, d := range diffs.FileContractDiffs {
 		d.Direction = !d.Direction
 		cd.FileContractDiffs[len(cd.FileContractDiffs)-i-1] = d
 	}
-	for i, d := range pb.SiafundOutputDiffs {
+	for i, d := range diffs.SiafundOutputDiffs {
 		d.Direction = !d.Direction
 		cd.SiafundOutputDiffs[len(cd.SiafundOutputDiffs)-i-1] = d
 	}
-	for i, d := range pb.DelayedSiacoinOutputDiffs {
+	for i, d := range diffs.DelayedSiacoinOutputDiffs {
 		d.Direction = !d.Direction
 		cd.DelayedSiacoinOutputDiffs[len(cd.DelayedSiacoinOutputDiffs)-i-1] = d
 	}
-	for i, d := range pb.SiafundPoolDiffs {
+	for i, d := range diffs.SiafundPoolDiffs {
 		d.Direction = !d.Direction
 		cd.SiafundPoolDiffs[len(cd.SiafundPoolDiffs)-i-1] = d
 	}
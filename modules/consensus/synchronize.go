@@ -132,6 +132,24 @@ func blockHistory(tx *bolt.Tx) (blockIDs [32]types.BlockID) {
 	return blockIDs
 }
 
+// latestKnownBlock returns the height of the most recent block of knownBlocks
+// which is part of the current path. The second return value is false if none
+// of the blocks is.
+func latestKnownBlock(tx *bolt.Tx, knownBlocks [32]types.BlockID) (types.BlockHeight, bool) {
+	for _, id := range knownBlocks {
+		pb, err := getBlockMap(tx, id)
+		if err != nil {
+			continue
+		}
+		pathID, err := getPath(tx, pb.Height)
+		if err != nil || pathID != pb.Block.ID() {
+			continue
+		}
+		return pb.Height, true
+	}
+	return 0, false
+}
+
 // managedReceiveBlocks is the calling end of the SendBlocks RPC, without the
 // threadgroup wrapping.
 func (cs *ConsensusSet) managedReceiveBlocks(conn modules.PeerConn) (returnErr error) {
@@ -299,26 +317,10 @@ func (cs *ConsensusSet) rpcSendBlocks(conn modules.PeerConn) error {
 	cs.mu.RLock()
 	err = cs.db.View(func(tx *bolt.Tx) error {
 		csHeight = blockHeight(tx)
-		for _, id := range knownBlocks {
-			pb, err := getBlockMap(tx, id)
-			if err != nil {
-				continue
-			}
-			pathID, err := getPath(tx, pb.Height)
-			if err != nil {
-				continue
-			}
-			if pathID != pb.Block.ID() {
-				continue
-			}
-			if pb.Height == csHeight {
-				break
-			}
-			found = true
-			// Start from the child of the common block.
-			start = pb.Height + 1
-			break
-		}
+		height, known := latestKnownBlock(tx, knownBlocks)
+		found = known && height != csHeight
+		// Start from the child of the common block.
+		start = height + 1
 		return nil
 	})
 	cs.mu.RUnlock()
@@ -575,6 +577,12 @@ func (cs *ConsensusSet) managedInitialBlockchainDownload() error {
 				}
 				defer cs.tg.Done()
 
+				// Get a snapshot of the NFT index before the blocks it
+				// replaces are applied.
+				if cs.staticNFTSnapshotBootstrap {
+					cs.managedBootstrapNFTIndex(p.NetAddress)
+				}
+
 				// Request blocks from the peer. The error returned will only be
 				// 'nil' if there are no more blocks to receive.
 				err = cs.gateway.RPC(p.NetAddress, "SendBlocks", cs.managedReceiveBlocks)
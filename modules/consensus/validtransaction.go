@@ -30,6 +30,50 @@ var (
 	errIncorrectTransferFees      = errors.New("transfer fees for NFT were paid incorrectly")
 	errIncorrectNFTCustody        = errors.New("NFT was spent without proper custody")
 	errOversizedLiquidation       = errors.New("NFT attempts to take more than allowed from liquidation pool")
+	errIncorrectNFTReclaim        = errors.New("NFT lockup reclaim must keep the NFT at its address and pay out the lockup")
+	errNFTReclaimInactive         = errors.New("NFT lockup reclaims are not active yet")
+	errNFTLockupReclaimed         = errors.New("NFT lockup was already reclaimed or is unknown")
+	errNFTLockupNotVested         = errors.New("NFT lockup is not vested yet")
+	errDuplicateNFTMint           = errors.New("NFT was minted before")
+	errNFTBridgeInactive          = errors.New("NFT bridge transactions are not active yet")
+	errIncorrectNFTBridgeLock     = errors.New("NFT bridge lock must pay the transfer fee and embed a valid claim")
+	errIncorrectNFTBridgeUnlock   = errors.New("NFT bridge unlock must have a single custody output")
+	errNFTBridgeLocked            = errors.New("NFT is locked by a bridge")
+	errNFTNotBridgeLocked         = errors.New("NFT is not locked by a bridge")
+	errNFTBridgeAttestations      = errors.New("NFT bridge unlock is not attested by enough signatures")
+	errNFTIdentifiersInactive     = errors.New("NFT identifiers are not active yet")
+	errNFTNotMintedToCreator      = errors.New("NFT with an identity must be minted to its creator")
+	errNFTEditionsInactive        = errors.New("NFT editions are not active yet")
+	errNFTEditionsMismatch        = errors.New("NFT edition doesn't match the number of editions of its run")
+	errNFTTransferPolicyInactive  = errors.New("NFT transfer policies are not active yet")
+	errNFTNotTransferable         = errors.New("NFT transfer policy doesn't allow the transfer")
+	errNFTStakingInactive         = errors.New("NFT staking transactions are not active yet")
+	errIncorrectNFTStake          = errors.New("NFT stake must keep the NFT at its address and burn at least the minimum stake")
+	errIncorrectNFTUnstake        = errors.New("NFT unstake must keep the NFT at its address and pay out its share of the stake")
+	errNFTStaked                  = errors.New("NFT is staked")
+	errNFTNotStaked               = errors.New("NFT is not staked")
+	errIncorrectNFTStakeReward    = errors.New("NFT stake reward claim must pay out the reward of its merkle root")
+	errNFTStakeRewardUnavailable  = errors.New("NFT stake reward can't be claimed for the merkle root yet")
+	errNFTStakeRewardProof        = errors.New("NFT stake reward claim has an invalid storage proof")
+	errNFTReferencesInactive      = errors.New("NFT content references are not active yet")
+	errNFTInsuranceInactive       = errors.New("NFT insurance transactions are not active yet")
+	errIncorrectNFTInsure         = errors.New("NFT insurance must burn at least the minimum collateral and expire in the future")
+	errIncorrectNFTChallenge      = errors.New("NFT insurance challenge must keep the NFT at its address")
+	errIncorrectNFTInsuranceClaim = errors.New("NFT insurance claim must keep the NFT at its address and pay out the collateral")
+	errIncorrectNFTRelease        = errors.New("NFT insurance release must pay out the collateral to the insurer")
+	errNFTInsured                 = errors.New("NFT is insured already")
+	errNFTNotInsurable            = errors.New("NFT is unknown or liquidated")
+	errNFTInsuranceUnavailable    = errors.New("NFT insurance can't be challenged, answered, claimed or released in this block")
+	errNFTInsuranceProof          = errors.New("NFT insurance response has an invalid storage proof")
+	errNFTApprovalsInactive       = errors.New("NFT approvals are not active yet")
+	errIncorrectNFTApproval       = errors.New("NFT approval must keep the NFT at its address and pay a ticket to an operator other than the owner")
+	errNFTGovernanceUnauthorized  = errors.New("NFT lockup pool spends and liquidations which return the lockup must be authorized by the governance")
+	errNFTDisputesInactive        = errors.New("NFT disputes are not active yet")
+	errNFTNotDisputable           = errors.New("NFT is unknown or liquidated")
+	errNFTAlreadyFrozen           = errors.New("NFT is already frozen by a dispute")
+	errNFTNotFrozen               = errors.New("NFT is not frozen by a dispute")
+	errNFTFrozen                  = errors.New("NFT is frozen by a dispute and can't be moved until it is unfrozen")
+	errNFTLicensesInactive        = errors.New("NFT licenses are not active yet")
 )
 
 // Make sure NFT has correct parent input
@@ -45,6 +89,367 @@ func nftValidParent(tx *bolt.Tx, t types.Transaction) bool {
 	return parentFound
 }
 
+// nftValidOperator returns true if one of the inputs of a transfer belongs to
+// the operator which is approved to transfer the NFT.
+func nftValidOperator(tx *bolt.Tx, t types.Transaction) bool {
+	nft, _ := types.ExtractNFTFromTransaction(t)
+	approval, err := viewNFTApprovalInternal(tx, nft)
+	if err != nil {
+		return false
+	}
+	for _, inp := range t.SiacoinInputs {
+		if inp.UnlockConditions.UnlockHash() == approval.Operator {
+			return true
+		}
+	}
+	return false
+}
+
+// validNFTBridge checks that NFTs which are locked by a bridge are only moved by
+// a bridge unlock, and that bridge locks and unlocks are well formed.
+func validNFTBridge(tx *bolt.Tx, t types.Transaction) error {
+	lock, unlock := types.IsNFTBridgeLockTransaction(t), types.IsNFTBridgeUnlockTransaction(t)
+	if (lock || unlock) && !nftRuleActiveInternal(tx, nftRuleBridge) {
+		return errNFTBridgeInactive
+	}
+	// insurance and dispute transactions keep the NFT at its address
+	if !types.IsNFTTransaction(t) || types.IsNFTMintTransaction(t) || types.IsNFTStakeRewardTransaction(t) || types.IsNFTInsuranceTransaction(t) || types.IsNFTDisputeTransaction(t) {
+		return nil
+	}
+	nft, _ := types.ExtractNFTFromTransaction(t)
+	_, err := viewNFTBridgeLockInternal(tx, nft)
+	locked := err == nil
+	if locked && !unlock {
+		return errNFTBridgeLocked
+	}
+
+	if lock {
+		// a lock is a transfer to the bridge's address which embeds the claim
+		var validOutputCount = (len(t.SiacoinOutputs) == 2) // storage + colored coin
+		var storagePaid = false
+		for _, op := range t.SiacoinOutputs {
+			if op.UnlockHash == types.NFTStoragePoolUnlockConditions.UnlockHash() && op.Value.Equals(types.NFTTransferCost) {
+				storagePaid = true
+			}
+		}
+		if _, _, err := types.ParseNFTBridgeClaim(t.ArbitraryData[0]); err != nil || !storagePaid || !validOutputCount {
+			return errIncorrectNFTBridgeLock
+		}
+		if !nftValidParent(tx, t) {
+			return errIncorrectNFTCustody
+		}
+	}
+
+	if unlock {
+		if !locked {
+			return errNFTNotBridgeLocked
+		}
+		if len(t.SiacoinOutputs) != 1 || !t.SiacoinOutputs[0].Value.Equals(types.OneBaseUnit) {
+			return errIncorrectNFTBridgeUnlock
+		}
+		// the custody output is held by the bridge, spending it requires the
+		// signatures of its attestors
+		custody, _ := viewNFTCustodyInternal(tx, nft)
+		var attested bool
+		for _, sci := range t.SiacoinInputs {
+			if sci.UnlockConditions.UnlockHash() == custody.UnlockHash {
+				attested = sci.UnlockConditions.SignaturesRequired >= types.NFTMinBridgeAttestations
+			}
+		}
+		if !nftValidParent(tx, t) {
+			return errIncorrectNFTCustody
+		}
+		if !attested {
+			return errNFTBridgeAttestations
+		}
+	}
+	return nil
+}
+
+// validNFTIdentity checks that entries with an NftID and edition mints are only
+// used once NFT identifiers and editions are active, that identified mints are
+// minted to the address of their creator and that an NftID is never minted
+// twice. Editions also need to agree with the earlier editions of their run on
+// the number of editions. The checks apply to the parsed NFT rather than the
+// version of the entry, because mints with a transfer policy wrap identified
+// and edition mints.
+func validNFTIdentity(tx *bolt.Tx, t types.Transaction) error {
+	if !types.IsNFTTransaction(t) {
+		return nil
+	}
+	_, _, nft, err := types.ParseNFTArbitraryData(t.ArbitraryData[0])
+	if err != nil || nft.ID == (types.NftID{}) {
+		return nil
+	}
+	if !nftRuleActiveInternal(tx, nftRuleIdentifiers) {
+		return errNFTIdentifiersInactive
+	}
+	if nft.IsEdition() && !nftRuleActiveInternal(tx, nftRuleEditions) {
+		return errNFTEditionsInactive
+	}
+	if !nft.HasIdentity() {
+		return nil
+	}
+	// the NftID commits to the creator, so only the creator can mint it
+	_, owner := types.ExtractNFTFromTransaction(t)
+	if owner.UnlockHash != nft.CreatorUnlockHash() {
+		return errNFTNotMintedToCreator
+	}
+	if _, err := viewNFTCustodyInternal(tx, nft); err == nil {
+		return errDuplicateNFTMint
+	}
+	if nft.IsEdition() {
+		for _, edition := range findNFTEditionsInternal(tx, nft.FileMerkleRoot) {
+			if edition.Creator == nft.Creator && edition.Collection == nft.Collection && edition.Editions != nft.Editions {
+				return errNFTEditionsMismatch
+			}
+		}
+	}
+	return nil
+}
+
+// validNFTTransferPolicy checks that mints with a transfer policy are only used
+// once transfer policies are active, and that transfers and bridge locks are
+// allowed by the policy of the NFT in the block they go into.
+func validNFTTransferPolicy(tx *bolt.Tx, t types.Transaction) error {
+	if !types.IsNFTTransaction(t) {
+		return nil
+	}
+	nft, _ := types.ExtractNFTFromTransaction(t)
+	if nft.HasTransferPolicy() && !nftRuleActiveInternal(tx, nftRuleTransferPolicy) {
+		return errNFTTransferPolicyInactive
+	}
+	if !types.IsNFTTransferTransaction(t) && !types.IsNFTBridgeLockTransaction(t) {
+		return nil
+	}
+	if !viewNFTTransferPolicyInternal(tx, nft).AllowsTransfer(blockHeight(tx) + 1) {
+		return errNFTNotTransferable
+	}
+	return nil
+}
+
+// validNFTContentReference checks that mints with a content reference are
+// only used once content references are active. The reference itself is
+// checked when parsing the entry.
+func validNFTContentReference(tx *bolt.Tx, t types.Transaction) error {
+	if !types.IsNFTTransaction(t) {
+		return nil
+	}
+	if _, _, err := types.ParseNFTContentReference(t.ArbitraryData[0]); err == nil && !nftRuleActiveInternal(tx, nftRuleContentReferences) {
+		return errNFTReferencesInactive
+	}
+	return nil
+}
+
+// validNFTLicense checks that mints with a license are only used once licenses
+// are active. The license itself is checked when parsing the entry.
+func validNFTLicense(tx *bolt.Tx, t types.Transaction) error {
+	if !types.IsNFTTransaction(t) {
+		return nil
+	}
+	if _, _, err := types.ParseNFTLicense(t.ArbitraryData[0]); err == nil && !nftRuleActiveInternal(tx, nftRuleLicenses) {
+		return errNFTLicensesInactive
+	}
+	return nil
+}
+
+// validNFTStake checks that staking transactions are only used once staking is
+// active, that staked NFTs are only moved by an unstake, and that stakes,
+// unstakes and reward claims are well formed. The coins minted by unstakes and
+// reward claims are checked in validSiacoins.
+func validNFTStake(tx *bolt.Tx, t types.Transaction) error {
+	stake, unstake, reward := types.IsNFTStakeTransaction(t), types.IsNFTUnstakeTransaction(t), types.IsNFTStakeRewardTransaction(t)
+	if (stake || unstake || reward) && !nftRuleActiveInternal(tx, nftRuleStaking) {
+		return errNFTStakingInactive
+	}
+	if reward {
+		return validNFTStakeReward(tx, t)
+	}
+	// insurance and dispute transactions keep the NFT at its address
+	if !types.IsNFTTransaction(t) || types.IsNFTMintTransaction(t) || types.IsNFTInsuranceTransaction(t) || types.IsNFTDisputeTransaction(t) {
+		return nil
+	}
+	nft, _ := types.ExtractNFTFromTransaction(t)
+	_, err := viewNFTStakeInternal(tx, nft)
+	staked := err == nil
+	if staked && !unstake {
+		return errNFTStaked
+	}
+
+	if stake {
+		// the NFT stays in custody of its owner and the stake is burned
+		custody, _ := viewNFTCustodyInternal(tx, nft)
+		if len(t.SiacoinOutputs) != 2 || !t.SiacoinOutputs[0].Value.Equals(types.OneBaseUnit) || t.SiacoinOutputs[0].UnlockHash != custody.UnlockHash ||
+			t.SiacoinOutputs[1].UnlockHash != types.NFTStakingPoolUnlockHash || t.SiacoinOutputs[1].Value.Cmp(types.NFTMinStake) < 0 {
+			return errIncorrectNFTStake
+		}
+		if !nftValidParent(tx, t) {
+			return errIncorrectNFTCustody
+		}
+	}
+
+	if unstake {
+		if !staked {
+			return errNFTNotStaked
+		}
+		// the NFT stays at its address in the first output, the second
+		// output receives the share of the stake which is minted in
+		// validSiacoins
+		custody, _ := viewNFTCustodyInternal(tx, nft)
+		if len(t.SiacoinOutputs) != 2 || !t.SiacoinOutputs[0].Value.Equals(types.OneBaseUnit) || t.SiacoinOutputs[0].UnlockHash != custody.UnlockHash {
+			return errIncorrectNFTUnstake
+		}
+		if !nftValidParent(tx, t) {
+			return errIncorrectNFTCustody
+		}
+	}
+	return nil
+}
+
+// validNFTStakeReward checks that a reward claim proves the storage of the
+// segment chosen by the current block and its payout address, and that the
+// merkle root can pay out a reward in the next block. The first output of the
+// claim receives the reward.
+func validNFTStakeReward(tx *bolt.Tx, t types.Transaction) error {
+	claim, err := types.ParseNFTStakeRewardClaim(t.ArbitraryData[0])
+	if err != nil || len(t.SiacoinOutputs) == 0 {
+		return errIncorrectNFTStakeReward
+	}
+	stake := viewNFTRootStakeInternal(tx, claim.Root)
+	if !stake.CanReward(blockHeight(tx) + 1) {
+		return errNFTStakeRewardUnavailable
+	}
+	if !t.SiacoinOutputs[0].Value.Equals(stake.Reward()) {
+		return errIncorrectNFTStakeReward
+	}
+	if !claim.Verify(currentBlockID(tx), t.SiacoinOutputs[0].UnlockHash) {
+		return errNFTStakeRewardProof
+	}
+	return nil
+}
+
+// nftStakeMinted returns the number of coins an unstake or reward claim is
+// allowed to mint.
+func nftStakeMinted(tx *bolt.Tx, t types.Transaction) types.Currency {
+	if types.IsNFTStakeRewardTransaction(t) {
+		claim, err := types.ParseNFTStakeRewardClaim(t.ArbitraryData[0])
+		if err != nil {
+			return types.ZeroCurrency
+		}
+		return viewNFTRootStakeInternal(tx, claim.Root).Reward()
+	}
+	nft, _ := types.ExtractNFTFromTransaction(t)
+	stake, err := viewNFTStakeInternal(tx, nft)
+	if err != nil {
+		return types.ZeroCurrency
+	}
+	return viewNFTRootStakeInternal(tx, stake.Root).Share(stake.Amount)
+}
+
+// validNFTInsurance checks that insurance transactions are only used once
+// insurance is active, that they are well formed, and that challenges,
+// responses, claims and releases are allowed by the state of the insurance in
+// the block they go into. The collateral minted by claims and releases is
+// checked in validSiacoins.
+func validNFTInsurance(tx *bolt.Tx, t types.Transaction) error {
+	if !types.IsNFTInsuranceTransaction(t) {
+		return nil
+	}
+	if !nftRuleActiveInternal(tx, nftRuleInsurance) {
+		return errNFTInsuranceInactive
+	}
+	height := blockHeight(tx) + 1
+	_, tag, nft, _ := types.ParseNFTArbitraryData(t.ArbitraryData[0])
+	insurance, _ := viewNFTInsuranceInternal(tx, nft)
+	custody, custodyErr := viewNFTCustodyInternal(tx, nft)
+
+	switch {
+	case types.NFTTagEqual(tag, types.NFTInsureTag):
+		if custodyErr != nil || custody.UnlockHash == types.LiquidatedNFTUnlockHash {
+			return errNFTNotInsurable
+		}
+		// the collateral is burned by the first output
+		_, terms, err := types.ParseNFTInsuranceTerms(t.ArbitraryData[0])
+		if err != nil || len(t.SiacoinOutputs) == 0 || t.SiacoinOutputs[0].UnlockHash != types.NFTInsurancePoolUnlockHash ||
+			t.SiacoinOutputs[0].Value.Cmp(types.NFTMinInsuranceCollateral) < 0 || terms.Expiry <= height {
+			return errIncorrectNFTInsure
+		}
+		if insurance.Active() {
+			return errNFTInsured
+		}
+
+	case types.NFTTagEqual(tag, types.NFTInsuranceChallengeTag):
+		if !insurance.CanChallenge(height) {
+			return errNFTInsuranceUnavailable
+		}
+		// the NFT stays in custody of its owner
+		if len(t.SiacoinOutputs) != 1 || !t.SiacoinOutputs[0].Value.Equals(types.OneBaseUnit) || t.SiacoinOutputs[0].UnlockHash != custody.UnlockHash {
+			return errIncorrectNFTChallenge
+		}
+		if !nftValidParent(tx, t) {
+			return errIncorrectNFTCustody
+		}
+
+	case types.NFTTagEqual(tag, types.NFTInsuranceResponseTag):
+		if !insurance.CanRespond(height) {
+			return errNFTInsuranceUnavailable
+		}
+		_, response, err := types.ParseNFTInsuranceResponse(t.ArbitraryData[0])
+		if err != nil {
+			return errNFTInsuranceProof
+		}
+		challengeID, err := getPath(tx, insurance.ChallengeHeight)
+		if err != nil || !response.Verify(challengeID, nft.Identifier(), insurance.Root) {
+			return errNFTInsuranceProof
+		}
+
+	case types.NFTTagEqual(tag, types.NFTInsuranceClaimTag):
+		if !insurance.CanClaim(height) {
+			return errNFTInsuranceUnavailable
+		}
+		// the NFT stays at its address in the first output, the second
+		// output receives the collateral which is minted in validSiacoins
+		if len(t.SiacoinOutputs) != 2 || !t.SiacoinOutputs[0].Value.Equals(types.OneBaseUnit) || t.SiacoinOutputs[0].UnlockHash != custody.UnlockHash ||
+			!t.SiacoinOutputs[1].Value.Equals(insurance.Collateral) {
+			return errIncorrectNFTInsuranceClaim
+		}
+		if !nftValidParent(tx, t) {
+			return errIncorrectNFTCustody
+		}
+
+	case types.NFTTagEqual(tag, types.NFTInsuranceReleaseTag):
+		if !insurance.CanRelease(height) {
+			return errNFTInsuranceUnavailable
+		}
+		// the only output receives the collateral which is minted in
+		// validSiacoins
+		if len(t.SiacoinOutputs) != 1 || !t.SiacoinOutputs[0].Value.Equals(insurance.Collateral) || t.SiacoinOutputs[0].UnlockHash != insurance.Insurer {
+			return errIncorrectNFTRelease
+		}
+	}
+	return nil
+}
+
+// nftInsuranceMinted returns the number of coins a claim or release is allowed
+// to mint.
+func nftInsuranceMinted(tx *bolt.Tx, t types.Transaction) types.Currency {
+	nft, _ := types.ExtractNFTFromTransaction(t)
+	insurance, err := viewNFTInsuranceInternal(tx, nft)
+	if err != nil {
+		return types.ZeroCurrency
+	}
+	return insurance.Collateral
+}
+
+// validNFTCompositeTransfer checks the outputs of a transfer which pays
+// additional siacoin outputs. The colored coin is the first output which isn't
+// paid to a pool, and it has to hold exactly one base unit so that it can't be
+// confused with the payments which follow it.
+func validNFTCompositeTransfer(t types.Transaction) bool {
+	_, owner := types.ExtractNFTFromTransaction(t)
+	return owner.Value.Equals(types.OneBaseUnit)
+}
+
 // validNFTCustody checks that for any nft operations (mint, transfer, liquidate)
 // the chain of custody is correct and all appropriate fees are apid
 func validNFTCustody(tx *bolt.Tx, t types.Transaction) error {
@@ -64,12 +469,24 @@ func validNFTCustody(tx *bolt.Tx, t types.Transaction) error {
 		if !lockupPaid || !storagePaid || !validOutputCount {
 			return errIncorrectMintFees
 		}
+		// legacy NFTs are keyed by their merkle root and identified NFTs by
+		// their NftID, so once identifiers are active a duplicate mint could
+		// overwrite the custody of an NFT whose NftID equals its root
+		if nftRuleActiveInternal(tx, nftRuleRejectDuplicateMint) || nftRuleActiveInternal(tx, nftRuleIdentifiers) {
+			nft, _ := types.ExtractNFTFromTransaction(t)
+			if _, err := viewNFTCustodyInternal(tx, nft); err == nil {
+				return errDuplicateNFTMint
+			}
+		}
 	}
 
 	if types.IsNFTTransferTransaction(t) {
 		// first validate payment to pool (as with mint)
 		var storagePaid = false
 		var validOutputCount = (len(t.SiacoinOutputs) == 2) // storage + colored coin
+		if nftRuleActiveInternal(tx, nftRuleCompositeTransfers) && len(t.SiacoinOutputs) > 2 {
+			validOutputCount = validNFTCompositeTransfer(t)
+		}
 		for _, op := range t.SiacoinOutputs {
 			if op.UnlockHash == types.NFTStoragePoolUnlockConditions.UnlockHash() && op.Value.Equals(types.NFTTransferCost) {
 				// fmt.Println("output", op.UnlockHash, op.Value)
@@ -80,8 +497,9 @@ func validNFTCustody(tx *bolt.Tx, t types.Transaction) error {
 			// fmt.Println(storagePaid, validOutputCount, len(t.SiacoinOutputs))
 			return errIncorrectTransferFees
 		}
-		// then check chain-of-custody (one input should correspond to address that previously owned NFT)
-		if !nftValidParent(tx, t) {
+		// then check chain-of-custody (one input should correspond to address that previously owned NFT,
+		// or to the operator approved by that address)
+		if !nftValidParent(tx, t) && !nftValidOperator(tx, t) {
 			return errIncorrectNFTCustody
 		}
 	}
@@ -95,6 +513,125 @@ func validNFTCustody(tx *bolt.Tx, t types.Transaction) error {
 		}
 	}
 
+	if types.IsNFTReclaimTransaction(t) {
+		if !nftRuleActiveInternal(tx, nftRuleLockupReclaim) {
+			return errNFTReclaimInactive
+		}
+		// the NFT stays at its address in the first output, the second
+		// output receives the lockup which is minted in validSiacoins
+		nft, _ := types.ExtractNFTFromTransaction(t)
+		custody, _ := viewNFTCustodyInternal(tx, nft)
+		if len(t.SiacoinOutputs) != 2 || !t.SiacoinOutputs[0].Value.Equals(types.OneBaseUnit) || t.SiacoinOutputs[0].UnlockHash != custody.UnlockHash {
+			return errIncorrectNFTReclaim
+		}
+		if !nftValidParent(tx, t) {
+			return errIncorrectNFTCustody
+		}
+		// the lockup can only be reclaimed once it is vested, which is
+		// checked against the height of the block the transaction goes into
+		lockup, err := viewNFTLockupInternal(tx, nft)
+		if err != nil || lockup.Reclaimed {
+			return errNFTLockupReclaimed
+		}
+		if blockHeight(tx)+1 < lockup.VestingHeight() {
+			return errNFTLockupNotVested
+		}
+	}
+
+	return nil
+}
+
+// validNFTApproval checks that approvals are only used once approvals are
+// active, and that they keep the NFT at its owner's address. Approvals pay a
+// ticket of one base unit to the operator, revocations have no ticket.
+func validNFTApproval(tx *bolt.Tx, t types.Transaction) error {
+	if !types.IsNFTApproveTransaction(t) {
+		return nil
+	}
+	if !nftRuleActiveInternal(tx, nftRuleApprovals) {
+		return errNFTApprovalsInactive
+	}
+	nft, _ := types.ExtractNFTFromTransaction(t)
+	custody, _ := viewNFTCustodyInternal(tx, nft)
+	outputs := t.SiacoinOutputs
+	if len(outputs) == 0 || len(outputs) > 2 || !outputs[0].Value.Equals(types.OneBaseUnit) || outputs[0].UnlockHash != custody.UnlockHash {
+		return errIncorrectNFTApproval
+	}
+	if len(outputs) == 2 && (!outputs[1].Value.Equals(types.OneBaseUnit) || outputs[1].UnlockHash == custody.UnlockHash) {
+		return errIncorrectNFTApproval
+	}
+	if !nftValidParent(tx, t) {
+		return errIncorrectNFTCustody
+	}
+	return nil
+}
+
+// validNFTGovernance checks that transactions which spend outputs of the
+// lockup pool, or which liquidate an NFT and return its lockup, are
+// authorized by the governance once governance is active. The signatures of
+// the governance input are checked like those of every other input.
+func validNFTGovernance(tx *bolt.Tx, t types.Transaction) error {
+	if !nftRuleActiveInternal(tx, nftRuleLockupGovernance) || types.IsNFTGovernanceAuthorized(t) {
+		return nil
+	}
+	if types.SpendsNFTLockupPool(t) {
+		return errNFTGovernanceUnauthorized
+	}
+	if !types.IsNFTLiquidationTransaction(t) {
+		return nil
+	}
+	// Like in applyNFTLockup, only liquidations which mint coins return the
+	// lockup.
+	var inputSum types.Currency
+	for _, sci := range t.SiacoinInputs {
+		sco, err := getSiacoinOutput(tx, sci.ParentID)
+		if err != nil {
+			return err
+		}
+		inputSum = inputSum.Add(sco.Value)
+	}
+	if inputSum.Cmp(t.SiacoinOutputSum()) < 0 {
+		return errNFTGovernanceUnauthorized
+	}
+	return nil
+}
+
+// validNFTDispute checks that dispute flags are only used once disputes are
+// active, that they are authorized by the governance and that they freeze
+// known NFTs which aren't frozen yet or unfreeze frozen NFTs. Transactions
+// which set the custody of frozen NFTs are rejected, see types.MovesFrozenNFT.
+func validNFTDispute(tx *bolt.Tx, t types.Transaction) error {
+	if !types.IsNFTDisputeTransaction(t) {
+		if !types.MovesFrozenNFT(t) {
+			return nil
+		}
+		nft, _ := types.ExtractNFTFromTransaction(t)
+		if dispute, err := viewNFTDisputeInternal(tx, nft); err == nil && dispute.Frozen() {
+			return errNFTFrozen
+		}
+		return nil
+	}
+	if !nftRuleActiveInternal(tx, nftRuleDisputes) {
+		return errNFTDisputesInactive
+	}
+	nft, _, err := types.ParseNFTDisputeFlag(t.ArbitraryData[0])
+	if err != nil {
+		return err
+	}
+	if !types.IsNFTGovernanceAuthorized(t) {
+		return errNFTGovernanceUnauthorized
+	}
+	custody, err := viewNFTCustodyInternal(tx, nft)
+	if err != nil || custody.UnlockHash == types.LiquidatedNFTUnlockHash {
+		return errNFTNotDisputable
+	}
+	dispute, _ := viewNFTDisputeInternal(tx, nft)
+	if types.IsNFTFreezeTransaction(t) && dispute.Frozen() {
+		return errNFTAlreadyFrozen
+	}
+	if types.IsNFTUnfreezeTransaction(t) && !dispute.Frozen() {
+		return errNFTNotFrozen
+	}
 	return nil
 }
 
@@ -123,12 +660,37 @@ func validSiacoins(tx *bolt.Tx, t types.Transaction) error {
 		inputSum = inputSum.Add(sco.Value)
 	}
 	if !inputSum.Equals(t.SiacoinOutputSum()) {
-		// the one case where this is acceptable
-		// is a liquidation, which should mint
-		// coins to account for those that were initially burned
-		if types.IsNFTLiquidationTransaction(t) {
+		// the cases where this is acceptable
+		// are liquidations and reclaims, which should mint
+		// coins to account for those that were initially burned,
+		// unless the lockup was already reclaimed, unstakes
+		// and stake reward claims, which mint burned stake, and
+		// insurance claims and releases, which mint burned collateral
+		minting := inputSum.Cmp(t.SiacoinOutputSum()) < 0
+		mintsNFTCollateral := types.IsNFTLiquidationTransaction(t) || types.IsNFTReclaimTransaction(t) ||
+			types.IsNFTUnstakeTransaction(t) || types.IsNFTStakeRewardTransaction(t) ||
+			types.IsNFTInsuranceClaimTransaction(t) || types.IsNFTInsuranceReleaseTransaction(t)
+		// The minted amounts are looked up in the NFT index. While it is
+		// bootstrapped, the snapshot's checkpoint vouches for them.
+		if minting && mintsNFTCollateral && nftIndexBootstrapping(tx) {
+			return nil
+		}
+		if minting && (types.IsNFTLiquidationTransaction(t) || types.IsNFTReclaimTransaction(t)) {
+			nft, _ := types.ExtractNFTFromTransaction(t)
+			lockup, err := viewNFTLockupInternal(tx, nft)
+			reclaimed := err == nil && lockup.Reclaimed
 			delta := t.SiacoinOutputSum().Sub(inputSum)
-			if delta.Equals(types.NFTLockupAmount) {
+			if !reclaimed && delta.Equals(types.NFTLockupAmount) {
+				return nil
+			}
+		}
+		if minting && (types.IsNFTUnstakeTransaction(t) || types.IsNFTStakeRewardTransaction(t)) {
+			if t.SiacoinOutputSum().Sub(inputSum).Equals(nftStakeMinted(tx, t)) {
+				return nil
+			}
+		}
+		if minting && (types.IsNFTInsuranceClaimTransaction(t) || types.IsNFTInsuranceReleaseTransaction(t)) {
+			if t.SiacoinOutputSum().Sub(inputSum).Equals(nftInsuranceMinted(tx, t)) {
 				return nil
 			}
 		}
@@ -405,6 +967,24 @@ func foundationUpdateIsSigned(tx *bolt.Tx, t types.Transaction) bool {
 // validTransaction checks that all fields are valid within the current
 // consensus state. If not an error is returned.
 func validTransaction(tx *bolt.Tx, t types.Transaction) error {
+	// The NFT rules depend on the NFT index, which isn't built while it is
+	// bootstrapped from a snapshot. The blocks applied meanwhile are pinned
+	// by the snapshot's checkpoint instead, see generateAndApplyDiff.
+	if nftIndexBootstrapping(tx) {
+		return errNFTIndexBootstrapping
+	}
+	err := validTransactionWithoutNFTIndex(tx, t)
+	if err != nil {
+		return err
+	}
+	return validNFTIndexRules(tx, t)
+}
+
+// validTransactionWithoutNFTIndex checks the fields of a transaction which can
+// be validated without the NFT index, i.e. everything except for the NFT
+// rules. Blocks applied while the NFT index is bootstrapped are only checked
+// by this function.
+func validTransactionWithoutNFTIndex(tx *bolt.Tx, t types.Transaction) error {
 	// StandaloneValid will check things like signatures and properties that
 	// should be inherent to the transaction. (storage proof rules, etc.)
 	currentHeight := blockHeight(tx)
@@ -435,10 +1015,62 @@ func validTransaction(tx *bolt.Tx, t types.Transaction) error {
 	if err != nil {
 		return err
 	}
+	if nftRuleActiveInternal(tx, nftRuleStrictArbitraryData) {
+		err = types.ValidateNFTTransaction(t)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validNFTIndexRules checks that a transaction follows the NFT rules which
+// depend on the NFT index.
+func validNFTIndexRules(tx *bolt.Tx, t types.Transaction) error {
+	err := validNFTGovernance(tx, t)
+	if err != nil {
+		return err
+	}
 	err = validNFTCustody(tx, t)
 	if err != nil {
 		return err
 	}
+	err = validNFTBridge(tx, t)
+	if err != nil {
+		return err
+	}
+	err = validNFTIdentity(tx, t)
+	if err != nil {
+		return err
+	}
+	err = validNFTTransferPolicy(tx, t)
+	if err != nil {
+		return err
+	}
+	err = validNFTStake(tx, t)
+	if err != nil {
+		return err
+	}
+	err = validNFTContentReference(tx, t)
+	if err != nil {
+		return err
+	}
+	err = validNFTInsurance(tx, t)
+	if err != nil {
+		return err
+	}
+	err = validNFTApproval(tx, t)
+	if err != nil {
+		return err
+	}
+	err = validNFTDispute(tx, t)
+	if err != nil {
+		return err
+	}
+	err = validNFTLicense(tx, t)
+	if err != nil {
+		return err
+	}
 	return nil
 }
 
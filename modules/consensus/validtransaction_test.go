@@ -968,3 +968,1283 @@ func TestValidArbitraryData(t *testing.T) {
 		t.Error("expected errUnsignedFoundationUpdate, got", err)
 	}
 }
+
+// TestValidNFTBridge probes the validNFTBridge function. It changes the
+// activation of the bridge rule and can't run in parallel.
+func TestValidNFTBridge(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	validate := func(txn types.Transaction) (err error) {
+		_ = cst.cs.db.View(func(tx *bolt.Tx) error {
+			err = validNFTBridge(tx, txn)
+			return nil
+		})
+		return
+	}
+
+	// Lock an NFT for a bridge which only requires a single signature.
+	nft := types.NftCustody{FileMerkleRoot: crypto.HashBytes([]byte(t.Name()))}
+	bridge := types.UnlockConditions{
+		PublicKeys:         []types.SiaPublicKey{{Algorithm: types.SignatureEd25519, Key: fastrand.Bytes(crypto.PublicKeySize)}},
+		SignaturesRequired: 1,
+	}
+	lock := types.Transaction{
+		SiacoinOutputs: []types.SiacoinOutput{
+			{UnlockHash: types.NFTStoragePoolUnlockConditions.UnlockHash(), Value: types.NFTTransferCost},
+			{UnlockHash: bridge.UnlockHash(), Value: types.OneBaseUnit},
+		},
+		ArbitraryData: [][]byte{types.NFTBridgeLockArbitraryData(nft, types.NFTBridgeClaim{Chain: "eth", Recipient: "0x01"})},
+	}
+	unlock := types.NFTBridgeUnlockTransaction(nft, types.SiacoinOutputID{1}, bridge, types.UnlockHash{1}, []uint64{0})
+
+	// Bridge transactions are rejected before the rule activates.
+	setNFTRuleActivationHeight(t, nftRuleBridge, cst.cs.Height()+2)
+	if err := validate(lock); !errors.Contains(err, errNFTBridgeInactive) {
+		t.Fatal("expected errNFTBridgeInactive but got", err)
+	}
+	setNFTRuleActivationHeight(t, nftRuleBridge, cst.cs.Height()+1)
+
+	// An NFT which isn't locked can't be unlocked.
+	if err := validate(unlock); !errors.Contains(err, errNFTNotBridgeLocked) {
+		t.Fatal("expected errNFTNotBridgeLocked but got", err)
+	}
+	err = cst.cs.db.Update(func(tx *bolt.Tx) error {
+		applyArbitraryData(tx, &processedBlock{Height: cst.cs.Height() + 1}, lock)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The locked NFT can't be transferred or locked again.
+	transfer := lock
+	transfer.ArbitraryData = [][]byte{types.NFTArbitraryData(types.NFTTransferTag, nft)}
+	for _, txn := range []types.Transaction{transfer, lock} {
+		if err := validate(txn); !errors.Contains(err, errNFTBridgeLocked) {
+			t.Fatal("expected errNFTBridgeLocked but got", err)
+		}
+	}
+
+	// The unlock needs a single custody output and enough attestations.
+	invalid := unlock
+	invalid.SiacoinOutputs = append(invalid.SiacoinOutputs, invalid.SiacoinOutputs[0])
+	if err := validate(invalid); !errors.Contains(err, errIncorrectNFTBridgeUnlock) {
+		t.Fatal("expected errIncorrectNFTBridgeUnlock but got", err)
+	}
+	if err := validate(unlock); !errors.Contains(err, errNFTBridgeAttestations) {
+		t.Fatal("expected errNFTBridgeAttestations but got", err)
+	}
+}
+
+// TestValidNFTIdentity probes the validNFTIdentity function.
+func TestValidNFTIdentity(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	validate := func(txn types.Transaction) (err error) {
+		_ = cst.cs.db.View(func(tx *bolt.Tx) error {
+			err = validNFTIdentity(tx, txn)
+			return nil
+		})
+		return
+	}
+
+	// Mint an NFT with an identity to its creator.
+	_, pk := crypto.GenerateKeyPair()
+	nft := types.NftCustody{
+		FileMerkleRoot: crypto.HashBytes([]byte(t.Name())),
+		Creator:        pk,
+		Collection:     "art",
+	}
+	nft.ID = types.DeriveNftID(nft.Creator, nft.Collection, nft.FileMerkleRoot, nft.Nonce)
+	mint := types.Transaction{
+		SiacoinOutputs: []types.SiacoinOutput{
+			{UnlockHash: types.NFTLockupUnlockConditions.UnlockHash(), Value: types.NFTLockupAmount},
+			{UnlockHash: types.NFTStoragePoolUnlockConditions.UnlockHash(), Value: types.NFTHostAmount},
+			{UnlockHash: nft.CreatorUnlockHash(), Value: types.OneBaseUnit},
+		},
+		ArbitraryData: [][]byte{types.NFTArbitraryData(types.NFTMintTag, nft)},
+	}
+
+	// Identified entries are rejected before the rule activates.
+	setNFTRuleActivationHeight(t, nftRuleIdentifiers, cst.cs.Height()+2)
+	if err := validate(mint); !errors.Contains(err, errNFTIdentifiersInactive) {
+		t.Fatal("expected errNFTIdentifiersInactive but got", err)
+	}
+	setNFTRuleActivationHeight(t, nftRuleIdentifiers, cst.cs.Height()+1)
+	if err := validate(mint); err != nil {
+		t.Fatal(err)
+	}
+
+	// The NFT can't be minted to anyone but its creator.
+	stolen := mint
+	stolen.SiacoinOutputs = append([]types.SiacoinOutput{}, mint.SiacoinOutputs...)
+	stolen.SiacoinOutputs[2].UnlockHash = types.UnlockHash{1}
+	if err := validate(stolen); !errors.Contains(err, errNFTNotMintedToCreator) {
+		t.Fatal("expected errNFTNotMintedToCreator but got", err)
+	}
+
+	// Once minted, the NftID can't be minted again even though duplicate
+	// mints of legacy NFTs are allowed.
+	setNFTRuleActivationHeight(t, nftRuleRejectDuplicateMint, nftRuleNotScheduled)
+	err = cst.cs.db.Update(func(tx *bolt.Tx) error {
+		applyArbitraryData(tx, &processedBlock{Height: cst.cs.Height() + 1}, mint)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := validate(mint); !errors.Contains(err, errDuplicateNFTMint) {
+		t.Fatal("expected errDuplicateNFTMint but got", err)
+	}
+
+	// A legacy mint whose merkle root equals the NftID can't overwrite the
+	// custody of the identified NFT.
+	legacy := mint
+	legacy.SiacoinOutputs = append([]types.SiacoinOutput{}, mint.SiacoinOutputs...)
+	legacy.SiacoinOutputs[2].UnlockHash = types.UnlockHash{1}
+	legacy.ArbitraryData = [][]byte{types.NFTArbitraryData(types.NFTMintTag, types.NftCustody{FileMerkleRoot: crypto.Hash(nft.ID)})}
+	err = cst.cs.db.View(func(tx *bolt.Tx) error {
+		return validNFTCustody(tx, legacy)
+	})
+	if !errors.Contains(err, errDuplicateNFTMint) {
+		t.Fatal("expected errDuplicateNFTMint but got", err)
+	}
+
+	// The same data can be minted as another NFT with a different nonce.
+	edition := nft
+	edition.Nonce++
+	edition.ID = types.DeriveNftID(edition.Creator, edition.Collection, edition.FileMerkleRoot, edition.Nonce)
+	mint.ArbitraryData = [][]byte{types.NFTArbitraryData(types.NFTMintTag, edition)}
+	if err := validate(mint); err != nil {
+		t.Fatal(err)
+	}
+
+	// The minted NFT is tracked by its NftID together with its identity.
+	found := cst.cs.FindNFTsForAddress(nft.CreatorUnlockHash())
+	if len(found) != 1 || found[0] != nft {
+		t.Fatal("unexpected nfts", found)
+	}
+	if _, err := cst.cs.ViewNFTCustody(types.NftCustody{FileMerkleRoot: nft.FileMerkleRoot}); err == nil {
+		t.Fatal("identified nft shouldn't be tracked by its merkle root")
+	}
+}
+
+// TestValidNFTEdition probes the edition checks of validNFTIdentity and
+// FindNFTEditions.
+func TestValidNFTEdition(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	validate := func(txn types.Transaction) (err error) {
+		_ = cst.cs.db.View(func(tx *bolt.Tx) error {
+			err = validNFTIdentity(tx, txn)
+			return nil
+		})
+		return
+	}
+	_, pk := crypto.GenerateKeyPair()
+	edition := func(index, editions uint64) (types.NftCustody, types.Transaction) {
+		nft := types.NftCustody{
+			FileMerkleRoot: crypto.HashBytes([]byte(t.Name())),
+			Creator:        pk,
+			Collection:     "prints",
+			Nonce:          index,
+			Editions:       editions,
+		}
+		nft.ID = types.DeriveNftID(nft.Creator, nft.Collection, nft.FileMerkleRoot, nft.Nonce)
+		return nft, types.Transaction{
+			SiacoinOutputs: []types.SiacoinOutput{
+				{UnlockHash: types.NFTLockupUnlockConditions.UnlockHash(), Value: types.NFTLockupAmount},
+				{UnlockHash: types.NFTStoragePoolUnlockConditions.UnlockHash(), Value: types.NFTHostAmount},
+				{UnlockHash: nft.CreatorUnlockHash(), Value: types.OneBaseUnit},
+			},
+			ArbitraryData: [][]byte{types.NFTArbitraryData(types.NFTMintTag, nft)},
+		}
+	}
+
+	// Editions are rejected before the rule activates.
+	first, mint := edition(2, 3)
+	setNFTRuleActivationHeight(t, nftRuleEditions, cst.cs.Height()+2)
+	if err := validate(mint); !errors.Contains(err, errNFTEditionsInactive) {
+		t.Fatal("expected errNFTEditionsInactive but got", err)
+	}
+	setNFTRuleActivationHeight(t, nftRuleEditions, cst.cs.Height()+1)
+	if err := validate(mint); err != nil {
+		t.Fatal(err)
+	}
+	err = cst.cs.db.Update(func(tx *bolt.Tx) error {
+		applyArbitraryData(tx, &processedBlock{Height: cst.cs.Height() + 1}, mint)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Further editions of the run need to agree on the number of editions.
+	if _, mint := edition(1, 4); !errors.Contains(validate(mint), errNFTEditionsMismatch) {
+		t.Fatal("expected errNFTEditionsMismatch")
+	}
+	second, mint := edition(1, 3)
+	if err := validate(mint); err != nil {
+		t.Fatal(err)
+	}
+	err = cst.cs.db.Update(func(tx *bolt.Tx) error {
+		applyArbitraryData(tx, &processedBlock{Height: cst.cs.Height() + 1}, mint)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Both editions are found by their merkle root, ordered by their index.
+	found := cst.cs.FindNFTEditions(first.FileMerkleRoot)
+	if len(found) != 2 || found[0].Nft != second || found[1].Nft != first {
+		t.Fatal("unexpected editions", found)
+	}
+	if found[0].Owner != second.CreatorUnlockHash() {
+		t.Fatal("edition has the wrong owner")
+	}
+	if found := cst.cs.FindNFTEditions(crypto.Hash{}); len(found) != 0 {
+		t.Fatal("unexpected editions", found)
+	}
+}
+
+// TestValidNFTTransferPolicy probes the validNFTTransferPolicy function.
+func TestValidNFTTransferPolicy(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	validate := func(txn types.Transaction) (err error) {
+		_ = cst.cs.db.View(func(tx *bolt.Tx) error {
+			err = validNFTTransferPolicy(tx, txn)
+			return nil
+		})
+		return
+	}
+	apply := func(txn types.Transaction) {
+		err := cst.cs.db.Update(func(tx *bolt.Tx) error {
+			applyArbitraryData(tx, &processedBlock{Height: cst.cs.Height() + 1}, txn)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	mint := func(name string, policy types.NFTTransferPolicy) (types.NftCustody, types.Transaction) {
+		nft := types.NftCustody{
+			FileMerkleRoot: crypto.HashBytes([]byte(t.Name() + name)),
+			TransferPolicy: policy,
+		}
+		return nft, types.Transaction{
+			SiacoinOutputs: []types.SiacoinOutput{
+				{UnlockHash: types.NFTLockupUnlockConditions.UnlockHash(), Value: types.NFTLockupAmount},
+				{UnlockHash: types.NFTStoragePoolUnlockConditions.UnlockHash(), Value: types.NFTHostAmount},
+				{UnlockHash: types.UnlockHash{1}, Value: types.OneBaseUnit},
+			},
+			ArbitraryData: [][]byte{types.NFTArbitraryData(types.NFTMintTag, nft)},
+		}
+	}
+	entry := func(tag []byte, nft types.NftCustody) types.Transaction {
+		return types.Transaction{ArbitraryData: [][]byte{types.NFTArbitraryData(tag, nft)}}
+	}
+	height := cst.cs.Height()
+
+	// Mints with a policy are rejected before the rule activates.
+	soulbound, soulboundMint := mint("soulbound", types.NFTTransferPolicy{Kind: types.NFTTransferSoulbound})
+	setNFTRuleActivationHeight(t, nftRuleTransferPolicy, height+2)
+	if err := validate(soulboundMint); !errors.Contains(err, errNFTTransferPolicyInactive) {
+		t.Fatal("expected errNFTTransferPolicyInactive but got", err)
+	}
+	setNFTRuleActivationHeight(t, nftRuleTransferPolicy, height+1)
+	if err := validate(soulboundMint); err != nil {
+		t.Fatal(err)
+	}
+	apply(soulboundMint)
+	if policy := cst.cs.ViewNFTTransferPolicy(soulbound); policy != soulbound.TransferPolicy {
+		t.Fatal("policy wasn't recorded", policy)
+	}
+
+	// Soulbound NFTs can't be transferred or locked by a bridge, but they
+	// can be liquidated and reclaimed.
+	for _, tag := range [][]byte{types.NFTTransferTag, types.NFTBridgeLockTag} {
+		if err := validate(entry(tag, soulbound)); !errors.Contains(err, errNFTNotTransferable) {
+			t.Fatalf("%s: expected errNFTNotTransferable but got %v", tag, err)
+		}
+	}
+	for _, tag := range [][]byte{types.NFTLiquidationTag, types.NFTReclaimTag} {
+		if err := validate(entry(tag, soulbound)); err != nil {
+			t.Fatalf("%s: %v", tag, err)
+		}
+	}
+
+	// NFTs with a height become transferable in the block at that height.
+	locked, lockedMint := mint("locked", types.NFTTransferPolicy{Kind: types.NFTTransferAfterHeight, Height: height + 2})
+	unlocked, unlockedMint := mint("unlocked", types.NFTTransferPolicy{Kind: types.NFTTransferAfterHeight, Height: height + 1})
+	apply(lockedMint)
+	apply(unlockedMint)
+	if err := validate(entry(types.NFTTransferTag, locked)); !errors.Contains(err, errNFTNotTransferable) {
+		t.Fatal("expected errNFTNotTransferable but got", err)
+	}
+	if err := validate(entry(types.NFTTransferTag, unlocked)); err != nil {
+		t.Fatal(err)
+	}
+
+	// NFTs minted without a policy are freely transferable.
+	free, freeMint := mint("free", types.NFTTransferPolicy{})
+	apply(freeMint)
+	if err := validate(entry(types.NFTTransferTag, free)); err != nil {
+		t.Fatal(err)
+	}
+
+	// A policy can't be used to mint identified NFTs before identifiers are
+	// active.
+	_, pk := crypto.GenerateKeyPair()
+	identified := types.NftCustody{
+		FileMerkleRoot: crypto.HashBytes([]byte(t.Name())),
+		Creator:        pk,
+		TransferPolicy: soulbound.TransferPolicy,
+	}
+	identified.ID = types.DeriveNftID(identified.Creator, identified.Collection, identified.FileMerkleRoot, identified.Nonce)
+	setNFTRuleActivationHeight(t, nftRuleIdentifiers, height+2)
+	err = cst.cs.db.View(func(tx *bolt.Tx) error {
+		return validNFTIdentity(tx, entry(types.NFTMintTag, identified))
+	})
+	if !errors.Contains(err, errNFTIdentifiersInactive) {
+		t.Fatal("expected errNFTIdentifiersInactive but got", err)
+	}
+}
+
+// TestValidNFTStake probes the validNFTStake function and the stake accounting
+// of applyNFTStake.
+func TestValidNFTStake(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	validate := func(txn types.Transaction) (err error) {
+		_ = cst.cs.db.View(func(tx *bolt.Tx) error {
+			err = validNFTStake(tx, txn)
+			return nil
+		})
+		return
+	}
+	apply := func(txn types.Transaction) {
+		err := cst.cs.db.Update(func(tx *bolt.Tx) error {
+			pb := &processedBlock{Height: cst.cs.Height() + 1}
+			applyNFTStake(tx, pb, txn)
+			applyArbitraryData(tx, pb, txn)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	data := fastrand.Bytes(int(crypto.SegmentSize) * 5)
+	nft := types.NftCustody{FileMerkleRoot: crypto.MerkleRoot(data)}
+	owner := types.UnlockHash{1}
+	apply(types.Transaction{
+		SiacoinOutputs: []types.SiacoinOutput{
+			{UnlockHash: types.NFTLockupUnlockConditions.UnlockHash(), Value: types.NFTLockupAmount},
+			{UnlockHash: types.NFTStoragePoolUnlockConditions.UnlockHash(), Value: types.NFTHostAmount},
+			{UnlockHash: owner, Value: types.OneBaseUnit},
+		},
+		ArbitraryData: [][]byte{types.NFTArbitraryData(types.NFTMintTag, nft)},
+	})
+	stakeTxn := types.Transaction{
+		SiacoinOutputs: []types.SiacoinOutput{
+			{UnlockHash: owner, Value: types.OneBaseUnit},
+			{UnlockHash: types.NFTStakingPoolUnlockHash, Value: types.NFTMinStake},
+		},
+		ArbitraryData: [][]byte{types.NFTArbitraryData(types.NFTStakeTag, nft)},
+	}
+
+	// Stakes are rejected before the rule activates.
+	height := cst.cs.Height()
+	setNFTRuleActivationHeight(t, nftRuleStaking, height+2)
+	if err := validate(stakeTxn); !errors.Contains(err, errNFTStakingInactive) {
+		t.Fatal("expected errNFTStakingInactive but got", err)
+	}
+	setNFTRuleActivationHeight(t, nftRuleStaking, height+1)
+
+	// Stakes must keep the NFT at its address and pay at least the minimum
+	// stake to the staking pool. The stake also has to spend the custody
+	// output, which this transaction doesn't.
+	small := stakeTxn
+	small.SiacoinOutputs = []types.SiacoinOutput{stakeTxn.SiacoinOutputs[0], {UnlockHash: types.NFTStakingPoolUnlockHash, Value: types.OneBaseUnit}}
+	moved := stakeTxn
+	moved.SiacoinOutputs = []types.SiacoinOutput{{UnlockHash: types.UnlockHash{2}, Value: types.OneBaseUnit}, stakeTxn.SiacoinOutputs[1]}
+	for _, txn := range []types.Transaction{small, moved} {
+		if err := validate(txn); !errors.Contains(err, errIncorrectNFTStake) {
+			t.Fatal("expected errIncorrectNFTStake but got", err)
+		}
+	}
+	if err := validate(stakeTxn); !errors.Contains(err, errIncorrectNFTCustody) {
+		t.Fatal("expected errIncorrectNFTCustody but got", err)
+	}
+
+	// Unstaking requires a stake.
+	unstakeTxn := types.Transaction{
+		SiacoinOutputs: []types.SiacoinOutput{
+			{UnlockHash: owner, Value: types.OneBaseUnit},
+			{UnlockHash: owner, Value: types.NFTMinStake},
+		},
+		ArbitraryData: [][]byte{types.NFTArbitraryData(types.NFTUnstakeTag, nft)},
+	}
+	if err := validate(unstakeTxn); !errors.Contains(err, errNFTNotStaked) {
+		t.Fatal("expected errNFTNotStaked but got", err)
+	}
+
+	// Once staked the NFT can only be unstaked.
+	apply(stakeTxn)
+	stake, err := cst.cs.ViewNFTStake(nft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stake.Root != nft.FileMerkleRoot || !stake.Amount.Equals(types.NFTMinStake) {
+		t.Fatal("stake wasn't recorded", stake)
+	}
+	rootStake := cst.cs.ViewNFTRootStake(nft.FileMerkleRoot)
+	if !rootStake.Staked.Equals(types.NFTMinStake) || !rootStake.Remaining.Equals(types.NFTMinStake) {
+		t.Fatal("root stake wasn't recorded", rootStake)
+	}
+	for _, tag := range [][]byte{types.NFTTransferTag, types.NFTLiquidationTag, types.NFTBridgeLockTag, types.NFTStakeTag} {
+		txn := types.Transaction{ArbitraryData: [][]byte{types.NFTArbitraryData(tag, nft)}}
+		if err := validate(txn); !errors.Contains(err, errNFTStaked) {
+			t.Fatalf("%s: expected errNFTStaked but got %v", tag, err)
+		}
+	}
+
+	// Reward claims must pay out the reward to the address their proof was
+	// made for.
+	payout := types.UnlockHash{3}
+	claimTxn := func(claim types.NFTStakeRewardClaim, sco types.SiacoinOutput) types.Transaction {
+		return types.Transaction{
+			SiacoinOutputs: []types.SiacoinOutput{sco},
+			ArbitraryData:  [][]byte{types.NFTStakeRewardArbitraryData(claim)},
+		}
+	}
+	parentID := cst.cs.CurrentBlock().ID()
+	claim := types.NewNFTStakeRewardClaim(data, parentID, payout)
+	index := types.NFTStakeRewardSegmentIndex(parentID, claim.Root, payout, claim.NumSegments)
+	reward := rootStake.Reward()
+	valid := claimTxn(claim, types.SiacoinOutput{UnlockHash: payout, Value: reward})
+	if err := validate(claimTxn(claim, types.SiacoinOutput{UnlockHash: payout, Value: reward.Add(types.OneBaseUnit)})); !errors.Contains(err, errIncorrectNFTStakeReward) {
+		t.Fatal("expected errIncorrectNFTStakeReward but got", err)
+	}
+	// The other address and block have to choose a different segment.
+	other := types.UnlockHash{4}
+	for types.NFTStakeRewardSegmentIndex(parentID, claim.Root, other, claim.NumSegments) == index {
+		other[0]++
+	}
+	if err := validate(claimTxn(claim, types.SiacoinOutput{UnlockHash: other, Value: reward})); !errors.Contains(err, errNFTStakeRewardProof) {
+		t.Fatal("expected errNFTStakeRewardProof but got", err)
+	}
+	var staleID types.BlockID
+	for types.NFTStakeRewardSegmentIndex(staleID, claim.Root, payout, claim.NumSegments) == index {
+		staleID[0]++
+	}
+	stale := types.NewNFTStakeRewardClaim(data, staleID, payout)
+	if err := validate(claimTxn(stale, types.SiacoinOutput{UnlockHash: payout, Value: reward})); !errors.Contains(err, errNFTStakeRewardProof) {
+		t.Fatal("expected errNFTStakeRewardProof but got", err)
+	}
+	if err := validate(valid); err != nil {
+		t.Fatal(err)
+	}
+
+	// Paying out the reward starts a new reward period.
+	apply(valid)
+	rootStake = cst.cs.ViewNFTRootStake(nft.FileMerkleRoot)
+	if !rootStake.Remaining.Equals(types.NFTMinStake.Sub(reward)) || !rootStake.Paid.Equals(reward) || rootStake.LastReward != height+1 {
+		t.Fatal("reward wasn't accounted", rootStake)
+	}
+	if err := validate(valid); !errors.Contains(err, errNFTStakeRewardUnavailable) {
+		t.Fatal("expected errNFTStakeRewardUnavailable but got", err)
+	}
+
+	// Unstakes can't move the NFT to another address.
+	movedUnstake := unstakeTxn
+	movedUnstake.SiacoinOutputs = []types.SiacoinOutput{{UnlockHash: types.UnlockHash{2}, Value: types.OneBaseUnit}, unstakeTxn.SiacoinOutputs[1]}
+	if err := validate(movedUnstake); !errors.Contains(err, errIncorrectNFTUnstake) {
+		t.Fatal("expected errIncorrectNFTUnstake but got", err)
+	}
+
+	// Unstaking returns what is left of the stake.
+	err = cst.cs.db.View(func(tx *bolt.Tx) error {
+		if minted := nftStakeMinted(tx, unstakeTxn); !minted.Equals(rootStake.Remaining) {
+			t.Fatal("unstake should mint the remaining stake", minted)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	apply(unstakeTxn)
+	if _, err := cst.cs.ViewNFTStake(nft); err == nil {
+		t.Fatal("stake wasn't removed")
+	}
+	rootStake = cst.cs.ViewNFTRootStake(nft.FileMerkleRoot)
+	if !rootStake.Staked.IsZero() || !rootStake.Remaining.IsZero() || !rootStake.Paid.Equals(types.NFTMinStake) {
+		t.Fatal("stake wasn't released", rootStake)
+	}
+}
+
+// TestValidNFTContentReference tests that mints with a content reference are
+// only valid once content references are active.
+func TestValidNFTContentReference(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	validate := func(txn types.Transaction) (err error) {
+		_ = cst.cs.db.View(func(tx *bolt.Tx) error {
+			err = validNFTContentReference(tx, txn)
+			return nil
+		})
+		return
+	}
+	nft := types.NftCustody{FileMerkleRoot: crypto.HashBytes([]byte(t.Name()))}
+	referenced := types.Transaction{ArbitraryData: [][]byte{types.NFTContentReferenceArbitraryData(nft, types.NFTRawCID([]byte(t.Name())))}}
+	plain := types.Transaction{ArbitraryData: [][]byte{types.NFTArbitraryData(types.NFTMintTag, nft)}}
+
+	height := cst.cs.Height()
+	setNFTRuleActivationHeight(t, nftRuleContentReferences, height+2)
+	if err := validate(referenced); !errors.Contains(err, errNFTReferencesInactive) {
+		t.Fatal("expected errNFTReferencesInactive but got", err)
+	}
+	if err := validate(plain); err != nil {
+		t.Fatal(err)
+	}
+	setNFTRuleActivationHeight(t, nftRuleContentReferences, height+1)
+	if err := validate(referenced); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestValidNFTLicense tests that mints with a license are only valid once
+// licenses are active, and that the license of a mint is recorded.
+func TestValidNFTLicense(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	validate := func(txn types.Transaction) (err error) {
+		_ = cst.cs.db.View(func(tx *bolt.Tx) error {
+			err = validNFTLicense(tx, txn)
+			return nil
+		})
+		return
+	}
+	nft := types.NftCustody{FileMerkleRoot: crypto.HashBytes([]byte(t.Name()))}
+	license := types.NFTSPDXLicense("CC-BY-4.0")
+	licensed := types.Transaction{
+		SiacoinOutputs: []types.SiacoinOutput{
+			{UnlockHash: types.NFTLockupUnlockConditions.UnlockHash(), Value: types.NFTLockupAmount},
+			{UnlockHash: types.NFTStoragePoolUnlockConditions.UnlockHash(), Value: types.NFTHostAmount},
+			{UnlockHash: types.UnlockHash{1}, Value: types.OneBaseUnit},
+		},
+		ArbitraryData: [][]byte{types.NFTLicenseArbitraryData(nft, license)},
+	}
+	plain := types.Transaction{ArbitraryData: [][]byte{types.NFTArbitraryData(types.NFTMintTag, nft)}}
+
+	height := cst.cs.Height()
+	setNFTRuleActivationHeight(t, nftRuleLicenses, height+2)
+	if err := validate(licensed); !errors.Contains(err, errNFTLicensesInactive) {
+		t.Fatal("expected errNFTLicensesInactive but got", err)
+	}
+	if err := validate(plain); err != nil {
+		t.Fatal(err)
+	}
+	setNFTRuleActivationHeight(t, nftRuleLicenses, height+1)
+	if err := validate(licensed); err != nil {
+		t.Fatal(err)
+	}
+
+	// The license is recorded when the mint is applied.
+	if _, err := cst.cs.ViewNFTLicense(nft); err == nil {
+		t.Fatal("license of an unminted nft")
+	}
+	err = cst.cs.db.Update(func(tx *bolt.Tx) error {
+		applyArbitraryData(tx, &processedBlock{Height: height + 1}, licensed)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l, err := cst.cs.ViewNFTLicense(nft); err != nil || l != license {
+		t.Fatal("license wasn't recorded", l, err)
+	}
+}
+
+// TestValidNFTApproval probes the validNFTApproval function and the approval
+// bookkeeping of applyNFTApproval.
+func TestValidNFTApproval(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	validate := func(txn types.Transaction) (err error) {
+		_ = cst.cs.db.View(func(tx *bolt.Tx) error {
+			err = validNFTApproval(tx, txn)
+			return nil
+		})
+		return
+	}
+	operatorValid := func(txn types.Transaction) (valid bool) {
+		_ = cst.cs.db.View(func(tx *bolt.Tx) error {
+			valid = nftValidOperator(tx, txn)
+			return nil
+		})
+		return
+	}
+	apply := func(txn types.Transaction) {
+		err := cst.cs.db.Update(func(tx *bolt.Tx) error {
+			pb := &processedBlock{Height: cst.cs.Height() + 1}
+			applyNFTApproval(tx, pb, txn)
+			applyArbitraryData(tx, pb, txn)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	nft := types.NftCustody{FileMerkleRoot: crypto.HashBytes([]byte(t.Name()))}
+	ownerUC := types.UnlockConditions{Timelock: 1}
+	operatorUC := types.UnlockConditions{Timelock: 2}
+	owner, operator := ownerUC.UnlockHash(), operatorUC.UnlockHash()
+	apply(types.Transaction{
+		SiacoinOutputs: []types.SiacoinOutput{
+			{UnlockHash: types.NFTLockupUnlockConditions.UnlockHash(), Value: types.NFTLockupAmount},
+			{UnlockHash: types.NFTStoragePoolUnlockConditions.UnlockHash(), Value: types.NFTHostAmount},
+			{UnlockHash: owner, Value: types.OneBaseUnit},
+		},
+		ArbitraryData: [][]byte{types.NFTArbitraryData(types.NFTMintTag, nft)},
+	})
+	approveTxn := types.Transaction{
+		SiacoinInputs: []types.SiacoinInput{{UnlockConditions: ownerUC}},
+		SiacoinOutputs: []types.SiacoinOutput{
+			{UnlockHash: owner, Value: types.OneBaseUnit},
+			{UnlockHash: operator, Value: types.OneBaseUnit},
+		},
+		ArbitraryData: [][]byte{types.NFTArbitraryData(types.NFTApproveTag, nft)},
+	}
+	transferTxn := types.Transaction{
+		SiacoinInputs:  []types.SiacoinInput{{UnlockConditions: operatorUC}},
+		SiacoinOutputs: []types.SiacoinOutput{{UnlockHash: types.UnlockHash{3}, Value: types.OneBaseUnit}},
+		ArbitraryData:  [][]byte{types.NFTArbitraryData(types.NFTTransferTag, nft)},
+	}
+
+	// Approvals are rejected before the rule activates.
+	height := cst.cs.Height()
+	setNFTRuleActivationHeight(t, nftRuleApprovals, height+2)
+	if err := validate(approveTxn); !errors.Contains(err, errNFTApprovalsInactive) {
+		t.Fatal("expected errNFTApprovalsInactive but got", err)
+	}
+	setNFTRuleActivationHeight(t, nftRuleApprovals, height+1)
+
+	// Approvals must keep the NFT at its address and pay a ticket of one base
+	// unit to another address.
+	toOwner := approveTxn
+	toOwner.SiacoinOutputs = []types.SiacoinOutput{approveTxn.SiacoinOutputs[0], {UnlockHash: owner, Value: types.OneBaseUnit}}
+	large := approveTxn
+	large.SiacoinOutputs = []types.SiacoinOutput{approveTxn.SiacoinOutputs[0], {UnlockHash: operator, Value: types.OneBaseUnit.Mul64(2)}}
+	moved := approveTxn
+	moved.SiacoinOutputs = []types.SiacoinOutput{approveTxn.SiacoinOutputs[1]}
+	for _, txn := range []types.Transaction{toOwner, large, moved} {
+		if err := validate(txn); !errors.Contains(err, errIncorrectNFTApproval) {
+			t.Fatal("expected errIncorrectNFTApproval but got", err)
+		}
+	}
+	// Only the owner can approve an operator.
+	stolen := approveTxn
+	stolen.SiacoinInputs = []types.SiacoinInput{{UnlockConditions: operatorUC}}
+	if err := validate(stolen); !errors.Contains(err, errIncorrectNFTCustody) {
+		t.Fatal("expected errIncorrectNFTCustody but got", err)
+	}
+	if err := validate(approveTxn); err != nil {
+		t.Fatal(err)
+	}
+
+	// The operator can only transfer the NFT once it is approved.
+	if operatorValid(transferTxn) {
+		t.Fatal("operator shouldn't be valid before the approval")
+	}
+	apply(approveTxn)
+	approval, err := cst.cs.ViewNFTApproval(nft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if approval.Operator != operator || approval.Height != height+1 {
+		t.Fatal("approval wasn't recorded", approval)
+	}
+	if !operatorValid(transferTxn) {
+		t.Fatal("approved operator should be valid")
+	}
+
+	// Revocations clear the approval.
+	revokeTxn := approveTxn
+	revokeTxn.SiacoinOutputs = approveTxn.SiacoinOutputs[:1]
+	if err := validate(revokeTxn); err != nil {
+		t.Fatal(err)
+	}
+	apply(revokeTxn)
+	if _, err := cst.cs.ViewNFTApproval(nft); err == nil {
+		t.Fatal("approval wasn't revoked")
+	}
+	if operatorValid(transferTxn) {
+		t.Fatal("operator shouldn't be valid after the revocation")
+	}
+
+	// Transfers clear the approval as well.
+	apply(approveTxn)
+	apply(transferTxn)
+	if _, err := cst.cs.ViewNFTApproval(nft); err == nil {
+		t.Fatal("approval wasn't cleared by the transfer")
+	}
+}
+
+// TestValidNFTGovernance probes the validNFTGovernance function.
+func TestValidNFTGovernance(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	validate := func(txn types.Transaction) (err error) {
+		_ = cst.cs.db.View(func(tx *bolt.Tx) error {
+			err = validNFTGovernance(tx, txn)
+			return nil
+		})
+		return
+	}
+	nft := types.NftCustody{FileMerkleRoot: crypto.HashBytes([]byte(t.Name()))}
+	ownerUC := types.UnlockConditions{Timelock: 1}
+	ownerOutput := types.SiacoinOutputID{1}
+	err = cst.cs.db.Update(func(tx *bolt.Tx) error {
+		addSiacoinOutput(tx, ownerOutput, types.SiacoinOutput{UnlockHash: ownerUC.UnlockHash(), Value: types.OneBaseUnit})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Spends of the lockup pool need the authorization of the governance.
+	spendTxn := types.Transaction{
+		SiacoinInputs:  []types.SiacoinInput{{UnlockConditions: types.NFTLockupUnlockConditions}},
+		SiacoinOutputs: []types.SiacoinOutput{{UnlockHash: types.UnlockHash{1}, Value: types.NFTLockupAmount}},
+	}
+	if err := validate(spendTxn); !errors.Contains(err, errNFTGovernanceUnauthorized) {
+		t.Fatal("expected errNFTGovernanceUnauthorized, got", err)
+	}
+	authorized := spendTxn
+	authorized.SiacoinInputs = append(authorized.SiacoinInputs, types.SiacoinInput{UnlockConditions: types.NFTGovernanceUnlockConditions})
+	if err := validate(authorized); err != nil {
+		t.Fatal(err)
+	}
+
+	// So do liquidations which return the lockup, but not those which only
+	// burn the NFT.
+	liquidateTxn := types.Transaction{
+		SiacoinInputs: []types.SiacoinInput{{ParentID: ownerOutput, UnlockConditions: ownerUC}},
+		SiacoinOutputs: []types.SiacoinOutput{
+			{UnlockHash: types.UnlockHash{1}, Value: types.NFTLockupAmount},
+		},
+		ArbitraryData: [][]byte{types.NFTArbitraryData(types.NFTLiquidationTag, nft)},
+	}
+	if err := validate(liquidateTxn); !errors.Contains(err, errNFTGovernanceUnauthorized) {
+		t.Fatal("expected errNFTGovernanceUnauthorized, got", err)
+	}
+	burnTxn := liquidateTxn
+	burnTxn.SiacoinOutputs = []types.SiacoinOutput{{UnlockHash: types.UnlockHash{1}, Value: types.OneBaseUnit}}
+	if err := validate(burnTxn); err != nil {
+		t.Fatal(err)
+	}
+
+	// Before the rule activates, the governance isn't required.
+	setNFTRuleActivationHeight(t, nftRuleLockupGovernance, cst.cs.Height()+10)
+	if err := validate(spendTxn); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestValidNFTDispute probes the validNFTDispute function and the dispute
+// bookkeeping of applyNFTDispute.
+func TestValidNFTDispute(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	validate := func(txn types.Transaction) (err error) {
+		_ = cst.cs.db.View(func(tx *bolt.Tx) error {
+			err = validNFTDispute(tx, txn)
+			return nil
+		})
+		return
+	}
+	apply := func(txn types.Transaction) {
+		err := cst.cs.db.Update(func(tx *bolt.Tx) error {
+			pb := &processedBlock{Height: cst.cs.Height() + 1}
+			applyNFTDispute(tx, pb, txn)
+			applyArbitraryData(tx, pb, txn)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	nft := types.NftCustody{FileMerkleRoot: crypto.HashBytes([]byte(t.Name()))}
+	ownerUC := types.UnlockConditions{Timelock: 1}
+	owner := ownerUC.UnlockHash()
+	governanceInput := types.SiacoinInput{UnlockConditions: types.NFTGovernanceUnlockConditions}
+	freezeTxn := types.Transaction{
+		SiacoinInputs: []types.SiacoinInput{governanceInput},
+		ArbitraryData: [][]byte{types.NFTDisputeArbitraryData(types.NFTFreezeTag, nft, types.NFTDisputeReason{
+			Kind:        types.NFTDisputeStolenContent,
+			Description: "minted without the rights to the content",
+		})},
+	}
+	unfreezeTxn := types.Transaction{
+		SiacoinInputs: []types.SiacoinInput{governanceInput},
+		ArbitraryData: [][]byte{types.NFTDisputeArbitraryData(types.NFTUnfreezeTag, nft, types.NFTDisputeReason{})},
+	}
+	transferTxn := types.Transaction{
+		SiacoinInputs:  []types.SiacoinInput{{UnlockConditions: ownerUC}},
+		SiacoinOutputs: []types.SiacoinOutput{{UnlockHash: types.UnlockHash{3}, Value: types.OneBaseUnit}},
+		ArbitraryData:  [][]byte{types.NFTArbitraryData(types.NFTTransferTag, nft)},
+	}
+
+	// Flags are rejected before the rule activates.
+	height := cst.cs.Height()
+	setNFTRuleActivationHeight(t, nftRuleDisputes, height+2)
+	if err := validate(freezeTxn); !errors.Contains(err, errNFTDisputesInactive) {
+		t.Fatal("expected errNFTDisputesInactive but got", err)
+	}
+	setNFTRuleActivationHeight(t, nftRuleDisputes, height+1)
+
+	// Only known NFTs can be flagged.
+	if err := validate(freezeTxn); !errors.Contains(err, errNFTNotDisputable) {
+		t.Fatal("expected errNFTNotDisputable but got", err)
+	}
+	apply(types.Transaction{
+		SiacoinOutputs: []types.SiacoinOutput{
+			{UnlockHash: types.NFTLockupUnlockConditions.UnlockHash(), Value: types.NFTLockupAmount},
+			{UnlockHash: types.NFTStoragePoolUnlockConditions.UnlockHash(), Value: types.NFTHostAmount},
+			{UnlockHash: owner, Value: types.OneBaseUnit},
+		},
+		ArbitraryData: [][]byte{types.NFTArbitraryData(types.NFTMintTag, nft)},
+	})
+
+	// Flags need the authorization of the governance.
+	unauthorized := freezeTxn
+	unauthorized.SiacoinInputs = []types.SiacoinInput{{UnlockConditions: ownerUC}}
+	if err := validate(unauthorized); !errors.Contains(err, errNFTGovernanceUnauthorized) {
+		t.Fatal("expected errNFTGovernanceUnauthorized but got", err)
+	}
+	if err := validate(unfreezeTxn); !errors.Contains(err, errNFTNotFrozen) {
+		t.Fatal("expected errNFTNotFrozen but got", err)
+	}
+	if err := validate(freezeTxn); err != nil {
+		t.Fatal(err)
+	}
+	if err := validate(transferTxn); err != nil {
+		t.Fatal(err)
+	}
+
+	// Frozen NFTs can't be transferred and can't be frozen again.
+	apply(freezeTxn)
+	dispute, err := cst.cs.ViewNFTDispute(nft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !dispute.Frozen() || len(dispute.Flags) != 1 || dispute.Flags[0].TransactionID != freezeTxn.ID() ||
+		dispute.Flags[0].Reason.Kind != types.NFTDisputeStolenContent || dispute.Flags[0].Height != height+1 {
+		t.Fatalf("flag wasn't recorded %+v", dispute)
+	}
+	if !cst.cs.ViewNFTRootFrozen(nft.FileMerkleRoot) {
+		t.Fatal("root should be frozen")
+	}
+	if err := validate(transferTxn); !errors.Contains(err, errNFTFrozen) {
+		t.Fatal("expected errNFTFrozen but got", err)
+	}
+	for _, tag := range [][]byte{types.NFTReclaimTag, types.NFTStakeTag, types.NFTUnstakeTag, types.NFTInsuranceChallengeTag, types.NFTInsuranceClaimTag} {
+		txn := types.Transaction{ArbitraryData: [][]byte{types.NFTArbitraryData(tag, nft)}}
+		if err := validate(txn); !errors.Contains(err, errNFTFrozen) {
+			t.Fatalf("%s: expected errNFTFrozen but got %v", tag, err)
+		}
+	}
+	if err := validate(freezeTxn); !errors.Contains(err, errNFTAlreadyFrozen) {
+		t.Fatal("expected errNFTAlreadyFrozen but got", err)
+	}
+
+	// Unflags unfreeze the NFT and are kept in its history.
+	if err := validate(unfreezeTxn); err != nil {
+		t.Fatal(err)
+	}
+	apply(unfreezeTxn)
+	dispute, err = cst.cs.ViewNFTDispute(nft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dispute.Frozen() || len(dispute.Flags) != 2 {
+		t.Fatalf("unflag wasn't recorded %+v", dispute)
+	}
+	if cst.cs.ViewNFTRootFrozen(nft.FileMerkleRoot) {
+		t.Fatal("root shouldn't be frozen")
+	}
+	if err := validate(transferTxn); err != nil {
+		t.Fatal(err)
+	}
+	disputes, err := cst.cs.NFTDisputes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(disputes) != 1 || disputes[0].ID != nft.Identifier() {
+		t.Fatal("unexpected disputes", disputes)
+	}
+}
+
+// TestValidNFTCompositeTransfer probes the validation of transfers which pay
+// additional siacoin outputs.
+func TestValidNFTCompositeTransfer(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	validate := func(txn types.Transaction) (err error) {
+		_ = cst.cs.db.View(func(tx *bolt.Tx) error {
+			err = validNFTCustody(tx, txn)
+			return nil
+		})
+		return
+	}
+	nft := types.NftCustody{FileMerkleRoot: crypto.HashBytes([]byte(t.Name()))}
+	ownerUC := types.UnlockConditions{Timelock: 1}
+	err = cst.cs.db.Update(func(tx *bolt.Tx) error {
+		applyArbitraryData(tx, &processedBlock{Height: cst.cs.Height() + 1}, types.Transaction{
+			SiacoinOutputs: []types.SiacoinOutput{
+				{UnlockHash: types.NFTLockupUnlockConditions.UnlockHash(), Value: types.NFTLockupAmount},
+				{UnlockHash: types.NFTStoragePoolUnlockConditions.UnlockHash(), Value: types.NFTHostAmount},
+				{UnlockHash: ownerUC.UnlockHash(), Value: types.OneBaseUnit},
+			},
+			ArbitraryData: [][]byte{types.NFTArbitraryData(types.NFTMintTag, nft)},
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	buyer := types.UnlockHash{3}
+	sale := types.Transaction{
+		SiacoinInputs: []types.SiacoinInput{{UnlockConditions: ownerUC}},
+		SiacoinOutputs: []types.SiacoinOutput{
+			{UnlockHash: types.NFTStoragePoolUnlockConditions.UnlockHash(), Value: types.NFTTransferCost},
+			{UnlockHash: buyer, Value: types.OneBaseUnit},
+			{UnlockHash: types.UnlockHash{4}, Value: types.SiacoinPrecision.Mul64(90)},
+			{UnlockHash: types.UnlockHash{5}, Value: types.SiacoinPrecision.Mul64(10)},
+		},
+		ArbitraryData: [][]byte{types.NFTArbitraryData(types.NFTTransferTag, nft)},
+	}
+	transfer := sale
+	transfer.SiacoinOutputs = sale.SiacoinOutputs[:2]
+
+	// Transfers with payments are rejected before the rule activates.
+	height := cst.cs.Height()
+	setNFTRuleActivationHeight(t, nftRuleCompositeTransfers, height+2)
+	if err := validate(sale); !errors.Contains(err, errIncorrectTransferFees) {
+		t.Fatal("expected errIncorrectTransferFees but got", err)
+	}
+	if err := validate(transfer); err != nil {
+		t.Fatal(err)
+	}
+	setNFTRuleActivationHeight(t, nftRuleCompositeTransfers, height+1)
+	if err := validate(sale); err != nil {
+		t.Fatal(err)
+	}
+	if _, owner := types.ExtractNFTFromTransaction(sale); owner.UnlockHash != buyer {
+		t.Fatal("sale moves the NFT to the wrong address", owner)
+	}
+
+	// The colored coin has to precede the payments and hold one base unit,
+	// and the transfer fee still has to be paid.
+	paymentFirst := sale
+	paymentFirst.SiacoinOutputs = []types.SiacoinOutput{sale.SiacoinOutputs[0], sale.SiacoinOutputs[2], sale.SiacoinOutputs[1]}
+	largeCoin := sale
+	largeCoin.SiacoinOutputs = append([]types.SiacoinOutput{sale.SiacoinOutputs[0], {UnlockHash: buyer, Value: types.OneBaseUnit.Mul64(2)}}, sale.SiacoinOutputs[2:]...)
+	unpaid := sale
+	unpaid.SiacoinOutputs = sale.SiacoinOutputs[1:]
+	for _, txn := range []types.Transaction{paymentFirst, largeCoin, unpaid} {
+		if err := validate(txn); !errors.Contains(err, errIncorrectTransferFees) {
+			t.Fatal("expected errIncorrectTransferFees but got", err)
+		}
+	}
+
+	// The seller has to sign.
+	stolen := sale
+	stolen.SiacoinInputs = []types.SiacoinInput{{UnlockConditions: types.UnlockConditions{Timelock: 2}}}
+	if err := validate(stolen); !errors.Contains(err, errIncorrectNFTCustody) {
+		t.Fatal("expected errIncorrectNFTCustody but got", err)
+	}
+}
+
+// TestValidNFTReclaim tests that reclaims are only valid once reclaims are
+// active and that they keep the NFT at its address.
+func TestValidNFTReclaim(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	validate := func(txn types.Transaction) (err error) {
+		_ = cst.cs.db.View(func(tx *bolt.Tx) error {
+			err = validNFTCustody(tx, txn)
+			return nil
+		})
+		return
+	}
+	nft := types.NftCustody{FileMerkleRoot: crypto.HashBytes([]byte(t.Name()))}
+	ownerUC := types.UnlockConditions{Timelock: 1}
+	err = cst.cs.db.Update(func(tx *bolt.Tx) error {
+		applyArbitraryData(tx, &processedBlock{Height: cst.cs.Height() + 1}, types.Transaction{
+			SiacoinOutputs: []types.SiacoinOutput{
+				{UnlockHash: types.NFTLockupUnlockConditions.UnlockHash(), Value: types.NFTLockupAmount},
+				{UnlockHash: types.NFTStoragePoolUnlockConditions.UnlockHash(), Value: types.NFTHostAmount},
+				{UnlockHash: ownerUC.UnlockHash(), Value: types.OneBaseUnit},
+			},
+			ArbitraryData: [][]byte{types.NFTArbitraryData(types.NFTMintTag, nft)},
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reclaim := types.Transaction{
+		SiacoinInputs: []types.SiacoinInput{{UnlockConditions: ownerUC}},
+		SiacoinOutputs: []types.SiacoinOutput{
+			{UnlockHash: ownerUC.UnlockHash(), Value: types.OneBaseUnit},
+			{UnlockHash: types.UnlockHash{3}, Value: types.NFTLockupAmount},
+		},
+		ArbitraryData: [][]byte{types.NFTArbitraryData(types.NFTReclaimTag, nft)},
+	}
+
+	// Reclaims are rejected before the rule activates.
+	height := cst.cs.Height()
+	setNFTRuleActivationHeight(t, nftRuleLockupReclaim, height+2)
+	if err := validate(reclaim); !errors.Contains(err, errNFTReclaimInactive) {
+		t.Fatal("expected errNFTReclaimInactive but got", err)
+	}
+	setNFTRuleActivationHeight(t, nftRuleLockupReclaim, height+1)
+
+	// Reclaims can't move the NFT to another address.
+	moved := reclaim
+	moved.SiacoinOutputs = []types.SiacoinOutput{{UnlockHash: types.UnlockHash{3}, Value: types.OneBaseUnit}, reclaim.SiacoinOutputs[1]}
+	if err := validate(moved); !errors.Contains(err, errIncorrectNFTReclaim) {
+		t.Fatal("expected errIncorrectNFTReclaim but got", err)
+	}
+
+	// The lockup of the NFT wasn't recorded, so the reclaim only fails the
+	// lockup check.
+	if err := validate(reclaim); !errors.Contains(err, errNFTLockupReclaimed) {
+		t.Fatal("expected errNFTLockupReclaimed but got", err)
+	}
+}
+
+// TestValidNFTInsuranceClaim tests that insurance claims keep the NFT at its
+// address.
+func TestValidNFTInsuranceClaim(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	validate := func(txn types.Transaction) (err error) {
+		_ = cst.cs.db.View(func(tx *bolt.Tx) error {
+			err = validNFTInsurance(tx, txn)
+			return nil
+		})
+		return
+	}
+	nft := types.NftCustody{FileMerkleRoot: crypto.HashBytes([]byte(t.Name()))}
+	ownerUC := types.UnlockConditions{Timelock: 1}
+	insurance := types.NFTInsurance{
+		Collateral:      types.NFTMinInsuranceCollateral,
+		ChallengeHeight: cst.cs.Height() + 1 - types.NFTInsuranceResponseWindow,
+	}
+	err = cst.cs.db.Update(func(tx *bolt.Tx) error {
+		applyArbitraryData(tx, &processedBlock{Height: cst.cs.Height() + 1}, types.Transaction{
+			SiacoinOutputs: []types.SiacoinOutput{
+				{UnlockHash: types.NFTLockupUnlockConditions.UnlockHash(), Value: types.NFTLockupAmount},
+				{UnlockHash: types.NFTStoragePoolUnlockConditions.UnlockHash(), Value: types.NFTHostAmount},
+				{UnlockHash: ownerUC.UnlockHash(), Value: types.OneBaseUnit},
+			},
+			ArbitraryData: [][]byte{types.NFTArbitraryData(types.NFTMintTag, nft)},
+		})
+		updateNFTInsurance(tx, nft, insurance)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	setNFTRuleActivationHeight(t, nftRuleInsurance, 0)
+	claim := types.Transaction{
+		SiacoinInputs: []types.SiacoinInput{{UnlockConditions: ownerUC}},
+		SiacoinOutputs: []types.SiacoinOutput{
+			{UnlockHash: ownerUC.UnlockHash(), Value: types.OneBaseUnit},
+			{UnlockHash: types.UnlockHash{3}, Value: insurance.Collateral},
+		},
+		ArbitraryData: [][]byte{types.NFTArbitraryData(types.NFTInsuranceClaimTag, nft)},
+	}
+
+	// Claims can't move the NFT to another address.
+	moved := claim
+	moved.SiacoinOutputs = []types.SiacoinOutput{{UnlockHash: types.UnlockHash{3}, Value: types.OneBaseUnit}, claim.SiacoinOutputs[1]}
+	if err := validate(moved); !errors.Contains(err, errIncorrectNFTInsuranceClaim) {
+		t.Fatal("expected errIncorrectNFTInsuranceClaim but got", err)
+	}
+	if err := validate(claim); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestValidTransactionStrictNFTData tests that malformed NFT arbitrary data is
+// only rejected by consensus once the strict NFT data rule activates.
+func TestValidTransactionStrictNFTData(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	validate := func(txn types.Transaction) (err error) {
+		_ = cst.cs.db.View(func(tx *bolt.Tx) error {
+			err = validTransaction(tx, txn)
+			return nil
+		})
+		return
+	}
+	nft := types.NftCustody{FileMerkleRoot: crypto.HashBytes([]byte(t.Name()))}
+	misplaced := types.Transaction{
+		ArbitraryData: [][]byte{[]byte("data"), types.NFTArbitraryData(types.NFTMintTag, nft)},
+	}
+
+	height := cst.cs.Height()
+	setNFTRuleActivationHeight(t, nftRuleStrictArbitraryData, height+2)
+	if err := validate(misplaced); err != nil {
+		t.Fatal("misplaced NFT data was rejected before the rule activated:", err)
+	}
+	setNFTRuleActivationHeight(t, nftRuleStrictArbitraryData, height+1)
+	if err := validate(misplaced); !errors.Contains(err, types.ErrNFTMisplacedData) {
+		t.Fatal("expected ErrNFTMisplacedData but got", err)
+	}
+}
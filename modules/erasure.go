@@ -4,15 +4,18 @@ package modules
 // type for data that is not erasure coded.
 
 import (
-	"bytes"
 	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
+	"math"
+	"runtime"
+	"sync"
 
 	"github.com/klauspost/reedsolomon"
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/types"
 )
 
 var (
@@ -32,6 +35,10 @@ var (
 		Testing:  4,
 	}).(int)
 
+	// RenterDefaultRedundancy is the redundancy of the renter's default
+	// erasure coding parameters.
+	RenterDefaultRedundancy = float64(RenterDefaultDataPieces+RenterDefaultParityPieces) / float64(RenterDefaultDataPieces)
+
 	// ECReedSolomon is the marshaled type of the reed solomon coder.
 	ECReedSolomon = ErasureCoderType{0, 0, 0, 1}
 
@@ -45,6 +52,17 @@ var (
 	ECPassthrough = ErasureCoderType{0, 0, 0, 3}
 )
 
+const (
+	// minSegmentsPerEncodeWorker is the minimum number of segments a single
+	// worker of the RSSubCode's EncodeShards is responsible for. Inputs with
+	// fewer segments than that are encoded without additional goroutines.
+	minSegmentsPerEncodeWorker = 64
+
+	// maxErasureCodePieces is the maximum number of pieces of a Reed-Solomon
+	// code.
+	maxErasureCodePieces = 256
+)
+
 type (
 	// ErasureCoderType is an identifier for the individual types of erasure
 	// coders.
@@ -114,8 +132,42 @@ type (
 
 	// PassthroughErasureCoder is a blank type that signifies no erasure coding.
 	PassthroughErasureCoder struct{}
+
+	// PieceChecksum is the expected Merkle root of an encoded piece as
+	// recorded in a file's metadata, together with the host storing the
+	// piece. A zero MerkleRoot means that the piece isn't verified.
+	PieceChecksum struct {
+		MerkleRoot crypto.Hash
+		HostPubKey types.SiaPublicKey
+	}
+
+	// CorruptPiecesError is returned by RecoverVerified if some of the pieces
+	// don't match their checksums. It names the corrupt pieces and their
+	// hosts so that repairs can replace exactly those pieces. If Recovered is
+	// true, the data was still recovered from the remaining pieces and
+	// written to the writer, otherwise Err is the reason recovery failed.
+	CorruptPiecesError struct {
+		Pieces    []uint64
+		Hosts     []types.SiaPublicKey
+		Recovered bool
+		Err       error
+	}
 )
 
+// Error implements the error interface.
+func (err *CorruptPiecesError) Error() string {
+	s := fmt.Sprintf("%v corrupt pieces %v stored on hosts %v", len(err.Pieces), err.Pieces, err.Hosts)
+	if err.Recovered {
+		return s + "; data was recovered from the remaining pieces"
+	}
+	return fmt.Sprintf("%v; unable to recover data: %v", s, err.Err)
+}
+
+// Unwrap returns the reason recovery failed.
+func (err *CorruptPiecesError) Unwrap() error {
+	return err.Err
+}
+
 // NewRSCode creates a new Reed-Solomon encoder/decoder using the supplied
 // parameters.
 func NewRSCode(nData, nParity int) (ErasureCoder, error) {
@@ -165,6 +217,44 @@ func NewRSSubCodeDefault() ErasureCoder {
 	return ec
 }
 
+// ErasureCodeParamsForSize chooses the erasure coding parameters for a file of
+// the given size and the target redundancy. Every data piece of a chunk takes
+// up a full sector on a host, so a file only uses as many data pieces as it
+// fills sectors, up to the renter's default. Files which fit into a single
+// sector are replicated, large files use the default number of data pieces.
+// There is always at least one parity piece.
+func ErasureCodeParamsForSize(size uint64, redundancy float64) (dataPieces, parityPieces int, err error) {
+	if redundancy < 1 {
+		return 0, 0, fmt.Errorf("redundancy has to be at least 1 but was %v", redundancy)
+	}
+	sectors := size / SectorSize
+	if size%SectorSize != 0 || size == 0 {
+		sectors++
+	}
+	dataPieces = RenterDefaultDataPieces
+	if sectors < uint64(dataPieces) {
+		dataPieces = int(sectors)
+	}
+	parityPieces = int(math.Ceil(float64(dataPieces)*redundancy)) - dataPieces
+	if parityPieces < 1 {
+		parityPieces = 1
+	}
+	if dataPieces+parityPieces > maxErasureCodePieces {
+		return 0, 0, fmt.Errorf("redundancy of %v requires more than %v pieces", redundancy, maxErasureCodePieces)
+	}
+	return dataPieces, parityPieces, nil
+}
+
+// NewRSSubCodeForSize creates a new Reed-Solomon encoder/decoder with
+// parameters chosen by ErasureCodeParamsForSize and the default segment size.
+func NewRSSubCodeForSize(size uint64, redundancy float64) (ErasureCoder, error) {
+	dataPieces, parityPieces, err := ErasureCodeParamsForSize(size, redundancy)
+	if err != nil {
+		return nil, err
+	}
+	return NewRSSubCode(dataPieces, parityPieces, crypto.SegmentSize)
+}
+
 // NewPassthroughErasureCoder will return an erasure coder that does not encode
 // the data. It uses 1-of-1 redundancy and always returns itself or some subset
 // of itself.
@@ -272,7 +362,9 @@ func (rs *RSSubCode) Encode(data []byte) ([][]byte, error) {
 }
 
 // EncodeShards encodes data in a way that every segmentSize bytes of the
-// encoded data can be decoded independently.
+// encoded data can be decoded independently. The segments are independent of
+// each other which allows for spreading them across a pool of workers sized to
+// GOMAXPROCS.
 func (rs *RSSubCode) EncodeShards(pieces [][]byte) ([][]byte, error) {
 	// Check that there are enough pieces.
 	if len(pieces) != rs.MinPieces() {
@@ -297,34 +389,70 @@ func (rs *RSSubCode) EncodeShards(pieces [][]byte) ([][]byte, error) {
 	data := make([]byte, uint64(len(pieces))*pieceSize)
 	for i, piece := range pieces {
 		copy(data[uint64(i)*pieceSize:], piece)
-		pieces[i] = pieces[i][:0]
 	}
-	// Add parity shards to pieces.
-	parityShards := make([][]byte, rs.NumPieces()-len(pieces))
-	pieces = append(pieces, parityShards...)
-	// Encode the pieces.
-	segmentOffset := uint64(0)
-	for buf := bytes.NewBuffer(data); buf.Len() > 0; {
-		// Get the next segments to encode.
-		s := buf.Next(int(rs.staticSegmentSize) * rs.MinPieces())
-
-		// Create a copy of it.
-		segments := make([]byte, len(s))
-		copy(segments, s)
-
-		// Encode the segment
-		encodedSegments, err := rs.RSCode.Encode(segments)
+	// Add parity shards to pieces. The data pieces are reused for the encoded
+	// output since their content was copied into data.
+	for len(pieces) < rs.NumPieces() {
+		pieces = append(pieces, make([]byte, pieceSize))
+	}
+	// Split the segments into contiguous ranges, one per worker. Small inputs
+	// are encoded on the calling thread since spawning workers for them would
+	// cost more than it saves.
+	numSegments := pieceSize / rs.staticSegmentSize
+	numWorkers := uint64(runtime.GOMAXPROCS(0))
+	if maxWorkers := numSegments / minSegmentsPerEncodeWorker; numWorkers > maxWorkers {
+		numWorkers = maxWorkers
+	}
+	if numWorkers <= 1 {
+		return pieces, rs.encodeSegments(data, pieces, 0, numSegments)
+	}
+	segmentsPerWorker := numSegments / numWorkers
+	errs := make([]error, numWorkers)
+	var wg sync.WaitGroup
+	for i := uint64(0); i < numWorkers; i++ {
+		start := i * segmentsPerWorker
+		end := start + segmentsPerWorker
+		if i == numWorkers-1 {
+			end = numSegments
+		}
+		wg.Add(1)
+		go func(i, start, end uint64) {
+			defer wg.Done()
+			errs[i] = rs.encodeSegments(data, pieces, start, end)
+		}(i, start, end)
+	}
+	wg.Wait()
+	for _, err := range errs {
 		if err != nil {
 			return nil, err
 		}
+	}
+	return pieces, nil
+}
 
-		// Write the encoded segments back to pieces.
-		for i, segment := range encodedSegments {
-			pieces[i] = append(pieces[i], segment...)
+// encodeSegments encodes the segments within the range [start, end) of the
+// flattened data and writes the encoded segments to pieces. Every segment
+// consists of segmentSize * MinPieces consecutive bytes of data. It's safe to
+// call encodeSegments concurrently for non-overlapping ranges.
+func (rs *RSSubCode) encodeSegments(data []byte, pieces [][]byte, start, end uint64) error {
+	segmentSize := rs.staticSegmentSize
+	decodedSegmentSize := segmentSize * uint64(rs.MinPieces())
+	shards := make([][]byte, len(pieces))
+	for segmentIndex := start; segmentIndex < end; segmentIndex++ {
+		off := segmentIndex * segmentSize
+		segment := data[segmentIndex*decodedSegmentSize:][:decodedSegmentSize]
+		for i := range pieces {
+			shards[i] = pieces[i][off : off+segmentSize]
+			if i < rs.MinPieces() {
+				copy(shards[i], segment[uint64(i)*segmentSize:])
+			}
+		}
+		// Encode the segment. This computes the parity shards in place.
+		if err := rs.enc.Encode(shards); err != nil {
+			return err
 		}
-		segmentOffset += rs.staticSegmentSize
 	}
-	return pieces, nil
+	return nil
 }
 
 // Identifier returns an identifier for an erasure coder which can be used to
@@ -397,12 +525,89 @@ func (rs *RSSubCode) Recover(pieces [][]byte, n uint64, w io.Writer) error {
 		return fmt.Errorf("expected pieces to have len %v but was %v",
 			rs.NumPieces(), len(pieces))
 	}
+	return rs.recoverSegments(func(pieceIndex int) []byte {
+		return pieces[pieceIndex]
+	}, n, w)
+}
+
+// RecoverVerified works like Recover but first checks every provided piece
+// against the Merkle root in checksums, which has to contain one entry per
+// piece. Pieces which don't match are treated as missing. If any corrupt pieces
+// are found, a *CorruptPiecesError naming them is returned, even if the
+// remaining pieces were sufficient to recover the data.
+func (rs *RSSubCode) RecoverVerified(pieces [][]byte, checksums []PieceChecksum, n uint64, w io.Writer) error {
+	// Check the length of pieces and checksums.
+	if len(pieces) != rs.NumPieces() {
+		return fmt.Errorf("expected pieces to have len %v but was %v",
+			rs.NumPieces(), len(pieces))
+	}
+	if len(checksums) != len(pieces) {
+		return fmt.Errorf("expected %v checksums but got %v", len(pieces), len(checksums))
+	}
+	// Drop the corrupt pieces without modifying the caller's slice.
+	verified := make([][]byte, len(pieces))
+	var cpe CorruptPiecesError
+	for i, piece := range pieces {
+		root := checksums[i].MerkleRoot
+		if len(piece) > 0 && root != (crypto.Hash{}) && crypto.MerkleRoot(piece) != root {
+			cpe.Pieces = append(cpe.Pieces, uint64(i))
+			cpe.Hosts = append(cpe.Hosts, checksums[i].HostPubKey)
+			continue
+		}
+		verified[i] = piece
+	}
+	err := rs.Recover(verified, n, w)
+	if len(cpe.Pieces) == 0 {
+		return err
+	}
+	cpe.Recovered = err == nil
+	cpe.Err = err
+	return &cpe
+}
+
+// RecoverSparse works like Recover but accepts the pieces as a sparse map from
+// piece index to piece data and starts decoding at the segment at
+// segmentIndex. That way a byte range of the original data can be recovered
+// from only the pieces that were actually fetched, without allocating
+// placeholders for the missing ones. The piece buffers start at the segment at
+// segmentIndex, i.e. at byte segmentIndex*segmentSize of the piece, like the
+// data of a ranged download, and need to contain at least the segments that
+// are decoded.
+func (rs *RSSubCode) RecoverSparse(pieces map[uint64][]byte, segmentIndex, n uint64, w io.Writer) error {
+	// Check that the piece indices are valid and that there are enough pieces.
+	var numPieces int
+	for pieceIndex, piece := range pieces {
+		if pieceIndex >= uint64(rs.NumPieces()) {
+			return fmt.Errorf("piece index %v out of bounds for %v pieces",
+				pieceIndex, rs.NumPieces())
+		}
+		if len(piece) > 0 {
+			numPieces++
+		}
+	}
+	if numPieces < rs.MinPieces() {
+		return fmt.Errorf("not enough pieces to recover data, need %v but got %v",
+			rs.MinPieces(), numPieces)
+	}
+	err := rs.recoverSegments(func(pieceIndex int) []byte {
+		return pieces[uint64(pieceIndex)]
+	}, n, w)
+	if err != nil {
+		return fmt.Errorf("unable to recover data starting at segment %v: %v", segmentIndex, err)
+	}
+	return nil
+}
+
+// recoverSegments decodes n bytes starting at the first segment of the pieces
+// and writes them to w. The pieces are looked up through the piece function
+// which returns nil for missing pieces.
+func (rs *RSSubCode) recoverSegments(piece func(int) []byte, n uint64, w io.Writer) error {
 	// Since all the pieces should have the same length, get the pieceSize from
 	// the first piece that was set.
 	var pieceSize uint64
-	for _, piece := range pieces {
-		if uint64(len(piece)) > pieceSize {
-			pieceSize = uint64(len(piece))
+	for i := 0; i < rs.NumPieces(); i++ {
+		if uint64(len(piece(i))) > pieceSize {
+			pieceSize = uint64(len(piece(i)))
 			break
 		}
 	}
@@ -414,15 +619,16 @@ func (rs *RSSubCode) Recover(pieces [][]byte, n uint64, w io.Writer) error {
 
 	// Extract the segment from the pieces.
 	decodedSegmentSize := rs.staticSegmentSize * uint64(rs.MinPieces())
-	segment := make([][]byte, len(pieces))
+	segment := make([][]byte, rs.NumPieces())
 	for i := range segment {
 		segment[i] = make([]byte, 0, rs.staticSegmentSize)
 	}
-	for segmentIndex := 0; uint64(segmentIndex) < pieceSize/rs.staticSegmentSize && n > 0; segmentIndex++ {
-		off := uint64(segmentIndex) * rs.staticSegmentSize
-		for i, piece := range pieces {
-			if uint64(len(piece)) >= off+rs.staticSegmentSize {
-				segment[i] = append(segment[i][:0], piece[off:off+rs.staticSegmentSize]...)
+	for segmentIndex := uint64(0); segmentIndex < pieceSize/rs.staticSegmentSize && n > 0; segmentIndex++ {
+		off := segmentIndex * rs.staticSegmentSize
+		for i := range segment {
+			p := piece(i)
+			if uint64(len(p)) >= off+rs.staticSegmentSize {
+				segment[i] = append(segment[i][:0], p[off:off+rs.staticSegmentSize]...)
 			} else {
 				segment[i] = segment[i][:0]
 			}
@@ -451,18 +657,37 @@ func (rs *RSSubCode) Type() ErasureCoderType {
 }
 
 // ExtractSegment is a convenience method that extracts the data of the segment
-// at segmentIndex from pieces.
-func ExtractSegment(pieces [][]byte, segmentIndex int, segmentSize uint64) [][]byte {
-	segment := make([][]byte, len(pieces))
-	off := uint64(segmentIndex) * segmentSize
+// at segmentIndex from pieces. Missing pieces are expected to be nil and result
+// in a nil segment. An error is returned if a piece is too short to contain the
+// segment.
+func ExtractSegment(pieces [][]byte, segmentIndex int, segmentSize uint64) ([][]byte, error) {
+	return ExtractSegments(pieces, segmentIndex, 1, segmentSize)
+}
+
+// ExtractSegments is a convenience method that extracts the data of count
+// consecutive segments starting at the segment at start from pieces. The
+// returned pieces can be passed to Recover to decode the range of segments.
+// Missing pieces are expected to be nil and result in nil segments. An error is
+// returned if a piece is too short to contain the range.
+func ExtractSegments(pieces [][]byte, start, count int, segmentSize uint64) ([][]byte, error) {
+	if start < 0 || count < 0 {
+		return nil, fmt.Errorf("invalid segment range start %v count %v", start, count)
+	}
+	segments := make([][]byte, len(pieces))
+	off := uint64(start) * segmentSize
+	end := off + uint64(count)*segmentSize
 	for i, piece := range pieces {
-		if uint64(len(piece)) >= off+segmentSize {
-			segment[i] = piece[off : off+segmentSize]
-		} else {
-			segment[i] = nil
+		if len(piece) == 0 {
+			segments[i] = nil
+			continue
+		}
+		if uint64(len(piece)) < end {
+			return nil, fmt.Errorf("piece %v is too short to contain segments [%v, %v), expected at least %v bytes but was %v",
+				i, start, start+count, end, len(piece))
 		}
+		segments[i] = piece[off:end]
 	}
-	return segment
+	return segments, nil
 }
 
 // NumPieces is the number of pieces returned by Encode. For the passthrough
@@ -2,12 +2,14 @@ package modules
 
 import (
 	"bytes"
+	"fmt"
 	"io/ioutil"
 	"testing"
 
 	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/fastrand"
 	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/types"
 )
 
 // TestErasureCode groups all of the tests the three implementations of the
@@ -15,9 +17,14 @@ import (
 func TestErasureCode(t *testing.T) {
 	t.Run("RSCode", testRSCode)
 	t.Run("RSSubCode", testRSSubCode)
+	t.Run("RSSubCodeParallelEncode", testRSSubCodeParallelEncode)
+	t.Run("RSSubCodeRecoverSparse", testRSSubCodeRecoverSparse)
+	t.Run("RSSubCodeRecoverVerified", testRSSubCodeRecoverVerified)
+	t.Run("ExtractSegments", testExtractSegments)
 	t.Run("Passthrough", testPassthrough)
 	t.Run("UniqueIdentifier", testUniqueIdentifier)
 	t.Run("DefaultConstructors", testDefaultConstructors)
+	t.Run("ParamsForSize", testParamsForSize)
 }
 
 // testRSCode tests the RSCode EC.
@@ -122,7 +129,10 @@ func testRSSubCode(t *testing.T) {
 	decodedSegmentSize := segmentSize * dataPieces
 	for segmentIndex := 0; segmentIndex < pieceSize/segmentSize; segmentIndex++ {
 		buf := new(bytes.Buffer)
-		segment := ExtractSegment(encodedPieces, segmentIndex, uint64(segmentSize))
+		segment, err := ExtractSegment(encodedPieces, segmentIndex, uint64(segmentSize))
+		if err != nil {
+			t.Fatal(err)
+		}
 		err = rsc.Recover(segment, uint64(segmentSize*rsc.MinPieces()), buf)
 		if err != nil {
 			t.Fatal(err)
@@ -143,6 +153,278 @@ func testRSSubCode(t *testing.T) {
 	}
 }
 
+// testRSSubCodeParallelEncode checks that encoding the segments of a piece in
+// parallel produces the same output as encoding them one after another.
+func testRSSubCodeParallelEncode(t *testing.T) {
+	segmentSize := crypto.SegmentSize
+	dataPieces := 10
+	parityPieces := 20
+	rsc, err := NewRSSubCode(dataPieces, parityPieces, uint64(segmentSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs := rsc.(*RSSubCode)
+
+	// Use enough segments to make sure that multiple workers are used and
+	// that the last worker gets a partial range.
+	numSegments := minSegmentsPerEncodeWorker*4 + 3
+	pieceSize := numSegments * segmentSize
+	data := fastrand.Bytes(pieceSize * dataPieces)
+
+	// Compute the expected pieces by encoding one segment at a time.
+	expected := make([][]byte, rsc.NumPieces())
+	for segmentIndex := 0; segmentIndex < numSegments; segmentIndex++ {
+		decodedSegmentSize := segmentSize * dataPieces
+		segment := make([]byte, decodedSegmentSize)
+		copy(segment, data[segmentIndex*decodedSegmentSize:])
+		encodedSegment, err := rs.RSCode.Encode(segment)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range expected {
+			expected[i] = append(expected[i], encodedSegment[i]...)
+		}
+	}
+
+	// Encode the pieces in parallel.
+	pieces := make([][]byte, dataPieces)
+	for i := range pieces {
+		pieces[i] = make([]byte, pieceSize)
+		copy(pieces[i], data[i*pieceSize:])
+	}
+	encodedPieces, err := rsc.EncodeShards(pieces)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(encodedPieces) != len(expected) {
+		t.Fatalf("expected %v pieces but got %v", len(expected), len(encodedPieces))
+	}
+	for i := range expected {
+		if !bytes.Equal(encodedPieces[i], expected[i]) {
+			t.Fatalf("piece %v doesn't match the serial encoding", i)
+		}
+	}
+}
+
+// testRSSubCodeRecoverSparse tests recovering a range of segments from a
+// sparse set of pieces.
+func testRSSubCodeRecoverSparse(t *testing.T) {
+	segmentSize := crypto.SegmentSize
+	pieceSize := 4096
+	dataPieces := 10
+	parityPieces := 20
+	data := fastrand.Bytes(pieceSize * dataPieces)
+	rsc, err := NewRSSubCode(dataPieces, parityPieces, uint64(segmentSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs := rsc.(*RSSubCode)
+	pieces := make([][]byte, dataPieces)
+	for i := range pieces {
+		pieces[i] = make([]byte, pieceSize)
+		copy(pieces[i], data[i*pieceSize:])
+	}
+	encodedPieces, err := rsc.EncodeShards(pieces)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only keep the minimum number of random pieces.
+	sparse := make(map[uint64][]byte)
+	for _, i := range fastrand.Perm(len(encodedPieces))[:dataPieces] {
+		sparse[uint64(i)] = encodedPieces[i]
+	}
+
+	// Recover a range of segments in the middle of the pieces. The pieces
+	// only contain the segments from segmentIndex onwards, like the data of a
+	// ranged download.
+	numSegments := pieceSize / segmentSize
+	decodedSegmentSize := segmentSize * dataPieces
+	segmentIndex := fastrand.Intn(numSegments-1) + 1
+	length := fastrand.Intn((numSegments-segmentIndex)*decodedSegmentSize) + 1
+	truncated := make(map[uint64][]byte)
+	for i, piece := range sparse {
+		truncated[i] = piece[segmentIndex*segmentSize:]
+	}
+	buf := new(bytes.Buffer)
+	err = rs.RecoverSparse(truncated, uint64(segmentIndex), uint64(length), buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	off := segmentIndex * decodedSegmentSize
+	if !bytes.Equal(buf.Bytes(), data[off:off+length]) {
+		t.Fatal("decoded bytes don't equal original data")
+	}
+
+	// Recovering everything from the start should match Recover.
+	buf.Reset()
+	err = rs.RecoverSparse(sparse, 0, uint64(len(data)), buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), data) {
+		t.Fatal("decoded bytes don't equal original data")
+	}
+
+	// Removing a piece should result in an error.
+	for i := range sparse {
+		delete(sparse, i)
+		break
+	}
+	err = rs.RecoverSparse(sparse, 0, uint64(len(data)), ioutil.Discard)
+	if err == nil {
+		t.Fatal("expected error when recovering from too few pieces")
+	}
+
+	// An out-of-bounds piece index should result in an error.
+	sparse[uint64(rsc.NumPieces())] = encodedPieces[0]
+	err = rs.RecoverSparse(sparse, 0, uint64(len(data)), ioutil.Discard)
+	if err == nil {
+		t.Fatal("expected error for out-of-bounds piece index")
+	}
+}
+
+// testRSSubCodeRecoverVerified tests that corrupt pieces are identified by
+// their checksums and reported together with their hosts.
+func testRSSubCodeRecoverVerified(t *testing.T) {
+	dataPieces := 2
+	parityPieces := 2
+	data := fastrand.Bytes(4096 * dataPieces)
+	rsc, err := NewRSSubCode(dataPieces, parityPieces, crypto.SegmentSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs := rsc.(*RSSubCode)
+	pieces, err := rsc.Encode(append([]byte{}, data...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checksums := make([]PieceChecksum, len(pieces))
+	for i, piece := range pieces {
+		checksums[i] = PieceChecksum{
+			MerkleRoot: crypto.MerkleRoot(piece),
+			HostPubKey: types.SiaPublicKey{Key: []byte{byte(i)}},
+		}
+	}
+
+	// Intact pieces are recovered without an error.
+	buf := new(bytes.Buffer)
+	if err := rs.RecoverVerified(pieces, checksums, uint64(len(data)), buf); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), data) {
+		t.Fatal("decoded bytes don't equal original data")
+	}
+
+	// Corrupt a data piece. The data is still recovered but the piece is
+	// reported.
+	pieces[1] = append([]byte{}, pieces[1]...)
+	pieces[1][0]++
+	buf.Reset()
+	err = rs.RecoverVerified(pieces, checksums, uint64(len(data)), buf)
+	cpe, ok := err.(*CorruptPiecesError)
+	if !ok {
+		t.Fatal("expected CorruptPiecesError but got", err)
+	}
+	if !cpe.Recovered || len(cpe.Pieces) != 1 || cpe.Pieces[0] != 1 || !cpe.Hosts[0].Equals(checksums[1].HostPubKey) {
+		t.Fatal("unexpected error", cpe)
+	}
+	if !bytes.Equal(buf.Bytes(), data) {
+		t.Fatal("decoded bytes don't equal original data")
+	}
+
+	// Corrupt two more pieces. Recovery fails.
+	pieces[2] = nil
+	pieces[3] = append([]byte{}, pieces[3]...)
+	pieces[3][0]++
+	buf.Reset()
+	err = rs.RecoverVerified(pieces, checksums, uint64(len(data)), buf)
+	if cpe, ok = err.(*CorruptPiecesError); !ok {
+		t.Fatal("expected CorruptPiecesError but got", err)
+	}
+	if cpe.Recovered || cpe.Err == nil || len(cpe.Pieces) != 2 || cpe.Pieces[1] != 3 {
+		t.Fatal("unexpected error", cpe)
+	}
+
+	// The number of checksums has to match.
+	if err := rs.RecoverVerified(pieces, checksums[1:], uint64(len(data)), buf); err == nil {
+		t.Fatal("expected error for missing checksums")
+	}
+}
+
+// testExtractSegments tests extracting ranges of segments from pieces.
+func testExtractSegments(t *testing.T) {
+	segmentSize := crypto.SegmentSize
+	pieceSize := 4096
+	dataPieces := 10
+	parityPieces := 20
+	data := fastrand.Bytes(pieceSize * dataPieces)
+	originalData := make([]byte, len(data))
+	copy(originalData, data)
+	rsc, err := NewRSSubCode(dataPieces, parityPieces, uint64(segmentSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	encodedPieces, err := rsc.Encode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, i := range fastrand.Perm(len(encodedPieces))[:parityPieces] {
+		encodedPieces[i] = nil
+	}
+
+	// Extract and recover a range of segments.
+	numSegments := pieceSize / segmentSize
+	decodedSegmentSize := segmentSize * dataPieces
+	start := fastrand.Intn(numSegments)
+	count := fastrand.Intn(numSegments-start) + 1
+	segments, err := ExtractSegments(encodedPieces, start, count, uint64(segmentSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, segment := range segments {
+		if encodedPieces[i] == nil && segment != nil {
+			t.Fatal("missing piece should result in nil segment")
+		} else if encodedPieces[i] != nil && len(segment) != count*segmentSize {
+			t.Fatalf("expected segment of len %v but was %v", count*segmentSize, len(segment))
+		}
+	}
+	buf := new(bytes.Buffer)
+	err = rsc.Recover(segments, uint64(count*decodedSegmentSize), buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	off := start * decodedSegmentSize
+	if !bytes.Equal(buf.Bytes(), originalData[off:off+count*decodedSegmentSize]) {
+		t.Fatal("decoded bytes don't equal original data")
+	}
+
+	// Extracting segments beyond the end of the pieces should fail instead of
+	// panicking.
+	_, err = ExtractSegment(encodedPieces, numSegments, uint64(segmentSize))
+	if err == nil {
+		t.Fatal("expected error when extracting out-of-bounds segment")
+	}
+	_, err = ExtractSegments(encodedPieces, start+1, numSegments, uint64(segmentSize))
+	if err == nil {
+		t.Fatal("expected error when extracting out-of-bounds range")
+	}
+	_, err = ExtractSegments(encodedPieces, -1, 1, uint64(segmentSize))
+	if err == nil {
+		t.Fatal("expected error for negative start")
+	}
+
+	// Extracting an empty range is allowed.
+	segments, err = ExtractSegments(encodedPieces, numSegments, 0, uint64(segmentSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, segment := range segments {
+		if len(segment) != 0 {
+			t.Fatalf("expected empty segment for piece %v", i)
+		}
+	}
+}
+
 // testPassthrough verifies the functionality of the Passthrough EC.
 func testPassthrough(t *testing.T) {
 	ptec := NewPassthroughErasureCoder()
@@ -278,6 +560,60 @@ func testDefaultConstructors(t *testing.T) {
 	}
 }
 
+// testParamsForSize checks that the erasure coding parameters chosen by
+// ErasureCodeParamsForSize fit the size of the file.
+func testParamsForSize(t *testing.T) {
+	// Small files are replicated.
+	for _, size := range []uint64{0, 1, SectorSize} {
+		data, parity, err := ErasureCodeParamsForSize(size, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data != 1 || parity != 2 {
+			t.Fatalf("size %v: expected 1-of-3 but got %v-of-%v", size, data, data+parity)
+		}
+	}
+	// A file spanning two sectors uses at most two data pieces.
+	data, _, err := ErasureCodeParamsForSize(SectorSize+1, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data > 2 {
+		t.Fatalf("expected at most 2 data pieces but got %v", data)
+	}
+	// Large files use the default.
+	data, parity, err := ErasureCodeParamsForSize(SectorSize*uint64(RenterDefaultDataPieces)*10, RenterDefaultRedundancy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data != RenterDefaultDataPieces || parity != RenterDefaultParityPieces {
+		t.Fatalf("expected default parameters but got %v-of-%v", data, data+parity)
+	}
+	// There is always a parity piece.
+	_, parity, err = ErasureCodeParamsForSize(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parity != 1 {
+		t.Fatalf("expected 1 parity piece but got %v", parity)
+	}
+	// Invalid redundancies are rejected.
+	if _, _, err := ErasureCodeParamsForSize(1, 0.5); err == nil {
+		t.Fatal("expected error for redundancy below 1")
+	}
+	if _, _, err := ErasureCodeParamsForSize(SectorSize, 1000); err == nil {
+		t.Fatal("expected error for too many pieces")
+	}
+	// The constructor creates a matching coder.
+	ec, err := NewRSSubCodeForSize(1, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ec.MinPieces() != 1 || ec.NumPieces() != 3 {
+		t.Fatalf("unexpected coder %v-of-%v", ec.MinPieces(), ec.NumPieces())
+	}
+}
+
 // BenchmarkRSEncode benchmarks the 'Encode' function of the RSCode EC.
 func BenchmarkRSEncode(b *testing.B) {
 	rsc, err := NewRSCode(80, 20)
@@ -293,6 +629,42 @@ func BenchmarkRSEncode(b *testing.B) {
 	}
 }
 
+// BenchmarkRSSubCodeEncodeShards benchmarks the 'EncodeShards' function of the
+// RSSubCode EC for pieces of various sizes.
+func BenchmarkRSSubCodeEncodeShards(b *testing.B) {
+	for _, pieceSize := range []int{1 << 12, 1 << 16, 1 << 20, 1 << 22} {
+		b.Run(fmt.Sprint(pieceSize), func(b *testing.B) {
+			benchmarkRSSubCodeEncodeShards(b, pieceSize)
+		})
+	}
+}
+
+// benchmarkRSSubCodeEncodeShards benchmarks the 'EncodeShards' function of the
+// RSSubCode EC for the provided piece size.
+func benchmarkRSSubCodeEncodeShards(b *testing.B, pieceSize int) {
+	dataPieces := 10
+	parityPieces := 20
+	rsc, err := NewRSSubCode(dataPieces, parityPieces, crypto.SegmentSize)
+	if err != nil {
+		b.Fatal(err)
+	}
+	data := fastrand.Bytes(pieceSize * dataPieces)
+	pieces := make([][]byte, dataPieces)
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := range pieces {
+			pieces[j] = data[j*pieceSize:][:pieceSize]
+		}
+		_, err := rsc.EncodeShards(pieces)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
 // BenchmarkRSEncode benchmarks the 'Recover' function of the RSCode EC.
 func BenchmarkRSRecover(b *testing.B) {
 	rsc, err := NewRSCode(50, 200)
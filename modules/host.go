@@ -283,12 +283,29 @@ type (
 		MinStoragePrice           types.Currency `json:"minstorageprice"`
 		MinUploadBandwidthPrice   types.Currency `json:"minuploadbandwidthprice"`
 
+		MinNFTStoragePrice types.Currency    `json:"minnftstorageprice"`
+		NFTRetentionPeriod types.BlockHeight `json:"nftretentionperiod"`
+		NFTStoragePool     bool              `json:"nftstoragepool"`
+
 		EphemeralAccountExpiry     time.Duration  `json:"ephemeralaccountexpiry"`
 		MaxEphemeralAccountBalance types.Currency `json:"maxephemeralaccountbalance"`
 		MaxEphemeralAccountRisk    types.Currency `json:"maxephemeralaccountrisk"`
 
-		CustomRegistryPath string `json:"customregistrypath"`
-		RegistrySize       uint64 `json:"registrysize"`
+		CustomRegistryPath    string              `json:"customregistrypath"`
+		MaxRegistryEntryTTL   types.BlockHeight   `json:"maxregistryentryttl"`
+		RegistryCompactIndex  bool                `json:"registrycompactindex"`
+		RegistryRejectedTypes []RegistryEntryType `json:"registryrejectedtypes"`
+		RegistryRetention     types.BlockHeight   `json:"registryretention"`
+		RegistrySize          uint64              `json:"registrysize"`
+
+		// FreeRegistryReads is the number of registry reads per minute the
+		// host serves to a single IP address without payment. Zero disables
+		// free reads.
+		FreeRegistryReads uint64 `json:"freeregistryreads"`
+
+		// MaxContractBandwidth is the number of bytes the renter of a
+		// contract may upload and download per hour. Zero disables the cap.
+		MaxContractBandwidth uint64 `json:"maxcontractbandwidth"`
 	}
 
 	// HostNetworkMetrics reports the quantity of each type of RPC call that
@@ -303,6 +320,18 @@ type (
 		UnrecognizedCalls uint64 `json:"unrecognizedcalls"`
 	}
 
+	// HostRegistryMetrics contains information about the host's registry and
+	// the expired entries it pruned automatically since startup.
+	HostRegistryMetrics struct {
+		Capacity uint64 `json:"capacity"`
+		Entries  uint64 `json:"entries"`
+
+		LastPruneHeight   types.BlockHeight `json:"lastpruneheight"`
+		LastPrunedEntries uint64            `json:"lastprunedentries"`
+		PrunedEntries     uint64            `json:"prunedentries"`
+		PruneErrors       uint64            `json:"pruneerrors"`
+	}
+
 	// StorageObligation contains information about a storage obligation that
 	// the host has accepted.
 	StorageObligation struct {
@@ -341,6 +370,33 @@ type (
 		// or a proof has been confirmed on the blockchain.
 		ValidProofOutputs  []types.SiacoinOutput `json:"validproofoutputs"`
 		MissedProofOutputs []types.SiacoinOutput `json:"missedproofoutputs"`
+
+		// NFTRoots are the merkle roots of the NFTs the renter tagged the
+		// obligation's data with.
+		NFTRoots []crypto.Hash `json:"nftroots"`
+
+		// UploadBandwidth and DownloadBandwidth are the bytes the renter
+		// uploaded and downloaded for the obligation since the host started.
+		UploadBandwidth   uint64 `json:"uploadbandwidth"`
+		DownloadBandwidth uint64 `json:"downloadbandwidth"`
+	}
+
+	// HostNFT contains information about the storage obligations of a host
+	// which were tagged with an NFT.
+	HostNFT struct {
+		// Root is the merkle root of the NFT.
+		Root crypto.Hash `json:"root"`
+
+		// Obligations are the unresolved storage obligations tagged with the
+		// NFT.
+		Obligations []types.FileContractID `json:"obligations"`
+
+		// DataSize is the total size of the data stored in the obligations.
+		DataSize uint64 `json:"datasize"`
+
+		// PotentialRevenue is the total potential storage, upload and download
+		// revenue of the obligations.
+		PotentialRevenue types.Currency `json:"potentialrevenue"`
 	}
 
 	// HostWorkingStatus reports the working state of a host. Can be one of
@@ -412,6 +468,10 @@ type (
 		// BandwidthCounters returns the Hosts's upload and download bandwidth
 		BandwidthCounters() (uint64, uint64, time.Time, error)
 
+		// HostedNFTs returns the NFTs the host's unresolved storage
+		// obligations were tagged with, ordered by potential revenue.
+		HostedNFTs() ([]HostNFT, error)
+
 		// FinancialMetrics returns the financial statistics of the host.
 		FinancialMetrics() HostFinancialMetrics
 
@@ -423,6 +483,10 @@ type (
 		// have been made to the host.
 		NetworkMetrics() HostNetworkMetrics
 
+		// RegistryMetrics returns information about the host's registry and
+		// its automatic pruning.
+		RegistryMetrics() HostRegistryMetrics
+
 		PaymentProcessor
 
 		// PriceTable returns the host's current price table.
@@ -524,6 +588,8 @@ func DefaultHostExternalSettings() HostExternalSettings {
 		SectorSize:           SectorSize,
 		WindowSize:           DefaultWindowSize,
 
+		MaxWriteStreamSectors: NegotiateMaxWriteStreamSectors,
+
 		Collateral:    DefaultCollateral,
 		MaxCollateral: DefaultMaxCollateral,
 
@@ -70,6 +70,7 @@ func (h *Host) managedAnnounce(addr modules.NetAddress) (err error) {
 	h.mu.Lock()
 	pubKey := h.publicKey
 	secKey := h.secretKey
+	capabilities := h.capabilities()
 	err = h.checkUnlockHash()
 	h.mu.Unlock()
 	if err != nil {
@@ -77,8 +78,9 @@ func (h *Host) managedAnnounce(addr modules.NetAddress) (err error) {
 	}
 
 	// Create the announcement that's going to be added to the arbitrary data
-	// field of the transaction.
-	signedAnnouncement, err := modules.CreateAnnouncement(addr, pubKey, secKey)
+	// field of the transaction. The announcement is extended with the host's
+	// capabilities.
+	signedAnnouncement, err := modules.CreateAnnouncementWithCapabilities(addr, pubKey, secKey, capabilities)
 	if err != nil {
 		return err
 	}
@@ -94,7 +96,7 @@ func (h *Host) managedAnnounce(addr modules.NetAddress) (err error) {
 		}
 	}()
 	_, fee := h.tpool.FeeEstimation()
-	fee = fee.Mul64(600) // Estimated txn size (in bytes) of a host announcement.
+	fee = fee.Mul64(700) // Estimated txn size (in bytes) of a host announcement.
 	err = txnBuilder.FundSiacoins(fee)
 	if err != nil {
 		return err
@@ -74,6 +74,24 @@ var (
 	// prevent the host from having too much money at risk.
 	defaultMaxEphemeralAccountRisk = types.SiacoinPrecision.Mul64(5)
 
+	// defaultMaxRegistryEntryTTL is the number of blocks the host keeps a
+	// registry entry after it was last updated if the host didn't set a
+	// MaxRegistryEntryTTL.
+	defaultMaxRegistryEntryTTL = types.BlocksPerYear
+
+	// freeRegistryReadsGlobalFactor bounds the free registry reads the host
+	// serves to all clients per minute to this multiple of the reads it
+	// serves to a single client.
+	freeRegistryReadsGlobalFactor = uint64(100)
+
+	// defaultRegistryRetention is the number of blocks the host keeps registry
+	// entries around after they expired before pruning them.
+	defaultRegistryRetention = build.Select(build.Var{
+		Dev:      types.BlockHeight(20),  // About 4 minutes
+		Standard: types.BlockHeight(144), // 1 day.
+		Testing:  types.BlockHeight(2),
+	}).(types.BlockHeight)
+
 	// logAllLimit is the number of errors of each type that the host will log
 	// before switching to probabilistic logging. If there are not many errors,
 	// it is reasonable that all errors get logged. If there are lots of
@@ -102,6 +120,15 @@ var (
 		Testing:  time.Second * 3,
 	}).(time.Duration)
 
+	// maxConcurrentReadPrograms is the maximum number of read-only programs
+	// the host executes concurrently against a single storage obligation.
+	// Programs beyond the limit are queued in the order they arrived.
+	maxConcurrentReadPrograms = build.Select(build.Var{
+		Dev:      16,
+		Standard: 32,
+		Testing:  4,
+	}).(int)
+
 	// revisionSubmissionBuffer describes the number of blocks ahead of time
 	// that the host will submit a file contract revision. The host will not
 	// accept any more revisions once inside the submission buffer.
@@ -0,0 +1,152 @@
+package host
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// contractbandwidth.go meters the bandwidth renters use for the host's storage
+// obligations. Hosts may cap the bandwidth of a single contract per window, so
+// that a single renter downloading popular NFT content can't degrade the
+// service for all other renters. The counters are kept in memory and start
+// over when the host restarts.
+
+// contractBandwidthWindow is the window over which the bandwidth of a contract
+// is capped.
+const contractBandwidthWindow = time.Hour
+
+type (
+	// contractBandwidth is the bandwidth used for a storage obligation.
+	contractBandwidth struct {
+		upload   uint64
+		download uint64
+
+		// windowUsed is the bandwidth used since windowStart.
+		windowUsed  uint64
+		windowStart time.Time
+	}
+
+	// contractBandwidthMeter tracks the bandwidth used per storage
+	// obligation.
+	contractBandwidthMeter struct {
+		contracts map[types.FileContractID]*contractBandwidth
+		mu        sync.Mutex
+	}
+
+	// meteredConn counts the bytes read from and written to a connection. It
+	// is used by a single RPC loop, which reads and writes sequentially.
+	meteredConn struct {
+		net.Conn
+		read    uint64
+		written uint64
+	}
+)
+
+// newContractBandwidthMeter creates a new meter.
+func newContractBandwidthMeter() *contractBandwidthMeter {
+	return &contractBandwidthMeter{
+		contracts: make(map[types.FileContractID]*contractBandwidth),
+	}
+}
+
+// Read implements io.Reader.
+func (c *meteredConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	c.read += uint64(n)
+	return n, err
+}
+
+// Write implements io.Writer.
+func (c *meteredConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	c.written += uint64(n)
+	return n, err
+}
+
+// managedAllow returns true if the contract used less than limit bytes in the
+// current window. A limit of 0 means that the bandwidth isn't capped.
+func (m *contractBandwidthMeter) managedAllow(fcid types.FileContractID, limit uint64, now time.Time) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cb := m.contract(fcid, now)
+	return limit == 0 || cb.windowUsed < limit
+}
+
+// managedForget stops tracking the bandwidth of a contract.
+func (m *contractBandwidthMeter) managedForget(fcid types.FileContractID) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.contracts, fcid)
+}
+
+// managedRecord records the bytes a renter uploaded and downloaded for a
+// contract.
+func (m *contractBandwidthMeter) managedRecord(fcid types.FileContractID, upload, download uint64, now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cb := m.contract(fcid, now)
+	cb.upload += upload
+	cb.download += download
+	cb.windowUsed += upload + download
+	contractUploadBytesMetric.Add(upload)
+	contractDownloadBytesMetric.Add(download)
+}
+
+// managedTracked returns true if the meter tracks the contract.
+func (m *contractBandwidthMeter) managedTracked(fcid types.FileContractID) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, exists := m.contracts[fcid]
+	return exists
+}
+
+// managedUsage returns the bytes a renter uploaded and downloaded for a
+// contract since the host started.
+func (m *contractBandwidthMeter) managedUsage(fcid types.FileContractID) (upload, download uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cb, exists := m.contracts[fcid]
+	if !exists {
+		return 0, 0
+	}
+	return cb.upload, cb.download
+}
+
+// contract returns the bandwidth of a contract, starting a new window if the
+// current one is over.
+func (m *contractBandwidthMeter) contract(fcid types.FileContractID, now time.Time) *contractBandwidth {
+	cb, exists := m.contracts[fcid]
+	if !exists {
+		cb = &contractBandwidth{windowStart: now}
+		m.contracts[fcid] = cb
+	}
+	if now.Sub(cb.windowStart) >= contractBandwidthWindow {
+		cb.windowUsed = 0
+		cb.windowStart = now
+	}
+	return cb
+}
+
+// managedCheckContractBandwidth returns modules.ErrContractBandwidthLimit if
+// the renter of a storage obligation used up the bandwidth the host allows per
+// contract and window. The returned bool is false if the host has no storage
+// obligation with the id, in which case the bandwidth isn't metered.
+func (h *Host) managedCheckContractBandwidth(fcid types.FileContractID) (bool, error) {
+	if !h.staticContractBandwidth.managedTracked(fcid) {
+		if _, err := h.managedGetStorageObligation(fcid); err != nil {
+			return false, nil
+		}
+	}
+	h.mu.RLock()
+	limit := h.settings.MaxContractBandwidth
+	h.mu.RUnlock()
+	if !h.staticContractBandwidth.managedAllow(fcid, limit, time.Now()) {
+		contractBandwidthLimitedMetric.Inc()
+		return true, modules.ErrContractBandwidthLimit
+	}
+	return true, nil
+}
@@ -0,0 +1,153 @@
+package host
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestContractBandwidthMeter is a unit test for the contract bandwidth meter.
+func TestContractBandwidthMeter(t *testing.T) {
+	m := newContractBandwidthMeter()
+	var fcid types.FileContractID
+	fastrand.Read(fcid[:])
+	now := time.Now()
+
+	// The meter doesn't track contracts it hasn't seen.
+	if m.managedTracked(fcid) {
+		t.Fatal("contract shouldn't be tracked")
+	}
+
+	// Record some bandwidth below the limit.
+	if !m.managedAllow(fcid, 100, now) {
+		t.Fatal("contract should be allowed")
+	}
+	m.managedRecord(fcid, 40, 50, now)
+	if up, down := m.managedUsage(fcid); up != 40 || down != 50 {
+		t.Fatalf("wrong usage %v %v", up, down)
+	}
+	if !m.managedAllow(fcid, 100, now) {
+		t.Fatal("contract should be allowed")
+	}
+
+	// Exceed the limit.
+	m.managedRecord(fcid, 10, 0, now)
+	if m.managedAllow(fcid, 100, now) {
+		t.Fatal("contract shouldn't be allowed")
+	}
+	if !m.managedAllow(fcid, 0, now) {
+		t.Fatal("contract should be allowed without a limit")
+	}
+
+	// The limit is reset in the next window, the usage isn't.
+	now = now.Add(contractBandwidthWindow)
+	if !m.managedAllow(fcid, 100, now) {
+		t.Fatal("contract should be allowed in the next window")
+	}
+	if up, down := m.managedUsage(fcid); up != 50 || down != 50 {
+		t.Fatalf("wrong usage %v %v", up, down)
+	}
+
+	// Forget the contract.
+	m.managedForget(fcid)
+	if m.managedTracked(fcid) {
+		t.Fatal("contract shouldn't be tracked")
+	}
+	if up, down := m.managedUsage(fcid); up != 0 || down != 0 {
+		t.Fatalf("wrong usage %v %v", up, down)
+	}
+}
+
+// TestContractBandwidthCap checks that the host meters the bandwidth of
+// programs executed for a contract and rejects programs once the contract
+// used up its bandwidth.
+func TestContractBandwidthCap(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rhp, err := newRenterHostPair(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := rhp.Close()
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+	host := rhp.staticHT.host
+
+	// Cap the bandwidth of contracts at a single byte.
+	is := host.InternalSettings()
+	is.MaxContractBandwidth = 1
+	err = host.SetInternalSettings(is)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Fund an account.
+	pt := rhp.managedPriceTable()
+	_, err = rhp.managedFundEphemeralAccount(pt.FundAccountCost.Add(types.SiacoinPrecision), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Execute a program for the contract.
+	executeProgram := func() error {
+		pb := modules.NewProgramBuilder(pt, 0)
+		pb.AddHasSectorInstruction(crypto.Hash{})
+		program, data := pb.Program()
+		programCost, _, _ := pb.Cost(true)
+		epr := modules.RPCExecuteProgramRequest{
+			FileContractID:    rhp.staticFCID,
+			Program:           program,
+			ProgramDataLength: uint64(len(data)),
+		}
+		bandwidthCost := pt.DownloadBandwidthCost.Add(pt.UploadBandwidthCost).Mul64(1 << 14)
+		_, _, err := rhp.managedExecuteProgram(epr, data, programCost.Add(bandwidthCost), true, true)
+		return err
+	}
+	if err := executeProgram(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The bandwidth is metered once the RPC is done.
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		so, err := host.StorageObligation(rhp.staticFCID)
+		if err != nil {
+			return err
+		}
+		if so.UploadBandwidth == 0 || so.DownloadBandwidth == 0 {
+			return errors.New("bandwidth wasn't metered")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The next program is rejected.
+	err = executeProgram()
+	if err == nil || !strings.Contains(err.Error(), modules.ErrContractBandwidthLimit.Error()) {
+		t.Fatal("expected ErrContractBandwidthLimit but got", err)
+	}
+
+	// Without a cap the program succeeds again.
+	is.MaxContractBandwidth = 0
+	err = host.SetInternalSettings(is)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := executeProgram(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -159,8 +159,11 @@ type Host struct {
 
 	// Subsystems
 	staticAccountManager        *accountManager
+	staticContractBandwidth     *contractBandwidthMeter
+	staticFreeRegistryReads     *freeRegistryReadLimiter
 	staticMDM                   *mdm.MDM
 	staticRegistry              *registry.Registry
+	staticRegistryPruner        *registryPruner
 	staticRegistrySubscriptions *registrySubscriptions
 
 	// Host ACID fields - these fields need to be updated in serial, ACID
@@ -186,6 +189,11 @@ type Host struct {
 	// be locked separately.
 	lockedStorageObligations map[types.FileContractID]*lockedObligation
 
+	// The program scheduler decides when programs against a storage
+	// obligation are executed. Read-only programs may run concurrently while
+	// write programs are serialized.
+	staticProgramScheduler *programScheduler
+
 	// A collection of rpc price tables, covered by its own RW mutex. It
 	// contains the host's current price table and the set of price tables the
 	// host has communicated to all renters, thus guaranteeing a set of prices
@@ -449,12 +457,16 @@ func newHost(dependencies modules.Dependencies, smDeps modules.Dependencies, cs
 		staticMux:                mux,
 		dependencies:             dependencies,
 		lockedStorageObligations: make(map[types.FileContractID]*lockedObligation),
+		staticProgramScheduler:   newProgramScheduler(maxConcurrentReadPrograms),
 		staticPriceTables: &hostPrices{
 			guaranteed: make(map[modules.UniqueID]*hostRPCPriceTable),
 			staticMinHeap: priceTableHeap{
 				heap: make([]*hostRPCPriceTable, 0),
 			},
 		},
+		staticContractBandwidth:     newContractBandwidthMeter(),
+		staticFreeRegistryReads:     newFreeRegistryReadLimiter(),
+		staticRegistryPruner:        newRegistryPruner(),
 		staticRegistrySubscriptions: newRegistrySubscriptions(),
 		persistDir:                  persistDir,
 	}
@@ -561,6 +573,9 @@ func newHost(dependencies modules.Dependencies, smDeps modules.Dependencies, cs
 	// Ensure the expired RPC tables get pruned as to not leak memory
 	go h.threadedPruneExpiredPriceTables()
 
+	// Prune expired registry entries whenever the block height changes.
+	go h.threadedPruneRegistry()
+
 	return h, nil
 }
 
@@ -728,6 +743,11 @@ func (h *Host) SetInternalSettings(settings modules.HostInternalSettings) error
 		}
 	}
 
+	// Prune the registry right away if the retention was lowered.
+	if settings.RegistryRetention < h.settings.RegistryRetention {
+		h.staticRegistryPruner.callNotify()
+	}
+
 	h.settings = settings
 	h.revisionNumber++
 
@@ -798,11 +818,25 @@ func (h *Host) RegistryUpdate(rv modules.SignedRegistryValue, pubKey types.SiaPu
 	if h.dependencies.Disrupt("RegistryUpdateNoOp") {
 		return modules.SignedRegistryValue{}, nil
 	}
+	// Reject the types of entries the host doesn't accept.
+	settings := h.managedInternalSettings()
+	for _, t := range settings.RegistryRejectedTypes {
+		if rv.Type == t {
+			registryUpdatesMetric.With("rejected").Inc()
+			return modules.SignedRegistryValue{}, errors.AddContext(modules.ErrRegistryEntryTypeRejected, rv.Type.String())
+		}
+	}
+	// Don't keep the entry for longer than the host's max TTL.
+	if maxExpiry := h.BlockHeight() + maxRegistryEntryTTL(settings); expiry > maxExpiry {
+		expiry = maxExpiry
+	}
 	// Update the registry.
 	existingSRV, err := h.staticRegistry.Update(rv, pubKey, expiry)
 	if err != nil {
+		registryUpdatesMetric.With("rejected").Inc()
 		return existingSRV, errors.AddContext(err, "failed to update registry")
 	}
+	registryUpdatesMetric.With("accepted").Inc()
 	// On success, we notify the subscribers.
 	go h.threadedNotifySubscribers(pubKey, rv)
 	return existingSRV, nil
@@ -846,8 +880,13 @@ func (h *Host) managedInitRegistry() error {
 		build.Critical("Host registry on disk was larger than specified in settings. Settings have been updated.")
 	}
 
-	// Load the registry.
-	registry, err := registry.New(path, settingsEntries, h.publicKey)
+	// Load the registry. A compact registry only keeps an index of its
+	// entries in memory and reads the values from disk.
+	newRegistry := registry.New
+	if is.RegistryCompactIndex {
+		newRegistry = registry.NewCompact
+	}
+	registry, err := newRegistry(path, settingsEntries, h.publicKey)
 	if err != nil {
 		return errors.AddContext(err, "failed to load host registry")
 	}
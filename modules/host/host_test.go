@@ -1212,6 +1212,84 @@ func TestHostRegistry(t *testing.T) {
 	}
 }
 
+// TestHostCapabilities checks that the host advertises its registry and NFT
+// capabilities and that registry entries don't outlive the max TTL.
+func TestHostCapabilities(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	h := ht.host
+
+	// Without a registry the host doesn't advertise a TTL.
+	hc := h.managedExternalSettings().HostCapabilities
+	if hc != (modules.HostCapabilities{}) {
+		t.Fatal("unexpected capabilities", hc)
+	}
+
+	// Enable the registry and join the NFT storage pool.
+	is := h.managedInternalSettings()
+	is.RegistrySize = 128 * modules.RegistryEntrySize
+	is.NFTStoragePool = true
+	err = h.SetInternalSettings(is)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hc = h.managedExternalSettings().HostCapabilities
+	expected := modules.HostCapabilities{
+		RegistryEntriesLeft: 128,
+		NFTStoragePool:      true,
+		MaxRegistryEntryTTL: defaultMaxRegistryEntryTTL,
+	}
+	if hc != expected {
+		t.Fatal("unexpected capabilities", hc)
+	}
+
+	// Lower the TTL and add an entry with a later expiry.
+	is.MaxRegistryEntryTTL = 10
+	err = h.SetInternalSettings(is)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sk, pk := crypto.GenerateKeyPair()
+	var tweak crypto.Hash
+	fastrand.Read(tweak[:])
+	rv := modules.NewRegistryValue(tweak, fastrand.Bytes(modules.RegistryDataSize), 0, modules.RegistryTypeWithoutPubkey).Sign(sk)
+	_, err = h.RegistryUpdate(rv, types.Ed25519PublicKey(pk), h.BlockHeight()+1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hc = h.managedExternalSettings().HostCapabilities
+	if hc.RegistryEntriesLeft != 127 || hc.MaxRegistryEntryTTL != 10 {
+		t.Fatal("unexpected capabilities", hc)
+	}
+
+	// The entry expires after the TTL.
+	pruned, err := h.staticRegistry.Prune(h.BlockHeight() + 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pruned != 0 {
+		t.Fatal("entry expired too early")
+	}
+	pruned, err = h.staticRegistry.Prune(h.BlockHeight() + 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pruned != 1 {
+		t.Fatal("entry should have expired after the ttl")
+	}
+}
+
 // TestHostMultiClose checks that the host returns an error if Close is called
 // multiple times on the host.
 func TestHostMultiClose(t *testing.T) {
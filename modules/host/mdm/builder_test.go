@@ -86,14 +86,14 @@ func (tb *testProgramBuilder) AddHasSectorInstruction(merkleRoot crypto.Hash) {
 // keeping track of running values.
 func (tb *testProgramBuilder) AddReadOffsetInstruction(length, offset uint64, merkleProof bool) {
 	tb.staticPB.AddReadOffsetInstruction(length, offset, merkleProof)
-	tb.staticValues.AddReadOffsetInstruction(length)
+	tb.staticValues.AddReadOffsetInstruction(length, merkleProof)
 }
 
 // AddReadSectorInstruction adds a readsector instruction to the builder,
 // keeping track of running values.
 func (tb *testProgramBuilder) AddReadSectorInstruction(length, offset uint64, merkleRoot crypto.Hash, merkleProof bool) {
 	tb.staticPB.AddReadSectorInstruction(length, offset, merkleRoot, merkleProof)
-	tb.staticValues.AddReadSectorInstruction(length)
+	tb.staticValues.AddReadSectorInstruction(length, merkleProof)
 }
 
 // AddRevisionInstruction adds a revision instruction to the builder, keeping
@@ -110,6 +110,13 @@ func (tb *testProgramBuilder) AddSwapSectorInstruction(sector1Idx, sector2Idx ui
 	tb.staticValues.AddSwapSectorInstruction()
 }
 
+// AddTagNFTInstruction adds a TagNFT instruction to the builder, keeping
+// track of running values.
+func (tb *testProgramBuilder) AddTagNFTInstruction(nftRoot crypto.Hash) {
+	tb.staticPB.AddTagNFTInstruction(nftRoot)
+	tb.staticValues.AddTagNFTInstruction()
+}
+
 // AddUpdateRegistryInstruction adds an UpdateRegistry instruction to the
 // builder, keeping track of running values.
 func (tb *testProgramBuilder) AddUpdateRegistryInstruction(spk types.SiaPublicKey, rv modules.SignedRegistryValue) {
@@ -137,7 +144,7 @@ func (tb *testProgramBuilder) AddReadRegistryInstruction(spk types.SiaPublicKey,
 	if err != nil {
 		panic(err)
 	}
-	tb.staticValues.AddReadRegistryInstruction(spk, refunded)
+	tb.staticValues.AddReadRegistryInstruction(spk, refunded, version)
 	return refund
 }
 
@@ -148,7 +155,7 @@ func (tb *testProgramBuilder) AddReadRegistryInstructionV156(spk types.SiaPublic
 	if err != nil {
 		panic(err)
 	}
-	tb.staticValues.AddReadRegistryInstruction(spk, refunded)
+	tb.staticValues.AddReadRegistryInstruction(spk, refunded, modules.ReadRegistryVersionNoType)
 	return refund
 }
 
@@ -159,7 +166,7 @@ func (tb *testProgramBuilder) AddReadRegistryEIDInstruction(sid modules.Registry
 	if err != nil {
 		panic(err)
 	}
-	tb.staticValues.AddReadRegistryEIDInstruction(sid, refunded)
+	tb.staticValues.AddReadRegistryEIDInstruction(sid, refunded, version)
 	return refund
 }
 
@@ -170,7 +177,7 @@ func (tb *testProgramBuilder) AddReadRegistryEIDInstructionV156(sid modules.Regi
 	if err != nil {
 		panic(err)
 	}
-	tb.staticValues.AddReadRegistryEIDInstruction(sid, refunded)
+	tb.staticValues.AddReadRegistryEIDInstruction(sid, refunded, modules.ReadRegistryVersionNoType)
 	return refund
 }
 
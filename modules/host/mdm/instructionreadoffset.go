@@ -107,7 +107,7 @@ func (i *instructionReadOffset) Cost() (executionCost, _ types.Currency, err err
 	if err != nil {
 		return
 	}
-	executionCost = modules.MDMReadCost(i.staticState.priceTable, length)
+	executionCost = modules.MDMReadCostWithProof(i.staticState.priceTable, length, i.staticMerkleProof)
 	return
 }
 
@@ -62,6 +62,7 @@ func executeReadRegistry(prevOutput output, ps *programState, sid modules.Regist
 	switch version {
 	case modules.ReadRegistryVersionNoType:
 	case modules.ReadRegistryVersionWithType:
+	case modules.ReadRegistryVersionNoProof:
 	default:
 		return errOutput(errors.New("invalid read registry type")), types.ZeroCurrency
 	}
@@ -76,7 +77,7 @@ func executeReadRegistry(prevOutput output, ps *programState, sid modules.Regist
 	// Get the value. If this fails we are done.
 	spk, rv, found := ps.host.RegistryGet(sid)
 	if !found {
-		_, refund := modules.MDMReadRegistryCost(ps.priceTable)
+		_, refund := modules.MDMReadRegistryCostForVersion(ps.priceTable, version)
 		return out, refund
 	}
 
@@ -86,13 +87,16 @@ func executeReadRegistry(prevOutput output, ps *programState, sid modules.Regist
 		out.Output = append(out.Output, rv.Tweak[:]...)
 	}
 
-	// Return the signature followed by the data.
+	// Return the signature followed by the data. Reads without a proof omit
+	// the signature.
 	rev := make([]byte, 8)
 	binary.LittleEndian.PutUint64(rev, rv.Revision)
-	out.Output = append(out.Output, rv.Signature[:]...)
+	if version != modules.ReadRegistryVersionNoProof {
+		out.Output = append(out.Output, rv.Signature[:]...)
+	}
 	out.Output = append(out.Output, rev...)
 	out.Output = append(out.Output, rv.Data...)
-	if version == modules.ReadRegistryVersionWithType {
+	if version == modules.ReadRegistryVersionWithType || version == modules.ReadRegistryVersionNoProof {
 		out.Output = append(out.Output, byte(rv.Type))
 	}
 	return out, types.ZeroCurrency
@@ -127,7 +131,7 @@ func (i *instructionReadRegistry) Collateral() types.Currency {
 
 // Cost returns the Cost of this `ReadRegistry` instruction.
 func (i *instructionReadRegistry) Cost() (executionCost, refund types.Currency, err error) {
-	executionCost, refund = modules.MDMReadRegistryCost(i.staticState.priceTable)
+	executionCost, refund = modules.MDMReadRegistryCostForVersion(i.staticState.priceTable, i.staticType)
 	return
 }
 
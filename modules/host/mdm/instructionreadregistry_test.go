@@ -30,6 +30,12 @@ func TestInstructionReadRegistry(t *testing.T) {
 			return modules.ReadRegistryVersionWithType
 		})
 	})
+	t.Run("NoProof", func(t *testing.T) {
+		testInstructionReadRegistry(t, func(tb *testProgramBuilder, spk types.SiaPublicKey, tweak crypto.Hash) modules.ReadRegistryVersion {
+			tb.AddReadRegistryInstruction(spk, tweak, false, modules.ReadRegistryVersionNoProof)
+			return modules.ReadRegistryVersionNoProof
+		})
+	})
 }
 
 // testInstructionReadRegistry tests the ReadRegistry instruction.
@@ -74,13 +80,20 @@ func testInstructionReadRegistry(t *testing.T, addReadRegistryInstruction func(t
 	revBytes := make([]byte, 8)
 	binary.LittleEndian.PutUint64(revBytes, rev)
 	expectedOutput := append(rv.Signature[:], append(revBytes, rv.Data...)...)
-	if version == modules.ReadRegistryVersionWithType {
+	if version == modules.ReadRegistryVersionNoProof {
+		// Reads without a proof don't return the signature.
+		expectedOutput = append(revBytes, rv.Data...)
+	}
+	if version == modules.ReadRegistryVersionWithType || version == modules.ReadRegistryVersionNoProof {
 		expectedOutput = append(expectedOutput, byte(rv.Type))
 	}
 	err = output.assert(0, crypto.Hash{}, []crypto.Hash{}, expectedOutput, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
+	if version == modules.ReadRegistryVersionNoProof {
+		return
+	}
 
 	// Verify the signature.
 	var sig2 crypto.Signature
@@ -76,7 +76,7 @@ func (i *instructionReadRegistryEID) Collateral() types.Currency {
 
 // Cost returns the Cost of this `ReadRegistryEID` instruction.
 func (i *instructionReadRegistryEID) Cost() (executionCost, refund types.Currency, err error) {
-	executionCost, refund = modules.MDMReadRegistryCost(i.staticState.priceTable)
+	executionCost, refund = modules.MDMReadRegistryCostForVersion(i.staticState.priceTable, i.staticType)
 	return
 }
 
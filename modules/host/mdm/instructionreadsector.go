@@ -127,7 +127,7 @@ func (i *instructionReadSector) Cost() (executionCost, _ types.Currency, err err
 	if err != nil {
 		return
 	}
-	executionCost = modules.MDMReadCost(i.staticState.priceTable, length)
+	executionCost = modules.MDMReadCostWithProof(i.staticState.priceTable, length, i.staticMerkleProof)
 	return
 }
 
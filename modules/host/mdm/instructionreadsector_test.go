@@ -79,6 +79,23 @@ func TestInstructionReadSector(t *testing.T) {
 	if !ok {
 		t.Fatal("failed to verify proof")
 	}
+
+	// Read the same range without a proof. The read is charged at a
+	// discount.
+	proofCost := outputs[0].ExecutionCost
+	tb = newTestProgramBuilder(pt, duration)
+	tb.AddReadSectorInstruction(length, offset, root, false)
+	outputs, err = mdm.ExecuteProgramWithBuilder(tb, so, duration, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = outputs[0].assert(ics, imr, []crypto.Hash{}, outputData, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outputs[0].ExecutionCost.Cmp(proofCost) >= 0 {
+		t.Fatalf("read without proof should be cheaper: %v >= %v", outputs[0].ExecutionCost, proofCost)
+	}
 }
 
 // TestInstructionReadOutsideSector tests reading a sector from outside the
@@ -0,0 +1,98 @@
+package mdm
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// errTagEmptyContract is returned if a program tries to tag an empty contract.
+var errTagEmptyContract = fmt.Errorf("can't tag a contract without data")
+
+// instructionTagNFT is an instruction which tags the contract as containing
+// the data of the NFT with the given root.
+type instructionTagNFT struct {
+	commonInstruction
+
+	nftRootOffset uint64
+}
+
+// staticDecodeTagNFTInstruction creates a new 'TagNFT' instruction from the
+// provided generic instruction.
+func (p *program) staticDecodeTagNFTInstruction(instruction modules.Instruction) (instruction, error) {
+	// Check specifier.
+	if instruction.Specifier != modules.SpecifierTagNFT {
+		return nil, fmt.Errorf("expected specifier %v but got %v",
+			modules.SpecifierTagNFT, instruction.Specifier)
+	}
+	// Check args.
+	if len(instruction.Args) != modules.RPCITagNFTLen {
+		return nil, fmt.Errorf("expected instruction to have len %v but was %v",
+			modules.RPCITagNFTLen, len(instruction.Args))
+	}
+	// Read args.
+	rootOffset := binary.LittleEndian.Uint64(instruction.Args[:8])
+	return &instructionTagNFT{
+		commonInstruction: commonInstruction{
+			staticData:        p.staticData,
+			staticMerkleProof: false,
+			staticState:       p.staticProgramState,
+		},
+		nftRootOffset: rootOffset,
+	}, nil
+}
+
+// Batch declares whether or not this instruction can be batched together with
+// the previous instruction.
+func (i instructionTagNFT) Batch() bool {
+	return true
+}
+
+// Collateral is zero for the TagNFT instruction.
+func (i *instructionTagNFT) Collateral() types.Currency {
+	return modules.MDMTagNFTCollateral()
+}
+
+// Cost returns the cost of executing this instruction.
+func (i *instructionTagNFT) Cost() (executionCost, _ types.Currency, err error) {
+	executionCost = modules.MDMTagNFTCost(i.staticState.priceTable)
+	return
+}
+
+// Memory returns the memory allocated by this instruction beyond the end of its
+// lifetime.
+func (i *instructionTagNFT) Memory() uint64 {
+	return modules.MDMTagNFTMemory()
+}
+
+// Execute executes the 'TagNFT' instruction.
+func (i *instructionTagNFT) Execute(prevOutput output) (output, types.Currency) {
+	// Fetch the operands.
+	nftRoot, err := i.staticData.Hash(i.nftRootOffset)
+	if err != nil {
+		return errOutput(err), types.ZeroCurrency
+	}
+	if nftRoot == (crypto.Hash{}) {
+		return errOutput(fmt.Errorf("can't tag a contract with an empty NFT root")), types.ZeroCurrency
+	}
+	if prevOutput.NewSize == 0 {
+		return errOutput(errTagEmptyContract), types.ZeroCurrency
+	}
+
+	// Remember the tag. It is added to the storage obligation when the program
+	// is finalized.
+	i.staticState.nftRoots[nftRoot] = struct{}{}
+
+	return output{
+		NewSize:       prevOutput.NewSize,       // size stays the same
+		NewMerkleRoot: prevOutput.NewMerkleRoot, // root stays the same
+	}, types.ZeroCurrency
+}
+
+// Time returns the execution time of a 'TagNFT' instruction.
+func (i *instructionTagNFT) Time() (uint64, error) {
+	return modules.MDMTimeTagNFT, nil
+}
@@ -0,0 +1,64 @@
+package mdm
+
+import (
+	"strings"
+	"testing"
+
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/types"
+)
+
+// TestInstructionTagNFT tests executing a program with TagNFT instructions.
+func TestInstructionTagNFT(t *testing.T) {
+	host := newTestHost()
+	mdm := New(host)
+	defer mdm.Stop()
+
+	// Create a storage obligation with some data.
+	so := host.newTestStorageObligation(true)
+	so.AddRandomSectors(2)
+
+	// Build a program which tags the contract twice with the same root.
+	pt := newTestPriceTable()
+	duration := types.BlockHeight(fastrand.Uint64n(5))
+	var nftRoot crypto.Hash
+	fastrand.Read(nftRoot[:])
+	tb := newTestProgramBuilder(pt, duration)
+	tb.AddTagNFTInstruction(nftRoot)
+	tb.AddTagNFTInstruction(nftRoot)
+
+	ics := so.ContractSize()
+	imr := so.MerkleRoot()
+
+	// Execute it.
+	outputs, err := mdm.ExecuteProgramWithBuilder(tb, so, duration, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Assert the outputs. Tagging doesn't change the contract.
+	for _, output := range outputs {
+		err = output.assert(ics, imr, []crypto.Hash{}, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The storage obligation should be tagged once.
+	if len(so.nftRoots) != 1 || so.nftRoots[0] != nftRoot {
+		t.Fatal("storage obligation wasn't tagged", so.nftRoots)
+	}
+
+	// Tagging an empty contract fails.
+	so = host.newTestStorageObligation(true)
+	tb = newTestProgramBuilder(pt, duration)
+	tb.AddTagNFTInstruction(nftRoot)
+	_, _, outputs, err = mdm.ExecuteProgramWithBuilderManualFinalize(tb, so, duration, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outputs) != 1 || outputs[0].Error == nil || !strings.Contains(outputs[0].Error.Error(), errTagEmptyContract.Error()) {
+		t.Fatal("expected tagging an empty contract to fail", outputs)
+	}
+}
@@ -9,8 +9,9 @@ import (
 
 // StorageObligation defines an interface the storage obligation must adhere to.
 type StorageObligation interface {
-	// Update updates the storage obligation.
-	Update(sectorRoots []crypto.Hash, sectorsRemoved map[crypto.Hash]struct{}, sectorsGained map[crypto.Hash][]byte) error
+	// Update updates the storage obligation and tags it with the roots of the
+	// NFTs it contains.
+	Update(sectorRoots []crypto.Hash, sectorsRemoved map[crypto.Hash]struct{}, sectorsGained map[crypto.Hash][]byte, nftRoots []crypto.Hash) error
 }
 
 // StorageObligationSnapshot defines an interface the snapshot must adhere to in
@@ -39,6 +39,7 @@ type (
 		host        *TestHost
 		sectorMap   map[crypto.Hash][]byte
 		sectorRoots []crypto.Hash
+		nftRoots    []crypto.Hash
 
 		// contract related fields.
 		sk crypto.SecretKey
@@ -199,7 +200,7 @@ func (so *TestStorageObligation) SectorRoots() []crypto.Hash {
 }
 
 // Update implements the StorageObligation interface.
-func (so *TestStorageObligation) Update(sectorRoots []crypto.Hash, sectorsRemoved map[crypto.Hash]struct{}, sectorsGained map[crypto.Hash][]byte) error {
+func (so *TestStorageObligation) Update(sectorRoots []crypto.Hash, sectorsRemoved map[crypto.Hash]struct{}, sectorsGained map[crypto.Hash][]byte, nftRoots []crypto.Hash) error {
 	for removedSector := range sectorsRemoved {
 		if _, exists := so.sectorMap[removedSector]; !exists {
 			return errors.New("sector doesn't exist")
@@ -213,6 +214,7 @@ func (so *TestStorageObligation) Update(sectorRoots []crypto.Hash, sectorsRemove
 		so.sectorMap[gainedSector] = gainedSectorData
 	}
 	so.sectorRoots = sectorRoots
+	so.nftRoots = append(so.nftRoots, nftRoots...)
 	return nil
 }
 
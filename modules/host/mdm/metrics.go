@@ -0,0 +1,13 @@
+package mdm
+
+import (
+	"go.sia.tech/siad/metrics"
+)
+
+var (
+	// programsMetric counts the programs executed by the MDM by outcome.
+	programsMetric = metrics.NewCounterVec("siad_host_mdm_programs_total", "Number of programs executed by the MDM, by outcome.", "outcome")
+
+	// instructionsMetric counts the instructions executed by the MDM.
+	instructionsMetric = metrics.NewCounter("siad_host_mdm_instructions_total", "Number of instructions executed by the MDM.")
+)
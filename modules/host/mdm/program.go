@@ -37,7 +37,8 @@ type programState struct {
 	staticRemainingDuration types.BlockHeight
 
 	// program cache
-	sectors sectors
+	sectors  sectors
+	nftRoots map[crypto.Hash]struct{}
 
 	// statistic related fields
 	potentialStorageRevenue types.Currency
@@ -101,6 +102,8 @@ func decodeInstruction(p *program, i modules.Instruction) (instruction, error) {
 		return p.staticDecodeRevisionInstruction(i)
 	case modules.SpecifierSwapSector:
 		return p.staticDecodeSwapSectorInstruction(i)
+	case modules.SpecifierTagNFT:
+		return p.staticDecodeTagNFTInstruction(i)
 	case modules.SpecifierUpdateRegistry:
 		return p.staticDecodeUpdateRegistryInstruction(i)
 	case modules.SpecifierReadRegistry:
@@ -134,6 +137,7 @@ func (mdm *MDM) ExecuteProgram(ctx context.Context, pt *modules.RPCPriceTable, p
 			host:                    mdm.host,
 			priceTable:              pt,
 			sectors:                 newSectors(sos.SectorRoots()),
+			nftRoots:                make(map[crypto.Hash]struct{}),
 			staticRevisionTxn:       sos.RevisionTxn(),
 		},
 		staticBudget:           budget,
@@ -173,6 +177,11 @@ func (mdm *MDM) ExecuteProgram(ctx context.Context, pt *modules.RPCPriceTable, p
 		defer program.tg.Done()
 		defer close(program.outputChan)
 		program.outputErr = program.executeInstructions(ctx, sos.ContractSize(), sos.MerkleRoot())
+		if program.outputErr != nil {
+			programsMetric.With("failure").Inc()
+		} else {
+			programsMetric.With("success").Inc()
+		}
 	}()
 	// If the program is readonly there is no need to finalize it.
 	if p.ReadOnly() {
@@ -270,6 +279,7 @@ func (p *program) executeInstructions(ctx context.Context, fcSize uint64, fcRoot
 		batch := idx < len(p.instructions)-1 && p.instructions[idx+1].Batch()
 		// Execute next instruction.
 		output, refund = i.Execute(output)
+		instructionsMetric.Inc()
 		// Issue potential refund.
 		if !refund.IsZero() {
 			p.refundCost(refund)
@@ -304,7 +314,11 @@ func (p *program) managedFinalize(so StorageObligation) error {
 	}
 	// Commit the changes to the storage obligation.
 	s := p.staticProgramState.sectors
-	err = so.Update(s.merkleRoots, s.sectorsRemoved, s.sectorsGained)
+	nftRoots := make([]crypto.Hash, 0, len(p.staticProgramState.nftRoots))
+	for root := range p.staticProgramState.nftRoots {
+		nftRoots = append(nftRoots, root)
+	}
+	err = so.Update(s.merkleRoots, s.sectorsRemoved, s.sectorsGained, nftRoots)
 	if err != nil {
 		return err
 	}
@@ -89,9 +89,9 @@ func (v *TestValues) AddHasSectorInstruction() {
 
 // AddReadOffsetInstruction adds a readoffset instruction to the builder,
 // keeping track of running values.
-func (v *TestValues) AddReadOffsetInstruction(length uint64) {
+func (v *TestValues) AddReadOffsetInstruction(length uint64, merkleProof bool) {
 	collateral := modules.MDMReadCollateral()
-	cost := modules.MDMReadCost(v.staticPT, length)
+	cost := modules.MDMReadCostWithProof(v.staticPT, length, merkleProof)
 	memory := modules.MDMReadMemory()
 	time := uint64(modules.MDMTimeReadOffset)
 	newData := 8 + 8
@@ -102,9 +102,9 @@ func (v *TestValues) AddReadOffsetInstruction(length uint64) {
 
 // AddReadSectorInstruction adds a readsector instruction to the builder,
 // keeping track of running values.
-func (v *TestValues) AddReadSectorInstruction(length uint64) {
+func (v *TestValues) AddReadSectorInstruction(length uint64, merkleProof bool) {
 	collateral := modules.MDMReadCollateral()
-	cost := modules.MDMReadCost(v.staticPT, length)
+	cost := modules.MDMReadCostWithProof(v.staticPT, length, merkleProof)
 	memory := modules.MDMReadMemory()
 	time := uint64(modules.MDMTimeReadSector)
 	newData := 8 + 8 + crypto.HashSize
@@ -138,6 +138,19 @@ func (v *TestValues) AddSwapSectorInstruction() {
 	v.addInstruction(collateral, cost, types.ZeroCurrency, types.ZeroCurrency, memory, time, newData, readonly, batch)
 }
 
+// AddTagNFTInstruction adds a TagNFT instruction to the builder, keeping
+// track of running values.
+func (v *TestValues) AddTagNFTInstruction() {
+	collateral := modules.MDMTagNFTCollateral()
+	cost := modules.MDMTagNFTCost(v.staticPT)
+	memory := modules.MDMTagNFTMemory()
+	time := uint64(modules.MDMTimeTagNFT)
+	newData := crypto.HashSize
+	readonly := false
+	batch := true
+	v.addInstruction(collateral, cost, types.ZeroCurrency, types.ZeroCurrency, memory, time, newData, readonly, batch)
+}
+
 // AddUpdateRegistryInstruction adds a revision instruction to the builder, keeping
 // track of running values.
 func (v *TestValues) AddUpdateRegistryInstruction(spk types.SiaPublicKey, rv modules.SignedRegistryValue) {
@@ -153,10 +166,10 @@ func (v *TestValues) AddUpdateRegistryInstruction(spk types.SiaPublicKey, rv mod
 
 // AddReadRegistryInstruction adds a revision instruction to the builder, keeping
 // track of running values.
-func (v *TestValues) AddReadRegistryInstruction(spk types.SiaPublicKey, refunded bool) {
+func (v *TestValues) AddReadRegistryInstruction(spk types.SiaPublicKey, refunded bool, version modules.ReadRegistryVersion) {
 	memory := modules.MDMReadRegistryMemory()
 	collateral := modules.MDMReadRegistryCollateral()
-	cost, refund := modules.MDMReadRegistryCost(v.staticPT)
+	cost, refund := modules.MDMReadRegistryCostForVersion(v.staticPT, version)
 	time := uint64(modules.MDMTimeReadRegistry)
 	newData := crypto.HashSize + len(encoding.Marshal(spk))
 	readonly := true
@@ -170,10 +183,10 @@ func (v *TestValues) AddReadRegistryInstruction(spk types.SiaPublicKey, refunded
 
 // AddReadRegistryEIDInstruction adds a revision instruction to the builder,
 // keeping track of running values.
-func (v *TestValues) AddReadRegistryEIDInstruction(sid modules.RegistryEntryID, refunded bool) {
+func (v *TestValues) AddReadRegistryEIDInstruction(sid modules.RegistryEntryID, refunded bool, version modules.ReadRegistryVersion) {
 	memory := modules.MDMReadRegistryMemory()
 	collateral := modules.MDMReadRegistryCollateral()
-	cost, refund := modules.MDMReadRegistryCost(v.staticPT)
+	cost, refund := modules.MDMReadRegistryCostForVersion(v.staticPT, version)
 	time := uint64(modules.MDMTimeReadRegistry)
 	newData := len(encoding.Marshal(sid))
 	readonly := true
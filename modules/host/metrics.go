@@ -0,0 +1,21 @@
+package host
+
+import (
+	"go.sia.tech/siad/metrics"
+)
+
+var (
+	// registryUpdatesMetric counts the registry updates received by the host
+	// by outcome.
+	registryUpdatesMetric = metrics.NewCounterVec("siad_host_registry_updates_total", "Number of registry updates received by the host, by outcome.", "outcome")
+
+	// contractUploadBytesMetric and contractDownloadBytesMetric count the
+	// bytes renters uploaded and downloaded for the host's storage
+	// obligations.
+	contractUploadBytesMetric   = metrics.NewCounter("siad_host_contract_upload_bytes_total", "Number of bytes renters uploaded for the host's storage obligations.")
+	contractDownloadBytesMetric = metrics.NewCounter("siad_host_contract_download_bytes_total", "Number of bytes renters downloaded for the host's storage obligations.")
+
+	// contractBandwidthLimitedMetric counts the RPCs the host rejected
+	// because a contract used up its bandwidth.
+	contractBandwidthLimitedMetric = metrics.NewCounter("siad_host_contract_bandwidth_limited_total", "Number of RPCs rejected because a contract used up its bandwidth.")
+)
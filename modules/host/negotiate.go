@@ -280,6 +280,13 @@ func (h *Host) managedFinalizeContract(args finalizeContractArgs) ([]types.Trans
 		h: h,
 	}
 
+	// A renewed contract keeps the data of the old one, so it also takes over
+	// its NFT tags.
+	if args.renewedSO != nil {
+		so.NFTRoots = args.renewedSO.NFTRoots
+		args.renewedSO.NFTRoots = nil
+	}
+
 	// Get a lock on the storage obligation.
 	lockErr := h.managedTryLockStorageObligation(so.id(), obligationLockTimeout)
 	if lockErr != nil {
@@ -265,8 +265,10 @@ func (h *Host) managedVerifyNewContract(txnSet []types.Transaction, renterPK cry
 		return ErrSmallWindow
 	}
 	// WindowStart must not be more than settings.MaxDuration blocks into the
-	// future.
-	if fc.WindowStart > blockHeight+eSettings.MaxDuration {
+	// future. Contracts are only tagged as storing NFT data after they are
+	// formed, which is why the NFT retention period is accepted for any new
+	// contract.
+	if fc.WindowStart > blockHeight+eSettings.MaxDurationFor(true) {
 		return ErrLongDuration
 	}
 
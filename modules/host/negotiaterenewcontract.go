@@ -145,8 +145,9 @@ func (h *Host) managedVerifyRenewedContract(so storageObligation, txnSet []types
 		return types.Currency{}, ErrSmallWindow
 	}
 	// WindowStart must not be more than settings.MaxDuration blocks into the
-	// future.
-	if fc.WindowStart > blockHeight+externalSettings.MaxDuration {
+	// future. Like for new contracts, the NFT retention period is accepted
+	// since the renewed contract might be tagged later.
+	if fc.WindowStart > blockHeight+externalSettings.MaxDurationFor(true) {
 		return types.Currency{}, ErrLongDuration
 	}
 
@@ -24,6 +24,37 @@ func (h *Host) capacity() (total, remaining uint64) {
 	return total, remaining
 }
 
+// maxRegistryEntryTTL returns the number of blocks the host keeps a registry
+// entry after it was last updated.
+func maxRegistryEntryTTL(settings modules.HostInternalSettings) types.BlockHeight {
+	if settings.MaxRegistryEntryTTL == 0 {
+		return defaultMaxRegistryEntryTTL
+	}
+	return settings.MaxRegistryEntryTTL
+}
+
+// capabilities returns the registry and NFT capabilities that the host
+// advertises.
+func (h *Host) capabilities() modules.HostCapabilities {
+	hc := modules.HostCapabilities{
+		RegistryEntriesLeft: h.staticRegistry.Cap() - h.staticRegistry.Len(),
+		NFTStoragePool:      h.settings.NFTStoragePool,
+	}
+	if h.staticRegistry.Cap() > 0 {
+		hc.MaxRegistryEntryTTL = maxRegistryEntryTTL(h.settings)
+	}
+	return hc
+}
+
+// freeRegistryReads returns the number of registry reads per minute the host
+// serves to a client without payment.
+func (h *Host) freeRegistryReads() uint64 {
+	if h.staticRegistry.Cap() == 0 {
+		return 0
+	}
+	return h.settings.FreeRegistryReads
+}
+
 // externalSettings compiles and returns the external settings for the host.
 func (h *Host) externalSettings(maxFeeEstimation types.Currency) modules.HostExternalSettings {
 	// Increment the revision number for the external settings
@@ -82,6 +113,9 @@ func (h *Host) externalSettings(maxFeeEstimation types.Currency) modules.HostExt
 		UnlockHash:           h.unlockHash,
 		WindowSize:           h.settings.WindowSize,
 
+		MaxWriteStreamSectors: modules.NegotiateMaxWriteStreamSectors,
+		FreeRegistryReads:     h.freeRegistryReads(),
+
 		Collateral:    h.settings.Collateral,
 		MaxCollateral: maxCollateral,
 
@@ -92,6 +126,11 @@ func (h *Host) externalSettings(maxFeeEstimation types.Currency) modules.HostExt
 		StoragePrice:           h.settings.MinStoragePrice,
 		UploadBandwidthPrice:   h.settings.MinUploadBandwidthPrice,
 
+		NFTStoragePrice:    h.settings.MinNFTStoragePrice,
+		NFTRetentionPeriod: h.settings.NFTRetentionPeriod,
+
+		HostCapabilities: h.capabilities(),
+
 		EphemeralAccountExpiry:     h.settings.EphemeralAccountExpiry,
 		MaxEphemeralAccountBalance: h.settings.MaxEphemeralAccountBalance,
 
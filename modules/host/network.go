@@ -417,10 +417,16 @@ func (h *Host) threadedHandleStream(stream siamux.Stream) {
 		err = h.managedRPCFundEphemeralAccount(stream)
 	case modules.RPCLatestRevision:
 		err = h.managedRPCLatestRevision(stream)
+	case modules.RPCMDMCostModel:
+		err = h.managedRPCMDMCostModel(stream)
 	case modules.RPCRegistrySubscription:
 		cleanup, err = h.managedRPCRegistrySubscribe(stream)
 	case modules.RPCRenewContract:
 		err = h.managedRPCRenewContract(stream)
+	case modules.RPCFreeRegistryRead:
+		err = h.managedRPCFreeRegistryRead(stream)
+	case modules.RPCAuditSector:
+		err = h.managedRPCAuditSector(stream)
 	default:
 		h.log.Debugf("WARN: incoming stream %v requested unknown RPC \"%v\"", stream.RemoteAddr().String(), rpcID)
 		err = errors.New(fmt.Sprintf("Unrecognized RPC id %v", rpcID))
@@ -169,6 +169,74 @@ func (h *Host) managedRPCLoopWrite(s *rpcSession) error {
 		err = errors.Compose(err, s.writeError(err))
 		return err
 	}
+	return h.managedLoopWrite(s, req)
+}
+
+// managedRPCLoopWriteStream reads the sectors of a streamed upload,
+// acknowledging each sector as soon as it was processed, and responds with a
+// signature for the new revision once all sectors were received.
+func (h *Host) managedRPCLoopWriteStream(s *rpcSession) error {
+	s.extendDeadline(modules.NegotiateFileContractRevisionTime)
+	// Read the request.
+	var req modules.LoopWriteStreamRequest
+	if err := s.readRequest(&req, modules.RPCMinLen); err != nil {
+		err = errors.Compose(err, s.writeError(err))
+		return err
+	}
+	if req.NumSectors == 0 || req.NumSectors > modules.NegotiateMaxWriteStreamSectors {
+		err := errors.New("illegal number of sectors")
+		err = errors.Compose(err, s.writeError(err))
+		return err
+	}
+
+	// Check that a contract is locked before accepting any data.
+	if len(s.so.OriginTransactionSet) == 0 {
+		err := errors.New("no contract locked")
+		err = errors.Compose(err, s.writeError(err))
+		return err
+	}
+
+	// Read the sectors. Every sector is acknowledged once it was read and
+	// hashed, so a host which can't keep up slows the renter down.
+	actions := make([]modules.LoopWriteAction, 0, req.NumSectors)
+	for i := uint64(0); i < req.NumSectors; i++ {
+		s.extendDeadline(modules.NegotiateFileContractRevisionTime)
+		var sector modules.LoopWriteStreamSector
+		if err := s.readRequest(&sector, modules.SectorSize+modules.RPCMinLen); err != nil {
+			err = errors.Compose(err, s.writeError(err))
+			return err
+		}
+		if uint64(len(sector.Data)) != modules.SectorSize {
+			s.writeError(ErrBadSectorSize)
+			return ErrBadSectorSize
+		}
+		ack := modules.LoopWriteStreamAck{
+			Index: i,
+			Root:  crypto.MerkleRoot(sector.Data),
+		}
+		if err := s.writeResponse(ack); err != nil {
+			return err
+		}
+		actions = append(actions, modules.LoopWriteAction{
+			Type: modules.WriteActionAppend,
+			Data: sector.Data,
+		})
+	}
+
+	// The rest of the RPC is a Write RPC with a Merkle proof.
+	s.extendDeadline(modules.NegotiateFileContractRevisionTime)
+	return h.managedLoopWrite(s, modules.LoopWriteRequest{
+		Actions:              actions,
+		MerkleProof:          true,
+		NewRevisionNumber:    req.NewRevisionNumber,
+		NewValidProofValues:  req.NewValidProofValues,
+		NewMissedProofValues: req.NewMissedProofValues,
+	})
+}
+
+// managedLoopWrite performs the actions of a Write RPC and responds with a
+// signature for the new revision.
+func (h *Host) managedLoopWrite(s *rpcSession, req modules.LoopWriteRequest) error {
 	// If no Merkle proof was requested, the renter's signature should be
 	// sent immediately.
 	var sigResponse modules.LoopWriteResponse
@@ -281,7 +349,7 @@ func (h *Host) managedRPCLoopWrite(s *rpcSession) error {
 		bytesAdded := modules.SectorSize * uint64(len(newRoots)-len(s.so.SectorRoots))
 		blocksRemaining := s.so.proofDeadline() - blockHeight
 		blockBytesCurrency := types.NewCurrency64(uint64(blocksRemaining)).Mul64(bytesAdded)
-		storageRevenue = settings.StoragePrice.Mul(blockBytesCurrency)
+		storageRevenue = settings.StoragePriceFor(len(s.so.NFTRoots) > 0).Mul(blockBytesCurrency)
 		newCollateral = newCollateral.Add(settings.Collateral.Mul(blockBytesCurrency))
 	}
 
@@ -75,6 +75,8 @@ func (h *Host) establishDefaults() error {
 		EphemeralAccountExpiry:     modules.DefaultEphemeralAccountExpiry,
 		MaxEphemeralAccountBalance: modules.DefaultMaxEphemeralAccountBalance,
 		MaxEphemeralAccountRisk:    defaultMaxEphemeralAccountRisk,
+
+		RegistryRetention: defaultRegistryRetention,
 	}
 
 	// Load the host's key pair, use the same keys as the SiaMux.
@@ -0,0 +1,189 @@
+package host
+
+import (
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/types"
+)
+
+// programscheduler.go contains the scheduler that decides when programs
+// against a storage obligation are executed. Read-only programs only read from
+// a snapshot of the obligation, which allows multiple of them to run at the
+// same time. Write programs modify the obligation and therefore run on their
+// own.
+//
+// To be fair to both kinds of programs, the scheduler admits programs in the
+// order they arrived. A read-only program that arrives while a write program
+// is waiting is queued behind the write program, which prevents a steady
+// stream of reads from starving writes. Once the write program is done, all
+// read-only programs that queued up behind it are admitted at once, up to
+// maxConcurrentReadPrograms.
+
+var (
+	// errProgramSchedulerTimeout is returned if a program couldn't be
+	// scheduled before the timeout.
+	errProgramSchedulerTimeout = errors.New("timed out waiting for other programs on the storage obligation to finish")
+
+	// errProgramSchedulerStopped is returned if the host shuts down while a
+	// program is waiting to be scheduled.
+	errProgramSchedulerStopped = errors.New("host is shutting down")
+)
+
+type (
+	// programScheduler schedules the execution of programs per storage
+	// obligation.
+	programScheduler struct {
+		obligations      map[types.FileContractID]*obligationPrograms
+		staticMaxReaders int
+		mu               sync.Mutex
+	}
+
+	// obligationPrograms tracks the programs running against a single storage
+	// obligation and the programs waiting to be executed.
+	obligationPrograms struct {
+		readers int
+		writer  bool
+		queue   []*scheduledProgram
+	}
+
+	// scheduledProgram is a program waiting to be executed. The ready channel
+	// is closed once the program is admitted.
+	scheduledProgram struct {
+		write bool
+		ready chan struct{}
+	}
+)
+
+// newProgramScheduler creates a new program scheduler which executes at most
+// maxReaders read-only programs concurrently per storage obligation.
+func newProgramScheduler(maxReaders int) *programScheduler {
+	return &programScheduler{
+		obligations:      make(map[types.FileContractID]*obligationPrograms),
+		staticMaxReaders: maxReaders,
+	}
+}
+
+// canRun returns whether a program can be admitted given the programs that are
+// currently running.
+func (op *obligationPrograms) canRun(write bool, maxReaders int) bool {
+	if write {
+		return !op.writer && op.readers == 0
+	}
+	return !op.writer && op.readers < maxReaders
+}
+
+// admit marks a program as running.
+func (op *obligationPrograms) admit(write bool) {
+	if write {
+		op.writer = true
+	} else {
+		op.readers++
+	}
+}
+
+// idle returns true if no programs are running or waiting.
+func (op *obligationPrograms) idle() bool {
+	return !op.writer && op.readers == 0 && len(op.queue) == 0
+}
+
+// managedSchedule blocks until the program can be executed against the storage
+// obligation. Write programs are executed exclusively while read-only programs
+// may be executed concurrently. If the program isn't admitted before the
+// timeout or before stop is closed, an error is returned. Every successful call
+// must be followed by a call to managedDone.
+func (ps *programScheduler) managedSchedule(fcid types.FileContractID, write bool, timeout time.Duration, stop <-chan struct{}) error {
+	ps.mu.Lock()
+	op, exists := ps.obligations[fcid]
+	if !exists {
+		op = &obligationPrograms{}
+		ps.obligations[fcid] = op
+	}
+	// Only run the program right away if nobody is waiting, otherwise it would
+	// overtake the programs in the queue.
+	if len(op.queue) == 0 && op.canRun(write, ps.staticMaxReaders) {
+		op.admit(write)
+		ps.mu.Unlock()
+		return nil
+	}
+	sp := &scheduledProgram{
+		write: write,
+		ready: make(chan struct{}),
+	}
+	op.queue = append(op.queue, sp)
+	ps.mu.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	var err error
+	select {
+	case <-sp.ready:
+		return nil
+	case <-timer.C:
+		err = errProgramSchedulerTimeout
+	case <-stop:
+		err = errProgramSchedulerStopped
+	}
+
+	// Remove the program from the queue. If it was admitted in the meantime,
+	// it needs to give up its slot again.
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	select {
+	case <-sp.ready:
+		ps.release(fcid, write)
+		return err
+	default:
+	}
+	for i := range op.queue {
+		if op.queue[i] == sp {
+			op.queue = append(op.queue[:i], op.queue[i+1:]...)
+			break
+		}
+	}
+	// Removing the program might allow the programs behind it to run.
+	ps.admitQueued(fcid, op)
+	return err
+}
+
+// managedDone signals that a program scheduled with managedSchedule finished
+// executing.
+func (ps *programScheduler) managedDone(fcid types.FileContractID, write bool) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.release(fcid, write)
+}
+
+// release marks a running program as done and admits the queued programs
+// that can run now.
+func (ps *programScheduler) release(fcid types.FileContractID, write bool) {
+	op, exists := ps.obligations[fcid]
+	if !exists {
+		build.Critical("program released for an obligation without running programs")
+		return
+	}
+	if write {
+		op.writer = false
+	} else {
+		op.readers--
+	}
+	ps.admitQueued(fcid, op)
+}
+
+// admitQueued admits queued programs from the front of the queue until the
+// next program can't run. The obligation is removed from the scheduler once
+// it's idle.
+func (ps *programScheduler) admitQueued(fcid types.FileContractID, op *obligationPrograms) {
+	for len(op.queue) > 0 && op.canRun(op.queue[0].write, ps.staticMaxReaders) {
+		sp := op.queue[0]
+		op.queue = op.queue[1:]
+		op.admit(sp.write)
+		close(sp.ready)
+	}
+	if op.idle() {
+		delete(ps.obligations, fcid)
+	}
+}
@@ -0,0 +1,104 @@
+package host
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/types"
+)
+
+// TestProgramScheduler is a unit test for the programScheduler.
+func TestProgramScheduler(t *testing.T) {
+	t.Parallel()
+
+	ps := newProgramScheduler(2)
+	fcid := types.FileContractID{1}
+	stop := make(chan struct{})
+
+	// schedule is a helper that schedules a program in a goroutine and returns
+	// a channel that receives the result.
+	schedule := func(write bool, timeout time.Duration) chan error {
+		c := make(chan error, 1)
+		go func() {
+			c <- ps.managedSchedule(fcid, write, timeout, stop)
+		}()
+		return c
+	}
+	// admitted returns whether a scheduled program was admitted.
+	admitted := func(c chan error) bool {
+		select {
+		case err := <-c:
+			if err != nil {
+				t.Fatal(err)
+			}
+			return true
+		case <-time.After(100 * time.Millisecond):
+			return false
+		}
+	}
+
+	// Two readers run concurrently, the third one has to wait.
+	for i := 0; i < 2; i++ {
+		if !admitted(schedule(false, time.Minute)) {
+			t.Fatal("readers should run concurrently")
+		}
+	}
+	r3 := schedule(false, time.Minute)
+	if admitted(r3) {
+		t.Fatal("reader shouldn't exceed the limit")
+	}
+
+	// A writer queues behind the waiting reader and new readers queue behind
+	// the writer.
+	w1 := schedule(true, time.Minute)
+	time.Sleep(50 * time.Millisecond)
+	r4 := schedule(false, time.Minute)
+	ps.managedDone(fcid, false)
+	if !admitted(r3) {
+		t.Fatal("waiting reader should be admitted")
+	}
+	if admitted(w1) || admitted(r4) {
+		t.Fatal("writer should wait for readers and reader shouldn't overtake the writer")
+	}
+	ps.managedDone(fcid, false)
+	ps.managedDone(fcid, false)
+	if !admitted(w1) {
+		t.Fatal("writer should be admitted")
+	}
+	if admitted(r4) {
+		t.Fatal("reader shouldn't run concurrently with the writer")
+	}
+	ps.managedDone(fcid, true)
+	if !admitted(r4) {
+		t.Fatal("reader should be admitted after the writer")
+	}
+
+	// Programs time out and don't block programs queued behind them.
+	w2 := schedule(true, 50*time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	r5 := schedule(false, time.Minute)
+	if err := <-w2; !errors.Contains(err, errProgramSchedulerTimeout) {
+		t.Fatal("expected errProgramSchedulerTimeout but got", err)
+	}
+	if !admitted(r5) {
+		t.Fatal("reader should be admitted after the writer timed out")
+	}
+
+	// Waiting programs are interrupted on shutdown.
+	w3 := schedule(true, time.Minute)
+	close(stop)
+	if err := <-w3; !errors.Contains(err, errProgramSchedulerStopped) {
+		t.Fatal("expected errProgramSchedulerStopped but got", err)
+	}
+
+	// The obligation is removed once all programs are done.
+	ps.managedDone(fcid, false)
+	ps.managedDone(fcid, false)
+	ps.mu.Lock()
+	_, exists := ps.obligations[fcid]
+	ps.mu.Unlock()
+	if exists {
+		t.Fatal("obligation should have been removed")
+	}
+}
@@ -0,0 +1,302 @@
+package registry
+
+import (
+	"io"
+	"sort"
+	"sync"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// compactEntryLocks is the number of locks used to lock the entries of a
+// registry with a compact index. Entries are assigned to a lock by their id.
+const compactEntryLocks = 256
+
+type (
+	// compactEntry is the in-memory representation of an entry of a registry
+	// with a compact index. It only contains the information required to find
+	// and prune the entry. The value itself is read from disk when needed.
+	compactEntry struct {
+		staticIndex int64
+		expiry      compressedBlockHeight
+	}
+
+	// compactPruneCandidate is an entry which is considered for pruning.
+	compactPruneCandidate struct {
+		id    modules.RegistryEntryID
+		entry compactEntry
+	}
+)
+
+// newCompactEntry creates the compact index entry of a value.
+func newCompactEntry(v *value) compactEntry {
+	return compactEntry{
+		staticIndex: v.staticIndex,
+		expiry:      compressedBlockHeight(v.expiry),
+	}
+}
+
+// loadRegistryIndex reads the currently in use registry entries from disk and
+// creates a compact index from them. If the registry is being upgraded from
+// v1.0.0, the upgraded entries are saved right away since they are not kept in
+// memory. It also returns the indices of duplicate entries which need to be
+// freed.
+func (r *Registry) loadRegistryIndex(rd io.Reader, numEntries int64, b bitfield, upgradeV100 bool) (map[modules.RegistryEntryID]compactEntry, []int64, error) {
+	index := make(map[modules.RegistryEntryID]compactEntry)
+	var duplicates []int64
+	err := forEachRegistryEntry(rd, numEntries, b, upgradeV100, func(v *value) error {
+		if upgradeV100 {
+			if err := r.staticSaveEntry(v, true); err != nil {
+				return errors.AddContext(err, "failed to save upgraded entry")
+			}
+		}
+		id := v.mapKey()
+		if existing, exists := index[id]; exists {
+			// Duplicates are rare, read the revision of the existing entry
+			// from disk.
+			existingValue, err := r.staticReadEntry(existing.staticIndex)
+			if err != nil {
+				return errors.AddContext(err, "failed to read duplicate entry")
+			}
+			if v.revision <= existingValue.revision {
+				duplicates = append(duplicates, v.staticIndex)
+				return nil
+			}
+			duplicates = append(duplicates, existing.staticIndex)
+		}
+		index[id] = newCompactEntry(v)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return index, duplicates, nil
+}
+
+// staticEntryLock returns the lock of the entry with the given id.
+func (r *Registry) staticEntryLock(id modules.RegistryEntryID) *sync.Mutex {
+	return &r.staticEntryLocks[id[0]]
+}
+
+// managedDeleteFromIndex deletes an entry from a compact registry by freeing
+// its index in the bitfield and removing it from the index. This does not
+// delete it from disk.
+func (r *Registry) managedDeleteFromIndex(id modules.RegistryEntryID, entry compactEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// Unset the index.
+	err := r.usage.Unset(uint64(entry.staticIndex) - 1)
+	if err != nil {
+		build.Critical("managedDeleteFromIndex: unsetting an index should never fail")
+	}
+	// Delete the entry from the index.
+	delete(r.index, id)
+}
+
+// managedGetCompact fetches the data associated with a key and tweak from a
+// compact registry.
+func (r *Registry) managedGetCompact(id modules.RegistryEntryID) (types.SiaPublicKey, modules.SignedRegistryValue, bool) {
+	l := r.staticEntryLock(id)
+	l.Lock()
+	defer l.Unlock()
+
+	r.mu.Lock()
+	entry, ok := r.index[id]
+	r.mu.Unlock()
+	if !ok {
+		return types.SiaPublicKey{}, modules.SignedRegistryValue{}, false
+	}
+	v, err := r.staticReadEntry(entry.staticIndex)
+	if err != nil {
+		return types.SiaPublicKey{}, modules.SignedRegistryValue{}, false
+	}
+	return v.key, modules.NewSignedRegistryValue(v.tweak, v.data, v.revision, v.signature, v.entryType), true
+}
+
+// managedUpdateCompact adds an entry to a compact registry or if it exists
+// already, updates it. The value is expected to be verified by the caller.
+func (r *Registry) managedUpdateCompact(rv modules.SignedRegistryValue, pubKey types.SiaPublicKey, expiry types.BlockHeight) (srv modules.SignedRegistryValue, _ error) {
+	id := modules.DeriveRegistryEntryID(pubKey, rv.Tweak)
+	l := r.staticEntryLock(id)
+	l.Lock()
+	defer l.Unlock()
+
+	// Check if the entry exists already. If it doesn't, we create a new one.
+	r.mu.Lock()
+	compact, exists := r.index[id]
+	var v *value
+	var err error
+	if !exists {
+		v, err = r.newValue(id, rv, pubKey, expiry)
+		if err != nil {
+			r.mu.Unlock()
+			return modules.SignedRegistryValue{}, errors.AddContext(err, "failed to create new value")
+		}
+		compact = newCompactEntry(v)
+	}
+	r.mu.Unlock()
+
+	// If the entry existed, read it from disk and remember it before updating
+	// it.
+	if exists {
+		v, err = r.staticReadEntry(compact.staticIndex)
+		if err != nil {
+			return modules.SignedRegistryValue{}, errors.AddContext(err, "failed to read existing entry")
+		}
+		srv = modules.NewSignedRegistryValue(v.tweak, v.data, v.revision, v.signature, v.entryType)
+	}
+	// Update the entry.
+	err = v.update(rv, expiry, !exists, r.staticHPK)
+	if err != nil {
+		return srv, errors.AddContext(err, "failed to update entry")
+	}
+
+	// Write the entry to disk.
+	err = r.staticSaveEntry(v, true)
+	if err != nil {
+		// If an error occurs during saving and the entry was just created, we
+		// delete it from the registry and free its index.
+		if !exists {
+			r.managedDeleteFromIndex(id, compact)
+		}
+		return modules.SignedRegistryValue{}, errors.New("failed to save new entry to disk")
+	}
+
+	// Update the expiry in the index.
+	r.mu.Lock()
+	r.index[id] = newCompactEntry(v)
+	r.mu.Unlock()
+	return srv, nil
+}
+
+// managedPruneCompact deletes all entries from a compact registry that expire
+// at a height smaller than or equal to the provided expiry argument.
+func (r *Registry) managedPruneCompact(expiry types.BlockHeight) (uint64, error) {
+	// Get the expired entries. We only hold the lock during the map access.
+	r.mu.Lock()
+	var candidates []compactPruneCandidate
+	for id, entry := range r.index {
+		if types.BlockHeight(entry.expiry) <= expiry {
+			candidates = append(candidates, compactPruneCandidate{id: id, entry: entry})
+		}
+	}
+	r.mu.Unlock()
+
+	// Sort the entries without holding the lock.
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].entry.staticIndex < candidates[j].entry.staticIndex
+	})
+
+	// Loop over them and delete the ones that are still expired.
+	var errs error
+	var pruned uint64
+	for _, candidate := range candidates {
+		l := r.staticEntryLock(candidate.id)
+		l.Lock()
+
+		// The entry might have been updated in the meantime.
+		r.mu.Lock()
+		entry, exists := r.index[candidate.id]
+		r.mu.Unlock()
+		if !exists || types.BlockHeight(entry.expiry) > expiry {
+			l.Unlock()
+			continue
+		}
+		// Delete the entry from disk.
+		if err := r.staticSaveEntry(&value{staticIndex: entry.staticIndex}, false); err != nil {
+			errs = errors.Compose(errs, err)
+			l.Unlock()
+			continue
+		}
+		// Delete the entry from the registry.
+		r.managedDeleteFromIndex(candidate.id, entry)
+		l.Unlock()
+		pruned++
+	}
+	return pruned, errs
+}
+
+// managedTruncateCompact resizes a compact registry. If 'force' was specified,
+// it will allow to shrink the registry below its current size. This will cause
+// random values to be lost.
+func (r *Registry) managedTruncateCompact(newMaxEntries uint64, force bool) error {
+	// Lock all the entries before acquiring the registry lock.
+	for i := range r.staticEntryLocks {
+		r.staticEntryLocks[i].Lock()
+		defer r.staticEntryLocks[i].Unlock()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Check if truncating is possible.
+	if !force && newMaxEntries < uint64(len(r.index)) {
+		return ErrInvalidTruncate
+	}
+
+	// Create a new bitfield and mark all the existing entries within its range
+	// and remember the ones that aren't.
+	var entriesToMove []modules.RegistryEntryID
+	newUsage, err := newBitfield(newMaxEntries)
+	if err != nil {
+		return errors.AddContext(err, "failed to create new bitfield")
+	}
+	for id, entry := range r.index {
+		// Check if entry is already in a valid spot.
+		bit := uint64(entry.staticIndex - 1)
+		if bit < newMaxEntries {
+			err = newUsage.Set(bit)
+			if err != nil {
+				return errors.AddContext(err, "failed to set bit in new bitfield for existing index")
+			}
+			continue
+		}
+		entriesToMove = append(entriesToMove, id)
+	}
+
+	// Loop over the unset bits of the new bitfield and move the entries
+	// accordingly.
+	for i := uint64(0); i < newUsage.Len() && len(entriesToMove) > 0; i++ {
+		if newUsage.IsSet(i) {
+			continue // already in use
+		}
+		err = newUsage.Set(i)
+		if err != nil {
+			return errors.AddContext(err, "failed to set bit in new bitfield for new index")
+		}
+		// Move entry to new location.
+		var id modules.RegistryEntryID
+		id, entriesToMove = entriesToMove[0], entriesToMove[1:]
+		v, err := r.staticReadEntry(r.index[id].staticIndex)
+		if err != nil {
+			return errors.AddContext(err, "failed to read value to move")
+		}
+		v.staticIndex = int64(i) + 1
+		err = r.staticSaveEntry(v, true)
+		if err != nil {
+			return errors.AddContext(err, "failed to save value at new location")
+		}
+		r.index[id] = newCompactEntry(v)
+	}
+
+	// If 'force' wasn't specified, there should be no more entries to move.
+	if !force && len(entriesToMove) > 0 {
+		err := errors.New("entriesToMove is longer than free entries, this shouldn't happen")
+		build.Critical(err)
+		return err
+	} else if force {
+		// If 'force' was specified, the remaining entries need to be removed from
+		// the index.
+		for _, id := range entriesToMove {
+			delete(r.index, id)
+		}
+	}
+
+	// Replace the usage bitfield.
+	r.usage = newUsage
+
+	// Truncate the file.
+	return r.staticFile.Truncate(int64(PersistedEntrySize * (newMaxEntries + 1)))
+}
@@ -0,0 +1,253 @@
+package registry
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// compactTestEntry is an entry that was added to a registry in a test.
+type compactTestEntry struct {
+	rv modules.SignedRegistryValue
+	v  *value
+	sk crypto.SecretKey
+}
+
+// checkCompactEntries checks that the registry contains exactly the provided
+// entries.
+func checkCompactEntries(r *Registry, entries []compactTestEntry) error {
+	if r.Len() != uint64(len(entries)) {
+		return fmt.Errorf("expected %v entries but got %v", len(entries), r.Len())
+	}
+	for _, entry := range entries {
+		spk, rv, ok := r.Get(entry.v.mapKey())
+		if !ok {
+			return errors.New("entry not found")
+		}
+		if !spk.Equals(entry.v.key) {
+			return errors.New("wrong key")
+		}
+		if !reflect.DeepEqual(rv, entry.rv) {
+			return errors.New("wrong value")
+		}
+	}
+	return nil
+}
+
+// TestCompactRegistry tests that a registry with a compact index behaves like
+// a regular one.
+func TestCompactRegistry(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	dir := testDir(t.Name())
+	registryPath := filepath.Join(dir, "registry")
+	r, err := NewCompact(registryPath, testingDefaultMaxEntries, types.SiaPublicKey{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.entries != nil {
+		t.Fatal("compact registry shouldn't keep entries in memory")
+	}
+
+	// Add some entries.
+	var entries []compactTestEntry
+	for i := 0; i < 128; i++ {
+		rv, v, sk := randomValue(0)
+		v.expiry = types.BlockHeight(i)
+		old, err := r.Update(rv, v.key, v.expiry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(old, modules.SignedRegistryValue{}) {
+			t.Fatal("entry shouldn't have existed before")
+		}
+		entries = append(entries, compactTestEntry{rv: rv, v: v, sk: sk})
+	}
+	if err := checkCompactEntries(r, entries); err != nil {
+		t.Fatal(err)
+	}
+
+	// Update an entry. The old value should be returned.
+	entry := &entries[0]
+	updated := entry.rv
+	updated.Revision++
+	updated = updated.Sign(entry.sk)
+	old, err := r.Update(updated, entry.v.key, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(old, entry.rv) {
+		t.Fatal("wrong old value returned")
+	}
+	entry.rv = updated
+	entry.v.expiry = 1000
+
+	// Updating it again with the same revision should fail and return the
+	// existing value.
+	old, err = r.Update(updated, entry.v.key, 1000)
+	if !errors.Contains(err, modules.ErrSameRevNum) {
+		t.Fatal("expected ErrSameRevNum but got", err)
+	}
+	if !reflect.DeepEqual(old, updated) {
+		t.Fatal("wrong existing value returned")
+	}
+	if err := checkCompactEntries(r, entries); err != nil {
+		t.Fatal(err)
+	}
+
+	// Prune half of the entries. The updated entry should survive.
+	pruned, err := r.Prune(64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pruned != 64 {
+		t.Fatal("expected 64 pruned entries but got", pruned)
+	}
+	entries = append(entries[:1], entries[65:]...)
+	if err := checkCompactEntries(r, entries); err != nil {
+		t.Fatal(err)
+	}
+
+	// Truncate the registry. The entries at the end of the file need to be
+	// moved.
+	if err := r.Truncate(uint64(len(entries)-1), false); !errors.Contains(err, ErrInvalidTruncate) {
+		t.Fatal("expected ErrInvalidTruncate but got", err)
+	}
+	if err := r.Truncate(uint64(len(entries)), false); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkCompactEntries(r, entries); err != nil {
+		t.Fatal(err)
+	}
+
+	// Migrate the registry.
+	newPath := filepath.Join(dir, "registry_new")
+	if err := r.Migrate(newPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkCompactEntries(r, entries); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reopen the registry both with and without a compact index.
+	for _, open := range []func(string, uint64, types.SiaPublicKey) (*Registry, error){New, NewCompact} {
+		r, err = open(newPath, uint64(len(entries)), types.SiaPublicKey{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := checkCompactEntries(r, entries); err != nil {
+			t.Fatal(err)
+		}
+		if err := r.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// TestCompactRegistryFull tests that a full compact registry rejects new
+// entries without leaking them into the index.
+func TestCompactRegistryFull(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	dir := testDir(t.Name())
+	r, err := NewCompact(filepath.Join(dir, "registry"), 64, types.SiaPublicKey{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(c io.Closer) {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}(r)
+
+	for i := 0; i < 64; i++ {
+		rv, v, _ := randomValue(0)
+		if _, err := r.Update(rv, v.key, v.expiry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rv, v, _ := randomValue(0)
+	if _, err := r.Update(rv, v.key, v.expiry); !errors.Contains(err, ErrNoFreeBit) {
+		t.Fatal("expected ErrNoFreeBit but got", err)
+	}
+	if r.Len() != 64 {
+		t.Fatal("wrong number of entries", r.Len())
+	}
+}
+
+// BenchmarkRegistryMemory measures the heap required to keep a registry in
+// memory and the latency of looking up its entries with and without a compact
+// index.
+func BenchmarkRegistryMemory(b *testing.B) {
+	const numEntries = 1 << 12
+
+	// Create a registry on disk.
+	dir := testDir(b.Name())
+	registryPath := filepath.Join(dir, "registry")
+	r, err := New(registryPath, numEntries, types.SiaPublicKey{})
+	if err != nil {
+		b.Fatal(err)
+	}
+	ids := make([]modules.RegistryEntryID, 0, numEntries)
+	for i := 0; i < numEntries; i++ {
+		rv, v, _ := randomValue(0)
+		if _, err := r.Update(rv, v.key, v.expiry); err != nil {
+			b.Fatal(err)
+		}
+		ids = append(ids, v.mapKey())
+	}
+	if err := r.Close(); err != nil {
+		b.Fatal(err)
+	}
+
+	for _, mode := range []struct {
+		name string
+		open func(string, uint64, types.SiaPublicKey) (*Registry, error)
+	}{
+		{"Full", New},
+		{"Compact", NewCompact},
+	} {
+		b.Run(mode.name, func(b *testing.B) {
+			// Measure the heap used by the loaded registry.
+			var before, after runtime.MemStats
+			runtime.GC()
+			runtime.ReadMemStats(&before)
+			r, err := mode.open(registryPath, numEntries, types.SiaPublicKey{})
+			if err != nil {
+				b.Fatal(err)
+			}
+			runtime.GC()
+			runtime.ReadMemStats(&after)
+			defer func(c io.Closer) {
+				if err := c.Close(); err != nil {
+					b.Fatal(err)
+				}
+			}(r)
+			// Measure the latency of Get.
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, _, ok := r.Get(ids[i%len(ids)]); !ok {
+					b.Fatal("entry not found")
+				}
+			}
+			b.ReportMetric(float64(int64(after.HeapAlloc)-int64(before.HeapAlloc))/numEntries, "heapB/entry")
+		})
+	}
+}
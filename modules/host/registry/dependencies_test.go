@@ -0,0 +1,134 @@
+package registry
+
+import (
+	"os"
+	"sync"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/modules"
+)
+
+// errCrash is returned by the files of a dependencyCrash after the simulated
+// crash.
+var errCrash = errors.New("registry crashed")
+
+type (
+	// dependencyCrash is a dependency which simulates the registry being
+	// killed after a number of writes. The write at which the crash happens
+	// and all following writes, truncations and syncs fail without reaching
+	// the disk. Unlike the faulty disk dependency it never scrambles data,
+	// since writing a single entry is atomic for a killed process.
+	dependencyCrash struct {
+		modules.ProductionDependencies
+		crashed    bool
+		writesLeft int
+		mu         sync.Mutex
+	}
+
+	// crashFile is a file of a dependencyCrash. It doesn't embed the
+	// os.File to make sure that all writes, including io.Copy's ReadFrom,
+	// go through the dependency.
+	crashFile struct {
+		deps *dependencyCrash
+		file *os.File
+	}
+)
+
+// newDependencyCrash creates a dependency which crashes at the given write.
+func newDependencyCrash(crashAt int) *dependencyCrash {
+	return &dependencyCrash{writesLeft: crashAt}
+}
+
+// Crashed returns whether the dependency crashed already.
+func (d *dependencyCrash) Crashed() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.crashed
+}
+
+// managedWrite counts a write and returns errCrash if the write shouldn't
+// reach the disk.
+func (d *dependencyCrash) managedWrite() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.crashed {
+		return errCrash
+	}
+	d.writesLeft--
+	if d.writesLeft <= 0 {
+		d.crashed = true
+		return errCrash
+	}
+	return nil
+}
+
+// OpenFile is an os.OpenFile replacement.
+func (d *dependencyCrash) OpenFile(path string, flag int, perm os.FileMode) (modules.File, error) {
+	f, err := os.OpenFile(path, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	return &crashFile{deps: d, file: f}, nil
+}
+
+// Close is a *File.Close replacement.
+func (f *crashFile) Close() error {
+	return f.file.Close()
+}
+
+// Name is a *File.Name replacement.
+func (f *crashFile) Name() string {
+	return f.file.Name()
+}
+
+// Read is a *File.Read replacement.
+func (f *crashFile) Read(p []byte) (int, error) {
+	return f.file.Read(p)
+}
+
+// ReadAt is a *File.ReadAt replacement.
+func (f *crashFile) ReadAt(p []byte, off int64) (int, error) {
+	return f.file.ReadAt(p, off)
+}
+
+// Seek is a *File.Seek replacement.
+func (f *crashFile) Seek(offset int64, whence int) (int64, error) {
+	return f.file.Seek(offset, whence)
+}
+
+// Stat is a *File.Stat replacement.
+func (f *crashFile) Stat() (os.FileInfo, error) {
+	return f.file.Stat()
+}
+
+// Sync is a *File.Sync replacement.
+func (f *crashFile) Sync() error {
+	if err := f.deps.managedWrite(); err != nil {
+		return err
+	}
+	return f.file.Sync()
+}
+
+// Truncate is a *File.Truncate replacement.
+func (f *crashFile) Truncate(size int64) error {
+	if err := f.deps.managedWrite(); err != nil {
+		return err
+	}
+	return f.file.Truncate(size)
+}
+
+// Write is a *File.Write replacement.
+func (f *crashFile) Write(p []byte) (int, error) {
+	if err := f.deps.managedWrite(); err != nil {
+		return 0, err
+	}
+	return f.file.Write(p)
+}
+
+// WriteAt is a *File.WriteAt replacement.
+func (f *crashFile) WriteAt(p []byte, off int64) (int, error) {
+	if err := f.deps.managedWrite(); err != nil {
+		return 0, err
+	}
+	return f.file.WriteAt(p, off)
+}
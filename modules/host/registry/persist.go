@@ -96,9 +96,9 @@ func newSiaPublicKey(cpk compressedPublicKey) (spk types.SiaPublicKey, _ error)
 }
 
 // initRegistry initializes a registry at the specified path using the provided
-// wal.
-func initRegistry(path string, maxEntries uint64) (*os.File, error) {
-	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, modules.DefaultFilePerm)
+// dependencies.
+func initRegistry(path string, maxEntries uint64, deps modules.Dependencies) (modules.File, error) {
+	f, err := deps.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, modules.DefaultFilePerm)
 	if err != nil {
 		return nil, errors.AddContext(err, "failed to create new file for key/value store")
 	}
@@ -140,19 +140,42 @@ func loadRegistryMetadata(r io.Reader, b bitfield) error {
 }
 
 // loadRegistryEntries reads the currently in use registry entries from disk.
-func loadRegistryEntries(r io.Reader, numEntries int64, b bitfield, upgradeV100 bool) (map[modules.RegistryEntryID]*value, error) {
+// It also returns the indices of duplicate entries which need to be freed.
+func loadRegistryEntries(r io.Reader, numEntries int64, b bitfield, upgradeV100 bool) (map[modules.RegistryEntryID]*value, []int64, error) {
+	entries := make(map[modules.RegistryEntryID]*value)
+	var duplicates []int64
+	err := forEachRegistryEntry(r, numEntries, b, upgradeV100, func(v *value) error {
+		id := v.mapKey()
+		if existing, exists := entries[id]; exists {
+			if v.revision <= existing.revision {
+				duplicates = append(duplicates, v.staticIndex)
+				return nil
+			}
+			duplicates = append(duplicates, existing.staticIndex)
+		}
+		entries[id] = v
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return entries, duplicates, nil
+}
+
+// forEachRegistryEntry reads the currently in use registry entries from disk,
+// marks them as used in the bitfield and calls fn for each of them.
+func forEachRegistryEntry(r io.Reader, numEntries int64, b bitfield, upgradeV100 bool, fn func(*value) error) error {
 	// Load the remaining entries.
 	var entry [PersistedEntrySize]byte
-	entries := make(map[modules.RegistryEntryID]*value)
 	for index := int64(1); index < numEntries; index++ {
 		_, err := io.ReadFull(r, entry[:])
 		if err != nil {
-			return nil, errors.AddContext(err, fmt.Sprintf("failed to read entry %v of %v", index, numEntries))
+			return errors.AddContext(err, fmt.Sprintf("failed to read entry %v of %v", index, numEntries))
 		}
 		var pe persistedEntry
 		err = pe.Unmarshal(entry[:])
 		if err != nil {
-			return nil, errors.AddContext(err, fmt.Sprintf("failed to parse entry %v of %v", index, numEntries))
+			return errors.AddContext(err, fmt.Sprintf("failed to parse entry %v of %v", index, numEntries))
 		}
 		if pe.Key == noKey {
 			continue // ignore unused entries
@@ -161,21 +184,60 @@ func loadRegistryEntries(r io.Reader, numEntries int64, b bitfield, upgradeV100
 		if upgradeV100 && pe.Type == modules.RegistryTypeInvalid {
 			pe.Type = modules.RegistryTypeWithoutPubkey
 		} else if pe.Type == modules.RegistryTypeInvalid {
-			return nil, modules.ErrInvalidRegistryEntryType
+			return modules.ErrInvalidRegistryEntryType
 		}
 		// Add the entry to the store.
 		v, err := pe.Value(index)
 		if err != nil {
-			return nil, errors.AddContext(err, fmt.Sprintf("failed to get key-value pair from entry %v of %v", index, numEntries))
+			return errors.AddContext(err, fmt.Sprintf("failed to get key-value pair from entry %v of %v", index, numEntries))
+		}
+		if err := fn(v); err != nil {
+			return err
 		}
-		entries[v.mapKey()] = v
 		// Track it in the bitfield.
 		err = b.Set(uint64(index) - 1)
 		if err != nil {
-			return nil, errors.AddContext(err, fmt.Sprintf("failed to mark entry %v of %v as used in bitfield", index, numEntries))
+			return errors.AddContext(err, fmt.Sprintf("failed to mark entry %v of %v as used in bitfield", index, numEntries))
+		}
+	}
+	return nil
+}
+
+// managedFreeDuplicates frees the slots of duplicate entries found while
+// loading the registry. Duplicates are left behind if the registry is
+// interrupted while moving entries, e.g. during a Truncate. The slots are
+// cleared on disk to prevent the duplicates from being loaded again after the
+// entries they duplicate were updated or pruned.
+func (r *Registry) managedFreeDuplicates(duplicates []int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, index := range duplicates {
+		if err := r.staticSaveEntry(&value{staticIndex: index}, false); err != nil {
+			return errors.AddContext(err, "failed to clear duplicate entry")
+		}
+		if err := r.usage.Unset(uint64(index) - 1); err != nil {
+			return errors.AddContext(err, "failed to free duplicate entry")
 		}
 	}
-	return entries, nil
+	return nil
+}
+
+// staticReadEntry reads the entry at the given index from disk.
+// NOTE: The entry is expected to be locked by the caller.
+func (r *Registry) staticReadEntry(index int64) (*value, error) {
+	b := make([]byte, PersistedEntrySize)
+	_, err := r.staticFile.ReadAt(b, index*PersistedEntrySize)
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to read entry")
+	}
+	var pe persistedEntry
+	if err := pe.Unmarshal(b); err != nil {
+		return nil, errors.AddContext(err, "failed to parse entry")
+	}
+	if pe.Key == noKey {
+		return nil, errors.New("entry is not in use")
+	}
+	return pe.Value(index)
 }
 
 // newPersistedEntry turns a value type into a persistedEntry.
@@ -208,7 +270,7 @@ func newPersistedEntry(value *value) (persistedEntry, error) {
 }
 
 // writeMetadata writes the metadata containing the recent version to disk.
-func writeMetadata(f *os.File) error {
+func writeMetadata(f modules.File) error {
 	// The first entry is reserved for metadata. Right now only the version
 	// number.
 	initData := make([]byte, PersistedEntrySize)
@@ -238,6 +300,7 @@ func (entry persistedEntry) Value(index int64) (*value, error) {
 		return nil, modules.ErrInvalidRegistryEntryType
 	case modules.RegistryTypeWithPubkey:
 	case modules.RegistryTypeWithoutPubkey:
+	case modules.RegistryTypeHostAnnouncement, modules.RegistryTypeNFTMetadata, modules.RegistryTypeMarketplaceOffer:
 	default:
 		return nil, modules.ErrInvalidRegistryEntryType
 	}
@@ -246,7 +309,7 @@ func (entry persistedEntry) Value(index int64) (*value, error) {
 		key:         spk,
 		tweak:       entry.Tweak,
 		expiry:      types.BlockHeight(entry.Expiry),
-		data:        entry.Data[:entry.DataLen],
+		data:        append([]byte(nil), entry.Data[:entry.DataLen]...),
 		revision:    entry.Revision,
 		signature:   entry.Signature,
 		staticIndex: index,
@@ -254,12 +317,25 @@ func (entry persistedEntry) Value(index int64) (*value, error) {
 }
 
 // Marshal marshals a persistedEntry.
-func (entry persistedEntry) Marshal() ([]byte, error) {
+func (entry *persistedEntry) Marshal() ([]byte, error) {
+	b := make([]byte, PersistedEntrySize)
+	if err := entry.MarshalTo(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// MarshalTo marshals a persistedEntry into b, which must be
+// PersistedEntrySize bytes long.
+func (entry *persistedEntry) MarshalTo(b []byte) error {
+	if len(b) != PersistedEntrySize {
+		build.Critical(errEntryWrongSize)
+		return errEntryWrongSize
+	}
 	if entry.DataLen > modules.RegistryDataSize {
 		build.Critical(errTooMuchData)
-		return nil, errTooMuchData
+		return errTooMuchData
 	}
-	b := make([]byte, PersistedEntrySize)
 	b[0] = entry.Key.Algorithm
 	copy(b[1:], entry.Key.Key[:])
 	copy(b[33:], entry.Tweak[:])
@@ -269,7 +345,7 @@ func (entry persistedEntry) Marshal() ([]byte, error) {
 	b[141] = byte(entry.DataLen)
 	copy(b[142:], entry.Data[:])
 	b[PersistedEntrySize-1] = uint8(entry.Type)
-	return b, nil
+	return nil
 }
 
 // Unmarshal unmarshals a persistedEntry.
@@ -305,11 +381,12 @@ func (r *Registry) staticSaveEntry(v *value, used bool) error {
 	if err != nil {
 		return errors.AddContext(err, "Save: failed to get persistedEntry from key-value pair")
 	}
-	b, err := entry.Marshal()
+	var b [PersistedEntrySize]byte
+	err = entry.MarshalTo(b[:])
 	if err != nil {
 		return errors.AddContext(err, "Save: failed to marshal persistedEntry")
 	}
-	_, err = r.staticFile.WriteAt(b, v.staticIndex*PersistedEntrySize)
+	_, err = r.staticFile.WriteAt(b[:], v.staticIndex*PersistedEntrySize)
 	if err != nil {
 		return errors.AddContext(err, "failed to save entry")
 	}
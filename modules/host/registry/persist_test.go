@@ -84,7 +84,7 @@ func TestInitRegistry(t *testing.T) {
 
 	// Init the registry.
 	registryPath := filepath.Join(dir, "registry")
-	f, err := initRegistry(registryPath, testingDefaultMaxEntries)
+	f, err := initRegistry(registryPath, testingDefaultMaxEntries, modules.ProdDependencies)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -119,7 +119,7 @@ func TestInitRegistry(t *testing.T) {
 	// Try to reinit the same registry again. This should fail. We check the
 	// string directly since neither os.IsExist nor errors.Contains(err,
 	// os.ErrExist) work.
-	_, err = initRegistry(registryPath, testingDefaultMaxEntries)
+	_, err = initRegistry(registryPath, testingDefaultMaxEntries, modules.ProdDependencies)
 	if err == nil || !strings.Contains(err.Error(), "file exists") {
 		t.Fatal(err)
 	}
@@ -49,11 +49,21 @@ type (
 	// register data with a given pubkey and secondary key (tweak).
 	Registry struct {
 		entries    map[modules.RegistryEntryID]*value
+		staticDeps modules.Dependencies
 		staticHPK  types.SiaPublicKey
 		staticPath string
-		staticFile *os.File
+		staticFile modules.File
 		usage      bitfield
 		mu         sync.Mutex
+
+		// index replaces entries if the registry uses a compact index. It
+		// only keeps the location and expiry of every entry in memory and
+		// reads the values from disk on demand. Since there are no
+		// in-memory values to lock, the entries are locked using
+		// staticEntryLocks instead.
+		index            map[modules.RegistryEntryID]compactEntry
+		staticCompact    bool
+		staticEntryLocks [compactEntryLocks]sync.Mutex
 	}
 
 	// values represents the value associated with a registered key.
@@ -94,19 +104,20 @@ func (v *value) update(rv modules.SignedRegistryValue, newExpiry types.BlockHeig
 	}
 
 	// Check if the new revision number is valid.
-	oldRV := modules.NewSignedRegistryValue(v.tweak, v.data, v.revision, v.signature, v.entryType)
 	if !init {
-		s := fmt.Sprintf("%v <= %v", oldRV.Revision, rv.Revision)
+		oldRV := modules.NewSignedRegistryValue(v.tweak, v.data, v.revision, v.signature, v.entryType)
 		update, err := oldRV.ShouldUpdateWith(&rv.RegistryValue, hpk)
 		if err != nil {
-			return errors.AddContext(err, s)
+			return errors.AddContext(err, fmt.Sprintf("%v <= %v", oldRV.Revision, rv.Revision))
 		}
 		if !update {
 			return nil
 		}
 	}
 
-	// Update the entry.
+	// Update the entry. The type is part of the signed value and can change
+	// with a new revision.
+	v.entryType = rv.Type
 	v.expiry = newExpiry
 	v.data = rv.Data
 	v.revision = rv.Revision
@@ -128,6 +139,9 @@ func (r *Registry) Close() error {
 
 // Get fetches the data associated with a key and tweak from the registry.
 func (r *Registry) Get(sid modules.RegistryEntryID) (types.SiaPublicKey, modules.SignedRegistryValue, bool) {
+	if r.staticCompact {
+		return r.managedGetCompact(sid)
+	}
 	r.mu.Lock()
 	v, ok := r.entries[sid]
 	r.mu.Unlock()
@@ -143,6 +157,14 @@ func (r *Registry) Get(sid modules.RegistryEntryID) (types.SiaPublicKey, modules
 func (r *Registry) Len() uint64 {
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	return r.len()
+}
+
+// len returns the length of the registry.
+func (r *Registry) len() uint64 {
+	if r.staticCompact {
+		return uint64(len(r.index))
+	}
 	return uint64(len(r.entries))
 }
 
@@ -150,6 +172,9 @@ func (r *Registry) Len() uint64 {
 // shrink the registry below its current size. This will cause random values to
 // be lost.
 func (r *Registry) Truncate(newMaxEntries uint64, force bool) error {
+	if r.staticCompact {
+		return r.managedTruncateCompact(newMaxEntries, force)
+	}
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -226,15 +251,29 @@ func (r *Registry) Truncate(newMaxEntries uint64, force bool) error {
 }
 
 // New creates a new registry or opens an existing one.
-func New(path string, maxEntries uint64, hpk types.SiaPublicKey) (_ *Registry, err error) {
+func New(path string, maxEntries uint64, hpk types.SiaPublicKey) (*Registry, error) {
+	return newRegistry(path, maxEntries, hpk, false, modules.ProdDependencies)
+}
+
+// NewCompact creates a new registry or opens an existing one. Unlike New, the
+// registry only keeps a compact index of its entries in memory and reads the
+// values from disk when they are needed. This trades some latency for a much
+// smaller memory footprint which allows for very large registries.
+func NewCompact(path string, maxEntries uint64, hpk types.SiaPublicKey) (*Registry, error) {
+	return newRegistry(path, maxEntries, hpk, true, modules.ProdDependencies)
+}
+
+// newRegistry creates a new registry or opens an existing one using the
+// provided dependencies.
+func newRegistry(path string, maxEntries uint64, hpk types.SiaPublicKey, compact bool, deps modules.Dependencies) (_ *Registry, err error) {
 	// The path should be an absolute path.
 	if !filepath.IsAbs(path) {
 		return nil, errPathNotAbsolute
 	}
-	f, err := os.OpenFile(path, os.O_RDWR, modules.DefaultFilePerm)
+	f, err := deps.OpenFile(path, os.O_RDWR, modules.DefaultFilePerm)
 	if os.IsNotExist(err) {
 		// try creating a new one
-		f, err = initRegistry(path, maxEntries)
+		f, err = initRegistry(path, maxEntries, deps)
 	}
 	if err != nil {
 		return nil, errors.AddContext(err, "failed to open store")
@@ -272,18 +311,30 @@ func New(path string, maxEntries uint64, hpk types.SiaPublicKey) (_ *Registry, e
 	}
 	// Create the registry.
 	reg := &Registry{
-		staticFile: f,
-		staticHPK:  hpk,
-		staticPath: path,
-		usage:      b,
+		staticCompact: compact,
+		staticDeps:    deps,
+		staticFile:    f,
+		staticHPK:     hpk,
+		staticPath:    path,
+		usage:         b,
 	}
 	// Load the remaining entries.
-	reg.entries, err = loadRegistryEntries(r, fi.Size()/PersistedEntrySize, b, compatV100)
+	var duplicates []int64
+	if compact {
+		reg.index, duplicates, err = reg.loadRegistryIndex(r, fi.Size()/PersistedEntrySize, b, compatV100)
+	} else {
+		reg.entries, duplicates, err = loadRegistryEntries(r, fi.Size()/PersistedEntrySize, b, compatV100)
+	}
 	if err != nil {
 		return nil, errors.AddContext(err, "failed to load registry entries")
 	}
+	err = reg.managedFreeDuplicates(duplicates)
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to free duplicate entries")
+	}
 	// If an upgrade happened, sync the body and upgrade the metadata
-	// afterwards. Then sync again.
+	// afterwards. Then sync again. A compact registry already saved the
+	// upgraded entries while loading them.
 	if compatV100 {
 		for _, entry := range reg.entries {
 			err = reg.staticSaveEntry(entry, true)
@@ -321,6 +372,12 @@ func (r *Registry) Update(rv modules.SignedRegistryValue, pubKey types.SiaPublic
 	if err := rv.Verify(pubKey.ToPublicKey()); err != nil {
 		return modules.SignedRegistryValue{}, err
 	}
+	if r.staticCompact {
+		return r.managedUpdateCompact(rv, pubKey, expiry)
+	}
+
+	// Derive the id before acquiring the lock.
+	id := modules.DeriveRegistryEntryID(pubKey, rv.Tweak)
 
 	// Lock the registry until we have found the existing entry or a new index
 	// on disk to save a new entry. Don't hold the lock during disk I/O.
@@ -328,11 +385,11 @@ func (r *Registry) Update(rv modules.SignedRegistryValue, pubKey types.SiaPublic
 
 	// Check if the entry exists already. If it does and the new revision is
 	// larger than the last one, we update it.
-	entry, exists := r.entries[modules.DeriveRegistryEntryID(pubKey, rv.Tweak)]
+	entry, exists := r.entries[id]
 	var err error
 	if !exists {
 		// If it doesn't exist we create a new entry.
-		entry, err = r.newValue(rv, pubKey, expiry)
+		entry, err = r.newValue(id, rv, pubKey, expiry)
 		if err != nil {
 			r.mu.Unlock()
 			return modules.SignedRegistryValue{}, errors.AddContext(err, "failed to create new value")
@@ -385,9 +442,9 @@ func (r *Registry) managedDeleteFromMemory(v *value) {
 	delete(r.entries, v.mapKey())
 }
 
-// newValue creates a new value and assigns it a free bit from the bitfield. It
-// adds the new value to the registry as well.
-func (r *Registry) newValue(rv modules.SignedRegistryValue, pubKey types.SiaPublicKey, expiry types.BlockHeight) (*value, error) {
+// newValue creates a new value with the given id and assigns it a free bit from
+// the bitfield. It adds the new value to the registry as well.
+func (r *Registry) newValue(id modules.RegistryEntryID, rv modules.SignedRegistryValue, pubKey types.SiaPublicKey, expiry types.BlockHeight) (*value, error) {
 	bit, err := r.usage.SetRandom()
 	if err != nil {
 		return nil, errors.AddContext(err, "failed to obtain free slot")
@@ -397,6 +454,7 @@ func (r *Registry) newValue(rv modules.SignedRegistryValue, pubKey types.SiaPubl
 		return nil, modules.ErrInvalidRegistryEntryType
 	case modules.RegistryTypeWithPubkey:
 	case modules.RegistryTypeWithoutPubkey:
+	case modules.RegistryTypeHostAnnouncement, modules.RegistryTypeNFTMetadata, modules.RegistryTypeMarketplaceOffer:
 	default:
 		return nil, modules.ErrInvalidRegistryEntryType
 	}
@@ -410,13 +468,20 @@ func (r *Registry) newValue(rv modules.SignedRegistryValue, pubKey types.SiaPubl
 		revision:    rv.Revision,
 		signature:   rv.Signature,
 	}
-	r.entries[v.mapKey()] = v
+	if r.staticCompact {
+		r.index[id] = newCompactEntry(v)
+	} else {
+		r.entries[id] = v
+	}
 	return v, nil
 }
 
 // Prune deletes all entries from the registry that expire at a height smaller
 // than or equal to the provided expiry argument.
 func (r *Registry) Prune(expiry types.BlockHeight) (uint64, error) {
+	if r.staticCompact {
+		return r.managedPruneCompact(expiry)
+	}
 	// Get a slice of entries. We only hold the lock during the map access.
 	r.mu.Lock()
 	entries := make([]*value, 0, len(r.entries))
@@ -425,6 +490,19 @@ func (r *Registry) Prune(expiry types.BlockHeight) (uint64, error) {
 	}
 	r.mu.Unlock()
 
+	// Only keep the expired entries. Usually only a small fraction of the
+	// entries expires at once, so this is a lot faster than sorting all of
+	// them.
+	expired := entries[:0]
+	for _, entry := range entries {
+		entry.mu.Lock()
+		if entry.expiry <= expiry {
+			expired = append(expired, entry)
+		}
+		entry.mu.Unlock()
+	}
+	entries = expired
+
 	// Sort the entries without holding the lock.
 	sort.Slice(entries, func(i, j int) bool {
 		return entries[i].staticIndex < entries[j].staticIndex
@@ -470,6 +548,15 @@ func (r *Registry) Migrate(path string) error {
 		return errPathNotAbsolute
 	}
 
+	// A compact registry has no in-memory values to lock. Lock all entries
+	// before acquiring the registry lock instead.
+	if r.staticCompact {
+		for i := range r.staticEntryLocks {
+			r.staticEntryLocks[i].Lock()
+			defer r.staticEntryLocks[i].Unlock()
+		}
+	}
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -480,7 +567,7 @@ func (r *Registry) Migrate(path string) error {
 	}
 
 	// Create the file at the new location only if it doesn't exist yet.
-	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, modules.DefaultFilePerm)
+	f, err := r.staticDeps.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, modules.DefaultFilePerm)
 	if err != nil {
 		return errors.AddContext(err, "Migrate: failed to create file at new location")
 	}
@@ -520,7 +607,7 @@ func (r *Registry) Migrate(path string) error {
 	if err != nil {
 		return errors.AddContext(err, "Migrate: failed to close old file handle")
 	}
-	err = os.Remove(oldPath)
+	err = r.staticDeps.RemoveFile(oldPath)
 	if err != nil {
 		return errors.AddContext(err, "Migrate: failed to delete old file")
 	}
@@ -0,0 +1,240 @@
+package registry
+
+import (
+	"bufio"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// benchmarkRegistryEntries is the number of entries of the registries used
+// by the benchmarks of large registries. The registries have room for 64 more
+// entries since the size of the registry's bitfield must be a multiple of 64
+// and the first entry of the file is its metadata.
+const benchmarkRegistryEntries = 1 << 20
+
+// benchmarkRegistryModes are the ways a registry can be opened.
+var benchmarkRegistryModes = []struct {
+	name string
+	open func(string, uint64, types.SiaPublicKey) (*Registry, error)
+}{
+	{"Full", New},
+	{"Compact", NewCompact},
+}
+
+// newBenchmarkRegistryFile creates a registry file with room for maxEntries
+// entries and fills the first numEntries of them. The entries are written
+// directly to the file since signing a million entries would take minutes.
+// The registry doesn't verify the signatures of the entries it loads.
+func newBenchmarkRegistryFile(b *testing.B, path string, maxEntries, numEntries uint64, expiry func(i uint64) types.BlockHeight) {
+	f, err := initRegistry(path, maxEntries, modules.ProdDependencies)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			b.Fatal(err)
+		}
+	}()
+	if _, err := f.Seek(PersistedEntrySize, io.SeekStart); err != nil {
+		b.Fatal(err)
+	}
+	w := bufio.NewWriterSize(f, 1<<20)
+	for i := uint64(0); i < numEntries; i++ {
+		pe := persistedEntry{
+			Key:      compressedPublicKey{Algorithm: signatureEd25519},
+			Expiry:   compressedBlockHeight(expiry(i)),
+			DataLen:  uint8(fastrand.Intn(modules.RegistryDataSize) + 1),
+			Revision: fastrand.Uint64n(1000),
+			Type:     modules.RegistryTypeWithoutPubkey,
+		}
+		fastrand.Read(pe.Key.Key[:])
+		fastrand.Read(pe.Tweak[:])
+		fastrand.Read(pe.Data[:pe.DataLen])
+		fastrand.Read(pe.Signature[:])
+		data, err := pe.Marshal()
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := w.Write(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		b.Fatal(err)
+	}
+}
+
+// BenchmarkRegistryNew is a benchmark for loading a registry with
+// benchmarkRegistryEntries entries from disk.
+//
+// Results (goos, goarch, CPU: Benchmark Output: date)
+//
+// linux, amd64, Intel(R) Xeon(R) Processor: Full       3 | 3329535276 ns/op | 1434761136 B/op | 11542562 allocs/op: 10/16/2026
+// linux, amd64, Intel(R) Xeon(R) Processor: Compact    3 | 2319868294 ns/op | 1468317872 B/op | 11542562 allocs/op: 10/16/2026
+// linux, amd64, Intel(R) Xeon(R) Processor: Full       3 | 1691258412 ns/op |  519785040 B/op |  3153952 allocs/op: 10/16/2026
+// linux, amd64, Intel(R) Xeon(R) Processor: Compact    3 | 1294081541 ns/op |  553374629 B/op |  3153954 allocs/op: 10/16/2026
+func BenchmarkRegistryNew(b *testing.B) {
+	dir := testDir(b.Name())
+	path := filepath.Join(dir, "registry")
+	newBenchmarkRegistryFile(b, path, benchmarkRegistryEntries+64, benchmarkRegistryEntries, func(uint64) types.BlockHeight { return 1 })
+
+	for _, mode := range benchmarkRegistryModes {
+		b.Run(mode.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				r, err := mode.open(path, benchmarkRegistryEntries+64, types.SiaPublicKey{})
+				if err != nil {
+					b.Fatal(err)
+				}
+				if r.Len() != benchmarkRegistryEntries {
+					b.Fatal("wrong number of entries", r.Len())
+				}
+				b.StopTimer()
+				if err := r.Close(); err != nil {
+					b.Fatal(err)
+				}
+				b.StartTimer()
+			}
+		})
+	}
+}
+
+// BenchmarkRegistryUpdateLarge is a benchmark for inserting new entries into
+// and revising entries of a registry with benchmarkRegistryEntries entries.
+// The signatures are created before the timer starts, so the results include
+// verifying them but not creating them, which dominates the time per update.
+//
+// Results (goos, goarch, CPU: Benchmark Output: date)
+//
+// linux, amd64, Intel(R) Xeon(R) Processor: Full/Insert       9903 | 123739 ns/op | 2616 B/op | 28 allocs/op: 10/16/2026
+// linux, amd64, Intel(R) Xeon(R) Processor: Full/Revise       9277 | 107881 ns/op | 1927 B/op | 23 allocs/op: 10/16/2026
+// linux, amd64, Intel(R) Xeon(R) Processor: Compact/Insert    8820 | 119149 ns/op | 2616 B/op | 28 allocs/op: 10/16/2026
+// linux, amd64, Intel(R) Xeon(R) Processor: Compact/Revise   10000 | 109802 ns/op | 2455 B/op | 26 allocs/op: 10/16/2026
+// linux, amd64, Intel(R) Xeon(R) Processor: Full/Insert      11718 | 142010 ns/op |  936 B/op | 10 allocs/op: 10/16/2026
+// linux, amd64, Intel(R) Xeon(R) Processor: Full/Revise      15336 |  94146 ns/op |  992 B/op | 12 allocs/op: 10/16/2026
+// linux, amd64, Intel(R) Xeon(R) Processor: Compact/Insert   12040 | 134464 ns/op |  936 B/op | 10 allocs/op: 10/16/2026
+// linux, amd64, Intel(R) Xeon(R) Processor: Compact/Revise    9566 | 106203 ns/op | 1359 B/op | 14 allocs/op: 10/16/2026
+func BenchmarkRegistryUpdateLarge(b *testing.B) {
+	// Leave room for the inserted entries.
+	const maxEntries = benchmarkRegistryEntries + benchmarkRegistryEntries/4
+
+	for _, mode := range benchmarkRegistryModes {
+		b.Run(mode.name, func(b *testing.B) {
+			dir := testDir(b.Name())
+			path := filepath.Join(dir, "registry")
+			newBenchmarkRegistryFile(b, path, maxEntries, benchmarkRegistryEntries, func(uint64) types.BlockHeight { return 1 })
+			r, err := mode.open(path, maxEntries, types.SiaPublicKey{})
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer func(c io.Closer) {
+				if err := c.Close(); err != nil {
+					b.Fatal(err)
+				}
+			}(r)
+
+			// The benchmark function is called repeatedly with growing b.N,
+			// the entries it inserts and revises must be new every time.
+			sk, pk := crypto.GenerateKeyPair()
+			spk := types.Ed25519PublicKey(pk)
+			var inserted, revision uint64
+			sign := func(tweak crypto.Hash, revision uint64) modules.SignedRegistryValue {
+				data := fastrand.Bytes(modules.RegistryDataSize)
+				return modules.NewRegistryValue(tweak, data, revision, modules.RegistryTypeWithoutPubkey).Sign(sk)
+			}
+
+			b.Run("Insert", func(b *testing.B) {
+				if inserted+uint64(b.N) > maxEntries-benchmarkRegistryEntries-2 {
+					b.Skip("registry too small for", b.N, "inserts")
+				}
+				b.StopTimer()
+				rvs := make([]modules.SignedRegistryValue, b.N)
+				for i := range rvs {
+					rvs[i] = sign(crypto.HashObject(inserted), 0)
+					inserted++
+				}
+				b.ReportAllocs()
+				b.StartTimer()
+				for _, rv := range rvs {
+					if _, err := r.Update(rv, spk, 2); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+			b.Run("Revise", func(b *testing.B) {
+				b.StopTimer()
+				rvs := make([]modules.SignedRegistryValue, b.N)
+				for i := range rvs {
+					rvs[i] = sign(crypto.HashObject("revise"), revision)
+					revision++
+				}
+				b.ReportAllocs()
+				b.StartTimer()
+				for _, rv := range rvs {
+					if _, err := r.Update(rv, spk, 2); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkRegistryPrune is a benchmark for pruning a registry with
+// benchmarkRegistryEntries entries. Like a host pruning its registry after
+// every block, every call prunes the entries of a single expiry height, which
+// are 1/1024 of the entries.
+//
+// Results (goos, goarch, CPU: Benchmark Output: date)
+//
+// linux, amd64, Intel(R) Xeon(R) Processor: Full      2 | 679779312 ns/op | 9302072 B/op | 9219 allocs/op: 10/16/2026
+// linux, amd64, Intel(R) Xeon(R) Processor: Compact  37 |  31613165 ns/op |  417864 B/op | 1039 allocs/op: 10/16/2026
+// linux, amd64, Intel(R) Xeon(R) Processor: Full     10 | 101932925 ns/op | 8343608 B/op |    3 allocs/op: 10/16/2026
+// linux, amd64, Intel(R) Xeon(R) Processor: Compact  43 |  32245877 ns/op |  155720 B/op |   15 allocs/op: 10/16/2026
+func BenchmarkRegistryPrune(b *testing.B) {
+	const heights = 1024
+
+	for _, mode := range benchmarkRegistryModes {
+		b.Run(mode.name, func(b *testing.B) {
+			dir := testDir(b.Name())
+			path := filepath.Join(dir, "registry")
+			newBenchmarkRegistryFile(b, path, benchmarkRegistryEntries+64, benchmarkRegistryEntries, func(i uint64) types.BlockHeight {
+				return types.BlockHeight(i%heights) + 1
+			})
+			r, err := mode.open(path, benchmarkRegistryEntries+64, types.SiaPublicKey{})
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer func(c io.Closer) {
+				if err := c.Close(); err != nil {
+					b.Fatal(err)
+				}
+			}(r)
+
+			// The benchmark function is called repeatedly with growing b.N,
+			// continue pruning at the last height.
+			var height types.BlockHeight
+			b.Run("Height", func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					height++
+					n, err := r.Prune(height)
+					if err != nil {
+						b.Fatal(errors.AddContext(err, "failed to prune"))
+					}
+					if height <= heights && n != benchmarkRegistryEntries/heights {
+						b.Fatal("wrong number of pruned entries", n)
+					}
+				}
+			})
+		})
+	}
+}
@@ -2,6 +2,7 @@ package registry
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
@@ -427,6 +428,66 @@ func TestUpdate(t *testing.T) {
 	}
 }
 
+// TestUpdateEntryType tests that typed entries are stored and that a new
+// revision can change the type of an entry, both in memory and on disk.
+func TestUpdateEntryType(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	dir := testDir(t.Name())
+	registryPath := filepath.Join(dir, "registry")
+	for _, compact := range []bool{false, true} {
+		newRegistry := New
+		if compact {
+			newRegistry = NewCompact
+		}
+		path := fmt.Sprintf("%v-%v", registryPath, compact)
+		r, err := newRegistry(path, testingDefaultMaxEntries, types.SiaPublicKey{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Store a generic entry and replace it with a marketplace offer.
+		rv, v, sk := randomValue(0)
+		if _, err := r.Update(rv, v.key, v.expiry); err != nil {
+			t.Fatal(err)
+		}
+		offer := modules.RegistryMarketplaceOffer{NFT: types.NftID{1}, Expiry: 100, Price: types.NewCurrency64(10)}
+		rv = modules.NewRegistryValue(rv.Tweak, offer.Bytes(), rv.Revision+1, modules.RegistryTypeMarketplaceOffer).Sign(sk)
+		if _, err := r.Update(rv, v.key, v.expiry); err != nil {
+			t.Fatal(err)
+		}
+		_, stored, ok := r.Get(modules.DeriveRegistryEntryID(v.key, rv.Tweak))
+		if !ok || stored.Type != modules.RegistryTypeMarketplaceOffer || stored.Verify(v.key.ToPublicKey()) != nil {
+			t.Fatal("wrong entry", stored)
+		}
+
+		// The type survives reloading the registry.
+		if err := r.Close(); err != nil {
+			t.Fatal(err)
+		}
+		r, err = newRegistry(path, testingDefaultMaxEntries, types.SiaPublicKey{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, stored, ok = r.Get(modules.DeriveRegistryEntryID(v.key, rv.Tweak))
+		if !ok || stored.Type != modules.RegistryTypeMarketplaceOffer || stored.Verify(v.key.ToPublicKey()) != nil {
+			t.Fatal("wrong entry after reload", stored)
+		}
+
+		// Malformed typed entries are rejected.
+		rv = modules.NewRegistryValue(rv.Tweak, []byte("no offer"), rv.Revision+1, modules.RegistryTypeMarketplaceOffer).Sign(sk)
+		if _, err := r.Update(rv, v.key, v.expiry); !errors.Contains(err, modules.ErrRegistryEntryDataMalformed) {
+			t.Fatal("expected ErrRegistryEntryDataMalformed but got", err)
+		}
+		if err := r.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
 // TestRegistryLimit checks if the bitfield of the limit enforces its
 // preallocated size.
 func TestRegistryLimit(t *testing.T) {
@@ -1432,3 +1493,199 @@ func TestFailedLoadLargeRegistry(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+// TestRegistryCrash simulates the registry being killed at random points while
+// updating, pruning and truncating it and makes sure that it always recovers
+// to a consistent state.
+func TestRegistryCrash(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	for i := 0; i < 100; i++ {
+		testRegistryCrash(t, fmt.Sprintf("%v/Full/%v", t.Name(), i), false)
+		testRegistryCrash(t, fmt.Sprintf("%v/Compact/%v", t.Name(), i), true)
+	}
+}
+
+// testRegistryCrash runs random operations on a registry until it crashes.
+// Then it reloads the registry and checks that every entry is either in the
+// state it had before the operation the registry crashed at or in the state it
+// would have after it.
+func testRegistryCrash(t *testing.T, name string, compact bool) {
+	const maxExpiry = 10
+
+	type crashTestState struct {
+		rv     modules.SignedRegistryValue
+		exists bool
+	}
+	type crashTestEntry struct {
+		spk    types.SiaPublicKey
+		sk     crypto.SecretKey
+		expiry types.BlockHeight
+
+		// state is the state of the entry before the crash. crashState is
+		// the state of the entry if the operation the registry crashed at
+		// was applied to it.
+		state      crashTestState
+		crashState *crashTestState
+	}
+
+	// Create the registry without crashing, then reopen it with a dependency
+	// which crashes at a random write.
+	path := filepath.Join(testDir(name), "registry")
+	maxEntries := uint64(128)
+	r, err := newRegistry(path, maxEntries, types.SiaPublicKey{}, compact, modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	deps := newDependencyCrash(fastrand.Intn(200) + 1)
+	r, err = newRegistry(path, maxEntries, types.SiaPublicKey{}, compact, deps)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Run random operations until the registry crashes.
+	entries := make(map[modules.RegistryEntryID]*crashTestEntry)
+	var live []modules.RegistryEntryID
+	checkErr := func(err error) bool {
+		if err != nil && !deps.Crashed() {
+			t.Fatal(err)
+		}
+		return err != nil
+	}
+	for !deps.Crashed() {
+		switch op := fastrand.Intn(10); {
+		case op < 7:
+			// Insert a new entry or revise an existing one.
+			var id modules.RegistryEntryID
+			var entry *crashTestEntry
+			var rv modules.SignedRegistryValue
+			data := fastrand.Bytes(fastrand.Intn(modules.RegistryDataSize) + 1)
+			if len(live) > 0 && (fastrand.Intn(2) == 0 || r.Len() == r.Cap()) {
+				id = live[fastrand.Intn(len(live))]
+				entry = entries[id]
+				rv = modules.NewRegistryValue(entry.state.rv.Tweak, data, entry.state.rv.Revision+1, modules.RegistryTypeWithoutPubkey).Sign(entry.sk)
+			} else {
+				sk, pk := crypto.GenerateKeyPair()
+				entry = &crashTestEntry{spk: types.Ed25519PublicKey(pk), sk: sk}
+				var tweak crypto.Hash
+				fastrand.Read(tweak[:])
+				rv = modules.NewRegistryValue(tweak, data, 0, modules.RegistryTypeWithoutPubkey).Sign(sk)
+				id = modules.DeriveRegistryEntryID(entry.spk, tweak)
+			}
+			expiry := types.BlockHeight(fastrand.Intn(maxExpiry) + 1)
+			_, err := r.Update(rv, entry.spk, expiry)
+			if checkErr(err) {
+				entries[id] = entry
+				entry.crashState = &crashTestState{rv: rv, exists: true}
+				break
+			}
+			if !entry.state.exists {
+				entries[id] = entry
+				live = append(live, id)
+			}
+			entry.state = crashTestState{rv: rv, exists: true}
+			entry.expiry = expiry
+		case op < 8:
+			// Prune the entries up to a random height.
+			height := types.BlockHeight(fastrand.Intn(maxExpiry) + 1)
+			_, err := r.Prune(height)
+			crashed := checkErr(err)
+			remaining := live[:0]
+			for _, id := range live {
+				entry := entries[id]
+				if entry.expiry > height {
+					remaining = append(remaining, id)
+				} else if crashed {
+					entry.crashState = &crashTestState{}
+				} else {
+					entry.state = crashTestState{}
+				}
+			}
+			if !crashed {
+				live = remaining
+			}
+		default:
+			// Truncate the registry to a random size which fits all entries.
+			newMaxEntries := uint64(64 * (fastrand.Intn(3) + 1))
+			if newMaxEntries < uint64(len(live)) {
+				break
+			}
+			if !checkErr(r.Truncate(newMaxEntries, false)) {
+				maxEntries = newMaxEntries
+			}
+		}
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reload the registry. Like the host, use the size of the file on disk if
+	// it is larger than the size of the last successful truncation.
+	reload := func() *Registry {
+		fi, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if onDisk := uint64(fi.Size()/PersistedEntrySize) - 1; onDisk > maxEntries {
+			maxEntries = onDisk
+		}
+		r, err := newRegistry(path, maxEntries, types.SiaPublicKey{}, compact, modules.ProdDependencies)
+		if err != nil {
+			t.Fatal("failed to recover registry", err)
+		}
+		return r
+	}
+	r = reload()
+
+	// Check the entries.
+	var found uint64
+	for id, entry := range entries {
+		spk, rv, ok := r.Get(id)
+		matches := func(s crashTestState) bool {
+			if !s.exists {
+				return !ok
+			}
+			return ok && spk.Equals(entry.spk) && reflect.DeepEqual(rv, s.rv)
+		}
+		if !matches(entry.state) && (entry.crashState == nil || !matches(*entry.crashState)) {
+			t.Fatalf("entry in unexpected state: exists %v, revision %v", ok, rv.Revision)
+		}
+		if ok {
+			found++
+		}
+	}
+	if r.Len() != found {
+		t.Fatalf("expected %v entries but got %v", found, r.Len())
+	}
+	var used uint64
+	for i := uint64(0); i < r.usage.Len(); i++ {
+		if r.usage.IsSet(i) {
+			used++
+		}
+	}
+	if used != found {
+		t.Fatalf("expected %v used slots but got %v", found, used)
+	}
+
+	// Prune all entries. They shouldn't come back after reloading the
+	// registry.
+	if _, err := r.Prune(maxExpiry); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r = reload()
+	if r.Len() != 0 {
+		t.Fatal("pruned entries were loaded again", r.Len())
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,100 @@
+package host
+
+import (
+	"sync"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/modules"
+)
+
+// registryPruner prunes expired entries from the host's registry in the
+// background. Pruning is triggered by changes to the block height.
+type registryPruner struct {
+	// staticTrigger is used to signal the pruning thread that the block height
+	// changed. It has a buffer of 1 to coalesce notifications which arrive
+	// while the registry is being pruned.
+	staticTrigger chan struct{}
+
+	metrics modules.HostRegistryMetrics
+	mu      sync.Mutex
+}
+
+// newRegistryPruner creates a new registryPruner.
+func newRegistryPruner() *registryPruner {
+	return &registryPruner{
+		staticTrigger: make(chan struct{}, 1),
+	}
+}
+
+// callNotify notifies the pruning thread that the registry might contain newly
+// expired entries. It never blocks.
+func (rp *registryPruner) callNotify() {
+	select {
+	case rp.staticTrigger <- struct{}{}:
+	default:
+	}
+}
+
+// managedPruneRegistry prunes all entries from the registry which expired
+// more than RegistryRetention blocks ago. An entry expires once the block
+// height exceeds its expiry.
+func (h *Host) managedPruneRegistry() {
+	h.mu.RLock()
+	height := h.blockHeight
+	retention := h.settings.RegistryRetention
+	h.mu.RUnlock()
+
+	// No entry can have been expired for long enough yet.
+	if height <= retention {
+		return
+	}
+	pruned, err := h.staticRegistry.Prune(height - retention - 1)
+
+	rp := h.staticRegistryPruner
+	rp.mu.Lock()
+	rp.metrics.LastPruneHeight = height
+	rp.metrics.LastPrunedEntries = pruned
+	rp.metrics.PrunedEntries += pruned
+	if err != nil {
+		rp.metrics.PruneErrors++
+	}
+	rp.mu.Unlock()
+
+	if err != nil {
+		h.log.Println(errors.AddContext(err, "failed to prune registry"))
+	}
+}
+
+// threadedPruneRegistry prunes the registry whenever it is notified about a
+// change to the block height or the retention.
+//
+// Note: threadgroup counter must be inside for loop. If not, calling 'Flush'
+// on the threadgroup would deadlock.
+func (h *Host) threadedPruneRegistry() {
+	for {
+		select {
+		case <-h.tg.StopChan():
+			return
+		case <-h.staticRegistryPruner.staticTrigger:
+		}
+		func() {
+			if err := h.tg.Add(); err != nil {
+				return
+			}
+			defer h.tg.Done()
+			h.managedPruneRegistry()
+		}()
+	}
+}
+
+// RegistryMetrics returns information about the host's registry and how many
+// entries were pruned automatically since startup.
+func (h *Host) RegistryMetrics() modules.HostRegistryMetrics {
+	rp := h.staticRegistryPruner
+	rp.mu.Lock()
+	metrics := rp.metrics
+	rp.mu.Unlock()
+	metrics.Entries = h.staticRegistry.Len()
+	metrics.Capacity = h.staticRegistry.Cap()
+	return metrics
+}
@@ -0,0 +1,111 @@
+package host
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestRegistryPruner tests that the host automatically prunes expired registry
+// entries once their retention is over and reports it in its metrics.
+func TestRegistryPruner(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	h := ht.host
+
+	// Enable the registry.
+	is := h.managedInternalSettings()
+	if is.RegistryRetention != defaultRegistryRetention {
+		t.Fatal("wrong default retention", is.RegistryRetention)
+	}
+	is.RegistrySize = 64 * modules.RegistryEntrySize
+	err = h.SetInternalSettings(is)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Add an entry which expires at the current height and one which doesn't
+	// expire for a long time.
+	h.mu.RLock()
+	height := h.blockHeight
+	h.mu.RUnlock()
+	for _, expiry := range []types.BlockHeight{height, height + 1000} {
+		rv, spk, _ := randomRegistryValue()
+		_, err = h.RegistryUpdate(rv, spk, expiry)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// checkMetrics checks the number of entries and the number of pruned
+	// entries.
+	checkMetrics := func(entries, pruned uint64) error {
+		return build.Retry(100, 100*time.Millisecond, func() error {
+			rm := h.RegistryMetrics()
+			if rm.Entries != entries || rm.PrunedEntries != pruned {
+				return fmt.Errorf("expected %v entries and %v pruned but got %v and %v", entries, pruned, rm.Entries, rm.PrunedEntries)
+			}
+			if rm.Capacity != 64 || rm.PruneErrors != 0 {
+				return fmt.Errorf("unexpected metrics %+v", rm)
+			}
+			return nil
+		})
+	}
+
+	// Mine blocks until the entry is expired but still retained. Nothing
+	// should be pruned.
+	for i := types.BlockHeight(0); i < defaultRegistryRetention; i++ {
+		if _, err := ht.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := checkMetrics(2, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// Mine one more block. The expired entry should be pruned.
+	if _, err := ht.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkMetrics(1, 1); err != nil {
+		t.Fatal(err)
+	}
+	rm := h.RegistryMetrics()
+	if rm.LastPruneHeight != height+defaultRegistryRetention+1 || rm.LastPrunedEntries != 1 {
+		t.Fatalf("unexpected metrics %+v", rm)
+	}
+
+	// Lowering the retention prunes right away.
+	rv, spk, _ := randomRegistryValue()
+	_, err = h.RegistryUpdate(rv, spk, rm.LastPruneHeight-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := checkMetrics(2, 1); err != nil {
+		t.Fatal(err)
+	}
+	is = h.managedInternalSettings()
+	is.RegistryRetention = 0
+	err = h.SetInternalSettings(is)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := checkMetrics(1, 2); err != nil {
+		t.Fatal(err)
+	}
+}
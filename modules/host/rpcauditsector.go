@@ -0,0 +1,87 @@
+package host
+
+import (
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/siamux"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+// managedRPCAuditSector handles the RPC which proves that the host stores a
+// sector. It lets third parties, like auditors of NFT storage pool claims,
+// spot-check the host without holding a contract. The auditor pays for
+// reading the sector and for the bandwidth of the RPC, the unused part of the
+// payment is refunded.
+func (h *Host) managedRPCAuditSector(stream siamux.Stream) error {
+	// read the price table
+	pt, err := h.staticReadPriceTableID(stream)
+	if err != nil {
+		return errors.AddContext(err, "failed to read price table")
+	}
+
+	// Process payment.
+	pd, err := h.ProcessPayment(stream, pt.HostBlockHeight)
+	if err != nil {
+		return errors.AddContext(err, "failed to process payment")
+	}
+
+	// Add limit to the stream. The readCost is the UploadBandwidthCost since
+	// reading from the stream means uploading from the host's perspective. That
+	// makes the writeCost the DownloadBandwidthCost.
+	budget := modules.NewBudget(pd.Amount())
+	bandwidthLimit := modules.NewBudgetLimit(budget, pt.UploadBandwidthCost, pt.DownloadBandwidthCost)
+	err = stream.SetLimit(bandwidthLimit)
+	if err != nil {
+		return errors.AddContext(err, "failed to set budget limit on stream")
+	}
+
+	// Refund the remaining budget at the end of the RPC.
+	refundAccount := pd.AccountID()
+	err = h.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		go func() {
+			defer h.tg.Done()
+			depositErr := h.staticAccountManager.callRefund(refundAccount, budget.Remaining())
+			if depositErr != nil {
+				h.log.Print("ERROR: failed to refund auditor", depositErr)
+			}
+		}()
+	}()
+
+	// Read the request.
+	var req modules.RPCAuditSectorRequest
+	err = modules.RPCRead(stream, &req)
+	if err != nil {
+		return errors.AddContext(err, "failed to read audit sector request")
+	}
+	if req.SegmentIndex >= modules.SectorSize/crypto.SegmentSize {
+		return modules.ErrAuditSegmentOutOfBounds
+	}
+
+	// Pay for the audit. The cost is refunded if the host doesn't store the
+	// sector.
+	cost := modules.RPCAuditSectorCost(pt)
+	if !budget.Withdraw(cost) {
+		return modules.ErrInsufficientPaymentForRPC
+	}
+	sector, err := h.ReadSector(req.Root)
+	if err != nil {
+		budget.Deposit(cost)
+		return errors.AddContext(err, "failed to read audited sector")
+	}
+
+	// Prove the segment and send the response.
+	start := int(req.SegmentIndex)
+	resp := modules.RPCAuditSectorResponse{
+		Segment: sector[start*crypto.SegmentSize : (start+1)*crypto.SegmentSize],
+		Proof:   crypto.MerkleRangeProof(sector, start, start+1),
+	}
+	err = modules.RPCWrite(stream, resp)
+	if err != nil {
+		return errors.AddContext(err, "failed to send audit sector response")
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package host
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// managedAuditSector audits a sector of the host using RPCAuditSector and
+// pays for it with the pair's EA.
+func (p *renterHostPair) managedAuditSector(req modules.RPCAuditSectorRequest, budget types.Currency) (_ modules.RPCAuditSectorResponse, err error) {
+	stream := p.managedNewStream()
+	defer func() {
+		err = errors.Compose(err, stream.Close())
+	}()
+
+	// Fetch the price table.
+	pt, err := p.managedFetchPriceTable()
+	if err != nil {
+		return modules.RPCAuditSectorResponse{}, err
+	}
+
+	// Initiate the RPC and pay for it.
+	err = modules.RPCWriteAll(stream, modules.RPCAuditSector, pt.UID)
+	if err != nil {
+		return modules.RPCAuditSectorResponse{}, err
+	}
+	err = p.managedPayByEphemeralAccount(stream, budget)
+	if err != nil {
+		return modules.RPCAuditSectorResponse{}, err
+	}
+
+	// Send the request and read the response.
+	err = modules.RPCWrite(stream, req)
+	if err != nil {
+		return modules.RPCAuditSectorResponse{}, err
+	}
+	var resp modules.RPCAuditSectorResponse
+	err = modules.RPCRead(stream, &resp)
+	return resp, err
+}
+
+// TestRPCAuditSector tests auditing a sector of the host.
+func TestRPCAuditSector(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rhp, err := newRenterHostPair(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := rhp.Close()
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+	host := rhp.staticHT.host
+	am := host.staticAccountManager
+
+	// Add a sector to the host which doesn't belong to a contract of the
+	// auditor.
+	sector := fastrand.Bytes(int(modules.SectorSize))
+	root := crypto.MerkleRoot(sector)
+	err = host.AddSector(root, sector)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Fund the account.
+	pt := rhp.managedPriceTable()
+	cost := modules.RPCAuditSectorCost(pt)
+	budget := cost.Add(pt.DownloadBandwidthCost.Add(pt.UploadBandwidthCost).Mul64(1 << 14))
+	funding := budget.Mul64(3)
+	_, err = rhp.managedFundEphemeralAccount(funding.Add(pt.FundAccountCost), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Audit a random segment.
+	req := modules.RPCAuditSectorRequest{
+		Root:         root,
+		SegmentIndex: fastrand.Uint64n(modules.SectorSize / crypto.SegmentSize),
+	}
+	resp, err := rhp.managedAuditSector(req, budget)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := modules.VerifyAuditSectorResponse(req, resp); err != nil {
+		t.Fatal(err)
+	}
+	// A proof for another segment doesn't verify.
+	req.SegmentIndex = (req.SegmentIndex + 1) % (modules.SectorSize / crypto.SegmentSize)
+	if err := modules.VerifyAuditSectorResponse(req, resp); err == nil {
+		t.Fatal("proof of the wrong segment shouldn't verify")
+	}
+
+	// The auditor paid for the audit and got the rest of the budget
+	// refunded. The refund is asynchronous.
+	balance := func() types.Currency {
+		am.mu.Lock()
+		defer am.mu.Unlock()
+		return am.accounts[rhp.staticAccountID].balance
+	}
+	var spent types.Currency
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		spent = funding.Sub(balance())
+		if spent.Cmp(cost) <= 0 || spent.Cmp(budget) >= 0 {
+			return fmt.Errorf("auditor should have paid more than %v but less than %v, paid %v", cost, budget, spent)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Audits of sectors the host doesn't store fail and are only charged for
+	// the bandwidth.
+	req.Root = crypto.Hash{1}
+	_, err = rhp.managedAuditSector(req, budget)
+	if err == nil || !strings.Contains(err.Error(), "failed to read audited sector") {
+		t.Fatal("expected audit of unknown sector to fail but got", err)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		if spentMissing := funding.Sub(spent).Sub(balance()); spentMissing.Cmp(cost) >= 0 {
+			return fmt.Errorf("auditor shouldn't pay for the audit of a missing sector, paid %v", spentMissing)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Segments beyond the end of the sector are rejected.
+	req.Root = root
+	req.SegmentIndex = modules.SectorSize / crypto.SegmentSize
+	_, err = rhp.managedAuditSector(req, budget)
+	if err == nil || !strings.Contains(err.Error(), modules.ErrAuditSegmentOutOfBounds.Error()) {
+		t.Fatal("expected ErrAuditSegmentOutOfBounds but got", err)
+	}
+}
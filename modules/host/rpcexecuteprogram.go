@@ -81,9 +81,20 @@ func (h *Host) managedRPCExecuteProgram(stream siamux.Stream) error {
 	fcid, instructions, dataLength := epr.FileContractID, epr.Program, epr.ProgramDataLength
 	program := modules.Program(instructions)
 
+	// Programs that access the storage obligation need to be scheduled.
+	// Read-only programs may run concurrently, but write programs run on
+	// their own.
+	readonly := program.ReadOnly()
+	if program.RequiresSnapshot() {
+		err = h.staticProgramScheduler.managedSchedule(fcid, !readonly, obligationLockTimeout, h.tg.StopChan())
+		if err != nil {
+			return errors.AddContext(err, fmt.Sprintf("failed to schedule program for contract %v", fcid))
+		}
+		defer h.staticProgramScheduler.managedDone(fcid, !readonly)
+	}
+
 	// If the program isn't readonly we need to acquire a lock on the storage
 	// obligation.
-	readonly := program.ReadOnly()
 	if !readonly {
 		h.managedLockStorageObligation(fcid)
 		defer h.managedUnlockStorageObligation(fcid)
@@ -98,6 +109,20 @@ func (h *Host) managedRPCExecuteProgram(stream siamux.Stream) error {
 		}
 	}
 
+	// Programs for a contract are subject to the contract's bandwidth cap.
+	// The bandwidth of the program is recorded once the RPC is done.
+	if fcid != (types.FileContractID{}) {
+		metered, err := h.managedCheckContractBandwidth(fcid)
+		if err != nil {
+			return err
+		}
+		if metered {
+			defer func() {
+				h.staticContractBandwidth.managedRecord(fcid, bandwidthLimit.Downloaded(), bandwidthLimit.Uploaded(), time.Now())
+			}()
+		}
+	}
+
 	// Get the remaining unallocated collateral.
 	collateralBudget := sos.UnallocatedCollateral()
 
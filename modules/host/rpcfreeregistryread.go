@@ -0,0 +1,88 @@
+package host
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/siamux"
+	"go.sia.tech/siad/modules"
+)
+
+// freeRegistryReadWindow is the window over which free registry reads are
+// counted.
+const freeRegistryReadWindow = time.Minute
+
+// freeRegistryReadLimiter limits the number of free registry reads per client
+// and in total. Reads are counted in fixed windows, the counters are reset
+// when a new window starts, which bounds the memory used by the limiter.
+type freeRegistryReadLimiter struct {
+	clients     map[string]uint64
+	total       uint64
+	windowStart time.Time
+	mu          sync.Mutex
+}
+
+// newFreeRegistryReadLimiter creates a new limiter.
+func newFreeRegistryReadLimiter() *freeRegistryReadLimiter {
+	return &freeRegistryReadLimiter{
+		clients: make(map[string]uint64),
+	}
+}
+
+// managedAllow returns true if the client may perform another free read at
+// time now given a limit of reads per client and window. The read is counted
+// if it is allowed.
+func (l *freeRegistryReadLimiter) managedAllow(client string, limit uint64, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Sub(l.windowStart) >= freeRegistryReadWindow {
+		l.clients = make(map[string]uint64)
+		l.total = 0
+		l.windowStart = now
+	}
+	if l.clients[client] >= limit || l.total >= limit*freeRegistryReadsGlobalFactor {
+		return false
+	}
+	l.clients[client]++
+	l.total++
+	return true
+}
+
+// managedRPCFreeRegistryRead handles the RPC which reads a registry entry
+// without payment. Free reads are rate limited per IP address and in total,
+// updates always require payment.
+func (h *Host) managedRPCFreeRegistryRead(stream siamux.Stream) error {
+	h.mu.RLock()
+	limit := h.freeRegistryReads()
+	h.mu.RUnlock()
+	if limit == 0 {
+		return modules.ErrFreeRegistryReadsDisabled
+	}
+
+	// Count the read against the IP address of the client.
+	client := stream.RemoteAddr().String()
+	if host, _, err := net.SplitHostPort(client); err == nil {
+		client = host
+	}
+	if !h.staticFreeRegistryReads.managedAllow(client, limit, time.Now()) {
+		return modules.ErrFreeRegistryReadLimit
+	}
+
+	// Read the request.
+	var req modules.RPCFreeRegistryReadRequest
+	err := modules.RPCRead(stream, &req)
+	if err != nil {
+		return errors.AddContext(err, "failed to read free registry read request")
+	}
+
+	// Look up the entry and send the response.
+	var resp modules.RPCFreeRegistryReadResponse
+	_, resp.Entry, resp.Found = h.RegistryGet(modules.DeriveRegistryEntryID(req.PubKey, req.Tweak))
+	err = modules.RPCWrite(stream, resp)
+	if err != nil {
+		return errors.AddContext(err, "failed to send free registry read response")
+	}
+	return nil
+}
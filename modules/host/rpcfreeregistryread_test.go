@@ -0,0 +1,116 @@
+package host
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestFreeRegistryReadLimiter is a unit test for freeRegistryReadLimiter.
+func TestFreeRegistryReadLimiter(t *testing.T) {
+	l := newFreeRegistryReadLimiter()
+	now := time.Now()
+
+	// Every client gets limit reads per window.
+	for i := 0; i < 2; i++ {
+		if !l.managedAllow("a", 2, now) || !l.managedAllow("b", 2, now) {
+			t.Fatal("read within the limit was denied")
+		}
+	}
+	if l.managedAllow("a", 2, now) {
+		t.Fatal("read beyond the limit was allowed")
+	}
+
+	// The limit resets with the next window.
+	now = now.Add(freeRegistryReadWindow)
+	if !l.managedAllow("a", 2, now) {
+		t.Fatal("read in a new window was denied")
+	}
+
+	// The total reads are limited too.
+	now = now.Add(freeRegistryReadWindow)
+	for i := uint64(0); i < freeRegistryReadsGlobalFactor; i++ {
+		if !l.managedAllow(string(fastrand.Bytes(8)), 1, now) {
+			t.Fatal("read within the global limit was denied", i)
+		}
+	}
+	if l.managedAllow("c", 1, now) {
+		t.Fatal("read beyond the global limit was allowed")
+	}
+}
+
+// TestRPCFreeRegistryRead tests reading registry entries from the host without
+// payment.
+func TestRPCFreeRegistryRead(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rhp, err := newRenterHostPair(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := rhp.Close()
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+	host := rhp.staticHT.host
+
+	read := func(spk types.SiaPublicKey, tweak crypto.Hash) (modules.SignedRegistryValue, bool, error) {
+		stream := rhp.managedNewStream()
+		defer func() {
+			if err := stream.Close(); err != nil {
+				t.Fatal(err)
+			}
+		}()
+		return modules.RPCFreeRegistryReadEntry(stream, spk, tweak)
+	}
+
+	// Free reads are disabled by default.
+	rv, spk, _ := randomRegistryValue()
+	_, _, err = read(spk, rv.Tweak)
+	if err == nil || !strings.Contains(err.Error(), modules.ErrFreeRegistryReadsDisabled.Error()) {
+		t.Fatal("expected free reads to be disabled but got", err)
+	}
+
+	// Enable them and add some space to the host's registry.
+	is := host.InternalSettings()
+	is.FreeRegistryReads = 2
+	is.RegistrySize += modules.RegistryEntrySize * 100
+	if err := host.SetInternalSettings(is); err != nil {
+		t.Fatal(err)
+	}
+	if host.ExternalSettings().FreeRegistryReads != 2 {
+		t.Fatal("free reads aren't advertised")
+	}
+
+	// Read an unknown entry and a known one.
+	_, found, err := read(spk, rv.Tweak)
+	if err != nil || found {
+		t.Fatal("unexpected result", found, err)
+	}
+	if _, err := host.RegistryUpdate(rv, spk, host.BlockHeight()+100); err != nil {
+		t.Fatal(err)
+	}
+	entry, found, err := read(spk, rv.Tweak)
+	if err != nil || !found {
+		t.Fatal("entry wasn't found", err)
+	}
+	if entry.Revision != rv.Revision || string(entry.Data) != string(rv.Data) {
+		t.Fatal("wrong entry", entry, rv)
+	}
+
+	// The limit is enforced.
+	_, _, err = read(spk, rv.Tweak)
+	if err == nil || !strings.Contains(err.Error(), modules.ErrFreeRegistryReadLimit.Error()) {
+		t.Fatal("expected the limit to be enforced but got", err)
+	}
+}
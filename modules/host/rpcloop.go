@@ -16,6 +16,15 @@ import (
 	"go.sia.tech/siad/types"
 )
 
+// loopDataRPCs are the RPCs of the RPC loop which transfer the data of the
+// locked contract.
+var loopDataRPCs = map[types.Specifier]struct{}{
+	modules.RPCLoopRead:        {},
+	modules.RPCLoopSectorRoots: {},
+	modules.RPCLoopWrite:       {},
+	modules.RPCLoopWriteStream: {},
+}
+
 // An rpcSession contains the state of an RPC session with a renter.
 type rpcSession struct {
 	conn      net.Conn
@@ -96,9 +105,10 @@ func (h *Host) managedRPCLoop(conn net.Conn) error {
 		build.Critical("could not create cipher")
 		return err
 	}
-	// create the session object
+	// create the session object, metering the bandwidth of the RPCs
+	mc := &meteredConn{Conn: conn}
 	s := &rpcSession{
-		conn: conn,
+		conn: mc,
 		aead: aead,
 	}
 	fastrand.Read(s.challenge[:])
@@ -127,6 +137,7 @@ func (h *Host) managedRPCLoop(conn net.Conn) error {
 		modules.RPCLoopFormContract:       h.managedRPCLoopFormContract,
 		modules.RPCLoopRenewClearContract: h.managedRPCLoopRenewAndClearContract,
 		modules.RPCLoopWrite:              h.managedRPCLoopWrite,
+		modules.RPCLoopWriteStream:        h.managedRPCLoopWriteStream,
 		modules.RPCLoopRead:               h.managedRPCLoopRead,
 		modules.RPCLoopSectorRoots:        h.managedRPCLoopSectorRoots,
 	}
@@ -140,9 +151,25 @@ func (h *Host) managedRPCLoop(conn net.Conn) error {
 		} else if id == modules.RPCLoopExit {
 			return nil
 		}
-		if rpcFn, ok := rpcs[id]; !ok {
+		rpcFn, ok := rpcs[id]
+		if !ok {
 			return errors.New("invalid or unknown RPC ID: " + id.String())
-		} else if err := rpcFn(s); err != nil {
+		}
+		// RPCs which transfer data are subject to the bandwidth cap of the
+		// locked contract.
+		_, transfersData := loopDataRPCs[id]
+		if len(s.so.OriginTransactionSet) != 0 && transfersData {
+			if _, err := h.managedCheckContractBandwidth(s.so.id()); err != nil {
+				s.writeError(err)
+				return err
+			}
+		}
+		read, written := mc.read, mc.written
+		err = rpcFn(s)
+		if len(s.so.OriginTransactionSet) != 0 {
+			h.staticContractBandwidth.managedRecord(s.so.id(), mc.read-read, mc.written-written, time.Now())
+		}
+		if err != nil {
 			return extendErr("incoming RPC"+id.String()+" failed: ", err)
 		}
 	}
@@ -0,0 +1,25 @@
+package host
+
+import (
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/siamux"
+	"go.sia.tech/siad/modules"
+)
+
+// managedRPCMDMCostModel handles the RPC which returns the cost model of the
+// MDM for a price table. The RPC is free since the cost model only contains
+// values derived from a price table the renter already paid for.
+func (h *Host) managedRPCMDMCostModel(stream siamux.Stream) error {
+	// read the price table
+	pt, err := h.staticReadPriceTableID(stream)
+	if err != nil {
+		return errors.AddContext(err, "failed to read price table")
+	}
+
+	// Send response.
+	err = modules.RPCWrite(stream, modules.NewMDMCostModel(pt))
+	if err != nil {
+		return errors.AddContext(err, "failed to send MDMCostModel")
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package host
+
+import (
+	"testing"
+
+	"go.sia.tech/siad/modules"
+)
+
+// TestMDMCostModel tests fetching the MDM cost model of a price table from the
+// host using RPCMDMCostModel.
+func TestMDMCostModel(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// create a blank host tester
+	rhp, err := newRenterHostPair(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := rhp.Close()
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+
+	pt, err := rhp.managedFetchPriceTable()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// fetch the cost model.
+	stream := rhp.managedNewStream()
+	defer func() {
+		if err := stream.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	err = modules.RPCWriteAll(stream, modules.RPCMDMCostModel, pt.UID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m modules.MDMCostModel
+	err = modules.RPCRead(stream, &m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// verify it against the price table.
+	if err := m.Verify(pt); err != nil {
+		t.Fatal(err)
+	}
+}
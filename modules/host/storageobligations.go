@@ -31,9 +31,11 @@ package host
 // is not set or used.
 
 import (
+	"bytes"
 	"encoding/binary"
 	"encoding/json"
 	"reflect"
+	"sort"
 	"strconv"
 	"time"
 
@@ -145,6 +147,10 @@ type storageObligation struct {
 	// much computational or I/O expense.
 	SectorRoots []crypto.Hash
 
+	// NFTRoots are the merkle roots of the NFTs the renter tagged the
+	// obligation's data with.
+	NFTRoots []crypto.Hash
+
 	// Variables about the file contract that enforces the storage obligation.
 	// The origin an revision transaction are stored as a set, where the set
 	// contains potentially unconfirmed transactions.
@@ -362,13 +368,16 @@ func (so *storageObligation) StorageObligation() modules.StorageObligation {
 
 		ValidProofOutputs:  valid,
 		MissedProofOutputs: missed,
+
+		NFTRoots: so.NFTRoots,
 	}
 }
 
-// Update will take a list of sector changes and update the database to account
-// for all of it.
-func (so storageObligation) Update(sectorRoots []crypto.Hash, sectorsRemoved map[crypto.Hash]struct{}, sectorsGained map[crypto.Hash][]byte) error {
+// Update will take a list of sector changes and NFT tags and update the
+// database to account for all of it.
+func (so storageObligation) Update(sectorRoots []crypto.Hash, sectorsRemoved map[crypto.Hash]struct{}, sectorsGained map[crypto.Hash][]byte, nftRoots []crypto.Hash) error {
 	so.SectorRoots = sectorRoots
+	so.addNFTRoots(nftRoots)
 	sr := make([]crypto.Hash, 0, len(sectorsRemoved))
 	for sector := range sectorsRemoved {
 		sr = append(sr, sector)
@@ -376,6 +385,23 @@ func (so storageObligation) Update(sectorRoots []crypto.Hash, sectorsRemoved map
 	return so.h.managedModifyStorageObligation(so, sr, sectorsGained)
 }
 
+// addNFTRoots tags the storage obligation with the given NFT roots, ignoring
+// the ones it is already tagged with.
+func (so *storageObligation) addNFTRoots(nftRoots []crypto.Hash) {
+	for _, root := range nftRoots {
+		tagged := false
+		for _, existing := range so.NFTRoots {
+			if existing == root {
+				tagged = true
+				break
+			}
+		}
+		if !tagged {
+			so.NFTRoots = append(so.NFTRoots, root)
+		}
+	}
+}
+
 // expiration returns the height at which the storage obligation expires.
 func (so storageObligation) expiration() types.BlockHeight {
 	if len(so.RevisionTransactionSet) > 0 {
@@ -987,6 +1013,7 @@ func (h *Host) removeStorageObligation(so storageObligation, sos storageObligati
 	if err := h.MarkSectorsForRemoval(so.SectorRoots); err != nil {
 		h.log.Printf("contract %s, error marking sectors for removal: %v", so.id(), err)
 	}
+	h.staticContractBandwidth.managedForget(so.id())
 
 	// Update the host revenue metrics based on the status of the obligation.
 	if sos == obligationUnresolved {
@@ -1457,7 +1484,9 @@ func (h *Host) StorageObligations() (sos []modules.StorageObligation) {
 				return build.ExtendErr("unable to unmarshal storage obligation:", err)
 			}
 
-			sos = append(sos, so.StorageObligation())
+			mso := so.StorageObligation()
+			mso.UploadBandwidth, mso.DownloadBandwidth = h.staticContractBandwidth.managedUsage(so.id())
+			sos = append(sos, mso)
 			return nil
 		})
 		if err != nil {
@@ -1472,6 +1501,58 @@ func (h *Host) StorageObligations() (sos []modules.StorageObligation) {
 	return sos
 }
 
+// HostedNFTs returns the NFTs the host's unresolved storage obligations were
+// tagged with, ordered by potential revenue.
+func (h *Host) HostedNFTs() ([]modules.HostNFT, error) {
+	if err := h.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer h.tg.Done()
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	nfts := make(map[crypto.Hash]*modules.HostNFT)
+	err := h.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketStorageObligations).ForEach(func(_, soBytes []byte) error {
+			var so storageObligation
+			err := json.Unmarshal(soBytes, &so)
+			if err != nil {
+				return build.ExtendErr("unable to unmarshal storage obligation:", err)
+			}
+			if so.ObligationStatus != obligationUnresolved {
+				return nil
+			}
+			revenue := so.PotentialStorageRevenue.Add(so.PotentialUploadRevenue).Add(so.PotentialDownloadRevenue)
+			for _, root := range so.NFTRoots {
+				nft, exists := nfts[root]
+				if !exists {
+					nft = &modules.HostNFT{Root: root}
+					nfts[root] = nft
+				}
+				nft.Obligations = append(nft.Obligations, so.id())
+				nft.DataSize += so.fileSize()
+				nft.PotentialRevenue = nft.PotentialRevenue.Add(revenue)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to get tagged storage obligations")
+	}
+
+	hostedNFTs := make([]modules.HostNFT, 0, len(nfts))
+	for _, nft := range nfts {
+		hostedNFTs = append(hostedNFTs, *nft)
+	}
+	sort.Slice(hostedNFTs, func(i, j int) bool {
+		if cmp := hostedNFTs[i].PotentialRevenue.Cmp(hostedNFTs[j].PotentialRevenue); cmp != 0 {
+			return cmp > 0
+		}
+		return bytes.Compare(hostedNFTs[i].Root[:], hostedNFTs[j].Root[:]) < 0
+	})
+	return hostedNFTs, nil
+}
+
 // StorageObligation returns the storage obligation matching the id or
 // an error if it does not exist
 func (h *Host) StorageObligation(obligationID types.FileContractID) (modules.StorageObligation, error) {
@@ -1480,5 +1561,7 @@ func (h *Host) StorageObligation(obligationID types.FileContractID) (modules.Sto
 		return modules.StorageObligation{}, errors.AddContext(err, "failed to fetch storage obligation")
 	}
 
-	return so.StorageObligation(), nil
+	mso := so.StorageObligation()
+	mso.UploadBandwidth, mso.DownloadBandwidth = h.staticContractBandwidth.managedUsage(obligationID)
+	return mso, nil
 }
@@ -167,7 +167,7 @@ func TestStorageObligationSnapshot(t *testing.T) {
 	// Update the SO with new data
 	sectorRoot2, sectorData := randSector()
 	ht.host.managedLockStorageObligation(so.id())
-	err = so.Update([]crypto.Hash{sectorRoot, sectorRoot2}, nil, map[crypto.Hash][]byte{sectorRoot2: sectorData})
+	err = so.Update([]crypto.Hash{sectorRoot, sectorRoot2}, nil, map[crypto.Hash][]byte{sectorRoot2: sectorData}, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -186,12 +186,90 @@ func TestStorageObligationSnapshot(t *testing.T) {
 	// Verify we can not update the SO if it is not locked
 	ht.host.managedUnlockStorageObligation(so.id())
 	sectorRoot3, sectorData := randSector()
-	err = so.Update([]crypto.Hash{sectorRoot, sectorRoot2, sectorRoot3}, nil, map[crypto.Hash][]byte{sectorRoot3: sectorData})
+	err = so.Update([]crypto.Hash{sectorRoot, sectorRoot2, sectorRoot3}, nil, map[crypto.Hash][]byte{sectorRoot3: sectorData}, nil)
 	if err == nil {
 		t.Fatal("Expected Update to fail on unlocked SO")
 	}
 }
 
+// TestHostedNFTs tests tagging storage obligations with NFTs and reporting
+// them.
+func TestHostedNFTs(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Add two storage obligations.
+	var sos []storageObligation
+	for i := 0; i < 2; i++ {
+		so, err := ht.newTesterStorageObligation()
+		if err != nil {
+			t.Fatal(err)
+		}
+		so.PotentialStorageRevenue = types.NewCurrency64(uint64(i + 1))
+		ht.host.managedLockStorageObligation(so.id())
+		err = ht.host.managedAddStorageObligation(so)
+		ht.host.managedUnlockStorageObligation(so.id())
+		if err != nil {
+			t.Fatal(err)
+		}
+		sos = append(sos, so)
+	}
+
+	// Tag the first obligation with one NFT and both with another one.
+	// Tagging twice with the same root is a no-op.
+	var shared, single crypto.Hash
+	fastrand.Read(shared[:])
+	fastrand.Read(single[:])
+	tags := [][]crypto.Hash{{single, shared}, {shared, shared}}
+	for i, so := range sos {
+		ht.host.managedLockStorageObligation(so.id())
+		err = so.Update(so.SectorRoots, nil, nil, tags[i])
+		ht.host.managedUnlockStorageObligation(so.id())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The NFT stored in both obligations earns more and comes first.
+	nfts, err := ht.host.HostedNFTs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nfts) != 2 {
+		t.Fatal("expected 2 nfts but got", len(nfts))
+	}
+	if nfts[0].Root != shared || len(nfts[0].Obligations) != 2 || !nfts[0].PotentialRevenue.Equals64(3) {
+		t.Fatal("wrong report for shared nft", nfts[0])
+	}
+	if nfts[1].Root != single || len(nfts[1].Obligations) != 1 || nfts[1].Obligations[0] != sos[0].id() || !nfts[1].PotentialRevenue.Equals64(1) {
+		t.Fatal("wrong report for single nft", nfts[1])
+	}
+	if nfts[0].DataSize != sos[0].fileSize()+sos[1].fileSize() {
+		t.Fatal("wrong data size", nfts[0].DataSize)
+	}
+
+	// The tags are part of the obligation.
+	so, err := ht.host.StorageObligation(sos[1].id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(so.NFTRoots) != 1 || so.NFTRoots[0] != shared {
+		t.Fatal("wrong nft roots", so.NFTRoots)
+	}
+}
+
 // TestAccountFundingTracking verifies the AccountFunding field is properly
 // updated when the SOs lifecycle methods get called on the host.
 func TestAccountFundingTracking(t *testing.T) {
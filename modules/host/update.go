@@ -304,6 +304,11 @@ func (h *Host) ProcessConsensusChange(cc modules.ConsensusChange) {
 	// change.
 	h.recentChange = cc.ID
 
+	// Expired registry entries are pruned when the block height changes.
+	if h.blockHeight != oldHeight {
+		h.staticRegistryPruner.callNotify()
+	}
+
 	// Save the host.
 	err = h.saveSync()
 	if err != nil {
@@ -44,6 +44,11 @@ const (
 	// ReadRegistryVersionWithType specifies a read registry instruction that
 	// returns the type of the fetched entry at the end of the output.
 	ReadRegistryVersionWithType
+
+	// ReadRegistryVersionNoProof specifies a read registry instruction that
+	// returns the type of the fetched entry but not its signature. It is
+	// charged at a discount for renters which trust the host.
+	ReadRegistryVersionNoProof
 )
 
 const (
@@ -54,6 +59,11 @@ const (
 	// MDMCancellationTokenLen is the length of a program's cancellation token
 	// in bytes.
 	MDMCancellationTokenLen = 16
+
+	// MDMProoflessReadDiscount is the factor by which the cost of reads which
+	// don't request a proof is divided. Without a proof the host doesn't need
+	// to build a Merkle proof or send a signature.
+	MDMProoflessReadDiscount = 2
 )
 
 const (
@@ -94,6 +104,9 @@ const (
 	// MDMTimeSwapSector is the time for executing an 'SwapSector' instruction.
 	MDMTimeSwapSector = 1
 
+	// MDMTimeTagNFT is the time for executing a 'TagNFT' instruction.
+	MDMTimeTagNFT = 1
+
 	// MDMTimeWriteSector is the time for executing a 'WriteSector' instruction.
 	MDMTimeWriteSector = 10000
 
@@ -133,6 +146,10 @@ const (
 	// instructon.
 	RPCISwapSectorLen = 17 // 2 uint64 offsets + merkle proof flag
 
+	// RPCITagNFTLen is the expected length of the 'Args' of a TagNFT
+	// instruction.
+	RPCITagNFTLen = 8 // nftRootOffset
+
 	// RPCIUpdateRegistryLen is the expected length of the 'Args' of an
 	// UpdateRegistry instruction.
 	// tweakOffset + revisionOffset + signatureOffset + pubKeyOffset +
@@ -200,6 +217,9 @@ var (
 	// SpecifierSwapSector is the specifier for the SwapSector instruction.
 	SpecifierSwapSector = InstructionSpecifier{'S', 'w', 'a', 'p', 'S', 'e', 'c', 't', 'o', 'r'}
 
+	// SpecifierTagNFT is the specifier for the TagNFT instruction.
+	SpecifierTagNFT = InstructionSpecifier{'T', 'a', 'g', 'N', 'F', 'T'}
+
 	// SpecifierUpdateRegistry is the specifier for the UpdateRegistry
 	// instruction.
 	SpecifierUpdateRegistry = InstructionSpecifier{'U', 'p', 'd', 'a', 't', 'e', 'R', 'e', 'g', 'i', 's', 't', 'r', 'y'}
@@ -241,7 +261,19 @@ type (
 // DeriveRegistryEntryID is a helper to derive an entry id for a registry key value
 // pair.
 func DeriveRegistryEntryID(pubKey types.SiaPublicKey, tweak crypto.Hash) RegistryEntryID {
-	return RegistryEntryID(crypto.HashAll(pubKey, tweak))
+	if len(pubKey.Key) != crypto.PublicKeySize {
+		return RegistryEntryID(crypto.HashAll(pubKey, tweak))
+	}
+	// The id is derived for every registry lookup and update. Encode the key
+	// and tweak manually to avoid the reflection and allocations of
+	// crypto.HashAll. The encoding is the same.
+	var b [types.SpecifierLen + 8 + crypto.PublicKeySize + crypto.HashSize]byte
+	n := copy(b[:], pubKey.Algorithm[:])
+	binary.LittleEndian.PutUint64(b[n:], uint64(len(pubKey.Key)))
+	n += 8
+	n += copy(b[n:], pubKey.Key)
+	copy(b[n:], tweak[:])
+	return RegistryEntryID(crypto.HashBytes(b[:]))
 }
 
 // RPCHasSectorInstruction creates an Instruction from arguments.
@@ -309,6 +341,23 @@ func MDMReadCost(pt *RPCPriceTable, readLength uint64) types.Currency {
 	return cost
 }
 
+// MDMProoflessReadCost is the cost of executing a 'Read' instruction which
+// doesn't request a Merkle proof. It is defined as:
+// 'readBaseCost' + 'readLengthCost' * `readLength` / MDMProoflessReadDiscount
+func MDMProoflessReadCost(pt *RPCPriceTable, readLength uint64) types.Currency {
+	cost := pt.ReadLengthCost.Mul64(readLength).Div64(MDMProoflessReadDiscount).Add(pt.ReadBaseCost)
+	return cost
+}
+
+// MDMReadCostWithProof returns the cost of executing a 'Read' instruction
+// which may or may not request a Merkle proof.
+func MDMReadCostWithProof(pt *RPCPriceTable, readLength uint64, merkleProof bool) types.Currency {
+	if !merkleProof {
+		return MDMProoflessReadCost(pt, readLength)
+	}
+	return MDMReadCost(pt, readLength)
+}
+
 // MDMRevisionCost is the cost of executing a 'Revision' instruction.
 func MDMRevisionCost(pt *RPCPriceTable) types.Currency {
 	cost := pt.RevisionBaseCost
@@ -320,6 +369,12 @@ func MDMSwapSectorCost(pt *RPCPriceTable) types.Currency {
 	return pt.SwapSectorCost
 }
 
+// MDMTagNFTCost is the cost of executing a 'TagNFT' instruction. The host
+// writes the NFT's merkle root to the storage obligation.
+func MDMTagNFTCost(pt *RPCPriceTable) types.Currency {
+	return MDMWriteCost(pt, crypto.HashSize)
+}
+
 // V154MDMUpdateRegistryCost is the cost of executing a 'UpdateRegistry'
 // instruction in host versions 1.5.4 and below.
 func V154MDMUpdateRegistryCost(pt *RPCPriceTable) (_, _ types.Currency) {
@@ -353,6 +408,17 @@ func MDMReadRegistryCost(pt *RPCPriceTable) (_, _ types.Currency) {
 	return writeCost.Add(storeCost), storeCost
 }
 
+// MDMReadRegistryCostForVersion is the cost of executing a 'ReadRegistry'
+// instruction of a version. Reads which don't return the signature of the
+// entry are charged at a discount.
+func MDMReadRegistryCostForVersion(pt *RPCPriceTable, version ReadRegistryVersion) (_, _ types.Currency) {
+	cost, refund := MDMReadRegistryCost(pt)
+	if version == ReadRegistryVersionNoProof {
+		return cost.Div64(MDMProoflessReadDiscount), refund.Div64(MDMProoflessReadDiscount)
+	}
+	return cost, refund
+}
+
 // MDMWriteCost is the cost of executing a 'Write' instruction of a certain length.
 func MDMWriteCost(pt *RPCPriceTable, writeLength uint64) types.Currency {
 	// Atomic write size for modern disks is 4kib so we round up.
@@ -440,6 +506,12 @@ func MDMSwapSectorMemory() uint64 {
 	return 0 // 'SwapSector' doesn't hold on to any memory beyond the lifetime of the instruction.
 }
 
+// MDMTagNFTMemory returns the additional memory consumption of a 'TagNFT'
+// instruction.
+func MDMTagNFTMemory() uint64 {
+	return crypto.HashSize // The NFT root is kept in memory until the program is finalized.
+}
+
 // MDMUpdateRegistryMemory returns the additional memory consumption of a
 // 'UpdateRegistry' instruction.
 func MDMUpdateRegistryMemory() uint64 {
@@ -507,6 +579,12 @@ func MDMSwapSectorCollateral() types.Currency {
 	return types.ZeroCurrency
 }
 
+// MDMTagNFTCollateral returns the additional collateral a 'TagNFT'
+// instruction requires the host to put up.
+func MDMTagNFTCollateral() types.Currency {
+	return types.ZeroCurrency
+}
+
 // MDMUpdateRegistryCollateral returns the additional collateral a
 // 'UpdateRegistry' instruction requires the host to put up.
 func MDMUpdateRegistryCollateral() types.Currency {
@@ -533,6 +611,8 @@ func (p Program) ReadOnly() bool {
 		case SpecifierRevision:
 		case SpecifierSwapSector:
 			return false
+		case SpecifierTagNFT:
+			return false
 		case SpecifierUpdateRegistry:
 			// considered read-only cause it doesn't update a contract
 		case SpecifierReadRegistry:
@@ -563,6 +643,8 @@ func (p Program) RequiresSnapshot() bool {
 			return true
 		case SpecifierSwapSector:
 			return true
+		case SpecifierTagNFT:
+			return true
 		case SpecifierUpdateRegistry:
 		case SpecifierReadRegistry:
 		case SpecifierReadRegistryEID:
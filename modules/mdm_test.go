@@ -4,6 +4,8 @@ import (
 	"testing"
 
 	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/types"
 )
 
@@ -226,6 +228,11 @@ func TestRequiresReadonlyAndSnapshot(t *testing.T) {
 			false,
 			true,
 		},
+		{
+			SpecifierTagNFT,
+			false,
+			true,
+		},
 	}
 
 	for i, test := range tests {
@@ -240,3 +247,23 @@ func TestRequiresReadonlyAndSnapshot(t *testing.T) {
 		}
 	}
 }
+
+// TestDeriveRegistryEntryID tests that DeriveRegistryEntryID hashes the same
+// encoding of the key and tweak as crypto.HashAll.
+func TestDeriveRegistryEntryID(t *testing.T) {
+	t.Parallel()
+
+	_, pk := crypto.GenerateKeyPair()
+	keys := []types.SiaPublicKey{
+		types.Ed25519PublicKey(pk),
+		{Algorithm: types.SignatureEd25519, Key: fastrand.Bytes(16)},
+		{},
+	}
+	for _, spk := range keys {
+		var tweak crypto.Hash
+		fastrand.Read(tweak[:])
+		if DeriveRegistryEntryID(spk, tweak) != RegistryEntryID(crypto.HashAll(spk, tweak)) {
+			t.Fatal("wrong id for key", spk)
+		}
+	}
+}
@@ -0,0 +1,336 @@
+package modules
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/types"
+)
+
+// mdmcostmodel.go contains the cost model of the MDM as a table. The table is
+// derived from a price table and published by hosts, which allows renters to
+// check that a host computes the costs of programs the same way they do and to
+// verify the costs a host quotes for the programs it executes.
+//
+// The cost of every instruction is linear in the instruction's unit, which is
+// the number of bytes read by the read instructions and the number of dropped
+// sectors by 'DropSectors', and the duration a sector is stored for by
+// 'Append'. The memory cost of an instruction depends on the memory used by
+// the program so far and the time the instruction takes. Reads which don't
+// request a proof are charged at a discount, see MDMProoflessReadDiscount.
+
+const (
+	// MDMCostModelVersion is the version of the cost model table. The version
+	// changes whenever the way the costs are computed changes.
+	MDMCostModelVersion = 1
+)
+
+var (
+	// ErrUnknownMDMCostModelVersion is returned if a cost model table has a
+	// version the renter doesn't know.
+	ErrUnknownMDMCostModelVersion = errors.New("unknown mdm cost model version")
+	// ErrUnknownMDMInstruction is returned if the cost model doesn't contain
+	// an instruction.
+	ErrUnknownMDMInstruction = errors.New("instruction is not part of the cost model")
+)
+
+type (
+	// MDMCostModel is the cost model of the MDM for a price table.
+	MDMCostModel struct {
+		Version       uint64   `json:"version"`
+		PriceTableUID UniqueID `json:"pricetableuid"`
+
+		// InitBaseCost and MemoryTimeCost are the base cost of a program and
+		// the cost of a byte of memory per unit of time.
+		InitBaseCost   types.Currency `json:"initbasecost"`
+		MemoryTimeCost types.Currency `json:"memorytimecost"`
+
+		// InitMemory is the memory used by a program before executing its
+		// instructions. Initializing a program takes InitTime plus
+		// InitInstructionTime per instruction and finalizing a write program
+		// takes CommitTime.
+		InitMemory          uint64 `json:"initmemory"`
+		InitTime            uint64 `json:"inittime"`
+		InitInstructionTime uint64 `json:"initinstructiontime"`
+		CommitTime          uint64 `json:"committime"`
+
+		Instructions []MDMInstructionCost `json:"instructions"`
+	}
+
+	// MDMInstructionCost is the cost model of a single instruction. The cost
+	// of executing an instruction is BaseCost plus UnitCost per unit,
+	// StorageCost and BlockStorageCost per block of storage. The storage
+	// costs are refunded if the program fails. The host puts up
+	// BlockCollateral per block of storage.
+	MDMInstructionCost struct {
+		Specifier        InstructionSpecifier `json:"specifier"`
+		BaseCost         types.Currency       `json:"basecost"`
+		UnitCost         types.Currency       `json:"unitcost"`
+		StorageCost      types.Currency       `json:"storagecost"`
+		BlockStorageCost types.Currency       `json:"blockstoragecost"`
+		BlockCollateral  types.Currency       `json:"blockcollateral"`
+		Memory           uint64               `json:"memory"`
+		Time             uint64               `json:"time"`
+		UnitTime         uint64               `json:"unittime"`
+	}
+
+	// MDMCostMismatchError is returned if a host's costs don't match the cost
+	// model. Index is the index of the instruction within the program or -1
+	// if the host published a cost model that doesn't match the renter's.
+	MDMCostMismatchError struct {
+		Index     int
+		Specifier InstructionSpecifier
+		Expected  types.Currency
+		Quoted    types.Currency
+	}
+)
+
+// Error implements the error interface.
+func (err *MDMCostMismatchError) Error() string {
+	if err.Index < 0 && err.Specifier == (InstructionSpecifier{}) {
+		return "cost model of program initialization doesn't match"
+	} else if err.Index < 0 {
+		return fmt.Sprintf("cost model of instruction %v doesn't match", types.Specifier(err.Specifier))
+	}
+	return fmt.Sprintf("quoted cost %v of instruction %v (%v) exceeds expected cost %v", err.Quoted, err.Index, types.Specifier(err.Specifier), err.Expected)
+}
+
+// NewMDMCostModel returns the cost model for a price table.
+func NewMDMCostModel(pt *RPCPriceTable) MDMCostModel {
+	appendCost, appendStorage := MDMAppendCost(pt, 1)
+	updateRegistryCost, updateRegistryStorage := MDMUpdateRegistryCost(pt)
+	readRegistryCost, readRegistryStorage := MDMReadRegistryCost(pt)
+	return MDMCostModel{
+		Version:       MDMCostModelVersion,
+		PriceTableUID: pt.UID,
+
+		InitBaseCost:   pt.InitBaseCost,
+		MemoryTimeCost: pt.MemoryTimeCost,
+
+		InitMemory:          MDMInitMemory(),
+		InitTime:            MDMTimeInitProgram,
+		InitInstructionTime: MDMTimeInitSingleInstruction,
+		CommitTime:          MDMTimeCommit,
+
+		Instructions: []MDMInstructionCost{
+			{
+				Specifier:        SpecifierAppend,
+				BaseCost:         appendCost.Sub(appendStorage),
+				BlockStorageCost: appendStorage,
+				BlockCollateral:  MDMAppendCollateral(pt, 1),
+				Memory:           MDMAppendMemory(),
+				Time:             MDMTimeAppend,
+			},
+			{
+				Specifier: SpecifierDropSectors,
+				BaseCost:  pt.DropSectorsBaseCost,
+				UnitCost:  pt.DropSectorsUnitCost,
+				Memory:    MDMDropSectorsMemory(),
+				Time:      MDMTimeDropSectorsBase,
+				UnitTime:  MDMTimeDropSingleSector,
+			},
+			{
+				Specifier: SpecifierHasSector,
+				BaseCost:  MDMHasSectorCost(pt),
+				Memory:    MDMHasSectorMemory(),
+				Time:      MDMTimeHasSector,
+			},
+			{
+				Specifier: SpecifierReadOffset,
+				BaseCost:  pt.ReadBaseCost,
+				UnitCost:  pt.ReadLengthCost,
+				Memory:    MDMReadMemory(),
+				Time:      MDMTimeReadOffset,
+			},
+			{
+				Specifier: SpecifierReadSector,
+				BaseCost:  pt.ReadBaseCost,
+				UnitCost:  pt.ReadLengthCost,
+				Memory:    MDMReadMemory(),
+				Time:      MDMTimeReadSector,
+			},
+			{
+				Specifier: SpecifierRevision,
+				BaseCost:  MDMRevisionCost(pt),
+				Memory:    MDMRevisionMemory(),
+				Time:      MDMTimeRevision,
+			},
+			{
+				Specifier: SpecifierSwapSector,
+				BaseCost:  MDMSwapSectorCost(pt),
+				Memory:    MDMSwapSectorMemory(),
+				Time:      MDMTimeSwapSector,
+			},
+			{
+				Specifier: SpecifierTagNFT,
+				BaseCost:  MDMTagNFTCost(pt),
+				Memory:    MDMTagNFTMemory(),
+				Time:      MDMTimeTagNFT,
+			},
+			{
+				Specifier:   SpecifierUpdateRegistry,
+				BaseCost:    updateRegistryCost.Sub(updateRegistryStorage),
+				StorageCost: updateRegistryStorage,
+				Memory:      MDMUpdateRegistryMemory(),
+				Time:        MDMTimeUpdateRegistry,
+			},
+			{
+				Specifier:   SpecifierReadRegistry,
+				BaseCost:    readRegistryCost.Sub(readRegistryStorage),
+				StorageCost: readRegistryStorage,
+				Memory:      MDMReadRegistryMemory(),
+				Time:        MDMTimeReadRegistry,
+			},
+			{
+				Specifier:   SpecifierReadRegistryEID,
+				BaseCost:    readRegistryCost.Sub(readRegistryStorage),
+				StorageCost: readRegistryStorage,
+				Memory:      MDMReadRegistryMemory(),
+				Time:        MDMTimeReadRegistry,
+			},
+		},
+	}
+}
+
+// Equals returns true if both instruction cost models are the same.
+func (c MDMInstructionCost) Equals(other MDMInstructionCost) bool {
+	return c.Specifier == other.Specifier &&
+		c.BaseCost.Equals(other.BaseCost) &&
+		c.UnitCost.Equals(other.UnitCost) &&
+		c.StorageCost.Equals(other.StorageCost) &&
+		c.BlockStorageCost.Equals(other.BlockStorageCost) &&
+		c.BlockCollateral.Equals(other.BlockCollateral) &&
+		c.Memory == other.Memory &&
+		c.Time == other.Time &&
+		c.UnitTime == other.UnitTime
+}
+
+// Verify checks that a cost model published by a host matches the cost model
+// derived from the price table.
+func (m MDMCostModel) Verify(pt *RPCPriceTable) error {
+	if m.Version != MDMCostModelVersion {
+		return errors.AddContext(ErrUnknownMDMCostModelVersion, fmt.Sprint(m.Version))
+	}
+	expected := NewMDMCostModel(pt)
+	if m.PriceTableUID != expected.PriceTableUID {
+		return errors.New("cost model is for a different price table")
+	}
+	if !m.InitBaseCost.Equals(expected.InitBaseCost) || !m.MemoryTimeCost.Equals(expected.MemoryTimeCost) ||
+		m.InitMemory != expected.InitMemory || m.InitTime != expected.InitTime ||
+		m.InitInstructionTime != expected.InitInstructionTime || m.CommitTime != expected.CommitTime {
+		return &MDMCostMismatchError{Index: -1, Expected: expected.InitBaseCost, Quoted: m.InitBaseCost}
+	}
+	if len(m.Instructions) != len(expected.Instructions) {
+		return errors.New("cost model has the wrong number of instructions")
+	}
+	for i, c := range expected.Instructions {
+		if !m.Instructions[i].Equals(c) {
+			return &MDMCostMismatchError{Index: -1, Specifier: c.Specifier, Expected: c.BaseCost, Quoted: m.Instructions[i].BaseCost}
+		}
+	}
+	return nil
+}
+
+// instruction returns the cost model of the instruction with the specifier.
+func (m MDMCostModel) instruction(s InstructionSpecifier) (MDMInstructionCost, bool) {
+	for _, c := range m.Instructions {
+		if c.Specifier == s {
+			return c, true
+		}
+	}
+	return MDMInstructionCost{}, false
+}
+
+// instructionUnits returns the number of units of an instruction, which are
+// read from the program data.
+func instructionUnits(i Instruction, data ProgramData) (uint64, error) {
+	var offsetArg int
+	switch i.Specifier {
+	case SpecifierDropSectors:
+		offsetArg = 0
+	case SpecifierReadOffset:
+		offsetArg = 8
+	case SpecifierReadSector:
+		offsetArg = 16
+	default:
+		return 0, nil
+	}
+	if len(i.Args) < offsetArg+8 {
+		return 0, fmt.Errorf("instruction %v has too few arguments", i.Specifier)
+	}
+	offset := binary.LittleEndian.Uint64(i.Args[offsetArg:])
+	if offset > uint64(len(data)) || uint64(len(data))-offset < 8 {
+		return 0, fmt.Errorf("argument of instruction %v is out of bounds", i.Specifier)
+	}
+	return binary.LittleEndian.Uint64(data[offset:]), nil
+}
+
+// instructionProofless returns true if a read instruction doesn't request a
+// proof of the data it returns.
+func instructionProofless(i Instruction) bool {
+	switch i.Specifier {
+	case SpecifierReadOffset:
+		return len(i.Args) == RPCIReadOffsetLen && i.Args[16] == 0
+	case SpecifierReadSector:
+		return len(i.Args) == RPCIReadSectorLen && i.Args[24] == 0
+	case SpecifierReadRegistry:
+		return len(i.Args) == RPCIReadRegistryWithVersionLen && ReadRegistryVersion(i.Args[24]) == ReadRegistryVersionNoProof
+	case SpecifierReadRegistryEID:
+		return len(i.Args) == RPCIReadRegistryEIDWithVersionLen && ReadRegistryVersion(i.Args[9]) == ReadRegistryVersionNoProof
+	}
+	return false
+}
+
+// ProgramCosts returns the running execution cost of a program after each of
+// its instructions, which is what hosts quote as the TotalCost of the
+// instructions' outputs. Sectors appended by the program are stored for
+// duration blocks. The cost of finalizing write programs isn't included.
+func (m MDMCostModel) ProgramCosts(p Program, data ProgramData, duration types.BlockHeight) ([]types.Currency, error) {
+	initTime := m.InitTime + m.InitInstructionTime*uint64(len(p))
+	cost := m.MemoryTimeCost.Mul64(uint64(len(data)) * initTime).Add(m.InitBaseCost)
+	usedMemory := m.InitMemory
+	costs := make([]types.Currency, 0, len(p))
+	for _, i := range p {
+		c, ok := m.instruction(i.Specifier)
+		if !ok {
+			return nil, errors.AddContext(ErrUnknownMDMInstruction, types.Specifier(i.Specifier).String())
+		}
+		units, err := instructionUnits(i, data)
+		if err != nil {
+			return nil, err
+		}
+		usedMemory += c.Memory
+		time := c.Time + c.UnitTime*units
+		cost = cost.Add(m.MemoryTimeCost.Mul64(usedMemory * time))
+		fixedCost := c.BaseCost.Add(c.StorageCost)
+		unitCost := c.UnitCost.Mul64(units)
+		if instructionProofless(i) {
+			switch i.Specifier {
+			case SpecifierReadOffset, SpecifierReadSector:
+				unitCost = unitCost.Div64(MDMProoflessReadDiscount)
+			default:
+				fixedCost = fixedCost.Div64(MDMProoflessReadDiscount)
+			}
+		}
+		cost = cost.Add(fixedCost).Add(unitCost).Add(c.BlockStorageCost.Mul64(uint64(duration)))
+		costs = append(costs, cost)
+	}
+	return costs, nil
+}
+
+// VerifyMDMProgramCosts checks the costs a host quoted for the outputs of a
+// program against the expected costs returned by ProgramCosts. Hosts may
+// charge less than expected since they refund the storage cost of some
+// instructions, but never more.
+func VerifyMDMProgramCosts(p Program, expected []types.Currency, quoted []RPCExecuteProgramResponse) error {
+	if len(quoted) > len(expected) || len(expected) != len(p) {
+		return errors.New("number of quoted costs doesn't match the program")
+	}
+	for i, resp := range quoted {
+		if resp.TotalCost.Cmp(expected[i]) > 0 {
+			return &MDMCostMismatchError{Index: i, Specifier: p[i].Specifier, Expected: expected[i], Quoted: resp.TotalCost}
+		}
+	}
+	return nil
+}
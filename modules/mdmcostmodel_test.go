@@ -0,0 +1,185 @@
+package modules
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/types"
+)
+
+// randomCostModelPriceTable returns a price table with random prices.
+func randomCostModelPriceTable() *RPCPriceTable {
+	pt := &RPCPriceTable{
+		InitBaseCost:          types.NewCurrency64(fastrand.Uint64n(1e9) + 1),
+		MemoryTimeCost:        types.NewCurrency64(fastrand.Uint64n(1e3) + 1),
+		DropSectorsBaseCost:   types.NewCurrency64(fastrand.Uint64n(1e9) + 1),
+		DropSectorsUnitCost:   types.NewCurrency64(fastrand.Uint64n(1e9) + 1),
+		HasSectorBaseCost:     types.NewCurrency64(fastrand.Uint64n(1e9) + 1),
+		ReadBaseCost:          types.NewCurrency64(fastrand.Uint64n(1e9) + 1),
+		ReadLengthCost:        types.NewCurrency64(fastrand.Uint64n(1e3) + 1),
+		RevisionBaseCost:      types.NewCurrency64(fastrand.Uint64n(1e9) + 1),
+		WriteBaseCost:         types.NewCurrency64(fastrand.Uint64n(1e9) + 1),
+		WriteLengthCost:       types.NewCurrency64(fastrand.Uint64n(1e3) + 1),
+		WriteStoreCost:        types.NewCurrency64(fastrand.Uint64n(1e3) + 1),
+		CollateralCost:        types.NewCurrency64(fastrand.Uint64n(1e3) + 1),
+		DownloadBandwidthCost: types.NewCurrency64(fastrand.Uint64n(1e3) + 1),
+		UploadBandwidthCost:   types.NewCurrency64(fastrand.Uint64n(1e3) + 1),
+	}
+	fastrand.Read(pt.UID[:])
+	return pt
+}
+
+// TestMDMCostModelProgramCosts checks that the costs computed from the cost
+// model match the costs computed by the program builder.
+func TestMDMCostModelProgramCosts(t *testing.T) {
+	pt := randomCostModelPriceTable()
+	m := NewMDMCostModel(pt)
+	duration := types.BlockHeight(fastrand.Uint64n(1000) + 1)
+
+	// Read-only program.
+	pb := NewProgramBuilder(pt, 0)
+	pb.AddHasSectorInstruction(crypto.Hash{1})
+	pb.AddReadSectorInstruction(SectorSize/2, 64, crypto.Hash{1}, true)
+	pb.AddReadOffsetInstruction(4096, 0, true)
+	var spk types.SiaPublicKey
+	if _, err := pb.V156AddReadRegistryInstruction(spk, crypto.Hash{2}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pb.V156AddReadRegistryEIDInstruction(DeriveRegistryEntryID(spk, crypto.Hash{2}), true); err != nil {
+		t.Fatal(err)
+	}
+	p, data := pb.Program()
+	costs, err := m.ProgramCosts(p, data, duration)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cost, _, _ := pb.Cost(false)
+	if len(costs) != len(p) || !costs[len(costs)-1].Equals(cost) {
+		t.Fatalf("expected cost %v but got %v", cost, costs)
+	}
+
+	// Write program.
+	pb = NewProgramBuilder(pt, duration)
+	if err := pb.AddAppendInstruction(fastrand.Bytes(int(SectorSize)), false, duration); err != nil {
+		t.Fatal(err)
+	}
+	pb.AddDropSectorsInstruction(3, true)
+	pb.AddSwapSectorInstruction(0, 1, false)
+	pb.AddRevisionInstruction()
+	p, data = pb.Program()
+	costs, err = m.ProgramCosts(p, data, duration)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cost, _, _ = pb.Cost(false)
+	if !costs[len(costs)-1].Equals(cost) {
+		t.Fatalf("expected cost %v but got %v", cost, costs[len(costs)-1])
+	}
+
+	// Unknown instructions are rejected.
+	_, err = m.ProgramCosts(Program{{Specifier: InstructionSpecifier{'x'}}}, nil, 0)
+	if !errors.Contains(err, ErrUnknownMDMInstruction) {
+		t.Fatal("expected ErrUnknownMDMInstruction but got", err)
+	}
+}
+
+// TestMDMCostModelProoflessReads checks that the cost model discounts reads
+// which don't request a proof the same way the program builder does.
+func TestMDMCostModelProoflessReads(t *testing.T) {
+	pt := randomCostModelPriceTable()
+	m := NewMDMCostModel(pt)
+
+	var spk types.SiaPublicKey
+	newProgram := func(merkleProof bool, version ReadRegistryVersion) (Program, ProgramData, types.Currency) {
+		pb := NewProgramBuilder(pt, 0)
+		pb.AddReadSectorInstruction(SectorSize/2, 64, crypto.Hash{1}, merkleProof)
+		pb.AddReadOffsetInstruction(4096, 0, merkleProof)
+		if _, err := pb.AddReadRegistryInstruction(spk, crypto.Hash{2}, version); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := pb.AddReadRegistryEIDInstruction(DeriveRegistryEntryID(spk, crypto.Hash{2}), true, version); err != nil {
+			t.Fatal(err)
+		}
+		p, data := pb.Program()
+		cost, _, _ := pb.Cost(false)
+		return p, data, cost
+	}
+
+	p, data, proofCost := newProgram(true, ReadRegistryVersionWithType)
+	costs, err := m.ProgramCosts(p, data, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !costs[len(costs)-1].Equals(proofCost) {
+		t.Fatalf("expected cost %v but got %v", proofCost, costs[len(costs)-1])
+	}
+	p, data, cost := newProgram(false, ReadRegistryVersionNoProof)
+	costs, err = m.ProgramCosts(p, data, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !costs[len(costs)-1].Equals(cost) {
+		t.Fatalf("expected cost %v but got %v", cost, costs[len(costs)-1])
+	}
+	if cost.Cmp(proofCost) >= 0 {
+		t.Fatalf("reads without proof should be cheaper: %v >= %v", cost, proofCost)
+	}
+}
+
+// TestMDMCostModelVerify tests verifying published cost models and quoted
+// program costs.
+func TestMDMCostModelVerify(t *testing.T) {
+	pt := randomCostModelPriceTable()
+
+	// The cost model survives a round trip through JSON.
+	b, err := json.Marshal(NewMDMCostModel(pt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m MDMCostModel
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Verify(pt); err != nil {
+		t.Fatal(err)
+	}
+
+	// Models that don't match are rejected.
+	bad := NewMDMCostModel(pt)
+	bad.Version++
+	if err := bad.Verify(pt); !errors.Contains(err, ErrUnknownMDMCostModelVersion) {
+		t.Fatal("expected ErrUnknownMDMCostModelVersion but got", err)
+	}
+	bad = NewMDMCostModel(pt)
+	bad.Instructions = append([]MDMInstructionCost(nil), bad.Instructions...)
+	bad.Instructions[2].BaseCost = bad.Instructions[2].BaseCost.Add64(1)
+	mismatch, ok := bad.Verify(pt).(*MDMCostMismatchError)
+	if !ok || mismatch.Index != -1 || mismatch.Specifier != SpecifierHasSector {
+		t.Fatal("expected MDMCostMismatchError but got", mismatch)
+	}
+
+	// Quoted costs up to the expected costs are accepted.
+	pb := NewProgramBuilder(pt, 0)
+	pb.AddHasSectorInstruction(crypto.Hash{})
+	pb.AddReadOffsetInstruction(64, 0, false)
+	p, data := pb.Program()
+	expected, err := m.ProgramCosts(p, data, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	quoted := []RPCExecuteProgramResponse{{TotalCost: expected[0]}, {TotalCost: expected[1].Sub64(1)}}
+	if err := VerifyMDMProgramCosts(p, expected, quoted); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyMDMProgramCosts(p, expected, quoted[:1]); err != nil {
+		t.Fatal(err)
+	}
+	quoted[1].TotalCost = expected[1].Add64(1)
+	err = VerifyMDMProgramCosts(p, expected, quoted)
+	if mismatch, ok = err.(*MDMCostMismatchError); !ok || mismatch.Index != 1 || mismatch.Specifier != SpecifierReadOffset || !mismatch.Quoted.Equals(quoted[1].TotalCost) {
+		t.Fatal("expected MDMCostMismatchError but got", err)
+	}
+}
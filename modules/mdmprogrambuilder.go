@@ -116,7 +116,7 @@ func (pb *ProgramBuilder) AddReadOffsetInstruction(length, offset uint64, merkle
 	pb.program = append(pb.program, i)
 	// Update cost, collateral and memory usage.
 	collateral := MDMReadCollateral()
-	cost := MDMReadCost(pb.staticPT, length)
+	cost := MDMReadCostWithProof(pb.staticPT, length, merkleProof)
 	memory := MDMReadMemory()
 	time := uint64(MDMTimeReadOffset)
 	pb.addInstruction(collateral, cost, types.ZeroCurrency, memory, time)
@@ -138,7 +138,7 @@ func (pb *ProgramBuilder) AddReadSectorInstruction(length, offset uint64, merkle
 	pb.program = append(pb.program, i)
 	// Update cost, collateral and memory usage.
 	collateral := MDMReadCollateral()
-	cost := MDMReadCost(pb.staticPT, length)
+	cost := MDMReadCostWithProof(pb.staticPT, length, merkleProof)
 	memory := MDMReadMemory()
 	time := uint64(MDMTimeReadSector)
 	pb.addInstruction(collateral, cost, types.ZeroCurrency, memory, time)
@@ -216,6 +216,25 @@ func (pb *ProgramBuilder) V156AddUpdateRegistryInstruction(spk types.SiaPublicKe
 	return nil
 }
 
+// AddTagNFTInstruction adds a TagNFT instruction to the program.
+func (pb *ProgramBuilder) AddTagNFTInstruction(nftRoot crypto.Hash) {
+	// Compute the argument offsets.
+	nftRootOffset := uint64(pb.programData.Len())
+	// Extend the programData.
+	binary.Write(pb.programData, binary.LittleEndian, nftRoot[:])
+	// Create the instruction.
+	i := NewTagNFTInstruction(nftRootOffset)
+	// Append instruction
+	pb.program = append(pb.program, i)
+	// Update cost, collateral and memory usage.
+	collateral := MDMTagNFTCollateral()
+	cost := MDMTagNFTCost(pb.staticPT)
+	memory := MDMTagNFTMemory()
+	time := uint64(MDMTimeTagNFT)
+	pb.addInstruction(collateral, cost, types.ZeroCurrency, memory, time)
+	pb.readonly = false
+}
+
 // AddUpdateRegistryInstruction adds an UpdateRegistry instruction to the
 // program.
 func (pb *ProgramBuilder) AddUpdateRegistryInstruction(spk types.SiaPublicKey, rv SignedRegistryValue) error {
@@ -337,7 +356,7 @@ func (pb *ProgramBuilder) AddReadRegistryInstruction(spk types.SiaPublicKey, twe
 	pb.program = append(pb.program, i)
 	// Read cost, collateral and memory usage.
 	collateral := MDMReadRegistryCollateral()
-	cost, refund := MDMReadRegistryCost(pb.staticPT)
+	cost, refund := MDMReadRegistryCostForVersion(pb.staticPT, version)
 	memory := MDMReadRegistryMemory()
 	time := uint64(MDMTimeReadRegistry)
 	pb.addInstruction(collateral, cost, refund, memory, time)
@@ -388,7 +407,7 @@ func (pb *ProgramBuilder) AddReadRegistryEIDInstruction(sid RegistryEntryID, nee
 	pb.program = append(pb.program, i)
 	// Read cost, collateral and memory usage.
 	collateral := MDMReadRegistryCollateral()
-	cost, refund := MDMReadRegistryCost(pb.staticPT)
+	cost, refund := MDMReadRegistryCostForVersion(pb.staticPT, version)
 	memory := MDMReadRegistryMemory()
 	time := uint64(MDMTimeReadRegistry)
 	pb.addInstruction(collateral, cost, refund, memory, time)
@@ -589,6 +608,16 @@ func NewSwapSectorInstruction(sector1Offset, sector2Offset uint64, merkleProof b
 	return i
 }
 
+// NewTagNFTInstruction creates a modules.Instruction from arguments.
+func NewTagNFTInstruction(nftRootOffset uint64) Instruction {
+	i := Instruction{
+		Specifier: SpecifierTagNFT,
+		Args:      make([]byte, RPCITagNFTLen),
+	}
+	binary.LittleEndian.PutUint64(i.Args[:8], nftRootOffset)
+	return i
+}
+
 // NewRevisionInstruction creates a modules.Instruction from arguments.
 func NewRevisionInstruction(merkleRootOffset uint64) Instruction {
 	return Instruction{
@@ -195,6 +195,12 @@ func New(cs modules.ConsensusSet, tpool modules.TransactionPool, w modules.Walle
 		return nil, errors.New("miner persistence startup failed: " + err.Error())
 	}
 
+	// The split sets of the persisted unsolved block are lost on restart, so
+	// its transactions could neither be removed nor deduplicated once the
+	// transaction pool resubmits them. Subscribing to the transaction pool
+	// adds all of its sets again.
+	m.persist.UnsolvedBlock.Transactions = nil
+
 	err = m.cs.ConsensusSetSubscribe(m, m.persist.RecentChange, m.tg.StopChan())
 	if errors.Contains(err, modules.ErrInvalidConsensusChangeID) {
 		// Perform a rescan of the consensus set if the change id is not found.
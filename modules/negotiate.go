@@ -23,7 +23,7 @@ import (
 const (
 	// RHPVersion is the version of the Sia renter-host protocol currently
 	// implemented by the host module.
-	RHPVersion = "1.5.8"
+	RHPVersion = "1.5.9"
 
 	// MinimumSupportedRenterHostProtocolVersion is the minimum version of Sia
 	// that supports the currently used version of the renter-host protocol.
@@ -115,6 +115,11 @@ const (
 	// during negotiation.
 	NegotiateMaxTransactionSignatureSize = 2e3
 
+	// NegotiateMaxWriteStreamSectors is the maximum number of sectors a host
+	// accepts in a single RPCLoopWriteStream. The host holds all sectors of
+	// the stream in memory until the revision is signed.
+	NegotiateMaxWriteStreamSectors = 32
+
 	// NegotiateMaxTransactionSignaturesSize defines the maximum size that a
 	// transaction signature slice is allowed to be when being sent over the
 	// wire during negotiation.
@@ -169,6 +174,10 @@ var (
 	// announcement is not a type of signature that is recognized.
 	ErrAnnUnrecognizedSignature = errors.New("the signature provided in the host announcement is not recognized")
 
+	// ErrAnnUnrecognizedExtension is returned when a host announcement is
+	// followed by data that isn't a recognized announcement extension.
+	ErrAnnUnrecognizedExtension = errors.New("the host announcement is followed by an unrecognized extension")
+
 	// ErrMaxVirtualSectors is returned when a sector cannot be added because
 	// the maximum number of virtual sectors for that sector id already exist.
 	ErrMaxVirtualSectors = errors.New("sector collides with a physical sector that already has the maximum allowed number of virtual sectors")
@@ -192,6 +201,10 @@ var (
 	// announcement will follow this prefix.
 	PrefixHostAnnouncement = types.NewSpecifier("HostAnnouncement")
 
+	// PrefixHostCapabilities is used to indicate that a host announcement is
+	// followed by an extension advertising the host's capabilities.
+	PrefixHostCapabilities = types.NewSpecifier("HostCapabilities")
+
 	// PrefixFileContractIdentifier is used to indicate that a transaction's
 	// Arbitrary Data field contains a file contract identifier. The identifier
 	// and its signature will follow this prefix.
@@ -262,6 +275,36 @@ type (
 		PublicKey  types.SiaPublicKey
 	}
 
+	// HostAnnouncementCapabilities is an optional extension of a host
+	// announcement. 'Specifier' is always 'PrefixHostCapabilities'. The
+	// extension follows the signature of the announcement and is followed by
+	// a signature from the public key of the announcement over everything
+	// that precedes it. Decoders which don't know about the extension ignore
+	// it.
+	HostAnnouncementCapabilities struct {
+		Specifier    types.Specifier
+		Capabilities HostCapabilities
+	}
+
+	// HostCapabilities are the registry and NFT related capabilities of a
+	// host. They are advertised in the host's announcement and its external
+	// settings so that renters can select hosts by capability.
+	//
+	// RegistryEntriesLeft is the number of entries that can still be stored
+	// in the host's registry.
+	//
+	// NFTStoragePool indicates that the host participates in the NFT storage
+	// pool.
+	//
+	// MaxRegistryEntryTTL is the maximum number of blocks the host keeps a
+	// registry entry after it was last updated. A value of zero means that
+	// the host doesn't offer a registry.
+	HostCapabilities struct {
+		RegistryEntriesLeft uint64            `json:"registryentriesleft"`
+		NFTStoragePool      bool              `json:"nftstoragepool"`
+		MaxRegistryEntryTTL types.BlockHeight `json:"maxregistryentryttl"`
+	}
+
 	// HostExternalSettings are the parameters advertised by the host. These
 	// are the values that the renter will request from the host in order to
 	// build its database.
@@ -286,6 +329,16 @@ type (
 		UnlockHash           types.UnlockHash  `json:"unlockhash"`
 		WindowSize           types.BlockHeight `json:"windowsize"`
 
+		// MaxWriteStreamSectors is the maximum number of sectors the host
+		// accepts in a single RPCLoopWriteStream. Hosts which don't support
+		// the RPC leave it at zero.
+		MaxWriteStreamSectors uint64 `json:"maxwritestreamsectors"`
+
+		// FreeRegistryReads is the number of registry reads per minute the
+		// host serves to a client through RPCFreeRegistryRead without
+		// payment. Hosts which don't offer free reads leave it at zero.
+		FreeRegistryReads uint64 `json:"freeregistryreads"`
+
 		// Collateral is the amount of collateral that the host will put up for
 		// storage in 'bytes per block', as an assurance to the renter that the
 		// host really is committed to keeping the file. But, because the file
@@ -327,6 +380,20 @@ type (
 		StoragePrice           types.Currency `json:"storageprice"`
 		UploadBandwidthPrice   types.Currency `json:"uploadbandwidthprice"`
 
+		// NFTStoragePrice is the cost per-byte-per-block in hastings of
+		// storing data that has been tagged as NFT data. A value of zero means
+		// that NFT data is charged the regular StoragePrice.
+		//
+		// NFTRetentionPeriod is the maximum duration in blocks of contracts
+		// formed to store NFT data. It allows hosts to guarantee a longer
+		// retention for NFT data than for regular data. A value of zero means
+		// that MaxDuration applies.
+		NFTStoragePrice    types.Currency    `json:"nftstorageprice"`
+		NFTRetentionPeriod types.BlockHeight `json:"nftretentionperiod"`
+
+		// HostCapabilities are the registry and NFT capabilities of the host.
+		HostCapabilities
+
 		// EphemeralAccountExpiry is the amount of time an account can be
 		// inactive before the host considers it expired.
 		//
@@ -399,6 +466,36 @@ func (hes HostExternalSettings) MaxSectorAccessPrice() types.Currency {
 	return hes.DownloadBandwidthPrice.Mul64(MaxSectorAccessPriceVsBandwidth)
 }
 
+// StoragePriceFor returns the price the host charges for storing either NFT
+// data or regular data.
+func (hes HostExternalSettings) StoragePriceFor(nft bool) types.Currency {
+	if nft && !hes.NFTStoragePrice.IsZero() {
+		return hes.NFTStoragePrice
+	}
+	return hes.StoragePrice
+}
+
+// MaxDurationFor returns the maximum duration of contracts the host accepts
+// for storing either NFT data or regular data.
+func (hes HostExternalSettings) MaxDurationFor(nft bool) types.BlockHeight {
+	if nft && hes.NFTRetentionPeriod > hes.MaxDuration {
+		return hes.NFTRetentionPeriod
+	}
+	return hes.MaxDuration
+}
+
+// Satisfies returns true if the capabilities meet the required capabilities.
+// Zero values of the required capabilities are ignored.
+func (hc HostCapabilities) Satisfies(required HostCapabilities) bool {
+	if hc.RegistryEntriesLeft < required.RegistryEntriesLeft {
+		return false
+	}
+	if required.NFTStoragePool && !hc.NFTStoragePool {
+		return false
+	}
+	return hc.MaxRegistryEntryTTL >= required.MaxRegistryEntryTTL
+}
+
 // SiaMuxAddress returns the address of the host's siamux.
 func (hes HostExternalSettings) SiaMuxAddress() string {
 	return fmt.Sprintf("%s:%s", hes.NetAddress.Host(), hes.SiaMuxPort)
@@ -416,6 +513,7 @@ var (
 	RPCLoopSettings           = types.NewSpecifier("LoopSettings")
 	RPCLoopUnlock             = types.NewSpecifier("LoopUnlock")
 	RPCLoopWrite              = types.NewSpecifier("LoopWrite")
+	RPCLoopWriteStream        = types.NewSpecifier("LoopWriteStream")
 )
 
 // RPC ciphers
@@ -634,6 +732,34 @@ type (
 	LoopWriteResponse struct {
 		Signature []byte
 	}
+
+	// LoopWriteStreamRequest contains the request parameters for
+	// RPCLoopWriteStream, which appends NumSectors sectors to the contract.
+	// The request is followed by NumSectors LoopWriteStreamSector messages.
+	// The renter doesn't wait for the response to a sector before sending the
+	// next one, the host acknowledges each sector with a LoopWriteStreamAck
+	// once it has processed it. After the last sector the RPC continues like
+	// RPCLoopWrite with a Merkle proof.
+	LoopWriteStreamRequest struct {
+		NumSectors uint64
+
+		NewRevisionNumber    uint64
+		NewValidProofValues  []types.Currency
+		NewMissedProofValues []types.Currency
+	}
+
+	// LoopWriteStreamSector contains the data of a sector of
+	// RPCLoopWriteStream.
+	LoopWriteStreamSector struct {
+		Data []byte
+	}
+
+	// LoopWriteStreamAck acknowledges the sector with the given index of
+	// RPCLoopWriteStream.
+	LoopWriteStreamAck struct {
+		Index uint64
+		Root  crypto.Hash
+	}
 )
 
 // Error implements the error interface.
@@ -881,32 +1007,75 @@ func CreateAnnouncement(addr NetAddress, pk types.SiaPublicKey, sk crypto.Secret
 	return append(annBytes, sig[:]...), nil
 }
 
+// CreateAnnouncementWithCapabilities creates a host announcement like
+// CreateAnnouncement and extends it with the host's capabilities.
+func CreateAnnouncementWithCapabilities(addr NetAddress, pk types.SiaPublicKey, sk crypto.SecretKey, hc HostCapabilities) ([]byte, error) {
+	annBytes, err := CreateAnnouncement(addr, pk, sk)
+	if err != nil {
+		return nil, err
+	}
+
+	// Append the extension and sign the whole announcement.
+	annBytes = append(annBytes, encoding.Marshal(HostAnnouncementCapabilities{
+		Specifier:    PrefixHostCapabilities,
+		Capabilities: hc,
+	})...)
+	sig := crypto.SignHash(crypto.HashBytes(annBytes), sk)
+	return append(annBytes, sig[:]...), nil
+}
+
 // DecodeAnnouncement decodes announcement bytes into a host announcement,
 // verifying the prefix and the signature.
 func DecodeAnnouncement(fullAnnouncement []byte) (na NetAddress, spk types.SiaPublicKey, err error) {
+	ha, _, err := decodeAnnouncement(fullAnnouncement, false)
+	if err != nil {
+		return "", types.SiaPublicKey{}, err
+	}
+	return ha.NetAddress, ha.PublicKey, nil
+}
+
+// DecodeAnnouncementWithCapabilities decodes announcement bytes into a host
+// announcement and the capabilities of its extension, verifying the prefixes
+// and the signatures. 'ok' is false if the announcement has no extension.
+func DecodeAnnouncementWithCapabilities(fullAnnouncement []byte) (na NetAddress, spk types.SiaPublicKey, hc HostCapabilities, ok bool, err error) {
+	ha, hac, err := decodeAnnouncement(fullAnnouncement, true)
+	if err != nil {
+		return "", types.SiaPublicKey{}, HostCapabilities{}, false, err
+	}
+	if hac == nil {
+		return ha.NetAddress, ha.PublicKey, HostCapabilities{}, false, nil
+	}
+	return ha.NetAddress, ha.PublicKey, hac.Capabilities, true, nil
+}
+
+// decodeAnnouncement decodes and verifies a host announcement. If
+// withExtension is true, an extension following the announcement is decoded
+// and verified as well.
+func decodeAnnouncement(fullAnnouncement []byte, withExtension bool) (HostAnnouncement, *HostAnnouncementCapabilities, error) {
 	// Read the first part of the announcement to get the intended host
 	// announcement.
 	var ha HostAnnouncement
-	dec := encoding.NewDecoder(bytes.NewReader(fullAnnouncement), len(fullAnnouncement)*3)
-	err = dec.Decode(&ha)
+	r := bytes.NewReader(fullAnnouncement)
+	dec := encoding.NewDecoder(r, len(fullAnnouncement)*3)
+	err := dec.Decode(&ha)
 	if err != nil {
-		return "", types.SiaPublicKey{}, err
+		return HostAnnouncement{}, nil, err
 	}
 
 	// Check that the announcement was registered as a host announcement.
 	if ha.Specifier != PrefixHostAnnouncement {
-		return "", types.SiaPublicKey{}, ErrAnnNotAnnouncement
+		return HostAnnouncement{}, nil, ErrAnnNotAnnouncement
 	}
 	// Check that the public key is a recognized type of public key.
 	if ha.PublicKey.Algorithm != types.SignatureEd25519 {
-		return "", types.SiaPublicKey{}, ErrAnnUnrecognizedSignature
+		return HostAnnouncement{}, nil, ErrAnnUnrecognizedSignature
 	}
 
 	// Read the signature out of the reader.
 	var sig crypto.Signature
 	err = dec.Decode(&sig)
 	if err != nil {
-		return "", types.SiaPublicKey{}, err
+		return HostAnnouncement{}, nil, err
 	}
 	// Verify the signature.
 	var pk crypto.PublicKey
@@ -914,9 +1083,32 @@ func DecodeAnnouncement(fullAnnouncement []byte) (na NetAddress, spk types.SiaPu
 	annHash := crypto.HashObject(ha)
 	err = crypto.VerifyHash(annHash, pk, sig)
 	if err != nil {
-		return "", types.SiaPublicKey{}, err
+		return HostAnnouncement{}, nil, err
 	}
-	return ha.NetAddress, ha.PublicKey, nil
+	if !withExtension || r.Len() == 0 {
+		return ha, nil, nil
+	}
+
+	// Read the extension and verify its signature, which covers everything
+	// up to and including the extension.
+	var hac HostAnnouncementCapabilities
+	err = dec.Decode(&hac)
+	if err != nil {
+		return HostAnnouncement{}, nil, err
+	}
+	if hac.Specifier != PrefixHostCapabilities {
+		return HostAnnouncement{}, nil, ErrAnnUnrecognizedExtension
+	}
+	signed := fullAnnouncement[:len(fullAnnouncement)-r.Len()]
+	err = dec.Decode(&sig)
+	if err != nil {
+		return HostAnnouncement{}, nil, err
+	}
+	err = crypto.VerifyHash(crypto.HashBytes(signed), pk, sig)
+	if err != nil {
+		return HostAnnouncement{}, nil, err
+	}
+	return ha, &hac, nil
 }
 
 // IsOOSErr is a helper function to determine whether an error from a host is
@@ -1079,6 +1271,62 @@ func RPCBeginSubscription(stream siamux.Stream, host types.SiaPublicKey, pt *RPC
 	return nil
 }
 
+// RPCFreeRegistryReadEntry reads a registry entry from a host which offers
+// free registry reads, see HostExternalSettings.FreeRegistryReads. The entry
+// is verified against the public key before it is returned.
+func RPCFreeRegistryReadEntry(stream siamux.Stream, spk types.SiaPublicKey, tweak crypto.Hash) (SignedRegistryValue, bool, error) {
+	buf := bytes.NewBuffer(nil)
+	err := RPCWriteAll(buf, RPCFreeRegistryRead, RPCFreeRegistryReadRequest{
+		PubKey: spk,
+		Tweak:  tweak,
+	})
+	if err != nil {
+		return SignedRegistryValue{}, false, err
+	}
+	_, err = buf.WriteTo(stream)
+	if err != nil {
+		return SignedRegistryValue{}, false, err
+	}
+	var resp RPCFreeRegistryReadResponse
+	err = RPCRead(stream, &resp)
+	if err != nil {
+		return SignedRegistryValue{}, false, err
+	}
+	if !resp.Found {
+		return SignedRegistryValue{}, false, nil
+	}
+	if resp.Entry.Tweak != tweak {
+		return SignedRegistryValue{}, false, errors.New("host returned an entry with the wrong tweak")
+	}
+	if err := resp.Entry.Verify(spk.ToPublicKey()); err != nil {
+		return SignedRegistryValue{}, false, errors.AddContext(err, "host returned an invalid entry")
+	}
+	return resp.Entry, true, nil
+}
+
+// RPCAuditSectorCost returns the cost of auditing a sector with
+// RPCAuditSector, excluding the bandwidth. Hosts need to read the whole sector
+// to prove a segment, so the audit costs as much as reading a full sector.
+func RPCAuditSectorCost(pt *RPCPriceTable) types.Currency {
+	return MDMReadCost(pt, SectorSize)
+}
+
+// VerifyAuditSectorResponse checks that the response to an audit proves the
+// requested segment of the sector with the given root.
+func VerifyAuditSectorResponse(req RPCAuditSectorRequest, resp RPCAuditSectorResponse) error {
+	if req.SegmentIndex >= SectorSize/crypto.SegmentSize {
+		return ErrAuditSegmentOutOfBounds
+	}
+	if len(resp.Segment) != crypto.SegmentSize {
+		return fmt.Errorf("audited segment has length %v, expected %v", len(resp.Segment), crypto.SegmentSize)
+	}
+	start := int(req.SegmentIndex)
+	if !crypto.VerifyRangeProof(resp.Segment, resp.Proof, start, start+1, req.Root) {
+		return errors.New("audit proof verification failed")
+	}
+	return nil
+}
+
 // RPCProvidePayment is a helper function that provides a payment by writing the
 // required payment request objects to the given stream.
 func RPCProvidePayment(stream io.Writer, accID AccountID, accSK crypto.SecretKey, blockHeight types.BlockHeight, amount types.Currency) error {
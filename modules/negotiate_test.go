@@ -86,6 +86,102 @@ func TestAnnouncementHandling(t *testing.T) {
 	}
 }
 
+// TestAnnouncementCapabilities checks that announcements extended with the
+// host's capabilities can be decoded by both decoders and that the extension is
+// verified.
+func TestAnnouncementCapabilities(t *testing.T) {
+	t.Parallel()
+
+	sk, pk := crypto.GenerateKeyPair()
+	spk := types.Ed25519PublicKey(pk)
+	addr := NetAddress("f.o:1234")
+	hc := HostCapabilities{
+		RegistryEntriesLeft: 128,
+		NFTStoragePool:      true,
+		MaxRegistryEntryTTL: types.BlocksPerYear,
+	}
+
+	// Decode an extended announcement.
+	annBytes, err := CreateAnnouncementWithCapabilities(addr, spk, sk, hc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decAddr, decPubKey, decHC, ok, err := DecodeAnnouncementWithCapabilities(annBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decAddr != addr || !decPubKey.Equals(spk) || !ok || decHC != hc {
+		t.Fatal("decoded announcement doesn't match", decAddr, decPubKey, ok, decHC)
+	}
+
+	// Decoders which don't know about the extension ignore it.
+	decAddr, decPubKey, err = DecodeAnnouncement(annBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decAddr != addr || !decPubKey.Equals(spk) {
+		t.Fatal("decoded announcement doesn't match", decAddr, decPubKey)
+	}
+
+	// An announcement without extension has no capabilities.
+	plainBytes, err := CreateAnnouncement(addr, spk, sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, decHC, ok, err = DecodeAnnouncementWithCapabilities(plainBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok || decHC != (HostCapabilities{}) {
+		t.Fatal("plain announcement shouldn't have capabilities", decHC)
+	}
+
+	// Corrupt the extension's capabilities.
+	extIndex := len(plainBytes) + types.SpecifierLen
+	annBytes[extIndex]++
+	_, _, _, _, err = DecodeAnnouncementWithCapabilities(annBytes)
+	if !errors.Contains(err, crypto.ErrInvalidSignature) {
+		t.Error(err)
+	}
+	annBytes[extIndex]--
+
+	// Corrupt the extension's specifier.
+	annBytes[len(plainBytes)]++
+	_, _, _, _, err = DecodeAnnouncementWithCapabilities(annBytes)
+	if !errors.Contains(err, ErrAnnUnrecognizedExtension) {
+		t.Error(err)
+	}
+	annBytes[len(plainBytes)]--
+}
+
+// TestHostCapabilitiesSatisfies is a unit test for HostCapabilities.Satisfies.
+func TestHostCapabilitiesSatisfies(t *testing.T) {
+	t.Parallel()
+	hc := HostCapabilities{
+		RegistryEntriesLeft: 10,
+		MaxRegistryEntryTTL: 100,
+	}
+	tests := []struct {
+		required  HostCapabilities
+		satisfied bool
+	}{
+		{HostCapabilities{}, true},
+		{HostCapabilities{RegistryEntriesLeft: 10, MaxRegistryEntryTTL: 100}, true},
+		{HostCapabilities{RegistryEntriesLeft: 11}, false},
+		{HostCapabilities{MaxRegistryEntryTTL: 101}, false},
+		{HostCapabilities{NFTStoragePool: true}, false},
+	}
+	for i, test := range tests {
+		if hc.Satisfies(test.required) != test.satisfied {
+			t.Errorf("%v: expected %v", i, test.satisfied)
+		}
+	}
+	hc.NFTStoragePool = true
+	if !hc.Satisfies(HostCapabilities{NFTStoragePool: true}) {
+		t.Error("host in the pool should satisfy the requirement")
+	}
+}
+
 // TestNegotiationResponses tests the WriteNegotiationAcceptance,
 // WriteNegotiationRejection, and ReadNegotiationAcceptance functions.
 func TestNegotiationResponses(t *testing.T) {
@@ -159,3 +255,34 @@ func TestRenterPayoutsPreTax(t *testing.T) {
 		t.Fatal("Negative currency returned for host collateral", hostCollateral)
 	}
 }
+
+// TestHostExternalSettingsNFT probes the StoragePriceFor and MaxDurationFor
+// methods.
+func TestHostExternalSettingsNFT(t *testing.T) {
+	hes := HostExternalSettings{
+		MaxDuration:  100,
+		StoragePrice: types.NewCurrency64(10),
+	}
+
+	// Without NFT settings the regular settings apply to NFT data.
+	if !hes.StoragePriceFor(true).Equals(hes.StoragePrice) || hes.MaxDurationFor(true) != hes.MaxDuration {
+		t.Fatal("unset NFT settings should fall back to the regular settings")
+	}
+
+	// With NFT settings they only apply to NFT data.
+	hes.NFTStoragePrice = types.NewCurrency64(5)
+	hes.NFTRetentionPeriod = 200
+	if !hes.StoragePriceFor(true).Equals(hes.NFTStoragePrice) || hes.MaxDurationFor(true) != hes.NFTRetentionPeriod {
+		t.Fatal("NFT settings should apply to NFT data")
+	}
+	if !hes.StoragePriceFor(false).Equals(hes.StoragePrice) || hes.MaxDurationFor(false) != hes.MaxDuration {
+		t.Fatal("NFT settings shouldn't apply to regular data")
+	}
+
+	// A retention period shorter than MaxDuration doesn't shorten the
+	// duration.
+	hes.NFTRetentionPeriod = 50
+	if hes.MaxDurationFor(true) != hes.MaxDuration {
+		t.Fatal("retention period shouldn't be shorter than MaxDuration")
+	}
+}
@@ -45,6 +45,15 @@ const (
 	// its data. The key is used to determine whether an entry is considered a
 	// primary or secondary entry on a host.
 	RegistryTypeWithPubkey
+	// RegistryTypeHostAnnouncement is the type of an entry containing the
+	// NetAddress of a host.
+	RegistryTypeHostAnnouncement
+	// RegistryTypeNFTMetadata is the type of an entry pointing to the metadata
+	// of an NFT. Its data is a RegistryNFTMetadataPointer.
+	RegistryTypeNFTMetadata
+	// RegistryTypeMarketplaceOffer is the type of an entry offering an NFT for
+	// sale. Its data is a RegistryMarketplaceOffer.
+	RegistryTypeMarketplaceOffer
 )
 
 type (
@@ -202,6 +211,10 @@ func (entry SignedRegistryValue) Verify(pk crypto.PublicKey) error {
 		if len(entry.Data) < RegistryPubKeyHashSize {
 			return ErrRegistryEntryDataMalformed
 		}
+	case RegistryTypeHostAnnouncement, RegistryTypeNFTMetadata, RegistryTypeMarketplaceOffer:
+		if err := entry.validateTypedData(); err != nil {
+			return err
+		}
 	default:
 		return ErrUnknownRegistryEntryType
 	}
@@ -0,0 +1,163 @@
+package modules
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/types"
+)
+
+// registrytypes.go contains the structure of the typed registry entries. The
+// generic entries, with or without a pubkey, contain arbitrary data. The data
+// of the other types has a fixed structure which is checked when an entry is
+// verified. That way hosts can apply policies to entries of a certain type and
+// consumers can rely on the structure of the entries they look up.
+
+const (
+	// registryNFTMetadataHeaderSize is the size of the NFT ID and the kind of
+	// the content reference at the beginning of an NFT metadata entry.
+	registryNFTMetadataHeaderSize = crypto.HashSize + 1
+
+	// registryMarketplaceOfferHeaderSize is the size of the NFT ID and the
+	// expiry at the beginning of a marketplace offer entry.
+	registryMarketplaceOfferHeaderSize = crypto.HashSize + 8
+)
+
+var (
+	// ErrRegistryEntryTypeRejected is returned by hosts which don't accept
+	// entries of a certain type.
+	ErrRegistryEntryTypeRejected = errors.New("host doesn't accept entries of this type")
+	// ErrRegistryEntryTypeMismatch is returned if a looked up entry doesn't
+	// have the expected type.
+	ErrRegistryEntryTypeMismatch = errors.New("entry has an unexpected type")
+
+	// registryEntryTypeNames are the names of the registry entry types used by
+	// their text encoding.
+	registryEntryTypeNames = map[RegistryEntryType]string{
+		RegistryTypeInvalid:          "invalid",
+		RegistryTypeWithoutPubkey:    "withoutpubkey",
+		RegistryTypeWithPubkey:       "withpubkey",
+		RegistryTypeHostAnnouncement: "hostannouncement",
+		RegistryTypeNFTMetadata:      "nftmetadata",
+		RegistryTypeMarketplaceOffer: "marketplaceoffer",
+	}
+)
+
+type (
+	// RegistryNFTMetadataPointer is the data of a RegistryTypeNFTMetadata
+	// entry. It points to the metadata of an NFT. The ID of the NFT is
+	// followed by the kind and the binary form of the reference.
+	RegistryNFTMetadataPointer struct {
+		NFT       types.NftID
+		Reference types.NFTContentReference
+	}
+
+	// RegistryMarketplaceOffer is the data of a RegistryTypeMarketplaceOffer
+	// entry. It offers an NFT for a price until the expiry height. The ID of
+	// the NFT is followed by the little-endian expiry and the big-endian
+	// price.
+	RegistryMarketplaceOffer struct {
+		NFT    types.NftID
+		Expiry types.BlockHeight
+		Price  types.Currency
+	}
+)
+
+// String returns the name of the type.
+func (t RegistryEntryType) String() string {
+	if name, ok := registryEntryTypeNames[t]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (t RegistryEntryType) MarshalText() ([]byte, error) {
+	if _, ok := registryEntryTypeNames[t]; !ok {
+		return nil, ErrUnknownRegistryEntryType
+	}
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (t *RegistryEntryType) UnmarshalText(b []byte) error {
+	for typ, name := range registryEntryTypeNames {
+		if name == string(b) && typ != RegistryTypeInvalid {
+			*t = typ
+			return nil
+		}
+	}
+	return errors.AddContext(ErrUnknownRegistryEntryType, string(b))
+}
+
+// Bytes returns the data of the entry containing the pointer.
+func (p RegistryNFTMetadataPointer) Bytes() []byte {
+	return append(append(append([]byte(nil), p.NFT[:]...), byte(p.Reference.Kind)), p.Reference.Bytes()...)
+}
+
+// ParseRegistryNFTMetadataPointer parses the data of a RegistryTypeNFTMetadata
+// entry.
+func ParseRegistryNFTMetadataPointer(data []byte) (RegistryNFTMetadataPointer, error) {
+	if len(data) <= registryNFTMetadataHeaderSize || len(data) > RegistryDataSize {
+		return RegistryNFTMetadataPointer{}, errors.AddContext(ErrRegistryEntryDataMalformed, "invalid nft metadata length")
+	}
+	var p RegistryNFTMetadataPointer
+	copy(p.NFT[:], data)
+	kind := types.NFTContentReferenceKind(data[crypto.HashSize])
+	ref, err := types.DecodeNFTContentReference(kind, data[registryNFTMetadataHeaderSize:])
+	if err != nil {
+		return RegistryNFTMetadataPointer{}, errors.Compose(ErrRegistryEntryDataMalformed, err)
+	}
+	p.Reference = ref
+	return p, nil
+}
+
+// Bytes returns the data of the entry containing the offer.
+func (o RegistryMarketplaceOffer) Bytes() []byte {
+	b := make([]byte, registryMarketplaceOfferHeaderSize)
+	copy(b, o.NFT[:])
+	binary.LittleEndian.PutUint64(b[crypto.HashSize:], uint64(o.Expiry))
+	return append(b, o.Price.Big().Bytes()...)
+}
+
+// ParseRegistryMarketplaceOffer parses the data of a
+// RegistryTypeMarketplaceOffer entry. The price of an offer can't be zero.
+func ParseRegistryMarketplaceOffer(data []byte) (RegistryMarketplaceOffer, error) {
+	if len(data) <= registryMarketplaceOfferHeaderSize || len(data) > RegistryDataSize {
+		return RegistryMarketplaceOffer{}, errors.AddContext(ErrRegistryEntryDataMalformed, "invalid marketplace offer length")
+	}
+	price := data[registryMarketplaceOfferHeaderSize:]
+	if price[0] == 0 {
+		return RegistryMarketplaceOffer{}, errors.AddContext(ErrRegistryEntryDataMalformed, "price isn't minimally encoded")
+	}
+	var o RegistryMarketplaceOffer
+	copy(o.NFT[:], data)
+	o.Expiry = types.BlockHeight(binary.LittleEndian.Uint64(data[crypto.HashSize:]))
+	o.Price = types.NewCurrency(new(big.Int).SetBytes(price))
+	return o, nil
+}
+
+// validateTypedData checks that the data of an entry matches the structure of
+// its type.
+func (entry RegistryValue) validateTypedData() error {
+	var err error
+	switch entry.Type {
+	case RegistryTypeHostAnnouncement:
+		if len(entry.Data) > RegistryDataSize {
+			return errors.AddContext(ErrRegistryEntryDataMalformed, "host announcement is too long")
+		}
+		err = NetAddress(entry.Data).IsStdValid()
+	case RegistryTypeNFTMetadata:
+		_, err = ParseRegistryNFTMetadataPointer(entry.Data)
+	case RegistryTypeMarketplaceOffer:
+		_, err = ParseRegistryMarketplaceOffer(entry.Data)
+	default:
+		return ErrUnknownRegistryEntryType
+	}
+	if err != nil {
+		return errors.Compose(ErrRegistryEntryDataMalformed, err)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package modules
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/types"
+)
+
+// TestTypedRegistryEntries tests verifying entries with a typed structure.
+func TestTypedRegistryEntries(t *testing.T) {
+	t.Parallel()
+
+	sk, pk := crypto.GenerateKeyPair()
+	nft := types.NftID{1, 2, 3}
+	pointer := RegistryNFTMetadataPointer{NFT: nft, Reference: types.NFTRawCID([]byte("metadata"))}
+	offer := RegistryMarketplaceOffer{NFT: nft, Expiry: 1000, Price: types.SiacoinPrecision.Mul64(5)}
+
+	// The structured data round trips.
+	parsedPointer, err := ParseRegistryNFTMetadataPointer(pointer.Bytes())
+	if err != nil || parsedPointer.NFT != nft || !parsedPointer.Reference.Equals(pointer.Reference) {
+		t.Fatal("pointer doesn't round trip", parsedPointer, err)
+	}
+	parsedOffer, err := ParseRegistryMarketplaceOffer(offer.Bytes())
+	if err != nil || parsedOffer.NFT != nft || parsedOffer.Expiry != offer.Expiry || !parsedOffer.Price.Equals(offer.Price) {
+		t.Fatal("offer doesn't round trip", parsedOffer, err)
+	}
+
+	zeroPrice := RegistryMarketplaceOffer{NFT: nft, Expiry: 1000}
+	paddedPrice := append(append([]byte(nil), offer.Bytes()[:registryMarketplaceOfferHeaderSize]...), 0, 1)
+	badReference := append(append([]byte(nil), nft[:]...), byte(types.NFTReferenceCIDv0), 1, 2, 3)
+	tests := []struct {
+		name      string
+		entryType RegistryEntryType
+		data      []byte
+		err       error
+	}{
+		{"host announcement", RegistryTypeHostAnnouncement, []byte("host.sia.tech:9982"), nil},
+		{"nft metadata", RegistryTypeNFTMetadata, pointer.Bytes(), nil},
+		{"marketplace offer", RegistryTypeMarketplaceOffer, offer.Bytes(), nil},
+		{"generic", RegistryTypeWithoutPubkey, []byte("host.sia.tech"), nil},
+		{"bad address", RegistryTypeHostAnnouncement, []byte("host.sia.tech"), ErrRegistryEntryDataMalformed},
+		{"empty announcement", RegistryTypeHostAnnouncement, nil, ErrRegistryEntryDataMalformed},
+		{"missing reference", RegistryTypeNFTMetadata, nft[:], ErrRegistryEntryDataMalformed},
+		{"bad reference", RegistryTypeNFTMetadata, badReference, ErrRegistryEntryDataMalformed},
+		{"zero price", RegistryTypeMarketplaceOffer, zeroPrice.Bytes(), ErrRegistryEntryDataMalformed},
+		{"padded price", RegistryTypeMarketplaceOffer, paddedPrice, ErrRegistryEntryDataMalformed},
+		{"unknown type", RegistryTypeMarketplaceOffer + 1, offer.Bytes(), ErrUnknownRegistryEntryType},
+	}
+	for _, test := range tests {
+		srv := NewRegistryValue(crypto.Hash{}, test.data, 0, test.entryType).Sign(sk)
+		err := srv.Verify(pk)
+		if (test.err == nil && err != nil) || (test.err != nil && !errors.Contains(err, test.err)) {
+			t.Errorf("%v: expected %v but got %v", test.name, test.err, err)
+		}
+	}
+
+	// Changing the type of a signed entry invalidates its signature.
+	srv := NewRegistryValue(crypto.Hash{}, offer.Bytes(), 0, RegistryTypeMarketplaceOffer).Sign(sk)
+	srv.Type = RegistryTypeWithPubkey
+	if err := srv.Verify(pk); !errors.Contains(err, crypto.ErrInvalidSignature) {
+		t.Fatal("expected ErrInvalidSignature but got", err)
+	}
+
+	// The types are encoded by name.
+	b, err := json.Marshal([]RegistryEntryType{RegistryTypeNFTMetadata, RegistryTypeMarketplaceOffer})
+	if err != nil || string(b) != `["nftmetadata","marketplaceoffer"]` {
+		t.Fatal("wrong encoding", string(b), err)
+	}
+	var decoded []RegistryEntryType
+	if err := json.Unmarshal(b, &decoded); err != nil || len(decoded) != 2 || decoded[1] != RegistryTypeMarketplaceOffer {
+		t.Fatal("wrong decoding", decoded, err)
+	}
+	if err := json.Unmarshal([]byte(`["invalid"]`), &decoded); !errors.Contains(err, ErrUnknownRegistryEntryType) {
+		t.Fatal("expected ErrUnknownRegistryEntryType but got", err)
+	}
+}
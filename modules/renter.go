@@ -301,6 +301,69 @@ type Allowance struct {
 	MaxSectorAccessPrice      types.Currency `json:"maxsectoraccessprice"`
 	MaxStoragePrice           types.Currency `json:"maxstorageprice"`
 	MaxUploadBandwidthPrice   types.Currency `json:"maxuploadbandwidthprice"`
+
+	// NFTStorage indicates that the allowance is used to store NFT data. If
+	// set, the contractor and the price estimates use the hosts' NFT storage
+	// prices and retention periods instead of the regular ones.
+	NFTStorage bool `json:"nftstorage"`
+
+	// The following fields restrict the hosts the contractor forms contracts
+	// with to hosts that advertise the required capabilities. A value of 0
+	// or false means that the capability isn't required.
+	//
+	// MinRegistryEntries is the number of free registry entries a host needs
+	// to offer.
+	//
+	// MinRegistryEntryTTL is the number of blocks a host needs to keep
+	// registry entries.
+	//
+	// NFTStoragePool requires hosts to participate in the NFT storage pool.
+	MinRegistryEntries  uint64            `json:"minregistryentries"`
+	MinRegistryEntryTTL types.BlockHeight `json:"minregistryentryttl"`
+	NFTStoragePool      bool              `json:"nftstoragepool"`
+
+	// The following fields allow for tuning how aggressively the contractor
+	// replaces hosts and refills contracts. A value of 0 means that the
+	// contractor's default is used.
+	//
+	// ScoreLeewayGoodForRenew and ScoreLeewayGoodForUpload are the factors by
+	// which a host's score may fall below the score of a freshly selected set
+	// of hosts before the host is marked !GoodForRenew and !GoodForUpload
+	// respectively.
+	//
+	// MinContractFunding is the lowest fraction of the per-host allowance that
+	// a new, renewed or refreshed contract is funded with.
+	//
+	// MinContractFundUploadThreshold and MinContractFundRenewalThreshold are
+	// the fractions of remaining contract funds below which a contract is
+	// marked !GoodForUpload and refreshed respectively.
+	ScoreLeewayGoodForRenew         uint64  `json:"scoreleewaygoodforrenew"`
+	ScoreLeewayGoodForUpload        uint64  `json:"scoreleewaygoodforupload"`
+	MinContractFunding              float64 `json:"mincontractfunding"`
+	MinContractFundUploadThreshold  float64 `json:"mincontractfunduploadthreshold"`
+	MinContractFundRenewalThreshold float64 `json:"mincontractfundrenewalthreshold"`
+
+	// The following fields configure the automated repair of pinned NFTs. A
+	// value of 0 means that the renter's default is used.
+	//
+	// NFTRepairThreshold is the health score below which the renter repairs
+	// the data of a pinned NFT.
+	//
+	// MaxNFTRepairsPerHour is the number of NFT repairs the renter starts at
+	// most within an hour.
+	//
+	// MaxNFTRepairSpending is the amount of the allowance's funds the renter
+	// spends at most on NFT repairs within a period.
+	NFTRepairThreshold   float64        `json:"nftrepairthreshold"`
+	MaxNFTRepairsPerHour uint64         `json:"maxnftrepairsperhour"`
+	MaxNFTRepairSpending types.Currency `json:"maxnftrepairspending"`
+
+	// MaxTxnFeePerByte is the highest transaction fee per byte the contractor
+	// pays to form and renew contracts. While the fee estimate of the
+	// transaction pool is above it, contract maintenance defers formations and
+	// renewals until the fees drop. A value of 0 means that there is no
+	// ceiling.
+	MaxTxnFeePerByte types.Currency `json:"maxtxnfeeperbyte"`
 }
 
 // Active returns true if and only if this allowance has been set in the
@@ -309,6 +372,16 @@ func (a Allowance) Active() bool {
 	return a.Period != 0
 }
 
+// RequiredHostCapabilities returns the capabilities that hosts need to
+// advertise to be used with the allowance.
+func (a Allowance) RequiredHostCapabilities() HostCapabilities {
+	return HostCapabilities{
+		RegistryEntriesLeft: a.MinRegistryEntries,
+		NFTStoragePool:      a.NFTStoragePool,
+		MaxRegistryEntryTTL: a.MinRegistryEntryTTL,
+	}
+}
+
 // ContractUtility contains metrics internal to the contractor that reflect the
 // utility of a given contract.
 type ContractUtility struct {
@@ -541,6 +614,7 @@ type HostScoreBreakdown struct {
 	AgeAdjustment              float64 `json:"ageadjustment"`
 	BasePriceAdjustment        float64 `json:"basepriceadjustment"`
 	BurnAdjustment             float64 `json:"burnadjustment"`
+	CapabilityAdjustment       float64 `json:"capabilityadjustment"`
 	CollateralAdjustment       float64 `json:"collateraladjustment"`
 	DurationAdjustment         float64 `json:"durationadjustment"`
 	InteractionAdjustment      float64 `json:"interactionadjustment"`
@@ -613,11 +687,14 @@ type RenterPriceEstimation struct {
 
 // RenterSettings control the behavior of the Renter.
 type RenterSettings struct {
-	Allowance        Allowance     `json:"allowance"`
-	IPViolationCheck bool          `json:"ipviolationcheck"`
-	MaxUploadSpeed   int64         `json:"maxuploadspeed"`
-	MaxDownloadSpeed int64         `json:"maxdownloadspeed"`
-	UploadsStatus    UploadsStatus `json:"uploadsstatus"`
+	Allowance        Allowance      `json:"allowance"`
+	IPViolationCheck bool           `json:"ipviolationcheck"`
+	MaxUploadSpeed   int64          `json:"maxuploadspeed"`
+	MaxDownloadSpeed int64          `json:"maxdownloadspeed"`
+	NFTPreviews      bool           `json:"nftpreviews"`
+	SectorCacheSize  uint64         `json:"sectorcachesize"`
+	UploadSchedule   UploadSchedule `json:"uploadschedule"`
+	UploadsStatus    UploadsStatus  `json:"uploadsstatus"`
 }
 
 // UploadsStatus contains information about the Renter's Uploads
@@ -829,6 +906,71 @@ type ContractorSpending struct {
 	PreviousSpending types.Currency `json:"previousspending"`
 }
 
+// SpendingCategories splits the money spent within contracts into its
+// categories. Fees contains the contract and transaction fees and SiafundTax
+// the siafund fee of the contracts.
+type SpendingCategories struct {
+	Storage     types.Currency `json:"storage"`
+	Upload      types.Currency `json:"upload"`
+	Download    types.Currency `json:"download"`
+	FundAccount types.Currency `json:"fundaccount"`
+	Maintenance types.Currency `json:"maintenance"`
+	Fees        types.Currency `json:"fees"`
+	SiafundTax  types.Currency `json:"siafundtax"`
+}
+
+// HostSpending is the money spent within the contracts with a host during the
+// current period.
+type HostSpending struct {
+	HostPublicKey types.SiaPublicKey `json:"hostpublickey"`
+	Contracts     uint64             `json:"contracts"`
+	SpendingCategories
+}
+
+// ContractorSpendingBreakdown splits the money spent during the current period
+// by category and by host. It covers the same contracts as the period spending
+// reported by ContractorSpending: the current contracts and the old contracts
+// which started in the current period. Hosts are sorted by their total
+// spending, starting with the largest.
+type ContractorSpendingBreakdown struct {
+	Period types.BlockHeight  `json:"period"`
+	Total  SpendingCategories `json:"total"`
+	Hosts  []HostSpending     `json:"hosts"`
+}
+
+// Add returns the sum of both spendings.
+func (x SpendingCategories) Add(y SpendingCategories) SpendingCategories {
+	return SpendingCategories{
+		Storage:     x.Storage.Add(y.Storage),
+		Upload:      x.Upload.Add(y.Upload),
+		Download:    x.Download.Add(y.Download),
+		FundAccount: x.FundAccount.Add(y.FundAccount),
+		Maintenance: x.Maintenance.Add(y.Maintenance),
+		Fees:        x.Fees.Add(y.Fees),
+		SiafundTax:  x.SiafundTax.Add(y.SiafundTax),
+	}
+}
+
+// Sum returns the total spending over all categories.
+func (x SpendingCategories) Sum() types.Currency {
+	return x.Storage.Add(x.Upload).Add(x.Download).Add(x.FundAccount).
+		Add(x.Maintenance).Add(x.Fees).Add(x.SiafundTax)
+}
+
+// ContractSpendingCategories returns the spending of a contract split into
+// its categories.
+func ContractSpendingCategories(c RenterContract) SpendingCategories {
+	return SpendingCategories{
+		Storage:     c.StorageSpending,
+		Upload:      c.UploadSpending,
+		Download:    c.DownloadSpending,
+		FundAccount: c.FundAccountSpending,
+		Maintenance: c.MaintenanceSpending.Sum(),
+		Fees:        c.ContractFee.Add(c.TxnFee),
+		SiafundTax:  c.SiafundFee,
+	}
+}
+
 // SpendingBreakdown provides a breakdown of a few fields in the Contractor
 // Spending
 func (cs ContractorSpending) SpendingBreakdown() (totalSpent, unspentAllocated, unspentUnallocated types.Currency) {
@@ -855,6 +997,139 @@ type ContractorChurnStatus struct {
 	MaxPeriodChurn uint64 `json:"maxperiodchurn"`
 }
 
+// ContractRenewalLink describes a single contract within the renewal chain of
+// a contract.
+type ContractRenewalLink struct {
+	ID          types.FileContractID `json:"id"`
+	StartHeight types.BlockHeight    `json:"startheight"`
+	EndHeight   types.BlockHeight    `json:"endheight"`
+
+	// Active indicates whether the contract is still part of the contractor's
+	// active contract set or whether it was moved to the old contracts.
+	Active bool `json:"active"`
+
+	// TotalCost is the amount of money the renter locked up in the contract
+	// and Spending is the amount of money spent on fees, storage, bandwidth,
+	// account funding and maintenance within the contract.
+	TotalCost types.Currency `json:"totalcost"`
+	Spending  types.Currency `json:"spending"`
+}
+
+// ContractReceipt itemizes the costs of forming or renewing a contract. The
+// Funding the renter put into the contract pays for the host's ContractPrice,
+// the SiafundFee, the TxnFee and the StorageCost of the data a renewal carries
+// over. The rest are the RenterFunds which can be spent within the contract.
+// HostCollateral is the amount of money the host locked up in the contract.
+//
+// RenewedFrom is the ID of the renewed contract, or empty if the contract was
+// newly formed.
+type ContractReceipt struct {
+	ContractID    types.FileContractID `json:"contractid"`
+	RenewedFrom   types.FileContractID `json:"renewedfrom"`
+	HostPublicKey types.SiaPublicKey   `json:"hostpublickey"`
+	StartHeight   types.BlockHeight    `json:"startheight"`
+	EndHeight     types.BlockHeight    `json:"endheight"`
+
+	Funding        types.Currency `json:"funding"`
+	ContractPrice  types.Currency `json:"contractprice"`
+	SiafundFee     types.Currency `json:"siafundfee"`
+	TxnFee         types.Currency `json:"txnfee"`
+	StorageCost    types.Currency `json:"storagecost"`
+	RenterFunds    types.Currency `json:"renterfunds"`
+	HostCollateral types.Currency `json:"hostcollateral"`
+}
+
+// ContractorNotificationType identifies the problem a ContractorNotification
+// is about.
+type ContractorNotificationType string
+
+const (
+	// ContractorNotificationRenewalFailures is sent when a contract failed to
+	// renew a number of times in a row.
+	ContractorNotificationRenewalFailures ContractorNotificationType = "renewalfailures"
+	// ContractorNotificationLowAllowance is sent when the funds remaining in
+	// the allowance drop below a fraction of the allowance.
+	ContractorNotificationLowAllowance ContractorNotificationType = "lowallowance"
+	// ContractorNotificationContractLocked is sent when a contract is locked
+	// after too many failed renewals and won't be used anymore.
+	ContractorNotificationContractLocked ContractorNotificationType = "contractlocked"
+)
+
+// ContractorNotification describes a problem of the contractor which an
+// operator should know about before it degrades the redundancy of the renter's
+// files. The contract fields are empty for notifications about the allowance
+// and the allowance fields are empty for notifications about contracts.
+type ContractorNotification struct {
+	Type        ContractorNotificationType `json:"type"`
+	BlockHeight types.BlockHeight          `json:"blockheight"`
+	Message     string                     `json:"message"`
+
+	ContractID          types.FileContractID `json:"contractid"`
+	HostPublicKey       types.SiaPublicKey   `json:"hostpublickey"`
+	ConsecutiveFailures types.BlockHeight    `json:"consecutivefailures"`
+
+	AllowanceFunds types.Currency `json:"allowancefunds"`
+	FundsRemaining types.Currency `json:"fundsremaining"`
+}
+
+// A ContractorNotifier is notified about problems of the contractor.
+type ContractorNotifier interface {
+	Notify(ContractorNotification) error
+}
+
+// ContractorNotifierFunc is a function which implements the ContractorNotifier
+// interface.
+type ContractorNotifierFunc func(ContractorNotification) error
+
+// Notify implements the ContractorNotifier interface.
+func (fn ContractorNotifierFunc) Notify(n ContractorNotification) error {
+	return fn(n)
+}
+
+// ContractStatus is the status of a contract within the contractor's current
+// contract set.
+type ContractStatus string
+
+const (
+	// ContractStatusActive contracts are good for uploads and renewals.
+	ContractStatusActive ContractStatus = "active"
+	// ContractStatusPassive contracts are only good for renewals.
+	ContractStatusPassive ContractStatus = "passive"
+	// ContractStatusRefreshed contracts were renewed within the same period
+	// because they ran out of funds.
+	ContractStatusRefreshed ContractStatus = "refreshed"
+	// ContractStatusDisabled contracts are neither good for uploads nor for
+	// renewals.
+	ContractStatusDisabled ContractStatus = "disabled"
+)
+
+// ContractSortField is the field a contract query sorts contracts by.
+type ContractSortField string
+
+const (
+	// ContractSortID sorts contracts by their ID. It is the default order,
+	// which keeps pages stable between queries.
+	ContractSortID ContractSortField = "id"
+	// ContractSortSpending sorts contracts by the money spent within them,
+	// see ContractRenewalLink.
+	ContractSortSpending ContractSortField = "spending"
+	// ContractSortEndHeight sorts contracts by their end height.
+	ContractSortEndHeight ContractSortField = "endheight"
+)
+
+// ContractQuery filters, sorts and paginates the contractor's current
+// contracts. Zero values don't filter: an empty Statuses matches every status,
+// a zero Host every host and a zero Limit returns all contracts after Offset.
+// Contracts with the same sort key are ordered by their ID.
+type ContractQuery struct {
+	Statuses   []ContractStatus
+	Host       types.SiaPublicKey
+	SortBy     ContractSortField
+	Descending bool
+	Offset     uint64
+	Limit      uint64
+}
+
 // UploadedBackup contains metadata about an uploaded backup.
 type UploadedBackup struct {
 	Name           string
@@ -864,6 +1139,116 @@ type UploadedBackup struct {
 	UploadProgress float64
 }
 
+// NFTPin is an NFT whose data the renter keeps available on the network. The
+// data is uploaded from Source to SiaPath and repaired from Source for as long
+// as the NFT is pinned. Reuploads counts how often the siafile had to be
+// uploaded again because it was lost.
+type NFTPin struct {
+	Root      crypto.Hash     `json:"root"`
+	SiaPath   SiaPath         `json:"siapath"`
+	Source    string          `json:"source"`
+	PinTime   types.Timestamp `json:"pintime"`
+	Reuploads uint64          `json:"reuploads"`
+}
+
+// NFTPreviewSizes are the sizes of the previews the renter generates for
+// pinned image NFTs if NFTPreviews is enabled. A preview is downscaled so that
+// its longer side is at most the size in pixels.
+var NFTPreviewSizes = []int{256, 1024}
+
+// NFTPinInfo contains the health of the data of a pinned NFT.
+type NFTPinInfo struct {
+	NFTPin
+	Health         float64 `json:"health"`
+	HealthPercent  float64 `json:"healthpercent"`
+	Recoverable    bool    `json:"recoverable"`
+	Redundancy     float64 `json:"redundancy"`
+	Stuck          bool    `json:"stuck"`
+	UploadProgress float64 `json:"uploadprogress"`
+}
+
+// NFTHealth is a report on how safe the data of a pinned NFT is. It combines
+// the piece availability of the NFT's siafile with the status of the contracts
+// and storage proofs of the hosts storing the pieces.
+//
+// Score ranges from 0, the data is lost, to 1, every chunk has all of its
+// pieces on good hosts. It is the lowest fraction of redundant pieces of any
+// chunk that are stored on good hosts. Reasons explain what needs attention
+// and what can be done about it.
+type NFTHealth struct {
+	Root    crypto.Hash `json:"root"`
+	SiaPath SiaPath     `json:"siapath"`
+	Score   float64     `json:"score"`
+	Reasons []string    `json:"reasons"`
+
+	// Piece availability of the siafile. MinGoodPieces is the lowest number
+	// of pieces of any chunk that are stored on good hosts.
+	Health        float64 `json:"health"`
+	Recoverable   bool    `json:"recoverable"`
+	Redundancy    float64 `json:"redundancy"`
+	Stuck         bool    `json:"stuck"`
+	Chunks        uint64  `json:"chunks"`
+	MinPieces     int     `json:"minpieces"`
+	NumPieces     int     `json:"numpieces"`
+	MinGoodPieces int     `json:"mingoodpieces"`
+
+	// SourceAvailable indicates that the local copy of the data which is
+	// used for repairs is unchanged.
+	SourceAvailable bool `json:"sourceavailable"`
+
+	Hosts []NFTHostHealth `json:"hosts"`
+}
+
+// NFTHostHealth contains the status of a host storing pieces of an NFT's data.
+// LastProofHeight is the height of the latest storage proof the host submitted
+// for any of the renter's contracts with it.
+type NFTHostHealth struct {
+	HostPublicKey     types.SiaPublicKey `json:"hostpublickey"`
+	Pieces            uint64             `json:"pieces"`
+	HasContract       bool               `json:"hascontract"`
+	GoodForRenew      bool               `json:"goodforrenew"`
+	GoodForUpload     bool               `json:"goodforupload"`
+	Offline           bool               `json:"offline"`
+	ContractEndHeight types.BlockHeight  `json:"contractendheight"`
+	LastProofHeight   types.BlockHeight  `json:"lastproofheight"`
+	MissedProofs      uint64             `json:"missedproofs"`
+}
+
+// NFTBundleVersion is the version of the NFTBundle format.
+const NFTBundleVersion = "1.0"
+
+// NFTBundle is an export of the metadata of the siafiles storing the data of
+// an NFT collection. It allows a different renter to download and repair the
+// data without uploading it again, which is used to migrate a collection
+// between renters or to hand it over to a buyer. The bundle contains the
+// encryption keys of the files, so anyone holding it can read the data.
+type NFTBundle struct {
+	Version    string          `json:"version"`
+	Collection string          `json:"collection"`
+	Files      []NFTBundleFile `json:"files"`
+}
+
+// NFTBundleFile is a siafile within an NFTBundle. SiaFile is the encoded
+// siafile, which contains the erasure coding parameters, the master key and
+// the hosts storing every piece. Hosts are the hosts storing pieces of the
+// file.
+type NFTBundleFile struct {
+	SiaPath SiaPath              `json:"siapath"`
+	Hosts   []types.SiaPublicKey `json:"hosts"`
+	SiaFile []byte               `json:"siafile"`
+}
+
+// NFTBundleImport is the result of importing an NFTBundle. MissingHosts are
+// the hosts storing pieces of the imported files which the renter has no
+// contract with. The pieces on these hosts can't be downloaded until the
+// renter forms contracts with them, until then the files are repaired onto the
+// renter's own hosts.
+type NFTBundleImport struct {
+	Collection   string               `json:"collection"`
+	SiaPaths     []SiaPath            `json:"siapaths"`
+	MissingHosts []types.SiaPublicKey `json:"missinghosts"`
+}
+
 type (
 	// WorkerPoolStatus contains information about the status of the workerPool
 	// and the workers
@@ -1022,10 +1407,18 @@ type Renter interface {
 	// watchdog, and a bool indicating whether or not the watchdog is aware of it.
 	ContractStatus(fcID types.FileContractID) (ContractWatchStatus, bool)
 
+	// ContractReceipts returns the receipts of the contracts the renter formed
+	// and renewed.
+	ContractReceipts() []ContractReceipt
+
 	// CreateBackup creates a backup of the renter's siafiles. If a secret is not
 	// nil, the backup will be encrypted using the provided secret.
 	CreateBackup(dst string, secret []byte) error
 
+	// RegisterContractorNotifier registers a notifier which is notified about
+	// renewal failures, locked contracts and a low allowance.
+	RegisterContractorNotifier(n ContractorNotifier)
+
 	// LoadBackup loads the siafiles of a previously created backup into the
 	// renter. If the backup is encrypted, secret will be used to decrypt it.
 	// Otherwise the argument is ignored.
@@ -1039,6 +1432,11 @@ type Renter interface {
 	// contracts within a separate thread.
 	InitRecoveryScan() error
 
+	// InitFullRecoveryScan starts scanning the whole blockchain for contracts
+	// derivable from the wallet seed, including contracts without a contract
+	// identifier, within a separate thread.
+	InitFullRecoveryScan() error
+
 	// OldContracts returns the oldContracts of the renter's hostContractor.
 	OldContracts() []RenterContract
 
@@ -1069,6 +1467,10 @@ type Renter interface {
 	// billing period.
 	PeriodSpending() (ContractorSpending, error)
 
+	// PeriodSpendingBreakdown returns the money spent on contracts in the
+	// current billing period split by category and by host.
+	PeriodSpendingBreakdown() (ContractorSpendingBreakdown, error)
+
 	// RecoverableContracts returns the contracts that the contractor deems
 	// recoverable. That means they are not expired yet and also not part of the
 	// active contracts. Usually this should return an empty slice unless the host
@@ -1082,6 +1484,15 @@ type Renter interface {
 	// RefreshedContract checks if the contract was previously refreshed
 	RefreshedContract(fcid types.FileContractID) bool
 
+	// RenewalChain returns the renewal chain of the contract with the given
+	// id, ordered from the oldest to the newest contract.
+	RenewalChain(fcid types.FileContractID) ([]ContractRenewalLink, error)
+
+	// QueryContracts returns the page of the contractor's current contracts
+	// selected by the query, together with the number of contracts matching
+	// the query's filters.
+	QueryContracts(q ContractQuery) ([]RenterContract, uint64, error)
+
 	// SetFileStuck sets the 'stuck' status of a file.
 	SetFileStuck(siaPath SiaPath, stuck bool) error
 
@@ -1099,6 +1510,35 @@ type Renter interface {
 	// BackupsOnHost returns the backups stored on the specified host.
 	BackupsOnHost(hostKey types.SiaPublicKey) ([]UploadedBackup, error)
 
+	// PinNFT uploads the data of the NFT with the given merkle root from
+	// source and keeps it available until the NFT is unpinned.
+	PinNFT(root crypto.Hash, source string) error
+
+	// UnpinNFT stops pinning the data of an NFT and deletes its siafile.
+	UnpinNFT(root crypto.Hash) error
+
+	// NFTPin returns the pin of an NFT.
+	NFTPin(root crypto.Hash) (NFTPin, error)
+
+	// NFTPreview returns the preview of a pinned image NFT with one of the
+	// NFTPreviewSizes.
+	NFTPreview(root crypto.Hash, size int) ([]byte, error)
+
+	// NFTPins returns the pinned NFTs together with the health of their
+	// data.
+	NFTPins() ([]NFTPinInfo, error)
+
+	// NFTHealth returns a report on how safe the data of a pinned NFT is.
+	NFTHealth(root crypto.Hash) (NFTHealth, error)
+
+	// ExportNFTBundle exports the metadata of the siafiles at the given
+	// siapaths, which store the data of an NFT collection.
+	ExportNFTBundle(collection string, siaPaths []SiaPath) (NFTBundle, error)
+
+	// ImportNFTBundle adds the siafiles of a bundle to the renter below the
+	// dst folder and binds them to the renter's contracts.
+	ImportNFTBundle(bundle NFTBundle, dst SiaPath) (NFTBundleImport, error)
+
 	// DeleteFile deletes a file entry from the renter.
 	DeleteFile(siaPath SiaPath) error
 
@@ -1168,6 +1608,11 @@ type Renter interface {
 	// used.
 	ReadRegistry(spk types.SiaPublicKey, tweak crypto.Hash, timeout time.Duration) (SignedRegistryValue, error)
 
+	// ReadRegistryOfType looks up a registry entry like ReadRegistry but
+	// fails with ErrRegistryEntryTypeMismatch if the most recent entry isn't
+	// of the expected type.
+	ReadRegistryOfType(spk types.SiaPublicKey, tweak crypto.Hash, entryType RegistryEntryType, timeout time.Duration) (SignedRegistryValue, error)
+
 	// ScoreBreakdown will return the score for a host db entry using the
 	// hostdb's weighting algorithm.
 	ScoreBreakdown(entry HostDBEntry) (HostScoreBreakdown, error)
@@ -1362,6 +1807,10 @@ type HostDB interface {
 	// hostdb.
 	SetIPViolationCheck(enabled bool) error
 
+	// SetPriorityHosts sets the hosts which are scanned more frequently than
+	// the other hosts to detect their downtime quickly.
+	SetPriorityHosts([]types.SiaPublicKey) error
+
 	// UpdateContracts rebuilds the knownContracts of the HostBD using the provided
 	// contracts.
 	UpdateContracts([]RenterContract) error
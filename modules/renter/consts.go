@@ -153,6 +153,21 @@ const (
 	// DefaultMaxUploadSpeed is set to zero to indicate no limit, the user
 	// can set a custom MaxUploadSpeed through the API
 	DefaultMaxUploadSpeed = 0
+
+	// merkleRootCacheSize is the number of merkle roots of pieces and pinned
+	// NFT data cached by the renter. Every entry takes up less than 200 bytes.
+	merkleRootCacheSize = 1 << 14
+)
+
+var (
+	// DefaultSectorCacheSize is the size of the cache for data read from
+	// hosts of a new renter. The user can set a custom SectorCacheSize through
+	// the API, a size of zero disables the cache.
+	DefaultSectorCacheSize = build.Select(build.Var{
+		Dev:      uint64(1 << 24), // 16 MiB
+		Standard: uint64(1 << 28), // 256 MiB
+		Testing:  uint64(1 << 20), // 1 MiB
+	}).(uint64)
 )
 
 // Naming conventions for code readability.
@@ -315,6 +330,36 @@ var (
 		Testing:  3 * time.Second,
 	}).(time.Duration)
 
+	// defaultNFTRepairThreshold is the health score below which the data of a
+	// pinned NFT is repaired if the allowance doesn't specify a threshold. It
+	// matches the RepairThreshold of the repair loop.
+	defaultNFTRepairThreshold = 1 - modules.RepairThreshold
+
+	// defaultMaxNFTRepairsPerHour is the number of NFT repairs started at most
+	// within an hour if the allowance doesn't specify a limit.
+	defaultMaxNFTRepairsPerHour = uint64(10)
+
+	// defaultNFTRepairSpendingFraction is the fraction of the allowance's
+	// funds spent at most on NFT repairs within a period if the allowance
+	// doesn't specify a cap.
+	defaultNFTRepairSpendingFraction = 0.1
+
+	// nftPinCheckInterval defines how long the renter sleeps between checking
+	// the health of the data of pinned NFTs.
+	nftPinCheckInterval = build.Select(build.Var{
+		Dev:      10 * time.Second,
+		Standard: 10 * time.Minute,
+		Testing:  2 * time.Second,
+	}).(time.Duration)
+
+	// uploadScheduleCheckInterval defines how long the renter sleeps between
+	// checking whether the upload schedule allows uploads.
+	uploadScheduleCheckInterval = build.Select(build.Var{
+		Dev:      10 * time.Second,
+		Standard: time.Minute,
+		Testing:  time.Second,
+	}).(time.Duration)
+
 	// snapshotSyncSleepDuration defines how long the renter sleeps between
 	// trying to synchronize snapshots across hosts.
 	snapshotSyncSleepDuration = build.Select(build.Var{
@@ -38,8 +38,111 @@ var (
 	// ErrAllowanceZeroMaxPeriodChurn is returned if the allowance max period
 	// churn is being set to zero when not cancelling the allowance
 	ErrAllowanceZeroMaxPeriodChurn = errors.New("max period churn must be non-zero")
+	// ErrAllowanceInvalidScoreLeeway is returned if the allowance's score
+	// leeway for renewing is smaller than the one for uploading. Hosts need to
+	// be marked !GoodForUpload before they are marked !GoodForRenew.
+	ErrAllowanceInvalidScoreLeeway = errors.New("score leeway for renew must not be smaller than score leeway for upload")
+	// ErrAllowanceInvalidMinContractFunding is returned if the allowance's
+	// minimum contract funding is not within [0, 1].
+	ErrAllowanceInvalidMinContractFunding = errors.New("min contract funding must be between 0 and 1")
+	// ErrAllowanceInvalidFundThreshold is returned if one of the allowance's
+	// contract fund thresholds is not within [0, 1].
+	ErrAllowanceInvalidFundThreshold = errors.New("min contract fund thresholds must be between 0 and 1")
+	// ErrAllowanceInvalidRenewalThreshold is returned if the allowance's
+	// renewal threshold is below its upload threshold. Contracts need to be
+	// refreshed before uploading to them stops.
+	ErrAllowanceInvalidRenewalThreshold = errors.New("min contract fund renewal threshold must not be smaller than the upload threshold")
+	// ErrAllowanceInvalidNFTRepairThreshold is returned if the allowance's
+	// NFT repair threshold is not within [0, 1].
+	ErrAllowanceInvalidNFTRepairThreshold = errors.New("nft repair threshold must be between 0 and 1")
+	// ErrAllowanceInvalidNFTRepairSpending is returned if the allowance's max
+	// NFT repair spending exceeds its funds.
+	ErrAllowanceInvalidNFTRepairSpending = errors.New("max nft repair spending must not exceed the allowance's funds")
 )
 
+// allowanceScoreLeeways returns the score leeways for GoodForRenew and
+// GoodForUpload of the allowance, falling back to the defaults for unset
+// values.
+func allowanceScoreLeeways(a modules.Allowance) (gfr, gfu types.Currency) {
+	leewayGFR, leewayGFU := a.ScoreLeewayGoodForRenew, a.ScoreLeewayGoodForUpload
+	if leewayGFR == 0 {
+		leewayGFR = scoreLeewayGoodForRenew
+	}
+	if leewayGFU == 0 {
+		leewayGFU = scoreLeewayGoodForUpload
+	}
+	return types.NewCurrency64(leewayGFR), types.NewCurrency64(leewayGFU)
+}
+
+// allowanceMinContractFunding returns the minimum amount of money a contract
+// formed with the allowance should be funded with.
+func allowanceMinContractFunding(a modules.Allowance) types.Currency {
+	minFunding := a.MinContractFunding
+	if minFunding == 0 {
+		minFunding = fileContractMinimumFunding
+	}
+	return a.Funds.MulFloat(minFunding).Div64(a.Hosts)
+}
+
+// allowanceFundThresholds returns the upload and renewal thresholds for the
+// remaining funds of a contract, falling back to the defaults for unset
+// values.
+func allowanceFundThresholds(a modules.Allowance) (upload, renewal float64) {
+	upload, renewal = a.MinContractFundUploadThreshold, a.MinContractFundRenewalThreshold
+	if upload == 0 {
+		upload = MinContractFundUploadThreshold
+	}
+	if renewal == 0 {
+		renewal = MinContractFundRenewalThreshold
+	}
+	return upload, renewal
+}
+
+// txnFeesAboveCeiling returns true if the fee per byte is above the allowance's
+// MaxTxnFeePerByte.
+func txnFeesAboveCeiling(a modules.Allowance, feePerByte types.Currency) bool {
+	return !a.MaxTxnFeePerByte.IsZero() && feePerByte.Cmp(a.MaxTxnFeePerByte) > 0
+}
+
+// renewalDeferrable returns true if the renewal of a contract can be deferred
+// because of high transaction fees. Contracts are renewed regardless of the
+// fees once they enter the second half of the renew window to avoid losing the
+// data stored with them.
+func renewalDeferrable(a modules.Allowance, blockHeight, endHeight types.BlockHeight) bool {
+	return blockHeight+a.RenewWindow/2 < endHeight
+}
+
+// validFraction returns true if f is within [0, 1].
+func validFraction(f float64) bool {
+	return f >= 0 && f <= 1
+}
+
+// validateAllowanceTuning checks the optional fields of an allowance which
+// tune the contractor's behavior.
+func validateAllowanceTuning(a modules.Allowance) error {
+	if !validFraction(a.MinContractFunding) {
+		return ErrAllowanceInvalidMinContractFunding
+	}
+	if !validFraction(a.MinContractFundUploadThreshold) || !validFraction(a.MinContractFundRenewalThreshold) {
+		return ErrAllowanceInvalidFundThreshold
+	}
+	leewayGFR, leewayGFU := allowanceScoreLeeways(a)
+	if leewayGFR.Cmp(leewayGFU) < 0 {
+		return ErrAllowanceInvalidScoreLeeway
+	}
+	upload, renewal := allowanceFundThresholds(a)
+	if renewal < upload {
+		return ErrAllowanceInvalidRenewalThreshold
+	}
+	if !validFraction(a.NFTRepairThreshold) {
+		return ErrAllowanceInvalidNFTRepairThreshold
+	}
+	if a.MaxNFTRepairSpending.Cmp(a.Funds) > 0 {
+		return ErrAllowanceInvalidNFTRepairSpending
+	}
+	return nil
+}
+
 // SetAllowance sets the amount of money the Contractor is allowed to spend on
 // contracts over a given time period, divided among the number of hosts
 // specified. Note that Contractor can start forming contracts as soon as
@@ -84,6 +187,8 @@ func (c *Contractor) SetAllowance(a modules.Allowance) error {
 		return ErrAllowanceZeroExpectedRedundancy
 	} else if a.MaxPeriodChurn == 0 {
 		return ErrAllowanceZeroMaxPeriodChurn
+	} else if err := validateAllowanceTuning(a); err != nil {
+		return err
 	} else if !c.cs.Synced() {
 		return errAllowanceNotSynced
 	}
@@ -224,8 +224,8 @@ func (c *Contractor) managedMarkContractUtility(contract modules.RenterContract,
 	}
 	defer c.staticContracts.Return(sc)
 
-	// Get latest metadata.
-	u := sc.Metadata().Utility
+	// Get latest utility.
+	u := sc.Utility()
 
 	// If the utility is locked, do nothing.
 	if u.Locked {
@@ -312,7 +312,7 @@ func (c *Contractor) managedMarkContractsUtility() error {
 	suggestedUpdateQueue := make([]contractScoreAndUtil, 0)
 
 	// Update utility fields for each contract.
-	for _, contract := range c.staticContracts.ViewAll() {
+	err = c.staticContracts.ForEach(func(contract modules.RenterContract) error {
 		sb, utility, update, err := c.managedMarkContractUtility(contract, minScoreGFR, minScoreGFU)
 		if err != nil {
 			return err
@@ -320,6 +320,10 @@ func (c *Contractor) managedMarkContractsUtility() error {
 		if update {
 			suggestedUpdateQueue = append(suggestedUpdateQueue, contractScoreAndUtil{contract, sb.Score, utility})
 		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 	// Process the suggested updates through the churn limiter.
 	err = c.staticChurnLimiter.managedProcessSuggestedUpdates(suggestedUpdateQueue)
@@ -20,6 +20,11 @@ var (
 	// AlertMSGFailedContractRenewal indicates that the contract renewal failed
 	AlertMSGFailedContractRenewal = "Contractor is attempting to renew/refresh contracts but failed"
 
+	// AlertMSGTxnFeesAboveCeiling indicates that contract maintenance defers
+	// forming/renewing contracts until the transaction fees drop below the
+	// allowance's ceiling.
+	AlertMSGTxnFeesAboveCeiling = "Contract formations/renewals are deferred because the transaction fees are above the allowance's ceiling"
+
 	// AlertMSGWalletLockedDuringMaintenance indicates that forming/renewing a
 	// contract during contract maintenance isn't possible due to a locked wallet.
 	AlertMSGWalletLockedDuringMaintenance = "At least one contract failed to form/renew due to the wallet being locked"
@@ -51,12 +56,28 @@ var (
 		Testing:  types.BlockHeight(12),
 	}).(types.BlockHeight)
 
+	// renewalFailuresBeforeNotification is the number of times in a row a
+	// contract has to fail to renew before the notifiers are notified.
+	renewalFailuresBeforeNotification = build.Select(build.Var{
+		Dev:      types.BlockHeight(3),
+		Standard: types.BlockHeight(3),
+		Testing:  types.BlockHeight(3),
+	}).(types.BlockHeight)
+
+	// lowAllowanceNotificationThreshold is the fraction of the allowance's
+	// funds below which the remaining funds cause a low allowance
+	// notification.
+	lowAllowanceNotificationThreshold = float64(0.1) // 10%
+
 	// fileContractMinimumFunding is the lowest percentage of an allowace (on a
 	// per-contract basis) that is allowed to go into funding a contract. If the
 	// allowance is 100 SC per contract (5,000 SC total for 50 contracts, or
 	// 2,000 SC total for 20 contracts, etc.), then the minimum amount of funds
 	// that a contract would be allowed to have is fileContractMinimumFunding *
 	// 100SC.
+	//
+	// This is the default which is used if the allowance doesn't specify a
+	// MinContractFunding.
 	fileContractMinimumFunding = float64(0.15)
 
 	// MinContractFundRenewalThreshold defines the ratio of remaining funds to
@@ -66,6 +87,9 @@ var (
 	// This number is deliberately a little higher than the
 	// minContractFundUploadThreshold because we want to make sure that renewals
 	// will kick in before uploading stops.
+	//
+	// This is the default which is used if the allowance doesn't specify a
+	// MinContractFundRenewalThreshold.
 	MinContractFundRenewalThreshold = float64(0.06) // 6%
 
 	// minContractFundUploadThreshold is the percentage of contract funds
@@ -76,6 +100,9 @@ var (
 	// things this way essentially allows the user to experience the failure
 	// mode of 'can't store additional stuff' before the user experiences the
 	// failure mode of 'can't retrieve stuff already uploaded'.
+	//
+	// This is the default which is used if the allowance doesn't specify a
+	// MinContractFundUploadThreshold.
 	MinContractFundUploadThreshold = float64(0.05) // 5%
 
 	// randomHostsBufferForScore defines how many extra hosts are queried when trying
@@ -119,8 +146,9 @@ var (
 	// to have before being marked as !GoodForRenew.
 	//
 	// TODO: At this point in time, this value is somewhat arbitrary and could
-	// be getting set in a lot more scientific way.
-	scoreLeewayGoodForRenew = types.NewCurrency64(500)
+	// be getting set in a lot more scientific way. The value can be overwritten
+	// by setting ScoreLeewayGoodForRenew in the allowance.
+	scoreLeewayGoodForRenew = uint64(500)
 
 	// scoreLeewayGoodForUpload defines the factor by which a host can miss the
 	// goal score for a set of hosts and still be GoodForUpload. To determine the
@@ -135,6 +163,7 @@ var (
 	// using a bad host without incurring data churn.
 	//
 	// TODO: At this point in time, this value is somewhat arbitrary and could
-	// be getting set in a lot more scientific way.
-	scoreLeewayGoodForUpload = types.NewCurrency64(40)
+	// be getting set in a lot more scientific way. The value can be overwritten
+	// by setting ScoreLeewayGoodForUpload in the allowance.
+	scoreLeewayGoodForUpload = uint64(40)
 )
@@ -32,6 +32,10 @@ var (
 	ErrInsufficientAllowance = errors.New("allowance is not large enough to cover fees of contract creation")
 	errTooExpensive          = errors.New("host price was too high")
 
+	// errHostLacksCapabilities is returned when forming a contract with a host
+	// that doesn't advertise the capabilities required by the allowance.
+	errHostLacksCapabilities = errors.New("host lacks the capabilities required by the allowance")
+
 	// errContractEnded is the error returned when the contract has already ended
 	errContractEnded = errors.New("contract has already ended")
 
@@ -72,67 +76,77 @@ func (c *Contractor) callNotifyDoubleSpend(fcID types.FileContractID, blockHeigh
 }
 
 // managedCheckForDuplicates checks for static contracts that have the same host
-// key and moves the older one to old contracts.
-func (c *Contractor) managedCheckForDuplicates() {
-	// Build map for comparison.
-	pubkeys := make(map[string]types.FileContractID)
-	var newContract, oldContract modules.RenterContract
-	for _, contract := range c.staticContracts.ViewAll() {
-		id, exists := pubkeys[contract.HostPublicKey.String()]
-		if !exists {
-			pubkeys[contract.HostPublicKey.String()] = contract.ID
+// key and moves the older ones to old contracts. It takes a snapshot of the
+// active contracts and returns a snapshot of the contracts which remain
+// active.
+func (c *Contractor) managedCheckForDuplicates(snapshot proto.ContractSnapshot) proto.ContractSnapshot {
+	// Group the contracts by host.
+	hostContracts := make(map[string][]modules.RenterContract)
+	for _, contract := range snapshot.Contracts() {
+		pk := contract.HostPublicKey.String()
+		hostContracts[pk] = append(hostContracts[pk], contract)
+	}
+
+	var duplicates []*proto.SafeContract
+	archived := make(map[types.FileContractID]struct{})
+	for _, contracts := range hostContracts {
+		if len(contracts) < 2 {
 			continue
 		}
-
-		// Duplicate contract found, determine older contract to delete.
-		if rc, ok := c.staticContracts.View(id); ok {
-			if rc.StartHeight >= contract.StartHeight {
-				newContract, oldContract = rc, contract
-			} else {
-				newContract, oldContract = contract, rc
-			}
+		// Sort the duplicates by their start height. That way the contracts
+		// are linked in the order they were renewed in. If in reality the
+		// renewal order was A<->B<->C, linking every contract only to the
+		// newest one would result in A->C and B<->C.
+		sort.SliceStable(contracts, func(i, j int) bool {
+			return contracts[i].StartHeight < contracts[j].StartHeight
+		})
+		for i, oldContract := range contracts[:len(contracts)-1] {
+			newContract := contracts[i+1]
 			c.log.Printf("Duplicate contract found. New contract is %x and old contract is %v", newContract.ID, oldContract.ID)
 
 			// Get SafeContract
 			oldSC, ok := c.staticContracts.Acquire(oldContract.ID)
 			if !ok {
-				// Update map
-				pubkeys[contract.HostPublicKey.String()] = newContract.ID
 				continue
 			}
 
 			// Link the contracts to each other and then store the old contract
 			// in the record of historic contracts.
-			//
-			// Note: This means that if there are multiple duplicates, say 3
-			// contracts that all share the same host, then the ordering may not
-			// be perfect. If in reality the renewal order was A<->B<->C, it's
-			// possible for the contractor to end up with A->C and B<->C in the
-			// mapping.
 			c.mu.Lock()
 			c.renewedFrom[newContract.ID] = oldContract.ID
 			c.renewedTo[oldContract.ID] = newContract.ID
 			c.oldContracts[oldContract.ID] = oldSC.Metadata()
-
-			// Save the contractor and delete the contract.
-			//
-			// TODO: Ideally these two things would happen atomically, but I'm
-			// not completely certain that's feasible with our current
-			// architecture.
-			//
-			// TODO: This should revert the in memory state in the event of an
-			// error and continue
-			err := c.save()
-			if err != nil {
-				c.log.Println("Failed to save the contractor after updating renewed maps.")
-			}
 			c.mu.Unlock()
-			c.staticContracts.Delete(oldSC)
-
-			// Update the pubkeys map to contain the newest contract id.
-			pubkeys[contract.HostPublicKey.String()] = newContract.ID
+			duplicates = append(duplicates, oldSC)
+			archived[oldContract.ID] = struct{}{}
 		}
 	}
+	if len(duplicates) == 0 {
+		return snapshot
+	}
+
+	// Save the contractor once for all duplicates and delete the contracts.
+	//
+	// TODO: Ideally these two things would happen atomically, but I'm not
+	// completely certain that's feasible with our current architecture.
+	//
+	// TODO: This should revert the in memory state in the event of an error
+	// and continue
+	c.mu.Lock()
+	err := c.save()
+	c.mu.Unlock()
+	if err != nil {
+		c.log.Println("Failed to save the contractor after updating renewed maps.")
+	}
+	for _, sc := range duplicates {
+		c.staticContracts.Delete(sc)
+	}
+
+	// Filter the archived contracts out of the snapshot.
+	return snapshot.Filter(func(contract modules.RenterContract) bool {
+		_, exists := archived[contract.ID]
+		return !exists
+	})
 }
 
 // managedEstimateRenewFundingRequirements estimates the amount of money that a
@@ -155,7 +169,7 @@ func (c *Contractor) managedEstimateRenewFundingRequirements(contract modules.Re
 	// Estimate the amount of money that's going to be needed for existing
 	// storage.
 	dataStored := contract.Transaction.FileContractRevisions[0].NewFileSize
-	storageCost := types.NewCurrency64(dataStored).Mul64(uint64(allowance.Period)).Mul(host.StoragePrice)
+	storageCost := types.NewCurrency64(dataStored).Mul64(uint64(allowance.Period)).Mul(host.StoragePriceFor(allowance.NFTStorage))
 
 	// For the spending estimates, we're going to need to know the amount of
 	// money that was spent on upload and download by this contract line in this
@@ -217,7 +231,7 @@ func (c *Contractor) managedEstimateRenewFundingRequirements(contract modules.Re
 	}
 	// The estimated cost for new upload spending is the previous upload
 	// bandwidth plus the implied storage cost for all of the new data.
-	newUploadsCost := prevUploadSpending.Add(prevUploadDataEstimate.Mul64(uint64(allowance.Period)).Mul(host.StoragePrice))
+	newUploadsCost := prevUploadSpending.Add(prevUploadDataEstimate.Mul64(uint64(allowance.Period)).Mul(host.StoragePriceFor(allowance.NFTStorage)))
 
 	// The download cost is assumed to be the same. Even if the user is
 	// uploading more data, the expectation is that the download amounts will be
@@ -251,9 +265,9 @@ func (c *Contractor) managedEstimateRenewFundingRequirements(contract modules.Re
 	estimatedCost = estimatedCost.Add(estimatedCost.Div64(3))
 
 	// Check for a sane minimum. The contractor should not be forming contracts
-	// with less than 'fileContractMinimumFunding / (num contracts)' of the
-	// value of the allowance.
-	minimum := allowance.Funds.MulFloat(fileContractMinimumFunding).Div64(allowance.Hosts)
+	// with less than 'MinContractFunding / (num contracts)' of the value of
+	// the allowance.
+	minimum := allowanceMinContractFunding(allowance)
 	if estimatedCost.Cmp(minimum) < 0 {
 		estimatedCost = minimum
 	}
@@ -295,6 +309,7 @@ func (c *Contractor) managedFindMinAllowedHostScores() (types.Currency, types.Cu
 	// worthwhile.
 	c.mu.RLock()
 	hostCount := int(c.allowance.Hosts)
+	leewayGFR, leewayGFU := allowanceScoreLeeways(c.allowance)
 	c.mu.RUnlock()
 	hosts, err := c.hdb.RandomHosts(hostCount+randomHostsBufferForScore, nil, nil)
 	if err != nil {
@@ -324,8 +339,8 @@ func (c *Contractor) managedFindMinAllowedHostScores() (types.Currency, types.Cu
 		}
 	}
 	// Set the minimum acceptable score to a factor of the lowest score.
-	minScoreGFR = lowestScore.Div(scoreLeewayGoodForRenew)
-	minScoreGFU = lowestScore.Div(scoreLeewayGoodForUpload)
+	minScoreGFR = lowestScore.Div(leewayGFR)
+	minScoreGFU = lowestScore.Div(leewayGFU)
 
 	// Set min score to the max score seen times 2.
 	if c.staticDeps.Disrupt("HighMinHostScore") {
@@ -346,26 +361,33 @@ func (c *Contractor) managedFindMinAllowedHostScores() (types.Currency, types.Cu
 }
 
 // managedNewContract negotiates an initial file contract with the specified
-// host, saves it, and returns it.
-func (c *Contractor) managedNewContract(host modules.HostDBEntry, contractFunding types.Currency, endHeight types.BlockHeight) (_ types.Currency, _ modules.RenterContract, err error) {
-	// reject hosts that are too expensive
-	if host.StoragePrice.Cmp(maxStoragePrice) > 0 {
-		return types.ZeroCurrency, modules.RenterContract{}, errTooExpensive
-	}
+// host, saves it, and returns it together with its receipt. The receipt is
+// also returned if setting up the contract fails after it was formed since the
+// funds were spent anyway.
+func (c *Contractor) managedNewContract(host modules.HostDBEntry, contractFunding types.Currency, endHeight types.BlockHeight) (_ modules.ContractReceipt, _ modules.RenterContract, err error) {
 	// Determine if host settings align with allowance period
 	c.mu.Lock()
 	if reflect.DeepEqual(c.allowance, modules.Allowance{}) {
 		c.mu.Unlock()
-		return types.ZeroCurrency, modules.RenterContract{}, errors.New("called managedNewContract but allowance wasn't set")
+		return modules.ContractReceipt{}, modules.RenterContract{}, errors.New("called managedNewContract but allowance wasn't set")
 	}
 	allowance := c.allowance
 	hostSettings := host.HostExternalSettings
 	period := c.allowance.Period
 	c.mu.Unlock()
 
-	if host.MaxDuration < period {
+	// reject hosts that are too expensive. Allowances for NFT storage use the
+	// host's NFT pricing and retention period.
+	if host.StoragePriceFor(allowance.NFTStorage).Cmp(maxStoragePrice) > 0 {
+		return modules.ContractReceipt{}, modules.RenterContract{}, errTooExpensive
+	}
+	if host.MaxDurationFor(allowance.NFTStorage) < period {
 		err := errors.New("unable to form contract with host due to insufficient MaxDuration of host")
-		return types.ZeroCurrency, modules.RenterContract{}, err
+		return modules.ContractReceipt{}, modules.RenterContract{}, err
+	}
+	// reject hosts that lack the capabilities required by the allowance.
+	if !host.HostCapabilities.Satisfies(allowance.RequiredHostCapabilities()) {
+		return modules.ContractReceipt{}, modules.RenterContract{}, errHostLacksCapabilities
 	}
 	// cap host.MaxCollateral
 	if host.MaxCollateral.Cmp(maxCollateral) > 0 {
@@ -375,13 +397,13 @@ func (c *Contractor) managedNewContract(host modules.HostDBEntry, contractFundin
 	// Check for price gouging.
 	err = checkFormContractGouging(allowance, hostSettings)
 	if err != nil {
-		return types.ZeroCurrency, modules.RenterContract{}, errors.AddContext(err, "unable to form a contract due to price gouging detection")
+		return modules.ContractReceipt{}, modules.RenterContract{}, errors.AddContext(err, "unable to form a contract due to price gouging detection")
 	}
 
 	// get an address to use for negotiation
 	uc, err := c.wallet.NextAddress()
 	if err != nil {
-		return types.ZeroCurrency, modules.RenterContract{}, err
+		return modules.ContractReceipt{}, modules.RenterContract{}, err
 	}
 	defer func() {
 		if err != nil {
@@ -392,7 +414,7 @@ func (c *Contractor) managedNewContract(host modules.HostDBEntry, contractFundin
 	// get the wallet seed.
 	seed, _, err := c.wallet.PrimarySeed()
 	if err != nil {
-		return types.ZeroCurrency, modules.RenterContract{}, err
+		return modules.ContractReceipt{}, modules.RenterContract{}, err
 	}
 	// derive the renter seed and wipe it once we are done with it.
 	renterSeed := modules.DeriveRenterSeed(seed)
@@ -417,14 +439,16 @@ func (c *Contractor) managedNewContract(host modules.HostDBEntry, contractFundin
 	// create transaction builder and trigger contract formation.
 	txnBuilder, err := c.wallet.StartTransaction()
 	if err != nil {
-		return types.ZeroCurrency, modules.RenterContract{}, err
+		return modules.ContractReceipt{}, modules.RenterContract{}, err
 	}
 
-	contract, formationTxnSet, sweepTxn, sweepParents, err := c.staticContracts.FormContract(params, txnBuilder, c.tpool, c.hdb, c.tg.StopChan())
+	contract, formationTxnSet, sweepTxn, sweepParents, err := c.staticContracts.FormContract(c.tg.StopCtx(), params, txnBuilder, c.tpool, c.hdb)
 	if err != nil {
 		txnBuilder.Drop()
-		return types.ZeroCurrency, modules.RenterContract{}, err
+		return modules.ContractReceipt{}, modules.RenterContract{}, err
 	}
+	receipt := newContractReceipt(contract, types.FileContractID{})
+	c.managedRecordContractReceipt(receipt)
 
 	monitorContractArgs := monitorContractArgs{
 		false,
@@ -437,7 +461,7 @@ func (c *Contractor) managedNewContract(host modules.HostDBEntry, contractFundin
 	}
 	err = c.staticWatchdog.callMonitorContract(monitorContractArgs)
 	if err != nil {
-		return types.ZeroCurrency, modules.RenterContract{}, err
+		return receipt, modules.RenterContract{}, err
 	}
 
 	// Add a mapping from the contract's id to the public key of the host.
@@ -446,10 +470,10 @@ func (c *Contractor) managedNewContract(host modules.HostDBEntry, contractFundin
 	if exists {
 		c.mu.Unlock()
 		txnBuilder.Drop()
-		// We need to return a funding value because money was spent on this
+		// We need to return the receipt because money was spent on this
 		// host, even though the full process could not be completed.
 		c.log.Println("WARN: Attempted to form a new contract with a host that we already have a contrat with.")
-		return contractFunding, modules.RenterContract{}, fmt.Errorf("We already have a contract with host %v", contract.HostPublicKey)
+		return receipt, modules.RenterContract{}, fmt.Errorf("We already have a contract with host %v", contract.HostPublicKey)
 	}
 	c.pubKeysToContractID[contract.HostPublicKey.String()] = contract.ID
 	c.mu.Unlock()
@@ -462,27 +486,22 @@ func (c *Contractor) managedNewContract(host modules.HostDBEntry, contractFundin
 	if err != nil {
 		c.log.Println("Unable to update hostdb contracts:", err)
 	}
-	return contractFunding, contract, nil
+	return receipt, contract, nil
 }
 
 // managedPrunedRedundantAddressRange uses the hostdb to find hosts that
 // violate the rules about address ranges and cancels them.
-func (c *Contractor) managedPrunedRedundantAddressRange() {
+func (c *Contractor) managedPrunedRedundantAddressRange(snapshot proto.ContractSnapshot) {
 	// Get all contracts which are not canceled.
-	allContracts := c.staticContracts.ViewAll()
-	var contracts []modules.RenterContract
-	for _, contract := range allContracts {
-		if contract.Utility.Locked && !contract.Utility.GoodForRenew && !contract.Utility.GoodForUpload {
-			// contract is canceled
-			continue
-		}
-		contracts = append(contracts, contract)
-	}
+	contracts := snapshot.Filter(func(contract modules.RenterContract) bool {
+		canceled := contract.Utility.Locked && !contract.Utility.GoodForRenew && !contract.Utility.GoodForUpload
+		return !canceled
+	})
 
 	// Get all the public keys and map them to contract ids.
-	pks := make([]types.SiaPublicKey, 0, len(allContracts))
+	pks := make([]types.SiaPublicKey, 0, contracts.Len())
 	cids := make(map[string]types.FileContractID)
-	for _, contract := range contracts {
+	for _, contract := range contracts.Contracts() {
 		pks = append(pks, contract.HostPublicKey)
 		cids[contract.HostPublicKey.String()] = contract.ID
 	}
@@ -502,8 +521,9 @@ func (c *Contractor) managedPrunedRedundantAddressRange() {
 }
 
 // managedLimitGFUHosts caps the number of GFU hosts for non-portals to
-// allowance.Hosts.
-func (c *Contractor) managedLimitGFUHosts() {
+// allowance.Hosts. It takes a snapshot of the contracts taken after their
+// utility was updated.
+func (c *Contractor) managedLimitGFUHosts(snapshot proto.ContractSnapshot) {
 	c.mu.Lock()
 	wantedHosts := c.allowance.Hosts
 	c.mu.Unlock()
@@ -513,7 +533,7 @@ func (c *Contractor) managedLimitGFUHosts() {
 		score types.Currency
 	}
 	var gfuContracts []gfuContract
-	for _, contract := range c.Contracts() {
+	for _, contract := range snapshot.Contracts() {
 		if !contract.Utility.GoodForUpload {
 			continue
 		}
@@ -591,13 +611,15 @@ func checkFormContractGouging(allowance modules.Allowance, hostSettings modules.
 }
 
 // managedRenew negotiates a new contract for data already stored with a host.
-// It returns the new contract. This is a blocking call that performs network
+// It returns the new contract together with its receipt. Like with
+// managedNewContract, the receipt is also returned if setting up the contract
+// fails after it was renewed. This is a blocking call that performs network
 // I/O.
-func (c *Contractor) managedRenew(id types.FileContractID, hpk types.SiaPublicKey, contractFunding types.Currency, newEndHeight types.BlockHeight, hostSettings modules.HostExternalSettings) (_ modules.RenterContract, err error) {
+func (c *Contractor) managedRenew(id types.FileContractID, hpk types.SiaPublicKey, contractFunding types.Currency, newEndHeight types.BlockHeight, hostSettings modules.HostExternalSettings) (_ modules.ContractReceipt, _ modules.RenterContract, err error) {
 	// Fetch the host associated with this contract.
 	host, ok, err := c.hdb.Host(hpk)
 	if err != nil {
-		return modules.RenterContract{}, errors.AddContext(err, "error getting host from hostdb:")
+		return modules.ContractReceipt{}, modules.RenterContract{}, errors.AddContext(err, "error getting host from hostdb:")
 	}
 	// Use the most recent hostSettings, along with the host db entry.
 	host.HostExternalSettings = hostSettings
@@ -617,19 +639,20 @@ func (c *Contractor) managedRenew(id types.FileContractID, hpk types.SiaPublicKe
 	c.mu.Lock()
 	if reflect.DeepEqual(c.allowance, modules.Allowance{}) {
 		c.mu.Unlock()
-		return modules.RenterContract{}, errors.New("called managedRenew but allowance isn't set")
+		return modules.ContractReceipt{}, modules.RenterContract{}, errors.New("called managedRenew but allowance isn't set")
 	}
 	period := c.allowance.Period
+	nft := c.allowance.NFTStorage
 	c.mu.Unlock()
 
 	if !ok {
-		return modules.RenterContract{}, errHostNotFound
+		return modules.ContractReceipt{}, modules.RenterContract{}, errHostNotFound
 	} else if host.Filtered {
-		return modules.RenterContract{}, errHostBlocked
-	} else if host.StoragePrice.Cmp(maxStoragePrice) > 0 {
-		return modules.RenterContract{}, errTooExpensive
-	} else if host.MaxDuration < period {
-		return modules.RenterContract{}, errors.New("insufficient MaxDuration of host")
+		return modules.ContractReceipt{}, modules.RenterContract{}, errHostBlocked
+	} else if host.StoragePriceFor(nft).Cmp(maxStoragePrice) > 0 {
+		return modules.ContractReceipt{}, modules.RenterContract{}, errTooExpensive
+	} else if host.MaxDurationFor(nft) < period {
+		return modules.ContractReceipt{}, modules.RenterContract{}, errors.New("insufficient MaxDuration of host")
 	}
 
 	// cap host.MaxCollateral
@@ -640,13 +663,13 @@ func (c *Contractor) managedRenew(id types.FileContractID, hpk types.SiaPublicKe
 	// Check for price gouging on the renewal.
 	err = checkFormContractGouging(c.allowance, host.HostExternalSettings)
 	if err != nil {
-		return modules.RenterContract{}, errors.AddContext(err, "unable to renew - price gouging protection enabled")
+		return modules.ContractReceipt{}, modules.RenterContract{}, errors.AddContext(err, "unable to renew - price gouging protection enabled")
 	}
 
 	// get an address to use for negotiation
 	uc, err := c.wallet.NextAddress()
 	if err != nil {
-		return modules.RenterContract{}, err
+		return modules.ContractReceipt{}, modules.RenterContract{}, err
 	}
 	defer func() {
 		if err != nil {
@@ -657,7 +680,7 @@ func (c *Contractor) managedRenew(id types.FileContractID, hpk types.SiaPublicKe
 	// get the wallet seed
 	seed, _, err := c.wallet.PrimarySeed()
 	if err != nil {
-		return modules.RenterContract{}, err
+		return modules.ContractReceipt{}, modules.RenterContract{}, err
 	}
 	// derive the renter seed and wipe it after we are done with it.
 	renterSeed := modules.DeriveRenterSeed(seed)
@@ -682,12 +705,12 @@ func (c *Contractor) managedRenew(id types.FileContractID, hpk types.SiaPublicKe
 	// create a transaction builder with the correct amount of funding for the renewal.
 	txnBuilder, err := c.wallet.StartTransaction()
 	if err != nil {
-		return modules.RenterContract{}, err
+		return modules.ContractReceipt{}, modules.RenterContract{}, err
 	}
 	err = txnBuilder.FundSiacoins(params.Funding)
 	if err != nil {
 		txnBuilder.Drop() // return unused outputs to wallet
-		return modules.RenterContract{}, err
+		return modules.ContractReceipt{}, modules.RenterContract{}, err
 	}
 	// Add an output that sends all fund back to the refundAddress.
 	// Note that in order to send this transaction, a miner fee will have to be subtracted.
@@ -703,27 +726,29 @@ func (c *Contractor) managedRenew(id types.FileContractID, hpk types.SiaPublicKe
 		// Acquire the SafeContract.
 		oldContract, ok := c.staticContracts.Acquire(id)
 		if !ok {
-			return modules.RenterContract{}, errContractNotFound
+			return modules.ContractReceipt{}, modules.RenterContract{}, errContractNotFound
 		}
 		if !oldContract.Utility().GoodForRenew {
-			return modules.RenterContract{}, errContractNotGFR
+			return modules.ContractReceipt{}, modules.RenterContract{}, errContractNotGFR
 		}
 		// RHP2 renewal.
-		newContract, formationTxnSet, err = c.staticContracts.Renew(oldContract, params, txnBuilder, c.tpool, c.hdb, c.tg.StopChan())
+		newContract, formationTxnSet, err = c.staticContracts.Renew(c.tg.StopCtx(), oldContract, params, txnBuilder, c.tpool, c.hdb)
 		c.staticContracts.Return(oldContract)
 	} else {
 		var w modules.Worker
 		w, err = c.workerPool.Worker(hpk)
 		if err != nil {
 			txnBuilder.Drop() // return unused outputs to wallet
-			return modules.RenterContract{}, err
+			return modules.ContractReceipt{}, modules.RenterContract{}, err
 		}
 		newContract, formationTxnSet, err = w.RenewContract(c.tg.StopCtx(), id, params, txnBuilder)
 	}
 	if err != nil {
 		txnBuilder.Drop() // return unused outputs to wallet
-		return modules.RenterContract{}, err
+		return modules.ContractReceipt{}, modules.RenterContract{}, err
 	}
+	receipt := newContractReceipt(newContract, id)
+	c.managedRecordContractReceipt(receipt)
 
 	monitorContractArgs := monitorContractArgs{
 		false,
@@ -736,7 +761,7 @@ func (c *Contractor) managedRenew(id types.FileContractID, hpk types.SiaPublicKe
 	}
 	err = c.staticWatchdog.callMonitorContract(monitorContractArgs)
 	if err != nil {
-		return modules.RenterContract{}, err
+		return receipt, modules.RenterContract{}, err
 	}
 
 	// Add a mapping from the contract's id to the public key of the host. This
@@ -752,11 +777,12 @@ func (c *Contractor) managedRenew(id types.FileContractID, hpk types.SiaPublicKe
 		c.log.Println("Unable to update hostdb contracts:", err)
 	}
 
-	return newContract, nil
+	return receipt, newContract, nil
 }
 
 // managedRenewContract will use the renew instructions to renew a contract,
 // returning the amount of money that was put into the contract for renewal.
+// The amount is only 0 if no contract was negotiated with the host.
 func (c *Contractor) managedRenewContract(renewInstructions fileContractRenewal, currentPeriod types.BlockHeight, allowance modules.Allowance, blockHeight, endHeight types.BlockHeight) (fundsSpent types.Currency, err error) {
 	if c.staticDeps.Disrupt("ContractRenewFail") {
 		err = errors.New("Renew failure due to dependency")
@@ -822,11 +848,12 @@ func (c *Contractor) managedRenewContract(renewInstructions fileContractRenewal,
 	// row and reached its second half of the renew window, we give up
 	// on renewing it and set goodForRenew to false.
 	c.log.Debugln("calling managedRenew on contract", id)
-	newContract, errRenew := c.managedRenew(id, hostPubKey, amount, endHeight, hostSettings)
+	receipt, newContract, errRenew := c.managedRenew(id, hostPubKey, amount, endHeight, hostSettings)
+	fundsSpent = receipt.Funding
 	c.log.Debugln("managedRenew has returned with error:", errRenew)
 	oldContract, exists := c.staticContracts.Acquire(id)
 	if !exists {
-		return types.ZeroCurrency, errors.AddContext(errContractNotFound, "failed to acquire oldContract after renewal")
+		return fundsSpent, errors.AddContext(errContractNotFound, "failed to acquire oldContract after renewal")
 	}
 	oldUtility := oldContract.Utility()
 	if errRenew != nil {
@@ -838,6 +865,7 @@ func (c *Contractor) managedRenewContract(renewInstructions fileContractRenewal,
 			totalFailures := c.numFailedRenews[oldContract.Metadata().ID]
 			c.mu.Unlock()
 			c.log.Debugln("remote host determined to be at fault, tallying up failed renews", totalFailures, id)
+			c.managedNotifyRenewalFailures(oldContract.Metadata(), totalFailures, errRenew)
 		}
 
 		// Check if contract has to be replaced.
@@ -857,8 +885,9 @@ func (c *Contractor) managedRenewContract(renewInstructions fileContractRenewal,
 			}
 			c.log.Printf("WARN: consistently failed to renew %v, marked as bad and locked: %v\n",
 				oldContract.Metadata().HostPublicKey, errRenew)
+			c.managedNotifyContractLocked(md, numRenews, errRenew)
 			c.staticContracts.Return(oldContract)
-			return types.ZeroCurrency, errors.AddContext(errRenew, "contract marked as bad for too many consecutive failed renew attempts")
+			return fundsSpent, errors.AddContext(errRenew, "contract marked as bad for too many consecutive failed renew attempts")
 		}
 
 		// Seems like it doesn't have to be replaced yet. Log the
@@ -866,7 +895,7 @@ func (c *Contractor) managedRenewContract(renewInstructions fileContractRenewal,
 		c.log.Printf("WARN: failed to renew contract %v [%v]: '%v', current height: %v, proposed end height: %v, max duration: %v",
 			oldContract.Metadata().HostPublicKey, numRenews, errRenew, blockHeight, endHeight, hostSettings.MaxDuration)
 		c.staticContracts.Return(oldContract)
-		return types.ZeroCurrency, errors.AddContext(errRenew, "contract renewal with host was unsuccessful")
+		return fundsSpent, errors.AddContext(errRenew, "contract renewal with host was unsuccessful")
 	}
 	c.log.Printf("Renewed contract %v\n", id)
 
@@ -879,7 +908,7 @@ func (c *Contractor) managedRenewContract(renewInstructions fileContractRenewal,
 		if ok {
 			c.staticContracts.Delete(newSC)
 		}
-		return amount, nil
+		return fundsSpent, nil
 	}
 
 	// Update the utility values for the new contract, and for the old
@@ -891,7 +920,7 @@ func (c *Contractor) managedRenewContract(renewInstructions fileContractRenewal,
 	if err := c.managedAcquireAndUpdateContractUtility(newContract.ID, newUtility); err != nil {
 		c.log.Println("Failed to update the contract utilities", err)
 		c.staticContracts.Return(oldContract)
-		return amount, nil // Error is not returned because the renew succeeded.
+		return fundsSpent, nil // Error is not returned because the renew succeeded.
 	}
 	oldUtility.GoodForRenew = false
 	oldUtility.GoodForUpload = false
@@ -899,12 +928,12 @@ func (c *Contractor) managedRenewContract(renewInstructions fileContractRenewal,
 	if err := c.callUpdateUtility(oldContract, oldUtility, true); err != nil {
 		c.log.Println("Failed to update the contract utilities", err)
 		c.staticContracts.Return(oldContract)
-		return amount, nil // Error is not returned because the renew succeeded.
+		return fundsSpent, nil // Error is not returned because the renew succeeded.
 	}
 
 	if c.staticDeps.Disrupt("InterruptContractSaveToDiskAfterDeletion") {
 		c.staticContracts.Return(oldContract)
-		return amount, errors.New("InterruptContractSaveToDiskAfterDeletion disrupt")
+		return fundsSpent, errors.New("InterruptContractSaveToDiskAfterDeletion disrupt")
 	}
 	// Lock the contractor as we update it to use the new contract
 	// instead of the old contract.
@@ -926,7 +955,7 @@ func (c *Contractor) managedRenewContract(renewInstructions fileContractRenewal,
 	// Signal to the watchdog that it should immediately post the last
 	// revision for this contract.
 	go c.staticWatchdog.threadedSendMostRecentRevision(oldContract.Metadata())
-	return amount, nil
+	return fundsSpent, nil
 }
 
 // managedFindRecoverableContracts will spawn a thread to rescan parts of the
@@ -939,7 +968,7 @@ func (c *Contractor) managedFindRecoverableContracts() {
 	c.mu.RLock()
 	cc := c.recentRecoveryChange
 	c.mu.RUnlock()
-	if err := c.callInitRecoveryScan(cc); err != nil {
+	if err := c.callInitRecoveryScan(cc, false); err != nil {
 		c.log.Debug(err)
 		return
 	}
@@ -963,7 +992,7 @@ func (c *Contractor) managedUpdateContractUtility(safeContract *proto.SafeContra
 	// Sanity check to verify that we aren't attempting to set a good utility on
 	// a contract that has been renewed.
 	c.mu.Lock()
-	_, exists := c.renewedTo[safeContract.Metadata().ID]
+	_, exists := c.renewedTo[safeContract.LastRevision().ID()]
 	c.mu.Unlock()
 	if exists && (utility.GoodForRenew || utility.GoodForUpload) {
 		c.log.Critical("attempting to update contract utility on a contract that has been renewed")
@@ -978,11 +1007,16 @@ func (c *Contractor) managedUpdateContractUtility(safeContract *proto.SafeContra
 // contractor. Pass in renewed as true if the contract has been renewed and is
 // not churn.
 func (c *Contractor) callUpdateUtility(safeContract *proto.SafeContract, newUtility modules.ContractUtility, renewed bool) error {
-	contract := safeContract.Metadata()
+	// Nothing to do if the utility didn't change. This avoids syncing the
+	// header of every contract to disk during maintenance.
+	oldUtility := safeContract.Utility()
+	if oldUtility == newUtility {
+		return nil
+	}
 
 	// If the contract is going from GFR to !GFR, notify the churn limiter.
-	if !renewed && contract.Utility.GoodForRenew && !newUtility.GoodForRenew {
-		c.staticChurnLimiter.callNotifyChurnedContract(contract)
+	if !renewed && oldUtility.GoodForRenew && !newUtility.GoodForRenew {
+		c.staticChurnLimiter.callNotifyChurnedContract(safeContract.Metadata())
 	}
 
 	return safeContract.UpdateUtility(newUtility)
@@ -1022,6 +1056,7 @@ func (c *Contractor) threadedContractMaintenance() {
 		return
 	}
 	defer c.maintenanceLock.Unlock()
+	maintenanceRunsMetric.Inc()
 
 	// Register the WalletLockedDuringMaintenance alert if necessary.
 	var registerWalletLockedDuringMaintenance bool
@@ -1038,21 +1073,29 @@ func (c *Contractor) threadedContractMaintenance() {
 	// happen before the rest of the maintenance.
 	c.managedFindRecoverableContracts()
 	c.callRecoverContracts()
-	c.managedArchiveContracts()
-	c.managedCheckForDuplicates()
-	c.managedUpdatePubKeyToContractIDMap()
-	c.managedPrunedRedundantAddressRange()
+
+	// The snapshot of the contract set is passed along instead of fetching it
+	// again in every step since copying a large contract set is expensive.
+	// It only needs to be refreshed when the utilities of the contracts
+	// change.
+	snapshot := c.managedArchiveContracts()
+	snapshot = c.managedCheckForDuplicates(snapshot)
+	c.mu.Lock()
+	c.updatePubKeyToContractIDMap(snapshot.Contracts())
+	c.mu.Unlock()
+	c.managedPrunedRedundantAddressRange(snapshot)
 	err = c.managedMarkContractsUtility()
 	if err != nil {
 		c.log.Debugln("Unable to mark contract utilities:", err)
 		return
 	}
-	err = c.hdb.UpdateContracts(c.staticContracts.ViewAll())
+	snapshot = c.staticContracts.ViewSnapshot()
+	err = c.hdb.UpdateContracts(snapshot.Contracts())
 	if err != nil {
 		c.log.Println("Unable to update hostdb contracts:", err)
 		return
 	}
-	c.managedLimitGFUHosts()
+	c.managedLimitGFUHosts(snapshot)
 
 	// If there are no hosts requested by the allowance, there is no remaining
 	// work.
@@ -1073,6 +1116,20 @@ func (c *Contractor) threadedContractMaintenance() {
 	currentPeriod := c.currentPeriod
 	endHeight := c.contractEndHeight()
 	c.mu.Unlock()
+	_, renewalThreshold := allowanceFundThresholds(allowance)
+
+	// Defer formations and renewals while the transaction fees are above the
+	// allowance's ceiling. Maintenance runs again on every block, so they are
+	// retried once the fees drop.
+	_, maxFee := c.tpool.FeeEstimation()
+	feesAboveCeiling := txnFeesAboveCeiling(allowance, maxFee)
+	if feesAboveCeiling {
+		cause := fmt.Sprintf("transaction fee estimate of %v/byte is above the ceiling of %v/byte", maxFee.HumanString(), allowance.MaxTxnFeePerByte.HumanString())
+		c.staticAlerter.RegisterAlert(modules.AlertIDRenterTxnFeesAboveCeiling, AlertMSGTxnFeesAboveCeiling, cause, modules.SeverityWarning)
+		c.log.Println("Deferring contract formations and renewals:", cause)
+	} else {
+		c.staticAlerter.UnregisterAlert(modules.AlertIDRenterTxnFeesAboveCeiling)
+	}
 
 	// Create the renewSet and refreshSet. Each is a list of contracts that need
 	// to be renewed, paired with the amount of money to use in each renewal.
@@ -1087,10 +1144,13 @@ func (c *Contractor) threadedContractMaintenance() {
 	// data in the long term rather than renew a contract.
 	var renewSet []fileContractRenewal
 	var refreshSet []fileContractRenewal
+	var deferredSet []types.FileContractID
 
 	// Iterate through the contracts again, figuring out which contracts to
-	// renew and how much extra funds to renew them with.
-	for _, contract := range c.staticContracts.ViewAll() {
+	// renew and how much extra funds to renew them with. The utility of the
+	// contracts is looked up separately since managedLimitGFUHosts might have
+	// changed it.
+	for _, contract := range snapshot.Contracts() {
 		c.log.Debugln("Examining a contract:", contract.HostPublicKey, contract.ID)
 		// Skip any host that does not match our whitelist/blacklist filter
 		// settings.
@@ -1125,6 +1185,11 @@ func (c *Contractor) threadedContractMaintenance() {
 		// much money was spend on the contract throughout this billing cycle
 		// (which is now ending).
 		if blockHeight+allowance.RenewWindow >= contract.EndHeight && !c.staticDeps.Disrupt("disableRenew") {
+			if feesAboveCeiling && renewalDeferrable(allowance, blockHeight, contract.EndHeight) {
+				deferredSet = append(deferredSet, contract.ID)
+				c.log.Debugln("Contract renewal deferred because the transaction fees are above the ceiling", contract.ID)
+				continue
+			}
 			renewAmount, err := c.managedEstimateRenewFundingRequirements(contract, blockHeight, allowance)
 			if err != nil {
 				c.log.Debugln("Contract skipped because there was an error estimating renew funding requirements", renewAmount, err)
@@ -1140,18 +1205,18 @@ func (c *Contractor) threadedContractMaintenance() {
 		}
 
 		// Check if the contract is empty. We define a contract as being empty
-		// if less than 'minContractFundRenewalThreshold' funds are remaining
-		// (3% at time of writing), or if there is less than 3 sectors worth of
+		// if less than 'MinContractFundRenewalThreshold' funds are remaining
+		// (6% by default), or if there is less than 3 sectors worth of
 		// storage+upload+download remaining.
 		blockBytes := types.NewCurrency64(modules.SectorSize * uint64(allowance.Period))
-		sectorStoragePrice := host.StoragePrice.Mul(blockBytes)
+		sectorStoragePrice := host.StoragePriceFor(allowance.NFTStorage).Mul(blockBytes)
 		sectorUploadBandwidthPrice := host.UploadBandwidthPrice.Mul64(modules.SectorSize)
 		sectorDownloadBandwidthPrice := host.DownloadBandwidthPrice.Mul64(modules.SectorSize)
 		sectorBandwidthPrice := sectorUploadBandwidthPrice.Add(sectorDownloadBandwidthPrice)
 		sectorPrice := sectorStoragePrice.Add(sectorBandwidthPrice)
 		percentRemaining, _ := big.NewRat(0, 1).SetFrac(contract.RenterFunds.Big(), contract.TotalCost.Big()).Float64()
 		lowFundsRefresh := c.staticDeps.Disrupt("LowFundsRefresh")
-		if lowFundsRefresh || ((contract.RenterFunds.Cmp(sectorPrice.Mul64(3)) < 0 || percentRemaining < MinContractFundRenewalThreshold) && !c.staticDeps.Disrupt("disableRenew")) {
+		if lowFundsRefresh || ((contract.RenterFunds.Cmp(sectorPrice.Mul64(3)) < 0 || percentRemaining < renewalThreshold) && !c.staticDeps.Disrupt("disableRenew")) {
 			// Renew the contract with double the amount of funds that the
 			// contract had previously. The reason that we double the funding
 			// instead of doing anything more clever is that we don't know what
@@ -1164,8 +1229,13 @@ func (c *Contractor) threadedContractMaintenance() {
 			// does mean that a larger percentage of funds get locked away from
 			// the user in the event that the user stops uploading immediately
 			// after the renew.
+			if feesAboveCeiling {
+				deferredSet = append(deferredSet, contract.ID)
+				c.log.Debugln("Contract refresh deferred because the transaction fees are above the ceiling", contract.ID)
+				continue
+			}
 			refreshAmount := contract.TotalCost.Mul64(2)
-			minimum := allowance.Funds.MulFloat(fileContractMinimumFunding).Div64(allowance.Hosts)
+			minimum := allowanceMinContractFunding(allowance)
 			if refreshAmount.Cmp(minimum) < 0 {
 				refreshAmount = minimum
 			}
@@ -1174,9 +1244,9 @@ func (c *Contractor) threadedContractMaintenance() {
 				amount:     refreshAmount,
 				hostPubKey: contract.HostPublicKey,
 			})
-			c.log.Debugln("Contract identified as needing to be added to refresh set", contract.RenterFunds, sectorPrice.Mul64(3), percentRemaining, MinContractFundRenewalThreshold)
+			c.log.Debugln("Contract identified as needing to be added to refresh set", contract.RenterFunds, sectorPrice.Mul64(3), percentRemaining, renewalThreshold)
 		} else {
-			c.log.Debugln("Contract did not get added to the refresh set", contract.RenterFunds, sectorPrice.Mul64(3), percentRemaining, MinContractFundRenewalThreshold)
+			c.log.Debugln("Contract did not get added to the refresh set", contract.RenterFunds, sectorPrice.Mul64(3), percentRemaining, renewalThreshold)
 		}
 	}
 	if len(renewSet) != 0 || len(refreshSet) != 0 {
@@ -1184,11 +1254,17 @@ func (c *Contractor) threadedContractMaintenance() {
 	}
 
 	// Update the failed renew map so that it only contains contracts which we
-	// are currently trying to renew or refresh. The failed renew map is a map
-	// that we use to track how many times consecutively we failed to renew a
-	// contract with a host, so that we know if we need to abandon that host.
+	// are currently trying to renew or refresh, including deferred ones. The
+	// failed renew map is a map that we use to track how many times
+	// consecutively we failed to renew a contract with a host, so that we know
+	// if we need to abandon that host.
 	c.mu.Lock()
 	newFirstFailedRenew := make(map[types.FileContractID]types.BlockHeight)
+	for _, id := range deferredSet {
+		if _, exists := c.numFailedRenews[id]; exists {
+			newFirstFailedRenew[id] = c.numFailedRenews[id]
+		}
+	}
 	for _, r := range renewSet {
 		if _, exists := c.numFailedRenews[r.id]; exists {
 			newFirstFailedRenew[r.id] = c.numFailedRenews[r.id]
@@ -1218,6 +1294,7 @@ func (c *Contractor) threadedContractMaintenance() {
 		fundsRemaining = allowance.Funds.Sub(spending.TotalAllocated)
 	}
 	c.log.Debugln("Remaining funds in allowance:", fundsRemaining.HumanString())
+	c.managedCheckLowAllowance(allowance, fundsRemaining)
 
 	// Keep track of the total number of renews that failed for any reason.
 	var numRenewFails int
@@ -1290,8 +1367,10 @@ func (c *Contractor) threadedContractMaintenance() {
 			c.log.Println("Error renewing a contract", renewal.id, err)
 			renewErr = errors.Compose(renewErr, err)
 			numRenewFails++
+			maintenanceContractsMetric.With("renew_failed").Inc()
 		} else {
 			c.log.Println("Renewal completed without error")
+			maintenanceContractsMetric.With("renewed").Inc()
 		}
 		fundsRemaining = fundsRemaining.Sub(fundsSpent)
 	}
@@ -1330,8 +1409,10 @@ func (c *Contractor) threadedContractMaintenance() {
 			c.log.Println("Error refreshing a contract", renewal.id, err)
 			renewErr = errors.Compose(renewErr, err)
 			numRenewFails++
+			maintenanceContractsMetric.With("refresh_failed").Inc()
 		} else {
 			c.log.Println("Refresh completed without error")
+			maintenanceContractsMetric.With("refreshed").Inc()
 		}
 		fundsRemaining = fundsRemaining.Sub(fundsSpent)
 	}
@@ -1350,21 +1431,25 @@ func (c *Contractor) threadedContractMaintenance() {
 	if neededContracts > 0 {
 		c.log.Println("need more contracts:", neededContracts)
 	}
+	if neededContracts > 0 && feesAboveCeiling {
+		c.log.Println("Not forming new contracts because the transaction fees are above the ceiling")
+		return
+	}
 
 	// Assemble two exclusion lists. The first one includes all hosts that we
 	// already have contracts with and the second one includes all hosts we
 	// have active contracts with. Then select a new batch of hosts to attempt
 	// contract formation with.
-	allContracts := c.staticContracts.ViewAll()
-	c.mu.RLock()
 	var blacklist []types.SiaPublicKey
 	var addressBlacklist []types.SiaPublicKey
-	for _, contract := range allContracts {
+	c.staticContracts.ForEach(func(contract modules.RenterContract) error {
 		blacklist = append(blacklist, contract.HostPublicKey)
 		if !contract.Utility.Locked || contract.Utility.GoodForRenew || contract.Utility.GoodForUpload {
 			addressBlacklist = append(addressBlacklist, contract.HostPublicKey)
 		}
-	}
+		return nil
+	})
+	c.mu.RLock()
 	// Add the hosts we have recoverable contracts with to the blacklist to
 	// avoid losing existing data by forming a new/empty contract.
 	for _, contract := range c.recoverableContracts {
@@ -1386,7 +1471,6 @@ func (c *Contractor) threadedContractMaintenance() {
 	c.log.Debugln("trying to form contracts with hosts, pulled this many hosts from hostdb:", len(hosts))
 
 	// Calculate the anticipated transaction fee.
-	_, maxFee := c.tpool.FeeEstimation()
 	txnFee := maxFee.Mul64(modules.EstimatedFileContractTransactionSetSize)
 
 	// Form contracts with the hosts one at a time, until we have enough
@@ -1447,12 +1531,14 @@ func (c *Contractor) threadedContractMaintenance() {
 
 		// Attempt forming a contract with this host.
 		start := time.Now()
-		fundsSpent, newContract, err := c.managedNewContract(host, contractFunds, endHeight)
+		receipt, newContract, err := c.managedNewContract(host, contractFunds, endHeight)
+		fundsRemaining = fundsRemaining.Sub(receipt.Funding)
 		if err != nil {
 			c.log.Printf("Attempted to form a contract with %v, time spent %v, but negotiation failed: %v\n", host.NetAddress, time.Since(start).Round(time.Millisecond), err)
+			maintenanceContractsMetric.With("form_failed").Inc()
 			continue
 		}
-		fundsRemaining = fundsRemaining.Sub(fundsSpent)
+		maintenanceContractsMetric.With("formed").Inc()
 		neededContracts--
 
 		sb, err := c.hdb.ScoreBreakdown(host)
@@ -1462,6 +1548,7 @@ func (c *Contractor) threadedContractMaintenance() {
 			c.log.Println("Age Adjustment:        ", sb.AgeAdjustment)
 			c.log.Println("Base Price Adjustment: ", sb.BasePriceAdjustment)
 			c.log.Println("Burn Adjustment:       ", sb.BurnAdjustment)
+			c.log.Println("Capability Adjustment: ", sb.CapabilityAdjustment)
 			c.log.Println("Collateral Adjustment: ", sb.CollateralAdjustment)
 			c.log.Println("Duration Adjustment:   ", sb.DurationAdjustment)
 			c.log.Println("Interaction Adjustment:", sb.InteractionAdjustment)
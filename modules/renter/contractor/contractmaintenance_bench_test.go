@@ -0,0 +1,303 @@
+package contractor
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"gitlab.com/NebulousLabs/ratelimit"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/proto"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// maintenanceLoadContracts is the number of active contracts the
+	// maintenance load test and benchmark run against.
+	maintenanceLoadContracts = 10000
+
+	// maintenanceLoadOldContracts is the number of old contracts the
+	// maintenance load test and benchmark run against.
+	maintenanceLoadOldContracts = 50000
+)
+
+type (
+	// maintenanceHostDB is a hostdb which knows every host and gives them all
+	// the same score. Calling methods which are not needed for maintenance
+	// panics.
+	maintenanceHostDB struct {
+		modules.HostDB
+		randomHosts []modules.HostDBEntry
+	}
+
+	// maintenanceTPool is a transaction pool which only returns fee
+	// estimates.
+	maintenanceTPool struct {
+		modules.TransactionPool
+	}
+
+	// maintenanceWallet is an unlocked wallet which can't be used to fund
+	// transactions.
+	maintenanceWallet struct {
+		modules.Wallet
+	}
+
+	// maintenanceDeps disables contract recovery since it requires a
+	// consensus set.
+	maintenanceDeps struct {
+		modules.ProductionDependencies
+	}
+)
+
+// Disrupt disables contract recovery.
+func (*maintenanceDeps) Disrupt(s string) bool {
+	return s == "DisableContractRecovery" || s == "disableAutomaticContractRecoveryScan"
+}
+
+// newMaintenanceHost creates an online host which is running the latest
+// protocol version.
+func newMaintenanceHost(pk types.SiaPublicKey) modules.HostDBEntry {
+	var host modules.HostDBEntry
+	host.PublicKey = pk
+	host.Version = modules.MinimumSupportedRenterHostProtocolVersion
+	host.ScanHistory = modules.HostDBScans{{Success: true}}
+	return host
+}
+
+// ActiveHosts implements the hostDB interface.
+func (hdb *maintenanceHostDB) ActiveHosts() ([]modules.HostDBEntry, error) {
+	return hdb.randomHosts, nil
+}
+
+// CheckForIPViolations implements the hostDB interface.
+func (hdb *maintenanceHostDB) CheckForIPViolations([]types.SiaPublicKey) ([]types.SiaPublicKey, error) {
+	return nil, nil
+}
+
+// Host implements the hostDB interface.
+func (hdb *maintenanceHostDB) Host(pk types.SiaPublicKey) (modules.HostDBEntry, bool, error) {
+	return newMaintenanceHost(pk), true, nil
+}
+
+// RandomHosts implements the hostDB interface.
+func (hdb *maintenanceHostDB) RandomHosts(n int, _, _ []types.SiaPublicKey) ([]modules.HostDBEntry, error) {
+	if n > len(hdb.randomHosts) {
+		n = len(hdb.randomHosts)
+	}
+	return hdb.randomHosts[:n], nil
+}
+
+// ScoreBreakdown implements the hostDB interface.
+func (hdb *maintenanceHostDB) ScoreBreakdown(modules.HostDBEntry) (modules.HostScoreBreakdown, error) {
+	return modules.HostScoreBreakdown{Score: types.NewCurrency64(1e6)}, nil
+}
+
+// UpdateContracts implements the hostDB interface.
+func (hdb *maintenanceHostDB) UpdateContracts([]modules.RenterContract) error {
+	return nil
+}
+
+// FeeEstimation implements the transactionPool interface.
+func (maintenanceTPool) FeeEstimation() (types.Currency, types.Currency) {
+	return types.SiacoinPrecision, types.SiacoinPrecision
+}
+
+// PrimarySeed implements the walletShim interface.
+func (maintenanceWallet) PrimarySeed() (modules.Seed, uint64, error) {
+	return modules.Seed{}, 0, errors.New("no seed")
+}
+
+// Unlocked implements the walletShim interface.
+func (maintenanceWallet) Unlocked() (bool, error) {
+	return true, nil
+}
+
+// newMaintenanceTestContractor creates a contractor with mocked dependencies
+// which has numContracts active contracts with unique hosts and numOld old
+// contracts. numExpired of the active contracts are expired and will be
+// archived by the first maintenance run.
+func newMaintenanceTestContractor(tb testing.TB, numContracts, numOld, numExpired int) *Contractor {
+	dir := build.TempDir("contractor", tb.Name())
+	contractSet, err := proto.NewContractSet(filepath.Join(dir, "contracts"), ratelimit.NewRateLimit(0, 0, 0), modules.ProdDependencies)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() {
+		if err := contractSet.Close(); err != nil {
+			tb.Error(err)
+		}
+	})
+	log, err := persist.NewLogger(ioutil.Discard)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	hdb := &maintenanceHostDB{}
+	for i := 0; i < 50; i++ {
+		hdb.randomHosts = append(hdb.randomHosts, newMaintenanceHost(types.SiaPublicKey{
+			Algorithm: types.SignatureEd25519,
+			Key:       fastrand.Bytes(crypto.PublicKeySize),
+		}))
+	}
+
+	c := &Contractor{
+		staticAlerter: modules.NewAlerter("contractor"),
+		staticDeps:    &maintenanceDeps{},
+		hdb:           hdb,
+		log:           log,
+		persistDir:    dir,
+		tpool:         maintenanceTPool{},
+		wallet:        maintenanceWallet{},
+
+		allowance: modules.Allowance{
+			Funds:       types.SiacoinPrecision.Mul64(1e9),
+			Hosts:       uint64(numContracts),
+			Period:      1000,
+			RenewWindow: 100,
+		},
+		blockHeight:   200,
+		currentPeriod: 100,
+
+		interruptMaintenance: make(chan struct{}),
+		synced:               make(chan struct{}),
+
+		staticContracts:      contractSet,
+		oldContracts:         make(map[types.FileContractID]modules.RenterContract),
+		doubleSpentContracts: make(map[types.FileContractID]types.BlockHeight),
+		recoverableContracts: make(map[types.FileContractID]modules.RecoverableContract),
+		renewing:             make(map[types.FileContractID]bool),
+		renewedFrom:          make(map[types.FileContractID]types.FileContractID),
+		renewedTo:            make(map[types.FileContractID]types.FileContractID),
+		workerPool:           emptyWorkerPool{},
+	}
+	close(c.synced)
+	c.staticChurnLimiter = newChurnLimiter(c)
+	c.staticWatchdog = newWatchdog(c)
+
+	// Insert the active contracts. Expired contracts ended before the
+	// current block height.
+	funds := types.SiacoinPrecision.Mul64(1e3)
+	for i := 0; i < numContracts+numExpired; i++ {
+		var id types.FileContractID
+		fastrand.Read(id[:])
+		hpk := types.SiaPublicKey{
+			Algorithm: types.SignatureEd25519,
+			Key:       fastrand.Bytes(crypto.PublicKeySize),
+		}
+		endHeight := types.BlockHeight(5000)
+		if i >= numContracts {
+			endHeight = c.blockHeight - 1
+		}
+		revTxn := types.Transaction{
+			FileContractRevisions: []types.FileContractRevision{{
+				ParentID: id,
+				UnlockConditions: types.UnlockConditions{
+					PublicKeys:         []types.SiaPublicKey{{}, hpk},
+					SignaturesRequired: 2,
+				},
+				NewWindowStart:        endHeight,
+				NewValidProofOutputs:  []types.SiacoinOutput{{Value: funds}, {}},
+				NewMissedProofOutputs: make([]types.SiacoinOutput, 3),
+			}},
+		}
+		rc := modules.RecoverableContract{
+			FileContract: types.FileContract{
+				ValidProofOutputs: []types.SiacoinOutput{{Value: funds}, {}},
+			},
+			StartHeight: c.currentPeriod,
+		}
+		if _, err := contractSet.InsertContract(rc, revTxn, nil, crypto.SecretKey{}); err != nil {
+			tb.Fatal(err)
+		}
+	}
+
+	// Add the old contracts.
+	for i := 0; i < numOld; i++ {
+		var id types.FileContractID
+		fastrand.Read(id[:])
+		c.oldContracts[id] = modules.RenterContract{
+			ID: id,
+			HostPublicKey: types.SiaPublicKey{
+				Algorithm: types.SignatureEd25519,
+				Key:       fastrand.Bytes(crypto.PublicKeySize),
+			},
+			StartHeight: types.BlockHeight(fastrand.Intn(int(c.currentPeriod) * 2)),
+			EndHeight:   c.currentPeriod,
+			TotalCost:   funds,
+			RenterFunds: funds,
+		}
+	}
+	return c
+}
+
+// TestContractMaintenanceLoad runs contract maintenance against a large number
+// of active and old contracts and checks that the contracts are archived and
+// marked correctly. Every active contract keeps two files open, so the full
+// sized run requires a high file descriptor limit and only runs with VLONG.
+func TestContractMaintenanceLoad(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	numContracts, numOld := maintenanceLoadContracts/5, maintenanceLoadOldContracts/5
+	if build.VLONG {
+		numContracts, numOld = maintenanceLoadContracts, maintenanceLoadOldContracts
+	}
+	const expired = 100
+	c := newMaintenanceTestContractor(t, numContracts, numOld, expired)
+
+	for i := 0; i < 2; i++ {
+		c.threadedContractMaintenance()
+
+		// The expired contracts should have been archived.
+		if n := c.staticContracts.Len(); n != numContracts {
+			t.Fatalf("expected %v active contracts but got %v", numContracts, n)
+		}
+		c.mu.RLock()
+		numArchived := len(c.oldContracts)
+		numPubKeys := len(c.pubKeysToContractID)
+		c.mu.RUnlock()
+		if numArchived != numOld+expired {
+			t.Fatalf("expected %v old contracts but got %v", numOld+expired, numArchived)
+		}
+		if numPubKeys != numContracts {
+			t.Fatalf("expected %v mapped host keys but got %v", numContracts, numPubKeys)
+		}
+
+		// All the remaining contracts should be good.
+		for _, contract := range c.Contracts() {
+			if !contract.Utility.GoodForUpload || !contract.Utility.GoodForRenew {
+				t.Fatal("contract should be GFU and GFR", contract.Utility)
+			}
+		}
+	}
+}
+
+// BenchmarkContractMaintenance measures how long a contract maintenance run
+// takes for a contractor with many active and old contracts once the contracts
+// have been archived and marked.
+func BenchmarkContractMaintenance(b *testing.B) {
+	sizes := []struct {
+		contracts int
+		old       int
+	}{
+		{maintenanceLoadContracts / 10, maintenanceLoadOldContracts / 10},
+		{maintenanceLoadContracts, maintenanceLoadOldContracts},
+	}
+	for _, size := range sizes {
+		b.Run(fmt.Sprintf("%v-%v", size.contracts, size.old), func(b *testing.B) {
+			c := newMaintenanceTestContractor(b, size.contracts, size.old, 0)
+			c.threadedContractMaintenance()
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				c.threadedContractMaintenance()
+			}
+		})
+	}
+}
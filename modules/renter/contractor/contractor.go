@@ -6,6 +6,7 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -32,6 +33,9 @@ var (
 	errHostNotFound     = errors.New("host not found")
 	errContractNotFound = errors.New("contract not found")
 
+	errUnknownContractStatus = errors.New("unknown contract status")
+	errUnknownContractSort   = errors.New("unknown contract sort field")
+
 	// COMPATv1.0.4-lts
 	// metricsContractID identifies a special contract that contains aggregate
 	// financial metrics from older contractors
@@ -98,12 +102,21 @@ type Contractor struct {
 	// renewedTo links the old contract's ID to the new contract's ID
 	// doubleSpentContracts keep track of all contracts that were double spent by
 	// either the renter or host.
+	// receipts contains the cost breakdown of every formed and renewed
+	// contract.
 	staticContracts      *proto.ContractSet
 	oldContracts         map[types.FileContractID]modules.RenterContract
 	doubleSpentContracts map[types.FileContractID]types.BlockHeight
 	recoverableContracts map[types.FileContractID]modules.RecoverableContract
 	renewedFrom          map[types.FileContractID]types.FileContractID
 	renewedTo            map[types.FileContractID]types.FileContractID
+	receipts             map[types.FileContractID]modules.ContractReceipt
+
+	// notifiers are notified about renewal failures, locked contracts and a
+	// low allowance. lowAllowanceNotified is set while the allowance is low
+	// to only notify about it once.
+	notifiers            []modules.ContractorNotifier
+	lowAllowanceNotified bool
 
 	staticChurnLimiter *churnLimiter
 	staticWatchdog     *watchdog
@@ -151,21 +164,32 @@ func (c *Contractor) InitRecoveryScan() (err error) {
 		return err
 	}
 	defer c.tg.Done()
-	return c.callInitRecoveryScan(modules.ConsensusChangeBeginning)
+	return c.callInitRecoveryScan(modules.ConsensusChangeBeginning, false)
+}
+
+// InitFullRecoveryScan starts scanning the whole blockchain for contracts
+// derivable from the wallet seed within a separate thread. Unlike
+// InitRecoveryScan it also finds contracts which were formed without a
+// contract identifier by matching them against all hosts in the hostdb.
+func (c *Contractor) InitFullRecoveryScan() (err error) {
+	if err := c.tg.Add(); err != nil {
+		return err
+	}
+	defer c.tg.Done()
+	return c.callInitRecoveryScan(modules.ConsensusChangeBeginning, true)
 }
 
 // PeriodSpending returns the amount spent on contracts during the current
 // billing period.
 func (c *Contractor) PeriodSpending() (modules.ContractorSpending, error) {
-	allContracts := c.staticContracts.ViewAll()
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
 	var spending modules.ContractorSpending
-	for _, contract := range allContracts {
+	c.staticContracts.ForEach(func(contract modules.RenterContract) error {
 		// Don't count double-spent contracts.
 		if _, doubleSpent := c.doubleSpentContracts[contract.ID]; doubleSpent {
-			continue
+			return nil
 		}
 
 		// Calculate ContractFees
@@ -181,7 +205,8 @@ func (c *Contractor) PeriodSpending() (modules.ContractorSpending, error) {
 		spending.MaintenanceSpending = spending.MaintenanceSpending.Add(contract.MaintenanceSpending)
 		spending.UploadSpending = spending.UploadSpending.Add(contract.UploadSpending)
 		spending.StorageSpending = spending.StorageSpending.Add(contract.StorageSpending)
-	}
+		return nil
+	})
 
 	// Calculate needed spending to be reported from old contracts
 	for _, contract := range c.oldContracts {
@@ -190,7 +215,8 @@ func (c *Contractor) PeriodSpending() (modules.ContractorSpending, error) {
 			continue
 		}
 
-		host, exist, err := c.hdb.Host(contract.HostPublicKey)
+		// The host is only looked up for contracts of previous periods to
+		// avoid a hostdb lookup for every old contract.
 		if contract.StartHeight >= c.currentPeriod {
 			// Calculate spending from contracts that were renewed during the current period
 			// Calculate ContractFees
@@ -205,7 +231,7 @@ func (c *Contractor) PeriodSpending() (modules.ContractorSpending, error) {
 			spending.MaintenanceSpending = spending.MaintenanceSpending.Add(contract.MaintenanceSpending)
 			spending.UploadSpending = spending.UploadSpending.Add(contract.UploadSpending)
 			spending.StorageSpending = spending.StorageSpending.Add(contract.StorageSpending)
-		} else if err != nil && exist && contract.EndHeight+host.WindowSize+types.MaturityDelay > c.blockHeight {
+		} else if host, exist, err := c.hdb.Host(contract.HostPublicKey); err != nil && exist && contract.EndHeight+host.WindowSize+types.MaturityDelay > c.blockHeight {
 			// Calculate funds that are being withheld in contracts
 			spending.WithheldFunds = spending.WithheldFunds.Add(contract.RenterFunds)
 			// Record the largest window size for worst case when reporting the spending
@@ -236,6 +262,55 @@ func (c *Contractor) PeriodSpending() (modules.ContractorSpending, error) {
 	return spending, nil
 }
 
+// PeriodSpendingBreakdown returns the money spent during the current period
+// split by category and by host. Like PeriodSpending it counts the current
+// contracts and the old contracts which started in the current period, except
+// for double-spent contracts.
+func (c *Contractor) PeriodSpendingBreakdown() (modules.ContractorSpendingBreakdown, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	breakdown := modules.ContractorSpendingBreakdown{Period: c.currentPeriod}
+	hosts := make(map[string]*modules.HostSpending)
+	addContract := func(contract modules.RenterContract) {
+		if _, doubleSpent := c.doubleSpentContracts[contract.ID]; doubleSpent {
+			return
+		}
+		categories := modules.ContractSpendingCategories(contract)
+		breakdown.Total = breakdown.Total.Add(categories)
+		hs, exists := hosts[contract.HostPublicKey.String()]
+		if !exists {
+			hs = &modules.HostSpending{HostPublicKey: contract.HostPublicKey}
+			hosts[contract.HostPublicKey.String()] = hs
+		}
+		hs.Contracts++
+		hs.SpendingCategories = hs.SpendingCategories.Add(categories)
+	}
+	c.staticContracts.ForEach(func(contract modules.RenterContract) error {
+		addContract(contract)
+		return nil
+	})
+	for _, contract := range c.oldContracts {
+		if contract.StartHeight >= c.currentPeriod {
+			addContract(contract)
+		}
+	}
+
+	// Sort the hosts by their total spending, falling back to their keys.
+	breakdown.Hosts = make([]modules.HostSpending, 0, len(hosts))
+	for _, hs := range hosts {
+		breakdown.Hosts = append(breakdown.Hosts, *hs)
+	}
+	sort.Slice(breakdown.Hosts, func(i, j int) bool {
+		cmp := breakdown.Hosts[i].Sum().Cmp(breakdown.Hosts[j].Sum())
+		if cmp == 0 {
+			return breakdown.Hosts[i].HostPublicKey.String() < breakdown.Hosts[j].HostPublicKey.String()
+		}
+		return cmp > 0
+	})
+	return breakdown, nil
+}
+
 // CurrentPeriod returns the height at which the current allowance period
 // began.
 func (c *Contractor) CurrentPeriod() types.BlockHeight {
@@ -367,7 +442,12 @@ func (c *Contractor) RefreshedContract(fcid types.FileContractID) bool {
 	defer c.tg.Done()
 	c.mu.RLock()
 	defer c.mu.RUnlock()
+	return c.refreshedContract(fcid)
+}
 
+// refreshedContract returns a bool indicating if the contract was a refreshed
+// contract. The caller needs to hold the lock.
+func (c *Contractor) refreshedContract(fcid types.FileContractID) bool {
 	// Check if contract ID is found in the renewedTo map indicating that the
 	// contract was renewed
 	newFCID, renewed := c.renewedTo[fcid]
@@ -492,6 +572,7 @@ func contractorBlockingStartup(cs modules.ConsensusSet, w modules.Wallet, tp mod
 		renewing:             make(map[types.FileContractID]bool),
 		renewedFrom:          make(map[types.FileContractID]types.FileContractID),
 		renewedTo:            make(map[types.FileContractID]types.FileContractID),
+		receipts:             make(map[types.FileContractID]modules.ContractReceipt),
 		workerPool:           emptyWorkerPool{},
 	}
 	c.staticChurnLimiter = newChurnLimiter(c)
@@ -598,8 +679,9 @@ func NewCustomContractor(cs modules.ConsensusSet, w modules.Wallet, tp modules.T
 }
 
 // callInitRecoveryScan starts scanning the whole blockchain at a certain
-// ChangeID for recoverable contracts within a separate thread.
-func (c *Contractor) callInitRecoveryScan(scanStart modules.ConsensusChangeID) (err error) {
+// ChangeID for recoverable contracts within a separate thread. A full scan
+// also matches contracts without a contract identifier against the hostdb.
+func (c *Contractor) callInitRecoveryScan(scanStart modules.ConsensusChangeID, full bool) (err error) {
 	// Check if we are already scanning the blockchain.
 	if !atomic.CompareAndSwapUint32(&c.atomicScanInProgress, 0, 1) {
 		return errors.New("scan for recoverable contracts is already in progress")
@@ -622,6 +704,12 @@ func (c *Contractor) callInitRecoveryScan(scanStart modules.ConsensusChangeID) (
 	atomic.StoreInt64(&c.atomicRecoveryScanHeight, 0)
 	// Create the scanner.
 	scanner := c.newRecoveryScanner(rs)
+	if full {
+		scanner, err = c.newFullRecoveryScanner(rs)
+		if err != nil {
+			return err
+		}
+	}
 	// Start the scan.
 	go func() {
 		// Add scanning thread to threadgroup.
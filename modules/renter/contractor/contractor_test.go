@@ -147,6 +147,106 @@ func TestAllowance(t *testing.T) {
 	}
 }
 
+// TestValidateAllowanceTuning tests validating the allowance fields which tune
+// the contractor and falling back to the defaults for unset fields.
+func TestValidateAllowanceTuning(t *testing.T) {
+	// The empty allowance should be valid and use the defaults.
+	var a modules.Allowance
+	if err := validateAllowanceTuning(a); err != nil {
+		t.Fatal(err)
+	}
+	gfr, gfu := allowanceScoreLeeways(a)
+	if !gfr.Equals64(scoreLeewayGoodForRenew) || !gfu.Equals64(scoreLeewayGoodForUpload) {
+		t.Fatal("wrong default leeways", gfr, gfu)
+	}
+	upload, renewal := allowanceFundThresholds(a)
+	if upload != MinContractFundUploadThreshold || renewal != MinContractFundRenewalThreshold {
+		t.Fatal("wrong default thresholds", upload, renewal)
+	}
+	a.Funds = types.NewCurrency64(1000)
+	a.Hosts = 10
+	if minFunding := allowanceMinContractFunding(a); !minFunding.Equals(a.Funds.MulFloat(fileContractMinimumFunding).Div64(10)) {
+		t.Fatal("wrong default min funding", minFunding)
+	}
+
+	// Set custom values.
+	a.ScoreLeewayGoodForRenew = 100
+	a.ScoreLeewayGoodForUpload = 10
+	a.MinContractFunding = 0.5
+	a.MinContractFundUploadThreshold = 0.1
+	a.MinContractFundRenewalThreshold = 0.2
+	a.NFTRepairThreshold = 0.5
+	a.MaxNFTRepairSpending = a.Funds
+	if err := validateAllowanceTuning(a); err != nil {
+		t.Fatal(err)
+	}
+	gfr, gfu = allowanceScoreLeeways(a)
+	if !gfr.Equals64(100) || !gfu.Equals64(10) {
+		t.Fatal("wrong leeways", gfr, gfu)
+	}
+	upload, renewal = allowanceFundThresholds(a)
+	if upload != 0.1 || renewal != 0.2 {
+		t.Fatal("wrong thresholds", upload, renewal)
+	}
+	if minFunding := allowanceMinContractFunding(a); !minFunding.Equals64(50) {
+		t.Fatal("wrong min funding", minFunding)
+	}
+
+	// Invalid values.
+	tests := []struct {
+		modify func(a *modules.Allowance)
+		err    error
+	}{
+		{func(a *modules.Allowance) { a.ScoreLeewayGoodForUpload = 101 }, ErrAllowanceInvalidScoreLeeway},
+		{func(a *modules.Allowance) { a.ScoreLeewayGoodForRenew = 0; a.ScoreLeewayGoodForUpload = 501 }, ErrAllowanceInvalidScoreLeeway},
+		{func(a *modules.Allowance) { a.MinContractFunding = -0.1 }, ErrAllowanceInvalidMinContractFunding},
+		{func(a *modules.Allowance) { a.MinContractFunding = 1.1 }, ErrAllowanceInvalidMinContractFunding},
+		{func(a *modules.Allowance) { a.MinContractFundUploadThreshold = 1.1 }, ErrAllowanceInvalidFundThreshold},
+		{func(a *modules.Allowance) { a.MinContractFundRenewalThreshold = -1 }, ErrAllowanceInvalidFundThreshold},
+		{func(a *modules.Allowance) { a.MinContractFundUploadThreshold = 0.3 }, ErrAllowanceInvalidRenewalThreshold},
+		{func(a *modules.Allowance) {
+			a.MinContractFundRenewalThreshold = 0.01
+			a.MinContractFundUploadThreshold = 0
+		}, ErrAllowanceInvalidRenewalThreshold},
+		{func(a *modules.Allowance) { a.NFTRepairThreshold = 1.1 }, ErrAllowanceInvalidNFTRepairThreshold},
+		{func(a *modules.Allowance) { a.MaxNFTRepairSpending = a.Funds.Add64(1) }, ErrAllowanceInvalidNFTRepairSpending},
+	}
+	for i, test := range tests {
+		invalid := a
+		test.modify(&invalid)
+		if err := validateAllowanceTuning(invalid); !errors.Contains(err, test.err) {
+			t.Errorf("%v: expected %v but got %v", i, test.err, err)
+		}
+	}
+}
+
+// TestTxnFeeCeiling tests deciding whether formations and renewals are deferred
+// because of the allowance's transaction fee ceiling.
+func TestTxnFeeCeiling(t *testing.T) {
+	// Without a ceiling nothing is deferred.
+	a := modules.Allowance{RenewWindow: 100}
+	if txnFeesAboveCeiling(a, types.SiacoinPrecision) {
+		t.Fatal("fees shouldn't be above a ceiling that isn't set")
+	}
+
+	// Fees up to the ceiling are paid.
+	a.MaxTxnFeePerByte = types.NewCurrency64(10)
+	if txnFeesAboveCeiling(a, types.NewCurrency64(10)) {
+		t.Fatal("fees at the ceiling should be paid")
+	}
+	if !txnFeesAboveCeiling(a, types.NewCurrency64(11)) {
+		t.Fatal("fees above the ceiling shouldn't be paid")
+	}
+
+	// Renewals are only deferred during the first half of the renew window.
+	if !renewalDeferrable(a, 900, 1000) || !renewalDeferrable(a, 949, 1000) {
+		t.Fatal("renewal at the start of the renew window should be deferrable")
+	}
+	if renewalDeferrable(a, 950, 1000) || renewalDeferrable(a, 1000, 1000) {
+		t.Fatal("renewal at the end of the renew window shouldn't be deferrable")
+	}
+}
+
 // TestIntegrationSetAllowance tests the SetAllowance method.
 func TestIntegrationSetAllowance(t *testing.T) {
 	if testing.Short() {
@@ -1,6 +1,9 @@
 package contractor
 
 import (
+	"bytes"
+	"sort"
+
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/modules/renter/proto"
@@ -175,6 +178,180 @@ func (c *Contractor) OldContracts() []modules.RenterContract {
 	return contracts
 }
 
+// RenewalChain returns the renewal chain of the contract with the given id,
+// ordered from the oldest to the newest contract. The chain is found by
+// following the renewedFrom links back to the first contract and then the
+// renewedTo links forward to the latest renewal.
+func (c *Contractor) RenewalChain(id types.FileContractID) ([]modules.ContractRenewalLink, error) {
+	if err := c.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer c.tg.Done()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	// Make sure the contract is known.
+	if _, _, exists := c.contractByID(id); !exists {
+		return nil, errContractNotFound
+	}
+
+	// Walk back to the first contract of the chain. The visited set prevents
+	// an infinite loop if there's an [impossible] contract cycle.
+	first := id
+	visited := map[types.FileContractID]struct{}{first: {}}
+	for {
+		prev, exists := c.renewedFrom[first]
+		if !exists {
+			break
+		}
+		if _, cycle := visited[prev]; cycle {
+			c.log.Critical("renewedFrom contains a cycle for contract", id)
+			break
+		}
+		visited[prev] = struct{}{}
+		first = prev
+	}
+
+	// Walk forward to the latest contract and collect the links.
+	var chain []modules.ContractRenewalLink
+	visited = make(map[types.FileContractID]struct{})
+	for current, exists := first, true; exists; current, exists = c.renewedTo[current] {
+		if _, cycle := visited[current]; cycle {
+			c.log.Critical("renewedTo contains a cycle for contract", id)
+			break
+		}
+		visited[current] = struct{}{}
+		contract, active, known := c.contractByID(current)
+		if !known {
+			c.log.Println("WARN: contract of renewal chain not found:", current)
+			break
+		}
+		chain = append(chain, modules.ContractRenewalLink{
+			ID:          contract.ID,
+			StartHeight: contract.StartHeight,
+			EndHeight:   contract.EndHeight,
+			Active:      active,
+			TotalCost:   contract.TotalCost,
+			Spending:    contractSpending(contract),
+		})
+	}
+	return chain, nil
+}
+
+// contractByID returns the contract with the given id from either the active or
+// the old contracts. The first returned bool indicates whether the contract is
+// active and the second one whether it was found at all.
+func (c *Contractor) contractByID(id types.FileContractID) (_ modules.RenterContract, active bool, exists bool) {
+	if contract, ok := c.staticContracts.View(id); ok {
+		return contract, true, true
+	}
+	contract, ok := c.oldContracts[id]
+	return contract, false, ok
+}
+
+// contractSpending returns the total amount of money spent within a contract.
+func contractSpending(contract modules.RenterContract) types.Currency {
+	return contract.ContractFee.Add(contract.TxnFee).Add(contract.SiafundFee).
+		Add(contract.DownloadSpending).Add(contract.UploadSpending).Add(contract.StorageSpending).
+		Add(contract.FundAccountSpending).Add(contract.MaintenanceSpending.Sum())
+}
+
+// contractStatus returns the status of a current contract. A contract is
+// either refreshed, active, passive or disabled. The caller needs to hold the
+// lock.
+func (c *Contractor) contractStatus(contract modules.RenterContract) modules.ContractStatus {
+	u := contract.Utility
+	switch {
+	case c.refreshedContract(contract.ID):
+		return modules.ContractStatusRefreshed
+	case u.GoodForUpload && u.GoodForRenew:
+		return modules.ContractStatusActive
+	case u.GoodForRenew:
+		return modules.ContractStatusPassive
+	default:
+		return modules.ContractStatusDisabled
+	}
+}
+
+// QueryContracts returns the page of the current contracts selected by the
+// query, together with the number of contracts matching the query's filters.
+func (c *Contractor) QueryContracts(q modules.ContractQuery) ([]modules.RenterContract, uint64, error) {
+	if err := c.tg.Add(); err != nil {
+		return nil, 0, err
+	}
+	defer c.tg.Done()
+
+	// Validate the query.
+	statuses := make(map[modules.ContractStatus]struct{})
+	for _, status := range q.Statuses {
+		switch status {
+		case modules.ContractStatusActive, modules.ContractStatusPassive, modules.ContractStatusRefreshed, modules.ContractStatusDisabled:
+			statuses[status] = struct{}{}
+		default:
+			return nil, 0, errors.AddContext(errUnknownContractStatus, string(status))
+		}
+	}
+	var compare func(a, b modules.RenterContract) int
+	switch q.SortBy {
+	case "", modules.ContractSortID:
+	case modules.ContractSortSpending:
+		compare = func(a, b modules.RenterContract) int {
+			return contractSpending(a).Cmp(contractSpending(b))
+		}
+	case modules.ContractSortEndHeight:
+		compare = func(a, b modules.RenterContract) int {
+			if a.EndHeight == b.EndHeight {
+				return 0
+			} else if a.EndHeight < b.EndHeight {
+				return -1
+			}
+			return 1
+		}
+	default:
+		return nil, 0, errors.AddContext(errUnknownContractSort, string(q.SortBy))
+	}
+
+	// Filter the contracts.
+	var contracts []modules.RenterContract
+	c.mu.RLock()
+	for _, contract := range c.staticContracts.ViewAll() {
+		if q.Host.Key != nil && !contract.HostPublicKey.Equals(q.Host) {
+			continue
+		}
+		if _, ok := statuses[c.contractStatus(contract)]; len(statuses) > 0 && !ok {
+			continue
+		}
+		contracts = append(contracts, contract)
+	}
+	c.mu.RUnlock()
+
+	// Sort the contracts, falling back to their IDs for equal keys.
+	sort.Slice(contracts, func(i, j int) bool {
+		cmp := 0
+		if compare != nil {
+			cmp = compare(contracts[i], contracts[j])
+		}
+		if cmp == 0 {
+			cmp = bytes.Compare(contracts[i].ID[:], contracts[j].ID[:])
+		}
+		if q.Descending {
+			return cmp > 0
+		}
+		return cmp < 0
+	})
+
+	// Paginate the contracts.
+	total := uint64(len(contracts))
+	if q.Offset >= total {
+		return nil, total, nil
+	}
+	contracts = contracts[q.Offset:]
+	if q.Limit != 0 && q.Limit < uint64(len(contracts)) {
+		contracts = contracts[:q.Limit]
+	}
+	return contracts, total, nil
+}
+
 // RecoverableContracts returns the contracts that the contractor deems
 // recoverable. That means they are not expired yet and also not part of the
 // active contracts. Usually this should return an empty slice unless the host
@@ -1,10 +1,16 @@
 package contractor
 
 import (
+	"path/filepath"
 	"testing"
 
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/ratelimit"
+
+	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/proto"
 	"go.sia.tech/siad/types"
 )
 
@@ -158,3 +164,289 @@ func TestTryAddContractToPubKeyMap(t *testing.T) {
 	// Can't test the case of a pubkey already in the pubkey map as that results
 	// in a Critical log
 }
+
+// TestRenewalChain tests that the contractor returns the full renewal chain of
+// a contract regardless of which contract of the chain is queried.
+func TestRenewalChain(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	dir := build.TempDir("contractor", t.Name())
+	cs, err := proto.NewContractSet(filepath.Join(dir, "contracts"), ratelimit.NewRateLimit(0, 0, 0), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Contractor{
+		staticContracts: cs,
+		oldContracts:    make(map[types.FileContractID]modules.RenterContract),
+		renewedFrom:     make(map[types.FileContractID]types.FileContractID),
+		renewedTo:       make(map[types.FileContractID]types.FileContractID),
+	}
+
+	// Add 2 old contracts and an active one which form the chain 0->1->2.
+	hpk := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: []byte("host")}
+	for i := 0; i < 2; i++ {
+		id := types.FileContractID{byte(i)}
+		c.oldContracts[id] = modules.RenterContract{
+			ID:            id,
+			HostPublicKey: hpk,
+			StartHeight:   types.BlockHeight(i * 10),
+			EndHeight:     types.BlockHeight(i*10 + 10),
+			ContractFee:   types.NewCurrency64(uint64(i + 1)),
+		}
+	}
+	activeID := types.FileContractID{2}
+	revTxn := types.Transaction{
+		FileContractRevisions: []types.FileContractRevision{{
+			ParentID: activeID,
+			UnlockConditions: types.UnlockConditions{
+				PublicKeys:         []types.SiaPublicKey{{}, hpk},
+				SignaturesRequired: 2,
+			},
+			NewWindowStart:        30,
+			NewValidProofOutputs:  make([]types.SiacoinOutput, 2),
+			NewMissedProofOutputs: make([]types.SiacoinOutput, 3),
+		}},
+	}
+	rc := modules.RecoverableContract{
+		FileContract: types.FileContract{
+			ValidProofOutputs: make([]types.SiacoinOutput, 2),
+		},
+		StartHeight: 20,
+	}
+	_, err = cs.InsertContract(rc, revTxn, nil, crypto.SecretKey{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		c.renewedTo[types.FileContractID{byte(i)}] = types.FileContractID{byte(i + 1)}
+		c.renewedFrom[types.FileContractID{byte(i + 1)}] = types.FileContractID{byte(i)}
+	}
+
+	// Querying any contract of the chain should return the whole chain.
+	for i := 0; i < 3; i++ {
+		chain, err := c.RenewalChain(types.FileContractID{byte(i)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(chain) != 3 {
+			t.Fatalf("expected chain of length 3 but got %v", len(chain))
+		}
+		for j, link := range chain {
+			if link.ID != (types.FileContractID{byte(j)}) {
+				t.Fatalf("link %v has wrong id %v", j, link.ID)
+			}
+			if link.StartHeight != types.BlockHeight(j*10) {
+				t.Fatalf("link %v has wrong start height %v", j, link.StartHeight)
+			}
+			if link.Active != (j == 2) {
+				t.Fatalf("link %v has wrong active status %v", j, link.Active)
+			}
+		}
+		if !chain[1].Spending.Equals(types.NewCurrency64(2)) {
+			t.Fatal("wrong spending", chain[1].Spending)
+		}
+	}
+
+	// Unknown contracts should return an error.
+	_, err = c.RenewalChain(types.FileContractID{3})
+	if !errors.Contains(err, errContractNotFound) {
+		t.Fatal("expected errContractNotFound but got", err)
+	}
+}
+
+// TestQueryContracts tests filtering, sorting and paginating the current
+// contracts.
+func TestQueryContracts(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	dir := build.TempDir("contractor", t.Name())
+	cs, err := proto.NewContractSet(filepath.Join(dir, "contracts"), ratelimit.NewRateLimit(0, 0, 0), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Contractor{
+		staticContracts: cs,
+		oldContracts:    make(map[types.FileContractID]modules.RenterContract),
+		renewedTo:       make(map[types.FileContractID]types.FileContractID),
+	}
+
+	// Insert an active, a passive and a disabled contract with hostA and an
+	// active contract with hostB.
+	hostA := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: []byte("hostA")}
+	hostB := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: []byte("hostB")}
+	active := modules.ContractUtility{GoodForUpload: true, GoodForRenew: true}
+	contracts := []struct {
+		host        types.SiaPublicKey
+		windowStart types.BlockHeight
+		txnFee      uint64
+		utility     modules.ContractUtility
+	}{
+		{hostA, 40, 1, active},
+		{hostA, 10, 4, modules.ContractUtility{GoodForRenew: true}},
+		{hostA, 30, 2, modules.ContractUtility{}},
+		{hostB, 20, 3, active},
+	}
+	ids := make([]types.FileContractID, len(contracts))
+	for i, contract := range contracts {
+		ids[i] = types.FileContractID{byte(i)}
+		revTxn := types.Transaction{
+			FileContractRevisions: []types.FileContractRevision{{
+				ParentID: ids[i],
+				UnlockConditions: types.UnlockConditions{
+					PublicKeys:         []types.SiaPublicKey{{}, contract.host},
+					SignaturesRequired: 2,
+				},
+				NewWindowStart:        contract.windowStart,
+				NewValidProofOutputs:  make([]types.SiacoinOutput, 2),
+				NewMissedProofOutputs: make([]types.SiacoinOutput, 3),
+			}},
+		}
+		rc := modules.RecoverableContract{
+			FileContract: types.FileContract{ValidProofOutputs: make([]types.SiacoinOutput, 2)},
+			TxnFee:       types.NewCurrency64(contract.txnFee),
+		}
+		if _, err := cs.InsertContract(rc, revTxn, nil, crypto.SecretKey{}); err != nil {
+			t.Fatal(err)
+		}
+		sc, ok := cs.Acquire(ids[i])
+		if !ok {
+			t.Fatal("contract wasn't inserted")
+		}
+		err = sc.UpdateUtility(contract.utility)
+		cs.Return(sc)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		query    modules.ContractQuery
+		expected []int
+		total    uint64
+	}{
+		{"all", modules.ContractQuery{}, []int{0, 1, 2, 3}, 4},
+		{"active", modules.ContractQuery{Statuses: []modules.ContractStatus{modules.ContractStatusActive}}, []int{0, 3}, 2},
+		{"active and passive", modules.ContractQuery{Statuses: []modules.ContractStatus{modules.ContractStatusActive, modules.ContractStatusPassive}}, []int{0, 1, 3}, 3},
+		{"disabled", modules.ContractQuery{Statuses: []modules.ContractStatus{modules.ContractStatusDisabled}}, []int{2}, 1},
+		{"host", modules.ContractQuery{Host: hostB}, []int{3}, 1},
+		{"endheight", modules.ContractQuery{SortBy: modules.ContractSortEndHeight}, []int{1, 3, 2, 0}, 4},
+		{"spending desc", modules.ContractQuery{SortBy: modules.ContractSortSpending, Descending: true}, []int{1, 3, 2, 0}, 4},
+		{"id desc", modules.ContractQuery{Descending: true}, []int{3, 2, 1, 0}, 4},
+		{"page", modules.ContractQuery{SortBy: modules.ContractSortEndHeight, Offset: 1, Limit: 2}, []int{3, 2}, 4},
+		{"filtered page", modules.ContractQuery{Host: hostA, SortBy: modules.ContractSortEndHeight, Offset: 2, Limit: 2}, []int{0}, 3},
+		{"past the end", modules.ContractQuery{Offset: 4}, nil, 4},
+	}
+	for _, test := range tests {
+		page, total, err := c.QueryContracts(test.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if total != test.total || len(page) != len(test.expected) {
+			t.Fatalf("%v: expected %v of %v contracts but got %v of %v", test.name, len(test.expected), test.total, len(page), total)
+		}
+		for i, contract := range page {
+			if contract.ID != ids[test.expected[i]] {
+				t.Fatalf("%v: expected contract %v at %v but got %v", test.name, test.expected[i], i, contract.ID)
+			}
+		}
+	}
+
+	// Unknown statuses and sort fields are rejected.
+	if _, _, err := c.QueryContracts(modules.ContractQuery{Statuses: []modules.ContractStatus{"expired"}}); !errors.Contains(err, errUnknownContractStatus) {
+		t.Fatal("expected errUnknownContractStatus but got", err)
+	}
+	if _, _, err := c.QueryContracts(modules.ContractQuery{SortBy: "size"}); !errors.Contains(err, errUnknownContractSort) {
+		t.Fatal("expected errUnknownContractSort but got", err)
+	}
+}
+
+// TestPeriodSpendingBreakdown tests splitting the period spending by category
+// and by host.
+func TestPeriodSpendingBreakdown(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	dir := build.TempDir("contractor", t.Name())
+	cs, err := proto.NewContractSet(filepath.Join(dir, "contracts"), ratelimit.NewRateLimit(0, 0, 0), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Contractor{
+		staticContracts:      cs,
+		oldContracts:         make(map[types.FileContractID]modules.RenterContract),
+		doubleSpentContracts: make(map[types.FileContractID]types.BlockHeight),
+		currentPeriod:        100,
+	}
+
+	// Insert two contracts with hostA and a double-spent contract with hostB.
+	hostA := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: []byte("hostA")}
+	hostB := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: []byte("hostB")}
+	for i, host := range []types.SiaPublicKey{hostA, hostA, hostB} {
+		revTxn := types.Transaction{
+			FileContractRevisions: []types.FileContractRevision{{
+				ParentID: types.FileContractID{byte(i)},
+				UnlockConditions: types.UnlockConditions{
+					PublicKeys:         []types.SiaPublicKey{{}, host},
+					SignaturesRequired: 2,
+				},
+				NewValidProofOutputs:  make([]types.SiacoinOutput, 2),
+				NewMissedProofOutputs: make([]types.SiacoinOutput, 3),
+			}},
+		}
+		rc := modules.RecoverableContract{
+			FileContract: types.FileContract{ValidProofOutputs: make([]types.SiacoinOutput, 2)},
+			TxnFee:       types.NewCurrency64(10),
+		}
+		if _, err := cs.InsertContract(rc, revTxn, nil, crypto.SecretKey{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c.doubleSpentContracts[types.FileContractID{2}] = 90
+
+	// Add an old contract with hostB from the current period and one with
+	// hostA from the previous period.
+	c.oldContracts[types.FileContractID{3}] = modules.RenterContract{
+		ID:              types.FileContractID{3},
+		HostPublicKey:   hostB,
+		StartHeight:     100,
+		StorageSpending: types.NewCurrency64(40),
+		UploadSpending:  types.NewCurrency64(8),
+		ContractFee:     types.NewCurrency64(2),
+		SiafundFee:      types.NewCurrency64(10),
+	}
+	c.oldContracts[types.FileContractID{4}] = modules.RenterContract{
+		ID:              types.FileContractID{4},
+		HostPublicKey:   hostA,
+		StartHeight:     50,
+		StorageSpending: types.NewCurrency64(1000),
+	}
+
+	breakdown, err := c.PeriodSpendingBreakdown()
+	if err != nil {
+		t.Fatal(err)
+	}
+	total := breakdown.Total
+	if breakdown.Period != 100 || !total.Storage.Equals64(40) || !total.Upload.Equals64(8) || !total.Fees.Equals64(22) || !total.SiafundTax.Equals64(10) || !total.Sum().Equals64(80) {
+		t.Fatalf("wrong total for period %v: %+v", breakdown.Period, total)
+	}
+	if len(breakdown.Hosts) != 2 {
+		t.Fatal("expected 2 hosts but got", len(breakdown.Hosts))
+	}
+	hb, ha := breakdown.Hosts[0], breakdown.Hosts[1]
+	if !hb.HostPublicKey.Equals(hostB) || hb.Contracts != 1 || !hb.Sum().Equals64(60) || !hb.Storage.Equals64(40) {
+		t.Fatalf("wrong spending for hostB: %+v", hb)
+	}
+	if !ha.HostPublicKey.Equals(hostA) || ha.Contracts != 2 || !ha.Fees.Equals64(20) || !ha.Sum().Equals64(20) {
+		t.Fatalf("wrong spending for hostA: %+v", ha)
+	}
+
+}
@@ -530,11 +530,35 @@ func TestIntegrationRenew(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	contract, err = c.managedRenew(contract.ID, contract.HostPublicKey, types.SiacoinPrecision.Mul64(50), c.blockHeight+200, hostSettings)
+	oldID := contract.ID
+	receipt, contract, err := c.managedRenew(contract.ID, contract.HostPublicKey, types.SiacoinPrecision.Mul64(50), c.blockHeight+200, hostSettings)
 	if err != nil {
 		t.Fatal(err)
 	}
 
+	// check the receipt of the renewal. The funding covers the costs and the
+	// renter's funds.
+	if receipt.ContractID != contract.ID || receipt.RenewedFrom != oldID {
+		t.Fatal("receipt has the wrong contract ids")
+	}
+	if !receipt.Funding.Equals(types.SiacoinPrecision.Mul64(50)) {
+		t.Fatal("wrong funding", receipt.Funding)
+	}
+	costs := receipt.ContractPrice.Add(receipt.SiafundFee).Add(receipt.TxnFee).Add(receipt.StorageCost)
+	if !costs.Add(receipt.RenterFunds).Equals(receipt.Funding) {
+		t.Fatal("receipt doesn't add up", costs, receipt.RenterFunds, receipt.Funding)
+	}
+	if receipt.HostCollateral.IsZero() {
+		t.Fatal("receipt doesn't contain the host's collateral")
+	}
+	var recorded bool
+	for _, r := range c.ContractReceipts() {
+		recorded = recorded || r.ContractID == receipt.ContractID
+	}
+	if !recorded || len(c.ContractReceipts()) != 2 {
+		t.Fatal("receipts weren't recorded", c.ContractReceipts())
+	}
+
 	// check renewed contract
 	if contract.EndHeight != c.blockHeight+200 {
 		t.Fatal(contract.EndHeight)
@@ -562,7 +586,7 @@ func TestIntegrationRenew(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	contract, err = c.managedRenew(contract.ID, contract.HostPublicKey, types.SiacoinPrecision.Mul64(50), c.blockHeight+100, hostSettings)
+	_, contract, err = c.managedRenew(contract.ID, contract.HostPublicKey, types.SiacoinPrecision.Mul64(50), c.blockHeight+100, hostSettings)
 	if err != nil {
 		t.Fatal(err)
 	}
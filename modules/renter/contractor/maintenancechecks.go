@@ -74,6 +74,7 @@ func (c *Contractor) managedCheckHostScore(contract modules.RenterContract, sb m
 			c.log.Println("Age Adjustment:        ", sb.AgeAdjustment)
 			c.log.Println("Base Price Adjustment: ", sb.BasePriceAdjustment)
 			c.log.Println("Burn Adjustment:       ", sb.BurnAdjustment)
+			c.log.Println("Capability Adjustment: ", sb.CapabilityAdjustment)
 			c.log.Println("Collateral Adjustment: ", sb.CollateralAdjustment)
 			c.log.Println("Duration Adjustment:   ", sb.DurationAdjustment)
 			c.log.Println("Interaction Adjustment:", sb.InteractionAdjustment)
@@ -104,6 +105,7 @@ func (c *Contractor) managedCheckHostScore(contract modules.RenterContract, sb m
 			c.log.Println("Age Adjustment:        ", sb.AgeAdjustment)
 			c.log.Println("Base Price Adjustment: ", sb.BasePriceAdjustment)
 			c.log.Println("Burn Adjustment:       ", sb.BurnAdjustment)
+			c.log.Println("Capability Adjustment: ", sb.CapabilityAdjustment)
 			c.log.Println("Collateral Adjustment: ", sb.CollateralAdjustment)
 			c.log.Println("Duration Adjustment:   ", sb.DurationAdjustment)
 			c.log.Println("Interaction Adjustment:", sb.InteractionAdjustment)
@@ -139,6 +141,7 @@ func (c *Contractor) managedCriticalUtilityChecks(sc *proto.SafeContract, host m
 	blockHeight := c.blockHeight
 	renewWindow := c.allowance.RenewWindow
 	period := c.allowance.Period
+	uploadThreshold, _ := allowanceFundThresholds(c.allowance)
 	_, renewed := c.renewedTo[contract.ID]
 	c.mu.RUnlock()
 
@@ -168,7 +171,7 @@ func (c *Contractor) managedCriticalUtilityChecks(sc *proto.SafeContract, host m
 		return u, needsUpdate
 	}
 
-	u, needsUpdate = c.sufficientFundsCheck(contract, host, period)
+	u, needsUpdate = c.sufficientFundsCheck(contract, host, period, uploadThreshold)
 	if needsUpdate {
 		return u, needsUpdate
 	}
@@ -247,10 +250,11 @@ func (c *Contractor) upForRenewalCheck(contract modules.RenterContract, renewWin
 }
 
 // sufficientFundsCheck checks if there are enough funds left in the contract
-// for uploads.
+// for uploads. Contracts with less than uploadThreshold of their funds
+// remaining are considered to be out of funds.
 // Returns true if a check fails and the utility returned must be used to update
 // the contract state.
-func (c *Contractor) sufficientFundsCheck(contract modules.RenterContract, host modules.HostDBEntry, period types.BlockHeight) (modules.ContractUtility, bool) {
+func (c *Contractor) sufficientFundsCheck(contract modules.RenterContract, host modules.HostDBEntry, period types.BlockHeight, uploadThreshold float64) (modules.ContractUtility, bool) {
 	u := contract.Utility
 
 	// Contract should not be used for uploading if the contract does
@@ -262,7 +266,7 @@ func (c *Contractor) sufficientFundsCheck(contract modules.RenterContract, host
 	sectorBandwidthPrice := sectorUploadBandwidthPrice.Add(sectorDownloadBandwidthPrice)
 	sectorPrice := sectorStoragePrice.Add(sectorBandwidthPrice)
 	percentRemaining, _ := big.NewRat(0, 1).SetFrac(contract.RenterFunds.Big(), contract.TotalCost.Big()).Float64()
-	if contract.RenterFunds.Cmp(sectorPrice.Mul64(3)) < 0 || percentRemaining < MinContractFundUploadThreshold {
+	if contract.RenterFunds.Cmp(sectorPrice.Mul64(3)) < 0 || percentRemaining < uploadThreshold {
 		if u.GoodForUpload {
 			c.log.Printf("Marking contract as not good for upload because of insufficient funds: %v vs. %v - %v", contract.RenterFunds.Cmp(sectorPrice.Mul64(3)) < 0, percentRemaining, contract.ID)
 		}
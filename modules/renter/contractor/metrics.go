@@ -0,0 +1,15 @@
+package contractor
+
+import (
+	"go.sia.tech/siad/metrics"
+)
+
+var (
+	// maintenanceRunsMetric counts the runs of the contract maintenance.
+	maintenanceRunsMetric = metrics.NewCounter("siad_contractor_maintenance_runs_total", "Number of contract maintenance runs.")
+
+	// maintenanceContractsMetric counts the outcomes of the contract
+	// formations, renewals and refreshes performed by the contract
+	// maintenance.
+	maintenanceContractsMetric = metrics.NewCounterVec("siad_contractor_maintenance_contracts_total", "Number of contracts formed, renewed and refreshed by the contract maintenance, by outcome.", "outcome")
+)
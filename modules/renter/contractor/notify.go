@@ -0,0 +1,159 @@
+package contractor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+// notify.go contains the notifiers of the contractor. Notifiers are called
+// when contracts fail to renew repeatedly, when contracts get locked and when
+// the allowance runs low on funds, so that operators learn about problems
+// before the redundancy of their files degrades.
+
+// webhookNotifierTimeout is the timeout of a single webhook request.
+const webhookNotifierTimeout = 30 * time.Second
+
+type (
+	// webhookNotifier posts notifications as JSON to a URL.
+	webhookNotifier struct {
+		staticClient *http.Client
+		staticURL    string
+	}
+
+	// emailNotifier is a stub for a notifier which sends notifications by
+	// email. It only logs the emails it would send.
+	emailNotifier struct {
+		staticLog     *persist.Logger
+		staticAddress string
+	}
+)
+
+// NewWebhookNotifier returns a notifier which posts notifications as JSON to
+// the provided URL.
+func NewWebhookNotifier(url string) modules.ContractorNotifier {
+	return &webhookNotifier{
+		staticClient: &http.Client{Timeout: webhookNotifierTimeout},
+		staticURL:    url,
+	}
+}
+
+// Notify implements the modules.ContractorNotifier interface.
+func (wn *webhookNotifier) Notify(n modules.ContractorNotification) error {
+	b, err := json.Marshal(n)
+	if err != nil {
+		return errors.AddContext(err, "failed to marshal notification")
+	}
+	resp, err := wn.staticClient.Post(wn.staticURL, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return errors.AddContext(err, "failed to post notification")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned status %v", resp.Status)
+	}
+	return nil
+}
+
+// NewEmailNotifier returns a notifier stub which logs the emails it would send
+// to the provided address.
+func NewEmailNotifier(address string, log *persist.Logger) modules.ContractorNotifier {
+	return &emailNotifier{
+		staticLog:     log,
+		staticAddress: address,
+	}
+}
+
+// Notify implements the modules.ContractorNotifier interface.
+func (en *emailNotifier) Notify(n modules.ContractorNotification) error {
+	en.staticLog.Printf("Email to %v: [%v] %v", en.staticAddress, n.Type, n.Message)
+	return nil
+}
+
+// RegisterNotifier registers a notifier which is notified about renewal
+// failures, locked contracts and a low allowance.
+func (c *Contractor) RegisterNotifier(n modules.ContractorNotifier) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.notifiers = append(c.notifiers, n)
+}
+
+// managedNotify sends a notification to all registered notifiers without
+// blocking the caller.
+func (c *Contractor) managedNotify(n modules.ContractorNotification) {
+	c.mu.RLock()
+	notifiers := append([]modules.ContractorNotifier(nil), c.notifiers...)
+	n.BlockHeight = c.blockHeight
+	c.mu.RUnlock()
+	c.log.Printf("Notification %v: %v", n.Type, n.Message)
+	for _, notifier := range notifiers {
+		go c.threadedNotify(notifier, n)
+	}
+}
+
+// threadedNotify sends a notification to a single notifier.
+func (c *Contractor) threadedNotify(notifier modules.ContractorNotifier, n modules.ContractorNotification) {
+	if err := c.tg.Add(); err != nil {
+		return
+	}
+	defer c.tg.Done()
+	if err := notifier.Notify(n); err != nil {
+		c.log.Printf("WARN: failed to send %v notification: %v", n.Type, err)
+	}
+}
+
+// managedNotifyRenewalFailures notifies about a contract which failed to renew
+// the given number of times in a row. Only the failure which reaches
+// renewalFailuresBeforeNotification is reported to avoid a notification for
+// every failed attempt.
+func (c *Contractor) managedNotifyRenewalFailures(md modules.RenterContract, failures types.BlockHeight, renewErr error) {
+	if failures != renewalFailuresBeforeNotification {
+		return
+	}
+	c.managedNotify(modules.ContractorNotification{
+		Type:                modules.ContractorNotificationRenewalFailures,
+		Message:             fmt.Sprintf("contract %v failed to renew %v times in a row: %v", md.ID, failures, renewErr),
+		ContractID:          md.ID,
+		HostPublicKey:       md.HostPublicKey,
+		ConsecutiveFailures: failures,
+	})
+}
+
+// managedNotifyContractLocked notifies about a contract which was locked after
+// too many failed renewals.
+func (c *Contractor) managedNotifyContractLocked(md modules.RenterContract, failures types.BlockHeight, renewErr error) {
+	c.managedNotify(modules.ContractorNotification{
+		Type:                modules.ContractorNotificationContractLocked,
+		Message:             fmt.Sprintf("contract %v was locked after %v failed renewals: %v", md.ID, failures, renewErr),
+		ContractID:          md.ID,
+		HostPublicKey:       md.HostPublicKey,
+		ConsecutiveFailures: failures,
+	})
+}
+
+// managedCheckLowAllowance notifies about the allowance running low on funds.
+// The notification is only sent once until the remaining funds recover above
+// the threshold.
+func (c *Contractor) managedCheckLowAllowance(allowance modules.Allowance, fundsRemaining types.Currency) {
+	low := !allowance.Funds.IsZero() && fundsRemaining.Cmp(allowance.Funds.MulFloat(lowAllowanceNotificationThreshold)) < 0
+	c.mu.Lock()
+	notify := low && !c.lowAllowanceNotified
+	c.lowAllowanceNotified = low
+	c.mu.Unlock()
+	if !notify {
+		return
+	}
+	c.managedNotify(modules.ContractorNotification{
+		Type:           modules.ContractorNotificationLowAllowance,
+		Message:        fmt.Sprintf("only %v of the allowance of %v remain", fundsRemaining.HumanString(), allowance.Funds.HumanString()),
+		AllowanceFunds: allowance.Funds,
+		FundsRemaining: fundsRemaining,
+	})
+}
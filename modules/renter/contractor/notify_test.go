@@ -0,0 +1,121 @@
+package contractor
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+// TestNotifiers tests that registered notifiers are notified about renewal
+// failures, locked contracts and a low allowance.
+func TestNotifiers(t *testing.T) {
+	log, err := persist.NewLogger(ioutil.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Contractor{
+		blockHeight: 10,
+		log:         log,
+	}
+	notifications := make(chan modules.ContractorNotification, 10)
+	c.RegisterNotifier(modules.ContractorNotifierFunc(func(n modules.ContractorNotification) error {
+		notifications <- n
+		return nil
+	}))
+	next := func() modules.ContractorNotification {
+		select {
+		case n := <-notifications:
+			return n
+		case <-time.After(10 * time.Second):
+			t.Fatal("notification wasn't sent")
+		}
+		return modules.ContractorNotification{}
+	}
+	none := func() {
+		select {
+		case n := <-notifications:
+			t.Fatal("unexpected notification", n)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	// Renewal failures are only reported once they reach the threshold.
+	contract := modules.RenterContract{ID: types.FileContractID{1}}
+	renewErr := errors.New("renewal failed")
+	c.managedNotifyRenewalFailures(contract, renewalFailuresBeforeNotification-1, renewErr)
+	none()
+	c.managedNotifyRenewalFailures(contract, renewalFailuresBeforeNotification, renewErr)
+	n := next()
+	if n.Type != modules.ContractorNotificationRenewalFailures || n.ContractID != contract.ID || n.BlockHeight != 10 {
+		t.Fatal("wrong notification", n)
+	}
+	c.managedNotifyRenewalFailures(contract, renewalFailuresBeforeNotification+1, renewErr)
+	none()
+
+	// Locked contracts are always reported.
+	c.managedNotifyContractLocked(contract, consecutiveRenewalsBeforeReplacement, renewErr)
+	if n := next(); n.Type != modules.ContractorNotificationContractLocked || n.ContractID != contract.ID {
+		t.Fatal("wrong notification", n)
+	}
+
+	// A low allowance is reported once until the funds recover.
+	allowance := modules.Allowance{Funds: types.NewCurrency64(1000)}
+	c.managedCheckLowAllowance(allowance, types.NewCurrency64(500))
+	none()
+	c.managedCheckLowAllowance(allowance, types.NewCurrency64(50))
+	if n := next(); n.Type != modules.ContractorNotificationLowAllowance || !n.FundsRemaining.Equals64(50) {
+		t.Fatal("wrong notification", n)
+	}
+	c.managedCheckLowAllowance(allowance, types.NewCurrency64(40))
+	none()
+	c.managedCheckLowAllowance(allowance, types.NewCurrency64(500))
+	c.managedCheckLowAllowance(allowance, types.NewCurrency64(40))
+	if n := next(); n.Type != modules.ContractorNotificationLowAllowance {
+		t.Fatal("wrong notification", n)
+	}
+	// No allowance is never low.
+	c.managedCheckLowAllowance(modules.Allowance{}, types.ZeroCurrency)
+	c.managedCheckLowAllowance(modules.Allowance{}, types.ZeroCurrency)
+	none()
+}
+
+// TestWebhookNotifier tests that the webhook notifier posts notifications as
+// JSON.
+func TestWebhookNotifier(t *testing.T) {
+	var received modules.ContractorNotification
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if err := json.NewDecoder(req.Body).Decode(&received); err != nil {
+			t.Error(err)
+		}
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	wn := NewWebhookNotifier(srv.URL)
+	n := modules.ContractorNotification{
+		Type:       modules.ContractorNotificationContractLocked,
+		ContractID: types.FileContractID{1},
+		Message:    "locked",
+	}
+	if err := wn.Notify(n); err != nil {
+		t.Fatal(err)
+	}
+	if received.Type != n.Type || received.ContractID != n.ContractID || received.Message != n.Message {
+		t.Fatal("wrong notification received", received)
+	}
+
+	// Errors returned by the webhook are returned.
+	status = http.StatusInternalServerError
+	if err := wn.Notify(n); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -37,6 +37,7 @@ type contractorPersist struct {
 	RecoverableContracts []modules.RecoverableContract   `json:"recoverablecontracts"`
 	RenewedFrom          map[string]types.FileContractID `json:"renewedfrom"`
 	RenewedTo            map[string]types.FileContractID `json:"renewedto"`
+	Receipts             []modules.ContractReceipt       `json:"receipts"`
 	Synced               bool                            `json:"synced"`
 
 	// Subsystem persistence:
@@ -78,6 +79,9 @@ func (c *Contractor) persistData() contractorPersist {
 	for _, contract := range c.recoverableContracts {
 		data.RecoverableContracts = append(data.RecoverableContracts, contract)
 	}
+	for _, receipt := range c.receipts {
+		data.Receipts = append(data.Receipts, receipt)
+	}
 	data.ChurnLimiter = c.staticChurnLimiter.callPersistData()
 	data.WatchdogData = c.staticWatchdog.callPersistData()
 	return data
@@ -147,6 +151,9 @@ func (c *Contractor) load() error {
 	for _, contract := range data.RecoverableContracts {
 		c.recoverableContracts[contract.ID] = contract
 	}
+	for _, receipt := range data.Receipts {
+		c.receipts[receipt.ContractID] = receipt
+	}
 
 	c.staticChurnLimiter = newChurnLimiterFromPersist(c, data.ChurnLimiter)
 
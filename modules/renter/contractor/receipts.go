@@ -0,0 +1,73 @@
+package contractor
+
+import (
+	"sort"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// receipts.go contains the receipts of formed and renewed contracts. A receipt
+// is recorded as soon as a contract was negotiated with the host, even if the
+// contractor fails to finish setting it up afterwards, since the funds are
+// spent either way.
+
+// newContractReceipt returns the receipt of a contract which was just formed
+// or renewed.
+func newContractReceipt(contract modules.RenterContract, renewedFrom types.FileContractID) modules.ContractReceipt {
+	receipt := modules.ContractReceipt{
+		ContractID:    contract.ID,
+		RenewedFrom:   renewedFrom,
+		HostPublicKey: contract.HostPublicKey,
+		StartHeight:   contract.StartHeight,
+		EndHeight:     contract.EndHeight,
+
+		Funding:       contract.TotalCost,
+		ContractPrice: contract.ContractFee,
+		SiafundFee:    contract.SiafundFee,
+		TxnFee:        contract.TxnFee,
+		StorageCost:   contract.StorageSpending,
+		RenterFunds:   contract.RenterFunds,
+	}
+
+	// The host's payout covers the contract price, the storage cost and the
+	// collateral of the host.
+	if len(contract.Transaction.FileContractRevisions) == 0 {
+		return receipt
+	}
+	hostPayout := contract.Transaction.FileContractRevisions[0].ValidHostPayout()
+	hostRevenue := receipt.ContractPrice.Add(receipt.StorageCost)
+	if hostPayout.Cmp(hostRevenue) > 0 {
+		receipt.HostCollateral = hostPayout.Sub(hostRevenue)
+	}
+	return receipt
+}
+
+// managedRecordContractReceipt adds the receipt of a contract to the
+// contractor and saves it.
+func (c *Contractor) managedRecordContractReceipt(receipt modules.ContractReceipt) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.receipts[receipt.ContractID] = receipt
+	if err := c.save(); err != nil {
+		c.log.Println("Unable to save the contractor after recording a contract receipt:", err)
+	}
+}
+
+// ContractReceipts returns the receipts of the contracts the contractor formed
+// and renewed, ordered by their start height.
+func (c *Contractor) ContractReceipts() []modules.ContractReceipt {
+	c.mu.RLock()
+	receipts := make([]modules.ContractReceipt, 0, len(c.receipts))
+	for _, receipt := range c.receipts {
+		receipts = append(receipts, receipt)
+	}
+	c.mu.RUnlock()
+	sort.Slice(receipts, func(i, j int) bool {
+		if receipts[i].StartHeight != receipts[j].StartHeight {
+			return receipts[i].StartHeight < receipts[j].StartHeight
+		}
+		return receipts[i].ContractID.String() < receipts[j].ContractID.String()
+	})
+	return receipts
+}
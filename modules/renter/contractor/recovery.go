@@ -30,6 +30,11 @@ import (
 type recoveryScanner struct {
 	c  *Contractor
 	rs modules.RenterSeed
+
+	// hostKeys are the keys of the hosts the scanner matches contracts
+	// against which don't carry a contract identifier. It is only set for
+	// full scans.
+	hostKeys []types.SiaPublicKey
 }
 
 // newRecoveryScanner creates a new scanner from a seed.
@@ -40,6 +45,26 @@ func (c *Contractor) newRecoveryScanner(rs modules.RenterSeed) *recoveryScanner
 	}
 }
 
+// newFullRecoveryScanner creates a new scanner from a seed which also
+// recovers contracts without a contract identifier. Their host can't be
+// decrypted from the transaction, so the scanner tries all hosts of the
+// hostdb instead.
+func (c *Contractor) newFullRecoveryScanner(rs modules.RenterSeed) (*recoveryScanner, error) {
+	hosts, err := c.hdb.AllHosts()
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to get hosts from hostdb")
+	}
+	hostKeys := make([]types.SiaPublicKey, 0, len(hosts))
+	for _, host := range hosts {
+		hostKeys = append(hostKeys, host.PublicKey)
+	}
+	return &recoveryScanner{
+		c:        c,
+		rs:       rs,
+		hostKeys: hostKeys,
+	}, nil
+}
+
 // threadedScan subscribes the scanner to cs and scans the blockchain for
 // filecontracts belonging to the wallet's seed. Once done, all recoverable
 // contracts should be known to the contractor after which it will periodically
@@ -81,7 +106,7 @@ func (rs *recoveryScanner) ProcessConsensusChange(cc modules.ConsensusChange) {
 	for _, block := range cc.AppliedBlocks {
 		// Find lost contracts for recovery.
 		rs.c.mu.Lock()
-		rs.c.findRecoverableContracts(rs.rs, block)
+		rs.c.findRecoverableContracts(rs.rs, block, rs.hostKeys)
 		rs.c.mu.Unlock()
 	}
 
@@ -95,12 +120,13 @@ func (rs *recoveryScanner) ProcessConsensusChange(cc modules.ConsensusChange) {
 // findRecoverableContracts scans the block for contracts that could
 // potentially be recovered. We are not going to recover them right away though
 // since many of them could already be expired. Recovery happens periodically
-// in threadedContractMaintenance.
-func (c *Contractor) findRecoverableContracts(renterSeed modules.RenterSeed, b types.Block) {
+// in threadedContractMaintenance. Contracts without a contract identifier are
+// only found if hostKeys contains the key of the host they were formed with.
+func (c *Contractor) findRecoverableContracts(renterSeed modules.RenterSeed, b types.Block, hostKeys []types.SiaPublicKey) {
 	for _, txn := range b.Transactions {
 		// Check if the arbitrary data starts with the correct prefix.
 		csi, encryptedHostKey, hasIdentifier := hasFCIdentifier(txn)
-		if !hasIdentifier {
+		if !hasIdentifier && (len(hostKeys) == 0 || len(txn.FileContracts) == 0 || len(txn.SiacoinInputs) == 0) {
 			continue
 		}
 		// Get the total txnFees of the transaction.
@@ -114,27 +140,26 @@ func (c *Contractor) findRecoverableContracts(renterSeed modules.RenterSeed, b t
 			// afterwards.
 			rs := renterSeed.EphemeralRenterSeed(fc.WindowStart)
 			defer fastrand.Read(rs[:])
-			// Validate the identifier.
-			hostKey, valid, err := csi.IsValid(rs, txn, encryptedHostKey)
-			if err != nil && !errors.Contains(err, modules.ErrCSIDoesNotMatchSeed) {
-				c.log.Println("WARN: error validating the identifier:", err)
-				continue
-			}
-			if !valid {
-				continue
+			// Validate the identifier. Without one, every host is a
+			// candidate.
+			candidates := hostKeys
+			if hasIdentifier {
+				hostKey, valid, err := csi.IsValid(rs, txn, encryptedHostKey)
+				if err != nil && !errors.Contains(err, modules.ErrCSIDoesNotMatchSeed) {
+					c.log.Println("WARN: error validating the identifier:", err)
+					continue
+				}
+				if !valid {
+					continue
+				}
+				candidates = []types.SiaPublicKey{hostKey}
 			}
 			// Make sure the contract belongs to us by comparing the unlock
 			// hash to what we would expect.
 			ourSK, ourPK := modules.GenerateContractKeyPair(rs, txn)
 			defer fastrand.Read(ourSK[:])
-			uc := types.UnlockConditions{
-				PublicKeys: []types.SiaPublicKey{
-					types.Ed25519PublicKey(ourPK),
-					hostKey,
-				},
-				SignaturesRequired: 2,
-			}
-			if fc.UnlockHash != uc.UnlockHash() {
+			hostKey, found := findContractHost(fc.UnlockHash, ourPK, candidates)
+			if !found {
 				continue
 			}
 			// Make sure we don't know about that contract already.
@@ -162,6 +187,24 @@ func (c *Contractor) findRecoverableContracts(renterSeed modules.RenterSeed, b t
 	}
 }
 
+// findContractHost returns the host key out of hostKeys which forms the
+// unlock hash uh together with the renter's contract key ourPK.
+func findContractHost(uh types.UnlockHash, ourPK crypto.PublicKey, hostKeys []types.SiaPublicKey) (types.SiaPublicKey, bool) {
+	for _, hostKey := range hostKeys {
+		uc := types.UnlockConditions{
+			PublicKeys: []types.SiaPublicKey{
+				types.Ed25519PublicKey(ourPK),
+				hostKey,
+			},
+			SignaturesRequired: 2,
+		}
+		if uc.UnlockHash() == uh {
+			return hostKey, true
+		}
+	}
+	return types.SiaPublicKey{}, false
+}
+
 // managedRecoverContract recovers a single contract by contacting the host it
 // was formed with and retrieving the latest revision and sector roots.
 func (c *Contractor) managedRecoverContract(rc modules.RecoverableContract, rs modules.EphemeralRenterSeed, blockHeight types.BlockHeight) (err error) {
@@ -177,7 +220,7 @@ func (c *Contractor) managedRecoverContract(rc modules.RecoverableContract, rs m
 	sk, _ := modules.GenerateContractKeyPairWithOutputID(rs, rc.InputParentID)
 	defer fastrand.Read(sk[:])
 	// Start a new RPC session.
-	s, err := c.staticContracts.NewRawSession(host, blockHeight, c.hdb, c.tg.StopChan())
+	s, err := c.staticContracts.NewRawSession(c.tg.StopCtx(), host, blockHeight, c.hdb)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,106 @@
+package contractor
+
+import (
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/NebulousLabs/fastrand"
+	"gitlab.com/NebulousLabs/ratelimit"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/proto"
+	"go.sia.tech/siad/types"
+)
+
+// TestFindRecoverableContracts tests that contracts with a contract identifier
+// are always found and that contracts without one are only found by full
+// scans.
+func TestFindRecoverableContracts(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	dir := build.TempDir("contractor", t.Name())
+	cs, err := proto.NewContractSet(filepath.Join(dir, "contracts"), ratelimit.NewRateLimit(0, 0, 0), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Contractor{
+		staticContracts:      cs,
+		recoverableContracts: make(map[types.FileContractID]modules.RecoverableContract),
+	}
+
+	var seed modules.Seed
+	fastrand.Read(seed[:])
+	renterSeed := modules.DeriveRenterSeed(seed)
+	hostKeys := []types.SiaPublicKey{
+		types.Ed25519PublicKey(crypto.PublicKey{1}),
+		types.Ed25519PublicKey(crypto.PublicKey{2}),
+	}
+
+	// contractTxn creates a transaction forming a contract between the
+	// renter and hostKey.
+	contractTxn := func(rs modules.RenterSeed, hostKey types.SiaPublicKey, withIdentifier bool) types.Transaction {
+		txn := types.Transaction{
+			SiacoinInputs: []types.SiacoinInput{{ParentID: types.SiacoinOutputID{}}},
+			FileContracts: []types.FileContract{{WindowStart: 100, WindowEnd: 200}},
+		}
+		fastrand.Read(txn.SiacoinInputs[0].ParentID[:])
+		ers := rs.EphemeralRenterSeed(txn.FileContracts[0].WindowStart)
+		_, ourPK := modules.GenerateContractKeyPair(ers, txn)
+		txn.FileContracts[0].UnlockHash = types.UnlockConditions{
+			PublicKeys:         []types.SiaPublicKey{types.Ed25519PublicKey(ourPK), hostKey},
+			SignaturesRequired: 2,
+		}.UnlockHash()
+		if withIdentifier {
+			csi, encryptedHostKey := modules.PrefixedSignedIdentifier(ers, txn, hostKey)
+			txn.ArbitraryData = [][]byte{append(csi[:], encryptedHostKey...)}
+		}
+		return txn
+	}
+
+	var otherSeed modules.Seed
+	fastrand.Read(otherSeed[:])
+	b := types.Block{
+		Transactions: []types.Transaction{
+			contractTxn(renterSeed, hostKeys[0], true),
+			contractTxn(renterSeed, hostKeys[1], false),
+			contractTxn(modules.DeriveRenterSeed(otherSeed), hostKeys[1], false),
+		},
+	}
+	identifiedID := b.Transactions[0].FileContractID(0)
+	unidentifiedID := b.Transactions[1].FileContractID(0)
+
+	// A regular scan only finds the contract with the identifier.
+	c.findRecoverableContracts(renterSeed, b, nil)
+	if len(c.recoverableContracts) != 1 {
+		t.Fatal("expected 1 recoverable contract but got", len(c.recoverableContracts))
+	}
+	if rc, ok := c.recoverableContracts[identifiedID]; !ok || !rc.HostPublicKey.Equals(hostKeys[0]) {
+		t.Fatal("contract with identifier wasn't found", rc)
+	}
+
+	// A full scan also finds the contract without an identifier but not the
+	// one formed by another seed.
+	c.findRecoverableContracts(renterSeed, b, hostKeys)
+	if len(c.recoverableContracts) != 2 {
+		t.Fatal("expected 2 recoverable contracts but got", len(c.recoverableContracts))
+	}
+	rc, ok := c.recoverableContracts[unidentifiedID]
+	if !ok || !rc.HostPublicKey.Equals(hostKeys[1]) {
+		t.Fatal("contract without identifier wasn't found", rc)
+	}
+	if rc.InputParentID != b.Transactions[1].SiacoinInputs[0].ParentID {
+		t.Fatal("wrong input parent id")
+	}
+
+	// Without the host in the hostdb the contract can't be found.
+	delete(c.recoverableContracts, unidentifiedID)
+	c.findRecoverableContracts(renterSeed, b, hostKeys[:1])
+	if _, ok := c.recoverableContracts[unidentifiedID]; ok {
+		t.Fatal("contract shouldn't be found without its host")
+	}
+}
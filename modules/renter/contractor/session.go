@@ -1,6 +1,7 @@
 package contractor
 
 import (
+	"context"
 	"sync"
 
 	"gitlab.com/NebulousLabs/errors"
@@ -47,18 +48,28 @@ type Session interface {
 	// determine whether or not an operation should continue.
 	HostSettings() modules.HostExternalSettings
 
+	// Prices returns the host settings which the session uses to calculate
+	// prices, fetching them from the host if they expired.
+	Prices() (modules.HostExternalSettings, error)
+
 	// Settings calls the Session RPC and updates the active host settings.
 	Settings() (modules.HostExternalSettings, error)
 
 	// Upload revises the underlying contract to store the new data. It
 	// returns the Merkle root of the data.
 	Upload(data []byte) (crypto.Hash, error)
+
+	// UploadStream revises the underlying contract to store multiple sectors,
+	// keeping several of them in flight at once. It returns the Merkle roots
+	// of the sectors.
+	UploadStream(sectors [][]byte) ([]crypto.Hash, error)
 }
 
 // A hostSession modifies a Contract via the renter-host RPC loop. It
 // implements the Session interface. hostSessions are safe for use by multiple
 // goroutines.
 type hostSession struct {
+	cancel     context.CancelFunc
 	clients    int // safe to Close when 0
 	contractor *Contractor
 	session    *proto.Session
@@ -81,6 +92,7 @@ func (hs *hostSession) invalidate() {
 		return
 	}
 	hs.session.Close()
+	hs.cancel()
 	hs.contractor.mu.Lock()
 	delete(hs.contractor.sessions, hs.id)
 	hs.contractor.mu.Unlock()
@@ -106,6 +118,7 @@ func (hs *hostSession) Close() error {
 	delete(hs.contractor.sessions, hs.id)
 	hs.contractor.mu.Unlock()
 
+	defer hs.cancel()
 	return hs.session.Close()
 }
 
@@ -123,7 +136,7 @@ func (hs *hostSession) Download(root crypto.Hash, offset, length uint32) ([]byte
 	}
 
 	// Download the data.
-	_, data, err := hs.session.ReadSection(root, offset, length)
+	_, data, err := hs.session.ReadSection(context.Background(), root, offset, length)
 	if err != nil {
 		return nil, err
 	}
@@ -148,7 +161,7 @@ func (hs *hostSession) DownloadIndex(index uint64, offset, length uint32) ([]byt
 	}
 
 	// Download the data.
-	_, data, err := hs.session.ReadSection(roots[0], offset, length)
+	_, data, err := hs.session.ReadSection(context.Background(), roots[0], offset, length)
 	if err != nil {
 		return nil, err
 	}
@@ -168,7 +181,7 @@ func (hs *hostSession) Upload(data []byte) (crypto.Hash, error) {
 	}
 
 	// Perform the upload.
-	_, sectorRoot, err := hs.session.Append(data)
+	_, sectorRoot, err := hs.session.Append(context.Background(), data)
 	if err != nil {
 		// Return the sector root so that it can be logged and used for
 		// debugging in the event of an error.
@@ -177,6 +190,22 @@ func (hs *hostSession) Upload(data []byte) (crypto.Hash, error) {
 	return sectorRoot, nil
 }
 
+// UploadStream negotiates a revision that adds multiple sectors to a file
+// contract.
+func (hs *hostSession) UploadStream(sectors [][]byte) ([]crypto.Hash, error) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	if hs.invalid {
+		return nil, errInvalidSession
+	}
+
+	_, roots, err := hs.session.AppendStream(context.Background(), sectors)
+	if err != nil {
+		return nil, err
+	}
+	return roots, nil
+}
+
 // Replace replaces the sector at the specified index with data.
 func (hs *hostSession) Replace(data []byte, sectorIndex uint64, trim bool) (crypto.Hash, error) {
 	hs.mu.Lock()
@@ -197,6 +226,17 @@ func (hs *hostSession) HostSettings() modules.HostExternalSettings {
 	return hs.session.HostSettings()
 }
 
+// Prices returns the current host settings which the session uses to
+// calculate prices.
+func (hs *hostSession) Prices() (modules.HostExternalSettings, error) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	if hs.invalid {
+		return modules.HostExternalSettings{}, errInvalidSession
+	}
+	return hs.session.Prices()
+}
+
 // Settings calls the Session RPC and updates the active host settings.
 func (hs *hostSession) Settings() (modules.HostExternalSettings, error) {
 	return hs.session.Settings()
@@ -247,17 +287,28 @@ func (c *Contractor) Session(pk types.SiaPublicKey, cancel <-chan struct{}) (_ S
 		return nil, errTooExpensive
 	}
 
-	// Create the session.
-	s, err := c.staticContracts.NewSession(host, id, height, c.hdb, c.log.Logger, cancel)
+	// Create the session. It lives until it is closed, cancel is closed or
+	// the contractor shuts down.
+	ctx, cancelCtx := context.WithCancel(c.tg.StopCtx())
+	go func() {
+		select {
+		case <-cancel:
+			cancelCtx()
+		case <-ctx.Done():
+		}
+	}()
+	s, err := c.staticContracts.NewSession(ctx, host, id, height, c.hdb, c.log.Logger)
 	if modules.IsContractNotRecognizedErr(err) {
 		err = errors.Compose(err, c.MarkContractBad(id))
 	}
 	if err != nil {
+		cancelCtx()
 		return nil, err
 	}
 
 	// cache session
 	hs := &hostSession{
+		cancel:     cancelCtx,
 		clients:    1,
 		contractor: c,
 		session:    s,
@@ -0,0 +1,201 @@
+package contractor
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"gitlab.com/NebulousLabs/ratelimit"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/consensus"
+	"go.sia.tech/siad/modules/gateway"
+	"go.sia.tech/siad/modules/miner"
+	"go.sia.tech/siad/modules/transactionpool"
+	"go.sia.tech/siad/siatest/dependencies"
+	"go.sia.tech/siad/siatest/simhost"
+)
+
+// TestSimulatedHosts tests contract formation, uploads and renewals against a
+// group of simulated hosts, some of which are failing, offline, slow or too
+// expensive.
+func TestSimulatedHosts(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	testdir := build.TempDir("contractor", t.Name())
+
+	// Create the consensus set, a funded wallet with a miner and the
+	// contractor.
+	g, err := gateway.New("localhost:0", false, filepath.Join(testdir, modules.GatewayDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs, errChan := consensus.New(g, false, filepath.Join(testdir, modules.ConsensusDir))
+	if err := <-errChan; err != nil {
+		t.Fatal(err)
+	}
+	tp, err := transactionpool.New(cs, g, filepath.Join(testdir, modules.TransactionPoolDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, walletCF, err := newTestingWallet(filepath.Join(testdir, "Wallet"), cs, tp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := miner.New(cs, tp, w, filepath.Join(testdir, modules.MinerDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, contractorCF, err := newTestingContractor(filepath.Join(testdir, "Contractor"), g, cs, tp, ratelimit.NewRateLimit(0, 0, 0), &dependencies.DependencyLegacyRenew{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hh, err := simhost.NewHarness(filepath.Join(testdir, "Hosts"), 20, cs.Height, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := errors.Compose(hh.Close(), contractorCF(), m.Close(), walletCF(), tp.Close(), cs.Close(), g.Close())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+	// All simulated hosts share the same IP.
+	if err := c.hdb.SetIPViolationCheck(false); err != nil {
+		t.Fatal(err)
+	}
+
+	// Configure the hosts.
+	a := modules.DefaultAllowance
+	a.Hosts = uint64(len(hh.Hosts))
+	a.Period = 50
+	a.RenewWindow = 20
+	a.MaxContractPrice = modules.DefaultContractPrice.Mul64(2)
+	failing, offline, expensive, slow := hh.Hosts[:3], hh.Hosts[3:5], hh.Hosts[5], hh.Hosts[6:8]
+	for _, h := range failing {
+		h.SetFailure(modules.RPCLoopFormContract, true)
+	}
+	for _, h := range offline {
+		h.SetOffline(true)
+	}
+	expensive.UpdateSettings(func(hes *modules.HostExternalSettings) {
+		hes.ContractPrice = a.MaxContractPrice.Mul64(2)
+	})
+	for _, h := range slow {
+		h.SetLatency(100 * time.Millisecond)
+	}
+	excluded := make(map[string]struct{})
+	for _, h := range append(append(failing, offline...), expensive) {
+		excluded[h.PublicKey().String()] = struct{}{}
+	}
+
+	// Announce the hosts and wait for the hostdb to scan them. Offline hosts
+	// never become active.
+	if err := hh.Announce(w, tp); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		active, err := c.hdb.ActiveHosts()
+		if err != nil {
+			return err
+		}
+		if len(active) != len(hh.Hosts)-len(offline) {
+			return fmt.Errorf("expected %v active hosts but got %v", len(hh.Hosts)-len(offline), len(active))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Contracts are only formed with the healthy hosts.
+	if err := c.SetAllowance(a); err != nil {
+		t.Fatal(err)
+	}
+	numHealthy := len(hh.Hosts) - len(excluded)
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		if n := len(c.Contracts()); n != numHealthy {
+			return fmt.Errorf("expected %v contracts but got %v", numHealthy, n)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, contract := range c.Contracts() {
+		if _, ok := excluded[contract.HostPublicKey.String()]; ok {
+			t.Fatal("contract was formed with an excluded host", contract.HostPublicKey)
+		}
+		h, ok := hh.Host(contract.HostPublicKey)
+		if !ok {
+			t.Fatal("contract was formed with an unknown host", contract.HostPublicKey)
+		}
+		if len(h.Contracts()) != 1 {
+			t.Fatal("host should have 1 contract but has", len(h.Contracts()))
+		}
+	}
+	for _, h := range failing {
+		if h.RPCCalls(modules.RPCLoopFormContract) == 0 {
+			t.Fatal("the contractor didn't try to form a contract with a failing host")
+		}
+	}
+
+	// Upload a sector to a slow host.
+	editor, err := c.Editor(slow[0].PublicKey(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := fastrand.Bytes(int(modules.SectorSize))
+	root, err := editor.Upload(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := editor.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sector, ok := slow[0].Sector(root)
+	if !ok || !bytes.Equal(sector, data) {
+		t.Fatal("host doesn't store the uploaded sector")
+	}
+
+	// Mine blocks until the contracts are renewed. The renewed contract of
+	// the slow host still contains the sector.
+	oldContract, ok := c.ContractByPublicKey(slow[0].PublicKey())
+	if !ok {
+		t.Fatal("no contract with the slow host")
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		contract, ok := c.ContractByPublicKey(slow[0].PublicKey())
+		if !ok || contract.ID == oldContract.ID {
+			if _, err := m.AddBlock(); err != nil {
+				return err
+			}
+			return errors.New("contract wasn't renewed yet")
+		}
+		if contract.Size() != modules.SectorSize {
+			return fmt.Errorf("renewed contract has size %v", contract.Size())
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cleared bool
+	for _, rev := range slow[0].Contracts() {
+		cleared = cleared || (rev.ParentID == oldContract.ID && rev.NewRevisionNumber == math.MaxUint64)
+	}
+	if !cleared {
+		t.Fatal("old contract wasn't cleared by the renewal")
+	}
+}
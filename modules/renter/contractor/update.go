@@ -5,6 +5,7 @@ import (
 
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/proto"
 	"go.sia.tech/siad/types"
 )
 
@@ -32,43 +33,48 @@ func hasFCIdentifier(txn types.Transaction) (modules.ContractSignedIdentifier, c
 }
 
 // managedArchiveContracts will figure out which contracts are no longer needed
-// and move them to the historic set of contracts.
-func (c *Contractor) managedArchiveContracts() {
-	// Determine the current block height.
-	c.mu.RLock()
-	currentHeight := c.blockHeight
-	c.mu.RUnlock()
-
+// and move them to the historic set of contracts. A snapshot of the contracts
+// which remain active is returned.
+func (c *Contractor) managedArchiveContracts() proto.ContractSnapshot {
 	// Loop through the current set of contracts and migrate any expired ones to
-	// the set of old contracts.
-	var expired []types.FileContractID
-	for _, contract := range c.staticContracts.ViewAll() {
+	// the set of old contracts. The lock is held for the whole loop to avoid
+	// acquiring it once per contract.
+	expired := make(map[types.FileContractID]struct{})
+	snapshot := c.staticContracts.ViewSnapshot()
+	c.mu.Lock()
+	currentHeight := c.blockHeight
+	for _, contract := range snapshot.Contracts() {
 		// Check map of renewedTo in case renew code was interrupted before
 		// archiving old contract
-		c.mu.RLock()
 		_, renewed := c.renewedTo[contract.ID]
-		c.mu.RUnlock()
 		if currentHeight > contract.EndHeight || renewed {
 			id := contract.ID
-			c.mu.Lock()
 			c.oldContracts[id] = contract
-			c.mu.Unlock()
-			expired = append(expired, id)
+			expired[id] = struct{}{}
 			c.log.Println("INFO: archived expired contract", id)
 		}
 	}
 
-	// Save.
-	c.mu.Lock()
-	c.save()
+	// Save. Persisting the contractor includes all of the old contracts, so
+	// only do so if something changed.
+	if len(expired) > 0 {
+		c.save()
+	}
 	c.mu.Unlock()
+	if len(expired) == 0 {
+		return snapshot
+	}
 
 	// Delete all the expired contracts from the contract set.
-	for _, id := range expired {
+	for id := range expired {
 		if sc, ok := c.staticContracts.Acquire(id); ok {
 			c.staticContracts.Delete(sc)
 		}
 	}
+	return snapshot.Filter(func(contract modules.RenterContract) bool {
+		_, archived := expired[contract.ID]
+		return !archived
+	})
 }
 
 // ProcessConsensusChange will be called by the consensus set every time there
@@ -101,7 +107,7 @@ func (c *Contractor) ProcessConsensusChange(cc modules.ConsensusChange) {
 		}
 		// Find lost contracts for recovery.
 		if haveSeed {
-			c.findRecoverableContracts(renterSeed, block)
+			c.findRecoverableContracts(renterSeed, block, nil)
 		} else {
 			missedRecovery = true
 		}
@@ -114,6 +114,15 @@ var (
 )
 
 var (
+	// idleScanInterval is the minimum amount of time between two scans of an
+	// idle host. Hosts are idle if the renter doesn't have a contract with
+	// them and they aren't a priority.
+	idleScanInterval = build.Select(build.Var{
+		Standard: 24 * time.Hour,
+		Dev:      30 * time.Minute,
+		Testing:  2 * time.Second,
+	}).(time.Duration)
+
 	// maxScanSleep is the maximum amount of time that the hostdb will sleep
 	// between performing scans of the hosts.
 	maxScanSleep = build.Select(build.Var{
@@ -130,6 +139,14 @@ var (
 		Testing:  100 * time.Millisecond,
 	}).(time.Duration)
 
+	// priorityScanInterval is the interval at which the priority hosts are
+	// scanned.
+	priorityScanInterval = build.Select(build.Var{
+		Standard: 10 * time.Minute,
+		Dev:      time.Minute,
+		Testing:  500 * time.Millisecond,
+	}).(time.Duration)
+
 	// minScanSleep is the minimum amount of time that the hostdb will sleep
 	// between performing scans of the hosts.
 	minScanSleep = build.Select(build.Var{
@@ -161,6 +161,12 @@ type HostDB struct {
 	// hosts. The mapkey is a serialized SiaPublicKey.
 	knownContracts map[string]contractInfo
 
+	// priorityHosts are hosts which store data the renter wants to keep a
+	// close eye on, like the data of pinned NFTs. They are scanned more
+	// frequently than other hosts to detect downtime quickly. The mapkey is a
+	// serialized SiaPublicKey.
+	priorityHosts map[string]types.SiaPublicKey
+
 	// The hostdb gets initialized with an allowance that can be modified. The
 	// allowance is used to build a weightFunc that the hosttree depends on to
 	// determine the weight of a host.
@@ -346,6 +352,7 @@ func hostdbBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 		filteredDomains: newFilteredDomains(nil),
 		filteredHosts:   make(map[string]types.SiaPublicKey),
 		knownContracts:  make(map[string]contractInfo),
+		priorityHosts:   make(map[string]types.SiaPublicKey),
 		scanMap:         make(map[string]struct{}),
 		staticAlerter:   modules.NewAlerter("hostdb"),
 	}
@@ -451,6 +458,7 @@ func hostdbBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 	// fake hosts and not have them marked as offline as the scanloop operates.
 	if !hdb.staticDeps.Disrupt("disableScanLoop") {
 		go hdb.threadedScan()
+		go hdb.threadedPriorityScan()
 	} else {
 		hdb.initialScanComplete = true
 	}
@@ -822,6 +830,36 @@ func (hdb *HostDB) SetIPViolationCheck(enabled bool) error {
 	return nil
 }
 
+// SetPriorityHosts replaces the set of hosts which are scanned more frequently
+// than the other hosts of the hostdb. Hosts which weren't a priority before are
+// scanned right away.
+func (hdb *HostDB) SetPriorityHosts(hosts []types.SiaPublicKey) error {
+	if err := hdb.tg.Add(); err != nil {
+		return errors.AddContext(err, "error adding hostdb threadgroup:")
+	}
+	defer hdb.tg.Done()
+	hdb.mu.Lock()
+	defer hdb.mu.Unlock()
+	priorityHosts := make(map[string]types.SiaPublicKey, len(hosts))
+	for _, pk := range hosts {
+		priorityHosts[pk.String()] = pk
+	}
+	oldPriorityHosts := hdb.priorityHosts
+	hdb.priorityHosts = priorityHosts
+	if !hdb.initialScanComplete {
+		return nil
+	}
+	for pkString, pk := range priorityHosts {
+		if _, exists := oldPriorityHosts[pkString]; exists {
+			continue
+		}
+		if entry, exists := hdb.staticHostTree.Select(pk); exists {
+			hdb.queuePriorityScan(entry)
+		}
+	}
+	return nil
+}
+
 // UpdateContracts rebuilds the knownContracts of the HostBD using the provided
 // contracts.
 func (hdb *HostDB) UpdateContracts(contracts []modules.RenterContract) error {
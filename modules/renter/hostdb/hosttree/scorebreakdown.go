@@ -21,6 +21,7 @@ type HostAdjustments struct {
 	AgeAdjustment              float64
 	BasePriceAdjustment        float64
 	BurnAdjustment             float64
+	CapabilityAdjustment       float64
 	CollateralAdjustment       float64
 	DurationAdjustment         float64
 	InteractionAdjustment      float64
@@ -73,6 +74,7 @@ func (h HostAdjustments) HostScoreBreakdown(totalScore types.Currency, ignoreAge
 		AgeAdjustment:              h.AgeAdjustment,
 		BasePriceAdjustment:        h.BasePriceAdjustment,
 		BurnAdjustment:             h.BurnAdjustment,
+		CapabilityAdjustment:       h.CapabilityAdjustment,
 		CollateralAdjustment:       h.CollateralAdjustment,
 		DurationAdjustment:         h.DurationAdjustment,
 		InteractionAdjustment:      h.InteractionAdjustment,
@@ -91,6 +93,7 @@ func (h HostAdjustments) Score() types.Currency {
 		h.AcceptContractAdjustment *
 		h.BasePriceAdjustment *
 		h.BurnAdjustment *
+		h.CapabilityAdjustment *
 		h.CollateralAdjustment *
 		h.DurationAdjustment *
 		h.InteractionAdjustment *
@@ -205,11 +205,20 @@ func (hdb *HostDB) acceptContractAdjustments(entry modules.HostDBEntry) float64
 	return 1
 }
 
+// capabilityAdjustments checks that the host advertises the capabilities
+// required by the allowance. The host's score is heavily minimized if not.
+func (hdb *HostDB) capabilityAdjustments(entry modules.HostDBEntry, allowance modules.Allowance) float64 {
+	if !entry.HostCapabilities.Satisfies(allowance.RequiredHostCapabilities()) {
+		return math.SmallestNonzeroFloat64
+	}
+	return 1
+}
+
 // durationAdjustments checks that the host has a maxduration which is larger
 // than the period of the allowance. The host's score is heavily minimized if
 // not.
 func (hdb *HostDB) durationAdjustments(entry modules.HostDBEntry, allowance modules.Allowance) float64 {
-	if entry.MaxDuration < allowance.Period+allowance.RenewWindow {
+	if entry.MaxDurationFor(allowance.NFTStorage) < allowance.Period+allowance.RenewWindow {
 		return math.SmallestNonzeroFloat64
 	}
 	return 1
@@ -306,7 +315,7 @@ func (hdb *HostDB) priceAdjustments(entry modules.HostDBEntry, allowance modules
 	// spending all of the contract's money.
 	contractPrice := entry.ContractPrice.Add(txnFees).Mul64(2)
 	downloadPrice := entry.DownloadBandwidthPrice.Mul(contractExpectedDownload).Add(extraDownloadRPCCost)
-	storagePrice := entry.StoragePrice.Mul(contractExpectedStorageTime)
+	storagePrice := entry.StoragePriceFor(allowance.NFTStorage).Mul(contractExpectedStorageTime)
 	uploadPrice := entry.UploadBandwidthPrice.Mul(contractExpectedUpload).Add(extraUploadRPCCost)
 	siafundFee := contractPrice.Add(hostCollateral).Add(downloadPrice).Add(storagePrice).Add(uploadPrice).MulTax()
 	totalPrice := contractPrice.Add(downloadPrice).Add(storagePrice).Add(uploadPrice).Add(siafundFee)
@@ -397,11 +406,14 @@ func versionAdjustments(entry modules.HostDBEntry) float64 {
 	// we give the current version a very tiny penalty is so that the test suite
 	// complains if we forget to update this file when we bump the version next
 	// time. The value compared against must be higher than the current version.
-	if build.VersionCmp(entry.Version, "1.5.9") < 0 {
+	if build.VersionCmp(entry.Version, "1.5.10") < 0 {
 		base = base * 0.99999 // Safety value to make sure we update the version penalties every time we update the host.
 	}
 
 	// This needs to be "less than the current version" - anything less than the current version should get a penalty.
+	if build.VersionCmp(entry.Version, "1.5.9") < 0 {
+		base = base * 0.99 // Slight penalty against slightly out of date hosts.
+	}
 	if build.VersionCmp(entry.Version, "1.5.8") < 0 {
 		base = base * 0.99 // Slight penalty against slightly out of date hosts.
 	}
@@ -574,6 +586,7 @@ func (hdb *HostDB) managedCalculateHostWeightFn(allowance modules.Allowance) hos
 			AgeAdjustment:              hdb.lifetimeAdjustments(entry),
 			BasePriceAdjustment:        hdb.basePriceAdjustments(entry),
 			BurnAdjustment:             1,
+			CapabilityAdjustment:       hdb.capabilityAdjustments(entry, allowance),
 			CollateralAdjustment:       hdb.collateralAdjustments(entry, allowance),
 			DurationAdjustment:         hdb.durationAdjustments(entry, allowance),
 			InteractionAdjustment:      hdb.interactionAdjustments(entry),
@@ -332,6 +332,83 @@ func TestHostWeightMaxDuration(t *testing.T) {
 	}
 }
 
+// TestHostWeightCapabilities checks that hosts which don't advertise the
+// capabilities required by the allowance have the smallest score possible.
+func TestHostWeightCapabilities(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	hdb := bareHostDB()
+	allowance := DefaultTestAllowance
+	allowance.MinRegistryEntries = 64
+	allowance.MinRegistryEntryTTL = 1000
+	allowance.NFTStoragePool = true
+	err := hdb.SetAllowance(allowance)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry := DefaultHostDBEntry
+	entry.HostCapabilities = modules.HostCapabilities{
+		RegistryEntriesLeft: 64,
+		NFTStoragePool:      true,
+		MaxRegistryEntryTTL: 1000,
+	}
+	w1 := hdb.weightFunc(entry).Score()
+
+	// Each missing capability results in the smallest weight possible.
+	entries := []modules.HostDBEntry{entry, entry, entry}
+	entries[0].RegistryEntriesLeft--
+	entries[1].NFTStoragePool = false
+	entries[2].MaxRegistryEntryTTL--
+	for i, e := range entries {
+		w2 := hdb.weightFunc(e).Score()
+		if w2.Cmp64(1) != 0 || w1.Cmp(w2) <= 0 {
+			t.Errorf("%v: entry should have smallest weight %v %v", i, w1, w2)
+		}
+	}
+}
+
+// TestHostWeightNFTStorage checks that allowances for NFT storage score hosts
+// by their NFT price and retention period.
+func TestHostWeightNFTStorage(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	hdb := bareHostDB()
+	allowance := DefaultTestAllowance
+	err := hdb.SetAllowance(allowance)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// entry2 offers cheaper storage and a sufficient retention period for NFT
+	// data but its MaxDuration is too short for regular data.
+	entry := DefaultHostDBEntry
+	entry2 := DefaultHostDBEntry
+	entry2.MaxDuration = allowance.Period
+	entry2.NFTRetentionPeriod = allowance.Period + allowance.RenewWindow
+	entry2.NFTStoragePrice = entry2.StoragePrice.Div64(2)
+	w2 := hdb.weightFunc(entry2).Score()
+	if w2.Cmp64(1) != 0 {
+		t.Error("Entry2 should have smallest weight for regular data", w2)
+	}
+
+	// For NFT data entry2 should be preferred.
+	allowance.NFTStorage = true
+	err = hdb.SetAllowance(allowance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w1 := hdb.weightFunc(entry).Score()
+	w2 = hdb.weightFunc(entry2).Score()
+	if w1.Cmp(w2) >= 0 {
+		t.Error("Entry2 should have larger weight for NFT data", w1, w2)
+	}
+}
+
 // TestHostWeightStorageRemainingDifferences checks that the host with more
 // collateral has more weight.
 func TestHostWeightCollateralDifferences(t *testing.T) {
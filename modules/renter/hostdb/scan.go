@@ -31,6 +31,15 @@ var (
 		Dev:      2 * time.Minute,
 		Testing:  500 * time.Millisecond,
 	}).(time.Duration)
+
+	// priorityScanTimeElapsedRequirement is the scanTimeElapsedRequirement of
+	// the priority hosts. It is shorter to add their downtime to the scan
+	// history quickly.
+	priorityScanTimeElapsedRequirement = build.Select(build.Var{
+		Standard: 5 * time.Minute,
+		Dev:      30 * time.Second,
+		Testing:  100 * time.Millisecond,
+	}).(time.Duration)
 )
 
 // equalIPNets checks if two slices of IP subnets contain the same subnets.
@@ -210,6 +219,20 @@ func (hdb *HostDB) queueScan(entry modules.HostDBEntry) {
 	}()
 }
 
+// queuePriorityScan adds a host to the front of the queue to be scanned. If the
+// host is already queued, it is moved to the front.
+func (hdb *HostDB) queuePriorityScan(entry modules.HostDBEntry) {
+	hdb.queueScan(entry)
+	pk := entry.PublicKey.String()
+	for i := range hdb.scanList {
+		if hdb.scanList[i].PublicKey.String() == pk {
+			copy(hdb.scanList[1:i+1], hdb.scanList[:i])
+			hdb.scanList[0] = entry
+			return
+		}
+	}
+}
+
 // updateEntry updates an entry in the hostdb after a scan has taken place.
 //
 // CAUTION: This function will automatically add multiple entries to a new host
@@ -257,10 +280,15 @@ func (hdb *HostDB) updateEntry(entry modules.HostDBEntry, netErr error) {
 		}
 	} else {
 		// Do not add a new timestamp for the scan unless more than an hour has
-		// passed since the previous scan.
+		// passed since the previous scan. Priority hosts only need to wait a
+		// few minutes.
+		elapsedRequirement := scanTimeElapsedRequirement
+		if _, priority := hdb.priorityHosts[newEntry.PublicKey.String()]; priority {
+			elapsedRequirement = priorityScanTimeElapsedRequirement
+		}
 		newTimestamp := time.Now()
 		prevTimestamp := newEntry.ScanHistory[len(newEntry.ScanHistory)-1].Timestamp
-		if newTimestamp.After(prevTimestamp.Add(scanTimeElapsedRequirement)) {
+		if newTimestamp.After(prevTimestamp.Add(elapsedRequirement)) {
 			if newEntry.ScanHistory[len(newEntry.ScanHistory)-1].Success && netErr != nil {
 				hdb.staticLog.Printf("Host %v is being downgraded from an online host to an offline host: %v\n", newEntry.PublicKey.String(), netErr)
 			}
@@ -600,11 +628,6 @@ func (hdb *HostDB) threadedScan() {
 	hdb.mu.Lock()
 	// Set the flag to indicate that the initial scan is complete.
 	hdb.initialScanComplete = true
-	// Copy the known contracts to avoid having to lock the hdb later.
-	knownContracts := make(map[string]contractInfo)
-	for k, c := range hdb.knownContracts {
-		knownContracts[k] = c
-	}
 	hdb.mu.Unlock()
 
 	for {
@@ -619,20 +642,34 @@ func (hdb *HostDB) threadedScan() {
 		// fewer than hostCheckupQuantity of them.
 
 		// Grab a set of hosts to scan, grab hosts that are active, inactive, offline
-		// and known to get high diversity.
+		// and known to get high diversity. Hosts we have a contract with and
+		// priority hosts are known hosts, the other hosts are idle and only
+		// scanned if they haven't been scanned for idleScanInterval.
+		hdb.mu.RLock()
+		knownPKs := make(map[string]struct{}, len(hdb.knownContracts)+len(hdb.priorityHosts))
+		for pk := range hdb.knownContracts {
+			knownPKs[pk] = struct{}{}
+		}
+		for pk := range hdb.priorityHosts {
+			knownPKs[pk] = struct{}{}
+		}
+		hdb.mu.RUnlock()
 		var onlineHosts, offlineHosts, knownHosts []modules.HostDBEntry
 		allHosts := hdb.staticHostTree.All()
 		for i := len(allHosts) - 1; i >= 0; i-- {
 			if len(onlineHosts) >= hostCheckupQuantity &&
 				len(offlineHosts) >= hostCheckupQuantity &&
-				len(knownHosts) == len(knownContracts) {
+				len(knownHosts) == len(knownPKs) {
 				break
 			}
 
 			// Figure out if the host is known, online or offline.
 			host := allHosts[i]
 			online := len(host.ScanHistory) > 0 && host.ScanHistory[len(host.ScanHistory)-1].Success
-			_, known := knownContracts[host.PublicKey.String()]
+			_, known := knownPKs[host.PublicKey.String()]
+			if !known && len(host.ScanHistory) > 0 && time.Since(host.ScanHistory[len(host.ScanHistory)-1].Timestamp) < idleScanInterval {
+				continue
+			}
 			if known {
 				knownHosts = append(knownHosts, host)
 			} else if online && len(onlineHosts) < hostCheckupQuantity {
@@ -672,6 +709,37 @@ func (hdb *HostDB) threadedScan() {
 	}
 }
 
+// threadedPriorityScan scans the priority hosts every priorityScanInterval,
+// ahead of the hosts queued by the regular scan loop.
+func (hdb *HostDB) threadedPriorityScan() {
+	err := hdb.tg.Add()
+	if err != nil {
+		return
+	}
+	defer hdb.tg.Done()
+
+	for {
+		select {
+		case <-hdb.tg.StopChan():
+			return
+		case <-time.After(priorityScanInterval):
+		}
+
+		// The initial scan covers the priority hosts.
+		hdb.mu.Lock()
+		if !hdb.initialScanComplete {
+			hdb.mu.Unlock()
+			continue
+		}
+		for _, pk := range hdb.priorityHosts {
+			if entry, exists := hdb.staticHostTree.Select(pk); exists {
+				hdb.queuePriorityScan(entry)
+			}
+		}
+		hdb.mu.Unlock()
+	}
+}
+
 // fetchPriceTable fetches a price table from a host without paying. This means
 // the price table is only useful for scoring the host and can't be used. This
 // uses an ephemeral stream which is a special type of stream that doesn't leak
@@ -320,3 +320,61 @@ func TestUpdateEntryWithKnownHost(t *testing.T) {
 		t.Fatal("Entry did not get removed from the host tree")
 	}
 }
+
+// TestPriorityScan checks that priority hosts are scanned ahead of the other
+// hosts and that their scans are added to the scan history sooner.
+func TestPriorityScan(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	hdbt, err := newHDBTesterDeps(t.Name(), &disableScanLoopDeps{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry1 := modules.HostDBEntry{
+		PublicKey: types.SiaPublicKey{
+			Key: []byte{1},
+		},
+	}
+	entry2 := modules.HostDBEntry{
+		PublicKey: types.SiaPublicKey{
+			Key: []byte{2},
+		},
+	}
+	hdbt.hdb.updateEntry(entry1, nil)
+	hdbt.hdb.updateEntry(entry2, nil)
+
+	// Queue a scan of the second host without spawning a thread which
+	// empties the scan list. Making the first host a priority moves it ahead.
+	hdbt.hdb.mu.Lock()
+	hdbt.hdb.scanWait = true
+	hdbt.hdb.queueScan(entry2)
+	hdbt.hdb.mu.Unlock()
+	if err := hdbt.hdb.SetPriorityHosts([]types.SiaPublicKey{entry1.PublicKey}); err != nil {
+		t.Fatal(err)
+	}
+	hdbt.hdb.mu.Lock()
+	hdbt.hdb.queuePriorityScan(entry1)
+	scanList := append([]modules.HostDBEntry(nil), hdbt.hdb.scanList...)
+	hdbt.hdb.mu.Unlock()
+	if len(scanList) != 2 {
+		t.Fatal("expected 2 queued scans but got", len(scanList))
+	}
+	if !scanList[0].PublicKey.Equals(entry1.PublicKey) {
+		t.Fatal("priority host wasn't queued first")
+	}
+
+	// Only the failed scan of the priority host is added to the history.
+	time.Sleep(2 * priorityScanTimeElapsedRequirement)
+	someErr := errors.New("testing err")
+	hdbt.hdb.updateEntry(entry1, someErr)
+	hdbt.hdb.updateEntry(entry2, someErr)
+	updatedEntry1, _ := hdbt.hdb.staticHostTree.Select(entry1.PublicKey)
+	updatedEntry2, _ := hdbt.hdb.staticHostTree.Select(entry2.PublicKey)
+	if len(updatedEntry1.ScanHistory) != 3 || updatedEntry1.ScanHistory[2].Success {
+		t.Fatal("failed scan of the priority host wasn't added to the history", updatedEntry1.ScanHistory)
+	}
+	if len(updatedEntry2.ScanHistory) != 2 {
+		t.Fatal("scan of the other host shouldn't be added to the history yet", updatedEntry2.ScanHistory)
+	}
+}
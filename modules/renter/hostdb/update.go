@@ -16,7 +16,7 @@ func findHostAnnouncements(b types.Block) (announcements []modules.HostDBEntry)
 		// the HostAnnouncement must be prefaced by the standard host
 		// announcement string
 		for _, arb := range t.ArbitraryData {
-			addr, pubKey, err := modules.DecodeAnnouncement(arb)
+			addr, pubKey, capabilities, _, err := modules.DecodeAnnouncementWithCapabilities(arb)
 			if err != nil {
 				continue
 			}
@@ -25,6 +25,7 @@ func findHostAnnouncements(b types.Block) (announcements []modules.HostDBEntry)
 			var host modules.HostDBEntry
 			host.NetAddress = addr
 			host.PublicKey = pubKey
+			host.HostCapabilities = capabilities
 			announcements = append(announcements, host)
 		}
 	}
@@ -55,6 +56,11 @@ func (hdb *HostDB) insertBlockchainHost(host modules.HostDBEntry) {
 		// first seen height of zero, but due to rescans hosts can end up with
 		// a zero-value FirstSeen field.
 		oldEntry.NetAddress = host.NetAddress
+		// Announced capabilities replace the known ones until the next scan
+		// of the host.
+		if host.HostCapabilities != (modules.HostCapabilities{}) {
+			oldEntry.HostCapabilities = host.HostCapabilities
+		}
 		if oldEntry.FirstSeen == 0 {
 			oldEntry.FirstSeen = hdb.blockHeight
 		}
@@ -51,4 +51,22 @@ func TestFindHostAnnouncements(t *testing.T) {
 	if len(announcements) != 0 {
 		t.Error("host announcement found when there was an invalid encoding of a host announcement")
 	}
+	b.Transactions[0].ArbitraryData[0][17]--
+
+	// Announcements extended with capabilities set the capabilities of the
+	// entry.
+	sk, pk := crypto.GenerateKeyPair()
+	hc := modules.HostCapabilities{RegistryEntriesLeft: 64, NFTStoragePool: true}
+	annBytes, err = modules.CreateAnnouncementWithCapabilities("foo.com:1234", types.Ed25519PublicKey(pk), sk, hc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Transactions[0].ArbitraryData = append(b.Transactions[0].ArbitraryData, annBytes)
+	announcements = findHostAnnouncements(b)
+	if len(announcements) != 2 {
+		t.Fatal("host announcements not found in block", len(announcements))
+	}
+	if announcements[0].HostCapabilities != (modules.HostCapabilities{}) || announcements[1].HostCapabilities != hc {
+		t.Error("wrong capabilities", announcements[0].HostCapabilities, announcements[1].HostCapabilities)
+	}
 }
@@ -0,0 +1,14 @@
+package renter
+
+import (
+	"go.sia.tech/siad/metrics"
+)
+
+var (
+	// uploadBytesMetric counts the bytes of the pieces uploaded to hosts.
+	uploadBytesMetric = metrics.NewCounter("siad_renter_upload_bytes_total", "Number of bytes of file pieces uploaded to hosts by the renter.")
+
+	// downloadBytesMetric counts the bytes of sector data downloaded from
+	// hosts.
+	downloadBytesMetric = metrics.NewCounter("siad_renter_download_bytes_total", "Number of bytes of sector data downloaded from hosts by the renter.")
+)
@@ -0,0 +1,196 @@
+package renter
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+	"go.sia.tech/siad/types"
+)
+
+// nftbundle.go exports and imports the metadata of the siafiles of an NFT
+// collection. A bundle contains the encoded siafiles, so the importing renter
+// can download the data from the hosts which store it without uploading it
+// again. The imported files are bound to the contracts of the importing renter
+// right away, pieces on hosts it has no contract with are treated as missing
+// and repaired onto its own hosts by the repair loop.
+
+var (
+	// errNFTBundleEmpty is returned when exporting or importing a bundle
+	// without files.
+	errNFTBundleEmpty = errors.New("nft bundle doesn't contain any files")
+
+	// errNFTBundleVersion is returned when importing a bundle of an unknown
+	// version.
+	errNFTBundleVersion = errors.New("unknown nft bundle version")
+)
+
+// ExportNFTBundle exports the metadata of the siafiles at the given siapaths,
+// which store the data of an NFT collection.
+func (r *Renter) ExportNFTBundle(collection string, siaPaths []modules.SiaPath) (modules.NFTBundle, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.NFTBundle{}, err
+	}
+	defer r.tg.Done()
+
+	if err := types.ValidateNFTCollection(collection); err != nil {
+		return modules.NFTBundle{}, err
+	}
+	if len(siaPaths) == 0 {
+		return modules.NFTBundle{}, errNFTBundleEmpty
+	}
+	bundle := modules.NFTBundle{
+		Version:    modules.NFTBundleVersion,
+		Collection: collection,
+		Files:      make([]modules.NFTBundleFile, 0, len(siaPaths)),
+	}
+	for _, siaPath := range siaPaths {
+		file, err := r.managedExportNFTBundleFile(siaPath)
+		if err != nil {
+			return modules.NFTBundle{}, errors.AddContext(err, fmt.Sprintf("unable to export %v", siaPath))
+		}
+		bundle.Files = append(bundle.Files, file)
+	}
+	return bundle, nil
+}
+
+// managedExportNFTBundleFile encodes the siafile at siaPath for an NFT bundle.
+func (r *Renter) managedExportNFTBundleFile(siaPath modules.SiaPath) (_ modules.NFTBundleFile, err error) {
+	entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		return modules.NFTBundleFile{}, err
+	}
+	defer func() {
+		err = errors.Compose(err, entry.Close())
+	}()
+
+	// Encode the siafile. The snapshot reader holds a lock on the file until
+	// it is closed.
+	sr, err := entry.SnapshotReader()
+	if err != nil {
+		return modules.NFTBundleFile{}, err
+	}
+	sf, err := ioutil.ReadAll(sr)
+	if err := errors.Compose(err, sr.Close()); err != nil {
+		return modules.NFTBundleFile{}, err
+	}
+
+	// Collect the hosts storing pieces of the file.
+	var hosts []types.SiaPublicKey
+	seen := make(map[string]struct{})
+	for chunkIndex := uint64(0); chunkIndex < entry.NumChunks(); chunkIndex++ {
+		pieces, err := entry.Pieces(chunkIndex)
+		if err != nil {
+			return modules.NFTBundleFile{}, err
+		}
+		for _, pieceSet := range pieces {
+			for _, piece := range pieceSet {
+				if _, exists := seen[piece.HostPubKey.String()]; exists {
+					continue
+				}
+				seen[piece.HostPubKey.String()] = struct{}{}
+				hosts = append(hosts, piece.HostPubKey)
+			}
+		}
+	}
+	return modules.NFTBundleFile{
+		SiaPath: siaPath,
+		Hosts:   hosts,
+		SiaFile: sf,
+	}, nil
+}
+
+// ImportNFTBundle adds the siafiles of a bundle to the renter below the dst
+// folder and binds them to the renter's contracts. Files which would overwrite
+// existing files aren't imported.
+func (r *Renter) ImportNFTBundle(bundle modules.NFTBundle, dst modules.SiaPath) (_ modules.NFTBundleImport, err error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.NFTBundleImport{}, err
+	}
+	defer r.tg.Done()
+
+	if bundle.Version != modules.NFTBundleVersion {
+		return modules.NFTBundleImport{}, errors.AddContext(errNFTBundleVersion, bundle.Version)
+	}
+	if err := types.ValidateNFTCollection(bundle.Collection); err != nil {
+		return modules.NFTBundleImport{}, err
+	}
+	if len(bundle.Files) == 0 {
+		return modules.NFTBundleImport{}, errNFTBundleEmpty
+	}
+
+	// Determine the destinations of the files and make sure none of them
+	// exist before importing anything.
+	siaPaths := make([]modules.SiaPath, 0, len(bundle.Files))
+	for _, file := range bundle.Files {
+		siaPath, err := dst.Join(file.SiaPath.String())
+		if err != nil {
+			return modules.NFTBundleImport{}, err
+		}
+		exists, err := r.staticFileSystem.FileExists(siaPath)
+		if err != nil {
+			return modules.NFTBundleImport{}, err
+		}
+		if exists {
+			return modules.NFTBundleImport{}, errors.AddContext(filesystem.ErrExists, siaPath.String())
+		}
+		siaPaths = append(siaPaths, siaPath)
+	}
+
+	// Bind the files to the current contracts.
+	r.managedUpdateRenterContractsAndUtilities()
+	_, _, contracts, used := r.callRenterContractsAndUtilities()
+
+	// The directories of the imported files need to be refreshed.
+	dirsToUpdate := r.newUniqueRefreshPaths()
+	defer func() {
+		err = errors.Compose(err, dirsToUpdate.callRefreshAll())
+	}()
+
+	result := modules.NFTBundleImport{
+		Collection: bundle.Collection,
+		SiaPaths:   siaPaths,
+	}
+	missing := make(map[string]struct{})
+	for i, file := range bundle.Files {
+		err := r.managedImportNFTBundleFile(file.SiaFile, siaPaths[i], used)
+		if err != nil {
+			return modules.NFTBundleImport{}, errors.AddContext(err, fmt.Sprintf("unable to import %v", file.SiaPath))
+		}
+		if err := dirsToUpdate.callAdd(siaPaths[i]); err != nil {
+			return modules.NFTBundleImport{}, err
+		}
+		for _, host := range file.Hosts {
+			if _, exists := contracts[host.String()]; exists {
+				continue
+			}
+			if _, exists := missing[host.String()]; exists {
+				continue
+			}
+			missing[host.String()] = struct{}{}
+			result.MissingHosts = append(result.MissingHosts, host)
+		}
+	}
+	return result, nil
+}
+
+// managedImportNFTBundleFile adds an encoded siafile to the renter at siaPath
+// and marks the hosts in used as the hosts it is stored on.
+func (r *Renter) managedImportNFTBundleFile(sf []byte, siaPath modules.SiaPath, used []types.SiaPublicKey) (err error) {
+	err = r.staticFileSystem.AddSiaFileFromReader(bytes.NewReader(sf), siaPath)
+	if err != nil {
+		return err
+	}
+	entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, entry.Close())
+	}()
+	return entry.UpdateUsedHosts(used)
+}
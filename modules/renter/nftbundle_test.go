@@ -0,0 +1,142 @@
+package renter
+
+import (
+	"bytes"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+	"go.sia.tech/siad/siatest/dependencies"
+	"go.sia.tech/siad/types"
+)
+
+// TestNFTBundle tests exporting the siafiles of an NFT collection and importing
+// them again.
+func TestNFTBundle(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTesterWithDependency(t.Name(), &dependencies.DependencyDisableRepairAndHealthLoops{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Create two files with a piece on a host the renter has no contract
+	// with.
+	hpk := types.SiaPublicKey{
+		Algorithm: types.SignatureEd25519,
+		Key:       fastrand.Bytes(crypto.PublicKeySize),
+	}
+	var siaPaths []modules.SiaPath
+	for _, name := range []string{"collection/1", "collection/2"} {
+		siaPath, err := modules.NewSiaPath(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entry, err := rt.renter.createRenterTestFile(siaPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := entry.AddPiece(hpk, 0, 0, crypto.Hash{}); err != nil {
+			t.Fatal(err)
+		}
+		if err := entry.Close(); err != nil {
+			t.Fatal(err)
+		}
+		siaPaths = append(siaPaths, siaPath)
+	}
+
+	// Export the files.
+	if _, err := rt.renter.ExportNFTBundle("collection", nil); !errors.Contains(err, errNFTBundleEmpty) {
+		t.Fatal("expected errNFTBundleEmpty but got", err)
+	}
+	bundle, err := rt.renter.ExportNFTBundle("collection", siaPaths)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bundle.Version != modules.NFTBundleVersion || bundle.Collection != "collection" || len(bundle.Files) != len(siaPaths) {
+		t.Fatalf("unexpected bundle %+v", bundle)
+	}
+	for i, file := range bundle.Files {
+		if file.SiaPath != siaPaths[i] || len(file.Hosts) != 1 || file.Hosts[0].String() != hpk.String() {
+			t.Fatalf("unexpected bundle file %v %v", file.SiaPath, file.Hosts)
+		}
+	}
+
+	// Import the files below a different folder.
+	dst, err := modules.NewSiaPath("imported")
+	if err != nil {
+		t.Fatal(err)
+	}
+	imported, err := rt.renter.ImportNFTBundle(bundle, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported.Collection != bundle.Collection || len(imported.SiaPaths) != len(siaPaths) {
+		t.Fatalf("unexpected import %+v", imported)
+	}
+	if len(imported.MissingHosts) != 1 || imported.MissingHosts[0].String() != hpk.String() {
+		t.Fatalf("unexpected missing hosts %v", imported.MissingHosts)
+	}
+
+	// The imported files share the keys, erasure coding and pieces of the
+	// exported ones.
+	for i, siaPath := range imported.SiaPaths {
+		expected, err := dst.Join(siaPaths[i].String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if siaPath != expected {
+			t.Fatalf("expected file at %v but was %v", expected, siaPath)
+		}
+		original, err := rt.renter.staticFileSystem.OpenSiaFile(siaPaths[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		entry, err := rt.renter.staticFileSystem.OpenSiaFile(siaPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(entry.MasterKey().Key(), original.MasterKey().Key()) {
+			t.Fatal("master keys don't match")
+		}
+		if entry.ErasureCode().Identifier() != original.ErasureCode().Identifier() {
+			t.Fatal("erasure codes don't match")
+		}
+		if entry.UID() == original.UID() {
+			t.Fatal("imported file should have a new UID")
+		}
+		pieces, err := entry.Pieces(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(pieces[0]) != 1 || pieces[0][0].HostPubKey.String() != hpk.String() {
+			t.Fatalf("unexpected pieces %v", pieces)
+		}
+		if err := errors.Compose(original.Close(), entry.Close()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Importing the bundle again would overwrite the files.
+	if _, err := rt.renter.ImportNFTBundle(bundle, dst); !errors.Contains(err, filesystem.ErrExists) {
+		t.Fatal("expected ErrExists but got", err)
+	}
+
+	// Bundles of an unknown version are rejected.
+	bundle.Version = "0.0"
+	if _, err := rt.renter.ImportNFTBundle(bundle, modules.RootSiaPath()); !errors.Contains(err, errNFTBundleVersion) {
+		t.Fatal("expected errNFTBundleVersion but got", err)
+	}
+}
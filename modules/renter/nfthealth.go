@@ -0,0 +1,196 @@
+package renter
+
+import (
+	"fmt"
+	"sort"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+)
+
+// nfthealth.go contains the health report of pinned NFTs. The report combines
+// the piece availability of an NFT's siafile with the contract status and the
+// storage proofs of the hosts storing its pieces to answer whether the NFT's
+// data is safe and what to do if it isn't.
+
+// NFTHealth returns a report on how safe the data of a pinned NFT is.
+func (r *Renter) NFTHealth(root crypto.Hash) (modules.NFTHealth, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.NFTHealth{}, err
+	}
+	defer r.tg.Done()
+
+	id := r.mu.RLock()
+	i, pinned := r.nftPinIndex(root)
+	var pin modules.NFTPin
+	if pinned {
+		pin = r.persist.NFTPins[i]
+	}
+	r.mu.RUnlock(id)
+	if !pinned {
+		return modules.NFTHealth{}, ErrNFTNotPinned
+	}
+
+	report := modules.NFTHealth{
+		Root:            pin.Root,
+		SiaPath:         pin.SiaPath,
+		SourceAvailable: r.staticVerifyNFTPinSource(pin.Root, pin.Source) == nil,
+		Reasons:         []string{},
+		Hosts:           []modules.NFTHostHealth{},
+	}
+
+	// Get the piece availability of the siafile.
+	fi, err := r.File(pin.SiaPath)
+	if errors.Contains(err, filesystem.ErrNotExist) {
+		if report.SourceAvailable {
+			report.Reasons = append(report.Reasons, "the siafile of the nft was lost, it will be uploaded again from the local copy of the data")
+		} else {
+			report.Reasons = append(report.Reasons, "the siafile of the nft was lost and the local copy of the data is missing or changed, pin the nft again from a copy of its data")
+		}
+		return report, nil
+	} else if err != nil {
+		return modules.NFTHealth{}, errors.AddContext(err, "unable to get the health of the nft's data")
+	}
+	report.Health = fi.Health
+	report.Recoverable = fi.Recoverable
+	report.Redundancy = fi.Redundancy
+	report.Stuck = fi.Stuck
+	err = r.managedNFTPieceHealth(&report)
+	if err != nil {
+		return modules.NFTHealth{}, errors.AddContext(err, "unable to get the pieces of the nft's data")
+	}
+	if !report.Recoverable {
+		report.Score = 0
+	}
+
+	// Explain what needs attention.
+	if !report.Recoverable {
+		report.Reasons = append(report.Reasons, "the data can't be recovered from the hosts, pin the nft again from a copy of its data")
+	} else if report.MinGoodPieces < report.MinPieces {
+		report.Reasons = append(report.Reasons, "the good hosts don't store enough pieces to recover the data, it can only be repaired from the local copy of the data")
+	} else if modules.NeedsRepair(report.Health) {
+		report.Reasons = append(report.Reasons, "the data is below the repair threshold, the repair loop will repair it from the local copy of the data")
+	}
+	if report.Stuck {
+		report.Reasons = append(report.Reasons, "the repair of the data is stuck, add funds or hosts to the allowance")
+	}
+	if !report.SourceAvailable {
+		report.Reasons = append(report.Reasons, "the local copy of the data is missing or changed, lost pieces can't be repaired, pin the nft again from a copy of its data")
+	}
+	for _, host := range report.Hosts {
+		switch {
+		case !host.HasContract:
+			report.Reasons = append(report.Reasons, fmt.Sprintf("there is no contract with host %v storing %v pieces", host.HostPublicKey, host.Pieces))
+		case host.Offline:
+			report.Reasons = append(report.Reasons, fmt.Sprintf("host %v storing %v pieces is offline", host.HostPublicKey, host.Pieces))
+		case !host.GoodForRenew:
+			report.Reasons = append(report.Reasons, fmt.Sprintf("the contract with host %v storing %v pieces won't be renewed after height %v", host.HostPublicKey, host.Pieces, host.ContractEndHeight))
+		}
+		if host.MissedProofs > 0 {
+			report.Reasons = append(report.Reasons, fmt.Sprintf("host %v storing %v pieces missed %v storage proofs", host.HostPublicKey, host.Pieces, host.MissedProofs))
+		}
+	}
+	return report, nil
+}
+
+// managedNFTPieceHealth fills out the piece availability, the score and the
+// host statuses of the report.
+func (r *Renter) managedNFTPieceHealth(report *modules.NFTHealth) (err error) {
+	offline, goodForRenew, contracts := r.managedContractUtilityMaps()
+
+	// Count the pieces of every host and the good pieces of every chunk.
+	entry, err := r.staticFileSystem.OpenSiaFile(report.SiaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, entry.Close())
+	}()
+	ec := entry.ErasureCode()
+	report.Chunks = entry.NumChunks()
+	report.MinPieces = ec.MinPieces()
+	report.NumPieces = ec.NumPieces()
+	report.MinGoodPieces = ec.NumPieces()
+	hostPieces := make(map[string]uint64)
+	hostKeys := make(map[string]modules.NFTHostHealth)
+	for chunkIndex := uint64(0); chunkIndex < report.Chunks; chunkIndex++ {
+		pieces, err := entry.Pieces(chunkIndex)
+		if err != nil {
+			return err
+		}
+		var goodPieces int
+		for _, pieceSet := range pieces {
+			var good bool
+			for _, piece := range pieceSet {
+				pk := piece.HostPubKey.String()
+				if _, exists := hostKeys[pk]; !exists {
+					hostKeys[pk] = modules.NFTHostHealth{HostPublicKey: piece.HostPubKey}
+				}
+				hostPieces[pk]++
+				good = good || (goodForRenew[pk] && !offline[pk])
+			}
+			if good {
+				goodPieces++
+			}
+		}
+		if goodPieces < report.MinGoodPieces {
+			report.MinGoodPieces = goodPieces
+		}
+	}
+	if report.Chunks == 0 {
+		report.MinGoodPieces = 0
+	}
+
+	// The score is the fraction of redundant pieces that are good.
+	switch {
+	case report.MinGoodPieces < report.MinPieces:
+		report.Score = 0
+	case report.NumPieces == report.MinPieces:
+		report.Score = 1
+	default:
+		report.Score = float64(report.MinGoodPieces-report.MinPieces) / float64(report.NumPieces-report.MinPieces)
+	}
+
+	// Collect the storage proofs of all of the renter's contracts with the
+	// hosts.
+	height := r.cs.Height()
+	for _, c := range append(r.OldContracts(), r.Contracts()...) {
+		pk := c.HostPublicKey.String()
+		host, exists := hostKeys[pk]
+		if !exists {
+			continue
+		}
+		status, ok := r.ContractStatus(c.ID)
+		if !ok {
+			continue
+		}
+		if status.StorageProofFoundAtHeight > host.LastProofHeight {
+			host.LastProofHeight = status.StorageProofFoundAtHeight
+		}
+		// Contracts which were renewed don't store data anymore and don't
+		// require a proof.
+		if status.StorageProofFoundAtHeight == 0 && status.DoubleSpendHeight == 0 && status.WindowEnd != 0 && status.WindowEnd <= height && c.Size() > 0 {
+			host.MissedProofs++
+		}
+		hostKeys[pk] = host
+	}
+
+	for pk, host := range hostKeys {
+		host.Pieces = hostPieces[pk]
+		if c, ok := contracts[pk]; ok {
+			host.HasContract = true
+			host.GoodForRenew = c.Utility.GoodForRenew
+			host.GoodForUpload = c.Utility.GoodForUpload
+			host.Offline = offline[pk]
+			host.ContractEndHeight = c.EndHeight
+		}
+		report.Hosts = append(report.Hosts, host)
+	}
+	sort.Slice(report.Hosts, func(i, j int) bool {
+		return report.Hosts[i].HostPublicKey.String() < report.Hosts[j].HostPublicKey.String()
+	})
+	return nil
+}
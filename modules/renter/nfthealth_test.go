@@ -0,0 +1,102 @@
+package renter
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/siatest/dependencies"
+	"go.sia.tech/siad/types"
+)
+
+// TestNFTHealth tests the health report of a pinned NFT.
+func TestNFTHealth(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Disable the background loops to keep the siafile unchanged.
+	rt, err := newRenterTesterWithDependency(t.Name(), &dependencies.DependencyDisableRepairAndHealthLoops{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Pin an NFT.
+	data := fastrand.Bytes(int(modules.SectorSize) / 2)
+	root := crypto.MerkleRoot(data)
+	source := filepath.Join(rt.dir, persist.RandomSuffix())
+	if err := ioutil.WriteFile(source, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rt.renter.NFTHealth(root); !errors.Contains(err, ErrNFTNotPinned) {
+		t.Fatal("expected ErrNFTNotPinned but got", err)
+	}
+	if err := rt.renter.PinNFT(root, source); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without hosts the data can only be recovered from the source.
+	health, err := rt.renter.NFTHealth(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if health.Root != root || health.Chunks != 1 || health.MinGoodPieces != 0 || health.Score != 0 || !health.SourceAvailable {
+		t.Fatalf("unexpected health %+v", health)
+	}
+	if len(health.Reasons) == 0 || !strings.Contains(health.Reasons[0], "only be repaired from the local copy") {
+		t.Fatal("unexpected reasons", health.Reasons)
+	}
+
+	// Add a piece stored on a host without a contract.
+	hpk := types.SiaPublicKey{
+		Algorithm: types.SignatureEd25519,
+		Key:       fastrand.Bytes(crypto.PublicKeySize),
+	}
+	entry, err := rt.renter.staticFileSystem.OpenSiaFile(health.SiaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := entry.AddPiece(hpk, 0, 0, crypto.Hash{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := entry.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Remove the source as well.
+	if err := os.Remove(source); err != nil {
+		t.Fatal(err)
+	}
+	health, err = rt.renter.NFTHealth(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(health.Hosts) != 1 || health.Hosts[0].HostPublicKey.String() != hpk.String() || health.Hosts[0].Pieces != 1 || health.Hosts[0].HasContract {
+		t.Fatalf("unexpected hosts %+v", health.Hosts)
+	}
+	if health.MinGoodPieces != 0 || health.SourceAvailable {
+		t.Fatalf("unexpected health %+v", health)
+	}
+	var noContract, noSource bool
+	for _, reason := range health.Reasons {
+		noContract = noContract || strings.Contains(reason, "no contract with host "+hpk.String())
+		noSource = noSource || strings.Contains(reason, "local copy of the data is missing")
+	}
+	if !noContract || !noSource {
+		t.Fatal("unexpected reasons", health.Reasons)
+	}
+}
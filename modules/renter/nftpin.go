@@ -0,0 +1,326 @@
+package renter
+
+import (
+	"io/ioutil"
+	"os"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+	"go.sia.tech/siad/types"
+)
+
+// nftpin.go contains the NFT pinning service. Pinning an NFT uploads its data
+// to a siafile in the NFTPinFolder, which the repair loop keeps at full
+// redundancy using the local copy of the data. A background loop checks the
+// health of every pinned NFT, prioritizes the repair of the ones that need it
+// and uploads the data again if its siafile was lost. The repair of NFTs whose
+// health score drops below the allowance's threshold is scheduled by
+// nftrepair.go and the previews of image NFTs are generated by nftpreview.go.
+// The hosts storing the data of pinned NFTs are priority hosts of the hostdb,
+// which scans them more frequently to detect their downtime quickly.
+
+var (
+	// ErrNFTAlreadyPinned is returned when pinning an NFT twice.
+	ErrNFTAlreadyPinned = errors.New("nft is already pinned")
+
+	// ErrNFTNotPinned is returned when unpinning or getting the pin of an NFT
+	// which isn't pinned.
+	ErrNFTNotPinned = errors.New("nft is not pinned")
+
+	// errNFTPinRootMismatch is returned when pinning data whose merkle root
+	// isn't the merkle root of the NFT.
+	errNFTPinRootMismatch = errors.New("merkle root of the data doesn't match the nft")
+)
+
+// nftPinSiaPath returns the siapath of the data of a pinned NFT.
+func nftPinSiaPath(root crypto.Hash) (modules.SiaPath, error) {
+	return modules.NFTPinFolder.Join(root.String())
+}
+
+// staticVerifyNFTPinSource checks that the file at source contains the data
+// with the given merkle root.
+func (r *Renter) staticVerifyNFTPinSource(root crypto.Hash, source string) error {
+	data, err := ioutil.ReadFile(source)
+	if err != nil {
+		return errors.AddContext(err, "unable to read the nft's data")
+	}
+	if r.staticMerkleRootCache.MerkleRoot(data) != root {
+		return errNFTPinRootMismatch
+	}
+	return nil
+}
+
+// PinNFT uploads the data of the NFT with the given merkle root from source and
+// keeps it available until the NFT is unpinned.
+func (r *Renter) PinNFT(root crypto.Hash, source string) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	id := r.mu.RLock()
+	_, pinned := r.nftPinIndex(root)
+	r.mu.RUnlock(id)
+	if pinned {
+		return ErrNFTAlreadyPinned
+	}
+	if err := r.staticVerifyNFTPinSource(root, source); err != nil {
+		return err
+	}
+	sp, err := nftPinSiaPath(root)
+	if err != nil {
+		return err
+	}
+	pin := modules.NFTPin{
+		Root:    root,
+		SiaPath: sp,
+		Source:  source,
+		PinTime: types.CurrentTimestamp(),
+	}
+	if err := r.managedUploadNFTPin(pin); err != nil {
+		return err
+	}
+
+	id = r.mu.Lock()
+	if _, pinned := r.nftPinIndex(root); pinned {
+		r.mu.Unlock(id)
+		return ErrNFTAlreadyPinned
+	}
+	r.persist.NFTPins = append(r.persist.NFTPins, pin)
+	err = r.saveSync()
+	r.mu.Unlock(id)
+	if err != nil {
+		return err
+	}
+	go r.threadedGenerateNFTPreviews(pin)
+	return nil
+}
+
+// UnpinNFT stops pinning the data of an NFT and deletes its siafile.
+func (r *Renter) UnpinNFT(root crypto.Hash) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	id := r.mu.Lock()
+	i, pinned := r.nftPinIndex(root)
+	if !pinned {
+		r.mu.Unlock(id)
+		return ErrNFTNotPinned
+	}
+	pin := r.persist.NFTPins[i]
+	r.persist.NFTPins = append(r.persist.NFTPins[:i], r.persist.NFTPins[i+1:]...)
+	err := r.saveSync()
+	r.mu.Unlock(id)
+	if err != nil {
+		return err
+	}
+
+	err = r.DeleteFile(pin.SiaPath)
+	if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+		return errors.AddContext(err, "unable to delete the siafile of the nft")
+	}
+	return errors.AddContext(os.RemoveAll(r.nftPreviewDir(root)), "unable to delete the previews of the nft")
+}
+
+// NFTPin returns the pin of an NFT.
+func (r *Renter) NFTPin(root crypto.Hash) (modules.NFTPin, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.NFTPin{}, err
+	}
+	defer r.tg.Done()
+
+	id := r.mu.RLock()
+	defer r.mu.RUnlock(id)
+	i, pinned := r.nftPinIndex(root)
+	if !pinned {
+		return modules.NFTPin{}, ErrNFTNotPinned
+	}
+	return r.persist.NFTPins[i], nil
+}
+
+// NFTPins returns the pinned NFTs together with the health of their data.
+func (r *Renter) NFTPins() ([]modules.NFTPinInfo, error) {
+	if err := r.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer r.tg.Done()
+
+	id := r.mu.RLock()
+	pins := append([]modules.NFTPin(nil), r.persist.NFTPins...)
+	r.mu.RUnlock(id)
+
+	infos := make([]modules.NFTPinInfo, 0, len(pins))
+	for _, pin := range pins {
+		info := modules.NFTPinInfo{NFTPin: pin}
+		fi, err := r.File(pin.SiaPath)
+		if err == nil {
+			info.Health = fi.Health
+			info.HealthPercent = fi.MaxHealthPercent
+			info.Recoverable = fi.Recoverable
+			info.Redundancy = fi.Redundancy
+			info.Stuck = fi.Stuck
+			info.UploadProgress = fi.UploadProgress
+		} else if !errors.Contains(err, filesystem.ErrNotExist) {
+			return nil, errors.AddContext(err, "unable to get the health of the nft's data")
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// nftPinIndex returns the index of the pin of an NFT in the renter's
+// persistence.
+func (r *Renter) nftPinIndex(root crypto.Hash) (int, bool) {
+	for i, pin := range r.persist.NFTPins {
+		if pin.Root == root {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// managedUploadNFTPin uploads the data of a pinned NFT, replacing any siafile
+// left at its siapath.
+func (r *Renter) managedUploadNFTPin(pin modules.NFTPin) error {
+	err := r.Upload(modules.FileUploadParams{
+		Source:  pin.Source,
+		SiaPath: pin.SiaPath,
+		Force:   true,
+	})
+	if err != nil {
+		return errors.AddContext(err, "unable to upload the nft's data")
+	}
+	return nil
+}
+
+// managedCheckNFTPin checks the health of the data of a pinned NFT. Data which
+// needs repair is pushed to the repair loop and lost siafiles are uploaded
+// again. It returns true if the data was uploaded again.
+func (r *Renter) managedCheckNFTPin(pin modules.NFTPin) (bool, error) {
+	fi, err := r.File(pin.SiaPath)
+	if errors.Contains(err, filesystem.ErrNotExist) {
+		// The siafile was lost, upload the data again if it is unchanged.
+		if err := r.staticVerifyNFTPinSource(pin.Root, pin.Source); err != nil {
+			return false, errors.AddContext(err, "siafile of the nft is lost and can't be uploaded again")
+		}
+		return true, r.managedUploadNFTPin(pin)
+	} else if err != nil {
+		return false, err
+	}
+	if !modules.NeedsRepair(fi.Health) {
+		return false, nil
+	}
+
+	// Make sure the repair loop sees the current health of the directory and
+	// signal it that there is work to do.
+	dirSiaPath, err := pin.SiaPath.Dir()
+	if err != nil {
+		return false, err
+	}
+	_ = r.staticBubbleScheduler.callQueueBubble(dirSiaPath)
+	select {
+	case r.uploadHeap.repairNeeded <- struct{}{}:
+	default:
+	}
+	return false, nil
+}
+
+// managedNFTPinHosts returns the hosts which store pieces of the data of the
+// pinned NFTs.
+func (r *Renter) managedNFTPinHosts(pins []modules.NFTPin) []types.SiaPublicKey {
+	hosts := make(map[string]types.SiaPublicKey)
+	for _, pin := range pins {
+		entry, err := r.staticFileSystem.OpenSiaFile(pin.SiaPath)
+		if err != nil {
+			continue
+		}
+		for chunkIndex := uint64(0); chunkIndex < entry.NumChunks(); chunkIndex++ {
+			pieces, err := entry.Pieces(chunkIndex)
+			if err != nil {
+				break
+			}
+			for _, pieceSet := range pieces {
+				for _, piece := range pieceSet {
+					hosts[piece.HostPubKey.String()] = piece.HostPubKey
+				}
+			}
+		}
+		if err := entry.Close(); err != nil {
+			r.log.Printf("Unable to close the siafile of pinned nft %v: %v", pin.Root, err)
+		}
+	}
+	pks := make([]types.SiaPublicKey, 0, len(hosts))
+	for _, pk := range hosts {
+		pks = append(pks, pk)
+	}
+	return pks
+}
+
+// managedCheckNFTPins checks the health of the data of all pinned NFTs.
+func (r *Renter) managedCheckNFTPins() {
+	id := r.mu.RLock()
+	pins := append([]modules.NFTPin(nil), r.persist.NFTPins...)
+	r.mu.RUnlock(id)
+
+	// Scan the hosts storing the data of the pinned NFTs more frequently.
+	if err := r.hostDB.SetPriorityHosts(r.managedNFTPinHosts(pins)); err != nil {
+		r.log.Println("Unable to set the priority hosts of the hostdb:", err)
+	}
+
+	for _, pin := range pins {
+		if err := r.managedGenerateNFTPreviews(pin); err != nil {
+			r.log.Printf("Unable to generate the previews of pinned nft %v: %v", pin.Root, err)
+		}
+		reuploaded, err := r.managedCheckNFTPin(pin)
+		if err != nil {
+			r.repairLog.Printf("Unable to check pinned nft %v: %v", pin.Root, err)
+			continue
+		}
+		if !reuploaded {
+			scheduled, err := r.managedScheduleNFTRepair(pin.Root)
+			if err != nil {
+				r.repairLog.Printf("Unable to schedule the repair of pinned nft %v: %v", pin.Root, err)
+			} else if scheduled {
+				r.repairLog.Printf("Scheduled the repair of pinned nft %v", pin.Root)
+			}
+			continue
+		}
+		r.repairLog.Printf("Uploaded the data of pinned nft %v again", pin.Root)
+		id := r.mu.Lock()
+		if i, pinned := r.nftPinIndex(pin.Root); pinned {
+			r.persist.NFTPins[i].Reuploads++
+			err = r.saveSync()
+		}
+		r.mu.Unlock(id)
+		if err != nil {
+			r.log.Println("Unable to save the renter after uploading a pinned nft:", err)
+		}
+	}
+}
+
+// threadedNFTPinLoop periodically checks the health of the data of pinned
+// NFTs.
+func (r *Renter) threadedNFTPinLoop() {
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+
+	for {
+		// Can't upload anything if the wallet is locked.
+		if unlocked, _ := r.w.Unlocked(); unlocked {
+			r.managedCheckNFTPins()
+		}
+		select {
+		case <-time.After(nftPinCheckInterval):
+		case <-r.tg.StopChan():
+			return
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package renter
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/siatest/dependencies"
+)
+
+// TestNFTPin tests pinning and unpinning NFTs and that the data of pinned NFTs
+// is uploaded again when its siafile is lost.
+func TestNFTPin(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Disable the background loops to check the pins manually.
+	rt, err := newRenterTesterWithDependency(t.Name(), &dependencies.DependencyDisableRepairAndHealthLoops{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Write the NFT's data to disk.
+	data := fastrand.Bytes(int(modules.SectorSize) / 2)
+	root := crypto.MerkleRoot(data)
+	source := filepath.Join(rt.dir, persist.RandomSuffix())
+	if err := ioutil.WriteFile(source, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Data with a different merkle root can't be pinned.
+	if err := rt.renter.PinNFT(crypto.Hash{}, source); !errors.Contains(err, errNFTPinRootMismatch) {
+		t.Fatal("expected errNFTPinRootMismatch but got", err)
+	}
+
+	// Pin the NFT.
+	if err := rt.renter.PinNFT(root, source); err != nil {
+		t.Fatal(err)
+	}
+	if err := rt.renter.PinNFT(root, source); !errors.Contains(err, ErrNFTAlreadyPinned) {
+		t.Fatal("expected ErrNFTAlreadyPinned but got", err)
+	}
+	pins, err := rt.renter.NFTPins()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sp, err := nftPinSiaPath(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pins) != 1 || pins[0].Root != root || pins[0].SiaPath != sp || pins[0].Source != source {
+		t.Fatalf("unexpected pins %+v", pins)
+	}
+	if _, err := rt.renter.File(sp); err != nil {
+		t.Fatal(err)
+	}
+
+	// Delete the siafile. Checking the pins uploads the data again.
+	if err := rt.renter.DeleteFile(sp); err != nil {
+		t.Fatal(err)
+	}
+	rt.renter.managedCheckNFTPins()
+	if _, err := rt.renter.File(sp); err != nil {
+		t.Fatal(err)
+	}
+
+	// The pins survive a restart.
+	r, err := rt.reloadRenter(rt.renter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pins, err = r.NFTPins()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pins) != 1 || pins[0].Root != root || pins[0].Reuploads != 1 {
+		t.Fatalf("unexpected pins %+v", pins)
+	}
+	pin, err := r.NFTPin(root)
+	if err != nil || pin != pins[0].NFTPin {
+		t.Fatalf("unexpected pin %+v %v", pin, err)
+	}
+
+	// Unpin the NFT, which deletes its siafile.
+	if err := r.UnpinNFT(root); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.UnpinNFT(root); !errors.Contains(err, ErrNFTNotPinned) {
+		t.Fatal("expected ErrNFTNotPinned but got", err)
+	}
+	if _, err := r.NFTPin(root); !errors.Contains(err, ErrNFTNotPinned) {
+		t.Fatal("expected ErrNFTNotPinned but got", err)
+	}
+	if _, err := r.File(sp); !errors.Contains(err, filesystem.ErrNotExist) {
+		t.Fatal("expected siafile to be deleted but got", err)
+	}
+	pins, err = r.NFTPins()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pins) != 0 {
+		t.Fatalf("unexpected pins %+v", pins)
+	}
+}
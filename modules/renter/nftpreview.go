@@ -0,0 +1,222 @@
+package renter
+
+import (
+	"bytes"
+	"image"
+	"image/draw"
+	_ "image/gif" // register the gif decoder
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+// nftpreview.go contains the preview pipeline for image NFTs. If enabled in the
+// renter's settings, pinning an NFT whose data is a gif, jpeg or png image
+// generates downscaled previews of it, which are stored in the renter's
+// directory and served by the NFT gateway. The NFT pin loop generates the
+// previews of NFTs which were pinned before the pipeline was enabled.
+
+const (
+	// nftPreviewsDir is the directory of the renter containing the previews
+	// of pinned NFTs. Every NFT which was processed has a subdirectory named
+	// after its merkle root, which is empty if the NFT isn't an image.
+	nftPreviewsDir = "nftpreviews"
+
+	// maxNFTPreviewPixels is the largest number of pixels of an image the
+	// renter generates previews for. It protects the renter from decoding
+	// images which would use an excessive amount of memory.
+	maxNFTPreviewPixels = 1 << 26
+
+	// nftPreviewJPEGQuality is the quality of previews encoded as jpeg.
+	nftPreviewJPEGQuality = 85
+)
+
+var (
+	// ErrNoNFTPreview is returned when requesting a preview which doesn't
+	// exist, e.g. because the NFT isn't an image.
+	ErrNoNFTPreview = errors.New("nft has no preview")
+
+	// errInvalidNFTPreviewSize is returned when requesting a preview with a
+	// size which isn't one of the NFTPreviewSizes.
+	errInvalidNFTPreviewSize = errors.New("invalid nft preview size")
+)
+
+// nftPreviewDir returns the directory of the previews of an NFT.
+func (r *Renter) nftPreviewDir(root crypto.Hash) string {
+	return filepath.Join(r.persistDir, nftPreviewsDir, root.String())
+}
+
+// staticDownscaleImage returns a copy of the image whose longer side is at
+// most size pixels. Every pixel of the copy is the average of the pixels it
+// covers in the original. Images which are small enough are copied unchanged.
+func staticDownscaleImage(img image.Image, size int) *image.RGBA {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if w > size || h > size {
+		if w >= h {
+			dw, dh = size, h*size/w
+		} else {
+			dw, dh = w*size/h, size
+		}
+		if dw < 1 {
+			dw = 1
+		}
+		if dh < 1 {
+			dh = 1
+		}
+	}
+
+	// Convert the image to premultiplied RGBA, which can be averaged without
+	// color fringes at transparent edges.
+	src, ok := img.(*image.RGBA)
+	if !ok || b.Min != (image.Point{}) {
+		src = image.NewRGBA(image.Rect(0, 0, w, h))
+		draw.Draw(src, src.Bounds(), img, b.Min, draw.Src)
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	if dw == w && dh == h {
+		copy(dst.Pix, src.Pix)
+		return dst
+	}
+	for y := 0; y < dh; y++ {
+		y0, y1 := y*h/dh, (y+1)*h/dh
+		for x := 0; x < dw; x++ {
+			x0, x1 := x*w/dw, (x+1)*w/dw
+			var sum [4]uint64
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride+x0*4 : sy*src.Stride+x1*4]
+				for i := 0; i < len(row); i += 4 {
+					sum[0] += uint64(row[i])
+					sum[1] += uint64(row[i+1])
+					sum[2] += uint64(row[i+2])
+					sum[3] += uint64(row[i+3])
+				}
+			}
+			n := uint64((x1 - x0) * (y1 - y0))
+			i := dst.PixOffset(x, y)
+			for c := range sum {
+				dst.Pix[i+c] = uint8((sum[c] + n/2) / n)
+			}
+		}
+	}
+	return dst
+}
+
+// staticGenerateNFTPreviews generates the previews of the image at source and
+// writes them to dir. Images which aren't gif, jpeg or png don't get previews.
+// Previews of jpeg images are encoded as jpeg, all others as png to keep their
+// transparency.
+func staticGenerateNFTPreviews(source, dir string) error {
+	data, err := ioutil.ReadFile(source)
+	if err != nil {
+		return errors.AddContext(err, "unable to read the nft's data")
+	}
+	if err := os.MkdirAll(dir, modules.DefaultDirPerm); err != nil {
+		return err
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if errors.Contains(err, image.ErrFormat) {
+		return nil
+	} else if err != nil {
+		return errors.AddContext(err, "unable to decode the nft's image")
+	}
+	if cfg.Width*cfg.Height > maxNFTPreviewPixels {
+		return nil
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return errors.AddContext(err, "unable to decode the nft's image")
+	}
+	for _, size := range modules.NFTPreviewSizes {
+		preview := staticDownscaleImage(img, size)
+		var buf bytes.Buffer
+		if format == "jpeg" {
+			err = jpeg.Encode(&buf, preview, &jpeg.Options{Quality: nftPreviewJPEGQuality})
+		} else {
+			err = png.Encode(&buf, preview)
+		}
+		if err != nil {
+			return errors.AddContext(err, "unable to encode the nft's preview")
+		}
+		// Write the preview to a temporary file first to never serve a
+		// partially written preview.
+		path := filepath.Join(dir, strconv.Itoa(size))
+		if err := ioutil.WriteFile(path+"_temp", buf.Bytes(), modules.DefaultFilePerm); err != nil {
+			return err
+		}
+		if err := os.Rename(path+"_temp", path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// managedGenerateNFTPreviews generates the previews of a pinned NFT unless
+// previews are disabled or the NFT was already processed.
+func (r *Renter) managedGenerateNFTPreviews(pin modules.NFTPin) error {
+	id := r.mu.RLock()
+	enabled := r.persist.NFTPreviews
+	r.mu.RUnlock(id)
+	if !enabled {
+		return nil
+	}
+	dir := r.nftPreviewDir(pin.Root)
+	if _, err := os.Stat(dir); err == nil {
+		return nil
+	}
+	err := staticGenerateNFTPreviews(pin.Source, dir)
+	if err != nil {
+		// Remove the directory to try again later.
+		err = errors.Compose(err, os.RemoveAll(dir))
+	}
+	return err
+}
+
+// threadedGenerateNFTPreviews generates the previews of an NFT which was just
+// pinned.
+func (r *Renter) threadedGenerateNFTPreviews(pin modules.NFTPin) {
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+	if err := r.managedGenerateNFTPreviews(pin); err != nil {
+		r.log.Printf("Unable to generate the previews of pinned nft %v: %v", pin.Root, err)
+	}
+}
+
+// NFTPreview returns the preview of a pinned image NFT with one of the
+// NFTPreviewSizes.
+func (r *Renter) NFTPreview(root crypto.Hash, size int) ([]byte, error) {
+	if err := r.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer r.tg.Done()
+
+	valid := false
+	for _, s := range modules.NFTPreviewSizes {
+		valid = valid || s == size
+	}
+	if !valid {
+		return nil, errInvalidNFTPreviewSize
+	}
+	id := r.mu.RLock()
+	_, pinned := r.nftPinIndex(root)
+	r.mu.RUnlock(id)
+	if !pinned {
+		return nil, ErrNFTNotPinned
+	}
+	preview, err := ioutil.ReadFile(filepath.Join(r.nftPreviewDir(root), strconv.Itoa(size)))
+	if os.IsNotExist(err) {
+		return nil, ErrNoNFTPreview
+	}
+	return preview, err
+}
@@ -0,0 +1,218 @@
+package renter
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/siatest/dependencies"
+)
+
+// testNFTImage returns a w by h image with random opaque pixels.
+func testNFTImage(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	fastrand.Read(img.Pix)
+	for i := 3; i < len(img.Pix); i += 4 {
+		img.Pix[i] = 255
+	}
+	return img
+}
+
+// TestDownscaleImage is a unit test for staticDownscaleImage.
+func TestDownscaleImage(t *testing.T) {
+	t.Parallel()
+
+	// The aspect ratio is kept and small images aren't upscaled.
+	tests := []struct {
+		w, h, size, dw, dh int
+	}{
+		{1000, 500, 256, 256, 128},
+		{500, 1000, 256, 128, 256},
+		{100, 50, 256, 100, 50},
+		{1000, 1, 256, 256, 1},
+	}
+	for _, test := range tests {
+		b := staticDownscaleImage(testNFTImage(test.w, test.h), test.size).Bounds()
+		if b.Dx() != test.dw || b.Dy() != test.dh {
+			t.Errorf("downscaling %vx%v to %v resulted in %vx%v, expected %vx%v", test.w, test.h, test.size, b.Dx(), b.Dy(), test.dw, test.dh)
+		}
+	}
+
+	// Every pixel is the average of the pixels it covers, also for images
+	// whose bounds don't start at the origin.
+	img := image.NewRGBA(image.Rect(10, 10, 12, 12))
+	img.Set(10, 10, color.RGBA{255, 255, 255, 255})
+	img.Set(11, 11, color.RGBA{255, 255, 255, 255})
+	img.Set(10, 11, color.RGBA{0, 0, 0, 255})
+	img.Set(11, 10, color.RGBA{0, 0, 0, 255})
+	preview := staticDownscaleImage(img, 1)
+	if c := preview.RGBAAt(0, 0); c != (color.RGBA{128, 128, 128, 255}) {
+		t.Fatal("wrong average", c)
+	}
+}
+
+// TestGenerateNFTPreviews is a unit test for staticGenerateNFTPreviews.
+func TestGenerateNFTPreviews(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	testDir := build.TempDir("renter", t.Name())
+	if err := os.MkdirAll(testDir, modules.DefaultDirPerm); err != nil {
+		t.Fatal(err)
+	}
+
+	// Write a png, a jpeg and some data which isn't an image.
+	img := testNFTImage(2000, 1000)
+	var pngData, jpegData bytes.Buffer
+	if err := png.Encode(&pngData, img); err != nil {
+		t.Fatal(err)
+	}
+	if err := jpeg.Encode(&jpegData, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	sources := map[string][]byte{
+		"png":  pngData.Bytes(),
+		"jpeg": jpegData.Bytes(),
+		"data": fastrand.Bytes(1000),
+	}
+	for name, data := range sources {
+		if err := ioutil.WriteFile(filepath.Join(testDir, name), data, modules.DefaultFilePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The previews keep the format of the images.
+	for _, format := range []string{"png", "jpeg"} {
+		dir := filepath.Join(testDir, format+"previews")
+		if err := staticGenerateNFTPreviews(filepath.Join(testDir, format), dir); err != nil {
+			t.Fatal(err)
+		}
+		for _, size := range modules.NFTPreviewSizes {
+			f, err := os.Open(filepath.Join(dir, strconv.Itoa(size)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			cfg, previewFormat, err := image.DecodeConfig(f)
+			if err := errors.Compose(err, f.Close()); err != nil {
+				t.Fatal(err)
+			}
+			if previewFormat != format || cfg.Width != size || cfg.Height != size/2 {
+				t.Fatalf("wrong %v preview of size %v: %v %vx%v", format, size, previewFormat, cfg.Width, cfg.Height)
+			}
+		}
+	}
+
+	// Data which isn't an image gets no previews.
+	dir := filepath.Join(testDir, "datapreviews")
+	if err := staticGenerateNFTPreviews(filepath.Join(testDir, "data"), dir); err != nil {
+		t.Fatal(err)
+	}
+	if fis, err := ioutil.ReadDir(dir); err != nil || len(fis) != 0 {
+		t.Fatal("expected empty preview dir", len(fis), err)
+	}
+}
+
+// TestNFTPreview tests that the renter generates the previews of pinned image
+// NFTs if previews are enabled.
+func TestNFTPreview(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTesterWithDependency(t.Name(), &dependencies.DependencyDisableRepairAndHealthLoops{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	pin := func(data []byte) crypto.Hash {
+		root := crypto.MerkleRoot(data)
+		source := filepath.Join(rt.dir, persist.RandomSuffix())
+		if err := ioutil.WriteFile(source, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := rt.renter.PinNFT(root, source); err != nil {
+			t.Fatal(err)
+		}
+		return root
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testNFTImage(300, 300)); err != nil {
+		t.Fatal(err)
+	}
+	size := modules.NFTPreviewSizes[0]
+
+	// Without previews enabled, pinning an image doesn't generate previews.
+	root := pin(buf.Bytes())
+	rt.renter.managedCheckNFTPins()
+	if _, err := rt.renter.NFTPreview(root, size); !errors.Contains(err, ErrNoNFTPreview) {
+		t.Fatal("expected ErrNoNFTPreview but got", err)
+	}
+
+	// Enable previews. Checking the pins generates the missing previews.
+	settings, err := rt.renter.Settings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings.NFTPreviews = true
+	if err := rt.renter.SetSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+	rt.renter.managedCheckNFTPins()
+	preview, err := rt.renter.NFTPreview(root, size)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := png.DecodeConfig(bytes.NewReader(preview))
+	if err != nil || cfg.Width != size {
+		t.Fatal("wrong preview", cfg.Width, err)
+	}
+	if _, err := rt.renter.NFTPreview(root, size+1); !errors.Contains(err, errInvalidNFTPreviewSize) {
+		t.Fatal("expected errInvalidNFTPreviewSize but got", err)
+	}
+
+	// Pinning an image generates its previews in the background.
+	buf.Reset()
+	if err := png.Encode(&buf, testNFTImage(100, 100)); err != nil {
+		t.Fatal(err)
+	}
+	root2 := pin(buf.Bytes())
+	err = build.Retry(100, 10*time.Millisecond, func() error {
+		_, err := rt.renter.NFTPreview(root2, size)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Unpinning removes the previews.
+	if err := rt.renter.UnpinNFT(root); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(rt.renter.nftPreviewDir(root)); !os.IsNotExist(err) {
+		t.Fatal("previews weren't removed", err)
+	}
+	if _, err := rt.renter.NFTPreview(root, size); !errors.Contains(err, ErrNFTNotPinned) {
+		t.Fatal("expected ErrNFTNotPinned but got", err)
+	}
+}
@@ -0,0 +1,213 @@
+package renter
+
+import (
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// nftrepair.go contains the repair scheduler of pinned NFTs. The scheduler
+// watches the health scores of pinned NFTs and pushes the chunks of NFTs whose
+// score dropped below the allowance's threshold onto the upload heap with high
+// priority. The number of repairs per hour and the estimated amount spent on
+// repairs per period are limited by the allowance.
+
+var (
+	// errNFTRepairRateLimited is returned if the maximum number of NFT
+	// repairs within the last hour was reached.
+	errNFTRepairRateLimited = errors.New("maximum number of nft repairs per hour reached")
+
+	// errNFTRepairSpendingCap is returned if an NFT repair would exceed the
+	// maximum NFT repair spending of the allowance.
+	errNFTRepairSpendingCap = errors.New("nft repair would exceed the maximum nft repair spending of the period")
+
+	// errNFTUnrecoverable is returned if the data of an NFT can neither be
+	// downloaded from the hosts nor read from its local copy.
+	errNFTUnrecoverable = errors.New("data of the nft can't be recovered")
+)
+
+// allowanceNFTRepairThreshold returns the health score below which the data of
+// a pinned NFT is repaired.
+func allowanceNFTRepairThreshold(a modules.Allowance) float64 {
+	if a.NFTRepairThreshold == 0 {
+		return defaultNFTRepairThreshold
+	}
+	return a.NFTRepairThreshold
+}
+
+// allowanceMaxNFTRepairsPerHour returns the number of NFT repairs started at
+// most within an hour.
+func allowanceMaxNFTRepairsPerHour(a modules.Allowance) uint64 {
+	if a.MaxNFTRepairsPerHour == 0 {
+		return defaultMaxNFTRepairsPerHour
+	}
+	return a.MaxNFTRepairsPerHour
+}
+
+// allowanceMaxNFTRepairSpending returns the amount spent at most on NFT
+// repairs within a period.
+func allowanceMaxNFTRepairSpending(a modules.Allowance) types.Currency {
+	if a.MaxNFTRepairSpending.IsZero() {
+		return a.Funds.MulFloat(defaultNFTRepairSpendingFraction)
+	}
+	return a.MaxNFTRepairSpending
+}
+
+// managedEstimateNFTRepairCost estimates the cost of uploading the given
+// number of pieces to the renter's hosts and storing them until the end of the
+// period.
+func (r *Renter) managedEstimateNFTRepairCost(pieces uint64, a modules.Allowance) types.Currency {
+	height := r.cs.Height()
+	periodEnd := r.hostContractor.CurrentPeriod() + a.Period
+	var duration types.BlockHeight
+	if periodEnd > height {
+		duration = periodEnd - height
+	}
+
+	// Use the average price of the hosts the renter has contracts with.
+	var total types.Currency
+	var hosts uint64
+	for _, c := range r.Contracts() {
+		host, ok, err := r.hostDB.Host(c.HostPublicKey)
+		if err != nil || !ok {
+			continue
+		}
+		storage := host.StoragePriceFor(a.NFTStorage).Mul64(uint64(duration))
+		total = total.Add(host.UploadBandwidthPrice).Add(storage)
+		hosts++
+	}
+	if hosts == 0 {
+		return types.ZeroCurrency
+	}
+	return total.Div64(hosts).Mul64(pieces).Mul64(modules.SectorSize)
+}
+
+// managedCheckNFTRepairLimits checks whether an NFT repair with the given cost
+// can be started without exceeding the rate limit and the spending cap of the
+// allowance.
+func (r *Renter) managedCheckNFTRepairLimits(cost types.Currency, a modules.Allowance) error {
+	period := r.hostContractor.CurrentPeriod()
+	id := r.mu.Lock()
+	defer r.mu.Unlock(id)
+
+	// Forget the repairs which were started more than an hour ago.
+	recent := r.nftRepairTimes[:0]
+	for _, t := range r.nftRepairTimes {
+		if time.Since(t) < time.Hour {
+			recent = append(recent, t)
+		}
+	}
+	r.nftRepairTimes = recent
+	if uint64(len(r.nftRepairTimes)) >= allowanceMaxNFTRepairsPerHour(a) {
+		return errNFTRepairRateLimited
+	}
+
+	// Reset the spending when a new period starts.
+	if r.persist.NFTRepairPeriod != period {
+		r.persist.NFTRepairPeriod = period
+		r.persist.NFTRepairSpending = types.ZeroCurrency
+	}
+	if r.persist.NFTRepairSpending.Add(cost).Cmp(allowanceMaxNFTRepairSpending(a)) > 0 {
+		return errNFTRepairSpendingCap
+	}
+	return nil
+}
+
+// managedRecordNFTRepair records a started NFT repair with the given cost.
+func (r *Renter) managedRecordNFTRepair(cost types.Currency) error {
+	id := r.mu.Lock()
+	defer r.mu.Unlock(id)
+	r.nftRepairTimes = append(r.nftRepairTimes, time.Now())
+	r.persist.NFTRepairSpending = r.persist.NFTRepairSpending.Add(cost)
+	return r.saveSync()
+}
+
+// managedPushNFTRepair pushes the chunks of an NFT's siafile which are missing
+// pieces onto the upload heap with high priority. It returns the number of
+// pushed chunks.
+func (r *Renter) managedPushNFTRepair(siaPath modules.SiaPath) (pushed int, err error) {
+	entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		err = errors.Compose(err, entry.Close())
+	}()
+
+	hosts := r.managedRefreshHostsAndWorkers()
+	offline, goodForRenew, _ := r.managedContractUtilityMaps()
+	pks := make(map[string]types.SiaPublicKey)
+	for _, pk := range entry.HostPublicKeys() {
+		pks[string(pk.Key)] = pk
+	}
+	for chunkIndex := uint64(0); chunkIndex < entry.NumChunks(); chunkIndex++ {
+		chunk, err := r.managedBuildUnfinishedChunk(entry, chunkIndex, hosts, pks, memoryPriorityHigh, offline, goodForRenew, r.repairMemoryManager)
+		if err != nil {
+			return pushed, err
+		}
+		if chunk.piecesCompleted >= chunk.staticPiecesNeeded {
+			if err := chunk.fileEntry.Close(); err != nil {
+				return pushed, err
+			}
+			continue
+		}
+		ok, err := r.managedPushChunkForRepair(chunk, chunkTypeLocalChunk)
+		if err != nil || !ok {
+			// The chunk wasn't added to the heap. Close its file.
+			err = errors.Compose(err, chunk.fileEntry.Close())
+			if err != nil {
+				return pushed, err
+			}
+			continue
+		}
+		pushed++
+	}
+
+	// Signal the repair loop that there is work to do.
+	if pushed > 0 {
+		select {
+		case r.uploadHeap.repairNeeded <- struct{}{}:
+		default:
+		}
+	}
+	return pushed, nil
+}
+
+// managedScheduleNFTRepair repairs the data of a pinned NFT if its health
+// score dropped below the allowance's NFT repair threshold. It returns true if
+// a repair was started.
+func (r *Renter) managedScheduleNFTRepair(root crypto.Hash) (bool, error) {
+	a := r.hostContractor.Allowance()
+	if !a.Active() {
+		return false, nil
+	}
+	health, err := r.NFTHealth(root)
+	if err != nil {
+		return false, err
+	}
+	// Lost siafiles are uploaded again by managedCheckNFTPin.
+	if health.Chunks == 0 || health.Score >= allowanceNFTRepairThreshold(a) {
+		return false, nil
+	}
+	if !health.Recoverable {
+		return false, errNFTUnrecoverable
+	}
+
+	missingPieces := health.Chunks * uint64(health.NumPieces-health.MinGoodPieces)
+	cost := r.managedEstimateNFTRepairCost(missingPieces, a)
+	if err := r.managedCheckNFTRepairLimits(cost, a); err != nil {
+		return false, err
+	}
+	pushed, err := r.managedPushNFTRepair(health.SiaPath)
+	if err != nil {
+		return false, errors.AddContext(err, "unable to push the chunks of the nft")
+	}
+	if pushed == 0 {
+		return false, nil
+	}
+	return true, r.managedRecordNFTRepair(cost)
+}
@@ -0,0 +1,100 @@
+package renter
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestAllowanceNFTRepair tests falling back to the defaults for the NFT repair
+// fields of the allowance.
+func TestAllowanceNFTRepair(t *testing.T) {
+	a := modules.Allowance{Funds: types.NewCurrency64(1000)}
+	if allowanceNFTRepairThreshold(a) != defaultNFTRepairThreshold {
+		t.Fatal("wrong default threshold", allowanceNFTRepairThreshold(a))
+	}
+	if allowanceMaxNFTRepairsPerHour(a) != defaultMaxNFTRepairsPerHour {
+		t.Fatal("wrong default repairs per hour", allowanceMaxNFTRepairsPerHour(a))
+	}
+	if !allowanceMaxNFTRepairSpending(a).Equals64(100) {
+		t.Fatal("wrong default spending", allowanceMaxNFTRepairSpending(a))
+	}
+
+	a.NFTRepairThreshold = 0.5
+	a.MaxNFTRepairsPerHour = 3
+	a.MaxNFTRepairSpending = types.NewCurrency64(500)
+	if allowanceNFTRepairThreshold(a) != 0.5 || allowanceMaxNFTRepairsPerHour(a) != 3 || !allowanceMaxNFTRepairSpending(a).Equals64(500) {
+		t.Fatal("allowance values weren't used")
+	}
+}
+
+// TestNFTRepairLimits tests the rate limit and the spending cap of NFT
+// repairs.
+func TestNFTRepairLimits(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+
+	a := modules.Allowance{
+		Funds:                types.NewCurrency64(1000),
+		MaxNFTRepairsPerHour: 2,
+		MaxNFTRepairSpending: types.NewCurrency64(100),
+	}
+	cost := types.NewCurrency64(40)
+
+	// Two repairs fit into the cap and the rate limit.
+	for i := 0; i < 2; i++ {
+		if err := r.managedCheckNFTRepairLimits(cost, a); err != nil {
+			t.Fatal(err)
+		}
+		if err := r.managedRecordNFTRepair(cost); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.managedCheckNFTRepairLimits(types.ZeroCurrency, a); !errors.Contains(err, errNFTRepairRateLimited) {
+		t.Fatal("expected errNFTRepairRateLimited but got", err)
+	}
+
+	// Repairs older than an hour don't count towards the rate limit but
+	// their spending does.
+	id := r.mu.Lock()
+	for i := range r.nftRepairTimes {
+		r.nftRepairTimes[i] = r.nftRepairTimes[i].Add(-time.Hour)
+	}
+	r.mu.Unlock(id)
+	if err := r.managedCheckNFTRepairLimits(cost, a); !errors.Contains(err, errNFTRepairSpendingCap) {
+		t.Fatal("expected errNFTRepairSpendingCap but got", err)
+	}
+	if err := r.managedCheckNFTRepairLimits(types.NewCurrency64(20), a); err != nil {
+		t.Fatal(err)
+	}
+
+	// The spending is reset in a new period.
+	id = r.mu.Lock()
+	r.persist.NFTRepairPeriod++
+	r.mu.Unlock(id)
+	if err := r.managedCheckNFTRepairLimits(types.NewCurrency64(100), a); err != nil {
+		t.Fatal(err)
+	}
+	id = r.mu.RLock()
+	spending := r.persist.NFTRepairSpending
+	r.mu.RUnlock(id)
+	if !spending.IsZero() {
+		t.Fatal("spending wasn't reset", spending)
+	}
+}
@@ -60,6 +60,15 @@ type (
 		MaxUploadSpeed   int64
 		UploadedBackups  []modules.UploadedBackup
 		SyncedContracts  []types.FileContractID
+		NFTPins          []modules.NFTPin
+		NFTPreviews      bool
+		SectorCacheSize  uint64
+		UploadSchedule   modules.UploadSchedule
+
+		// NFTRepairSpending is the estimated amount spent on repairing pinned
+		// NFTs during NFTRepairPeriod.
+		NFTRepairSpending types.Currency
+		NFTRepairPeriod   types.BlockHeight
 	}
 )
 
@@ -76,6 +85,7 @@ func (r *Renter) managedLoadSettings() error {
 		// No persistence yet, set the defaults and continue.
 		r.persist.MaxDownloadSpeed = DefaultMaxDownloadSpeed
 		r.persist.MaxUploadSpeed = DefaultMaxUploadSpeed
+		r.persist.SectorCacheSize = DefaultSectorCacheSize
 		id := r.mu.Lock()
 		err = r.saveSync()
 		r.mu.Unlock(id)
@@ -197,5 +207,9 @@ func (r *Renter) managedInitPersist() error {
 	if err != nil && !errors.Contains(err, filesystem.ErrExists) {
 		return err
 	}
+	err = fs.NewSiaDir(modules.NFTPinFolder, modules.DefaultDirPerm)
+	if err != nil && !errors.Contains(err, filesystem.ErrExists) {
+		return err
+	}
 	return nil
 }
@@ -0,0 +1,69 @@
+package renter
+
+// proofverifier.go implements a pool of threads which verify the merkle proofs
+// of downloaded sectors. Verifying the proof of a full sector requires hashing
+// all of its data. Doing that on the thread of the read job keeps the job and
+// its bandwidth reservation on the worker alive until the proof is verified,
+// which serializes downloads from fast hosts behind proof verification. With
+// the proofVerifier a read job hands off the verification and the worker can
+// start its next job right away.
+
+import (
+	"runtime"
+
+	"gitlab.com/NebulousLabs/threadgroup"
+)
+
+type (
+	// proofVerifier runs verification tasks on a fixed number of threads.
+	proofVerifier struct {
+		staticTasks chan func()
+		staticTG    *threadgroup.ThreadGroup
+	}
+)
+
+// newProofVerifier creates a proofVerifier with one thread per CPU. The threads
+// exit when the threadgroup is stopped.
+func newProofVerifier(tg *threadgroup.ThreadGroup) *proofVerifier {
+	pv := &proofVerifier{
+		staticTasks: make(chan func()),
+		staticTG:    tg,
+	}
+	for i := 0; i < runtime.NumCPU(); i++ {
+		go pv.threadedVerify()
+	}
+	return pv
+}
+
+// callVerify runs the verification task on one of the verifier's threads. It
+// blocks until a thread is available to make sure that downloads don't outpace
+// verification by too much. After shutdown, or for a nil verifier, the task
+// is executed on the calling thread instead.
+func (pv *proofVerifier) callVerify(task func()) {
+	if pv == nil {
+		task()
+		return
+	}
+	select {
+	case pv.staticTasks <- task:
+	case <-pv.staticTG.StopChan():
+		task()
+	}
+}
+
+// threadedVerify executes verification tasks until the threadgroup is
+// stopped.
+func (pv *proofVerifier) threadedVerify() {
+	if err := pv.staticTG.Add(); err != nil {
+		return
+	}
+	defer pv.staticTG.Done()
+	for {
+		select {
+		case task := <-pv.staticTasks:
+			task()
+		case <-pv.staticTG.StopChan():
+			return
+		}
+	}
+}
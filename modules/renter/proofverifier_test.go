@@ -0,0 +1,114 @@
+package renter
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/fastrand"
+	"gitlab.com/NebulousLabs/threadgroup"
+
+	"go.sia.tech/siad/crypto"
+)
+
+// TestProofVerifier is a unit test for the proofVerifier.
+func TestProofVerifier(t *testing.T) {
+	t.Parallel()
+
+	var tg threadgroup.ThreadGroup
+	pv := newProofVerifier(&tg)
+
+	// Run a few tasks concurrently.
+	var wg sync.WaitGroup
+	var executed uint64
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go pv.callVerify(func() {
+			atomic.AddUint64(&executed, 1)
+			wg.Done()
+		})
+	}
+	wg.Wait()
+	if executed != 100 {
+		t.Fatal("expected 100 executed tasks but got", executed)
+	}
+
+	// After shutdown the tasks should be executed on the calling thread.
+	if err := tg.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	done := false
+	pv.callVerify(func() {
+		done = true
+	})
+	if !done {
+		t.Fatal("task wasn't executed after shutdown")
+	}
+
+	// A nil verifier should execute the tasks on the calling thread as well.
+	done = false
+	var nilVerifier *proofVerifier
+	nilVerifier.callVerify(func() {
+		done = true
+	})
+	if !done {
+		t.Fatal("task wasn't executed by nil verifier")
+	}
+}
+
+// BenchmarkProofVerifier benchmarks the throughput of a worker which downloads
+// full sectors and verifies their proofs inline or on a proofVerifier. The
+// download of a sector is simulated to take 10ms, which corresponds to a
+// connection of 400 MB/s.
+//
+// Results (goos: linux, goarch: amd64, cpu: Intel Xeon, 1 core, 10/16/2026)
+//
+// BenchmarkProofVerifier/Inline    99 MB/s
+// BenchmarkProofVerifier/Pool     130 MB/s
+//
+// With a single core the pool is limited by the verification itself but the
+// downloads overlap with it. With more cores the pool scales with the number
+// of cores until the connection becomes the bottleneck.
+func BenchmarkProofVerifier(b *testing.B) {
+	const sectorSize = 1 << 22
+	const downloadTime = 10 * time.Millisecond
+	sector := fastrand.Bytes(sectorSize)
+	root := crypto.MerkleRoot(sector)
+	numSegments := sectorSize / crypto.SegmentSize
+	proof := crypto.MerkleRangeProof(sector, 0, numSegments)
+	verify := func() {
+		if !crypto.VerifyRangeProof(sector, proof, 0, numSegments, root) {
+			b.Error("proof verification failed")
+		}
+	}
+
+	b.Run("Inline", func(b *testing.B) {
+		b.SetBytes(sectorSize)
+		for i := 0; i < b.N; i++ {
+			time.Sleep(downloadTime)
+			verify()
+		}
+	})
+	b.Run("Pool", func(b *testing.B) {
+		var tg threadgroup.ThreadGroup
+		pv := newProofVerifier(&tg)
+		defer func() {
+			if err := tg.Stop(); err != nil {
+				b.Fatal(err)
+			}
+		}()
+		b.SetBytes(sectorSize)
+		b.ResetTimer()
+		var wg sync.WaitGroup
+		for i := 0; i < b.N; i++ {
+			time.Sleep(downloadTime)
+			wg.Add(1)
+			pv.callVerify(func() {
+				verify()
+				wg.Done()
+			})
+		}
+		wg.Wait()
+	})
+}
@@ -32,6 +32,10 @@ const (
 	// remainingFile is a constant used to indicate that a fileSection can access
 	// the whole remaining file instead of being bound to a certain end offset.
 	remainingFile = -1
+
+	// merkleRootCacheSize is the number of sector roots cached by a
+	// ContractSet. Every entry takes up less than 200 bytes.
+	merkleRootCacheSize = 1 << 12
 )
 
 var (
@@ -67,6 +71,15 @@ var (
 		Testing:  0.002,
 	}).(float64)
 
+	// sessionPricesValidity is the amount of time for which a session uses the
+	// host's settings before fetching them again. The prices of the session's
+	// RPCs are calculated from the settings.
+	sessionPricesValidity = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: 10 * time.Minute,
+		Testing:  3 * time.Second,
+	}).(time.Duration)
+
 	// sectorHeight is the height of a Merkle tree that covers a single
 	// sector. It is log2(modules.SectorSize / crypto.SegmentSize)
 	sectorHeight = func() uint64 {
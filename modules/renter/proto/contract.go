@@ -922,9 +922,69 @@ func loadSafeContractHeader(f io.ReadSeeker, decodeMaxSize int) (contractHeader,
 	return header, nil
 }
 
+// walTxnContractID returns the id of the contract a wal transaction applies to
+// or the zero id if the transaction doesn't apply to a contract.
+func walTxnContractID(t *writeaheadlog.Transaction) (types.FileContractID, error) {
+	// NOTE: we assume here that if any of the updates apply to the contract,
+	// the whole transaction applies to the contract.
+	if len(t.Updates) == 0 {
+		return types.FileContractID{}, nil
+	}
+	switch update := t.Updates[0]; update.Name {
+	case updateNameSetHeader:
+		var u updateSetHeader
+		if err := unmarshalHeader(update.Instructions, &u); err != nil {
+			return types.FileContractID{}, errors.AddContext(err, "unable to unmarshal the contract header during wal txn recovery")
+		}
+		return u.ID, nil
+	case updateNameSetRoot:
+		var u updateSetRoot
+		if err := encoding.Unmarshal(update.Instructions, &u); err != nil {
+			return types.FileContractID{}, errors.AddContext(err, "unable to unmarshal the update root set during wal txn recovery")
+		}
+		return u.ID, nil
+	}
+	return types.FileContractID{}, nil
+}
+
+// compactUnappliedTxns marks the unapplied transactions of a contract which
+// was just loaded as applied if they contain an older revision than the
+// contract's header. Such transactions were superseded by a revision which was
+// committed later, so they can neither be applied nor help to resync the
+// contract with the host. Without compaction they would stay in the WAL until
+// the next revision of the contract and be recovered again at every startup.
+func (c *SafeContract) compactUnappliedTxns() error {
+	revisionNumber := c.header.LastRevision().NewRevisionNumber
+	var remaining []*unappliedWalTxn
+	for _, t := range c.unappliedTxns {
+		stale := false
+		for _, update := range t.Updates {
+			if update.Name != updateNameSetHeader {
+				continue
+			}
+			var u updateSetHeader
+			if err := unmarshalHeader(update.Instructions, &u); err != nil {
+				return errors.AddContext(err, "unable to unmarshal the contract header during wal txn compaction")
+			}
+			stale = u.Header.LastRevision().NewRevisionNumber < revisionNumber
+		}
+		if !stale {
+			remaining = append(remaining, t)
+			continue
+		}
+		if err := t.SignalUpdatesApplied(); err != nil {
+			return errors.AddContext(err, "failed to mark stale wal txn as applied")
+		}
+	}
+	c.unappliedTxns = remaining
+	return nil
+}
+
 // loadSafeContract loads a contract from disk and adds it to the contractset
-// if it is valid.
-func (cs *ContractSet) loadSafeContract(headerFileName, rootsFileName, refCountFileName string, walTxns []*writeaheadlog.Transaction) (err error) {
+// if it is valid. The sector roots of the contract are only read from disk
+// when they are first needed. contractTxns maps contract ids to the wal
+// transactions which apply to them.
+func (cs *ContractSet) loadSafeContract(headerFileName, rootsFileName, refCountFileName string, contractTxns map[types.FileContractID][]*writeaheadlog.Transaction) (err error) {
 	headerFile, err := os.OpenFile(headerFileName, os.O_RDWR, modules.DefaultFilePerm)
 	if err != nil {
 		return err
@@ -947,37 +1007,15 @@ func (cs *ContractSet) loadSafeContract(headerFileName, rootsFileName, refCountF
 		return errors.AddContext(err, "unable to load contract header")
 	}
 
-	// read merkleRoots
-	merkleRoots, applyTxns, err := loadExistingMerkleRoots(rootsFile)
+	// open merkleRoots without reading them
+	merkleRoots, applyTxns, err := openExistingMerkleRoots(newFileSection(rootsFile, 0, remainingFile))
 	if err != nil {
 		return errors.AddContext(err, "unable to load the merkle roots of the contract")
 	}
-	// add relevant unapplied transactions
+	// add unapplied transactions
 	var unappliedTxns []*unappliedWalTxn
-	for _, t := range walTxns {
-		// NOTE: we assume here that if any of the updates apply to the
-		// contract, the whole transaction applies to the contract.
-		if len(t.Updates) == 0 {
-			continue
-		}
-		var id types.FileContractID
-		switch update := t.Updates[0]; update.Name {
-		case updateNameSetHeader:
-			var u updateSetHeader
-			if err := unmarshalHeader(update.Instructions, &u); err != nil {
-				return errors.AddContext(err, "unable to unmarshal the contract header during wal txn recovery")
-			}
-			id = u.ID
-		case updateNameSetRoot:
-			var u updateSetRoot
-			if err := encoding.Unmarshal(update.Instructions, &u); err != nil {
-				return errors.AddContext(err, "unable to unmarshal the update root set during wal txn recovery")
-			}
-			id = u.ID
-		}
-		if id == header.ID() {
-			unappliedTxns = append(unappliedTxns, newUnappliedWalTxn(t))
-		}
+	for _, t := range contractTxns[header.ID()] {
+		unappliedTxns = append(unappliedTxns, newUnappliedWalTxn(t))
 	}
 	var rc *refCounter
 	if build.Release == "testing" {
@@ -1000,7 +1038,10 @@ func (cs *ContractSet) loadSafeContract(headerFileName, rootsFileName, refCountF
 		staticRC:         rc,
 	}
 
-	// apply the wal txns if necessary.
+	// drop the stale wal txns and apply the remaining ones if necessary.
+	if err := sc.compactUnappliedTxns(); err != nil {
+		return errors.AddContext(err, "unable to compact the wal transactions during contractset recovery")
+	}
 	if applyTxns {
 		if err := sc.managedCommitTxns(); err != nil {
 			return errors.AddContext(err, "unable to commit the wal transactions during contractset recovery")
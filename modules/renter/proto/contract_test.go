@@ -936,6 +936,122 @@ func TestContractRecordCommitAppendIntent(t *testing.T) {
 	}
 }
 
+// TestContractCompactStaleTxns tests that unapplied wal transactions which
+// contain an older revision than the contract's header are dropped when the
+// contract set is loaded and that the roots are only loaded when needed.
+func TestContractCompactStaleTxns(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// create contract set
+	dir := build.TempDir(filepath.Join("proto", t.Name()))
+	rl := ratelimit.NewRateLimit(0, 0, 0)
+	cs, err := NewContractSet(dir, rl, modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// add a contract
+	initialHeader := contractHeader{
+		Transaction: types.Transaction{
+			FileContractRevisions: []types.FileContractRevision{{
+				NewRevisionNumber: 1,
+				NewValidProofOutputs: []types.SiacoinOutput{
+					{Value: types.SiacoinPrecision},
+					{Value: types.SiacoinPrecision},
+				},
+				NewMissedProofOutputs: []types.SiacoinOutput{
+					{Value: types.SiacoinPrecision},
+					{Value: types.SiacoinPrecision},
+					{Value: types.ZeroCurrency},
+				},
+				UnlockConditions: types.UnlockConditions{
+					PublicKeys: []types.SiaPublicKey{{}, {}},
+				},
+			}},
+		},
+	}
+	initialRoots := []crypto.Hash{{1}, {2}, {3}}
+	contract, err := cs.managedInsertContract(initialHeader, initialRoots)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc := cs.managedMustAcquire(t, contract.ID)
+	expectedRoot := sc.merkleRoots.root()
+
+	// record an append intent without committing it.
+	curr := sc.LastRevision()
+	newRoot := crypto.Hash{4}
+	rev, err := newUploadRevision(curr, newRoot, types.SiacoinPrecision, types.ZeroCurrency)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = sc.managedRecordAppendIntent(rev, newRoot, types.ZeroCurrency, types.ZeroCurrency)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// reload the contract set. The txn is not stale yet and should be kept.
+	cs, err = NewContractSet(dir, rl, modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc = cs.managedMustAcquire(t, contract.ID)
+	if len(sc.unappliedTxns) != 1 {
+		t.Fatalf("expected %v unapplied txns but got %v", 1, len(sc.unappliedTxns))
+	}
+
+	// the roots shouldn't be loaded but the number of roots should be known.
+	if sc.merkleRoots.loaded {
+		t.Fatal("roots shouldn't be loaded")
+	}
+	if sc.merkleRoots.len() != len(initialRoots) {
+		t.Fatalf("expected %v roots but got %v", len(initialRoots), sc.merkleRoots.len())
+	}
+	if root := sc.merkleRoots.root(); root != expectedRoot {
+		t.Fatal("wrong root after loading the roots", root, expectedRoot)
+	}
+	if !sc.merkleRoots.loaded {
+		t.Fatal("roots should be loaded")
+	}
+
+	// write a newer revision to the header without going through the wal.
+	newHeader := sc.header
+	newHeader.Transaction.FileContractRevisions = []types.FileContractRevision{rev}
+	newHeader.Transaction.FileContractRevisions[0].NewRevisionNumber++
+	if err := sc.applySetHeader(newHeader); err != nil {
+		t.Fatal(err)
+	}
+
+	// reload the contract set. The txn is stale now and should be dropped.
+	cs, err = NewContractSet(dir, rl, modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc = cs.managedMustAcquire(t, contract.ID)
+	if len(sc.unappliedTxns) != 0 {
+		t.Fatalf("expected %v unapplied txns but got %v", 0, len(sc.unappliedTxns))
+	}
+	if sc.LastRevision().NewRevisionNumber != rev.NewRevisionNumber+1 {
+		t.Fatal("Unexpected revision number after reloading the contract set")
+	}
+	if sc.merkleRoots.len() != len(initialRoots) {
+		t.Fatalf("expected %v roots but got %v", len(initialRoots), sc.merkleRoots.len())
+	}
+
+	// the txn should be gone from the wal as well.
+	cs, err = NewContractSet(dir, rl, modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc = cs.managedMustAcquire(t, contract.ID)
+	if len(sc.unappliedTxns) != 0 {
+		t.Fatalf("expected %v unapplied txns but got %v", 0, len(sc.unappliedTxns))
+	}
+}
+
 // TestContractRecordCommitRenewAndClearIntent tests recording and committing
 // downloads and makes sure they use the wal correctly.
 func TestContractRecordCommitRenewAndClearIntent(t *testing.T) {
@@ -29,6 +29,11 @@ type ContractSet struct {
 	mu         sync.Mutex
 	staticRL   *ratelimit.RateLimit
 	staticWal  *writeaheadlog.WAL
+
+	// staticMerkleRootCache caches the roots of the sectors which are
+	// uploaded and downloaded. Uploading a sector requires its root multiple
+	// times and the same sectors are often uploaded to multiple hosts.
+	staticMerkleRootCache *crypto.MerkleRootCache
 }
 
 // Acquire looks up the contract for the specified host key and locks it before
@@ -171,6 +176,34 @@ func (cs *ContractSet) ViewAll() []modules.RenterContract {
 	return contracts
 }
 
+// ForEach calls fn with the metadata of every contract in the set. Unlike
+// ViewAll, the metadata is copied one contract at a time instead of copying the
+// whole set up front. The set is not locked while fn is called, which means
+// that fn may acquire contracts but also that contracts which are deleted
+// concurrently might still be passed to fn. Iteration stops at the first error
+// returned by fn.
+func (cs *ContractSet) ForEach(fn func(modules.RenterContract) error) error {
+	cs.mu.Lock()
+	contracts := make([]*SafeContract, 0, len(cs.contracts))
+	for _, safeContract := range cs.contracts {
+		contracts = append(contracts, safeContract)
+	}
+	cs.mu.Unlock()
+	for _, safeContract := range contracts {
+		if err := fn(safeContract.Metadata()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ViewSnapshot returns a snapshot of the metadata of all contracts in the set.
+// The snapshot can be shared between callers which only need to read the
+// contracts to avoid copying the set repeatedly.
+func (cs *ContractSet) ViewSnapshot() ContractSnapshot {
+	return ContractSnapshot{contracts: cs.ViewAll()}
+}
+
 // Close closes all contracts in a contract set, this means rendering it unusable for I/O
 func (cs *ContractSet) Close() error {
 	cs.mu.Lock()
@@ -219,10 +252,11 @@ func NewContractSet(dir string, rl *ratelimit.RateLimit, deps modules.Dependenci
 		contracts: make(map[types.FileContractID]*SafeContract),
 		pubKeys:   make(map[string]types.FileContractID),
 
-		staticDeps: deps,
-		staticDir:  dir,
-		staticRL:   rl,
-		staticWal:  wal,
+		staticDeps:            deps,
+		staticDir:             dir,
+		staticMerkleRootCache: crypto.NewMerkleRootCache(merkleRootCacheSize),
+		staticRL:              rl,
+		staticWal:             wal,
 	}
 	// Set the initial rate limit to 'unlimited' bandwidth with 4kib packets.
 	cs.staticRL = ratelimit.NewRateLimit(0, 0, 0)
@@ -254,6 +288,17 @@ func NewContractSet(dir string, rl *ratelimit.RateLimit, deps modules.Dependenci
 		return nil, err
 	}
 
+	// Sort the remaining transactions by contract to only decode them once
+	// instead of once for every contract.
+	contractTxns := make(map[types.FileContractID][]*writeaheadlog.Transaction)
+	for _, txn := range walTxns {
+		id, err := walTxnContractID(txn)
+		if err != nil {
+			return nil, err
+		}
+		contractTxns[id] = append(contractTxns[id], txn)
+	}
+
 	// Load the contract files.
 	fis, err := ioutil.ReadDir(dir)
 	if err != nil {
@@ -269,7 +314,7 @@ func NewContractSet(dir string, rl *ratelimit.RateLimit, deps modules.Dependenci
 		rootsPath := filepath.Join(dir, nameNoExt+contractRootsExtension)
 		refCounterPath := filepath.Join(dir, nameNoExt+refCounterExtension)
 
-		if err := cs.loadSafeContract(headerPath, rootsPath, refCounterPath, walTxns); err != nil {
+		if err := cs.loadSafeContract(headerPath, rootsPath, refCounterPath, contractTxns); err != nil {
 			extErr := fmt.Errorf("failed to load safecontract for header %v", headerPath)
 			return nil, errors.Compose(extErr, err)
 		}
@@ -413,3 +413,85 @@ func TestInsertContractTotalCost(t *testing.T) {
 		t.Fatal("wrong TotalCost", contract.TotalCost, expectedTotalCost)
 	}
 }
+
+// TestContractSetForEachAndSnapshot tests iterating over the contracts of a
+// contract set and taking snapshots of it.
+func TestContractSetForEachAndSnapshot(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	testDir := build.TempDir(t.Name())
+	cs, err := NewContractSet(testDir, ratelimit.NewRateLimit(0, 0, 0), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make(map[types.FileContractID]struct{})
+	for i := 0; i < 5; i++ {
+		header := contractHeader{Transaction: types.Transaction{
+			FileContractRevisions: []types.FileContractRevision{{
+				ParentID:             types.FileContractID{byte(i)},
+				NewValidProofOutputs: []types.SiacoinOutput{{}, {}},
+				UnlockConditions: types.UnlockConditions{
+					PublicKeys: []types.SiaPublicKey{{}, {}},
+				},
+			}},
+		}}
+		if _, err := cs.managedInsertContract(header, []crypto.Hash{}); err != nil {
+			t.Fatal(err)
+		}
+		ids[header.ID()] = struct{}{}
+	}
+
+	// ForEach should visit every contract and may acquire contracts.
+	visited := make(map[types.FileContractID]struct{})
+	err = cs.ForEach(func(contract modules.RenterContract) error {
+		sc := cs.managedMustAcquire(t, contract.ID)
+		cs.Return(sc)
+		visited[contract.ID] = struct{}{}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(visited, ids) {
+		t.Fatal("ForEach didn't visit every contract", visited)
+	}
+
+	// ForEach should stop at the first error.
+	errStop := errors.New("stop")
+	calls := 0
+	err = cs.ForEach(func(modules.RenterContract) error {
+		calls++
+		return errStop
+	})
+	if !errors.Contains(err, errStop) || calls != 1 {
+		t.Fatal("ForEach didn't stop", err, calls)
+	}
+
+	// A snapshot isn't affected by deleting contracts from the set.
+	snapshot := cs.ViewSnapshot()
+	deleted := types.FileContractID{0}
+	cs.Delete(cs.managedMustAcquire(t, deleted))
+	if snapshot.Len() != len(ids) || cs.Len() != len(ids)-1 {
+		t.Fatal("wrong number of contracts", snapshot.Len(), cs.Len())
+	}
+
+	// Filter the deleted contract out of the snapshot.
+	filtered := snapshot.Filter(func(contract modules.RenterContract) bool {
+		return contract.ID != deleted
+	})
+	if filtered.Len() != len(ids)-1 || snapshot.Len() != len(ids) {
+		t.Fatal("wrong number of contracts after filtering", filtered.Len(), snapshot.Len())
+	}
+	err = filtered.ForEach(func(contract modules.RenterContract) error {
+		if contract.ID == deleted {
+			return errors.New("deleted contract wasn't filtered")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,47 @@
+package proto
+
+import (
+	"go.sia.tech/siad/modules"
+)
+
+// ContractSnapshot is a read-only view of the contracts of a ContractSet at the
+// time the snapshot was taken. Changes to the set after that are not reflected
+// in the snapshot.
+type ContractSnapshot struct {
+	contracts []modules.RenterContract
+}
+
+// Contracts returns the contracts of the snapshot. The returned slice is shared
+// with the snapshot and must not be modified.
+func (s ContractSnapshot) Contracts() []modules.RenterContract {
+	return s.contracts
+}
+
+// Filter returns a new snapshot which only contains the contracts for which
+// keep returns true. The metadata of the contracts is shared with the
+// original snapshot.
+func (s ContractSnapshot) Filter(keep func(modules.RenterContract) bool) ContractSnapshot {
+	contracts := make([]modules.RenterContract, 0, len(s.contracts))
+	for _, contract := range s.contracts {
+		if keep(contract) {
+			contracts = append(contracts, contract)
+		}
+	}
+	return ContractSnapshot{contracts: contracts}
+}
+
+// ForEach calls fn for every contract of the snapshot. Iteration stops at the
+// first error returned by fn.
+func (s ContractSnapshot) ForEach(fn func(modules.RenterContract) error) error {
+	for _, contract := range s.contracts {
+		if err := fn(contract); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Len returns the number of contracts in the snapshot.
+func (s ContractSnapshot) Len() int {
+	return len(s.contracts)
+}
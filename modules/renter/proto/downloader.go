@@ -137,7 +137,7 @@ func (hd *Downloader) Download(root crypto.Hash, offset, length uint32) (_ modul
 	sector := sectors[0]
 	if uint64(len(sector)) != modules.SectorSize {
 		return modules.RenterContract{}, nil, errors.New("host did not send enough sector data")
-	} else if crypto.MerkleRoot(sector) != root {
+	} else if hd.contractSet.staticMerkleRootCache.MerkleRoot(sector) != root {
 		return modules.RenterContract{}, nil, errors.New("host sent bad sector data")
 	}
 
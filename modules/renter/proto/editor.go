@@ -97,8 +97,11 @@ func (he *Editor) Upload(data []byte) (_ modules.RenterContract, _ crypto.Hash,
 	}
 
 	// calculate the new Merkle root
-	sectorRoot := crypto.MerkleRoot(data)
-	merkleRoot := sc.merkleRoots.checkNewRoot(sectorRoot)
+	sectorRoot := he.contractSet.staticMerkleRootCache.MerkleRoot(data)
+	merkleRoot, err := sc.merkleRoots.checkNewRoot(sectorRoot)
+	if err != nil {
+		return modules.RenterContract{}, crypto.Hash{}, errors.AddContext(err, "failed to compute the new merkle root")
+	}
 
 	// create the action and revision
 	actions := []modules.RevisionAction{{
@@ -0,0 +1,353 @@
+package proto
+
+import (
+	"bytes"
+	"io"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// ephemeralaccount.go contains a lightweight implementation of the renter side
+// of ephemeral accounts. An account is funded from a contract once and then
+// pays for reads and writes without revising the contract for every payment,
+// which makes fetching small pieces of content a lot cheaper in round trips.
+// All of the methods operate on an RHP3 stream to the host and expect a price
+// table the host still considers valid.
+
+var (
+	// errInvalidFundAccountReceipt is returned if the receipt returned by the
+	// host after funding an account doesn't match the request.
+	errInvalidFundAccountReceipt = errors.New("host returned an invalid receipt for funding the account")
+)
+
+type (
+	// EphemeralAccount is an ephemeral account on a host.
+	EphemeralAccount struct {
+		ID        modules.AccountID
+		SecretKey crypto.SecretKey
+	}
+
+	// programResponse is a response to an instruction of a program executed
+	// on the host together with its output.
+	programResponse struct {
+		modules.RPCExecuteProgramResponse
+		Output []byte
+	}
+)
+
+// NewEphemeralAccount creates a new ephemeral account with a random id.
+func NewEphemeralAccount() EphemeralAccount {
+	id, sk := modules.NewAccountID()
+	return EphemeralAccount{
+		ID:        id,
+		SecretKey: sk,
+	}
+}
+
+// ProvidePayment writes the objects required to pay amount from the account to
+// w. Since it doesn't read from the stream, w can be a buffer.
+func (ea EphemeralAccount) ProvidePayment(w io.Writer, amount types.Currency, blockHeight types.BlockHeight) error {
+	return modules.RPCProvidePayment(w, ea.ID, ea.SecretKey, blockHeight, amount)
+}
+
+// FundEphemeralAccount deposits amount into the account using the contract
+// with the given id to pay for the deposit and the FundAccount RPC. The host's
+// signed receipt for the deposit is returned.
+func (cs *ContractSet) FundEphemeralAccount(stream io.ReadWriter, pt *modules.RPCPriceTable, id types.FileContractID, account modules.AccountID, amount types.Currency) (_ modules.FundAccountResponse, err error) {
+	sc, ok := cs.Acquire(id)
+	if !ok {
+		return modules.FundAccountResponse{}, errors.New("contract not present in contract set")
+	}
+	defer cs.Return(sc)
+	hpk := sc.header.HostPublicKey()
+
+	// create the payment revision
+	cost := amount.Add(pt.FundAccountCost)
+	rev, err := sc.LastRevision().EAFundRevision(cost)
+	if err != nil {
+		return modules.FundAccountResponse{}, errors.AddContext(err, "failed to create payment revision")
+	}
+	signedTxn := rev.ToTransaction()
+	sig := sc.Sign(signedTxn.SigHash(0, pt.HostBlockHeight))
+	signedTxn.TransactionSignatures[0].Signature = sig[:]
+
+	// record the payment intent
+	details := modules.SpendingDetails{
+		FundAccountSpending: amount,
+		MaintenanceSpending: modules.MaintenanceSpending{
+			FundAccountCost: pt.FundAccountCost,
+		},
+	}
+	walTxn, err := sc.RecordPaymentIntent(rev, cost, details)
+	if err != nil {
+		return modules.FundAccountResponse{}, errors.AddContext(err, "failed to record payment intent")
+	}
+
+	// send the request and the payment at once
+	buffer := bytes.NewBuffer(nil)
+	err = modules.RPCWriteAll(buffer, modules.RPCFundAccount, pt.UID, modules.FundAccountRequest{Account: account})
+	if err != nil {
+		return modules.FundAccountResponse{}, err
+	}
+	err = modules.RPCWrite(buffer, modules.PaymentRequest{Type: modules.PayByContract})
+	if err != nil {
+		return modules.FundAccountResponse{}, err
+	}
+	err = modules.RPCWrite(buffer, newPayByContractRequest(rev, sig))
+	if err != nil {
+		return modules.FundAccountResponse{}, err
+	}
+	_, err = buffer.WriteTo(stream)
+	if err != nil {
+		return modules.FundAccountResponse{}, errors.AddContext(err, "failed to send fund account request")
+	}
+
+	// verify the host's signature of the payment revision
+	var payResp modules.PayByContractResponse
+	err = modules.RPCRead(stream, &payResp)
+	if err != nil {
+		return modules.FundAccountResponse{}, errors.AddContext(err, "failed to read pay by contract response")
+	}
+	err = crypto.VerifyHash(crypto.HashAll(rev), hpk.ToPublicKey(), payResp.Signature)
+	if err != nil {
+		return modules.FundAccountResponse{}, errors.AddContext(err, "invalid host signature for payment revision")
+	}
+	err = sc.CommitPaymentIntent(walTxn, signedTxn, cost, details)
+	if err != nil {
+		return modules.FundAccountResponse{}, errors.AddContext(err, "failed to commit payment intent")
+	}
+
+	// verify the receipt
+	var resp modules.FundAccountResponse
+	err = modules.RPCRead(stream, &resp)
+	if err != nil {
+		return modules.FundAccountResponse{}, errors.AddContext(err, "failed to read fund account response")
+	}
+	if resp.Receipt.Account != account || !resp.Receipt.Amount.Equals(amount) || !resp.Receipt.Host.Equals(hpk) {
+		return modules.FundAccountResponse{}, errInvalidFundAccountReceipt
+	}
+	err = crypto.VerifyHash(crypto.HashObject(resp.Receipt), hpk.ToPublicKey(), resp.Signature)
+	if err != nil {
+		return modules.FundAccountResponse{}, errors.Compose(err, errInvalidFundAccountReceipt)
+	}
+	return resp, nil
+}
+
+// ReadSector downloads length bytes at offset of the sector with the given
+// root and pays for the download from the account.
+func (ea EphemeralAccount) ReadSector(stream io.ReadWriter, pt *modules.RPCPriceTable, root crypto.Hash, offset, length uint64) ([]byte, error) {
+	pb := modules.NewProgramBuilder(pt, 0) // 0 duration since ReadSector doesn't depend on it.
+	pb.AddReadSectorInstruction(length, offset, root, true)
+	program, programData := pb.Program()
+	cost, _, _ := pb.Cost(true)
+	cost = cost.Add(modules.MDMBandwidthCost(*pt, 1<<15, uint64(float64(length)*1.01)+1<<14))
+
+	responses, err := ea.managedExecuteProgram(stream, pt, types.FileContractID{}, program, programData, cost)
+	if err != nil {
+		return nil, err
+	}
+	resp := responses[0]
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+
+	// verify proof
+	proofStart := int(offset) / crypto.SegmentSize
+	proofEnd := int(offset+length) / crypto.SegmentSize
+	if !crypto.VerifyRangeProof(resp.Output, resp.Proof, proofStart, proofEnd, root) {
+		return nil, errors.New("invalid Merkle proof for sector data")
+	}
+	return resp.Output, nil
+}
+
+// AppendSector uploads a sector to the contract with the given id and pays for
+// the upload from the account. The contract is only revised to move the
+// host's collateral for the sector, not for the payment.
+func (cs *ContractSet) AppendSector(stream io.ReadWriter, pt *modules.RPCPriceTable, ea EphemeralAccount, id types.FileContractID, data []byte) (_ modules.RenterContract, _ crypto.Hash, err error) {
+	sc, ok := cs.Acquire(id)
+	if !ok {
+		return modules.RenterContract{}, crypto.Hash{}, errors.New("contract not present in contract set")
+	}
+	defer cs.Return(sc)
+	contract := sc.header // for convenience
+	current := contract.LastRevision()
+	if current.NewWindowEnd <= pt.HostBlockHeight {
+		return modules.RenterContract{}, crypto.Hash{}, errors.New("contract has expired")
+	}
+
+	// create the program
+	duration := current.NewWindowEnd - pt.HostBlockHeight
+	pb := modules.NewProgramBuilder(pt, duration)
+	err = pb.AddAppendInstruction(data, true, duration)
+	if err != nil {
+		return modules.RenterContract{}, crypto.Hash{}, err
+	}
+	program, programData := pb.Program()
+	cost, _, _ := pb.Cost(true)
+	cost = cost.Add(modules.MDMBandwidthCost(*pt, uint64(len(programData))+1<<15, 1<<15))
+
+	responses, err := ea.managedExecuteProgram(stream, pt, id, program, programData, cost)
+	if err != nil {
+		return modules.RenterContract{}, crypto.Hash{}, err
+	}
+	resp := responses[0]
+	if resp.Error != nil {
+		return modules.RenterContract{}, crypto.Hash{}, resp.Error
+	}
+
+	// verify the proof, first by verifying the old Merkle root and then by
+	// appending the new sector and verifying the new Merkle root.
+	root := cs.staticMerkleRootCache.MerkleRoot(data)
+	numSectors := current.NewFileSize / modules.SectorSize
+	actions := []modules.LoopWriteAction{{Type: modules.WriteActionAppend, Data: data}}
+	proofRanges := calculateProofRanges(actions, numSectors)
+	if !crypto.VerifyDiffProof(proofRanges, numSectors, resp.Proof, nil, current.NewFileMerkleRoot) {
+		return modules.RenterContract{}, crypto.Hash{}, errors.New("invalid Merkle proof for old root")
+	}
+	leafHashes := modifyLeaves(cs.staticMerkleRootCache, nil, actions, numSectors)
+	proofRanges = modifyProofRanges(proofRanges, actions, numSectors)
+	if !crypto.VerifyDiffProof(proofRanges, numSectors, resp.Proof, leafHashes, resp.NewMerkleRoot) {
+		return modules.RenterContract{}, crypto.Hash{}, errors.New("invalid Merkle proof for new root")
+	}
+	if resp.NewSize != current.NewFileSize+modules.SectorSize {
+		return modules.RenterContract{}, crypto.Hash{}, errors.New("host returned wrong contract size")
+	}
+
+	// create the new revision, which moves the collateral and the storage
+	// cost that is refunded on failure to the void.
+	transfer := resp.AdditionalCollateral.Add(resp.FailureRefund)
+	rev, err := current.ExecuteProgramRevision(current.NewRevisionNumber+1, transfer, resp.NewMerkleRoot, resp.NewSize)
+	if err != nil {
+		return modules.RenterContract{}, crypto.Hash{}, errors.AddContext(err, "failed to create revision")
+	}
+	txn := rev.ToTransaction()
+	sig := sc.Sign(txn.SigHash(0, pt.HostBlockHeight))
+	txn.TransactionSignatures[0].Signature = sig[:]
+
+	// record the intent before sending the signature to the host. The upload
+	// was paid for by the account so no spending is recorded.
+	walTxn, err := sc.managedRecordAppendIntent(rev, root, types.ZeroCurrency, types.ZeroCurrency)
+	if err != nil {
+		return modules.RenterContract{}, crypto.Hash{}, err
+	}
+	req := modules.RPCExecuteProgramRevisionSigningRequest{
+		Signature:            sig[:],
+		NewRevisionNumber:    rev.NewRevisionNumber,
+		NewValidProofValues:  make([]types.Currency, len(rev.NewValidProofOutputs)),
+		NewMissedProofValues: make([]types.Currency, len(rev.NewMissedProofOutputs)),
+	}
+	for i, o := range rev.NewValidProofOutputs {
+		req.NewValidProofValues[i] = o.Value
+	}
+	for i, o := range rev.NewMissedProofOutputs {
+		req.NewMissedProofValues[i] = o.Value
+	}
+	err = modules.RPCWrite(stream, req)
+	if err != nil {
+		return modules.RenterContract{}, crypto.Hash{}, errors.AddContext(err, "failed to send revision")
+	}
+
+	// read and verify the host's signature
+	var signingResp modules.RPCExecuteProgramRevisionSigningResponse
+	err = modules.RPCRead(stream, &signingResp)
+	if err != nil {
+		return modules.RenterContract{}, crypto.Hash{}, errors.AddContext(err, "failed to read host signature")
+	}
+	txn.TransactionSignatures = append(txn.TransactionSignatures, types.TransactionSignature{
+		ParentID:       crypto.Hash(rev.ParentID),
+		PublicKeyIndex: 1,
+		CoveredFields:  types.CoveredFields{FileContractRevisions: []uint64{0}},
+		Signature:      signingResp.Signature,
+	})
+	err = modules.VerifyFileContractRevisionTransactionSignatures(rev, txn.TransactionSignatures, pt.HostBlockHeight)
+	if err != nil {
+		return modules.RenterContract{}, crypto.Hash{}, errors.AddContext(err, "invalid host signature for revision")
+	}
+
+	// update contract
+	err = sc.managedCommitAppend(walTxn, txn, types.ZeroCurrency, types.ZeroCurrency)
+	if err != nil {
+		return modules.RenterContract{}, crypto.Hash{}, err
+	}
+	return sc.Metadata(), root, nil
+}
+
+// managedExecuteProgram executes a program on the host and pays cost for it
+// from the account. It returns the responses of the instructions up to the
+// first one that failed.
+func (ea EphemeralAccount) managedExecuteProgram(stream io.ReadWriter, pt *modules.RPCPriceTable, fcid types.FileContractID, p modules.Program, data []byte, cost types.Currency) ([]programResponse, error) {
+	// send the request, the payment and the program data at once
+	buffer := bytes.NewBuffer(nil)
+	err := modules.RPCWriteAll(buffer, modules.RPCExecuteProgram, pt.UID)
+	if err != nil {
+		return nil, err
+	}
+	err = ea.ProvidePayment(buffer, cost, pt.HostBlockHeight)
+	if err != nil {
+		return nil, err
+	}
+	err = modules.RPCWrite(buffer, modules.RPCExecuteProgramRequest{
+		FileContractID:    fcid,
+		Program:           p,
+		ProgramDataLength: uint64(len(data)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	buffer.Write(data)
+	_, err = buffer.WriteTo(stream)
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to send program")
+	}
+
+	// read the cancellation token
+	var ct modules.MDMCancellationToken
+	err = modules.RPCRead(stream, &ct)
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to read cancellation token")
+	}
+
+	// read the responses
+	responses := make([]programResponse, 0, len(p))
+	for range p {
+		var resp programResponse
+		err = modules.RPCRead(stream, &resp.RPCExecuteProgramResponse)
+		if err != nil {
+			return nil, errors.AddContext(err, "failed to read program response")
+		}
+		resp.Output = make([]byte, resp.OutputLength)
+		_, err = io.ReadFull(stream, resp.Output)
+		if err != nil {
+			return nil, errors.AddContext(err, "failed to read program output")
+		}
+		responses = append(responses, resp)
+		if resp.Error != nil {
+			break
+		}
+	}
+	return responses, nil
+}
+
+// newPayByContractRequest creates the request to pay with the given payment
+// revision and signature.
+func newPayByContractRequest(rev types.FileContractRevision, sig crypto.Signature) modules.PayByContractRequest {
+	req := modules.PayByContractRequest{
+		ContractID:           rev.ID(),
+		NewRevisionNumber:    rev.NewRevisionNumber,
+		NewValidProofValues:  make([]types.Currency, len(rev.NewValidProofOutputs)),
+		NewMissedProofValues: make([]types.Currency, len(rev.NewMissedProofOutputs)),
+		RefundAccount:        modules.ZeroAccountID,
+		Signature:            sig[:],
+	}
+	for i, o := range rev.NewValidProofOutputs {
+		req.NewValidProofValues[i] = o.Value
+	}
+	for i, o := range rev.NewMissedProofOutputs {
+		req.NewMissedProofValues[i] = o.Value
+	}
+	return req
+}
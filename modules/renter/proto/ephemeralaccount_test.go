@@ -0,0 +1,246 @@
+package proto
+
+import (
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/NebulousLabs/fastrand"
+	"gitlab.com/NebulousLabs/ratelimit"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// rpcReadAll reads multiple objects from the stream.
+func rpcReadAll(stream io.Reader, objs ...interface{}) error {
+	for _, obj := range objs {
+		if err := modules.RPCRead(stream, obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readTestProgram reads an ExecuteProgram request paid for by an ephemeral
+// account from the stream.
+func readTestProgram(stream io.Reader) (modules.RPCExecuteProgramRequest, []byte, error) {
+	var id types.Specifier
+	var uid modules.UniqueID
+	var pr modules.PaymentRequest
+	var pbear modules.PayByEphemeralAccountRequest
+	var epr modules.RPCExecuteProgramRequest
+	err := rpcReadAll(stream, &id, &uid, &pr, &pbear, &epr)
+	if err != nil {
+		return modules.RPCExecuteProgramRequest{}, nil, err
+	}
+	data := make([]byte, epr.ProgramDataLength)
+	_, err = io.ReadFull(stream, data)
+	return epr, data, err
+}
+
+// TestEphemeralAccount tests funding an ephemeral account and uploading and
+// downloading a sector paid for by the account against a mocked host.
+func TestEphemeralAccount(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// create contract set
+	dir := build.TempDir(filepath.Join("proto", t.Name()))
+	cs, err := NewContractSet(dir, ratelimit.NewRateLimit(0, 0, 0), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// add a contract
+	renterSK, renterPK := crypto.GenerateKeyPair()
+	hostSK, hostPK := crypto.GenerateKeyPair()
+	header := contractHeader{
+		Transaction: types.Transaction{
+			FileContractRevisions: []types.FileContractRevision{{
+				NewRevisionNumber: 1,
+				NewWindowStart:    90,
+				NewWindowEnd:      100,
+				NewValidProofOutputs: []types.SiacoinOutput{
+					{Value: types.SiacoinPrecision},
+					{Value: types.SiacoinPrecision},
+				},
+				NewMissedProofOutputs: []types.SiacoinOutput{
+					{Value: types.SiacoinPrecision},
+					{Value: types.SiacoinPrecision},
+					{Value: types.ZeroCurrency},
+				},
+				UnlockConditions: types.UnlockConditions{
+					PublicKeys: []types.SiaPublicKey{
+						types.Ed25519PublicKey(renterPK),
+						types.Ed25519PublicKey(hostPK),
+					},
+					SignaturesRequired: 2,
+				},
+			}},
+		},
+		SecretKey: renterSK,
+	}
+	contract, err := cs.managedInsertContract(header, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pt := &modules.RPCPriceTable{
+		HostBlockHeight: 10,
+		FundAccountCost: types.NewCurrency64(1),
+		InitBaseCost:    types.NewCurrency64(1),
+		ReadBaseCost:    types.NewCurrency64(1),
+		WriteBaseCost:   types.NewCurrency64(1),
+	}
+	fastrand.Read(pt.UID[:])
+	ea := NewEphemeralAccount()
+	amount := types.SiacoinPrecision.Div64(10)
+
+	// Fund the account.
+	renter, host := net.Pipe()
+	defer renter.Close()
+	defer host.Close()
+	hostErr := make(chan error, 1)
+	go func() {
+		hostErr <- func() error {
+			var id types.Specifier
+			var uid modules.UniqueID
+			var far modules.FundAccountRequest
+			var pr modules.PaymentRequest
+			var pbcr modules.PayByContractRequest
+			err := rpcReadAll(host, &id, &uid, &far, &pr, &pbcr)
+			if err != nil {
+				return err
+			}
+			rev, err := contract.Transaction.FileContractRevisions[0].EAFundRevision(amount.Add(pt.FundAccountCost))
+			if err != nil {
+				return err
+			}
+			err = modules.RPCWrite(host, modules.PayByContractResponse{Signature: crypto.SignHash(crypto.HashAll(rev), hostSK)})
+			if err != nil {
+				return err
+			}
+			receipt := modules.Receipt{Host: types.Ed25519PublicKey(hostPK), Account: far.Account, Amount: amount}
+			return modules.RPCWrite(host, modules.FundAccountResponse{Balance: amount, Receipt: receipt, Signature: crypto.SignHash(crypto.HashObject(receipt), hostSK)})
+		}()
+	}()
+	_, err = cs.FundEphemeralAccount(renter, pt, contract.ID, ea.ID, amount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-hostErr; err != nil {
+		t.Fatal(err)
+	}
+	c, _ := cs.View(contract.ID)
+	if !c.FundAccountSpending.Equals(amount) || c.Transaction.FileContractRevisions[0].NewRevisionNumber != 2 {
+		t.Fatal("contract wasn't revised", c.FundAccountSpending, c.Transaction.FileContractRevisions[0].NewRevisionNumber)
+	}
+
+	// Upload a sector.
+	sector := fastrand.Bytes(int(modules.SectorSize))
+	root := crypto.MerkleRoot(sector)
+	go func() {
+		hostErr <- func() error {
+			_, _, err := readTestProgram(host)
+			if err != nil {
+				return err
+			}
+			resp := modules.RPCExecuteProgramResponse{
+				AdditionalCollateral: types.NewCurrency64(2),
+				FailureRefund:        types.NewCurrency64(3),
+				NewMerkleRoot:        cachedMerkleRoot([]crypto.Hash{root}),
+				NewSize:              modules.SectorSize,
+				Proof:                crypto.MerkleDiffProof(nil, 0, nil, nil),
+			}
+			err = modules.RPCWriteAll(host, modules.MDMCancellationToken{}, resp)
+			if err != nil {
+				return err
+			}
+			var req modules.RPCExecuteProgramRevisionSigningRequest
+			err = modules.RPCRead(host, &req)
+			if err != nil {
+				return err
+			}
+			rev, err := c.Transaction.FileContractRevisions[0].ExecuteProgramRevision(req.NewRevisionNumber, types.NewCurrency64(5), resp.NewMerkleRoot, resp.NewSize)
+			if err != nil {
+				return err
+			}
+			txn := rev.ToTransaction()
+			txn.TransactionSignatures[0].PublicKeyIndex = 1
+			sig := crypto.SignHash(txn.SigHash(0, pt.HostBlockHeight), hostSK)
+			return modules.RPCWrite(host, modules.RPCExecuteProgramRevisionSigningResponse{Signature: sig[:]})
+		}()
+	}()
+	c, uploadedRoot, err := cs.AppendSector(renter, pt, ea, contract.ID, sector)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-hostErr; err != nil {
+		t.Fatal(err)
+	}
+	if uploadedRoot != root || c.Size() != modules.SectorSize || c.Transaction.FileContractRevisions[0].NewRevisionNumber != 3 || !c.StorageSpending.IsZero() {
+		t.Fatal("contract wasn't revised", c.Size(), c.Transaction.FileContractRevisions[0].NewRevisionNumber, c.StorageSpending)
+	}
+	if !c.Transaction.FileContractRevisions[0].MissedHostOutput().Value.Equals(types.SiacoinPrecision.Add(amount).Add(pt.FundAccountCost).Sub64(5)) {
+		t.Fatal("wrong missed host payout", c.Transaction.FileContractRevisions[0].MissedHostOutput().Value)
+	}
+
+	// Download part of the sector.
+	offset, length := uint64(crypto.SegmentSize), uint64(2*crypto.SegmentSize)
+	go func() {
+		hostErr <- func() error {
+			_, _, err := readTestProgram(host)
+			if err != nil {
+				return err
+			}
+			output := sector[offset : offset+length]
+			resp := modules.RPCExecuteProgramResponse{
+				OutputLength: length,
+				Proof:        crypto.MerkleRangeProof(sector, int(offset/crypto.SegmentSize), int((offset+length)/crypto.SegmentSize)),
+			}
+			err = modules.RPCWriteAll(host, modules.MDMCancellationToken{}, resp)
+			if err != nil {
+				return err
+			}
+			_, err = host.Write(output)
+			return err
+		}()
+	}()
+	data, err := ea.ReadSector(renter, pt, root, offset, length)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-hostErr; err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != string(sector[offset:offset+length]) {
+		t.Fatal("wrong data")
+	}
+
+	// Data that doesn't match the proof is rejected.
+	go func() {
+		hostErr <- func() error {
+			_, _, err := readTestProgram(host)
+			if err != nil {
+				return err
+			}
+			resp := modules.RPCExecuteProgramResponse{OutputLength: length}
+			err = modules.RPCWriteAll(host, modules.MDMCancellationToken{}, resp)
+			if err != nil {
+				return err
+			}
+			_, err = host.Write(make([]byte, length))
+			return err
+		}()
+	}()
+	if _, err := ea.ReadSector(renter, pt, root, offset, length); err == nil {
+		t.Fatal("expected proof verification to fail")
+	}
+	if err := <-hostErr; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -1,6 +1,8 @@
 package proto
 
 import (
+	"context"
+
 	"gitlab.com/NebulousLabs/errors"
 
 	"go.sia.tech/siad/build"
@@ -12,8 +14,8 @@ import (
 
 // FormContract forms a contract with a host and submits the contract
 // transaction to tpool. The contract is added to the ContractSet and its
-// metadata is returned.
-func (cs *ContractSet) FormContract(params modules.ContractParams, txnBuilder transactionBuilder, tpool transactionPool, hdb hostDB, cancel <-chan struct{}) (rc modules.RenterContract, formationTxnSet []types.Transaction, sweepTxn types.Transaction, sweepParents []types.Transaction, err error) {
+// metadata is returned. Cancelling ctx aborts the negotiation with the host.
+func (cs *ContractSet) FormContract(ctx context.Context, params modules.ContractParams, txnBuilder transactionBuilder, tpool transactionPool, hdb hostDB) (rc modules.RenterContract, formationTxnSet []types.Transaction, sweepTxn types.Transaction, sweepParents []types.Transaction, err error) {
 	// Check that the host version is high enough. This should never happen
 	// because hosts with old versions should be filtered / blocked by the
 	// contractor anyway.
@@ -125,7 +127,7 @@ func (cs *ContractSet) FormContract(params modules.ContractParams, txnBuilder tr
 	}()
 
 	// Initiate protocol.
-	s, err := cs.NewRawSession(host, startHeight, hdb, cancel)
+	s, err := cs.NewRawSession(ctx, host, startHeight, hdb)
 	if err != nil {
 		return modules.RenterContract{}, nil, types.Transaction{}, nil, err
 	}
@@ -43,6 +43,10 @@ type (
 		rootsFile *fileSection
 		// numMerkleRoots is the number of merkle roots in file.
 		numMerkleRoots int
+		// loaded indicates whether cachedSubTrees and uncachedRoots were
+		// built from the roots on disk. Contracts are opened without loading
+		// their roots to speed up startup, the roots are loaded on first use.
+		loaded bool
 	}
 
 	// cachedSubTree is a cached subTree of a merkle tree. A height of 0 means
@@ -84,6 +88,20 @@ func loadExistingMerkleRoots(file *os.File) (*merkleRoots, bool, error) {
 // and return a boolean to indicate that the last write was incomplete and that
 // the unapplied wal transactions should be applied after loading the roots.
 func loadExistingMerkleRootsFromSection(file *fileSection) (*merkleRoots, bool, error) {
+	mr, applyTxns, err := openExistingMerkleRoots(file)
+	if err != nil {
+		return nil, applyTxns, err
+	}
+	if err := mr.load(); err != nil {
+		return nil, applyTxns, err
+	}
+	return mr, applyTxns, nil
+}
+
+// openExistingMerkleRoots creates a merkleRoots object from existing merkle
+// roots like loadExistingMerkleRootsFromSection but without reading the roots.
+// They are read when they are first needed.
+func openExistingMerkleRoots(file *fileSection) (*merkleRoots, bool, error) {
 	mr := &merkleRoots{
 		rootsFile: file,
 	}
@@ -104,11 +122,20 @@ func loadExistingMerkleRootsFromSection(file *fileSection) (*merkleRoots, bool,
 	if err != nil {
 		return nil, applyTxns, err
 	}
+	return mr, applyTxns, nil
+}
+
+// load reads the roots from disk and builds the cached subTrees from them if
+// that didn't happen yet.
+func (mr *merkleRoots) load() error {
+	if mr.loaded {
+		return nil
+	}
 	// Read the roots from the file without reading all of them at once.
 	readOff := int64(0)
 	rootsData := make([]byte, rootsDiskLoadBulkSize)
 	for {
-		n, err := file.ReadAt(rootsData, readOff)
+		n, err := mr.rootsFile.ReadAt(rootsData, readOff)
 		if errors.Contains(err, io.ErrUnexpectedEOF) && n == 0 {
 			break
 		}
@@ -116,16 +143,17 @@ func loadExistingMerkleRootsFromSection(file *fileSection) (*merkleRoots, bool,
 			break
 		}
 		if err != nil && !errors.Contains(err, io.EOF) && err != io.ErrUnexpectedEOF {
-			return nil, applyTxns, err
+			return err
 		}
 		roots, err := parseRootsFromData(rootsData[:n])
 		if err != nil {
-			return nil, applyTxns, err
+			return err
 		}
 		mr.appendRootMemory(roots...)
 		readOff += int64(n)
 	}
-	return mr, applyTxns, nil
+	mr.loaded = true
+	return nil
 }
 
 // newCachedSubTree creates a cachedSubTree from exactly
@@ -147,6 +175,7 @@ func newCachedSubTree(roots []crypto.Hash) *cachedSubTree {
 func newMerkleRoots(file *os.File) *merkleRoots {
 	return &merkleRoots{
 		rootsFile: newFileSection(file, 0, remainingFile),
+		loaded:    true,
 	}
 }
 
@@ -173,6 +202,9 @@ func (mr *merkleRoots) appendRootMemory(roots ...crypto.Hash) {
 // last root and truncates the file to truncateSize after that. This ensures
 // that the operation is indempotent.
 func (mr *merkleRoots) delete(i int, lastRoot crypto.Hash, truncateSize int64) error {
+	if err := mr.load(); err != nil {
+		return errors.AddContext(err, "failed to load roots")
+	}
 	// Swap the element at index i with the lastRoot. This might actually
 	// increase mr.numMerkleRoots since there is a chance that i points to an
 	// index after the end of the file. That's why the insert is executed first
@@ -212,6 +244,9 @@ func (mr *merkleRoots) delete(i int, lastRoot crypto.Hash, truncateSize int64) e
 
 // insert inserts a root by replacing a root at an existing index.
 func (mr *merkleRoots) insert(index int, root crypto.Hash) error {
+	if err := mr.load(); err != nil {
+		return errors.AddContext(err, "failed to load roots")
+	}
 	// If the index does point to an offset beyond the end of the file we fill
 	// in the blanks with empty merkle roots. This usually just means that the
 	// machine crashed during the recovery process and that the next few
@@ -293,6 +328,9 @@ func (mr *merkleRoots) moveLastCachedSubTreeToUncached() error {
 // push appends a merkle root to the end of the contract. If the number of
 // uncached merkle roots grows too big we cache them in a new subTree.
 func (mr *merkleRoots) push(root crypto.Hash) error {
+	if err := mr.load(); err != nil {
+		return errors.AddContext(err, "failed to load roots")
+	}
 	// Sanity check the number of uncached roots before adding a new one.
 	if len(mr.uncachedRoots) == merkleRootsPerCache {
 		build.Critical("the number of uncachedRoots is too big. They should've been cached by now")
@@ -312,6 +350,9 @@ func (mr *merkleRoots) push(root crypto.Hash) error {
 
 // root returns the root of the merkle roots.
 func (mr *merkleRoots) root() crypto.Hash {
+	if err := mr.load(); err != nil {
+		build.Critical(err)
+	}
 	tree := crypto.NewTree()
 	for _, st := range mr.cachedSubTrees {
 		if err := tree.PushSubTree(st.height, st.sum); err != nil {
@@ -327,7 +368,10 @@ func (mr *merkleRoots) root() crypto.Hash {
 
 // checkNewRoot returns the root of the merkleTree after appending the checkNewRoot
 // without actually appending it.
-func (mr *merkleRoots) checkNewRoot(newRoot crypto.Hash) crypto.Hash {
+func (mr *merkleRoots) checkNewRoot(newRoot crypto.Hash) (crypto.Hash, error) {
+	if err := mr.load(); err != nil {
+		return crypto.Hash{}, errors.AddContext(err, "failed to load roots")
+	}
 	tree := crypto.NewCachedTree(sectorHeight)
 	for _, st := range mr.cachedSubTrees {
 		if err := tree.PushSubTree(st.height, st.sum); err != nil {
@@ -340,7 +384,7 @@ func (mr *merkleRoots) checkNewRoot(newRoot crypto.Hash) crypto.Hash {
 	}
 	// Push the new root.
 	tree.Push(newRoot)
-	return tree.Root()
+	return tree.Root(), nil
 }
 
 // merkleRoots reads all the merkle roots from disk and returns them.
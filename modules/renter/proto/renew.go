@@ -1,6 +1,7 @@
 package proto
 
 import (
+	"context"
 	"math"
 	"net"
 
@@ -15,21 +16,22 @@ import (
 
 // Renew negotiates a new contract for data already stored with a host, and
 // submits the new contract transaction to tpool. The new contract is added to
-// the ContractSet and its metadata is returned.
-func (cs *ContractSet) Renew(oldContract *SafeContract, params modules.ContractParams, txnBuilder transactionBuilder, tpool transactionPool, hdb hostDB, cancel <-chan struct{}) (rc modules.RenterContract, formationTxnSet []types.Transaction, err error) {
+// the ContractSet and its metadata is returned. Cancelling ctx aborts the
+// negotiation with the host.
+func (cs *ContractSet) Renew(ctx context.Context, oldContract *SafeContract, params modules.ContractParams, txnBuilder transactionBuilder, tpool transactionPool, hdb hostDB) (rc modules.RenterContract, formationTxnSet []types.Transaction, err error) {
 	// Check that the host version is high enough as belt-and-suspenders. This
 	// should never happen, because hosts with old versions should be blacklisted
 	// by the contractor.
 	if build.VersionCmp(params.Host.Version, "1.4.4") < 0 {
 		return modules.RenterContract{}, nil, ErrBadHostVersion
 	}
-	return cs.managedNewRenewAndClear(oldContract, params, txnBuilder, tpool, hdb, cancel)
+	return cs.managedNewRenewAndClear(ctx, oldContract, params, txnBuilder, tpool, hdb)
 }
 
 // managedNewRenewAndClear uses the new RPC to renew a contract, creating a new
 // contract that is identical to the old one, and then clears the old one to be
 // empty.
-func (cs *ContractSet) managedNewRenewAndClear(oldContract *SafeContract, params modules.ContractParams, txnBuilder transactionBuilder, tpool transactionPool, hdb hostDB, cancel <-chan struct{}) (rc modules.RenterContract, formationTxnSet []types.Transaction, err error) {
+func (cs *ContractSet) managedNewRenewAndClear(ctx context.Context, oldContract *SafeContract, params modules.ContractParams, txnBuilder transactionBuilder, tpool transactionPool, hdb hostDB) (rc modules.RenterContract, formationTxnSet []types.Transaction, err error) {
 	// for convenience
 	contract := oldContract.header
 
@@ -79,7 +81,7 @@ func (cs *ContractSet) managedNewRenewAndClear(oldContract *SafeContract, params
 	}()
 
 	// Initiate protocol.
-	s, err := cs.NewRawSession(host, startHeight, hdb, cancel)
+	s, err := cs.NewRawSession(ctx, host, startHeight, hdb)
 	if err != nil {
 		return modules.RenterContract{}, nil, err
 	}
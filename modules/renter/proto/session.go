@@ -2,6 +2,7 @@ package proto
 
 import (
 	"bytes"
+	"context"
 	"crypto/cipher"
 	"encoding/json"
 	"fmt"
@@ -45,6 +46,11 @@ type Session struct {
 	height      types.BlockHeight
 	host        modules.HostDBEntry
 	once        sync.Once
+	writeWindow *writeWindow
+
+	// pricesExpiry is the time at which the host's settings have to be
+	// fetched again before calculating prices.
+	pricesExpiry time.Time
 }
 
 // writeRequest sends an encrypted RPC request to the host.
@@ -138,14 +144,62 @@ func (s *Session) Settings() (modules.HostExternalSettings, error) {
 		return modules.HostExternalSettings{}, err
 	}
 	s.host.HostExternalSettings = hes
+	s.pricesExpiry = time.Now().Add(sessionPricesValidity)
 	return s.host.HostExternalSettings, nil
 }
 
+// Prices returns the host's settings which the session uses to calculate the
+// prices of its RPCs. The settings are fetched from the host when the session
+// uses them for the first time and again once they expire, so the session
+// never pays based on the possibly outdated settings of the hostdb.
+func (s *Session) Prices() (modules.HostExternalSettings, error) {
+	if time.Now().Before(s.pricesExpiry) {
+		return s.host.HostExternalSettings, nil
+	}
+	hes, err := s.Settings()
+	if err != nil {
+		return modules.HostExternalSettings{}, errors.AddContext(err, "unable to refresh the host's prices")
+	}
+	return hes, nil
+}
+
 // Append calls the Write RPC with a single Append action, returning the
-// updated contract and the Merkle root of the appended sector.
-func (s *Session) Append(data []byte) (_ modules.RenterContract, _ crypto.Hash, err error) {
+// updated contract and the Merkle root of the appended sector. Cancelling ctx
+// aborts the upload and closes the session.
+func (s *Session) Append(ctx context.Context, data []byte) (_ modules.RenterContract, _ crypto.Hash, err error) {
+	if err := ctx.Err(); err != nil {
+		return modules.RenterContract{}, crypto.Hash{}, err
+	}
+	stop := s.watchContext(ctx)
 	rc, err := s.Write([]modules.LoopWriteAction{{Type: modules.WriteActionAppend, Data: data}})
-	return rc, crypto.MerkleRoot(data), err
+	return rc, s.contractSet.staticMerkleRootCache.MerkleRoot(data), stop(err)
+}
+
+// watchContext closes the session's connection if ctx is cancelled before the
+// returned function is called. An RPC can't be interrupted without losing
+// track of the state of the contract, so cancelling it ends the session. The
+// returned function adds the context's error to the error of the RPC if ctx
+// was cancelled.
+func (s *Session) watchContext(ctx context.Context) func(error) error {
+	if ctx.Done() == nil {
+		// ctx can't be cancelled.
+		return func(err error) error { return err }
+	}
+	doneChan := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.conn.Close()
+		case <-doneChan:
+		}
+	}()
+	return func(err error) error {
+		close(doneChan)
+		if err != nil && ctx.Err() != nil {
+			return errors.Compose(ctx.Err(), err)
+		}
+		return err
+	}
 }
 
 // Replace calls the Write RPC with a series of actions that replace the sector
@@ -171,7 +225,58 @@ func (s *Session) Replace(data []byte, sectorIndex uint64, trim bool) (_ modules
 	}
 
 	rc, err := s.write(sc, actions)
-	return rc, crypto.MerkleRoot(data), errors.AddContext(err, "write to host failed")
+	return rc, s.contractSet.staticMerkleRootCache.MerkleRoot(data), errors.AddContext(err, "write to host failed")
+}
+
+// AppendStream appends sectors to the contract, returning the updated contract
+// and the Merkle roots of the sectors. The sectors are uploaded with the
+// WriteStream RPC, which keeps multiple sectors in flight at once, in batches
+// of up to the host's MaxWriteStreamSectors sectors. Hosts which don't support
+// the WriteStream RPC receive the sectors one Append at a time. Cancelling ctx
+// aborts the upload and closes the session.
+func (s *Session) AppendStream(ctx context.Context, sectors [][]byte) (rc modules.RenterContract, roots []crypto.Hash, err error) {
+	if err := ctx.Err(); err != nil {
+		return modules.RenterContract{}, nil, err
+	}
+	stop := s.watchContext(ctx)
+	defer func() {
+		err = stop(err)
+	}()
+	if len(sectors) == 0 {
+		return modules.RenterContract{}, nil, errors.New("no sectors to append")
+	}
+	hes, err := s.Prices()
+	if err != nil {
+		return modules.RenterContract{}, nil, err
+	}
+	maxSectors := hes.MaxWriteStreamSectors
+	if maxSectors == 0 {
+		for _, sector := range sectors {
+			rc, err = s.Write([]modules.LoopWriteAction{{Type: modules.WriteActionAppend, Data: sector}})
+			if err != nil {
+				return modules.RenterContract{}, nil, err
+			}
+			roots = append(roots, s.contractSet.staticMerkleRootCache.MerkleRoot(sector))
+		}
+		return rc, roots, nil
+	}
+	for len(sectors) > 0 {
+		n := uint64(len(sectors))
+		if n > maxSectors {
+			n = maxSectors
+		}
+		batch := make([]crypto.Hash, n)
+		for i := range batch {
+			batch[i] = s.contractSet.staticMerkleRootCache.MerkleRoot(sectors[i])
+		}
+		rc, err = s.writeStream(sectors[:n], batch)
+		if err != nil {
+			return modules.RenterContract{}, nil, errors.AddContext(err, "write stream to host failed")
+		}
+		roots = append(roots, batch...)
+		sectors = sectors[n:]
+	}
+	return rc, roots, nil
 }
 
 // Write implements the Write RPC, except for ActionUpdate. A Merkle proof is
@@ -186,6 +291,68 @@ func (s *Session) Write(actions []modules.LoopWriteAction) (_ modules.RenterCont
 }
 
 func (s *Session) write(sc *SafeContract, actions []modules.LoopWriteAction) (_ modules.RenterContract, err error) {
+	rev, storagePrice, bandwidthPrice, err := s.writeRevision(sc, actions)
+	if err != nil {
+		return modules.RenterContract{}, err
+	}
+
+	// create the request
+	req := modules.LoopWriteRequest{
+		Actions:           actions,
+		MerkleProof:       true,
+		NewRevisionNumber: rev.NewRevisionNumber,
+	}
+	req.NewValidProofValues = make([]types.Currency, len(rev.NewValidProofOutputs))
+	for i, o := range rev.NewValidProofOutputs {
+		req.NewValidProofValues[i] = o.Value
+	}
+	req.NewMissedProofValues = make([]types.Currency, len(rev.NewMissedProofOutputs))
+	for i, o := range rev.NewMissedProofOutputs {
+		req.NewMissedProofValues[i] = o.Value
+	}
+
+	// record the change we are about to make to the contract. If we lose power
+	// mid-revision, this allows us to restore either the pre-revision or
+	// post-revision contract.
+	//
+	// TODO: update this for non-local root storage
+	walTxn, err := sc.managedRecordAppendIntent(rev, crypto.Hash{}, storagePrice, bandwidthPrice)
+	if err != nil {
+		return modules.RenterContract{}, err
+	}
+
+	defer func() {
+		// Increase Successful/Failed interactions accordingly
+		if err != nil {
+			s.hdb.IncrementFailedInteractions(s.host.PublicKey)
+		} else {
+			s.hdb.IncrementSuccessfulInteractions(s.host.PublicKey)
+		}
+
+		// reset deadline
+		extendDeadline(s.conn, time.Hour)
+	}()
+
+	// Disrupt here before sending the signed revision to the host.
+	if s.deps.Disrupt("InterruptUploadBeforeSendingRevision") {
+		return modules.RenterContract{}, errors.New("InterruptUploadBeforeSendingRevision disrupt")
+	}
+
+	// send Write RPC request
+	extendDeadline(s.conn, modules.NegotiateFileContractRevisionTime)
+	if err := s.writeRequest(modules.RPCLoopWrite, req); err != nil {
+		return modules.RenterContract{}, err
+	}
+	return s.finishWrite(sc, walTxn, rev, actions, storagePrice, bandwidthPrice)
+}
+
+// writeRevision creates the revision of the contract which pays for the
+// actions of a Write RPC. The Merkle root of the revision is updated once the
+// host sent its Merkle proof.
+func (s *Session) writeRevision(sc *SafeContract, actions []modules.LoopWriteAction) (_ types.FileContractRevision, storagePrice, bandwidthPrice types.Currency, err error) {
+	if _, err := s.Prices(); err != nil {
+		return types.FileContractRevision{}, types.ZeroCurrency, types.ZeroCurrency, err
+	}
 	contract := sc.header // for convenience
 
 	// calculate price per sector
@@ -195,7 +362,7 @@ func (s *Session) write(sc *SafeContract, actions []modules.LoopWriteAction) (_
 	sectorCollateral := s.host.Collateral.Mul(blockBytes)
 
 	// calculate the new Merkle root set and total cost/collateral
-	var bandwidthPrice, storagePrice, collateral types.Currency
+	var collateral types.Currency
 	newFileSize := contract.LastRevision().NewFileSize
 	for _, action := range actions {
 		switch action.Type {
@@ -209,7 +376,7 @@ func (s *Session) write(sc *SafeContract, actions []modules.LoopWriteAction) (_
 		case modules.WriteActionSwap:
 
 		case modules.WriteActionUpdate:
-			return modules.RenterContract{}, errors.New("update not supported")
+			return types.FileContractRevision{}, types.ZeroCurrency, types.ZeroCurrency, errors.New("update not supported")
 
 		default:
 			build.Critical("unknown action type", action.Type)
@@ -233,7 +400,7 @@ func (s *Session) write(sc *SafeContract, actions []modules.LoopWriteAction) (_
 
 	// check that enough funds are available
 	if contract.RenterFunds().Cmp(cost) < 0 {
-		return modules.RenterContract{}, errors.New("contract has insufficient funds to support upload")
+		return types.FileContractRevision{}, types.ZeroCurrency, types.ZeroCurrency, errors.New("contract has insufficient funds to support upload")
 	}
 	if contract.LastRevision().MissedHostOutput().Value.Cmp(collateral) < 0 {
 		// The contract doesn't have enough value in it to supply the
@@ -260,61 +427,21 @@ func (s *Session) write(sc *SafeContract, actions []modules.LoopWriteAction) (_
 	// create the revision; we will update the Merkle root later
 	rev, err := contract.LastRevision().PaymentRevision(cost)
 	if err != nil {
-		return modules.RenterContract{}, errors.AddContext(err, "Error creating new write revision")
+		return types.FileContractRevision{}, types.ZeroCurrency, types.ZeroCurrency, errors.AddContext(err, "Error creating new write revision")
 	}
 
 	rev.SetMissedHostPayout(rev.MissedHostOutput().Value.Sub(collateral))
 	voidOutput, err := rev.MissedVoidOutput()
 	rev.SetMissedVoidPayout(voidOutput.Value.Add(collateral))
 	rev.NewFileSize = newFileSize
+	return rev, storagePrice, bandwidthPrice, nil
+}
 
-	// create the request
-	req := modules.LoopWriteRequest{
-		Actions:           actions,
-		MerkleProof:       true,
-		NewRevisionNumber: rev.NewRevisionNumber,
-	}
-	req.NewValidProofValues = make([]types.Currency, len(rev.NewValidProofOutputs))
-	for i, o := range rev.NewValidProofOutputs {
-		req.NewValidProofValues[i] = o.Value
-	}
-	req.NewMissedProofValues = make([]types.Currency, len(rev.NewMissedProofOutputs))
-	for i, o := range rev.NewMissedProofOutputs {
-		req.NewMissedProofValues[i] = o.Value
-	}
-
-	// record the change we are about to make to the contract. If we lose power
-	// mid-revision, this allows us to restore either the pre-revision or
-	// post-revision contract.
-	//
-	// TODO: update this for non-local root storage
-	walTxn, err := sc.managedRecordAppendIntent(rev, crypto.Hash{}, storagePrice, bandwidthPrice)
-	if err != nil {
-		return modules.RenterContract{}, err
-	}
-
-	defer func() {
-		// Increase Successful/Failed interactions accordingly
-		if err != nil {
-			s.hdb.IncrementFailedInteractions(s.host.PublicKey)
-		} else {
-			s.hdb.IncrementSuccessfulInteractions(s.host.PublicKey)
-		}
-
-		// reset deadline
-		extendDeadline(s.conn, time.Hour)
-	}()
-
-	// Disrupt here before sending the signed revision to the host.
-	if s.deps.Disrupt("InterruptUploadBeforeSendingRevision") {
-		return modules.RenterContract{}, errors.New("InterruptUploadBeforeSendingRevision disrupt")
-	}
-
-	// send Write RPC request
-	extendDeadline(s.conn, modules.NegotiateFileContractRevisionTime)
-	if err := s.writeRequest(modules.RPCLoopWrite, req); err != nil {
-		return modules.RenterContract{}, err
-	}
+// finishWrite completes a Write RPC after the request was sent. It verifies
+// the host's Merkle proof, exchanges signatures for the revision and commits
+// it to the contract.
+func (s *Session) finishWrite(sc *SafeContract, walTxn *unappliedWalTxn, rev types.FileContractRevision, actions []modules.LoopWriteAction, storagePrice, bandwidthPrice types.Currency) (_ modules.RenterContract, err error) {
+	contract := sc.header // for convenience
 
 	// read Merkle proof from host
 	var merkleResp modules.LoopWriteMerkleProof
@@ -331,7 +458,7 @@ func (s *Session) write(sc *SafeContract, actions []modules.LoopWriteAction) (_
 		return modules.RenterContract{}, errors.New("invalid Merkle proof for old root")
 	}
 	// ...then by modifying the leaves and verifying the new Merkle root
-	leafHashes = modifyLeaves(leafHashes, actions, numSectors)
+	leafHashes = modifyLeaves(s.contractSet.staticMerkleRootCache, leafHashes, actions, numSectors)
 	proofRanges = modifyProofRanges(proofRanges, actions, numSectors)
 	if !crypto.VerifyDiffProof(proofRanges, numSectors, proofHashes, leafHashes, newRoot) {
 		return modules.RenterContract{}, errors.New("invalid Merkle proof for new root")
@@ -393,9 +520,141 @@ func (s *Session) write(sc *SafeContract, actions []modules.LoopWriteAction) (_
 	return sc.Metadata(), nil
 }
 
+// writeStream implements the WriteStream RPC, appending the sectors with the
+// given Merkle roots to the contract.
+func (s *Session) writeStream(sectors [][]byte, roots []crypto.Hash) (_ modules.RenterContract, err error) {
+	sc, haveContract := s.contractSet.Acquire(s.contractID)
+	if !haveContract {
+		return modules.RenterContract{}, errors.New("contract not present in contract set")
+	}
+	defer s.contractSet.Return(sc)
+
+	actions := make([]modules.LoopWriteAction, len(sectors))
+	for i, sector := range sectors {
+		if uint64(len(sector)) != modules.SectorSize {
+			return modules.RenterContract{}, errors.New("sectors must be exactly SectorSize bytes")
+		}
+		actions[i] = modules.LoopWriteAction{Type: modules.WriteActionAppend, Data: sector}
+	}
+	rev, storagePrice, bandwidthPrice, err := s.writeRevision(sc, actions)
+	if err != nil {
+		return modules.RenterContract{}, err
+	}
+
+	// create the request
+	req := modules.LoopWriteStreamRequest{
+		NumSectors:        uint64(len(sectors)),
+		NewRevisionNumber: rev.NewRevisionNumber,
+	}
+	req.NewValidProofValues = make([]types.Currency, len(rev.NewValidProofOutputs))
+	for i, o := range rev.NewValidProofOutputs {
+		req.NewValidProofValues[i] = o.Value
+	}
+	req.NewMissedProofValues = make([]types.Currency, len(rev.NewMissedProofOutputs))
+	for i, o := range rev.NewMissedProofOutputs {
+		req.NewMissedProofValues[i] = o.Value
+	}
+
+	// record the change we are about to make to the contract.
+	walTxn, err := sc.managedRecordAppendIntent(rev, crypto.Hash{}, storagePrice, bandwidthPrice)
+	if err != nil {
+		return modules.RenterContract{}, err
+	}
+
+	defer func() {
+		// Increase Successful/Failed interactions accordingly
+		if err != nil {
+			s.hdb.IncrementFailedInteractions(s.host.PublicKey)
+		} else {
+			s.hdb.IncrementSuccessfulInteractions(s.host.PublicKey)
+		}
+
+		// reset deadline
+		extendDeadline(s.conn, time.Hour)
+	}()
+
+	// send WriteStream RPC request followed by the sectors
+	extendDeadline(s.conn, modules.NegotiateFileContractRevisionTime)
+	if err := s.writeRequest(modules.RPCLoopWriteStream, req); err != nil {
+		return modules.RenterContract{}, err
+	}
+	if err := s.streamSectors(sectors, roots); err != nil {
+		return modules.RenterContract{}, err
+	}
+	extendDeadline(s.conn, modules.NegotiateFileContractRevisionTime)
+	return s.finishWrite(sc, walTxn, rev, actions, storagePrice, bandwidthPrice)
+}
+
+// streamSectors sends the sectors of a WriteStream RPC and waits for the
+// host's acknowledgements. Sectors are sent without waiting for the
+// acknowledgements of the previous ones, as long as the number of
+// unacknowledged sectors fits the session's write window. The acknowledgements
+// are used to adapt the window to the connection.
+//
+// A stream can't be resumed after an error, so the connection is closed if
+// streaming fails.
+func (s *Session) streamSectors(sectors [][]byte, roots []crypto.Hash) (err error) {
+	type streamAck struct {
+		ack  modules.LoopWriteStreamAck
+		err  error
+		time time.Time
+	}
+	acks := make(chan streamAck, len(sectors))
+	go func() {
+		for range sectors {
+			var ack modules.LoopWriteStreamAck
+			err := s.readResponse(&ack, modules.RPCMinLen)
+			acks <- streamAck{ack, err, time.Now()}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	defer func() {
+		if err != nil {
+			// Closing the connection also stops the goroutine reading the
+			// acknowledgements.
+			s.conn.Close()
+		}
+	}()
+
+	sent := make([]time.Time, len(sectors))
+	deliveredAtSend := make([]uint64, len(sectors))
+	var delivered uint64
+	var next, acked int
+	for acked < len(sectors) {
+		// Send the next sector if the window isn't full.
+		if next < len(sectors) && uint64(next-acked) < s.writeWindow.size {
+			extendDeadline(s.conn, modules.NegotiateFileContractRevisionTime)
+			sent[next] = time.Now()
+			deliveredAtSend[next] = delivered
+			sector := modules.LoopWriteStreamSector{Data: sectors[next]}
+			if err := modules.WriteRPCMessage(s.conn, s.aead, sector); err != nil {
+				return errors.AddContext(err, "unable to send sector")
+			}
+			next++
+			continue
+		}
+
+		// Otherwise wait for the next acknowledgement.
+		a := <-acks
+		if a.err != nil {
+			return errors.AddContext(a.err, "host didn't acknowledge sector")
+		}
+		if a.ack.Index != uint64(acked) || a.ack.Root != roots[acked] {
+			return errors.New("host acknowledged the wrong sector")
+		}
+		delivered += modules.SectorSize
+		s.writeWindow.update(delivered-deliveredAtSend[acked], a.time.Sub(sent[acked]))
+		acked++
+	}
+	return nil
+}
+
 // Read calls the Read RPC, writing the requested data to w. The RPC can be
-// cancelled (with a granularity of one section) via the cancel channel.
-func (s *Session) Read(w io.Writer, req modules.LoopReadRequest, cancel <-chan struct{}) (_ modules.RenterContract, err error) {
+// cancelled (with a granularity of one section) via ctx. Unlike the writing
+// RPCs, a cancelled read leaves the session usable.
+func (s *Session) Read(ctx context.Context, w io.Writer, req modules.LoopReadRequest) (_ modules.RenterContract, err error) {
 	// Reset deadline when finished.
 	defer extendDeadline(s.conn, time.Hour)
 
@@ -441,6 +700,9 @@ func (s *Session) Read(w io.Writer, req modules.LoopReadRequest, cancel <-chan s
 		sectorAccesses[sec.MerkleRoot] = struct{}{}
 	}
 	// calculate price
+	if _, err := s.Prices(); err != nil {
+		return modules.RenterContract{}, err
+	}
 	bandwidthPrice := s.host.DownloadBandwidthPrice.Mul64(estBandwidth)
 	sectorAccessPrice := s.host.SectorAccessPrice.Mul64(uint64(len(sectorAccesses)))
 	price := s.host.BaseRPCPrice.Add(bandwidthPrice).Add(sectorAccessPrice)
@@ -520,7 +782,7 @@ func (s *Session) Read(w io.Writer, req modules.LoopReadRequest, cancel <-chan s
 	doneChan := make(chan struct{})
 	go func() {
 		select {
-		case <-cancel:
+		case <-ctx.Done():
 		case <-doneChan:
 		}
 		s.writeResponse(modules.RPCLoopReadStop, nil)
@@ -588,8 +850,9 @@ func (s *Session) Read(w io.Writer, req modules.LoopReadRequest, cancel <-chan s
 }
 
 // ReadSection calls the Read RPC with a single section and returns the
-// requested data. A Merkle proof is always requested.
-func (s *Session) ReadSection(root crypto.Hash, offset, length uint32) (_ modules.RenterContract, _ []byte, err error) {
+// requested data. A Merkle proof is always requested. Cancelling ctx aborts
+// the download and closes the session.
+func (s *Session) ReadSection(ctx context.Context, root crypto.Hash, offset, length uint32) (_ modules.RenterContract, _ []byte, err error) {
 	req := modules.LoopReadRequest{
 		Sections: []modules.LoopReadRequestSection{{
 			MerkleRoot: root,
@@ -600,7 +863,7 @@ func (s *Session) ReadSection(root crypto.Hash, offset, length uint32) (_ module
 	}
 	var buf bytes.Buffer
 	buf.Grow(int(length))
-	contract, err := s.Read(&buf, req, nil)
+	contract, err := s.Read(ctx, &buf, req)
 	return contract, buf.Bytes(), err
 }
 
@@ -625,6 +888,9 @@ func (s *Session) SectorRoots(req modules.LoopSectorRootsRequest) (_ modules.Ren
 	if estBandwidth < modules.RPCMinLen {
 		estBandwidth = modules.RPCMinLen
 	}
+	if _, err := s.Prices(); err != nil {
+		return modules.RenterContract{}, nil, err
+	}
 	bandwidthPrice := s.host.DownloadBandwidthPrice.Mul64(estBandwidth)
 	price := s.host.BaseRPCPrice.Add(bandwidthPrice)
 	if contract.RenterFunds().Cmp(price) < 0 {
@@ -738,6 +1004,9 @@ func (s *Session) RecoverSectorRoots(lastRev types.FileContractRevision, sk cryp
 	if estBandwidth < modules.RPCMinLen {
 		estBandwidth = modules.RPCMinLen
 	}
+	if _, err := s.Prices(); err != nil {
+		return types.Transaction{}, nil, err
+	}
 	bandwidthPrice := s.host.DownloadBandwidthPrice.Mul64(estBandwidth)
 	price := s.host.BaseRPCPrice.Add(bandwidthPrice)
 	if lastRev.ValidRenterPayout().Cmp(price) < 0 {
@@ -832,13 +1101,14 @@ func (s *Session) Close() error {
 }
 
 // NewSession initiates the RPC loop with a host and returns a Session.
-func (cs *ContractSet) NewSession(host modules.HostDBEntry, id types.FileContractID, currentHeight types.BlockHeight, hdb hostDB, logger *log.Logger, cancel <-chan struct{}) (_ *Session, err error) {
+// Cancelling ctx closes the session.
+func (cs *ContractSet) NewSession(ctx context.Context, host modules.HostDBEntry, id types.FileContractID, currentHeight types.BlockHeight, hdb hostDB, logger *log.Logger) (_ *Session, err error) {
 	sc, ok := cs.Acquire(id)
 	if !ok {
 		return nil, errors.New("could not locate contract to create session")
 	}
 	defer cs.Return(sc)
-	s, err := cs.managedNewSession(host, currentHeight, hdb, cancel)
+	s, err := cs.managedNewSession(ctx, host, currentHeight, hdb)
 	if err != nil {
 		return nil, errors.AddContext(err, "unable to create a new session with the host")
 	}
@@ -862,12 +1132,12 @@ func (cs *ContractSet) NewSession(host modules.HostDBEntry, id types.FileContrac
 }
 
 // NewRawSession creates a new session unassociated with any contract.
-func (cs *ContractSet) NewRawSession(host modules.HostDBEntry, currentHeight types.BlockHeight, hdb hostDB, cancel <-chan struct{}) (_ *Session, err error) {
-	return cs.managedNewSession(host, currentHeight, hdb, cancel)
+func (cs *ContractSet) NewRawSession(ctx context.Context, host modules.HostDBEntry, currentHeight types.BlockHeight, hdb hostDB) (_ *Session, err error) {
+	return cs.managedNewSession(ctx, host, currentHeight, hdb)
 }
 
 // managedNewSession initiates the RPC loop with a host and returns a Session.
-func (cs *ContractSet) managedNewSession(host modules.HostDBEntry, currentHeight types.BlockHeight, hdb hostDB, cancel <-chan struct{}) (_ *Session, err error) {
+func (cs *ContractSet) managedNewSession(ctx context.Context, host modules.HostDBEntry, currentHeight types.BlockHeight, hdb hostDB) (_ *Session, err error) {
 	// Increase Successful/Failed interactions accordingly
 	defer func() {
 		if err != nil {
@@ -886,18 +1156,18 @@ func (cs *ContractSet) managedNewSession(host modules.HostDBEntry, currentHeight
 	}
 
 	c, err := (&net.Dialer{
-		Cancel:  cancel,
 		Timeout: sessionDialTimeout,
-	}).Dial("tcp", string(host.NetAddress))
+	}).DialContext(ctx, "tcp", string(host.NetAddress))
 	if err != nil {
+		err = errors.Compose(err, ctx.Err())
 		return nil, errors.AddContext(err, "unsuccessful dial when creating a new session")
 	}
-	conn := ratelimit.NewRLConn(c, cs.staticRL, cancel)
+	conn := ratelimit.NewRLConn(c, cs.staticRL, ctx.Done())
 
 	closeChan := make(chan struct{})
 	go func() {
 		select {
-		case <-cancel:
+		case <-ctx.Done():
 			conn.Close()
 		case <-closeChan:
 			// we don't close the connection here because we want session.Close
@@ -910,6 +1180,7 @@ func (cs *ContractSet) managedNewSession(host modules.HostDBEntry, currentHeight
 	if err != nil {
 		conn.Close()
 		close(closeChan)
+		err = errors.Compose(err, ctx.Err())
 		return nil, errors.AddContext(err, "session handshake failed")
 	}
 	s := &Session{
@@ -922,6 +1193,7 @@ func (cs *ContractSet) managedNewSession(host modules.HostDBEntry, currentHeight
 		hdb:         hdb,
 		height:      currentHeight,
 		host:        host,
+		writeWindow: newWriteWindow(),
 	}
 
 	return s, nil
@@ -989,7 +1261,7 @@ func modifyProofRanges(proofRanges []crypto.ProofRange, actions []modules.LoopWr
 
 // modifyLeaves modifies the leaf hashes of a Merkle diff proof to verify a
 // post-modification Merkle diff proof for the specified actions.
-func modifyLeaves(leafHashes []crypto.Hash, actions []modules.LoopWriteAction, numSectors uint64) []crypto.Hash {
+func modifyLeaves(cache *crypto.MerkleRootCache, leafHashes []crypto.Hash, actions []modules.LoopWriteAction, numSectors uint64) []crypto.Hash {
 	// determine which sector index corresponds to each leaf hash
 	var indices []uint64
 	for _, action := range actions {
@@ -1020,7 +1292,7 @@ func modifyLeaves(leafHashes []crypto.Hash, actions []modules.LoopWriteAction, n
 	for _, action := range actions {
 		switch action.Type {
 		case modules.WriteActionAppend:
-			leafHashes = append(leafHashes, crypto.MerkleRoot(action.Data))
+			leafHashes = append(leafHashes, cache.MerkleRoot(action.Data))
 
 		case modules.WriteActionTrim:
 			leafHashes = leafHashes[:uint64(len(leafHashes))-action.A]
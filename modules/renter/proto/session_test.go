@@ -241,7 +241,7 @@ func TestModifyLeafHashes(t *testing.T) {
 	}
 	for _, test := range tests {
 		t.Run(test.desc, func(t *testing.T) {
-			res := modifyLeaves(test.leaves, test.actions, test.numSectors)
+			res := modifyLeaves(nil, test.leaves, test.actions, test.numSectors)
 			if !reflect.DeepEqual(res, test.exp) {
 				t.Errorf("incorrect modification: expected %v, got %v", test.exp, res)
 			}
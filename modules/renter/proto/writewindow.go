@@ -0,0 +1,77 @@
+package proto
+
+import (
+	"math"
+	"time"
+
+	"go.sia.tech/siad/modules"
+)
+
+// writewindow.go contains the window of the WriteStream RPC, which limits the
+// number of sectors which are in flight during a streamed upload. A window of
+// a single sector turns the upload into a round trip per sector, which only
+// uses a small fraction of the bandwidth of connections with a high latency.
+//
+// The window is sized to the bandwidth-delay product of the connection. The
+// bandwidth is estimated from the rate at which the host acknowledges
+// sectors, and the delay is the shortest round trip of a sector seen by the
+// session. A host which can't keep up acknowledges sectors more slowly, which
+// shrinks the window again.
+
+const (
+	// initialWriteWindow is the number of sectors which may be in flight
+	// before the session measured its connection to the host.
+	initialWriteWindow = 2
+
+	// maxWriteWindow is the maximum number of sectors which may be in
+	// flight.
+	maxWriteWindow = modules.NegotiateMaxWriteStreamSectors
+
+	// writeWindowBandwidthWeight is the weight of a new measurement in the
+	// estimate of the bandwidth.
+	writeWindowBandwidthWeight = 0.25
+)
+
+// writeWindow tracks the number of sectors which may be in flight during a
+// streamed upload.
+type writeWindow struct {
+	bandwidth float64 // bytes per second
+	minRTT    time.Duration
+	size      uint64
+}
+
+// newWriteWindow creates a new write window.
+func newWriteWindow() *writeWindow {
+	return &writeWindow{
+		size: initialWriteWindow,
+	}
+}
+
+// update adapts the window to the acknowledgement of a sector. rtt is the time
+// between sending the sector and receiving its acknowledgement and delivered
+// the number of bytes the host acknowledged in that time, including the
+// sector itself.
+func (ww *writeWindow) update(delivered uint64, rtt time.Duration) {
+	if rtt <= 0 {
+		return
+	}
+	if ww.minRTT == 0 || rtt < ww.minRTT {
+		ww.minRTT = rtt
+	}
+	rate := float64(delivered) / rtt.Seconds()
+	if ww.bandwidth == 0 {
+		ww.bandwidth = rate
+	} else {
+		ww.bandwidth = writeWindowBandwidthWeight*rate + (1-writeWindowBandwidthWeight)*ww.bandwidth
+	}
+
+	// Keep enough sectors in flight to fill the bandwidth-delay product, plus
+	// one more so that the window grows if the connection has more bandwidth
+	// than measured so far.
+	bdp := ww.bandwidth * ww.minRTT.Seconds()
+	size := uint64(math.Ceil(bdp/float64(modules.SectorSize))) + 1
+	if size > maxWriteWindow {
+		size = maxWriteWindow
+	}
+	ww.size = size
+}
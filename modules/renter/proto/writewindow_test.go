@@ -0,0 +1,82 @@
+package proto
+
+import (
+	"testing"
+	"time"
+
+	"go.sia.tech/siad/modules"
+)
+
+// simulateWriteStream streams numSectors sectors over a simulated connection
+// which transmits a sector in transmit and takes delay to acknowledge it,
+// adapting ww along the way. It returns the time it took to stream the
+// sectors.
+func simulateWriteStream(ww *writeWindow, numSectors int, transmit, delay time.Duration) time.Duration {
+	sent := make([]time.Duration, numSectors)
+	ackAt := make([]time.Duration, numSectors)
+	deliveredAtSend := make([]uint64, numSectors)
+	var now, linkFree time.Duration
+	var delivered uint64
+	var next, acked int
+	for acked < numSectors {
+		if next < numSectors && uint64(next-acked) < ww.size {
+			sent[next] = now
+			deliveredAtSend[next] = delivered
+			if linkFree < now {
+				linkFree = now
+			}
+			linkFree += transmit
+			ackAt[next] = linkFree + delay
+			next++
+			continue
+		}
+		now = ackAt[acked]
+		delivered += modules.SectorSize
+		ww.update(delivered-deliveredAtSend[acked], ackAt[acked]-sent[acked])
+		acked++
+	}
+	return now
+}
+
+// TestWriteWindow tests that the write window adapts to the bandwidth-delay
+// product of the connection.
+func TestWriteWindow(t *testing.T) {
+	// A connection which transmits a sector in 10ms with a delay of 100ms has
+	// a bandwidth-delay product of 11 sectors.
+	transmit, delay := 10*time.Millisecond, 100*time.Millisecond
+	bdp := uint64((transmit + delay) / transmit)
+	ww := newWriteWindow()
+	if ww.size != initialWriteWindow {
+		t.Fatal("wrong initial window", ww.size)
+	}
+	simulateWriteStream(ww, 200, transmit, delay)
+	if ww.size < bdp || ww.size > bdp+3 {
+		t.Fatalf("window should be close to %v but was %v", bdp, ww.size)
+	}
+
+	// Once the window is open, streaming should run close to line rate.
+	elapsed := simulateWriteStream(ww, 200, transmit, delay)
+	lineRate := 200*transmit + delay
+	if elapsed > lineRate*11/10 {
+		t.Fatalf("streaming took %v, line rate is %v", elapsed, lineRate)
+	}
+
+	// Without a window the same upload would take a round trip per sector.
+	if elapsed*5 > 200*(transmit+delay) {
+		t.Fatal("streaming should be much faster than a round trip per sector", elapsed)
+	}
+
+	// A host which slows down shrinks the window.
+	size := ww.size
+	simulateWriteStream(ww, 200, 4*transmit, delay)
+	if ww.size >= size {
+		t.Fatalf("window should shrink but went from %v to %v", size, ww.size)
+	}
+
+	// The window never exceeds the maximum.
+	ww = newWriteWindow()
+	simulateWriteStream(ww, 500, time.Millisecond, time.Second)
+	if ww.size != maxWriteWindow {
+		t.Fatal("window should be capped at the maximum", ww.size)
+	}
+}
@@ -254,6 +254,19 @@ func (r *Renter) ReadRegistry(spk types.SiaPublicKey, tweak crypto.Hash, timeout
 	return srv, err
 }
 
+// ReadRegistryOfType looks up a registry entry and checks that it is of the
+// expected type.
+func (r *Renter) ReadRegistryOfType(spk types.SiaPublicKey, tweak crypto.Hash, entryType modules.RegistryEntryType, timeout time.Duration) (modules.SignedRegistryValue, error) {
+	srv, err := r.ReadRegistry(spk, tweak, timeout)
+	if err != nil {
+		return modules.SignedRegistryValue{}, err
+	}
+	if srv.Type != entryType {
+		return modules.SignedRegistryValue{}, errors.AddContext(modules.ErrRegistryEntryTypeMismatch, fmt.Sprintf("expected %v but got %v", entryType, srv.Type))
+	}
+	return srv, nil
+}
+
 // UpdateRegistry updates the registries on all workers with the given
 // registry value.
 func (r *Renter) UpdateRegistry(spk types.SiaPublicKey, srv modules.SignedRegistryValue, timeout time.Duration) error {
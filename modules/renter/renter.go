@@ -101,6 +101,14 @@ type hostContractor interface {
 	// watchdog.
 	ContractStatus(fcID types.FileContractID) (modules.ContractWatchStatus, bool)
 
+	// RegisterNotifier registers a notifier which is notified about renewal
+	// failures, locked contracts and a low allowance.
+	RegisterNotifier(n modules.ContractorNotifier)
+
+	// ContractReceipts returns the receipts of the contracts the contractor
+	// formed and renewed.
+	ContractReceipts() []modules.ContractReceipt
+
 	// CurrentPeriod returns the height at which the current allowance period
 	// began.
 	CurrentPeriod() types.BlockHeight
@@ -109,10 +117,19 @@ type hostContractor interface {
 	// contracts within a separate thread.
 	InitRecoveryScan() error
 
+	// InitFullRecoveryScan starts scanning the whole blockchain for contracts
+	// derivable from the wallet seed, including contracts without a contract
+	// identifier, within a separate thread.
+	InitFullRecoveryScan() error
+
 	// PeriodSpending returns the amount spent on contracts during the current
 	// billing period.
 	PeriodSpending() (modules.ContractorSpending, error)
 
+	// PeriodSpendingBreakdown returns the amount spent on contracts during
+	// the current billing period split by category and by host.
+	PeriodSpendingBreakdown() (modules.ContractorSpendingBreakdown, error)
+
 	// ProvidePayment takes a stream and a set of payment details and handles
 	// the payment for an RPC by sending and processing payment request and
 	// response objects to the host. It returns an error in case of failure.
@@ -148,6 +165,14 @@ type hostContractor interface {
 	// RefreshedContract checks if the contract was previously refreshed
 	RefreshedContract(fcid types.FileContractID) bool
 
+	// RenewalChain returns the renewal chain of the contract with the given
+	// id, ordered from the oldest to the newest contract.
+	RenewalChain(fcid types.FileContractID) ([]modules.ContractRenewalLink, error)
+
+	// QueryContracts returns the page of the current contracts selected by
+	// the query and the number of contracts matching its filters.
+	QueryContracts(q modules.ContractQuery) ([]modules.RenterContract, uint64, error)
+
 	// RenewContract takes an established connection to a host and renews the
 	// given contract with that host.
 	RenewContract(conn net.Conn, fcid types.FileContractID, params modules.ContractParams, txnBuilder modules.TransactionBuilder, tpool modules.TransactionPool, hdb modules.HostDB, pt *modules.RPCPriceTable) (modules.RenterContract, []types.Transaction, error)
@@ -203,6 +228,10 @@ type Renter struct {
 	directoryHeap directoryHeap
 	stuckStack    stuckStack
 
+	// nftRepairTimes are the times at which the repairs of pinned NFTs within
+	// the last hour were started.
+	nftRepairTimes []time.Time
+
 	// Cache the hosts from the last price estimation result.
 	lastEstimationHosts []modules.HostDBEntry
 
@@ -225,6 +254,17 @@ type Renter struct {
 	// read registry stats
 	staticRRS *readRegistryStats
 
+	// staticSectorCache caches the data read from hosts by sector root.
+	staticSectorCache *sectorCache
+
+	// staticProofVerifier verifies the proofs of downloaded sectors.
+	staticProofVerifier *proofVerifier
+
+	// staticMerkleRootCache caches the merkle roots of pieces and pinned NFT
+	// data to avoid hashing the same data again when it is repaired or
+	// verified.
+	staticMerkleRootCache *crypto.MerkleRootCache
+
 	// Memory management
 	//
 	// registryMemoryManager is used for updating registry entries and reading
@@ -399,7 +439,7 @@ func (r *Renter) PriceEstimation(allowance modules.Allowance) (modules.RenterPri
 	for _, host := range hosts {
 		totalContractCost = totalContractCost.Add(host.ContractPrice)
 		totalDownloadCost = totalDownloadCost.Add(host.DownloadBandwidthPrice)
-		totalStorageCost = totalStorageCost.Add(host.StoragePrice)
+		totalStorageCost = totalStorageCost.Add(host.StoragePriceFor(allowance.NFTStorage))
 		totalUploadCost = totalUploadCost.Add(host.UploadBandwidthPrice)
 	}
 
@@ -604,6 +644,9 @@ func (r *Renter) SetSettings(s modules.RenterSettings) error {
 	if s.MaxDownloadSpeed < 0 || s.MaxUploadSpeed < 0 {
 		return errors.New("bandwidth limits cannot be negative")
 	}
+	if err := s.UploadSchedule.Validate(); err != nil {
+		return err
+	}
 
 	// Set allowance.
 	err := r.hostContractor.SetAllowance(s.Allowance)
@@ -620,10 +663,16 @@ func (r *Renter) SetSettings(s modules.RenterSettings) error {
 		return err
 	}
 
+	// Resize the sector cache.
+	r.staticSectorCache.callSetMaxSize(s.SectorCacheSize)
+
 	// Save the changes.
 	id := r.mu.Lock()
 	r.persist.MaxDownloadSpeed = s.MaxDownloadSpeed
 	r.persist.MaxUploadSpeed = s.MaxUploadSpeed
+	r.persist.NFTPreviews = s.NFTPreviews
+	r.persist.SectorCacheSize = s.SectorCacheSize
+	r.persist.UploadSchedule = s.UploadSchedule
 	err = r.saveSync()
 	r.mu.Unlock(id)
 	if err != nil {
@@ -778,6 +827,13 @@ func (r *Renter) InitRecoveryScan() error {
 	return r.hostContractor.InitRecoveryScan()
 }
 
+// InitFullRecoveryScan starts scanning the whole blockchain for contracts
+// derivable from the wallet seed, including contracts without a contract
+// identifier, within a separate thread.
+func (r *Renter) InitFullRecoveryScan() error {
+	return r.hostContractor.InitFullRecoveryScan()
+}
+
 // RecoveryScanStatus returns a bool indicating if a scan for recoverable
 // contracts is in progress and if it is, the current progress of the scan.
 func (r *Renter) RecoveryScanStatus() (bool, types.BlockHeight) {
@@ -794,6 +850,23 @@ func (r *Renter) PeriodSpending() (modules.ContractorSpending, error) {
 	return r.hostContractor.PeriodSpending()
 }
 
+// PeriodSpendingBreakdown returns the host contractor's period spending split
+// by category and by host.
+func (r *Renter) PeriodSpendingBreakdown() (modules.ContractorSpendingBreakdown, error) {
+	return r.hostContractor.PeriodSpendingBreakdown()
+}
+
+// RegisterContractorNotifier registers a notifier with the host contractor.
+func (r *Renter) RegisterContractorNotifier(n modules.ContractorNotifier) {
+	r.hostContractor.RegisterNotifier(n)
+}
+
+// ContractReceipts returns the receipts of the contracts the host contractor
+// formed and renewed.
+func (r *Renter) ContractReceipts() []modules.ContractReceipt {
+	return r.hostContractor.ContractReceipts()
+}
+
 // RecoverableContracts returns the host contractor's recoverable contracts.
 func (r *Renter) RecoverableContracts() []modules.RecoverableContract {
 	return r.hostContractor.RecoverableContracts()
@@ -805,6 +878,18 @@ func (r *Renter) RefreshedContract(fcid types.FileContractID) bool {
 	return r.hostContractor.RefreshedContract(fcid)
 }
 
+// RenewalChain returns the renewal chain of the contract with the given id,
+// ordered from the oldest to the newest contract.
+func (r *Renter) RenewalChain(fcid types.FileContractID) ([]modules.ContractRenewalLink, error) {
+	return r.hostContractor.RenewalChain(fcid)
+}
+
+// QueryContracts returns the page of the host contractor's current contracts
+// selected by the query and the number of contracts matching its filters.
+func (r *Renter) QueryContracts(q modules.ContractQuery) ([]modules.RenterContract, uint64, error) {
+	return r.hostContractor.QueryContracts(q)
+}
+
 // Settings returns the Renter's current settings.
 func (r *Renter) Settings() (modules.RenterSettings, error) {
 	if err := r.tg.Add(); err != nil {
@@ -817,11 +902,19 @@ func (r *Renter) Settings() (modules.RenterSettings, error) {
 		return modules.RenterSettings{}, errors.AddContext(err, "error getting IPViolationsCheck:")
 	}
 	paused, endTime := r.uploadHeap.managedPauseStatus()
+	id := r.mu.RLock()
+	nftPreviews := r.persist.NFTPreviews
+	sectorCacheSize := r.persist.SectorCacheSize
+	uploadSchedule := r.persist.UploadSchedule
+	r.mu.RUnlock(id)
 	return modules.RenterSettings{
 		Allowance:        r.hostContractor.Allowance(),
 		IPViolationCheck: enabled,
 		MaxDownloadSpeed: download,
 		MaxUploadSpeed:   upload,
+		NFTPreviews:      nftPreviews,
+		SectorCacheSize:  sectorCacheSize,
+		UploadSchedule:   uploadSchedule,
 		UploadsStatus: modules.UploadsStatus{
 			Paused:       paused,
 			PauseEndTime: endTime,
@@ -979,7 +1072,11 @@ func renterBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 		return nil, err
 	}
 
-	// After persist is initialized, create the worker pool.
+	// After persist is initialized, create the sector cache, the proof
+	// verifier and the worker pool.
+	r.staticSectorCache = newSectorCache(r.persist.SectorCacheSize)
+	r.staticMerkleRootCache = crypto.NewMerkleRootCache(merkleRootCacheSize)
+	r.staticProofVerifier = newProofVerifier(&r.tg)
 	r.staticWorkerPool = r.newWorkerPool()
 
 	// Set the worker pool on the contractor.
@@ -1038,6 +1135,8 @@ func renterAsyncStartup(r *Renter, cs modules.ConsensusSet) error {
 	if !r.deps.Disrupt("DisableRepairAndHealthLoops") {
 		go r.threadedUploadAndRepair()
 		go r.threadedStuckFileLoop()
+		go r.threadedNFTPinLoop()
+		go r.threadedEnforceUploadSchedule()
 	}
 	// Spin up the snapshot synchronization thread.
 	if !r.deps.Disrupt("DisableSnapshotSync") {
@@ -0,0 +1,151 @@
+// Package renterutil contains lightweight renter routines which operate on
+// sessions with hosts instead of the full renter module, e.g. to keep the
+// content of NFTs alive from a node which doesn't run a renter.
+package renterutil
+
+import (
+	"context"
+	"sort"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/proto"
+	"go.sia.tech/siad/types"
+)
+
+// repair.go contains a repair routine for erasure-coded data which is stored
+// through sessions. Every piece is a full sector on a different host. Pieces
+// on hosts which are gone or whose contract expired are reconstructed from the
+// surviving pieces and uploaded to replacement hosts.
+
+var (
+	// errNotEnoughPieces is returned if too few pieces survived to
+	// reconstruct the missing ones.
+	errNotEnoughPieces = errors.New("not enough pieces survived to repair the data")
+
+	// errNotEnoughHosts is returned if there are fewer replacement hosts
+	// than missing pieces.
+	errNotEnoughHosts = errors.New("not enough replacement hosts to upload the missing pieces to")
+)
+
+type (
+	// RepairHost is a host which pieces are downloaded from or uploaded to
+	// during a repair, usually a *proto.Session.
+	RepairHost interface {
+		Append(ctx context.Context, data []byte) (modules.RenterContract, crypto.Hash, error)
+		ReadSection(ctx context.Context, root crypto.Hash, offset, length uint32) (modules.RenterContract, []byte, error)
+	}
+
+	// ErasureManifest describes erasure-coded data. Pieces contains the
+	// Merkle root and the host of every piece in the order of the pieces
+	// returned by the ErasureCoder.
+	ErasureManifest struct {
+		ErasureCoder modules.ErasureCoder
+		Pieces       []modules.PieceChecksum
+	}
+)
+
+var _ RepairHost = (*proto.Session)(nil)
+
+// RepairErasureCoded reconstructs the pieces of the manifest which were lost
+// and uploads them to replacement hosts. hosts contains the hosts with a
+// usable contract, keyed by their public key. Pieces on hosts which are
+// missing from hosts are lost. Surviving pieces are downloaded until MinPieces
+// of them match their Merkle root; pieces which can't be downloaded or don't
+// match are lost as well. Hosts which don't store a piece of the manifest are
+// used as replacements. The returned manifest points to the new locations of
+// the repaired pieces, and to the old locations of the pieces which couldn't
+// be uploaded.
+func RepairErasureCoded(ctx context.Context, m ErasureManifest, hosts map[string]RepairHost) (ErasureManifest, error) {
+	if len(m.Pieces) != m.ErasureCoder.NumPieces() {
+		return m, errors.New("manifest doesn't contain every piece of the erasure coder")
+	}
+
+	// Pieces on hosts without a usable contract are lost.
+	lost := make([]bool, len(m.Pieces))
+	used := make(map[string]struct{})
+	var numLost int
+	for i, pc := range m.Pieces {
+		if _, ok := hosts[pc.HostPubKey.String()]; ok {
+			used[pc.HostPubKey.String()] = struct{}{}
+		} else {
+			lost[i] = true
+			numLost++
+		}
+	}
+	if numLost == 0 {
+		return m, nil
+	}
+
+	// Download surviving pieces until enough of them are in hand to
+	// reconstruct the others.
+	pieces := make([][]byte, len(m.Pieces))
+	var good int
+	for i, pc := range m.Pieces {
+		if good == m.ErasureCoder.MinPieces() {
+			break
+		}
+		if lost[i] {
+			continue
+		}
+		_, data, err := hosts[pc.HostPubKey.String()].ReadSection(ctx, pc.MerkleRoot, 0, uint32(modules.SectorSize))
+		if err := ctx.Err(); err != nil {
+			return m, err
+		}
+		if err != nil || uint64(len(data)) != modules.SectorSize || crypto.MerkleRoot(data) != pc.MerkleRoot {
+			lost[i] = true
+			continue
+		}
+		pieces[i] = data
+		good++
+	}
+	if good < m.ErasureCoder.MinPieces() {
+		return m, errNotEnoughPieces
+	}
+	if err := m.ErasureCoder.Reconstruct(pieces); err != nil {
+		return m, errors.AddContext(err, "unable to reconstruct the missing pieces")
+	}
+
+	// Upload the lost pieces to hosts which don't store a piece yet. The
+	// hosts are sorted to make the choice deterministic.
+	var replacements []string
+	for key := range hosts {
+		if _, ok := used[key]; !ok {
+			replacements = append(replacements, key)
+		}
+	}
+	sort.Strings(replacements)
+
+	repaired := ErasureManifest{
+		ErasureCoder: m.ErasureCoder,
+		Pieces:       append([]modules.PieceChecksum(nil), m.Pieces...),
+	}
+	var errs []error
+	for i := range m.Pieces {
+		if !lost[i] {
+			continue
+		}
+		var uploaded bool
+		for !uploaded && len(replacements) > 0 {
+			key := replacements[0]
+			replacements = replacements[1:]
+			_, root, err := hosts[key].Append(ctx, pieces[i])
+			if err != nil {
+				errs = append(errs, errors.AddContext(err, "unable to upload piece to "+key))
+				continue
+			}
+			var hpk types.SiaPublicKey
+			if err := hpk.LoadString(key); err != nil {
+				return repaired, errors.AddContext(err, "invalid host key")
+			}
+			repaired.Pieces[i] = modules.PieceChecksum{MerkleRoot: root, HostPubKey: hpk}
+			uploaded = true
+		}
+		if !uploaded {
+			return repaired, errors.Compose(append(errs, errNotEnoughHosts)...)
+		}
+	}
+	return repaired, nil
+}
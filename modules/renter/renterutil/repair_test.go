@@ -0,0 +1,133 @@
+package renterutil
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// repairTestHost is an in-memory RepairHost.
+type repairTestHost struct {
+	sectors map[crypto.Hash][]byte
+	reads   int
+}
+
+// Append implements RepairHost.
+func (h *repairTestHost) Append(_ context.Context, data []byte) (modules.RenterContract, crypto.Hash, error) {
+	root := crypto.MerkleRoot(data)
+	h.sectors[root] = append([]byte(nil), data...)
+	return modules.RenterContract{}, root, nil
+}
+
+// ReadSection implements RepairHost.
+func (h *repairTestHost) ReadSection(_ context.Context, root crypto.Hash, offset, length uint32) (modules.RenterContract, []byte, error) {
+	h.reads++
+	sector, ok := h.sectors[root]
+	if !ok {
+		return modules.RenterContract{}, nil, errors.New("sector not found")
+	}
+	return modules.RenterContract{}, sector[offset : offset+length], nil
+}
+
+// TestRepairErasureCoded tests repairing erasure-coded data of which pieces
+// were lost.
+func TestRepairErasureCoded(t *testing.T) {
+	ec, err := modules.NewRSSubCode(2, 2, crypto.SegmentSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := fastrand.Bytes(int(2 * modules.SectorSize))
+	pieces, err := ec.Encode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Store every piece on a different host and add two spare hosts.
+	hosts := make(map[string]RepairHost)
+	newHost := func() types.SiaPublicKey {
+		var key crypto.PublicKey
+		fastrand.Read(key[:])
+		pk := types.Ed25519PublicKey(key)
+		hosts[pk.String()] = &repairTestHost{sectors: make(map[crypto.Hash][]byte)}
+		return pk
+	}
+	m := ErasureManifest{ErasureCoder: ec}
+	for _, piece := range pieces {
+		pk := newHost()
+		_, root, _ := hosts[pk.String()].Append(context.Background(), piece)
+		m.Pieces = append(m.Pieces, modules.PieceChecksum{MerkleRoot: root, HostPubKey: pk})
+	}
+	newHost()
+	newHost()
+
+	// Nothing is downloaded or repaired while every host is available.
+	repaired, err := RepairErasureCoded(context.Background(), m, hosts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range m.Pieces {
+		if repaired.Pieces[i].HostPubKey.String() != m.Pieces[i].HostPubKey.String() {
+			t.Fatal("piece was moved although it wasn't lost")
+		}
+		if hosts[m.Pieces[i].HostPubKey.String()].(*repairTestHost).reads != 0 {
+			t.Fatal("piece was downloaded although no piece was lost")
+		}
+	}
+
+	// Lose one host and corrupt the piece of another host.
+	delete(hosts, m.Pieces[0].HostPubKey.String())
+	corrupted := hosts[m.Pieces[1].HostPubKey.String()].(*repairTestHost)
+	corrupted.sectors[m.Pieces[1].MerkleRoot] = fastrand.Bytes(int(modules.SectorSize))
+	repaired, err = RepairErasureCoded(context.Background(), m, hosts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the pieces needed to reconstruct the data were downloaded: the
+	// corrupted piece and the next two.
+	var reads int
+	for _, h := range hosts {
+		reads += h.(*repairTestHost).reads
+	}
+	if reads != 3 {
+		t.Fatal("expected 3 downloads but got", reads)
+	}
+	used := make(map[string]struct{})
+	for i, pc := range repaired.Pieces {
+		if pc.MerkleRoot != m.Pieces[i].MerkleRoot {
+			t.Fatal("repaired piece doesn't match the original piece", i)
+		}
+		host, ok := hosts[pc.HostPubKey.String()]
+		if !ok {
+			t.Fatal("piece wasn't moved to a replacement host", i)
+		}
+		if _, ok := used[pc.HostPubKey.String()]; ok {
+			t.Fatal("two pieces are stored on the same host")
+		}
+		used[pc.HostPubKey.String()] = struct{}{}
+		_, piece, err := host.ReadSection(context.Background(), pc.MerkleRoot, 0, uint32(modules.SectorSize))
+		if err != nil || !bytes.Equal(piece, pieces[i]) {
+			t.Fatal("host doesn't store the piece", i, err)
+		}
+	}
+
+	// The data can't be repaired without enough replacement hosts or enough
+	// surviving pieces.
+	delete(hosts, m.Pieces[1].HostPubKey.String())
+	delete(hosts, repaired.Pieces[0].HostPubKey.String())
+	if _, err := RepairErasureCoded(context.Background(), repaired, hosts); !errors.Contains(err, errNotEnoughHosts) {
+		t.Fatal("expected errNotEnoughHosts but got", err)
+	}
+	delete(hosts, repaired.Pieces[1].HostPubKey.String())
+	delete(hosts, repaired.Pieces[2].HostPubKey.String())
+	if _, err := RepairErasureCoded(context.Background(), repaired, hosts); !errors.Contains(err, errNotEnoughPieces) {
+		t.Fatal("expected errNotEnoughPieces but got", err)
+	}
+}
@@ -0,0 +1,175 @@
+package renter
+
+// sectorcache.go implements a cache for the data read from hosts. The cache is
+// keyed by the merkle root of the sector the data was read from, so repeated
+// reads of the same data, such as the thumbnails of popular NFTs, don't have to
+// be paid for every time. Since sectors are content addressed and every read is
+// verified against the sector root before it is cached, cached data never goes
+// stale.
+//
+// The cache holds ranges of sectors and is limited by the total size of the
+// cached ranges. It is implemented as a doubly-linked list of ranges sorted by
+// how recently each range was used, together with a map from sector roots to
+// their cached ranges. When the cache is full the least recently used ranges
+// are evicted.
+
+import (
+	"sync"
+
+	"go.sia.tech/siad/crypto"
+)
+
+type (
+	// sectorCacheEntry is a cached range of a sector.
+	sectorCacheEntry struct {
+		root   crypto.Hash
+		offset uint64
+		data   []byte
+
+		prev *sectorCacheEntry
+		next *sectorCacheEntry
+	}
+
+	// sectorCache is an LRU cache for ranges of sectors.
+	sectorCache struct {
+		head    *sectorCacheEntry
+		tail    *sectorCacheEntry
+		entries map[crypto.Hash][]*sectorCacheEntry
+		size    uint64
+		maxSize uint64
+
+		mu sync.Mutex
+	}
+)
+
+// newSectorCache creates a sector cache which holds up to maxSize bytes. A
+// maxSize of 0 disables the cache.
+func newSectorCache(maxSize uint64) *sectorCache {
+	return &sectorCache{
+		entries: make(map[crypto.Hash][]*sectorCacheEntry),
+		maxSize: maxSize,
+	}
+}
+
+// covers returns true if the entry contains the range of the sector starting
+// at offset with the given length.
+func (e *sectorCacheEntry) covers(offset, length uint64) bool {
+	return e.offset <= offset && offset+length <= e.offset+uint64(len(e.data))
+}
+
+// callGet returns a copy of the cached range of the sector with the given root
+// starting at offset with the given length.
+func (sc *sectorCache) callGet(root crypto.Hash, offset, length uint64) ([]byte, bool) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	for _, e := range sc.entries[root] {
+		if e.covers(offset, length) {
+			sc.moveToFront(e)
+			start := offset - e.offset
+			return append([]byte(nil), e.data[start:start+length]...), true
+		}
+	}
+	return nil, false
+}
+
+// callAdd caches a copy of the range of the sector with the given root which
+// starts at offset. Ranges which are larger than the cache are not cached.
+func (sc *sectorCache) callAdd(root crypto.Hash, offset uint64, data []byte) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	length := uint64(len(data))
+	if length == 0 || length > sc.maxSize {
+		return
+	}
+	// Ranges which are already covered don't need to be cached again, and
+	// ranges covered by the new one can be dropped.
+	for _, e := range sc.entries[root] {
+		if e.covers(offset, length) {
+			sc.moveToFront(e)
+			return
+		}
+	}
+	e := &sectorCacheEntry{
+		root:   root,
+		offset: offset,
+		data:   append([]byte(nil), data...),
+	}
+	for _, old := range append([]*sectorCacheEntry(nil), sc.entries[root]...) {
+		if e.covers(old.offset, uint64(len(old.data))) {
+			sc.remove(old)
+		}
+	}
+	sc.insertHead(e)
+	sc.evict()
+}
+
+// callSetMaxSize changes the size of the cache, evicting ranges if the cache
+// shrinks.
+func (sc *sectorCache) callSetMaxSize(maxSize uint64) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.maxSize = maxSize
+	sc.evict()
+}
+
+// evict removes the least recently used ranges until the cache fits its
+// maximum size.
+func (sc *sectorCache) evict() {
+	for sc.size > sc.maxSize {
+		sc.remove(sc.tail)
+	}
+}
+
+// insertHead places a new entry at the head of the cache.
+func (sc *sectorCache) insertHead(e *sectorCacheEntry) {
+	sc.entries[e.root] = append(sc.entries[e.root], e)
+	sc.size += uint64(len(e.data))
+	e.prev = nil
+	e.next = sc.head
+	if sc.head != nil {
+		sc.head.prev = e
+	}
+	sc.head = e
+	if sc.tail == nil {
+		sc.tail = e
+	}
+}
+
+// remove removes an entry from the cache.
+func (sc *sectorCache) remove(e *sectorCacheEntry) {
+	if e.prev != nil {
+		e.prev.next = e.next
+	} else {
+		sc.head = e.next
+	}
+	if e.next != nil {
+		e.next.prev = e.prev
+	} else {
+		sc.tail = e.prev
+	}
+	e.prev, e.next = nil, nil
+	sc.size -= uint64(len(e.data))
+
+	entries := sc.entries[e.root]
+	for i := range entries {
+		if entries[i] == e {
+			entries = append(entries[:i], entries[i+1:]...)
+			break
+		}
+	}
+	if len(entries) == 0 {
+		delete(sc.entries, e.root)
+	} else {
+		sc.entries[e.root] = entries
+	}
+}
+
+// moveToFront moves an entry which is already in the cache to the head of the
+// cache.
+func (sc *sectorCache) moveToFront(e *sectorCacheEntry) {
+	if sc.head == e {
+		return
+	}
+	sc.remove(e)
+	sc.insertHead(e)
+}
@@ -0,0 +1,84 @@
+package renter
+
+import (
+	"bytes"
+	"testing"
+
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+)
+
+// TestSectorCache is a unit test for the sectorCache.
+func TestSectorCache(t *testing.T) {
+	sc := newSectorCache(100)
+	var root1, root2, root3 crypto.Hash
+	fastrand.Read(root1[:])
+	fastrand.Read(root2[:])
+	fastrand.Read(root3[:])
+	data := fastrand.Bytes(40)
+
+	// Ranges within a cached range are served from the cache.
+	sc.callAdd(root1, 10, data)
+	if _, ok := sc.callGet(root1, 0, 10); ok {
+		t.Fatal("uncached range shouldn't be found")
+	}
+	if _, ok := sc.callGet(root1, 40, 20); ok {
+		t.Fatal("partially cached range shouldn't be found")
+	}
+	if _, ok := sc.callGet(root2, 10, 40); ok {
+		t.Fatal("range of a different sector shouldn't be found")
+	}
+	got, ok := sc.callGet(root1, 20, 10)
+	if !ok || !bytes.Equal(got, data[10:20]) {
+		t.Fatal("cached range wasn't found", ok)
+	}
+
+	// Modifying data returned or added doesn't modify the cache.
+	got[0]++
+	data[10]++
+	if got, _ := sc.callGet(root1, 20, 10); got[0] == data[10] {
+		t.Fatal("cache shares memory with the caller")
+	}
+	data[10]--
+
+	// Adding a range covering cached ranges replaces them.
+	sc.callAdd(root1, 20, data[10:20])
+	sc.callAdd(root1, 0, fastrand.Bytes(60))
+	if len(sc.entries[root1]) != 1 || sc.size != 60 {
+		t.Fatal("covered ranges weren't replaced", len(sc.entries[root1]), sc.size)
+	}
+
+	// The least recently used ranges are evicted once the cache is full.
+	sc.callAdd(root2, 0, fastrand.Bytes(30))
+	if _, ok := sc.callGet(root1, 0, 60); !ok {
+		t.Fatal("range should still be cached")
+	}
+	sc.callAdd(root3, 0, fastrand.Bytes(30))
+	if _, ok := sc.callGet(root2, 0, 30); ok {
+		t.Fatal("least recently used range wasn't evicted")
+	}
+	if _, ok := sc.callGet(root1, 0, 60); !ok {
+		t.Fatal("recently used range was evicted")
+	}
+	if sc.size != 90 {
+		t.Fatal("unexpected size", sc.size)
+	}
+
+	// Ranges larger than the cache are not cached.
+	sc.callAdd(root2, 0, fastrand.Bytes(101))
+	if _, ok := sc.callGet(root2, 0, 101); ok {
+		t.Fatal("range larger than the cache was cached")
+	}
+
+	// Shrinking the cache evicts ranges and a size of zero disables it.
+	sc.callSetMaxSize(60)
+	if _, ok := sc.callGet(root3, 0, 30); ok || sc.size != 60 {
+		t.Fatal("cache wasn't shrunk", sc.size)
+	}
+	sc.callSetMaxSize(0)
+	sc.callAdd(root3, 0, fastrand.Bytes(30))
+	if sc.size != 0 || len(sc.entries) != 0 || sc.head != nil || sc.tail != nil {
+		t.Fatal("disabled cache isn't empty")
+	}
+}
@@ -60,9 +60,14 @@ func (r *Renter) Upload(up modules.FileUploadParams) error {
 		}
 	}
 
-	// Fill in any missing upload params with sensible defaults.
+	// Fill in any missing upload params with sensible defaults. The erasure
+	// code is chosen by the size of the file so that small files don't waste
+	// a full sector per data piece.
 	if up.ErasureCode == nil {
-		up.ErasureCode = modules.NewRSSubCodeDefault()
+		up.ErasureCode, err = modules.NewRSSubCodeForSize(uint64(sourceInfo.Size()), modules.RenterDefaultRedundancy)
+		if err != nil {
+			return errors.AddContext(err, "unable to choose erasure code for file")
+		}
 	}
 
 	// Check that we have contracts to upload to. We need at least data +
@@ -442,8 +442,9 @@ func (r *Renter) threadedFetchAndRepairChunk(chunk *unfinishedUploadChunk) {
 // staticEncryptAndCheckIntegrity will run through the pieces that are
 // presented, assumed to be already erasure coded. The integrity check will
 // perform the encryption on the pieces and then ensure that the result matches
-// any known roots for the renter.
-func (uc *unfinishedUploadChunk) staticEncryptAndCheckIntegrity() error {
+// any known roots for the renter. The roots of the pieces are looked up in the
+// provided cache first.
+func (uc *unfinishedUploadChunk) staticEncryptAndCheckIntegrity(cache *crypto.MerkleRootCache) error {
 	// Verify that all of the shards match the piece roots we are expecting. Use
 	// one thread per piece so that the verification is multicore.
 	var zeroHash crypto.Hash
@@ -471,7 +472,7 @@ func (uc *unfinishedUploadChunk) staticEncryptAndCheckIntegrity() error {
 			if uc.staticExpectedPieceRoots[i] == zeroHash {
 				return
 			}
-			root := crypto.MerkleRoot(uc.logicalChunkData[i])
+			root := cache.MerkleRoot(uc.logicalChunkData[i])
 			if root != uc.staticExpectedPieceRoots[i] {
 				failures[i] = true
 			}
@@ -518,7 +519,7 @@ func (r *Renter) staticFetchLogicalDataFromReader(uc *unfinishedUploadChunk) (er
 	}
 
 	// Perform an integrity check on the data that was pulled from the reader.
-	err = uc.staticEncryptAndCheckIntegrity()
+	err = uc.staticEncryptAndCheckIntegrity(r.staticMerkleRootCache)
 	if err != nil {
 		return errors.AddContext(err, "source data does not match previously uploaded data - blocking corrupt repair")
 	}
@@ -580,7 +581,7 @@ func (r *Renter) managedFetchLogicalChunkData(uc *unfinishedUploadChunk) error {
 			return errors.AddContext(err, "unable to read the data from the local file")
 		}
 		uc.logicalChunkData, _ = uc.fileEntry.ErasureCode().EncodeShards(dataPieces)
-		err = uc.staticEncryptAndCheckIntegrity()
+		err = uc.staticEncryptAndCheckIntegrity(r.staticMerkleRootCache)
 		if err != nil {
 			return errors.AddContext(err, "local file failed the integrity check")
 		}
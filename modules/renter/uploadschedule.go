@@ -0,0 +1,60 @@
+package renter
+
+import (
+	"time"
+)
+
+// uploadschedule.go enforces the renter's upload schedule by pausing the
+// uploads and repairs of the upload heap outside of the scheduled hours.
+
+// managedEnforceUploadSchedule pauses the uploads until the next start of the
+// upload schedule if the schedule doesn't allow uploads at now. If the uploads
+// were paused by an earlier call, whose pause ends at pauseEnd, and the
+// schedule allows uploads again, e.g. because it was disabled, the uploads are
+// resumed. Pauses requested by the user are left alone. The end of the pause
+// set by the schedule is returned.
+func (r *Renter) managedEnforceUploadSchedule(now, pauseEnd time.Time) time.Time {
+	id := r.mu.RLock()
+	schedule := r.persist.UploadSchedule
+	r.mu.RUnlock(id)
+
+	paused, endTime := r.uploadHeap.managedPauseStatus()
+	pausedBySchedule := paused && !pauseEnd.IsZero() && endTime.Equal(pauseEnd)
+	if schedule.Active(now) {
+		if pausedBySchedule {
+			r.log.Println("Resuming uploads, the upload schedule allows uploads again")
+			r.uploadHeap.managedResume()
+		}
+		return time.Time{}
+	}
+	if pausedBySchedule {
+		return pauseEnd
+	}
+	if paused {
+		// The user paused the uploads.
+		return time.Time{}
+	}
+	nextStart := schedule.NextStart(now)
+	r.log.Printf("Pausing uploads until %v per the upload schedule %v", nextStart, schedule)
+	r.uploadHeap.managedPause(nextStart.Sub(now))
+	_, endTime = r.uploadHeap.managedPauseStatus()
+	return endTime
+}
+
+// threadedEnforceUploadSchedule periodically enforces the upload schedule.
+func (r *Renter) threadedEnforceUploadSchedule() {
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+
+	var pauseEnd time.Time
+	for {
+		pauseEnd = r.managedEnforceUploadSchedule(time.Now(), pauseEnd)
+		select {
+		case <-time.After(uploadScheduleCheckInterval):
+		case <-r.tg.StopChan():
+			return
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package renter
+
+import (
+	"testing"
+	"time"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/siatest/dependencies"
+)
+
+// TestEnforceUploadSchedule tests that the upload schedule pauses the uploads
+// outside of the scheduled hours without interfering with pauses requested by
+// the user.
+func TestEnforceUploadSchedule(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Disable the background loops to enforce the schedule manually.
+	rt, err := newRenterTesterWithDependency(t.Name(), &dependencies.DependencyDisableRepairAndHealthLoops{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+	setSchedule := func(us modules.UploadSchedule) {
+		id := r.mu.Lock()
+		r.persist.UploadSchedule = us
+		r.mu.Unlock(id)
+	}
+	paused := func() bool {
+		p, _ := r.uploadHeap.managedPauseStatus()
+		return p
+	}
+	now := time.Now()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	night, noon := day.Add(23*time.Hour), day.Add(12*time.Hour)
+
+	// Without a schedule the uploads aren't paused.
+	if end := r.managedEnforceUploadSchedule(noon, time.Time{}); !end.IsZero() || paused() {
+		t.Fatal("uploads were paused without a schedule")
+	}
+
+	// Only upload at night.
+	setSchedule(modules.UploadSchedule{Start: 22, End: 6})
+	if end := r.managedEnforceUploadSchedule(night, time.Time{}); !end.IsZero() || paused() {
+		t.Fatal("uploads were paused within the schedule")
+	}
+	end := r.managedEnforceUploadSchedule(noon, time.Time{})
+	if end.IsZero() || !paused() {
+		t.Fatal("uploads weren't paused outside of the schedule")
+	}
+	if newEnd := r.managedEnforceUploadSchedule(noon, end); !newEnd.Equal(end) || !paused() {
+		t.Fatal("pause wasn't kept")
+	}
+
+	// Disabling the schedule resumes the uploads.
+	setSchedule(modules.UploadSchedule{})
+	if end := r.managedEnforceUploadSchedule(noon, end); !end.IsZero() || paused() {
+		t.Fatal("uploads weren't resumed")
+	}
+
+	// A pause requested by the user isn't lifted by the schedule.
+	if err := r.PauseRepairsAndUploads(time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if end := r.managedEnforceUploadSchedule(noon, end); !end.IsZero() || !paused() {
+		t.Fatal("user's pause was lifted")
+	}
+}
@@ -35,6 +35,16 @@ const (
 	// host to support the registry.
 	minRegistryVersion = "1.5.1"
 
+	// minMDMCostModelVersion defines the minimum version that is required for
+	// a host to publish its MDM cost model. The renter only verifies the costs
+	// quoted by hosts that are at least at this version.
+	minMDMCostModelVersion = "1.5.6"
+
+	// minProoflessReadVersion defines the minimum version that is required
+	// for a host to charge reads without a proof at a discount. Trusted reads
+	// from older hosts request a proof.
+	minProoflessReadVersion = "1.5.9"
+
 	// registryCacheSize is the cache size used by a single worker for the
 	// registry cache.
 	registryCacheSize = 1 << 20 // 1 MiB
@@ -188,6 +188,7 @@ func (j *jobRead) managedRead(w *worker, program modules.Program, programData []
 	if uint64(len(sectorData)) != j.staticLength {
 		return []programResponse{}, errors.New("worker returned the wrong amount of data")
 	}
+	downloadBytesMetric.Add(uint64(len(sectorData)))
 	return responses, nil
 }
 
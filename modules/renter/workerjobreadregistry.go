@@ -59,9 +59,15 @@ type (
 // containing a signed registry value.
 func parseSignedRegistryValueResponse(resp []byte, needPKAndTweak bool, version modules.ReadRegistryVersion) (spk types.SiaPublicKey, tweak crypto.Hash, data []byte, rev uint64, sig crypto.Signature, rrv modules.RegistryEntryType, err error) {
 	dec := encoding.NewDecoder(bytes.NewReader(resp), encoding.DefaultAllocLimit)
-	if needPKAndTweak {
+	// Reads without a proof don't return the signature.
+	switch {
+	case needPKAndTweak && version == modules.ReadRegistryVersionNoProof:
+		err = dec.DecodeAll(&spk, &tweak, &rev)
+	case needPKAndTweak:
 		err = dec.DecodeAll(&spk, &tweak, &sig, &rev)
-	} else {
+	case version == modules.ReadRegistryVersionNoProof:
+		err = dec.DecodeAll(&rev)
+	default:
 		err = dec.DecodeAll(&sig, &rev)
 	}
 	if err != nil {
@@ -71,7 +77,7 @@ func parseSignedRegistryValueResponse(resp []byte, needPKAndTweak bool, version
 
 	// Last byte might be the entry type.
 	rrv = modules.RegistryTypeWithoutPubkey
-	if version == modules.ReadRegistryVersionWithType {
+	if version == modules.ReadRegistryVersionWithType || version == modules.ReadRegistryVersionNoProof {
 		if len(data) < 1 {
 			err = errors.New("parsing the registry value failed - not enough data to contain entry type")
 			return
@@ -89,12 +95,16 @@ func lookupRegistry(w *worker, spk types.SiaPublicKey, tweak crypto.Hash) (*modu
 	pb := modules.NewProgramBuilder(&pt, 0) // 0 duration since ReadRegistry doesn't depend on it.
 	var refund types.Currency
 	var err error
+	// Hosts which return the type of the entry allow for looking up typed
+	// entries since the type is part of their signature.
+	version := modules.ReadRegistryVersionNoType
 	if build.VersionCmp(w.staticCache().staticHostVersion, "1.5.5") < 0 {
 		refund, err = pb.V154AddReadRegistryInstruction(spk, tweak)
 	} else if build.VersionCmp(w.staticCache().staticHostVersion, "1.5.6") < 0 {
 		refund, err = pb.V156AddReadRegistryInstruction(spk, tweak)
 	} else {
-		refund, err = pb.AddReadRegistryInstruction(spk, tweak, modules.ReadRegistryVersionNoType)
+		version = modules.ReadRegistryVersionWithType
+		refund, err = pb.AddReadRegistryInstruction(spk, tweak, version)
 	}
 	if err != nil {
 		return nil, errors.AddContext(err, "Unable to add read registry instruction")
@@ -132,7 +142,7 @@ func lookupRegistry(w *worker, spk types.SiaPublicKey, tweak crypto.Hash) (*modu
 	}
 
 	// Parse response.
-	_, _, data, revision, sig, entryType, err := parseSignedRegistryValueResponse(resp.Output, false, modules.ReadRegistryVersionNoType)
+	_, _, data, revision, sig, entryType, err := parseSignedRegistryValueResponse(resp.Output, false, version)
 	if err != nil {
 		return nil, errors.AddContext(err, "failed to parse signed revision response")
 	}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 )
@@ -16,27 +17,57 @@ type (
 
 		staticOffset uint64
 		staticSector crypto.Hash
+
+		// staticTrusted indicates that the data is read without a proof if
+		// the host supports it. The data isn't verified in that case.
+		staticTrusted bool
 	}
 )
 
-// callExecute executes the jobReadSector.
+// callExecute executes the jobReadSector. The proof of the downloaded data is
+// verified by the renter's proof verifier, which finishes the execution. That
+// way the worker can launch its next job while the proof is verified. The job
+// time only covers the download.
 func (j *jobReadSector) callExecute() {
 	// Track how long the job takes.
 	start := time.Now()
-	data, err := j.managedReadSector()
+	data, proof, cached, proofless, err := j.managedReadSector()
 	jobTime := time.Since(start)
+	if err != nil || cached || proofless {
+		j.jobRead.managedFinishExecute(data, err, jobTime)
+		return
+	}
 
-	// Finish the execution.
-	j.jobRead.managedFinishExecute(data, err, jobTime)
+	// Verify the proof and finish the execution.
+	w := j.staticQueue.staticWorker()
+	w.renter.staticProofVerifier.callVerify(func() {
+		err := j.staticVerifyProof(data, proof)
+		if err != nil {
+			data = nil
+		} else {
+			w.renter.staticSectorCache.callAdd(j.staticSector, j.staticOffset, data)
+		}
+		j.jobRead.managedFinishExecute(data, err, jobTime)
+	})
 }
 
-// managedReadSector returns the sector data for given root.
-func (j *jobReadSector) managedReadSector() ([]byte, error) {
-	// create the program
+// managedReadSector returns the sector data for given root and the proof for
+// the data. Data which was read before is served from the renter's sector
+// cache, in which case cached is true and there is no proof to verify.
+// Trusted reads from hosts which discount reads without a proof don't request
+// a proof, in which case proofless is true.
+func (j *jobReadSector) managedReadSector() (data []byte, proof []crypto.Hash, cached, proofless bool, err error) {
 	w := j.staticQueue.staticWorker()
+	cache := w.renter.staticSectorCache
+	if data, ok := cache.callGet(j.staticSector, j.staticOffset, j.staticLength); ok {
+		return data, nil, true, false, nil
+	}
+	proofless = j.staticTrusted && build.VersionCmp(w.staticCache().staticHostVersion, minProoflessReadVersion) >= 0
+
+	// create the program
 	pt := w.staticPriceTable().staticPriceTable
 	pb := modules.NewProgramBuilder(&pt, 0) // 0 duration since ReadSector doesn't depend on it.
-	pb.AddReadSectorInstruction(j.staticLength, j.staticOffset, j.staticSector, true)
+	pb.AddReadSectorInstruction(j.staticLength, j.staticOffset, j.staticSector, !proofless)
 	program, programData := pb.Program()
 	cost, _, _ := pb.Cost(true)
 
@@ -47,18 +78,19 @@ func (j *jobReadSector) managedReadSector() ([]byte, error) {
 
 	responses, err := j.jobRead.managedRead(w, program, programData, cost)
 	if err != nil {
-		return nil, errors.AddContext(err, "jobReadSector: failed to execute managedRead")
+		return nil, nil, false, false, errors.AddContext(err, "jobReadSector: failed to execute managedRead")
 	}
-	data := responses[0].Output
-	proof := responses[0].Proof
+	return responses[0].Output, responses[0].Proof, false, proofless, nil
+}
 
-	// verify proof
+// staticVerifyProof verifies the proof for the data read by the job.
+func (j *jobReadSector) staticVerifyProof(data []byte, proof []crypto.Hash) error {
 	proofStart := int(j.staticOffset) / crypto.SegmentSize
 	proofEnd := int(j.staticOffset+j.staticLength) / crypto.SegmentSize
 	if !crypto.VerifyRangeProof(data, proof, proofStart, proofEnd, j.staticSector) {
-		return nil, errors.New("proof verification failed")
+		return errors.New("proof verification failed")
 	}
-	return data, nil
+	return nil
 }
 
 // newJobReadSector creates a new read sector job.
@@ -120,6 +152,30 @@ func (w *worker) ReadSector(ctx context.Context, category spendingCategory, root
 	return resp.staticData, resp.staticErr
 }
 
+// ReadSectorTrusted is a helper method to run a ReadSector job on a worker
+// which doesn't request a proof for the data if the host supports discounted
+// reads without a proof. It is meant for renters which trust the host or
+// verify the data out of band, since the data isn't verified and not cached.
+func (w *worker) ReadSectorTrusted(ctx context.Context, category spendingCategory, root crypto.Hash, offset, length uint64) ([]byte, error) {
+	readSectorRespChan := make(chan *jobReadResponse)
+	jro := w.newJobReadSector(ctx, w.staticJobReadQueue, readSectorRespChan, category, root, offset, length)
+	jro.staticTrusted = true
+
+	// Add the job to the queue.
+	if !w.staticJobReadQueue.callAdd(jro) {
+		return nil, errors.New("worker unavailable")
+	}
+
+	// Wait for the response.
+	var resp *jobReadResponse
+	select {
+	case <-ctx.Done():
+		return nil, errors.New("Read interrupted")
+	case resp = <-readSectorRespChan:
+	}
+	return resp.staticData, resp.staticErr
+}
+
 // readSectorJobExpectedBandwidth is a helper function that returns the expected
 // bandwidth consumption of a read sector job. This helper function takes a
 // length parameter and is used to get the expected bandwidth without having to
@@ -165,7 +165,11 @@ func (j *jobUploadSnapshot) callExecute() {
 	}()
 
 	allowance := w.renter.hostContractor.Allowance()
-	hostSettings := sess.HostSettings()
+	hostSettings, err := sess.Prices()
+	if err != nil {
+		err = errors.AddContext(err, "unable to get the host's prices")
+		return
+	}
 	err = checkUploadSnapshotGouging(allowance, hostSettings)
 	if err != nil {
 		err = errors.AddContext(err, "snapshot upload blocked because potential price gouging was detected")
@@ -256,13 +260,11 @@ func (r *Renter) managedUploadSnapshotHost(meta modules.UploadedBackup, dotSia [
 		CreationDate: meta.CreationDate,
 		Size:         meta.Size,
 	}
-	for j, piece := range sectors {
-		root, err := host.Upload(piece)
-		if err != nil {
-			return errors.AddContext(err, "could not perform host upload")
-		}
-		entry.DataSectors[j] = root
+	roots, err := host.UploadStream(sectors)
+	if err != nil {
+		return errors.AddContext(err, "could not perform host upload")
 	}
+	copy(entry.DataSectors[:], roots)
 
 	shouldOverwrite := len(entryTable) != 0 // only overwrite if the sector already contained an entryTable
 	entryTable = append(entryTable, entry)
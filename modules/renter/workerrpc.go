@@ -157,9 +157,31 @@ func (w *worker) managedExecuteProgram(p modules.Program, data []byte, fcid type
 			break
 		}
 	}
+
+	// Verify the costs the host quoted against its cost model. Only read-only
+	// programs are verified since the cost of write programs depends on the
+	// duration the sectors are stored for.
+	if p.ReadOnly() && build.VersionCmp(w.staticCache().staticHostVersion, minMDMCostModelVersion) >= 0 {
+		err = staticVerifyProgramCosts(pt, p, data, responses)
+	}
 	return
 }
 
+// staticVerifyProgramCosts verifies the costs quoted in the responses of a
+// program against the MDM cost model of the price table the program was
+// executed with.
+func staticVerifyProgramCosts(pt modules.RPCPriceTable, p modules.Program, data []byte, responses []programResponse) error {
+	expected, err := modules.NewMDMCostModel(&pt).ProgramCosts(p, data, 0)
+	if err != nil {
+		return errors.AddContext(err, "failed to compute expected program costs")
+	}
+	quoted := make([]modules.RPCExecuteProgramResponse, 0, len(responses))
+	for _, resp := range responses {
+		quoted = append(quoted, resp.RPCExecuteProgramResponse)
+	}
+	return modules.VerifyMDMProgramCosts(p, expected, quoted)
+}
+
 // staticNewStream returns a new stream to the worker's host
 func (w *worker) staticNewStream() (siamux.Stream, error) {
 	// If disrupt is called we sleep for the specified 'defaultNewStreamTimeout'
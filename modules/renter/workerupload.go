@@ -239,6 +239,7 @@ func (w *worker) managedPerformUploadChunkJob() {
 	uc.chunkSuccessProcessTimes = append(uc.chunkSuccessProcessTimes, time.Now())
 	uc.mu.Unlock()
 	uc.staticMemoryManager.Return(uint64(releaseSize))
+	uploadBytesMetric.Add(uint64(releaseSize))
 	w.renter.managedCleanUpUploadChunk(uc)
 }
 
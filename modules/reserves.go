@@ -0,0 +1,49 @@
+package modules
+
+import (
+	"fmt"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// ErrProofOfReservesUnknownBlock is returned if the block of a proof of
+	// reserves isn't part of the current blockchain.
+	ErrProofOfReservesUnknownBlock = errors.New("proof of reserves block is not in the current blockchain")
+
+	// ErrProofOfReservesCustodyMismatch is returned if the custody index
+	// doesn't assign an NFT of a proof of reserves to the listed address.
+	ErrProofOfReservesCustodyMismatch = errors.New("nft is not held by the address listed in the proof of reserves")
+
+	// ErrProofOfReservesSpentOutput is returned if an output of a proof of
+	// reserves isn't an unspent output of the consensus set.
+	ErrProofOfReservesSpentOutput = errors.New("proof of reserves output is not unspent")
+)
+
+// CheckProofOfReserves verifies a proof of reserves and checks its holdings
+// against the custody index and the unspent outputs of the consensus set. The
+// consensus set only knows the current holdings, so a proof fails the check
+// once one of its NFTs or outputs moves after the proof's block.
+func CheckProofOfReserves(cs ConsensusSet, p types.ProofOfReserves) error {
+	if err := types.VerifyProofOfReserves(p); err != nil {
+		return err
+	}
+	if b, exists := cs.BlockAtHeight(p.BlockHeight); !exists || b.ID() != p.BlockID {
+		return ErrProofOfReservesUnknownBlock
+	}
+	for _, held := range p.NFTs {
+		custody, err := cs.ViewNFTCustody(held.NFT)
+		if err != nil || custody.UnlockHash != held.Address {
+			return errors.AddContext(errors.Compose(ErrProofOfReservesCustodyMismatch, err), fmt.Sprintf("nft %v", held.NFT.Identifier()))
+		}
+	}
+	for _, held := range p.Outputs {
+		sco, err := cs.ViewSiacoinOutput(held.ID)
+		if err != nil || sco.UnlockHash != held.Output.UnlockHash || !sco.Value.Equals(held.Output.Value) {
+			return errors.AddContext(errors.Compose(ErrProofOfReservesSpentOutput, err), fmt.Sprintf("output %v", held.ID))
+		}
+	}
+	return nil
+}
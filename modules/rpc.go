@@ -194,6 +194,33 @@ var (
 
 	// RPCRenewContract specifier
 	RPCRenewContract = types.NewSpecifier("RenewContract")
+
+	// RPCMDMCostModel specifier
+	RPCMDMCostModel = types.NewSpecifier("MDMCostModel")
+
+	// RPCFreeRegistryRead specifier
+	RPCFreeRegistryRead = types.NewSpecifier("FreeRegistryRead")
+
+	// RPCAuditSector specifier
+	RPCAuditSector = types.NewSpecifier("AuditSector")
+)
+
+var (
+	// ErrFreeRegistryReadsDisabled is returned by hosts which don't serve
+	// registry reads without payment.
+	ErrFreeRegistryReadsDisabled = errors.New("host doesn't offer free registry reads")
+
+	// ErrFreeRegistryReadLimit is returned by hosts if a client exceeded the
+	// number of free registry reads it may perform per minute.
+	ErrFreeRegistryReadLimit = errors.New("free registry read limit exceeded")
+
+	// ErrContractBandwidthLimit is returned by hosts if the renter of a
+	// contract used up the bandwidth the host allows per contract and hour.
+	ErrContractBandwidthLimit = errors.New("contract bandwidth limit exceeded")
+
+	// ErrAuditSegmentOutOfBounds is returned by hosts if an audit requests a
+	// segment beyond the end of a sector.
+	ErrAuditSegmentOutOfBounds = errors.New("audited segment is out of bounds")
 )
 
 type (
@@ -222,6 +249,35 @@ type (
 		Signature crypto.Signature
 	}
 
+	// RPCAuditSectorRequest is the request sent by an auditor to spot-check
+	// that a host stores a sector. The auditor picks the segment the host has
+	// to prove.
+	RPCAuditSectorRequest struct {
+		Root         crypto.Hash
+		SegmentIndex uint64
+	}
+
+	// RPCAuditSectorResponse contains the audited segment of the sector and
+	// the Merkle proof of the segment.
+	RPCAuditSectorResponse struct {
+		Segment []byte
+		Proof   []crypto.Hash
+	}
+
+	// RPCFreeRegistryReadRequest is the request sent by a client to read a
+	// registry entry without payment.
+	RPCFreeRegistryReadRequest struct {
+		PubKey types.SiaPublicKey
+		Tweak  crypto.Hash
+	}
+
+	// RPCFreeRegistryReadResponse is the response to a free registry read.
+	// Entry is only set if the host found the entry.
+	RPCFreeRegistryReadResponse struct {
+		Found bool
+		Entry SignedRegistryValue
+	}
+
 	// RPCExecuteProgramRequest is the request sent by the renter to execute a
 	// program on the host's MDM.
 	RPCExecuteProgramRequest struct {
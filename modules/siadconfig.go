@@ -1,12 +1,15 @@
 package modules
 
 import (
+	"crypto/subtle"
 	"errors"
 	"os"
+	"strings"
 	"sync"
 
 	"gitlab.com/NebulousLabs/ratelimit"
 
+	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/persist"
 )
 
@@ -19,6 +22,15 @@ type (
 		WriteBPS           int64  `json:"writebps"`
 		PacketSize         uint64 `json:"packetsize"`
 
+		// APIKeys are the keys which can be used in place of the API
+		// password.
+		APIKeys []APIKey `json:"apikeys"`
+
+		// APIRateLimits limits the number of requests per minute a single
+		// client may send to the API. The keys are path prefixes and the
+		// longest prefix matching a request applies.
+		APIRateLimits map[string]uint64 `json:"apiratelimits"`
+
 		// path of config on disk.
 		path string
 		mu   sync.Mutex
@@ -37,6 +49,28 @@ var (
 
 	// ConfigName is the name of the config file on disk
 	ConfigName = "siad.config"
+
+	// DefaultAPIRateLimits are the API rate limits of a new config. They
+	// cover the endpoints which are expensive to serve.
+	DefaultAPIRateLimits = build.Select(build.Var{
+		Standard: map[string]uint64{
+			"/renter/contracts":      60,
+			"/renter/download/":      60,
+			"/renter/downloadasync/": 60,
+			"/wallet/nft/audit":      60,
+			"/wallet/nft/provenance": 60,
+			"/wallet/transactions":   120,
+		},
+		Dev: map[string]uint64{
+			"/renter/contracts":      60,
+			"/renter/download/":      60,
+			"/renter/downloadasync/": 60,
+			"/wallet/nft/audit":      60,
+			"/wallet/nft/provenance": 60,
+			"/wallet/transactions":   120,
+		},
+		Testing: map[string]uint64{},
+	}).(map[string]uint64)
 )
 
 // SetRatelimit sets the ratelimit related fields in the config and persists it
@@ -59,6 +93,119 @@ func (cfg *SiadConfig) SetRatelimit(readBPS, writeBPS int64) error {
 	return cfg.save()
 }
 
+// AddAPIKey creates a new API key with the given name and scopes and persists
+// it to disk.
+func (cfg *SiadConfig) AddAPIKey(name string, scopes []APIKeyScope) (APIKey, error) {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	for _, k := range cfg.APIKeys {
+		if k.Name == name {
+			return APIKey{}, errDuplicateAPIKeyName
+		}
+	}
+	key, err := NewAPIKey(name, scopes)
+	if err != nil {
+		return APIKey{}, err
+	}
+	cfg.APIKeys = append(cfg.APIKeys, key)
+	if err := cfg.save(); err != nil {
+		cfg.APIKeys = cfg.APIKeys[:len(cfg.APIKeys)-1]
+		return APIKey{}, err
+	}
+	return key, nil
+}
+
+// RemoveAPIKey removes the API key with the given name and persists the change
+// to disk.
+func (cfg *SiadConfig) RemoveAPIKey(name string) error {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	for i, k := range cfg.APIKeys {
+		if k.Name != name {
+			continue
+		}
+		keys := append(append([]APIKey(nil), cfg.APIKeys[:i]...), cfg.APIKeys[i+1:]...)
+		old := cfg.APIKeys
+		cfg.APIKeys = keys
+		if err := cfg.save(); err != nil {
+			cfg.APIKeys = old
+			return err
+		}
+		return nil
+	}
+	return ErrUnknownAPIKey
+}
+
+// APIKey returns the API key matching the given secret.
+func (cfg *SiadConfig) APIKey(secret string) (APIKey, bool) {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	for _, k := range cfg.APIKeys {
+		if subtle.ConstantTimeCompare([]byte(k.Key), []byte(secret)) == 1 {
+			return k, true
+		}
+	}
+	return APIKey{}, false
+}
+
+// APIKeyList returns a copy of all API keys.
+func (cfg *SiadConfig) APIKeyList() []APIKey {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	return append([]APIKey(nil), cfg.APIKeys...)
+}
+
+// SetAPIRateLimit sets the number of requests per minute a single client may
+// send to the endpoints starting with the given prefix and persists it to disk.
+// A limit of 0 removes the rate limit of the prefix.
+func (cfg *SiadConfig) SetAPIRateLimit(prefix string, limit uint64) error {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	if !strings.HasPrefix(prefix, "/") {
+		return errors.New("rate limited path must start with '/'")
+	}
+	old := cfg.APIRateLimits
+	limits := make(map[string]uint64, len(old)+1)
+	for p, l := range old {
+		limits[p] = l
+	}
+	if limit == 0 {
+		delete(limits, prefix)
+	} else {
+		limits[prefix] = limit
+	}
+	cfg.APIRateLimits = limits
+	if err := cfg.save(); err != nil {
+		cfg.APIRateLimits = old
+		return err
+	}
+	return nil
+}
+
+// APIRateLimit returns the rate limit which applies to the given path and the
+// prefix it was configured for.
+func (cfg *SiadConfig) APIRateLimit(path string) (prefix string, limit uint64, ok bool) {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	for p, l := range cfg.APIRateLimits {
+		if strings.HasPrefix(path, p) && len(p) > len(prefix) {
+			prefix, limit, ok = p, l, true
+		}
+	}
+	return
+}
+
+// APIRateLimitList returns a copy of all API rate limits.
+func (cfg *SiadConfig) APIRateLimitList() map[string]uint64 {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	limits := make(map[string]uint64, len(cfg.APIRateLimits))
+	for p, l := range cfg.APIRateLimits {
+		limits[p] = l
+	}
+	return limits
+}
+
 // save saves the config to disk.
 func (cfg *SiadConfig) save() error {
 	return persist.SaveJSON(configMetadata, cfg, cfg.path)
@@ -97,6 +244,14 @@ func NewConfig(path string) (*SiadConfig, error) {
 		cfg.WriteBPS = 0   // unlimited
 		cfg.PacketSize = 0 // unlimited
 	}
+	// Configs created before the API rate limits were added get the default
+	// limits.
+	if cfg.APIRateLimits == nil {
+		cfg.APIRateLimits = make(map[string]uint64, len(DefaultAPIRateLimits))
+		for p, l := range DefaultAPIRateLimits {
+			cfg.APIRateLimits[p] = l
+		}
+	}
 	// Init the global ratelimit.
 	GlobalRateLimits.SetLimits(cfg.ReadBPS, cfg.WriteBPS, cfg.PacketSize)
 	return &cfg, nil
@@ -97,3 +97,126 @@ func checkWriteBPS(sc *SiadConfig, writeBPS int64) error {
 	}
 	return nil
 }
+
+// TestSiadConfigAPIKeys tests adding, persisting and removing API keys.
+func TestSiadConfigAPIKeys(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	testDir := build.TempDir("siadconfig", t.Name())
+	if err := os.MkdirAll(testDir, persist.DefaultDiskPermissionsTest); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(testDir, ConfigName)
+	sc, err := NewConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Invalid keys are rejected.
+	if _, err := sc.AddAPIKey("", []APIKeyScope{APIKeyScopeReadOnly}); err != errEmptyAPIKeyName {
+		t.Fatal("expected errEmptyAPIKeyName but got", err)
+	}
+	if _, err := sc.AddAPIKey("gallery", nil); err != errNoAPIKeyScopes {
+		t.Fatal("expected errNoAPIKeyScopes but got", err)
+	}
+	if _, err := sc.AddAPIKey("gallery", []APIKeyScope{"admin"}); err == nil {
+		t.Fatal("expected unknown scope to be rejected")
+	}
+
+	// Add a key and make sure the name can't be reused.
+	key, err := sc.AddAPIKey("gallery", []APIKeyScope{APIKeyScopeReadOnly})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !key.HasScope(APIKeyScopeReadOnly) || key.HasScope(APIKeyScopeWalletSpend) {
+		t.Fatal("key has wrong scopes", key.Scopes)
+	}
+	if _, err := sc.AddAPIKey("gallery", []APIKeyScope{APIKeyScopeReadOnly}); err != errDuplicateAPIKeyName {
+		t.Fatal("expected errDuplicateAPIKeyName but got", err)
+	}
+
+	// The key survives a reload.
+	sc, err = NewConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k, ok := sc.APIKey(key.Key); !ok || k.Name != key.Name {
+		t.Fatal("key wasn't persisted")
+	}
+
+	// Remove the key.
+	if err := sc.RemoveAPIKey(key.Name); err != nil {
+		t.Fatal(err)
+	}
+	if err := sc.RemoveAPIKey(key.Name); err != ErrUnknownAPIKey {
+		t.Fatal("expected ErrUnknownAPIKey but got", err)
+	}
+	sc, err = NewConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := sc.APIKey(key.Key); ok || len(sc.APIKeyList()) != 0 {
+		t.Fatal("key wasn't removed")
+	}
+}
+
+// TestSiadConfigAPIRateLimits tests setting and persisting API rate limits.
+func TestSiadConfigAPIRateLimits(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	testDir := build.TempDir("siadconfig", t.Name())
+	if err := os.MkdirAll(testDir, persist.DefaultDiskPermissionsTest); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(testDir, ConfigName)
+	sc, err := NewConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sc.APIRateLimitList()) != len(DefaultAPIRateLimits) {
+		t.Fatal("new config should have the default limits")
+	}
+
+	// Paths need to be absolute.
+	if err := sc.SetAPIRateLimit("wallet", 10); err == nil {
+		t.Fatal("expected relative path to be rejected")
+	}
+
+	// The longest matching prefix applies.
+	if err := sc.SetAPIRateLimit("/wallet", 100); err != nil {
+		t.Fatal(err)
+	}
+	if err := sc.SetAPIRateLimit("/wallet/nft/", 10); err != nil {
+		t.Fatal(err)
+	}
+	if prefix, limit, ok := sc.APIRateLimit("/wallet/nft/scan"); !ok || prefix != "/wallet/nft/" || limit != 10 {
+		t.Fatal("wrong limit", prefix, limit, ok)
+	}
+	if prefix, limit, ok := sc.APIRateLimit("/wallet/siacoins"); !ok || prefix != "/wallet" || limit != 100 {
+		t.Fatal("wrong limit", prefix, limit, ok)
+	}
+	if _, _, ok := sc.APIRateLimit("/renter/files"); ok {
+		t.Fatal("path shouldn't be limited")
+	}
+
+	// Removing a limit survives a reload.
+	if err := sc.SetAPIRateLimit("/wallet", 0); err != nil {
+		t.Fatal(err)
+	}
+	sc, err = NewConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, ok := sc.APIRateLimit("/wallet/siacoins"); ok {
+		t.Fatal("limit wasn't removed")
+	}
+	if _, limit, _ := sc.APIRateLimit("/wallet/nft/scan"); limit != 10 {
+		t.Fatal("limit wasn't persisted", limit)
+	}
+}
@@ -55,6 +55,10 @@ var (
 
 	// UserFolder is the Sia folder that is used to store the renter's siafiles.
 	UserFolder = NewGlobalSiaPath("/home/user")
+
+	// NFTPinFolder is the Sia folder where the siafiles of the data of pinned
+	// NFTs are stored.
+	NFTPinFolder = NewGlobalSiaPath("/nftpins")
 )
 
 type (
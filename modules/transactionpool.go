@@ -39,6 +39,10 @@ var (
 	// IsStandard rules.
 	ErrLargeTransaction = errors.New("transaction is too large for this transaction pool")
 
+	// ErrNFTRelayPolicy is the error that gets returned if a peer relays an
+	// NFT transaction set that doesn't satisfy the node's relay policy.
+	ErrNFTRelayPolicy = errors.New("nft transaction set declined by relay policy")
+
 	// ErrLargeTransactionSet is the error that gets returned if a transaction
 	// set given to the transaction pool is larger than the limit placed by the
 	// IsStandard rules of the transaction pool.
@@ -75,6 +79,20 @@ type (
 		RevertedTransactions []TransactionSetID
 	}
 
+	// TPoolRelayPolicy describes which NFT transaction sets received from
+	// peers the transaction pool is willing to accept and relay. A zero value
+	// disables the corresponding check. Transaction sets submitted locally are
+	// never filtered.
+	TPoolRelayPolicy struct {
+		// MinNFTFeePerByte is the minimum miner fee per byte an NFT
+		// transaction set has to pay.
+		MinNFTFeePerByte types.Currency `json:"minnftfeeperbyte"`
+
+		// MaxNFTArbitraryDataSize is the maximum number of bytes of arbitrary
+		// data an NFT transaction set may contain.
+		MaxNFTArbitraryDataSize uint64 `json:"maxnftarbitrarydatasize"`
+	}
+
 	// UnconfirmedTransactionSet defines a new unconfirmed transaction that has
 	// been added to the transaction pool. ID is the ID of the set, IDs contains
 	// an ID for each transaction, eliminating the need to recompute it (because
@@ -130,6 +148,14 @@ type (
 		// that make this condition necessary.
 		PurgeTransactionPool()
 
+		// RelayPolicy returns the policy used to filter NFT transaction sets
+		// received from peers.
+		RelayPolicy() TPoolRelayPolicy
+
+		// SetRelayPolicy sets and persists the policy used to filter NFT
+		// transaction sets received from peers.
+		SetRelayPolicy(TPoolRelayPolicy) error
+
 		// Transaction returns the transaction and unconfirmed parents
 		// corresponding to the provided transaction id.
 		Transaction(id types.TransactionID) (txn types.Transaction, unconfirmedParents []types.Transaction, exists bool)
@@ -385,6 +385,14 @@ func (tp *TransactionPool) AcceptTransactionSet(ts []types.Transaction) error {
 		return err
 	}
 	defer tp.tg.Done()
+	return tp.managedAcceptTransactionSet(ts, true)
+}
+
+// managedAcceptTransactionSet adds a transaction set which was either
+// submitted locally or relayed by a peer to the unconfirmed set of
+// transactions and relays it to connected peers. Accepted sets which should
+// survive a restart are persisted.
+func (tp *TransactionPool) managedAcceptTransactionSet(ts []types.Transaction, local bool) error {
 
 	// Drop the transaction set and return ErrTxnSetNotAccepted
 	if tp.deps.Disrupt("DoNotAcceptTxnSet") {
@@ -400,6 +408,9 @@ func (tp *TransactionPool) AcceptTransactionSet(ts []types.Transaction) error {
 		tp.log.Debugln("Transaction set will not be broadcast due to an error:", err)
 		return err
 	}
+	if isPendingTransactionSet(minSuperSet, local) {
+		tp.managedPersistPendingTransactionSet(minSuperSet)
+	}
 	go tp.gateway.Broadcast("RelayTransactionSet", minSuperSet, tp.gateway.Peers())
 	tp.log.Debugln("Transaction set broadcast appears to have succeeded")
 	return nil
@@ -436,5 +447,11 @@ func (tp *TransactionPool) relayTransactionSet(conn modules.PeerConn) error {
 	if err != nil {
 		return err
 	}
-	return tp.AcceptTransactionSet(ts)
+	// Decline NFT transaction sets that don't satisfy the relay policy.
+	err = tp.managedCheckRelayPolicy(ts)
+	if err != nil {
+		tp.log.Debugln("Transaction set declined by relay policy:", err)
+		return err
+	}
+	return tp.managedAcceptTransactionSet(ts, false)
 }
@@ -30,6 +30,15 @@ var (
 	// median.
 	bucketFeeMedian = []byte("FeeMedian")
 
+	// bucketPendingTransactionSets holds the unconfirmed transaction sets
+	// which are resubmitted when the transaction pool restarts, keyed by the
+	// hash of the set.
+	bucketPendingTransactionSets = []byte("PendingTransactionSets")
+
+	// bucketRelayPolicy stores the policy used to filter NFT transaction sets
+	// relayed by peers.
+	bucketRelayPolicy = []byte("RelayPolicy")
+
 	// bucketRecentConsensusChange holds the most recent consensus change seen
 	// by the transaction pool.
 	bucketRecentConsensusChange = []byte("RecentConsensusChange")
@@ -45,6 +54,10 @@ var (
 	// field.
 	fieldFeeMedian = []byte("FeeMedian")
 
+	// fieldRelayPolicy is the field in bucketRelayPolicy that holds the relay
+	// policy.
+	fieldRelayPolicy = []byte("RelayPolicy")
+
 	// fieldRecentBlockID is used to store the id of the most recent block seen
 	// by the transaction pool.
 	fieldRecentBlockID = []byte("RecentBlockID")
@@ -85,6 +98,12 @@ func (tp *TransactionPool) deleteTransaction(tx *bolt.Tx, id types.TransactionID
 	return tx.Bucket(bucketConfirmedTransactions).Delete(id[:])
 }
 
+// deletePendingTransactionSet removes a transaction set from the pending
+// transaction sets.
+func (tp *TransactionPool) deletePendingTransactionSet(tx *bolt.Tx, id modules.TransactionSetID) error {
+	return tx.Bucket(bucketPendingTransactionSets).Delete(id[:])
+}
+
 // getBlockHeight returns the most recent block height from the database.
 func (tp *TransactionPool) getBlockHeight(tx *bolt.Tx) (bh types.BlockHeight, err error) {
 	err = encoding.Unmarshal(tx.Bucket(bucketBlockHeight).Get(fieldBlockHeight), &bh)
@@ -106,6 +125,37 @@ func (tp *TransactionPool) getFeeMedian(tx *bolt.Tx) (medianPersist, error) {
 	return mp, nil
 }
 
+// getRelayPolicy returns the relay policy stored in the database. The zero
+// policy is returned if none was stored yet.
+func (tp *TransactionPool) getRelayPolicy(tx *bolt.Tx) (rp modules.TPoolRelayPolicy, err error) {
+	policyBytes := tx.Bucket(bucketRelayPolicy).Get(fieldRelayPolicy)
+	if policyBytes == nil {
+		return modules.TPoolRelayPolicy{}, nil
+	}
+	err = json.Unmarshal(policyBytes, &rp)
+	if err != nil {
+		return modules.TPoolRelayPolicy{}, build.ExtendErr("unable to unmarshal relay policy:", err)
+	}
+	return rp, nil
+}
+
+// getPendingTransactionSets returns the pending transaction sets stored in the
+// database.
+func (tp *TransactionPool) getPendingTransactionSets(tx *bolt.Tx) (map[modules.TransactionSetID][]types.Transaction, error) {
+	sets := make(map[modules.TransactionSetID][]types.Transaction)
+	err := tx.Bucket(bucketPendingTransactionSets).ForEach(func(k, v []byte) error {
+		var id modules.TransactionSetID
+		copy(id[:], k)
+		var ts []types.Transaction
+		if err := encoding.Unmarshal(v, &ts); err != nil {
+			return build.ExtendErr("unable to unmarshal pending transaction set:", err)
+		}
+		sets[id] = ts
+		return nil
+	})
+	return sets, err
+}
+
 // getRecentBlockID will fetch the most recent block id and most recent parent
 // id from the database.
 func (tp *TransactionPool) getRecentBlockID(tx *bolt.Tx) (recentID types.BlockID, err error) {
@@ -146,6 +196,21 @@ func (tp *TransactionPool) putFeeMedian(tx *bolt.Tx, mp medianPersist) error {
 	return tx.Bucket(bucketFeeMedian).Put(fieldFeeMedian, objBytes)
 }
 
+// putPendingTransactionSet adds a transaction set to the pending transaction
+// sets.
+func (tp *TransactionPool) putPendingTransactionSet(tx *bolt.Tx, id modules.TransactionSetID, ts []types.Transaction) error {
+	return tx.Bucket(bucketPendingTransactionSets).Put(id[:], encoding.Marshal(ts))
+}
+
+// putRelayPolicy puts the relay policy into the database.
+func (tp *TransactionPool) putRelayPolicy(tx *bolt.Tx, rp modules.TPoolRelayPolicy) error {
+	policyBytes, err := json.Marshal(rp)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(bucketRelayPolicy).Put(fieldRelayPolicy, policyBytes)
+}
+
 // putRecentBlockID will store the most recent block id and the parent id of
 // that block in the database.
 func (tp *TransactionPool) putRecentBlockID(tx *bolt.Tx, recentID types.BlockID) error {
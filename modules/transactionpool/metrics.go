@@ -0,0 +1,12 @@
+package transactionpool
+
+import (
+	"go.sia.tech/siad/metrics"
+)
+
+var (
+	// nftRelayFilteredMetric counts the NFT transaction sets relayed by peers
+	// that were declined by the relay policy, labeled by the check that
+	// failed.
+	nftRelayFilteredMetric = metrics.NewCounterVec("siad_tpool_nft_relay_filtered_total", "Number of NFT transaction sets relayed by peers that were declined by the relay policy.", "reason")
+)
@@ -0,0 +1,130 @@
+package transactionpool
+
+import (
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// pending.go persists the unconfirmed transaction sets that must survive a
+// restart of the daemon. Sets submitted by the node's own modules, like the
+// wallet, and sets containing an NFT transaction are stored until they are
+// confirmed or dropped from the pool. Once the transaction pool has caught up
+// with consensus after a restart, the stored sets are resubmitted, so that
+// pending NFT transfers which counterparties believe are in flight aren't lost
+// silently.
+
+// isPendingTransactionSet returns true if the transaction set should be
+// persisted until it is confirmed. Local sets are always persisted, sets
+// relayed by peers only if they contain an NFT transaction.
+func isPendingTransactionSet(ts []types.Transaction, local bool) bool {
+	if local {
+		return true
+	}
+	for _, txn := range ts {
+		if types.IsNFTTransaction(txn) {
+			return true
+		}
+	}
+	return false
+}
+
+// managedPersistPendingTransactionSet stores an accepted transaction set so
+// that it is resubmitted after a restart.
+func (tp *TransactionPool) managedPersistPendingTransactionSet(ts []types.Transaction) {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	id := modules.TransactionSetID(crypto.HashObject(ts))
+	if err := tp.putPendingTransactionSet(tp.dbTx, id, ts); err != nil {
+		tp.log.Println("ERROR: unable to persist pending transaction set:", err)
+	}
+}
+
+// prunePendingTransactionSets removes the pending transaction sets whose
+// transactions were all confirmed, as well as the sets with an unconfirmed
+// transaction which isn't in the pool anymore, e.g. because it became invalid
+// or reached the MaxTransactionAge. Nothing is pruned before the pending sets
+// were resubmitted after a restart, because the pool is still empty then.
+func (tp *TransactionPool) prunePendingTransactionSets() {
+	if !tp.pendingSetsLoaded {
+		return
+	}
+	sets, err := tp.getPendingTransactionSets(tp.dbTx)
+	if err != nil {
+		tp.log.Println("ERROR: unable to load pending transaction sets:", err)
+		return
+	}
+	if len(sets) == 0 {
+		return
+	}
+	pooled := make(map[types.TransactionID]struct{})
+	for _, ts := range tp.transactionSets {
+		for _, txn := range ts {
+			pooled[txn.ID()] = struct{}{}
+		}
+	}
+	for id, ts := range sets {
+		keep := false
+		for _, txn := range ts {
+			if tp.transactionConfirmed(tp.dbTx, txn.ID()) {
+				continue
+			}
+			_, keep = pooled[txn.ID()]
+			if !keep {
+				break
+			}
+		}
+		if keep {
+			continue
+		}
+		if err := tp.deletePendingTransactionSet(tp.dbTx, id); err != nil {
+			tp.log.Println("ERROR: unable to delete pending transaction set:", err)
+		}
+	}
+}
+
+// managedResubmitPendingTransactionSets resubmits the pending transaction sets
+// which were persisted before the last shutdown. Sets which aren't valid
+// anymore are dropped. It is called once the transaction pool has caught up
+// with consensus.
+func (tp *TransactionPool) managedResubmitPendingTransactionSets() {
+	if err := tp.tg.Add(); err != nil {
+		return
+	}
+	defer tp.tg.Done()
+
+	tp.mu.Lock()
+	sets, err := tp.getPendingTransactionSets(tp.dbTx)
+	tp.mu.Unlock()
+	if err != nil {
+		tp.log.Println("ERROR: unable to load pending transaction sets:", err)
+	}
+
+	var dropped []modules.TransactionSetID
+	for id, ts := range sets {
+		minSuperSet, err := tp.submitTransactionSet(ts)
+		if errors.Contains(err, modules.ErrDuplicateTransactionSet) {
+			continue
+		}
+		if err != nil {
+			tp.log.Debugln("Dropping pending transaction set which can't be resubmitted:", err)
+			dropped = append(dropped, id)
+			continue
+		}
+		go tp.gateway.Broadcast("RelayTransactionSet", minSuperSet, tp.gateway.Peers())
+	}
+	if len(sets) > 0 {
+		tp.log.Printf("Resubmitted %v of %v pending transaction sets", len(sets)-len(dropped), len(sets))
+	}
+
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	for _, id := range dropped {
+		if err := tp.deletePendingTransactionSet(tp.dbTx, id); err != nil {
+			tp.log.Println("ERROR: unable to delete pending transaction set:", err)
+		}
+	}
+	tp.pendingSetsLoaded = true
+}
@@ -132,6 +132,8 @@ func (tp *TransactionPool) initPersist() error {
 		bucketRecentConsensusChange,
 		bucketConfirmedTransactions,
 		bucketFeeMedian,
+		bucketRelayPolicy,
+		bucketPendingTransactionSets,
 	}
 	for _, bucket := range buckets {
 		_, err := tp.dbTx.CreateBucketIfNotExists(bucket)
@@ -178,6 +180,12 @@ func (tp *TransactionPool) initPersist() error {
 		tp.recentMedianFee = mp.RecentMedianFee
 	}
 
+	// Get the relay policy.
+	tp.relayPolicy, err = tp.getRelayPolicy(tp.dbTx)
+	if err != nil {
+		return build.ExtendErr("unable to load the relay policy", err)
+	}
+
 	// Subscribe to the consensus set using the most recent consensus change.
 	go func() {
 		err := tp.consensusSet.ConsensusSetSubscribe(tp, cc, tp.tg.StopChan())
@@ -206,12 +214,14 @@ func (tp *TransactionPool) initPersist() error {
 			tp.tg.OnStop(func() {
 				tp.consensusSet.Unsubscribe(tp)
 			})
+			tp.managedResubmitPendingTransactionSets()
 			return
 		}
 		if err != nil {
 			tp.log.Critical(err)
 			return
 		}
+		tp.managedResubmitPendingTransactionSets()
 	}()
 	tp.tg.OnStop(func() {
 		tp.consensusSet.Unsubscribe(tp)
@@ -163,3 +163,67 @@ func TestRescan(t *testing.T) {
 		t.Fatal("expecting modules.ErrDuplicateTransactionSet, got:", err)
 	}
 }
+
+// TestPendingTransactionSetsPersist checks that unconfirmed transaction sets
+// submitted locally are resubmitted after a restart and forgotten once they
+// are confirmed.
+func TestPendingTransactionSetsPersist(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	tpt, err := createTpoolTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := tpt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	txns, err := tpt.wallet.SendSiacoins(types.NewCurrency64(100), types.UnlockHash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	txid := txns[len(txns)-1].ID()
+
+	// Restart the tpool. The set should be back in the pool once the tpool
+	// caught up with consensus.
+	persistDir := tpt.tpool.persistDir
+	if err := tpt.tpool.Close(); err != nil {
+		t.Fatal(err)
+	}
+	tpt.tpool, err = New(tpt.cs, tpt.gateway, persistDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		if _, _, exists := tpt.tpool.Transaction(txid); !exists {
+			return errors.New("pending transaction wasn't resubmitted")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Once the set is confirmed it shouldn't be persisted anymore.
+	if _, err := tpt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		tpt.tpool.mu.Lock()
+		defer tpt.tpool.mu.Unlock()
+		sets, err := tpt.tpool.getPendingTransactionSets(tpt.tpool.dbTx)
+		if err != nil {
+			return err
+		}
+		if len(sets) != 0 {
+			return errors.New("confirmed transaction set is still pending")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
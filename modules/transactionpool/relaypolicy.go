@@ -0,0 +1,94 @@
+package transactionpool
+
+import (
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errNFTRelayFee is returned if an NFT transaction set pays less than the
+	// minimum fee per byte of the relay policy.
+	errNFTRelayFee = errors.New("nft transaction set fee per byte is too low")
+
+	// errNFTRelayArbitraryData is returned if an NFT transaction set contains
+	// more arbitrary data than the relay policy allows.
+	errNFTRelayArbitraryData = errors.New("nft transaction set contains too much arbitrary data")
+)
+
+// checkRelayPolicy checks whether a transaction set satisfies the relay
+// policy. Transaction sets that don't contain an NFT transaction always
+// satisfy it.
+func checkRelayPolicy(rp modules.TPoolRelayPolicy, ts []types.Transaction) error {
+	var nft bool
+	var fees types.Currency
+	var arbSize uint64
+	for _, txn := range ts {
+		nft = nft || types.IsNFTTransaction(txn)
+		for _, fee := range txn.MinerFees {
+			fees = fees.Add(fee)
+		}
+		for _, arb := range txn.ArbitraryData {
+			arbSize += uint64(len(arb))
+		}
+	}
+	if !nft {
+		return nil
+	}
+	if rp.MaxNFTArbitraryDataSize != 0 && arbSize > rp.MaxNFTArbitraryDataSize {
+		return errors.Compose(modules.ErrNFTRelayPolicy, errNFTRelayArbitraryData)
+	}
+	if !rp.MinNFTFeePerByte.IsZero() {
+		size := uint64(len(encoding.Marshal(ts)))
+		if fees.Cmp(rp.MinNFTFeePerByte.Mul64(size)) < 0 {
+			return errors.Compose(modules.ErrNFTRelayPolicy, errNFTRelayFee)
+		}
+	}
+	return nil
+}
+
+// managedCheckRelayPolicy checks whether a transaction set relayed by a peer
+// satisfies the transaction pool's relay policy and counts the sets that
+// don't.
+func (tp *TransactionPool) managedCheckRelayPolicy(ts []types.Transaction) error {
+	tp.mu.RLock()
+	rp := tp.relayPolicy
+	tp.mu.RUnlock()
+
+	err := checkRelayPolicy(rp, ts)
+	switch {
+	case errors.Contains(err, errNFTRelayFee):
+		nftRelayFilteredMetric.With("fee").Inc()
+	case errors.Contains(err, errNFTRelayArbitraryData):
+		nftRelayFilteredMetric.With("arbitrarydata").Inc()
+	}
+	return err
+}
+
+// RelayPolicy returns the policy used to filter NFT transaction sets received
+// from peers.
+func (tp *TransactionPool) RelayPolicy() modules.TPoolRelayPolicy {
+	tp.mu.RLock()
+	defer tp.mu.RUnlock()
+	return tp.relayPolicy
+}
+
+// SetRelayPolicy sets and persists the policy used to filter NFT transaction
+// sets received from peers.
+func (tp *TransactionPool) SetRelayPolicy(rp modules.TPoolRelayPolicy) error {
+	if err := tp.tg.Add(); err != nil {
+		return err
+	}
+	defer tp.tg.Done()
+
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	err := tp.putRelayPolicy(tp.dbTx, rp)
+	if err != nil {
+		return errors.AddContext(err, "unable to persist relay policy")
+	}
+	tp.relayPolicy = rp
+	return nil
+}
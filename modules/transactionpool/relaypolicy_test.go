@@ -0,0 +1,92 @@
+package transactionpool
+
+import (
+	"reflect"
+	"testing"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestCheckRelayPolicy tests that NFT transaction sets are filtered by the
+// relay policy and that other transaction sets are not.
+func TestCheckRelayPolicy(t *testing.T) {
+	nftTxn := types.Transaction{
+		ArbitraryData: [][]byte{types.NFTArbitraryData(types.NFTMintTag, types.NftCustody{FileMerkleRoot: crypto.Hash{1}})},
+		MinerFees:     []types.Currency{types.NewCurrency64(1000)},
+	}
+	plainTxn := types.Transaction{
+		ArbitraryData: [][]byte{make([]byte, 1000)},
+	}
+	ts := []types.Transaction{nftTxn}
+	size := uint64(len(encoding.Marshal(ts)))
+	arbSize := uint64(len(nftTxn.ArbitraryData[0]))
+
+	tests := []struct {
+		name string
+		rp   modules.TPoolRelayPolicy
+		ts   []types.Transaction
+		err  error
+	}{
+		{"disabled", modules.TPoolRelayPolicy{}, ts, nil},
+		{"plain txn", modules.TPoolRelayPolicy{MinNFTFeePerByte: types.NewCurrency64(1e6), MaxNFTArbitraryDataSize: 1}, []types.Transaction{plainTxn}, nil},
+		{"fee ok", modules.TPoolRelayPolicy{MinNFTFeePerByte: types.NewCurrency64(1000 / size)}, ts, nil},
+		{"fee too low", modules.TPoolRelayPolicy{MinNFTFeePerByte: types.NewCurrency64(1000/size + 1)}, ts, errNFTRelayFee},
+		{"arb ok", modules.TPoolRelayPolicy{MaxNFTArbitraryDataSize: arbSize}, ts, nil},
+		{"arb too large", modules.TPoolRelayPolicy{MaxNFTArbitraryDataSize: arbSize - 1}, ts, errNFTRelayArbitraryData},
+		{"arb of whole set", modules.TPoolRelayPolicy{MaxNFTArbitraryDataSize: arbSize}, []types.Transaction{plainTxn, nftTxn}, errNFTRelayArbitraryData},
+	}
+	for _, test := range tests {
+		err := checkRelayPolicy(test.rp, test.ts)
+		if (test.err == nil && err != nil) || (test.err != nil && !errors.Contains(err, test.err)) {
+			t.Errorf("%v: expected %v but got %v", test.name, test.err, err)
+		}
+		if err != nil && !errors.Contains(err, modules.ErrNFTRelayPolicy) {
+			t.Errorf("%v: expected ErrNFTRelayPolicy but got %v", test.name, err)
+		}
+	}
+}
+
+// TestRelayPolicyPersist tests that the relay policy is persisted across
+// restarts.
+func TestRelayPolicyPersist(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	tpt, err := blankTpoolTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := tpt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	if !reflect.DeepEqual(tpt.tpool.RelayPolicy(), modules.TPoolRelayPolicy{}) {
+		t.Fatal("expected the relay policy to be disabled by default")
+	}
+	rp := modules.TPoolRelayPolicy{
+		MinNFTFeePerByte:        types.NewCurrency64(100),
+		MaxNFTArbitraryDataSize: 4096,
+	}
+	if err := tpt.tpool.SetRelayPolicy(rp); err != nil {
+		t.Fatal(err)
+	}
+
+	persistDir := tpt.tpool.persistDir
+	if err := tpt.tpool.Close(); err != nil {
+		t.Fatal(err)
+	}
+	tpt.tpool, err = New(tpt.cs, tpt.gateway, persistDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(tpt.tpool.RelayPolicy(), rp) {
+		t.Fatal("relay policy wasn't persisted", tpt.tpool.RelayPolicy())
+	}
+}
@@ -114,6 +114,21 @@ func isStandardTransaction(t types.Transaction) (uint64, error) {
 
 		return 0, modules.ErrInvalidArbPrefix
 	}
+
+	// Check that NFT arbitrary data is well formed before the more expensive
+	// consensus checks run.
+	if err := types.ValidateNFTTransaction(t); err != nil {
+		return 0, err
+	}
+
+	// Unknown NFT versions are valid for consensus so that new formats can be
+	// introduced by softfork, but they are not relayed until this node
+	// understands them.
+	if types.IsNFTTransaction(t) {
+		if _, _, _, err := types.ParseNFTArbitraryData(t.ArbitraryData[0]); err != nil {
+			return 0, err
+		}
+	}
 	return uint64(tlen), nil
 }
 
@@ -6,6 +6,7 @@ import (
 	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/fastrand"
 
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/types"
 )
@@ -51,3 +52,37 @@ func TestIntegrationLargeTransactions(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+// TestIsStandardNFTTransaction checks that malformed NFT arbitrary data is
+// rejected by the IsStandard checks.
+func TestIsStandardNFTTransaction(t *testing.T) {
+	nft := types.NftCustody{FileMerkleRoot: crypto.HashBytes(fastrand.Bytes(16))}
+	valid := types.NFTArbitraryData(types.NFTMintTag, nft)
+	txn := types.Transaction{ArbitraryData: [][]byte{valid}}
+	if _, err := isStandardTransaction(txn); err != nil {
+		t.Fatal(err)
+	}
+
+	// Truncated data should be rejected.
+	txn.ArbitraryData = [][]byte{valid[:len(valid)-1]}
+	if _, err := isStandardTransaction(txn); !errors.Contains(err, types.ErrNFTDataLength) {
+		t.Fatal("expected ErrNFTDataLength but got", err)
+	}
+
+	// Unknown versions are valid for consensus but not standard.
+	unknown := append([]byte{}, valid...)
+	unknown[types.SpecifierLen] = types.NFTCurrentVersion + 1
+	txn.ArbitraryData = [][]byte{unknown}
+	if err := types.ValidateNFTTransaction(txn); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := isStandardTransaction(txn); !errors.Contains(err, types.ErrNFTUnsupportedVersion) {
+		t.Fatal("expected ErrNFTUnsupportedVersion but got", err)
+	}
+
+	// NFT data after the first entry should be rejected.
+	txn.ArbitraryData = [][]byte{append(modules.PrefixNonSia[:], 'x'), valid}
+	if _, err := isStandardTransaction(txn); !errors.Contains(err, types.ErrNFTMisplacedData) {
+		t.Fatal("expected ErrNFTMisplacedData but got", err)
+	}
+}
@@ -60,6 +60,13 @@ type (
 		recentMedians   []types.Currency
 		recentMedianFee types.Currency // SC per byte
 
+		// relayPolicy filters the NFT transaction sets relayed by peers.
+		relayPolicy modules.TPoolRelayPolicy
+
+		// pendingSetsLoaded is set once the pending transaction sets persisted
+		// before the last shutdown were resubmitted.
+		pendingSetsLoaded bool
+
 		// The consensus change index tracks how many consensus changes have
 		// been sent to the transaction pool. When a new subscriber joins the
 		// transaction pool, all prior consensus changes are sent to the new
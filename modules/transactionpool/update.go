@@ -432,6 +432,9 @@ func (tp *TransactionPool) ProcessConsensusChange(cc modules.ConsensusChange) {
 		}
 	}
 
+	// Forget the pending transaction sets which were confirmed or dropped.
+	tp.prunePendingTransactionSets()
+
 	// Log the size of the transaction pool following an integration of the
 	// block, this will tell us if all of the transactions have been consumed or
 	// not.
@@ -443,9 +446,12 @@ func (tp *TransactionPool) ProcessConsensusChange(cc modules.ConsensusChange) {
 	tp.mu.DemotedUnlock()
 }
 
-// PurgeTransactionPool deletes all transactions from the transaction pool.
+// PurgeTransactionPool deletes all transactions from the transaction pool,
+// including the pending transaction sets which would be resubmitted after a
+// restart.
 func (tp *TransactionPool) PurgeTransactionPool() {
 	tp.mu.Lock()
 	tp.purge()
+	tp.prunePendingTransactionSets()
 	tp.mu.Unlock()
 }
@@ -0,0 +1,88 @@
+package modules
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+)
+
+// uploadschedule.go contains the schedule which restricts the renter's uploads
+// and repairs to certain hours of the day, e.g. to keep the renter from
+// saturating a home connection during the day.
+
+// ErrInvalidUploadSchedule is returned when parsing or setting an upload
+// schedule with hours outside of the day.
+var ErrInvalidUploadSchedule = errors.New("upload schedule hours must be between 0 and 23")
+
+// UploadSchedule restricts the renter's uploads and repairs to the hours from
+// Start up to End in the local time zone of the renter. The window wraps
+// around midnight if Start is greater than End, e.g. a schedule from 22 to 6
+// only uploads at night. A schedule with equal Start and End is disabled and
+// uploads run at any time.
+type UploadSchedule struct {
+	Start uint8 `json:"start"`
+	End   uint8 `json:"end"`
+}
+
+// ParseUploadSchedule parses an upload schedule of the form "start-end", e.g.
+// "22-6". An empty string or "off" disables the schedule.
+func ParseUploadSchedule(s string) (UploadSchedule, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "off" {
+		return UploadSchedule{}, nil
+	}
+	var us UploadSchedule
+	if _, err := fmt.Sscanf(s, "%d-%d", &us.Start, &us.End); err != nil {
+		return UploadSchedule{}, errors.AddContext(err, "upload schedule must be of the form 'start-end'")
+	}
+	return us, us.Validate()
+}
+
+// Enabled returns true if the schedule restricts the uploads.
+func (us UploadSchedule) Enabled() bool {
+	return us.Start != us.End
+}
+
+// Validate returns an error if the hours of the schedule are outside of the
+// day.
+func (us UploadSchedule) Validate() error {
+	if us.Start > 23 || us.End > 23 {
+		return ErrInvalidUploadSchedule
+	}
+	return nil
+}
+
+// Active returns true if uploads may run at t.
+func (us UploadSchedule) Active(t time.Time) bool {
+	if !us.Enabled() {
+		return true
+	}
+	hour := uint8(t.Hour())
+	if us.Start < us.End {
+		return hour >= us.Start && hour < us.End
+	}
+	return hour >= us.Start || hour < us.End
+}
+
+// NextStart returns the next time at or after t at which the schedule allows
+// uploads.
+func (us UploadSchedule) NextStart(t time.Time) time.Time {
+	if us.Active(t) {
+		return t
+	}
+	start := time.Date(t.Year(), t.Month(), t.Day(), int(us.Start), 0, 0, 0, t.Location())
+	if !start.After(t) {
+		start = start.AddDate(0, 0, 1)
+	}
+	return start
+}
+
+// String returns the schedule in the format accepted by ParseUploadSchedule.
+func (us UploadSchedule) String() string {
+	if !us.Enabled() {
+		return "off"
+	}
+	return fmt.Sprintf("%d-%d", us.Start, us.End)
+}
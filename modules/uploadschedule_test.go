@@ -0,0 +1,60 @@
+package modules
+
+import (
+	"testing"
+	"time"
+)
+
+// TestUploadSchedule tests parsing upload schedules and checking whether they
+// allow uploads.
+func TestUploadSchedule(t *testing.T) {
+	// Parse schedules.
+	for _, s := range []string{"", "off", "7-7"} {
+		us, err := ParseUploadSchedule(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if us.Enabled() {
+			t.Fatalf("schedule %q shouldn't be enabled", s)
+		}
+	}
+	for _, s := range []string{"22", "a-b", "22-24", "24-6"} {
+		if _, err := ParseUploadSchedule(s); err == nil {
+			t.Fatalf("schedule %q shouldn't parse", s)
+		}
+	}
+	night, err := ParseUploadSchedule("22-6")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if night != (UploadSchedule{Start: 22, End: 6}) || night.String() != "22-6" {
+		t.Fatal("wrong schedule", night)
+	}
+	work := UploadSchedule{Start: 9, End: 17}
+
+	day := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		us        UploadSchedule
+		hour      int
+		active    bool
+		nextStart time.Time
+	}{
+		{night, 23, true, day.Add(23 * time.Hour)},
+		{night, 3, true, day.Add(3 * time.Hour)},
+		{night, 6, false, day.Add(22 * time.Hour)},
+		{night, 12, false, day.Add(22 * time.Hour)},
+		{work, 9, true, day.Add(9 * time.Hour)},
+		{work, 8, false, day.Add(9 * time.Hour)},
+		{work, 17, false, day.Add(33 * time.Hour)},
+		{UploadSchedule{}, 12, true, day.Add(12 * time.Hour)},
+	}
+	for _, test := range tests {
+		now := day.Add(time.Duration(test.hour) * time.Hour)
+		if test.us.Active(now) != test.active {
+			t.Errorf("%v at %v: expected active %v", test.us, test.hour, test.active)
+		}
+		if next := test.us.NextStart(now); !next.Equal(test.nextStart) {
+			t.Errorf("%v at %v: expected next start %v but got %v", test.us, test.hour, test.nextStart, next)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 	"unicode"
 
 	mnemonics "gitlab.com/NebulousLabs/entropy-mnemonics"
@@ -27,6 +28,38 @@ const (
 	WalletDir = "wallet"
 )
 
+const (
+	// NFTAuditMissingOutput means that the wallet has no spendable output
+	// backing the NFT's custody.
+	NFTAuditMissingOutput NFTAuditIssueType = "missingoutput"
+
+	// NFTAuditDoubleCounted means that the NFT shares its backing output
+	// with other NFTs of the same address or was reported more than once.
+	NFTAuditDoubleCounted NFTAuditIssueType = "doublecounted"
+
+	// NFTAuditCustodyMismatch means that the consensus custody index
+	// doesn't assign the NFT to the address it was reported for.
+	NFTAuditCustodyMismatch NFTAuditIssueType = "custodymismatch"
+
+	// NFTAuditUnknownKey means that the NFT is held by an address the
+	// wallet knows about but can't derive the key for anymore.
+	NFTAuditUnknownKey NFTAuditIssueType = "unknownkey"
+)
+
+const (
+	// NFTTemplateDestinationFixed means that NFTs minted from a template are
+	// minted to the template's destination.
+	NFTTemplateDestinationFixed NFTTemplateDestinationPolicy = "fixed"
+
+	// NFTTemplateDestinationNewAddress means that every NFT minted from a
+	// template is minted to a new address of the wallet.
+	NFTTemplateDestinationNewAddress NFTTemplateDestinationPolicy = "newaddress"
+
+	// NFTMaxRoyalty is the maximum royalty of a mint template in basis
+	// points.
+	NFTMaxRoyalty = 10000
+)
+
 var (
 	// ErrBadEncryptionKey is returned if the incorrect encryption key to a
 	// file is provided.
@@ -46,6 +79,14 @@ var (
 	// complete the desired action.
 	ErrLowBalance = errors.New("insufficient balance")
 
+	// ErrNFTNotMinted is returned when exporting the provenance of an NFT
+	// which has no mint transaction on the blockchain.
+	ErrNFTNotMinted = errors.New("no mint transaction found for nft")
+
+	// ErrNotNFTCreator is returned when exporting the provenance of an NFT
+	// which wasn't minted to an address of the wallet.
+	ErrNotNFTCreator = errors.New("nft was not minted to an address of this wallet")
+
 	// ErrWalletShutdown is returned when a method can't continue execution due
 	// to the wallet shutting down.
 	ErrWalletShutdown = errors.New("wallet is shutting down")
@@ -128,6 +169,148 @@ type (
 		IsWatchOnly        bool              `json:"iswatchonly"`
 	}
 
+	// NFTDeposit is an NFT that was minted or transferred to one of the
+	// wallet's NFT deposit addresses. Confirmations is the number of blocks
+	// that confirm the deposit, including the block that contains it.
+	NFTDeposit struct {
+		NFT           types.NftCustody      `json:"nft"`
+		UserID        string                `json:"userid"`
+		Address       types.UnlockHash      `json:"address"`
+		OutputID      types.SiacoinOutputID `json:"outputid"`
+		TransactionID types.TransactionID   `json:"transactionid"`
+		BlockHeight   types.BlockHeight     `json:"blockheight"`
+		Confirmations types.BlockHeight     `json:"confirmations"`
+	}
+
+	// TransactionGroupID identifies a group of interdependent transactions
+	// broadcast by the wallet.
+	TransactionGroupID crypto.Hash
+
+	// TransactionGroupParams control for how long the wallet broadcasts a
+	// transaction group. The wallet gives up on the group at GiveUpHeight,
+	// where 0 means never. Droppable groups are no longer tracked once the
+	// wallet gave up on them or a broadcast failed, other groups are kept and
+	// marked as abandoned when the wallet gives up on them.
+	TransactionGroupParams struct {
+		GiveUpHeight types.BlockHeight `json:"giveupheight"`
+		Droppable    bool              `json:"droppable"`
+	}
+
+	// TransactionGroup is a set of interdependent transactions which the
+	// wallet broadcasts as a whole until all of them are confirmed. The
+	// transactions are ordered by their dependencies. ConfirmationHeights
+	// contains the height of the block which confirmed each transaction, or 0
+	// if the transaction is unconfirmed. Broadcasts is the number of times the
+	// group was submitted to the transaction pool or announced to its peers
+	// and LastError the error of the last failed broadcast. Abandoned is set
+	// once the wallet gave up on an unconfirmed group.
+	TransactionGroup struct {
+		ID                  TransactionGroupID  `json:"id"`
+		Transactions        []types.Transaction `json:"transactions"`
+		ConfirmationHeights []types.BlockHeight `json:"confirmationheights"`
+		Confirmed           bool                `json:"confirmed"`
+		GiveUpHeight        types.BlockHeight   `json:"giveupheight"`
+		Droppable           bool                `json:"droppable"`
+		Abandoned           bool                `json:"abandoned"`
+		Broadcasts          uint64              `json:"broadcasts"`
+		LastBroadcast       types.BlockHeight   `json:"lastbroadcast"`
+		LastError           string              `json:"lasterror"`
+	}
+
+	// BroadcastFailure describes a transaction set which the transaction pool
+	// refused to accept when the wallet broadcast it. FailedIndex is the
+	// index of the transaction which caused the failure and ValidityError
+	// its specific error. FailedIndex is -1 if the set was refused as a
+	// whole, e.g. for its fees or size. MinimumFee is the minimum recommended
+	// fee per byte of the transaction pool at the time of the failure and
+	// SetFee the fee per byte paid by the set.
+	BroadcastFailure struct {
+		Time                time.Time             `json:"time"`
+		Height              types.BlockHeight     `json:"height"`
+		TransactionIDs      []types.TransactionID `json:"transactionids"`
+		FailedIndex         int                   `json:"failedindex"`
+		FailedTransactionID types.TransactionID   `json:"failedtransactionid"`
+		ValidityError       string                `json:"validityerror"`
+		PoolError           string                `json:"poolerror"`
+		SetFee              types.Currency        `json:"setfee"`
+		SetSize             uint64                `json:"setsize"`
+		MinimumFee          types.Currency        `json:"minimumfee"`
+	}
+
+	// NamedWalletInfo describes a named wallet managed by the wallet and
+	// whether it is currently open.
+	NamedWalletInfo struct {
+		Name string `json:"name"`
+		Open bool   `json:"open"`
+	}
+
+	// NFTAuditIssueType describes the kind of problem found by an NFT audit.
+	NFTAuditIssueType string
+
+	// NFTAuditIssue is a problem with the custody of an NFT held by the
+	// wallet that was found by an NFT audit.
+	NFTAuditIssue struct {
+		NFT   types.NftCustody  `json:"nft"`
+		Owner types.UnlockHash  `json:"owner"`
+		Type  NFTAuditIssueType `json:"type"`
+	}
+
+	// NFTTemplateDestinationPolicy decides which address the NFTs minted from
+	// a template are minted to.
+	NFTTemplateDestinationPolicy string
+
+	// NFTMintTemplate contains the settings shared by the NFTs of a
+	// collection so that they don't have to be specified for every mint.
+	// NFTs are minted with an identity if Collection is set, using the
+	// lowest nonce that wasn't minted yet for the NFT's data. Royalty is the
+	// creator's royalty in basis points. It is recorded for marketplaces and
+	// not enforced by consensus.
+	NFTMintTemplate struct {
+		ID                string                       `json:"id"`
+		Collection        string                       `json:"collection"`
+		Royalty           uint64                       `json:"royalty"`
+		ContentType       string                       `json:"contenttype"`
+		TransferPolicy    types.NFTTransferPolicy      `json:"transferpolicy"`
+		DestinationPolicy NFTTemplateDestinationPolicy `json:"destinationpolicy"`
+		Destination       types.UnlockHash             `json:"destination"`
+	}
+
+	// TransactionSpec describes a transaction composed by
+	// Wallet.ComposeTransaction. If NFT is set, the NFT is transferred from
+	// the wallet to NFTDestination in the same transaction which pays
+	// SiacoinOutputs, e.g. the seller, royalty and marketplace fee of a
+	// sale.
+	TransactionSpec struct {
+		NFT            *types.NftCustody     `json:"nft,omitempty"`
+		NFTDestination types.UnlockHash      `json:"nftdestination"`
+		SiacoinOutputs []types.SiacoinOutput `json:"siacoinoutputs"`
+	}
+
+	// NFTActivity is a confirmed mint or transfer of an NFT which involves
+	// the wallet. Incoming is set if the NFT was sent to one of the wallet's
+	// addresses and Outgoing if the wallet funded the transaction.
+	NFTActivity struct {
+		NFT           types.NftCustody    `json:"nft"`
+		Minted        bool                `json:"minted"`
+		Incoming      bool                `json:"incoming"`
+		Outgoing      bool                `json:"outgoing"`
+		Address       types.UnlockHash    `json:"address"`
+		TransactionID types.TransactionID `json:"transactionid"`
+		BlockHeight   types.BlockHeight   `json:"blockheight"`
+	}
+
+	// NFTScheduledTransfer is a transfer of an NFT held by the wallet which
+	// the wallet broadcasts once the chain reaches Height. Failed attempts
+	// are retried at every new block until the transfer is broadcast or
+	// cancelled. LastError is the error of the last failed attempt.
+	NFTScheduledTransfer struct {
+		NFT         types.NftCustody  `json:"nft"`
+		Destination types.UnlockHash  `json:"destination"`
+		Height      types.BlockHeight `json:"height"`
+		Attempts    uint64            `json:"attempts"`
+		LastError   string            `json:"lasterror"`
+	}
+
 	// TransactionBuilder is used to construct custom transactions. A transaction
 	// builder is initialized via 'RegisterTransaction' and then can be modified by
 	// adding funds or other fields. The transaction is completed by calling
@@ -148,6 +331,11 @@ type (
 		// transaction failed.
 		FundSiacoins(amount types.Currency) error
 
+		// AllowNFTCustodyOutputs allows FundSiacoins to spend the outputs
+		// that hold the custody of NFTs, which are skipped by default.
+		// Spending such an output breaks the chain of custody of the NFT.
+		AllowNFTCustodyOutputs()
+
 		// FundSiafunds will add a siafund input of exactly 'amount' to the
 		// transaction. A parent transaction may be needed to achieve an input
 		// with the correct value. The siafund input will not be signed until
@@ -391,6 +579,11 @@ type (
 		// primary seed.
 		NextAddress() (types.UnlockConditions, error)
 
+		// NextNFTCustodyAddress returns a new address of the NFT custody
+		// account. Outputs of custody addresses are never used to fund
+		// transactions.
+		NextNFTCustodyAddress() (types.UnlockConditions, error)
+
 		// NextAddresses returns n new coin addresses generated from the primary
 		// seed.
 		NextAddresses(uint64) ([]types.UnlockConditions, error)
@@ -512,15 +705,229 @@ type (
 		// Mint an NFT corresponding to specific data to an address
 		MintNFT(nft types.NftCustody, dest types.UnlockHash) ([]types.Transaction, error)
 
+		// MintReferencedNFT mints an NFT like MintNFT and records the IPFS
+		// CID or multihash of its data in the mint.
+		MintReferencedNFT(nft types.NftCustody, ref types.NFTContentReference, dest types.UnlockHash) ([]types.Transaction, error)
+
+		// MintLicensedNFT mints an NFT like MintNFT and commits to the
+		// license of its data in the mint.
+		MintLicensedNFT(nft types.NftCustody, license types.NFTLicense, dest types.UnlockHash) ([]types.Transaction, error)
+
+		// MintIdentifiedNFT mints an NFT with an NftID derived from the key
+		// of dest, the collection, the NFT's merkle root and the nonce. The
+		// returned NFT carries its NftID.
+		MintIdentifiedNFT(nft types.NftCustody, collection string, nonce uint64, dest types.UnlockHash) (types.NftCustody, []types.Transaction, error)
+
+		// MintNFTEdition mints edition number edition out of a run of
+		// editions of the same data. The returned NFT carries its NftID.
+		MintNFTEdition(nft types.NftCustody, collection string, edition, editions uint64, dest types.UnlockHash) (types.NftCustody, []types.Transaction, error)
+
 		// Transfer an NFT corresponding to specific data to an address
 		TransferNFT(nft types.NftCustody, dest types.UnlockHash) ([]types.Transaction, error)
 
+		// ComposeTransaction builds, signs and broadcasts a single
+		// transaction which pays the siacoin outputs of spec and transfers
+		// its NFT, if any, so that both settle atomically.
+		ComposeTransaction(spec TransactionSpec) ([]types.Transaction, error)
+
 		// Liquidate an NFT to extract the lockup value
 		LiquidateNFT(nft types.NftCustody, dest types.UnlockHash) ([]types.Transaction, error)
 
+		// SignNFTGovernance co-signs the last transaction of a set which needs
+		// the authorization of the lockup pool governance, and broadcasts the
+		// set once the transaction is fully signed.
+		SignNFTGovernance(txns []types.Transaction) ([]types.Transaction, error)
+
+		// DisburseNFTLockupPool pays amount out of the lockup pool to dest.
+		// The transaction has to be co-signed by the governance.
+		DisburseNFTLockupPool(amount types.Currency, dest types.UnlockHash) ([]types.Transaction, error)
+
+		// FreezeNFT flags an NFT as disputed, which freezes it until it is
+		// unfrozen. The flag has to be co-signed by the governance.
+		FreezeNFT(nft types.NftCustody, reason types.NFTDisputeReason) ([]types.Transaction, error)
+
+		// UnfreezeNFT resolves the dispute of a frozen NFT. The unflag has to
+		// be co-signed by the governance.
+		UnfreezeNFT(nft types.NftCustody, description string) ([]types.Transaction, error)
+
+		// ReclaimNFTLockup returns the vested lockup of an NFT to an address
+		// without liquidating the NFT.
+		ReclaimNFTLockup(nft types.NftCustody, dest types.UnlockHash) ([]types.Transaction, error)
+
+		// BridgeLockNFT locks an NFT for a cross-chain bridge with multisig
+		// unlock conditions and records the claim on the foreign chain.
+		BridgeLockNFT(nft types.NftCustody, bridge types.UnlockConditions, claim types.NFTBridgeClaim) ([]types.Transaction, error)
+
+		// StakeNFT locks the custody of an NFT and burns amount to signal
+		// demand for hosting the NFT's data.
+		StakeNFT(nft types.NftCustody, amount types.Currency) ([]types.Transaction, error)
+
+		// UnstakeNFT releases a staked NFT and pays out its share of the
+		// remaining stake to an address.
+		UnstakeNFT(nft types.NftCustody, dest types.UnlockHash) ([]types.Transaction, error)
+
+		// ClaimNFTStakeReward claims the stake reward of the merkle root of
+		// data by proving storage of data.
+		ClaimNFTStakeReward(data []byte, dest types.UnlockHash) ([]types.Transaction, error)
+
+		// InsureNFT burns collateral against an NFT for duration blocks,
+		// guaranteeing the availability of the NFT's data to its owner.
+		InsureNFT(nft types.NftCustody, collateral types.Currency, duration types.BlockHeight) ([]types.Transaction, error)
+
+		// ChallengeNFTInsurance challenges the insurer of an NFT held by the
+		// wallet to prove the storage of the NFT's data.
+		ChallengeNFTInsurance(nft types.NftCustody) ([]types.Transaction, error)
+
+		// RespondNFTInsuranceChallenge answers the challenge of an insurance
+		// by proving the storage of the NFT's data.
+		RespondNFTInsuranceChallenge(nft types.NftCustody, data []byte) ([]types.Transaction, error)
+
+		// ClaimNFTInsurance pays out the collateral of an insurance whose
+		// challenge wasn't answered in time to an address.
+		ClaimNFTInsurance(nft types.NftCustody, dest types.UnlockHash) ([]types.Transaction, error)
+
+		// ReleaseNFTInsurance returns the collateral of an expired insurance
+		// to the insurer.
+		ReleaseNFTInsurance(nft types.NftCustody) ([]types.Transaction, error)
+
+		// ApproveNFTOperator approves an operator address to transfer an
+		// NFT held by the wallet once.
+		ApproveNFTOperator(nft types.NftCustody, operator types.UnlockHash) ([]types.Transaction, error)
+
+		// RevokeNFTApproval revokes the approval of an NFT held by the
+		// wallet.
+		RevokeNFTApproval(nft types.NftCustody) ([]types.Transaction, error)
+
+		// TransferApprovedNFT transfers an NFT on behalf of its owner, who
+		// approved one of the wallet's addresses as its operator, to an
+		// address.
+		TransferApprovedNFT(nft types.NftCustody, dest types.UnlockHash) ([]types.Transaction, error)
+
 		// List all NFTs in the custody of this wallet
 		ScanAllNFTS() []types.NftOwnershipStats
 
+		// ProofOfReserves returns a proof of the NFTs and siacoins held by
+		// the wallet's addresses at the current height, signed by the keys of
+		// the addresses and covering the verifier's challenge.
+		ProofOfReserves(challenge crypto.Hash) (types.ProofOfReserves, error)
+
+		// ExportProvenance returns the signed provenance document of an NFT
+		// that was minted to an address of this wallet.
+		ExportProvenance(nft types.NftCustody) (types.NFTProvenance, error)
+
+		// NFTDepositAddress returns the NFT deposit address of a user,
+		// creating one if the user doesn't have one yet.
+		NFTDepositAddress(userID string) (types.UnlockHash, error)
+
+		// NFTDeposits returns all NFT deposits to the wallet's deposit
+		// addresses.
+		NFTDeposits() ([]NFTDeposit, error)
+
+		// RegisterNFTDepositCallback registers a function which is called
+		// for every NFT deposit as soon as it has reached the given number
+		// of confirmations. Deposits are delivered at least once, e.g. again
+		// after a restart.
+		RegisterNFTDepositCallback(confirmations types.BlockHeight, fn func(NFTDeposit)) error
+
+		// RegisterNFTActivityCallback registers a function which is called
+		// for every NFT mint or transfer involving the wallet that is
+		// confirmed while the wallet is synced.
+		RegisterNFTActivityCallback(fn func(NFTActivity)) error
+
+		// SweepNFTDeposits transfers all NFTs that are still held by deposit
+		// addresses to dest in batched transaction sets.
+		SweepNFTDeposits(dest types.UnlockHash) ([]types.Transaction, error)
+
+		// AuditNFTs cross-checks the NFTs held by the wallet against the
+		// consensus custody index and the wallet's spendable outputs and
+		// returns every problem that was found.
+		AuditNFTs() ([]NFTAuditIssue, error)
+
+		// SetNFTMintTemplate creates or replaces a mint template.
+		SetNFTMintTemplate(template NFTMintTemplate) error
+
+		// RemoveNFTMintTemplate removes a mint template.
+		RemoveNFTMintTemplate(id string) error
+
+		// NFTMintTemplates returns all mint templates of the wallet.
+		NFTMintTemplates() ([]NFTMintTemplate, error)
+
+		// MintNFTFromTemplate mints an NFT of the data with the given merkle
+		// root using the settings of a mint template. The returned NFT
+		// carries its NftID if it was minted with an identity.
+		MintNFTFromTemplate(templateID string, root crypto.Hash) (types.NftCustody, []types.Transaction, error)
+
+		// ScheduleNFTTransfer schedules the transfer of an NFT held by the
+		// wallet to dest. The transfer is broadcast once the chain reaches
+		// the given height.
+		ScheduleNFTTransfer(nft types.NftCustody, dest types.UnlockHash, height types.BlockHeight) error
+
+		// CancelNFTTransfer cancels the scheduled transfer of an NFT.
+		CancelNFTTransfer(nft types.NftCustody) error
+
+		// ScheduledNFTTransfers returns the pending scheduled transfers of
+		// the wallet.
+		ScheduledNFTTransfers() ([]NFTScheduledTransfer, error)
+
+		// MintUnlockableNFT mints an NFT like MintNFT and stores the content
+		// key of its unlockable content wrapped to the key of dest, which
+		// needs to be a single-key address of the wallet.
+		MintUnlockableNFT(nft types.NftCustody, u types.NFTUnlockable, key types.NFTUnlockableKey, dest types.UnlockHash) ([]types.Transaction, error)
+
+		// NFTUnlockableHandshake returns the signed handshake the sender of
+		// an NFT with unlockable content needs to re-wrap the content key to
+		// dest, a single-key address of the wallet.
+		NFTUnlockableHandshake(dest types.UnlockHash) (types.NFTUnlockableHandshake, error)
+
+		// TransferUnlockableNFT transfers an NFT with unlockable content to
+		// the address of a handshake and returns the envelope with the
+		// content key re-wrapped to the recipient.
+		TransferUnlockableNFT(nft types.NftCustody, h types.NFTUnlockableHandshake) ([]types.Transaction, types.NFTUnlockableEnvelope, error)
+
+		// ImportNFTUnlockable stores an envelope which was wrapped to an
+		// address of the wallet.
+		ImportNFTUnlockable(e types.NFTUnlockableEnvelope) error
+
+		// UnlockNFT returns the unlockable content of an NFT held by the
+		// wallet together with its content key.
+		UnlockNFT(nft types.NftCustody) (types.NFTUnlockable, types.NFTUnlockableKey, error)
+
+		// BroadcastTransactionGroup orders a set of interdependent
+		// transactions by their dependencies, submits them to the transaction
+		// pool as a single set and keeps broadcasting them until all of them
+		// are confirmed or the wallet gives up on them.
+		BroadcastTransactionGroup(txns []types.Transaction, params TransactionGroupParams) (TransactionGroup, error)
+
+		// TransactionGroup returns a group of transactions broadcast by the
+		// wallet.
+		TransactionGroup(id TransactionGroupID) (TransactionGroup, error)
+
+		// TransactionGroups returns all groups of transactions broadcast by
+		// the wallet.
+		TransactionGroups() ([]TransactionGroup, error)
+
+		// LastBroadcastFailures returns the most recent transaction sets
+		// which the transaction pool refused to accept from the wallet,
+		// newest first.
+		LastBroadcastFailures() []BroadcastFailure
+
+		// CreateNamedWallet creates a new wallet with its own seed and
+		// persist directory which is addressed by name, and opens it. The
+		// new wallet needs to be initialized before it can be used.
+		CreateNamedWallet(name string) (Wallet, error)
+
+		// OpenNamedWallet opens an existing named wallet.
+		OpenNamedWallet(name string) (Wallet, error)
+
+		// CloseNamedWallet closes an open named wallet.
+		CloseNamedWallet(name string) error
+
+		// NamedWallet returns the open named wallet with the given name.
+		NamedWallet(name string) (Wallet, error)
+
+		// NamedWallets returns all named wallets managed by the wallet.
+		NamedWallets() ([]NamedWalletInfo, error)
+
 		// SendSiacoinsFeeIncluded sends siacoins with fees included.
 		SendSiacoinsFeeIncluded(amount types.Currency, dest types.UnlockHash) ([]types.Transaction, error)
 
@@ -551,6 +958,11 @@ type (
 	// WalletSettings control the behavior of the Wallet.
 	WalletSettings struct {
 		NoDefrag bool `json:"nodefrag"`
+
+		// NFTRebroadcastBlocks is the number of blocks for which the wallet
+		// broadcasts unconfirmed NFT transactions again before giving up on
+		// them. 0 means the wallet never gives up.
+		NFTRebroadcastBlocks types.BlockHeight `json:"nftrebroadcastblocks"`
 	}
 )
 
@@ -560,6 +972,38 @@ func CalculateWalletTransactionID(tid types.TransactionID, oid types.OutputID) W
 	return WalletTransactionID(crypto.HashAll(tid, oid))
 }
 
+// String returns the hex representation of a TransactionGroupID.
+func (id TransactionGroupID) String() string {
+	return crypto.Hash(id).String()
+}
+
+// LoadString loads a TransactionGroupID from its hex representation.
+func (id *TransactionGroupID) LoadString(s string) error {
+	return (*crypto.Hash)(id).LoadString(s)
+}
+
+// MarshalJSON marshals a TransactionGroupID as a hex string.
+func (id TransactionGroupID) MarshalJSON() ([]byte, error) {
+	return crypto.Hash(id).MarshalJSON()
+}
+
+// UnmarshalJSON unmarshals a TransactionGroupID from a hex string.
+func (id *TransactionGroupID) UnmarshalJSON(b []byte) error {
+	return (*crypto.Hash)(id).UnmarshalJSON(b)
+}
+
+// Error implements the error interface. It describes the refused set, the
+// transaction which caused the failure and the fees of the set.
+func (bf BroadcastFailure) Error() string {
+	var culprit string
+	if bf.FailedIndex >= 0 {
+		culprit = fmt.Sprintf("transaction %v (%v) of %v: %v", bf.FailedIndex, bf.FailedTransactionID, len(bf.TransactionIDs), bf.ValidityError)
+	} else {
+		culprit = fmt.Sprintf("set of %v transactions: %v", len(bf.TransactionIDs), bf.PoolError)
+	}
+	return fmt.Sprintf("transaction pool refused %v (set fee %v/byte for %v bytes, minimum recommended fee %v/byte)", culprit, bf.SetFee.HumanString(), bf.SetSize, bf.MinimumFee.HumanString())
+}
+
 // SeedToString converts a wallet seed to a human friendly string.
 func SeedToString(seed Seed, did mnemonics.DictionaryID) (string, error) {
 	fullChecksum := crypto.HashObject(seed)
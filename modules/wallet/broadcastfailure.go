@@ -0,0 +1,74 @@
+package wallet
+
+import (
+	"time"
+
+	"gitlab.com/NebulousLabs/encoding"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// managedRecordBroadcastFailure diagnoses why the transaction pool refused the
+// transaction set txns with err and records the result. The transaction which
+// caused the failure is found by checking every transaction on its own and, for
+// consensus conflicts, by checking growing prefixes of the set against the
+// consensus set.
+func (w *Wallet) managedRecordBroadcastFailure(txns []types.Transaction, err error) modules.BroadcastFailure {
+	height := w.cs.Height()
+	minFee, _ := w.tpool.FeeEstimation()
+	failure := modules.BroadcastFailure{
+		Time:        time.Now(),
+		Height:      height,
+		FailedIndex: -1,
+		PoolError:   err.Error(),
+		SetFee:      modules.CalculateFee(txns),
+		SetSize:     uint64(len(encoding.Marshal(txns))),
+		MinimumFee:  minFee,
+	}
+	for _, txn := range txns {
+		failure.TransactionIDs = append(failure.TransactionIDs, txn.ID())
+	}
+
+	// Find the transaction which caused the failure.
+	for i, txn := range txns {
+		validityErr := txn.StandaloneValid(height)
+		if validityErr == nil {
+			validityErr = types.ValidateNFTTransaction(txn)
+		}
+		if validityErr != nil {
+			failure.FailedIndex = i
+			failure.ValidityError = validityErr.Error()
+			break
+		}
+	}
+	if failure.FailedIndex < 0 && modules.IsConsensusConflict(err) {
+		for i := range txns {
+			if _, validityErr := w.cs.TryTransactionSet(txns[:i+1]); validityErr != nil {
+				failure.FailedIndex = i
+				failure.ValidityError = validityErr.Error()
+				break
+			}
+		}
+	}
+	if failure.FailedIndex >= 0 {
+		failure.FailedTransactionID = failure.TransactionIDs[failure.FailedIndex]
+	}
+
+	w.mu.Lock()
+	w.broadcastFailures = append([]modules.BroadcastFailure{failure}, w.broadcastFailures...)
+	if len(w.broadcastFailures) > maxBroadcastFailures {
+		w.broadcastFailures = w.broadcastFailures[:maxBroadcastFailures]
+	}
+	w.mu.Unlock()
+	return failure
+}
+
+// LastBroadcastFailures returns the most recent transaction sets which the
+// transaction pool refused to accept from the wallet, newest first. Failures
+// are only kept in memory.
+func (w *Wallet) LastBroadcastFailures() []modules.BroadcastFailure {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return append([]modules.BroadcastFailure(nil), w.broadcastFailures...)
+}
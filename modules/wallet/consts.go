@@ -1,7 +1,10 @@
 package wallet
 
 import (
+	"time"
+
 	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/types"
 )
 
 const (
@@ -15,6 +18,10 @@ const (
 	// defragThreshold is the number of outputs a wallet is allowed before it is
 	// defragmented.
 	defragThreshold = 50
+
+	// maxBroadcastFailures is the number of refused transaction sets the
+	// wallet keeps for LastBroadcastFailures.
+	maxBroadcastFailures = 20
 )
 
 var (
@@ -33,6 +40,32 @@ var (
 		Standard: uint64(1000),
 		Testing:  uint64(10),
 	}).(uint64)
+
+	// transactionGroupBroadcastInterval is the interval at which the wallet
+	// broadcasts the unconfirmed transactions of transaction groups again if
+	// they dropped out of the transaction pool.
+	transactionGroupBroadcastInterval = build.Select(build.Var{
+		Dev:      30 * time.Second,
+		Standard: 10 * time.Minute,
+		Testing:  time.Second,
+	}).(time.Duration)
+
+	// nftRebroadcastBlocks is the default number of blocks for which the
+	// wallet broadcasts unconfirmed NFT transactions again before giving up on
+	// them.
+	nftRebroadcastBlocks = build.Select(build.Var{
+		Dev:      types.BlockHeight(50),
+		Standard: 3 * types.BlocksPerDay,
+		Testing:  types.BlockHeight(10),
+	}).(types.BlockHeight)
+
+	// transactionGroupPruneDepth is the number of confirmations after which
+	// a confirmed transaction group is no longer tracked by the wallet.
+	transactionGroupPruneDepth = build.Select(build.Var{
+		Dev:      types.BlockHeight(100),
+		Standard: types.BlockHeight(1008),
+		Testing:  types.BlockHeight(20),
+	}).(types.BlockHeight)
 )
 
 func init() {
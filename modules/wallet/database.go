@@ -46,6 +46,28 @@ var (
 	// bucketWallet contains various fields needed by the wallet, such as its
 	// UID, EncryptionVerification, and PrimarySeedFile.
 	bucketWallet = []byte("bucketWallet")
+	// bucketNFTDepositAddrs maps an NFT deposit address to the ID of the user
+	// it was created for.
+	bucketNFTDepositAddrs = []byte("bucketNFTDepositAddrs")
+	// bucketNFTDepositUsers maps the ID of a user to the NFT deposit address
+	// created for it. It is the reverse of bucketNFTDepositAddrs.
+	bucketNFTDepositUsers = []byte("bucketNFTDepositUsers")
+	// bucketNFTDeposits maps the SiacoinOutputID of an NFT's custody output
+	// to the NFTDeposit that created it. Only deposits to addresses in
+	// bucketNFTDepositAddrs are stored.
+	bucketNFTDeposits = []byte("bucketNFTDeposits")
+	// bucketTransactionGroups maps a TransactionGroupID to the
+	// TransactionGroup broadcast by the wallet.
+	bucketTransactionGroups = []byte("bucketTransactionGroups")
+	// bucketNFTMintTemplates maps the ID of an NFT mint template to the
+	// template.
+	bucketNFTMintTemplates = []byte("bucketNFTMintTemplates")
+	// bucketNFTScheduledTransfers maps the NftID of an NFT to its pending
+	// NFTScheduledTransfer.
+	bucketNFTScheduledTransfers = []byte("bucketNFTScheduledTransfers")
+	// bucketNFTUnlockables maps the NftID of an NFT to the
+	// NFTUnlockableEnvelope of its unlockable content.
+	bucketNFTUnlockables = []byte("bucketNFTUnlockables")
 
 	dbBuckets = [][]byte{
 		bucketProcessedTransactions,
@@ -56,6 +78,13 @@ var (
 		bucketSpentOutputs,
 		bucketUnlockConditions,
 		bucketWallet,
+		bucketNFTDepositAddrs,
+		bucketNFTDepositUsers,
+		bucketNFTDeposits,
+		bucketTransactionGroups,
+		bucketNFTMintTemplates,
+		bucketNFTScheduledTransfers,
+		bucketNFTUnlockables,
 	}
 
 	errNoKey = errors.New("key does not exist")
@@ -65,6 +94,7 @@ var (
 	keyConsensusChange        = []byte("keyConsensusChange")
 	keyConsensusHeight        = []byte("keyConsensusHeight")
 	keyEncryptionVerification = []byte("keyEncryptionVerification")
+	keyNFTCustodyProgress     = []byte("keyNFTCustodyProgress")
 	keyPrimarySeedFile        = []byte("keyPrimarySeedFile")
 	keyPrimarySeedProgress    = []byte("keyPrimarySeedProgress")
 	keySiafundPool            = []byte("keySiafundPool")
@@ -207,6 +237,10 @@ func dbPutSiacoinOutput(tx *bolt.Tx, id types.SiacoinOutputID, output types.Siac
 func dbDeleteSiacoinOutput(tx *bolt.Tx, id types.SiacoinOutputID) error {
 	return dbDelete(tx.Bucket(bucketSiacoinOutputs), id)
 }
+func dbGetSiacoinOutput(tx *bolt.Tx, id types.SiacoinOutputID) (output types.SiacoinOutput, err error) {
+	err = dbGet(tx.Bucket(bucketSiacoinOutputs), id, &output)
+	return
+}
 func dbForEachSiacoinOutput(tx *bolt.Tx, fn func(types.SiacoinOutputID, types.SiacoinOutput)) error {
 	return dbForEach(tx.Bucket(bucketSiacoinOutputs), fn)
 }
@@ -452,6 +486,23 @@ func dbPutPrimarySeedProgress(tx *bolt.Tx, progress uint64) error {
 	return tx.Bucket(bucketWallet).Put(keyPrimarySeedProgress, encoding.Marshal(progress))
 }
 
+// dbGetNFTCustodyProgress returns the number of keys generated from the NFT
+// custody account of the primary seed. Wallets created before the account
+// existed haven't generated any custody keys.
+func dbGetNFTCustodyProgress(tx *bolt.Tx) (progress uint64, err error) {
+	b := tx.Bucket(bucketWallet).Get(keyNFTCustodyProgress)
+	if b == nil {
+		return 0, nil
+	}
+	err = encoding.Unmarshal(b, &progress)
+	return
+}
+
+// dbPutNFTCustodyProgress sets the NFT custody account progress counter.
+func dbPutNFTCustodyProgress(tx *bolt.Tx, progress uint64) error {
+	return tx.Bucket(bucketWallet).Put(keyNFTCustodyProgress, encoding.Marshal(progress))
+}
+
 // dbGetConsensusChangeID returns the ID of the last ConsensusChange processed by the wallet.
 func dbGetConsensusChangeID(tx *bolt.Tx) (cc modules.ConsensusChangeID) {
 	copy(cc[:], tx.Bucket(bucketWallet).Get(keyConsensusChange))
@@ -3,6 +3,7 @@ package wallet
 import (
 	"sort"
 
+	"gitlab.com/NebulousLabs/bolt"
 	"gitlab.com/NebulousLabs/errors"
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/types"
@@ -12,6 +13,27 @@ var (
 	errDefragNotNeeded = errors.New("defragging not needed, wallet is already sufficiently defragged")
 )
 
+// isNFTPoolAddress returns true if the address is one of the pools that
+// receive the lockups, host payments and stakes of NFTs.
+func isNFTPoolAddress(uh types.UnlockHash) bool {
+	return uh == types.NFTLockupUnlockConditions.UnlockHash() ||
+		uh == types.NFTStoragePoolUnlockConditions.UnlockHash() ||
+		uh == types.NFTStakingPoolUnlockHash ||
+		uh == types.LiquidatedNFTUnlockHash
+}
+
+// defragOutput returns true if an output can be consolidated by a defrag.
+// Defrag transactions aren't tagged as NFT transactions, so spending the
+// custody output of an NFT would move the NFT to the defrag's refund address
+// without a trace in its provenance. Outputs of the NFT pools and outputs the
+// wallet can't sign for are never consolidated either.
+func (w *Wallet) defragOutput(tx *bolt.Tx, consensusHeight types.BlockHeight, scoid types.SiacoinOutputID, sco types.SiacoinOutput, dustThreshold types.Currency) bool {
+	if _, spendable := w.keys[sco.UnlockHash]; !spendable || isNFTPoolAddress(sco.UnlockHash) {
+		return false
+	}
+	return w.checkOutput(tx, consensusHeight, scoid, sco, dustThreshold, false) == nil
+}
+
 // managedCreateDefragTransaction creates a transaction that spends multiple existing
 // wallet outputs into a single new address.
 func (w *Wallet) managedCreateDefragTransaction() (_ []types.Transaction, err error) {
@@ -33,7 +55,7 @@ func (w *Wallet) managedCreateDefragTransaction() (_ []types.Transaction, err er
 	// Collect a value-sorted set of siacoin outputs.
 	var so sortedOutputs
 	err = dbForEachSiacoinOutput(w.dbTx, func(scoid types.SiacoinOutputID, sco types.SiacoinOutput) {
-		if w.checkOutput(w.dbTx, consensusHeight, scoid, sco, dustThreshold) == nil {
+		if w.defragOutput(w.dbTx, consensusHeight, scoid, sco, dustThreshold) {
 			so.ids = append(so.ids, scoid)
 			so.outputs = append(so.outputs, sco)
 		}
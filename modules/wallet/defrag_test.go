@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
 
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/modules"
@@ -329,3 +330,121 @@ func TestDefragInterrupted(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+// TestDefragWalletNFTs verifies that a defrag of a wallet holding many NFTs
+// doesn't consolidate the custody outputs of the NFTs.
+func TestDefragWalletNFTs(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	// Defrag manually to control when it runs.
+	settings, err := wt.wallet.Settings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings.NoDefrag = true
+	if err := wt.wallet.SetSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+
+	// Mint defragThreshold NFTs to ordinary addresses of the wallet.
+	var nfts []types.NftCustody
+	for i := 0; i < defragThreshold; i++ {
+		uc, err := wt.wallet.NextAddress()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var nft types.NftCustody
+		fastrand.Read(nft.FileMerkleRoot[:])
+		if _, err := wt.wallet.MintNFT(nft, uc.UnlockHash()); err != nil {
+			t.Fatal(err)
+		}
+		nfts = append(nfts, nft)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Create enough ordinary outputs for a defrag.
+	uc, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	outputs := make([]types.SiacoinOutput, defragThreshold+1)
+	for i := range outputs {
+		outputs[i] = types.SiacoinOutput{
+			Value:      types.SiacoinPrecision.Mul64(100),
+			UnlockHash: uc.UnlockHash(),
+		}
+	}
+	if _, err := wt.wallet.SendSiacoinsMulti(outputs); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	// None of the custody outputs can be consolidated, even if they aren't
+	// considered dust.
+	custodyOutputs := make(map[types.SiacoinOutputID]struct{})
+	for _, nft := range nfts {
+		id, sco, err := wt.wallet.managedNFTCustodyOutput(nft)
+		if err != nil {
+			t.Fatal(err)
+		}
+		custodyOutputs[id] = struct{}{}
+		wt.wallet.mu.Lock()
+		height, err := dbGetConsensusHeight(wt.wallet.dbTx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defrag := wt.wallet.defragOutput(wt.wallet.dbTx, height, id, sco, types.ZeroCurrency)
+		wt.wallet.mu.Unlock()
+		if defrag {
+			t.Fatal("custody output shouldn't be consolidated")
+		}
+	}
+
+	// Defrag the wallet.
+	txnSet, err := wt.wallet.managedCreateDefragTransaction()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, txn := range txnSet {
+		for _, sci := range txn.SiacoinInputs {
+			if _, exists := custodyOutputs[sci.ParentID]; exists {
+				t.Fatal("defrag spent the custody output of an nft")
+			}
+		}
+	}
+	if err := wt.tpool.AcceptTransactionSet(txnSet); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	// All NFTs are still held by the wallet without issues.
+	issues, err := wt.wallet.AuditNFTs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 0 {
+		t.Fatal("expected no audit issues but got", issues)
+	}
+	for _, nft := range nfts {
+		if _, _, err := wt.wallet.managedNFTCustodyOutput(nft); err != nil {
+			t.Fatal("nft was moved by the defrag", err)
+		}
+	}
+}
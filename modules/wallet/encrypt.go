@@ -192,6 +192,7 @@ func (w *Wallet) managedBlockingUnlock(masterKey crypto.CipherKey) (modules.Cons
 	var lastChange modules.ConsensusChangeID
 	var primarySeedFile seedFile
 	var primarySeedProgress uint64
+	var nftCustodyProgress uint64
 	var auxiliarySeedFiles []seedFile
 	var unseededKeyFiles []spendableKeyFile
 	var watchedAddrs []types.UnlockHash
@@ -218,6 +219,10 @@ func (w *Wallet) managedBlockingUnlock(masterKey crypto.CipherKey) (modules.Cons
 		if err != nil {
 			return err
 		}
+		nftCustodyProgress, err = dbGetNFTCustodyProgress(w.dbTx)
+		if err != nil {
+			return err
+		}
 
 		// auxiliarySeedFiles
 		err = encoding.Unmarshal(wb.Get(keyAuxiliarySeedFiles), &auxiliarySeedFiles)
@@ -256,6 +261,8 @@ func (w *Wallet) managedBlockingUnlock(masterKey crypto.CipherKey) (modules.Cons
 		w.integrateSeed(primarySeed, primarySeedProgress)
 		w.primarySeed = primarySeed
 		w.regenerateLookahead(primarySeedProgress)
+		w.integrateNFTCustodyKeys(nftCustodyProgress)
+		w.regenerateNFTCustodyLookahead(nftCustodyProgress)
 
 		// auxiliarySeedFiles
 		for _, sf := range auxiliarySeedFiles {
@@ -316,6 +323,11 @@ func (w *Wallet) managedAsyncUnlock(lastChange modules.ConsensusChangeID) error
 		go w.rescanMessage(done)
 		defer close(done)
 
+		// A light consensus set needs to know the addresses of the wallet
+		// before it can send their transactions.
+		if err := w.managedWatchLightAddresses(); err != nil {
+			return fmt.Errorf("failed to watch wallet addresses: %v", err)
+		}
 		err := w.cs.ConsensusSetSubscribe(w, lastChange, w.tg.StopChan())
 		if errors.Contains(err, modules.ErrInvalidConsensusChangeID) {
 			// something went wrong; resubscribe from the beginning
@@ -452,6 +464,8 @@ func (w *Wallet) Reset() error {
 	w.wipeSecrets()
 	w.keys = make(map[types.UnlockHash]spendableKey)
 	w.lookahead = make(map[types.UnlockHash]uint64)
+	w.nftCustodyAddrs = make(map[types.UnlockHash]uint64)
+	w.nftCustodyLookahead = make(map[types.UnlockHash]uint64)
 	w.seeds = []modules.Seed{}
 	w.unconfirmedProcessedTransactions = []modules.ProcessedTransaction{}
 	w.unlocked = false
@@ -0,0 +1,57 @@
+package wallet
+
+import (
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// light.go contains the support of the wallet for light consensus sets. A
+// light consensus set only follows the addresses it was told to watch, so the
+// wallet registers all of its addresses, including the lookahead, whenever
+// new ones are generated.
+
+// walletAddresses returns every address the wallet tracks on the blockchain.
+func (w *Wallet) walletAddresses() []types.UnlockHash {
+	addrs := make([]types.UnlockHash, 0, len(w.keys)+len(w.lookahead)+len(w.watchedAddrs)+len(w.nftCustodyLookahead))
+	for addr := range w.keys {
+		addrs = append(addrs, addr)
+	}
+	for addr := range w.lookahead {
+		addrs = append(addrs, addr)
+	}
+	for addr := range w.watchedAddrs {
+		addrs = append(addrs, addr)
+	}
+	for addr := range w.nftCustodyLookahead {
+		addrs = append(addrs, addr)
+	}
+	return addrs
+}
+
+// managedWatchLightAddresses registers the addresses of the wallet with the
+// consensus set if it is a light consensus set.
+func (w *Wallet) managedWatchLightAddresses() error {
+	lcs, ok := w.cs.(modules.LightConsensusSet)
+	if !ok {
+		return nil
+	}
+	w.mu.RLock()
+	addrs := w.walletAddresses()
+	w.mu.RUnlock()
+	return lcs.WatchAddresses(addrs)
+}
+
+// threadedWatchLightAddresses registers the addresses of the wallet with the
+// consensus set if it is a light consensus set.
+func (w *Wallet) threadedWatchLightAddresses() {
+	if _, ok := w.cs.(modules.LightConsensusSet); !ok {
+		return
+	}
+	if err := w.tg.Add(); err != nil {
+		return
+	}
+	defer w.tg.Done()
+	if err := w.managedWatchLightAddresses(); err != nil {
+		w.log.Println("WARN: failed to watch addresses of the wallet:", err)
+	}
+}
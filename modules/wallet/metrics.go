@@ -0,0 +1,76 @@
+package wallet
+
+import (
+	"go.sia.tech/siad/metrics"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// nftOperationsMetric counts the NFT operations the wallet submitted to
+	// the transaction pool.
+	nftOperationsMetric = metrics.NewCounterVec("siad_wallet_nft_operations_total", "Number of NFT operations submitted to the transaction pool by the wallet.", "operation")
+
+	// nftOperationFailuresMetric counts the NFT operations the transaction
+	// pool rejected.
+	nftOperationFailuresMetric = metrics.NewCounterVec("siad_wallet_nft_operation_failures_total", "Number of NFT operations of the wallet rejected by the transaction pool.", "operation")
+)
+
+// nftOperation returns the name of the NFT operation performed by a
+// transaction, or an empty string if the transaction isn't an NFT transaction.
+func nftOperation(txn types.Transaction) string {
+	switch {
+	case types.IsNFTMintTransaction(txn):
+		return "mint"
+	case types.IsNFTTransferTransaction(txn):
+		return "transfer"
+	case types.IsNFTLiquidationTransaction(txn):
+		return "liquidate"
+	case types.IsNFTReclaimTransaction(txn):
+		return "reclaim"
+	case types.IsNFTBridgeLockTransaction(txn):
+		return "bridgelock"
+	case types.IsNFTBridgeUnlockTransaction(txn):
+		return "bridgeunlock"
+	case types.IsNFTStakeTransaction(txn):
+		return "stake"
+	case types.IsNFTUnstakeTransaction(txn):
+		return "unstake"
+	case types.IsNFTStakeRewardTransaction(txn):
+		return "stakereward"
+	case types.IsNFTInsureTransaction(txn):
+		return "insure"
+	case types.IsNFTInsuranceChallengeTransaction(txn):
+		return "insurancechallenge"
+	case types.IsNFTInsuranceResponseTransaction(txn):
+		return "insuranceresponse"
+	case types.IsNFTInsuranceClaimTransaction(txn):
+		return "insuranceclaim"
+	case types.IsNFTInsuranceReleaseTransaction(txn):
+		return "insurancerelease"
+	case types.IsNFTRevokeApprovalTransaction(txn):
+		return "revokeapproval"
+	case types.IsNFTApproveTransaction(txn):
+		return "approve"
+	case types.IsNFTFreezeTransaction(txn):
+		return "freeze"
+	case types.IsNFTUnfreezeTransaction(txn):
+		return "unfreeze"
+	}
+	return ""
+}
+
+// recordNFTOperations counts the NFT operations of a transaction set which was
+// submitted to the transaction pool.
+func recordNFTOperations(txnSet []types.Transaction, err error) {
+	for _, txn := range txnSet {
+		op := nftOperation(txn)
+		if op == "" {
+			continue
+		}
+		if err != nil {
+			nftOperationFailuresMetric.With(op).Inc()
+		} else {
+			nftOperationsMetric.With(op).Inc()
+		}
+	}
+}
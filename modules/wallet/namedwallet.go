@@ -0,0 +1,187 @@
+package wallet
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+)
+
+// namedwallet.go allows a single wallet to manage additional wallets which are
+// addressed by name. Every named wallet is a full wallet with its own seed,
+// database and NFT holdings, which is stored in a subdirectory of the wallet
+// that manages it. Named wallets share the consensus set and transaction pool
+// of the managing wallet.
+
+const (
+	// namedWalletsDir is the directory within the wallet's persist directory
+	// that contains the named wallets.
+	namedWalletsDir = "wallets"
+)
+
+var (
+	// errInvalidWalletName is returned if a wallet name contains characters
+	// other than lowercase letters, digits, dashes and underscores.
+	errInvalidWalletName = errors.New("wallet name must consist of 1 to 64 lowercase letters, digits, dashes and underscores")
+
+	// errNamedWalletExists is returned when creating a named wallet that
+	// already exists.
+	errNamedWalletExists = errors.New("named wallet already exists")
+
+	// errNamedWalletNotFound is returned if a named wallet doesn't exist.
+	errNamedWalletNotFound = errors.New("named wallet doesn't exist")
+
+	// errNamedWalletNotOpen is returned if a named wallet exists but wasn't
+	// opened.
+	errNamedWalletNotOpen = errors.New("named wallet isn't open")
+
+	// errNestedNamedWallet is returned if a named wallet is asked to manage
+	// named wallets itself.
+	errNestedNamedWallet = errors.New("named wallets can't have named wallets")
+
+	// walletNameRegexp matches valid wallet names.
+	walletNameRegexp = regexp.MustCompile(`^[a-z0-9_-]{1,64}$`)
+)
+
+// namedWalletDir returns the persist directory of the named wallet with the
+// given name.
+func (w *Wallet) namedWalletDir(name string) string {
+	return filepath.Join(w.persistDir, namedWalletsDir, name)
+}
+
+// managedOpenNamedWallet opens the named wallet with the given name. If create
+// is true, the wallet must not exist yet, otherwise it must exist.
+func (w *Wallet) managedOpenNamedWallet(name string, create bool) (modules.Wallet, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	if w.staticNamed {
+		return nil, errNestedNamedWallet
+	}
+	if !walletNameRegexp.MatchString(name) {
+		return nil, errInvalidWalletName
+	}
+
+	// Only open one wallet at a time to avoid opening the same wallet twice.
+	w.namedWalletsMu.Lock()
+	defer w.namedWalletsMu.Unlock()
+	if nw, open := w.namedWallets[name]; open && !create {
+		return nw, nil
+	}
+
+	dir := w.namedWalletDir(name)
+	_, err := os.Stat(dir)
+	if create && err == nil {
+		return nil, errNamedWalletExists
+	} else if !create && os.IsNotExist(err) {
+		return nil, errNamedWalletNotFound
+	} else if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	nw, err := NewCustomWallet(w.cs, w.tpool, dir, w.deps)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to open named wallet")
+	}
+	nw.staticNamed = true
+	w.namedWallets[name] = nw
+	return nw, nil
+}
+
+// CreateNamedWallet creates a new named wallet and opens it. The wallet needs
+// to be initialized before it can be used.
+func (w *Wallet) CreateNamedWallet(name string) (modules.Wallet, error) {
+	return w.managedOpenNamedWallet(name, true)
+}
+
+// OpenNamedWallet opens an existing named wallet. Opening a wallet that is
+// already open returns the open wallet.
+func (w *Wallet) OpenNamedWallet(name string) (modules.Wallet, error) {
+	return w.managedOpenNamedWallet(name, false)
+}
+
+// CloseNamedWallet closes an open named wallet.
+func (w *Wallet) CloseNamedWallet(name string) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	w.namedWalletsMu.Lock()
+	nw, open := w.namedWallets[name]
+	delete(w.namedWallets, name)
+	w.namedWalletsMu.Unlock()
+	if !open {
+		return errNamedWalletNotOpen
+	}
+	return nw.Close()
+}
+
+// NamedWallet returns the open named wallet with the given name.
+func (w *Wallet) NamedWallet(name string) (modules.Wallet, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	w.namedWalletsMu.Lock()
+	defer w.namedWalletsMu.Unlock()
+	nw, open := w.namedWallets[name]
+	if open {
+		return nw, nil
+	}
+	if !walletNameRegexp.MatchString(name) {
+		return nil, errNamedWalletNotFound
+	}
+	if _, err := os.Stat(w.namedWalletDir(name)); err == nil {
+		return nil, errNamedWalletNotOpen
+	}
+	return nil, errNamedWalletNotFound
+}
+
+// NamedWallets returns all named wallets sorted by name.
+func (w *Wallet) NamedWallets() ([]modules.NamedWalletInfo, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	fis, err := ioutil.ReadDir(filepath.Join(w.persistDir, namedWalletsDir))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	w.namedWalletsMu.Lock()
+	defer w.namedWalletsMu.Unlock()
+	wallets := make([]modules.NamedWalletInfo, 0, len(fis))
+	for _, fi := range fis {
+		if !fi.IsDir() || !walletNameRegexp.MatchString(fi.Name()) {
+			continue
+		}
+		_, open := w.namedWallets[fi.Name()]
+		wallets = append(wallets, modules.NamedWalletInfo{
+			Name: fi.Name(),
+			Open: open,
+		})
+	}
+	sort.Slice(wallets, func(i, j int) bool {
+		return wallets[i].Name < wallets[j].Name
+	})
+	return wallets, nil
+}
+
+// closeNamedWallets closes all open named wallets.
+func (w *Wallet) closeNamedWallets() error {
+	w.namedWalletsMu.Lock()
+	defer w.namedWalletsMu.Unlock()
+	var errs error
+	for name, nw := range w.namedWallets {
+		errs = errors.Compose(errs, nw.Close())
+		delete(w.namedWallets, name)
+	}
+	return errs
+}
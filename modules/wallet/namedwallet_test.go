@@ -0,0 +1,138 @@
+package wallet
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestNamedWallets tests creating, opening and closing named wallets and that
+// named wallets are independent of the wallet managing them.
+func TestNamedWallets(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Invalid names are rejected.
+	for _, name := range []string{"", "Alice", "../alice", "alice/bob"} {
+		if _, err := wt.wallet.CreateNamedWallet(name); !errors.Contains(err, errInvalidWalletName) {
+			t.Fatalf("expected errInvalidWalletName for %q but got %v", name, err)
+		}
+	}
+	if _, err := wt.wallet.OpenNamedWallet("alice"); !errors.Contains(err, errNamedWalletNotFound) {
+		t.Fatal("expected errNamedWalletNotFound but got", err)
+	}
+
+	// Create a named wallet and initialize it.
+	nw, err := wt.wallet.CreateNamedWallet("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.wallet.CreateNamedWallet("alice"); !errors.Contains(err, errNamedWalletExists) {
+		t.Fatal("expected errNamedWalletExists but got", err)
+	}
+	if _, err := nw.CreateNamedWallet("bob"); !errors.Contains(err, errNestedNamedWallet) {
+		t.Fatal("expected errNestedNamedWallet but got", err)
+	}
+	key := crypto.GenerateSiaKey(crypto.TypeDefaultWallet)
+	seed, err := nw.Encrypt(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := nw.Unlock(key); err != nil {
+		t.Fatal(err)
+	}
+	primarySeed, _, err := wt.wallet.PrimarySeed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seed == primarySeed {
+		t.Fatal("named wallet should have its own seed")
+	}
+
+	// Send money to the named wallet.
+	uc, err := nw.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	amount := types.SiacoinPrecision.Mul64(100)
+	if _, err := wt.wallet.SendSiacoins(amount, uc.UnlockHash()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	balance, _, _, err := nw.ConfirmedBalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !balance.Equals(amount) {
+		t.Fatalf("expected balance %v but got %v", amount, balance)
+	}
+
+	// The wallet is listed and can be looked up while it's open.
+	wallets, err := wt.wallet.NamedWallets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wallets) != 1 || wallets[0].Name != "alice" || !wallets[0].Open {
+		t.Fatal("unexpected named wallets", wallets)
+	}
+	if w, err := wt.wallet.NamedWallet("alice"); err != nil || w != nw {
+		t.Fatal("expected to find open wallet", err)
+	}
+
+	// Close the wallet.
+	if err := wt.wallet.CloseNamedWallet("alice"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.wallet.NamedWallet("alice"); !errors.Contains(err, errNamedWalletNotOpen) {
+		t.Fatal("expected errNamedWalletNotOpen but got", err)
+	}
+	if err := wt.wallet.CloseNamedWallet("alice"); !errors.Contains(err, errNamedWalletNotOpen) {
+		t.Fatal("expected errNamedWalletNotOpen but got", err)
+	}
+	wallets, err = wt.wallet.NamedWallets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wallets) != 1 || wallets[0].Open {
+		t.Fatal("unexpected named wallets", wallets)
+	}
+
+	// Reopen the wallet. It should still have its seed and balance.
+	nw, err = wt.wallet.OpenNamedWallet("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := nw.Unlock(key); err != nil {
+		t.Fatal(err)
+	}
+	reopenedSeed, _, err := nw.PrimarySeed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopenedSeed != seed {
+		t.Fatal("seed changed after reopening the wallet")
+	}
+	balance, _, _, err = nw.ConfirmedBalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !balance.Equals(amount) {
+		t.Fatalf("expected balance %v but got %v", amount, balance)
+	}
+}
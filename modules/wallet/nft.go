@@ -1,8 +1,11 @@
 package wallet
 
 import (
+	"fmt"
+
 	"gitlab.com/NebulousLabs/errors"
 	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/types"
 )
@@ -11,26 +14,74 @@ import (
 /// all primary wallet operations
 /// Author: Ian McJohn
 
-// allow room for significant amounts of arbitrary data
-// in NFT transactions
-const estimatedNFTTransactionSize = estimatedTransactionSize * 2.0
+// EstimatedNFTTransactionSize is the size assumed when estimating the fee of
+// an NFT transaction. It allows room for significant amounts of arbitrary
+// data.
+const EstimatedNFTTransactionSize = estimatedTransactionSize * 2.0
+
+var (
+	// errNFTContentLengthWithoutType is returned when minting an NFT which
+	// commits to a content length but not to a content type.
+	errNFTContentLengthWithoutType = errors.New("nft content length requires a content type")
+
+	// errNFTLockupReclaimed is returned when reclaiming the lockup of an NFT
+	// whose lockup was already returned or was never tracked by consensus.
+	errNFTLockupReclaimed = errors.New("nft lockup was already reclaimed or is unknown")
+
+	// errNFTLockupNotVested is returned when reclaiming the lockup of an NFT
+	// before its vesting height.
+	errNFTLockupNotVested = errors.New("nft lockup is not vested yet")
+
+	// errNFTBridgeNotMultisig is returned when locking an NFT for a bridge
+	// whose unlock conditions don't require enough signatures.
+	errNFTBridgeNotMultisig = errors.New("nft bridge must require multiple signatures")
+
+	// errNFTBridgeLocked is returned when locking an NFT which is already
+	// locked by a bridge.
+	errNFTBridgeLocked = errors.New("nft is already locked by a bridge")
+
+	// errNFTCreatorKey is returned when minting an NFT with an identity to
+	// an address which is not a single-key address of this wallet.
+	errNFTCreatorKey = errors.New("nft with an identity must be minted to a single-key address of this wallet")
+
+	// errNFTIDExists is returned when minting an NFT with an identity whose
+	// NftID was already minted.
+	errNFTIDExists = errors.New("nft with the same id was already minted")
+
+	// errNFTEditionsMismatch is returned when minting an edition whose
+	// number of editions differs from the earlier editions of its run.
+	errNFTEditionsMismatch = errors.New("nft edition doesn't match the number of editions of its run")
+
+	// errNFTNotTransferable is returned when transferring or bridge locking
+	// an NFT whose transfer policy doesn't allow it in the next block.
+	errNFTNotTransferable = errors.New("nft transfer policy doesn't allow the transfer")
+
+	// errNFTNotInWallet is returned when the output which holds the custody
+	// of an NFT isn't tracked by the wallet.
+	errNFTNotInWallet = errors.New("nft custody output is not held by this wallet")
+)
 
 // Random valid address to use for NFT Lockup
 // TODO: Switch to anyone-can-spend outputs
 
-func signAndSend(w *Wallet, txnBuilder *(modules.TransactionBuilder)) (txns []types.Transaction, err error) {
+// signAndSend signs the transaction of txnBuilder and broadcasts it. The
+// broadcast is skipped with an error if the dependencies disrupt on disrupt,
+// which simulates a crash between signing and submitting the transaction.
+func signAndSend(w *Wallet, txnBuilder *(modules.TransactionBuilder), disrupt string) (txns []types.Transaction, err error) {
 	txnSet, err := (*txnBuilder).Sign(true)
 	if err != nil {
 		w.log.Println("Attempt to send coins has failed - failed to sign transaction:", err)
 		return nil, build.ExtendErr("unable to sign transaction", err)
 	}
-	if w.deps.Disrupt("SendSiacoinsInterrupted") {
-		return nil, errors.New("failed to accept transaction set (SendSiacoinsInterrupted)")
+	if w.deps.Disrupt(disrupt) {
+		return nil, errors.New("failed to accept transaction set (" + disrupt + ")")
 	}
-	err = w.tpool.AcceptTransactionSet(txnSet)
+	// Refused sets are returned as a modules.BroadcastFailure, which
+	// explains why the transaction pool refused them.
+	err = w.managedBroadcastNFTTransactions(txnSet)
 	if err != nil {
 		w.log.Println("Attempt to send coins has failed - transaction pool rejected transaction:", err)
-		return nil, build.ExtendErr("unable to get transaction accepted", err)
+		return nil, err
 	}
 	for _, txn := range txnSet {
 		w.log.Println("\t", txn.ID())
@@ -61,12 +112,48 @@ func preNFTWalletSetup(w *Wallet) (txns []types.Transaction, err error) {
 }
 
 func (w *Wallet) MintNFT(nft types.NftCustody, dest types.UnlockHash) (txns []types.Transaction, err error) {
+	return w.managedMintNFT(nft, types.NFTArbitraryData(types.NFTMintTag, nft), dest)
+}
+
+// MintReferencedNFT mints an NFT like MintNFT and records the IPFS CID or
+// multihash of its data in the mint.
+func (w *Wallet) MintReferencedNFT(nft types.NftCustody, ref types.NFTContentReference, dest types.UnlockHash) ([]types.Transaction, error) {
+	if err := ref.Validate(); err != nil {
+		return nil, err
+	}
+	return w.managedMintNFT(nft, types.NFTContentReferenceArbitraryData(nft, ref), dest)
+}
+
+// MintLicensedNFT mints an NFT like MintNFT and commits to the license the
+// NFT's data may be used under in the mint.
+func (w *Wallet) MintLicensedNFT(nft types.NftCustody, license types.NFTLicense, dest types.UnlockHash) ([]types.Transaction, error) {
+	if err := license.Validate(); err != nil {
+		return nil, err
+	}
+	return w.managedMintNFT(nft, types.NFTLicenseArbitraryData(nft, license), dest)
+}
+
+// managedMintNFT builds, signs and submits the mint of an NFT whose NFT
+// arbitrary data entry is arb.
+func (w *Wallet) managedMintNFT(nft types.NftCustody, arb []byte, dest types.UnlockHash) (txns []types.Transaction, err error) {
 	// Add to threadgroup, check locks
 	_, err = preNFTWalletSetup(w)
 	if err != nil {
 		return nil, err // setup failed, pass the error on
 	}
 
+	// Check the content commitment before paying for the mint
+	if nft.HasContentCommitment() {
+		if err := types.ValidateNFTContentType(nft.ContentType); err != nil {
+			return nil, err
+		}
+	} else if nft.ContentLength != 0 {
+		return nil, errNFTContentLengthWithoutType
+	}
+	if err := nft.TransferPolicy.Validate(); err != nil {
+		return nil, err
+	}
+
 	// Create outputs for lockup pool, host pool, and colored-coin custody
 	lockupOutput := types.SiacoinOutput{
 		UnlockHash: types.NFTLockupUnlockConditions.UnlockHash(),
@@ -74,7 +161,7 @@ func (w *Wallet) MintNFT(nft types.NftCustody, dest types.UnlockHash) (txns []ty
 	}
 	storagePoolOutput := types.SiacoinOutput{
 		UnlockHash: types.NFTStoragePoolUnlockConditions.UnlockHash(),
-		Value:      types.NFTLockupAmount,
+		Value:      types.NFTHostAmount,
 	}
 	NFTMintingOutput := types.SiacoinOutput{
 		UnlockHash: dest,
@@ -83,7 +170,7 @@ func (w *Wallet) MintNFT(nft types.NftCustody, dest types.UnlockHash) (txns []ty
 
 	// Assemble transaction and fund
 	_, fee := w.tpool.FeeEstimation()
-	fee = fee.Mul64(estimatedNFTTransactionSize)
+	fee = fee.Mul64(EstimatedNFTTransactionSize)
 	totalCost := types.NFTHostAmount.Add(types.NFTLockupAmount).Add(types.OneBaseUnit).Add(fee)
 	txnBuilder, err := w.StartTransaction()
 	if err != nil {
@@ -102,19 +189,64 @@ func (w *Wallet) MintNFT(nft types.NftCustody, dest types.UnlockHash) (txns []ty
 	txnBuilder.AddMinerFee(fee)
 
 	// Add Arbitrary Data specifier to prove NFT Minting Transaction for validators
-	arbitraryData := types.PrefixNFTCustody[:]
-	merkleRoot := []byte(nft.FileMerkleRoot.String())
-	arbitraryData = append(arbitraryData, types.NFTMintTag...)
-	arbitraryData = append(arbitraryData, merkleRoot...)
-	txnBuilder.AddArbitraryData(arbitraryData)
+	txnBuilder.AddArbitraryData(arb)
 
 	// Include outputs in transaction and send
 	txnBuilder.AddSiacoinOutput(lockupOutput)
 	txnBuilder.AddSiacoinOutput(storagePoolOutput)
 	txnBuilder.AddSiacoinOutput(NFTMintingOutput)
 
-	w.log.Println("Submitting an NFT Minting transaction for nft", nft.FileMerkleRoot, "with fees", fee.HumanString())
-	return signAndSend(w, &txnBuilder)
+	w.log.Println("Submitting an NFT Minting transaction for nft", nft.Identifier(), "with fees", fee.HumanString())
+	return signAndSend(w, &txnBuilder, "InterruptNFTMintBeforeBroadcast")
+}
+
+// MintIdentifiedNFT mints an NFT whose NftID is derived from the creator's
+// public key, the collection, the NFT's merkle root and the nonce, so that the
+// same data can be minted as several distinct NFTs. The key of dest becomes the
+// creator of the NFT. The minted NFT is returned together with its NftID.
+func (w *Wallet) MintIdentifiedNFT(nft types.NftCustody, collection string, nonce uint64, dest types.UnlockHash) (types.NftCustody, []types.Transaction, error) {
+	if err := types.ValidateNFTCollection(collection); err != nil {
+		return types.NftCustody{}, nil, err
+	}
+	w.mu.RLock()
+	key, exists := w.keys[dest]
+	w.mu.RUnlock()
+	if !exists || len(key.UnlockConditions.PublicKeys) != 1 {
+		return types.NftCustody{}, nil, errNFTCreatorKey
+	}
+	copy(nft.Creator[:], key.UnlockConditions.PublicKeys[0].Key)
+	if nft.CreatorUnlockHash() != dest {
+		return types.NftCustody{}, nil, errNFTCreatorKey
+	}
+	nft.Collection = collection
+	nft.Nonce = nonce
+	nft.ID = types.DeriveNftID(nft.Creator, nft.Collection, nft.FileMerkleRoot, nft.Nonce)
+	if _, err := w.cs.ViewNFTCustody(nft); err == nil {
+		return types.NftCustody{}, nil, errNFTIDExists
+	}
+	txns, err := w.MintNFT(nft, dest)
+	if err != nil {
+		return types.NftCustody{}, nil, err
+	}
+	return nft, txns, nil
+}
+
+// MintNFTEdition mints edition number edition out of a limited run of editions
+// of the same data. Editions are identified mints whose nonce is the index of
+// the edition, and all editions of a run need to be minted by the same creator
+// into the same collection with the same number of editions.
+func (w *Wallet) MintNFTEdition(nft types.NftCustody, collection string, edition, editions uint64, dest types.UnlockHash) (types.NftCustody, []types.Transaction, error) {
+	if err := types.ValidateNFTEdition(edition, editions); err != nil {
+		return types.NftCustody{}, nil, err
+	}
+	for _, stats := range w.cs.FindNFTEditions(nft.FileMerkleRoot) {
+		minted := stats.Nft
+		if minted.CreatorUnlockHash() == dest && minted.Collection == collection && minted.Editions != editions {
+			return types.NftCustody{}, nil, errors.AddContext(errNFTEditionsMismatch, fmt.Sprintf("run has %v editions", minted.Editions))
+		}
+	}
+	nft.Editions = editions
+	return w.MintIdentifiedNFT(nft, collection, edition, dest)
 }
 
 func (w *Wallet) TransferNFT(nft types.NftCustody, dest types.UnlockHash) (txns []types.Transaction, err error) {
@@ -124,6 +256,58 @@ func (w *Wallet) TransferNFT(nft types.NftCustody, dest types.UnlockHash) (txns
 		return nil, err // setup failed, pass the error on
 	}
 
+	// Check the transfer policy before paying for the transfer
+	if err := w.managedCheckNFTTransferPolicy(nft); err != nil {
+		return nil, err
+	}
+	if err := w.managedCheckNFTUnstaked(nft); err != nil {
+		return nil, err
+	}
+	if err := w.managedCheckNFTNotFrozen(nft); err != nil {
+		return nil, err
+	}
+
+	// Locate NFT output from previous chain-of-custody
+	goal_scoid, goal_sco, err := w.managedNFTCustodyOutput(nft)
+	if err != nil {
+		w.log.Println("Attempt to locate NFT chain-of-custody has failed, perhaps sending an NFT that is not ours?")
+		return nil, err
+	}
+
+	txnSet, txnBuilder, err := w.managedBuildNFTTransfer(nft, goal_scoid, goal_sco, dest)
+	if err != nil {
+		return nil, err
+	}
+	if w.deps.Disrupt("InterruptNFTTransferBeforeBroadcast") {
+		txnBuilder.Drop()
+		return nil, errors.New("failed to accept transaction set (InterruptNFTTransferBeforeBroadcast)")
+	}
+	err = w.managedBroadcastNFTTransactions(txnSet)
+	if err != nil {
+		txnBuilder.Drop()
+		w.log.Println("Attempt to send coins has failed - transaction pool rejected transaction:", err)
+		return nil, build.ExtendErr("unable to get transaction accepted", err)
+	}
+	for _, txn := range txnSet {
+		w.log.Println("\t", txn.ID())
+	}
+	return txnSet, nil
+}
+
+// managedBuildNFTTransfer builds and signs a transaction set which transfers
+// the NFT held by the output with id scoid to dest. The transaction set is not
+// submitted to the transaction pool. The returned builder needs to be dropped
+// if the set doesn't make it into the transaction pool.
+func (w *Wallet) managedBuildNFTTransfer(nft types.NftCustody, scoid types.SiacoinOutputID, sco types.SiacoinOutput, dest types.UnlockHash) (_ []types.Transaction, _ modules.TransactionBuilder, err error) {
+	return w.managedBuildNFTCustodyTransfer(types.NFTArbitraryData(types.NFTTransferTag, nft), nft, scoid, sco, dest, nil)
+}
+
+// managedBuildNFTCustodyTransfer builds and signs a transaction set which pays
+// the transfer fee and moves the NFT held by the output with id scoid to dest.
+// arb is the NFT arbitrary data entry of the transfer. The payments are paid
+// by the same transaction, after the colored coin. Like
+// managedBuildNFTTransfer, the set is not submitted to the transaction pool.
+func (w *Wallet) managedBuildNFTCustodyTransfer(arb []byte, nft types.NftCustody, scoid types.SiacoinOutputID, sco types.SiacoinOutput, dest types.UnlockHash, payments []types.SiacoinOutput) (_ []types.Transaction, _ modules.TransactionBuilder, err error) {
 	// Create outputs for transfer fees into host pool, and colored-coin custody
 	storagePoolOutput := types.SiacoinOutput{
 		UnlockHash: types.NFTStoragePoolUnlockConditions.UnlockHash(),
@@ -136,11 +320,14 @@ func (w *Wallet) TransferNFT(nft types.NftCustody, dest types.UnlockHash) (txns
 
 	// Assemble transaction and fund
 	_, fee := w.tpool.FeeEstimation()
-	fee = fee.Mul64(estimatedNFTTransactionSize)
+	fee = fee.Mul64(EstimatedNFTTransactionSize + 60*uint64(len(payments)))
 	totalCost := types.NFTTransferCost.Add(fee)
+	for _, payment := range payments {
+		totalCost = totalCost.Add(payment.Value)
+	}
 	txnBuilder, err := w.StartTransaction()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer func() {
 		if err != nil {
@@ -150,53 +337,93 @@ func (w *Wallet) TransferNFT(nft types.NftCustody, dest types.UnlockHash) (txns
 	err = txnBuilder.FundSiacoins(totalCost)
 	if err != nil {
 		w.log.Println("Attempt to send coins has failed - failed to fund transaction:", err)
-		return nil, build.ExtendErr("unable to fund transaction", err)
+		return nil, nil, build.ExtendErr("unable to fund transaction", err)
 	}
 	txnBuilder.AddMinerFee(fee)
 
-	// Locate NFT output from previous chain-of-custody
-	goalOutput, err := w.cs.ViewNFTCustody(nft)
-	if err != nil {
-		w.log.Println("Attempt to send NFT has failed - Could not locate NFT output for transfer")
-		return nil, build.ExtendErr("unable to locate NFT output for transfer", err)
-	}
-	var goal_scoid types.SiacoinOutputID
-	var goal_sco types.SiacoinOutput
-	var found bool = false
-	err = dbForEachSiacoinOutput(w.dbTx, func(scoid types.SiacoinOutputID, sco types.SiacoinOutput) {
-		if sco.Value.Equals(goalOutput.Value) && sco.UnlockHash == goalOutput.UnlockHash {
-			// Not guaranteed to be the same output that was used to transfer the NFT to this address
-			// but as far as I know that shouldn't cause any problems? Haven't yet found a use-case
-			// where it needs to be the same one. If it does we can start recording output ids in applytransaction
-			goal_scoid = scoid
-			goal_sco = sco
-			found = true
-		}
-	})
-	if err != nil || !found {
-		w.log.Println("Attempt to locate NFT chain-of-custody has failed, perhaps sending an NFT that is not ours?")
-		return nil, build.ExtendErr("unable to locate NFT within our wallet", err)
-	}
-
 	// Transform into input
+	w.mu.RLock()
+	key, exists := w.keys[sco.UnlockHash]
+	w.mu.RUnlock()
+	if !exists {
+		return nil, nil, errors.New("NFT is held by an address that doesn't belong to the wallet")
+	}
 	sci := types.SiacoinInput{
-		ParentID:         goal_scoid,
-		UnlockConditions: w.keys[goal_sco.UnlockHash].UnlockConditions,
+		ParentID:         scoid,
+		UnlockConditions: key.UnlockConditions,
 	}
 	txnBuilder.AddAndSignSiacoinInput(sci)
 
-	// Add Arbitrary Data specifier to prove NFT Minting Transaction for validators
-	arbitraryData := types.PrefixNFTCustody[:]
-	merkleRoot := []byte(nft.FileMerkleRoot.String())
-	arbitraryData = append(arbitraryData, types.NFTTransferTag...)
-	arbitraryData = append(arbitraryData, merkleRoot...)
-	txnBuilder.AddArbitraryData(arbitraryData)
+	// Add Arbitrary Data specifier to prove NFT Transfer Transaction for validators
+	txnBuilder.AddArbitraryData(arb)
 
-	// Include outputs in transaction and send
+	// Include outputs in transaction and sign
 	txnBuilder.AddSiacoinOutput(storagePoolOutput)
 	txnBuilder.AddSiacoinOutput(NFTTransferOutput)
-	w.log.Println("Submitting an NFT Transfer transaction for nft", nft.FileMerkleRoot, "with fees", fee.HumanString(), "IDs:")
-	return signAndSend(w, &txnBuilder)
+	for _, payment := range payments {
+		txnBuilder.AddSiacoinOutput(payment)
+	}
+	w.log.Println("Submitting an NFT Transfer transaction for nft", nft.Identifier(), "with fees", fee.HumanString(), "IDs:")
+	txnSet, err := txnBuilder.Sign(true)
+	if err != nil {
+		w.log.Println("Attempt to send coins has failed - failed to sign transaction:", err)
+		return nil, nil, build.ExtendErr("unable to sign transaction", err)
+	}
+	return txnSet, txnBuilder, nil
+}
+
+// managedNFTCustodyOutput returns the id of the wallet's output which holds the
+// custody of an NFT together with the output. The output is identified by the
+// id of the custody output of the most recent confirmed transaction which set
+// the custody of the NFT, not by its value and address, so that a different
+// output to the same address can't be picked while the wallet is updated.
+func (w *Wallet) managedNFTCustodyOutput(nft types.NftCustody) (types.SiacoinOutputID, types.SiacoinOutput, error) {
+	goalOutput, err := w.cs.ViewNFTCustody(nft)
+	if err != nil {
+		return types.SiacoinOutputID{}, types.SiacoinOutput{}, build.ExtendErr("unable to locate NFT output", err)
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, exists := w.keys[goalOutput.UnlockHash]; !exists {
+		return types.SiacoinOutputID{}, types.SiacoinOutput{}, errors.AddContext(errNFTNotInWallet, "unable to locate NFT within our wallet")
+	}
+	scoid, err := w.nftCustodyOutputID(nft, goalOutput)
+	if err != nil {
+		return types.SiacoinOutputID{}, types.SiacoinOutput{}, errors.AddContext(errors.Compose(err, errNFTNotInWallet), "unable to locate NFT within our wallet")
+	}
+	return scoid, goalOutput, nil
+}
+
+// nftCustodyOutputID returns the id of the unspent wallet output which holds
+// the custody of an NFT. The transactions of the custody address are searched
+// from newest to oldest for the last one which set the custody of the NFT.
+func (w *Wallet) nftCustodyOutputID(nft types.NftCustody, goalOutput types.SiacoinOutput) (types.SiacoinOutputID, error) {
+	txns, err := dbGetAddrTransactions(w.dbTx, goalOutput.UnlockHash)
+	if err != nil {
+		return types.SiacoinOutputID{}, err
+	}
+	id := nft.Identifier()
+	for i := len(txns) - 1; i >= 0; i-- {
+		pt, err := dbGetProcessedTransaction(w.dbTx, txns[i])
+		if err != nil || !types.UpdatesNFTCustody(pt.Transaction) {
+			continue
+		}
+		txnNFT, owner := types.ExtractNFTFromTransaction(pt.Transaction)
+		index, ok := types.NFTCustodyOutputIndex(pt.Transaction)
+		if !ok || txnNFT.Identifier() != id || owner.UnlockHash != goalOutput.UnlockHash {
+			continue
+		}
+		scoid := pt.Transaction.SiacoinOutputID(index)
+		sco, err := dbGetSiacoinOutput(w.dbTx, scoid)
+		if err != nil {
+			return types.SiacoinOutputID{}, errors.AddContext(err, "custody output is not an unspent wallet output")
+		}
+		if !sco.Value.Equals(goalOutput.Value) {
+			return types.SiacoinOutputID{}, errors.New("custody output doesn't match the custody of the NFT")
+		}
+		return scoid, nil
+	}
+	return types.SiacoinOutputID{}, errors.New("no confirmed transaction set the custody of the NFT to the wallet")
 }
 
 // Liquidate an NFT, transferring the total value of
@@ -207,16 +434,25 @@ func (w *Wallet) LiquidateNFT(nft types.NftCustody, dest types.UnlockHash) (txns
 	if err != nil {
 		return nil, err // setup failed, pass the error on
 	}
+	if err := w.managedCheckNFTUnstaked(nft); err != nil {
+		return nil, err
+	}
+	if err := w.managedCheckNFTNotFrozen(nft); err != nil {
+		return nil, err
+	}
 
 	// Create outputs for transfer fees into host pool, and colored-coin custody
 	NFTLiquidationOutput := types.SiacoinOutput{
 		UnlockHash: dest,
 		Value:      types.NFTLockupAmount, // Liquidation money minted here to match initial burn
 	}
+	// If the lockup was already reclaimed, liquidating only burns the NFT
+	lockup, err := w.cs.ViewNFTLockup(nft)
+	reclaimed := err == nil && lockup.Reclaimed
 
 	// Assemble transaction and fund
 	_, fee := w.tpool.FeeEstimation()
-	fee = fee.Mul64(estimatedNFTTransactionSize)
+	fee = fee.Mul64(EstimatedNFTTransactionSize)
 	totalCost := fee
 	txnBuilder, err := w.StartTransaction()
 	if err != nil {
@@ -234,28 +470,16 @@ func (w *Wallet) LiquidateNFT(nft types.NftCustody, dest types.UnlockHash) (txns
 	}
 	txnBuilder.AddMinerFee(fee.Add(types.OneBaseUnit)) // burn the 1SC nft custody token as a miner fee (gotta do smth with it)
 
+	// Check the transfer policy before paying for the transfer
+	if err := w.managedCheckNFTTransferPolicy(nft); err != nil {
+		return nil, err
+	}
+
 	// Locate NFT output from previous chain-of-custody
-	goalOutput, err := w.cs.ViewNFTCustody(nft)
+	goal_scoid, goal_sco, err := w.managedNFTCustodyOutput(nft)
 	if err != nil {
-		w.log.Println("Attempt to send NFT has failed - Could not locate NFT output for transfer")
-		return nil, build.ExtendErr("unable to locate NFT output for transfer", err)
-	}
-	var goal_scoid types.SiacoinOutputID
-	var goal_sco types.SiacoinOutput
-	var found bool = false
-	err = dbForEachSiacoinOutput(w.dbTx, func(scoid types.SiacoinOutputID, sco types.SiacoinOutput) {
-		if sco.Value.Equals(goalOutput.Value) && sco.UnlockHash == goalOutput.UnlockHash {
-			// Not guaranteed to be the same output that was used to transfer the NFT to this address
-			// but as far as I know that shouldn't cause any problems? Haven't yet found a use-case
-			// where it needs to be the same one. If it does we can start recording output ids in applytransaction
-			goal_scoid = scoid
-			goal_sco = sco
-			found = true
-		}
-	})
-	if err != nil || !found {
 		w.log.Println("Attempt to locate NFT chain-of-custody has failed, perhaps sending an NFT that is not ours?")
-		return nil, build.ExtendErr("unable to locate NFT within our wallet", err)
+		return nil, err
 	}
 
 	// Transform into input
@@ -266,16 +490,167 @@ func (w *Wallet) LiquidateNFT(nft types.NftCustody, dest types.UnlockHash) (txns
 	txnBuilder.AddAndSignSiacoinInput(sci)
 
 	// Add Arbitrary Data specifier to prove NFT Minting Transaction for validators
-	arbitraryData := types.PrefixNFTCustody[:]
-	merkleRoot := []byte(nft.FileMerkleRoot.String())
-	arbitraryData = append(arbitraryData, types.NFTLiquidationTag...)
-	arbitraryData = append(arbitraryData, merkleRoot...)
-	txnBuilder.AddArbitraryData(arbitraryData)
+	txnBuilder.AddArbitraryData(types.NFTArbitraryData(types.NFTLiquidationTag, nft))
 
 	// Include outputs in transaction and send
-	txnBuilder.AddSiacoinOutput(NFTLiquidationOutput)
-	w.log.Println("Submitting an NFT Liquidation transaction for nft", nft.FileMerkleRoot, "with fees", fee.HumanString(), "IDs:")
-	return signAndSend(w, &txnBuilder)
+	if !reclaimed {
+		txnBuilder.AddSiacoinOutput(NFTLiquidationOutput)
+	}
+
+	// Returning the lockup needs the authorization of the governance. The
+	// signatures only cover the current fields of the transaction, so that
+	// the governance can add its input before co-signing it.
+	if !reclaimed && types.NFTGovernanceActive(w.cs.Height()+1) {
+		txns, err = txnBuilder.Sign(false)
+		if err != nil {
+			return nil, build.ExtendErr("unable to sign transaction", err)
+		}
+		w.log.Println("Built an NFT Liquidation transaction for nft", nft.Identifier(), "which needs to be co-signed by the governance")
+		return txns, nil
+	}
+	w.log.Println("Submitting an NFT Liquidation transaction for nft", nft.Identifier(), "with fees", fee.HumanString(), "IDs:")
+	return signAndSend(w, &txnBuilder, "SendSiacoinsInterrupted")
+}
+
+// ReclaimNFTLockup returns the vested lockup of an NFT held by the wallet to
+// dest. Unlike a liquidation the wallet keeps the NFT, which is moved to a new
+// custody output of the same address.
+func (w *Wallet) ReclaimNFTLockup(nft types.NftCustody, dest types.UnlockHash) (txns []types.Transaction, err error) {
+	// Add to threadgroup, check locks
+	_, err = preNFTWalletSetup(w)
+	if err != nil {
+		return nil, err // setup failed, pass the error on
+	}
+
+	// Check that the lockup can be reclaimed
+	lockup, err := w.cs.ViewNFTLockup(nft)
+	if err != nil || lockup.Reclaimed {
+		return nil, errNFTLockupReclaimed
+	}
+	if w.cs.Height()+1 < lockup.VestingHeight() {
+		return nil, errors.AddContext(errNFTLockupNotVested, fmt.Sprintf("vests at height %v", lockup.VestingHeight()))
+	}
+	if err := w.managedCheckNFTNotFrozen(nft); err != nil {
+		return nil, err
+	}
+
+	// Locate NFT output from previous chain-of-custody
+	goal_scoid, goalOutput, err := w.managedNFTCustodyOutput(nft)
+	if err != nil {
+		w.log.Println("Attempt to reclaim NFT lockup has failed:", err)
+		return nil, err
+	}
+	w.mu.RLock()
+	key := w.keys[goalOutput.UnlockHash]
+	w.mu.RUnlock()
+
+	// Assemble transaction and fund
+	_, fee := w.tpool.FeeEstimation()
+	fee = fee.Mul64(EstimatedNFTTransactionSize)
+	txnBuilder, err := w.StartTransaction()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			txnBuilder.Drop()
+		}
+	}()
+	err = txnBuilder.FundSiacoins(fee)
+	if err != nil {
+		w.log.Println("Attempt to send coins has failed - failed to fund transaction:", err)
+		return nil, build.ExtendErr("unable to fund transaction", err)
+	}
+	txnBuilder.AddMinerFee(fee)
+
+	// Transform into input
+	sci := types.SiacoinInput{
+		ParentID:         goal_scoid,
+		UnlockConditions: key.UnlockConditions,
+	}
+	txnBuilder.AddAndSignSiacoinInput(sci)
+
+	// Add Arbitrary Data specifier to prove NFT Reclaim Transaction for validators
+	txnBuilder.AddArbitraryData(types.NFTArbitraryData(types.NFTReclaimTag, nft))
+
+	// Keep the NFT at the same address and pay out the lockup
+	txnBuilder.AddSiacoinOutput(goalOutput)
+	txnBuilder.AddSiacoinOutput(types.SiacoinOutput{
+		UnlockHash: dest,
+		Value:      types.NFTLockupAmount,
+	})
+	w.log.Println("Submitting an NFT Lockup Reclaim transaction for nft", nft.Identifier(), "with fees", fee.HumanString(), "IDs:")
+	return signAndSend(w, &txnBuilder, "SendSiacoinsInterrupted")
+}
+
+// managedCheckNFTTransferPolicy returns errNFTNotTransferable if the transfer
+// policy of an NFT doesn't allow moving it to another address in the next
+// block.
+func (w *Wallet) managedCheckNFTTransferPolicy(nft types.NftCustody) error {
+	policy := w.cs.ViewNFTTransferPolicy(nft)
+	if !policy.AllowsTransfer(w.cs.Height() + 1) {
+		return errors.AddContext(errNFTNotTransferable, fmt.Sprintf("%v policy with height %v", policy.Kind, policy.Height))
+	}
+	return nil
+}
+
+// BridgeLockNFT locks an NFT held by the wallet for a cross-chain bridge. The
+// NFT is moved to the address of the bridge's unlock conditions together with
+// the claim on the foreign chain. Only the bridge's attestors can release it
+// again, which is why the bridge needs to require multiple signatures.
+func (w *Wallet) BridgeLockNFT(nft types.NftCustody, bridge types.UnlockConditions, claim types.NFTBridgeClaim) (txns []types.Transaction, err error) {
+	// Add to threadgroup, check locks
+	_, err = preNFTWalletSetup(w)
+	if err != nil {
+		return nil, err // setup failed, pass the error on
+	}
+
+	// Check the bridge and the claim before paying for the lock
+	if bridge.SignaturesRequired < types.NFTMinBridgeAttestations || uint64(len(bridge.PublicKeys)) < bridge.SignaturesRequired {
+		return nil, errNFTBridgeNotMultisig
+	}
+	if err := claim.Validate(); err != nil {
+		return nil, err
+	}
+	if _, err := w.cs.ViewNFTBridgeLock(nft); err == nil {
+		return nil, errNFTBridgeLocked
+	}
+	if err := w.managedCheckNFTTransferPolicy(nft); err != nil {
+		return nil, err
+	}
+	if err := w.managedCheckNFTUnstaked(nft); err != nil {
+		return nil, err
+	}
+	if err := w.managedCheckNFTNotFrozen(nft); err != nil {
+		return nil, err
+	}
+
+	// Locate NFT output from previous chain-of-custody
+	goal_scoid, goalOutput, err := w.managedNFTCustodyOutput(nft)
+	if err != nil {
+		w.log.Println("Attempt to lock NFT for bridge has failed:", err)
+		return nil, err
+	}
+
+	arb := types.NFTBridgeLockArbitraryData(nft, claim)
+	txnSet, txnBuilder, err := w.managedBuildNFTCustodyTransfer(arb, nft, goal_scoid, goalOutput, bridge.UnlockHash(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if w.deps.Disrupt("SendSiacoinsInterrupted") {
+		txnBuilder.Drop()
+		return nil, errors.New("failed to accept transaction set (SendSiacoinsInterrupted)")
+	}
+	err = w.managedBroadcastNFTTransactions(txnSet)
+	if err != nil {
+		txnBuilder.Drop()
+		w.log.Println("Attempt to send coins has failed - transaction pool rejected transaction:", err)
+		return nil, build.ExtendErr("unable to get transaction accepted", err)
+	}
+	for _, txn := range txnSet {
+		w.log.Println("\t", txn.ID())
+	}
+	return txnSet, nil
 }
 
 // Return all NFTs owned by this wallet as ownership stats
@@ -285,8 +660,15 @@ func (w *Wallet) ScanAllNFTS() []types.NftOwnershipStats {
 	}
 	defer w.tg.Done()
 
-	var ret []types.NftOwnershipStats
+	w.mu.RLock()
+	addrs := make([]types.UnlockHash, 0, len(w.keys))
 	for key := range w.keys {
+		addrs = append(addrs, key)
+	}
+	w.mu.RUnlock()
+
+	var ret []types.NftOwnershipStats
+	for _, key := range addrs {
 		for _, nft := range w.cs.FindNFTsForAddress(key) {
 			var custody types.NftOwnershipStats
 			custody.Nft = nft
@@ -296,3 +678,128 @@ func (w *Wallet) ScanAllNFTS() []types.NftOwnershipStats {
 	}
 	return ret
 }
+
+// ExportProvenance builds the provenance document of an NFT and signs it with
+// the key of the address the NFT was minted to, which needs to belong to this
+// wallet. The chain of custody is found by scanning the blockchain for the
+// NFT's transactions. The scan is cached, so that exporting the document again
+// only scans the blocks which were added since.
+func (w *Wallet) ExportProvenance(nft types.NftCustody) (types.NFTProvenance, error) {
+	if err := w.tg.Add(); err != nil {
+		return types.NFTProvenance{}, err
+	}
+	defer w.tg.Done()
+
+	w.mu.RLock()
+	unlocked := w.unlocked
+	w.mu.RUnlock()
+	if !unlocked {
+		return types.NFTProvenance{}, modules.ErrLockedWallet
+	}
+
+	scan := w.managedScanNFTProvenance(nft)
+	if !scan.minted {
+		return types.NFTProvenance{}, modules.ErrNFTNotMinted
+	}
+	p := scan.provenance
+
+	// The mint carries the NFT's content commitment, if any.
+	var creator types.SiacoinOutput
+	p.NFT, creator = types.ExtractNFTFromTransaction(p.Mint.Transaction)
+	p.License = types.NFTProvenanceLicense(p.Mint.Transaction)
+
+	// Sign the document with the creator's key.
+	w.mu.RLock()
+	key, exists := w.keys[creator.UnlockHash]
+	w.mu.RUnlock()
+	if !exists || len(key.SecretKeys) == 0 || len(key.UnlockConditions.PublicKeys) == 0 {
+		return types.NFTProvenance{}, modules.ErrNotNFTCreator
+	}
+	p.CreatorPublicKey = key.UnlockConditions.PublicKeys[0]
+	p.CreatorSignature = crypto.SignHash(p.SigHash(), key.SecretKeys[0])
+	return p, nil
+}
+
+// nftProvenanceScan is the progress of scanning the blockchain for the
+// transactions of an NFT's provenance.
+type nftProvenanceScan struct {
+	provenance types.NFTProvenance
+	minted     bool
+	liquidated bool
+
+	// height is the height of the next block to scan and tip is the id of
+	// the last scanned block. The scan is only continued if tip is still
+	// part of the current path.
+	height types.BlockHeight
+	tip    types.BlockID
+}
+
+// managedScanNFTProvenance scans the blockchain for the mint and all following
+// transfers of an NFT. The liquidation of the NFT ends its chain of custody.
+// The scan continues from the cached scan of the NFT unless the last scanned
+// block was reverted since.
+func (w *Wallet) managedScanNFTProvenance(nft types.NftCustody) nftProvenanceScan {
+	id := nft.Identifier()
+	w.mu.RLock()
+	scan, cached := w.nftProvenanceScans[id]
+	w.mu.RUnlock()
+	if cached && scan.height > 0 {
+		if b, exists := w.cs.BlockAtHeight(scan.height - 1); !exists || b.ID() != scan.tip {
+			cached = false
+		}
+	}
+	if !cached {
+		scan = nftProvenanceScan{provenance: types.NFTProvenance{NFT: nft}}
+	}
+	// Copy the transfers so that the cached scan isn't modified while it is
+	// read by other threads.
+	scan.provenance.Transfers = append([]types.NFTProvenanceEntry(nil), scan.provenance.Transfers...)
+
+	height := w.cs.Height()
+	for ; scan.height <= height && !scan.liquidated; scan.height++ {
+		b, exists := w.cs.BlockAtHeight(scan.height)
+		if !exists {
+			break
+		}
+		for _, txn := range b.Transactions {
+			mint := types.IsNFTMintTransaction(txn)
+			transfer := types.IsNFTTransferTransaction(txn) || types.IsNFTReclaimTransaction(txn) ||
+				types.IsNFTBridgeLockTransaction(txn) || types.IsNFTBridgeUnlockTransaction(txn) ||
+				types.IsNFTStakeTransaction(txn) || types.IsNFTUnstakeTransaction(txn) ||
+				types.IsNFTInsuranceChallengeTransaction(txn) || types.IsNFTInsuranceClaimTransaction(txn) ||
+				types.IsNFTApproveTransaction(txn)
+			liquidation := types.IsNFTLiquidationTransaction(txn)
+			if !mint && !transfer && !liquidation {
+				continue
+			}
+			if found, _ := types.ExtractNFTFromTransaction(txn); found.Identifier() != id {
+				continue
+			}
+			entry := types.NFTProvenanceEntry{
+				Transaction: txn,
+				BlockID:     b.ID(),
+				BlockHeight: scan.height,
+			}
+			switch {
+			case mint && !scan.minted:
+				scan.provenance.Mint = entry
+				scan.minted = true
+			case transfer && scan.minted && !scan.liquidated:
+				scan.provenance.Transfers = append(scan.provenance.Transfers, entry)
+			case liquidation && scan.minted && !scan.liquidated:
+				scan.provenance.Transfers = append(scan.provenance.Transfers, entry)
+				scan.liquidated = true
+			}
+		}
+		scan.tip = b.ID()
+	}
+
+	// Scans of NFTs which weren't minted aren't cached, so that looking up
+	// arbitrary NFTs doesn't grow the cache.
+	if scan.minted {
+		w.mu.Lock()
+		w.nftProvenanceScans[id] = scan
+		w.mu.Unlock()
+	}
+	return scan
+}
@@ -0,0 +1,541 @@
+package wallet
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/gateway"
+	"go.sia.tech/siad/siatest/dependencies"
+	"go.sia.tech/siad/siatest/nfttest"
+	"go.sia.tech/siad/types"
+)
+
+// mockNFTConsensus replaces the NFT queries of the consensus set of the
+// tester's wallet with an in-memory fake which doesn't know any NFT.
+func (wt *walletTester) mockNFTConsensus() *nfttest.Consensus {
+	fake := nfttest.New()
+	wt.wallet.mu.Lock()
+	wt.wallet.cs = fake.Overlay(wt.cs)
+	wt.wallet.mu.Unlock()
+	return fake
+}
+
+// mintTestNFT mints an NFT with random data to a new address of the tester's
+// wallet and confirms the mint.
+func (wt *walletTester) mintTestNFT() (types.NftCustody, types.UnlockHash, error) {
+	uc, err := wt.wallet.NextAddress()
+	if err != nil {
+		return types.NftCustody{}, types.UnlockHash{}, err
+	}
+	nft := types.NftCustody{FileMerkleRoot: crypto.MerkleRoot(fastrand.Bytes(crypto.SegmentSize))}
+	if _, err := wt.wallet.MintNFT(nft, uc.UnlockHash()); err != nil {
+		return types.NftCustody{}, types.UnlockHash{}, err
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		return types.NftCustody{}, types.UnlockHash{}, err
+	}
+	return nft, uc.UnlockHash(), nil
+}
+
+// TestMintNFT tests that a mint pays the pools and the fee and that the minted
+// NFT is found by the wallet once the mint is confirmed.
+func TestMintNFT(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	uc, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner := uc.UnlockHash()
+	nft := types.NftCustody{FileMerkleRoot: crypto.MerkleRoot(fastrand.Bytes(crypto.SegmentSize))}
+	txns, err := wt.wallet.MintNFT(nft, owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The mint pays the lockup, the storage pool and the fee.
+	_, fee := wt.tpool.FeeEstimation()
+	fee = fee.Mul64(EstimatedNFTTransactionSize)
+	mint := txns[len(txns)-1]
+	if len(mint.MinerFees) != 1 || !mint.MinerFees[0].Equals(fee) {
+		t.Fatal("unexpected miner fees", mint.MinerFees, fee)
+	}
+	paid := make(map[types.UnlockHash]types.Currency)
+	for _, sco := range mint.SiacoinOutputs {
+		paid[sco.UnlockHash] = paid[sco.UnlockHash].Add(sco.Value)
+	}
+	if !paid[types.NFTLockupUnlockConditions.UnlockHash()].Equals(types.NFTLockupAmount) {
+		t.Fatal("wrong lockup", paid[types.NFTLockupUnlockConditions.UnlockHash()])
+	}
+	if !paid[types.NFTStoragePoolUnlockConditions.UnlockHash()].Equals(types.NFTHostAmount) {
+		t.Fatal("wrong storage pool payment", paid[types.NFTStoragePoolUnlockConditions.UnlockHash()])
+	}
+	if !paid[owner].Equals(types.OneBaseUnit) {
+		t.Fatal("wrong custody output", paid[owner])
+	}
+	outgoing, incoming, err := wt.wallet.UnconfirmedBalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cost := types.NFTLockupAmount.Add(types.NFTHostAmount).Add(fee); !outgoing.Sub(incoming).Equals(cost) {
+		t.Fatalf("wallet spent %v, expected %v", outgoing.Sub(incoming), cost)
+	}
+
+	// Once confirmed, the NFT is held by the owner.
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	custody, err := wt.cs.ViewNFTCustody(nft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if custody.UnlockHash != owner {
+		t.Fatal("nft was minted to the wrong address")
+	}
+	stats := wt.wallet.ScanAllNFTS()
+	if len(stats) != 1 || stats[0].Owner != owner || stats[0].Nft.Identifier() != nft.Identifier() {
+		t.Fatal("wallet doesn't find the minted nft", stats)
+	}
+}
+
+// TestTransferNFT tests transferring an NFT between addresses of the wallet
+// and that NFTs which aren't held by the wallet can't be transferred.
+func TestTransferNFT(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	nft, _, err := wt.mintTestNFT()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Transfer the NFT to another address of the wallet.
+	uc, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner := uc.UnlockHash()
+	txns, err := wt.wallet.TransferNFT(nft, owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transfer := txns[len(txns)-1]
+	_, fee := wt.tpool.FeeEstimation()
+	fee = fee.Mul64(EstimatedNFTTransactionSize)
+	if len(transfer.MinerFees) != 1 || !transfer.MinerFees[0].Equals(fee) {
+		t.Fatal("unexpected miner fees", transfer.MinerFees, fee)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	custody, err := wt.cs.ViewNFTCustody(nft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if custody.UnlockHash != owner {
+		t.Fatal("nft wasn't transferred")
+	}
+
+	// Transfer it out of the wallet. The wallet can't transfer it again.
+	if _, err := wt.wallet.TransferNFT(nft, types.UnlockHash{1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.wallet.TransferNFT(nft, owner); !errors.Contains(err, errNFTNotInWallet) {
+		t.Fatal("expected errNFTNotInWallet but got", err)
+	}
+	if len(wt.wallet.ScanAllNFTS()) != 0 {
+		t.Fatal("wallet still finds the transferred nft")
+	}
+
+	// NFTs which were never minted can't be transferred either.
+	unknown := types.NftCustody{FileMerkleRoot: crypto.MerkleRoot(fastrand.Bytes(crypto.SegmentSize))}
+	if _, err := wt.wallet.TransferNFT(unknown, owner); err == nil {
+		t.Fatal("transferred an unknown nft")
+	}
+}
+
+// TestTransferNFTMissingOutput uses a mocked consensus set to test that the
+// wallet refuses to transfer an NFT whose custody output it doesn't track,
+// even if the custody is held by one of its addresses.
+func TestTransferNFTMissingOutput(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	uc, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner := uc.UnlockHash()
+	fake := wt.mockNFTConsensus()
+	nft := types.NftCustody{FileMerkleRoot: crypto.MerkleRoot(fastrand.Bytes(crypto.SegmentSize))}
+	fake.Mint(nft, owner)
+
+	// The wallet reports the NFT held by its address.
+	stats := wt.wallet.ScanAllNFTS()
+	if len(stats) != 1 || stats[0].Owner != owner || stats[0].Nft.Identifier() != nft.Identifier() {
+		t.Fatal("wallet doesn't find the nft", stats)
+	}
+
+	// But it can't spend the custody output it never received.
+	if _, err := wt.wallet.TransferNFT(nft, types.UnlockHash{1}); !errors.Contains(err, errNFTNotInWallet) {
+		t.Fatal("expected errNFTNotInWallet but got", err)
+	}
+	if len(wt.tpool.TransactionList()) != 0 {
+		t.Fatal("failed transfer was broadcast")
+	}
+}
+
+// TestNFTLockedWallet tests that NFT operations of a locked wallet fail.
+func TestNFTLockedWallet(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	nft, owner, err := wt.mintTestNFT()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.wallet.Lock(); err != nil {
+		t.Fatal(err)
+	}
+	other := types.NftCustody{FileMerkleRoot: crypto.MerkleRoot(fastrand.Bytes(crypto.SegmentSize))}
+	if _, err := wt.wallet.MintNFT(other, owner); !errors.Contains(err, modules.ErrLockedWallet) {
+		t.Fatal("expected ErrLockedWallet but got", err)
+	}
+	if _, err := wt.wallet.TransferNFT(nft, types.UnlockHash{1}); !errors.Contains(err, modules.ErrLockedWallet) {
+		t.Fatal("expected ErrLockedWallet but got", err)
+	}
+	if _, err := wt.wallet.LiquidateNFT(nft, owner); !errors.Contains(err, modules.ErrLockedWallet) {
+		t.Fatal("expected ErrLockedWallet but got", err)
+	}
+
+	// Unlocking the wallet allows the transfer.
+	if err := wt.wallet.Unlock(wt.walletMasterKey); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.wallet.TransferNFT(nft, types.UnlockHash{1}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestMintNFTReorg tests that a mint which is reorged out of the blockchain
+// is reverted and confirmed again once the mint is mined on the new chain.
+func TestMintNFTReorg(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	wt2, err := createWalletTester(t.Name()+"2", modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt2.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	sync := func() {
+		t.Helper()
+		if err := wt.gateway.Connect(wt2.gateway.Address()); err != nil {
+			t.Fatal(err)
+		}
+		for start := time.Now(); time.Since(start) < time.Minute; time.Sleep(time.Millisecond * 100) {
+			if wt.cs.CurrentBlock().ID() == wt2.cs.CurrentBlock().ID() {
+				return
+			}
+		}
+		t.Fatal("testers did not have the same block after one minute")
+	}
+
+	// Put the testers on the first tester's blockchain, so that the outputs
+	// funding the mint survive the reorg.
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	sync()
+	if err := wt.gateway.Disconnect(wt2.gateway.Address()); err != nil && !errors.Contains(err, gateway.ErrPeerNotConnected) {
+		t.Fatal(err)
+	}
+
+	// Mint an NFT on the first tester and put the second tester at a higher
+	// height.
+	nft, owner, err := wt.mintTestNFT()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := wt2.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Reconnect the testers, which reorgs the mint out of the first tester's
+	// blockchain.
+	sync()
+	if _, err := wt.cs.ViewNFTCustody(nft); err == nil {
+		t.Fatal("reorged mint wasn't reverted")
+	}
+	if len(wt.wallet.ScanAllNFTS()) != 0 {
+		t.Fatal("wallet still finds the reverted nft")
+	}
+
+	// The reorged mint is returned to the transaction pool and confirmed by
+	// the next block.
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	custody, err := wt.cs.ViewNFTCustody(nft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if custody.UnlockHash != owner {
+		t.Fatal("nft was minted to the wrong address")
+	}
+}
+
+// TestMintNFTInterrupted tests that a mint which is interrupted between
+// signing and broadcasting the mint transaction doesn't lock the wallet's
+// outputs and can be retried.
+func TestMintNFTInterrupted(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	deps := dependencies.NewDependencyInterruptNFTMintBeforeBroadcast()
+	wt, err := createWalletTester(t.Name(), deps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	uc, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	nft := types.NftCustody{FileMerkleRoot: crypto.MerkleRoot(fastrand.Bytes(crypto.SegmentSize))}
+
+	// The interrupted mint doesn't reach the transaction pool.
+	deps.Fail()
+	if _, err := wt.wallet.MintNFT(nft, uc.UnlockHash()); err == nil {
+		t.Fatal("expected mint to be interrupted")
+	}
+	if len(wt.tpool.TransactionList()) != 0 {
+		t.Fatal("interrupted mint was broadcast")
+	}
+
+	// Retrying the mint spends the same outputs.
+	if _, err := wt.wallet.MintNFT(nft, uc.UnlockHash()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	custody, err := wt.cs.ViewNFTCustody(nft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if custody.UnlockHash != uc.UnlockHash() {
+		t.Fatal("nft was minted to the wrong address")
+	}
+}
+
+// TestTransferNFTInterrupted tests that a transfer which is interrupted
+// between signing and broadcasting the transfer transaction doesn't lock the
+// NFT's custody output and can be retried.
+func TestTransferNFTInterrupted(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	deps := dependencies.NewDependencyInterruptNFTTransferBeforeBroadcast()
+	wt, err := createWalletTester(t.Name(), deps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	dest := func() types.UnlockHash {
+		uc, err := wt.wallet.NextAddress()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return uc.UnlockHash()
+	}
+
+	// Mint an NFT to the wallet and confirm it.
+	nft := types.NftCustody{FileMerkleRoot: crypto.MerkleRoot(fastrand.Bytes(crypto.SegmentSize))}
+	if _, err := wt.wallet.MintNFT(nft, dest()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The interrupted transfer doesn't reach the transaction pool.
+	deps.Fail()
+	if _, err := wt.wallet.TransferNFT(nft, dest()); err == nil {
+		t.Fatal("expected transfer to be interrupted")
+	}
+	if len(wt.tpool.TransactionList()) != 0 {
+		t.Fatal("interrupted transfer was broadcast")
+	}
+
+	// Retrying the transfer spends the custody output.
+	owner := dest()
+	if _, err := wt.wallet.TransferNFT(nft, owner); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	custody, err := wt.cs.ViewNFTCustody(nft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if custody.UnlockHash != owner {
+		t.Fatal("nft wasn't transferred")
+	}
+}
+
+// TestExportProvenanceCached tests that the cached provenance scan of an NFT
+// is continued by later exports and thrown away once its last scanned block
+// is no longer part of the current path.
+func TestExportProvenanceCached(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// An NFT which wasn't minted has no provenance.
+	var unminted types.NftCustody
+	fastrand.Read(unminted.FileMerkleRoot[:])
+	if _, err := wt.wallet.ExportProvenance(unminted); !errors.Contains(err, modules.ErrNFTNotMinted) {
+		t.Fatal("expected ErrNFTNotMinted but got", err)
+	}
+
+	nft, _, err := wt.mintTestNFT()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := wt.wallet.ExportProvenance(nft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Transfers) != 0 {
+		t.Fatal("expected no transfers but got", len(p.Transfers))
+	}
+
+	// A transfer after the cached scan is found by the next export.
+	uc, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.wallet.TransferNFT(nft, uc.UnlockHash()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	p, err = wt.wallet.ExportProvenance(nft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Transfers) != 1 {
+		t.Fatal("expected 1 transfer but got", len(p.Transfers))
+	}
+	if err := types.VerifyNFTProvenance(p); err != nil {
+		t.Fatal(err)
+	}
+
+	// A cached scan whose last block was reverted is rescanned from the
+	// start.
+	wt.wallet.mu.Lock()
+	scan := wt.wallet.nftProvenanceScans[nft.Identifier()]
+	scan.tip = types.BlockID{1}
+	scan.provenance.Transfers = append(scan.provenance.Transfers, scan.provenance.Transfers...)
+	wt.wallet.nftProvenanceScans[nft.Identifier()] = scan
+	wt.wallet.mu.Unlock()
+	p, err = wt.wallet.ExportProvenance(nft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Transfers) != 1 {
+		t.Fatal("expected 1 transfer but got", len(p.Transfers))
+	}
+	if err := types.VerifyNFTProvenance(p); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,160 @@
+package wallet
+
+import (
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// nftaccount.go contains the NFT custody account of the wallet. Similar to the
+// accounts of BIP-44, the keys of the primary seed are derived from separate
+// account branches. The spending account contains the wallet's ordinary
+// addresses while the NFT custody account contains the addresses that receive
+// NFTs. Outputs of custody addresses are never used to fund transactions,
+// which keeps ordinary sends from spending the one base unit custody output of
+// an NFT as dust.
+
+// walletAccount identifies an account branch of the primary seed.
+type walletAccount uint64
+
+const (
+	// accountSpending is the account of the wallet's ordinary addresses. Its
+	// keys are derived without the account to stay compatible with seeds
+	// created before accounts existed.
+	accountSpending walletAccount = iota
+
+	// accountNFTCustody is the account of the addresses that hold NFTs in
+	// custody.
+	accountNFTCustody
+)
+
+var (
+	// errNFTCustodyOutput indicates an output is not spendable because it
+	// belongs to the NFT custody account.
+	errNFTCustodyOutput = errors.New("output belongs to the nft custody account")
+
+	// errNFTCustodyToken indicates an output is not spendable because it is
+	// the custody output of an NFT.
+	errNFTCustodyToken = errors.New("output holds the custody of an nft")
+)
+
+// isNFTCustodyToken returns true if the output is the custody output of an NFT
+// according to the consensus set's custody index.
+func (w *Wallet) isNFTCustodyToken(sco types.SiacoinOutput) bool {
+	for _, nft := range w.cs.FindNFTsForAddress(sco.UnlockHash) {
+		custody, err := w.cs.ViewNFTCustody(nft)
+		if err == nil && custody.UnlockHash == sco.UnlockHash && custody.Value.Equals(sco.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+// integrateNFTCustodyKeys loads the first n keys of the NFT custody account
+// into the wallet.
+func (w *Wallet) integrateNFTCustodyKeys(n uint64) {
+	for i, sk := range generateAccountKeys(w.primarySeed, accountNFTCustody, 0, n) {
+		uh := sk.UnlockConditions.UnlockHash()
+		w.keys[uh] = sk
+		w.nftCustodyAddrs[uh] = uint64(i)
+	}
+}
+
+// regenerateNFTCustodyLookahead creates the lookahead of the NFT custody
+// account for the given progress.
+func (w *Wallet) regenerateNFTCustodyLookahead(start uint64) {
+	maxKeys := maxLookahead(start)
+	existingKeys := uint64(len(w.nftCustodyLookahead))
+
+	for i, k := range generateAccountKeys(w.primarySeed, accountNFTCustody, start+existingKeys, maxKeys-existingKeys) {
+		w.nftCustodyLookahead[k.UnlockConditions.UnlockHash()] = start + existingKeys + uint64(i)
+	}
+	if maxKeys > existingKeys {
+		go w.threadedWatchLightAddresses()
+	}
+}
+
+// advanceNFTCustodyLookahead generates all keys of the NFT custody account up
+// to index. Returns true if a blockchain rescan is required.
+func (w *Wallet) advanceNFTCustodyLookahead(index uint64) (bool, error) {
+	progress, err := dbGetNFTCustodyProgress(w.dbTx)
+	if err != nil {
+		return false, err
+	}
+	newProgress := index + 1
+	if newProgress <= progress {
+		return false, nil
+	}
+
+	spendableKeys := generateAccountKeys(w.primarySeed, accountNFTCustody, progress, newProgress-progress)
+	for i, key := range spendableKeys {
+		uh := key.UnlockConditions.UnlockHash()
+		w.keys[uh] = key
+		w.nftCustodyAddrs[uh] = progress + uint64(i)
+		delete(w.nftCustodyLookahead, uh)
+	}
+	if err := dbPutNFTCustodyProgress(w.dbTx, newProgress); err != nil {
+		return false, err
+	}
+	w.regenerateNFTCustodyLookahead(newProgress)
+	return uint64(len(spendableKeys)) > lookaheadRescanThreshold, nil
+}
+
+// updateNFTCustodyLookahead advances the progress of the NFT custody account if
+// one of the outputs of a consensus change belongs to its lookahead. Returns
+// true if a blockchain rescan is required.
+func (w *Wallet) updateNFTCustodyLookahead(cc modules.ConsensusChange) (bool, error) {
+	var largestIndex uint64
+	var found bool
+	for _, diff := range cc.SiacoinOutputDiffs {
+		if index, ok := w.nftCustodyLookahead[diff.SiacoinOutput.UnlockHash]; ok && (!found || index > largestIndex) {
+			largestIndex, found = index, true
+		}
+	}
+	if !found {
+		return false, nil
+	}
+	return w.advanceNFTCustodyLookahead(largestIndex)
+}
+
+// nextNFTCustodyAddress fetches the next address of the NFT custody account.
+func (w *Wallet) nextNFTCustodyAddress(tx *bolt.Tx) (types.UnlockConditions, error) {
+	if !w.unlocked {
+		return types.UnlockConditions{}, modules.ErrLockedWallet
+	}
+	progress, err := dbGetNFTCustodyProgress(tx)
+	if err != nil {
+		return types.UnlockConditions{}, err
+	}
+	if err := dbPutNFTCustodyProgress(tx, progress+1); err != nil {
+		return types.UnlockConditions{}, err
+	}
+	key := generateAccountKey(w.primarySeed, accountNFTCustody, progress)
+	uh := key.UnlockConditions.UnlockHash()
+	w.keys[uh] = key
+	w.nftCustodyAddrs[uh] = progress
+	delete(w.nftCustodyLookahead, uh)
+	w.regenerateNFTCustodyLookahead(progress + 1)
+	return key.UnlockConditions, nil
+}
+
+// NextNFTCustodyAddress returns a new address of the NFT custody account which
+// is ready to receive an NFT. The wallet never spends the outputs of custody
+// addresses to fund transactions.
+func (w *Wallet) NextNFTCustodyAddress() (types.UnlockConditions, error) {
+	if err := w.tg.Add(); err != nil {
+		return types.UnlockConditions{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	uc, err := w.nextNFTCustodyAddress(w.dbTx)
+	err = errors.Compose(err, w.syncDB())
+	if err != nil {
+		return types.UnlockConditions{}, err
+	}
+	return uc, nil
+}
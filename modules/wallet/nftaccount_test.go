@@ -0,0 +1,223 @@
+package wallet
+
+import (
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestGenerateAccountKey tests that the spending account derives the same keys
+// as before accounts existed and that the NFT custody account derives
+// different keys.
+func TestGenerateAccountKey(t *testing.T) {
+	t.Parallel()
+	var seed modules.Seed
+	fastrand.Read(seed[:])
+
+	for i := uint64(0); i < 3; i++ {
+		legacy := generateSpendableKey(seed, i).UnlockConditions.UnlockHash()
+		spending := generateAccountKey(seed, accountSpending, i).UnlockConditions.UnlockHash()
+		custody := generateAccountKey(seed, accountNFTCustody, i).UnlockConditions.UnlockHash()
+		if spending != legacy {
+			t.Fatal("spending account keys changed")
+		}
+		if custody == legacy {
+			t.Fatal("custody account should derive different keys")
+		}
+		if custody != generateAccountKeys(seed, accountNFTCustody, i, 1)[0].UnlockConditions.UnlockHash() {
+			t.Fatal("generateAccountKeys doesn't match generateAccountKey")
+		}
+	}
+}
+
+// TestNFTCustodyAccount tests that outputs of NFT custody addresses aren't
+// used to fund transactions and that custody addresses are recovered from the
+// seed.
+func TestNFTCustodyAccount(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Send money to a custody address.
+	uc, err := wt.wallet.NextNFTCustodyAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	custodyAddr := uc.UnlockHash()
+	if custodyAddr != generateAccountKey(wt.wallet.primarySeed, accountNFTCustody, 0).UnlockConditions.UnlockHash() {
+		t.Fatal("first custody address should have index 0")
+	}
+	amount := types.SiacoinPrecision.Mul64(100)
+	if _, err := wt.wallet.SendSiacoins(amount, custodyAddr); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The output belongs to the wallet but can't be used to fund
+	// transactions.
+	wt.wallet.mu.Lock()
+	height, err := dbGetConsensusHeight(wt.wallet.dbTx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found bool
+	err = dbForEachSiacoinOutput(wt.wallet.dbTx, func(id types.SiacoinOutputID, sco types.SiacoinOutput) {
+		if sco.UnlockHash != custodyAddr {
+			return
+		}
+		found = true
+		if err := wt.wallet.checkOutput(wt.wallet.dbTx, height, id, sco, types.ZeroCurrency, false); err != errNFTCustodyOutput {
+			t.Error("expected errNFTCustodyOutput but got", err)
+		}
+	})
+	wt.wallet.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !found {
+		t.Fatal("custody output wasn't tracked by the wallet")
+	}
+
+	// A wallet restored from the seed finds the custody address.
+	seed, _, err := wt.wallet.PrimarySeed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(build.TempDir(modules.WalletDir, t.Name()+"-restored"), modules.WalletDir)
+	w, err := New(wt.cs, wt.tpool, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if err := w.InitFromSeed(nil, seed); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Unlock(crypto.NewWalletKey(crypto.HashObject(seed))); err != nil {
+		t.Fatal(err)
+	}
+	w.mu.Lock()
+	_, isCustody := w.nftCustodyAddrs[custodyAddr]
+	progress, err := dbGetNFTCustodyProgress(w.dbTx)
+	w.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isCustody || progress != 1 {
+		t.Fatal("restored wallet didn't recover the custody address", isCustody, progress)
+	}
+	uc, err = w.NextNFTCustodyAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uc.UnlockHash() == custodyAddr {
+		t.Fatal("restored wallet reused the custody address")
+	}
+}
+
+// TestNFTCustodyToken tests that the custody outputs of NFTs are only spent if
+// the transaction builder allows it while other outputs of the same address
+// remain spendable.
+func TestNFTCustodyToken(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Mint an NFT to an ordinary address and send some money to the same
+	// address.
+	uc, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner := uc.UnlockHash()
+	var nft types.NftCustody
+	fastrand.Read(nft.FileMerkleRoot[:])
+	if _, err := wt.wallet.MintNFT(nft, owner); err != nil {
+		t.Fatal(err)
+	}
+	amount := types.SiacoinPrecision.Mul64(10)
+	if _, err := wt.wallet.SendSiacoins(amount, owner); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the custody output is protected.
+	wt.wallet.mu.Lock()
+	height, err := dbGetConsensusHeight(wt.wallet.dbTx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tokens, others int
+	err = dbForEachSiacoinOutput(wt.wallet.dbTx, func(id types.SiacoinOutputID, sco types.SiacoinOutput) {
+		if sco.UnlockHash != owner {
+			return
+		}
+		err := wt.wallet.checkOutput(wt.wallet.dbTx, height, id, sco, types.ZeroCurrency, false)
+		if sco.Value.Equals(types.OneBaseUnit) {
+			tokens++
+			if err != errNFTCustodyToken {
+				t.Error("expected errNFTCustodyToken but got", err)
+			}
+			if err := wt.wallet.checkOutput(wt.wallet.dbTx, height, id, sco, types.ZeroCurrency, true); err != nil {
+				t.Error("custody output should be spendable if allowed", err)
+			}
+		} else {
+			others++
+			if err != nil {
+				t.Error("ordinary output should be spendable", err)
+			}
+		}
+	})
+	wt.wallet.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tokens != 1 || others != 1 {
+		t.Fatal("unexpected outputs", tokens, others)
+	}
+
+	// The override is kept when copying the builder.
+	tb, err := wt.wallet.StartTransaction()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tb.Drop()
+	tb.AllowNFTCustodyOutputs()
+	cp := tb.Copy()
+	defer cp.Drop()
+	if !cp.(*transactionBuilder).allowNFTCustody {
+		t.Fatal("copy should allow spending custody outputs")
+	}
+}
@@ -0,0 +1,87 @@
+package wallet
+
+import (
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// nftactivity.go notifies callbacks about NFT mints and transfers which
+// involve the wallet, either because an NFT was sent to one of its addresses or
+// because the wallet funded the transaction.
+
+// nftActivity returns the NFT activities involving the wallet within the
+// applied blocks of a consensus change.
+func (w *Wallet) nftActivity(cc modules.ConsensusChange) []modules.NFTActivity {
+	var activities []modules.NFTActivity
+	consensusHeight := cc.InitialHeight()
+	for _, block := range cc.AppliedBlocks {
+		if block.ID() != types.GenesisID {
+			consensusHeight++
+		}
+		for _, txn := range block.Transactions {
+			nft, _, sco, ok := nftCustodyOutput(txn)
+			if !ok {
+				continue
+			}
+			var outgoing bool
+			for _, sci := range txn.SiacoinInputs {
+				if w.isWalletAddress(sci.UnlockConditions.UnlockHash()) {
+					outgoing = true
+					break
+				}
+			}
+			incoming := w.isWalletAddress(sco.UnlockHash)
+			if !incoming && !outgoing {
+				continue
+			}
+			activities = append(activities, modules.NFTActivity{
+				NFT:           nft,
+				Minted:        types.IsNFTMintTransaction(txn),
+				Incoming:      incoming,
+				Outgoing:      outgoing,
+				Address:       sco.UnlockHash,
+				TransactionID: txn.ID(),
+				BlockHeight:   consensusHeight,
+			})
+		}
+	}
+	return activities
+}
+
+// notifyNFTActivityCallbacks calls the registered callbacks for every NFT
+// activity involving the wallet within a consensus change.
+func (w *Wallet) notifyNFTActivityCallbacks(cc modules.ConsensusChange) {
+	if len(w.nftActivityCallbacks) == 0 {
+		return
+	}
+	for _, activity := range w.nftActivity(cc) {
+		for _, fn := range w.nftActivityCallbacks {
+			go w.threadedCallNFTActivityCallback(fn, activity)
+		}
+	}
+}
+
+// threadedCallNFTActivityCallback calls an activity callback without blocking
+// consensus updates.
+func (w *Wallet) threadedCallNFTActivityCallback(fn func(modules.NFTActivity), activity modules.NFTActivity) {
+	if err := w.tg.Add(); err != nil {
+		return
+	}
+	defer w.tg.Done()
+	fn(activity)
+}
+
+// RegisterNFTActivityCallback registers a function which is called for every
+// NFT mint or transfer involving the wallet that is confirmed while the wallet
+// is synced. The callback is called in its own goroutine.
+func (w *Wallet) RegisterNFTActivityCallback(fn func(modules.NFTActivity)) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.nftActivityCallbacks = append(w.nftActivityCallbacks, fn)
+	return nil
+}
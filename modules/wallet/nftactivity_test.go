@@ -0,0 +1,90 @@
+package wallet
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestNFTActivityCallback tests that callbacks are notified about confirmed
+// NFT mints involving the wallet.
+func TestNFTActivityCallback(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	activities := make(chan modules.NFTActivity, 10)
+	err = wt.wallet.RegisterNFTActivityCallback(func(a modules.NFTActivity) {
+		activities <- a
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := func() modules.NFTActivity {
+		select {
+		case a := <-activities:
+			return a
+		case <-time.After(10 * time.Second):
+			t.Fatal("callback wasn't called")
+		}
+		return modules.NFTActivity{}
+	}
+
+	// Mint an NFT to the wallet. The mint is both incoming and outgoing.
+	uc, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	nft := types.NftCustody{FileMerkleRoot: crypto.MerkleRoot(fastrand.Bytes(crypto.SegmentSize))}
+	if _, err := wt.wallet.MintNFT(nft, uc.UnlockHash()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	a := next()
+	if a.NFT.Identifier() != nft.Identifier() || !a.Minted || !a.Incoming || !a.Outgoing || a.Address != uc.UnlockHash() {
+		t.Fatal("unexpected activity", a)
+	}
+
+	// Mint an NFT to an address of a different wallet. The mint is only
+	// outgoing.
+	var dest types.UnlockHash
+	fastrand.Read(dest[:])
+	nft = types.NftCustody{FileMerkleRoot: crypto.MerkleRoot(fastrand.Bytes(crypto.SegmentSize))}
+	if _, err := wt.wallet.MintNFT(nft, dest); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	a = next()
+	if a.NFT.Identifier() != nft.Identifier() || a.Incoming || !a.Outgoing || a.Address != dest {
+		t.Fatal("unexpected activity", a)
+	}
+
+	// Blocks without NFTs don't call the callback.
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case a := <-activities:
+		t.Fatal("unexpected activity", a)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
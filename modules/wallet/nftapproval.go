@@ -0,0 +1,167 @@
+package wallet
+
+import (
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/types"
+)
+
+// nftapproval.go contains the wallet side of operator approvals. Owners approve
+// an operator, e.g. a marketplace, to transfer an NFT once. The approval pays a
+// ticket of one base unit to the operator's address, which the operator spends
+// to transfer the NFT on behalf of the owner. Operators should hand out
+// addresses of the NFT custody account, which keeps the tickets from being
+// spent by ordinary sends.
+
+var (
+	// errNFTNotApproved is returned when revoking the approval of an NFT or
+	// transferring an NFT without an approved operator.
+	errNFTNotApproved = errors.New("nft has no approved operator")
+
+	// errNFTOperatorIsOwner is returned when approving the address which
+	// holds the NFT as its operator.
+	errNFTOperatorIsOwner = errors.New("nft operator can't be the address holding the nft")
+
+	// errNotNFTOperator is returned when transferring an approved NFT
+	// without holding the ticket of its approval.
+	errNotNFTOperator = errors.New("wallet doesn't hold the ticket of the nft's approved operator")
+)
+
+// ApproveNFTOperator approves operator to transfer an NFT held by the wallet
+// once. The approval replaces an earlier approval of the NFT and is cleared by
+// any transfer of the NFT.
+func (w *Wallet) ApproveNFTOperator(nft types.NftCustody, operator types.UnlockHash) (txns []types.Transaction, err error) {
+	// Add to threadgroup, check locks
+	_, err = preNFTWalletSetup(w)
+	if err != nil {
+		return nil, err // setup failed, pass the error on
+	}
+	if err := w.managedCheckNFTUnstaked(nft); err != nil {
+		return nil, err
+	}
+	if err := w.managedCheckNFTNotFrozen(nft); err != nil {
+		return nil, err
+	}
+
+	// Locate NFT output from previous chain-of-custody
+	goal_scoid, goalOutput, err := w.managedNFTCustodyOutput(nft)
+	if err != nil {
+		w.log.Println("Attempt to approve NFT operator has failed:", err)
+		return nil, err
+	}
+	if operator == goalOutput.UnlockHash {
+		return nil, errNFTOperatorIsOwner
+	}
+
+	// Keep the NFT at the same address and pay the ticket to the operator
+	outputs := []types.SiacoinOutput{goalOutput, {
+		UnlockHash: operator,
+		Value:      types.OneBaseUnit,
+	}}
+	w.log.Println("Submitting an NFT Approval transaction for nft", nft.Identifier(), "approving", operator)
+	return w.managedSendNFTStakeTransaction(types.NFTArbitraryData(types.NFTApproveTag, nft), goal_scoid, goalOutput, outputs, types.OneBaseUnit)
+}
+
+// RevokeNFTApproval revokes the approval of an NFT held by the wallet. The
+// ticket of the operator stays with the operator but can't be used anymore.
+func (w *Wallet) RevokeNFTApproval(nft types.NftCustody) (txns []types.Transaction, err error) {
+	// Add to threadgroup, check locks
+	_, err = preNFTWalletSetup(w)
+	if err != nil {
+		return nil, err // setup failed, pass the error on
+	}
+	if _, err := w.cs.ViewNFTApproval(nft); err != nil {
+		return nil, errNFTNotApproved
+	}
+
+	// Locate NFT output from previous chain-of-custody
+	goal_scoid, goalOutput, err := w.managedNFTCustodyOutput(nft)
+	if err != nil {
+		w.log.Println("Attempt to revoke NFT approval has failed:", err)
+		return nil, err
+	}
+
+	// Keep the NFT at the same address without paying a ticket
+	outputs := []types.SiacoinOutput{goalOutput}
+	w.log.Println("Submitting an NFT Approval Revocation transaction for nft", nft.Identifier())
+	return w.managedSendNFTStakeTransaction(types.NFTArbitraryData(types.NFTApproveTag, nft), goal_scoid, goalOutput, outputs, types.ZeroCurrency)
+}
+
+// TransferApprovedNFT transfers an NFT whose owner approved one of the
+// wallet's addresses as its operator to dest. The transfer spends the ticket
+// of the approval instead of the NFT's custody output and the wallet pays the
+// transfer fee.
+func (w *Wallet) TransferApprovedNFT(nft types.NftCustody, dest types.UnlockHash) (txns []types.Transaction, err error) {
+	// Add to threadgroup, check locks
+	_, err = preNFTWalletSetup(w)
+	if err != nil {
+		return nil, err // setup failed, pass the error on
+	}
+
+	// Check the transfer policy before paying for the transfer
+	if err := w.managedCheckNFTTransferPolicy(nft); err != nil {
+		return nil, err
+	}
+	if err := w.managedCheckNFTUnstaked(nft); err != nil {
+		return nil, err
+	}
+	if err := w.managedCheckNFTNotFrozen(nft); err != nil {
+		return nil, err
+	}
+	approval, err := w.cs.ViewNFTApproval(nft)
+	if err != nil {
+		return nil, errNFTNotApproved
+	}
+
+	// Locate a ticket of the operator
+	ticketID, ticket, err := w.managedNFTApprovalTicket(approval.Operator)
+	if err != nil {
+		w.log.Println("Attempt to transfer approved NFT has failed:", err)
+		return nil, err
+	}
+	txnSet, txnBuilder, err := w.managedBuildNFTTransfer(nft, ticketID, ticket, dest)
+	if err != nil {
+		return nil, err
+	}
+	if w.deps.Disrupt("InterruptNFTTransferBeforeBroadcast") {
+		txnBuilder.Drop()
+		return nil, errors.New("failed to accept transaction set (InterruptNFTTransferBeforeBroadcast)")
+	}
+	err = w.managedBroadcastNFTTransactions(txnSet)
+	if err != nil {
+		txnBuilder.Drop()
+		w.log.Println("Attempt to send coins has failed - transaction pool rejected transaction:", err)
+		return nil, build.ExtendErr("unable to get transaction accepted", err)
+	}
+	for _, txn := range txnSet {
+		w.log.Println("\t", txn.ID())
+	}
+	return txnSet, nil
+}
+
+// managedNFTApprovalTicket returns the id of one of the wallet's tickets for
+// the operator address together with the output. Every ticket of an operator
+// can be used for any NFT the operator is approved for.
+func (w *Wallet) managedNFTApprovalTicket(operator types.UnlockHash) (types.SiacoinOutputID, types.SiacoinOutput, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, exists := w.keys[operator]; !exists {
+		return types.SiacoinOutputID{}, types.SiacoinOutput{}, errNotNFTOperator
+	}
+	var ticketID types.SiacoinOutputID
+	var ticket types.SiacoinOutput
+	var found bool
+	err := dbForEachSiacoinOutput(w.dbTx, func(scoid types.SiacoinOutputID, sco types.SiacoinOutput) {
+		if sco.UnlockHash == operator && sco.Value.Equals(types.OneBaseUnit) {
+			ticketID, ticket, found = scoid, sco, true
+		}
+	})
+	if err != nil {
+		return types.SiacoinOutputID{}, types.SiacoinOutput{}, err
+	}
+	if !found {
+		return types.SiacoinOutputID{}, types.SiacoinOutput{}, errNotNFTOperator
+	}
+	return ticketID, ticket, nil
+}
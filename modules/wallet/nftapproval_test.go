@@ -0,0 +1,130 @@
+package wallet
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestApproveNFTOperator tests approving an operator, revoking the approval and
+// transferring an NFT on behalf of its owner.
+func TestApproveNFTOperator(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	dest := func() types.UnlockHash {
+		uc, err := wt.wallet.NextAddress()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return uc.UnlockHash()
+	}
+	mine := func() {
+		if _, err := wt.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Mint an NFT of some data to the wallet and confirm it.
+	nft := types.NftCustody{FileMerkleRoot: crypto.MerkleRoot(fastrand.Bytes(100))}
+	owner := dest()
+	if _, err := wt.wallet.MintNFT(nft, owner); err != nil {
+		t.Fatal(err)
+	}
+	mine()
+
+	// The wallet acts as the operator as well, using an address of its NFT
+	// custody account.
+	uc, err := wt.wallet.NextNFTCustodyAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	operator := uc.UnlockHash()
+	if _, err := wt.wallet.TransferApprovedNFT(nft, dest()); !errors.Contains(err, errNFTNotApproved) {
+		t.Fatal("expected errNFTNotApproved but got", err)
+	}
+	if _, err := wt.wallet.RevokeNFTApproval(nft); !errors.Contains(err, errNFTNotApproved) {
+		t.Fatal("expected errNFTNotApproved but got", err)
+	}
+	if _, err := wt.wallet.ApproveNFTOperator(nft, owner); !errors.Contains(err, errNFTOperatorIsOwner) {
+		t.Fatal("expected errNFTOperatorIsOwner but got", err)
+	}
+
+	// Approve the operator and revoke the approval again.
+	if _, err := wt.wallet.ApproveNFTOperator(nft, operator); err != nil {
+		t.Fatal(err)
+	}
+	mine()
+	approval, err := wt.cs.ViewNFTApproval(nft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if approval.Operator != operator || approval.Height != wt.cs.Height() {
+		t.Fatal("unexpected approval", approval)
+	}
+	if _, err := wt.wallet.RevokeNFTApproval(nft); err != nil {
+		t.Fatal(err)
+	}
+	mine()
+	if _, err := wt.cs.ViewNFTApproval(nft); err == nil {
+		t.Fatal("approval wasn't revoked")
+	}
+	if _, err := wt.wallet.TransferApprovedNFT(nft, dest()); !errors.Contains(err, errNFTNotApproved) {
+		t.Fatal("expected errNFTNotApproved but got", err)
+	}
+
+	// Approve the operator again and transfer the NFT on behalf of the
+	// owner.
+	if _, err := wt.wallet.ApproveNFTOperator(nft, operator); err != nil {
+		t.Fatal(err)
+	}
+	mine()
+	buyer := dest()
+	if _, err := wt.wallet.TransferApprovedNFT(nft, buyer); err != nil {
+		t.Fatal(err)
+	}
+	mine()
+	custody, err := wt.cs.ViewNFTCustody(nft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if custody.UnlockHash != buyer {
+		t.Fatal("nft wasn't transferred to the buyer")
+	}
+
+	// The transfer used up the approval.
+	if _, err := wt.cs.ViewNFTApproval(nft); err == nil {
+		t.Fatal("approval wasn't cleared by the transfer")
+	}
+	if _, err := wt.wallet.TransferApprovedNFT(nft, dest()); !errors.Contains(err, errNFTNotApproved) {
+		t.Fatal("expected errNFTNotApproved but got", err)
+	}
+
+	// Approvals and the transfer by the operator continue the chain of
+	// custody.
+	p, err := wt.wallet.ExportProvenance(nft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Transfers) != 4 {
+		t.Fatal("expected 4 transfers but got", len(p.Transfers))
+	}
+	if err := types.VerifyNFTProvenance(p); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,121 @@
+package wallet
+
+import (
+	"bytes"
+	"sort"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// nftaudit.go contains the NFT custody reconciliation check. After a crash or
+// a restore the wallet's view of its NFTs can diverge from consensus, e.g.
+// because outputs are missing from the database or because keys are no longer
+// derived from the seed.
+
+// nftBacking identifies the outputs which can back the custody of an NFT: the
+// outputs of an address with the value the custody index expects.
+type nftBacking struct {
+	addr  types.UnlockHash
+	value string
+}
+
+// AuditNFTs cross-checks the NFTs reported by ScanAllNFTS against the
+// consensus custody index and the wallet's spendable outputs. It reports NFTs
+// whose backing output is missing, NFTs which are double-counted and NFTs held
+// by addresses the wallet tracks without being able to derive their keys.
+func (w *Wallet) AuditNFTs() ([]modules.NFTAuditIssue, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	scanned := w.ScanAllNFTS()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.unlocked {
+		return nil, modules.ErrLockedWallet
+	}
+
+	// Count the spendable outputs of every address by value and collect the
+	// addresses which are tracked by the wallet.
+	available := make(map[nftBacking]int)
+	tracked := make(map[types.UnlockHash]struct{})
+	err := dbForEachSiacoinOutput(w.dbTx, func(_ types.SiacoinOutputID, sco types.SiacoinOutput) {
+		available[nftBacking{sco.UnlockHash, sco.Value.String()}]++
+		tracked[sco.UnlockHash] = struct{}{}
+	})
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to iterate over wallet outputs")
+	}
+	err = dbForEachNFTDepositAddr(w.dbTx, func(addr types.UnlockHash, _ string) {
+		tracked[addr] = struct{}{}
+	})
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to iterate over nft deposit addresses")
+	}
+	for addr := range w.watchedAddrs {
+		tracked[addr] = struct{}{}
+	}
+
+	// Group the scanned NFTs by the outputs backing them.
+	var issues []modules.NFTAuditIssue
+	report := func(nft types.NftCustody, owner types.UnlockHash, issue modules.NFTAuditIssueType) {
+		issues = append(issues, modules.NFTAuditIssue{
+			NFT:   nft,
+			Owner: owner,
+			Type:  issue,
+		})
+	}
+	seen := make(map[types.NftID]struct{})
+	claims := make(map[nftBacking][]types.NftOwnershipStats)
+	for _, stats := range scanned {
+		if _, exists := seen[stats.Nft.Identifier()]; exists {
+			report(stats.Nft, stats.Owner, modules.NFTAuditDoubleCounted)
+			continue
+		}
+		seen[stats.Nft.Identifier()] = struct{}{}
+
+		custody, err := w.cs.ViewNFTCustody(stats.Nft)
+		if err != nil || custody.UnlockHash != stats.Owner {
+			report(stats.Nft, stats.Owner, modules.NFTAuditCustodyMismatch)
+			continue
+		}
+		backing := nftBacking{stats.Owner, custody.Value.String()}
+		claims[backing] = append(claims[backing], stats)
+	}
+	for backing, nfts := range claims {
+		switch {
+		case available[backing] == 0:
+			for _, stats := range nfts {
+				report(stats.Nft, stats.Owner, modules.NFTAuditMissingOutput)
+			}
+		case available[backing] < len(nfts):
+			for _, stats := range nfts {
+				report(stats.Nft, stats.Owner, modules.NFTAuditDoubleCounted)
+			}
+		}
+	}
+
+	// Check the tracked addresses without keys.
+	for addr := range tracked {
+		if _, exists := w.keys[addr]; exists {
+			continue
+		}
+		for _, nft := range w.cs.FindNFTsForAddress(addr) {
+			report(nft, addr, modules.NFTAuditUnknownKey)
+		}
+	}
+
+	sort.Slice(issues, func(i, j int) bool {
+		if issues[i].Owner != issues[j].Owner {
+			return bytes.Compare(issues[i].Owner[:], issues[j].Owner[:]) < 0
+		}
+		idI, idJ := issues[i].NFT.Identifier(), issues[j].NFT.Identifier()
+		return bytes.Compare(idI[:], idJ[:]) < 0
+	})
+	return issues, nil
+}
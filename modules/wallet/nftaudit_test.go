@@ -0,0 +1,111 @@
+package wallet
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestAuditNFTs tests that the NFT audit detects missing backing outputs and
+// NFTs held by addresses the wallet can't derive keys for.
+func TestAuditNFTs(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Mint an NFT to the wallet and confirm it.
+	uc, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner := uc.UnlockHash()
+	var nft types.NftCustody
+	fastrand.Read(nft.FileMerkleRoot[:])
+	if _, err := wt.wallet.MintNFT(nft, owner); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A healthy wallet has no issues.
+	issues, err := wt.wallet.AuditNFTs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 0 {
+		t.Fatal("expected no issues but got", issues)
+	}
+
+	// Remove the backing output from the database.
+	wt.wallet.mu.Lock()
+	var backing []types.SiacoinOutputID
+	var backingOutputs []types.SiacoinOutput
+	err = dbForEachSiacoinOutput(wt.wallet.dbTx, func(id types.SiacoinOutputID, sco types.SiacoinOutput) {
+		if sco.UnlockHash == owner {
+			backing = append(backing, id)
+			backingOutputs = append(backingOutputs, sco)
+		}
+	})
+	for _, id := range backing {
+		err = dbDeleteSiacoinOutput(wt.wallet.dbTx, id)
+	}
+	wt.wallet.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backing) == 0 {
+		t.Fatal("no backing output found")
+	}
+	issues, err = wt.wallet.AuditNFTs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 1 || issues[0].Type != modules.NFTAuditMissingOutput || issues[0].NFT != nft || issues[0].Owner != owner {
+		t.Fatal("expected missing output but got", issues)
+	}
+
+	// Restore the output and forget the key of the owner.
+	wt.wallet.mu.Lock()
+	for i, id := range backing {
+		err = dbPutSiacoinOutput(wt.wallet.dbTx, id, backingOutputs[i])
+	}
+	key := wt.wallet.keys[owner]
+	delete(wt.wallet.keys, owner)
+	wt.wallet.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	issues, err = wt.wallet.AuditNFTs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 1 || issues[0].Type != modules.NFTAuditUnknownKey || issues[0].NFT != nft || issues[0].Owner != owner {
+		t.Fatal("expected unknown key but got", issues)
+	}
+
+	// Restoring the key fixes the wallet.
+	wt.wallet.mu.Lock()
+	wt.wallet.keys[owner] = key
+	wt.wallet.mu.Unlock()
+	issues, err = wt.wallet.AuditNFTs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 0 {
+		t.Fatal("expected no issues but got", issues)
+	}
+}
@@ -0,0 +1,119 @@
+package wallet
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestBridgeLockNFT tests locking an NFT for a bridge and releasing it with the
+// attestations of the bridge.
+func TestBridgeLockNFT(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Mint an NFT to the wallet and confirm it.
+	uc, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var nft types.NftCustody
+	fastrand.Read(nft.FileMerkleRoot[:])
+	if _, err := wt.wallet.MintNFT(nft, uc.UnlockHash()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A bridge which doesn't require multiple signatures is rejected.
+	bridge, keys := types.GenerateDeterministicMultisig(2, 3, t.Name())
+	claim := types.NFTBridgeClaim{Chain: "eth", Recipient: "0x0123456789abcdef"}
+	singleSig := types.UnlockConditions{
+		PublicKeys:         bridge.PublicKeys[:1],
+		SignaturesRequired: 1,
+	}
+	if _, err := wt.wallet.BridgeLockNFT(nft, singleSig, claim); !errors.Contains(err, errNFTBridgeNotMultisig) {
+		t.Fatal("expected errNFTBridgeNotMultisig but got", err)
+	}
+
+	// Lock the NFT.
+	txns, err := wt.wallet.BridgeLockNFT(nft, bridge, claim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	lock, err := wt.cs.ViewNFTBridgeLock(nft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lock.Claim != claim || lock.Bridge != bridge.UnlockHash() || lock.Height != wt.cs.Height() {
+		t.Fatalf("unexpected lock %+v", lock)
+	}
+	if _, err := wt.wallet.BridgeLockNFT(nft, bridge, claim); !errors.Contains(err, errNFTBridgeLocked) {
+		t.Fatal("expected errNFTBridgeLocked but got", err)
+	}
+
+	// Release the NFT to a new address with the bridge's attestations.
+	lockTxn := txns[len(txns)-1]
+	custodyIndex, ok := types.NFTCustodyOutputIndex(lockTxn)
+	if !ok {
+		t.Fatal("lock has no custody output")
+	}
+	uc, err = wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dest := uc.UnlockHash()
+	unlock := types.NFTBridgeUnlockTransaction(nft, lockTxn.SiacoinOutputID(custodyIndex), bridge, dest, []uint64{0, 2})
+	for _, i := range []uint64{0, 2} {
+		if err := types.SignNFTBridgeUnlock(&unlock, i, keys[i], wt.cs.Height()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := wt.tpool.AcceptTransactionSet([]types.Transaction{unlock}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.cs.ViewNFTBridgeLock(nft); err == nil {
+		t.Fatal("NFT should be unlocked")
+	}
+	custody, err := wt.cs.ViewNFTCustody(nft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if custody.UnlockHash != dest {
+		t.Fatal("NFT wasn't released to the destination")
+	}
+
+	// The provenance of the NFT includes the lock and the unlock.
+	p, err := wt.wallet.ExportProvenance(nft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Transfers) != 2 {
+		t.Fatal("expected 2 transfers but got", len(p.Transfers))
+	}
+	if err := types.VerifyNFTProvenance(p); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,92 @@
+package wallet
+
+import (
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// nftcompose.go contains the transaction composer, which lets marketplaces
+// settle a sale atomically: the NFT is transferred by the same transaction
+// which pays the seller, the royalty and the marketplace fee. Consensus accepts
+// transfers with additional outputs once composite transfers are active, as
+// long as the colored coin precedes the payments.
+
+var (
+	// errEmptyTransactionSpec is returned when composing a transaction which
+	// neither transfers an NFT nor pays an output.
+	errEmptyTransactionSpec = errors.New("transaction spec has neither an nft nor siacoin outputs")
+
+	// errNoNFTDestination is returned when composing a transaction which
+	// transfers an NFT without a destination.
+	errNoNFTDestination = errors.New("transaction spec transfers an nft without a destination")
+)
+
+// validateTransactionSpec checks that a transaction spec can be composed.
+func validateTransactionSpec(spec modules.TransactionSpec) error {
+	if spec.NFT == nil && len(spec.SiacoinOutputs) == 0 {
+		return errEmptyTransactionSpec
+	}
+	if spec.NFT != nil && spec.NFTDestination == (types.UnlockHash{}) {
+		return errNoNFTDestination
+	}
+	for _, sco := range spec.SiacoinOutputs {
+		if sco.Value.IsZero() {
+			return types.ErrZeroOutput
+		}
+	}
+	return nil
+}
+
+// ComposeTransaction builds, signs and broadcasts a single transaction which
+// pays the siacoin outputs of spec and, if spec has an NFT, transfers the NFT
+// to spec.NFTDestination. The NFT has to be held by the wallet.
+func (w *Wallet) ComposeTransaction(spec modules.TransactionSpec) (txns []types.Transaction, err error) {
+	if err := validateTransactionSpec(spec); err != nil {
+		return nil, err
+	}
+	if spec.NFT == nil {
+		return w.SendSiacoinsMulti(spec.SiacoinOutputs)
+	}
+
+	// Add to threadgroup, check locks
+	_, err = preNFTWalletSetup(w)
+	if err != nil {
+		return nil, err // setup failed, pass the error on
+	}
+	nft := *spec.NFT
+
+	// Check the NFT can be transferred before paying for the transaction
+	if err := w.managedCheckNFTTransferPolicy(nft); err != nil {
+		return nil, err
+	}
+	if err := w.managedCheckNFTUnstaked(nft); err != nil {
+		return nil, err
+	}
+	if err := w.managedCheckNFTNotFrozen(nft); err != nil {
+		return nil, err
+	}
+	scoid, sco, err := w.managedNFTCustodyOutput(nft)
+	if err != nil {
+		w.log.Println("Attempt to compose NFT transaction has failed:", err)
+		return nil, err
+	}
+
+	arb := types.NFTArbitraryData(types.NFTTransferTag, nft)
+	txnSet, txnBuilder, err := w.managedBuildNFTCustodyTransfer(arb, nft, scoid, sco, spec.NFTDestination, spec.SiacoinOutputs)
+	if err != nil {
+		return nil, err
+	}
+	err = w.managedBroadcastNFTTransactions(txnSet)
+	if err != nil {
+		txnBuilder.Drop()
+		w.log.Println("Attempt to compose NFT transaction has failed - transaction pool rejected transaction:", err)
+		return nil, build.ExtendErr("unable to get transaction accepted", err)
+	}
+	for _, txn := range txnSet {
+		w.log.Println("\t", txn.ID())
+	}
+	return txnSet, nil
+}
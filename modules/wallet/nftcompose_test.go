@@ -0,0 +1,98 @@
+package wallet
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestComposeTransaction tests that a composed sale transfers the NFT and pays
+// every recipient in the same transaction.
+func TestComposeTransaction(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	nft, _, err := wt.mintTestNFT()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Invalid specs are rejected.
+	buyer, seller, marketplace := types.UnlockHash{1}, types.UnlockHash{2}, types.UnlockHash{3}
+	for _, test := range []struct {
+		spec modules.TransactionSpec
+		err  error
+	}{
+		{modules.TransactionSpec{}, errEmptyTransactionSpec},
+		{modules.TransactionSpec{NFT: &nft}, errNoNFTDestination},
+		{modules.TransactionSpec{SiacoinOutputs: []types.SiacoinOutput{{UnlockHash: seller}}}, types.ErrZeroOutput},
+	} {
+		if _, err := wt.wallet.ComposeTransaction(test.spec); !errors.Contains(err, test.err) {
+			t.Fatal("expected", test.err, "but got", err)
+		}
+	}
+
+	// Compose the sale.
+	payments := []types.SiacoinOutput{
+		{UnlockHash: seller, Value: types.SiacoinPrecision.Mul64(900)},
+		{UnlockHash: marketplace, Value: types.SiacoinPrecision.Mul64(100)},
+	}
+	txns, err := wt.wallet.ComposeTransaction(modules.TransactionSpec{
+		NFT:            &nft,
+		NFTDestination: buyer,
+		SiacoinOutputs: payments,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sale := txns[len(txns)-1]
+	if !types.IsNFTTransferTransaction(sale) {
+		t.Fatal("sale isn't a transfer")
+	}
+	outputs := sale.SiacoinOutputs
+	if len(outputs) != 4 || outputs[1].UnlockHash != buyer {
+		t.Fatal("sale has the wrong outputs", outputs)
+	}
+	for i, payment := range payments {
+		if outputs[i+2].UnlockHash != payment.UnlockHash || !outputs[i+2].Value.Equals(payment.Value) {
+			t.Fatal("sale doesn't pay", payment)
+		}
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	custody, err := wt.cs.ViewNFTCustody(nft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if custody.UnlockHash != buyer {
+		t.Fatal("NFT wasn't transferred to the buyer")
+	}
+
+	// The NFT has left the wallet.
+	if _, err := wt.wallet.ComposeTransaction(modules.TransactionSpec{NFT: &nft, NFTDestination: seller}); !errors.Contains(err, errNFTNotInWallet) {
+		t.Fatal("expected errNFTNotInWallet but got", err)
+	}
+
+	// Specs without an NFT only pay their outputs.
+	txns, err = wt.wallet.ComposeTransaction(modules.TransactionSpec{SiacoinOutputs: payments})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if types.IsNFTTransaction(txns[len(txns)-1]) {
+		t.Fatal("payment shouldn't be an NFT transaction")
+	}
+}
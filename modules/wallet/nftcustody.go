@@ -0,0 +1,367 @@
+package wallet
+
+import (
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// nftcustody.go contains the exchange oriented NFT custody subsystem. Every
+// user of an exchange gets a deposit address. NFTs minted or transferred to
+// those addresses are tracked as deposits, registered callbacks are notified
+// once a deposit is confirmed and the deposited NFTs can be swept to a cold
+// address.
+
+const (
+	// nftSweepBatchSize is the number of NFT transfers that are submitted to
+	// the transaction pool within a single transaction set when sweeping the
+	// deposit addresses.
+	nftSweepBatchSize = 10
+)
+
+var (
+	// errEmptyNFTDepositUserID is returned when requesting a deposit address
+	// for an empty user ID.
+	errEmptyNFTDepositUserID = errors.New("user id of nft deposit address can't be empty")
+
+	// errNoNFTDepositConfirmations is returned when registering a deposit
+	// callback which requires 0 confirmations.
+	errNoNFTDepositConfirmations = errors.New("nft deposit callbacks require at least 1 confirmation")
+)
+
+// nftDepositCallback is a function that is called for every NFT deposit as
+// soon as the deposit has reached the required number of confirmations.
+type nftDepositCallback struct {
+	confirmations types.BlockHeight
+	fn            func(modules.NFTDeposit)
+
+	// notified contains the deposits the callback was already called for.
+	// It is only kept in memory, like the callback itself, which is why
+	// deposits are delivered at least once rather than exactly once.
+	notified map[types.SiacoinOutputID]struct{}
+}
+
+// dbPutNFTDepositAddr stores the user id of a deposit address.
+func dbPutNFTDepositAddr(tx *bolt.Tx, addr types.UnlockHash, userID string) error {
+	return dbPut(tx.Bucket(bucketNFTDepositAddrs), addr, userID)
+}
+
+// dbGetNFTDepositAddr returns the user id of a deposit address.
+func dbGetNFTDepositAddr(tx *bolt.Tx, addr types.UnlockHash) (userID string, err error) {
+	err = dbGet(tx.Bucket(bucketNFTDepositAddrs), addr, &userID)
+	return
+}
+
+// dbPutNFTDepositUser stores the deposit address of a user.
+func dbPutNFTDepositUser(tx *bolt.Tx, userID string, addr types.UnlockHash) error {
+	return dbPut(tx.Bucket(bucketNFTDepositUsers), userID, addr)
+}
+
+// dbGetNFTDepositUser returns the deposit address of a user.
+func dbGetNFTDepositUser(tx *bolt.Tx, userID string) (addr types.UnlockHash, err error) {
+	err = dbGet(tx.Bucket(bucketNFTDepositUsers), userID, &addr)
+	return
+}
+
+// dbForEachNFTDepositAddr iterates over all deposit addresses.
+func dbForEachNFTDepositAddr(tx *bolt.Tx, fn func(types.UnlockHash, string)) error {
+	return dbForEach(tx.Bucket(bucketNFTDepositAddrs), fn)
+}
+
+// dbPutNFTDeposit stores a deposit.
+func dbPutNFTDeposit(tx *bolt.Tx, deposit modules.NFTDeposit) error {
+	return dbPut(tx.Bucket(bucketNFTDeposits), deposit.OutputID, deposit)
+}
+
+// dbDeleteNFTDeposit deletes the deposit which created the output with the
+// given id.
+func dbDeleteNFTDeposit(tx *bolt.Tx, id types.SiacoinOutputID) error {
+	return dbDelete(tx.Bucket(bucketNFTDeposits), id)
+}
+
+// dbForEachNFTDeposit iterates over all deposits.
+func dbForEachNFTDeposit(tx *bolt.Tx, fn func(types.SiacoinOutputID, modules.NFTDeposit)) error {
+	return dbForEach(tx.Bucket(bucketNFTDeposits), fn)
+}
+
+// nftCustodyOutput returns the NFT and the id and output of the custody output
+// of an NFT mint or transfer. The last return value is false for any other
+// transaction.
+func nftCustodyOutput(txn types.Transaction) (types.NftCustody, types.SiacoinOutputID, types.SiacoinOutput, bool) {
+	if !types.IsNFTMintTransaction(txn) && !types.IsNFTTransferTransaction(txn) {
+		return types.NftCustody{}, types.SiacoinOutputID{}, types.SiacoinOutput{}, false
+	}
+	i, ok := types.NFTCustodyOutputIndex(txn)
+	if !ok {
+		return types.NftCustody{}, types.SiacoinOutputID{}, types.SiacoinOutput{}, false
+	}
+	nft, _ := types.ExtractNFTFromTransaction(txn)
+	return nft, txn.SiacoinOutputID(i), txn.SiacoinOutputs[i], true
+}
+
+// applyNFTDeposits records the NFT deposits to deposit addresses in the
+// applied blocks of a consensus change.
+func (w *Wallet) applyNFTDeposits(tx *bolt.Tx, cc modules.ConsensusChange) error {
+	consensusHeight := cc.InitialHeight()
+	for _, block := range cc.AppliedBlocks {
+		if block.ID() != types.GenesisID {
+			consensusHeight++
+		}
+		for _, txn := range block.Transactions {
+			nft, id, sco, ok := nftCustodyOutput(txn)
+			if !ok {
+				continue
+			}
+			userID, err := dbGetNFTDepositAddr(tx, sco.UnlockHash)
+			if errors.Contains(err, errNoKey) {
+				continue
+			} else if err != nil {
+				return errors.AddContext(err, "failed to get nft deposit address")
+			}
+			w.log.Println("Received NFT deposit", nft.Identifier(), "for user", userID)
+			err = dbPutNFTDeposit(tx, modules.NFTDeposit{
+				NFT:           nft,
+				UserID:        userID,
+				Address:       sco.UnlockHash,
+				OutputID:      id,
+				TransactionID: txn.ID(),
+				BlockHeight:   consensusHeight,
+			})
+			if err != nil {
+				return errors.AddContext(err, "failed to store nft deposit")
+			}
+		}
+	}
+	return nil
+}
+
+// revertNFTDeposits removes the NFT deposits within reverted blocks.
+func (w *Wallet) revertNFTDeposits(tx *bolt.Tx, reverted []types.Block) error {
+	for _, block := range reverted {
+		for _, txn := range block.Transactions {
+			_, id, _, ok := nftCustodyOutput(txn)
+			if !ok {
+				continue
+			}
+			if err := dbDeleteNFTDeposit(tx, id); err != nil {
+				return errors.AddContext(err, "failed to delete nft deposit")
+			}
+			for _, cb := range w.nftDepositCallbacks {
+				delete(cb.notified, id)
+			}
+		}
+	}
+	return nil
+}
+
+// nftDeposits returns all deposits with their number of confirmations.
+func (w *Wallet) nftDeposits(tx *bolt.Tx) ([]modules.NFTDeposit, error) {
+	height, err := dbGetConsensusHeight(tx)
+	if err != nil {
+		return nil, err
+	}
+	var deposits []modules.NFTDeposit
+	err = dbForEachNFTDeposit(tx, func(_ types.SiacoinOutputID, deposit modules.NFTDeposit) {
+		if height >= deposit.BlockHeight {
+			deposit.Confirmations = height - deposit.BlockHeight + 1
+		}
+		deposits = append(deposits, deposit)
+	})
+	return deposits, err
+}
+
+// notifyNFTDepositCallbacks calls the registered callbacks for all deposits
+// that have reached the callback's number of confirmations.
+func (w *Wallet) notifyNFTDepositCallbacks(tx *bolt.Tx) {
+	if len(w.nftDepositCallbacks) == 0 {
+		return
+	}
+	deposits, err := w.nftDeposits(tx)
+	if err != nil {
+		w.log.Println("WARN: failed to get nft deposits for callbacks:", err)
+		return
+	}
+	for _, cb := range w.nftDepositCallbacks {
+		for _, deposit := range deposits {
+			if deposit.Confirmations < cb.confirmations {
+				continue
+			}
+			if _, notified := cb.notified[deposit.OutputID]; notified {
+				continue
+			}
+			cb.notified[deposit.OutputID] = struct{}{}
+			go w.threadedCallNFTDepositCallback(cb.fn, deposit)
+		}
+	}
+}
+
+// threadedCallNFTDepositCallback calls a deposit callback without blocking
+// consensus updates.
+func (w *Wallet) threadedCallNFTDepositCallback(fn func(modules.NFTDeposit), deposit modules.NFTDeposit) {
+	if err := w.tg.Add(); err != nil {
+		return
+	}
+	defer w.tg.Done()
+	fn(deposit)
+}
+
+// NFTDepositAddress returns the NFT deposit address of a user, creating one
+// if the user doesn't have one yet.
+func (w *Wallet) NFTDepositAddress(userID string) (types.UnlockHash, error) {
+	if err := w.tg.Add(); err != nil {
+		return types.UnlockHash{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	if userID == "" {
+		return types.UnlockHash{}, errEmptyNFTDepositUserID
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.unlocked {
+		return types.UnlockHash{}, modules.ErrLockedWallet
+	}
+
+	// Return the existing address if there is one.
+	addr, err := dbGetNFTDepositUser(w.dbTx, userID)
+	if err == nil {
+		return addr, nil
+	} else if !errors.Contains(err, errNoKey) {
+		return types.UnlockHash{}, errors.AddContext(err, "failed to look up deposit address")
+	}
+
+	// Otherwise create a new one.
+	uc, err := w.nextNFTCustodyAddress(w.dbTx)
+	if err != nil {
+		return types.UnlockHash{}, errors.AddContext(err, "failed to create deposit address")
+	}
+	addr = uc.UnlockHash()
+	err = dbPutNFTDepositAddr(w.dbTx, addr, userID)
+	err = errors.Compose(err, dbPutNFTDepositUser(w.dbTx, userID, addr))
+	err = errors.Compose(err, w.syncDB())
+	if err != nil {
+		return types.UnlockHash{}, errors.AddContext(err, "failed to store deposit address")
+	}
+	return addr, nil
+}
+
+// NFTDeposits returns all NFT deposits to the wallet's deposit addresses.
+func (w *Wallet) NFTDeposits() ([]modules.NFTDeposit, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.nftDeposits(w.dbTx)
+}
+
+// RegisterNFTDepositCallback registers a function which is called for every
+// NFT deposit as soon as it has reached the given number of confirmations.
+// Deposits which already have enough confirmations are passed to the callback
+// right away. The callback is called in its own goroutine. Deliveries are at
+// least once: callbacks aren't persisted, so a callback registered again after
+// a restart is called for all confirmed deposits again, and a deposit which is
+// reverted and confirmed again is delivered twice. Callers should deduplicate
+// deposits by their OutputID.
+func (w *Wallet) RegisterNFTDepositCallback(confirmations types.BlockHeight, fn func(modules.NFTDeposit)) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	if confirmations == 0 {
+		return errNoNFTDepositConfirmations
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.nftDepositCallbacks = append(w.nftDepositCallbacks, &nftDepositCallback{
+		confirmations: confirmations,
+		fn:            fn,
+		notified:      make(map[types.SiacoinOutputID]struct{}),
+	})
+	w.notifyNFTDepositCallbacks(w.dbTx)
+	return nil
+}
+
+// SweepNFTDeposits transfers all NFTs that are still held by deposit addresses
+// to dest. The transfers are submitted in transaction sets of up to
+// nftSweepBatchSize transfers each. If submitting a batch fails, the
+// transactions of the previous batches are returned together with the error.
+func (w *Wallet) SweepNFTDeposits(dest types.UnlockHash) (txns []types.Transaction, err error) {
+	// Add to threadgroup, check locks
+	_, err = preNFTWalletSetup(w)
+	if err != nil {
+		return nil, err
+	}
+
+	// Collect the deposits whose custody outputs are still unspent and held
+	// by their deposit address.
+	w.mu.Lock()
+	outputs := make(map[types.SiacoinOutputID]types.SiacoinOutput)
+	var deposits []modules.NFTDeposit
+	err = dbForEachNFTDeposit(w.dbTx, func(id types.SiacoinOutputID, deposit modules.NFTDeposit) {
+		var sco types.SiacoinOutput
+		if dbGet(w.dbTx.Bucket(bucketSiacoinOutputs), id, &sco) != nil {
+			return // already spent
+		}
+		outputs[id] = sco
+		deposits = append(deposits, deposit)
+	})
+	w.mu.Unlock()
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to collect nft deposits")
+	}
+	var held []modules.NFTDeposit
+	for _, deposit := range deposits {
+		custody, err := w.cs.ViewNFTCustody(deposit.NFT)
+		if err != nil || custody.UnlockHash != deposit.Address {
+			continue
+		}
+		held = append(held, deposit)
+	}
+
+	// Transfer the NFTs in batches.
+	for len(held) > 0 {
+		n := nftSweepBatchSize
+		if n > len(held) {
+			n = len(held)
+		}
+		set, err := w.managedSweepNFTBatch(held[:n], outputs, dest)
+		if err != nil {
+			return txns, err
+		}
+		w.log.Println("Swept", n, "NFT deposits to", dest)
+		txns = append(txns, set...)
+		held = held[n:]
+	}
+	return txns, nil
+}
+
+// managedSweepNFTBatch transfers the NFTs of the given deposits to dest within
+// a single transaction set.
+func (w *Wallet) managedSweepNFTBatch(deposits []modules.NFTDeposit, outputs map[types.SiacoinOutputID]types.SiacoinOutput, dest types.UnlockHash) (_ []types.Transaction, err error) {
+	var builders []modules.TransactionBuilder
+	defer func() {
+		if err != nil {
+			for _, builder := range builders {
+				builder.Drop()
+			}
+		}
+	}()
+	var set []types.Transaction
+	for _, deposit := range deposits {
+		transfer, builder, err := w.managedBuildNFTTransfer(deposit.NFT, deposit.OutputID, outputs[deposit.OutputID], dest)
+		if err != nil {
+			return nil, errors.AddContext(err, "failed to build nft transfer")
+		}
+		builders = append(builders, builder)
+		set = append(set, transfer...)
+	}
+	if err := w.managedBroadcastNFTTransactions(set); err != nil {
+		return nil, errors.AddContext(err, "failed to submit nft sweep")
+	}
+	return set, nil
+}
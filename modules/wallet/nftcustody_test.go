@@ -0,0 +1,206 @@
+package wallet
+
+import (
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/siatest/dependencies"
+	"go.sia.tech/siad/types"
+)
+
+// TestNFTDepositAddress tests that every user gets a single, unique deposit
+// address which is tracked by the wallet.
+func TestNFTDepositAddress(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// An empty user id is not allowed.
+	if _, err := wt.wallet.NFTDepositAddress(""); !errors.Contains(err, errEmptyNFTDepositUserID) {
+		t.Fatal("expected errEmptyNFTDepositUserID but got", err)
+	}
+
+	// Requesting the address of the same user twice returns the same address.
+	addr1, err := wt.wallet.NFTDepositAddress("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr, err := wt.wallet.NFTDepositAddress("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr != addr1 {
+		t.Fatal("expected the same deposit address for the same user")
+	}
+
+	// Another user gets a different address.
+	addr2, err := wt.wallet.NFTDepositAddress("bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr2 == addr1 {
+		t.Fatal("expected different deposit addresses for different users")
+	}
+
+	// Both addresses belong to the wallet.
+	for _, addr := range []types.UnlockHash{addr1, addr2} {
+		if _, err := wt.wallet.UnlockConditions(addr); err != nil {
+			t.Fatal("deposit address is not tracked by the wallet", err)
+		}
+	}
+
+	// There are no deposits yet and callbacks need at least 1 confirmation.
+	deposits, err := wt.wallet.NFTDeposits()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deposits) != 0 {
+		t.Fatal("expected no deposits but got", len(deposits))
+	}
+	err = wt.wallet.RegisterNFTDepositCallback(0, func(modules.NFTDeposit) {})
+	if !errors.Contains(err, errNoNFTDepositConfirmations) {
+		t.Fatal("expected errNoNFTDepositConfirmations but got", err)
+	}
+}
+
+// TestNFTDepositUsersMigration tests that the index of the deposit addresses
+// by user is built for a wallet which was created without it.
+func TestNFTDepositUsersMigration(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	addr, err := wt.wallet.NFTDepositAddress("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Drop the index and restart the wallet.
+	wt.wallet.mu.Lock()
+	err = wt.wallet.dbTx.DeleteBucket(bucketNFTDepositUsers)
+	err = errors.Compose(err, wt.wallet.syncDB())
+	wt.wallet.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.wallet.Close(); err != nil {
+		t.Fatal(err)
+	}
+	w, err := New(wt.cs, wt.tpool, filepath.Join(wt.persistDir, modules.WalletDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt.wallet = w
+	if err := wt.wallet.Unlock(wt.walletMasterKey); err != nil {
+		t.Fatal(err)
+	}
+
+	// The user still gets the same address.
+	migrated, err := wt.wallet.NFTDepositAddress("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if migrated != addr {
+		t.Fatal("expected the same deposit address after the migration")
+	}
+}
+
+// TestNFTDepositsApplyInterrupted tests that a wallet which crashes while
+// recording the NFT deposits of a block records them after restarting.
+func TestNFTDepositsApplyInterrupted(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	deps := dependencies.NewDependencyInterruptNFTDepositsApply()
+	wt, err := createWalletTester(t.Name(), deps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Mint an NFT to a deposit address and interrupt the wallet while it
+	// processes the block confirming the mint.
+	addr, err := wt.wallet.NFTDepositAddress("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	nft := types.NftCustody{FileMerkleRoot: crypto.MerkleRoot(fastrand.Bytes(crypto.SegmentSize))}
+	if _, err := wt.wallet.MintNFT(nft, addr); err != nil {
+		t.Fatal(err)
+	}
+	deps.Fail()
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate the crash by discarding everything that wasn't synced to disk
+	// and restart the wallet.
+	wt.wallet.mu.Lock()
+	err = wt.wallet.dbTx.Rollback()
+	if err == nil {
+		wt.wallet.dbTx, err = wt.wallet.db.Begin(true)
+	}
+	wt.wallet.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	deposits, err := wt.wallet.NFTDeposits()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deposits) != 0 {
+		t.Fatal("deposit was recorded before the wallet restarted")
+	}
+	if err := wt.wallet.Close(); err != nil {
+		t.Fatal(err)
+	}
+	w, err := New(wt.cs, wt.tpool, filepath.Join(wt.persistDir, modules.WalletDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt.wallet = w
+	if err := wt.wallet.Unlock(wt.walletMasterKey); err != nil {
+		t.Fatal(err)
+	}
+
+	// The deposit was recorded when the wallet processed the block again.
+	deposits, err = wt.wallet.NFTDeposits()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deposits) != 1 {
+		t.Fatal("expected 1 deposit but got", len(deposits))
+	}
+	if deposits[0].NFT.Identifier() != nft.Identifier() || deposits[0].UserID != "alice" || deposits[0].Confirmations != 1 {
+		t.Fatal("unexpected deposit", deposits[0])
+	}
+}
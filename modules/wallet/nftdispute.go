@@ -0,0 +1,89 @@
+package wallet
+
+import (
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errNFTFrozen is returned when transferring, liquidating, bridge locking,
+	// approving, staking, unstaking, reclaiming or claiming the insurance of
+	// an NFT which is frozen by a dispute.
+	errNFTFrozen = errors.New("nft is frozen by a dispute and can't be moved until it is unfrozen")
+
+	// errNFTAlreadyFrozen is returned when freezing an NFT which is already
+	// frozen.
+	errNFTAlreadyFrozen = errors.New("nft is already frozen by a dispute")
+
+	// errNFTNotFrozen is returned when unfreezing an NFT which isn't frozen.
+	errNFTNotFrozen = errors.New("nft is not frozen by a dispute")
+)
+
+// managedCheckNFTNotFrozen returns errNFTFrozen if the NFT is frozen by a
+// dispute.
+func (w *Wallet) managedCheckNFTNotFrozen(nft types.NftCustody) error {
+	if dispute, err := w.cs.ViewNFTDispute(nft); err == nil && dispute.Frozen() {
+		return errNFTFrozen
+	}
+	return nil
+}
+
+// FreezeNFT flags an NFT as disputed for the given reason. The flag is
+// authorized by the governance like a disbursement of the lockup pool: the
+// wallet adds an input of the governance address, whose value pays the fee,
+// and signs it with the governance keys it holds. If the wallet's signatures
+// don't complete the transaction, it has to be co-signed by other members of
+// the governance.
+func (w *Wallet) FreezeNFT(nft types.NftCustody, reason types.NFTDisputeReason) ([]types.Transaction, error) {
+	if reason.Kind == types.NFTDisputeResolved {
+		return nil, errors.AddContext(types.ErrNFTBadDisputeReason, "freezes need a kind other than resolved")
+	}
+	dispute, err := w.cs.ViewNFTDispute(nft)
+	if err == nil && dispute.Frozen() {
+		return nil, errNFTAlreadyFrozen
+	}
+	return w.managedSendNFTDisputeFlag(types.NFTFreezeTag, nft, reason)
+}
+
+// UnfreezeNFT resolves the dispute of a frozen NFT. Like FreezeNFT it needs to
+// be co-signed by the governance.
+func (w *Wallet) UnfreezeNFT(nft types.NftCustody, description string) ([]types.Transaction, error) {
+	dispute, err := w.cs.ViewNFTDispute(nft)
+	if err != nil || !dispute.Frozen() {
+		return nil, errNFTNotFrozen
+	}
+	reason := types.NFTDisputeReason{
+		Kind:        types.NFTDisputeResolved,
+		Description: description,
+	}
+	return w.managedSendNFTDisputeFlag(types.NFTUnfreezeTag, nft, reason)
+}
+
+// managedSendNFTDisputeFlag builds the dispute flag of an NFT with the given
+// tag, authorizes it and signs it with the governance keys of the wallet.
+func (w *Wallet) managedSendNFTDisputeFlag(tag []byte, nft types.NftCustody, reason types.NFTDisputeReason) ([]types.Transaction, error) {
+	_, err := preNFTWalletSetup(w)
+	if err != nil {
+		return nil, err
+	}
+	arb := types.NFTDisputeArbitraryData(tag, nft, reason)
+	if _, _, err := types.ParseNFTDisputeFlag(arb); err != nil {
+		return nil, err
+	}
+	if _, err := w.cs.ViewNFTCustody(nft); err != nil {
+		return nil, build.ExtendErr("unable to locate NFT to flag", err)
+	}
+
+	_, fee := w.tpool.FeeEstimation()
+	fee = fee.Mul64(EstimatedNFTTransactionSize)
+	txn := types.Transaction{
+		ArbitraryData: [][]byte{arb},
+	}
+	if err := w.managedAuthorizeNFTGovernance(&txn, fee); err != nil {
+		return nil, err
+	}
+	w.log.Println("Built an NFT dispute flag for nft", nft.Identifier(), "with reason", reason.Kind)
+	return w.SignNFTGovernance([]types.Transaction{txn})
+}
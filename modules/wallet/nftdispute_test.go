@@ -0,0 +1,112 @@
+package wallet
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestNFTDispute tests that a frozen NFT can't be transferred until the
+// governance unfreezes it.
+func TestNFTDispute(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if !types.NFTGovernanceActive(wt.cs.Height() + 1) {
+		t.Skip("governance isn't active")
+	}
+
+	// Load a quorum of the governance keys into the wallet and fund the
+	// governance address.
+	uc, sks := types.GenerateDeterministicMultisig(2, 3, types.NFTGovernanceTestingSalt)
+	governance := uc.UnlockHash()
+	wt.wallet.mu.Lock()
+	wt.wallet.keys[governance] = spendableKey{UnlockConditions: uc, SecretKeys: sks[:2]}
+	wt.wallet.mu.Unlock()
+	if _, err := wt.wallet.SendSiacoins(types.SiacoinPrecision, governance); err != nil {
+		t.Fatal(err)
+	}
+
+	// Mint an NFT to the wallet and confirm it.
+	addr, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var nft types.NftCustody
+	fastrand.Read(nft.FileMerkleRoot[:])
+	if _, err := wt.wallet.MintNFT(nft, addr.UnlockHash()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Freezes need a reason and the NFT can only be unfrozen once frozen.
+	if _, err := wt.wallet.FreezeNFT(nft, types.NFTDisputeReason{}); !errors.Contains(err, types.ErrNFTBadDisputeReason) {
+		t.Fatal("expected ErrNFTBadDisputeReason but got", err)
+	}
+	if _, err := wt.wallet.UnfreezeNFT(nft, ""); !errors.Contains(err, errNFTNotFrozen) {
+		t.Fatal("expected errNFTNotFrozen but got", err)
+	}
+
+	// Freeze the NFT. The wallet holds a quorum, so the flag is broadcast.
+	reason := types.NFTDisputeReason{Kind: types.NFTDisputeCourtOrder, Description: "case 1234/56"}
+	if _, err := wt.wallet.FreezeNFT(nft, reason); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	if !wt.cs.ViewNFTRootFrozen(nft.FileMerkleRoot) {
+		t.Fatal("NFT wasn't frozen")
+	}
+	if _, err := wt.wallet.FreezeNFT(nft, reason); !errors.Contains(err, errNFTAlreadyFrozen) {
+		t.Fatal("expected errNFTAlreadyFrozen but got", err)
+	}
+	dest := types.UnlockHash{1}
+	if _, err := wt.wallet.TransferNFT(nft, dest); !errors.Contains(err, errNFTFrozen) {
+		t.Fatal("expected errNFTFrozen but got", err)
+	}
+
+	// Unfreeze the NFT and transfer it.
+	if _, err := wt.wallet.UnfreezeNFT(nft, "the order was lifted"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	dispute, err := wt.cs.ViewNFTDispute(nft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dispute.Frozen() || len(dispute.Flags) != 2 || dispute.Flags[0].Reason != reason {
+		t.Fatalf("unexpected dispute history %+v", dispute)
+	}
+	if _, err := wt.wallet.TransferNFT(nft, dest); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	custody, err := wt.cs.ViewNFTCustody(nft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if custody.UnlockHash != dest {
+		t.Fatal("NFT wasn't transferred")
+	}
+}
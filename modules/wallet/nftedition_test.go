@@ -0,0 +1,75 @@
+package wallet
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestMintNFTEdition tests minting several editions of a limited run.
+func TestMintNFTEdition(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	uc, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	creator := uc.UnlockHash()
+	var data types.NftCustody
+	fastrand.Read(data.FileMerkleRoot[:])
+
+	// Invalid edition numbers are rejected before paying for the mint.
+	for _, edition := range []uint64{0, 4} {
+		if _, _, err := wt.wallet.MintNFTEdition(data, "prints", edition, 3, creator); !errors.Contains(err, types.ErrNFTBadEdition) {
+			t.Fatal("expected ErrNFTBadEdition but got", err)
+		}
+	}
+
+	// Mint two editions of the run.
+	var minted []types.NftCustody
+	for _, edition := range []uint64{1, 3} {
+		nft, _, err := wt.wallet.MintNFTEdition(data, "prints", edition, 3, creator)
+		if err != nil {
+			t.Fatal(err)
+		}
+		minted = append(minted, nft)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The run can't be extended and editions can't be minted twice.
+	if _, _, err := wt.wallet.MintNFTEdition(data, "prints", 2, 4, creator); !errors.Contains(err, errNFTEditionsMismatch) {
+		t.Fatal("expected errNFTEditionsMismatch but got", err)
+	}
+	if _, _, err := wt.wallet.MintNFTEdition(data, "prints", 3, 3, creator); !errors.Contains(err, errNFTIDExists) {
+		t.Fatal("expected errNFTIDExists but got", err)
+	}
+
+	// Both editions are found together with their owner.
+	editions := wt.cs.FindNFTEditions(data.FileMerkleRoot)
+	if len(editions) != len(minted) {
+		t.Fatalf("expected %v editions but got %v", len(minted), len(editions))
+	}
+	for i, stats := range editions {
+		if stats.Nft != minted[i] || stats.Owner != creator {
+			t.Fatal("unexpected edition", stats)
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package wallet
+
+import (
+	"bytes"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/types"
+)
+
+// nftgovernance.go contains the wallet side of the lockup pool governance.
+// Liquidations which return a lockup and disbursements of the lockup pool are
+// built by one wallet and passed between the wallets of the governance, which
+// co-sign them. Members of the governance load their key of the governance
+// address into their wallets, like siag keys, so that their wallets track the
+// outputs of the governance address. The first co-signer adds an input of the
+// governance address which authorizes the transaction, the co-signer which
+// completes the signatures broadcasts it. Wallets which disburse the lockup
+// pool track its outputs by watching its address. Dispute flags, see
+// nftdispute.go, are co-signed the same way.
+
+var (
+	// errNFTNotGovernor is returned when co-signing an authorized governance
+	// transaction with a wallet which holds none of the missing governance
+	// keys.
+	errNFTNotGovernor = errors.New("wallet holds none of the missing nft governance keys")
+
+	// errNFTGovernanceOutput is returned when authorizing a transaction with
+	// a wallet which doesn't track an unspent output of the governance
+	// address that covers the fee of the transaction.
+	errNFTGovernanceOutput = errors.New("wallet doesn't track an unspent output of the nft governance address")
+
+	// errNFTLockupPoolFunds is returned when disbursing more than the
+	// outputs of the lockup pool which are tracked by the wallet hold.
+	errNFTLockupPoolFunds = errors.New("wallet doesn't track enough outputs of the nft lockup pool")
+
+	// errNoNFTGovernanceTransaction is returned when co-signing a transaction
+	// set whose last transaction doesn't need the authorization of the
+	// governance.
+	errNoNFTGovernanceTransaction = errors.New("transaction doesn't need the authorization of the nft governance")
+)
+
+// nftGovernanceInput returns the index of the input of the governance address
+// in txn, or -1 if there is none.
+func nftGovernanceInput(txn types.Transaction) int {
+	governance := types.NFTGovernanceUnlockConditions.UnlockHash()
+	for i, sci := range txn.SiacoinInputs {
+		if sci.UnlockConditions.UnlockHash() == governance {
+			return i
+		}
+	}
+	return -1
+}
+
+// nftGovernanceComplete returns true if the governance input of txn has all
+// the signatures it requires.
+func nftGovernanceComplete(txn types.Transaction) bool {
+	i := nftGovernanceInput(txn)
+	if i < 0 {
+		return false
+	}
+	parentID := crypto.Hash(txn.SiacoinInputs[i].ParentID)
+	var sigs uint64
+	for _, sig := range txn.TransactionSignatures {
+		if sig.ParentID == parentID {
+			sigs++
+		}
+	}
+	return sigs >= types.NFTGovernanceUnlockConditions.SignaturesRequired
+}
+
+// addNFTGovernanceSignature signs the governance input of txn with sk. It
+// returns false if sk isn't a governance key or already signed the input.
+func addNFTGovernanceSignature(txn *types.Transaction, sk crypto.SecretKey, height types.BlockHeight) bool {
+	i := nftGovernanceInput(*txn)
+	if i < 0 || nftGovernanceComplete(*txn) {
+		return false
+	}
+	pk := sk.PublicKey()
+	keyIndex := -1
+	for j, spk := range types.NFTGovernanceUnlockConditions.PublicKeys {
+		if bytes.Equal(spk.Key, pk[:]) {
+			keyIndex = j
+			break
+		}
+	}
+	if keyIndex < 0 {
+		return false
+	}
+	parentID := crypto.Hash(txn.SiacoinInputs[i].ParentID)
+	for _, sig := range txn.TransactionSignatures {
+		if sig.ParentID == parentID && sig.PublicKeyIndex == uint64(keyIndex) {
+			return false
+		}
+	}
+	txn.TransactionSignatures = append(txn.TransactionSignatures, types.TransactionSignature{
+		ParentID:       parentID,
+		CoveredFields:  types.CoveredFields{WholeTransaction: true},
+		PublicKeyIndex: uint64(keyIndex),
+	})
+	sigIndex := len(txn.TransactionSignatures) - 1
+	encodedSig := crypto.SignHash(txn.SigHash(sigIndex, height), sk)
+	txn.TransactionSignatures[sigIndex].Signature = encodedSig[:]
+	return true
+}
+
+// AddNFTGovernanceSignature signs the governance input of txn with a
+// governance key which isn't held by a wallet. The transaction needs to be
+// authorized already, see Wallet.SignNFTGovernance.
+func AddNFTGovernanceSignature(txn *types.Transaction, sk crypto.SecretKey, height types.BlockHeight) error {
+	if !addNFTGovernanceSignature(txn, sk, height) {
+		return errNFTNotGovernor
+	}
+	return nil
+}
+
+// managedTrackedOutputs returns the unspent outputs of an address which is
+// tracked by the wallet, skipping outputs which were spent recently.
+func (w *Wallet) managedTrackedOutputs(addr types.UnlockHash) (ids []types.SiacoinOutputID, outputs []types.SiacoinOutput, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	height, err := dbGetConsensusHeight(w.dbTx)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = dbForEachSiacoinOutput(w.dbTx, func(id types.SiacoinOutputID, sco types.SiacoinOutput) {
+		if sco.UnlockHash != addr {
+			return
+		}
+		if spendHeight, err := dbGetSpentOutput(w.dbTx, types.OutputID(id)); err == nil && spendHeight+RespendTimeout > height {
+			return
+		}
+		ids = append(ids, id)
+		outputs = append(outputs, sco)
+	})
+	return ids, outputs, err
+}
+
+// managedMarkSpent marks outputs which were added to a governance
+// transaction as spent, so that they aren't used by another one.
+func (w *Wallet) managedMarkSpent(ids []types.SiacoinOutputID) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	height, err := dbGetConsensusHeight(w.dbTx)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := dbPutSpentOutput(w.dbTx, types.OutputID(id), height); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// managedAuthorizeNFTGovernance adds an input of the governance address to
+// txn, together with an output which returns its value to the governance
+// address. The fee is paid out of the input's value.
+func (w *Wallet) managedAuthorizeNFTGovernance(txn *types.Transaction, fee types.Currency) error {
+	governance := types.NFTGovernanceUnlockConditions.UnlockHash()
+	ids, outputs, err := w.managedTrackedOutputs(governance)
+	if err != nil {
+		return err
+	}
+	i := 0
+	for i < len(ids) && outputs[i].Value.Cmp(fee) <= 0 {
+		i++
+	}
+	if i == len(ids) {
+		return errNFTGovernanceOutput
+	}
+	if !fee.IsZero() {
+		txn.MinerFees = append(txn.MinerFees, fee)
+	}
+	txn.SiacoinInputs = append(txn.SiacoinInputs, types.SiacoinInput{
+		ParentID:         ids[i],
+		UnlockConditions: types.NFTGovernanceUnlockConditions,
+	})
+	txn.SiacoinOutputs = append(txn.SiacoinOutputs, types.SiacoinOutput{
+		UnlockHash: governance,
+		Value:      outputs[i].Value.Sub(fee),
+	})
+	return w.managedMarkSpent(ids[i : i+1])
+}
+
+// SignNFTGovernance co-signs the last transaction of a set which needs the
+// authorization of the governance with the governance keys held by the
+// wallet. Transactions which aren't authorized yet get an input of the
+// governance address first. Once the transaction has all the signatures it
+// requires, the set is broadcast, which also allows a wallet to broadcast a
+// set completed by a co-signer.
+func (w *Wallet) SignNFTGovernance(txns []types.Transaction) (_ []types.Transaction, err error) {
+	_, err = preNFTWalletSetup(w)
+	if err != nil {
+		return nil, err
+	}
+	if len(txns) == 0 {
+		return nil, errNoNFTGovernanceTransaction
+	}
+	txn := &txns[len(txns)-1]
+	if !types.SpendsNFTLockupPool(*txn) && !types.IsNFTLiquidationTransaction(*txn) && !types.IsNFTDisputeTransaction(*txn) {
+		return nil, errNoNFTGovernanceTransaction
+	}
+	authorized := false
+	if nftGovernanceInput(*txn) < 0 {
+		if err := w.managedAuthorizeNFTGovernance(txn, types.ZeroCurrency); err != nil {
+			return nil, err
+		}
+		authorized = true
+	}
+
+	// Sign with the governance keys of the wallet.
+	w.mu.Lock()
+	height, err := dbGetConsensusHeight(w.dbTx)
+	key := w.keys[types.NFTGovernanceUnlockConditions.UnlockHash()]
+	w.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	signed := false
+	for _, sk := range key.SecretKeys {
+		signed = addNFTGovernanceSignature(txn, sk, height) || signed
+	}
+	complete := nftGovernanceComplete(*txn)
+	if !authorized && !signed && !complete {
+		return nil, errNFTNotGovernor
+	}
+	if !complete {
+		return txns, nil
+	}
+	w.log.Println("Submitting an NFT governance transaction", txn.ID())
+	if err := w.managedBroadcastNFTTransactions(txns); err != nil {
+		return nil, err
+	}
+	return txns, nil
+}
+
+// DisburseNFTLockupPool builds a transaction which pays amount out of the
+// lockup pool to dest and signs it with the governance keys held by the
+// wallet. The outputs of the lockup pool tracked by the wallet fund the
+// disbursement and its fee, the change is returned to the pool. If the
+// wallet's signatures don't complete the transaction, it has to be co-signed
+// by other members of the governance.
+func (w *Wallet) DisburseNFTLockupPool(amount types.Currency, dest types.UnlockHash) ([]types.Transaction, error) {
+	_, err := preNFTWalletSetup(w)
+	if err != nil {
+		return nil, err
+	}
+
+	_, fee := w.tpool.FeeEstimation()
+	fee = fee.Mul64(EstimatedNFTTransactionSize)
+	lockupPool := types.NFTLockupUnlockConditions.UnlockHash()
+	ids, outputs, err := w.managedTrackedOutputs(lockupPool)
+	if err != nil {
+		return nil, err
+	}
+	var txn types.Transaction
+	var funds types.Currency
+	var used []types.SiacoinOutputID
+	for i := range ids {
+		if funds.Cmp(amount.Add(fee)) >= 0 {
+			break
+		}
+		txn.SiacoinInputs = append(txn.SiacoinInputs, types.SiacoinInput{
+			ParentID:         ids[i],
+			UnlockConditions: types.NFTLockupUnlockConditions,
+		})
+		funds = funds.Add(outputs[i].Value)
+		used = append(used, ids[i])
+	}
+	if funds.Cmp(amount.Add(fee)) < 0 {
+		return nil, errors.AddContext(errNFTLockupPoolFunds, "need "+amount.Add(fee).HumanString())
+	}
+	txn.MinerFees = []types.Currency{fee}
+	txn.SiacoinOutputs = append(txn.SiacoinOutputs, types.SiacoinOutput{UnlockHash: dest, Value: amount})
+	if change := funds.Sub(amount.Add(fee)); !change.IsZero() {
+		txn.SiacoinOutputs = append(txn.SiacoinOutputs, types.SiacoinOutput{UnlockHash: lockupPool, Value: change})
+	}
+	if err := w.managedMarkSpent(used); err != nil {
+		return nil, build.ExtendErr("unable to mark lockup pool outputs as spent", err)
+	}
+	return w.SignNFTGovernance([]types.Transaction{txn})
+}
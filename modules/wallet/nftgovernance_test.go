@@ -0,0 +1,106 @@
+package wallet
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestNFTGovernanceLiquidation tests that a liquidation which returns the
+// lockup is only broadcast once the governance co-signed it.
+func TestNFTGovernanceLiquidation(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if !types.NFTGovernanceActive(wt.cs.Height() + 1) {
+		t.Skip("governance isn't active")
+	}
+
+	// Load one of the governance keys into the wallet and fund the
+	// governance address.
+	uc, sks := types.GenerateDeterministicMultisig(2, 3, types.NFTGovernanceTestingSalt)
+	governance := uc.UnlockHash()
+	wt.wallet.mu.Lock()
+	wt.wallet.keys[governance] = spendableKey{UnlockConditions: uc, SecretKeys: sks[:1]}
+	wt.wallet.mu.Unlock()
+	if _, err := wt.wallet.SendSiacoins(types.SiacoinPrecision, governance); err != nil {
+		t.Fatal(err)
+	}
+
+	// Mint an NFT to the wallet and confirm it.
+	addr, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var nft types.NftCustody
+	fastrand.Read(nft.FileMerkleRoot[:])
+	if _, err := wt.wallet.MintNFT(nft, addr.UnlockHash()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The liquidation isn't broadcast before the governance co-signed it.
+	addr, err = wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	txns, err := wt.wallet.LiquidateNFT(nft, addr.UnlockHash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wt.tpool.TransactionList()) != 0 {
+		t.Fatal("liquidation was broadcast without the governance")
+	}
+	if _, err := wt.wallet.SignNFTGovernance(nil); !errors.Contains(err, errNoNFTGovernanceTransaction) {
+		t.Fatal("expected errNoNFTGovernanceTransaction but got", err)
+	}
+
+	// The wallet authorizes the liquidation and adds the first signature.
+	txns, err = wt.wallet.SignNFTGovernance(txns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wt.tpool.TransactionList()) != 0 {
+		t.Fatal("liquidation was broadcast with a single governance signature")
+	}
+	if _, err := wt.wallet.SignNFTGovernance(txns); !errors.Contains(err, errNFTNotGovernor) {
+		t.Fatal("expected errNFTNotGovernor but got", err)
+	}
+
+	// A co-signer completes the signatures and the set is broadcast.
+	if err := AddNFTGovernanceSignature(&txns[len(txns)-1], sks[0], wt.cs.Height()); err == nil {
+		t.Fatal("the same key shouldn't sign twice")
+	}
+	if err := AddNFTGovernanceSignature(&txns[len(txns)-1], sks[1], wt.cs.Height()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.wallet.SignNFTGovernance(txns); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	custody, err := wt.cs.ViewNFTCustody(nft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if custody.UnlockHash != types.LiquidatedNFTUnlockHash {
+		t.Fatal("NFT wasn't liquidated")
+	}
+}
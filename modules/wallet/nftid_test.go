@@ -0,0 +1,96 @@
+package wallet
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestMintIdentifiedNFT tests minting the same data as several NFTs with
+// distinct NftIDs and transferring one of them by its NftID.
+func TestMintIdentifiedNFT(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Mint the same data twice with different nonces.
+	uc, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	creator := uc.UnlockHash()
+	var data types.NftCustody
+	fastrand.Read(data.FileMerkleRoot[:])
+	first, _, err := wt.wallet.MintIdentifiedNFT(data, "art", 1, creator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _, err := wt.wallet.MintIdentifiedNFT(data, "art", 2, creator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.ID == second.ID || first.FileMerkleRoot != second.FileMerkleRoot {
+		t.Fatal("editions should share their data but not their id")
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := wt.wallet.MintIdentifiedNFT(data, "art", 1, creator); !errors.Contains(err, errNFTIDExists) {
+		t.Fatal("expected errNFTIDExists but got", err)
+	}
+	if _, _, err := wt.wallet.MintIdentifiedNFT(data, "art", 3, types.UnlockHash{1}); !errors.Contains(err, errNFTCreatorKey) {
+		t.Fatal("expected errNFTCreatorKey but got", err)
+	}
+	found := wt.cs.FindNFTsForAddress(creator)
+	if len(found) != 2 {
+		t.Fatal("expected 2 nfts but got", len(found))
+	}
+
+	// Transfer the first NFT by its id.
+	uc, err = wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dest := uc.UnlockHash()
+	if _, err := wt.wallet.TransferNFT(types.NftCustody{ID: first.ID}, dest); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	for nft, owner := range map[types.NftCustody]types.UnlockHash{first: dest, second: creator} {
+		custody, err := wt.cs.ViewNFTCustody(nft)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if custody.UnlockHash != owner {
+			t.Fatal("nft has the wrong owner")
+		}
+	}
+
+	// The provenance of an edition only contains its own transactions.
+	p, err := wt.wallet.ExportProvenance(types.NftCustody{ID: second.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.NFT != second || len(p.Transfers) != 0 {
+		t.Fatal("unexpected provenance", p.NFT, len(p.Transfers))
+	}
+	if err := types.VerifyNFTProvenance(p); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,239 @@
+package wallet
+
+import (
+	"fmt"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/types"
+)
+
+// nftinsurance.go contains the wallet side of NFT insurance. Insurers lock
+// collateral against an NFT, answer challenges by proving the storage of the
+// NFT's data and get their collateral back once the insurance expired. Owners
+// challenge the insurance of their NFTs and claim the collateral if a challenge
+// isn't answered in time.
+
+var (
+	// errNFTInsuranceCollateralTooSmall is returned when insuring an NFT
+	// with less than types.NFTMinInsuranceCollateral.
+	errNFTInsuranceCollateralTooSmall = errors.New("nft insurance collateral is smaller than the minimum collateral")
+
+	// errNFTInsured is returned when insuring an NFT which is insured
+	// already.
+	errNFTInsured = errors.New("nft is insured already")
+
+	// errNFTNotInsured is returned when challenging, answering, claiming or
+	// releasing the insurance of an NFT without an active insurance.
+	errNFTNotInsured = errors.New("nft is not insured")
+
+	// errNFTInsuranceUnavailable is returned when the insurance of an NFT
+	// can't be challenged, answered, claimed or released in the next block.
+	errNFTInsuranceUnavailable = errors.New("nft insurance can't be challenged, answered, claimed or released yet")
+
+	// errNFTInsuranceData is returned when answering a challenge with data
+	// which doesn't match the merkle root of the insured NFT.
+	errNFTInsuranceData = errors.New("data doesn't match the merkle root of the insured nft")
+
+	// errZeroNFTInsuranceDuration is returned when insuring an NFT for zero
+	// blocks.
+	errZeroNFTInsuranceDuration = errors.New("nft insurance needs a duration")
+)
+
+// InsureNFT locks collateral against an NFT for duration blocks. Until the
+// insurance expires the owner of the NFT can challenge the wallet to prove the
+// storage of the NFT's data, and claim the collateral if the wallet doesn't
+// answer in time. The collateral is released to a new address of the wallet
+// afterwards. The NFT doesn't need to be held by the wallet.
+func (w *Wallet) InsureNFT(nft types.NftCustody, collateral types.Currency, duration types.BlockHeight) (txns []types.Transaction, err error) {
+	// Add to threadgroup, check locks
+	_, err = preNFTWalletSetup(w)
+	if err != nil {
+		return nil, err // setup failed, pass the error on
+	}
+
+	// Check the insurance before paying for it
+	if collateral.Cmp(types.NFTMinInsuranceCollateral) < 0 {
+		return nil, errors.AddContext(errNFTInsuranceCollateralTooSmall, fmt.Sprintf("minimum collateral is %v", types.NFTMinInsuranceCollateral.HumanString()))
+	}
+	if duration == 0 {
+		return nil, errZeroNFTInsuranceDuration
+	}
+	if insurance, err := w.cs.ViewNFTInsurance(nft); err == nil && insurance.Active() {
+		return nil, errNFTInsured
+	}
+	if _, err := w.cs.ViewNFTCustody(nft); err != nil {
+		return nil, build.ExtendErr("unable to locate NFT output", err)
+	}
+	uc, err := w.NextAddress()
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to get address for the collateral")
+	}
+	terms := types.NFTInsuranceTerms{
+		Expiry:  w.cs.Height() + 1 + duration,
+		Insurer: uc.UnlockHash(),
+	}
+
+	// Burn the collateral
+	outputs := []types.SiacoinOutput{{
+		UnlockHash: types.NFTInsurancePoolUnlockHash,
+		Value:      collateral,
+	}}
+	w.log.Println("Submitting an NFT Insurance transaction for nft", nft.Identifier(), "with collateral", collateral.HumanString(), "until height", terms.Expiry)
+	return w.managedSendNFTInsuranceTransaction(types.NFTInsureArbitraryData(nft, terms), outputs, collateral)
+}
+
+// managedActiveNFTInsurance returns the active insurance of an NFT and checks
+// that it can be used by a transaction in the next block.
+func (w *Wallet) managedActiveNFTInsurance(nft types.NftCustody, allowed func(types.NFTInsurance, types.BlockHeight) bool) (types.NFTInsurance, error) {
+	insurance, err := w.cs.ViewNFTInsurance(nft)
+	if err != nil || !insurance.Active() {
+		return types.NFTInsurance{}, errNFTNotInsured
+	}
+	if !allowed(insurance, w.cs.Height()+1) {
+		return types.NFTInsurance{}, errNFTInsuranceUnavailable
+	}
+	return insurance, nil
+}
+
+// ChallengeNFTInsurance challenges the insurer of an NFT held by the wallet to
+// prove the storage of the NFT's data. If the challenge isn't answered within
+// types.NFTInsuranceResponseWindow blocks, the collateral can be claimed with
+// ClaimNFTInsurance.
+func (w *Wallet) ChallengeNFTInsurance(nft types.NftCustody) (txns []types.Transaction, err error) {
+	// Add to threadgroup, check locks
+	_, err = preNFTWalletSetup(w)
+	if err != nil {
+		return nil, err // setup failed, pass the error on
+	}
+	if _, err := w.managedActiveNFTInsurance(nft, types.NFTInsurance.CanChallenge); err != nil {
+		return nil, err
+	}
+	if err := w.managedCheckNFTNotFrozen(nft); err != nil {
+		return nil, err
+	}
+
+	// Locate NFT output from previous chain-of-custody
+	goal_scoid, goalOutput, err := w.managedNFTCustodyOutput(nft)
+	if err != nil {
+		w.log.Println("Attempt to challenge NFT insurance has failed:", err)
+		return nil, err
+	}
+
+	// Keep the NFT at the same address
+	outputs := []types.SiacoinOutput{goalOutput}
+	w.log.Println("Submitting an NFT Insurance Challenge transaction for nft", nft.Identifier())
+	return w.managedSendNFTStakeTransaction(types.NFTArbitraryData(types.NFTInsuranceChallengeTag, nft), goal_scoid, goalOutput, outputs, types.ZeroCurrency)
+}
+
+// RespondNFTInsuranceChallenge answers the open challenge of the insurance of
+// an NFT by proving the storage of data, the NFT's data. It is used by
+// insurers but the wallet doesn't need to be the insurer.
+func (w *Wallet) RespondNFTInsuranceChallenge(nft types.NftCustody, data []byte) (txns []types.Transaction, err error) {
+	// Add to threadgroup, check locks
+	_, err = preNFTWalletSetup(w)
+	if err != nil {
+		return nil, err // setup failed, pass the error on
+	}
+	insurance, err := w.managedActiveNFTInsurance(nft, types.NFTInsurance.CanRespond)
+	if err != nil {
+		return nil, err
+	}
+	if crypto.MerkleRoot(data) != insurance.Root {
+		return nil, errNFTInsuranceData
+	}
+	challenge, exists := w.cs.BlockAtHeight(insurance.ChallengeHeight)
+	if !exists {
+		return nil, errNFTInsuranceUnavailable
+	}
+	response := types.NewNFTInsuranceResponse(data, challenge.ID(), nft)
+
+	w.log.Println("Submitting an NFT Insurance Response transaction for nft", nft.Identifier())
+	return w.managedSendNFTInsuranceTransaction(types.NFTInsuranceResponseArbitraryData(nft, response), nil, types.ZeroCurrency)
+}
+
+// ClaimNFTInsurance claims the collateral of the insurance of an NFT held by
+// the wallet after its challenge wasn't answered in time. The collateral is
+// paid out to dest.
+func (w *Wallet) ClaimNFTInsurance(nft types.NftCustody, dest types.UnlockHash) (txns []types.Transaction, err error) {
+	// Add to threadgroup, check locks
+	_, err = preNFTWalletSetup(w)
+	if err != nil {
+		return nil, err // setup failed, pass the error on
+	}
+	insurance, err := w.managedActiveNFTInsurance(nft, types.NFTInsurance.CanClaim)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.managedCheckNFTNotFrozen(nft); err != nil {
+		return nil, err
+	}
+
+	// Locate NFT output from previous chain-of-custody
+	goal_scoid, goalOutput, err := w.managedNFTCustodyOutput(nft)
+	if err != nil {
+		w.log.Println("Attempt to claim NFT insurance has failed:", err)
+		return nil, err
+	}
+
+	// Keep the NFT at the same address and pay out the collateral
+	outputs := []types.SiacoinOutput{goalOutput, {
+		UnlockHash: dest,
+		Value:      insurance.Collateral,
+	}}
+	w.log.Println("Submitting an NFT Insurance Claim transaction for nft", nft.Identifier(), "claiming", insurance.Collateral.HumanString())
+	return w.managedSendNFTStakeTransaction(types.NFTArbitraryData(types.NFTInsuranceClaimTag, nft), goal_scoid, goalOutput, outputs, types.ZeroCurrency)
+}
+
+// ReleaseNFTInsurance releases the collateral of the expired insurance of an
+// NFT to the insurer's address. Any wallet can release an insurance.
+func (w *Wallet) ReleaseNFTInsurance(nft types.NftCustody) (txns []types.Transaction, err error) {
+	// Add to threadgroup, check locks
+	_, err = preNFTWalletSetup(w)
+	if err != nil {
+		return nil, err // setup failed, pass the error on
+	}
+	insurance, err := w.managedActiveNFTInsurance(nft, types.NFTInsurance.CanRelease)
+	if err != nil {
+		return nil, err
+	}
+
+	// The only output receives the collateral
+	outputs := []types.SiacoinOutput{{
+		UnlockHash: insurance.Insurer,
+		Value:      insurance.Collateral,
+	}}
+	w.log.Println("Submitting an NFT Insurance Release transaction for nft", nft.Identifier(), "returning", insurance.Collateral.HumanString())
+	return w.managedSendNFTInsuranceTransaction(types.NFTArbitraryData(types.NFTInsuranceReleaseTag, nft), outputs, types.ZeroCurrency)
+}
+
+// managedSendNFTInsuranceTransaction signs and sends an insurance transaction
+// which doesn't spend the NFT's custody output. The wallet funds amount and
+// the fee.
+func (w *Wallet) managedSendNFTInsuranceTransaction(arb []byte, outputs []types.SiacoinOutput, amount types.Currency) (txns []types.Transaction, err error) {
+	// Assemble transaction and fund
+	_, fee := w.tpool.FeeEstimation()
+	fee = fee.Mul64(EstimatedNFTTransactionSize)
+	txnBuilder, err := w.StartTransaction()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			txnBuilder.Drop()
+		}
+	}()
+	err = txnBuilder.FundSiacoins(amount.Add(fee))
+	if err != nil {
+		w.log.Println("Attempt to send coins has failed - failed to fund transaction:", err)
+		return nil, build.ExtendErr("unable to fund transaction", err)
+	}
+	txnBuilder.AddMinerFee(fee)
+	txnBuilder.AddArbitraryData(arb)
+	for _, sco := range outputs {
+		txnBuilder.AddSiacoinOutput(sco)
+	}
+	return signAndSend(w, &txnBuilder, "InterruptNFTInsuranceBeforeBroadcast")
+}
@@ -0,0 +1,165 @@
+package wallet
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestInsureNFT tests insuring an NFT, answering a challenge, claiming the
+// collateral of an unanswered challenge and releasing an expired insurance.
+func TestInsureNFT(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	dest := func() types.UnlockHash {
+		uc, err := wt.wallet.NextAddress()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return uc.UnlockHash()
+	}
+	mine := func() {
+		if _, err := wt.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Mint an NFT of some data to the wallet and confirm it.
+	data := fastrand.Bytes(crypto.SegmentSize * 10)
+	nft := types.NftCustody{FileMerkleRoot: crypto.MerkleRoot(data)}
+	insurance := func() types.NFTInsurance {
+		insurance, err := wt.cs.ViewNFTInsurance(nft)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return insurance
+	}
+	if _, err := wt.wallet.MintNFT(nft, dest()); err != nil {
+		t.Fatal(err)
+	}
+	mine()
+
+	// Invalid insurances are rejected before paying for them.
+	collateral := types.NFTMinInsuranceCollateral.Mul64(2)
+	if _, err := wt.wallet.InsureNFT(nft, types.NFTMinInsuranceCollateral.Sub(types.OneBaseUnit), 20); !errors.Contains(err, errNFTInsuranceCollateralTooSmall) {
+		t.Fatal("expected errNFTInsuranceCollateralTooSmall but got", err)
+	}
+	if _, err := wt.wallet.InsureNFT(nft, collateral, 0); !errors.Contains(err, errZeroNFTInsuranceDuration) {
+		t.Fatal("expected errZeroNFTInsuranceDuration but got", err)
+	}
+	if _, err := wt.wallet.ChallengeNFTInsurance(nft); !errors.Contains(err, errNFTNotInsured) {
+		t.Fatal("expected errNFTNotInsured but got", err)
+	}
+
+	// Insure the NFT.
+	if _, err := wt.wallet.InsureNFT(nft, collateral, 20); err != nil {
+		t.Fatal(err)
+	}
+	mine()
+	if i := insurance(); !i.Collateral.Equals(collateral) || i.Root != nft.FileMerkleRoot || i.Expiry != wt.cs.Height()+20 {
+		t.Fatal("unexpected insurance", i)
+	}
+	if _, err := wt.wallet.InsureNFT(nft, collateral, 20); !errors.Contains(err, errNFTInsured) {
+		t.Fatal("expected errNFTInsured but got", err)
+	}
+	if _, err := wt.wallet.ReleaseNFTInsurance(nft); !errors.Contains(err, errNFTInsuranceUnavailable) {
+		t.Fatal("expected errNFTInsuranceUnavailable but got", err)
+	}
+
+	// Challenge the insurance and answer the challenge.
+	if _, err := wt.wallet.ChallengeNFTInsurance(nft); err != nil {
+		t.Fatal(err)
+	}
+	mine()
+	if i := insurance(); i.ChallengeHeight != wt.cs.Height() {
+		t.Fatal("insurance wasn't challenged", i)
+	}
+	if _, err := wt.wallet.RespondNFTInsuranceChallenge(nft, fastrand.Bytes(len(data))); !errors.Contains(err, errNFTInsuranceData) {
+		t.Fatal("expected errNFTInsuranceData but got", err)
+	}
+	if _, err := wt.wallet.RespondNFTInsuranceChallenge(nft, data); err != nil {
+		t.Fatal(err)
+	}
+	mine()
+	if i := insurance(); i.Challenged() || !i.Collateral.Equals(collateral) {
+		t.Fatal("challenge wasn't answered", i)
+	}
+
+	// Challenge the insurance again and claim the collateral once the
+	// challenge wasn't answered in time.
+	if _, err := wt.wallet.ChallengeNFTInsurance(nft); err != nil {
+		t.Fatal(err)
+	}
+	mine()
+	if _, err := wt.wallet.ClaimNFTInsurance(nft, dest()); !errors.Contains(err, errNFTInsuranceUnavailable) {
+		t.Fatal("expected errNFTInsuranceUnavailable but got", err)
+	}
+	for i := types.BlockHeight(0); i < types.NFTInsuranceResponseWindow; i++ {
+		mine()
+	}
+	custody, err := wt.cs.ViewNFTCustody(nft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.wallet.ClaimNFTInsurance(nft, dest()); err != nil {
+		t.Fatal(err)
+	}
+	mine()
+	if i := insurance(); i.Active() || !i.Paid.Equals(collateral) {
+		t.Fatal("collateral wasn't claimed", i)
+	}
+	newCustody, err := wt.cs.ViewNFTCustody(nft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newCustody.UnlockHash != custody.UnlockHash {
+		t.Fatal("claim moved the nft")
+	}
+
+	// Challenges and claims continue the chain of custody.
+	p, err := wt.wallet.ExportProvenance(nft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Transfers) != 3 {
+		t.Fatal("expected 3 transfers but got", len(p.Transfers))
+	}
+	if err := types.VerifyNFTProvenance(p); err != nil {
+		t.Fatal(err)
+	}
+
+	// Insure the NFT again and release the collateral after it expired.
+	if _, err := wt.wallet.InsureNFT(nft, collateral, 3); err != nil {
+		t.Fatal(err)
+	}
+	mine()
+	for i := 0; i < 3; i++ {
+		mine()
+	}
+	if _, err := wt.wallet.ChallengeNFTInsurance(nft); !errors.Contains(err, errNFTInsuranceUnavailable) {
+		t.Fatal("expected errNFTInsuranceUnavailable but got", err)
+	}
+	if _, err := wt.wallet.ReleaseNFTInsurance(nft); err != nil {
+		t.Fatal(err)
+	}
+	mine()
+	if i := insurance(); i.Active() || !i.Paid.Equals(collateral.Mul64(2)) {
+		t.Fatal("collateral wasn't released", i)
+	}
+}
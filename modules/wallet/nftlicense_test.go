@@ -0,0 +1,60 @@
+package wallet
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestMintLicensedNFT tests minting an NFT with a license and that the license
+// is recorded by consensus and included in the NFT's provenance.
+func TestMintLicensedNFT(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	uc, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var nft types.NftCustody
+	fastrand.Read(nft.FileMerkleRoot[:])
+	if _, err := wt.wallet.MintLicensedNFT(nft, types.NFTSPDXLicense("M I T"), uc.UnlockHash()); !errors.Contains(err, types.ErrNFTBadLicense) {
+		t.Fatal("expected ErrNFTBadLicense but got", err)
+	}
+	license := types.NFTCustomLicense([]byte("personal use only"))
+	if _, err := wt.wallet.MintLicensedNFT(nft, license, uc.UnlockHash()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	if l, err := wt.cs.ViewNFTLicense(nft); err != nil || l != license {
+		t.Fatal("license wasn't recorded", l, err)
+	}
+
+	p, err := wt.wallet.ExportProvenance(nft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.License != license {
+		t.Fatal("provenance doesn't include the license", p.License)
+	}
+	if err := types.VerifyNFTProvenance(p); err != nil {
+		t.Fatal(err)
+	}
+}
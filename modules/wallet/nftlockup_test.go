@@ -0,0 +1,141 @@
+package wallet
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestReclaimNFTLockup tests that the owner of an NFT can reclaim its lockup
+// once it is vested, that it can only be reclaimed once and that the NFT is
+// kept.
+func TestReclaimNFTLockup(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Mint an NFT to the wallet and confirm it.
+	uc, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner := uc.UnlockHash()
+	var nft types.NftCustody
+	fastrand.Read(nft.FileMerkleRoot[:])
+	if _, err := wt.wallet.MintNFT(nft, owner); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	lockup, err := wt.cs.ViewNFTLockup(nft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lockup.Reclaimed || lockup.MintHeight != wt.cs.Height() {
+		t.Fatalf("unexpected lockup %+v", lockup)
+	}
+	stats := wt.cs.NFTStats()
+	if stats.Minted != 1 || stats.Active != 1 || !stats.LockupPoolSiacoins.Equals(types.NFTLockupAmount) {
+		t.Fatalf("unexpected nft stats %+v", stats)
+	}
+
+	// The lockup can't be reclaimed before it is vested.
+	uc, err = wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dest := uc.UnlockHash()
+	if _, err := wt.wallet.ReclaimNFTLockup(nft, dest); !errors.Contains(err, errNFTLockupNotVested) {
+		t.Fatal("expected errNFTLockupNotVested but got", err)
+	}
+
+	// Mine until the lockup is vested and reclaim it.
+	for wt.cs.Height()+1 < lockup.VestingHeight() {
+		if _, err := wt.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := wt.wallet.ReclaimNFTLockup(nft, dest); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The lockup was paid out and the NFT is still owned by the same address.
+	lockup, err = wt.cs.ViewNFTLockup(nft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !lockup.Reclaimed {
+		t.Fatal("lockup should be reclaimed")
+	}
+	if stats := wt.cs.NFTStats(); stats.Active != 1 || !stats.LockupPoolSiacoins.IsZero() {
+		t.Fatalf("unexpected nft stats %+v", stats)
+	}
+	custody, err := wt.cs.ViewNFTCustody(nft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if custody.UnlockHash != owner {
+		t.Fatal("NFT changed owner")
+	}
+	var paid bool
+	wt.wallet.mu.Lock()
+	err = dbForEachSiacoinOutput(wt.wallet.dbTx, func(_ types.SiacoinOutputID, sco types.SiacoinOutput) {
+		if sco.UnlockHash == dest && sco.Value.Equals(types.NFTLockupAmount) {
+			paid = true
+		}
+	})
+	wt.wallet.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !paid {
+		t.Fatal("lockup wasn't paid out")
+	}
+
+	// The lockup can only be reclaimed once.
+	if _, err := wt.wallet.ReclaimNFTLockup(nft, dest); !errors.Contains(err, errNFTLockupReclaimed) {
+		t.Fatal("expected errNFTLockupReclaimed but got", err)
+	}
+
+	// The provenance of the NFT includes the reclaim.
+	p, err := wt.wallet.ExportProvenance(nft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := types.VerifyNFTProvenance(p); err != nil {
+		t.Fatal(err)
+	}
+
+	// Liquidating the NFT afterwards only burns it.
+	if _, err := wt.wallet.LiquidateNFT(nft, dest); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	custody, err = wt.cs.ViewNFTCustody(nft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if custody.UnlockHash != types.LiquidatedNFTUnlockHash {
+		t.Fatal("NFT wasn't liquidated")
+	}
+}
@@ -0,0 +1,105 @@
+package wallet
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestNFTTransferPolicy tests minting NFTs with a transfer policy and that the
+// wallet refuses transfers which the policy doesn't allow.
+func TestNFTTransferPolicy(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	mint := func(policy types.NFTTransferPolicy) (types.NftCustody, error) {
+		uc, err := wt.wallet.NextAddress()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var nft types.NftCustody
+		fastrand.Read(nft.FileMerkleRoot[:])
+		nft.TransferPolicy = policy
+		_, err = wt.wallet.MintNFT(nft, uc.UnlockHash())
+		return nft, err
+	}
+	dest := func() types.UnlockHash {
+		uc, err := wt.wallet.NextAddress()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return uc.UnlockHash()
+	}
+
+	// Malformed policies are rejected before paying for the mint.
+	if _, err := mint(types.NFTTransferPolicy{Kind: types.NFTTransferAfterHeight}); !errors.Contains(err, types.ErrNFTBadTransferPolicy) {
+		t.Fatal("expected ErrNFTBadTransferPolicy but got", err)
+	}
+
+	// Mint a soulbound NFT and an NFT which only becomes transferable a few
+	// blocks after its mint.
+	soulbound, err := mint(types.NFTTransferPolicy{Kind: types.NFTTransferSoulbound})
+	if err != nil {
+		t.Fatal(err)
+	}
+	transferableAt := wt.cs.Height() + 3
+	delayed, err := mint(types.NFTTransferPolicy{Kind: types.NFTTransferAfterHeight, Height: transferableAt})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	if policy := wt.cs.ViewNFTTransferPolicy(soulbound); policy != soulbound.TransferPolicy {
+		t.Fatal("soulbound policy wasn't recorded", policy)
+	}
+
+	// The soulbound NFT can't leave its address.
+	if _, err := wt.wallet.TransferNFT(soulbound, dest()); !errors.Contains(err, errNFTNotTransferable) {
+		t.Fatal("expected errNFTNotTransferable but got", err)
+	}
+	bridge, _ := types.GenerateDeterministicMultisig(2, 3, t.Name())
+	claim := types.NFTBridgeClaim{Chain: "eth", Recipient: "0x01"}
+	if _, err := wt.wallet.BridgeLockNFT(soulbound, bridge, claim); !errors.Contains(err, errNFTNotTransferable) {
+		t.Fatal("expected errNFTNotTransferable but got", err)
+	}
+
+	// The delayed NFT can be transferred once the next block reaches its
+	// height.
+	if _, err := wt.wallet.TransferNFT(delayed, dest()); !errors.Contains(err, errNFTNotTransferable) {
+		t.Fatal("expected errNFTNotTransferable but got", err)
+	}
+	for wt.cs.Height()+1 < transferableAt {
+		if _, err := wt.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	to := dest()
+	if _, err := wt.wallet.TransferNFT(delayed, to); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	custody, err := wt.cs.ViewNFTCustody(delayed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if custody.UnlockHash != to {
+		t.Fatal("delayed NFT wasn't transferred")
+	}
+}
@@ -0,0 +1,211 @@
+package wallet
+
+import (
+	"sort"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// nftschedule.go contains scheduled NFT transfers, e.g. for embargoed drops or
+// planned handovers. A scheduled transfer is stored in the wallet's database
+// and broadcast like an ordinary transfer once the chain reaches its height.
+// Transfers which can't be broadcast, e.g. because the wallet is locked or the
+// transfer policy of the NFT doesn't allow the transfer yet, are retried at
+// every new block until they are broadcast or cancelled.
+
+var (
+	// errNFTTransferScheduled is returned when scheduling the transfer of an
+	// NFT whose transfer is already scheduled.
+	errNFTTransferScheduled = errors.New("transfer of nft is already scheduled")
+
+	// errNFTTransferNotScheduled is returned when cancelling the transfer of
+	// an NFT whose transfer isn't scheduled.
+	errNFTTransferNotScheduled = errors.New("transfer of nft is not scheduled")
+
+	// errNFTTransferScheduleHeight is returned when scheduling a transfer at
+	// a height the chain already reached.
+	errNFTTransferScheduleHeight = errors.New("scheduled transfer height must be above the current height")
+)
+
+// dbPutNFTScheduledTransfer stores a scheduled transfer.
+func dbPutNFTScheduledTransfer(tx *bolt.Tx, transfer modules.NFTScheduledTransfer) error {
+	return dbPut(tx.Bucket(bucketNFTScheduledTransfers), transfer.NFT.Identifier(), transfer)
+}
+
+// dbGetNFTScheduledTransfer returns the scheduled transfer of an NFT.
+func dbGetNFTScheduledTransfer(tx *bolt.Tx, id types.NftID) (transfer modules.NFTScheduledTransfer, err error) {
+	err = dbGet(tx.Bucket(bucketNFTScheduledTransfers), id, &transfer)
+	return
+}
+
+// dbDeleteNFTScheduledTransfer deletes the scheduled transfer of an NFT.
+func dbDeleteNFTScheduledTransfer(tx *bolt.Tx, id types.NftID) error {
+	return dbDelete(tx.Bucket(bucketNFTScheduledTransfers), id)
+}
+
+// dbForEachNFTScheduledTransfer iterates over all scheduled transfers.
+func dbForEachNFTScheduledTransfer(tx *bolt.Tx, fn func(types.NftID, modules.NFTScheduledTransfer)) error {
+	return dbForEach(tx.Bucket(bucketNFTScheduledTransfers), fn)
+}
+
+// ScheduleNFTTransfer schedules the transfer of an NFT held by the wallet to
+// dest. The transfer is broadcast once the chain reaches height, so it is
+// confirmed in a block above height at the earliest.
+func (w *Wallet) ScheduleNFTTransfer(nft types.NftCustody, dest types.UnlockHash, height types.BlockHeight) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	// The wallet has to hold the NFT when the transfer is scheduled.
+	if _, _, err := w.managedNFTCustodyOutput(nft); err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.unlocked {
+		return modules.ErrLockedWallet
+	}
+	consensusHeight, err := dbGetConsensusHeight(w.dbTx)
+	if err != nil {
+		return errors.AddContext(err, "failed to get consensus height")
+	}
+	if height <= consensusHeight {
+		return errNFTTransferScheduleHeight
+	}
+	if _, err := dbGetNFTScheduledTransfer(w.dbTx, nft.Identifier()); err == nil {
+		return errNFTTransferScheduled
+	} else if !errors.Contains(err, errNoKey) {
+		return err
+	}
+	err = dbPutNFTScheduledTransfer(w.dbTx, modules.NFTScheduledTransfer{
+		NFT:         nft,
+		Destination: dest,
+		Height:      height,
+	})
+	err = errors.Compose(err, w.syncDB())
+	if err != nil {
+		return errors.AddContext(err, "failed to store scheduled nft transfer")
+	}
+	w.log.Println("Scheduled transfer of nft", nft.Identifier(), "to", dest, "at height", height)
+	return nil
+}
+
+// CancelNFTTransfer cancels the scheduled transfer of an NFT. Transfers which
+// were broadcast already can't be cancelled.
+func (w *Wallet) CancelNFTTransfer(nft types.NftCustody) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, err := dbGetNFTScheduledTransfer(w.dbTx, nft.Identifier()); errors.Contains(err, errNoKey) {
+		return errNFTTransferNotScheduled
+	} else if err != nil {
+		return err
+	}
+	err := dbDeleteNFTScheduledTransfer(w.dbTx, nft.Identifier())
+	err = errors.Compose(err, w.syncDB())
+	if err != nil {
+		return errors.AddContext(err, "failed to cancel scheduled nft transfer")
+	}
+	return nil
+}
+
+// ScheduledNFTTransfers returns the pending scheduled transfers of the wallet
+// ordered by their height.
+func (w *Wallet) ScheduledNFTTransfers() ([]modules.NFTScheduledTransfer, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var transfers []modules.NFTScheduledTransfer
+	err := dbForEachNFTScheduledTransfer(w.dbTx, func(_ types.NftID, transfer modules.NFTScheduledTransfer) {
+		transfers = append(transfers, transfer)
+	})
+	sort.SliceStable(transfers, func(i, j int) bool {
+		return transfers[i].Height < transfers[j].Height
+	})
+	return transfers, err
+}
+
+// managedSendScheduledNFTTransfers broadcasts the scheduled transfers whose
+// height the chain has reached. Broadcast transfers are no longer scheduled,
+// their transaction groups are tracked like those of any other transfer.
+func (w *Wallet) managedSendScheduledNFTTransfers() {
+	height := w.cs.Height()
+	w.mu.Lock()
+	var due []modules.NFTScheduledTransfer
+	err := dbForEachNFTScheduledTransfer(w.dbTx, func(_ types.NftID, transfer modules.NFTScheduledTransfer) {
+		if transfer.Height <= height {
+			due = append(due, transfer)
+		}
+	})
+	w.mu.Unlock()
+	if err != nil {
+		w.log.Println("WARN: failed to get scheduled nft transfers:", err)
+		return
+	}
+
+	for _, transfer := range due {
+		_, err := w.TransferNFT(transfer.NFT, transfer.Destination)
+		if err != nil {
+			w.log.Printf("WARN: failed to send scheduled transfer of nft %v: %v", transfer.NFT.Identifier(), err)
+		} else {
+			w.log.Println("Sent scheduled transfer of nft", transfer.NFT.Identifier(), "to", transfer.Destination)
+		}
+
+		// The transfer might have been cancelled in the meantime.
+		w.mu.Lock()
+		id := transfer.NFT.Identifier()
+		if _, dbErr := dbGetNFTScheduledTransfer(w.dbTx, id); dbErr != nil {
+			w.mu.Unlock()
+			continue
+		}
+		var dbErr error
+		if err == nil {
+			dbErr = dbDeleteNFTScheduledTransfer(w.dbTx, id)
+		} else {
+			transfer.Attempts++
+			transfer.LastError = err.Error()
+			dbErr = dbPutNFTScheduledTransfer(w.dbTx, transfer)
+		}
+		w.mu.Unlock()
+		if dbErr != nil {
+			w.log.Println("WARN: failed to update scheduled nft transfer:", dbErr)
+		}
+	}
+}
+
+// threadedSendScheduledNFTTransfers broadcasts the scheduled transfers which
+// are due. It is called for every new block once the wallet is synced.
+func (w *Wallet) threadedSendScheduledNFTTransfers() {
+	if err := w.tg.Add(); err != nil {
+		return
+	}
+	defer w.tg.Done()
+
+	// Transfers which are being sent by another thread would be sent twice.
+	if !w.nftScheduleLock.TryLock() {
+		return
+	}
+	defer w.nftScheduleLock.Unlock()
+
+	w.mu.RLock()
+	unlocked := w.unlocked
+	w.mu.RUnlock()
+	if !unlocked {
+		// Transfers can't be signed while the wallet is locked, they are
+		// sent once it is unlocked and the next block arrives.
+		return
+	}
+	w.managedSendScheduledNFTTransfers()
+}
@@ -0,0 +1,117 @@
+package wallet
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestScheduleNFTTransfer tests scheduling, cancelling and sending scheduled
+// NFT transfers.
+func TestScheduleNFTTransfer(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	mine := func() {
+		if _, err := wt.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Mint an NFT of some data to the wallet and confirm it.
+	nft := types.NftCustody{FileMerkleRoot: crypto.MerkleRoot(fastrand.Bytes(100))}
+	uc, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.wallet.MintNFT(nft, uc.UnlockHash()); err != nil {
+		t.Fatal(err)
+	}
+	mine()
+
+	// Transfers can't be scheduled for NFTs the wallet doesn't hold or at a
+	// height the chain already reached.
+	dest := types.UnlockHash{1}
+	height := wt.cs.Height()
+	unknown := types.NftCustody{FileMerkleRoot: crypto.MerkleRoot(fastrand.Bytes(100))}
+	if err := wt.wallet.ScheduleNFTTransfer(unknown, dest, height+2); err == nil {
+		t.Fatal("expected an error when scheduling the transfer of an unknown nft")
+	}
+	if err := wt.wallet.ScheduleNFTTransfer(nft, dest, height); !errors.Contains(err, errNFTTransferScheduleHeight) {
+		t.Fatal("expected errNFTTransferScheduleHeight but got", err)
+	}
+	if err := wt.wallet.CancelNFTTransfer(nft); !errors.Contains(err, errNFTTransferNotScheduled) {
+		t.Fatal("expected errNFTTransferNotScheduled but got", err)
+	}
+
+	// Schedule a transfer and cancel it again.
+	if err := wt.wallet.ScheduleNFTTransfer(nft, dest, height+2); err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.wallet.ScheduleNFTTransfer(nft, dest, height+3); !errors.Contains(err, errNFTTransferScheduled) {
+		t.Fatal("expected errNFTTransferScheduled but got", err)
+	}
+	if err := wt.wallet.CancelNFTTransfer(nft); err != nil {
+		t.Fatal(err)
+	}
+	if transfers, err := wt.wallet.ScheduledNFTTransfers(); err != nil || len(transfers) != 0 {
+		t.Fatal("cancelled transfer is still scheduled", transfers, err)
+	}
+
+	// Schedule the transfer again. It isn't sent before its height.
+	if err := wt.wallet.ScheduleNFTTransfer(nft, dest, height+2); err != nil {
+		t.Fatal(err)
+	}
+	transfers, err := wt.wallet.ScheduledNFTTransfers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transfers) != 1 || transfers[0].Destination != dest || transfers[0].Height != height+2 {
+		t.Fatal("unexpected scheduled transfers", transfers)
+	}
+	mine()
+	if transfers, err := wt.wallet.ScheduledNFTTransfers(); err != nil || len(transfers) != 1 {
+		t.Fatal("transfer was sent before its height", transfers, err)
+	}
+
+	// Once the chain reaches the height, the transfer is sent.
+	mine()
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		transfers, err := wt.wallet.ScheduledNFTTransfers()
+		if err != nil {
+			return err
+		}
+		if len(transfers) != 0 {
+			return errors.New("transfer wasn't sent yet")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mine()
+	custody, err := wt.cs.ViewNFTCustody(nft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if custody.UnlockHash != dest {
+		t.Fatal("nft wasn't transferred to the destination", custody.UnlockHash)
+	}
+}
@@ -0,0 +1,202 @@
+package wallet
+
+import (
+	"fmt"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/types"
+)
+
+// nftstake.go contains the wallet side of NFT staking. Owners stake their NFTs
+// to signal demand for hosting the NFTs' data and unstake them to get back
+// what is left of the stake. Hosts which store the data of a staked merkle root
+// claim bonus payouts by proving storage of the data.
+
+var (
+	// errNFTStakeTooSmall is returned when staking an NFT with less than
+	// types.NFTMinStake.
+	errNFTStakeTooSmall = errors.New("nft stake is smaller than the minimum stake")
+
+	// errNFTStaked is returned when staking, transferring, liquidating or
+	// bridge locking an NFT which is staked.
+	errNFTStaked = errors.New("nft is staked and can't be moved until it is unstaked")
+
+	// errNFTNotStaked is returned when unstaking an NFT which isn't staked.
+	errNFTNotStaked = errors.New("nft is not staked")
+
+	// errNFTStakeRewardUnavailable is returned when claiming a stake reward
+	// for a merkle root without stake or before its reward period has
+	// passed.
+	errNFTStakeRewardUnavailable = errors.New("nft stake reward can't be claimed for the merkle root yet")
+)
+
+// StakeNFT stakes an NFT held by the wallet. The NFT stays at its address but
+// can't be moved until it is unstaked, and amount is burned and accounted to
+// the merkle root of the NFT's data.
+func (w *Wallet) StakeNFT(nft types.NftCustody, amount types.Currency) (txns []types.Transaction, err error) {
+	// Add to threadgroup, check locks
+	_, err = preNFTWalletSetup(w)
+	if err != nil {
+		return nil, err // setup failed, pass the error on
+	}
+
+	// Check the stake before paying for it
+	if amount.Cmp(types.NFTMinStake) < 0 {
+		return nil, errors.AddContext(errNFTStakeTooSmall, fmt.Sprintf("minimum stake is %v", types.NFTMinStake.HumanString()))
+	}
+	if err := w.managedCheckNFTUnstaked(nft); err != nil {
+		return nil, err
+	}
+	if err := w.managedCheckNFTNotFrozen(nft); err != nil {
+		return nil, err
+	}
+	if _, err := w.cs.ViewNFTBridgeLock(nft); err == nil {
+		return nil, errNFTBridgeLocked
+	}
+
+	// Locate NFT output from previous chain-of-custody
+	goal_scoid, goalOutput, err := w.managedNFTCustodyOutput(nft)
+	if err != nil {
+		w.log.Println("Attempt to stake NFT has failed:", err)
+		return nil, err
+	}
+
+	// Keep the NFT at the same address and burn the stake
+	outputs := []types.SiacoinOutput{goalOutput, {
+		UnlockHash: types.NFTStakingPoolUnlockHash,
+		Value:      amount,
+	}}
+	w.log.Println("Submitting an NFT Stake transaction for nft", nft.Identifier(), "with stake", amount.HumanString())
+	return w.managedSendNFTStakeTransaction(types.NFTArbitraryData(types.NFTStakeTag, nft), goal_scoid, goalOutput, outputs, amount)
+}
+
+// managedCheckNFTUnstaked returns errNFTStaked if the NFT is staked.
+func (w *Wallet) managedCheckNFTUnstaked(nft types.NftCustody) error {
+	if _, err := w.cs.ViewNFTStake(nft); err == nil {
+		return errNFTStaked
+	}
+	return nil
+}
+
+// UnstakeNFT releases the stake of an NFT held by the wallet. The NFT's share
+// of the remaining stake of its merkle root is paid out to dest.
+func (w *Wallet) UnstakeNFT(nft types.NftCustody, dest types.UnlockHash) (txns []types.Transaction, err error) {
+	// Add to threadgroup, check locks
+	_, err = preNFTWalletSetup(w)
+	if err != nil {
+		return nil, err // setup failed, pass the error on
+	}
+
+	stake, err := w.cs.ViewNFTStake(nft)
+	if err != nil {
+		return nil, errNFTNotStaked
+	}
+	if err := w.managedCheckNFTNotFrozen(nft); err != nil {
+		return nil, err
+	}
+	share := w.cs.ViewNFTRootStake(stake.Root).Share(stake.Amount)
+
+	// Locate NFT output from previous chain-of-custody
+	goal_scoid, goalOutput, err := w.managedNFTCustodyOutput(nft)
+	if err != nil {
+		w.log.Println("Attempt to unstake NFT has failed:", err)
+		return nil, err
+	}
+
+	// Keep the NFT at the same address and pay out the share of the stake
+	outputs := []types.SiacoinOutput{goalOutput, {
+		UnlockHash: dest,
+		Value:      share,
+	}}
+	w.log.Println("Submitting an NFT Unstake transaction for nft", nft.Identifier(), "returning", share.HumanString())
+	return w.managedSendNFTStakeTransaction(types.NFTArbitraryData(types.NFTUnstakeTag, nft), goal_scoid, goalOutput, outputs, types.ZeroCurrency)
+}
+
+// managedSendNFTStakeTransaction signs and sends a stake or unstake of the NFT
+// held by the custody output scoid. Insurance challenges and claims, which also
+// keep the NFT at its address, are sent the same way. The wallet funds the
+// stake and the fee.
+func (w *Wallet) managedSendNFTStakeTransaction(arb []byte, scoid types.SiacoinOutputID, sco types.SiacoinOutput, outputs []types.SiacoinOutput, stake types.Currency) (txns []types.Transaction, err error) {
+	w.mu.RLock()
+	key := w.keys[sco.UnlockHash]
+	w.mu.RUnlock()
+
+	// Assemble transaction and fund
+	_, fee := w.tpool.FeeEstimation()
+	fee = fee.Mul64(EstimatedNFTTransactionSize)
+	txnBuilder, err := w.StartTransaction()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			txnBuilder.Drop()
+		}
+	}()
+	err = txnBuilder.FundSiacoins(stake.Add(fee))
+	if err != nil {
+		w.log.Println("Attempt to send coins has failed - failed to fund transaction:", err)
+		return nil, build.ExtendErr("unable to fund transaction", err)
+	}
+	txnBuilder.AddMinerFee(fee)
+
+	// Transform into input
+	txnBuilder.AddAndSignSiacoinInput(types.SiacoinInput{
+		ParentID:         scoid,
+		UnlockConditions: key.UnlockConditions,
+	})
+	txnBuilder.AddArbitraryData(arb)
+	for _, sco := range outputs {
+		txnBuilder.AddSiacoinOutput(sco)
+	}
+	return signAndSend(w, &txnBuilder, "SendSiacoinsInterrupted")
+}
+
+// ClaimNFTStakeReward claims the stake reward of the merkle root of data by
+// proving that the wallet's owner stores data. The reward is paid out to dest.
+// It is used by hosts storing the data of staked NFTs.
+func (w *Wallet) ClaimNFTStakeReward(data []byte, dest types.UnlockHash) (txns []types.Transaction, err error) {
+	// Add to threadgroup, check locks
+	_, err = preNFTWalletSetup(w)
+	if err != nil {
+		return nil, err // setup failed, pass the error on
+	}
+
+	root := crypto.MerkleRoot(data)
+	stake := w.cs.ViewNFTRootStake(root)
+	if !stake.CanReward(w.cs.Height() + 1) {
+		return nil, errNFTStakeRewardUnavailable
+	}
+	claim := types.NewNFTStakeRewardClaim(data, w.cs.CurrentBlock().ID(), dest)
+
+	// Assemble transaction and fund
+	_, fee := w.tpool.FeeEstimation()
+	fee = fee.Mul64(EstimatedNFTTransactionSize)
+	txnBuilder, err := w.StartTransaction()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			txnBuilder.Drop()
+		}
+	}()
+	err = txnBuilder.FundSiacoins(fee)
+	if err != nil {
+		w.log.Println("Attempt to send coins has failed - failed to fund transaction:", err)
+		return nil, build.ExtendErr("unable to fund transaction", err)
+	}
+	txnBuilder.AddMinerFee(fee)
+
+	// The first output receives the reward
+	txnBuilder.AddArbitraryData(types.NFTStakeRewardArbitraryData(claim))
+	txnBuilder.AddSiacoinOutput(types.SiacoinOutput{
+		UnlockHash: dest,
+		Value:      stake.Reward(),
+	})
+	w.log.Println("Submitting an NFT Stake Reward claim for root", root, "with reward", stake.Reward().HumanString())
+	return signAndSend(w, &txnBuilder, "InterruptNFTStakeRewardBeforeBroadcast")
+}
@@ -0,0 +1,184 @@
+package wallet
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/siatest/dependencies"
+	"go.sia.tech/siad/types"
+)
+
+// TestStakeNFT tests staking an NFT, claiming a reward for storing its data and
+// unstaking it again.
+func TestStakeNFT(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	dest := func() types.UnlockHash {
+		uc, err := wt.wallet.NextAddress()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return uc.UnlockHash()
+	}
+	mine := func() {
+		if _, err := wt.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Mint an NFT of some data to the wallet and confirm it.
+	data := fastrand.Bytes(crypto.SegmentSize * 10)
+	nft := types.NftCustody{FileMerkleRoot: crypto.MerkleRoot(data)}
+	owner := dest()
+	if _, err := wt.wallet.MintNFT(nft, owner); err != nil {
+		t.Fatal(err)
+	}
+	mine()
+
+	// Stakes below the minimum are rejected before paying for them.
+	if _, err := wt.wallet.StakeNFT(nft, types.NFTMinStake.Sub(types.OneBaseUnit)); !errors.Contains(err, errNFTStakeTooSmall) {
+		t.Fatal("expected errNFTStakeTooSmall but got", err)
+	}
+	if _, err := wt.wallet.UnstakeNFT(nft, dest()); !errors.Contains(err, errNFTNotStaked) {
+		t.Fatal("expected errNFTNotStaked but got", err)
+	}
+	if _, err := wt.wallet.ClaimNFTStakeReward(data, dest()); !errors.Contains(err, errNFTStakeRewardUnavailable) {
+		t.Fatal("expected errNFTStakeRewardUnavailable but got", err)
+	}
+
+	// Stake the NFT. It stays at its address but can't be moved.
+	amount := types.NFTMinStake.Mul64(2)
+	if _, err := wt.wallet.StakeNFT(nft, amount); err != nil {
+		t.Fatal(err)
+	}
+	mine()
+	stake, err := wt.cs.ViewNFTStake(nft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !stake.Amount.Equals(amount) || stake.Root != nft.FileMerkleRoot {
+		t.Fatal("unexpected stake", stake)
+	}
+	custody, err := wt.cs.ViewNFTCustody(nft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if custody.UnlockHash != owner {
+		t.Fatal("staking moved the NFT")
+	}
+	if _, err := wt.wallet.TransferNFT(nft, dest()); !errors.Contains(err, errNFTStaked) {
+		t.Fatal("expected errNFTStaked but got", err)
+	}
+	if _, err := wt.wallet.StakeNFT(nft, amount); !errors.Contains(err, errNFTStaked) {
+		t.Fatal("expected errNFTStaked but got", err)
+	}
+
+	// Claim a reward by proving storage of the data.
+	reward := wt.cs.ViewNFTRootStake(nft.FileMerkleRoot).Reward()
+	if _, err := wt.wallet.ClaimNFTStakeReward(data, dest()); err != nil {
+		t.Fatal(err)
+	}
+	mine()
+	rootStake := wt.cs.ViewNFTRootStake(nft.FileMerkleRoot)
+	if !rootStake.Remaining.Equals(amount.Sub(reward)) || rootStake.LastReward != wt.cs.Height() {
+		t.Fatal("reward wasn't paid out", rootStake)
+	}
+	if _, err := wt.wallet.ClaimNFTStakeReward(data, dest()); !errors.Contains(err, errNFTStakeRewardUnavailable) {
+		t.Fatal("expected errNFTStakeRewardUnavailable but got", err)
+	}
+
+	// Unstaking returns the rest of the stake and frees the NFT.
+	if _, err := wt.wallet.UnstakeNFT(nft, dest()); err != nil {
+		t.Fatal(err)
+	}
+	mine()
+	if _, err := wt.cs.ViewNFTStake(nft); err == nil {
+		t.Fatal("NFT is still staked")
+	}
+	rootStake = wt.cs.ViewNFTRootStake(nft.FileMerkleRoot)
+	if !rootStake.Staked.IsZero() || !rootStake.Remaining.IsZero() {
+		t.Fatal("stake wasn't released", rootStake)
+	}
+	if _, err := wt.wallet.TransferNFT(nft, dest()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestClaimNFTStakeRewardInterrupted tests that a stake reward claim which is
+// interrupted before it is broadcast can be retried.
+func TestClaimNFTStakeRewardInterrupted(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	deps := dependencies.NewDependencyInterruptNFTStakeRewardBeforeBroadcast()
+	wt, err := createWalletTester(t.Name(), deps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	dest := func() types.UnlockHash {
+		uc, err := wt.wallet.NextAddress()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return uc.UnlockHash()
+	}
+	mine := func() {
+		if _, err := wt.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Mint and stake an NFT.
+	data := fastrand.Bytes(crypto.SegmentSize * 10)
+	nft := types.NftCustody{FileMerkleRoot: crypto.MerkleRoot(data)}
+	if _, err := wt.wallet.MintNFT(nft, dest()); err != nil {
+		t.Fatal(err)
+	}
+	mine()
+	amount := types.NFTMinStake.Mul64(2)
+	if _, err := wt.wallet.StakeNFT(nft, amount); err != nil {
+		t.Fatal(err)
+	}
+	mine()
+
+	// The interrupted claim doesn't pay out the reward.
+	deps.Fail()
+	if _, err := wt.wallet.ClaimNFTStakeReward(data, dest()); err == nil {
+		t.Fatal("expected claim to be interrupted")
+	}
+	mine()
+	if rootStake := wt.cs.ViewNFTRootStake(nft.FileMerkleRoot); !rootStake.Remaining.Equals(amount) {
+		t.Fatal("interrupted claim paid out a reward", rootStake)
+	}
+
+	// Retrying the claim pays out the reward.
+	reward := wt.cs.ViewNFTRootStake(nft.FileMerkleRoot).Reward()
+	if _, err := wt.wallet.ClaimNFTStakeReward(data, dest()); err != nil {
+		t.Fatal(err)
+	}
+	mine()
+	if rootStake := wt.cs.ViewNFTRootStake(nft.FileMerkleRoot); !rootStake.Remaining.Equals(amount.Sub(reward)) {
+		t.Fatal("reward wasn't paid out", rootStake)
+	}
+}
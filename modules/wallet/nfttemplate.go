@@ -0,0 +1,224 @@
+package wallet
+
+import (
+	"sort"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// nfttemplate.go contains NFT mint templates. A template stores the settings
+// shared by the NFTs of a collection, so that creators minting many NFTs only
+// need to provide the merkle root of every NFT and all NFTs of the collection
+// are minted with the same settings.
+
+var (
+	// errEmptyNFTTemplateID is returned when storing a mint template without
+	// an ID.
+	errEmptyNFTTemplateID = errors.New("id of nft mint template can't be empty")
+
+	// errUnknownNFTTemplate is returned when requesting a mint template
+	// which doesn't exist.
+	errUnknownNFTTemplate = errors.New("nft mint template doesn't exist")
+
+	// errNFTTemplateRoyalty is returned when storing a mint template whose
+	// royalty exceeds modules.NFTMaxRoyalty.
+	errNFTTemplateRoyalty = errors.New("nft mint template royalty can't exceed 10000 basis points")
+
+	// errNFTTemplateDestination is returned when storing a mint template
+	// with an unknown destination policy or a fixed policy without a
+	// destination.
+	errNFTTemplateDestination = errors.New("invalid nft mint template destination")
+
+	// errNFTTemplateNewAddressCollection is returned when storing a mint
+	// template for a collection which mints to new addresses. The address
+	// an identified NFT is minted to becomes its creator, so all NFTs of a
+	// collection need to be minted to the same address.
+	errNFTTemplateNewAddressCollection = errors.New("nft mint templates with a collection need a fixed destination")
+)
+
+// dbPutNFTMintTemplate stores a mint template.
+func dbPutNFTMintTemplate(tx *bolt.Tx, template modules.NFTMintTemplate) error {
+	return dbPut(tx.Bucket(bucketNFTMintTemplates), template.ID, template)
+}
+
+// dbGetNFTMintTemplate returns the mint template with the given id.
+func dbGetNFTMintTemplate(tx *bolt.Tx, id string) (template modules.NFTMintTemplate, err error) {
+	err = dbGet(tx.Bucket(bucketNFTMintTemplates), id, &template)
+	return
+}
+
+// dbDeleteNFTMintTemplate deletes the mint template with the given id.
+func dbDeleteNFTMintTemplate(tx *bolt.Tx, id string) error {
+	return dbDelete(tx.Bucket(bucketNFTMintTemplates), id)
+}
+
+// dbForEachNFTMintTemplate iterates over all mint templates.
+func dbForEachNFTMintTemplate(tx *bolt.Tx, fn func(string, modules.NFTMintTemplate)) error {
+	return dbForEach(tx.Bucket(bucketNFTMintTemplates), fn)
+}
+
+// validateNFTMintTemplate checks the settings of a mint template. Templates
+// with a collection mint identified NFTs, which requires the destination to be
+// a single-key address of the wallet.
+func (w *Wallet) validateNFTMintTemplate(template modules.NFTMintTemplate) error {
+	if template.ID == "" {
+		return errEmptyNFTTemplateID
+	}
+	if err := types.ValidateNFTCollection(template.Collection); err != nil {
+		return err
+	}
+	if template.Royalty > modules.NFTMaxRoyalty {
+		return errNFTTemplateRoyalty
+	}
+	if template.ContentType != "" {
+		if err := types.ValidateNFTContentType(template.ContentType); err != nil {
+			return err
+		}
+	}
+	if err := template.TransferPolicy.Validate(); err != nil {
+		return err
+	}
+	switch template.DestinationPolicy {
+	case modules.NFTTemplateDestinationFixed:
+		if template.Destination == (types.UnlockHash{}) {
+			return errNFTTemplateDestination
+		}
+	case modules.NFTTemplateDestinationNewAddress:
+		if template.Collection != "" {
+			return errNFTTemplateNewAddressCollection
+		}
+		return nil
+	default:
+		return errNFTTemplateDestination
+	}
+	if template.Collection == "" {
+		return nil
+	}
+	key, exists := w.keys[template.Destination]
+	if !exists || len(key.UnlockConditions.PublicKeys) != 1 {
+		return errNFTCreatorKey
+	}
+	return nil
+}
+
+// SetNFTMintTemplate creates or replaces a mint template.
+func (w *Wallet) SetNFTMintTemplate(template modules.NFTMintTemplate) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.unlocked {
+		return modules.ErrLockedWallet
+	}
+	if err := w.validateNFTMintTemplate(template); err != nil {
+		return err
+	}
+	err := dbPutNFTMintTemplate(w.dbTx, template)
+	err = errors.Compose(err, w.syncDB())
+	if err != nil {
+		return errors.AddContext(err, "failed to store nft mint template")
+	}
+	return nil
+}
+
+// RemoveNFTMintTemplate removes a mint template.
+func (w *Wallet) RemoveNFTMintTemplate(id string) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, err := dbGetNFTMintTemplate(w.dbTx, id); errors.Contains(err, errNoKey) {
+		return errUnknownNFTTemplate
+	} else if err != nil {
+		return err
+	}
+	err := dbDeleteNFTMintTemplate(w.dbTx, id)
+	err = errors.Compose(err, w.syncDB())
+	if err != nil {
+		return errors.AddContext(err, "failed to remove nft mint template")
+	}
+	return nil
+}
+
+// NFTMintTemplates returns all mint templates of the wallet ordered by their
+// ID.
+func (w *Wallet) NFTMintTemplates() ([]modules.NFTMintTemplate, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var templates []modules.NFTMintTemplate
+	err := dbForEachNFTMintTemplate(w.dbTx, func(_ string, template modules.NFTMintTemplate) {
+		templates = append(templates, template)
+	})
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].ID < templates[j].ID
+	})
+	return templates, err
+}
+
+// MintNFTFromTemplate mints an NFT of the data with the given merkle root using
+// the settings of a mint template. NFTs of templates with a collection are
+// minted with the lowest nonce whose NftID wasn't minted yet, so the same data
+// can be minted several times from one template.
+func (w *Wallet) MintNFTFromTemplate(templateID string, root crypto.Hash) (types.NftCustody, []types.Transaction, error) {
+	if err := w.tg.Add(); err != nil {
+		return types.NftCustody{}, nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	template, err := dbGetNFTMintTemplate(w.dbTx, templateID)
+	w.mu.Unlock()
+	if errors.Contains(err, errNoKey) {
+		return types.NftCustody{}, nil, errUnknownNFTTemplate
+	} else if err != nil {
+		return types.NftCustody{}, nil, err
+	}
+
+	dest := template.Destination
+	if template.DestinationPolicy == modules.NFTTemplateDestinationNewAddress {
+		uc, err := w.NextNFTCustodyAddress()
+		if err != nil {
+			return types.NftCustody{}, nil, errors.AddContext(err, "failed to create destination address")
+		}
+		dest = uc.UnlockHash()
+	}
+
+	nft := types.NftCustody{
+		FileMerkleRoot: root,
+		ContentType:    template.ContentType,
+		TransferPolicy: template.TransferPolicy,
+	}
+	if template.Collection == "" {
+		txns, err := w.MintNFT(nft, dest)
+		return nft, txns, err
+	}
+	w.mu.RLock()
+	key, exists := w.keys[dest]
+	w.mu.RUnlock()
+	if !exists || len(key.UnlockConditions.PublicKeys) != 1 {
+		return types.NftCustody{}, nil, errNFTCreatorKey
+	}
+	var creator crypto.PublicKey
+	copy(creator[:], key.UnlockConditions.PublicKeys[0].Key)
+	var nonce uint64
+	for {
+		minted := types.NftCustody{ID: types.DeriveNftID(creator, template.Collection, root, nonce)}
+		if _, err := w.cs.ViewNFTCustody(minted); err != nil {
+			break
+		}
+		nonce++
+	}
+	return w.MintIdentifiedNFT(nft, template.Collection, nonce, dest)
+}
@@ -0,0 +1,166 @@
+package wallet
+
+import (
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestNFTMintTemplates tests storing, validating and removing mint templates
+// and minting NFTs from them.
+func TestNFTMintTemplates(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	uc, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	creator := uc.UnlockHash()
+	collection := modules.NFTMintTemplate{
+		ID:                "art",
+		Collection:        "art",
+		Royalty:           500,
+		ContentType:       "image/png",
+		TransferPolicy:    types.NFTTransferPolicy{Kind: types.NFTTransferAfterHeight, Height: 5},
+		DestinationPolicy: modules.NFTTemplateDestinationFixed,
+		Destination:       creator,
+	}
+	plain := modules.NFTMintTemplate{
+		ID:                "plain",
+		DestinationPolicy: modules.NFTTemplateDestinationNewAddress,
+	}
+
+	// Invalid templates are rejected.
+	invalid := func(fn func(*modules.NFTMintTemplate)) modules.NFTMintTemplate {
+		template := collection
+		fn(&template)
+		return template
+	}
+	tests := []struct {
+		name     string
+		template modules.NFTMintTemplate
+		err      error
+	}{
+		{"no id", invalid(func(t *modules.NFTMintTemplate) { t.ID = "" }), errEmptyNFTTemplateID},
+		{"royalty", invalid(func(t *modules.NFTMintTemplate) { t.Royalty = modules.NFTMaxRoyalty + 1 }), errNFTTemplateRoyalty},
+		{"content type", invalid(func(t *modules.NFTMintTemplate) { t.ContentType = "Image/PNG" }), types.ErrNFTBadContentType},
+		{"policy", invalid(func(t *modules.NFTMintTemplate) { t.DestinationPolicy = "" }), errNFTTemplateDestination},
+		{"no destination", invalid(func(t *modules.NFTMintTemplate) { t.Destination = types.UnlockHash{} }), errNFTTemplateDestination},
+		{"foreign creator", invalid(func(t *modules.NFTMintTemplate) { t.Destination = types.UnlockHash{1} }), errNFTCreatorKey},
+		{"new address collection", invalid(func(t *modules.NFTMintTemplate) { t.DestinationPolicy = modules.NFTTemplateDestinationNewAddress }), errNFTTemplateNewAddressCollection},
+	}
+	for _, test := range tests {
+		if err := wt.wallet.SetNFTMintTemplate(test.template); !errors.Contains(err, test.err) {
+			t.Errorf("%v: expected %v but got %v", test.name, test.err, err)
+		}
+	}
+
+	// Store the valid templates.
+	for _, template := range []modules.NFTMintTemplate{plain, collection} {
+		if err := wt.wallet.SetNFTMintTemplate(template); err != nil {
+			t.Fatal(err)
+		}
+	}
+	templates, err := wt.wallet.NFTMintTemplates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(templates) != 2 || templates[0] != collection || templates[1] != plain {
+		t.Fatalf("unexpected templates %+v", templates)
+	}
+
+	// Minting the same data twice from the collection template uses the next
+	// nonce and the settings of the template.
+	var root crypto.Hash
+	fastrand.Read(root[:])
+	first, _, err := wt.wallet.MintNFTFromTemplate("art", root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	second, _, err := wt.wallet.MintNFTFromTemplate("art", root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Nonce != 0 || second.Nonce != 1 || first.ID == second.ID {
+		t.Fatal("expected consecutive nonces", first.Nonce, second.Nonce)
+	}
+	if second.Collection != "art" || second.ContentType != "image/png" || second.TransferPolicy != collection.TransferPolicy {
+		t.Fatalf("nft wasn't minted with the template's settings %+v", second)
+	}
+
+	// The plain template mints to a new address.
+	fastrand.Read(root[:])
+	nft, txns, err := wt.wallet.MintNFTFromTemplate("plain", root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	custody, err := wt.cs.ViewNFTCustody(nft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if custody.UnlockHash == creator || len(txns) == 0 {
+		t.Fatal("nft wasn't minted to a new address")
+	}
+	if _, _, err := wt.wallet.MintNFTFromTemplate("unknown", root); !errors.Contains(err, errUnknownNFTTemplate) {
+		t.Fatal("expected errUnknownNFTTemplate but got", err)
+	}
+
+	// Templates are persisted.
+	if err := wt.wallet.Close(); err != nil {
+		t.Fatal(err)
+	}
+	w, err := New(wt.cs, wt.tpool, filepath.Join(wt.persistDir, modules.WalletDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt.wallet = w
+	if err := wt.wallet.Unlock(wt.walletMasterKey); err != nil {
+		t.Fatal(err)
+	}
+	templates, err = wt.wallet.NFTMintTemplates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(templates) != 2 {
+		t.Fatal("templates weren't persisted", templates)
+	}
+
+	// Removing a template.
+	if err := wt.wallet.RemoveNFTMintTemplate("plain"); err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.wallet.RemoveNFTMintTemplate("plain"); !errors.Contains(err, errUnknownNFTTemplate) {
+		t.Fatal("expected errUnknownNFTTemplate but got", err)
+	}
+	templates, err = wt.wallet.NFTMintTemplates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(templates) != 1 || templates[0] != collection {
+		t.Fatalf("unexpected templates %+v", templates)
+	}
+}
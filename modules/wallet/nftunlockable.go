@@ -0,0 +1,233 @@
+package wallet
+
+import (
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// nftunlockable.go contains the wallet's side of unlockable content. The
+// wallet stores the envelope of every NFT with unlockable content it holds.
+// The content key of an envelope is wrapped to an X25519 key which is derived
+// from the secret key of the address holding the NFT, so the key can be
+// recovered from the seed. Transfers of NFTs with unlockable content re-wrap
+// the content key to the key of the recipient's handshake.
+
+var (
+	// errNFTUnlockableAddress is returned when the address unlockable
+	// content is wrapped to isn't a single-key address of the wallet.
+	errNFTUnlockableAddress = errors.New("nft unlockable content must be wrapped to a single-key address of this wallet")
+
+	// errNFTNoUnlockable is returned when the wallet has no unlockable
+	// content for an NFT.
+	errNFTNoUnlockable = errors.New("wallet has no unlockable content for the nft")
+
+	// errNFTUnlockableNotOwner is returned when unlocking the content of an
+	// NFT which isn't held by the address its envelope is wrapped to.
+	errNFTUnlockableNotOwner = errors.New("nft is not held by the owner of the unlockable content")
+
+	// specifierNFTUnlockableKey is used as the prefix when deriving the
+	// X25519 key of an address from its secret key.
+	specifierNFTUnlockableKey = types.NewSpecifier("nft unlock key")
+)
+
+// dbPutNFTUnlockable stores the envelope of an NFT's unlockable content.
+func dbPutNFTUnlockable(tx *bolt.Tx, e types.NFTUnlockableEnvelope) error {
+	return dbPut(tx.Bucket(bucketNFTUnlockables), e.NFT.Identifier(), e)
+}
+
+// dbGetNFTUnlockable returns the envelope of an NFT's unlockable content.
+func dbGetNFTUnlockable(tx *bolt.Tx, id types.NftID) (e types.NFTUnlockableEnvelope, err error) {
+	err = dbGet(tx.Bucket(bucketNFTUnlockables), id, &e)
+	return
+}
+
+// dbDeleteNFTUnlockable deletes the envelope of an NFT's unlockable content.
+func dbDeleteNFTUnlockable(tx *bolt.Tx, id types.NftID) error {
+	return dbDelete(tx.Bucket(bucketNFTUnlockables), id)
+}
+
+// nftUnlockableKey returns the X25519 key unlockable content is wrapped to
+// for an address of the wallet. The caller needs to hold the wallet's mutex.
+func (w *Wallet) nftUnlockableKey(addr types.UnlockHash) (spendableKey, crypto.X25519SecretKey, error) {
+	if !w.unlocked {
+		return spendableKey{}, crypto.X25519SecretKey{}, modules.ErrLockedWallet
+	}
+	key, exists := w.keys[addr]
+	if !exists || len(key.UnlockConditions.PublicKeys) != 1 || len(key.SecretKeys) != 1 {
+		return spendableKey{}, crypto.X25519SecretKey{}, errNFTUnlockableAddress
+	}
+	return key, crypto.X25519SecretKey(crypto.HashAll(specifierNFTUnlockableKey, key.SecretKeys[0])), nil
+}
+
+// managedStoreNFTUnlockable stores an envelope if the wallet holds the key it
+// is wrapped to, so that the content key can be unwrapped later.
+func (w *Wallet) managedStoreNFTUnlockable(e types.NFTUnlockableEnvelope) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, xsk, err := w.nftUnlockableKey(e.Owner)
+	if err != nil {
+		return err
+	}
+	if _, err := e.Unwrap(xsk); err != nil {
+		return err
+	}
+	err = dbPutNFTUnlockable(w.dbTx, e)
+	err = errors.Compose(err, w.syncDB())
+	if err != nil {
+		return errors.AddContext(err, "failed to store nft unlockable content")
+	}
+	return nil
+}
+
+// MintUnlockableNFT mints an NFT like MintNFT and stores the content key of
+// its unlockable content wrapped to the key of dest. The ciphertext of the
+// content needs to be uploaded by the caller.
+func (w *Wallet) MintUnlockableNFT(nft types.NftCustody, u types.NFTUnlockable, key types.NFTUnlockableKey, dest types.UnlockHash) ([]types.Transaction, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	if err := u.Validate(); err != nil {
+		return nil, err
+	}
+	w.mu.RLock()
+	_, xsk, err := w.nftUnlockableKey(dest)
+	w.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	txns, err := w.MintNFT(nft, dest)
+	if err != nil {
+		return nil, err
+	}
+	e := types.WrapNFTUnlockable(nft, u, key, dest, xsk.PublicKey())
+	if err := w.managedStoreNFTUnlockable(e); err != nil {
+		return nil, err
+	}
+	return txns, nil
+}
+
+// NFTUnlockableHandshake returns the handshake the sender of an NFT with
+// unlockable content needs to re-wrap the content key to dest. The handshake
+// is signed by the key of dest, which needs to be a single-key address of the
+// wallet.
+func (w *Wallet) NFTUnlockableHandshake(dest types.UnlockHash) (types.NFTUnlockableHandshake, error) {
+	if err := w.tg.Add(); err != nil {
+		return types.NFTUnlockableHandshake{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.RLock()
+	key, xsk, err := w.nftUnlockableKey(dest)
+	w.mu.RUnlock()
+	if err != nil {
+		return types.NFTUnlockableHandshake{}, err
+	}
+	h := types.NFTUnlockableHandshake{
+		Address:   dest,
+		PublicKey: key.UnlockConditions.PublicKeys[0],
+		UnlockKey: xsk.PublicKey(),
+	}
+	h.Signature = crypto.SignHash(h.SigHash(), key.SecretKeys[0])
+	return h, nil
+}
+
+// TransferUnlockableNFT transfers an NFT with unlockable content to the
+// address of a handshake. The returned envelope contains the content key
+// re-wrapped to the recipient's key and needs to be passed to the recipient,
+// whose wallet imports it with ImportNFTUnlockable. The wallet forgets about
+// the unlockable content unless the NFT is transferred to itself.
+func (w *Wallet) TransferUnlockableNFT(nft types.NftCustody, h types.NFTUnlockableHandshake) ([]types.Transaction, types.NFTUnlockableEnvelope, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, types.NFTUnlockableEnvelope{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	if err := h.Verify(); err != nil {
+		return nil, types.NFTUnlockableEnvelope{}, err
+	}
+
+	// Re-wrap the content key before transferring the NFT, so that the
+	// transfer isn't broadcast if the key can't be unwrapped.
+	w.mu.Lock()
+	e, err := dbGetNFTUnlockable(w.dbTx, nft.Identifier())
+	var xsk crypto.X25519SecretKey
+	if err == nil {
+		_, xsk, err = w.nftUnlockableKey(e.Owner)
+	}
+	w.mu.Unlock()
+	if errors.Contains(err, errNoKey) {
+		return nil, types.NFTUnlockableEnvelope{}, errNFTNoUnlockable
+	} else if err != nil {
+		return nil, types.NFTUnlockableEnvelope{}, err
+	}
+	key, err := e.Unwrap(xsk)
+	if err != nil {
+		return nil, types.NFTUnlockableEnvelope{}, err
+	}
+	rewrapped := types.WrapNFTUnlockable(e.NFT, e.Unlockable, key, h.Address, h.UnlockKey)
+
+	txns, err := w.TransferNFT(nft, h.Address)
+	if err != nil {
+		return nil, types.NFTUnlockableEnvelope{}, err
+	}
+	if err := w.managedStoreNFTUnlockable(rewrapped); errors.Contains(err, errNFTUnlockableAddress) {
+		w.mu.Lock()
+		err = dbDeleteNFTUnlockable(w.dbTx, nft.Identifier())
+		err = errors.Compose(err, w.syncDB())
+		w.mu.Unlock()
+		if err != nil {
+			w.log.Println("WARN: failed to remove unlockable content of transferred nft:", err)
+		}
+	} else if err != nil {
+		w.log.Println("WARN: failed to store unlockable content of nft transferred to the wallet:", err)
+	}
+	return txns, rewrapped, nil
+}
+
+// ImportNFTUnlockable stores an envelope which was wrapped to an address of the
+// wallet, e.g. by the sender of an incoming NFT. An earlier envelope of the same
+// NFT is replaced.
+func (w *Wallet) ImportNFTUnlockable(e types.NFTUnlockableEnvelope) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	if err := e.Unlockable.Validate(); err != nil {
+		return err
+	}
+	return w.managedStoreNFTUnlockable(e)
+}
+
+// UnlockNFT returns the unlockable content of an NFT together with its content
+// key. The content is only unlocked while the NFT is held by the address its
+// envelope is wrapped to.
+func (w *Wallet) UnlockNFT(nft types.NftCustody) (types.NFTUnlockable, types.NFTUnlockableKey, error) {
+	if err := w.tg.Add(); err != nil {
+		return types.NFTUnlockable{}, types.NFTUnlockableKey{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	e, err := dbGetNFTUnlockable(w.dbTx, nft.Identifier())
+	var xsk crypto.X25519SecretKey
+	if err == nil {
+		_, xsk, err = w.nftUnlockableKey(e.Owner)
+	}
+	w.mu.Unlock()
+	if errors.Contains(err, errNoKey) {
+		return types.NFTUnlockable{}, types.NFTUnlockableKey{}, errNFTNoUnlockable
+	} else if err != nil {
+		return types.NFTUnlockable{}, types.NFTUnlockableKey{}, err
+	}
+	custody, err := w.cs.ViewNFTCustody(nft)
+	if err != nil || custody.UnlockHash != e.Owner {
+		return types.NFTUnlockable{}, types.NFTUnlockableKey{}, errors.Compose(errNFTUnlockableNotOwner, err)
+	}
+	key, err := e.Unwrap(xsk)
+	if err != nil {
+		return types.NFTUnlockable{}, types.NFTUnlockableKey{}, err
+	}
+	return e.Unlockable, key, nil
+}
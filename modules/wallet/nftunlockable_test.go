@@ -0,0 +1,116 @@
+package wallet
+
+import (
+	"bytes"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestUnlockableNFT tests minting an NFT with unlockable content, unlocking it
+// and re-wrapping its content key when transferring the NFT.
+func TestUnlockableNFT(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	payload := fastrand.Bytes(64)
+	ciphertext, key := types.EncryptNFTUnlockable(payload)
+	u := types.NFTUnlockable{
+		Storage:        types.NFTUnlockableStorageHost,
+		Root:           crypto.MerkleRoot(ciphertext),
+		CiphertextHash: crypto.HashBytes(ciphertext),
+	}
+	uc, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var nft types.NftCustody
+	fastrand.Read(nft.FileMerkleRoot[:])
+	if _, err := wt.wallet.MintUnlockableNFT(nft, u, key, types.UnlockHash{1}); !errors.Contains(err, errNFTUnlockableAddress) {
+		t.Fatal("expected errNFTUnlockableAddress but got", err)
+	}
+	if _, err := wt.wallet.MintUnlockableNFT(nft, u, key, uc.UnlockHash()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The content is locked until the mint is confirmed.
+	if _, _, err := wt.wallet.UnlockNFT(nft); !errors.Contains(err, errNFTUnlockableNotOwner) {
+		t.Fatal("expected errNFTUnlockableNotOwner but got", err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	unlockable, unlocked, err := wt.wallet.UnlockNFT(nft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decrypted, err := types.DecryptNFTUnlockable(unlockable, ciphertext, unlocked); err != nil || !bytes.Equal(decrypted, payload) {
+		t.Fatal("unlocked content doesn't decrypt", err)
+	}
+
+	// Transfer the NFT to another address of the wallet, which keeps the
+	// unlockable content.
+	uc2, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := wt.wallet.NFTUnlockableHandshake(uc2.UnlockHash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	bad := h
+	bad.Address = uc.UnlockHash()
+	if _, _, err := wt.wallet.TransferUnlockableNFT(nft, bad); !errors.Contains(err, types.ErrNFTUnlockableBadHandshake) {
+		t.Fatal("expected ErrNFTUnlockableBadHandshake but got", err)
+	}
+	if _, _, err := wt.wallet.TransferUnlockableNFT(nft, h); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	if _, unlocked, err := wt.wallet.UnlockNFT(nft); err != nil || unlocked != key {
+		t.Fatal("content wasn't unlocked after transfer", err)
+	}
+
+	// Transfer the NFT out of the wallet. The recipient can unwrap the
+	// content key, the wallet forgets about it.
+	sk, pk := crypto.GenerateKeyPair()
+	recipient := types.UnlockConditions{PublicKeys: []types.SiaPublicKey{types.Ed25519PublicKey(pk)}, SignaturesRequired: 1}
+	xsk, xpk := crypto.GenerateX25519KeyPair()
+	h = types.NFTUnlockableHandshake{
+		Address:   recipient.UnlockHash(),
+		PublicKey: types.Ed25519PublicKey(pk),
+		UnlockKey: xpk,
+	}
+	h.Signature = crypto.SignHash(h.SigHash(), sk)
+	_, e, err := wt.wallet.TransferUnlockableNFT(nft, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unwrapped, err := e.Unwrap(xsk); err != nil || unwrapped != key {
+		t.Fatal("recipient can't unwrap the content key", err)
+	}
+	if _, _, err := wt.wallet.UnlockNFT(nft); !errors.Contains(err, errNFTNoUnlockable) {
+		t.Fatal("expected errNFTNoUnlockable but got", err)
+	}
+	if err := wt.wallet.ImportNFTUnlockable(e); !errors.Contains(err, errNFTUnlockableAddress) {
+		t.Fatal("expected errNFTUnlockableAddress but got", err)
+	}
+}
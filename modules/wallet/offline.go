@@ -337,6 +337,9 @@ func (w *Wallet) AddWatchAddresses(addrs []types.UnlockHash, unused bool) error
 	if err != nil {
 		return err
 	}
+	if err := w.managedWatchLightAddresses(); err != nil {
+		return err
+	}
 
 	if !unused {
 		// rescan the blockchain
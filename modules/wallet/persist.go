@@ -50,6 +50,8 @@ func (w *Wallet) openDB(filename string) (err error) {
 	err = w.db.Update(func(tx *bolt.Tx) error {
 		// check whether we need to init bucketAddrTransactions
 		buildAddrTxns := tx.Bucket(bucketAddrTransactions) == nil
+		// check whether we need to init bucketNFTDepositUsers
+		buildNFTDepositUsers := tx.Bucket(bucketNFTDepositUsers) == nil
 		// ensure that all buckets exist
 		for _, b := range dbBuckets {
 			_, err := tx.CreateBucketIfNotExists(b)
@@ -92,6 +94,17 @@ func (w *Wallet) openDB(filename string) (err error) {
 			}
 		}
 
+		// build the bucketNFTDepositUsers bucket if necessary
+		if buildNFTDepositUsers {
+			var err error
+			forEachErr := dbForEachNFTDepositAddr(tx, func(addr types.UnlockHash, userID string) {
+				err = errors.Compose(err, dbPutNFTDepositUser(tx, userID, addr))
+			})
+			if err := errors.Compose(forEachErr, err); err != nil {
+				return err
+			}
+		}
+
 		// check whether wallet is encrypted
 		w.encrypted = tx.Bucket(bucketWallet).Get(keyEncryptionVerification) != nil
 		return nil
@@ -178,6 +191,8 @@ func (w *Wallet) initPersist() error {
 
 	// spawn a goroutine to commit the db transaction at regular intervals
 	go w.threadedDBUpdate()
+	// spawn a goroutine to broadcast unconfirmed transaction groups again
+	go w.threadedBroadcastTransactionGroups()
 	return nil
 }
 
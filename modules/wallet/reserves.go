@@ -0,0 +1,114 @@
+package wallet
+
+import (
+	"bytes"
+	"sort"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// errProofOfReservesSyncing is returned when the consensus set moves on while
+// a proof of reserves is built, which would mix holdings of different heights.
+var errProofOfReservesSyncing = errors.New("consensus changed while building the proof of reserves, try again")
+
+// ProofOfReserves returns a proof of the NFTs and siacoins held by the wallet's
+// addresses at the current height, signed by the keys of every address which
+// holds any of them. Watched addresses are left out since the wallet can't
+// sign for them.
+func (w *Wallet) ProofOfReserves(challenge crypto.Hash) (types.ProofOfReserves, error) {
+	if err := w.tg.Add(); err != nil {
+		return types.ProofOfReserves{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	// The NFTs are scanned from the custody index, which needs to stay at
+	// the same block during the scan.
+	current := w.cs.CurrentBlock().ID()
+	scanned := w.ScanAllNFTS()
+	if w.cs.CurrentBlock().ID() != current {
+		return types.ProofOfReserves{}, errProofOfReservesSyncing
+	}
+
+	// Holding the lock keeps the wallet from processing new blocks, so the
+	// outputs belong to the wallet's height, which needs to be the height
+	// the NFTs were scanned at.
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.unlocked {
+		return types.ProofOfReserves{}, modules.ErrLockedWallet
+	}
+	height, err := dbGetConsensusHeight(w.dbTx)
+	if err != nil {
+		return types.ProofOfReserves{}, errors.AddContext(err, "failed to get consensus height")
+	}
+	block, exists := w.cs.BlockAtHeight(height)
+	if !exists || block.ID() != current {
+		return types.ProofOfReserves{}, errProofOfReservesSyncing
+	}
+	p := types.ProofOfReserves{
+		Challenge:   challenge,
+		BlockHeight: height,
+		BlockID:     block.ID(),
+	}
+
+	// Collect the holdings of the wallet's addresses.
+	holders := make(map[types.UnlockHash]spendableKey)
+	for _, stats := range scanned {
+		key, exists := w.keys[stats.Owner]
+		if !exists {
+			continue
+		}
+		holders[stats.Owner] = key
+		p.NFTs = append(p.NFTs, types.ProofOfReservesNFT{
+			NFT:     stats.Nft,
+			Address: stats.Owner,
+		})
+	}
+	err = dbForEachSiacoinOutput(w.dbTx, func(id types.SiacoinOutputID, sco types.SiacoinOutput) {
+		key, exists := w.keys[sco.UnlockHash]
+		if !exists {
+			return
+		}
+		holders[sco.UnlockHash] = key
+		p.Outputs = append(p.Outputs, types.ProofOfReservesOutput{
+			ID:     id,
+			Output: sco,
+		})
+		p.Siacoins = p.Siacoins.Add(sco.Value)
+	})
+	if err != nil {
+		return types.ProofOfReserves{}, errors.AddContext(err, "failed to iterate over wallet outputs")
+	}
+
+	// Order everything, so that the same holdings result in the same proof.
+	sort.Slice(p.NFTs, func(i, j int) bool {
+		a, b := p.NFTs[i].NFT.Identifier(), p.NFTs[j].NFT.Identifier()
+		return bytes.Compare(a[:], b[:]) < 0
+	})
+	sort.Slice(p.Outputs, func(i, j int) bool {
+		return bytes.Compare(p.Outputs[i].ID[:], p.Outputs[j].ID[:]) < 0
+	})
+	addrs := make([]types.UnlockHash, 0, len(holders))
+	for addr := range holders {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return bytes.Compare(addrs[i][:], addrs[j][:]) < 0
+	})
+
+	// Sign the proof with the keys of every holder.
+	sigHash := p.SigHash()
+	for _, addr := range addrs {
+		key := holders[addr]
+		signer := types.ProofOfReservesSigner{UnlockConditions: key.UnlockConditions}
+		for i := uint64(0); i < key.UnlockConditions.SignaturesRequired && i < uint64(len(key.SecretKeys)); i++ {
+			signer.Signatures = append(signer.Signatures, crypto.SignHash(sigHash, key.SecretKeys[i]))
+		}
+		p.Signers = append(p.Signers, signer)
+	}
+	return p, nil
+}
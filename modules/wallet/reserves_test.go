@@ -0,0 +1,83 @@
+package wallet
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestProofOfReserves tests that a proof of reserves lists the wallet's NFTs
+// and siacoins and that it passes the check against the consensus set until
+// the holdings move.
+func TestProofOfReserves(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	uc, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var nft types.NftCustody
+	fastrand.Read(nft.FileMerkleRoot[:])
+	if _, err := wt.wallet.MintNFT(nft, uc.UnlockHash()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	challenge := crypto.Hash{1}
+	p, err := wt.wallet.ProofOfReserves(challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Challenge != challenge || p.BlockHeight != wt.cs.Height() || p.BlockID != wt.cs.CurrentBlock().ID() {
+		t.Fatal("proof doesn't cover the challenge and current block", p.Challenge, p.BlockHeight, p.BlockID)
+	}
+	if len(p.NFTs) != 1 || p.NFTs[0].NFT.Identifier() != nft.Identifier() || p.NFTs[0].Address != uc.UnlockHash() {
+		t.Fatal("proof doesn't list the nft", p.NFTs)
+	}
+	if p.Siacoins.IsZero() || len(p.Outputs) == 0 {
+		t.Fatal("proof doesn't list the wallet's siacoins")
+	}
+	if err := modules.CheckProofOfReserves(wt.cs, p); err != nil {
+		t.Fatal(err)
+	}
+
+	// Once the NFT leaves the wallet, the proof fails the check.
+	if _, err := wt.wallet.TransferNFT(nft, types.UnlockHash{1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	if err := modules.CheckProofOfReserves(wt.cs, p); !errors.Contains(err, modules.ErrProofOfReservesCustodyMismatch) {
+		t.Fatal("expected ErrProofOfReservesCustodyMismatch but got", err)
+	}
+	p, err = wt.wallet.ProofOfReserves(challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.NFTs) != 0 {
+		t.Fatal("proof lists the transferred nft", p.NFTs)
+	}
+	if err := modules.CheckProofOfReserves(wt.cs, p); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -45,8 +45,31 @@ func generateSpendableKey(seed modules.Seed, index uint64) spendableKey {
 	}
 }
 
+// generateAccountKey creates the keys and unlock conditions for an account of
+// seed at a given index. The keys of the spending account are the keys created
+// by generateSpendableKey.
+func generateAccountKey(seed modules.Seed, account walletAccount, index uint64) spendableKey {
+	if account == accountSpending {
+		return generateSpendableKey(seed, index)
+	}
+	sk, pk := crypto.GenerateKeyPairDeterministic(crypto.HashAll(seed, account, index))
+	return spendableKey{
+		UnlockConditions: types.UnlockConditions{
+			PublicKeys:         []types.SiaPublicKey{types.Ed25519PublicKey(pk)},
+			SignaturesRequired: 1,
+		},
+		SecretKeys: []crypto.SecretKey{sk},
+	}
+}
+
 // generateKeys generates n keys from seed, starting from index start.
 func generateKeys(seed modules.Seed, start, n uint64) []spendableKey {
+	return generateAccountKeys(seed, accountSpending, start, n)
+}
+
+// generateAccountKeys generates n keys of an account from seed, starting from
+// index start.
+func generateAccountKeys(seed modules.Seed, account walletAccount, start, n uint64) []spendableKey {
 	// generate in parallel, one goroutine per core.
 	keys := make([]spendableKey, n)
 	var wg sync.WaitGroup
@@ -58,7 +81,7 @@ func generateKeys(seed modules.Seed, start, n uint64) []spendableKey {
 				// NOTE: don't bother trying to optimize generateSpendableKey;
 				// profiling shows that ed25519 key generation consumes far
 				// more CPU time than encoding or hashing.
-				keys[i] = generateSpendableKey(seed, start+i)
+				keys[i] = generateAccountKey(seed, account, start+i)
 			}
 		}(uint64(cpu))
 	}
@@ -103,6 +126,9 @@ func (w *Wallet) regenerateLookahead(start uint64) {
 	for i, k := range generateKeys(w.primarySeed, start+existingKeys, maxKeys-existingKeys) {
 		w.lookahead[k.UnlockConditions.UnlockHash()] = start + existingKeys + uint64(i)
 	}
+	if maxKeys > existingKeys {
+		go w.threadedWatchLightAddresses()
+	}
 }
 
 // integrateSeed generates n spendableKeys from the seed and loads them into
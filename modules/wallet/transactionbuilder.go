@@ -2,14 +2,12 @@ package wallet
 
 import (
 	"bytes"
-	"fmt"
 	"sort"
 
 	"gitlab.com/NebulousLabs/bolt"
 	"gitlab.com/NebulousLabs/errors"
 
 	"gitlab.com/NebulousLabs/encoding"
-	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/types"
@@ -50,6 +48,10 @@ type transactionBuilder struct {
 	siafundInputs         []int
 	transactionSignatures []int
 
+	// allowNFTCustody allows FundSiacoins to spend the custody outputs of
+	// NFTs.
+	allowNFTCustody bool
+
 	wallet *Wallet
 }
 
@@ -98,7 +100,9 @@ func addSignatures(txn *types.Transaction, cf types.CoveredFields, uc types.Unlo
 }
 
 // checkOutput is a helper function used to determine if an output is usable.
-func (w *Wallet) checkOutput(tx *bolt.Tx, currentHeight types.BlockHeight, id types.SiacoinOutputID, output types.SiacoinOutput, dustThreshold types.Currency) error {
+// Outputs that hold the custody of an NFT are only usable if allowNFTCustody is
+// set.
+func (w *Wallet) checkOutput(tx *bolt.Tx, currentHeight types.BlockHeight, id types.SiacoinOutputID, output types.SiacoinOutput, dustThreshold types.Currency, allowNFTCustody bool) error {
 	// Check that an output is not dust
 	if output.Value.Cmp(dustThreshold) < 0 {
 		return errDustOutput
@@ -110,6 +114,13 @@ func (w *Wallet) checkOutput(tx *bolt.Tx, currentHeight types.BlockHeight, id ty
 			return errSpendHeightTooHigh
 		}
 	}
+	// Check that the output isn't reserved for NFT custody.
+	if _, custody := w.nftCustodyAddrs[output.UnlockHash]; custody && !allowNFTCustody {
+		return errNFTCustodyOutput
+	}
+	if !allowNFTCustody && w.isNFTCustodyToken(output) {
+		return errNFTCustodyToken
+	}
 	outputUnlockConditions := w.keys[output.UnlockHash].UnlockConditions
 	if currentHeight < outputUnlockConditions.Timelock {
 		return errOutputTimelock
@@ -118,6 +129,13 @@ func (w *Wallet) checkOutput(tx *bolt.Tx, currentHeight types.BlockHeight, id ty
 	return nil
 }
 
+// AllowNFTCustodyOutputs allows FundSiacoins to spend outputs that hold the
+// custody of an NFT. Spending such an output breaks the NFT's chain of custody,
+// so this should only be used for intentional spends.
+func (tb *transactionBuilder) AllowNFTCustodyOutputs() {
+	tb.allowNFTCustody = true
+}
+
 // Copy creates a deep copy of the current transactionBuilder that can be used to
 // extend the transaction in an alternate way (i.e. create a double spend
 // transaction).
@@ -138,6 +156,7 @@ func (tb *transactionBuilder) Copy() modules.TransactionBuilder {
 	copy(copyBuilder.transactionSignatures, tb.transactionSignatures)
 
 	copyBuilder.signed = tb.signed
+	copyBuilder.allowNFTCustody = tb.allowNFTCustody
 	return copyBuilder
 }
 
@@ -210,29 +229,30 @@ func (tb *transactionBuilder) FundSiacoins(amount types.Currency) (err error) {
 		return err
 	}
 
-	// Collect a value-sorted set of siacoin outputs.
+	// Collect a value-sorted set of siacoin outputs. Custody outputs of NFTs
+	// are filtered out by checkOutput.
 	var so sortedOutputs
 	err = dbForEachSiacoinOutput(tb.wallet.dbTx, func(scoid types.SiacoinOutputID, sco types.SiacoinOutput) {
-		if len(tb.wallet.cs.FindNFTsForAddress(sco.UnlockHash)) == 0 {
-			// for now just don't touch addresses that hold NFTs in custody
-			// in the future we can change to spending non-nft outputs in the wallet
-			so.ids = append(so.ids, scoid)
-			so.outputs = append(so.outputs, sco)
-		} else if build.DEBUG {
-			fmt.Println("Skipping output", sco, "for auto-funding because it may belong to an NFT")
-		}
+		so.ids = append(so.ids, scoid)
+		so.outputs = append(so.outputs, sco)
 	})
 	if err != nil {
 		return err
 	}
-	// Add all of the unconfirmed outputs as well.
+	// Add all of the unconfirmed outputs as well. The custody output of an
+	// unconfirmed NFT mint or transfer isn't known to the consensus set yet
+	// and is skipped here.
 	for _, upt := range tb.wallet.unconfirmedProcessedTransactions {
+		_, custodyID, _, isNFT := nftCustodyOutput(upt.Transaction)
 		for i, sco := range upt.Transaction.SiacoinOutputs {
 			// Determine if the output belongs to the wallet.
 			_, exists := tb.wallet.keys[sco.UnlockHash]
 			if !exists {
 				continue
 			}
+			if isNFT && custodyID == upt.Transaction.SiacoinOutputID(uint64(i)) && !tb.allowNFTCustody {
+				continue
+			}
 			so.ids = append(so.ids, upt.Transaction.SiacoinOutputID(uint64(i)))
 			so.outputs = append(so.outputs, sco)
 		}
@@ -253,7 +273,7 @@ func (tb *transactionBuilder) FundSiacoins(amount types.Currency) (err error) {
 		scoid := so.ids[i]
 		sco := so.outputs[i]
 		// Check that the output can be spent.
-		if err := tb.wallet.checkOutput(tb.wallet.dbTx, consensusHeight, scoid, sco, dustThreshold); err != nil {
+		if err := tb.wallet.checkOutput(tb.wallet.dbTx, consensusHeight, scoid, sco, dustThreshold, tb.allowNFTCustody); err != nil {
 			if errors.Contains(err, errSpendHeightTooHigh) {
 				potentialFund = potentialFund.Add(sco.Value)
 			}
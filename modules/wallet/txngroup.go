@@ -0,0 +1,471 @@
+package wallet
+
+import (
+	"time"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// txngroup.go contains transaction groups, sets of interdependent transactions
+// such as the mint of an NFT followed by an immediate transfer, or a sweep
+// followed by a mint. The wallet orders the transactions of a group by their
+// dependencies, submits them to the transaction pool as a single set and
+// tracks the confirmation of every transaction of the group. The unconfirmed
+// transactions of a group are periodically announced to the transaction pool's
+// peers again, or submitted to the transaction pool again if they dropped out
+// of it, e.g. because the pool was purged or the node restarted. The wallet
+// gives up on a group once it reaches its give up height.
+//
+// The transactions of NFT operations are broadcast as transaction groups, so
+// that NFT transfers don't silently vanish from the transaction pool.
+
+var (
+	// errEmptyTransactionGroup is returned when broadcasting a group without
+	// any transactions.
+	errEmptyTransactionGroup = errors.New("transaction group doesn't contain any transactions")
+
+	// errDuplicateGroupTransaction is returned when broadcasting a group
+	// which contains the same transaction twice.
+	errDuplicateGroupTransaction = errors.New("transaction group contains the same transaction twice")
+
+	// errTransactionGroupCycle is returned when the transactions of a group
+	// depend on each other in a cycle.
+	errTransactionGroupCycle = errors.New("transactions of the group depend on each other in a cycle")
+
+	// errUnknownTransactionGroup is returned when requesting a group which
+	// isn't tracked by the wallet.
+	errUnknownTransactionGroup = errors.New("transaction group is not tracked by the wallet")
+)
+
+// dbPutTransactionGroup stores a transaction group.
+func dbPutTransactionGroup(tx *bolt.Tx, group modules.TransactionGroup) error {
+	return dbPut(tx.Bucket(bucketTransactionGroups), group.ID, group)
+}
+
+// dbGetTransactionGroup returns the transaction group with the given id.
+func dbGetTransactionGroup(tx *bolt.Tx, id modules.TransactionGroupID) (group modules.TransactionGroup, err error) {
+	err = dbGet(tx.Bucket(bucketTransactionGroups), id, &group)
+	return
+}
+
+// dbDeleteTransactionGroup deletes the transaction group with the given id.
+func dbDeleteTransactionGroup(tx *bolt.Tx, id modules.TransactionGroupID) error {
+	return dbDelete(tx.Bucket(bucketTransactionGroups), id)
+}
+
+// dbForEachTransactionGroup iterates over all transaction groups.
+func dbForEachTransactionGroup(tx *bolt.Tx, fn func(modules.TransactionGroupID, modules.TransactionGroup)) error {
+	return dbForEach(tx.Bucket(bucketTransactionGroups), fn)
+}
+
+// transactionGroupID returns the id of a group with the given ordered
+// transactions.
+func transactionGroupID(txns []types.Transaction) modules.TransactionGroupID {
+	ids := make([]types.TransactionID, len(txns))
+	for i, txn := range txns {
+		ids[i] = txn.ID()
+	}
+	return modules.TransactionGroupID(crypto.HashObject(ids))
+}
+
+// transactionGroupConfirmation returns the height of the block which confirmed
+// the last transaction of a group. The second return value is false if the
+// group isn't fully confirmed.
+func transactionGroupConfirmation(group modules.TransactionGroup) (types.BlockHeight, bool) {
+	var height types.BlockHeight
+	for _, h := range group.ConfirmationHeights {
+		if h == 0 {
+			return 0, false
+		}
+		if h > height {
+			height = h
+		}
+	}
+	return height, true
+}
+
+// orderTransactionGroup orders the transactions of a group so that every
+// transaction comes after the transactions which create the outputs and file
+// contracts it spends. Transactions which don't depend on each other keep
+// their relative order.
+func orderTransactionGroup(txns []types.Transaction) ([]types.Transaction, error) {
+	if len(txns) == 0 {
+		return nil, errEmptyTransactionGroup
+	}
+
+	// Map the objects created within the group to the index of the
+	// transaction which creates them.
+	ids := make(map[types.TransactionID]struct{})
+	created := make(map[crypto.Hash]int)
+	for i, txn := range txns {
+		id := txn.ID()
+		if _, exists := ids[id]; exists {
+			return nil, errDuplicateGroupTransaction
+		}
+		ids[id] = struct{}{}
+		for j := range txn.SiacoinOutputs {
+			created[crypto.Hash(txn.SiacoinOutputID(uint64(j)))] = i
+		}
+		for j := range txn.SiafundOutputs {
+			created[crypto.Hash(txn.SiafundOutputID(uint64(j)))] = i
+		}
+		for j := range txn.FileContracts {
+			created[crypto.Hash(txn.FileContractID(uint64(j)))] = i
+		}
+	}
+
+	// Count the parents of every transaction within the group. Parents
+	// outside of the group have to be confirmed or in the transaction pool
+	// already.
+	parents := make([]int, len(txns))
+	children := make([][]int, len(txns))
+	addParent := func(child int, id crypto.Hash) {
+		if parent, exists := created[id]; exists {
+			parents[child]++
+			children[parent] = append(children[parent], child)
+		}
+	}
+	for i, txn := range txns {
+		for _, sci := range txn.SiacoinInputs {
+			addParent(i, crypto.Hash(sci.ParentID))
+		}
+		for _, sfi := range txn.SiafundInputs {
+			addParent(i, crypto.Hash(sfi.ParentID))
+		}
+		for _, fcr := range txn.FileContractRevisions {
+			addParent(i, crypto.Hash(fcr.ParentID))
+		}
+		for _, sp := range txn.StorageProofs {
+			addParent(i, crypto.Hash(sp.ParentID))
+		}
+	}
+
+	// Repeatedly place the first transaction whose parents have all been
+	// placed. Since ids are hashes of the transactions a cycle would require a
+	// hash collision, but a cycle must not make the loop spin forever.
+	ordered := make([]types.Transaction, 0, len(txns))
+	placed := make([]bool, len(txns))
+	for len(ordered) < len(txns) {
+		next := -1
+		for i := range txns {
+			if !placed[i] && parents[i] == 0 {
+				next = i
+				break
+			}
+		}
+		if next == -1 {
+			return nil, errTransactionGroupCycle
+		}
+		placed[next] = true
+		ordered = append(ordered, txns[next])
+		for _, child := range children[next] {
+			parents[child]--
+		}
+	}
+	return ordered, nil
+}
+
+// updateTransactionGroups records the confirmation of the transactions of
+// tracked groups in the applied and reverted blocks of a consensus change.
+// Groups which have been confirmed for transactionGroupPruneDepth blocks are
+// no longer tracked.
+func (w *Wallet) updateTransactionGroups(tx *bolt.Tx, cc modules.ConsensusChange) error {
+	var groups []modules.TransactionGroup
+	err := dbForEachTransactionGroup(tx, func(_ modules.TransactionGroupID, group modules.TransactionGroup) {
+		groups = append(groups, group)
+	})
+	if err != nil {
+		return errors.AddContext(err, "failed to get transaction groups")
+	}
+	if len(groups) == 0 {
+		return nil
+	}
+
+	// Index the transactions of all groups. A transaction might be part of
+	// multiple groups.
+	type groupTxn struct {
+		group, txn int
+	}
+	index := make(map[types.TransactionID][]groupTxn)
+	for i, group := range groups {
+		for j, txn := range group.Transactions {
+			index[txn.ID()] = append(index[txn.ID()], groupTxn{i, j})
+		}
+	}
+	changed := make(map[int]struct{})
+	setHeight := func(txn types.Transaction, height types.BlockHeight) {
+		for _, gt := range index[txn.ID()] {
+			groups[gt.group].ConfirmationHeights[gt.txn] = height
+			changed[gt.group] = struct{}{}
+		}
+	}
+	for _, block := range cc.RevertedBlocks {
+		for _, txn := range block.Transactions {
+			setHeight(txn, 0)
+		}
+	}
+	consensusHeight := cc.InitialHeight()
+	for _, block := range cc.AppliedBlocks {
+		if block.ID() != types.GenesisID {
+			consensusHeight++
+		}
+		for _, txn := range block.Transactions {
+			setHeight(txn, consensusHeight)
+		}
+	}
+
+	for i, group := range groups {
+		height, confirmed := transactionGroupConfirmation(group)
+		if confirmed && cc.BlockHeight >= height+transactionGroupPruneDepth {
+			if err := dbDeleteTransactionGroup(tx, group.ID); err != nil {
+				return errors.AddContext(err, "failed to delete transaction group")
+			}
+			continue
+		}
+		if _, ok := changed[i]; !ok {
+			continue
+		}
+		if confirmed && !group.Confirmed {
+			w.log.Println("Transaction group", group.ID, "was confirmed at height", height)
+		}
+		group.Confirmed = confirmed
+		if err := dbPutTransactionGroup(tx, group); err != nil {
+			return errors.AddContext(err, "failed to update transaction group")
+		}
+	}
+	return nil
+}
+
+// BroadcastTransactionGroup orders a set of interdependent transactions by
+// their dependencies, submits them to the transaction pool as a single set and
+// keeps broadcasting them until all of them are confirmed or the wallet gives
+// up on them. Broadcasting a group which is already tracked returns the tracked
+// group. Sets refused by the transaction pool are returned as a
+// modules.BroadcastFailure.
+func (w *Wallet) BroadcastTransactionGroup(txns []types.Transaction, params modules.TransactionGroupParams) (modules.TransactionGroup, error) {
+	if err := w.tg.Add(); err != nil {
+		return modules.TransactionGroup{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	ordered, err := orderTransactionGroup(txns)
+	if err != nil {
+		return modules.TransactionGroup{}, err
+	}
+	group := modules.TransactionGroup{
+		ID:                  transactionGroupID(ordered),
+		Transactions:        ordered,
+		ConfirmationHeights: make([]types.BlockHeight, len(ordered)),
+		GiveUpHeight:        params.GiveUpHeight,
+		Droppable:           params.Droppable,
+	}
+
+	// Track the group before submitting it, so that its confirmation isn't
+	// missed if it is mined right away.
+	w.mu.Lock()
+	existing, err := dbGetTransactionGroup(w.dbTx, group.ID)
+	if err == nil {
+		w.mu.Unlock()
+		return existing, nil
+	} else if !errors.Contains(err, errNoKey) {
+		w.mu.Unlock()
+		return modules.TransactionGroup{}, errors.AddContext(err, "failed to look up transaction group")
+	}
+	group.LastBroadcast, err = dbGetConsensusHeight(w.dbTx)
+	if err == nil {
+		err = dbPutTransactionGroup(w.dbTx, group)
+	}
+	w.mu.Unlock()
+	if err != nil {
+		return modules.TransactionGroup{}, errors.AddContext(err, "failed to store transaction group")
+	}
+
+	// The tpool can't be accessed while holding the wallet's lock, since it
+	// notifies the wallet about the new transactions.
+	err = w.tpool.AcceptTransactionSet(ordered)
+	if err != nil && !errors.Contains(err, modules.ErrDuplicateTransactionSet) {
+		failure := w.managedRecordBroadcastFailure(ordered, err)
+		w.mu.Lock()
+		err = dbDeleteTransactionGroup(w.dbTx, group.ID)
+		w.mu.Unlock()
+		if err != nil {
+			return modules.TransactionGroup{}, errors.Compose(failure, errors.AddContext(err, "failed to delete refused transaction group"))
+		}
+		return modules.TransactionGroup{}, failure
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	group, err = dbGetTransactionGroup(w.dbTx, group.ID)
+	if err != nil {
+		return modules.TransactionGroup{}, errors.AddContext(err, "failed to get transaction group")
+	}
+	group.Broadcasts++
+	err = dbPutTransactionGroup(w.dbTx, group)
+	err = errors.Compose(err, w.syncDB())
+	if err != nil {
+		return modules.TransactionGroup{}, errors.AddContext(err, "failed to store transaction group")
+	}
+	w.log.Println("Broadcast transaction group", group.ID, "with", len(ordered), "transactions")
+	return group, nil
+}
+
+// TransactionGroup returns a transaction group tracked by the wallet.
+func (w *Wallet) TransactionGroup(id modules.TransactionGroupID) (modules.TransactionGroup, error) {
+	if err := w.tg.Add(); err != nil {
+		return modules.TransactionGroup{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	group, err := dbGetTransactionGroup(w.dbTx, id)
+	if errors.Contains(err, errNoKey) {
+		return modules.TransactionGroup{}, errUnknownTransactionGroup
+	}
+	return group, err
+}
+
+// TransactionGroups returns all transaction groups tracked by the wallet.
+func (w *Wallet) TransactionGroups() ([]modules.TransactionGroup, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var groups []modules.TransactionGroup
+	err := dbForEachTransactionGroup(w.dbTx, func(_ modules.TransactionGroupID, group modules.TransactionGroup) {
+		groups = append(groups, group)
+	})
+	return groups, err
+}
+
+// managedBroadcastNFTTransactions submits the transaction set of an NFT
+// operation to the transaction pool. The set is tracked as a transaction group,
+// so that it is broadcast again until it is confirmed or the wallet gives up on
+// it after nftRebroadcastBlocks blocks.
+func (w *Wallet) managedBroadcastNFTTransactions(txnSet []types.Transaction) error {
+	w.mu.RLock()
+	blocks := w.nftRebroadcastBlocks
+	w.mu.RUnlock()
+	var params modules.TransactionGroupParams
+	if blocks != 0 {
+		params.GiveUpHeight = w.cs.Height() + blocks
+	}
+	_, err := w.BroadcastTransactionGroup(txnSet, params)
+	recordNFTOperations(txnSet, err)
+	return err
+}
+
+// managedUpdateTransactionGroup applies fn to the tracked transaction group
+// with the given id. If fn returns false, the group is no longer tracked.
+// Groups which were confirmed or pruned in the meantime are ignored.
+func (w *Wallet) managedUpdateTransactionGroup(id modules.TransactionGroupID, fn func(*modules.TransactionGroup) bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	group, err := dbGetTransactionGroup(w.dbTx, id)
+	if err != nil || group.Confirmed {
+		return
+	}
+	if fn(&group) {
+		err = dbPutTransactionGroup(w.dbTx, group)
+	} else {
+		err = dbDeleteTransactionGroup(w.dbTx, id)
+	}
+	if err != nil {
+		w.log.Println("WARN: failed to update transaction group:", err)
+	}
+}
+
+// managedBroadcastTransactionGroups broadcasts the unconfirmed transactions of
+// every tracked group again. Groups which dropped out of the transaction pool
+// are submitted to the transaction pool again, the others are announced to the
+// transaction pool's peers again. Groups which reached their give up height
+// are abandoned, or dropped if they are droppable.
+func (w *Wallet) managedBroadcastTransactionGroups() {
+	w.mu.Lock()
+	var groups []modules.TransactionGroup
+	err := dbForEachTransactionGroup(w.dbTx, func(_ modules.TransactionGroupID, group modules.TransactionGroup) {
+		if !group.Confirmed && !group.Abandoned {
+			groups = append(groups, group)
+		}
+	})
+	w.mu.Unlock()
+	if err != nil {
+		w.log.Println("WARN: failed to get transaction groups for broadcasting:", err)
+		return
+	}
+
+	height := w.cs.Height()
+	for _, group := range groups {
+		if group.GiveUpHeight != 0 && height >= group.GiveUpHeight {
+			w.log.Printf("Giving up on unconfirmed transaction group %v at height %v", group.ID, height)
+			w.managedUpdateTransactionGroup(group.ID, func(g *modules.TransactionGroup) bool {
+				g.Abandoned = true
+				return !g.Droppable
+			})
+			continue
+		}
+
+		// The confirmed transactions of the group are parents of the
+		// remaining ones, so only the unconfirmed ones are broadcast.
+		var unconfirmed []types.Transaction
+		var missing bool
+		for i, txn := range group.Transactions {
+			if group.ConfirmationHeights[i] != 0 {
+				continue
+			}
+			unconfirmed = append(unconfirmed, txn)
+			if _, _, exists := w.tpool.Transaction(txn.ID()); !exists {
+				missing = true
+			}
+		}
+		var err error
+		if missing {
+			err = w.tpool.AcceptTransactionSet(unconfirmed)
+			if errors.Contains(err, modules.ErrDuplicateTransactionSet) {
+				err = nil
+			}
+		} else {
+			w.tpool.Broadcast(unconfirmed)
+		}
+		if err != nil && group.Droppable {
+			w.log.Printf("Dropping transaction group %v after failing to broadcast it: %v", group.ID, err)
+		} else if err != nil {
+			w.log.Printf("WARN: failed to broadcast transaction group %v again: %v", group.ID, err)
+		}
+		w.managedUpdateTransactionGroup(group.ID, func(g *modules.TransactionGroup) bool {
+			g.LastBroadcast = height
+			g.LastError = ""
+			if err != nil {
+				g.LastError = err.Error()
+				return !g.Droppable
+			}
+			g.Broadcasts++
+			return true
+		})
+	}
+}
+
+// threadedBroadcastTransactionGroups periodically broadcasts the transaction
+// groups which dropped out of the transaction pool before being confirmed.
+func (w *Wallet) threadedBroadcastTransactionGroups() {
+	if err := w.tg.Add(); err != nil {
+		return
+	}
+	defer w.tg.Done()
+
+	for {
+		select {
+		case <-time.After(transactionGroupBroadcastInterval):
+		case <-w.tg.StopChan():
+			return
+		}
+		w.managedBroadcastTransactionGroups()
+	}
+}
@@ -0,0 +1,454 @@
+package wallet
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestOrderTransactionGroup tests that the transactions of a group are ordered
+// by their dependencies.
+func TestOrderTransactionGroup(t *testing.T) {
+	// a creates an output and a file contract, b spends the output, c revises
+	// the file contract and spends the output of b. d is unrelated.
+	a := types.Transaction{
+		SiacoinOutputs: []types.SiacoinOutput{{Value: types.NewCurrency64(1)}},
+		FileContracts:  []types.FileContract{{}},
+	}
+	b := types.Transaction{
+		SiacoinInputs:  []types.SiacoinInput{{ParentID: a.SiacoinOutputID(0)}},
+		SiacoinOutputs: []types.SiacoinOutput{{Value: types.NewCurrency64(1)}},
+	}
+	c := types.Transaction{
+		SiacoinInputs:         []types.SiacoinInput{{ParentID: b.SiacoinOutputID(0)}},
+		FileContractRevisions: []types.FileContractRevision{{ParentID: a.FileContractID(0)}},
+	}
+	d := types.Transaction{
+		ArbitraryData: [][]byte{{1}},
+	}
+
+	ordered, err := orderTransactionGroup([]types.Transaction{c, d, b, a})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []types.Transaction{d, a, b, c}
+	for i := range expected {
+		if ordered[i].ID() != expected[i].ID() {
+			t.Fatalf("transaction %v is out of order", i)
+		}
+	}
+
+	// Ordered groups keep their order.
+	ordered, err = orderTransactionGroup([]types.Transaction{a, b, c, d})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = []types.Transaction{a, b, c, d}
+	for i := range expected {
+		if ordered[i].ID() != expected[i].ID() {
+			t.Fatalf("transaction %v is out of order", i)
+		}
+	}
+
+	// Empty groups and duplicates are rejected.
+	if _, err := orderTransactionGroup(nil); !errors.Contains(err, errEmptyTransactionGroup) {
+		t.Fatal("expected errEmptyTransactionGroup but got", err)
+	}
+	if _, err := orderTransactionGroup([]types.Transaction{a, b, a}); !errors.Contains(err, errDuplicateGroupTransaction) {
+		t.Fatal("expected errDuplicateGroupTransaction but got", err)
+	}
+}
+
+// TestBroadcastTransactionGroup tests that a group of interdependent
+// transactions is broadcast in the right order, broadcast again after dropping
+// out of the transaction pool and tracked until it is confirmed.
+func TestBroadcastTransactionGroup(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Create a transaction which sends coins to an address anyone can spend
+	// from and a transaction which spends them again.
+	value := types.SiacoinPrecision.Mul64(100)
+	fee := types.SiacoinPrecision
+	b, err := wt.wallet.StartTransaction()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.FundSiacoins(value); err != nil {
+		t.Fatal(err)
+	}
+	b.AddSiacoinOutput(types.SiacoinOutput{
+		Value:      value,
+		UnlockHash: types.UnlockConditions{}.UnlockHash(),
+	})
+	set, err := b.Sign(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parent := set[len(set)-1]
+	var index uint64
+	for i, sco := range parent.SiacoinOutputs {
+		if sco.UnlockHash == (types.UnlockConditions{}).UnlockHash() {
+			index = uint64(i)
+		}
+	}
+	child := types.Transaction{
+		SiacoinInputs: []types.SiacoinInput{{ParentID: parent.SiacoinOutputID(index)}},
+		SiacoinOutputs: []types.SiacoinOutput{{
+			Value:      value.Sub(fee),
+			UnlockHash: types.UnlockHash{1},
+		}},
+		MinerFees: []types.Currency{fee},
+	}
+
+	// Submitting the child before its parent fails.
+	if err := wt.tpool.AcceptTransactionSet([]types.Transaction{child, parent}); err == nil {
+		t.Fatal("expected unordered set to be rejected")
+	}
+
+	// Broadcast the group in reverse order.
+	txns := []types.Transaction{child}
+	for i := len(set) - 1; i >= 0; i-- {
+		txns = append(txns, set[i])
+	}
+	group, err := wt.wallet.BroadcastTransactionGroup(txns, modules.TransactionGroupParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(group.Transactions) != len(txns) || group.Transactions[len(txns)-1].ID() != child.ID() {
+		t.Fatal("child should be the last transaction of the group")
+	}
+	if group.Confirmed || group.Broadcasts != 1 {
+		t.Fatal("wrong group status", group.Confirmed, group.Broadcasts)
+	}
+	if _, _, exists := wt.tpool.Transaction(child.ID()); !exists {
+		t.Fatal("child should be in the tpool")
+	}
+
+	// Broadcasting the group again returns the tracked group.
+	group2, err := wt.wallet.BroadcastTransactionGroup(txns, modules.TransactionGroupParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if group2.ID != group.ID || group2.Broadcasts == 0 {
+		t.Fatal("expected the tracked group")
+	}
+
+	// Purge the tpool. The group should be broadcast again.
+	wt.tpool.PurgeTransactionPool()
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		current, err := wt.wallet.TransactionGroup(group.ID)
+		if err != nil {
+			return err
+		}
+		if _, _, exists := wt.tpool.Transaction(child.ID()); !exists {
+			return errors.New("child is not in the tpool")
+		}
+		if current.Broadcasts <= group.Broadcasts {
+			return errors.New("group was not broadcast again")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Mine a block. The group should be confirmed.
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	group, err = wt.wallet.TransactionGroup(group.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	height := wt.cs.Height()
+	if !group.Confirmed {
+		t.Fatal("group should be confirmed")
+	}
+	for _, h := range group.ConfirmationHeights {
+		if h != height {
+			t.Fatal("wrong confirmation height", h, height)
+		}
+	}
+	groups, err := wt.wallet.TransactionGroups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 1 || groups[0].ID != group.ID {
+		t.Fatal("expected a single group", len(groups))
+	}
+
+	// Once the group is buried deep enough it is no longer tracked.
+	for i := types.BlockHeight(0); i < transactionGroupPruneDepth; i++ {
+		if _, err := wt.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := wt.wallet.TransactionGroup(group.ID); !errors.Contains(err, errUnknownTransactionGroup) {
+		t.Fatal("expected errUnknownTransactionGroup but got", err)
+	}
+}
+
+// TestBroadcastFailures tests that the wallet explains why the transaction
+// pool refused a transaction group.
+func TestBroadcastFailures(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Create a transaction which sends coins to an address anyone can spend
+	// from and a transaction which spends more than that.
+	value := types.SiacoinPrecision.Mul64(100)
+	fee := types.SiacoinPrecision
+	b, err := wt.wallet.StartTransaction()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.FundSiacoins(value); err != nil {
+		t.Fatal(err)
+	}
+	b.AddSiacoinOutput(types.SiacoinOutput{
+		Value:      value,
+		UnlockHash: types.UnlockConditions{}.UnlockHash(),
+	})
+	set, err := b.Sign(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parent := set[len(set)-1]
+	var index uint64
+	for i, sco := range parent.SiacoinOutputs {
+		if sco.UnlockHash == (types.UnlockConditions{}).UnlockHash() {
+			index = uint64(i)
+		}
+	}
+	child := types.Transaction{
+		SiacoinInputs: []types.SiacoinInput{{ParentID: parent.SiacoinOutputID(index)}},
+		SiacoinOutputs: []types.SiacoinOutput{{
+			Value:      value,
+			UnlockHash: types.UnlockHash{1},
+		}},
+		MinerFees: []types.Currency{fee},
+	}
+
+	// No failures should be recorded yet.
+	if failures := wt.wallet.LastBroadcastFailures(); len(failures) != 0 {
+		t.Fatal("expected no failures but got", len(failures))
+	}
+
+	// The group should be refused because of the child.
+	txns := append(set, child)
+	_, err = wt.wallet.BroadcastTransactionGroup(txns, modules.TransactionGroupParams{})
+	var failure modules.BroadcastFailure
+	if err == nil {
+		t.Fatal("expected group to be refused")
+	} else if bf, ok := err.(modules.BroadcastFailure); !ok {
+		t.Fatal("expected a BroadcastFailure but got", err)
+	} else {
+		failure = bf
+	}
+	if failure.FailedIndex != len(txns)-1 || failure.FailedTransactionID != child.ID() {
+		t.Fatal("wrong failed transaction", failure.FailedIndex, failure.FailedTransactionID)
+	}
+	if failure.ValidityError == "" || !modules.IsConsensusConflict(errors.New(failure.PoolError)) {
+		t.Fatal("missing errors", failure.ValidityError, failure.PoolError)
+	}
+	if len(failure.TransactionIDs) != len(txns) || failure.Height != wt.cs.Height() {
+		t.Fatal("wrong set", len(failure.TransactionIDs), failure.Height)
+	}
+	if failure.SetFee.IsZero() || failure.SetSize == 0 || failure.MinimumFee.IsZero() {
+		t.Fatal("missing fees", failure.SetFee, failure.SetSize, failure.MinimumFee)
+	}
+
+	// The failure should be recorded and the group not tracked.
+	failures := wt.wallet.LastBroadcastFailures()
+	if len(failures) != 1 || failures[0].FailedTransactionID != child.ID() {
+		t.Fatal("failure wasn't recorded", failures)
+	}
+	groups, err := wt.wallet.TransactionGroups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 0 {
+		t.Fatal("refused group shouldn't be tracked")
+	}
+}
+
+// TestTransactionGroupGiveUp tests that the wallet gives up on transaction
+// groups at their give up height.
+func TestTransactionGroupGiveUp(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Create two transactions and broadcast them as groups which reached
+	// their give up height already, one of them droppable. A block is mined
+	// before each transaction, so that the wallet can fund both.
+	var sets [][]types.Transaction
+	for i := 0; i < 2; i++ {
+		if _, err := wt.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+		b, err := wt.wallet.StartTransaction()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := b.FundSiacoins(types.SiacoinPrecision); err != nil {
+			t.Fatal(err)
+		}
+		b.AddMinerFee(types.SiacoinPrecision)
+		set, err := b.Sign(true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sets = append(sets, set)
+	}
+	height := wt.cs.Height()
+	kept, err := wt.wallet.BroadcastTransactionGroup(sets[0], modules.TransactionGroupParams{GiveUpHeight: height})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dropped, err := wt.wallet.BroadcastTransactionGroup(sets[1], modules.TransactionGroupParams{GiveUpHeight: height, Droppable: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first group should be abandoned and the second one dropped.
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		group, err := wt.wallet.TransactionGroup(kept.ID)
+		if err != nil {
+			return err
+		}
+		if !group.Abandoned {
+			return errors.New("group wasn't abandoned")
+		}
+		if _, err := wt.wallet.TransactionGroup(dropped.ID); !errors.Contains(err, errUnknownTransactionGroup) {
+			return errors.New("group wasn't dropped")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Abandoned groups are still updated when they are confirmed.
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	group, err := wt.wallet.TransactionGroup(kept.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !group.Confirmed {
+		t.Fatal("abandoned group should be confirmed")
+	}
+}
+
+// TestNFTRebroadcast tests that the transactions of NFT operations are
+// broadcast again after dropping out of the transaction pool.
+func TestNFTRebroadcast(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Change the number of blocks NFT transactions are broadcast for.
+	settings, err := wt.wallet.Settings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings.NFTRebroadcastBlocks != nftRebroadcastBlocks {
+		t.Fatal("wrong default", settings.NFTRebroadcastBlocks)
+	}
+	settings.NFTRebroadcastBlocks = 5
+	if err := wt.wallet.SetSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+
+	// Mint an NFT. Its transactions should be tracked as a group.
+	uc, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	nft := types.NftCustody{FileMerkleRoot: crypto.MerkleRoot(fastrand.Bytes(crypto.SegmentSize))}
+	txns, err := wt.wallet.MintNFT(nft, uc.UnlockHash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	groups, err := wt.wallet.TransactionGroups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 1 || groups[0].ID != transactionGroupID(txns) {
+		t.Fatal("mint should be tracked as a transaction group", len(groups))
+	}
+	if groups[0].GiveUpHeight != wt.cs.Height()+5 || groups[0].Droppable {
+		t.Fatal("wrong group params", groups[0].GiveUpHeight, groups[0].Droppable)
+	}
+
+	// Purge the tpool, the mint should be broadcast again and confirmed.
+	mint := txns[len(txns)-1]
+	wt.tpool.PurgeTransactionPool()
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		if _, _, exists := wt.tpool.Transaction(mint.ID()); !exists {
+			return errors.New("mint is not in the tpool")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.cs.ViewNFTCustody(nft); err != nil {
+		t.Fatal("mint wasn't confirmed", err)
+	}
+}
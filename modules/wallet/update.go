@@ -505,10 +505,17 @@ func (w *Wallet) ProcessConsensusChange(cc modules.ConsensusChange) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
-	if needRescan, err := w.updateLookahead(w.dbTx, cc); err != nil {
+	needRescan, err := w.updateLookahead(w.dbTx, cc)
+	if err != nil {
 		w.log.Severe("ERROR: failed to update lookahead:", err)
 		w.dbRollback = true
-	} else if needRescan {
+	}
+	needCustodyRescan, err := w.updateNFTCustodyLookahead(cc)
+	if err != nil {
+		w.log.Severe("ERROR: failed to update nft custody lookahead:", err)
+		w.dbRollback = true
+	}
+	if needRescan || needCustodyRescan {
 		go w.threadedResetSubscriptions()
 	}
 	if err := w.updateConfirmedSet(w.dbTx, cc); err != nil {
@@ -523,6 +530,26 @@ func (w *Wallet) ProcessConsensusChange(cc modules.ConsensusChange) {
 		w.log.Severe("ERROR: failed to apply consensus change:", err)
 		w.dbRollback = true
 	}
+	// The disrupts simulate a crash while the NFT deposits are updated. The
+	// consensus change is left half processed and its ID isn't stored.
+	if w.deps.Disrupt("InterruptNFTDepositsRevert") {
+		return
+	}
+	if err := w.revertNFTDeposits(w.dbTx, cc.RevertedBlocks); err != nil {
+		w.log.Severe("ERROR: failed to revert nft deposits:", err)
+		w.dbRollback = true
+	}
+	if w.deps.Disrupt("InterruptNFTDepositsApply") {
+		return
+	}
+	if err := w.applyNFTDeposits(w.dbTx, cc); err != nil {
+		w.log.Severe("ERROR: failed to apply nft deposits:", err)
+		w.dbRollback = true
+	}
+	if err := w.updateTransactionGroups(w.dbTx, cc); err != nil {
+		w.log.Severe("ERROR: failed to update transaction groups:", err)
+		w.dbRollback = true
+	}
 	if err := dbPutConsensusChangeID(w.dbTx, cc.ID); err != nil {
 		w.log.Severe("ERROR: failed to update consensus change ID:", err)
 		w.dbRollback = true
@@ -532,8 +559,14 @@ func (w *Wallet) ProcessConsensusChange(cc modules.ConsensusChange) {
 		w.dbRollback = true
 	}
 
+	w.notifyNFTDepositCallbacks(w.dbTx)
+	if cc.Synced {
+		w.notifyNFTActivityCallbacks(cc)
+	}
+
 	if cc.Synced {
 		go w.threadedDefragWallet()
+		go w.threadedSendScheduledNFTTransfers()
 	}
 }
 
@@ -80,6 +80,12 @@ type Wallet struct {
 	lookahead    map[types.UnlockHash]uint64
 	watchedAddrs map[types.UnlockHash]struct{}
 
+	// nftCustodyAddrs maps the addresses of the NFT custody account to their
+	// index. Their keys are also contained in keys. nftCustodyLookahead is the
+	// lookahead of the NFT custody account.
+	nftCustodyAddrs     map[types.UnlockHash]uint64
+	nftCustodyLookahead map[types.UnlockHash]uint64
+
 	// unconfirmedProcessedTransactions tracks unconfirmed transactions.
 	//
 	// TODO: Replace this field with a linked list. Currently when a new
@@ -116,6 +122,37 @@ type Wallet struct {
 	// defragDisabled determines if the wallet is set to defrag outputs once it
 	// reaches a certain threshold
 	defragDisabled bool
+
+	// nftRebroadcastBlocks is the number of blocks for which unconfirmed NFT
+	// transactions are broadcast again before the wallet gives up on them.
+	nftRebroadcastBlocks types.BlockHeight
+
+	// nftScheduleLock prevents scheduled NFT transfers from being sent by
+	// multiple threads at once.
+	nftScheduleLock siasync.TryMutex
+
+	// broadcastFailures are the most recent transaction sets which the
+	// transaction pool refused, newest first.
+	broadcastFailures []modules.BroadcastFailure
+
+	// nftDepositCallbacks are the callbacks that are notified about confirmed
+	// NFT deposits.
+	nftDepositCallbacks []*nftDepositCallback
+
+	// nftActivityCallbacks are the callbacks that are notified about
+	// confirmed NFT mints and transfers involving the wallet.
+	nftActivityCallbacks []func(modules.NFTActivity)
+
+	// nftProvenanceScans caches the scans of the blockchain for the
+	// provenance of the NFTs whose provenance was exported.
+	nftProvenanceScans map[types.NftID]nftProvenanceScan
+
+	// namedWallets are the open named wallets managed by the wallet. Named
+	// wallets are marked with staticNamed and can't manage named wallets
+	// themselves.
+	namedWallets   map[string]*Wallet
+	namedWalletsMu sync.Mutex
+	staticNamed    bool
 }
 
 // Height return the internal processed consensus height of the wallet
@@ -206,8 +243,17 @@ func NewCustomWallet(cs modules.ConsensusSet, tpool modules.TransactionPool, per
 		unusedKeys:   make(map[types.UnlockHash]types.UnlockConditions),
 		watchedAddrs: make(map[types.UnlockHash]struct{}),
 
+		nftCustodyAddrs:     make(map[types.UnlockHash]uint64),
+		nftCustodyLookahead: make(map[types.UnlockHash]uint64),
+
 		unconfirmedSets: make(map[modules.TransactionSetID][]types.TransactionID),
 
+		nftProvenanceScans: make(map[types.NftID]nftProvenanceScan),
+
+		namedWallets: make(map[string]*Wallet),
+
+		nftRebroadcastBlocks: nftRebroadcastBlocks,
+
 		persistDir: persistDir,
 
 		deps: deps,
@@ -222,6 +268,7 @@ func NewCustomWallet(cs modules.ConsensusSet, tpool modules.TransactionPool, per
 // Close terminates all ongoing processes involving the wallet, enabling
 // garbage collection.
 func (w *Wallet) Close() error {
+	namedErr := w.closeNamedWallets()
 	w.cs.Unsubscribe(w)
 	w.tpool.Unsubscribe(w)
 	var lockErr error
@@ -232,7 +279,7 @@ func (w *Wallet) Close() error {
 	if w.managedUnlocked() {
 		lockErr = w.managedLock()
 	}
-	return errors.Compose(lockErr, w.tg.Stop())
+	return errors.Compose(namedErr, lockErr, w.tg.Stop())
 }
 
 // AllAddresses returns all addresses that the wallet is able to spend from,
@@ -277,8 +324,11 @@ func (w *Wallet) Settings() (modules.WalletSettings, error) {
 		return modules.WalletSettings{}, modules.ErrWalletShutdown
 	}
 	defer w.tg.Done()
+	w.mu.RLock()
+	defer w.mu.RUnlock()
 	return modules.WalletSettings{
-		NoDefrag: w.defragDisabled,
+		NoDefrag:             w.defragDisabled,
+		NFTRebroadcastBlocks: w.nftRebroadcastBlocks,
 	}, nil
 }
 
@@ -291,6 +341,7 @@ func (w *Wallet) SetSettings(s modules.WalletSettings) error {
 
 	w.mu.Lock()
 	w.defragDisabled = s.NoDefrag
+	w.nftRebroadcastBlocks = s.NFTRebroadcastBlocks
 	w.mu.Unlock()
 	return nil
 }
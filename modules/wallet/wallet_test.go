@@ -46,6 +46,9 @@ func createWalletTester(name string, deps modules.Dependencies) (*walletTester,
 	if err != nil {
 		return nil, err
 	}
+	if err := waitForTpoolSubscription(tp); err != nil {
+		return nil, err
+	}
 	w, err := NewCustomWallet(cs, tp, filepath.Join(testdir, modules.WalletDir), deps)
 	if err != nil {
 		return nil, err
@@ -105,6 +108,9 @@ func createBlankWalletTester(name string) (*walletTester, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := waitForTpoolSubscription(tp); err != nil {
+		return nil, err
+	}
 	w, err := New(cs, tp, filepath.Join(testdir, modules.WalletDir))
 	if err != nil {
 		return nil, err
@@ -127,6 +133,23 @@ func createBlankWalletTester(name string) (*walletTester, error) {
 	return wt, nil
 }
 
+// waitForTpoolSubscription waits until the transaction pool has subscribed to
+// the consensus set. The transaction pool subscribes asynchronously, and a
+// pool which hasn't seen the mined blocks yet rejects transactions which spend
+// their outputs.
+func waitForTpoolSubscription(tp modules.TransactionPool) error {
+	genesisTxnID := types.GenesisBlock.Transactions[0].ID()
+	return build.Retry(100, 10*time.Millisecond, func() error {
+		confirmed, err := tp.TransactionConfirmed(genesisTxnID)
+		if err != nil {
+			return err
+		} else if !confirmed {
+			return errors.New("transaction pool hasn't processed the genesis block yet")
+		}
+		return nil
+	})
+}
+
 // closeWt closes all of the modules in the wallet tester.
 func (wt *walletTester) closeWt() error {
 	errs := []error{
@@ -118,6 +118,7 @@ type (
 		wallet              modules.Wallet
 		staticConfigModules configModules
 		modulesSet          bool
+		readOnly            bool
 
 		downloadMu sync.Mutex
 		downloads  map[modules.DownloadID]func()
@@ -129,7 +130,8 @@ type (
 		Shutdown          func() error
 		siadConfig        *modules.SiadConfig
 
-		staticStartTime time.Time
+		staticRateLimiter *apiRateLimiter
+		staticStartTime   time.Time
 
 		staticDeps modules.Dependencies
 	}
@@ -221,6 +223,9 @@ func NewCustom(cfg *modules.SiadConfig, requiredUserAgent string, requiredPasswo
 		staticDeps:      deps,
 		staticStartTime: time.Now(),
 	}
+	if cfg != nil {
+		api.staticRateLimiter = newAPIRateLimiter(cfg)
+	}
 
 	// Register API handlers
 	api.buildHTTPRoutes()
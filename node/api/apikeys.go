@@ -0,0 +1,216 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+
+	"go.sia.tech/siad/modules"
+)
+
+type (
+	// DaemonAPIKeysGet contains the API keys of the daemon.
+	DaemonAPIKeysGet struct {
+		APIKeys []modules.APIKey `json:"apikeys"`
+	}
+
+	// DaemonAPIKeyPOST contains a newly created API key.
+	DaemonAPIKeyPOST struct {
+		modules.APIKey
+	}
+
+	// apiKeyContextKey is the context key of the API key a request
+	// authenticated with.
+	apiKeyContextKey struct{}
+
+	// apiKeyRoute is an endpoint which can be accessed with an API key. If
+	// extraScope is set, the key needs both scopes, e.g. for endpoints which
+	// spend siacoins and move NFTs in the same transaction.
+	apiKeyRoute struct {
+		method     string
+		path       string
+		prefix     bool
+		scope      modules.APIKeyScope
+		extraScope modules.APIKeyScope
+	}
+)
+
+// apiKeyRoutes are the password protected endpoints which an API key with the
+// right scope may access. All other protected endpoints, e.g. the ones
+// revealing the wallet seed or managing the API keys themselves, require the
+// API password. The wallet endpoints are listed with their "/wallet" prefix
+// and also cover the "/wallets/:walletname" routes.
+var apiKeyRoutes = []apiKeyRoute{
+	// read-only
+	{method: http.MethodGet, path: "/metrics", scope: modules.APIKeyScopeReadOnly},
+	{method: http.MethodGet, path: "/renter/backups", scope: modules.APIKeyScopeReadOnly},
+	{method: http.MethodGet, path: "/wallets", scope: modules.APIKeyScopeReadOnly},
+	{method: http.MethodGet, path: "/wallet/nft/audit", scope: modules.APIKeyScopeReadOnly},
+	{method: http.MethodGet, path: "/wallet/nft/deposits", scope: modules.APIKeyScopeReadOnly},
+	{method: http.MethodGet, path: "/wallet/nft/provenance", scope: modules.APIKeyScopeReadOnly},
+	{method: http.MethodGet, path: "/wallet/nft/scan", scope: modules.APIKeyScopeReadOnly},
+	{method: http.MethodGet, path: "/wallet/nft/schedule", scope: modules.APIKeyScopeReadOnly},
+	{method: http.MethodGet, path: "/wallet/nft/templates", scope: modules.APIKeyScopeReadOnly},
+	{method: http.MethodGet, path: "/wallet/nft/unlockable", scope: modules.APIKeyScopeReadOnly},
+	{method: http.MethodGet, path: "/wallet/reserves", scope: modules.APIKeyScopeReadOnly},
+	{method: http.MethodGet, path: "/wallet/transactiongroup/", prefix: true, scope: modules.APIKeyScopeReadOnly},
+	{method: http.MethodGet, path: "/wallet/transactiongroups", scope: modules.APIKeyScopeReadOnly},
+	{method: http.MethodGet, path: "/wallet/broadcastfailures", scope: modules.APIKeyScopeReadOnly},
+	{method: http.MethodGet, path: "/wallet/unlockconditions/", prefix: true, scope: modules.APIKeyScopeReadOnly},
+	{method: http.MethodGet, path: "/wallet/unspent", scope: modules.APIKeyScopeReadOnly},
+	{method: http.MethodGet, path: "/wallet/watch", scope: modules.APIKeyScopeReadOnly},
+
+	// wallet-spend
+	{method: http.MethodGet, path: "/wallet/address", scope: modules.APIKeyScopeWalletSpend},
+	{method: http.MethodGet, path: "/wallet/nft/address", scope: modules.APIKeyScopeWalletSpend},
+	{method: http.MethodPost, path: "/wallet/nft/deposit/address", scope: modules.APIKeyScopeWalletSpend},
+	{method: http.MethodPost, path: "/wallet/nft/approve", scope: modules.APIKeyScopeWalletSpend},
+	{method: http.MethodPost, path: "/wallet/nft/approve/revoke", scope: modules.APIKeyScopeWalletSpend},
+	{method: http.MethodPost, path: "/wallet/nft/dispute/freeze", scope: modules.APIKeyScopeWalletSpend},
+	{method: http.MethodPost, path: "/wallet/nft/dispute/unfreeze", scope: modules.APIKeyScopeWalletSpend},
+	{method: http.MethodPost, path: "/wallet/nft/governance/sign", scope: modules.APIKeyScopeWalletSpend},
+	{method: http.MethodPost, path: "/wallet/nft/liquidate", scope: modules.APIKeyScopeWalletSpend},
+	{method: http.MethodPost, path: "/wallet/nft/lockup/disburse", scope: modules.APIKeyScopeWalletSpend},
+	{method: http.MethodPost, path: "/wallet/nft/mint", scope: modules.APIKeyScopeWalletSpend},
+	{method: http.MethodPost, path: "/wallet/nft/reclaim", scope: modules.APIKeyScopeWalletSpend},
+	{method: http.MethodPost, path: "/wallet/nft/stake", scope: modules.APIKeyScopeWalletSpend},
+	{method: http.MethodPost, path: "/wallet/nft/templates", scope: modules.APIKeyScopeWalletSpend},
+	{method: http.MethodGet, path: "/wallet/nft/unlockable/handshake", scope: modules.APIKeyScopeWalletSpend},
+	{method: http.MethodPost, path: "/wallet/nft/unlockable/import", scope: modules.APIKeyScopeWalletSpend},
+	{method: http.MethodPost, path: "/wallet/nft/unlockable/mint", scope: modules.APIKeyScopeWalletSpend},
+	{method: http.MethodPost, path: "/wallet/nft/unstake", scope: modules.APIKeyScopeWalletSpend},
+	{method: http.MethodPost, path: "/wallet/siacoins", scope: modules.APIKeyScopeWalletSpend},
+	{method: http.MethodPost, path: "/wallet/siafunds", scope: modules.APIKeyScopeWalletSpend},
+	{method: http.MethodPost, path: "/wallet/sign", scope: modules.APIKeyScopeWalletSpend},
+	{method: http.MethodPost, path: "/wallet/sweep/seed", scope: modules.APIKeyScopeWalletSpend},
+	{method: http.MethodPost, path: "/wallet/transactiongroup", scope: modules.APIKeyScopeWalletSpend},
+
+	// nft-transfer
+	{method: http.MethodPost, path: "/wallet/nft/approve/transfer", scope: modules.APIKeyScopeNFTTransfer},
+	{method: http.MethodPost, path: "/wallet/nft/bridge/lock", scope: modules.APIKeyScopeNFTTransfer},
+	{method: http.MethodPost, path: "/wallet/nft/schedule", scope: modules.APIKeyScopeNFTTransfer},
+	{method: http.MethodPost, path: "/wallet/nft/schedule/cancel", scope: modules.APIKeyScopeNFTTransfer},
+	{method: http.MethodPost, path: "/wallet/nft/sweep", scope: modules.APIKeyScopeNFTTransfer},
+	{method: http.MethodPost, path: "/wallet/nft/transfer", scope: modules.APIKeyScopeNFTTransfer},
+	{method: http.MethodPost, path: "/wallet/nft/unlockable/transfer", scope: modules.APIKeyScopeNFTTransfer},
+	{method: http.MethodPost, path: "/wallet/nft/compose", scope: modules.APIKeyScopeNFTTransfer, extraScope: modules.APIKeyScopeWalletSpend},
+
+	// host-admin
+	{method: http.MethodPost, path: "/host", scope: modules.APIKeyScopeHostAdmin},
+	{method: http.MethodPost, path: "/host/announce", scope: modules.APIKeyScopeHostAdmin},
+	{method: http.MethodPost, path: "/host/storage/folders/add", scope: modules.APIKeyScopeHostAdmin},
+	{method: http.MethodPost, path: "/host/storage/folders/remove", scope: modules.APIKeyScopeHostAdmin},
+	{method: http.MethodPost, path: "/host/storage/folders/resize", scope: modules.APIKeyScopeHostAdmin},
+	{method: http.MethodPost, path: "/host/storage/sectors/delete/", prefix: true, scope: modules.APIKeyScopeHostAdmin},
+
+	// renter-upload
+	{method: http.MethodPost, path: "/renter/nft/pin", scope: modules.APIKeyScopeRenterUpload},
+	{method: http.MethodPost, path: "/renter/nft/unpin", scope: modules.APIKeyScopeRenterUpload},
+}
+
+// apiKeyScopes returns the scopes an API key needs to access the endpoint of
+// the request. If no scope grants access to the endpoint, false is returned.
+func apiKeyScopes(req *http.Request) ([]modules.APIKeyScope, bool) {
+	path := canonicalWalletPath(req.URL.Path)
+	for _, r := range apiKeyRoutes {
+		if r.method != req.Method {
+			continue
+		}
+		if path == r.path || (r.prefix && strings.HasPrefix(path, r.path)) {
+			if r.extraScope != "" {
+				return []modules.APIKeyScope{r.scope, r.extraScope}, true
+			}
+			return []modules.APIKeyScope{r.scope}, true
+		}
+	}
+	return nil, false
+}
+
+// apiKeyAllowed returns true if key grants access to the endpoint of the
+// request.
+func apiKeyAllowed(key modules.APIKey, req *http.Request) bool {
+	scopes, ok := apiKeyScopes(req)
+	if !ok {
+		return false
+	}
+	for _, scope := range scopes {
+		if !key.HasScope(scope) {
+			return false
+		}
+	}
+	return true
+}
+
+// canonicalWalletPath maps "/wallets/:walletname/..." to "/wallet/..." so that
+// the routes of all wallets share their API key scopes and rate limits.
+func canonicalWalletPath(path string) string {
+	if !strings.HasPrefix(path, "/wallets/") {
+		return path
+	}
+	parts := strings.SplitN(strings.TrimPrefix(path, "/wallets/"), "/", 2)
+	if len(parts) != 2 {
+		return path
+	}
+	return "/wallet/" + parts[1]
+}
+
+// requestAPIKey returns the API key the request authenticated with.
+func requestAPIKey(req *http.Request) (modules.APIKey, bool) {
+	key, ok := req.Context().Value(apiKeyContextKey{}).(modules.APIKey)
+	return key, ok
+}
+
+// WithAPIKeys is middleware that attaches the API key of a request to its
+// context if the request authenticates with one of the keys of cfg.
+// RequirePassword then grants access to the endpoints covered by the key's
+// scopes.
+func WithAPIKeys(h http.Handler, cfg *modules.SiadConfig) http.Handler {
+	if cfg == nil {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if _, pass, ok := req.BasicAuth(); ok && pass != "" {
+			if key, ok := cfg.APIKey(pass); ok {
+				req = req.WithContext(context.WithValue(req.Context(), apiKeyContextKey{}, key))
+			}
+		}
+		h.ServeHTTP(w, req)
+	})
+}
+
+// daemonAPIKeysHandlerGET handles the API call listing the daemon's API keys.
+func (api *API) daemonAPIKeysHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	keys := api.siadConfig.APIKeyList()
+	if keys == nil {
+		keys = []modules.APIKey{}
+	}
+	WriteJSON(w, DaemonAPIKeysGet{APIKeys: keys})
+}
+
+// daemonAPIKeysHandlerPOST handles the API call creating a new API key.
+func (api *API) daemonAPIKeysHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	name := req.FormValue("name")
+	var scopes []modules.APIKeyScope
+	for _, s := range strings.Split(req.FormValue("scopes"), ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			scopes = append(scopes, modules.APIKeyScope(s))
+		}
+	}
+	key, err := api.siadConfig.AddAPIKey(name, scopes)
+	if err != nil {
+		WriteError(w, Error{"unable to add api key: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, DaemonAPIKeyPOST{key})
+}
+
+// daemonAPIKeysRemoveHandlerPOST handles the API call removing an API key.
+func (api *API) daemonAPIKeysRemoveHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	if err := api.siadConfig.RemoveAPIKey(req.FormValue("name")); err != nil {
+		WriteError(w, Error{"unable to remove api key: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.sia.tech/siad/modules"
+)
+
+// TestAPIKeyAllowed tests that API keys are only allowed to access the
+// endpoints covered by their scopes.
+func TestAPIKeyAllowed(t *testing.T) {
+	readOnly := modules.APIKey{Scopes: []modules.APIKeyScope{modules.APIKeyScopeReadOnly}}
+	spend := modules.APIKey{Scopes: []modules.APIKeyScope{modules.APIKeyScopeWalletSpend}}
+	transfer := modules.APIKey{Scopes: []modules.APIKeyScope{modules.APIKeyScopeNFTTransfer}}
+	both := modules.APIKey{Scopes: []modules.APIKeyScope{modules.APIKeyScopeWalletSpend, modules.APIKeyScopeNFTTransfer}}
+
+	tests := []struct {
+		key     modules.APIKey
+		method  string
+		path    string
+		allowed bool
+	}{
+		{readOnly, http.MethodGet, "/wallet/reserves", true},
+		{readOnly, http.MethodGet, "/wallets/cold/reserves", true},
+		{readOnly, http.MethodGet, "/wallet/nft/unlockable", true},
+		{readOnly, http.MethodPost, "/wallet/nft/unlockable/mint", false},
+		{spend, http.MethodPost, "/wallet/nft/unlockable/mint", true},
+		{spend, http.MethodPost, "/wallet/nft/unlockable/import", true},
+		{spend, http.MethodGet, "/wallet/nft/unlockable/handshake", true},
+		{spend, http.MethodPost, "/wallet/nft/unlockable/transfer", false},
+		{transfer, http.MethodPost, "/wallet/nft/unlockable/transfer", true},
+		{spend, http.MethodPost, "/wallet/nft/approve", true},
+		{transfer, http.MethodPost, "/wallet/nft/approve/transfer", true},
+		{spend, http.MethodPost, "/wallet/nft/dispute/freeze", true},
+		{spend, http.MethodPost, "/wallet/nft/governance/sign", true},
+		{transfer, http.MethodPost, "/wallet/nft/schedule", true},
+		{spend, http.MethodPost, "/wallet/nft/compose", false},
+		{transfer, http.MethodPost, "/wallet/nft/compose", false},
+		{both, http.MethodPost, "/wallet/nft/compose", true},
+		{both, http.MethodGet, "/wallet/seeds", false},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, test.path, nil)
+		if apiKeyAllowed(test.key, req) != test.allowed {
+			t.Errorf("%v %v with scopes %v: expected allowed to be %v", test.method, test.path, test.key.Scopes, test.allowed)
+		}
+	}
+}
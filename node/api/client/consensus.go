@@ -2,6 +2,7 @@ package client
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -9,6 +10,7 @@ import (
 	"time"
 
 	"gitlab.com/NebulousLabs/encoding"
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/node/api"
 	"go.sia.tech/siad/types"
@@ -32,6 +34,63 @@ func (c *Client) ConsensusBlocksHeightGet(height types.BlockHeight) (cbg api.Con
 	return
 }
 
+// ConsensusNFTStatsGet requests the /consensus/nft/stats api resource
+func (c *Client) ConsensusNFTStatsGet() (stats types.NFTStats, err error) {
+	err = c.get("/consensus/nft/stats", &stats)
+	return
+}
+
+// ConsensusNFTStoragePoolGet requests the /consensus/nft/storagepool api
+// resource
+func (c *Client) ConsensusNFTStoragePoolGet() (cpg api.ConsensusNFTStoragePoolGET, err error) {
+	err = c.get("/consensus/nft/storagepool", &cpg)
+	return
+}
+
+// ConsensusNFTEarmarkGet requests the /consensus/nft/earmark api resource
+func (c *Client) ConsensusNFTEarmarkGet(root crypto.Hash) (earmark types.NFTStorageEarmark, err error) {
+	err = c.get("/consensus/nft/earmark?merkleRoot="+root.String(), &earmark)
+	return
+}
+
+// ConsensusNFTDisputeGet requests the /consensus/nft/dispute api resource
+func (c *Client) ConsensusNFTDisputeGet(root crypto.Hash) (cdg api.ConsensusNFTDisputeGET, err error) {
+	err = c.get("/consensus/nft/dispute?merkleRoot="+root.String(), &cdg)
+	return
+}
+
+// ConsensusNFTLicenseGet requests the /consensus/nft/license api resource
+func (c *Client) ConsensusNFTLicenseGet(root crypto.Hash) (license types.NFTLicense, err error) {
+	err = c.get("/consensus/nft/license?merkleRoot="+root.String(), &license)
+	return
+}
+
+// ConsensusNFTCheckpointPost uses the /consensus/nft/checkpoint api endpoint
+// to add a checkpoint signed by the NFT governance.
+func (c *Client) ConsensusNFTCheckpointPost(cp modules.NFTIndexCheckpoint) error {
+	json, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	return c.post("/consensus/nft/checkpoint", string(json), nil)
+}
+
+// ConsensusValidateReservesPost uses the /consensus/validate/reserves api
+// endpoint to check a proof of reserves against the consensus set.
+func (c *Client) ConsensusValidateReservesPost(p types.ProofOfReserves) error {
+	json, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return c.post("/consensus/validate/reserves", string(json), nil)
+}
+
+// ConsensusNFTDisputesGet requests the /consensus/nft/disputes api resource
+func (c *Client) ConsensusNFTDisputesGet() (cdg api.ConsensusNFTDisputesGET, err error) {
+	err = c.get("/consensus/nft/disputes", &cdg)
+	return
+}
+
 // ConsensusSubscribeSingle streams consensus changes from the
 // /consensus/subscribe endpoint to the provided subscriber. Multiple calls may
 // be required before the subscriber is fully caught up. It returns the latest
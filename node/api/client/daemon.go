@@ -3,7 +3,9 @@ package client
 import (
 	"net/url"
 	"strconv"
+	"strings"
 
+	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/node/api"
 )
 
@@ -24,6 +26,52 @@ func (c *Client) DaemonAlertsGet() (dag api.DaemonAlertsGet, err error) {
 	return
 }
 
+// DaemonAPIKeysGet requests the /daemon/apikeys resource.
+func (c *Client) DaemonAPIKeysGet() (dakg api.DaemonAPIKeysGet, err error) {
+	err = c.get("/daemon/apikeys", &dakg)
+	return
+}
+
+// DaemonAPIKeysPost uses the /daemon/apikeys endpoint to create a new API key
+// with the given name and scopes.
+func (c *Client) DaemonAPIKeysPost(name string, scopes ...modules.APIKeyScope) (dakp api.DaemonAPIKeyPOST, err error) {
+	strs := make([]string, 0, len(scopes))
+	for _, s := range scopes {
+		strs = append(strs, string(s))
+	}
+	values := url.Values{}
+	values.Set("name", name)
+	values.Set("scopes", strings.Join(strs, ","))
+	err = c.post("/daemon/apikeys", values.Encode(), &dakp)
+	return
+}
+
+// DaemonAPIKeyRemovePost uses the /daemon/apikeys/remove endpoint to remove
+// the API key with the given name.
+func (c *Client) DaemonAPIKeyRemovePost(name string) (err error) {
+	values := url.Values{}
+	values.Set("name", name)
+	err = c.post("/daemon/apikeys/remove", values.Encode(), nil)
+	return
+}
+
+// DaemonRateLimitsGet requests the /daemon/ratelimits resource.
+func (c *Client) DaemonRateLimitsGet() (drlg api.DaemonRateLimitsGet, err error) {
+	err = c.get("/daemon/ratelimits", &drlg)
+	return
+}
+
+// DaemonRateLimitsPost uses the /daemon/ratelimits endpoint to limit the
+// requests per minute a single client may send to the endpoints starting with
+// path. A limit of 0 removes the rate limit.
+func (c *Client) DaemonRateLimitsPost(path string, limit uint64) (err error) {
+	values := url.Values{}
+	values.Set("path", path)
+	values.Set("limit", strconv.FormatUint(limit, 10))
+	err = c.post("/daemon/ratelimits", values.Encode(), nil)
+	return
+}
+
 // DaemonVersionGet requests the /daemon/version resource.
 func (c *Client) DaemonVersionGet() (dvg api.DaemonVersionGet, err error) {
 	err = c.get("/daemon/version", &dvg)
@@ -57,6 +105,13 @@ func (c *Client) DaemonStackGet() (dsg api.DaemonStackGet, err error) {
 	return
 }
 
+// MetricsGet requests the /metrics api resource and returns the metrics in
+// the Prometheus text format.
+func (c *Client) MetricsGet() (string, error) {
+	_, data, err := c.getRawResponse("/metrics")
+	return string(data), err
+}
+
 // DaemonStopGet stops the daemon using the /daemon/stop endpoint.
 func (c *Client) DaemonStopGet() (err error) {
 	err = c.get("/daemon/stop", nil)
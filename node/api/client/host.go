@@ -39,6 +39,15 @@ const (
 	// HostParamMinStoragePrice is the minimum storage price in
 	// hastings/byte/block.
 	HostParamMinStoragePrice = HostParam("minstorageprice")
+	// HostParamMinNFTStoragePrice is the minimum storage price for NFT data
+	// in hastings/byte/block.
+	HostParamMinNFTStoragePrice = HostParam("minnftstorageprice")
+	// HostParamNFTRetentionPeriod is the max duration of contracts storing
+	// NFT data in blocks.
+	HostParamNFTRetentionPeriod = HostParam("nftretentionperiod")
+	// HostParamNFTStoragePool indicates if the host participates in the NFT
+	// storage pool.
+	HostParamNFTStoragePool = HostParam("nftstoragepool")
 	// HostParamAcceptingContracts indicates if the host is accepting new
 	// contracts.
 	HostParamAcceptingContracts = HostParam("acceptingcontracts")
@@ -68,6 +77,21 @@ const (
 	// HostParamCustomRegistryPath is the locataion of the host's registry on
 	// disk.
 	HostParamCustomRegistryPath = HostParam("customregistrypath")
+	// HostParamRegistryRetention is the number of blocks the host keeps
+	// expired registry entries before pruning them.
+	HostParamRegistryRetention = HostParam("registryretention")
+	// HostParamRegistryCompactIndex enables the compact in-memory index of
+	// the host's registry. It takes effect after restarting the host.
+	HostParamRegistryCompactIndex = HostParam("registrycompactindex")
+	// HostParamMaxRegistryEntryTTL is the number of blocks the host keeps a
+	// registry entry after it was last updated.
+	HostParamMaxRegistryEntryTTL = HostParam("maxregistryentryttl")
+	// HostParamFreeRegistryReads is the number of registry reads per minute
+	// the host serves to a single IP address without payment.
+	HostParamFreeRegistryReads = HostParam("freeregistryreads")
+	// HostParamMaxContractBandwidth is the number of bytes the renter of a
+	// contract may upload and download per hour.
+	HostParamMaxContractBandwidth = HostParam("maxcontractbandwidth")
 )
 
 // HostAnnouncePost uses the /host/announce endpoint to announce the host to
@@ -98,6 +122,13 @@ func (c *Client) HostContractGet(obligationID types.FileContractID) (cg api.Host
 	return
 }
 
+// HostNFTsGet uses the /host/nfts endpoint to get the NFTs the host's storage
+// obligations were tagged with.
+func (c *Client) HostNFTsGet() (hng api.HostNFTsGET, err error) {
+	err = c.get("/host/nfts", &hng)
+	return
+}
+
 // HostEstimateScoreGet requests the /host/estimatescore endpoint.
 func (c *Client) HostEstimateScoreGet(param, value string) (eg api.HostEstimateScoreGET, err error) {
 	err = c.get(fmt.Sprintf("/host/estimatescore?%v=%v", param, value), &eg)
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"math"
@@ -12,6 +13,7 @@ import (
 
 	"gitlab.com/NebulousLabs/errors"
 
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/node/api"
 	"go.sia.tech/siad/types"
@@ -123,6 +125,90 @@ func (a *AllowanceRequestPost) WithMaxUploadBandwidthPrice(price types.Currency)
 	return a
 }
 
+// WithScoreLeewayGoodForRenew adds the scoreleewaygoodforrenew field to the
+// request.
+func (a *AllowanceRequestPost) WithScoreLeewayGoodForRenew(leeway uint64) *AllowanceRequestPost {
+	a.values.Set("scoreleewaygoodforrenew", fmt.Sprint(leeway))
+	return a
+}
+
+// WithScoreLeewayGoodForUpload adds the scoreleewaygoodforupload field to the
+// request.
+func (a *AllowanceRequestPost) WithScoreLeewayGoodForUpload(leeway uint64) *AllowanceRequestPost {
+	a.values.Set("scoreleewaygoodforupload", fmt.Sprint(leeway))
+	return a
+}
+
+// WithMinContractFunding adds the mincontractfunding field to the request.
+func (a *AllowanceRequestPost) WithMinContractFunding(minFunding float64) *AllowanceRequestPost {
+	a.values.Set("mincontractfunding", fmt.Sprint(minFunding))
+	return a
+}
+
+// WithMinContractFundUploadThreshold adds the mincontractfunduploadthreshold
+// field to the request.
+func (a *AllowanceRequestPost) WithMinContractFundUploadThreshold(threshold float64) *AllowanceRequestPost {
+	a.values.Set("mincontractfunduploadthreshold", fmt.Sprint(threshold))
+	return a
+}
+
+// WithMinContractFundRenewalThreshold adds the mincontractfundrenewalthreshold
+// field to the request.
+func (a *AllowanceRequestPost) WithMinContractFundRenewalThreshold(threshold float64) *AllowanceRequestPost {
+	a.values.Set("mincontractfundrenewalthreshold", fmt.Sprint(threshold))
+	return a
+}
+
+// WithNFTStorage adds the nftstorage field to the request.
+func (a *AllowanceRequestPost) WithNFTStorage(nftStorage bool) *AllowanceRequestPost {
+	a.values.Set("nftstorage", fmt.Sprint(nftStorage))
+	return a
+}
+
+// WithMinRegistryEntries adds the minregistryentries field to the request.
+func (a *AllowanceRequestPost) WithMinRegistryEntries(entries uint64) *AllowanceRequestPost {
+	a.values.Set("minregistryentries", fmt.Sprint(entries))
+	return a
+}
+
+// WithMinRegistryEntryTTL adds the minregistryentryttl field to the request.
+func (a *AllowanceRequestPost) WithMinRegistryEntryTTL(ttl types.BlockHeight) *AllowanceRequestPost {
+	a.values.Set("minregistryentryttl", fmt.Sprint(ttl))
+	return a
+}
+
+// WithNFTStoragePool adds the nftstoragepool field to the request.
+func (a *AllowanceRequestPost) WithNFTStoragePool(nftStoragePool bool) *AllowanceRequestPost {
+	a.values.Set("nftstoragepool", fmt.Sprint(nftStoragePool))
+	return a
+}
+
+// WithNFTRepairThreshold adds the nftrepairthreshold field to the request.
+func (a *AllowanceRequestPost) WithNFTRepairThreshold(threshold float64) *AllowanceRequestPost {
+	a.values.Set("nftrepairthreshold", fmt.Sprint(threshold))
+	return a
+}
+
+// WithMaxNFTRepairsPerHour adds the maxnftrepairsperhour field to the
+// request.
+func (a *AllowanceRequestPost) WithMaxNFTRepairsPerHour(repairs uint64) *AllowanceRequestPost {
+	a.values.Set("maxnftrepairsperhour", fmt.Sprint(repairs))
+	return a
+}
+
+// WithMaxNFTRepairSpending adds the maxnftrepairspending field to the
+// request.
+func (a *AllowanceRequestPost) WithMaxNFTRepairSpending(spending types.Currency) *AllowanceRequestPost {
+	a.values.Set("maxnftrepairspending", spending.String())
+	return a
+}
+
+// WithMaxTxnFeePerByte adds the maxtxnfeeperbyte field to the request.
+func (a *AllowanceRequestPost) WithMaxTxnFeePerByte(fee types.Currency) *AllowanceRequestPost {
+	a.values.Set("maxtxnfeeperbyte", fee.String())
+	return a
+}
+
 // Send finalizes and sends the request.
 func (a *AllowanceRequestPost) Send() (err error) {
 	if a.sent {
@@ -170,6 +256,45 @@ func (c *Client) RenterContractCancelPost(id types.FileContractID) (err error) {
 	return
 }
 
+// RenterContractReceiptsGet requests the /renter/contract/receipts resource and
+// returns the receipts of all formed and renewed contracts.
+func (c *Client) RenterContractReceiptsGet() (rcr api.RenterContractReceipts, err error) {
+	err = c.get("/renter/contract/receipts", &rcr)
+	return
+}
+
+// RenterContractReceiptGet requests the /renter/contract/receipts resource
+// and returns the receipt of a single contract.
+func (c *Client) RenterContractReceiptGet(id types.FileContractID) (receipt modules.ContractReceipt, err error) {
+	values := url.Values{}
+	values.Set("id", id.String())
+	var rcr api.RenterContractReceipts
+	err = c.get("/renter/contract/receipts?"+values.Encode(), &rcr)
+	if err != nil {
+		return modules.ContractReceipt{}, err
+	}
+	if len(rcr.Receipts) != 1 {
+		return modules.ContractReceipt{}, fmt.Errorf("expected 1 receipt but got %v", len(rcr.Receipts))
+	}
+	return rcr.Receipts[0], nil
+}
+
+// RenterContractRenewalChainGet requests the /renter/contract/renewalchain
+// resource and returns the renewal chain of a contract.
+func (c *Client) RenterContractRenewalChainGet(id types.FileContractID) (rcrc api.RenterContractRenewalChain, err error) {
+	values := url.Values{}
+	values.Set("id", id.String())
+	err = c.get("/renter/contract/renewalchain?"+values.Encode(), &rcrc)
+	return
+}
+
+// RenterSpendingGet requests the /renter/spending resource and returns the
+// spending of the current period split by category and by host.
+func (c *Client) RenterSpendingGet() (sb modules.ContractorSpendingBreakdown, err error) {
+	err = c.get("/renter/spending", &sb)
+	return
+}
+
 // RenterAllContractsGet requests the /renter/contracts resource with all
 // options set to true
 func (c *Client) RenterAllContractsGet() (rc api.RenterContracts, err error) {
@@ -188,6 +313,33 @@ func (c *Client) RenterContractsGet() (rc api.RenterContracts, err error) {
 	return
 }
 
+// RenterContractsQueryGet requests a filtered, sorted and paginated listing of
+// the current contracts from the /renter/contracts resource.
+func (c *Client) RenterContractsQueryGet(q modules.ContractQuery) (rc api.RenterContracts, err error) {
+	values := url.Values{}
+	var statuses []string
+	for _, status := range q.Statuses {
+		statuses = append(statuses, string(status))
+	}
+	if len(statuses) > 0 {
+		values.Set("status", strings.Join(statuses, ","))
+	}
+	if q.Host.Key != nil {
+		values.Set("host", q.Host.String())
+	}
+	values.Set("sortby", string(modules.ContractSortID))
+	if q.SortBy != "" {
+		values.Set("sortby", string(q.SortBy))
+	}
+	if q.Descending {
+		values.Set("order", "desc")
+	}
+	values.Set("offset", fmt.Sprint(q.Offset))
+	values.Set("limit", fmt.Sprint(q.Limit))
+	err = c.get("/renter/contracts?"+values.Encode(), &rc)
+	return
+}
+
 // RenterContractStatus requests the /watchdog/contractstatus resource and returns
 // the status of a contract.
 func (c *Client) RenterContractStatus(fcID types.FileContractID) (status modules.ContractWatchStatus, err error) {
@@ -222,6 +374,13 @@ func (c *Client) RenterInitContractRecoveryScanPost() (err error) {
 	return
 }
 
+// RenterInitFullContractRecoveryScanPost initializes a full contract recovery
+// scan using the /renter/recoveryscan endpoint.
+func (c *Client) RenterInitFullContractRecoveryScanPost() (err error) {
+	err = c.post("/renter/recoveryscan", "full=true", nil)
+	return
+}
+
 // RenterContractRecoveryProgressGet returns information about potentially
 // ongoing contract recovery scans.
 func (c *Client) RenterContractRecoveryProgressGet() (rrs api.RenterRecoveryStatusGET, err error) {
@@ -445,6 +604,51 @@ func (c *Client) RenterFileGet(siaPath modules.SiaPath) (rf api.RenterFile, err
 	return
 }
 
+// RenterNFTHealthGet uses the /renter/nft/health endpoint to get the health
+// report of a pinned NFT.
+func (c *Client) RenterNFTHealthGet(root crypto.Hash) (health modules.NFTHealth, err error) {
+	err = c.get("/renter/nft/health?merkleRoot="+root.String(), &health)
+	return
+}
+
+// RenterNFTBundleExportPost uses the /renter/nft/bundle/export endpoint to
+// export the metadata of the siafiles of an NFT collection.
+func (c *Client) RenterNFTBundleExportPost(collection string, siaPaths []modules.SiaPath) (bundle modules.NFTBundle, err error) {
+	json, err := json.Marshal(api.RenterNFTBundleExportPOSTParams{
+		Collection: collection,
+		SiaPaths:   siaPaths,
+	})
+	if err != nil {
+		return modules.NFTBundle{}, err
+	}
+	err = c.post("/renter/nft/bundle/export", string(json), &bundle)
+	return
+}
+
+// RenterNFTBundleImportPost uses the /renter/nft/bundle/import endpoint to
+// import the siafiles of an NFT bundle below the folder at siaPath.
+func (c *Client) RenterNFTBundleImportPost(bundle modules.NFTBundle, siaPath modules.SiaPath) (imported modules.NFTBundleImport, err error) {
+	json, err := json.Marshal(api.RenterNFTBundleImportPOSTParams{
+		Bundle:  bundle,
+		SiaPath: siaPath,
+	})
+	if err != nil {
+		return modules.NFTBundleImport{}, err
+	}
+	err = c.post("/renter/nft/bundle/import", string(json), &imported)
+	return
+}
+
+// RenterNFTPinPost uses the /renter/nft/pin endpoint to pin the data of an NFT
+// from the file at source, which has to be an absolute path.
+func (c *Client) RenterNFTPinPost(root crypto.Hash, source string) (err error) {
+	values := url.Values{}
+	values.Set("merkleRoot", root.String())
+	values.Set("source", source)
+	err = c.post("/renter/nft/pin", values.Encode(), nil)
+	return
+}
+
 // RenterFilesGet requests the /renter/files resource.
 func (c *Client) RenterFilesGet(cached bool) (rf api.RenterFiles, err error) {
 	err = c.get("/renter/files?cached="+fmt.Sprint(cached), &rf)
@@ -469,6 +673,19 @@ func (c *Client) RenterPostAllowance(allowance modules.Allowance) error {
 	a = a.WithExpectedDownload(allowance.ExpectedDownload)
 	a = a.WithExpectedRedundancy(allowance.ExpectedRedundancy)
 	a = a.WithMaxPeriodChurn(allowance.MaxPeriodChurn)
+	a = a.WithScoreLeewayGoodForRenew(allowance.ScoreLeewayGoodForRenew)
+	a = a.WithScoreLeewayGoodForUpload(allowance.ScoreLeewayGoodForUpload)
+	a = a.WithMinContractFunding(allowance.MinContractFunding)
+	a = a.WithMinContractFundUploadThreshold(allowance.MinContractFundUploadThreshold)
+	a = a.WithMinContractFundRenewalThreshold(allowance.MinContractFundRenewalThreshold)
+	a = a.WithNFTStorage(allowance.NFTStorage)
+	a = a.WithMinRegistryEntries(allowance.MinRegistryEntries)
+	a = a.WithMinRegistryEntryTTL(allowance.MinRegistryEntryTTL)
+	a = a.WithNFTStoragePool(allowance.NFTStoragePool)
+	a = a.WithNFTRepairThreshold(allowance.NFTRepairThreshold)
+	a = a.WithMaxNFTRepairsPerHour(allowance.MaxNFTRepairsPerHour)
+	a = a.WithMaxNFTRepairSpending(allowance.MaxNFTRepairSpending)
+	a = a.WithMaxTxnFeePerByte(allowance.MaxTxnFeePerByte)
 	return a.Send()
 }
 
@@ -497,6 +714,24 @@ func (c *Client) RenterRateLimitPost(readBPS, writeBPS int64) (err error) {
 	return
 }
 
+// RenterUploadSchedulePost uses the /renter endpoint to change the renter's
+// upload schedule.
+func (c *Client) RenterUploadSchedulePost(us modules.UploadSchedule) (err error) {
+	values := url.Values{}
+	values.Set("uploadschedule", us.String())
+	err = c.post("/renter", values.Encode(), nil)
+	return
+}
+
+// RenterNFTPreviewsPost uses the /renter endpoint to enable or disable the
+// generation of previews for pinned image NFTs.
+func (c *Client) RenterNFTPreviewsPost(enabled bool) (err error) {
+	values := url.Values{}
+	values.Set("nftpreviews", strconv.FormatBool(enabled))
+	err = c.post("/renter", values.Encode(), nil)
+	return
+}
+
 // RenterRenamePost uses the /renter/rename/:siapath endpoint to rename a file.
 func (c *Client) RenterRenamePost(siaPathOld, siaPathNew modules.SiaPath, root bool) (err error) {
 	spo := escapeSiaPath(siaPathOld)
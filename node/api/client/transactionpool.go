@@ -3,8 +3,10 @@ package client
 import (
 	"encoding/base64"
 	"net/url"
+	"strconv"
 
 	"gitlab.com/NebulousLabs/encoding"
+	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/node/api"
 	"go.sia.tech/siad/types"
 )
@@ -31,3 +33,20 @@ func (c *Client) TransactionPoolTransactionsGet() (tptg api.TpoolTxnsGET, err er
 	err = c.get("/tpool/transactions", &tptg)
 	return
 }
+
+// TransactionPoolRelayPolicyGet uses the /tpool/relaypolicy endpoint to get
+// the policy used to filter NFT transaction sets relayed by peers.
+func (c *Client) TransactionPoolRelayPolicyGet() (trpg api.TpoolRelayPolicyGET, err error) {
+	err = c.get("/tpool/relaypolicy", &trpg)
+	return
+}
+
+// TransactionPoolRelayPolicyPost uses the /tpool/relaypolicy endpoint to set
+// the policy used to filter NFT transaction sets relayed by peers.
+func (c *Client) TransactionPoolRelayPolicyPost(rp modules.TPoolRelayPolicy) (err error) {
+	values := url.Values{}
+	values.Set("minnftfeeperbyte", rp.MinNFTFeePerByte.String())
+	values.Set("maxnftarbitrarydatasize", strconv.FormatUint(rp.MaxNFTArbitraryDataSize, 10))
+	err = c.post("/tpool/relaypolicy", values.Encode(), nil)
+	return
+}
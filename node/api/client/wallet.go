@@ -152,6 +152,126 @@ func (c *Client) WalletSiacoinsPost(amount types.Currency, destination types.Unl
 	return
 }
 
+// WalletNFTMintPost uses the /wallet/nft/mint api endpoint to mint an NFT.
+// The mint commits to the content type and length of nft if they are set. If
+// collection or nonce is set, the NFT is minted with an NftID. If dest is the
+// zero address, the NFT is minted to a new address of the wallet.
+func (c *Client) WalletNFTMintPost(nft types.NftCustody, collection string, nonce uint64, dest types.UnlockHash) (wnmp api.WalletNFTMintPOST, err error) {
+	values := url.Values{}
+	values.Set("merkleRoot", nft.FileMerkleRoot.String())
+	if nft.ContentType != "" {
+		values.Set("contenttype", nft.ContentType)
+		values.Set("contentlength", strconv.FormatUint(nft.ContentLength, 10))
+	}
+	if collection != "" || nonce != 0 {
+		values.Set("collection", collection)
+		values.Set("nonce", strconv.FormatUint(nonce, 10))
+	}
+	if dest != (types.UnlockHash{}) {
+		values.Set("destination", dest.String())
+	}
+	err = c.post("/wallet/nft/mint", values.Encode(), &wnmp)
+	return
+}
+
+// WalletNFTMintLicensedPost uses the /wallet/nft/mint api endpoint to mint an
+// NFT to a new address of the wallet whose mint commits to a license.
+func (c *Client) WalletNFTMintLicensedPost(nft types.NftCustody, license types.NFTLicense) (wnmp api.WalletNFTMintPOST, err error) {
+	values := url.Values{}
+	values.Set("merkleRoot", nft.FileMerkleRoot.String())
+	if nft.ContentType != "" {
+		values.Set("contenttype", nft.ContentType)
+		values.Set("contentlength", strconv.FormatUint(nft.ContentLength, 10))
+	}
+	if license.Kind == types.NFTLicenseCustom {
+		values.Set("licenseterms", license.Terms.String())
+	} else {
+		values.Set("license", license.Identifier)
+	}
+	err = c.post("/wallet/nft/mint", values.Encode(), &wnmp)
+	return
+}
+
+// WalletNFTMintMetadataPost uses the /wallet/nft/mint api endpoint to mint an
+// NFT to a new address of the wallet after validating its nftschema metadata.
+func (c *Client) WalletNFTMintMetadataPost(root crypto.Hash, metadata []byte, strict bool) (wnmp api.WalletNFTMintPOST, err error) {
+	values := url.Values{}
+	values.Set("merkleRoot", root.String())
+	values.Set("metadata", string(metadata))
+	values.Set("strictmetadata", strconv.FormatBool(strict))
+	err = c.post("/wallet/nft/mint", values.Encode(), &wnmp)
+	return
+}
+
+// WalletNFTUnlockableGet uses the /wallet/nft/unlockable api endpoint to
+// unlock the unlockable content of an NFT held by the wallet.
+func (c *Client) WalletNFTUnlockableGet(root crypto.Hash) (wnug api.WalletNFTUnlockableGET, err error) {
+	err = c.get("/wallet/nft/unlockable?merkleRoot="+root.String(), &wnug)
+	return
+}
+
+// WalletNFTUnlockableHandshakeGet uses the /wallet/nft/unlockable/handshake
+// api endpoint to get the handshake for receiving an NFT with unlockable
+// content at addr.
+func (c *Client) WalletNFTUnlockableHandshakeGet(addr types.UnlockHash) (h types.NFTUnlockableHandshake, err error) {
+	err = c.get("/wallet/nft/unlockable/handshake?address="+addr.String(), &h)
+	return
+}
+
+// WalletNFTUnlockableImportPost uses the /wallet/nft/unlockable/import api
+// endpoint to import the envelope of an incoming NFT's unlockable content.
+func (c *Client) WalletNFTUnlockableImportPost(e types.NFTUnlockableEnvelope) error {
+	json, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return c.post("/wallet/nft/unlockable/import", string(json), nil)
+}
+
+// WalletNFTUnlockableMintPost uses the /wallet/nft/unlockable/mint api
+// endpoint to mint an NFT with unlockable content.
+func (c *Client) WalletNFTUnlockableMintPost(params api.WalletNFTUnlockableMintPOST) (wnmp api.WalletNFTMintPOST, err error) {
+	json, err := json.Marshal(params)
+	if err != nil {
+		return api.WalletNFTMintPOST{}, err
+	}
+	err = c.post("/wallet/nft/unlockable/mint", string(json), &wnmp)
+	return
+}
+
+// WalletNFTUnlockableTransferPost uses the /wallet/nft/unlockable/transfer api
+// endpoint to transfer an NFT with unlockable content to the address of a
+// handshake.
+func (c *Client) WalletNFTUnlockableTransferPost(nft types.NftCustody, h types.NFTUnlockableHandshake) (resp api.WalletNFTUnlockableTransferPOSTResp, err error) {
+	json, err := json.Marshal(api.WalletNFTUnlockableTransferPOST{
+		NFT:       nft,
+		Handshake: h,
+	})
+	if err != nil {
+		return api.WalletNFTUnlockableTransferPOSTResp{}, err
+	}
+	err = c.post("/wallet/nft/unlockable/transfer", string(json), &resp)
+	return
+}
+
+// WalletReservesGet uses the /wallet/reserves api endpoint to get a proof of
+// the wallet's reserves covering the challenge.
+func (c *Client) WalletReservesGet(challenge crypto.Hash) (p types.ProofOfReserves, err error) {
+	err = c.get("/wallet/reserves?challenge="+challenge.String(), &p)
+	return
+}
+
+// WalletNFTComposePost uses the /wallet/nft/compose api endpoint to build a
+// single transaction which transfers an NFT and pays siacoin outputs.
+func (c *Client) WalletNFTComposePost(spec modules.TransactionSpec) (wsp api.WalletSiacoinsPOST, err error) {
+	json, err := json.Marshal(spec)
+	if err != nil {
+		return api.WalletSiacoinsPOST{}, err
+	}
+	err = c.post("/wallet/nft/compose", string(json), &wsp)
+	return
+}
+
 // WalletSignPost uses the /wallet/sign api endpoint to sign a transaction.
 func (c *Client) WalletSignPost(txn types.Transaction, toSign []crypto.Hash) (wspr api.WalletSignPOSTResp, err error) {
 	json, err := json.Marshal(api.WalletSignPOSTParams{
@@ -194,6 +314,41 @@ func (c *Client) WalletSweepPost(seed string) (wsp api.WalletSweepPOST, err erro
 	return
 }
 
+// WalletTransactionGroupPost uses the /wallet/transactiongroup endpoint to
+// broadcast a group of interdependent transactions.
+func (c *Client) WalletTransactionGroupPost(txns []types.Transaction, params modules.TransactionGroupParams) (wtgg api.WalletTransactionGroupGET, err error) {
+	json, err := json.Marshal(api.WalletTransactionGroupPOSTParams{
+		Transactions:           txns,
+		TransactionGroupParams: params,
+	})
+	if err != nil {
+		return
+	}
+	err = c.post("/wallet/transactiongroup", string(json), &wtgg)
+	return
+}
+
+// WalletTransactionGroupGet requests the /wallet/transactiongroup/:id api
+// resource for a group of transactions broadcast by the wallet.
+func (c *Client) WalletTransactionGroupGet(id modules.TransactionGroupID) (wtgg api.WalletTransactionGroupGET, err error) {
+	err = c.get("/wallet/transactiongroup/"+id.String(), &wtgg)
+	return
+}
+
+// WalletTransactionGroupsGet requests the /wallet/transactiongroups api
+// resource.
+func (c *Client) WalletTransactionGroupsGet() (wtgg api.WalletTransactionGroupsGET, err error) {
+	err = c.get("/wallet/transactiongroups", &wtgg)
+	return
+}
+
+// WalletBroadcastFailuresGet requests the /wallet/broadcastfailures api
+// resource.
+func (c *Client) WalletBroadcastFailuresGet() (wbfg api.WalletBroadcastFailuresGET, err error) {
+	err = c.get("/wallet/broadcastfailures", &wbfg)
+	return
+}
+
 // WalletTransactionsGet requests the/wallet/transactions api resource for a
 // certain startheight and endheight
 func (c *Client) WalletTransactionsGet(startHeight types.BlockHeight, endHeight types.BlockHeight) (wtg api.WalletTransactionsGET, err error) {
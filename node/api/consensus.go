@@ -6,6 +6,7 @@ import (
 	"io"
 	"math/big"
 	"net/http"
+	"strconv"
 
 	"github.com/julienschmidt/httprouter"
 
@@ -30,6 +31,12 @@ type ConsensusGET struct {
 	FoundationPrimaryUnlockHash  types.UnlockHash `json:"foundationprimaryunlockhash"`
 	FoundationFailsafeUnlockHash types.UnlockHash `json:"foundationfailsafeunlockhash"`
 
+	// NFT pool addresses and the unlock conditions which spend them.
+	NFTLockupUnlockHash            types.UnlockHash       `json:"nftlockupunlockhash"`
+	NFTLockupUnlockConditions      types.UnlockConditions `json:"nftlockupunlockconditions"`
+	NFTStoragePoolUnlockHash       types.UnlockHash       `json:"nftstoragepoolunlockhash"`
+	NFTStoragePoolUnlockConditions types.UnlockConditions `json:"nftstoragepoolunlockconditions"`
+
 	// Consensus code constants.
 	BlockFrequency         types.BlockHeight `json:"blockfrequency"`
 	BlockSizeLimit         uint64            `json:"blocksizelimit"`
@@ -115,6 +122,48 @@ type ConsensusBlocksGetSiafundOutput struct {
 	UnlockHash types.UnlockHash      `json:"unlockhash"`
 }
 
+// ConsensusNFTEditionsGET contains the editions returned by a GET call to
+// /consensus/nft/editions.
+type ConsensusNFTEditionsGET struct {
+	Editions []types.NftOwnershipStats `json:"editions"`
+}
+
+// ConsensusNFTSnapshotGET describes a snapshot of the NFT index returned by a
+// GET call to /consensus/nft/snapshot. The entries of the snapshot are only
+// exchanged between peers.
+type ConsensusNFTSnapshotGET struct {
+	Height  types.BlockHeight `json:"height"`
+	BlockID types.BlockID     `json:"blockid"`
+	Root    crypto.Hash       `json:"root"`
+	Entries int               `json:"entries"`
+}
+
+// ConsensusNFTStoragePoolGET contains the accounting of the NFT storage pool
+// returned by a GET call to /consensus/nft/storagepool.
+type ConsensusNFTStoragePoolGET struct {
+	Height          types.BlockHeight `json:"height"`
+	Balance         types.Currency    `json:"balance"`
+	Inflows         types.Currency    `json:"inflows"`
+	MintInflows     types.Currency    `json:"mintinflows"`
+	TransferInflows types.Currency    `json:"transferinflows"`
+	TopUpInflows    types.Currency    `json:"topupinflows"`
+	Outflows        types.Currency    `json:"outflows"`
+	Solvent         bool              `json:"solvent"`
+}
+
+// ConsensusNFTDisputeGET contains the dispute flags of an NFT returned by a
+// GET call to /consensus/nft/dispute.
+type ConsensusNFTDisputeGET struct {
+	types.NFTDispute
+	Frozen bool `json:"frozen"`
+}
+
+// ConsensusNFTDisputesGET contains the dispute flags of every NFT which was
+// ever flagged returned by a GET call to /consensus/nft/disputes.
+type ConsensusNFTDisputesGET struct {
+	Disputes []ConsensusNFTDisputeGET `json:"disputes"`
+}
+
 // RegisterRoutesConsensus is a helper function to register all consensus routes.
 func RegisterRoutesConsensus(router *httprouter.Router, cs modules.ConsensusSet) {
 	router.GET("/consensus", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
@@ -126,6 +175,48 @@ func RegisterRoutesConsensus(router *httprouter.Router, cs modules.ConsensusSet)
 	router.GET("/consensus/subscribe/:id", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		consensusSubscribeHandler(cs, w, req, ps)
 	})
+	router.GET("/consensus/nft/approval", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		consensusNFTApprovalHandler(cs, w, req, ps)
+	})
+	router.GET("/consensus/nft/bridge", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		consensusNFTBridgeHandler(cs, w, req, ps)
+	})
+	router.GET("/consensus/nft/dispute", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		consensusNFTDisputeHandler(cs, w, req, ps)
+	})
+	router.GET("/consensus/nft/disputes", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		consensusNFTDisputesHandler(cs, w, req, ps)
+	})
+	router.GET("/consensus/nft/earmark", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		consensusNFTEarmarkHandler(cs, w, req, ps)
+	})
+	router.GET("/consensus/nft/editions", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		consensusNFTEditionsHandler(cs, w, req, ps)
+	})
+	router.GET("/consensus/nft/license", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		consensusNFTLicenseHandler(cs, w, req, ps)
+	})
+	router.GET("/consensus/nft/policy", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		consensusNFTPolicyHandler(cs, w, req, ps)
+	})
+	router.GET("/consensus/nft/snapshot", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		consensusNFTSnapshotHandler(cs, w, req, ps)
+	})
+	router.POST("/consensus/nft/checkpoint", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		consensusNFTCheckpointHandlerPOST(cs, w, req, ps)
+	})
+	router.GET("/consensus/nft/stake", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		consensusNFTStakeHandler(cs, w, req, ps)
+	})
+	router.GET("/consensus/nft/storagepool", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		consensusNFTStoragePoolHandler(cs, w, req, ps)
+	})
+	router.GET("/consensus/nft/stats", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		consensusNFTStatsHandler(cs, w, req, ps)
+	})
+	router.POST("/consensus/validate/reserves", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		consensusValidateReservesHandler(cs, w, req, ps)
+	})
 	router.POST("/consensus/validate/transactionset", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		consensusValidateTransactionsetHandler(cs, w, req, ps)
 	})
@@ -238,6 +329,11 @@ func consensusHandler(cs modules.ConsensusSet, w http.ResponseWriter, _ *http.Re
 		FoundationPrimaryUnlockHash:  primary,
 		FoundationFailsafeUnlockHash: failsafe,
 
+		NFTLockupUnlockHash:            types.NFTLockupUnlockConditions.UnlockHash(),
+		NFTLockupUnlockConditions:      types.NFTLockupUnlockConditions,
+		NFTStoragePoolUnlockHash:       types.NFTStoragePoolUnlockConditions.UnlockHash(),
+		NFTStoragePoolUnlockConditions: types.NFTStoragePoolUnlockConditions,
+
 		BlockFrequency:         types.BlockFrequency,
 		BlockSizeLimit:         types.BlockSizeLimit,
 		ExtremeFutureThreshold: types.ExtremeFutureThreshold,
@@ -306,6 +402,23 @@ func consensusBlocksHandler(cs modules.ConsensusSet, w http.ResponseWriter, req
 	WriteJSON(w, consensusBlocksGetFromBlock(b, h, d))
 }
 
+// consensusValidateReservesHandler handles the API calls to
+// /consensus/validate/reserves.
+func consensusValidateReservesHandler(cs modules.ConsensusSet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var p types.ProofOfReserves
+	err := json.NewDecoder(req.Body).Decode(&p)
+	if err != nil {
+		WriteError(w, Error{"could not decode proof of reserves: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	err = modules.CheckProofOfReserves(cs, p)
+	if err != nil {
+		WriteError(w, Error{"proof of reserves validation failed: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // consensusValidateTransactionsetHandler handles the API calls to
 // /consensus/validate/transactionset.
 func consensusValidateTransactionsetHandler(cs modules.ConsensusSet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
@@ -323,6 +436,195 @@ func consensusValidateTransactionsetHandler(cs modules.ConsensusSet, w http.Resp
 	WriteSuccess(w)
 }
 
+// consensusNFTBridgeHandler handles the API calls to /consensus/nft/bridge.
+func consensusNFTBridgeHandler(cs modules.ConsensusSet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	nft, err := scanNFT(req)
+	if err != nil {
+		WriteError(w, Error{"could not load merkle root or id of NFT"}, http.StatusBadRequest)
+		return
+	}
+	lock, err := cs.ViewNFTBridgeLock(nft)
+	if err != nil {
+		WriteError(w, Error{"NFT is not locked by a bridge"}, http.StatusNotFound)
+		return
+	}
+	WriteJSON(w, lock)
+}
+
+// consensusNFTEarmarkHandler handles the API calls to /consensus/nft/earmark.
+func consensusNFTEarmarkHandler(cs modules.ConsensusSet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	nft, err := scanNFT(req)
+	if err != nil {
+		WriteError(w, Error{"could not load merkle root or id of NFT"}, http.StatusBadRequest)
+		return
+	}
+	earmark, err := cs.ViewNFTStorageEarmark(nft)
+	if err != nil {
+		WriteError(w, Error{"NFT has no storage pool earmark"}, http.StatusNotFound)
+		return
+	}
+	WriteJSON(w, earmark)
+}
+
+// consensusNFTEditionsHandler handles the API calls to
+// /consensus/nft/editions.
+func consensusNFTEditionsHandler(cs modules.ConsensusSet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	root, err := scanHash(req.FormValue("merkleRoot"))
+	if err != nil {
+		WriteError(w, Error{"could not load merkle root of NFT"}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, ConsensusNFTEditionsGET{
+		Editions: cs.FindNFTEditions(root),
+	})
+}
+
+// consensusNFTApprovalHandler handles the API calls to
+// /consensus/nft/approval.
+func consensusNFTApprovalHandler(cs modules.ConsensusSet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	nft, err := scanNFT(req)
+	if err != nil {
+		WriteError(w, Error{"could not load merkle root or id of NFT"}, http.StatusBadRequest)
+		return
+	}
+	approval, err := cs.ViewNFTApproval(nft)
+	if err != nil {
+		WriteError(w, Error{"NFT has no approved operator"}, http.StatusNotFound)
+		return
+	}
+	WriteJSON(w, approval)
+}
+
+// consensusNFTDisputeHandler handles the API calls to /consensus/nft/dispute.
+func consensusNFTDisputeHandler(cs modules.ConsensusSet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	nft, err := scanNFT(req)
+	if err != nil {
+		WriteError(w, Error{"could not load merkle root or id of NFT"}, http.StatusBadRequest)
+		return
+	}
+	dispute, err := cs.ViewNFTDispute(nft)
+	if err != nil {
+		WriteError(w, Error{"NFT was never flagged as disputed"}, http.StatusNotFound)
+		return
+	}
+	WriteJSON(w, ConsensusNFTDisputeGET{
+		NFTDispute: dispute,
+		Frozen:     dispute.Frozen(),
+	})
+}
+
+// consensusNFTDisputesHandler handles the API calls to
+// /consensus/nft/disputes.
+func consensusNFTDisputesHandler(cs modules.ConsensusSet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	disputes, err := cs.NFTDisputes()
+	if err != nil {
+		WriteError(w, Error{"unable to get nft disputes: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	resp := ConsensusNFTDisputesGET{Disputes: make([]ConsensusNFTDisputeGET, 0, len(disputes))}
+	for _, dispute := range disputes {
+		resp.Disputes = append(resp.Disputes, ConsensusNFTDisputeGET{
+			NFTDispute: dispute,
+			Frozen:     dispute.Frozen(),
+		})
+	}
+	WriteJSON(w, resp)
+}
+
+// consensusNFTLicenseHandler handles the API calls to /consensus/nft/license.
+func consensusNFTLicenseHandler(cs modules.ConsensusSet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	nft, err := scanNFT(req)
+	if err != nil {
+		WriteError(w, Error{"could not load merkle root or id of NFT"}, http.StatusBadRequest)
+		return
+	}
+	license, err := cs.ViewNFTLicense(nft)
+	if err != nil {
+		WriteError(w, Error{"NFT was minted without a license"}, http.StatusNotFound)
+		return
+	}
+	WriteJSON(w, license)
+}
+
+// consensusNFTPolicyHandler handles the API calls to /consensus/nft/policy.
+func consensusNFTPolicyHandler(cs modules.ConsensusSet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	nft, err := scanNFT(req)
+	if err != nil {
+		WriteError(w, Error{"could not load merkle root or id of NFT"}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, cs.ViewNFTTransferPolicy(nft))
+}
+
+// consensusNFTStakeHandler handles the API calls to /consensus/nft/stake.
+func consensusNFTStakeHandler(cs modules.ConsensusSet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	root, err := scanHash(req.FormValue("merkleRoot"))
+	if err != nil {
+		WriteError(w, Error{"could not load merkle root of NFT"}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, cs.ViewNFTRootStake(root))
+}
+
+// consensusNFTSnapshotHandler handles the API calls to
+// /consensus/nft/snapshot.
+func consensusNFTSnapshotHandler(cs modules.ConsensusSet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	height, err := strconv.ParseUint(req.FormValue("height"), 10, 64)
+	if err != nil {
+		WriteError(w, Error{"could not read height: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	snapshot, err := cs.NFTIndexSnapshot(types.BlockHeight(height))
+	if err != nil {
+		WriteError(w, Error{"no NFT index snapshot at this height"}, http.StatusNotFound)
+		return
+	}
+	WriteJSON(w, ConsensusNFTSnapshotGET{
+		Height:  snapshot.Height,
+		BlockID: snapshot.BlockID,
+		Root:    snapshot.Root(),
+		Entries: len(snapshot.Entries),
+	})
+}
+
+// consensusNFTCheckpointHandlerPOST handles the API calls to
+// /consensus/nft/checkpoint.
+func consensusNFTCheckpointHandlerPOST(cs modules.ConsensusSet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var cp modules.NFTIndexCheckpoint
+	err := json.NewDecoder(req.Body).Decode(&cp)
+	if err != nil {
+		WriteError(w, Error{"could not decode checkpoint: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	err = cs.AddNFTIndexCheckpoint(cp)
+	if err != nil {
+		WriteError(w, Error{"could not add checkpoint: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// consensusNFTStatsHandler handles the API calls to /consensus/nft/stats.
+func consensusNFTStatsHandler(cs modules.ConsensusSet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, cs.NFTStats())
+}
+
+// consensusNFTStoragePoolHandler handles the API calls to
+// /consensus/nft/storagepool.
+func consensusNFTStoragePoolHandler(cs modules.ConsensusSet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	stats := cs.NFTStats()
+	WriteJSON(w, ConsensusNFTStoragePoolGET{
+		Height:          stats.Height,
+		Balance:         stats.StoragePoolSiacoins,
+		Inflows:         stats.StoragePoolInflows(),
+		MintInflows:     stats.StoragePoolMintInflows,
+		TransferInflows: stats.StoragePoolTransferInflows,
+		TopUpInflows:    stats.StoragePoolTopUpInflows,
+		Outflows:        stats.StoragePoolOutflows,
+		Solvent:         stats.StoragePoolSolvent(),
+	})
+}
+
 // consensusSubscribeHandler handles the API calls to the /consensus/subscribe
 // endpoint.
 func consensusSubscribeHandler(cs modules.ConsensusSet, w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
@@ -24,6 +24,7 @@ import (
 
 	"gitlab.com/NebulousLabs/errors"
 	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/metrics"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/profile"
 	"go.sia.tech/siad/types"
@@ -137,6 +138,7 @@ type (
 		MaxDownloadSpeed int64         `json:"maxdownloadspeed"`
 		MaxUploadSpeed   int64         `json:"maxuploadspeed"`
 		Modules          configModules `json:"modules"`
+		ReadOnly         bool          `json:"readonly"`
 	}
 
 	// DaemonVersion holds the version information for siad
@@ -432,6 +434,18 @@ func (api *API) daemonConstantsHandler(w http.ResponseWriter, _ *http.Request, _
 	WriteJSON(w, sc)
 }
 
+// metricsHandlerGET handles the API call that requests the metrics of the
+// modules in the Prometheus text format.
+func (api *API) metricsHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	var buf bytes.Buffer
+	if err := metrics.WritePrometheus(&buf); err != nil {
+		WriteError(w, Error{"failed to write metrics: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	_, _ = w.Write(buf.Bytes())
+}
+
 // daemonStackHandlerGET handles the API call that requests the daemon's stack trace.
 func (api *API) daemonStackHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
 	// Get the stack traces of all running goroutines.
@@ -514,6 +528,7 @@ func (api *API) daemonSettingsHandlerGET(w http.ResponseWriter, _ *http.Request,
 		MaxDownloadSpeed: gmds,
 		MaxUploadSpeed:   gmus,
 		Modules:          api.staticConfigModules,
+		ReadOnly:         api.readOnly,
 	})
 }
 
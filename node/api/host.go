@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/julienschmidt/httprouter"
@@ -57,6 +58,7 @@ type (
 		NetworkMetrics       modules.HostNetworkMetrics       `json:"networkmetrics"`
 		PriceTable           modules.RPCPriceTable            `json:"pricetable"`
 		PublicKey            types.SiaPublicKey               `json:"publickey"`
+		RegistryMetrics      modules.HostRegistryMetrics      `json:"registrymetrics"`
 		WorkingStatus        modules.HostWorkingStatus        `json:"workingstatus"`
 	}
 
@@ -67,6 +69,13 @@ type (
 		ConversionRate float64        `json:"conversionrate"`
 	}
 
+	// HostNFTsGET contains the information that is returned after a GET
+	// request to /host/nfts - the NFTs the host's storage obligations were
+	// tagged with.
+	HostNFTsGET struct {
+		NFTs []modules.HostNFT `json:"nfts"`
+	}
+
 	// StorageGET contains the information that is returned after a GET request
 	// to /host/storage - a bunch of information about the status of storage
 	// management on the host.
@@ -93,6 +102,9 @@ func RegisterRoutesHost(router *httprouter.Router, h modules.Host, deps modules.
 	router.GET("/host/contracts/:contractID", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		hostContractGetHandler(h, w, req, ps)
 	})
+	router.GET("/host/nfts", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostNFTsHandlerGET(h, w, req, ps)
+	})
 	router.GET("/host/bandwidth", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		hostBandwidthHandlerGET(h, w, req, ps)
 	})
@@ -159,6 +171,19 @@ func hostContractInfoHandler(host modules.Host, w http.ResponseWriter, _ *http.R
 	WriteJSON(w, cg)
 }
 
+// hostNFTsHandlerGET handles the API call to get the NFTs the host's storage
+// obligations were tagged with.
+func hostNFTsHandlerGET(host modules.Host, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	nfts, err := host.HostedNFTs()
+	if err != nil {
+		WriteError(w, Error{"failed to get hosted nfts: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, HostNFTsGET{
+		NFTs: nfts,
+	})
+}
+
 // hostHandlerGET handles GET requests to the /host API endpoint, returning key
 // information about the host.
 func hostHandlerGET(host modules.Host, w http.ResponseWriter, deps modules.Dependencies, _ *http.Request, _ httprouter.Params) {
@@ -170,6 +195,7 @@ func hostHandlerGET(host modules.Host, w http.ResponseWriter, deps modules.Depen
 	ws := host.WorkingStatus()
 	pk := host.PublicKey()
 	pt := host.PriceTable()
+	rm := host.RegistryMetrics()
 	hg := HostGET{
 		ConnectabilityStatus: cs,
 		ExternalSettings:     es,
@@ -178,6 +204,7 @@ func hostHandlerGET(host modules.Host, w http.ResponseWriter, deps modules.Depen
 		NetworkMetrics:       nm,
 		PriceTable:           pt,
 		PublicKey:            pk,
+		RegistryMetrics:      rm,
 		WorkingStatus:        ws,
 	}
 
@@ -331,6 +358,30 @@ func parseHostSettings(host modules.Host, req *http.Request) (modules.HostIntern
 		}
 		settings.MinUploadBandwidthPrice = x
 	}
+	if req.FormValue("minnftstorageprice") != "" {
+		var x types.Currency
+		_, err := fmt.Sscan(req.FormValue("minnftstorageprice"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.MinNFTStoragePrice = x
+	}
+	if req.FormValue("nftretentionperiod") != "" {
+		var x types.BlockHeight
+		_, err := fmt.Sscan(req.FormValue("nftretentionperiod"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.NFTRetentionPeriod = x
+	}
+	if req.FormValue("nftstoragepool") != "" {
+		var x bool
+		_, err := fmt.Sscan(req.FormValue("nftstoragepool"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.NFTStoragePool = x
+	}
 	if req.FormValue("ephemeralaccountexpiry") != "" {
 		var x uint64
 		_, err := fmt.Sscan(req.FormValue("ephemeralaccountexpiry"), &x)
@@ -366,6 +417,60 @@ func parseHostSettings(host modules.Host, req *http.Request) (modules.HostIntern
 	if req.FormValue("customregistrypath") != "" {
 		settings.CustomRegistryPath = req.FormValue("customregistrypath")
 	}
+	if req.FormValue("registryretention") != "" {
+		var x types.BlockHeight
+		_, err := fmt.Sscan(req.FormValue("registryretention"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.RegistryRetention = x
+	}
+	if req.FormValue("maxregistryentryttl") != "" {
+		var x types.BlockHeight
+		_, err := fmt.Sscan(req.FormValue("maxregistryentryttl"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.MaxRegistryEntryTTL = x
+	}
+	if req.FormValue("freeregistryreads") != "" {
+		var x uint64
+		_, err := fmt.Sscan(req.FormValue("freeregistryreads"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.FreeRegistryReads = x
+	}
+	if req.FormValue("maxcontractbandwidth") != "" {
+		var x uint64
+		_, err := fmt.Sscan(req.FormValue("maxcontractbandwidth"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.MaxContractBandwidth = x
+	}
+	if req.FormValue("registrycompactindex") != "" {
+		var x bool
+		_, err := fmt.Sscan(req.FormValue("registrycompactindex"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.RegistryCompactIndex = x
+	}
+	if _, ok := req.Form["registryrejectedtypes"]; ok {
+		var rejected []modules.RegistryEntryType
+		for _, name := range strings.Split(req.FormValue("registryrejectedtypes"), ",") {
+			if name == "" {
+				continue
+			}
+			var t modules.RegistryEntryType
+			if err := t.UnmarshalText([]byte(name)); err != nil {
+				return modules.HostInternalSettings{}, err
+			}
+			rejected = append(rejected, t)
+		}
+		settings.RegistryRejectedTypes = rejected
+	}
 
 	// Validate the RPC, Sector Access, and Download Prices
 	minBaseRPCPrice := settings.MinBaseRPCPrice
@@ -419,6 +524,9 @@ func hostEstimateScoreGET(host modules.Host, renter modules.Renter, w http.Respo
 		StoragePrice:           settings.MinStoragePrice,
 		UploadBandwidthPrice:   settings.MinUploadBandwidthPrice,
 
+		NFTStoragePrice:    settings.MinNFTStoragePrice,
+		NFTRetentionPeriod: settings.NFTRetentionPeriod,
+
 		EphemeralAccountExpiry:     settings.EphemeralAccountExpiry,
 		MaxEphemeralAccountBalance: settings.MaxEphemeralAccountBalance,
 
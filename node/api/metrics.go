@@ -0,0 +1,11 @@
+package api
+
+import (
+	"go.sia.tech/siad/metrics"
+)
+
+var (
+	// rateLimitedRequestsMetric counts the API requests rejected by the API
+	// rate limits.
+	rateLimitedRequestsMetric = metrics.NewCounterVec("siad_api_rate_limited_requests_total", "Number of API requests rejected by the API rate limits, by rate limited path.", "path")
+)
@@ -0,0 +1,195 @@
+// Package nftgateway implements a read-only HTTP gateway serving the data of
+// the renter's pinned NFTs by their merkle root. Unlike the API, the gateway
+// requires neither a password nor a user agent, so browsers and marketplaces
+// can embed the content directly, e.g. with <img src="/nft/[merkle root]">.
+package nftgateway
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter"
+	"go.sia.tech/siad/types/nftschema"
+)
+
+const (
+	// nftPathPrefix is the path prefix of the gateway's content.
+	nftPathPrefix = "/nft/"
+
+	// cacheControl is the Cache-Control header of the gateway's responses.
+	// The data of an NFT is addressed by its merkle root and never changes.
+	cacheControl = "public, max-age=31536000, immutable"
+)
+
+// ComplianceHook returns true if the data of a merkle root must not be served,
+// e.g. because an NFT with that data is frozen by a dispute.
+type ComplianceHook func(root crypto.Hash) bool
+
+// Gateway serves the data of the renter's pinned NFTs.
+type Gateway struct {
+	staticRenter     modules.Renter
+	staticCompliance ComplianceHook
+}
+
+// New creates a gateway for the renter. If compliance isn't nil, the gateway
+// refuses to serve the data it blocks.
+func New(r modules.Renter, compliance ComplianceHook) *Gateway {
+	return &Gateway{
+		staticRenter:     r,
+		staticCompliance: compliance,
+	}
+}
+
+// ServeHTTP implements http.Handler. It serves GET and HEAD requests for
+// /nft/[merkle root] with the data of the NFT and for
+// /nft/[merkle root]/preview/[size] with the preview of an image NFT and for
+// /nft/[merkle root]/metadata with the NFT's data rendered as validated
+// nftschema metadata. The
+// content type is derived from the extension of the pin's source or sniffed
+// from the data, and range requests are supported. Data blocked by the
+// compliance hook is answered with 451 Unavailable For Legal Reasons.
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	if req.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Range")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD, OPTIONS")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !strings.HasPrefix(req.URL.Path, nftPathPrefix) {
+		http.NotFound(w, req)
+		return
+	}
+	parts := strings.Split(strings.TrimPrefix(req.URL.Path, nftPathPrefix), "/")
+	var root crypto.Hash
+	if err := root.LoadString(parts[0]); err != nil {
+		http.Error(w, "invalid merkle root", http.StatusBadRequest)
+		return
+	}
+	if g.staticCompliance != nil && g.staticCompliance(root) {
+		http.Error(w, "nft is unavailable for legal reasons", http.StatusUnavailableForLegalReasons)
+		return
+	}
+	switch {
+	case len(parts) == 1:
+		g.serveData(w, req, root)
+	case len(parts) == 2 && parts[1] == "metadata":
+		g.serveMetadata(w, req, root)
+	case len(parts) == 3 && parts[1] == "preview":
+		g.servePreview(w, req, root, parts[2])
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+// serveData serves the data of an NFT.
+func (g *Gateway) serveData(w http.ResponseWriter, req *http.Request, root crypto.Hash) {
+	pin, err := g.staticRenter.NFTPin(root)
+	if errors.Contains(err, renter.ErrNFTNotPinned) {
+		http.NotFound(w, req)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	_, streamer, err := g.staticRenter.Streamer(pin.SiaPath, false)
+	if err != nil {
+		http.Error(w, "unable to stream the nft's data: "+err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer func() {
+		_ = streamer.Close()
+	}()
+
+	// The merkle root is a strong validator for the data, which allows
+	// ServeContent to handle conditional requests.
+	w.Header().Set("ETag", `"`+root.String()+`"`)
+	w.Header().Set("Cache-Control", cacheControl)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, req, filepath.Base(pin.Source), time.Unix(int64(pin.PinTime), 0), streamer)
+}
+
+// serveMetadata serves the data of an NFT as a canonical nftschema metadata
+// document. Data which isn't valid metadata is answered with 422
+// Unprocessable Entity. The query parameter strict enables the strict
+// validation of the metadata.
+func (g *Gateway) serveMetadata(w http.ResponseWriter, req *http.Request, root crypto.Hash) {
+	strict, _ := strconv.ParseBool(req.FormValue("strict"))
+	pin, err := g.staticRenter.NFTPin(root)
+	if errors.Contains(err, renter.ErrNFTNotPinned) {
+		http.NotFound(w, req)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	_, streamer, err := g.staticRenter.Streamer(pin.SiaPath, false)
+	if err != nil {
+		http.Error(w, "unable to stream the nft's data: "+err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer func() {
+		_ = streamer.Close()
+	}()
+	b, err := ioutil.ReadAll(io.LimitReader(streamer, nftschema.MaxMetadataSize+1))
+	if err != nil {
+		http.Error(w, "unable to read the nft's data: "+err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	md, err := nftschema.Parse(b, strict)
+	if err != nil {
+		http.Error(w, "invalid metadata: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", cacheControl)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if req.Method == http.MethodHead {
+		return
+	}
+	_ = json.NewEncoder(w).Encode(md)
+}
+
+// servePreview serves the preview of an image NFT. Previews are generated by
+// the renter when the NFT is pinned, so galleries can render them without
+// downloading the NFT's data from the hosts.
+func (g *Gateway) servePreview(w http.ResponseWriter, req *http.Request, root crypto.Hash, sizeStr string) {
+	size, err := strconv.Atoi(sizeStr)
+	valid := false
+	for _, s := range modules.NFTPreviewSizes {
+		valid = valid || s == size
+	}
+	if err != nil || !valid {
+		http.Error(w, fmt.Sprintf("invalid preview size, valid sizes are %v", modules.NFTPreviewSizes), http.StatusBadRequest)
+		return
+	}
+	preview, err := g.staticRenter.NFTPreview(root, size)
+	if errors.Contains(err, renter.ErrNFTNotPinned) || errors.Contains(err, renter.ErrNoNFTPreview) {
+		http.NotFound(w, req)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("ETag", `"`+root.String()+"-"+sizeStr+`"`)
+	w.Header().Set("Cache-Control", cacheControl)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, req, "", time.Time{}, bytes.NewReader(preview))
+}
@@ -0,0 +1,199 @@
+package nftgateway
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter"
+	"go.sia.tech/siad/types/nftschema"
+)
+
+// testRenter is a renter which streams the data of pinned NFTs from their
+// source.
+type testRenter struct {
+	modules.Renter
+	pins     map[crypto.Hash]modules.NFTPin
+	previews map[crypto.Hash][]byte
+}
+
+// NFTPin implements modules.Renter.
+func (r *testRenter) NFTPin(root crypto.Hash) (modules.NFTPin, error) {
+	pin, exists := r.pins[root]
+	if !exists {
+		return modules.NFTPin{}, renter.ErrNFTNotPinned
+	}
+	return pin, nil
+}
+
+// NFTPreview implements modules.Renter.
+func (r *testRenter) NFTPreview(root crypto.Hash, _ int) ([]byte, error) {
+	if _, exists := r.pins[root]; !exists {
+		return nil, renter.ErrNFTNotPinned
+	}
+	preview, exists := r.previews[root]
+	if !exists {
+		return nil, renter.ErrNoNFTPreview
+	}
+	return preview, nil
+}
+
+// Streamer implements modules.Renter.
+func (r *testRenter) Streamer(siaPath modules.SiaPath, _ bool) (string, modules.Streamer, error) {
+	for _, pin := range r.pins {
+		if pin.SiaPath == siaPath {
+			f, err := os.Open(pin.Source)
+			return siaPath.Name(), f, err
+		}
+	}
+	return "", nil, os.ErrNotExist
+}
+
+// TestGateway tests serving the data of pinned NFTs.
+func TestGateway(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Pin a PNG image and some data without an extension.
+	dir := filepath.Join(os.TempDir(), "nftgateway", t.Name())
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	r := &testRenter{
+		pins:     make(map[crypto.Hash]modules.NFTPin),
+		previews: make(map[crypto.Hash][]byte),
+	}
+	pin := func(name string, data []byte) crypto.Hash {
+		source := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(source, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+		root := crypto.MerkleRoot(data)
+		siaPath, err := modules.NFTPinFolder.Join(root.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.pins[root] = modules.NFTPin{Root: root, SiaPath: siaPath, Source: source}
+		return root
+	}
+	image := append([]byte("\x89PNG\r\n\x1a\n"), fastrand.Bytes(1000)...)
+	imageRoot := pin("image.png", image)
+	html := []byte("<!DOCTYPE html><html><body>nft</body></html>")
+	htmlRoot := pin("data", html)
+
+	frozen := make(map[crypto.Hash]bool)
+	srv := httptest.NewServer(New(r, func(root crypto.Hash) bool { return frozen[root] }))
+	defer srv.Close()
+	get := func(method, path string, header http.Header, status int) (*http.Response, []byte) {
+		req, err := http.NewRequest(method, srv.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header = header
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+		b, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != status {
+			t.Fatalf("%v %v: expected status %v but got %v: %s", method, path, status, resp.StatusCode, b)
+		}
+		return resp, b
+	}
+
+	resp, b := get(http.MethodGet, "/nft/"+imageRoot.String(), nil, http.StatusOK)
+	if !bytes.Equal(b, image) {
+		t.Fatal("wrong data")
+	}
+	if resp.Header.Get("Content-Type") != "image/png" {
+		t.Fatal("wrong content type", resp.Header.Get("Content-Type"))
+	}
+	if resp.Header.Get("Access-Control-Allow-Origin") != "*" {
+		t.Fatal("missing CORS header")
+	}
+	etag := resp.Header.Get("ETag")
+	if etag != `"`+imageRoot.String()+`"` {
+		t.Fatal("wrong ETag", etag)
+	}
+
+	// Range and conditional requests.
+	_, b = get(http.MethodGet, "/nft/"+imageRoot.String(), http.Header{"Range": []string{"bytes=10-19"}}, http.StatusPartialContent)
+	if !bytes.Equal(b, image[10:20]) {
+		t.Fatal("wrong range")
+	}
+	get(http.MethodGet, "/nft/"+imageRoot.String(), http.Header{"If-None-Match": []string{etag}}, http.StatusNotModified)
+	resp, b = get(http.MethodHead, "/nft/"+imageRoot.String(), nil, http.StatusOK)
+	if len(b) != 0 || resp.ContentLength != int64(len(image)) {
+		t.Fatal("wrong head response", resp.ContentLength, len(b))
+	}
+
+	// The content type of data without an extension is sniffed.
+	resp, _ = get(http.MethodGet, "/nft/"+htmlRoot.String(), nil, http.StatusOK)
+	if resp.Header.Get("Content-Type") != "text/html; charset=utf-8" {
+		t.Fatal("wrong content type", resp.Header.Get("Content-Type"))
+	}
+
+	// Previews of image NFTs.
+	preview := append([]byte("\x89PNG\r\n\x1a\n"), fastrand.Bytes(100)...)
+	r.previews[imageRoot] = preview
+	size := strconv.Itoa(modules.NFTPreviewSizes[0])
+	resp, b = get(http.MethodGet, "/nft/"+imageRoot.String()+"/preview/"+size, nil, http.StatusOK)
+	if !bytes.Equal(b, preview) {
+		t.Fatal("wrong preview")
+	}
+	if resp.Header.Get("Content-Type") != "image/png" || resp.Header.Get("Cache-Control") != cacheControl {
+		t.Fatal("wrong headers", resp.Header)
+	}
+	get(http.MethodGet, "/nft/"+htmlRoot.String()+"/preview/"+size, nil, http.StatusNotFound)
+	get(http.MethodGet, "/nft/"+imageRoot.String()+"/preview/1", nil, http.StatusBadRequest)
+	get(http.MethodGet, "/nft/"+imageRoot.String()+"/other", nil, http.StatusNotFound)
+
+	// Metadata is validated before it is rendered.
+	md := nftschema.Metadata{Version: nftschema.CurrentVersion, Title: "nft", Creator: "creator", ContentHash: imageRoot}
+	mdJSON, err := json.Marshal(md)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mdRoot := pin("metadata.json", mdJSON)
+	resp, b = get(http.MethodGet, "/nft/"+mdRoot.String()+"/metadata", nil, http.StatusOK)
+	var rendered nftschema.Metadata
+	if err := json.Unmarshal(b, &rendered); err != nil {
+		t.Fatal(err)
+	}
+	if rendered.Title != md.Title || rendered.ContentHash != imageRoot || resp.Header.Get("Content-Type") != "application/json" {
+		t.Fatal("wrong metadata", rendered, resp.Header)
+	}
+	get(http.MethodGet, "/nft/"+mdRoot.String()+"/metadata?strict=true", nil, http.StatusUnprocessableEntity)
+	get(http.MethodGet, "/nft/"+htmlRoot.String()+"/metadata", nil, http.StatusUnprocessableEntity)
+
+	// Data blocked by the compliance hook isn't served.
+	frozen[imageRoot] = true
+	get(http.MethodGet, "/nft/"+imageRoot.String(), nil, http.StatusUnavailableForLegalReasons)
+	get(http.MethodGet, "/nft/"+imageRoot.String()+"/preview/"+size, nil, http.StatusUnavailableForLegalReasons)
+	delete(frozen, imageRoot)
+	get(http.MethodGet, "/nft/"+imageRoot.String(), nil, http.StatusOK)
+
+	// Errors.
+	get(http.MethodGet, "/nft/"+crypto.MerkleRoot([]byte("unknown")).String(), nil, http.StatusNotFound)
+	get(http.MethodGet, "/nft/invalid", nil, http.StatusBadRequest)
+	get(http.MethodGet, "/other", nil, http.StatusNotFound)
+	get(http.MethodPost, "/nft/"+imageRoot.String(), nil, http.StatusMethodNotAllowed)
+}
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"go.sia.tech/siad/modules"
+)
+
+// apiRateLimitMaxBuckets is the number of buckets after which the rate limiter
+// starts dropping the buckets of idle clients.
+const apiRateLimitMaxBuckets = 10000
+
+type (
+	// DaemonRateLimitsGet contains the API rate limits of the daemon.
+	DaemonRateLimitsGet struct {
+		RateLimits map[string]uint64 `json:"ratelimits"`
+	}
+
+	// apiRateLimiter enforces the API rate limits of the config. Every client
+	// gets a token bucket per rate limited path which holds up to a minute's
+	// worth of requests.
+	apiRateLimiter struct {
+		buckets map[apiRateLimitBucketID]*apiRateLimitBucket
+		mu      sync.Mutex
+
+		staticCfg *modules.SiadConfig
+	}
+
+	// apiRateLimitBucketID identifies the bucket of a client for a rate
+	// limited path.
+	apiRateLimitBucketID struct {
+		client string
+		prefix string
+	}
+
+	// apiRateLimitBucket contains the requests a client may still send to a
+	// rate limited path.
+	apiRateLimitBucket struct {
+		tokens     float64
+		lastUpdate time.Time
+	}
+)
+
+// newAPIRateLimiter creates a rate limiter for the limits of cfg.
+func newAPIRateLimiter(cfg *modules.SiadConfig) *apiRateLimiter {
+	return &apiRateLimiter{
+		buckets:   make(map[apiRateLimitBucketID]*apiRateLimitBucket),
+		staticCfg: cfg,
+	}
+}
+
+// apiRateLimitClient returns the identifier of the client sending the request.
+// Requests authenticated with an API key are limited per key, all others per
+// IP address.
+func apiRateLimitClient(req *http.Request) string {
+	if key, ok := requestAPIKey(req); ok {
+		return "apikey:" + key.Name
+	}
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
+
+// managedAllow takes a token from the client's bucket for the rate limited
+// path. If the bucket is empty, false is returned together with the time until
+// the next token is available.
+func (rl *apiRateLimiter) managedAllow(client, prefix string, limit uint64, now time.Time) (bool, time.Duration) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if len(rl.buckets) >= apiRateLimitMaxBuckets {
+		rl.pruneBuckets(now)
+	}
+	id := apiRateLimitBucketID{client: client, prefix: prefix}
+	b, exists := rl.buckets[id]
+	if !exists {
+		b = &apiRateLimitBucket{tokens: float64(limit), lastUpdate: now}
+		rl.buckets[id] = b
+	}
+	// Refill the bucket.
+	perSecond := float64(limit) / time.Minute.Seconds()
+	b.tokens = math.Min(float64(limit), b.tokens+now.Sub(b.lastUpdate).Seconds()*perSecond)
+	b.lastUpdate = now
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / perSecond * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// pruneBuckets drops the buckets which haven't been used for a minute. These
+// buckets are full again, so dropping them doesn't change the limits.
+func (rl *apiRateLimiter) pruneBuckets(now time.Time) {
+	for id, b := range rl.buckets {
+		if now.Sub(b.lastUpdate) >= time.Minute {
+			delete(rl.buckets, id)
+		}
+	}
+}
+
+// withRateLimits is middleware that rejects requests with status 429 once a
+// client exceeds the rate limit of the requested path. It needs to be wrapped
+// by WithAPIKeys to limit requests with API keys per key.
+func withRateLimits(h http.Handler, rl *apiRateLimiter) http.Handler {
+	if rl == nil {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		prefix, limit, ok := rl.staticCfg.APIRateLimit(canonicalWalletPath(req.URL.Path))
+		if ok {
+			allowed, retryAfter := rl.managedAllow(apiRateLimitClient(req), prefix, limit, time.Now())
+			if !allowed {
+				rateLimitedRequestsMetric.With(prefix).Inc()
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				WriteError(w, Error{fmt.Sprintf("rate limit of %v requests per minute exceeded for %v", limit, prefix)}, http.StatusTooManyRequests)
+				return
+			}
+		}
+		h.ServeHTTP(w, req)
+	})
+}
+
+// daemonRateLimitsHandlerGET handles the API call listing the daemon's API rate
+// limits.
+func (api *API) daemonRateLimitsHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, DaemonRateLimitsGet{RateLimits: api.siadConfig.APIRateLimitList()})
+}
+
+// daemonRateLimitsHandlerPOST handles the API call changing the API rate limit
+// of a path.
+func (api *API) daemonRateLimitsHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var limit uint64
+	if _, err := fmt.Sscan(req.FormValue("limit"), &limit); err != nil {
+		WriteError(w, Error{"unable to parse limit: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err := api.siadConfig.SetAPIRateLimit(req.FormValue("path"), limit); err != nil {
+		WriteError(w, Error{"unable to set rate limit: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+// TestAPIRateLimiter tests that the rate limiter allows a minute's worth of
+// requests per client and path and refills the buckets over time.
+func TestAPIRateLimiter(t *testing.T) {
+	rl := newAPIRateLimiter(nil)
+	now := time.Now()
+
+	// The first 60 requests are allowed, the 61st is rejected.
+	for i := 0; i < 60; i++ {
+		if ok, _ := rl.managedAllow("client", "/renter/contracts", 60, now); !ok {
+			t.Fatal("request was rejected", i)
+		}
+	}
+	ok, retryAfter := rl.managedAllow("client", "/renter/contracts", 60, now)
+	if ok {
+		t.Fatal("request should be rejected")
+	}
+	if retryAfter <= 0 || retryAfter > time.Second {
+		t.Fatal("unexpected retry after", retryAfter)
+	}
+
+	// Other clients and other paths have their own buckets.
+	if ok, _ := rl.managedAllow("other", "/renter/contracts", 60, now); !ok {
+		t.Fatal("request of other client was rejected")
+	}
+	if ok, _ := rl.managedAllow("client", "/wallet/nft/audit", 60, now); !ok {
+		t.Fatal("request to other path was rejected")
+	}
+
+	// After a second the client may send another request.
+	now = now.Add(time.Second)
+	if ok, _ := rl.managedAllow("client", "/renter/contracts", 60, now); !ok {
+		t.Fatal("request was rejected after refill")
+	}
+	if ok, _ := rl.managedAllow("client", "/renter/contracts", 60, now); ok {
+		t.Fatal("request should be rejected")
+	}
+
+	// Idle buckets are pruned.
+	rl.pruneBuckets(now.Add(time.Minute))
+	if len(rl.buckets) != 0 {
+		t.Fatal("idle buckets weren't pruned", len(rl.buckets))
+	}
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"net/http"
+
+	"go.sia.tech/siad/build"
+)
+
+// readOnlyPOSTRoutes are the POST endpoints served by a read-only node. They
+// only validate the data they are given against the consensus set and don't
+// change the state of the node.
+var readOnlyPOSTRoutes = map[string]struct{}{
+	"/consensus/validate/reserves":       {},
+	"/consensus/validate/transactionset": {},
+}
+
+// isReadOnlyRequest returns true if a read-only node serves the request. Apart
+// from the password protected /daemon/stop, GET endpoints don't change the
+// state of the node.
+func isReadOnlyRequest(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		return true
+	case http.MethodPost:
+		_, allowed := readOnlyPOSTRoutes[req.URL.Path]
+		return allowed
+	}
+	return false
+}
+
+// withReadOnly is middleware that restricts the API of a read-only node to the
+// endpoints which don't change the state of the node or the network, so that
+// indexing nodes can expose their API to untrusted clients.
+func withReadOnly(h http.Handler, readOnly bool) http.Handler {
+	if !readOnly {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !isReadOnlyRequest(req) {
+			WriteError(w, Error{"API call is not available on a read-only node"}, http.StatusForbidden)
+			return
+		}
+		h.ServeHTTP(w, req)
+	})
+}
+
+// SetReadOnly restricts the API to the read-only endpoints. It needs to be
+// called before the modules are set.
+func (api *API) SetReadOnly() {
+	if api.modulesSet {
+		build.Critical("can't call SetReadOnly after SetModules")
+	}
+	api.readOnly = true
+	api.buildHTTPRoutes()
+}
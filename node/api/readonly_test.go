@@ -0,0 +1,45 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestWithReadOnly tests that the read-only middleware only passes on requests
+// to read-only endpoints.
+func TestWithReadOnly(t *testing.T) {
+	h := withReadOnly(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		WriteSuccess(w)
+	}), true)
+
+	tests := []struct {
+		method string
+		path   string
+		code   int
+	}{
+		{http.MethodGet, "/consensus", http.StatusNoContent},
+		{http.MethodGet, "/consensus/nft/stats", http.StatusNoContent},
+		{http.MethodPost, "/consensus/validate/transactionset", http.StatusNoContent},
+		{http.MethodPost, "/tpool/raw", http.StatusForbidden},
+		{http.MethodPost, "/gateway/connect/127.0.0.1:9981", http.StatusForbidden},
+		{http.MethodPost, "/daemon/settings", http.StatusForbidden},
+		{http.MethodDelete, "/consensus", http.StatusForbidden},
+	}
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(test.method, test.path, nil))
+		if rec.Code != test.code {
+			t.Errorf("%v %v: expected status %v but got %v", test.method, test.path, test.code, rec.Code)
+		}
+	}
+
+	// Without the read-only flag all requests are passed on.
+	rec := httptest.NewRecorder()
+	withReadOnly(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		WriteSuccess(w)
+	}), false).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/tpool/raw", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Fatal("request was rejected by a node which isn't read-only", rec.Code)
+	}
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -155,6 +156,23 @@ type (
 		ExpiredContracts          []RenterContract              `json:"expiredcontracts"`
 		ExpiredRefreshedContracts []RenterContract              `json:"expiredrefreshedcontracts"`
 		RecoverableContracts      []modules.RecoverableContract `json:"recoverablecontracts"`
+
+		// TotalContracts is the number of contracts matching the filters of
+		// a paginated listing, of which Contracts contains the requested
+		// page.
+		TotalContracts uint64 `json:"totalcontracts"`
+	}
+
+	// RenterContractReceipts contains the receipts of the contracts the
+	// renter formed and renewed.
+	RenterContractReceipts struct {
+		Receipts []modules.ContractReceipt `json:"receipts"`
+	}
+
+	// RenterContractRenewalChain contains the renewal chain of a contract,
+	// ordered from the oldest to the newest contract.
+	RenterContractRenewalChain struct {
+		Chain []modules.ContractRenewalLink `json:"chain"`
 	}
 
 	// RenterDirectory lists the files and directories contained in the queried
@@ -222,6 +240,26 @@ type (
 		UnsyncedHosts []types.SiaPublicKey   `json:"unsyncedhosts"`
 	}
 
+	// RenterNFTPinsGET lists the renter's pinned NFTs and the health of their
+	// data.
+	RenterNFTPinsGET struct {
+		Pins []modules.NFTPinInfo `json:"pins"`
+	}
+
+	// RenterNFTBundleExportPOSTParams contains the siafiles of an NFT
+	// collection exported by a POST call to /renter/nft/bundle/export.
+	RenterNFTBundleExportPOSTParams struct {
+		Collection string            `json:"collection"`
+		SiaPaths   []modules.SiaPath `json:"siapaths"`
+	}
+
+	// RenterNFTBundleImportPOSTParams contains the bundle imported by a POST
+	// call to /renter/nft/bundle/import and the folder it is imported to.
+	RenterNFTBundleImportPOSTParams struct {
+		Bundle  modules.NFTBundle `json:"bundle"`
+		SiaPath modules.SiaPath   `json:"siapath"`
+	}
+
 	// RenterUploadReadyGet lists the upload ready status of the renter
 	RenterUploadReadyGet struct {
 		// Ready indicates whether of not the renter is ready to successfully
@@ -588,6 +626,132 @@ func (api *API) renterLoadBackupHandlerPOST(w http.ResponseWriter, req *http.Req
 	WriteSuccess(w)
 }
 
+// renterNFTPinsHandlerGET handles the API calls to /renter/nft/pins
+func (api *API) renterNFTPinsHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	pins, err := api.renter.NFTPins()
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterNFTPinsGET{
+		Pins: pins,
+	})
+}
+
+// renterNFTHealthHandlerGET handles the API calls to /renter/nft/health
+func (api *API) renterNFTHealthHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	root, err := scanHash(req.FormValue("merkleRoot"))
+	if err != nil {
+		WriteError(w, Error{"could not load merkle root of NFT"}, http.StatusBadRequest)
+		return
+	}
+	health, err := api.renter.NFTHealth(root)
+	if err != nil {
+		WriteError(w, Error{"failed to get nft health: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, health)
+}
+
+// renterNFTPinHandlerPOST handles the API calls to /renter/nft/pin
+func (api *API) renterNFTPinHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	root, err := scanHash(req.FormValue("merkleRoot"))
+	if err != nil {
+		WriteError(w, Error{"could not load merkle root of NFT"}, http.StatusBadRequest)
+		return
+	}
+	// Check that source was specified.
+	src := req.FormValue("source")
+	if src == "" {
+		WriteError(w, Error{"source not specified"}, http.StatusBadRequest)
+		return
+	}
+	// The source needs to be an absolute path.
+	if !filepath.IsAbs(src) {
+		WriteError(w, Error{"source must be an absolute path"}, http.StatusBadRequest)
+		return
+	}
+	if err := api.renter.PinNFT(root, src); err != nil {
+		WriteError(w, Error{"failed to pin nft: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterNFTUnpinHandlerPOST handles the API calls to /renter/nft/unpin
+func (api *API) renterNFTUnpinHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	root, err := scanHash(req.FormValue("merkleRoot"))
+	if err != nil {
+		WriteError(w, Error{"could not load merkle root of NFT"}, http.StatusBadRequest)
+		return
+	}
+	if err := api.renter.UnpinNFT(root); err != nil {
+		WriteError(w, Error{"failed to unpin nft: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterNFTBundleExportHandlerPOST handles the API calls to
+// /renter/nft/bundle/export
+func (api *API) renterNFTBundleExportHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var params RenterNFTBundleExportPOSTParams
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	siaPaths := make([]modules.SiaPath, 0, len(params.SiaPaths))
+	for _, siaPath := range params.SiaPaths {
+		siaPath, err = rebaseInputSiaPath(siaPath)
+		if err != nil {
+			WriteError(w, Error{"failed to rebase siapath: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		siaPaths = append(siaPaths, siaPath)
+	}
+	bundle, err := api.renter.ExportNFTBundle(params.Collection, siaPaths)
+	if err != nil {
+		WriteError(w, Error{"failed to export nft bundle: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	// The bundle refers to the files relative to the user's home.
+	for i := range bundle.Files {
+		bundle.Files[i].SiaPath = params.SiaPaths[i]
+	}
+	WriteJSON(w, bundle)
+}
+
+// renterNFTBundleImportHandlerPOST handles the API calls to
+// /renter/nft/bundle/import
+func (api *API) renterNFTBundleImportHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var params RenterNFTBundleImportPOSTParams
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	dst, err := rebaseInputSiaPath(params.SiaPath)
+	if err != nil {
+		WriteError(w, Error{"failed to rebase siapath: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	imported, err := api.renter.ImportNFTBundle(params.Bundle, dst)
+	if err != nil {
+		WriteError(w, Error{"failed to import nft bundle: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	// Return the siapaths relative to the user's home.
+	for i, siaPath := range imported.SiaPaths {
+		imported.SiaPaths[i], err = siaPath.Rebase(modules.UserFolder, modules.RootSiaPath())
+		if err != nil {
+			WriteError(w, Error{"failed to rebase siapath: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+	}
+	WriteJSON(w, imported)
+}
+
 // parseErasureCodingParameters parses the supplied string values and creates
 // an erasure coder. If values haven't been supplied it will fill in sane
 // defaults.
@@ -829,6 +993,110 @@ func (api *API) renterHandlerPOST(w http.ResponseWriter, req *http.Request, _ ht
 		}
 		settings.Allowance.MaxUploadBandwidthPrice = price
 	}
+	if str := req.FormValue("scoreleewaygoodforrenew"); str != "" {
+		var leeway uint64
+		if _, err := fmt.Sscan(str, &leeway); err != nil {
+			WriteError(w, Error{"unable to parse scoreleewaygoodforrenew: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.Allowance.ScoreLeewayGoodForRenew = leeway
+	}
+	if str := req.FormValue("scoreleewaygoodforupload"); str != "" {
+		var leeway uint64
+		if _, err := fmt.Sscan(str, &leeway); err != nil {
+			WriteError(w, Error{"unable to parse scoreleewaygoodforupload: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.Allowance.ScoreLeewayGoodForUpload = leeway
+	}
+	if str := req.FormValue("mincontractfunding"); str != "" {
+		var minFunding float64
+		if _, err := fmt.Sscan(str, &minFunding); err != nil {
+			WriteError(w, Error{"unable to parse mincontractfunding: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.Allowance.MinContractFunding = minFunding
+	}
+	if str := req.FormValue("mincontractfunduploadthreshold"); str != "" {
+		var threshold float64
+		if _, err := fmt.Sscan(str, &threshold); err != nil {
+			WriteError(w, Error{"unable to parse mincontractfunduploadthreshold: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.Allowance.MinContractFundUploadThreshold = threshold
+	}
+	if str := req.FormValue("mincontractfundrenewalthreshold"); str != "" {
+		var threshold float64
+		if _, err := fmt.Sscan(str, &threshold); err != nil {
+			WriteError(w, Error{"unable to parse mincontractfundrenewalthreshold: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.Allowance.MinContractFundRenewalThreshold = threshold
+	}
+	if str := req.FormValue("nftstorage"); str != "" {
+		nftStorage, err := scanBool(str)
+		if err != nil {
+			WriteError(w, Error{"unable to parse nftstorage: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.Allowance.NFTStorage = nftStorage
+	}
+	if str := req.FormValue("minregistryentries"); str != "" {
+		var entries uint64
+		if _, err := fmt.Sscan(str, &entries); err != nil {
+			WriteError(w, Error{"unable to parse minregistryentries: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.Allowance.MinRegistryEntries = entries
+	}
+	if str := req.FormValue("minregistryentryttl"); str != "" {
+		var ttl types.BlockHeight
+		if _, err := fmt.Sscan(str, &ttl); err != nil {
+			WriteError(w, Error{"unable to parse minregistryentryttl: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.Allowance.MinRegistryEntryTTL = ttl
+	}
+	if str := req.FormValue("nftstoragepool"); str != "" {
+		nftStoragePool, err := scanBool(str)
+		if err != nil {
+			WriteError(w, Error{"unable to parse nftstoragepool: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.Allowance.NFTStoragePool = nftStoragePool
+	}
+	if str := req.FormValue("nftrepairthreshold"); str != "" {
+		var threshold float64
+		if _, err := fmt.Sscan(str, &threshold); err != nil {
+			WriteError(w, Error{"unable to parse nftrepairthreshold: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.Allowance.NFTRepairThreshold = threshold
+	}
+	if str := req.FormValue("maxnftrepairsperhour"); str != "" {
+		var repairs uint64
+		if _, err := fmt.Sscan(str, &repairs); err != nil {
+			WriteError(w, Error{"unable to parse maxnftrepairsperhour: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.Allowance.MaxNFTRepairsPerHour = repairs
+	}
+	if str := req.FormValue("maxnftrepairspending"); str != "" {
+		spending, ok := scanAmount(str)
+		if !ok {
+			WriteError(w, Error{"unable to parse maxnftrepairspending"}, http.StatusBadRequest)
+			return
+		}
+		settings.Allowance.MaxNFTRepairSpending = spending
+	}
+	if str := req.FormValue("maxtxnfeeperbyte"); str != "" {
+		fee, ok := scanAmount(str)
+		if !ok {
+			WriteError(w, Error{"unable to parse maxtxnfeeperbyte"}, http.StatusBadRequest)
+			return
+		}
+		settings.Allowance.MaxTxnFeePerByte = fee
+	}
 
 	// Validate any allowance changes. Funds and Period are the only required
 	// fields.
@@ -945,6 +1213,36 @@ func (api *API) renterHandlerPOST(w http.ResponseWriter, req *http.Request, _ ht
 		settings.MaxUploadSpeed = uploadSpeed
 	}
 
+	// Scan the sector cache size. (optional parameter)
+	if c := req.FormValue("sectorcachesize"); c != "" {
+		var sectorCacheSize uint64
+		if _, err := fmt.Sscan(c, &sectorCacheSize); err != nil {
+			WriteError(w, Error{"unable to parse sectorcachesize: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.SectorCacheSize = sectorCacheSize
+	}
+
+	// Scan the upload schedule. (optional parameter)
+	if us := req.FormValue("uploadschedule"); us != "" {
+		uploadSchedule, err := modules.ParseUploadSchedule(us)
+		if err != nil {
+			WriteError(w, Error{"unable to parse uploadschedule: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.UploadSchedule = uploadSchedule
+	}
+
+	// Scan the nftpreviews flag. (optional parameter)
+	if np := req.FormValue("nftpreviews"); np != "" {
+		nftPreviews, err := strconv.ParseBool(np)
+		if err != nil {
+			WriteError(w, Error{"unable to parse nftpreviews: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.NFTPreviews = nftPreviews
+	}
+
 	// Scan the checkforipviolation flag.
 	if ipc := req.FormValue("checkforipviolation"); ipc != "" {
 		var ipviolationcheck bool
@@ -1084,6 +1382,30 @@ func (api *API) renterContractsHandler(w http.ResponseWriter, req *http.Request,
 		}
 	}
 
+	// Serve a filtered, sorted and paginated listing of the current contracts
+	// if any of its parameters are set.
+	q, paginated, err := parseContractQuery(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if paginated {
+		page, total, err := api.renter.QueryContracts(q)
+		if err != nil {
+			WriteError(w, Error{"unable to query contracts: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		rc := RenterContracts{
+			Contracts:      make([]RenterContract, 0, len(page)),
+			TotalContracts: total,
+		}
+		for _, c := range page {
+			rc.Contracts = append(rc.Contracts, api.renterContract(c))
+		}
+		WriteJSON(w, rc)
+		return
+	}
+
 	// Parse the renter's contracts into their appropriate categories
 	contracts := api.parseRenterContracts(disabled, inactive, expired)
 
@@ -1097,42 +1419,90 @@ func (api *API) renterContractsHandler(w http.ResponseWriter, req *http.Request,
 	WriteJSON(w, contracts)
 }
 
+// parseContractQuery parses the parameters of a filtered, sorted and paginated
+// contract listing. The returned bool indicates whether any of them were set.
+// status is a comma separated list of contract statuses, host the public key
+// of a host, sortby the field to sort by, order either asc or desc, and offset
+// and limit select the page.
+func parseContractQuery(req *http.Request) (q modules.ContractQuery, paginated bool, err error) {
+	for _, param := range []string{"status", "host", "sortby", "order", "offset", "limit"} {
+		paginated = paginated || req.FormValue(param) != ""
+	}
+	if s := req.FormValue("status"); s != "" {
+		for _, status := range strings.Split(s, ",") {
+			q.Statuses = append(q.Statuses, modules.ContractStatus(status))
+		}
+	}
+	if s := req.FormValue("host"); s != "" {
+		if err := q.Host.LoadString(s); err != nil {
+			return modules.ContractQuery{}, false, errors.AddContext(err, "unable to parse host")
+		}
+	}
+	q.SortBy = modules.ContractSortField(req.FormValue("sortby"))
+	switch order := req.FormValue("order"); order {
+	case "", "asc":
+	case "desc":
+		q.Descending = true
+	default:
+		return modules.ContractQuery{}, false, errors.New("order must be asc or desc")
+	}
+	for _, param := range []struct {
+		name string
+		dst  *uint64
+	}{
+		{"offset", &q.Offset},
+		{"limit", &q.Limit},
+	} {
+		if s := req.FormValue(param.name); s != "" {
+			if *param.dst, err = strconv.ParseUint(s, 10, 64); err != nil {
+				return modules.ContractQuery{}, false, errors.AddContext(err, "unable to parse "+param.name)
+			}
+		}
+	}
+	return q, paginated, nil
+}
+
+// renterContract converts one of the renter's current contracts into its API
+// representation.
+func (api *API) renterContract(c modules.RenterContract) RenterContract {
+	// Fetch host address
+	var netAddress modules.NetAddress
+	hdbe, exists, _ := api.renter.Host(c.HostPublicKey)
+	if exists {
+		netAddress = hdbe.NetAddress
+	}
+
+	return RenterContract{
+		BadContract:               c.Utility.BadContract,
+		DownloadSpending:          c.DownloadSpending,
+		EndHeight:                 c.EndHeight,
+		Fees:                      c.TxnFee.Add(c.SiafundFee).Add(c.ContractFee),
+		FundAccountSpending:       c.FundAccountSpending,
+		GoodForUpload:             c.Utility.GoodForUpload,
+		GoodForRenew:              c.Utility.GoodForRenew,
+		HostPublicKey:             c.HostPublicKey,
+		HostVersion:               hdbe.Version,
+		ID:                        c.ID,
+		LastTransaction:           c.Transaction,
+		NetAddress:                netAddress,
+		MaintenanceSpending:       c.MaintenanceSpending,
+		RenterFunds:               c.RenterFunds,
+		Size:                      c.Size(),
+		StartHeight:               c.StartHeight,
+		StorageSpending:           c.StorageSpending,
+		StorageSpendingDeprecated: c.StorageSpending,
+		TotalCost:                 c.TotalCost,
+		UploadSpending:            c.UploadSpending,
+	}
+}
+
 // parseRenterContracts categorized the Renter's contracts from Contracts() and
 // OldContracts().
 func (api *API) parseRenterContracts(disabled, inactive, expired bool) RenterContracts {
 	var rc RenterContracts
 	currentBlockHeight := api.cs.Height()
 	for _, c := range api.renter.Contracts() {
-		// Fetch host address
-		var netAddress modules.NetAddress
-		hdbe, exists, _ := api.renter.Host(c.HostPublicKey)
-		if exists {
-			netAddress = hdbe.NetAddress
-		}
-
-		// Build the contract.
-		contract := RenterContract{
-			BadContract:               c.Utility.BadContract,
-			DownloadSpending:          c.DownloadSpending,
-			EndHeight:                 c.EndHeight,
-			Fees:                      c.TxnFee.Add(c.SiafundFee).Add(c.ContractFee),
-			FundAccountSpending:       c.FundAccountSpending,
-			GoodForUpload:             c.Utility.GoodForUpload,
-			GoodForRenew:              c.Utility.GoodForRenew,
-			HostPublicKey:             c.HostPublicKey,
-			HostVersion:               hdbe.Version,
-			ID:                        c.ID,
-			LastTransaction:           c.Transaction,
-			NetAddress:                netAddress,
-			MaintenanceSpending:       c.MaintenanceSpending,
-			RenterFunds:               c.RenterFunds,
-			Size:                      c.Size(),
-			StartHeight:               c.StartHeight,
-			StorageSpending:           c.StorageSpending,
-			StorageSpendingDeprecated: c.StorageSpending,
-			TotalCost:                 c.TotalCost,
-			UploadSpending:            c.UploadSpending,
-		}
+		contract := api.renterContract(c)
 
 		// Determine contract status
 		refreshed := api.renter.RefreshedContract(c.ID)
@@ -1160,6 +1530,7 @@ func (api *API) parseRenterContracts(disabled, inactive, expired bool) RenterCon
 		}
 		rc.Contracts = append(rc.Contracts, contract)
 	}
+	rc.TotalContracts = uint64(len(rc.Contracts))
 
 	// Get current block height for reference
 	currentPeriod := api.renter.CurrentPeriod()
@@ -1409,8 +1780,22 @@ func (api *API) renterFuseUnmountHandlerPOST(w http.ResponseWriter, req *http.Re
 }
 
 // renterRecoveryScanHandlerPOST handles the API call to /renter/recoveryscan.
-func (api *API) renterRecoveryScanHandlerPOST(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
-	if err := api.renter.InitRecoveryScan(); err != nil {
+func (api *API) renterRecoveryScanHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Parse the 'full' parameter.
+	full := false
+	var err error
+	if f := req.FormValue("full"); f != "" {
+		full, err = scanBool(f)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'full' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	initScan := api.renter.InitRecoveryScan
+	if full {
+		initScan = api.renter.InitFullRecoveryScan
+	}
+	if err := initScan(); err != nil {
 		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
 		return
 	}
@@ -2290,6 +2675,65 @@ func (api *API) renterContractStatusHandler(w http.ResponseWriter, req *http.Req
 	WriteJSON(w, contractStatus)
 }
 
+// renterContractRenewalChainHandler handles the API call to get the renewal
+// chain of a contract.
+func (api *API) renterContractRenewalChainHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var fcID types.FileContractID
+	if err := fcID.LoadString(req.FormValue("id")); err != nil {
+		WriteError(w, Error{"unable to parse id: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	chain, err := api.renter.RenewalChain(fcID)
+	if err != nil {
+		WriteError(w, Error{"unable to get renewal chain: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	WriteJSON(w, RenterContractRenewalChain{
+		Chain: chain,
+	})
+}
+
+// renterContractReceiptsHandler handles the API call to get the receipts of
+// the renter's contracts. If an id is given, only the receipt of that contract
+// is returned.
+func (api *API) renterContractReceiptsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var fcID types.FileContractID
+	filter := req.FormValue("id") != ""
+	if filter {
+		if err := fcID.LoadString(req.FormValue("id")); err != nil {
+			WriteError(w, Error{"unable to parse id: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	receipts := []modules.ContractReceipt{}
+	for _, receipt := range api.renter.ContractReceipts() {
+		if !filter || receipt.ContractID == fcID {
+			receipts = append(receipts, receipt)
+		}
+	}
+	if filter && len(receipts) == 0 {
+		WriteError(w, Error{"no receipt found for contract " + fcID.String()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterContractReceipts{
+		Receipts: receipts,
+	})
+}
+
+// renterSpendingHandlerGET handles the API call to get the renter's spending
+// in the current period split by category and by host.
+func (api *API) renterSpendingHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	breakdown, err := api.renter.PeriodSpendingBreakdown()
+	if err != nil {
+		WriteError(w, Error{"unable to get spending breakdown: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, breakdown)
+}
+
 // renterWorkersHandler handles the API call to check the status of the renter's
 // workers
 func (api *API) renterWorkersHandler(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
@@ -35,6 +35,11 @@ func (api *API) buildHTTPRoutes() {
 
 	// Daemon API Calls
 	router.GET("/daemon/alerts", api.daemonAlertsHandlerGET)
+	router.GET("/daemon/apikeys", RequirePassword(api.daemonAPIKeysHandlerGET, requiredPassword))
+	router.POST("/daemon/apikeys", RequirePassword(api.daemonAPIKeysHandlerPOST, requiredPassword))
+	router.POST("/daemon/apikeys/remove", RequirePassword(api.daemonAPIKeysRemoveHandlerPOST, requiredPassword))
+	router.GET("/daemon/ratelimits", api.daemonRateLimitsHandlerGET)
+	router.POST("/daemon/ratelimits", RequirePassword(api.daemonRateLimitsHandlerPOST, requiredPassword))
 	router.GET("/daemon/constants", api.daemonConstantsHandler)
 	router.GET("/daemon/settings", api.daemonSettingsHandlerGET)
 	router.POST("/daemon/settings", api.daemonSettingsHandlerPOST)
@@ -46,6 +51,9 @@ func (api *API) buildHTTPRoutes() {
 	router.POST("/daemon/update", api.daemonUpdateHandlerPOST)
 	router.GET("/daemon/version", api.daemonVersionHandler)
 
+	// Metrics API Call
+	router.GET("/metrics", RequirePassword(api.metricsHandlerGET, requiredPassword))
+
 	// Consensus API Calls
 	if api.cs != nil {
 		RegisterRoutesConsensus(router, api.cs)
@@ -88,6 +96,8 @@ func (api *API) buildHTTPRoutes() {
 		router.POST("/renter/clean", RequirePassword(api.renterCleanHandlerPOST, requiredPassword))
 		router.POST("/renter/contract/cancel", RequirePassword(api.renterContractCancelHandler, requiredPassword))
 		router.GET("/renter/contracts", api.renterContractsHandler)
+		router.GET("/renter/contract/receipts", api.renterContractReceiptsHandler)
+		router.GET("/renter/contract/renewalchain", api.renterContractRenewalChainHandler)
 		router.GET("/renter/contractorchurnstatus", api.renterContractorChurnStatus)
 		router.GET("/renter/downloadinfo/*uid", api.renterDownloadByUIDHandlerGET)
 		router.GET("/renter/downloads", api.renterDownloadsHandler)
@@ -98,6 +108,13 @@ func (api *API) buildHTTPRoutes() {
 		router.GET("/renter/prices", api.renterPricesHandler)
 		router.POST("/renter/recoveryscan", RequirePassword(api.renterRecoveryScanHandlerPOST, requiredPassword))
 		router.GET("/renter/recoveryscan", api.renterRecoveryScanHandlerGET)
+		router.GET("/renter/spending", api.renterSpendingHandlerGET)
+		router.GET("/renter/nft/health", api.renterNFTHealthHandlerGET)
+		router.GET("/renter/nft/pins", api.renterNFTPinsHandlerGET)
+		router.POST("/renter/nft/pin", RequirePassword(api.renterNFTPinHandlerPOST, requiredPassword))
+		router.POST("/renter/nft/unpin", RequirePassword(api.renterNFTUnpinHandlerPOST, requiredPassword))
+		router.POST("/renter/nft/bundle/export", RequirePassword(api.renterNFTBundleExportHandlerPOST, requiredPassword))
+		router.POST("/renter/nft/bundle/import", RequirePassword(api.renterNFTBundleImportHandlerPOST, requiredPassword))
 		router.GET("/renter/fuse", api.renterFuseHandlerGET)
 		router.POST("/renter/fuse/mount", RequirePassword(api.renterFuseMountHandlerPOST, requiredPassword))
 		router.POST("/renter/fuse/unmount", RequirePassword(api.renterFuseUnmountHandlerPOST, requiredPassword))
@@ -147,14 +164,15 @@ func (api *API) buildHTTPRoutes() {
 		RegisterRoutesWallet(router, api.wallet, requiredPassword)
 	}
 
-	// Apply UserAgent middleware and return the Router
+	// Apply UserAgent, API key, rate limit and read-only middleware and return the
+	// Router
 	timeoutErr := Error{fmt.Sprintf("HTTP call exceeded the timeout of %v", httpServerTimeout)}
 	jsonErr, err := json.Marshal(timeoutErr)
 	if err != nil {
 		build.Critical("marshalling error on object that should be safe to marshal:", err)
 	}
 	api.routerMu.Lock()
-	api.router = http.TimeoutHandler(RequireUserAgent(router, requiredUserAgent), httpServerTimeout, string(jsonErr))
+	api.router = http.TimeoutHandler(RequireUserAgent(WithAPIKeys(withRateLimits(withReadOnly(router, api.readOnly), api.staticRateLimiter), api.siadConfig), requiredUserAgent), httpServerTimeout, string(jsonErr))
 	api.routerMu.Unlock()
 	return
 }
@@ -173,7 +191,8 @@ func RequireUserAgent(h http.Handler, ua string) http.Handler {
 
 // RequirePassword is middleware that requires a request to authenticate with a
 // password using HTTP basic auth. Usernames are ignored. Empty passwords
-// indicate no authentication is required.
+// indicate no authentication is required. A request authenticated with an API
+// key by WithAPIKeys is allowed if the key has the scopes of the endpoint.
 func RequirePassword(h httprouter.Handle, password string) httprouter.Handle {
 	// An empty password is equivalent to no password.
 	if password == "" {
@@ -181,16 +200,26 @@ func RequirePassword(h httprouter.Handle, password string) httprouter.Handle {
 	}
 	return func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		_, pass, ok := req.BasicAuth()
-		if !ok || pass != password {
-			w.Header().Set("WWW-Authenticate", "Basic realm=\"SiaAPI\"")
-			WriteError(w, Error{"API authentication failed."}, http.StatusUnauthorized)
+		if ok && pass == password {
+			h(w, req, ps)
+			return
+		}
+		if key, ok := requestAPIKey(req); ok {
+			if !apiKeyAllowed(key, req) {
+				WriteError(w, Error{"API key doesn't grant access to this endpoint."}, http.StatusForbidden)
+				return
+			}
+			h(w, req, ps)
 			return
 		}
-		h(w, req, ps)
+		w.Header().Set("WWW-Authenticate", "Basic realm=\"SiaAPI\"")
+		WriteError(w, Error{"API authentication failed."}, http.StatusUnauthorized)
 	}
 }
 
-// isUnrestricted checks if a request may bypass the useragent check.
+// isUnrestricted checks if a request may bypass the useragent check. Metrics
+// are scraped by monitoring systems which can't set the useragent, but the
+// endpoint still requires the API password.
 func isUnrestricted(req *http.Request) bool {
-	return strings.HasPrefix(req.URL.Path, "/renter/stream/")
+	return strings.HasPrefix(req.URL.Path, "/renter/stream/") || req.URL.Path == "/metrics"
 }
@@ -0,0 +1,373 @@
+package s3gateway
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+)
+
+// auth.go verifies the AWS Signature Version 4 of the requests sent to the
+// gateway. Only signatures in the Authorization header are supported, which is
+// what rclone and the aws-cli use by default.
+
+const (
+	// sigV4Algorithm is the signing algorithm of AWS Signature Version 4.
+	sigV4Algorithm = "AWS4-HMAC-SHA256"
+
+	// maxClockSkew is the largest difference between the date of a request
+	// and the gateway's clock.
+	maxClockSkew = 15 * time.Minute
+
+	// unsignedPayload is the payload hash of requests which don't sign their
+	// body.
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+
+	// streamingPayloadPrefix is the prefix of the payload hashes of requests
+	// sending their body with the aws-chunked encoding.
+	streamingPayloadPrefix = "STREAMING-"
+
+	// amzDateFormat is the format of the X-Amz-Date header.
+	amzDateFormat = "20060102T150405Z"
+)
+
+var (
+	// errMissingAuth is returned for requests without an Authorization
+	// header.
+	errMissingAuth = errors.New("request is not signed")
+
+	// errMalformedAuth is returned for requests with an Authorization header
+	// that can't be parsed.
+	errMalformedAuth = errors.New("authorization header is malformed")
+
+	// errUnknownAccessKey is returned for requests signed with an unknown
+	// access key ID.
+	errUnknownAccessKey = errors.New("access key id doesn't exist")
+
+	// errSignatureMismatch is returned for requests with an invalid
+	// signature.
+	errSignatureMismatch = errors.New("request signature doesn't match")
+
+	// errRequestTooSkewed is returned for requests whose date differs too
+	// much from the gateway's clock.
+	errRequestTooSkewed = errors.New("difference between the request time and the server's time is too large")
+
+	// errContentSHA256Mismatch is returned when the body of a request doesn't
+	// match its signed payload hash.
+	errContentSHA256Mismatch = errors.New("body doesn't match the x-amz-content-sha256 header")
+)
+
+// sigV4Auth is the parsed Authorization header of a signed request.
+type sigV4Auth struct {
+	accessKeyID   string
+	date          string
+	region        string
+	service       string
+	signedHeaders []string
+	signature     string
+}
+
+// parseSigV4Auth parses the Authorization header of a signed request.
+func parseSigV4Auth(header string) (sigV4Auth, error) {
+	if !strings.HasPrefix(header, sigV4Algorithm+" ") {
+		return sigV4Auth{}, errMalformedAuth
+	}
+	var auth sigV4Auth
+	for _, field := range strings.Split(strings.TrimPrefix(header, sigV4Algorithm+" "), ",") {
+		kv := strings.SplitN(strings.TrimSpace(field), "=", 2)
+		if len(kv) != 2 {
+			return sigV4Auth{}, errMalformedAuth
+		}
+		switch kv[0] {
+		case "Credential":
+			scope := strings.Split(kv[1], "/")
+			if len(scope) != 5 || scope[4] != "aws4_request" {
+				return sigV4Auth{}, errMalformedAuth
+			}
+			auth.accessKeyID, auth.date, auth.region, auth.service = scope[0], scope[1], scope[2], scope[3]
+		case "SignedHeaders":
+			auth.signedHeaders = strings.Split(kv[1], ";")
+		case "Signature":
+			auth.signature = kv[1]
+		}
+	}
+	if auth.accessKeyID == "" || len(auth.signedHeaders) == 0 || auth.signature == "" {
+		return sigV4Auth{}, errMalformedAuth
+	}
+	return auth, nil
+}
+
+// scope returns the credential scope of the signature.
+func (a sigV4Auth) scope() string {
+	return strings.Join([]string{a.date, a.region, a.service, "aws4_request"}, "/")
+}
+
+// awsURIEncode encodes s as described by the AWS Signature Version 4. All
+// bytes but unreserved characters are percent-encoded, slashes are kept if
+// keepSlash is set.
+func awsURIEncode(s string, keepSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && keepSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// canonicalQuery returns the canonical query string of a request.
+func canonicalQuery(query url.Values) string {
+	var params []string
+	for k, vs := range query {
+		for _, v := range vs {
+			params = append(params, awsURIEncode(k, false)+"="+awsURIEncode(v, false))
+		}
+	}
+	sort.Strings(params)
+	return strings.Join(params, "&")
+}
+
+// canonicalHeaders returns the canonical headers of a request for the signed
+// headers.
+func canonicalHeaders(req *http.Request, signedHeaders []string) string {
+	var b strings.Builder
+	for _, h := range signedHeaders {
+		var values []string
+		if h == "host" {
+			values = []string{req.Host}
+		} else {
+			values = append([]string(nil), req.Header.Values(h)...)
+		}
+		for i, v := range values {
+			values[i] = strings.Join(strings.Fields(v), " ")
+		}
+		b.WriteString(h + ":" + strings.Join(values, ",") + "\n")
+	}
+	return b.String()
+}
+
+// sigV4Signature computes the signature of a request with the secret access
+// key.
+func sigV4Signature(req *http.Request, auth sigV4Auth, amzDate, payloadHash, secret string) string {
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		awsURIEncode(req.URL.Path, true),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders(req, auth.signedHeaders),
+		strings.Join(auth.signedHeaders, ";"),
+		payloadHash,
+	}, "\n")
+	crHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		sigV4Algorithm,
+		amzDate,
+		auth.scope(),
+		hex.EncodeToString(crHash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+secret), auth.date)
+	key = hmacSHA256(key, auth.region)
+	key = hmacSHA256(key, auth.service)
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of data with the key.
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// verifySigV4 verifies the signature of a request at the time now and returns
+// the access key ID it was signed with. The secret of an access key ID is
+// looked up with secret. If the body of the request is signed, it is replaced
+// by a reader that fails once the body doesn't match its signed hash. Bodies
+// sent with the aws-chunked encoding are decoded, the signatures of their
+// chunks aren't verified.
+func verifySigV4(req *http.Request, now time.Time, secret func(string) (string, bool)) (string, error) {
+	header := req.Header.Get("Authorization")
+	if header == "" {
+		return "", errMissingAuth
+	}
+	auth, err := parseSigV4Auth(header)
+	if err != nil {
+		return "", err
+	}
+	amzDate := req.Header.Get("X-Amz-Date")
+	t, err := time.Parse(amzDateFormat, amzDate)
+	if err != nil || !strings.HasPrefix(amzDate, auth.date) {
+		return "", errMalformedAuth
+	}
+	if d := now.Sub(t); d > maxClockSkew || d < -maxClockSkew {
+		return "", errRequestTooSkewed
+	}
+	key, ok := secret(auth.accessKeyID)
+	if !ok {
+		return "", errUnknownAccessKey
+	}
+	payloadHash := req.Header.Get("X-Amz-Content-Sha256")
+	if payloadHash == "" {
+		payloadHash = unsignedPayload
+	}
+	signature := sigV4Signature(req, auth, amzDate, payloadHash, key)
+	if subtle.ConstantTimeCompare([]byte(signature), []byte(auth.signature)) != 1 {
+		return "", errSignatureMismatch
+	}
+	if err := wrapBody(req); err != nil {
+		return "", err
+	}
+	return auth.accessKeyID, nil
+}
+
+// wrapBody replaces the body of a request according to its
+// x-amz-content-sha256 header. Bodies sent with the aws-chunked encoding are
+// decoded and bodies with a payload hash are verified against it.
+func wrapBody(req *http.Request) error {
+	payloadHash := req.Header.Get("X-Amz-Content-Sha256")
+	switch {
+	case payloadHash == "" || payloadHash == unsignedPayload:
+	case strings.HasPrefix(payloadHash, streamingPayloadPrefix):
+		req.Body = newChunkedReader(req.Body)
+		if l, err := strconv.ParseInt(req.Header.Get("X-Amz-Decoded-Content-Length"), 10, 64); err == nil {
+			req.ContentLength = l
+		}
+	default:
+		expected, err := hex.DecodeString(payloadHash)
+		if err != nil {
+			return errMalformedAuth
+		}
+		req.Body = &hashVerifyingReader{r: req.Body, h: sha256.New(), expected: expected}
+	}
+	return nil
+}
+
+// hashVerifyingReader wraps the body of a request and returns an error
+// instead of io.EOF if the body doesn't match the expected hash.
+type hashVerifyingReader struct {
+	r        io.ReadCloser
+	h        hash.Hash
+	expected []byte
+}
+
+// Read implements io.Reader.
+func (hr *hashVerifyingReader) Read(p []byte) (int, error) {
+	n, err := hr.r.Read(p)
+	hr.h.Write(p[:n])
+	if err == io.EOF && !bytes.Equal(hr.h.Sum(nil), hr.expected) {
+		return n, errContentSHA256Mismatch
+	}
+	return n, err
+}
+
+// Close implements io.Closer.
+func (hr *hashVerifyingReader) Close() error {
+	return hr.r.Close()
+}
+
+// chunkedReader decodes a body sent with the aws-chunked encoding. Every chunk
+// starts with its size in hex, optionally followed by its signature, and ends
+// with a CRLF. The last chunk is empty and may be followed by trailing
+// headers, which are ignored.
+type chunkedReader struct {
+	r         *bufio.Reader
+	c         io.Closer
+	remaining int64
+	done      bool
+}
+
+// newChunkedReader decodes the aws-chunked body r.
+func newChunkedReader(r io.ReadCloser) *chunkedReader {
+	return &chunkedReader{r: bufio.NewReader(r), c: r}
+}
+
+// Read implements io.Reader.
+func (cr *chunkedReader) Read(p []byte) (int, error) {
+	for cr.remaining == 0 {
+		if cr.done {
+			return 0, io.EOF
+		}
+		if err := cr.nextChunk(); err != nil {
+			return 0, err
+		}
+	}
+	if int64(len(p)) > cr.remaining {
+		p = p[:cr.remaining]
+	}
+	n, err := cr.r.Read(p)
+	cr.remaining -= int64(n)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err == nil && cr.remaining == 0 {
+		err = cr.readCRLF()
+	}
+	return n, err
+}
+
+// nextChunk reads the header of the next chunk.
+func (cr *chunkedReader) nextChunk() error {
+	line, err := cr.r.ReadString('\n')
+	if err != nil {
+		return errors.AddContext(err, "unable to read chunk header")
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if i := strings.IndexByte(line, ';'); i >= 0 {
+		line = line[:i]
+	}
+	size, err := strconv.ParseInt(line, 16, 64)
+	if err != nil || size < 0 {
+		return errors.New("malformed chunk size")
+	}
+	if size == 0 {
+		// Skip the trailing headers.
+		cr.done = true
+		for {
+			line, err := cr.r.ReadString('\n')
+			if err == io.EOF || strings.TrimRight(line, "\r\n") == "" {
+				return nil
+			} else if err != nil {
+				return err
+			}
+		}
+	}
+	cr.remaining = size
+	return nil
+}
+
+// readCRLF reads the CRLF at the end of a chunk.
+func (cr *chunkedReader) readCRLF() error {
+	var crlf [2]byte
+	if _, err := io.ReadFull(cr.r, crlf[:]); err != nil {
+		return err
+	}
+	if crlf != [2]byte{'\r', '\n'} {
+		return errors.New("malformed chunk")
+	}
+	return nil
+}
+
+// Close implements io.Closer.
+func (cr *chunkedReader) Close() error {
+	return cr.c.Close()
+}
@@ -0,0 +1,235 @@
+package s3gateway
+
+import (
+	"encoding/base64"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// buckets.go contains the handlers of the requests addressing buckets.
+
+// maxListKeys is the largest number of keys returned by a single ListObjects
+// request.
+const maxListKeys = 1000
+
+// gatewayOwner is the owner of all buckets and objects of the gateway.
+var gatewayOwner = xmlOwner{ID: "siad", DisplayName: "siad"}
+
+// listBuckets handles ListBuckets.
+func (g *Gateway) listBuckets(w http.ResponseWriter, req *http.Request) {
+	g.mu.Lock()
+	result := listAllMyBucketsResult{Xmlns: s3Namespace, Owner: gatewayOwner}
+	for name, b := range g.manifest.Buckets {
+		result.Buckets = append(result.Buckets, xmlBucket{Name: name, CreationDate: s3Time(b.Created)})
+	}
+	g.mu.Unlock()
+	sort.Slice(result.Buckets, func(i, j int) bool {
+		return result.Buckets[i].Name < result.Buckets[j].Name
+	})
+	writeXML(w, req, result)
+}
+
+// serveBucket handles the requests addressing a bucket.
+func (g *Gateway) serveBucket(w http.ResponseWriter, req *http.Request, name string) {
+	query := req.URL.Query()
+	switch {
+	case req.Method == http.MethodPut:
+		g.createBucket(w, req, name)
+	case req.Method == http.MethodDelete:
+		g.deleteBucket(w, req, name)
+	case req.Method == http.MethodHead:
+		if !g.managedBucketExists(name) {
+			writeError(w, req, errNoSuchBucket)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	case req.Method == http.MethodPost && hasQuery(query, "delete"):
+		g.deleteObjects(w, req, name)
+	case req.Method == http.MethodGet && hasQuery(query, "location"):
+		if !g.managedBucketExists(name) {
+			writeError(w, req, errNoSuchBucket)
+			return
+		}
+		writeXML(w, req, locationConstraint{Xmlns: s3Namespace})
+	case req.Method == http.MethodGet && !hasQuery(query, "acl", "policy", "uploads", "versioning", "versions"):
+		g.listObjects(w, req, name)
+	default:
+		writeError(w, req, errNotImplemented)
+	}
+}
+
+// hasQuery returns true if the query contains one of the keys.
+func hasQuery(query map[string][]string, keys ...string) bool {
+	for _, k := range keys {
+		if _, ok := query[k]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// managedBucketExists returns true if the bucket exists.
+func (g *Gateway) managedBucketExists(name string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	_, exists := g.manifest.Buckets[name]
+	return exists
+}
+
+// createBucket handles CreateBucket.
+func (g *Gateway) createBucket(w http.ResponseWriter, req *http.Request, name string) {
+	if !bucketNameRegex.MatchString(name) {
+		writeError(w, req, errInvalidBucketName)
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, exists := g.manifest.Buckets[name]; exists {
+		writeError(w, req, errBucketAlreadyOwnedByYou)
+		return
+	}
+	g.manifest.Buckets[name] = &bucket{
+		Created: time.Now(),
+		Objects: make(map[string]object),
+	}
+	if err := g.saveManifest(); err != nil {
+		delete(g.manifest.Buckets, name)
+		writeError(w, req, err)
+		return
+	}
+	w.Header().Set("Location", "/"+name)
+	w.WriteHeader(http.StatusOK)
+}
+
+// deleteBucket handles DeleteBucket. Only empty buckets can be deleted.
+func (g *Gateway) deleteBucket(w http.ResponseWriter, req *http.Request, name string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	b, exists := g.manifest.Buckets[name]
+	if !exists {
+		writeError(w, req, errNoSuchBucket)
+		return
+	}
+	if len(b.Objects) > 0 {
+		writeError(w, req, errBucketNotEmpty)
+		return
+	}
+	delete(g.manifest.Buckets, name)
+	if err := g.saveManifest(); err != nil {
+		g.manifest.Buckets[name] = b
+		writeError(w, req, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listObjects handles ListObjects and ListObjectsV2.
+func (g *Gateway) listObjects(w http.ResponseWriter, req *http.Request, name string) {
+	query := req.URL.Query()
+	v2 := query.Get("list-type") == "2"
+	prefix, delimiter := query.Get("prefix"), query.Get("delimiter")
+	maxKeys := maxListKeys
+	if mk := query.Get("max-keys"); mk != "" {
+		n, err := strconv.Atoi(mk)
+		if err != nil || n < 0 {
+			writeError(w, req, s3Error{"InvalidArgument", "max-keys must be a non-negative integer.", http.StatusBadRequest})
+			return
+		}
+		if n < maxKeys {
+			maxKeys = n
+		}
+	}
+	result := listBucketResult{
+		Xmlns:     s3Namespace,
+		Name:      name,
+		Prefix:    prefix,
+		Delimiter: delimiter,
+		MaxKeys:   maxKeys,
+	}
+	var start string
+	if v2 {
+		result.StartAfter = query.Get("start-after")
+		result.ContinuationToken = query.Get("continuation-token")
+		start = result.StartAfter
+		if result.ContinuationToken != "" {
+			b, err := base64.URLEncoding.DecodeString(result.ContinuationToken)
+			if err != nil {
+				writeError(w, req, s3Error{"InvalidArgument", "The continuation token provided is incorrect.", http.StatusBadRequest})
+				return
+			}
+			start = string(b)
+		}
+	} else {
+		marker := query.Get("marker")
+		result.Marker = &marker
+		start = marker
+	}
+
+	g.mu.Lock()
+	b, exists := g.manifest.Buckets[name]
+	if !exists {
+		g.mu.Unlock()
+		writeError(w, req, errNoSuchBucket)
+		return
+	}
+	keys := make([]string, 0, len(b.Objects))
+	for key := range b.Objects {
+		if strings.HasPrefix(key, prefix) && key > start {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	var last string
+	for _, key := range keys {
+		// Skip the keys of a common prefix which was returned by the
+		// previous page.
+		if delimiter != "" && strings.HasSuffix(start, delimiter) && strings.HasPrefix(key, start) {
+			continue
+		}
+		commonPrefix := ""
+		if delimiter != "" {
+			if i := strings.Index(key[len(prefix):], delimiter); i >= 0 {
+				commonPrefix = key[:len(prefix)+i+len(delimiter)]
+			}
+		}
+		if commonPrefix != "" && commonPrefix == last {
+			continue
+		}
+		if len(result.Contents)+len(result.CommonPrefixes) == maxKeys {
+			result.IsTruncated = true
+			break
+		}
+		if commonPrefix != "" {
+			result.CommonPrefixes = append(result.CommonPrefixes, xmlCommonPrefix{Prefix: commonPrefix})
+			last = commonPrefix
+			continue
+		}
+		obj := b.Objects[key]
+		result.Contents = append(result.Contents, xmlObject{
+			Key:          key,
+			LastModified: s3Time(obj.LastModified),
+			ETag:         obj.ETag,
+			Size:         obj.Size,
+			StorageClass: "STANDARD",
+			Owner:        gatewayOwner,
+		})
+		last = key
+	}
+	g.mu.Unlock()
+
+	if result.IsTruncated {
+		if v2 {
+			result.NextContinuationToken = base64.URLEncoding.EncodeToString([]byte(last))
+		} else {
+			result.NextMarker = last
+		}
+	}
+	if v2 {
+		keyCount := len(result.Contents) + len(result.CommonPrefixes)
+		result.KeyCount = &keyCount
+	}
+	writeXML(w, req, result)
+}
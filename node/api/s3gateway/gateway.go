@@ -0,0 +1,407 @@
+// Package s3gateway implements an S3-compatible HTTP gateway for the renter.
+// The objects of the gateway's buckets are pinned as NFTs, so tools like rclone
+// or the aws-cli can upload NFT assets to the Sia network without a custom
+// integration. The gateway's manifest maps every object to the merkle root of
+// its data, which is also the root of the NFT.
+package s3gateway
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"hash"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter"
+	"go.sia.tech/siad/persist"
+)
+
+const (
+	// manifestFilename is the name of the file containing the gateway's
+	// buckets and objects.
+	manifestFilename = "manifest.json"
+
+	// objectsDir is the directory containing the data of the objects. The
+	// data is kept as the source of the NFT pins, named after its merkle
+	// root.
+	objectsDir = "objects"
+
+	// multipartDir is the directory containing the parts of multipart
+	// uploads.
+	multipartDir = "multipart"
+
+	// tmpDir is the directory containing objects which are being uploaded.
+	tmpDir = "tmp"
+
+	// defaultContentType is the content type of objects uploaded without one.
+	defaultContentType = "binary/octet-stream"
+
+	// nftRootHeader is the response header containing the merkle root of an
+	// object's data.
+	nftRootHeader = "X-Sia-Nft-Root"
+)
+
+var (
+	// manifestMetadata is the metadata of the gateway's manifest.
+	manifestMetadata = persist.Metadata{
+		Header:  "S3 Gateway Manifest",
+		Version: "1.0",
+	}
+
+	// bucketNameRegex matches valid bucket names.
+	bucketNameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{1,61}[a-z0-9]$`)
+)
+
+type (
+	// Credentials returns the secret access key of an access key ID and
+	// whether the key may create and delete buckets and objects. ok is false
+	// for unknown access key IDs.
+	Credentials func(accessKeyID string) (secret string, write bool, ok bool)
+
+	// Gateway is an S3-compatible HTTP gateway storing its objects as pinned
+	// NFTs.
+	Gateway struct {
+		manifest manifest
+		// refs counts the objects referencing the data of a merkle root.
+		refs    map[crypto.Hash]int
+		uploads map[string]multipartUpload
+		mu      sync.Mutex
+
+		staticCredentials Credentials
+		staticDir         string
+		staticRenter      modules.Renter
+	}
+
+	// manifest contains the gateway's buckets and the merkle roots it pinned.
+	manifest struct {
+		Buckets map[string]*bucket `json:"buckets"`
+
+		// Pinned are the merkle roots which were pinned by the gateway
+		// rather than the user. They are unpinned once no object references
+		// them anymore.
+		Pinned map[string]bool `json:"pinned"`
+	}
+
+	// bucket is a bucket of the gateway.
+	bucket struct {
+		Created time.Time         `json:"created"`
+		Objects map[string]object `json:"objects"`
+	}
+
+	// object is an object of the gateway.
+	object struct {
+		Root         crypto.Hash       `json:"root"`
+		Size         int64             `json:"size"`
+		ETag         string            `json:"etag"`
+		ContentType  string            `json:"contenttype"`
+		Metadata     map[string]string `json:"metadata,omitempty"`
+		LastModified time.Time         `json:"lastmodified"`
+	}
+
+	// objectHasher computes the md5 hash and the merkle root of an object's
+	// data.
+	objectHasher struct {
+		md5  hash.Hash
+		tree *crypto.MerkleTree
+		buf  []byte
+	}
+)
+
+// newObjectHasher creates a hasher for the data of an object.
+func newObjectHasher() *objectHasher {
+	return &objectHasher{
+		md5:  md5.New(),
+		tree: crypto.NewTree(),
+		buf:  make([]byte, 0, crypto.SegmentSize),
+	}
+}
+
+// Write implements io.Writer.
+func (h *objectHasher) Write(p []byte) (int, error) {
+	n := len(p)
+	h.md5.Write(p)
+	for len(p) > 0 {
+		take := crypto.SegmentSize - len(h.buf)
+		if take > len(p) {
+			take = len(p)
+		}
+		h.buf = append(h.buf, p[:take]...)
+		p = p[take:]
+		if len(h.buf) == crypto.SegmentSize {
+			h.tree.Push(h.buf)
+			h.buf = h.buf[:0]
+		}
+	}
+	return n, nil
+}
+
+// Sums returns the md5 hash and the merkle root of the data written to the
+// hasher.
+func (h *objectHasher) Sums() ([]byte, crypto.Hash) {
+	if len(h.buf) > 0 {
+		h.tree.Push(h.buf)
+		h.buf = h.buf[:0]
+	}
+	return h.md5.Sum(nil), h.tree.Root()
+}
+
+// New creates a gateway for the renter which keeps its manifest and the data
+// of its objects in dir. If creds is nil, the gateway doesn't authenticate
+// requests.
+func New(r modules.Renter, dir string, creds Credentials) (*Gateway, error) {
+	for _, d := range []string{objectsDir, multipartDir, tmpDir} {
+		// Unfinished uploads can't be resumed after a restart.
+		if d != objectsDir {
+			if err := os.RemoveAll(filepath.Join(dir, d)); err != nil {
+				return nil, err
+			}
+		}
+		if err := os.MkdirAll(filepath.Join(dir, d), modules.DefaultDirPerm); err != nil {
+			return nil, err
+		}
+	}
+	g := &Gateway{
+		refs:              make(map[crypto.Hash]int),
+		uploads:           make(map[string]multipartUpload),
+		staticCredentials: creds,
+		staticDir:         dir,
+		staticRenter:      r,
+	}
+	err := persist.LoadJSON(manifestMetadata, &g.manifest, filepath.Join(dir, manifestFilename))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, errors.AddContext(err, "unable to load the gateway's manifest")
+	}
+	if g.manifest.Buckets == nil {
+		g.manifest.Buckets = make(map[string]*bucket)
+	}
+	if g.manifest.Pinned == nil {
+		g.manifest.Pinned = make(map[string]bool)
+	}
+	for _, b := range g.manifest.Buckets {
+		for _, obj := range b.Objects {
+			if obj.Size > 0 {
+				g.refs[obj.Root]++
+			}
+		}
+	}
+	return g, nil
+}
+
+// saveManifest saves the gateway's manifest to disk.
+func (g *Gateway) saveManifest() error {
+	return persist.SaveJSON(manifestMetadata, g.manifest, filepath.Join(g.staticDir, manifestFilename))
+}
+
+// objectPath returns the path of the data with the merkle root.
+func (g *Gateway) objectPath(root crypto.Hash) string {
+	return filepath.Join(g.staticDir, objectsDir, root.String())
+}
+
+// addRef adds a reference to the data of an object, which was written to
+// source. The first reference moves the data into the objects directory and
+// pins it unless the user already pinned it.
+func (g *Gateway) addRef(obj object, source string) error {
+	if obj.Size == 0 {
+		return os.Remove(source)
+	}
+	if g.refs[obj.Root] > 0 {
+		g.refs[obj.Root]++
+		return os.Remove(source)
+	}
+	path := g.objectPath(obj.Root)
+	if err := os.Rename(source, path); err != nil {
+		return err
+	}
+	err := g.staticRenter.PinNFT(obj.Root, path)
+	if err != nil && !errors.Contains(err, renter.ErrNFTAlreadyPinned) {
+		return errors.Compose(err, os.Remove(path))
+	}
+	if err == nil {
+		g.manifest.Pinned[obj.Root.String()] = true
+	}
+	g.refs[obj.Root]++
+	return nil
+}
+
+// releaseRef removes a reference to the data of an object. The last reference
+// removes the data and unpins it if the gateway pinned it.
+func (g *Gateway) releaseRef(obj object) error {
+	if obj.Size == 0 {
+		return nil
+	}
+	g.refs[obj.Root]--
+	if g.refs[obj.Root] > 0 {
+		return nil
+	}
+	delete(g.refs, obj.Root)
+	err := os.Remove(g.objectPath(obj.Root))
+	if os.IsNotExist(err) {
+		err = nil
+	}
+	if g.manifest.Pinned[obj.Root.String()] {
+		delete(g.manifest.Pinned, obj.Root.String())
+		err = errors.Compose(err, g.staticRenter.UnpinNFT(obj.Root))
+	}
+	return err
+}
+
+// managedPutObject adds an object to a bucket, replacing an existing object
+// with the same key. The data of the object was written to source.
+func (g *Gateway) managedPutObject(bucketName, key string, obj object, source string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	b, exists := g.manifest.Buckets[bucketName]
+	if !exists {
+		if err := os.Remove(source); err != nil {
+			return err
+		}
+		return errNoSuchBucket
+	}
+	if err := g.addRef(obj, source); err != nil {
+		return err
+	}
+	old, replaced := b.Objects[key]
+	b.Objects[key] = obj
+	var err error
+	if replaced {
+		err = g.releaseRef(old)
+	}
+	return errors.Compose(err, g.saveManifest())
+}
+
+// managedObject returns an object of a bucket.
+func (g *Gateway) managedObject(bucketName, key string) (object, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	b, exists := g.manifest.Buckets[bucketName]
+	if !exists {
+		return object{}, errNoSuchBucket
+	}
+	obj, exists := b.Objects[key]
+	if !exists {
+		return object{}, errNoSuchKey
+	}
+	return obj, nil
+}
+
+// managedDeleteObject removes an object from a bucket. Deleting an object
+// which doesn't exist succeeds.
+func (g *Gateway) managedDeleteObject(bucketName, key string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	b, exists := g.manifest.Buckets[bucketName]
+	if !exists {
+		return errNoSuchBucket
+	}
+	obj, exists := b.Objects[key]
+	if !exists {
+		return nil
+	}
+	delete(b.Objects, key)
+	return errors.Compose(g.releaseRef(obj), g.saveManifest())
+}
+
+// managedCopyObject copies an object. The copy shares the data of the source
+// object, if metadata isn't nil it replaces the metadata of the source.
+func (g *Gateway) managedCopyObject(srcBucket, srcKey, dstBucket, dstKey string, contentType string, metadata map[string]string) (object, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	src, exists := g.manifest.Buckets[srcBucket]
+	if !exists {
+		return object{}, errNoSuchBucket
+	}
+	obj, exists := src.Objects[srcKey]
+	if !exists {
+		return object{}, errNoSuchKey
+	}
+	dst, exists := g.manifest.Buckets[dstBucket]
+	if !exists {
+		return object{}, errNoSuchBucket
+	}
+	if metadata != nil {
+		obj.ContentType = contentType
+		obj.Metadata = metadata
+	}
+	obj.LastModified = time.Now()
+	if obj.Size > 0 {
+		g.refs[obj.Root]++
+	}
+	old, replaced := dst.Objects[dstKey]
+	dst.Objects[dstKey] = obj
+	var err error
+	if replaced {
+		err = g.releaseRef(old)
+	}
+	return obj, errors.Compose(err, g.saveManifest())
+}
+
+// ServeHTTP implements http.Handler. Buckets are addressed by the first
+// segment of the path, virtual-hosted-style requests aren't supported.
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if err := g.authenticate(req); err != nil {
+		writeError(w, req, err)
+		return
+	}
+	path := strings.TrimPrefix(req.URL.Path, "/")
+	if path == "" {
+		if req.Method != http.MethodGet {
+			writeError(w, req, errNotImplemented)
+			return
+		}
+		g.listBuckets(w, req)
+		return
+	}
+	parts := strings.SplitN(path, "/", 2)
+	bucketName := parts[0]
+	if len(parts) == 1 || parts[1] == "" {
+		g.serveBucket(w, req, bucketName)
+		return
+	}
+	g.serveObject(w, req, bucketName, parts[1])
+}
+
+// authenticate verifies the signature of a request and whether its access key
+// may perform the request.
+func (g *Gateway) authenticate(req *http.Request) error {
+	if g.staticCredentials == nil {
+		return wrapBody(req)
+	}
+	var write bool
+	_, err := verifySigV4(req, time.Now(), func(accessKeyID string) (string, bool) {
+		secret, w, ok := g.staticCredentials(accessKeyID)
+		write = w
+		return secret, ok
+	})
+	switch {
+	case errors.Contains(err, errMissingAuth):
+		return errAccessDenied
+	case errors.Contains(err, errMalformedAuth):
+		return errMissingSecurityHeader
+	case errors.Contains(err, errUnknownAccessKey):
+		return errInvalidAccessKeyID
+	case errors.Contains(err, errSignatureMismatch):
+		return errSignatureDoesNotMatch
+	case errors.Contains(err, errRequestTooSkewed):
+		return errRequestTimeTooSkewed
+	case err != nil:
+		return err
+	}
+	if !write && req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return errAccessDenied
+	}
+	return nil
+}
+
+// etag returns the quoted ETag of an md5 hash.
+func etag(sum []byte) string {
+	return `"` + hex.EncodeToString(sum) + `"`
+}
@@ -0,0 +1,383 @@
+package s3gateway
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter"
+	"go.sia.tech/siad/persist"
+)
+
+// testRenter is a renter which pins NFTs by remembering the path of their
+// source file.
+type testRenter struct {
+	modules.Renter
+	pins map[crypto.Hash]string
+	mu   sync.Mutex
+}
+
+// PinNFT implements modules.Renter.
+func (r *testRenter) PinNFT(root crypto.Hash, source string) error {
+	data, err := ioutil.ReadFile(source)
+	if err != nil {
+		return err
+	}
+	if crypto.MerkleRoot(data) != root {
+		return errors.New("merkle root mismatch")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.pins[root]; exists {
+		return renter.ErrNFTAlreadyPinned
+	}
+	r.pins[root] = source
+	return nil
+}
+
+// UnpinNFT implements modules.Renter.
+func (r *testRenter) UnpinNFT(root crypto.Hash) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.pins, root)
+	return nil
+}
+
+// Streamer implements modules.Renter.
+func (r *testRenter) Streamer(siaPath modules.SiaPath, _ bool) (string, modules.Streamer, error) {
+	var root crypto.Hash
+	if err := root.LoadString(siaPath.Name()); err != nil {
+		return "", nil, err
+	}
+	r.mu.Lock()
+	source, exists := r.pins[root]
+	r.mu.Unlock()
+	if !exists {
+		return "", nil, errors.New("not pinned")
+	}
+	f, err := os.Open(source)
+	return siaPath.Name(), f, err
+}
+
+// managedPinned returns true if the merkle root is pinned.
+func (r *testRenter) managedPinned(root crypto.Hash) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, exists := r.pins[root]
+	return exists
+}
+
+// testClient sends signed requests to a gateway.
+type testClient struct {
+	t           *testing.T
+	url         string
+	accessKeyID string
+	secret      string
+}
+
+// do sends a request to the gateway and returns the response and its body.
+func (c *testClient) do(method, path string, body []byte, header http.Header) (*http.Response, []byte) {
+	req, err := http.NewRequest(method, c.url+path, bytes.NewReader(body))
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	for k, vs := range header {
+		req.Header[k] = vs
+	}
+	signRequest(req, body, c.accessKeyID, c.secret, time.Now())
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	return resp, b
+}
+
+// expect sends a request and fails the test if the response doesn't have the
+// status code.
+func (c *testClient) expect(status int, method, path string, body []byte, header http.Header) (*http.Response, []byte) {
+	resp, b := c.do(method, path, body, header)
+	if resp.StatusCode != status {
+		c.t.Fatalf("%v %v: expected status %v but got %v: %s", method, path, status, resp.StatusCode, b)
+	}
+	return resp, b
+}
+
+// newTestGateway creates a gateway with a full access key "full" and a
+// read-only key "read".
+func newTestGateway(t *testing.T) (*testRenter, *testClient, *testClient) {
+	dir := filepath.Join(os.TempDir(), "s3gateway", t.Name())
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	r := &testRenter{pins: make(map[crypto.Hash]string)}
+	g, err := New(r, dir, func(id string) (string, bool, bool) {
+		switch id {
+		case "full":
+			return "fullsecret", true, true
+		case "read":
+			return "readsecret", false, true
+		}
+		return "", false, false
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(g)
+	t.Cleanup(srv.Close)
+	full := &testClient{t: t, url: srv.URL, accessKeyID: "full", secret: "fullsecret"}
+	read := &testClient{t: t, url: srv.URL, accessKeyID: "read", secret: "readsecret"}
+	return r, full, read
+}
+
+// TestGatewayObjects tests creating buckets and storing objects through the
+// gateway.
+func TestGatewayObjects(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	r, c, read := newTestGateway(t)
+
+	// Create a bucket.
+	c.expect(http.StatusOK, http.MethodPut, "/bucket", nil, nil)
+	c.expect(http.StatusConflict, http.MethodPut, "/bucket", nil, nil)
+	c.expect(http.StatusBadRequest, http.MethodPut, "/B", nil, nil)
+	_, b := read.expect(http.StatusOK, http.MethodGet, "/", nil, nil)
+	var buckets listAllMyBucketsResult
+	if err := xml.Unmarshal(b, &buckets); err != nil {
+		t.Fatal(err)
+	}
+	if len(buckets.Buckets) != 1 || buckets.Buckets[0].Name != "bucket" {
+		t.Fatal("wrong buckets", buckets.Buckets)
+	}
+
+	// Upload an object and check that its data was pinned.
+	data := fastrand.Bytes(1000)
+	root := crypto.MerkleRoot(data)
+	sum := md5.Sum(data)
+	resp, _ := c.expect(http.StatusOK, http.MethodPut, "/bucket/dir/object", data, http.Header{
+		"Content-Type":    []string{"image/png"},
+		"X-Amz-Meta-Name": []string{"foo"},
+	})
+	if resp.Header.Get("ETag") != etag(sum[:]) {
+		t.Fatal("wrong ETag", resp.Header.Get("ETag"))
+	}
+	if resp.Header.Get(nftRootHeader) != root.String() {
+		t.Fatal("wrong merkle root", resp.Header.Get(nftRootHeader))
+	}
+	if !r.managedPinned(root) {
+		t.Fatal("object wasn't pinned")
+	}
+
+	// Read-only keys can't upload but can download.
+	read.expect(http.StatusForbidden, http.MethodPut, "/bucket/other", data, nil)
+	resp, b = read.expect(http.StatusOK, http.MethodGet, "/bucket/dir/object", nil, nil)
+	if !bytes.Equal(b, data) {
+		t.Fatal("wrong data")
+	}
+	if resp.Header.Get("Content-Type") != "image/png" || resp.Header.Get("X-Amz-Meta-Name") != "foo" {
+		t.Fatal("wrong headers", resp.Header)
+	}
+	_, b = c.expect(http.StatusPartialContent, http.MethodGet, "/bucket/dir/object", nil, http.Header{"Range": []string{"bytes=100-199"}})
+	if !bytes.Equal(b, data[100:200]) {
+		t.Fatal("wrong range")
+	}
+	resp, b = c.expect(http.StatusOK, http.MethodHead, "/bucket/dir/object", nil, nil)
+	if len(b) != 0 || resp.ContentLength != int64(len(data)) {
+		t.Fatal("wrong head response", resp.ContentLength, len(b))
+	}
+	c.expect(http.StatusNotFound, http.MethodGet, "/bucket/missing", nil, nil)
+	c.expect(http.StatusNotFound, http.MethodGet, "/missing/object", nil, nil)
+
+	// Copy the object and list the bucket.
+	c.expect(http.StatusOK, http.MethodPut, "/bucket/copy", nil, http.Header{"X-Amz-Copy-Source": []string{"/bucket/dir/object"}})
+	c.expect(http.StatusOK, http.MethodPut, "/bucket/empty", nil, nil)
+	_, b = c.expect(http.StatusOK, http.MethodGet, "/bucket?list-type=2&delimiter=/", nil, nil)
+	var list listBucketResult
+	if err := xml.Unmarshal(b, &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Contents) != 2 || list.Contents[0].Key != "copy" || list.Contents[1].Key != "empty" {
+		t.Fatal("wrong contents", list.Contents)
+	}
+	if len(list.CommonPrefixes) != 1 || list.CommonPrefixes[0].Prefix != "dir/" {
+		t.Fatal("wrong common prefixes", list.CommonPrefixes)
+	}
+	_, b = c.expect(http.StatusOK, http.MethodGet, "/bucket?list-type=2&max-keys=1", nil, nil)
+	list = listBucketResult{}
+	if err := xml.Unmarshal(b, &list); err != nil {
+		t.Fatal(err)
+	}
+	if !list.IsTruncated || len(list.Contents) != 1 || list.NextContinuationToken == "" {
+		t.Fatal("list wasn't truncated", list)
+	}
+
+	// The data stays pinned until the last object referencing it is deleted.
+	c.expect(http.StatusConflict, http.MethodDelete, "/bucket", nil, nil)
+	c.expect(http.StatusNoContent, http.MethodDelete, "/bucket/dir/object", nil, nil)
+	if !r.managedPinned(root) {
+		t.Fatal("object was unpinned")
+	}
+	deleteBody := []byte(`<Delete><Object><Key>copy</Key></Object><Object><Key>empty</Key></Object></Delete>`)
+	c.expect(http.StatusOK, http.MethodPost, "/bucket?delete", deleteBody, nil)
+	if r.managedPinned(root) {
+		t.Fatal("object wasn't unpinned")
+	}
+	c.expect(http.StatusNoContent, http.MethodDelete, "/bucket", nil, nil)
+}
+
+// TestGatewayMultipart tests multipart uploads.
+func TestGatewayMultipart(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	r, c, _ := newTestGateway(t)
+	c.expect(http.StatusOK, http.MethodPut, "/bucket", nil, nil)
+
+	_, b := c.expect(http.StatusOK, http.MethodPost, "/bucket/object?uploads", nil, nil)
+	var imu initiateMultipartUploadResult
+	if err := xml.Unmarshal(b, &imu); err != nil {
+		t.Fatal(err)
+	}
+	parts := [][]byte{fastrand.Bytes(700), fastrand.Bytes(300)}
+	var complete completeMultipartUpload
+	for i, p := range parts {
+		path := fmt.Sprintf("/bucket/object?partNumber=%v&uploadId=%v", i+1, imu.UploadID)
+		resp, _ := c.expect(http.StatusOK, http.MethodPut, path, p, nil)
+		complete.Parts = append(complete.Parts, completePart{PartNumber: i + 1, ETag: resp.Header.Get("ETag")})
+	}
+	c.expect(http.StatusNotFound, http.MethodPut, "/bucket/object?partNumber=1&uploadId=unknown", parts[0], nil)
+
+	// Parts must be in order.
+	reversed := completeMultipartUpload{Parts: []completePart{complete.Parts[1], complete.Parts[0]}}
+	body, err := xml.Marshal(reversed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.expect(http.StatusBadRequest, http.MethodPost, "/bucket/object?uploadId="+imu.UploadID, body, nil)
+
+	body, err = xml.Marshal(complete)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.expect(http.StatusOK, http.MethodPost, "/bucket/object?uploadId="+imu.UploadID, body, nil)
+	data := append(append([]byte{}, parts[0]...), parts[1]...)
+	if !r.managedPinned(crypto.MerkleRoot(data)) {
+		t.Fatal("object wasn't pinned")
+	}
+	resp, b := c.expect(http.StatusOK, http.MethodGet, "/bucket/object", nil, nil)
+	if !bytes.Equal(b, data) {
+		t.Fatal("wrong data")
+	}
+	if !strings.HasSuffix(resp.Header.Get("ETag"), `-2"`) {
+		t.Fatal("wrong ETag", resp.Header.Get("ETag"))
+	}
+
+	// Aborted uploads are gone.
+	_, b = c.expect(http.StatusOK, http.MethodPost, "/bucket/other?uploads", nil, nil)
+	if err := xml.Unmarshal(b, &imu); err != nil {
+		t.Fatal(err)
+	}
+	c.expect(http.StatusNoContent, http.MethodDelete, "/bucket/other?uploadId="+imu.UploadID, nil, nil)
+	c.expect(http.StatusNotFound, http.MethodPost, "/bucket/other?uploadId="+imu.UploadID, body, nil)
+}
+
+// TestGatewayAuth tests that requests without a valid signature are rejected.
+func TestGatewayAuth(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	_, c, _ := newTestGateway(t)
+
+	resp, err := http.Get(c.url + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatal("unsigned request wasn't rejected", resp.StatusCode)
+	}
+	wrongSecret := &testClient{t: t, url: c.url, accessKeyID: "full", secret: "wrong"}
+	_, b := wrongSecret.expect(http.StatusForbidden, http.MethodGet, "/", nil, nil)
+	if !bytes.Contains(b, []byte(errSignatureDoesNotMatch.code)) {
+		t.Fatalf("wrong error %s", b)
+	}
+	unknown := &testClient{t: t, url: c.url, accessKeyID: "unknown", secret: "wrong"}
+	unknown.expect(http.StatusForbidden, http.MethodGet, "/", nil, nil)
+}
+
+// TestGatewayManifest tests that the gateway's objects survive a restart.
+func TestGatewayManifest(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	dir := filepath.Join(os.TempDir(), "s3gateway", t.Name())
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	r := &testRenter{pins: make(map[crypto.Hash]string)}
+	g, err := New(r, dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(g)
+	defer srv.Close()
+	c := &testClient{t: t, url: srv.URL}
+	c.expect(http.StatusOK, http.MethodPut, "/bucket", nil, nil)
+	data := fastrand.Bytes(100)
+	c.expect(http.StatusOK, http.MethodPut, "/bucket/a", data, nil)
+	c.expect(http.StatusOK, http.MethodPut, "/bucket/b", data, nil)
+
+	// Reload the gateway, deleting one of the objects must not unpin the
+	// data.
+	g, err = New(r, dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := crypto.MerkleRoot(data)
+	if g.refs[root] != 2 {
+		t.Fatal("wrong refs", g.refs[root])
+	}
+	if err := g.managedDeleteObject("bucket", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if !r.managedPinned(root) {
+		t.Fatal("object was unpinned")
+	}
+	var m manifest
+	if err := persist.LoadJSON(manifestMetadata, &m, filepath.Join(dir, manifestFilename)); err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := m.Buckets["bucket"].Objects["b"]; !exists || len(m.Buckets["bucket"].Objects) != 1 {
+		t.Fatal("wrong manifest", m.Buckets["bucket"].Objects)
+	}
+	if !m.Pinned[hex.EncodeToString(root[:])] {
+		t.Fatal("root wasn't marked as pinned")
+	}
+}
@@ -0,0 +1,229 @@
+package s3gateway
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+)
+
+// multipart.go contains the handlers of multipart uploads. The parts of an
+// upload are written to disk and concatenated into a single object once the
+// upload is completed. Uploads which aren't completed are lost when siad
+// restarts.
+
+// maxPartNumber is the largest part number of a multipart upload.
+const maxPartNumber = 10000
+
+// multipartUpload is an unfinished multipart upload.
+type multipartUpload struct {
+	bucket      string
+	key         string
+	contentType string
+	metadata    map[string]string
+}
+
+// uploadDir returns the directory containing the parts of an upload.
+func (g *Gateway) uploadDir(uploadID string) string {
+	return filepath.Join(g.staticDir, multipartDir, uploadID)
+}
+
+// partPath returns the path of a part of an upload.
+func (g *Gateway) partPath(uploadID string, partNumber int) string {
+	return filepath.Join(g.uploadDir(uploadID), strconv.Itoa(partNumber))
+}
+
+// managedUpload returns the upload with the ID if it uploads to the key of the
+// bucket.
+func (g *Gateway) managedUpload(uploadID, bucketName, key string) (multipartUpload, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	u, exists := g.uploads[uploadID]
+	if !exists || u.bucket != bucketName || u.key != key {
+		return multipartUpload{}, errNoSuchUpload
+	}
+	return u, nil
+}
+
+// managedRemoveUpload removes an upload and its parts.
+func (g *Gateway) managedRemoveUpload(uploadID string) error {
+	g.mu.Lock()
+	delete(g.uploads, uploadID)
+	g.mu.Unlock()
+	return os.RemoveAll(g.uploadDir(uploadID))
+}
+
+// createMultipartUpload handles CreateMultipartUpload.
+func (g *Gateway) createMultipartUpload(w http.ResponseWriter, req *http.Request, bucketName, key string) {
+	if !g.managedBucketExists(bucketName) {
+		writeError(w, req, errNoSuchBucket)
+		return
+	}
+	uploadID := hex.EncodeToString(fastrand.Bytes(16))
+	if err := os.MkdirAll(g.uploadDir(uploadID), 0700); err != nil {
+		writeError(w, req, err)
+		return
+	}
+	g.mu.Lock()
+	g.uploads[uploadID] = multipartUpload{
+		bucket:      bucketName,
+		key:         key,
+		contentType: contentType(req.Header),
+		metadata:    userMetadata(req.Header),
+	}
+	g.mu.Unlock()
+	writeXML(w, req, initiateMultipartUploadResult{
+		Xmlns:    s3Namespace,
+		Bucket:   bucketName,
+		Key:      key,
+		UploadID: uploadID,
+	})
+}
+
+// uploadPart handles UploadPart. Uploading a part again replaces it.
+func (g *Gateway) uploadPart(w http.ResponseWriter, req *http.Request, bucketName, key string) {
+	uploadID := req.URL.Query().Get("uploadId")
+	partNumber, err := strconv.Atoi(req.URL.Query().Get("partNumber"))
+	if err != nil || partNumber < 1 || partNumber > maxPartNumber {
+		writeError(w, req, s3Error{"InvalidArgument", fmt.Sprintf("Part number must be an integer between 1 and %v, inclusive.", maxPartNumber), http.StatusBadRequest})
+		return
+	}
+	if _, err := g.managedUpload(uploadID, bucketName, key); err != nil {
+		writeError(w, req, err)
+		return
+	}
+	f, err := ioutil.TempFile(g.uploadDir(uploadID), "part")
+	if err != nil {
+		writeError(w, req, errNoSuchUpload)
+		return
+	}
+	h := md5.New()
+	_, err = io.Copy(io.MultiWriter(f, h), req.Body)
+	err = errors.Compose(err, f.Close())
+	if err == nil {
+		err = os.Rename(f.Name(), g.partPath(uploadID, partNumber))
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		writeError(w, req, err)
+		return
+	}
+	w.Header().Set("ETag", etag(h.Sum(nil)))
+	w.WriteHeader(http.StatusOK)
+}
+
+// completeMultipartUpload handles CompleteMultipartUpload. The ETag of the
+// object is the md5 hash of the md5 hashes of its parts followed by the number
+// of parts.
+func (g *Gateway) completeMultipartUpload(w http.ResponseWriter, req *http.Request, bucketName, key string) {
+	uploadID := req.URL.Query().Get("uploadId")
+	u, err := g.managedUpload(uploadID, bucketName, key)
+	if err != nil {
+		writeError(w, req, err)
+		return
+	}
+	var cmu completeMultipartUpload
+	if err := xml.NewDecoder(req.Body).Decode(&cmu); err != nil || len(cmu.Parts) == 0 {
+		writeError(w, req, errMalformedXML)
+		return
+	}
+	for i := 1; i < len(cmu.Parts); i++ {
+		if cmu.Parts[i].PartNumber <= cmu.Parts[i-1].PartNumber {
+			writeError(w, req, errInvalidPartOrder)
+			return
+		}
+	}
+
+	// Concatenate the parts while checking their ETags.
+	f, err := ioutil.TempFile(filepath.Join(g.staticDir, tmpDir), "object")
+	if err != nil {
+		writeError(w, req, err)
+		return
+	}
+	h := newObjectHasher()
+	var size int64
+	var partSums []byte
+	err = func() error {
+		defer func() {
+			_ = f.Close()
+		}()
+		for _, p := range cmu.Parts {
+			part, err := os.Open(g.partPath(uploadID, p.PartNumber))
+			if os.IsNotExist(err) {
+				return errInvalidPart
+			} else if err != nil {
+				return err
+			}
+			ph := md5.New()
+			n, err := io.Copy(io.MultiWriter(f, h, ph), part)
+			err = errors.Compose(err, part.Close())
+			if err != nil {
+				return err
+			}
+			sum := ph.Sum(nil)
+			if strings.Trim(p.ETag, `"`) != hex.EncodeToString(sum) {
+				return errInvalidPart
+			}
+			size += n
+			partSums = append(partSums, sum...)
+		}
+		return f.Sync()
+	}()
+	if err != nil {
+		_ = os.Remove(f.Name())
+		writeError(w, req, err)
+		return
+	}
+
+	_, root := h.Sums()
+	sum := md5.Sum(partSums)
+	obj := object{
+		Root:         root,
+		Size:         size,
+		ETag:         fmt.Sprintf(`"%x-%d"`, sum, len(cmu.Parts)),
+		ContentType:  u.contentType,
+		Metadata:     u.metadata,
+		LastModified: time.Now(),
+	}
+	if err := g.managedPutObject(bucketName, key, obj, f.Name()); err != nil {
+		writeError(w, req, err)
+		return
+	}
+	if err := g.managedRemoveUpload(uploadID); err != nil {
+		writeError(w, req, err)
+		return
+	}
+	w.Header().Set(nftRootHeader, root.String())
+	writeXML(w, req, completeMultipartUploadResult{
+		Xmlns:    s3Namespace,
+		Location: "/" + bucketName + "/" + key,
+		Bucket:   bucketName,
+		Key:      key,
+		ETag:     obj.ETag,
+	})
+}
+
+// abortMultipartUpload handles AbortMultipartUpload.
+func (g *Gateway) abortMultipartUpload(w http.ResponseWriter, req *http.Request, bucketName, key string) {
+	uploadID := req.URL.Query().Get("uploadId")
+	if _, err := g.managedUpload(uploadID, bucketName, key); err != nil {
+		writeError(w, req, err)
+		return
+	}
+	if err := g.managedRemoveUpload(uploadID); err != nil {
+		writeError(w, req, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
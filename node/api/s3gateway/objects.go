@@ -0,0 +1,227 @@
+package s3gateway
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+)
+
+// objects.go contains the handlers of the requests addressing objects.
+
+// userMetadataPrefix is the prefix of the headers containing the user-defined
+// metadata of an object.
+const userMetadataPrefix = "X-Amz-Meta-"
+
+// serveObject handles the requests addressing an object.
+func (g *Gateway) serveObject(w http.ResponseWriter, req *http.Request, bucketName, key string) {
+	query := req.URL.Query()
+	switch {
+	case req.Method == http.MethodPut && hasQuery(query, "uploadId"):
+		g.uploadPart(w, req, bucketName, key)
+	case req.Method == http.MethodPut && req.Header.Get("X-Amz-Copy-Source") != "":
+		g.copyObject(w, req, bucketName, key)
+	case req.Method == http.MethodPut && len(query) == 0:
+		g.putObject(w, req, bucketName, key)
+	case req.Method == http.MethodPost && hasQuery(query, "uploads"):
+		g.createMultipartUpload(w, req, bucketName, key)
+	case req.Method == http.MethodPost && hasQuery(query, "uploadId"):
+		g.completeMultipartUpload(w, req, bucketName, key)
+	case req.Method == http.MethodDelete && hasQuery(query, "uploadId"):
+		g.abortMultipartUpload(w, req, bucketName, key)
+	case req.Method == http.MethodDelete:
+		if err := g.managedDeleteObject(bucketName, key); err != nil {
+			writeError(w, req, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case req.Method == http.MethodGet || req.Method == http.MethodHead:
+		if hasQuery(query, "acl", "tagging", "uploadId") {
+			writeError(w, req, errNotImplemented)
+			return
+		}
+		g.getObject(w, req, bucketName, key)
+	default:
+		writeError(w, req, errNotImplemented)
+	}
+}
+
+// userMetadata returns the user-defined metadata of the request's headers.
+func userMetadata(h http.Header) map[string]string {
+	var metadata map[string]string
+	for k, vs := range h {
+		if !strings.HasPrefix(k, userMetadataPrefix) || len(vs) == 0 {
+			continue
+		}
+		if metadata == nil {
+			metadata = make(map[string]string)
+		}
+		metadata[strings.ToLower(strings.TrimPrefix(k, userMetadataPrefix))] = vs[0]
+	}
+	return metadata
+}
+
+// contentType returns the content type of the request's body.
+func contentType(h http.Header) string {
+	if ct := h.Get("Content-Type"); ct != "" {
+		return ct
+	}
+	return defaultContentType
+}
+
+// writeTempObject writes the data of an object to a temporary file and
+// returns its path.
+func (g *Gateway) writeTempObject(r io.Reader) (string, int64, *objectHasher, error) {
+	f, err := ioutil.TempFile(filepath.Join(g.staticDir, tmpDir), "object")
+	if err != nil {
+		return "", 0, nil, err
+	}
+	h := newObjectHasher()
+	n, err := io.Copy(io.MultiWriter(f, h), r)
+	err = errors.Compose(err, f.Close())
+	if err != nil {
+		return "", 0, nil, errors.Compose(err, os.Remove(f.Name()))
+	}
+	return f.Name(), n, h, nil
+}
+
+// putObject handles PutObject.
+func (g *Gateway) putObject(w http.ResponseWriter, req *http.Request, bucketName, key string) {
+	if !g.managedBucketExists(bucketName) {
+		writeError(w, req, errNoSuchBucket)
+		return
+	}
+	source, size, h, err := g.writeTempObject(req.Body)
+	if err != nil {
+		writeError(w, req, err)
+		return
+	}
+	sum, root := h.Sums()
+	obj := object{
+		Root:         root,
+		Size:         size,
+		ETag:         etag(sum),
+		ContentType:  contentType(req.Header),
+		Metadata:     userMetadata(req.Header),
+		LastModified: time.Now(),
+	}
+	if err := g.managedPutObject(bucketName, key, obj, source); err != nil {
+		writeError(w, req, err)
+		return
+	}
+	w.Header().Set("ETag", obj.ETag)
+	w.Header().Set(nftRootHeader, root.String())
+	w.WriteHeader(http.StatusOK)
+}
+
+// getObject handles GetObject and HeadObject. The data of the object is
+// streamed from the NFT's siafile and range requests are supported.
+func (g *Gateway) getObject(w http.ResponseWriter, req *http.Request, bucketName, key string) {
+	obj, err := g.managedObject(bucketName, key)
+	if err != nil {
+		writeError(w, req, err)
+		return
+	}
+	w.Header().Set("ETag", obj.ETag)
+	w.Header().Set("Content-Type", obj.ContentType)
+	w.Header().Set("Accept-Ranges", "bytes")
+	for k, v := range obj.Metadata {
+		w.Header().Set(userMetadataPrefix+k, v)
+	}
+	if obj.Size > 0 {
+		w.Header().Set(nftRootHeader, obj.Root.String())
+	}
+	if req.Method == http.MethodHead {
+		w.Header().Set("Last-Modified", obj.LastModified.UTC().Format(http.TimeFormat))
+		w.Header().Set("Content-Length", strconv.FormatInt(obj.Size, 10))
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if obj.Size == 0 {
+		http.ServeContent(w, req, "", obj.LastModified, bytes.NewReader(nil))
+		return
+	}
+	siaPath, err := modules.NFTPinFolder.Join(obj.Root.String())
+	if err != nil {
+		writeError(w, req, err)
+		return
+	}
+	_, streamer, err := g.staticRenter.Streamer(siaPath, false)
+	if err != nil {
+		writeError(w, req, errors.AddContext(err, "unable to stream the object's data"))
+		return
+	}
+	defer func() {
+		_ = streamer.Close()
+	}()
+	http.ServeContent(w, req, "", obj.LastModified, streamer)
+}
+
+// copyObject handles CopyObject. The copy shares the data of the source
+// object.
+func (g *Gateway) copyObject(w http.ResponseWriter, req *http.Request, bucketName, key string) {
+	source, err := url.PathUnescape(req.Header.Get("X-Amz-Copy-Source"))
+	if err != nil {
+		writeError(w, req, s3Error{"InvalidArgument", "Copy Source must mention the source bucket and key.", http.StatusBadRequest})
+		return
+	}
+	if i := strings.Index(source, "?"); i >= 0 {
+		source = source[:i]
+	}
+	parts := strings.SplitN(strings.TrimPrefix(source, "/"), "/", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		writeError(w, req, s3Error{"InvalidArgument", "Copy Source must mention the source bucket and key.", http.StatusBadRequest})
+		return
+	}
+	var metadata map[string]string
+	if req.Header.Get("X-Amz-Metadata-Directive") == "REPLACE" {
+		metadata = userMetadata(req.Header)
+		if metadata == nil {
+			metadata = make(map[string]string)
+		}
+	}
+	obj, err := g.managedCopyObject(parts[0], parts[1], bucketName, key, contentType(req.Header), metadata)
+	if err != nil {
+		writeError(w, req, err)
+		return
+	}
+	writeXML(w, req, copyObjectResult{
+		Xmlns:        s3Namespace,
+		LastModified: s3Time(obj.LastModified),
+		ETag:         obj.ETag,
+	})
+}
+
+// deleteObjects handles DeleteObjects.
+func (g *Gateway) deleteObjects(w http.ResponseWriter, req *http.Request, bucketName string) {
+	var dr deleteRequest
+	if err := xml.NewDecoder(req.Body).Decode(&dr); err != nil {
+		writeError(w, req, errMalformedXML)
+		return
+	}
+	result := deleteResult{Xmlns: s3Namespace}
+	for _, o := range dr.Objects {
+		err := g.managedDeleteObject(bucketName, o.Key)
+		if errors.Contains(err, errNoSuchBucket) {
+			writeError(w, req, err)
+			return
+		}
+		if err != nil {
+			result.Errors = append(result.Errors, xmlDeleteError{Key: o.Key, Code: "InternalError", Message: err.Error()})
+		} else if !dr.Quiet {
+			result.Deleted = append(result.Deleted, xmlDeleted{Key: o.Key})
+		}
+	}
+	writeXML(w, req, result)
+}
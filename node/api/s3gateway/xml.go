@@ -0,0 +1,222 @@
+package s3gateway
+
+import (
+	"encoding/xml"
+	"net/http"
+	"time"
+)
+
+// xml.go contains the XML documents of the S3 API and its errors.
+
+// s3Namespace is the XML namespace of the S3 API.
+const s3Namespace = "http://s3.amazonaws.com/doc/2006-03-01/"
+
+// s3TimeFormat is the format of the timestamps in the S3 API's XML documents.
+const s3TimeFormat = "2006-01-02T15:04:05.000Z"
+
+// s3Error is an error of the S3 API.
+type s3Error struct {
+	code    string
+	message string
+	status  int
+}
+
+// Error implements the error interface.
+func (e s3Error) Error() string {
+	return e.code + ": " + e.message
+}
+
+var (
+	errAccessDenied            = s3Error{"AccessDenied", "Access Denied", http.StatusForbidden}
+	errBucketAlreadyOwnedByYou = s3Error{"BucketAlreadyOwnedByYou", "The bucket you tried to create already exists, and you own it.", http.StatusConflict}
+	errBucketNotEmpty          = s3Error{"BucketNotEmpty", "The bucket you tried to delete is not empty.", http.StatusConflict}
+	errInvalidAccessKeyID      = s3Error{"InvalidAccessKeyId", "The access key ID you provided does not exist in our records.", http.StatusForbidden}
+	errInvalidBucketName       = s3Error{"InvalidBucketName", "The specified bucket is not valid.", http.StatusBadRequest}
+	errInvalidPart             = s3Error{"InvalidPart", "One or more of the specified parts could not be found.", http.StatusBadRequest}
+	errInvalidPartOrder        = s3Error{"InvalidPartOrder", "The list of parts was not in ascending order.", http.StatusBadRequest}
+	errMalformedXML            = s3Error{"MalformedXML", "The XML you provided was not well-formed.", http.StatusBadRequest}
+	errMissingSecurityHeader   = s3Error{"MissingSecurityHeader", "Your request is missing a required header.", http.StatusBadRequest}
+	errNoSuchBucket            = s3Error{"NoSuchBucket", "The specified bucket does not exist.", http.StatusNotFound}
+	errNoSuchKey               = s3Error{"NoSuchKey", "The specified key does not exist.", http.StatusNotFound}
+	errNoSuchUpload            = s3Error{"NoSuchUpload", "The specified multipart upload does not exist.", http.StatusNotFound}
+	errNotImplemented          = s3Error{"NotImplemented", "A header or query you provided implies functionality that is not implemented.", http.StatusNotImplemented}
+	errRequestTimeTooSkewed    = s3Error{"RequestTimeTooSkewed", "The difference between the request time and the server's time is too large.", http.StatusForbidden}
+	errSignatureDoesNotMatch   = s3Error{"SignatureDoesNotMatch", "The request signature we calculated does not match the signature you provided.", http.StatusForbidden}
+)
+
+type (
+	// xmlError is the body of an error response.
+	xmlError struct {
+		XMLName  xml.Name `xml:"Error"`
+		Code     string   `xml:"Code"`
+		Message  string   `xml:"Message"`
+		Resource string   `xml:"Resource"`
+	}
+
+	// xmlOwner is the owner of buckets and objects.
+	xmlOwner struct {
+		ID          string `xml:"ID"`
+		DisplayName string `xml:"DisplayName"`
+	}
+
+	// xmlBucket is a bucket listed by ListBuckets.
+	xmlBucket struct {
+		Name         string `xml:"Name"`
+		CreationDate string `xml:"CreationDate"`
+	}
+
+	// listAllMyBucketsResult is the response of ListBuckets.
+	listAllMyBucketsResult struct {
+		XMLName xml.Name    `xml:"ListAllMyBucketsResult"`
+		Xmlns   string      `xml:"xmlns,attr"`
+		Owner   xmlOwner    `xml:"Owner"`
+		Buckets []xmlBucket `xml:"Buckets>Bucket"`
+	}
+
+	// locationConstraint is the response of GetBucketLocation.
+	locationConstraint struct {
+		XMLName xml.Name `xml:"LocationConstraint"`
+		Xmlns   string   `xml:"xmlns,attr"`
+	}
+
+	// xmlObject is an object listed by ListObjects.
+	xmlObject struct {
+		Key          string   `xml:"Key"`
+		LastModified string   `xml:"LastModified"`
+		ETag         string   `xml:"ETag"`
+		Size         int64    `xml:"Size"`
+		StorageClass string   `xml:"StorageClass"`
+		Owner        xmlOwner `xml:"Owner"`
+	}
+
+	// xmlCommonPrefix is a common prefix of keys listed by ListObjects.
+	xmlCommonPrefix struct {
+		Prefix string `xml:"Prefix"`
+	}
+
+	// listBucketResult is the response of ListObjects and ListObjectsV2.
+	// Marker and NextMarker are only set for ListObjects, the continuation
+	// tokens and KeyCount only for ListObjectsV2.
+	listBucketResult struct {
+		XMLName               xml.Name          `xml:"ListBucketResult"`
+		Xmlns                 string            `xml:"xmlns,attr"`
+		Name                  string            `xml:"Name"`
+		Prefix                string            `xml:"Prefix"`
+		Delimiter             string            `xml:"Delimiter,omitempty"`
+		Marker                *string           `xml:"Marker"`
+		NextMarker            string            `xml:"NextMarker,omitempty"`
+		StartAfter            string            `xml:"StartAfter,omitempty"`
+		ContinuationToken     string            `xml:"ContinuationToken,omitempty"`
+		NextContinuationToken string            `xml:"NextContinuationToken,omitempty"`
+		KeyCount              *int              `xml:"KeyCount"`
+		MaxKeys               int               `xml:"MaxKeys"`
+		IsTruncated           bool              `xml:"IsTruncated"`
+		Contents              []xmlObject       `xml:"Contents"`
+		CommonPrefixes        []xmlCommonPrefix `xml:"CommonPrefixes"`
+	}
+
+	// copyObjectResult is the response of CopyObject.
+	copyObjectResult struct {
+		XMLName      xml.Name `xml:"CopyObjectResult"`
+		Xmlns        string   `xml:"xmlns,attr"`
+		LastModified string   `xml:"LastModified"`
+		ETag         string   `xml:"ETag"`
+	}
+
+	// deleteRequest is the body of DeleteObjects.
+	deleteRequest struct {
+		XMLName xml.Name `xml:"Delete"`
+		Quiet   bool     `xml:"Quiet"`
+		Objects []struct {
+			Key string `xml:"Key"`
+		} `xml:"Object"`
+	}
+
+	// deleteResult is the response of DeleteObjects.
+	deleteResult struct {
+		XMLName xml.Name         `xml:"DeleteResult"`
+		Xmlns   string           `xml:"xmlns,attr"`
+		Deleted []xmlDeleted     `xml:"Deleted"`
+		Errors  []xmlDeleteError `xml:"Error"`
+	}
+
+	// xmlDeleted is an object deleted by DeleteObjects.
+	xmlDeleted struct {
+		Key string `xml:"Key"`
+	}
+
+	// xmlDeleteError is an object DeleteObjects failed to delete.
+	xmlDeleteError struct {
+		Key     string `xml:"Key"`
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+
+	// initiateMultipartUploadResult is the response of
+	// CreateMultipartUpload.
+	initiateMultipartUploadResult struct {
+		XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+		Xmlns    string   `xml:"xmlns,attr"`
+		Bucket   string   `xml:"Bucket"`
+		Key      string   `xml:"Key"`
+		UploadID string   `xml:"UploadId"`
+	}
+
+	// completeMultipartUpload is the body of CompleteMultipartUpload.
+	completeMultipartUpload struct {
+		XMLName xml.Name       `xml:"CompleteMultipartUpload"`
+		Parts   []completePart `xml:"Part"`
+	}
+
+	// completePart is a part of a completed multipart upload.
+	completePart struct {
+		PartNumber int    `xml:"PartNumber"`
+		ETag       string `xml:"ETag"`
+	}
+
+	// completeMultipartUploadResult is the response of
+	// CompleteMultipartUpload.
+	completeMultipartUploadResult struct {
+		XMLName  xml.Name `xml:"CompleteMultipartUploadResult"`
+		Xmlns    string   `xml:"xmlns,attr"`
+		Location string   `xml:"Location"`
+		Bucket   string   `xml:"Bucket"`
+		Key      string   `xml:"Key"`
+		ETag     string   `xml:"ETag"`
+	}
+)
+
+// s3Time formats a timestamp of an XML document.
+func s3Time(t time.Time) string {
+	return t.UTC().Format(s3TimeFormat)
+}
+
+// writeXML writes an XML document with status 200.
+func writeXML(w http.ResponseWriter, req *http.Request, v interface{}) {
+	b, err := xml.Marshal(v)
+	if err != nil {
+		writeError(w, req, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(xml.Header))
+	_, _ = w.Write(b)
+}
+
+// writeError writes an error response. Errors that aren't errors of the S3
+// API are reported as internal errors.
+func writeError(w http.ResponseWriter, req *http.Request, err error) {
+	e, ok := err.(s3Error)
+	if !ok {
+		e = s3Error{"InternalError", err.Error(), http.StatusInternalServerError}
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(e.status)
+	if req.Method == http.MethodHead {
+		return
+	}
+	b, _ := xml.Marshal(xmlError{Code: e.code, Message: e.message, Resource: req.URL.Path})
+	_, _ = w.Write([]byte(xml.Header))
+	_, _ = w.Write(b)
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"math/big"
+	"net/http"
 
 	"errors"
 
@@ -29,6 +30,18 @@ func scanAddress(addrStr string) (addr types.UnlockHash, err error) {
 	return addr, nil
 }
 
+// scanNFT scans the NFT referenced by a request. NFTs are referenced either by
+// their NftID in the nftid parameter or by the merkle root of their data in
+// the merkleRoot parameter.
+func scanNFT(req *http.Request) (nft types.NftCustody, err error) {
+	if id := req.FormValue("nftid"); id != "" {
+		err = nft.ID.LoadString(id)
+	} else {
+		err = nft.FileMerkleRoot.LoadString(req.FormValue("merkleRoot"))
+	}
+	return nft, err
+}
+
 // scanHash scans a crypto.Hash from a string.
 func scanHash(s string) (h crypto.Hash, err error) {
 	err = h.LoadString(s)
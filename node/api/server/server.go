@@ -22,6 +22,10 @@ import (
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/node"
 	"go.sia.tech/siad/node/api"
+	"go.sia.tech/siad/node/api/nftgateway"
+	"go.sia.tech/siad/node/api/s3gateway"
+	"go.sia.tech/siad/node/api/webhook"
+	"go.sia.tech/siad/persist"
 	"go.sia.tech/siad/types"
 )
 
@@ -32,9 +36,17 @@ type Server struct {
 	apiServer         *http.Server
 	listener          net.Listener
 	node              *node.Node
+	requiredPassword  string
 	requiredUserAgent string
+	siadConfig        *modules.SiadConfig
 	Dir               string
 
+	// gatewayServers serve the renter's gateways which were started.
+	gatewayServers []*http.Server
+
+	// webhooks posts events to the configured webhooks once started.
+	webhooks *webhook.Dispatcher
+
 	serveChan chan struct{}
 	serveErr  error
 
@@ -62,6 +74,12 @@ func (srv *Server) Close() error {
 	defer srv.closeMu.Unlock()
 	// Stop accepting API requests.
 	err := srv.apiServer.Shutdown(context.Background())
+	for _, gs := range srv.gatewayServers {
+		err = errors.Compose(err, gs.Shutdown(context.Background()))
+	}
+	if srv.webhooks != nil {
+		err = errors.Compose(err, srv.webhooks.Close())
+	}
 	// Wait for serve() to return and capture its error.
 	<-srv.serveChan
 	if !errors.Contains(srv.serveErr, http.ErrServerClosed) {
@@ -116,6 +134,104 @@ func (srv *Server) RenterSettings() (modules.RenterSettings, error) {
 	return srv.node.Renter.Settings()
 }
 
+// SetRenterSettings sets the renter's settings or returns an error if the node
+// has no renter
+func (srv *Server) SetRenterSettings(s modules.RenterSettings) error {
+	if srv.node.Renter == nil {
+		return errors.New("can't set renter settings for a non-renter node")
+	}
+	return srv.node.Renter.SetSettings(s)
+}
+
+// ServeS3Gateway starts serving the renter's S3 gateway on addr. If the API
+// requires a password, requests must be signed either with an API key, using
+// its name as the access key ID and the key as the secret, or with the API
+// password and any other access key ID. API keys need the read-only scope to
+// download objects and the renter-upload scope to modify buckets.
+func (srv *Server) ServeS3Gateway(addr string) error {
+	if srv.node.Renter == nil {
+		return errors.New("can't serve the S3 gateway for a non-renter node")
+	}
+	var creds s3gateway.Credentials
+	if srv.requiredPassword != "" {
+		creds = func(accessKeyID string) (string, bool, bool) {
+			for _, key := range srv.siadConfig.APIKeyList() {
+				if key.Name == accessKeyID {
+					write := key.HasScope(modules.APIKeyScopeRenterUpload)
+					return key.Key, write, write || key.HasScope(modules.APIKeyScopeReadOnly)
+				}
+			}
+			return srv.requiredPassword, true, true
+		}
+	}
+	g, err := s3gateway.New(srv.node.Renter, filepath.Join(srv.Dir, modules.RenterDir, "s3gateway"), creds)
+	if err != nil {
+		return errors.AddContext(err, "unable to create the S3 gateway")
+	}
+	return srv.serveGateway(addr, g)
+}
+
+// ServeNFTGateway starts serving the data of the renter's pinned NFTs on addr.
+// The gateway is read-only and doesn't require authentication. The data of
+// NFTs which are frozen by a dispute isn't served.
+func (srv *Server) ServeNFTGateway(addr string) error {
+	if srv.node.Renter == nil {
+		return errors.New("can't serve the NFT gateway for a non-renter node")
+	}
+	var compliance nftgateway.ComplianceHook
+	if srv.node.ConsensusSet != nil {
+		compliance = srv.node.ConsensusSet.ViewNFTRootFrozen
+	}
+	return srv.serveGateway(addr, nftgateway.New(srv.node.Renter, compliance))
+}
+
+// StartWebhooks starts posting events about the node's wallet and renter to
+// the provided webhooks. The dispatcher logs to webhooks.log in the node's
+// directory.
+func (srv *Server) StartWebhooks(hooks []webhook.Config) error {
+	logger, err := persist.NewFileLogger(filepath.Join(srv.Dir, "webhooks.log"))
+	if err != nil {
+		return errors.AddContext(err, "unable to create the webhook log")
+	}
+	d, err := webhook.New(hooks, logger)
+	if err != nil {
+		return errors.Compose(err, logger.Close())
+	}
+	if srv.node.Wallet != nil {
+		if err := d.SubscribeWallet(srv.node.Wallet); err != nil {
+			return errors.Compose(err, d.Close())
+		}
+	}
+	if srv.node.Renter != nil {
+		d.SubscribeRenter(srv.node.Renter)
+	}
+	srv.closeMu.Lock()
+	srv.webhooks = d
+	srv.closeMu.Unlock()
+	return nil
+}
+
+// serveGateway serves the handler of a gateway on addr until the server is
+// closed.
+func (srv *Server) serveGateway(addr string, h http.Handler) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	gs := &http.Server{
+		Handler:           h,
+		ReadHeaderTimeout: time.Minute * 2,
+		IdleTimeout:       time.Minute * 5,
+	}
+	srv.closeMu.Lock()
+	srv.gatewayServers = append(srv.gatewayServers, gs)
+	srv.closeMu.Unlock()
+	go func() {
+		_ = gs.Serve(listener)
+	}()
+	return nil
+}
+
 // ServeErr is a blocking call that will return the result of srv.serve after
 // the server stopped.
 func (srv *Server) ServeErr() <-chan error {
@@ -176,6 +292,9 @@ func NewAsync(APIaddr string, requiredUserAgent string, requiredPassword string,
 
 		// Create the api for the server.
 		api := api.New(cfg, requiredUserAgent, requiredPassword, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+		if nodeParams.ReadOnly {
+			api.SetReadOnly()
+		}
 		srv := &Server{
 			api: api,
 			apiServer: &http.Server{
@@ -201,7 +320,9 @@ func NewAsync(APIaddr string, requiredUserAgent string, requiredPassword string,
 			closeChan:         make(chan struct{}),
 			serveChan:         make(chan struct{}),
 			listener:          listener,
+			requiredPassword:  requiredPassword,
 			requiredUserAgent: requiredUserAgent,
+			siadConfig:        cfg,
 			Dir:               nodeParams.Dir,
 		}
 
@@ -4,6 +4,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"net/http"
+	"strconv"
 
 	"github.com/julienschmidt/httprouter"
 
@@ -35,6 +36,12 @@ type (
 		Confirmed bool `json:"confirmed"`
 	}
 
+	// TpoolRelayPolicyGET contains the policy used to filter NFT transaction
+	// sets relayed by peers.
+	TpoolRelayPolicyGET struct {
+		modules.TPoolRelayPolicy
+	}
+
 	// TpoolTxnsGET contains the information about the tpool's transactions
 	TpoolTxnsGET struct {
 		Transactions []types.Transaction `json:"transactions"`
@@ -59,6 +66,12 @@ func RegisterRoutesTransactionPool(router *httprouter.Router, tpool modules.Tran
 	router.GET("/tpool/transactions", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		tpoolTransactionsHandler(tpool, w, req, ps)
 	})
+	router.GET("/tpool/relaypolicy", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		tpoolRelayPolicyHandlerGET(tpool, w, req, ps)
+	})
+	router.POST("/tpool/relaypolicy", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		tpoolRelayPolicyHandlerPOST(tpool, w, req, ps)
+	})
 }
 
 // decodeTransactionID will decode a transaction id from a string.
@@ -168,3 +181,40 @@ func tpoolTransactionsHandler(tpool modules.TransactionPool, w http.ResponseWrit
 		Transactions: txns,
 	})
 }
+
+// tpoolRelayPolicyHandlerGET returns the policy used to filter NFT transaction
+// sets relayed by peers.
+func tpoolRelayPolicyHandlerGET(tpool modules.TransactionPool, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, TpoolRelayPolicyGET{
+		TPoolRelayPolicy: tpool.RelayPolicy(),
+	})
+}
+
+// tpoolRelayPolicyHandlerPOST updates the policy used to filter NFT
+// transaction sets relayed by peers. Fields that aren't specified keep their
+// current value.
+func tpoolRelayPolicyHandlerPOST(tpool modules.TransactionPool, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	rp := tpool.RelayPolicy()
+	if v := req.FormValue("minnftfeeperbyte"); v != "" {
+		fee, ok := scanAmount(v)
+		if !ok {
+			WriteError(w, Error{"unable to parse minnftfeeperbyte"}, http.StatusBadRequest)
+			return
+		}
+		rp.MinNFTFeePerByte = fee
+	}
+	if v := req.FormValue("maxnftarbitrarydatasize"); v != "" {
+		size, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"unable to parse maxnftarbitrarydatasize: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		rp.MaxNFTArbitraryDataSize = size
+	}
+	err := tpool.SetRelayPolicy(rp)
+	if err != nil {
+		WriteError(w, Error{"failed to set relay policy: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
@@ -16,6 +16,7 @@ import (
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/types"
+	"go.sia.tech/siad/types/nftschema"
 )
 
 type (
@@ -54,6 +55,85 @@ type (
 		PrimarySeed string `json:"primaryseed"`
 	}
 
+	// WalletNFTAuditGET contains the issues found by a GET call to
+	// /wallet/nft/audit.
+	WalletNFTAuditGET struct {
+		Issues []modules.NFTAuditIssue `json:"issues"`
+	}
+
+	// WalletNFTMintPOST contains the transactions sent in the POST call to
+	// /wallet/nft/mint. NftID is only set for NFTs minted with an identity.
+	WalletNFTMintPOST struct {
+		Transactions   []types.Transaction   `json:"transactions"`
+		TransactionIDs []types.TransactionID `json:"transactionids"`
+		NftID          types.NftID           `json:"nftid"`
+	}
+
+	// WalletNFTDepositAddressPOST contains the deposit address returned by a
+	// POST call to /wallet/nft/deposit/address.
+	WalletNFTDepositAddressPOST struct {
+		Address types.UnlockHash `json:"address"`
+	}
+
+	// WalletNFTDepositsGET contains the NFT deposits returned by a GET call
+	// to /wallet/nft/deposits.
+	WalletNFTDepositsGET struct {
+		Deposits []modules.NFTDeposit `json:"deposits"`
+	}
+
+	// WalletNFTTemplatesGET contains the mint templates returned by a GET
+	// call to /wallet/nft/templates.
+	WalletNFTTemplatesGET struct {
+		Templates []modules.NFTMintTemplate `json:"templates"`
+	}
+
+	// WalletNFTTemplatesPOST contains the mint template of a POST call to
+	// /wallet/nft/templates. If Remove is set, the template with the given ID
+	// is removed instead.
+	WalletNFTTemplatesPOST struct {
+		modules.NFTMintTemplate
+		Remove bool `json:"remove"`
+	}
+
+	// WalletNFTScheduleGET contains the scheduled transfers returned by a GET
+	// call to /wallet/nft/schedule.
+	WalletNFTScheduleGET struct {
+		Transfers []modules.NFTScheduledTransfer `json:"transfers"`
+	}
+
+	// WalletNFTUnlockableGET contains the unlockable content of an NFT and
+	// its content key returned by a GET call to /wallet/nft/unlockable.
+	WalletNFTUnlockableGET struct {
+		Unlockable types.NFTUnlockable    `json:"unlockable"`
+		Key        types.NFTUnlockableKey `json:"key"`
+	}
+
+	// WalletNFTUnlockableMintPOST contains the parameters of a POST call to
+	// /wallet/nft/unlockable/mint. If Destination is the zero address, the
+	// NFT is minted to a new address of the wallet.
+	WalletNFTUnlockableMintPOST struct {
+		NFT         types.NftCustody       `json:"nft"`
+		Unlockable  types.NFTUnlockable    `json:"unlockable"`
+		Key         types.NFTUnlockableKey `json:"key"`
+		Destination types.UnlockHash       `json:"destination"`
+	}
+
+	// WalletNFTUnlockableTransferPOST contains the parameters of a POST call
+	// to /wallet/nft/unlockable/transfer.
+	WalletNFTUnlockableTransferPOST struct {
+		NFT       types.NftCustody             `json:"nft"`
+		Handshake types.NFTUnlockableHandshake `json:"handshake"`
+	}
+
+	// WalletNFTUnlockableTransferPOSTResp contains the transactions sent in
+	// a POST call to /wallet/nft/unlockable/transfer and the envelope which
+	// needs to be passed to the recipient.
+	WalletNFTUnlockableTransferPOSTResp struct {
+		Transactions   []types.Transaction         `json:"transactions"`
+		TransactionIDs []types.TransactionID       `json:"transactionids"`
+		Envelope       types.NFTUnlockableEnvelope `json:"envelope"`
+	}
+
 	// WalletSiacoinsPOST contains the transaction sent in the POST call to
 	// /wallet/siacoins.
 	WalletSiacoinsPOST struct {
@@ -94,6 +174,38 @@ type (
 		Funds types.Currency `json:"funds"`
 	}
 
+	// WalletTransactionGroupPOSTParams contains the transactions of a group
+	// broadcast by a POST call to /wallet/transactiongroup and for how long
+	// the wallet broadcasts them.
+	WalletTransactionGroupPOSTParams struct {
+		Transactions []types.Transaction `json:"transactions"`
+		modules.TransactionGroupParams
+	}
+
+	// WalletNFTGovernancePOSTParams contains the transaction set co-signed
+	// by a POST call to /wallet/nft/governance/sign.
+	WalletNFTGovernancePOSTParams struct {
+		Transactions []types.Transaction `json:"transactions"`
+	}
+
+	// WalletTransactionGroupGET contains the transaction group returned by a
+	// call to /wallet/transactiongroup.
+	WalletTransactionGroupGET struct {
+		Group modules.TransactionGroup `json:"group"`
+	}
+
+	// WalletTransactionGroupsGET contains the transaction groups returned by
+	// a GET call to /wallet/transactiongroups.
+	WalletTransactionGroupsGET struct {
+		Groups []modules.TransactionGroup `json:"groups"`
+	}
+
+	// WalletBroadcastFailuresGET contains the refused transaction sets
+	// returned by a GET call to /wallet/broadcastfailures.
+	WalletBroadcastFailuresGET struct {
+		Failures []modules.BroadcastFailure `json:"failures"`
+	}
+
 	// WalletTransactionGETid contains the transaction returned by a call to
 	// /wallet/transaction/:id
 	WalletTransactionGETid struct {
@@ -158,106 +270,121 @@ type (
 	WalletWatchGET struct {
 		Addresses []types.UnlockHash `json:"addresses"`
 	}
+
+	// WalletsGET contains the named wallets managed by the daemon.
+	WalletsGET struct {
+		Wallets []modules.NamedWalletInfo `json:"wallets"`
+	}
 )
 
 // RegisterRoutesWallet is a helper function to register all wallet routes.
+// The routes of the wallet are registered under /wallet and the routes of its
+// named wallets under /wallets/:walletname.
 func RegisterRoutesWallet(router *httprouter.Router, wallet modules.Wallet, requiredPassword string) {
-	router.GET("/wallet", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
-		walletHandler(wallet, w, req, ps)
-	})
-	router.POST("/wallet/033x", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
-		wallet033xHandler(wallet, w, req, ps)
-	}, requiredPassword))
-	router.GET("/wallet/address", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
-		walletAddressHandler(wallet, w, req, ps)
-	}, requiredPassword))
-	router.GET("/wallet/addresses", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
-		walletAddressesHandler(wallet, w, req, ps)
-	})
-	router.GET("/wallet/seedaddrs", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
-		walletSeedAddressesHandler(wallet, w, req, ps)
-	})
-	router.GET("/wallet/backup", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
-		walletBackupHandler(wallet, w, req, ps)
-	}, requiredPassword))
-	router.POST("/wallet/init", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
-		walletInitHandler(wallet, w, req, ps)
-	}, requiredPassword))
-	router.POST("/wallet/init/seed", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
-		walletInitSeedHandler(wallet, w, req, ps)
-	}, requiredPassword))
-	router.POST("/wallet/lock", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
-		walletLockHandler(wallet, w, req, ps)
-	}, requiredPassword))
-	router.POST("/wallet/seed", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
-		walletSeedHandler(wallet, w, req, ps)
-	}, requiredPassword))
-	router.GET("/wallet/seeds", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
-		walletSeedsHandler(wallet, w, req, ps)
-	}, requiredPassword))
-	router.POST("/wallet/nft/mint", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
-		walletMintNFTHandler(wallet, w, req, ps)
+	registerWalletRoutes(router, "/wallet", func(httprouter.Params) (modules.Wallet, error) {
+		return wallet, nil
+	}, requiredPassword)
+	registerWalletRoutes(router, "/wallets/:walletname", func(ps httprouter.Params) (modules.Wallet, error) {
+		return wallet.NamedWallet(ps.ByName("walletname"))
+	}, requiredPassword)
+
+	router.GET("/wallets", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletsHandlerGET(wallet, w, req, ps)
 	}, requiredPassword))
-	router.GET("/wallet/nft/scan", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
-		walletScanNFTHandler(wallet, w, req, ps)
-	}, requiredPassword)) // not sure if this should require password
-	router.POST("/wallet/nft/transfer", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
-		walletTransferNFTHandler(wallet, w, req, ps)
+	router.POST("/wallets", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletsHandlerPOST(wallet, w, req, ps)
 	}, requiredPassword))
-	router.POST("/wallet/nft/liquidate", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
-		walletLiquidateNFTHandler(wallet, w, req, ps)
+	router.POST("/wallets/:walletname/open", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletsOpenHandler(wallet, w, req, ps)
 	}, requiredPassword))
-	router.POST("/wallet/siacoins", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
-		walletSiacoinsHandler(wallet, w, req, ps)
+	router.POST("/wallets/:walletname/close", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletsCloseHandler(wallet, w, req, ps)
 	}, requiredPassword))
-	router.POST("/wallet/siafunds", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
-		walletSiafundsHandler(wallet, w, req, ps)
-	}, requiredPassword))
-	router.POST("/wallet/siagkey", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
-		walletSiagkeyHandler(wallet, w, req, ps)
-	}, requiredPassword))
-	router.POST("/wallet/sweep/seed", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
-		walletSweepSeedHandler(wallet, w, req, ps)
-	}, requiredPassword))
-	router.GET("/wallet/transaction/:id", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
-		walletTransactionHandler(wallet, w, req, ps)
-	})
-	router.GET("/wallet/transactions", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
-		walletTransactionsHandler(wallet, w, req, ps)
-	})
-	router.GET("/wallet/transactions/:addr", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
-		walletTransactionsAddrHandler(wallet, w, req, ps)
-	})
-	router.GET("/wallet/verify/address/:addr", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+}
+
+// withWallet turns a wallet handler into a httprouter.Handle which looks up the
+// wallet the request is addressed to using walletFn.
+func withWallet(walletFn func(httprouter.Params) (modules.Wallet, error), handler func(modules.Wallet, http.ResponseWriter, *http.Request, httprouter.Params)) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		wallet, err := walletFn(ps)
+		if err != nil {
+			WriteError(w, Error{"unable to find wallet: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		handler(wallet, w, req, ps)
+	}
+}
+
+// registerWalletRoutes registers the routes of a single wallet under prefix.
+// walletFn returns the wallet a request is addressed to.
+func registerWalletRoutes(router *httprouter.Router, prefix string, walletFn func(httprouter.Params) (modules.Wallet, error), requiredPassword string) {
+	router.GET(prefix, withWallet(walletFn, walletHandler))
+	router.POST(prefix+"/033x", RequirePassword(withWallet(walletFn, wallet033xHandler), requiredPassword))
+	router.GET(prefix+"/address", RequirePassword(withWallet(walletFn, walletAddressHandler), requiredPassword))
+	router.GET(prefix+"/addresses", withWallet(walletFn, walletAddressesHandler))
+	router.GET(prefix+"/seedaddrs", withWallet(walletFn, walletSeedAddressesHandler))
+	router.GET(prefix+"/backup", RequirePassword(withWallet(walletFn, walletBackupHandler), requiredPassword))
+	router.POST(prefix+"/init", RequirePassword(withWallet(walletFn, walletInitHandler), requiredPassword))
+	router.POST(prefix+"/init/seed", RequirePassword(withWallet(walletFn, walletInitSeedHandler), requiredPassword))
+	router.POST(prefix+"/lock", RequirePassword(withWallet(walletFn, walletLockHandler), requiredPassword))
+	router.POST(prefix+"/seed", RequirePassword(withWallet(walletFn, walletSeedHandler), requiredPassword))
+	router.GET(prefix+"/seeds", RequirePassword(withWallet(walletFn, walletSeedsHandler), requiredPassword))
+	router.GET(prefix+"/nft/address", RequirePassword(withWallet(walletFn, walletNFTAddressHandler), requiredPassword))
+	router.POST(prefix+"/nft/mint", RequirePassword(withWallet(walletFn, walletMintNFTHandler), requiredPassword))
+	router.GET(prefix+"/nft/scan", RequirePassword(withWallet(walletFn, walletScanNFTHandler), requiredPassword)) // not sure if this should require password
+	router.POST(prefix+"/nft/transfer", RequirePassword(withWallet(walletFn, walletTransferNFTHandler), requiredPassword))
+	router.POST(prefix+"/nft/liquidate", RequirePassword(withWallet(walletFn, walletLiquidateNFTHandler), requiredPassword))
+	router.POST(prefix+"/nft/reclaim", RequirePassword(withWallet(walletFn, walletReclaimNFTLockupHandler), requiredPassword))
+	router.POST(prefix+"/nft/compose", RequirePassword(withWallet(walletFn, walletComposeNFTHandler), requiredPassword))
+	router.POST(prefix+"/nft/governance/sign", RequirePassword(withWallet(walletFn, walletSignNFTGovernanceHandler), requiredPassword))
+	router.POST(prefix+"/nft/dispute/freeze", RequirePassword(withWallet(walletFn, walletFreezeNFTHandler), requiredPassword))
+	router.POST(prefix+"/nft/dispute/unfreeze", RequirePassword(withWallet(walletFn, walletUnfreezeNFTHandler), requiredPassword))
+	router.POST(prefix+"/nft/lockup/disburse", RequirePassword(withWallet(walletFn, walletDisburseNFTLockupPoolHandler), requiredPassword))
+	router.POST(prefix+"/nft/bridge/lock", RequirePassword(withWallet(walletFn, walletBridgeLockNFTHandler), requiredPassword))
+	router.POST(prefix+"/nft/stake", RequirePassword(withWallet(walletFn, walletStakeNFTHandler), requiredPassword))
+	router.POST(prefix+"/nft/unstake", RequirePassword(withWallet(walletFn, walletUnstakeNFTHandler), requiredPassword))
+	router.POST(prefix+"/nft/approve", RequirePassword(withWallet(walletFn, walletApproveNFTHandler), requiredPassword))
+	router.POST(prefix+"/nft/approve/revoke", RequirePassword(withWallet(walletFn, walletRevokeNFTApprovalHandler), requiredPassword))
+	router.POST(prefix+"/nft/approve/transfer", RequirePassword(withWallet(walletFn, walletTransferApprovedNFTHandler), requiredPassword))
+	router.GET(prefix+"/nft/provenance", RequirePassword(withWallet(walletFn, walletNFTProvenanceHandler), requiredPassword))
+	router.GET(prefix+"/reserves", RequirePassword(withWallet(walletFn, walletReservesHandler), requiredPassword))
+	router.GET(prefix+"/nft/audit", RequirePassword(withWallet(walletFn, walletNFTAuditHandler), requiredPassword))
+	router.POST(prefix+"/nft/deposit/address", RequirePassword(withWallet(walletFn, walletNFTDepositAddressHandler), requiredPassword))
+	router.GET(prefix+"/nft/deposits", RequirePassword(withWallet(walletFn, walletNFTDepositsHandler), requiredPassword))
+	router.GET(prefix+"/nft/templates", RequirePassword(withWallet(walletFn, walletNFTTemplatesHandlerGET), requiredPassword))
+	router.POST(prefix+"/nft/templates", RequirePassword(withWallet(walletFn, walletNFTTemplatesHandlerPOST), requiredPassword))
+	router.GET(prefix+"/nft/schedule", RequirePassword(withWallet(walletFn, walletNFTScheduleHandlerGET), requiredPassword))
+	router.POST(prefix+"/nft/schedule", RequirePassword(withWallet(walletFn, walletNFTScheduleHandlerPOST), requiredPassword))
+	router.POST(prefix+"/nft/schedule/cancel", RequirePassword(withWallet(walletFn, walletNFTScheduleCancelHandler), requiredPassword))
+	router.POST(prefix+"/nft/sweep", RequirePassword(withWallet(walletFn, walletNFTSweepHandler), requiredPassword))
+	router.GET(prefix+"/nft/unlockable", RequirePassword(withWallet(walletFn, walletNFTUnlockableHandler), requiredPassword))
+	router.GET(prefix+"/nft/unlockable/handshake", RequirePassword(withWallet(walletFn, walletNFTUnlockableHandshakeHandler), requiredPassword))
+	router.POST(prefix+"/nft/unlockable/import", RequirePassword(withWallet(walletFn, walletNFTUnlockableImportHandler), requiredPassword))
+	router.POST(prefix+"/nft/unlockable/mint", RequirePassword(withWallet(walletFn, walletNFTUnlockableMintHandler), requiredPassword))
+	router.POST(prefix+"/nft/unlockable/transfer", RequirePassword(withWallet(walletFn, walletNFTUnlockableTransferHandler), requiredPassword))
+	router.POST(prefix+"/siacoins", RequirePassword(withWallet(walletFn, walletSiacoinsHandler), requiredPassword))
+	router.POST(prefix+"/siafunds", RequirePassword(withWallet(walletFn, walletSiafundsHandler), requiredPassword))
+	router.POST(prefix+"/siagkey", RequirePassword(withWallet(walletFn, walletSiagkeyHandler), requiredPassword))
+	router.POST(prefix+"/sweep/seed", RequirePassword(withWallet(walletFn, walletSweepSeedHandler), requiredPassword))
+	router.POST(prefix+"/transactiongroup", RequirePassword(withWallet(walletFn, walletTransactionGroupHandlerPOST), requiredPassword))
+	router.GET(prefix+"/transactiongroup/:id", RequirePassword(withWallet(walletFn, walletTransactionGroupHandlerGET), requiredPassword))
+	router.GET(prefix+"/transactiongroups", RequirePassword(withWallet(walletFn, walletTransactionGroupsHandler), requiredPassword))
+	router.GET(prefix+"/broadcastfailures", RequirePassword(withWallet(walletFn, walletBroadcastFailuresHandler), requiredPassword))
+	router.GET(prefix+"/transaction/:id", withWallet(walletFn, walletTransactionHandler))
+	router.GET(prefix+"/transactions", withWallet(walletFn, walletTransactionsHandler))
+	router.GET(prefix+"/transactions/:addr", withWallet(walletFn, walletTransactionsAddrHandler))
+	router.GET(prefix+"/verify/address/:addr", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		walletVerifyAddressHandler(w, req, ps)
 	})
-	router.POST("/wallet/unlock", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
-		walletUnlockHandler(wallet, w, req, ps)
-	}, requiredPassword))
-	router.POST("/wallet/changepassword", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
-		walletChangePasswordHandler(wallet, w, req, ps)
-	}, requiredPassword))
-	router.GET("/wallet/verifypassword", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
-		walletVerifyPasswordHandler(wallet, w, req, ps)
-	}, requiredPassword))
-	router.GET("/wallet/unlockconditions/:addr", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
-		walletUnlockConditionsHandlerGET(wallet, w, req, ps)
-	}, requiredPassword))
-	router.POST("/wallet/unlockconditions", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
-		walletUnlockConditionsHandlerPOST(wallet, w, req, ps)
-	}, requiredPassword))
-	router.GET("/wallet/unspent", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
-		walletUnspentHandler(wallet, w, req, ps)
-	}, requiredPassword))
-	router.POST("/wallet/sign", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
-		walletSignHandler(wallet, w, req, ps)
-	}, requiredPassword))
-	router.GET("/wallet/watch", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
-		walletWatchHandlerGET(wallet, w, req, ps)
-	}, requiredPassword))
-	router.POST("/wallet/watch", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
-		walletWatchHandlerPOST(wallet, w, req, ps)
-	}, requiredPassword))
+	router.POST(prefix+"/unlock", RequirePassword(withWallet(walletFn, walletUnlockHandler), requiredPassword))
+	router.POST(prefix+"/changepassword", RequirePassword(withWallet(walletFn, walletChangePasswordHandler), requiredPassword))
+	router.GET(prefix+"/verifypassword", RequirePassword(withWallet(walletFn, walletVerifyPasswordHandler), requiredPassword))
+	router.GET(prefix+"/unlockconditions/:addr", RequirePassword(withWallet(walletFn, walletUnlockConditionsHandlerGET), requiredPassword))
+	router.POST(prefix+"/unlockconditions", RequirePassword(withWallet(walletFn, walletUnlockConditionsHandlerPOST), requiredPassword))
+	router.GET(prefix+"/unspent", RequirePassword(withWallet(walletFn, walletUnspentHandler), requiredPassword))
+	router.POST(prefix+"/sign", RequirePassword(withWallet(walletFn, walletSignHandler), requiredPassword))
+	router.GET(prefix+"/watch", RequirePassword(withWallet(walletFn, walletWatchHandlerGET), requiredPassword))
+	router.POST(prefix+"/watch", RequirePassword(withWallet(walletFn, walletWatchHandlerPOST), requiredPassword))
 }
 
 // encryptionKeys enumerates the possible encryption keys that can be derived
@@ -365,6 +492,18 @@ func walletAddressHandler(wallet modules.Wallet, w http.ResponseWriter, _ *http.
 	})
 }
 
+// walletNFTAddressHandler handles API calls to /wallet/nft/address.
+func walletNFTAddressHandler(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	unlockConditions, err := wallet.NextNFTCustodyAddress()
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/nft/address: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletAddressGET{
+		Address: unlockConditions.UnlockHash(),
+	})
+}
+
 // walletSeedAddressesHandler handles the requests to /wallet/seedaddrs.
 func walletSeedAddressesHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	// Parse the count argument. If it isn't specified we return as many
@@ -592,7 +731,23 @@ func walletSeedsHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.
 }
 
 // walletMintNFTHandler handles API calls to /wallet/nft/mint
-// only argument is merkleRoot for merkle root of the data
+// arguments are merkleRoot for merkle root of the data
+// and the optional contenttype and contentlength the mint commits to.
+// If a collection or nonce is given, the NFT is minted with an NftID derived
+// from them, which allows minting the same data more than once. If editions is
+// given, edition number edition out of that many editions is minted instead
+// of using the nonce. All editions of a run have to be minted by the same
+// creator, which is why the optional destination can be set to the address of
+// the earlier editions. By default the NFT is minted to a new address. The
+// optional transferpolicy restricts the transfers of the NFT to soulbound or
+// afterheight, which requires transferheight. The optional cid or multihash
+// record the IPFS CID or hex encoded multihash of the data in the mint, which
+// is only supported for NFTs minted without an NftID. The optional license or
+// licenseterms commit the mint to the SPDX identifier of a license or to the
+// merkle root of custom license terms, which is supported for NFTs minted
+// without an NftID and content reference. If metadata is given,
+// the mint is refused unless it is a valid nftschema document describing the
+// data. strictmetadata enables the strict validation of the metadata.
 func walletMintNFTHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	// load params
 	var merkleRoot crypto.Hash
@@ -602,24 +757,167 @@ func walletMintNFTHandler(wallet modules.Wallet, w http.ResponseWriter, req *htt
 		WriteError(w, Error{"could not load merkle root of NFT to mint"}, http.StatusInternalServerError)
 		return
 	}
+	if metadata := req.FormValue("metadata"); metadata != "" {
+		var strict bool
+		if s := req.FormValue("strictmetadata"); s != "" {
+			strict, err = strconv.ParseBool(s)
+			if err != nil {
+				WriteError(w, Error{"could not parse strictmetadata: " + err.Error()}, http.StatusBadRequest)
+				return
+			}
+		}
+		md, err := nftschema.Parse([]byte(metadata), strict)
+		if err == nil {
+			err = md.ValidateFor(merkleRoot, strict)
+		}
+		if err != nil {
+			WriteError(w, Error{"invalid metadata: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if template := req.FormValue("template"); template != "" {
+		nft, txns, err := wallet.MintNFTFromTemplate(template, merkleRoot)
+		if err != nil {
+			WriteError(w, Error{"error when calling /wallet/nft/mint: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		writeNFTMintResponse(w, nft, txns)
+		return
+	}
 	nft.FileMerkleRoot = merkleRoot
+	nft.ContentType = req.FormValue("contenttype")
+	if cl := req.FormValue("contentlength"); cl != "" {
+		nft.ContentLength, err = strconv.ParseUint(cl, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"could not parse contentlength: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if policy := req.FormValue("transferpolicy"); policy != "" {
+		if err := nft.TransferPolicy.Kind.LoadString(policy); err != nil {
+			WriteError(w, Error{"could not parse transferpolicy: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if th := req.FormValue("transferheight"); th != "" {
+		if _, err := fmt.Sscan(th, &nft.TransferPolicy.Height); err != nil {
+			WriteError(w, Error{"could not parse transferheight: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	collection, nonceStr := req.FormValue("collection"), req.FormValue("nonce")
+	editionStr, editionsStr := req.FormValue("edition"), req.FormValue("editions")
+	var ref *types.NFTContentReference
+	if cid, multihash := req.FormValue("cid"), req.FormValue("multihash"); cid != "" || multihash != "" {
+		if cid != "" && multihash != "" {
+			WriteError(w, Error{"cid and multihash can't be combined"}, http.StatusBadRequest)
+			return
+		}
+		if collection != "" || nonceStr != "" || editionsStr != "" {
+			WriteError(w, Error{"content references are only supported for nfts minted without an id"}, http.StatusBadRequest)
+			return
+		}
+		var r types.NFTContentReference
+		if cid != "" {
+			r, err = types.ParseNFTCID(cid)
+		} else {
+			r, err = types.ParseNFTMultihash(multihash)
+		}
+		if err != nil {
+			WriteError(w, Error{"could not parse content reference: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		ref = &r
+	}
+	var license *types.NFTLicense
+	if id, terms := req.FormValue("license"), req.FormValue("licenseterms"); id != "" || terms != "" {
+		if id != "" && terms != "" {
+			WriteError(w, Error{"license and licenseterms can't be combined"}, http.StatusBadRequest)
+			return
+		}
+		if ref != nil || collection != "" || nonceStr != "" || editionsStr != "" {
+			WriteError(w, Error{"licenses are only supported for nfts minted without an id or content reference"}, http.StatusBadRequest)
+			return
+		}
+		l := types.NFTSPDXLicense(id)
+		if terms != "" {
+			l = types.NFTLicense{Kind: types.NFTLicenseCustom}
+			if err := l.Terms.LoadString(terms); err != nil {
+				WriteError(w, Error{"could not parse licenseterms: " + err.Error()}, http.StatusBadRequest)
+				return
+			}
+		}
+		if err := l.Validate(); err != nil {
+			WriteError(w, Error{"invalid license: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		license = &l
+	}
+	var nonce, edition, editions uint64
+	for _, param := range []struct {
+		name  string
+		value string
+		dst   *uint64
+	}{
+		{"nonce", nonceStr, &nonce},
+		{"edition", editionStr, &edition},
+		{"editions", editionsStr, &editions},
+	} {
+		if param.value == "" {
+			continue
+		}
+		*param.dst, err = strconv.ParseUint(param.value, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"could not parse " + param.name + ": " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
 	// make minting transaction(s)
-	unlockConditions, _ := wallet.NextAddress()
+	var output types.UnlockHash
+	if dest := req.FormValue("destination"); dest != "" {
+		output, err = scanAddress(dest)
+		if err != nil {
+			WriteError(w, Error{"could not read address from POST call to /wallet/nft/mint"}, http.StatusBadRequest)
+			return
+		}
+	} else {
+		unlockConditions, err := wallet.NextNFTCustodyAddress()
+		if err != nil {
+			WriteError(w, Error{"error when calling /wallet/nft/mint: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		output = unlockConditions.UnlockHash()
+	}
 	var txns []types.Transaction
-	var output types.UnlockHash = unlockConditions.UnlockHash()
-	txns, err = wallet.MintNFT(nft, output)
+	if editionsStr != "" {
+		nft, txns, err = wallet.MintNFTEdition(nft, collection, edition, editions, output)
+	} else if collection != "" || nonceStr != "" {
+		nft, txns, err = wallet.MintIdentifiedNFT(nft, collection, nonce, output)
+	} else if ref != nil {
+		txns, err = wallet.MintReferencedNFT(nft, *ref, output)
+	} else if license != nil {
+		txns, err = wallet.MintLicensedNFT(nft, *license, output)
+	} else {
+		txns, err = wallet.MintNFT(nft, output)
+	}
 	if err != nil {
 		WriteError(w, Error{"error when calling /wallet/nft/mint: " + err.Error()}, http.StatusInternalServerError)
 		return
 	}
+	writeNFTMintResponse(w, nft, txns)
+}
 
+// writeNFTMintResponse writes the response of a successful call to
+// /wallet/nft/mint.
+func writeNFTMintResponse(w http.ResponseWriter, nft types.NftCustody, txns []types.Transaction) {
 	var txids []types.TransactionID
 	for _, txn := range txns {
 		txids = append(txids, txn.ID())
 	}
-	WriteJSON(w, WalletSiacoinsPOST{
+	WriteJSON(w, WalletNFTMintPOST{
 		Transactions:   txns,
 		TransactionIDs: txids,
+		NftID:          nft.ID,
 	})
 }
 
@@ -631,15 +929,13 @@ func walletScanNFTHandler(wallet modules.Wallet, w http.ResponseWriter, req *htt
 }
 
 // walletMintNFTHandler handles API calls to /wallet/nft/transfer
-// arguments are merkleRoot for merkle root of the data
+// arguments are merkleRoot for merkle root of the data or nftid for the NftID
 // and address to transfer the NFT to
 func walletTransferNFTHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	// load params
-	var merkleRoot crypto.Hash
-	var nft types.NftCustody
-	err := merkleRoot.LoadString(req.FormValue("merkleRoot"))
+	nft, err := scanNFT(req)
 	if err != nil {
-		WriteError(w, Error{"could not load merkle root of NFT to transfer"}, http.StatusInternalServerError)
+		WriteError(w, Error{"could not load merkle root or id of NFT to transfer"}, http.StatusInternalServerError)
 		return
 	}
 	dest, err := scanAddress(req.FormValue("destination"))
@@ -647,7 +943,6 @@ func walletTransferNFTHandler(wallet modules.Wallet, w http.ResponseWriter, req
 		WriteError(w, Error{"could not read address from POST call to /wallet/nft/transfer"}, http.StatusBadRequest)
 		return
 	}
-	nft.FileMerkleRoot = merkleRoot
 	// make minting transaction(s)
 	var txns []types.Transaction
 	txns, err = wallet.TransferNFT(nft, dest)
@@ -667,15 +962,13 @@ func walletTransferNFTHandler(wallet modules.Wallet, w http.ResponseWriter, req
 }
 
 // walletMintNFTHandler handles API calls to /wallet/nft/liquidate
-// arguments are merkleRoot for merkle root of the data
+// arguments are merkleRoot for merkle root of the data or nftid for the NftID
 // and address to send NFT lockup value to
 func walletLiquidateNFTHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	// load params
-	var merkleRoot crypto.Hash
-	var nft types.NftCustody
-	err := merkleRoot.LoadString(req.FormValue("merkleRoot"))
+	nft, err := scanNFT(req)
 	if err != nil {
-		WriteError(w, Error{"could not load merkle root of NFT to transfer"}, http.StatusInternalServerError)
+		WriteError(w, Error{"could not load merkle root or id of NFT to transfer"}, http.StatusInternalServerError)
 		return
 	}
 	dest, err := scanAddress(req.FormValue("destination"))
@@ -683,7 +976,6 @@ func walletLiquidateNFTHandler(wallet modules.Wallet, w http.ResponseWriter, req
 		WriteError(w, Error{"could not read address from POST call to /wallet/nft/liquidate"}, http.StatusBadRequest)
 		return
 	}
-	nft.FileMerkleRoot = merkleRoot
 	// make minting transaction(s)
 	var txns []types.Transaction
 	txns, err = wallet.LiquidateNFT(nft, dest)
@@ -702,54 +994,19 @@ func walletLiquidateNFTHandler(wallet modules.Wallet, w http.ResponseWriter, req
 	})
 }
 
-// walletSiacoinsHandler handles API calls to /wallet/siacoins.
-func walletSiacoinsHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
-	var txns []types.Transaction
-	if req.FormValue("outputs") != "" {
-		// multiple amounts + destinations
-		if req.FormValue("amount") != "" || req.FormValue("destination") != "" || req.FormValue("feeIncluded") != "" {
-			WriteError(w, Error{"cannot supply both 'outputs' and single amount+destination pair and/or feeIncluded parameter"}, http.StatusInternalServerError)
-			return
-		}
-
-		var outputs []types.SiacoinOutput
-		err := json.Unmarshal([]byte(req.FormValue("outputs")), &outputs)
-		if err != nil {
-			WriteError(w, Error{"could not decode outputs: " + err.Error()}, http.StatusInternalServerError)
-			return
-		}
-		txns, err = wallet.SendSiacoinsMulti(outputs)
-		if err != nil {
-			WriteError(w, Error{"error when calling /wallet/siacoins: " + err.Error()}, http.StatusInternalServerError)
-			return
-		}
-	} else {
-		// single amount + destination
-		amount, ok := scanAmount(req.FormValue("amount"))
-		if !ok {
-			WriteError(w, Error{"could not read amount from POST call to /wallet/siacoins"}, http.StatusBadRequest)
-			return
-		}
-		dest, err := scanAddress(req.FormValue("destination"))
-		if err != nil {
-			WriteError(w, Error{"could not read address from POST call to /wallet/siacoins"}, http.StatusBadRequest)
-			return
-		}
-		feeIncluded, err := scanBool(req.FormValue("feeIncluded"))
-		if err != nil {
-			WriteError(w, Error{"could not read feeIncluded from POST call to /wallet/siacoins"}, http.StatusBadRequest)
-			return
-		}
-
-		if feeIncluded {
-			txns, err = wallet.SendSiacoinsFeeIncluded(amount, dest)
-		} else {
-			txns, err = wallet.SendSiacoins(amount, dest)
-		}
-		if err != nil {
-			WriteError(w, Error{"error when calling /wallet/siacoins: " + err.Error()}, http.StatusInternalServerError)
-			return
-		}
+// walletComposeNFTHandler handles API calls to /wallet/nft/compose. The body
+// holds a modules.TransactionSpec, the response the composed transaction set.
+func walletComposeNFTHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var spec modules.TransactionSpec
+	err := json.NewDecoder(req.Body).Decode(&spec)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	txns, err := wallet.ComposeTransaction(spec)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/nft/compose: " + err.Error()}, http.StatusBadRequest)
+		return
 	}
 
 	var txids []types.TransactionID
@@ -762,77 +1019,808 @@ func walletSiacoinsHandler(wallet modules.Wallet, w http.ResponseWriter, req *ht
 	})
 }
 
-// walletSiafundsHandler handles API calls to /wallet/siafunds.
-func walletSiafundsHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
-	amount, ok := scanAmount(req.FormValue("amount"))
-	if !ok {
-		WriteError(w, Error{"could not read 'amount' from POST call to /wallet/siafunds"}, http.StatusBadRequest)
-		return
-	}
-	dest, err := scanAddress(req.FormValue("destination"))
+// walletSignNFTGovernanceHandler handles API calls to
+// /wallet/nft/governance/sign. The body holds the transaction set to co-sign
+// with the governance keys of the wallet, the response holds the set with the
+// added signatures.
+func walletSignNFTGovernanceHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var params WalletNFTGovernancePOSTParams
+	err := json.NewDecoder(req.Body).Decode(&params)
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/siafunds: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
 		return
 	}
-
-	txns, err := wallet.SendSiafunds(amount, dest)
+	txns, err := wallet.SignNFTGovernance(params.Transactions)
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/siafunds: " + err.Error()}, http.StatusInternalServerError)
+		WriteError(w, Error{"error when calling /wallet/nft/governance/sign: " + err.Error()}, http.StatusBadRequest)
 		return
 	}
+
 	var txids []types.TransactionID
 	for _, txn := range txns {
 		txids = append(txids, txn.ID())
 	}
-	WriteJSON(w, WalletSiafundsPOST{
+	WriteJSON(w, WalletSiacoinsPOST{
 		Transactions:   txns,
 		TransactionIDs: txids,
 	})
 }
 
-// walletSweepSeedHandler handles API calls to /wallet/sweep/seed.
-func walletSweepSeedHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
-	// Get the seed using the dictionary + phrase
-	dictID := mnemonics.DictionaryID(req.FormValue("dictionary"))
-	if dictID == "" {
-		dictID = "english"
-	}
-	seed, err := modules.StringToSeed(req.FormValue("seed"), dictID)
+// walletFreezeNFTHandler handles API calls to /wallet/nft/dispute/freeze
+// arguments are merkleRoot for merkle root of the NFT's data or nftid for its
+// NftID, kind of the dispute and its description
+func walletFreezeNFTHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	nft, err := scanNFT(req)
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/sweep/seed: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{"could not load merkle root or id of NFT"}, http.StatusBadRequest)
 		return
 	}
-
-	coins, funds, err := wallet.SweepSeed(seed)
+	reason := types.NFTDisputeReason{Description: req.FormValue("description")}
+	if err := reason.Kind.LoadString(req.FormValue("kind")); err != nil {
+		WriteError(w, Error{"could not read kind from POST call to /wallet/nft/dispute/freeze: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	txns, err := wallet.FreezeNFT(nft, reason)
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/sweep/seed: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{"error when calling /wallet/nft/dispute/freeze: " + err.Error()}, http.StatusInternalServerError)
 		return
 	}
-	WriteJSON(w, WalletSweepPOST{
-		Coins: coins,
-		Funds: funds,
+
+	var txids []types.TransactionID
+	for _, txn := range txns {
+		txids = append(txids, txn.ID())
+	}
+	WriteJSON(w, WalletSiacoinsPOST{
+		Transactions:   txns,
+		TransactionIDs: txids,
 	})
 }
 
-// walletTransactionHandler handles API calls to /wallet/transaction/:id.
-func walletTransactionHandler(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
-	// Parse the id from the url.
-	var id types.TransactionID
-	jsonID := "\"" + ps.ByName("id") + "\""
-	err := id.UnmarshalJSON([]byte(jsonID))
+// walletUnfreezeNFTHandler handles API calls to /wallet/nft/dispute/unfreeze
+// arguments are merkleRoot for merkle root of the NFT's data or nftid for its
+// NftID and description of the resolution
+func walletUnfreezeNFTHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	nft, err := scanNFT(req)
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/transaction/id: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{"could not load merkle root or id of NFT"}, http.StatusBadRequest)
 		return
 	}
-
-	txn, ok, err := wallet.Transaction(id)
+	txns, err := wallet.UnfreezeNFT(nft, req.FormValue("description"))
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/transaction/id: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{"error when calling /wallet/nft/dispute/unfreeze: " + err.Error()}, http.StatusInternalServerError)
 		return
 	}
-	if !ok {
-		WriteError(w, Error{"error when calling /wallet/transaction/id  :  transaction not found"}, http.StatusBadRequest)
-		return
+
+	var txids []types.TransactionID
+	for _, txn := range txns {
+		txids = append(txids, txn.ID())
+	}
+	WriteJSON(w, WalletSiacoinsPOST{
+		Transactions:   txns,
+		TransactionIDs: txids,
+	})
+}
+
+// walletDisburseNFTLockupPoolHandler handles API calls to
+// /wallet/nft/lockup/disburse
+// arguments are amount of hastings to pay out of the lockup pool and
+// destination address
+func walletDisburseNFTLockupPoolHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	amount, ok := scanAmount(req.FormValue("amount"))
+	if !ok {
+		WriteError(w, Error{"could not read amount from POST call to /wallet/nft/lockup/disburse"}, http.StatusBadRequest)
+		return
+	}
+	dest, err := scanAddress(req.FormValue("destination"))
+	if err != nil {
+		WriteError(w, Error{"could not read address from POST call to /wallet/nft/lockup/disburse"}, http.StatusBadRequest)
+		return
+	}
+	txns, err := wallet.DisburseNFTLockupPool(amount, dest)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/nft/lockup/disburse: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	var txids []types.TransactionID
+	for _, txn := range txns {
+		txids = append(txids, txn.ID())
+	}
+	WriteJSON(w, WalletSiacoinsPOST{
+		Transactions:   txns,
+		TransactionIDs: txids,
+	})
+}
+
+// walletReclaimNFTLockupHandler handles API calls to /wallet/nft/reclaim
+// arguments are merkleRoot for merkle root of the NFT's data or nftid for its
+// NftID
+// and address to send the vested NFT lockup value to
+func walletReclaimNFTLockupHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	nft, err := scanNFT(req)
+	if err != nil {
+		WriteError(w, Error{"could not load merkle root or id of NFT"}, http.StatusBadRequest)
+		return
+	}
+	dest, err := scanAddress(req.FormValue("destination"))
+	if err != nil {
+		WriteError(w, Error{"could not read address from POST call to /wallet/nft/reclaim"}, http.StatusBadRequest)
+		return
+	}
+	txns, err := wallet.ReclaimNFTLockup(nft, dest)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/nft/reclaim: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	var txids []types.TransactionID
+	for _, txn := range txns {
+		txids = append(txids, txn.ID())
+	}
+	WriteJSON(w, WalletSiacoinsPOST{
+		Transactions:   txns,
+		TransactionIDs: txids,
+	})
+}
+
+// walletStakeNFTHandler handles API calls to /wallet/nft/stake
+// arguments are merkleRoot for merkle root of the NFT's data or nftid for its
+// NftID and amount for the stake in hastings
+func walletStakeNFTHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	nft, err := scanNFT(req)
+	if err != nil {
+		WriteError(w, Error{"could not load merkle root or id of NFT"}, http.StatusBadRequest)
+		return
+	}
+	amount, ok := scanAmount(req.FormValue("amount"))
+	if !ok {
+		WriteError(w, Error{"could not read amount from POST call to /wallet/nft/stake"}, http.StatusBadRequest)
+		return
+	}
+	txns, err := wallet.StakeNFT(nft, amount)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/nft/stake: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	var txids []types.TransactionID
+	for _, txn := range txns {
+		txids = append(txids, txn.ID())
+	}
+	WriteJSON(w, WalletSiacoinsPOST{
+		Transactions:   txns,
+		TransactionIDs: txids,
+	})
+}
+
+// walletUnstakeNFTHandler handles API calls to /wallet/nft/unstake
+// arguments are merkleRoot for merkle root of the NFT's data or nftid for its
+// NftID and address to send the NFT's share of the stake to
+func walletUnstakeNFTHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	nft, err := scanNFT(req)
+	if err != nil {
+		WriteError(w, Error{"could not load merkle root or id of NFT"}, http.StatusBadRequest)
+		return
+	}
+	dest, err := scanAddress(req.FormValue("destination"))
+	if err != nil {
+		WriteError(w, Error{"could not read address from POST call to /wallet/nft/unstake"}, http.StatusBadRequest)
+		return
+	}
+	txns, err := wallet.UnstakeNFT(nft, dest)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/nft/unstake: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	var txids []types.TransactionID
+	for _, txn := range txns {
+		txids = append(txids, txn.ID())
+	}
+	WriteJSON(w, WalletSiacoinsPOST{
+		Transactions:   txns,
+		TransactionIDs: txids,
+	})
+}
+
+// walletApproveNFTHandler handles API calls to /wallet/nft/approve
+// arguments are merkleRoot for merkle root of the NFT's data or nftid for its
+// NftID and operator for the address which is approved to transfer the NFT
+func walletApproveNFTHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	nft, err := scanNFT(req)
+	if err != nil {
+		WriteError(w, Error{"could not load merkle root or id of NFT"}, http.StatusBadRequest)
+		return
+	}
+	operator, err := scanAddress(req.FormValue("operator"))
+	if err != nil {
+		WriteError(w, Error{"could not read operator from POST call to /wallet/nft/approve"}, http.StatusBadRequest)
+		return
+	}
+	txns, err := wallet.ApproveNFTOperator(nft, operator)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/nft/approve: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	var txids []types.TransactionID
+	for _, txn := range txns {
+		txids = append(txids, txn.ID())
+	}
+	WriteJSON(w, WalletSiacoinsPOST{
+		Transactions:   txns,
+		TransactionIDs: txids,
+	})
+}
+
+// walletRevokeNFTApprovalHandler handles API calls to
+// /wallet/nft/approve/revoke
+// arguments are merkleRoot for merkle root of the NFT's data or nftid for its
+// NftID
+func walletRevokeNFTApprovalHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	nft, err := scanNFT(req)
+	if err != nil {
+		WriteError(w, Error{"could not load merkle root or id of NFT"}, http.StatusBadRequest)
+		return
+	}
+	txns, err := wallet.RevokeNFTApproval(nft)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/nft/approve/revoke: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	var txids []types.TransactionID
+	for _, txn := range txns {
+		txids = append(txids, txn.ID())
+	}
+	WriteJSON(w, WalletSiacoinsPOST{
+		Transactions:   txns,
+		TransactionIDs: txids,
+	})
+}
+
+// walletTransferApprovedNFTHandler handles API calls to
+// /wallet/nft/approve/transfer
+// arguments are merkleRoot for merkle root of the NFT's data or nftid for its
+// NftID and address to transfer the NFT to on behalf of its owner
+func walletTransferApprovedNFTHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	nft, err := scanNFT(req)
+	if err != nil {
+		WriteError(w, Error{"could not load merkle root or id of NFT"}, http.StatusBadRequest)
+		return
+	}
+	dest, err := scanAddress(req.FormValue("destination"))
+	if err != nil {
+		WriteError(w, Error{"could not read address from POST call to /wallet/nft/approve/transfer"}, http.StatusBadRequest)
+		return
+	}
+	txns, err := wallet.TransferApprovedNFT(nft, dest)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/nft/approve/transfer: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	var txids []types.TransactionID
+	for _, txn := range txns {
+		txids = append(txids, txn.ID())
+	}
+	WriteJSON(w, WalletSiacoinsPOST{
+		Transactions:   txns,
+		TransactionIDs: txids,
+	})
+}
+
+// walletBridgeLockNFTHandler handles API calls to /wallet/nft/bridge/lock
+// arguments are merkleRoot for merkle root of the NFT's data or nftid for its
+// NftID, bridge for the JSON encoded unlock conditions of the bridge, and chain and recipient for
+// the claim on the foreign chain
+func walletBridgeLockNFTHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	nft, err := scanNFT(req)
+	if err != nil {
+		WriteError(w, Error{"could not load merkle root or id of NFT"}, http.StatusBadRequest)
+		return
+	}
+	var bridge types.UnlockConditions
+	err = json.Unmarshal([]byte(req.FormValue("bridge")), &bridge)
+	if err != nil {
+		WriteError(w, Error{"could not decode bridge unlock conditions: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	claim := types.NFTBridgeClaim{
+		Chain:     req.FormValue("chain"),
+		Recipient: req.FormValue("recipient"),
+	}
+	txns, err := wallet.BridgeLockNFT(nft, bridge, claim)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/nft/bridge/lock: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	var txids []types.TransactionID
+	for _, txn := range txns {
+		txids = append(txids, txn.ID())
+	}
+	WriteJSON(w, WalletSiacoinsPOST{
+		Transactions:   txns,
+		TransactionIDs: txids,
+	})
+}
+
+// walletNFTProvenanceHandler handles API calls to /wallet/nft/provenance
+// only argument is merkleRoot for merkle root of the NFT's data or nftid for
+// its NftID
+func walletNFTProvenanceHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	nft, err := scanNFT(req)
+	if err != nil {
+		WriteError(w, Error{"could not load merkle root or id of NFT"}, http.StatusBadRequest)
+		return
+	}
+	provenance, err := wallet.ExportProvenance(nft)
+	if errors.Contains(err, modules.ErrNFTNotMinted) {
+		WriteError(w, Error{"error when calling /wallet/nft/provenance: " + err.Error()}, http.StatusNotFound)
+		return
+	} else if errors.Contains(err, modules.ErrNotNFTCreator) {
+		WriteError(w, Error{"error when calling /wallet/nft/provenance: " + err.Error()}, http.StatusBadRequest)
+		return
+	} else if err != nil {
+		WriteError(w, Error{"error when calling /wallet/nft/provenance: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, provenance)
+}
+
+// walletReservesHandler handles API calls to /wallet/reserves
+// only argument is the challenge the proof of reserves covers
+func walletReservesHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var challenge crypto.Hash
+	if err := challenge.LoadString(req.FormValue("challenge")); err != nil {
+		WriteError(w, Error{"could not load challenge: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	p, err := wallet.ProofOfReserves(challenge)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/reserves: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, p)
+}
+
+// walletNFTAuditHandler handles API calls to /wallet/nft/audit
+func walletNFTAuditHandler(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	issues, err := wallet.AuditNFTs()
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/nft/audit: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, WalletNFTAuditGET{
+		Issues: issues,
+	})
+}
+
+// walletNFTDepositAddressHandler handles API calls to
+// /wallet/nft/deposit/address
+// only argument is userid of the user the deposit address is assigned to
+func walletNFTDepositAddressHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	addr, err := wallet.NFTDepositAddress(req.FormValue("userid"))
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/nft/deposit/address: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletNFTDepositAddressPOST{
+		Address: addr,
+	})
+}
+
+// walletNFTDepositsHandler handles API calls to /wallet/nft/deposits
+func walletNFTDepositsHandler(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	deposits, err := wallet.NFTDeposits()
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/nft/deposits: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, WalletNFTDepositsGET{
+		Deposits: deposits,
+	})
+}
+
+// walletNFTSweepHandler handles API calls to /wallet/nft/sweep
+// only argument is destination, the address all deposited NFTs are swept to
+func walletNFTSweepHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	dest, err := scanAddress(req.FormValue("destination"))
+	if err != nil {
+		WriteError(w, Error{"could not read address from POST call to /wallet/nft/sweep"}, http.StatusBadRequest)
+		return
+	}
+	txns, err := wallet.SweepNFTDeposits(dest)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/nft/sweep: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	var txids []types.TransactionID
+	for _, txn := range txns {
+		txids = append(txids, txn.ID())
+	}
+	WriteJSON(w, WalletSiacoinsPOST{
+		Transactions:   txns,
+		TransactionIDs: txids,
+	})
+}
+
+// walletNFTScheduleHandlerGET handles GET calls to /wallet/nft/schedule.
+func walletNFTScheduleHandlerGET(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	transfers, err := wallet.ScheduledNFTTransfers()
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/nft/schedule: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, WalletNFTScheduleGET{
+		Transfers: transfers,
+	})
+}
+
+// walletNFTScheduleHandlerPOST handles POST calls to /wallet/nft/schedule.
+// arguments are merkleRoot or nftid of the NFT, destination and the height at
+// which the transfer is broadcast
+func walletNFTScheduleHandlerPOST(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	nft, err := scanNFT(req)
+	if err != nil {
+		WriteError(w, Error{"could not load merkle root or id of NFT to transfer"}, http.StatusBadRequest)
+		return
+	}
+	dest, err := scanAddress(req.FormValue("destination"))
+	if err != nil {
+		WriteError(w, Error{"could not read address from POST call to /wallet/nft/schedule"}, http.StatusBadRequest)
+		return
+	}
+	height, err := strconv.ParseUint(req.FormValue("height"), 10, 64)
+	if err != nil {
+		WriteError(w, Error{"could not read height from POST call to /wallet/nft/schedule"}, http.StatusBadRequest)
+		return
+	}
+	err = wallet.ScheduleNFTTransfer(nft, dest, types.BlockHeight(height))
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/nft/schedule: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// walletNFTScheduleCancelHandler handles POST calls to
+// /wallet/nft/schedule/cancel.
+func walletNFTScheduleCancelHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	nft, err := scanNFT(req)
+	if err != nil {
+		WriteError(w, Error{"could not load merkle root or id of NFT"}, http.StatusBadRequest)
+		return
+	}
+	err = wallet.CancelNFTTransfer(nft)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/nft/schedule/cancel: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// walletNFTTemplatesHandlerGET handles GET calls to /wallet/nft/templates.
+func walletNFTTemplatesHandlerGET(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	templates, err := wallet.NFTMintTemplates()
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/nft/templates: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, WalletNFTTemplatesGET{
+		Templates: templates,
+	})
+}
+
+// walletNFTTemplatesHandlerPOST handles POST calls to /wallet/nft/templates.
+func walletNFTTemplatesHandlerPOST(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var params WalletNFTTemplatesPOST
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if params.Remove {
+		err = wallet.RemoveNFTMintTemplate(params.ID)
+	} else {
+		err = wallet.SetNFTMintTemplate(params.NFTMintTemplate)
+	}
+	if err != nil {
+		WriteError(w, Error{"failed to update nft mint templates: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// walletNFTUnlockableHandler handles GET calls to /wallet/nft/unlockable.
+func walletNFTUnlockableHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	nft, err := scanNFT(req)
+	if err != nil {
+		WriteError(w, Error{"could not load merkle root or id of NFT"}, http.StatusBadRequest)
+		return
+	}
+	u, key, err := wallet.UnlockNFT(nft)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/nft/unlockable: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletNFTUnlockableGET{
+		Unlockable: u,
+		Key:        key,
+	})
+}
+
+// walletNFTUnlockableHandshakeHandler handles GET calls to
+// /wallet/nft/unlockable/handshake.
+func walletNFTUnlockableHandshakeHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	addr, err := scanAddress(req.FormValue("address"))
+	if err != nil {
+		WriteError(w, Error{"could not read address from GET call to /wallet/nft/unlockable/handshake"}, http.StatusBadRequest)
+		return
+	}
+	h, err := wallet.NFTUnlockableHandshake(addr)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/nft/unlockable/handshake: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, h)
+}
+
+// walletNFTUnlockableImportHandler handles POST calls to
+// /wallet/nft/unlockable/import.
+func walletNFTUnlockableImportHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var e types.NFTUnlockableEnvelope
+	if err := json.NewDecoder(req.Body).Decode(&e); err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err := wallet.ImportNFTUnlockable(e); err != nil {
+		WriteError(w, Error{"error when calling /wallet/nft/unlockable/import: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// walletNFTUnlockableMintHandler handles POST calls to
+// /wallet/nft/unlockable/mint.
+func walletNFTUnlockableMintHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var params WalletNFTUnlockableMintPOST
+	if err := json.NewDecoder(req.Body).Decode(&params); err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	dest := params.Destination
+	if dest == (types.UnlockHash{}) {
+		uc, err := wallet.NextNFTCustodyAddress()
+		if err != nil {
+			WriteError(w, Error{"error when calling /wallet/nft/unlockable/mint: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		dest = uc.UnlockHash()
+	}
+	txns, err := wallet.MintUnlockableNFT(params.NFT, params.Unlockable, params.Key, dest)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/nft/unlockable/mint: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	writeNFTMintResponse(w, params.NFT, txns)
+}
+
+// walletNFTUnlockableTransferHandler handles POST calls to
+// /wallet/nft/unlockable/transfer.
+func walletNFTUnlockableTransferHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var params WalletNFTUnlockableTransferPOST
+	if err := json.NewDecoder(req.Body).Decode(&params); err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	txns, e, err := wallet.TransferUnlockableNFT(params.NFT, params.Handshake)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/nft/unlockable/transfer: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	var txids []types.TransactionID
+	for _, txn := range txns {
+		txids = append(txids, txn.ID())
+	}
+	WriteJSON(w, WalletNFTUnlockableTransferPOSTResp{
+		Transactions:   txns,
+		TransactionIDs: txids,
+		Envelope:       e,
+	})
+}
+
+// walletSiacoinsHandler handles API calls to /wallet/siacoins.
+func walletSiacoinsHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var txns []types.Transaction
+	if req.FormValue("outputs") != "" {
+		// multiple amounts + destinations
+		if req.FormValue("amount") != "" || req.FormValue("destination") != "" || req.FormValue("feeIncluded") != "" {
+			WriteError(w, Error{"cannot supply both 'outputs' and single amount+destination pair and/or feeIncluded parameter"}, http.StatusInternalServerError)
+			return
+		}
+
+		var outputs []types.SiacoinOutput
+		err := json.Unmarshal([]byte(req.FormValue("outputs")), &outputs)
+		if err != nil {
+			WriteError(w, Error{"could not decode outputs: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		txns, err = wallet.SendSiacoinsMulti(outputs)
+		if err != nil {
+			WriteError(w, Error{"error when calling /wallet/siacoins: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+	} else {
+		// single amount + destination
+		amount, ok := scanAmount(req.FormValue("amount"))
+		if !ok {
+			WriteError(w, Error{"could not read amount from POST call to /wallet/siacoins"}, http.StatusBadRequest)
+			return
+		}
+		dest, err := scanAddress(req.FormValue("destination"))
+		if err != nil {
+			WriteError(w, Error{"could not read address from POST call to /wallet/siacoins"}, http.StatusBadRequest)
+			return
+		}
+		feeIncluded, err := scanBool(req.FormValue("feeIncluded"))
+		if err != nil {
+			WriteError(w, Error{"could not read feeIncluded from POST call to /wallet/siacoins"}, http.StatusBadRequest)
+			return
+		}
+
+		if feeIncluded {
+			txns, err = wallet.SendSiacoinsFeeIncluded(amount, dest)
+		} else {
+			txns, err = wallet.SendSiacoins(amount, dest)
+		}
+		if err != nil {
+			WriteError(w, Error{"error when calling /wallet/siacoins: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	var txids []types.TransactionID
+	for _, txn := range txns {
+		txids = append(txids, txn.ID())
+	}
+	WriteJSON(w, WalletSiacoinsPOST{
+		Transactions:   txns,
+		TransactionIDs: txids,
+	})
+}
+
+// walletSiafundsHandler handles API calls to /wallet/siafunds.
+func walletSiafundsHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	amount, ok := scanAmount(req.FormValue("amount"))
+	if !ok {
+		WriteError(w, Error{"could not read 'amount' from POST call to /wallet/siafunds"}, http.StatusBadRequest)
+		return
+	}
+	dest, err := scanAddress(req.FormValue("destination"))
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/siafunds: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	txns, err := wallet.SendSiafunds(amount, dest)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/siafunds: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	var txids []types.TransactionID
+	for _, txn := range txns {
+		txids = append(txids, txn.ID())
+	}
+	WriteJSON(w, WalletSiafundsPOST{
+		Transactions:   txns,
+		TransactionIDs: txids,
+	})
+}
+
+// walletSweepSeedHandler handles API calls to /wallet/sweep/seed.
+func walletSweepSeedHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Get the seed using the dictionary + phrase
+	dictID := mnemonics.DictionaryID(req.FormValue("dictionary"))
+	if dictID == "" {
+		dictID = "english"
+	}
+	seed, err := modules.StringToSeed(req.FormValue("seed"), dictID)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/sweep/seed: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	coins, funds, err := wallet.SweepSeed(seed)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/sweep/seed: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletSweepPOST{
+		Coins: coins,
+		Funds: funds,
+	})
+}
+
+// walletTransactionGroupHandlerPOST handles POST calls to
+// /wallet/transactiongroup.
+func walletTransactionGroupHandlerPOST(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var params WalletTransactionGroupPOSTParams
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	group, err := wallet.BroadcastTransactionGroup(params.Transactions, params.TransactionGroupParams)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/transactiongroup: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletTransactionGroupGET{
+		Group: group,
+	})
+}
+
+// walletTransactionGroupHandlerGET handles GET calls to
+// /wallet/transactiongroup/:id.
+func walletTransactionGroupHandlerGET(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	var id modules.TransactionGroupID
+	if err := id.LoadString(ps.ByName("id")); err != nil {
+		WriteError(w, Error{"error when calling /wallet/transactiongroup/id: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	group, err := wallet.TransactionGroup(id)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/transactiongroup/id: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletTransactionGroupGET{
+		Group: group,
+	})
+}
+
+// walletTransactionGroupsHandler handles API calls to
+// /wallet/transactiongroups.
+func walletTransactionGroupsHandler(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	groups, err := wallet.TransactionGroups()
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/transactiongroups: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, WalletTransactionGroupsGET{
+		Groups: groups,
+	})
+}
+
+// walletBroadcastFailuresHandler handles API calls to
+// /wallet/broadcastfailures.
+func walletBroadcastFailuresHandler(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, WalletBroadcastFailuresGET{
+		Failures: wallet.LastBroadcastFailures(),
+	})
+}
+
+// walletTransactionHandler handles API calls to /wallet/transaction/:id.
+func walletTransactionHandler(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	// Parse the id from the url.
+	var id types.TransactionID
+	jsonID := "\"" + ps.ByName("id") + "\""
+	err := id.UnmarshalJSON([]byte(jsonID))
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/transaction/id: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	txn, ok, err := wallet.Transaction(id)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/transaction/id: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if !ok {
+		WriteError(w, Error{"error when calling /wallet/transaction/id  :  transaction not found"}, http.StatusBadRequest)
+		return
 	}
 	WriteJSON(w, WalletTransactionGETid{
 		Transaction: txn,
@@ -1081,3 +2069,45 @@ func walletWatchHandlerPOST(wallet modules.Wallet, w http.ResponseWriter, req *h
 	}
 	WriteSuccess(w)
 }
+
+// walletsHandlerGET handles GET calls to /wallets.
+func walletsHandlerGET(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	wallets, err := wallet.NamedWallets()
+	if err != nil {
+		WriteError(w, Error{"failed to get named wallets: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletsGET{
+		Wallets: wallets,
+	})
+}
+
+// walletsHandlerPOST handles POST calls to /wallets.
+func walletsHandlerPOST(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	_, err := wallet.CreateNamedWallet(req.FormValue("name"))
+	if err != nil {
+		WriteError(w, Error{"failed to create named wallet: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// walletsOpenHandler handles POST calls to /wallets/:walletname/open.
+func walletsOpenHandler(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	_, err := wallet.OpenNamedWallet(ps.ByName("walletname"))
+	if err != nil {
+		WriteError(w, Error{"failed to open named wallet: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// walletsCloseHandler handles POST calls to /wallets/:walletname/close.
+func walletsCloseHandler(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	err := wallet.CloseNamedWallet(ps.ByName("walletname"))
+	if err != nil {
+		WriteError(w, Error{"failed to close named wallet: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
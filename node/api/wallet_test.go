@@ -1810,3 +1810,84 @@ func TestWalletTransactionsGetAddr(t *testing.T) {
 		t.Errorf("There should be exactly 0 unconfirmed and 1 confirmed related txns")
 	}
 }
+
+// TestNamedWallets tests creating named wallets and addressing them through
+// the API.
+func TestNamedWallets(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	// Create a named wallet and initialize it.
+	err = st.stdPostAPI("/wallets", url.Values{"name": {"alice"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wip WalletInitPOST
+	err = st.postAPI("/wallets/alice/init", url.Values{}, &wip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = st.stdPostAPI("/wallets/alice/unlock", url.Values{"encryptionpassword": {wip.PrimarySeed}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Send money to the named wallet.
+	var wag WalletAddressGET
+	err = st.getAPI("/wallets/alice/address", &wag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	amount := types.SiacoinPrecision.Mul64(10)
+	_, err = st.wallet.SendSiacoins(amount, wag.Address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = st.miner.AddBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg WalletGET
+	err = st.getAPI("/wallets/alice", &wg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !wg.ConfirmedSiacoinBalance.Equals(amount) {
+		t.Fatalf("expected balance %v but got %v", amount, wg.ConfirmedSiacoinBalance)
+	}
+
+	// Close the wallet. It can't be addressed anymore until it's reopened.
+	err = st.stdPostAPI("/wallets/alice/close", url.Values{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wsg WalletsGET
+	err = st.getAPI("/wallets", &wsg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wsg.Wallets) != 1 || wsg.Wallets[0].Name != "alice" || wsg.Wallets[0].Open {
+		t.Fatal("unexpected wallets", wsg.Wallets)
+	}
+	if err := st.getAPI("/wallets/alice", &wg); err == nil {
+		t.Fatal("closed wallet shouldn't be addressable")
+	}
+	err = st.stdPostAPI("/wallets/alice/open", url.Values{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = st.getAPI("/wallets/alice", &wg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wg.Unlocked {
+		t.Fatal("reopened wallet should be locked")
+	}
+}
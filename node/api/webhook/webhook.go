@@ -0,0 +1,391 @@
+// Package webhook implements a dispatcher which posts JSON events about the
+// node to configured URLs, so that web backends can react to NFT mints and
+// transfers involving the wallet, confirmed NFT deposits, problems of the
+// renter's contracts and unhealthy NFT data without polling the API. Every webhook can filter the
+// events it receives, signs its requests with a secret and retries failed
+// deliveries with an exponential backoff.
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"gitlab.com/NebulousLabs/threadgroup"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+// EventType identifies the kind of an event.
+type EventType string
+
+const (
+	// EventNFTMint is sent for a confirmed NFT mint involving the wallet.
+	EventNFTMint EventType = "nft.mint"
+	// EventNFTTransfer is sent for a confirmed NFT transfer involving the
+	// wallet.
+	EventNFTTransfer EventType = "nft.transfer"
+	// EventNFTDeposit is sent once a deposit to an NFT deposit address of
+	// the wallet has reached the webhook's DepositConfirmations. Deposits
+	// are delivered at least once, e.g. again after a restart of the node.
+	EventNFTDeposit EventType = "nft.deposit"
+	// EventNFTHealth is sent when the health score of a pinned NFT drops
+	// below nftHealthAlertThreshold.
+	EventNFTHealth EventType = "nft.health"
+	// EventContractRenewalFailures is sent when a contract failed to renew a
+	// number of times in a row.
+	EventContractRenewalFailures EventType = "contract.renewalfailures"
+	// EventContractLocked is sent when a contract is locked after too many
+	// failed renewals.
+	EventContractLocked EventType = "contract.locked"
+	// EventAllowanceLow is sent when the allowance runs low on funds.
+	EventAllowanceLow EventType = "allowance.low"
+)
+
+// SignatureHeader is the header which contains the hex encoded HMAC-SHA256 of
+// the request body keyed with the webhook's secret.
+const SignatureHeader = "Sia-Webhook-Signature"
+
+const (
+	// defaultMaxRetries is the number of times a failed delivery is retried
+	// if the webhook doesn't specify it.
+	defaultMaxRetries = 5
+
+	// defaultDepositConfirmations is the number of confirmations after which
+	// an EventNFTDeposit is sent if the webhook doesn't specify it.
+	defaultDepositConfirmations = 6
+
+	// requestTimeout is the timeout of a single delivery.
+	requestTimeout = 30 * time.Second
+)
+
+var (
+	// retryBaseInterval is the time to wait before the first retry of a
+	// failed delivery. The interval doubles with every retry.
+	retryBaseInterval = build.Select(build.Var{
+		Dev:      time.Second,
+		Standard: 5 * time.Second,
+		Testing:  10 * time.Millisecond,
+	}).(time.Duration)
+
+	// nftHealthCheckInterval is the interval at which the health of the
+	// renter's pinned NFTs is checked.
+	nftHealthCheckInterval = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: 10 * time.Minute,
+		Testing:  100 * time.Millisecond,
+	}).(time.Duration)
+
+	// nftHealthAlertThreshold is the health score of a pinned NFT below which
+	// an EventNFTHealth is sent.
+	nftHealthAlertThreshold = 0.5
+)
+
+var (
+	// errNoURL is returned if a webhook has no URL.
+	errNoURL = errors.New("webhook needs a url")
+
+	// errUnknownEvent is returned if a webhook filters for an unknown event.
+	errUnknownEvent = errors.New("unknown webhook event")
+)
+
+// Config configures a single webhook. Events are the events the webhook
+// receives, all events if empty. If Secret isn't empty, requests are signed
+// with it. MaxRetries is the number of times a failed delivery is retried,
+// defaultMaxRetries if 0 and none if negative. DepositConfirmations is the
+// number of confirmations after which an EventNFTDeposit is sent,
+// defaultDepositConfirmations if 0.
+type Config struct {
+	URL                  string            `json:"url"`
+	Secret               string            `json:"secret"`
+	Events               []EventType       `json:"events"`
+	MaxRetries           int               `json:"maxretries"`
+	DepositConfirmations types.BlockHeight `json:"depositconfirmations"`
+}
+
+// Event is the JSON body posted to a webhook.
+type Event struct {
+	ID        string      `json:"id"`
+	Type      EventType   `json:"type"`
+	Timestamp int64       `json:"timestamp"`
+	Data      interface{} `json:"data"`
+}
+
+// Dispatcher posts events to the configured webhooks.
+type Dispatcher struct {
+	staticClient *http.Client
+	staticHooks  []Config
+	staticLog    *persist.Logger
+
+	// unhealthyNFTs contains the roots of the pinned NFTs which are below
+	// the health threshold, so that every drop is only reported once.
+	unhealthyNFTs map[crypto.Hash]struct{}
+
+	mu sync.Mutex
+	tg threadgroup.ThreadGroup
+}
+
+// validate checks that a webhook config is valid.
+func (c Config) validate() error {
+	if c.URL == "" {
+		return errNoURL
+	}
+	for _, e := range c.Events {
+		switch e {
+		case EventNFTMint, EventNFTTransfer, EventNFTDeposit, EventNFTHealth, EventContractRenewalFailures, EventContractLocked, EventAllowanceLow:
+		default:
+			return errors.AddContext(errUnknownEvent, string(e))
+		}
+	}
+	return nil
+}
+
+// wants returns whether the webhook receives events of the given type.
+func (c Config) wants(t EventType) bool {
+	if len(c.Events) == 0 {
+		return true
+	}
+	for _, e := range c.Events {
+		if e == t {
+			return true
+		}
+	}
+	return false
+}
+
+// depositConfirmations returns the number of confirmations after which the
+// webhook receives an EventNFTDeposit.
+func (c Config) depositConfirmations() types.BlockHeight {
+	if c.DepositConfirmations == 0 {
+		return defaultDepositConfirmations
+	}
+	return c.DepositConfirmations
+}
+
+// New creates a dispatcher for the provided webhooks.
+func New(hooks []Config, log *persist.Logger) (*Dispatcher, error) {
+	for i, hook := range hooks {
+		if err := hook.validate(); err != nil {
+			return nil, errors.AddContext(err, fmt.Sprintf("invalid webhook %v", i))
+		}
+	}
+	return &Dispatcher{
+		staticClient:  &http.Client{Timeout: requestTimeout},
+		staticHooks:   hooks,
+		staticLog:     log,
+		unhealthyNFTs: make(map[crypto.Hash]struct{}),
+	}, nil
+}
+
+// Close stops the dispatcher, waits for pending deliveries to give up and
+// closes the dispatcher's logger.
+func (d *Dispatcher) Close() error {
+	return errors.Compose(d.tg.Stop(), d.staticLog.Close())
+}
+
+// Dispatch sends an event to every webhook which receives events of its type.
+// The deliveries happen in the background.
+func (d *Dispatcher) Dispatch(t EventType, data interface{}) {
+	d.dispatch(t, data, func(Config) bool { return true })
+}
+
+// dispatch sends an event to every webhook which receives events of its type
+// and for which filter returns true.
+func (d *Dispatcher) dispatch(t EventType, data interface{}, filter func(Config) bool) {
+	event := Event{
+		ID:        hex.EncodeToString(fastrand.Bytes(16)),
+		Type:      t,
+		Timestamp: time.Now().Unix(),
+		Data:      data,
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		d.staticLog.Printf("WARN: failed to marshal %v event: %v", t, err)
+		return
+	}
+	for _, hook := range d.staticHooks {
+		if hook.wants(t) && filter(hook) {
+			go d.threadedDeliver(hook, event, body)
+		}
+	}
+}
+
+// threadedDeliver posts an event to a webhook, retrying with an exponential
+// backoff until the delivery succeeds, the retries are exhausted or the
+// dispatcher is closed.
+func (d *Dispatcher) threadedDeliver(hook Config, event Event, body []byte) {
+	if err := d.tg.Add(); err != nil {
+		return
+	}
+	defer d.tg.Done()
+
+	maxRetries := hook.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = defaultMaxRetries
+	}
+	interval := retryBaseInterval
+	for attempt := 0; ; attempt++ {
+		err := d.managedPost(hook, body)
+		if err == nil {
+			return
+		}
+		if attempt >= maxRetries {
+			d.staticLog.Printf("WARN: giving up on delivering %v event %v to %v: %v", event.Type, event.ID, hook.URL, err)
+			return
+		}
+		select {
+		case <-d.tg.StopChan():
+			return
+		case <-time.After(interval):
+		}
+		interval *= 2
+	}
+}
+
+// managedPost posts the body of an event to a webhook once.
+func (d *Dispatcher) managedPost(hook Config, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if hook.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(hook.Secret, body))
+	}
+	resp, err := d.staticClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned status %v", resp.Status)
+	}
+	return nil
+}
+
+// Sign returns the signature of a request body, the hex encoded HMAC-SHA256 of
+// the body keyed with the secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SubscribeWallet dispatches events for the NFT mints and transfers involving
+// the wallet and for the confirmed NFT deposits. A deposit callback is
+// registered for every number of confirmations the webhooks wait for.
+func (d *Dispatcher) SubscribeWallet(w modules.Wallet) error {
+	err := w.RegisterNFTActivityCallback(func(a modules.NFTActivity) {
+		if a.Minted {
+			d.Dispatch(EventNFTMint, a)
+		} else {
+			d.Dispatch(EventNFTTransfer, a)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	registered := make(map[types.BlockHeight]struct{})
+	for _, hook := range d.staticHooks {
+		confirmations := hook.depositConfirmations()
+		if _, exists := registered[confirmations]; exists || !hook.wants(EventNFTDeposit) {
+			continue
+		}
+		registered[confirmations] = struct{}{}
+		err := w.RegisterNFTDepositCallback(confirmations, func(deposit modules.NFTDeposit) {
+			d.dispatch(EventNFTDeposit, deposit, func(c Config) bool {
+				return c.depositConfirmations() == confirmations
+			})
+		})
+		if err != nil {
+			return errors.AddContext(err, "unable to register nft deposit callback")
+		}
+	}
+	return nil
+}
+
+// SubscribeRenter dispatches events for the contractor's notifications and
+// starts checking the health of the renter's pinned NFTs.
+func (d *Dispatcher) SubscribeRenter(r modules.Renter) {
+	r.RegisterContractorNotifier(modules.ContractorNotifierFunc(func(n modules.ContractorNotification) error {
+		switch n.Type {
+		case modules.ContractorNotificationRenewalFailures:
+			d.Dispatch(EventContractRenewalFailures, n)
+		case modules.ContractorNotificationContractLocked:
+			d.Dispatch(EventContractLocked, n)
+		case modules.ContractorNotificationLowAllowance:
+			d.Dispatch(EventAllowanceLow, n)
+		}
+		return nil
+	}))
+	go d.threadedCheckNFTHealth(r)
+}
+
+// threadedCheckNFTHealth periodically checks the health of the renter's
+// pinned NFTs until the dispatcher is closed.
+func (d *Dispatcher) threadedCheckNFTHealth(r modules.Renter) {
+	if err := d.tg.Add(); err != nil {
+		return
+	}
+	defer d.tg.Done()
+	for {
+		select {
+		case <-d.tg.StopChan():
+			return
+		case <-time.After(nftHealthCheckInterval):
+		}
+		d.managedCheckNFTHealth(r)
+	}
+}
+
+// managedCheckNFTHealth dispatches an EventNFTHealth for every pinned NFT
+// whose health dropped below the threshold since the last check.
+func (d *Dispatcher) managedCheckNFTHealth(r modules.Renter) {
+	pins, err := r.NFTPins()
+	if err != nil {
+		d.staticLog.Println("WARN: failed to get the pinned nfts:", err)
+		return
+	}
+	pinned := make(map[crypto.Hash]struct{}, len(pins))
+	for _, pin := range pins {
+		pinned[pin.Root] = struct{}{}
+		health, err := r.NFTHealth(pin.Root)
+		if err != nil {
+			d.staticLog.Printf("WARN: failed to get the health of nft %v: %v", pin.Root, err)
+			continue
+		}
+		unhealthy := health.Score < nftHealthAlertThreshold
+		d.mu.Lock()
+		_, reported := d.unhealthyNFTs[pin.Root]
+		if unhealthy {
+			d.unhealthyNFTs[pin.Root] = struct{}{}
+		} else {
+			delete(d.unhealthyNFTs, pin.Root)
+		}
+		d.mu.Unlock()
+		if unhealthy && !reported {
+			d.Dispatch(EventNFTHealth, health)
+		}
+	}
+
+	// Forget about NFTs which were unpinned.
+	d.mu.Lock()
+	for root := range d.unhealthyNFTs {
+		if _, exists := pinned[root]; !exists {
+			delete(d.unhealthyNFTs, root)
+		}
+	}
+	d.mu.Unlock()
+}
@@ -0,0 +1,300 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+// testServer is a webhook which records the events it receives. The first
+// failures requests are answered with an error.
+type testServer struct {
+	*httptest.Server
+	events     chan Event
+	signatures chan string
+
+	failures int
+	mu       sync.Mutex
+}
+
+// newTestServer creates a new webhook server.
+func newTestServer(t *testing.T, failures int) *testServer {
+	ts := &testServer{
+		events:     make(chan Event, 10),
+		signatures: make(chan string, 10),
+		failures:   failures,
+	}
+	ts.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ts.mu.Lock()
+		fail := ts.failures > 0
+		ts.failures--
+		ts.mu.Unlock()
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		var e Event
+		if err := json.Unmarshal(body, &e); err != nil {
+			t.Error(err)
+			return
+		}
+		ts.signatures <- req.Header.Get(SignatureHeader)
+		if sig := req.Header.Get(SignatureHeader); sig != "" && sig != Sign("secret", body) {
+			t.Error("wrong signature")
+		}
+		ts.events <- e
+	}))
+	return ts
+}
+
+// next returns the next event received by the server.
+func (ts *testServer) next(t *testing.T) Event {
+	select {
+	case e := <-ts.events:
+		return e
+	case <-time.After(10 * time.Second):
+		t.Fatal("event wasn't delivered")
+	}
+	return Event{}
+}
+
+// none checks that the server doesn't receive an event.
+func (ts *testServer) none(t *testing.T) {
+	select {
+	case e := <-ts.events:
+		t.Fatal("unexpected event", e)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// newTestDispatcher creates a dispatcher which logs to nowhere.
+func newTestDispatcher(t *testing.T, hooks []Config) *Dispatcher {
+	log, err := persist.NewLogger(ioutil.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := New(hooks, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+// TestNewDispatcher tests validating the webhook configs.
+func TestNewDispatcher(t *testing.T) {
+	log, err := persist.NewLogger(ioutil.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New([]Config{{}}, log); !errors.Contains(err, errNoURL) {
+		t.Fatal("expected errNoURL but got", err)
+	}
+	if _, err := New([]Config{{URL: "http://localhost", Events: []EventType{"foo"}}}, log); !errors.Contains(err, errUnknownEvent) {
+		t.Fatal("expected errUnknownEvent but got", err)
+	}
+	if _, err := New([]Config{{URL: "http://localhost", Events: []EventType{EventNFTMint}}}, log); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestDispatch tests that events are delivered to the webhooks which receive
+// them, that requests are signed and that failed deliveries are retried.
+func TestDispatch(t *testing.T) {
+	all := newTestServer(t, 0)
+	defer all.Close()
+	mints := newTestServer(t, 2)
+	defer mints.Close()
+	d := newTestDispatcher(t, []Config{
+		{URL: all.URL},
+		{URL: mints.URL, Secret: "secret", Events: []EventType{EventNFTMint}},
+	})
+	defer func() {
+		if err := d.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// A mint is delivered to both webhooks, the second one after retrying.
+	d.Dispatch(EventNFTMint, modules.NFTActivity{Minted: true})
+	e := all.next(t)
+	if e.Type != EventNFTMint || e.ID == "" {
+		t.Fatal("unexpected event", e)
+	}
+	if sig := <-all.signatures; sig != "" {
+		t.Fatal("unsigned webhook received signature", sig)
+	}
+	e2 := mints.next(t)
+	if e2.ID != e.ID {
+		t.Fatal("webhooks received different events")
+	}
+	if sig := <-mints.signatures; sig == "" {
+		t.Fatal("request wasn't signed")
+	}
+
+	// A transfer is only delivered to the first webhook.
+	d.Dispatch(EventNFTTransfer, modules.NFTActivity{})
+	if e := all.next(t); e.Type != EventNFTTransfer {
+		t.Fatal("unexpected event", e)
+	}
+	mints.none(t)
+}
+
+// TestDispatchGiveUp tests that a delivery is given up after the retries are
+// exhausted.
+func TestDispatchGiveUp(t *testing.T) {
+	ts := newTestServer(t, 2)
+	defer ts.Close()
+	d := newTestDispatcher(t, []Config{{URL: ts.URL, MaxRetries: 1}})
+	defer func() {
+		if err := d.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	d.Dispatch(EventAllowanceLow, nil)
+	ts.none(t)
+
+	// The next event is delivered since the server recovered.
+	d.Dispatch(EventAllowanceLow, nil)
+	if e := ts.next(t); e.Type != EventAllowanceLow {
+		t.Fatal("unexpected event", e)
+	}
+}
+
+// healthRenter is a renter which only reports the health of its pinned NFTs.
+type healthRenter struct {
+	modules.Renter
+	scores map[crypto.Hash]float64
+	mu     sync.Mutex
+}
+
+// NFTPins implements modules.Renter.
+func (r *healthRenter) NFTPins() ([]modules.NFTPinInfo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var pins []modules.NFTPinInfo
+	for root := range r.scores {
+		pins = append(pins, modules.NFTPinInfo{NFTPin: modules.NFTPin{Root: root}})
+	}
+	return pins, nil
+}
+
+// NFTHealth implements modules.Renter.
+func (r *healthRenter) NFTHealth(root crypto.Hash) (modules.NFTHealth, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return modules.NFTHealth{Root: root, Score: r.scores[root]}, nil
+}
+
+// TestCheckNFTHealth tests that a drop of an NFT's health is reported once.
+func TestCheckNFTHealth(t *testing.T) {
+	ts := newTestServer(t, 0)
+	defer ts.Close()
+	d := newTestDispatcher(t, []Config{{URL: ts.URL}})
+	defer func() {
+		if err := d.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	root := crypto.Hash{1}
+	r := &healthRenter{scores: map[crypto.Hash]float64{root: 1}}
+	d.managedCheckNFTHealth(r)
+	ts.none(t)
+
+	// The drop is reported once.
+	r.scores[root] = 0.1
+	d.managedCheckNFTHealth(r)
+	if e := ts.next(t); e.Type != EventNFTHealth {
+		t.Fatal("unexpected event", e)
+	}
+	d.managedCheckNFTHealth(r)
+	ts.none(t)
+
+	// After recovering, the next drop is reported again.
+	r.scores[root] = 1
+	d.managedCheckNFTHealth(r)
+	r.scores[root] = 0
+	d.managedCheckNFTHealth(r)
+	if e := ts.next(t); e.Type != EventNFTHealth {
+		t.Fatal("unexpected event", e)
+	}
+}
+
+// depositWallet is a wallet which records the registered NFT callbacks.
+type depositWallet struct {
+	modules.Wallet
+	deposits map[types.BlockHeight]func(modules.NFTDeposit)
+}
+
+// RegisterNFTActivityCallback implements modules.Wallet.
+func (w *depositWallet) RegisterNFTActivityCallback(func(modules.NFTActivity)) error {
+	return nil
+}
+
+// RegisterNFTDepositCallback implements modules.Wallet.
+func (w *depositWallet) RegisterNFTDepositCallback(confirmations types.BlockHeight, fn func(modules.NFTDeposit)) error {
+	if _, exists := w.deposits[confirmations]; exists {
+		return errors.New("callback registered twice")
+	}
+	w.deposits[confirmations] = fn
+	return nil
+}
+
+// TestSubscribeWalletDeposits tests that confirmed deposits are delivered to
+// the webhooks which wait for their number of confirmations.
+func TestSubscribeWalletDeposits(t *testing.T) {
+	def := newTestServer(t, 0)
+	defer def.Close()
+	slow := newTestServer(t, 0)
+	defer slow.Close()
+	mints := newTestServer(t, 0)
+	defer mints.Close()
+	d := newTestDispatcher(t, []Config{
+		{URL: def.URL},
+		{URL: slow.URL, Events: []EventType{EventNFTDeposit}, DepositConfirmations: 20},
+		{URL: mints.URL, Events: []EventType{EventNFTMint}},
+	})
+	defer func() {
+		if err := d.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// A callback is registered for every number of confirmations.
+	w := &depositWallet{deposits: make(map[types.BlockHeight]func(modules.NFTDeposit))}
+	if err := d.SubscribeWallet(w); err != nil {
+		t.Fatal(err)
+	}
+	if len(w.deposits) != 2 || w.deposits[defaultDepositConfirmations] == nil || w.deposits[20] == nil {
+		t.Fatal("unexpected deposit callbacks", w.deposits)
+	}
+
+	// Every callback only delivers to its webhooks.
+	w.deposits[defaultDepositConfirmations](modules.NFTDeposit{UserID: "alice"})
+	if e := def.next(t); e.Type != EventNFTDeposit {
+		t.Fatal("unexpected event", e)
+	}
+	slow.none(t)
+	w.deposits[20](modules.NFTDeposit{UserID: "alice"})
+	if e := slow.next(t); e.Type != EventNFTDeposit {
+		t.Fatal("unexpected event", e)
+	}
+	def.none(t)
+	mints.none(t)
+}
@@ -108,6 +108,22 @@ type NodeParams struct {
 	HostStorage uint64
 	RPCAddress  string
 
+	// NFTSnapshotBootstrap makes the consensus set bootstrap its NFT index
+	// from a snapshot instead of indexing the whole NFT history.
+	NFTSnapshotBootstrap bool
+
+	// LightWallet makes the node create a light consensus set, which only
+	// follows the addresses of the wallet instead of the full blockchain.
+	// It can't be combined with modules which need the full blockchain.
+	LightWallet bool
+
+	// ReadOnly makes the node an indexing node which runs the gateway, the
+	// consensus set with its NFT index, the transaction pool and optionally
+	// the explorer, but no module holding keys or data of its owner. Such a
+	// node doesn't create a siamux either, since only the host and the
+	// renter use it.
+	ReadOnly bool
+
 	// Initialize node from existing seed.
 	PrimarySeed string
 
@@ -176,6 +192,20 @@ func (np NodeParams) NumModules() (n int) {
 	return
 }
 
+// checkReadOnly returns an error if the NodeParams of a read-only node ask for
+// a module which a read-only node can't run.
+func (np NodeParams) checkReadOnly() error {
+	if np.CreateAccounting || np.Accounting != nil || np.CreateHost || np.Host != nil ||
+		np.CreateMiner || np.Miner != nil || np.CreateRenter || np.Renter != nil ||
+		np.CreateWallet || np.Wallet != nil {
+		return errors.New("read-only nodes can't run an accounting module, host, miner, renter or wallet")
+	}
+	if np.LightWallet {
+		return errors.New("read-only nodes can't follow a light wallet")
+	}
+	return nil
+}
+
 // printlnRelease is a wrapper that only prints to stdout in release builds.
 func printlnRelease(a ...interface{}) {
 	if build.Release == "standard" {
@@ -250,11 +280,22 @@ func New(params NodeParams, loadStartTime time.Time) (*Node, <-chan error) {
 		return nil, errChan
 	}
 
+	// Read-only nodes can't run modules which act on behalf of their owner.
+	if params.ReadOnly {
+		if err := params.checkReadOnly(); err != nil {
+			errChan <- err
+			return nil, errChan
+		}
+	}
+
 	// Create the siamux.
-	mux, err := modules.NewSiaMux(filepath.Join(dir, modules.SiaMuxDir), dir, params.SiaMuxTCPAddress, params.SiaMuxWSAddress)
-	if err != nil {
-		errChan <- errors.Extend(err, errors.New("unable to create siamux"))
-		return nil, errChan
+	var mux *siamux.SiaMux
+	if !params.ReadOnly {
+		mux, err = modules.NewSiaMux(filepath.Join(dir, modules.SiaMuxDir), dir, params.SiaMuxTCPAddress, params.SiaMuxWSAddress)
+		if err != nil {
+			errChan <- errors.Extend(err, errors.New("unable to create siamux"))
+			return nil, errChan
+		}
 	}
 
 	// Load all modules
@@ -308,6 +349,21 @@ func New(params NodeParams, loadStartTime time.Time) (*Node, <-chan error) {
 		if consensusSetDeps == nil {
 			consensusSetDeps = modules.ProdDependencies
 		}
+		if params.LightWallet {
+			if params.CreateHost || params.CreateRenter || params.CreateMiner || params.CreateExplorer {
+				c <- errors.New("light wallet nodes can't run a host, renter, miner or explorer")
+				return nil, c
+			}
+			lcs, err := consensus.NewLightConsensusSet(g, filepath.Join(dir, modules.ConsensusDir), consensusSetDeps)
+			if err != nil {
+				c <- err
+				return nil, c
+			}
+			return lcs, c
+		}
+		if params.NFTSnapshotBootstrap {
+			return consensus.NewNFTSnapshotConsensusSet(g, params.Bootstrap, filepath.Join(dir, modules.ConsensusDir), consensusSetDeps)
+		}
 		return consensus.NewCustomConsensusSet(g, params.Bootstrap, filepath.Join(dir, modules.ConsensusDir), consensusSetDeps)
 	}()
 	if err := modules.PeekErr(errChanCS); err != nil {
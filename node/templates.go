@@ -84,6 +84,20 @@ var (
 		CreateTransactionPool: true,
 		CreateWallet:          true,
 	}
+	// ReadOnlyTemplate is a template for a read-only Sia node. The node has a
+	// gateway, consensus set and transaction pool, but no other modules.
+	ReadOnlyTemplate = NodeParams{
+		CreateAccounting:      false,
+		CreateConsensusSet:    true,
+		CreateExplorer:        false,
+		CreateGateway:         true,
+		CreateHost:            false,
+		CreateMiner:           false,
+		CreateRenter:          false,
+		CreateTransactionPool: true,
+		CreateWallet:          false,
+		ReadOnly:              true,
+	}
 	// WalletTemplate is a template for a Sia node that has a functioning
 	// wallet. The node has a wallet and all dependencies, but no other
 	// modules.
@@ -142,6 +156,13 @@ func Renter(dir string) NodeParams {
 	return template
 }
 
+// ReadOnly returns a ReadOnlyTemplate filled out with the provided dir.
+func ReadOnly(dir string) NodeParams {
+	template := ReadOnlyTemplate
+	template.Dir = dir
+	return template
+}
+
 // Wallet returns a WalletTemplate filled out with the provided dir.
 func Wallet(dir string) NodeParams {
 	template := WalletTemplate
@@ -84,4 +84,37 @@ func TestNew(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+
+	// Test ReadOnlyTemplate.
+	dir = build.TempDir("node", t.Name()+"-ReadOnlyTemplate")
+	n, errChan = New(ReadOnly(dir), time.Now())
+	if err := <-errChan; err != nil {
+		t.Fatal(err)
+	}
+	if n.Gateway == nil {
+		t.Error("gateway not set correctly")
+	}
+	if n.ConsensusSet == nil {
+		t.Error("consensus set not set correctly")
+	}
+	if n.TransactionPool == nil {
+		t.Error("transaction pool not set correctly")
+	}
+	if n.Wallet != nil || n.Host != nil || n.Renter != nil || n.Miner != nil || n.Accounting != nil {
+		t.Error("read-only node should only have a gateway, consensus set and transaction pool")
+	}
+	if n.Mux != nil {
+		t.Error("siamux should not be created for a read-only node")
+	}
+	err = n.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A read-only node can't run a wallet.
+	params := ReadOnly(build.TempDir("node", t.Name()+"-ReadOnlyWallet"))
+	params.CreateWallet = true
+	if _, errChan = New(params, time.Now()); <-errChan == nil {
+		t.Fatal("expected read-only node with a wallet to fail")
+	}
 }
@@ -2,6 +2,7 @@ package daemon
 
 import (
 	"encoding/hex"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
@@ -12,10 +13,12 @@ import (
 
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/node"
 	"go.sia.tech/siad/node/api/client"
 	"go.sia.tech/siad/profile"
 	"go.sia.tech/siad/siatest"
+	"go.sia.tech/siad/types"
 )
 
 // TestDaemonAPIPassword makes sure that the daemon rejects requests with the
@@ -314,6 +317,189 @@ func TestDaemonStack(t *testing.T) {
 	}
 }
 
+// TestMetrics tests the /metrics endpoint.
+func TestMetrics(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	testDir := daemonTestDir(t.Name())
+
+	// Create a new server
+	testNode, err := siatest.NewCleanNode(node.Gateway(testDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err = testNode.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Get the metrics and make sure the metrics of the modules are exported.
+	metrics, err := testNode.MetricsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"siad_wallet_nft_operations_total", "siad_contractor_maintenance_runs_total", "siad_host_registry_updates_total", "siad_host_mdm_programs_total", "siad_renter_upload_bytes_total", "siad_renter_download_bytes_total"} {
+		if !strings.Contains(metrics, "# TYPE "+name+" counter") {
+			t.Fatalf("metric %v is missing:\n%v", name, metrics)
+		}
+	}
+
+	// Scrapers don't set the useragent, so the endpoint should work without
+	// it as long as the password is provided.
+	req, err := http.NewRequest("GET", "http://"+testNode.Server.APIAddress()+"/metrics", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.SetBasicAuth("", testNode.Password)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal("expected request without useragent to succeed", resp.StatusCode)
+	}
+
+	// Without the password the request is rejected.
+	req.Header.Del("Authorization")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatal("expected request without password to fail", resp.StatusCode)
+	}
+}
+
+// TestAPIKeys tests that API keys only grant access to the endpoints covered
+// by their scopes.
+func TestAPIKeys(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	testDir := daemonTestDir(t.Name())
+
+	testNode, err := siatest.NewCleanNode(node.Wallet(testDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err = testNode.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Create a read-only key.
+	dakp, err := testNode.DaemonAPIKeysPost("gallery", modules.APIKeyScopeReadOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dakg, err := testNode.DaemonAPIKeysGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dakg.APIKeys) != 1 || dakg.APIKeys[0].Key != dakp.Key {
+		t.Fatal("key wasn't added", dakg.APIKeys)
+	}
+	c := testNode.Client
+	c.Password = dakp.Key
+
+	// The key can read the wallet's transactions but can't spend, reveal the
+	// seeds or manage the API keys.
+	if _, err := c.WalletTransactionGroupsGet(); err != nil {
+		t.Fatal(err)
+	}
+	uc, err := testNode.WalletAddressGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.WalletSiacoinsPost(types.SiacoinPrecision, uc.Address, false); err == nil || !strings.Contains(err.Error(), "API key doesn't grant access") {
+		t.Fatal("expected read-only key to be rejected", err)
+	}
+	if _, err := c.WalletSeedsGet(); err == nil {
+		t.Fatal("expected read-only key to be rejected")
+	}
+	if _, err := c.DaemonAPIKeysPost("admin", modules.APIKeyScopeWalletSpend); err == nil {
+		t.Fatal("expected read-only key to be rejected")
+	}
+
+	// After removing the key it is rejected like a wrong password.
+	if err := testNode.DaemonAPIKeyRemovePost("gallery"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.WalletTransactionGroupsGet(); err == nil || !strings.Contains(err.Error(), "API authentication failed") {
+		t.Fatal("expected removed key to be rejected", err)
+	}
+}
+
+// TestAPIRateLimits tests that clients exceeding an API rate limit are
+// rejected.
+func TestAPIRateLimits(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	testDir := daemonTestDir(t.Name())
+
+	testNode, err := siatest.NewCleanNode(node.Gateway(testDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err = testNode.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Limit the version endpoint to 2 requests per minute.
+	if err := testNode.DaemonRateLimitsPost("/daemon/version", 2); err != nil {
+		t.Fatal(err)
+	}
+	drlg, err := testNode.DaemonRateLimitsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if drlg.RateLimits["/daemon/version"] != 2 {
+		t.Fatal("rate limit wasn't set", drlg.RateLimits)
+	}
+
+	// The third request is rejected.
+	for i := 0; i < 2; i++ {
+		if _, err := testNode.DaemonVersionGet(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err = testNode.DaemonVersionGet()
+	if err == nil || !strings.Contains(err.Error(), "rate limit") {
+		t.Fatal("expected request to be rate limited", err)
+	}
+	metrics, err := testNode.MetricsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(metrics, `siad_api_rate_limited_requests_total{path="/daemon/version"}`) {
+		t.Fatal("rejected request wasn't counted")
+	}
+
+	// Other endpoints aren't affected.
+	if _, err := testNode.DaemonSettingsGet(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without the limit the request succeeds.
+	if err := testNode.DaemonRateLimitsPost("/daemon/version", 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := testNode.DaemonVersionGet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
 // TestDaemonProfile test the /dameon/profile endpoint.
 func TestDaemonProfile(t *testing.T) {
 	if testing.Short() {
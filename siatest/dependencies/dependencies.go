@@ -308,6 +308,41 @@ func NewDependencyInterruptUploadAfterSendingRevision() *DependencyInterruptOnce
 	return newDependencyInterruptOnceOnKeyword("InterruptUploadAfterSendingRevision")
 }
 
+// NewDependencyInterruptNFTMintBeforeBroadcast creates a new dependency that
+// interrupts an NFT mint after signing the mint transaction but before
+// broadcasting it.
+func NewDependencyInterruptNFTMintBeforeBroadcast() *DependencyInterruptOnceOnKeyword {
+	return newDependencyInterruptOnceOnKeyword("InterruptNFTMintBeforeBroadcast")
+}
+
+// NewDependencyInterruptNFTTransferBeforeBroadcast creates a new dependency
+// that interrupts an NFT transfer after signing the transfer transaction but
+// before broadcasting it.
+func NewDependencyInterruptNFTTransferBeforeBroadcast() *DependencyInterruptOnceOnKeyword {
+	return newDependencyInterruptOnceOnKeyword("InterruptNFTTransferBeforeBroadcast")
+}
+
+// NewDependencyInterruptNFTStakeRewardBeforeBroadcast creates a new
+// dependency that interrupts claiming a reward from the NFT storage pool after
+// signing the claim but before broadcasting it.
+func NewDependencyInterruptNFTStakeRewardBeforeBroadcast() *DependencyInterruptOnceOnKeyword {
+	return newDependencyInterruptOnceOnKeyword("InterruptNFTStakeRewardBeforeBroadcast")
+}
+
+// NewDependencyInterruptNFTDepositsApply creates a new dependency that
+// interrupts the wallet while it processes a consensus change, right before
+// it records the change's NFT deposits.
+func NewDependencyInterruptNFTDepositsApply() *DependencyInterruptOnceOnKeyword {
+	return newDependencyInterruptOnceOnKeyword("InterruptNFTDepositsApply")
+}
+
+// NewDependencyInterruptNFTDepositsRevert creates a new dependency that
+// interrupts the wallet while it processes a consensus change, right before
+// it removes the NFT deposits of the reverted blocks.
+func NewDependencyInterruptNFTDepositsRevert() *DependencyInterruptOnceOnKeyword {
+	return newDependencyInterruptOnceOnKeyword("InterruptNFTDepositsRevert")
+}
+
 // newDependencyInterruptOnceOnKeyword creates a new
 // DependencyInterruptOnceOnKeyword from a given disrupt key.
 func newDependencyInterruptOnceOnKeyword(str string) *DependencyInterruptOnceOnKeyword {
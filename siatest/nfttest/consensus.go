@@ -0,0 +1,402 @@
+// Package nfttest provides an in-memory fake of the NFT index of a consensus
+// set. Wallets, marketplaces and other code which depends on
+// modules.NFTConsensus can use it to be tested without a consensus database.
+package nfttest
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// ErrNotFound is returned when querying an item of an NFT which the fake
+// doesn't hold.
+var ErrNotFound = errors.New("nft item not found")
+
+// Consensus is an in-memory modules.NFTConsensus. Its state is set directly by
+// the test; Mint, Transfer and Liquidate update it the way the consensus set
+// does for the corresponding transactions.
+type Consensus struct {
+	height types.BlockHeight
+	stats  types.NFTStats
+
+	nfts        map[types.NftID]types.NftCustody
+	custody     map[types.NftID]types.SiacoinOutput
+	lockups     map[types.NftID]types.NFTLockup
+	stakes      map[types.NftID]types.NFTStake
+	rootStakes  map[crypto.Hash]types.NFTRootStake
+	insurances  map[types.NftID]types.NFTInsurance
+	approvals   map[types.NftID]types.NFTApproval
+	disputes    map[types.NftID]types.NFTDispute
+	earmarks    map[types.NftID]types.NFTStorageEarmark
+	bridgeLocks map[types.NftID]types.NFTBridgeLock
+	licenses    map[types.NftID]types.NFTLicense
+
+	mu sync.Mutex
+}
+
+// New returns an empty fake at height zero.
+func New() *Consensus {
+	return &Consensus{
+		nfts:        make(map[types.NftID]types.NftCustody),
+		custody:     make(map[types.NftID]types.SiacoinOutput),
+		lockups:     make(map[types.NftID]types.NFTLockup),
+		stakes:      make(map[types.NftID]types.NFTStake),
+		rootStakes:  make(map[crypto.Hash]types.NFTRootStake),
+		insurances:  make(map[types.NftID]types.NFTInsurance),
+		approvals:   make(map[types.NftID]types.NFTApproval),
+		disputes:    make(map[types.NftID]types.NFTDispute),
+		earmarks:    make(map[types.NftID]types.NFTStorageEarmark),
+		bridgeLocks: make(map[types.NftID]types.NFTBridgeLock),
+		licenses:    make(map[types.NftID]types.NFTLicense),
+	}
+}
+
+// SetHeight sets the height at which the following updates happen.
+func (c *Consensus) SetHeight(height types.BlockHeight) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.height = height
+	c.stats.Height = height
+}
+
+// Mint adds an NFT held by owner, together with its lockup and the earmark of
+// its mint.
+func (c *Consensus) Mint(nft types.NftCustody, owner types.UnlockHash) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	id := nft.Identifier()
+	c.nfts[id] = nft
+	c.custody[id] = types.SiacoinOutput{UnlockHash: owner, Value: types.OneBaseUnit}
+	c.lockups[id] = types.NFTLockup{MintHeight: c.height}
+	c.earmarks[id] = types.NFTStorageEarmark{Mint: types.NFTHostAmount, Height: c.height}
+	c.stats.Minted++
+	c.stats.Active++
+	c.stats.LockupPoolSiacoins = c.stats.LockupPoolSiacoins.Add(types.NFTLockupAmount)
+}
+
+// Transfer moves the custody of an NFT to dest and clears its approval.
+func (c *Consensus) Transfer(nft types.NftCustody, dest types.UnlockHash) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	id := nft.Identifier()
+	if _, exists := c.custody[id]; !exists {
+		return ErrNotFound
+	}
+	c.custody[id] = types.SiacoinOutput{UnlockHash: dest, Value: types.OneBaseUnit}
+	delete(c.approvals, id)
+	earmark := c.earmarks[id]
+	earmark.Transfers = earmark.Transfers.Add(types.NFTTransferCost)
+	earmark.Height = c.height
+	c.earmarks[id] = earmark
+	c.stats.Transfers++
+	c.stats.BlockTransfers++
+	return nil
+}
+
+// Liquidate moves the custody of an NFT to types.LiquidatedNFTUnlockHash.
+func (c *Consensus) Liquidate(nft types.NftCustody) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	id := nft.Identifier()
+	if _, exists := c.custody[id]; !exists {
+		return ErrNotFound
+	}
+	c.custody[id] = types.SiacoinOutput{UnlockHash: types.LiquidatedNFTUnlockHash, Value: types.OneBaseUnit}
+	delete(c.approvals, id)
+	c.stats.Liquidated++
+	c.stats.Active--
+	return nil
+}
+
+// SetCustody sets the custody output of an NFT without touching the rest of
+// its state.
+func (c *Consensus) SetCustody(nft types.NftCustody, sco types.SiacoinOutput) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.nfts[nft.Identifier()] = nft
+	c.custody[nft.Identifier()] = sco
+}
+
+// SetLockup sets the lockup of an NFT.
+func (c *Consensus) SetLockup(nft types.NftCustody, lockup types.NFTLockup) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lockups[nft.Identifier()] = lockup
+}
+
+// SetStake stakes an NFT. A zero amount removes the stake.
+func (c *Consensus) SetStake(nft types.NftCustody, stake types.NFTStake) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if stake.Amount.IsZero() {
+		delete(c.stakes, nft.Identifier())
+		return
+	}
+	c.stakes[nft.Identifier()] = stake
+}
+
+// SetRootStake sets the stake accounted to a merkle root.
+func (c *Consensus) SetRootStake(root crypto.Hash, stake types.NFTRootStake) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rootStakes[root] = stake
+}
+
+// SetInsurance sets the last insurance of an NFT.
+func (c *Consensus) SetInsurance(nft types.NftCustody, insurance types.NFTInsurance) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.insurances[nft.Identifier()] = insurance
+}
+
+// SetApproval approves an operator of an NFT. A zero operator revokes the
+// approval.
+func (c *Consensus) SetApproval(nft types.NftCustody, approval types.NFTApproval) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if approval.Operator == (types.UnlockHash{}) {
+		delete(c.approvals, nft.Identifier())
+		return
+	}
+	c.approvals[nft.Identifier()] = approval
+}
+
+// AddDisputeFlag appends a flag to the dispute history of an NFT.
+func (c *Consensus) AddDisputeFlag(nft types.NftCustody, flag types.NFTDisputeFlag) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	dispute := c.disputes[nft.Identifier()]
+	dispute.ID = nft.Identifier()
+	dispute.Flags = append(dispute.Flags, flag)
+	c.disputes[nft.Identifier()] = dispute
+}
+
+// SetStorageEarmark sets the earmark of the storage pool for an NFT.
+func (c *Consensus) SetStorageEarmark(nft types.NftCustody, earmark types.NFTStorageEarmark) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.earmarks[nft.Identifier()] = earmark
+}
+
+// SetBridgeLock locks an NFT for a bridge. A zero bridge removes the lock.
+func (c *Consensus) SetBridgeLock(nft types.NftCustody, lock types.NFTBridgeLock) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if lock.Bridge == (types.UnlockHash{}) {
+		delete(c.bridgeLocks, nft.Identifier())
+		return
+	}
+	c.bridgeLocks[nft.Identifier()] = lock
+}
+
+// SetLicense sets the license the mint of an NFT embedded.
+func (c *Consensus) SetLicense(nft types.NftCustody, license types.NFTLicense) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.licenses[nft.Identifier()] = license
+}
+
+// SetStats replaces the NFT statistics.
+func (c *Consensus) SetStats(stats types.NFTStats) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stats = stats
+}
+
+// ViewNFTCustody implements modules.NFTConsensus.
+func (c *Consensus) ViewNFTCustody(nft types.NftCustody) (types.SiacoinOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	sco, exists := c.custody[nft.Identifier()]
+	if !exists {
+		return types.SiacoinOutput{}, ErrNotFound
+	}
+	return sco, nil
+}
+
+// FindNFTsForAddress implements modules.NFTConsensus. The NFTs are ordered by
+// their identifiers.
+func (c *Consensus) FindNFTsForAddress(address types.UnlockHash) []types.NftCustody {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var nfts []types.NftCustody
+	for id, sco := range c.custody {
+		if sco.UnlockHash == address {
+			nfts = append(nfts, c.nfts[id])
+		}
+	}
+	sort.Slice(nfts, func(i, j int) bool {
+		a, b := nfts[i].Identifier(), nfts[j].Identifier()
+		return bytes.Compare(a[:], b[:]) < 0
+	})
+	return nfts
+}
+
+// FindNFTEditions implements modules.NFTConsensus. The editions are ordered
+// by their creator, collection and index, like those of the consensus set.
+func (c *Consensus) FindNFTEditions(root crypto.Hash) []types.NftOwnershipStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var editions []types.NftOwnershipStats
+	for id, nft := range c.nfts {
+		if nft.IsEdition() && nft.FileMerkleRoot == root {
+			editions = append(editions, types.NftOwnershipStats{Nft: nft, Owner: c.custody[id].UnlockHash})
+		}
+	}
+	sort.Slice(editions, func(i, j int) bool {
+		a, b := editions[i].Nft, editions[j].Nft
+		if a.Creator != b.Creator {
+			return bytes.Compare(a.Creator[:], b.Creator[:]) < 0
+		}
+		if a.Collection != b.Collection {
+			return a.Collection < b.Collection
+		}
+		return a.Edition() < b.Edition()
+	})
+	return editions
+}
+
+// ViewNFTTransferPolicy implements modules.NFTConsensus.
+func (c *Consensus) ViewNFTTransferPolicy(nft types.NftCustody) types.NFTTransferPolicy {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.nfts[nft.Identifier()].TransferPolicy
+}
+
+// ViewNFTLicense implements modules.NFTConsensus.
+func (c *Consensus) ViewNFTLicense(nft types.NftCustody) (types.NFTLicense, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	license, exists := c.licenses[nft.Identifier()]
+	if !exists {
+		return types.NFTLicense{}, ErrNotFound
+	}
+	return license, nil
+}
+
+// ViewNFTStake implements modules.NFTConsensus.
+func (c *Consensus) ViewNFTStake(nft types.NftCustody) (types.NFTStake, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stake, exists := c.stakes[nft.Identifier()]
+	if !exists {
+		return types.NFTStake{}, ErrNotFound
+	}
+	return stake, nil
+}
+
+// ViewNFTRootStake implements modules.NFTConsensus.
+func (c *Consensus) ViewNFTRootStake(root crypto.Hash) types.NFTRootStake {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rootStakes[root]
+}
+
+// ViewNFTInsurance implements modules.NFTConsensus.
+func (c *Consensus) ViewNFTInsurance(nft types.NftCustody) (types.NFTInsurance, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	insurance, exists := c.insurances[nft.Identifier()]
+	if !exists {
+		return types.NFTInsurance{}, ErrNotFound
+	}
+	return insurance, nil
+}
+
+// ViewNFTApproval implements modules.NFTConsensus.
+func (c *Consensus) ViewNFTApproval(nft types.NftCustody) (types.NFTApproval, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	approval, exists := c.approvals[nft.Identifier()]
+	if !exists {
+		return types.NFTApproval{}, ErrNotFound
+	}
+	return approval, nil
+}
+
+// ViewNFTDispute implements modules.NFTConsensus.
+func (c *Consensus) ViewNFTDispute(nft types.NftCustody) (types.NFTDispute, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	dispute, exists := c.disputes[nft.Identifier()]
+	if !exists {
+		return types.NFTDispute{}, ErrNotFound
+	}
+	return dispute, nil
+}
+
+// ViewNFTRootFrozen implements modules.NFTConsensus.
+func (c *Consensus) ViewNFTRootFrozen(root crypto.Hash) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for id, dispute := range c.disputes {
+		if dispute.Frozen() && c.nfts[id].FileMerkleRoot == root {
+			return true
+		}
+	}
+	return false
+}
+
+// NFTDisputes implements modules.NFTConsensus. The disputes are ordered by
+// the identifiers of their NFTs.
+func (c *Consensus) NFTDisputes() ([]types.NFTDispute, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var disputes []types.NFTDispute
+	for _, dispute := range c.disputes {
+		disputes = append(disputes, dispute)
+	}
+	sort.Slice(disputes, func(i, j int) bool {
+		return bytes.Compare(disputes[i].ID[:], disputes[j].ID[:]) < 0
+	})
+	return disputes, nil
+}
+
+// ViewNFTLockup implements modules.NFTConsensus.
+func (c *Consensus) ViewNFTLockup(nft types.NftCustody) (types.NFTLockup, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	lockup, exists := c.lockups[nft.Identifier()]
+	if !exists {
+		return types.NFTLockup{}, ErrNotFound
+	}
+	return lockup, nil
+}
+
+// NFTStats implements modules.NFTConsensus.
+func (c *Consensus) NFTStats() types.NFTStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// ViewNFTStorageEarmark implements modules.NFTConsensus.
+func (c *Consensus) ViewNFTStorageEarmark(nft types.NftCustody) (types.NFTStorageEarmark, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	earmark, exists := c.earmarks[nft.Identifier()]
+	if !exists {
+		return types.NFTStorageEarmark{}, ErrNotFound
+	}
+	return earmark, nil
+}
+
+// ViewNFTBridgeLock implements modules.NFTConsensus.
+func (c *Consensus) ViewNFTBridgeLock(nft types.NftCustody) (types.NFTBridgeLock, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	lock, exists := c.bridgeLocks[nft.Identifier()]
+	if !exists {
+		return types.NFTBridgeLock{}, ErrNotFound
+	}
+	return lock, nil
+}
+
+var _ modules.NFTConsensus = (*Consensus)(nil)
@@ -0,0 +1,106 @@
+package nfttest
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/types"
+)
+
+// TestConsensus tests that the fake tracks mints, transfers, liquidations and
+// disputes like the consensus set.
+func TestConsensus(t *testing.T) {
+	c := New()
+	var nft types.NftCustody
+	fastrand.Read(nft.FileMerkleRoot[:])
+	owner, dest := types.UnlockHash{1}, types.UnlockHash{2}
+
+	// Unknown NFTs aren't found.
+	if _, err := c.ViewNFTCustody(nft); !errors.Contains(err, ErrNotFound) {
+		t.Fatal("expected ErrNotFound but got", err)
+	}
+	if err := c.Transfer(nft, dest); !errors.Contains(err, ErrNotFound) {
+		t.Fatal("expected ErrNotFound but got", err)
+	}
+
+	// Mints are held by their owner and locked up.
+	c.SetHeight(10)
+	c.Mint(nft, owner)
+	if sco, err := c.ViewNFTCustody(nft); err != nil || sco.UnlockHash != owner {
+		t.Fatal("minted NFT isn't held by its owner", sco, err)
+	}
+	if nfts := c.FindNFTsForAddress(owner); len(nfts) != 1 || nfts[0].Identifier() != nft.Identifier() {
+		t.Fatal("owner doesn't hold the NFT", nfts)
+	}
+	if lockup, err := c.ViewNFTLockup(nft); err != nil || lockup.MintHeight != 10 {
+		t.Fatal("wrong lockup", lockup, err)
+	}
+
+	// Transfers move the NFT and clear its approval.
+	c.SetApproval(nft, types.NFTApproval{Operator: dest})
+	if err := c.Transfer(nft, dest); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.FindNFTsForAddress(owner)) != 0 || len(c.FindNFTsForAddress(dest)) != 1 {
+		t.Fatal("NFT wasn't transferred")
+	}
+	if _, err := c.ViewNFTApproval(nft); !errors.Contains(err, ErrNotFound) {
+		t.Fatal("approval wasn't cleared", err)
+	}
+	if earmark, err := c.ViewNFTStorageEarmark(nft); err != nil || !earmark.Transfers.Equals(types.NFTTransferCost) {
+		t.Fatal("transfer wasn't earmarked", earmark, err)
+	}
+	if stats := c.NFTStats(); stats.Minted != 1 || stats.Transfers != 1 {
+		t.Fatal("wrong stats", stats)
+	}
+
+	// Frozen disputes freeze the root of the NFT.
+	c.AddDisputeFlag(nft, types.NFTDisputeFlag{Frozen: true})
+	if !c.ViewNFTRootFrozen(nft.FileMerkleRoot) {
+		t.Fatal("root isn't frozen")
+	}
+	c.AddDisputeFlag(nft, types.NFTDisputeFlag{})
+	if c.ViewNFTRootFrozen(nft.FileMerkleRoot) {
+		t.Fatal("root is still frozen")
+	}
+	if disputes, err := c.NFTDisputes(); err != nil || len(disputes) != 1 || len(disputes[0].Flags) != 2 {
+		t.Fatal("wrong disputes", disputes, err)
+	}
+
+	// Liquidations move the NFT to the liquidation address.
+	if err := c.Liquidate(nft); err != nil {
+		t.Fatal(err)
+	}
+	if sco, _ := c.ViewNFTCustody(nft); sco.UnlockHash != types.LiquidatedNFTUnlockHash {
+		t.Fatal("NFT wasn't liquidated")
+	}
+	if stats := c.NFTStats(); stats.Active != 0 || stats.Liquidated != 1 {
+		t.Fatal("wrong stats", stats)
+	}
+}
+
+// TestConsensusEditions tests that editions are found by their root and
+// ordered by their index.
+func TestConsensusEditions(t *testing.T) {
+	c := New()
+	root := crypto.Hash{1}
+	for _, i := range []uint64{3, 1, 2} {
+		edition := types.NftCustody{FileMerkleRoot: root, Collection: "c", Nonce: i, Editions: 3}
+		edition.ID = types.DeriveNftID(edition.Creator, edition.Collection, root, i)
+		c.Mint(edition, types.UnlockHash{byte(i)})
+	}
+	c.Mint(types.NftCustody{FileMerkleRoot: root}, types.UnlockHash{})
+
+	editions := c.FindNFTEditions(root)
+	if len(editions) != 3 {
+		t.Fatal("wrong number of editions", len(editions))
+	}
+	for i, e := range editions {
+		if e.Nft.Edition() != uint64(i+1) || e.Owner != (types.UnlockHash{byte(i + 1)}) {
+			t.Fatal("editions are out of order", editions)
+		}
+	}
+}
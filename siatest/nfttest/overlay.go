@@ -0,0 +1,100 @@
+package nfttest
+
+import (
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// overlay is a consensus set whose NFT queries are answered by a fake.
+type overlay struct {
+	modules.ConsensusSet
+	nft *Consensus
+}
+
+// Overlay returns a consensus set which answers the queries of
+// modules.NFTConsensus from c and everything else from cs. Modules which take
+// a full consensus set, like the wallet, can be tested against it.
+func (c *Consensus) Overlay(cs modules.ConsensusSet) modules.ConsensusSet {
+	return overlay{ConsensusSet: cs, nft: c}
+}
+
+// ViewNFTCustody implements modules.NFTConsensus.
+func (o overlay) ViewNFTCustody(nft types.NftCustody) (types.SiacoinOutput, error) {
+	return o.nft.ViewNFTCustody(nft)
+}
+
+// FindNFTsForAddress implements modules.NFTConsensus.
+func (o overlay) FindNFTsForAddress(address types.UnlockHash) []types.NftCustody {
+	return o.nft.FindNFTsForAddress(address)
+}
+
+// FindNFTEditions implements modules.NFTConsensus.
+func (o overlay) FindNFTEditions(root crypto.Hash) []types.NftOwnershipStats {
+	return o.nft.FindNFTEditions(root)
+}
+
+// ViewNFTTransferPolicy implements modules.NFTConsensus.
+func (o overlay) ViewNFTTransferPolicy(nft types.NftCustody) types.NFTTransferPolicy {
+	return o.nft.ViewNFTTransferPolicy(nft)
+}
+
+// ViewNFTLicense implements modules.NFTConsensus.
+func (o overlay) ViewNFTLicense(nft types.NftCustody) (types.NFTLicense, error) {
+	return o.nft.ViewNFTLicense(nft)
+}
+
+// ViewNFTStake implements modules.NFTConsensus.
+func (o overlay) ViewNFTStake(nft types.NftCustody) (types.NFTStake, error) {
+	return o.nft.ViewNFTStake(nft)
+}
+
+// ViewNFTRootStake implements modules.NFTConsensus.
+func (o overlay) ViewNFTRootStake(root crypto.Hash) types.NFTRootStake {
+	return o.nft.ViewNFTRootStake(root)
+}
+
+// ViewNFTInsurance implements modules.NFTConsensus.
+func (o overlay) ViewNFTInsurance(nft types.NftCustody) (types.NFTInsurance, error) {
+	return o.nft.ViewNFTInsurance(nft)
+}
+
+// ViewNFTApproval implements modules.NFTConsensus.
+func (o overlay) ViewNFTApproval(nft types.NftCustody) (types.NFTApproval, error) {
+	return o.nft.ViewNFTApproval(nft)
+}
+
+// ViewNFTDispute implements modules.NFTConsensus.
+func (o overlay) ViewNFTDispute(nft types.NftCustody) (types.NFTDispute, error) {
+	return o.nft.ViewNFTDispute(nft)
+}
+
+// ViewNFTRootFrozen implements modules.NFTConsensus.
+func (o overlay) ViewNFTRootFrozen(root crypto.Hash) bool {
+	return o.nft.ViewNFTRootFrozen(root)
+}
+
+// NFTDisputes implements modules.NFTConsensus.
+func (o overlay) NFTDisputes() ([]types.NFTDispute, error) {
+	return o.nft.NFTDisputes()
+}
+
+// ViewNFTLockup implements modules.NFTConsensus.
+func (o overlay) ViewNFTLockup(nft types.NftCustody) (types.NFTLockup, error) {
+	return o.nft.ViewNFTLockup(nft)
+}
+
+// NFTStats implements modules.NFTConsensus.
+func (o overlay) NFTStats() types.NFTStats {
+	return o.nft.NFTStats()
+}
+
+// ViewNFTStorageEarmark implements modules.NFTConsensus.
+func (o overlay) ViewNFTStorageEarmark(nft types.NftCustody) (types.NFTStorageEarmark, error) {
+	return o.nft.ViewNFTStorageEarmark(nft)
+}
+
+// ViewNFTBridgeLock implements modules.NFTConsensus.
+func (o overlay) ViewNFTBridgeLock(nft types.NftCustody) (types.NFTBridgeLock, error) {
+	return o.nft.ViewNFTBridgeLock(nft)
+}
@@ -2,11 +2,13 @@ package renterhost
 
 import (
 	"bytes"
+	"context"
 	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/fastrand"
 	"gitlab.com/NebulousLabs/log"
 	"gitlab.com/NebulousLabs/ratelimit"
@@ -65,25 +67,25 @@ func TestSession(t *testing.T) {
 	}
 
 	// begin the RPC session
-	s, err := cs.NewSession(hhg.Entry.HostDBEntry, contract.ID, cg.Height, stubHostDB{}, log.DiscardLogger, nil)
+	s, err := cs.NewSession(context.Background(), hhg.Entry.HostDBEntry, contract.ID, cg.Height, stubHostDB{}, log.DiscardLogger)
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	// upload a sector
 	sector := fastrand.Bytes(int(modules.SectorSize))
-	_, root, err := s.Append(sector)
+	_, root, err := s.Append(context.Background(), sector)
 	if err != nil {
 		t.Fatal(err)
 	}
 	// upload another sector, to test Merkle proofs
-	_, _, err = s.Append(sector)
+	_, _, err = s.Append(context.Background(), sector)
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	// download the sector
-	_, dsector, err := s.ReadSection(root, 0, uint32(len(sector)))
+	_, dsector, err := s.ReadSection(context.Background(), root, 0, uint32(len(sector)))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -92,7 +94,7 @@ func TestSession(t *testing.T) {
 	}
 
 	// download less than a full sector
-	_, partialSector, err := s.ReadSection(root, crypto.SegmentSize*5, crypto.SegmentSize*12)
+	_, partialSector, err := s.ReadSection(context.Background(), root, crypto.SegmentSize*5, crypto.SegmentSize*12)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -149,11 +151,11 @@ func TestSession(t *testing.T) {
 	}
 	hhg.Entry.HostDBEntry.NetAddress = hg.ExternalSettings.NetAddress
 	// initiate session
-	s, err = cs.NewSession(hhg.Entry.HostDBEntry, contract.ID, cg.Height, stubHostDB{}, log.DiscardLogger, nil)
+	s, err = cs.NewSession(context.Background(), hhg.Entry.HostDBEntry, contract.ID, cg.Height, stubHostDB{}, log.DiscardLogger)
 	if err != nil {
 		t.Fatal(err)
 	}
-	_, s2data, err := s.ReadSection(droots[0], 0, uint32(len(sector2)))
+	_, s2data, err := s.ReadSection(context.Background(), droots[0], 0, uint32(len(sector2)))
 	if err != nil {
 		t.Fatal(err)
 	} else if !bytes.Equal(s2data, sector2) {
@@ -161,6 +163,253 @@ func TestSession(t *testing.T) {
 	}
 }
 
+// TestSessionWriteStream tests uploading sectors with the WriteStream RPC.
+func TestSessionWriteStream(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	gp := siatest.GroupParams{
+		Hosts:   1,
+		Renters: 1,
+		Miners:  1,
+	}
+	tg, err := siatest.NewGroupFromTemplate(renterHostTestDir(t.Name()), gp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := tg.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// manually grab a renter contract
+	renter := tg.Renters()[0]
+	rl := ratelimit.NewRateLimit(0, 0, 0)
+	cs, err := proto.NewContractSet(filepath.Join(renter.Dir, "renter", "contracts"), rl, new(modules.ProductionDependencies))
+	if err != nil {
+		t.Fatal(err)
+	}
+	contract := cs.ViewAll()[0]
+
+	hhg, err := renter.HostDbHostsGet(contract.HostPublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cg, err := renter.ConsensusGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// begin the RPC session
+	s, err := cs.NewSession(context.Background(), hhg.Entry.HostDBEntry, contract.ID, cg.Height, stubHostDB{}, log.DiscardLogger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := s.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	settings, err := s.Settings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings.MaxWriteStreamSectors != modules.NegotiateMaxWriteStreamSectors {
+		t.Fatal("host doesn't advertise the WriteStream RPC", settings.MaxWriteStreamSectors)
+	}
+
+	// upload more sectors than fit in a single stream
+	sectors := make([][]byte, modules.NegotiateMaxWriteStreamSectors+3)
+	for i := range sectors {
+		sectors[i] = fastrand.Bytes(int(modules.SectorSize))
+	}
+	rc, roots, err := s.AppendStream(context.Background(), sectors)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(roots) != len(sectors) {
+		t.Fatal("wrong number of roots", len(roots))
+	}
+	if rc.Size() != uint64(len(sectors))*modules.SectorSize {
+		t.Fatal("wrong contract size", rc.Size())
+	}
+
+	// check that the host stored the sectors in order
+	_, droots, err := s.SectorRoots(modules.LoopSectorRootsRequest{
+		RootOffset: 0,
+		NumRoots:   uint64(len(sectors)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range sectors {
+		if droots[i] != crypto.MerkleRoot(sectors[i]) || roots[i] != droots[i] {
+			t.Fatal("sector root does not match", i)
+		}
+	}
+
+	// the session can keep using the Write RPC after a stream
+	if _, _, err := s.Append(context.Background(), sectors[0]); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestSessionContext tests cancelling sessions and their RPCs with a context.
+func TestSessionContext(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	gp := siatest.GroupParams{
+		Hosts:   1,
+		Renters: 1,
+		Miners:  1,
+	}
+	tg, err := siatest.NewGroupFromTemplate(renterHostTestDir(t.Name()), gp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := tg.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// manually grab a renter contract
+	renter := tg.Renters()[0]
+	rl := ratelimit.NewRateLimit(0, 0, 0)
+	cs, err := proto.NewContractSet(filepath.Join(renter.Dir, "renter", "contracts"), rl, new(modules.ProductionDependencies))
+	if err != nil {
+		t.Fatal(err)
+	}
+	contract := cs.ViewAll()[0]
+
+	hhg, err := renter.HostDbHostsGet(contract.HostPublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cg, err := renter.ConsensusGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a session can't be started with a cancelled context
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = cs.NewSession(cancelled, hhg.Entry.HostDBEntry, contract.ID, cg.Height, stubHostDB{}, log.DiscardLogger)
+	if !errors.Contains(err, context.Canceled) {
+		t.Fatal("expected context.Canceled, got", err)
+	}
+
+	// a cancelled upload doesn't revise the contract
+	ctx, cancel := context.WithCancel(context.Background())
+	s, err := cs.NewSession(ctx, hhg.Entry.HostDBEntry, contract.ID, cg.Height, stubHostDB{}, log.DiscardLogger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sector := fastrand.Bytes(int(modules.SectorSize))
+	if _, _, err := s.Append(cancelled, sector); !errors.Contains(err, context.Canceled) {
+		t.Fatal("expected context.Canceled, got", err)
+	}
+	rc, _, err := s.Append(context.Background(), sector)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rc.Size() != modules.SectorSize {
+		t.Fatal("wrong contract size", rc.Size())
+	}
+
+	// cancelling the session's context closes the session
+	cancel()
+	if _, _, err := s.Append(context.Background(), sector); err == nil {
+		t.Fatal("session should be closed")
+	}
+	s.Close()
+}
+
+// TestSessionPrices tests that a session refreshes the host's prices once they
+// expire.
+func TestSessionPrices(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	gp := siatest.GroupParams{
+		Hosts:   1,
+		Renters: 1,
+		Miners:  1,
+	}
+	tg, err := siatest.NewGroupFromTemplate(renterHostTestDir(t.Name()), gp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := tg.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// manually grab a renter contract
+	renter := tg.Renters()[0]
+	rl := ratelimit.NewRateLimit(0, 0, 0)
+	cs, err := proto.NewContractSet(filepath.Join(renter.Dir, "renter", "contracts"), rl, new(modules.ProductionDependencies))
+	if err != nil {
+		t.Fatal(err)
+	}
+	contract := cs.ViewAll()[0]
+
+	hhg, err := renter.HostDbHostsGet(contract.HostPublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cg, err := renter.ConsensusGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// begin the RPC session
+	s, err := cs.NewSession(context.Background(), hhg.Entry.HostDBEntry, contract.ID, cg.Height, stubHostDB{}, log.DiscardLogger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := s.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	prices, err := s.Prices()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// raise the host's upload price; the session keeps using its prices until
+	// they expire
+	host := tg.Hosts()[0]
+	newPrice := prices.UploadBandwidthPrice.Mul64(10)
+	if err := host.HostModifySettingPost(client.HostParamMinUploadBandwidthPrice, newPrice); err != nil {
+		t.Fatal(err)
+	}
+	cached, err := s.Prices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cached.UploadBandwidthPrice.Equals(prices.UploadBandwidthPrice) {
+		t.Fatal("prices were refreshed before they expired")
+	}
+
+	// once the prices expired, the session fetches the new ones and pays the
+	// new price for uploads
+	time.Sleep(5 * time.Second)
+	if _, _, err := s.Append(context.Background(), fastrand.Bytes(int(modules.SectorSize))); err != nil {
+		t.Fatal(err)
+	}
+	if !s.HostSettings().UploadBandwidthPrice.Equals(newPrice) {
+		t.Fatal("prices weren't refreshed", s.HostSettings().UploadBandwidthPrice, newPrice)
+	}
+}
+
 // TestHostLockTimeout tests that the host respects the requested timeout in the
 // Lock RPC.
 func TestHostLockTimeout(t *testing.T) {
@@ -202,14 +451,14 @@ func TestHostLockTimeout(t *testing.T) {
 	}
 
 	// Begin an RPC session. This will lock the contract.
-	s1, err := cs.NewSession(hhg.Entry.HostDBEntry, contract.ID, cg.Height, stubHostDB{}, log.DiscardLogger, nil)
+	s1, err := cs.NewSession(context.Background(), hhg.Entry.HostDBEntry, contract.ID, cg.Height, stubHostDB{}, log.DiscardLogger)
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	// Attempt to begin a separate RPC session. This will block while waiting
 	// to acquire the contract lock, and eventually fail.
-	_, err = cs.NewSession(hhg.Entry.HostDBEntry, contract.ID, cg.Height, stubHostDB{}, log.DiscardLogger, nil)
+	_, err = cs.NewSession(context.Background(), hhg.Entry.HostDBEntry, contract.ID, cg.Height, stubHostDB{}, log.DiscardLogger)
 	if err == nil || !strings.Contains(err.Error(), "contract is locked by another party") {
 		t.Fatal("expected contract lock error, got", err)
 	}
@@ -221,7 +470,7 @@ func TestHostLockTimeout(t *testing.T) {
 			panic(err) // can't call t.Fatal from goroutine
 		}
 	})
-	s2, err := cs.NewSession(hhg.Entry.HostDBEntry, contract.ID, cg.Height, stubHostDB{}, log.DiscardLogger, nil)
+	s2, err := cs.NewSession(context.Background(), hhg.Entry.HostDBEntry, contract.ID, cg.Height, stubHostDB{}, log.DiscardLogger)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -250,7 +499,7 @@ func TestHostLockTimeout(t *testing.T) {
 				t.Error(err)
 			}
 		}()
-		s1, err = cs2.NewSession(hhg.Entry.HostDBEntry, contract.ID, cg.Height, stubHostDB{}, log.DiscardLogger, nil)
+		s1, err = cs2.NewSession(context.Background(), hhg.Entry.HostDBEntry, contract.ID, cg.Height, stubHostDB{}, log.DiscardLogger)
 		if err != nil {
 			errCh <- err
 			return
@@ -260,7 +509,7 @@ func TestHostLockTimeout(t *testing.T) {
 		errCh <- nil
 	}()
 	time.Sleep(3 * time.Second) // wait for goroutine to start acquiring lock
-	contract, _, err = s2.Append(make([]byte, modules.SectorSize))
+	contract, _, err = s2.Append(context.Background(), make([]byte, modules.SectorSize))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -323,14 +572,14 @@ func TestHostBaseRPCPrice(t *testing.T) {
 	}
 
 	// Begin an RPC session.
-	s, err := cs.NewSession(hhg.Entry.HostDBEntry, contract.ID, cg.Height, stubHostDB{}, log.DiscardLogger, nil)
+	s, err := cs.NewSession(context.Background(), hhg.Entry.HostDBEntry, contract.ID, cg.Height, stubHostDB{}, log.DiscardLogger)
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	// Upload a sector.
 	sector := fastrand.Bytes(int(modules.SectorSize))
-	_, _, err = s.Append(sector)
+	_, _, err = s.Append(context.Background(), sector)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -349,7 +598,7 @@ func TestHostBaseRPCPrice(t *testing.T) {
 	}
 
 	// Attempt to upload another sector.
-	_, _, err = s.Append(sector)
+	_, _, err = s.Append(context.Background(), sector)
 	if err == nil || !strings.Contains(err.Error(), "rejected for high paying renter valid output") {
 		t.Fatal("expected underpayment error, got", err)
 	}
@@ -393,14 +642,14 @@ func TestMultiRead(t *testing.T) {
 	}
 
 	// begin the RPC session
-	s, err := cs.NewSession(hhg.Entry.HostDBEntry, contract.ID, cg.Height, stubHostDB{}, log.DiscardLogger, nil)
+	s, err := cs.NewSession(context.Background(), hhg.Entry.HostDBEntry, contract.ID, cg.Height, stubHostDB{}, log.DiscardLogger)
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	// upload a sector
 	sector := fastrand.Bytes(int(modules.SectorSize))
-	_, root, err := s.Append(sector)
+	_, root, err := s.Append(context.Background(), sector)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -415,7 +664,7 @@ func TestMultiRead(t *testing.T) {
 		}},
 		MerkleProof: true,
 	}
-	_, err = s.Read(&buf, req, nil)
+	_, err = s.Read(context.Background(), &buf, req)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -430,9 +679,9 @@ func TestMultiRead(t *testing.T) {
 		{MerkleRoot: root, Offset: 0, Length: uint32(modules.SectorSize)},
 		{MerkleRoot: root, Offset: 0, Length: uint32(modules.SectorSize)},
 	}
-	cancel := make(chan struct{}, 1)
-	cancel <- struct{}{}
-	_, err = s.Read(&buf, req, cancel)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = s.Read(ctx, &buf, req)
 	if err != nil {
 		t.Fatal(err)
 	}
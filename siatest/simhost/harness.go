@@ -0,0 +1,85 @@
+package simhost
+
+import (
+	"path/filepath"
+	"strconv"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// announcementTxnSize is the estimated size of a transaction announcing a
+// single host.
+const announcementTxnSize = 700
+
+// Harness manages a group of simulated hosts.
+type Harness struct {
+	Hosts []*Host
+}
+
+// NewHarness creates a harness with n simulated hosts. Every host persists its
+// siamux in a subdirectory of dir and funds its collateral from w.
+func NewHarness(dir string, n int, height func() types.BlockHeight, w modules.Wallet) (*Harness, error) {
+	hh := &Harness{}
+	for i := 0; i < n; i++ {
+		h, err := New(filepath.Join(dir, "host"+strconv.Itoa(i)), height, w)
+		if err != nil {
+			return nil, errors.Compose(errors.AddContext(err, "unable to create host"), hh.Close())
+		}
+		hh.Hosts = append(hh.Hosts, h)
+	}
+	return hh, nil
+}
+
+// Announce submits a transaction announcing all hosts of the harness to the
+// transaction pool. The transaction is funded by w.
+func (hh *Harness) Announce(w modules.Wallet, tp modules.TransactionPool) (err error) {
+	txnBuilder, err := w.StartTransaction()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			txnBuilder.Drop()
+		}
+	}()
+	_, fee := tp.FeeEstimation()
+	fee = fee.Mul64(announcementTxnSize * uint64(len(hh.Hosts)))
+	if err := txnBuilder.FundSiacoins(fee); err != nil {
+		return err
+	}
+	_ = txnBuilder.AddMinerFee(fee)
+	for _, h := range hh.Hosts {
+		announcement, err := h.Announcement()
+		if err != nil {
+			return err
+		}
+		_ = txnBuilder.AddArbitraryData(announcement)
+	}
+	txnSet, err := txnBuilder.Sign(true)
+	if err != nil {
+		return err
+	}
+	return tp.AcceptTransactionSet(txnSet)
+}
+
+// Host returns the host with the given public key.
+func (hh *Harness) Host(pk types.SiaPublicKey) (*Host, bool) {
+	for _, h := range hh.Hosts {
+		if h.PublicKey().Equals(pk) {
+			return h, true
+		}
+	}
+	return nil, false
+}
+
+// Close shuts down all hosts of the harness.
+func (hh *Harness) Close() error {
+	var errs []error
+	for _, h := range hh.Hosts {
+		errs = append(errs, h.Close())
+	}
+	return errors.Compose(errs...)
+}
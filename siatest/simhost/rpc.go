@@ -0,0 +1,677 @@
+package simhost
+
+import (
+	"crypto/cipher"
+	"encoding/json"
+	"math"
+	"net"
+	"sort"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"gitlab.com/NebulousLabs/siamux"
+	"golang.org/x/crypto/chacha20poly1305"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// rpc.go contains the RPCs of a simulated host. They follow the RPCs of the
+// real host in modules/host but skip storage proofs and everything else that
+// involves the blockchain besides funding the host's collateral. The host
+// doesn't check the payments of the renter either, it only makes sure that the
+// revisions it signs are valid.
+
+// A session is an RPC session with a renter.
+type session struct {
+	conn      net.Conn
+	aead      cipher.AEAD
+	challenge [16]byte
+
+	// contractID is the contract locked by the session, if any.
+	contractID types.FileContractID
+	locked     bool
+}
+
+// readRequest reads an encrypted RPC request from the renter.
+func (s *session) readRequest(req interface{}, maxLen uint64) error {
+	return modules.ReadRPCRequest(s.conn, s.aead, req, maxLen)
+}
+
+// readResponse reads an encrypted RPC response from the renter.
+func (s *session) readResponse(resp interface{}, maxLen uint64) error {
+	return modules.ReadRPCResponse(s.conn, s.aead, resp, maxLen)
+}
+
+// writeResponse sends an encrypted RPC response to the renter.
+func (s *session) writeResponse(resp interface{}) error {
+	return modules.WriteRPCResponse(s.conn, s.aead, resp, nil)
+}
+
+// writeError sends an encrypted RPC error to the renter and returns the
+// error.
+func (s *session) writeError(err error) error {
+	return errors.Compose(err, modules.WriteRPCResponse(s.conn, s.aead, nil, err))
+}
+
+// managedRPCLoop performs the handshake of the RPC loop and handles RPCs until
+// the renter sends modules.RPCLoopExit or an RPC fails.
+func (h *Host) managedRPCLoop(conn net.Conn) error {
+	// Read the renter's half of the key exchange.
+	var req modules.LoopKeyExchangeRequest
+	if err := encoding.NewDecoder(conn, encoding.DefaultAllocLimit).Decode(&req); err != nil {
+		return err
+	}
+	var supportsChaCha bool
+	for _, c := range req.Ciphers {
+		supportsChaCha = supportsChaCha || c == modules.CipherChaCha20Poly1305
+	}
+	if !supportsChaCha {
+		encoding.NewEncoder(conn).Encode(modules.LoopKeyExchangeResponse{
+			Cipher: modules.CipherNoOverlap,
+		})
+		return errors.New("no supported ciphers")
+	}
+
+	// Send our half of the key exchange and derive the shared secret.
+	xsk, xpk := crypto.GenerateX25519KeyPair()
+	pubkeySig := crypto.SignHash(crypto.HashAll(req.PublicKey, xpk), h.secretKey)
+	cipherKey := crypto.DeriveSharedSecret(xsk, req.PublicKey)
+	resp := modules.LoopKeyExchangeResponse{
+		Cipher:    modules.CipherChaCha20Poly1305,
+		PublicKey: xpk,
+		Signature: pubkeySig[:],
+	}
+	if err := encoding.NewEncoder(conn).Encode(resp); err != nil {
+		return err
+	}
+	aead, err := chacha20poly1305.New(cipherKey[:])
+	if err != nil {
+		return err
+	}
+	s := &session{
+		conn: conn,
+		aead: aead,
+	}
+	fastrand.Read(s.challenge[:])
+	if err := modules.WriteRPCMessage(conn, aead, modules.LoopChallengeRequest{Challenge: s.challenge}); err != nil {
+		return err
+	}
+	defer h.managedUnlockContract(s)
+
+	rpcs := map[types.Specifier]func(*session) error{
+		modules.RPCLoopSettings:           h.managedRPCLoopSettings,
+		modules.RPCLoopLock:               h.managedRPCLoopLock,
+		modules.RPCLoopUnlock:             h.managedRPCLoopUnlock,
+		modules.RPCLoopFormContract:       h.managedRPCLoopFormContract,
+		modules.RPCLoopRenewClearContract: h.managedRPCLoopRenewAndClearContract,
+		modules.RPCLoopWrite:              h.managedRPCLoopWrite,
+	}
+	for {
+		conn.SetDeadline(time.Now().Add(rpcDeadline))
+		id, err := modules.ReadRPCID(conn, aead)
+		if err != nil {
+			return err
+		} else if id == modules.RPCLoopExit {
+			return nil
+		}
+		rpcFn, ok := rpcs[id]
+		if !ok {
+			return s.writeError(errors.New("rpc not supported by simulated host: " + id.String()))
+		}
+		if err := h.managedStartRPC(id); err != nil {
+			return s.writeError(err)
+		}
+		if err := rpcFn(s); err != nil {
+			return err
+		}
+	}
+}
+
+// managedRPCLoopSettings sends the host's settings to the renter.
+func (h *Host) managedRPCLoopSettings(s *session) error {
+	js, err := json.Marshal(h.Settings())
+	if err != nil {
+		return s.writeError(err)
+	}
+	return s.writeResponse(modules.LoopSettingsResponse{Settings: js})
+}
+
+// managedRPCLoopLock locks a contract for the session. If the contract is
+// locked by another session, the host waits for it until the renter's timeout
+// expires.
+func (h *Host) managedRPCLoopLock(s *session) error {
+	// Challenges can only be used once.
+	challenge := s.challenge
+	fastrand.Read(s.challenge[:])
+
+	var req modules.LoopLockRequest
+	if err := s.readRequest(&req, modules.RPCMinLen); err != nil {
+		return s.writeError(err)
+	}
+	if s.locked {
+		return s.writeError(errors.New("another contract is already locked"))
+	}
+
+	deadline := time.Now().Add(time.Duration(req.Timeout) * time.Millisecond)
+	for {
+		h.mu.Lock()
+		c, exists := h.contracts[req.ContractID]
+		if !exists {
+			h.mu.Unlock()
+			return s.writeError(errUnknownContract)
+		}
+		var renterPK crypto.PublicKey
+		var renterSig crypto.Signature
+		copy(renterPK[:], c.revision.UnlockConditions.PublicKeys[0].Key)
+		copy(renterSig[:], req.Signature)
+		if crypto.VerifyHash(crypto.HashAll(modules.RPCChallengePrefix, challenge), renterPK, renterSig) != nil {
+			h.mu.Unlock()
+			return s.writeError(errors.New("challenge signature is invalid"))
+		}
+		if !c.locked || time.Now().After(deadline) {
+			acquired := !c.locked
+			if acquired {
+				c.locked = true
+				s.contractID, s.locked = req.ContractID, true
+			}
+			resp := modules.LoopLockResponse{
+				Acquired:     acquired,
+				NewChallenge: s.challenge,
+				Revision:     c.revision,
+				Signatures:   c.signatures,
+			}
+			h.mu.Unlock()
+			return s.writeResponse(resp)
+		}
+		h.mu.Unlock()
+
+		select {
+		case <-time.After(10 * time.Millisecond):
+		case <-h.tg.StopChan():
+			return s.writeError(errors.New("host is shutting down"))
+		}
+	}
+}
+
+// managedRPCLoopUnlock unlocks the contract of the session. No response is
+// sent.
+func (h *Host) managedRPCLoopUnlock(s *session) error {
+	h.managedUnlockContract(s)
+	return nil
+}
+
+// managedRPCLoopFormContract forms a new contract with the renter. The host
+// adds its collateral to the contract but leaves submitting the contract to
+// the renter.
+func (h *Host) managedRPCLoopFormContract(s *session) (err error) {
+	var req modules.LoopFormContractRequest
+	if err := s.readRequest(&req, modules.TransactionSetSizeLimit); err != nil {
+		return s.writeError(err)
+	}
+	settings := h.Settings()
+	if !settings.AcceptingContracts {
+		return s.writeError(errors.New("host is not accepting new contracts"))
+	}
+	fc, err := staticFileContract(req.Transactions)
+	if err != nil {
+		return s.writeError(err)
+	}
+	if fc.ValidHostPayout().Cmp(settings.ContractPrice) < 0 {
+		return s.writeError(errors.New("host payout doesn't cover the contract price"))
+	}
+	collateral := fc.ValidHostPayout().Sub(settings.ContractPrice)
+
+	builder, additions, err := h.managedAddCollateral(req.Transactions, collateral)
+	if err != nil {
+		return s.writeError(err)
+	}
+	defer func() {
+		if err != nil && builder != nil {
+			builder.Drop()
+		}
+	}()
+	rev, err := h.staticInitialRevision(builder, req.Transactions, req.RenterKey)
+	if err != nil {
+		return s.writeError(err)
+	}
+	if err := s.writeResponse(additions); err != nil {
+		return err
+	}
+	var renterSigs modules.LoopContractSignatures
+	if err := s.readResponse(&renterSigs, modules.RPCMinLen); err != nil {
+		return s.writeError(err)
+	}
+	contractSigs, err := staticSignCollateral(builder, renterSigs.ContractSignatures)
+	if err != nil {
+		return s.writeError(err)
+	}
+	sigs, err := h.staticSignRevision(rev, renterSigs.RevisionSignature)
+	if err != nil {
+		return s.writeError(err)
+	}
+
+	h.mu.Lock()
+	h.contracts[rev.ParentID] = &contract{
+		revision:   rev,
+		signatures: sigs,
+	}
+	h.mu.Unlock()
+	return s.writeResponse(modules.LoopContractSignatures{
+		ContractSignatures: contractSigs,
+		RevisionSignature:  sigs[1],
+	})
+}
+
+// managedRPCLoopRenewAndClearContract renews the locked contract and clears
+// it. The sectors of the old contract are moved to the new one.
+func (h *Host) managedRPCLoopRenewAndClearContract(s *session) (err error) {
+	var req modules.LoopRenewAndClearContractRequest
+	if err := s.readRequest(&req, modules.TransactionSetSizeLimit); err != nil {
+		return s.writeError(err)
+	}
+	if !s.locked {
+		return s.writeError(errNoContractLocked)
+	}
+	settings := h.Settings()
+	if !settings.AcceptingContracts {
+		return s.writeError(errors.New("host is not accepting new contracts"))
+	}
+	fc, err := staticFileContract(req.Transactions)
+	if err != nil {
+		return s.writeError(err)
+	}
+	h.mu.Lock()
+	current := h.contracts[s.contractID].revision
+	h.mu.Unlock()
+
+	// The renter pays for storing the existing data during the extension of
+	// the contract, the rest of the host's payout is its collateral.
+	var basePrice types.Currency
+	if fc.WindowEnd > current.NewWindowEnd {
+		basePrice = settings.StoragePrice.Mul64(fc.FileSize).Mul64(uint64(fc.WindowEnd - current.NewWindowEnd))
+	}
+	if fc.ValidHostPayout().Cmp(settings.ContractPrice.Add(basePrice)) < 0 {
+		return s.writeError(errors.New("host payout doesn't cover the contract price and base price"))
+	}
+	collateral := fc.ValidHostPayout().Sub(settings.ContractPrice).Sub(basePrice)
+
+	builder, additions, err := h.managedAddCollateral(req.Transactions, collateral)
+	if err != nil {
+		return s.writeError(err)
+	}
+	defer func() {
+		if err != nil && builder != nil {
+			builder.Drop()
+		}
+	}()
+	rev, err := h.staticInitialRevision(builder, req.Transactions, req.RenterKey)
+	if err != nil {
+		return s.writeError(err)
+	}
+
+	// Prepare the final revision of the old contract.
+	if len(req.FinalValidProofValues) != len(current.NewValidProofOutputs) {
+		return s.writeError(errors.New("wrong number of valid proof values"))
+	}
+	finalRev := current
+	finalRev.NewRevisionNumber = math.MaxUint64
+	finalRev.NewFileMerkleRoot = crypto.Hash{}
+	finalRev.NewFileSize = 0
+	finalRev.NewValidProofOutputs = make([]types.SiacoinOutput, len(current.NewValidProofOutputs))
+	for i, o := range current.NewValidProofOutputs {
+		finalRev.NewValidProofOutputs[i] = types.SiacoinOutput{
+			Value:      req.FinalValidProofValues[i],
+			UnlockHash: o.UnlockHash,
+		}
+	}
+	finalRev.NewMissedProofOutputs = finalRev.NewValidProofOutputs
+
+	if err := s.writeResponse(additions); err != nil {
+		return err
+	}
+	var renterSigs modules.LoopRenewAndClearContractSignatures
+	if err := s.readResponse(&renterSigs, modules.RPCMinLen); err != nil {
+		return s.writeError(err)
+	}
+	contractSigs, err := staticSignCollateral(builder, renterSigs.ContractSignatures)
+	if err != nil {
+		return s.writeError(err)
+	}
+	finalSigs, err := h.staticSignRevision(finalRev, types.TransactionSignature{
+		ParentID:       crypto.Hash(finalRev.ParentID),
+		CoveredFields:  types.CoveredFields{FileContractRevisions: []uint64{0}},
+		PublicKeyIndex: 0,
+		Signature:      renterSigs.FinalRevisionSignature,
+	})
+	if err != nil {
+		return s.writeError(err)
+	}
+	sigs, err := h.staticSignRevision(rev, renterSigs.RevisionSignature)
+	if err != nil {
+		return s.writeError(err)
+	}
+
+	h.mu.Lock()
+	old := h.contracts[s.contractID]
+	h.contracts[rev.ParentID] = &contract{
+		revision:   rev,
+		signatures: sigs,
+		roots:      old.roots,
+	}
+	old.revision = finalRev
+	old.signatures = finalSigs
+	old.roots = nil
+	h.mu.Unlock()
+	return s.writeResponse(modules.LoopRenewAndClearContractSignatures{
+		ContractSignatures:     contractSigs,
+		RevisionSignature:      sigs[1],
+		FinalRevisionSignature: finalSigs[1].Signature,
+	})
+}
+
+// managedRPCLoopWrite applies the actions of a Write RPC to the locked
+// contract.
+func (h *Host) managedRPCLoopWrite(s *session) error {
+	var req modules.LoopWriteRequest
+	if err := s.readRequest(&req, modules.SectorSize*5); err != nil {
+		return s.writeError(err)
+	}
+	var sigResponse modules.LoopWriteResponse
+	if !req.MerkleProof {
+		if err := s.readResponse(&sigResponse, modules.RPCMinLen); err != nil {
+			return err
+		}
+	}
+	if !s.locked {
+		return s.writeError(errNoContractLocked)
+	}
+	h.mu.Lock()
+	c := h.contracts[s.contractID]
+	current, oldRoots := c.revision, c.roots
+	h.mu.Unlock()
+
+	// Apply the actions.
+	newRoots := append([]crypto.Hash(nil), oldRoots...)
+	sectorsChanged := make(map[uint64]struct{})
+	sectorsGained := make(map[crypto.Hash][]byte)
+	for _, action := range req.Actions {
+		switch action.Type {
+		case modules.WriteActionAppend:
+			if uint64(len(action.Data)) != modules.SectorSize {
+				return s.writeError(errors.New("sector has the wrong size"))
+			}
+			root := crypto.MerkleRoot(action.Data)
+			newRoots = append(newRoots, root)
+			sectorsGained[root] = action.Data
+			sectorsChanged[uint64(len(newRoots))-1] = struct{}{}
+
+		case modules.WriteActionTrim:
+			if uint64(len(newRoots)) < action.A {
+				return s.writeError(errors.New("trim size exceeds number of sectors"))
+			}
+			newRoots = newRoots[:uint64(len(newRoots))-action.A]
+			sectorsChanged[uint64(len(newRoots))] = struct{}{}
+
+		case modules.WriteActionSwap:
+			i, j := action.A, action.B
+			if i >= uint64(len(newRoots)) || j >= uint64(len(newRoots)) {
+				return s.writeError(errors.New("illegal sector index"))
+			}
+			newRoots[i], newRoots[j] = newRoots[j], newRoots[i]
+			sectorsChanged[i] = struct{}{}
+			sectorsChanged[j] = struct{}{}
+
+		default:
+			return s.writeError(errors.New("action not supported by simulated host: " + action.Type.String()))
+		}
+	}
+	newMerkleRoot := merkleRoot(newRoots)
+
+	// Send the Merkle proof of the changes if the renter requested it.
+	if req.MerkleProof {
+		oldNumSectors := uint64(len(oldRoots))
+		proofRanges := make([]crypto.ProofRange, 0, len(sectorsChanged))
+		for index := range sectorsChanged {
+			if index < oldNumSectors {
+				proofRanges = append(proofRanges, crypto.ProofRange{Start: index, End: index + 1})
+			}
+		}
+		sort.Slice(proofRanges, func(i, j int) bool {
+			return proofRanges[i].Start < proofRanges[j].Start
+		})
+		leafHashes := make([]crypto.Hash, len(proofRanges))
+		for i, r := range proofRanges {
+			leafHashes[i] = oldRoots[r.Start]
+		}
+		merkleResp := modules.LoopWriteMerkleProof{
+			OldSubtreeHashes: crypto.MerkleDiffProof(proofRanges, oldNumSectors, nil, oldRoots),
+			OldLeafHashes:    leafHashes,
+			NewMerkleRoot:    newMerkleRoot,
+		}
+		if err := s.writeResponse(merkleResp); err != nil {
+			return err
+		} else if err := s.readResponse(&sigResponse, modules.RPCMinLen); err != nil {
+			return err
+		}
+	}
+
+	// Construct the new revision and sign it.
+	if len(req.NewValidProofValues) != len(current.NewValidProofOutputs) || len(req.NewMissedProofValues) != len(current.NewMissedProofOutputs) {
+		return s.writeError(errors.New("wrong number of proof values"))
+	}
+	newRevision := current
+	newRevision.NewRevisionNumber = req.NewRevisionNumber
+	newRevision.NewFileSize = uint64(len(newRoots)) * modules.SectorSize
+	newRevision.NewFileMerkleRoot = newMerkleRoot
+	newRevision.NewValidProofOutputs = make([]types.SiacoinOutput, len(current.NewValidProofOutputs))
+	for i, o := range current.NewValidProofOutputs {
+		newRevision.NewValidProofOutputs[i] = types.SiacoinOutput{Value: req.NewValidProofValues[i], UnlockHash: o.UnlockHash}
+	}
+	newRevision.NewMissedProofOutputs = make([]types.SiacoinOutput, len(current.NewMissedProofOutputs))
+	for i, o := range current.NewMissedProofOutputs {
+		newRevision.NewMissedProofOutputs[i] = types.SiacoinOutput{Value: req.NewMissedProofValues[i], UnlockHash: o.UnlockHash}
+	}
+	if newRevision.NewRevisionNumber <= current.NewRevisionNumber {
+		return s.writeError(errors.New("revision number must increase"))
+	}
+	sigs, err := h.staticSignRevision(newRevision, types.TransactionSignature{
+		ParentID:       crypto.Hash(newRevision.ParentID),
+		CoveredFields:  types.CoveredFields{FileContractRevisions: []uint64{0}},
+		PublicKeyIndex: 0,
+		Signature:      sigResponse.Signature,
+	})
+	if err != nil {
+		return s.writeError(err)
+	}
+
+	h.mu.Lock()
+	c.revision = newRevision
+	c.signatures = sigs
+	c.roots = newRoots
+	for root, sector := range sectorsGained {
+		h.sectors[root] = sector
+	}
+	h.mu.Unlock()
+	return s.writeResponse(modules.LoopWriteResponse{Signature: sigs[1].Signature})
+}
+
+// managedRPCUpdatePriceTable sends the host's price table to the renter.
+func (h *Host) managedRPCUpdatePriceTable(stream siamux.Stream) error {
+	if err := h.managedStartRPC(modules.RPCUpdatePriceTable); err != nil {
+		return modules.RPCWriteError(stream, err)
+	}
+	settings := h.Settings()
+	pt := modules.RPCPriceTable{
+		Validity:        priceTableValidity,
+		HostBlockHeight: h.staticHeight(),
+
+		UpdatePriceTableCost:  settings.BaseRPCPrice,
+		InitBaseCost:          settings.BaseRPCPrice,
+		DownloadBandwidthCost: settings.DownloadBandwidthPrice,
+		UploadBandwidthCost:   settings.UploadBandwidthPrice,
+		ReadBaseCost:          settings.SectorAccessPrice,
+		WriteStoreCost:        settings.StoragePrice,
+
+		ContractPrice:  settings.ContractPrice,
+		CollateralCost: settings.Collateral,
+		MaxCollateral:  settings.MaxCollateral,
+		MaxDuration:    settings.MaxDuration,
+		WindowSize:     settings.WindowSize,
+	}
+	fastrand.Read(pt.UID[:])
+	ptBytes, err := json.Marshal(pt)
+	if err != nil {
+		return err
+	}
+	return modules.RPCWrite(stream, modules.RPCUpdatePriceTableResponse{PriceTableJSON: ptBytes})
+}
+
+// managedUnlockContract unlocks the contract locked by the session.
+func (h *Host) managedUnlockContract(s *session) {
+	if !s.locked {
+		return
+	}
+	h.mu.Lock()
+	if c, exists := h.contracts[s.contractID]; exists {
+		c.locked = false
+	}
+	h.mu.Unlock()
+	s.contractID, s.locked = types.FileContractID{}, false
+}
+
+// managedAddCollateral funds the host's collateral for the contract in a
+// transaction set sent by the renter. It returns the builder of the contract
+// transaction, which is nil if the host has no wallet, and the inputs and
+// outputs the host added.
+func (h *Host) managedAddCollateral(txnSet []types.Transaction, collateral types.Currency) (modules.TransactionBuilder, modules.LoopContractAdditions, error) {
+	if h.staticWallet == nil {
+		return nil, modules.LoopContractAdditions{}, nil
+	}
+	builder, err := h.staticWallet.RegisterTransaction(txnSet[len(txnSet)-1], txnSet[:len(txnSet)-1])
+	if err != nil {
+		return nil, modules.LoopContractAdditions{}, err
+	}
+	if collateral.IsZero() {
+		return builder, modules.LoopContractAdditions{}, nil
+	}
+	if err := builder.FundSiacoins(collateral); err != nil {
+		builder.Drop()
+		return nil, modules.LoopContractAdditions{}, errors.AddContext(err, "could not add collateral")
+	}
+
+	var additions modules.LoopContractAdditions
+	parentIndices, inputIndices, outputIndices, _ := builder.ViewAdded()
+	txn, parents := builder.View()
+	for _, i := range parentIndices {
+		additions.Parents = append(additions.Parents, parents[i])
+	}
+	for _, i := range inputIndices {
+		additions.Inputs = append(additions.Inputs, txn.SiacoinInputs[i])
+	}
+	for _, i := range outputIndices {
+		additions.Outputs = append(additions.Outputs, txn.SiacoinOutputs[i])
+	}
+	return builder, additions, nil
+}
+
+// staticSignCollateral adds the renter's signatures to the contract
+// transaction and signs the inputs the host added. It returns the host's
+// signatures.
+func staticSignCollateral(builder modules.TransactionBuilder, renterSigs []types.TransactionSignature) ([]types.TransactionSignature, error) {
+	if builder == nil {
+		return nil, nil
+	}
+	for _, sig := range renterSigs {
+		builder.AddTransactionSignature(sig)
+	}
+	txnSet, err := builder.Sign(true)
+	if err != nil {
+		return nil, err
+	}
+	txn := txnSet[len(txnSet)-1]
+	var sigs []types.TransactionSignature
+	_, _, _, sigIndices := builder.ViewAdded()
+	for _, i := range sigIndices {
+		sigs = append(sigs, txn.TransactionSignatures[i])
+	}
+	return sigs, nil
+}
+
+// staticFileContract returns the file contract in the last transaction of a
+// transaction set sent by the renter.
+func staticFileContract(txnSet []types.Transaction) (types.FileContract, error) {
+	if len(txnSet) == 0 || len(txnSet[len(txnSet)-1].FileContracts) != 1 {
+		return types.FileContract{}, errors.New("transaction set doesn't contain a file contract")
+	}
+	return txnSet[len(txnSet)-1].FileContracts[0], nil
+}
+
+// staticInitialRevision returns the initial revision of the contract in a
+// transaction set sent by the renter. If the host added collateral, the
+// contract transaction is taken from the builder since the contract's ID
+// depends on the host's inputs and outputs.
+func (h *Host) staticInitialRevision(builder modules.TransactionBuilder, txnSet []types.Transaction, renterKey types.SiaPublicKey) (types.FileContractRevision, error) {
+	txn := txnSet[len(txnSet)-1]
+	if builder != nil {
+		txn, _ = builder.View()
+	}
+	fc := txn.FileContracts[0]
+	uc := types.UnlockConditions{
+		PublicKeys:         []types.SiaPublicKey{renterKey, h.publicKey},
+		SignaturesRequired: 2,
+	}
+	if fc.UnlockHash != uc.UnlockHash() {
+		return types.FileContractRevision{}, errors.New("file contract has the wrong unlock hash")
+	}
+	return types.FileContractRevision{
+		ParentID:          txn.FileContractID(0),
+		UnlockConditions:  uc,
+		NewRevisionNumber: 1,
+
+		NewFileSize:           fc.FileSize,
+		NewFileMerkleRoot:     fc.FileMerkleRoot,
+		NewWindowStart:        fc.WindowStart,
+		NewWindowEnd:          fc.WindowEnd,
+		NewValidProofOutputs:  fc.ValidProofOutputs,
+		NewMissedProofOutputs: fc.MissedProofOutputs,
+		NewUnlockHash:         fc.UnlockHash,
+	}, nil
+}
+
+// staticSignRevision adds the host's signature to the renter's signature of a
+// revision and verifies both.
+func (h *Host) staticSignRevision(rev types.FileContractRevision, renterSig types.TransactionSignature) ([]types.TransactionSignature, error) {
+	height := h.staticHeight()
+	txn := types.Transaction{
+		FileContractRevisions: []types.FileContractRevision{rev},
+		TransactionSignatures: []types.TransactionSignature{renterSig, {
+			ParentID:       crypto.Hash(rev.ParentID),
+			PublicKeyIndex: 1,
+			CoveredFields:  types.CoveredFields{FileContractRevisions: []uint64{0}},
+		}},
+	}
+	sig := crypto.SignHash(txn.SigHash(1, height), h.secretKey)
+	txn.TransactionSignatures[1].Signature = sig[:]
+	if err := modules.VerifyFileContractRevisionTransactionSignatures(rev, txn.TransactionSignatures, height); err != nil {
+		return nil, errors.AddContext(err, "invalid revision signatures")
+	}
+	return txn.TransactionSignatures, nil
+}
+
+// merkleRoot returns the Merkle root of a contract with the given sector
+// roots.
+func merkleRoot(roots []crypto.Hash) crypto.Hash {
+	log2SectorSize := uint64(0)
+	for 1<<log2SectorSize < (modules.SectorSize / crypto.SegmentSize) {
+		log2SectorSize++
+	}
+	ct := crypto.NewCachedTree(log2SectorSize)
+	for _, root := range roots {
+		ct.PushSubTree(0, root)
+	}
+	return ct.Root()
+}
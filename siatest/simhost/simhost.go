@@ -0,0 +1,341 @@
+// Package simhost provides simulated hosts for testing the renter's
+// interactions with hosts. A simulated host speaks the renter-host protocol
+// over a real loopback connection but keeps its contracts and sectors in
+// memory and never submits anything to the blockchain. This allows tests to
+// spin up dozens of hosts with configurable prices, failures and latencies
+// within a single process.
+//
+// Simulated hosts support the RPCs the contractor uses for scanning, forming,
+// renewing and uploading to contracts. They fund their collateral from a
+// wallet shared by all simulated hosts. Without a wallet they advertise zero
+// collateral, which makes the hostdb unlikely to select them. They don't
+// support downloads or the RPCs of RHP3 besides fetching the price table.
+package simhost
+
+import (
+	"net"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/siamux"
+	"gitlab.com/NebulousLabs/threadgroup"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// ErrSimulatedFailure is the error a simulated host returns for RPCs
+	// which were configured to fail.
+	ErrSimulatedFailure = errors.New("simulated host failure")
+
+	// errNoContractLocked is returned by RPCs which require a locked
+	// contract.
+	errNoContractLocked = errors.New("no contract locked")
+
+	// errUnknownContract is returned if the renter tries to lock a contract
+	// the host doesn't know.
+	errUnknownContract = errors.New(modules.V1420ContractNotRecognizedErrString)
+)
+
+const (
+	// simulatedStorage is the total and remaining storage advertised by a
+	// simulated host.
+	simulatedStorage = 1 << 40
+
+	// rpcDeadline is the deadline of a single RPC.
+	rpcDeadline = 2 * time.Minute
+
+	// priceTableValidity is the validity of the host's price tables.
+	priceTableValidity = time.Minute
+)
+
+type (
+	// Host is a simulated host.
+	Host struct {
+		listener     net.Listener
+		mux          *siamux.SiaMux
+		publicKey    types.SiaPublicKey
+		secretKey    crypto.SecretKey
+		staticHeight func() types.BlockHeight
+		staticWallet modules.Wallet
+
+		contracts map[types.FileContractID]*contract
+		sectors   map[crypto.Hash][]byte
+		settings  modules.HostExternalSettings
+
+		// The behavior of the host can be changed at any time to simulate
+		// failing or slow hosts.
+		failures map[types.Specifier]bool
+		latency  time.Duration
+		offline  bool
+		rpcCalls map[types.Specifier]uint64
+
+		mu sync.Mutex
+		tg threadgroup.ThreadGroup
+	}
+
+	// contract is a contract of a simulated host.
+	contract struct {
+		revision   types.FileContractRevision
+		signatures []types.TransactionSignature
+		roots      []crypto.Hash
+		locked     bool
+	}
+)
+
+// New creates a simulated host listening on a random loopback port. dir is
+// used to persist the host's siamux, height returns the current block height
+// which the host uses to sign revisions. The host's collateral is funded by w,
+// which may be nil.
+func New(dir string, height func() types.BlockHeight, w modules.Wallet) (*Host, error) {
+	mux, err := modules.NewSiaMux(filepath.Join(dir, modules.SiaMuxDir), dir, "localhost:0", "localhost:0")
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to create siamux")
+	}
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		return nil, errors.Compose(err, mux.Close())
+	}
+
+	// The siamux authenticates the host with its key, so the host uses the
+	// keys of the siamux as its own.
+	mpk, msk := mux.PublicKey(), mux.PrivateKey()
+	h := &Host{
+		listener:     listener,
+		mux:          mux,
+		publicKey:    types.Ed25519PublicKey(crypto.PublicKey(mpk)),
+		secretKey:    crypto.SecretKey(msk),
+		staticHeight: height,
+		staticWallet: w,
+
+		contracts: make(map[types.FileContractID]*contract),
+		sectors:   make(map[crypto.Hash][]byte),
+		failures:  make(map[types.Specifier]bool),
+		rpcCalls:  make(map[types.Specifier]uint64),
+	}
+
+	// Initialize the settings.
+	_, muxPort, err := net.SplitHostPort(mux.Address().String())
+	if err != nil {
+		return nil, errors.Compose(err, listener.Close(), mux.Close())
+	}
+	h.settings = modules.DefaultHostExternalSettings()
+	h.settings.NetAddress = modules.NetAddress("127.0.0.1:" + strconv.Itoa(listener.Addr().(*net.TCPAddr).Port))
+	h.settings.SiaMuxPort = muxPort
+	if w == nil {
+		h.settings.Collateral = types.ZeroCurrency
+		h.settings.MaxCollateral = types.ZeroCurrency
+	}
+	h.settings.MaxWriteStreamSectors = 0
+	h.settings.RemainingStorage = simulatedStorage
+	h.settings.TotalStorage = simulatedStorage
+	h.settings.UnlockHash = types.UnlockConditions{
+		PublicKeys:         []types.SiaPublicKey{h.publicKey},
+		SignaturesRequired: 1,
+	}.UnlockHash()
+
+	// Start serving.
+	err = mux.NewListener(modules.HostSiaMuxSubscriberName, h.threadedHandleStream)
+	if err != nil {
+		return nil, errors.Compose(err, listener.Close(), mux.Close())
+	}
+	err = h.tg.OnStop(func() error {
+		return errors.Compose(listener.Close(), mux.Close())
+	})
+	if err != nil {
+		return nil, errors.Compose(err, listener.Close(), mux.Close())
+	}
+	go h.threadedListen()
+	return h, nil
+}
+
+// Close shuts down the host.
+func (h *Host) Close() error {
+	return h.tg.Stop()
+}
+
+// Announcement returns the signed announcement of the host.
+func (h *Host) Announcement() ([]byte, error) {
+	return modules.CreateAnnouncement(h.NetAddress(), h.publicKey, h.secretKey)
+}
+
+// NetAddress returns the address of the host.
+func (h *Host) NetAddress() modules.NetAddress {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.settings.NetAddress
+}
+
+// PublicKey returns the public key of the host.
+func (h *Host) PublicKey() types.SiaPublicKey {
+	return h.publicKey
+}
+
+// Settings returns the settings the host reports to renters.
+func (h *Host) Settings() modules.HostExternalSettings {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.settings
+}
+
+// UpdateSettings changes the settings the host reports to renters, e.g. its
+// prices.
+func (h *Host) UpdateSettings(update func(*modules.HostExternalSettings)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	update(&h.settings)
+	h.settings.RevisionNumber++
+}
+
+// SetFailure makes the RPC with the given id fail with ErrSimulatedFailure
+// until it is reset.
+func (h *Host) SetFailure(id types.Specifier, fail bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.failures[id] = fail
+}
+
+// SetLatency delays the response to every RPC by d.
+func (h *Host) SetLatency(d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.latency = d
+}
+
+// SetOffline simulates a host which is offline by closing all incoming
+// connections without responding.
+func (h *Host) SetOffline(offline bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.offline = offline
+}
+
+// RPCCalls returns the number of calls of the RPC with the given id.
+func (h *Host) RPCCalls(id types.Specifier) uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.rpcCalls[id]
+}
+
+// Contracts returns the latest revisions of the host's contracts.
+func (h *Host) Contracts() []types.FileContractRevision {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	revs := make([]types.FileContractRevision, 0, len(h.contracts))
+	for _, c := range h.contracts {
+		revs = append(revs, c.revision)
+	}
+	return revs
+}
+
+// Sector returns the sector with the given root if the host stores it.
+func (h *Host) Sector(root crypto.Hash) ([]byte, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	sector, ok := h.sectors[root]
+	return sector, ok
+}
+
+// managedStartRPC records a call of an RPC and simulates the host's latency.
+// It returns an error if the RPC is configured to fail.
+func (h *Host) managedStartRPC(id types.Specifier) error {
+	h.mu.Lock()
+	h.rpcCalls[id]++
+	latency, fail := h.latency, h.failures[id]
+	h.mu.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-h.tg.StopChan():
+			return errors.New("host is shutting down")
+		}
+	}
+	if fail {
+		return ErrSimulatedFailure
+	}
+	return nil
+}
+
+// threadedListen accepts incoming connections until the host is closed.
+func (h *Host) threadedListen() {
+	for {
+		conn, err := h.listener.Accept()
+		if err != nil {
+			return
+		}
+		go h.threadedHandleConn(conn)
+	}
+}
+
+// threadedHandleConn handles an incoming connection. Only the RPC loop of
+// RHP2 is supported.
+func (h *Host) threadedHandleConn(conn net.Conn) {
+	defer conn.Close()
+	if err := h.tg.Add(); err != nil {
+		return
+	}
+	defer h.tg.Done()
+
+	h.mu.Lock()
+	offline := h.offline
+	h.mu.Unlock()
+	if offline {
+		return
+	}
+
+	// Close the connection when the host shuts down.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-h.tg.StopChan():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	conn.SetDeadline(time.Now().Add(rpcDeadline))
+	var id types.Specifier
+	if err := encoding.NewDecoder(conn, encoding.DefaultAllocLimit).Decode(&id); err != nil {
+		return
+	}
+	if id != modules.RPCLoopEnter {
+		return
+	}
+	_ = h.managedRPCLoop(conn)
+}
+
+// threadedHandleStream handles an incoming siamux stream. Only the price table
+// can be fetched, the host never accepts a payment for it.
+func (h *Host) threadedHandleStream(stream siamux.Stream) {
+	defer stream.Close()
+	if err := h.tg.Add(); err != nil {
+		return
+	}
+	defer h.tg.Done()
+
+	h.mu.Lock()
+	offline := h.offline
+	h.mu.Unlock()
+	if offline {
+		return
+	}
+
+	stream.SetDeadline(time.Now().Add(rpcDeadline))
+	var id types.Specifier
+	if err := modules.RPCRead(stream, &id); err != nil {
+		return
+	}
+	if id != modules.RPCUpdatePriceTable {
+		modules.RPCWriteError(stream, errors.New("rpc not supported by simulated host: "+id.String()))
+		return
+	}
+	_ = h.managedRPCUpdatePriceTable(stream)
+}
@@ -133,6 +133,18 @@ var (
 	// block subsidies.
 	InitialFoundationSubsidy = SiacoinPrecision.Mul64(30e3).Mul64(uint64(BlocksPerYear))
 
+	// NFTLockupUnlockConditions are the unlock conditions of the lockup pool,
+	// which holds the lockup paid by every mint. NFTStoragePoolUnlockConditions
+	// are the unlock conditions of the storage pool, which pays the hosts that
+	// store NFT data. Neither requires a signature: consensus rules govern
+	// which transactions may spend the pools.
+	NFTLockupUnlockConditions      UnlockConditions
+	NFTStoragePoolUnlockConditions UnlockConditions
+	// NFTLockupTestingSalt and NFTStoragePoolTestingSalt are the salts used
+	// to generate the keys of the pools in dev and testing builds.
+	NFTLockupTestingSalt      = "saltgennftlockup"
+	NFTStoragePoolTestingSalt = "saltgennftstoragepool"
+
 	// MaturityDelay specifies the number of blocks that a maturity-required output
 	// is required to be on hold before it can be spent on the blockchain.
 	// Outputs are maturity-required if they are highly likely to be altered or
@@ -241,6 +253,9 @@ func init() {
 		InitialFoundationUnlockHash = initialFoundationUnlockConditions.UnlockHash()
 		InitialFoundationFailsafeUnlockHash = initialFoundationFailsafeUnlockConditions.UnlockHash()
 
+		NFTLockupUnlockConditions, _ = GenerateDeterministicMultisig(0, 1, NFTLockupTestingSalt)
+		NFTStoragePoolUnlockConditions, _ = GenerateDeterministicMultisig(0, 1, NFTStoragePoolTestingSalt)
+
 		BlockFrequency = 12                      // 12 seconds: slow enough for developers to see ~each block, fast enough that blocks don't waste time.
 		MaturityDelay = 10                       // 60 seconds before a delayed output matures.
 		GenesisTimestamp = Timestamp(1424139000) // Change as necessary.
@@ -299,6 +314,9 @@ func init() {
 		InitialFoundationUnlockHash = initialFoundationUnlockConditions.UnlockHash()
 		InitialFoundationFailsafeUnlockHash = initialFoundationFailsafeUnlockConditions.UnlockHash()
 
+		NFTLockupUnlockConditions, _ = GenerateDeterministicMultisig(0, 1, NFTLockupTestingSalt)
+		NFTStoragePoolUnlockConditions, _ = GenerateDeterministicMultisig(0, 1, NFTStoragePoolTestingSalt)
+
 		BlockFrequency = 1 // As fast as possible
 		MaturityDelay = 3
 		GenesisTimestamp = CurrentTimestamp() - 1e6
@@ -373,6 +391,9 @@ func init() {
 		InitialFoundationUnlockHash = MustParseAddress("053b2def3cbdd078c19d62ce2b4f0b1a3c5e0ffbeeff01280efb1f8969b2f5bb4fdc680f0807")
 		InitialFoundationFailsafeUnlockHash = MustParseAddress("27c22a6c6e6645802a3b8fa0e5374657438ef12716d2205d3e866272de1b644dbabd53d6d560")
 
+		NFTLockupUnlockConditions = nftPoolUnlockConditions("4d652d8ce36facbf0c194a7533a7a2ee7c9c9e364af45e65cf7433e5b8496696")
+		NFTStoragePoolUnlockConditions = nftPoolUnlockConditions("171b3650f74b718fc003828e3e33a6b525f055db049bdefdc3122baba3e016e0")
+
 		// A block time of 1 block per 10 minutes is chosen to follow Bitcoin's
 		// example. The security lost by lowering the block time is not
 		// insignificant, and the convenience gained by lowering the blocktime
@@ -23,3 +23,31 @@ func TestCheckBuildConstants(t *testing.T) {
 		t.Error(build.DEBUG)
 	}
 }
+
+// TestNFTPoolUnlockConditions checks that the NFT pools of the build have
+// distinct keys and can be spent without signatures.
+func TestNFTPoolUnlockConditions(t *testing.T) {
+	lockup, storagePool := NFTLockupUnlockConditions, NFTStoragePoolUnlockConditions
+	if lockup.UnlockHash() == storagePool.UnlockHash() {
+		t.Fatal("pools share an address")
+	}
+	for _, uc := range []UnlockConditions{lockup, storagePool} {
+		if len(uc.PublicKeys) != 1 || uc.SignaturesRequired != 0 || uc.Timelock != 0 {
+			t.Fatal("unexpected pool unlock conditions", uc)
+		}
+	}
+
+	// The standard pools use fixed keys.
+	uc := nftPoolUnlockConditions("4d652d8ce36facbf0c194a7533a7a2ee7c9c9e364af45e65cf7433e5b8496696")
+	if len(uc.PublicKeys) != 1 || uc.SignaturesRequired != 0 || len(uc.PublicKeys[0].Key) != 32 {
+		t.Fatal("unexpected pool unlock conditions", uc)
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("invalid key should panic")
+			}
+		}()
+		nftPoolUnlockConditions("4d65")
+	}()
+}
@@ -266,3 +266,38 @@ func ParseCurrency(amount string) (string, error) {
 
 	return "", ErrParseCurrencyUnits
 }
+
+// CurrencyFromString parses a siacoin amount with units into a Currency.
+func CurrencyFromString(amount string) (Currency, error) {
+	hastings, err := ParseCurrency(amount)
+	if err != nil {
+		return Currency{}, err
+	}
+	i, ok := new(big.Int).SetString(hastings, 10)
+	if !ok {
+		return Currency{}, ErrParseCurrencyAmount
+	}
+	if i.Sign() < 0 {
+		return Currency{}, ErrNegativeCurrency
+	}
+	return NewCurrency(i), nil
+}
+
+// MustCurrency parses a siacoin amount with units into a Currency and panics if
+// the amount is malformed. It is meant for initializing constants, where a
+// typo must never silently result in a zero amount.
+func MustCurrency(amount string) Currency {
+	c, err := CurrencyFromString(amount)
+	if err != nil {
+		panic("invalid currency constant " + amount + ": " + err.Error())
+	}
+	return c
+}
+
+// CurrencyFromConst parses a siacoin amount with units into a Currency.
+//
+// Deprecated: use MustCurrency, or CurrencyFromString to handle malformed
+// amounts.
+func CurrencyFromConst(amount string) Currency {
+	return MustCurrency(amount)
+}
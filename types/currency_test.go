@@ -453,3 +453,40 @@ func TestParseCurrency(t *testing.T) {
 		}
 	}
 }
+
+// TestCurrencyFromString probes CurrencyFromString and MustCurrency.
+func TestCurrencyFromString(t *testing.T) {
+	tests := []struct {
+		in  string
+		out Currency
+		err error
+	}{
+		{"5000SC", SiacoinPrecision.Mul64(5000), nil},
+		{"1.5 KS", SiacoinPrecision.Mul64(1500), nil},
+		{"42H", NewCurrency64(42), nil},
+		{"5000", Currency{}, ErrParseCurrencyUnits},
+		{"50O0SC", Currency{}, ErrParseCurrencyAmount},
+		{"4xH", Currency{}, ErrParseCurrencyAmount},
+		{"-5SC", Currency{}, ErrNegativeCurrency},
+		{"1pH", Currency{}, ErrParseCurrencyAmount},
+	}
+	for _, test := range tests {
+		c, err := CurrencyFromString(test.in)
+		if err != test.err || !c.Equals(test.out) {
+			t.Errorf("CurrencyFromString(%v): expected %v %v, got %v %v", test.in, test.out, test.err, c, err)
+		}
+	}
+
+	if !MustCurrency("500SC").Equals(SiacoinPrecision.Mul64(500)) {
+		t.Fatal("wrong currency")
+	}
+	if !CurrencyFromConst("500SC").Equals(MustCurrency("500SC")) {
+		t.Fatal("CurrencyFromConst doesn't match MustCurrency")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected MustCurrency to panic")
+		}
+	}()
+	MustCurrency("500 Sc")
+}
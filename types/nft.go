@@ -1,23 +1,21 @@
 package types
 
 import (
+	"crypto/subtle"
+	"encoding/binary"
 	"encoding/hex"
-	"math/big"
+	"mime"
+	"strings"
 
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/crypto"
 )
 
 /// Contains core NFT types for internal representation of on-chain assets
 /// Author: Ian McJohn
 
-// Helper Functions
-func CurrencyFromConst(amount string) Currency {
-	hastings, _ := ParseCurrency(amount)
-	i, _ := new(big.Int).SetString(hastings, 10)
-	c := NewCurrency(i)
-	return c
-}
-
 // Useful constants
 var (
 	NFTMerkleRootLength     = len(crypto.Hash{}.String())
@@ -28,69 +26,479 @@ var (
 	NFTTransferTagLength    = len(NFTTransferTag) + NFTMerkleRootLength
 	NFTLiquidationTag       = []byte{'L', 'Q'}
 	NFTLiquidationTagLength = len(NFTLiquidationTag) + NFTMerkleRootLength
+	// NFTReclaimTag marks a transaction in which the current owner of an NFT
+	// reclaims its vested lockup while keeping the NFT. Its first byte is not
+	// recognized as a legacy tag, so reclaims always carry a version byte.
+	NFTReclaimTag = []byte{'R', 'C'}
+	// NFTBridgeLockTag and NFTBridgeUnlockTag mark transactions which
+	// freeze an NFT for a cross-chain bridge and release it again. Like
+	// reclaims they always carry a version byte.
+	NFTBridgeLockTag        = []byte{'B', 'L'}
+	NFTBridgeUnlockTag      = []byte{'B', 'U'}
 	NFTWithoutCustody       = SiacoinOutput{}
 	LiquidatedNFTUnlockHash = UnlockHash{'L', 'Q'}
 	// Network-specific costs
-	NFTMintCost     = CurrencyFromConst("5000SC")
-	NFTLockupAmount = CurrencyFromConst("2500SC")
-	NFTHostAmount   = CurrencyFromConst("2500SC")
-	NFTTransferCost = CurrencyFromConst("500SC")
+	NFTMintCost     = MustCurrency("5000SC")
+	NFTLockupAmount = MustCurrency("2500SC")
+	NFTHostAmount   = MustCurrency("2500SC")
+	NFTTransferCost = MustCurrency("500SC")
 	// PrefixNFTCustody means that this transaction is specially marked
 	// as an NFT chain-of-custody transfer, and thus uses the arbitrary
 	// data field
 	PrefixNFTCustody = NewSpecifier("NFT")
+	// NFTArbitraryDataLength is the exact length of a legacy NFT arbitrary
+	// data entry: the custody prefix, the tag and the hex encoded merkle root.
+	NFTArbitraryDataLength = SpecifierLen + NFTTagLen + NFTMerkleRootLength
+	// NFTVersionLen is the length of the version byte which follows
+	// PrefixNFTCustody in versioned NFT arbitrary data entries.
+	NFTVersionLen = 1
+	// NFTContentLengthLen is the length of the encoded content length of a
+	// mint which commits to its content.
+	NFTContentLengthLen = 8
+	// NFTMaxContentTypeLength is the maximum length of the MIME type a mint
+	// can commit to.
+	NFTMaxContentTypeLength = 255
+)
+
+const (
+	// NFTVersionLegacy is the version of NFT arbitrary data entries which
+	// were created before versioning was introduced. They don't contain a
+	// version byte and start with their tag instead, which is why no version
+	// may ever use the first byte of a legacy tag.
+	NFTVersionLegacy byte = 0
+	// NFTVersion1 entries contain the version byte followed by the tag and
+	// merkle root of the legacy format.
+	NFTVersion1 byte = 1
+	// NFTVersion2 entries are mints which additionally commit to the MIME
+	// type and length of the NFT's content. The tag and merkle root are
+	// followed by the big endian content length and the MIME type.
+	NFTVersion2 byte = 2
+	// NFTVersion3 entries are bridge locks which additionally embed the
+	// claim on the foreign chain. The tag and merkle root are followed by the
+	// length of the chain identifier, the chain identifier and the recipient
+	// on that chain.
+	NFTVersion3 byte = 3
+	// NFTVersion4 entries reference NFTs by their NftID. Mints embed the
+	// merkle root followed by the creator's public key, the big endian
+	// nonce, the length of the collection, the collection and optionally a
+	// content commitment. All other entries carry the NftID in place of the
+	// merkle root.
+	NFTVersion4 byte = 4
+	// NFTVersion5 entries are edition mints. They contain the tag and
+	// merkle root followed by the big endian number of editions and the
+	// remainder of an NFTVersion4 mint, whose nonce is the index of the
+	// edition.
+	NFTVersion5 byte = 5
+	// NFTVersion6 entries are mints with a transfer policy. They contain the
+	// kind of the policy and its big endian height followed by the version
+	// byte and body of a mint of any other version.
+	NFTVersion6 byte = 6
+	// NFTVersion7 entries are stake reward claims. The tag and merkle root
+	// are followed by the big endian number of segments of the data, the
+	// length of the proven segment, the segment, the number of hashes of the
+	// merkle proof and the hashes.
+	NFTVersion7 byte = 7
+	// NFTVersion8 entries are mints with a content reference. They contain
+	// the kind and length of the reference and the reference followed by
+	// the version byte and body of a mint of any other version.
+	NFTVersion8 byte = 8
+	// NFTVersion9 entries are insurances. They contain the big endian
+	// expiry height and the insurer's address followed by the version byte
+	// and body of an NFTVersion1 or NFTVersion4 entry referencing the NFT.
+	NFTVersion9 byte = 9
+	// NFTVersion10 entries are insurance responses. They contain the merkle
+	// proof of a segment in the layout of stake reward claims followed by
+	// the version byte and body of an NFTVersion1 or NFTVersion4 entry
+	// referencing the NFT.
+	NFTVersion10 byte = 10
+	// NFTVersion11 entries are dispute flags. They contain the kind of the
+	// dispute, the length of its description and the description followed
+	// by the version byte and body of an NFTVersion1 or NFTVersion4 entry
+	// referencing the NFT.
+	NFTVersion11 byte = 11
+	// NFTVersion12 entries are mints with a license. They contain the kind
+	// and length of the license's identifier and the identifier followed by
+	// the version byte and body of a mint of any other version except
+	// NFTVersion8.
+	NFTVersion12 byte = 12
+	// NFTCurrentVersion is the newest version known to this node.
+	NFTCurrentVersion = NFTVersion12
 )
 
+var (
+	// NFTLockupVestingPeriod is the number of blocks after its mint at which
+	// the current owner of an NFT can reclaim the NFT's lockup without
+	// liquidating it. Until then the lockup can only be reclaimed by
+	// liquidating the NFT.
+	NFTLockupVestingPeriod = build.Select(build.Var{
+		Dev:      BlockHeight(200),
+		Standard: BlocksPerYear,
+		Testing:  BlockHeight(10),
+	}).(BlockHeight)
+
+	// MinSupportedNFTVersion is the oldest NFT arbitrary data version which
+	// is accepted by consensus. Raising it allows a network to retire old
+	// formats.
+	MinSupportedNFTVersion = build.Select(build.Var{
+		Dev:      NFTVersionLegacy,
+		Standard: NFTVersionLegacy,
+		Testing:  NFTVersionLegacy,
+	}).(byte)
+
+	// nftVersionParsers maps every known NFT arbitrary data version to the
+	// function parsing the body of its entries, i.e. everything following
+	// the prefix and version byte. Future formats are added here.
+	nftVersionParsers = map[byte]func(body []byte) ([]byte, NftCustody, error){
+		NFTVersion1:  parseNFTTagAndRoot,
+		NFTVersion2:  parseNFTContentMint,
+		NFTVersion3:  parseNFTBridgeLock,
+		NFTVersion4:  parseNFTIdentified,
+		NFTVersion5:  parseNFTEditionMint,
+		NFTVersion6:  parseNFTPolicyMint,
+		NFTVersion7:  parseNFTStakeReward,
+		NFTVersion8:  parseNFTReferenceMint,
+		NFTVersion9:  parseNFTInsure,
+		NFTVersion10: parseNFTInsuranceResponse,
+		NFTVersion11: parseNFTDisputeFlag,
+		NFTVersion12: parseNFTLicenseMint,
+	}
+)
+
+var (
+	// ErrNFTDataLength is returned if an NFT arbitrary data entry doesn't
+	// have the length required by its version.
+	ErrNFTDataLength = errors.New("nft arbitrary data has an invalid length")
+	// ErrNFTUnknownTag is returned if an NFT arbitrary data entry contains a
+	// tag other than the mint, transfer and liquidation tags.
+	ErrNFTUnknownTag = errors.New("nft arbitrary data has an unknown tag")
+	// ErrNFTBadMerkleRoot is returned if the merkle root of an NFT arbitrary
+	// data entry can't be decoded.
+	ErrNFTBadMerkleRoot = errors.New("nft arbitrary data contains an invalid merkle root")
+	// ErrNFTMisplacedData is returned if a transaction contains NFT arbitrary
+	// data anywhere but in its first arbitrary data entry.
+	ErrNFTMisplacedData = errors.New("nft arbitrary data must be the first and only nft entry of a transaction")
+	// ErrNFTUnsupportedVersion is returned if an NFT arbitrary data entry
+	// uses a version which is unknown to this node.
+	ErrNFTUnsupportedVersion = errors.New("nft arbitrary data uses an unsupported version")
+	// ErrNFTVersionTooOld is returned if an NFT arbitrary data entry uses a
+	// version older than MinSupportedNFTVersion.
+	ErrNFTVersionTooOld = errors.New("nft arbitrary data uses a version which is no longer supported")
+	// ErrNFTContentNotMint is returned if a transaction other than a mint
+	// commits to an NFT's content.
+	ErrNFTContentNotMint = errors.New("only nft mints can commit to the nft's content")
+	// ErrNFTBadContentType is returned if the content type a mint commits to
+	// is not a valid MIME type.
+	ErrNFTBadContentType = errors.New("nft content type is not a valid mime type")
+)
+
+// isNFTArbitraryData returns true if an arbitrary data entry is prefixed with
+// PrefixNFTCustody. Entries shorter than the prefix are padded with zeros,
+// matching the way the transaction pool reads prefixes.
+func isNFTArbitraryData(arb []byte) bool {
+	var prefix Specifier
+	copy(prefix[:], arb)
+	return prefix == PrefixNFTCustody
+}
+
+// splitNFTArbitraryData splits an NFT arbitrary data entry into the byte
+// following the prefix and the remaining body. For versioned entries these are
+// the version and the body of the version, legacy entries start with their tag
+// instead. ok is false if the entry is not NFT arbitrary data or too short to
+// hold a version.
+func splitNFTArbitraryData(arb []byte) (version byte, body []byte, ok bool) {
+	if !isNFTArbitraryData(arb) || len(arb) < SpecifierLen+NFTVersionLen {
+		return 0, nil, false
+	}
+	return arb[SpecifierLen], arb[SpecifierLen+NFTVersionLen:], true
+}
+
+// NFTTagEqual returns true if tag is the NFT tag want. Tags of any length other
+// than NFTTagLen never match. The comparison takes the same time for all tags
+// of the right length.
+func NFTTagEqual(tag, want []byte) bool {
+	if len(tag) != NFTTagLen || len(want) != NFTTagLen {
+		return false
+	}
+	return subtle.ConstantTimeCompare(tag, want) == 1
+}
+
+// hasNFTTag returns true if body starts with the NFT tag want.
+func hasNFTTag(body, want []byte) bool {
+	return len(body) >= NFTTagLen && NFTTagEqual(body[:NFTTagLen], want)
+}
+
+// isKnownNFTTag returns true if tag can precede the merkle root or NftID of an
+// NFT. Reward claims are tagged with NFTStakeRewardTag but have a layout of
+// their own.
+func isKnownNFTTag(tag []byte) bool {
+	for _, known := range [][]byte{NFTMintTag, NFTTransferTag, NFTLiquidationTag, NFTReclaimTag, NFTBridgeLockTag, NFTBridgeUnlockTag, NFTStakeTag, NFTUnstakeTag,
+		NFTInsureTag, NFTInsuranceChallengeTag, NFTInsuranceResponseTag, NFTInsuranceClaimTag, NFTInsuranceReleaseTag, NFTApproveTag,
+		NFTFreezeTag, NFTUnfreezeTag} {
+		if NFTTagEqual(tag, known) {
+			return true
+		}
+	}
+	return false
+}
+
+// NFTArbitraryData encodes an NFT arbitrary data entry with the given tag.
+// Mints with a transfer policy use NFTVersion6, other edition mints use
+// NFTVersion5, other identified mints and all entries of NFTs with an NftID use
+// NFTVersion4, other mints of NFTs with a content commitment use NFTVersion2
+// and everything else uses NFTVersion1.
+func NFTArbitraryData(tag []byte, nft NftCustody) []byte {
+	mint := NFTTagEqual(tag, NFTMintTag)
+	if mint && nft.HasTransferPolicy() {
+		return nftPolicyArbitraryData(nft)
+	}
+	if mint && nft.HasIdentity() && nft.IsEdition() {
+		return nftEditionArbitraryData(nft)
+	}
+	if (mint && nft.HasIdentity()) || (!mint && nft.ID != (NftID{})) {
+		return nftIdentifiedArbitraryData(tag, nft)
+	}
+	version := NFTVersion1
+	if NFTTagEqual(tag, NFTMintTag) && nft.HasContentCommitment() {
+		version = NFTVersion2
+	}
+	arb := make([]byte, 0, SpecifierLen+NFTVersionLen+NFTTagLen+NFTMerkleRootLength)
+	arb = append(arb, PrefixNFTCustody[:]...)
+	arb = append(arb, version)
+	arb = append(arb, tag...)
+	arb = append(arb, nft.FileMerkleRoot.String()...)
+	if version == NFTVersion2 {
+		arb = append(arb, nftContentCommitment(nft)...)
+	}
+	return arb
+}
+
+// nftContentCommitment encodes the content commitment of a mint: the big endian
+// content length followed by the MIME type.
+func nftContentCommitment(nft NftCustody) []byte {
+	b := make([]byte, NFTContentLengthLen, NFTContentLengthLen+len(nft.ContentType))
+	binary.BigEndian.PutUint64(b, nft.ContentLength)
+	return append(b, nft.ContentType...)
+}
+
+// isLegacyNFTData returns true if the byte following PrefixNFTCustody is the
+// first byte of a legacy tag rather than a version byte.
+func isLegacyNFTData(b byte) bool {
+	return b == NFTMintTag[0] || b == NFTTransferTag[0] || b == NFTLiquidationTag[0]
+}
+
+// parseNFTTagAndRoot parses a body consisting of exactly a tag and a hex
+// encoded merkle root.
+func parseNFTTagAndRoot(body []byte) ([]byte, NftCustody, error) {
+	if len(body) != NFTTagLen+NFTMerkleRootLength {
+		return nil, NftCustody{}, ErrNFTDataLength
+	}
+	tag := body[:NFTTagLen]
+	if !isKnownNFTTag(tag) {
+		return nil, NftCustody{}, ErrNFTUnknownTag
+	}
+	var nft NftCustody
+	err := nft.FileMerkleRoot.LoadString(string(body[NFTTagLen:]))
+	if err != nil {
+		return nil, NftCustody{}, errors.Compose(ErrNFTBadMerkleRoot, err)
+	}
+	return tag, nft, nil
+}
+
+// parseNFTContentMint parses the body of a mint which commits to its content:
+// a tag and merkle root followed by the content length and MIME type.
+func parseNFTContentMint(body []byte) ([]byte, NftCustody, error) {
+	headerLen := NFTTagLen + NFTMerkleRootLength
+	if len(body) < headerLen+NFTContentLengthLen+1 || len(body) > headerLen+NFTContentLengthLen+NFTMaxContentTypeLength {
+		return nil, NftCustody{}, ErrNFTDataLength
+	}
+	tag, nft, err := parseNFTTagAndRoot(body[:headerLen])
+	if err != nil {
+		return nil, NftCustody{}, err
+	}
+	if !NFTTagEqual(tag, NFTMintTag) {
+		return nil, NftCustody{}, ErrNFTContentNotMint
+	}
+	if err := parseNFTContentCommitment(&nft, body[headerLen:]); err != nil {
+		return nil, NftCustody{}, err
+	}
+	return tag, nft, nil
+}
+
+// parseNFTContentCommitment parses the content length and MIME type of a mint
+// into nft.
+func parseNFTContentCommitment(nft *NftCustody, b []byte) error {
+	if len(b) < NFTContentLengthLen+1 || len(b) > NFTContentLengthLen+NFTMaxContentTypeLength {
+		return ErrNFTDataLength
+	}
+	nft.ContentLength = binary.BigEndian.Uint64(b)
+	nft.ContentType = string(b[NFTContentLengthLen:])
+	return ValidateNFTContentType(nft.ContentType)
+}
+
+// ValidateNFTContentType checks that a content type is a lowercase MIME type
+// without parameters which can be committed to by a mint.
+func ValidateNFTContentType(contentType string) error {
+	if len(contentType) == 0 || len(contentType) > NFTMaxContentTypeLength {
+		return ErrNFTBadContentType
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return errors.Compose(ErrNFTBadContentType, err)
+	}
+	if mediaType != contentType || len(params) != 0 || !strings.Contains(mediaType, "/") {
+		return ErrNFTBadContentType
+	}
+	return nil
+}
+
+// ParseNFTArbitraryData strictly parses an NFT arbitrary data entry and
+// returns its version, tag and NFT. Legacy entries are recognized by their
+// tag, all other entries are dispatched on their version byte. An error is returned if the version is
+// unknown, the entry doesn't have the length required by its version, has an
+// unknown tag or contains an invalid merkle root.
+func ParseNFTArbitraryData(arb []byte) (version byte, tag []byte, nft NftCustody, err error) {
+	version, body, ok := splitNFTArbitraryData(arb)
+	if !ok {
+		return 0, nil, NftCustody{}, ErrNFTDataLength
+	}
+	if isLegacyNFTData(version) {
+		tag, nft, err = parseNFTTagAndRoot(arb[SpecifierLen:])
+		return NFTVersionLegacy, tag, nft, err
+	}
+	parse, ok := nftVersionParsers[version]
+	if !ok {
+		return version, nil, NftCustody{}, ErrNFTUnsupportedVersion
+	}
+	tag, nft, err = parse(body)
+	return version, tag, nft, err
+}
+
+// parseLenientLegacyNFTData recognizes legacy mint, transfer and liquidation
+// entries the way they were recognized before the strict NFT data rule: an
+// entry whose tag follows the prefix and which is at least as long as
+// NFTMintTagLength is recognized by its tag, even if it is truncated or
+// followed by trailing bytes. If the rest of the entry isn't exactly a hex
+// encoded merkle root, the NFT has the zero merkle root.
+func parseLenientLegacyNFTData(arb []byte) (tag []byte, nft NftCustody, ok bool) {
+	if !isNFTArbitraryData(arb) || len(arb) < NFTMintTagLength {
+		return nil, NftCustody{}, false
+	}
+	tag = arb[SpecifierLen : SpecifierLen+NFTTagLen]
+	if !NFTTagEqual(tag, NFTMintTag) && !NFTTagEqual(tag, NFTTransferTag) && !NFTTagEqual(tag, NFTLiquidationTag) {
+		return nil, NftCustody{}, false
+	}
+	_ = nft.FileMerkleRoot.LoadString(string(arb[SpecifierLen+NFTTagLen:]))
+	return tag, nft, true
+}
+
+// parseRecognizedNFTData returns the tag and NFT of an NFT arbitrary data
+// entry as it is recognized by the NFT transaction kinds. Well formed entries
+// are parsed strictly, malformed legacy entries leniently, see
+// parseLenientLegacyNFTData.
+func parseRecognizedNFTData(arb []byte) (tag []byte, nft NftCustody, ok bool) {
+	_, tag, nft, err := ParseNFTArbitraryData(arb)
+	if err == nil {
+		return tag, nft, true
+	}
+	return parseLenientLegacyNFTData(arb)
+}
+
+// ValidateNFTTransaction checks that the NFT arbitrary data of a transaction
+// is well formed. Transactions without NFT arbitrary data are always valid.
+// The transaction pool rejects transactions which fail this check, and
+// consensus rejects them once the strict NFT data rule activates. Before that,
+// malformed entries are ignored by the custody index, except for legacy mints,
+// transfers and liquidations with a malformed merkle root or trailing bytes,
+// which are still recognized as they were before the rule, see
+// parseLenientLegacyNFTData. Once the rule is active, such entries can't be
+// part of a block anymore.
+//
+// Entries with an unknown version are not rejected, so that new formats can be
+// introduced by softfork without breaking old validators. They are ignored by
+// the custody index and refused by the transaction pool instead.
+func ValidateNFTTransaction(t Transaction) error {
+	for i, arb := range t.ArbitraryData {
+		if i > 0 && isNFTArbitraryData(arb) {
+			return ErrNFTMisplacedData
+		}
+	}
+	if !IsNFTTransaction(t) {
+		return nil
+	}
+	version, _, _, err := ParseNFTArbitraryData(t.ArbitraryData[0])
+	if errors.Contains(err, ErrNFTUnsupportedVersion) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if version < MinSupportedNFTVersion {
+		return ErrNFTVersionTooOld
+	}
+	return nil
+}
+
 // Discerning functions for filtering NFT transactions
 func IsNFTTransaction(t Transaction) bool {
 	// Don't run on non-nft transactions
-	var prefix Specifier
 	if len(t.ArbitraryData) < 1 {
 		return false
 	}
-	nftTag := t.ArbitraryData[0]
-	copy(prefix[:], nftTag)
-	return prefix == PrefixNFTCustody
+	return isNFTArbitraryData(t.ArbitraryData[0])
 }
 
-func IsNFTMintTransaction(t Transaction) bool {
-	if !IsNFTTransaction(t) || len(t.ArbitraryData[0]) < NFTMintTagLength {
+// isNFTTransactionWithTag returns true if the transaction's NFT arbitrary data
+// carries the given tag. The entry has to be well formed, apart from legacy
+// entries which are recognized leniently, see parseLenientLegacyNFTData.
+func isNFTTransactionWithTag(t Transaction, tag []byte) bool {
+	if len(t.ArbitraryData) == 0 {
 		return false
 	}
-	idx := SpecifierLen
-	b1 := t.ArbitraryData[0][idx]
-	b2 := t.ArbitraryData[0][idx+1]
-	return b1 == NFTMintTag[0] && b2 == NFTMintTag[1]
+	found, _, ok := parseRecognizedNFTData(t.ArbitraryData[0])
+	return ok && NFTTagEqual(found, tag)
+}
+
+func IsNFTMintTransaction(t Transaction) bool {
+	return isNFTTransactionWithTag(t, NFTMintTag)
 }
 
 func IsNFTTransferTransaction(t Transaction) bool {
-	if !IsNFTTransaction(t) || len(t.ArbitraryData[0]) < NFTTransferTagLength {
-		return false
-	}
-	idx := SpecifierLen
-	b1 := t.ArbitraryData[0][idx]
-	b2 := t.ArbitraryData[0][idx+1]
-	return b1 == NFTTransferTag[0] && b2 == NFTTransferTag[1]
+	return isNFTTransactionWithTag(t, NFTTransferTag)
 }
 
 func IsNFTLiquidationTransaction(t Transaction) bool {
-	if !IsNFTTransaction(t) || len(t.ArbitraryData[0]) < NFTLiquidationTagLength {
-		return false
-	}
-	idx := SpecifierLen
-	b1 := t.ArbitraryData[0][idx]
-	b2 := t.ArbitraryData[0][idx+1]
-	return b1 == NFTLiquidationTag[0] && b2 == NFTLiquidationTag[1]
+	return isNFTTransactionWithTag(t, NFTLiquidationTag)
+}
+
+// IsNFTReclaimTransaction returns true if the transaction reclaims the lockup
+// of an NFT.
+func IsNFTReclaimTransaction(t Transaction) bool {
+	return isNFTTransactionWithTag(t, NFTReclaimTag)
+}
+
+// UpdatesNFTCustody returns true if the transaction is an NFT transaction which
+// sets the custody of its NFT.
+func UpdatesNFTCustody(t Transaction) bool {
+	return IsNFTMintTransaction(t) || IsNFTTransferTransaction(t) ||
+		IsNFTLiquidationTransaction(t) || IsNFTReclaimTransaction(t) ||
+		IsNFTBridgeLockTransaction(t) || IsNFTBridgeUnlockTransaction(t) ||
+		IsNFTStakeTransaction(t) || IsNFTUnstakeTransaction(t) ||
+		IsNFTInsuranceChallengeTransaction(t) || IsNFTInsuranceClaimTransaction(t) ||
+		IsNFTApproveTransaction(t)
 }
 
 // Remove NFT Information from arbitrary data section of transaction
 // Precondition on t: must be valid NFT chain-of-custody transaction
-// as determined by above funcs
+// as determined by above funcs. Malformed transactions return zero values
+// instead of panicking.
 func ExtractNFTFromTransaction(t Transaction) (ret NftCustody, owner SiacoinOutput) {
 	// First extract merkle root
-	startIndex := SpecifierLen + NFTTagLen
-	var merkleRoot []byte = t.ArbitraryData[0][startIndex:]
-	ret.FileMerkleRoot.LoadString(string(merkleRoot))
+	if !IsNFTTransaction(t) {
+		return NftCustody{}, SiacoinOutput{}
+	}
+	_, ret, ok := parseRecognizedNFTData(t.ArbitraryData[0])
+	if !ok {
+		return NftCustody{}, SiacoinOutput{}
+	}
 	// Then extract current owner
 	if IsNFTLiquidationTransaction(t) {
 		owner.UnlockHash = LiquidatedNFTUnlockHash
@@ -108,36 +516,21 @@ func ExtractNFTFromTransaction(t Transaction) (ret NftCustody, owner SiacoinOutp
 	return ret, owner
 }
 
-// Function to create the unlock conditions for
-// the two NFT storage pools
-func NFTPoolUnlockConditions() (UnlockConditions, UnlockConditions) {
-	// Lockup Conditions
-	lockupPkey, _ := hex.DecodeString("4d652d8ce36facbf0c194a7533a7a2ee7c9c9e364af45e65cf7433e5b8496696")
-	storagePoolPkey, _ := hex.DecodeString("171b3650f74b718fc003828e3e33a6b525f055db049bdefdc3122baba3e016e0")
-	NFTLockupUnlockConditions := UnlockConditions{
-		Timelock:           0,
-		SignaturesRequired: 0,
-		PublicKeys: []SiaPublicKey{{
-			Algorithm: SignatureEd25519,
-			Key:       lockupPkey,
-		}},
+// nftPoolUnlockConditions returns the unlock conditions of an NFT pool with
+// the given hex encoded ed25519 key.
+func nftPoolUnlockConditions(key string) UnlockConditions {
+	pk, err := hex.DecodeString(key)
+	if err != nil || len(pk) != crypto.PublicKeySize {
+		panic("invalid nft pool key " + key)
 	}
-	NFTStoragePoolUnlockConditions := UnlockConditions{
-		Timelock:           0,
-		SignaturesRequired: 0,
+	return UnlockConditions{
 		PublicKeys: []SiaPublicKey{{
 			Algorithm: SignatureEd25519,
-			Key:       storagePoolPkey,
+			Key:       pk,
 		}},
 	}
-
-	return NFTLockupUnlockConditions, NFTStoragePoolUnlockConditions
 }
 
-var (
-	NFTLockupUnlockConditions, NFTStoragePoolUnlockConditions = NFTPoolUnlockConditions()
-)
-
 // Core NFT Types
 type (
 	NftCustody struct {
@@ -145,9 +538,57 @@ type (
 		// used as unique identifier for NFT throughout codebase
 		// ideally set this to a more useful/constrained type in the future
 		FileMerkleRoot crypto.Hash
+
+		// ContentType and ContentLength are the MIME type and length in
+		// bytes of the NFT's data. They are only set for NFTs whose mint
+		// committed to them, and are not part of transfers.
+		ContentType   string
+		ContentLength uint64
+
+		// ID identifies NFTs which were minted with an identity, see
+		// nftid.go. It is zero for legacy NFTs, which are identified by
+		// their merkle root.
+		ID NftID
+
+		// Creator, Collection and Nonce are the identity the ID of the NFT
+		// is derived from. Like the content commitment they are only set
+		// for mints.
+		Creator    crypto.PublicKey
+		Collection string
+		Nonce      uint64
+
+		// Editions is the number of editions of an NFT which was minted as
+		// an edition, see nftedition.go. It is zero for all other NFTs.
+		Editions uint64
+
+		// TransferPolicy restricts the transfers of the NFT, see
+		// nftpolicy.go. Like the content commitment it is only set for
+		// mints.
+		TransferPolicy NFTTransferPolicy
 	}
 	NftOwnershipStats struct {
 		Nft   NftCustody `json:"nftroots"`
 		Owner UnlockHash `json:"nftowner"`
 	}
+
+	// NFTLockup describes the lockup paid by the mint of an NFT. The lockup
+	// is returned to the owner of the NFT either by liquidating the NFT or,
+	// once it is vested, by reclaiming it. It can only be returned once and
+	// Reclaimed is set by whichever returns it first.
+	NFTLockup struct {
+		MintHeight BlockHeight `json:"mintheight"`
+		Reclaimed  bool        `json:"reclaimed"`
+	}
 )
+
+// VestingHeight returns the first height at which the lockup can be reclaimed
+// without liquidating the NFT.
+func (l NFTLockup) VestingHeight() BlockHeight {
+	return l.MintHeight + NFTLockupVestingPeriod
+}
+
+// HasContentCommitment returns true if the NFT carries a content type and
+// length.
+func (nft NftCustody) HasContentCommitment() bool {
+	return nft.ContentType != ""
+}
@@ -0,0 +1,424 @@
+package types
+
+import (
+	"bytes"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+)
+
+// newTestNFTArbitraryData creates a well formed legacy NFT arbitrary data
+// entry.
+func newTestNFTArbitraryData(tag []byte, root crypto.Hash) []byte {
+	return append(append(PrefixNFTCustody[:], tag...), []byte(root.String())...)
+}
+
+// TestParseNFTArbitraryData tests parsing well formed and malformed NFT
+// arbitrary data entries.
+func TestParseNFTArbitraryData(t *testing.T) {
+	var root crypto.Hash
+	fastrand.Read(root[:])
+
+	// Well formed legacy and current entries.
+	for _, tag := range [][]byte{NFTMintTag, NFTTransferTag, NFTLiquidationTag} {
+		entries := map[byte][]byte{
+			NFTVersionLegacy: newTestNFTArbitraryData(tag, root),
+			NFTVersion1:      NFTArbitraryData(tag, NftCustody{FileMerkleRoot: root}),
+		}
+		for expected, arb := range entries {
+			version, found, nft, err := ParseNFTArbitraryData(arb)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if version != expected || !bytes.Equal(found, tag) || nft.FileMerkleRoot != root {
+				t.Fatal("parsed data doesn't match", version, found, nft.FileMerkleRoot)
+			}
+		}
+	}
+
+	// Reclaims are only supported with a version byte.
+	version, found, nft, err := ParseNFTArbitraryData(NFTArbitraryData(NFTReclaimTag, NftCustody{FileMerkleRoot: root}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != NFTVersion1 || !bytes.Equal(found, NFTReclaimTag) || nft.FileMerkleRoot != root {
+		t.Fatal("parsed reclaim doesn't match", version, found, nft.FileMerkleRoot)
+	}
+
+	// Malformed entries.
+	valid := newTestNFTArbitraryData(NFTMintTag, root)
+	badHex := append([]byte{}, valid...)
+	badHex[len(badHex)-1] = 'z'
+	badTag := append([]byte{}, valid...)
+	badTag[SpecifierLen+1] = 'X'
+	current := NFTArbitraryData(NFTMintTag, NftCustody{FileMerkleRoot: root})
+	unknownVersion := append([]byte{}, current...)
+	unknownVersion[SpecifierLen] = NFTCurrentVersion + 1
+	tests := []struct {
+		name string
+		arb  []byte
+		err  error
+	}{
+		{"empty", nil, ErrNFTDataLength},
+		{"prefix only", PrefixNFTCustody[:], ErrNFTDataLength},
+		{"short prefix", []byte("NFT"), ErrNFTDataLength},
+		{"truncated", valid[:len(valid)-1], ErrNFTDataLength},
+		{"extended", append(append([]byte{}, valid...), '0'), ErrNFTDataLength},
+		{"wrong prefix", append(SpecifierFoundation[:], valid[SpecifierLen:]...), ErrNFTDataLength},
+		{"unknown tag", badTag, ErrNFTUnknownTag},
+		{"bad hex", badHex, ErrNFTBadMerkleRoot},
+		{"truncated current", current[:len(current)-1], ErrNFTDataLength},
+		{"extended current", append(append([]byte{}, current...), '0'), ErrNFTDataLength},
+		{"unknown version", unknownVersion, ErrNFTUnsupportedVersion},
+		{"legacy reclaim", newTestNFTArbitraryData(NFTReclaimTag, root), ErrNFTUnsupportedVersion},
+		{"version only", current[:SpecifierLen+NFTVersionLen], ErrNFTDataLength},
+	}
+	for _, test := range tests {
+		if _, _, _, err := ParseNFTArbitraryData(test.arb); !errors.Contains(err, test.err) {
+			t.Errorf("%v: expected %v but got %v", test.name, test.err, err)
+		}
+	}
+}
+
+// TestValidateNFTTransaction tests validating the NFT arbitrary data of
+// transactions.
+func TestValidateNFTTransaction(t *testing.T) {
+	var root crypto.Hash
+	fastrand.Read(root[:])
+	valid := newTestNFTArbitraryData(NFTTransferTag, root)
+	nonSia := []byte("NonSia\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00data")
+
+	tests := []struct {
+		name string
+		arbs [][]byte
+		err  error
+	}{
+		{"no data", nil, nil},
+		{"not nft", [][]byte{nonSia}, nil},
+		{"valid", [][]byte{valid}, nil},
+		{"valid with trailing data", [][]byte{valid, nonSia}, nil},
+		{"truncated", [][]byte{valid[:SpecifierLen+NFTTagLen]}, ErrNFTDataLength},
+		{"second entry", [][]byte{nonSia, valid}, ErrNFTMisplacedData},
+		{"two entries", [][]byte{valid, valid}, ErrNFTMisplacedData},
+	}
+	for _, test := range tests {
+		txn := Transaction{ArbitraryData: test.arbs}
+		err := ValidateNFTTransaction(txn)
+		if test.err == nil && err != nil {
+			t.Errorf("%v: unexpected error %v", test.name, err)
+		} else if test.err != nil && !errors.Contains(err, test.err) {
+			t.Errorf("%v: expected %v but got %v", test.name, test.err, err)
+		}
+	}
+
+	// A valid transaction is recognized and its NFT can be extracted.
+	txn := Transaction{ArbitraryData: [][]byte{valid}}
+	if !IsNFTTransferTransaction(txn) || IsNFTMintTransaction(txn) || IsNFTLiquidationTransaction(txn) {
+		t.Fatal("transfer wasn't recognized correctly")
+	}
+	if nft, _ := ExtractNFTFromTransaction(txn); nft.FileMerkleRoot != root {
+		t.Fatal("wrong nft extracted")
+	}
+
+	// A legacy transaction with trailing bytes or a malformed merkle root is
+	// recognized with the zero merkle root, as it was before the strict NFT
+	// data rule.
+	for _, arb := range [][]byte{append(append([]byte{}, valid...), 0), valid[:NFTTransferTagLength]} {
+		txn.ArbitraryData[0] = arb
+		if ValidateNFTTransaction(txn) == nil {
+			t.Fatal("malformed transfer is valid")
+		}
+		if !IsNFTTransferTransaction(txn) || IsNFTMintTransaction(txn) {
+			t.Fatal("legacy transfer wasn't recognized leniently")
+		}
+		if nft, _ := ExtractNFTFromTransaction(txn); nft != (NftCustody{}) {
+			t.Fatal("expected the zero merkle root for a malformed legacy transfer")
+		}
+	}
+
+	// A transaction which is too short to be a legacy transfer is not
+	// recognized and extracting it doesn't panic.
+	txn.ArbitraryData[0] = valid[:NFTTransferTagLength-1]
+	if IsNFTTransferTransaction(txn) {
+		t.Fatal("malformed transfer was recognized")
+	}
+	if nft, _ := ExtractNFTFromTransaction(txn); nft != (NftCustody{}) {
+		t.Fatal("expected empty nft for malformed transaction")
+	}
+}
+
+// TestNFTArbitraryDataFuzz feeds random and randomly mutated arbitrary data to
+// the NFT parsing functions. They must never panic and a transaction must only
+// be recognized as an NFT operation if it passes validation or is a malformed
+// legacy entry, which is recognized leniently.
+func TestNFTArbitraryDataFuzz(t *testing.T) {
+	iters := 10000
+	if testing.Short() {
+		iters = 1000
+	}
+	tags := [][]byte{NFTMintTag, NFTTransferTag, NFTLiquidationTag}
+	for i := 0; i < iters; i++ {
+		var root crypto.Hash
+		fastrand.Read(root[:])
+		tag := tags[fastrand.Intn(len(tags))]
+		arb := newTestNFTArbitraryData(tag, root)
+		switch fastrand.Intn(3) {
+		case 0:
+			arb = NFTArbitraryData(tag, NftCustody{FileMerkleRoot: root})
+		case 1:
+			arb = NFTArbitraryData(NFTMintTag, NftCustody{
+				FileMerkleRoot: root,
+				ContentType:    "image/png",
+				ContentLength:  fastrand.Uint64n(1 << 40),
+			})
+		}
+
+		// Mutate the entry.
+		switch fastrand.Intn(4) {
+		case 0:
+			// Truncate it.
+			arb = arb[:fastrand.Intn(len(arb)+1)]
+		case 1:
+			// Extend it.
+			arb = append(arb, fastrand.Bytes(fastrand.Intn(8)+1)...)
+		case 2:
+			// Flip a random byte after the prefix.
+			arb[SpecifierLen+fastrand.Intn(len(arb)-SpecifierLen)] ^= byte(fastrand.Intn(255) + 1)
+		case 3:
+			// Replace everything after the prefix with random bytes.
+			arb = append(PrefixNFTCustody[:], fastrand.Bytes(fastrand.Intn(2*NFTArbitraryDataLength))...)
+		}
+		txn := Transaction{ArbitraryData: [][]byte{arb}}
+
+		// Entries which no longer carry the NFT prefix, use an unknown
+		// version or whose tag was mutated into the tag of another kind of
+		// NFT transaction, e.g. an insurance, are valid but not recognized.
+		err := ValidateNFTTransaction(txn)
+		_, tag, _, parseErr := ParseNFTArbitraryData(arb)
+		otherKind := parseErr == nil && !NFTTagEqual(tag, NFTMintTag) && !NFTTagEqual(tag, NFTTransferTag) && !NFTTagEqual(tag, NFTLiquidationTag)
+		ignored := !IsNFTTransaction(txn) || errors.Contains(parseErr, ErrNFTUnsupportedVersion) || otherKind
+		recognized := IsNFTMintTransaction(txn) || IsNFTTransferTransaction(txn) || IsNFTLiquidationTransaction(txn)
+		_, _, lenient := parseLenientLegacyNFTData(arb)
+		if ignored && (recognized || err != nil) || !ignored && recognized != (err == nil || lenient) {
+			t.Fatalf("recognized: %v, validation error: %v, data: %x", recognized, err, arb)
+		}
+		nft, _ := ExtractNFTFromTransaction(txn)
+		if err != nil && nft != (NftCustody{}) {
+			t.Fatalf("extracted nft from invalid data: %x", arb)
+		}
+	}
+}
+
+// TestNFTContentCommitment tests encoding and parsing mints which commit to
+// the content type and length of an NFT.
+func TestNFTContentCommitment(t *testing.T) {
+	nft := NftCustody{
+		ContentType:   "video/mp4",
+		ContentLength: 4 << 30,
+	}
+	fastrand.Read(nft.FileMerkleRoot[:])
+
+	// Mints with a content commitment use version 2 and round trip.
+	mint := NFTArbitraryData(NFTMintTag, nft)
+	version, tag, parsed, err := ParseNFTArbitraryData(mint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != NFTVersion2 || !bytes.Equal(tag, NFTMintTag) || parsed != nft {
+		t.Fatal("parsed mint doesn't match", version, tag, parsed)
+	}
+	txn := Transaction{ArbitraryData: [][]byte{mint}}
+	if !IsNFTMintTransaction(txn) {
+		t.Fatal("content mint wasn't recognized")
+	}
+	if extracted, _ := ExtractNFTFromTransaction(txn); extracted != nft {
+		t.Fatal("wrong nft extracted", extracted)
+	}
+
+	// Transfers never carry the content commitment.
+	transfer := NFTArbitraryData(NFTTransferTag, nft)
+	version, _, parsed, err = ParseNFTArbitraryData(transfer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != NFTVersion1 || parsed.HasContentCommitment() || parsed.FileMerkleRoot != nft.FileMerkleRoot {
+		t.Fatal("unexpected transfer", version, parsed)
+	}
+
+	// A version 2 entry with a different tag is invalid.
+	notMint := append([]byte{}, mint...)
+	copy(notMint[SpecifierLen+NFTVersionLen:], NFTTransferTag)
+	if _, _, _, err := ParseNFTArbitraryData(notMint); !errors.Contains(err, ErrNFTContentNotMint) {
+		t.Fatal("expected ErrNFTContentNotMint but got", err)
+	}
+
+	// Missing and oversized content types are invalid.
+	header := SpecifierLen + NFTVersionLen + NFTTagLen + NFTMerkleRootLength + NFTContentLengthLen
+	if _, _, _, err := ParseNFTArbitraryData(mint[:header]); !errors.Contains(err, ErrNFTDataLength) {
+		t.Fatal("expected ErrNFTDataLength but got", err)
+	}
+	oversized := append(append([]byte{}, mint[:header]...), bytes.Repeat([]byte{'a'}, NFTMaxContentTypeLength+1)...)
+	if _, _, _, err := ParseNFTArbitraryData(oversized); !errors.Contains(err, ErrNFTDataLength) {
+		t.Fatal("expected ErrNFTDataLength but got", err)
+	}
+
+	// Check the content type validation.
+	contentTypes := []struct {
+		contentType string
+		valid       bool
+	}{
+		{"text/plain", true},
+		{"image/svg+xml", true},
+		{"application/vnd.sia.nft", true},
+		{"", false},
+		{"text", false},
+		{"Text/Plain", false},
+		{"text/plain; charset=utf-8", false},
+		{"text/plain\x00", false},
+	}
+	for _, ct := range contentTypes {
+		if err := ValidateNFTContentType(ct.contentType); (err == nil) != ct.valid {
+			t.Errorf("%q: expected valid %v but got %v", ct.contentType, ct.valid, err)
+		}
+	}
+}
+
+// TestNFTTagEqual tests comparing NFT tags and splitting NFT arbitrary data
+// entries with malformed inputs.
+func TestNFTTagEqual(t *testing.T) {
+	tests := []struct {
+		name  string
+		tag   []byte
+		want  []byte
+		equal bool
+	}{
+		{"equal", []byte{'M', 'N'}, NFTMintTag, true},
+		{"different", NFTTransferTag, NFTMintTag, false},
+		{"nil", nil, NFTMintTag, false},
+		{"empty", []byte{}, NFTMintTag, false},
+		{"short", NFTMintTag[:1], NFTMintTag, false},
+		{"long", []byte{'M', 'N', 'N'}, NFTMintTag, false},
+		{"short want", NFTMintTag[:1], NFTMintTag[:1], false},
+		{"nil want", nil, nil, false},
+		{"swapped", []byte{'N', 'M'}, NFTMintTag, false},
+	}
+	for _, test := range tests {
+		if NFTTagEqual(test.tag, test.want) != test.equal {
+			t.Errorf("%v: expected %v", test.name, test.equal)
+		}
+		body := append(append([]byte{}, test.tag...), '0')
+		if hasNFTTag(body, test.want) != (test.equal || test.name == "long") {
+			t.Errorf("%v: hasNFTTag returned wrong result", test.name)
+		}
+	}
+
+	// Only the tags preceding a merkle root or NftID are known.
+	for _, tag := range [][]byte{NFTMintTag, NFTTransferTag, NFTLiquidationTag, NFTReclaimTag, NFTBridgeLockTag, NFTBridgeUnlockTag, NFTStakeTag, NFTUnstakeTag} {
+		if !isKnownNFTTag(tag) {
+			t.Errorf("tag %s should be known", tag)
+		}
+	}
+	for _, tag := range [][]byte{nil, NFTStakeRewardTag, {'X', 'X'}, {'M'}, {'M', 'N', 'N'}} {
+		if isKnownNFTTag(tag) {
+			t.Errorf("tag %s shouldn't be known", tag)
+		}
+	}
+
+	// Split entries of different lengths.
+	current := NFTArbitraryData(NFTMintTag, NftCustody{})
+	splitTests := []struct {
+		name    string
+		arb     []byte
+		ok      bool
+		version byte
+		bodyLen int
+	}{
+		{"nil", nil, false, 0, 0},
+		{"short prefix", []byte("NFT"), false, 0, 0},
+		{"prefix only", PrefixNFTCustody[:], false, 0, 0},
+		{"wrong prefix", append(SpecifierFoundation[:], current[SpecifierLen:]...), false, 0, 0},
+		{"version only", current[:SpecifierLen+NFTVersionLen], true, NFTVersion1, 0},
+		{"current", current, true, NFTVersion1, len(current) - SpecifierLen - NFTVersionLen},
+	}
+	for _, test := range splitTests {
+		version, body, ok := splitNFTArbitraryData(test.arb)
+		if ok != test.ok || version != test.version || len(body) != test.bodyLen {
+			t.Errorf("%v: unexpected result %v %v %v", test.name, version, len(body), ok)
+		}
+	}
+}
+
+// TestIsNFTTransactionTags tests that every NFT entry is recognized by exactly
+// one of the IsNFT*Transaction functions and that malformed entries are
+// recognized by none.
+func TestIsNFTTransactionTags(t *testing.T) {
+	var root crypto.Hash
+	fastrand.Read(root[:])
+	nft := NftCustody{FileMerkleRoot: root}
+
+	checks := []struct {
+		name string
+		is   func(Transaction) bool
+	}{
+		{"mint", IsNFTMintTransaction},
+		{"transfer", IsNFTTransferTransaction},
+		{"liquidation", IsNFTLiquidationTransaction},
+		{"reclaim", IsNFTReclaimTransaction},
+		{"bridge lock", IsNFTBridgeLockTransaction},
+		{"bridge unlock", IsNFTBridgeUnlockTransaction},
+		{"stake", IsNFTStakeTransaction},
+		{"unstake", IsNFTUnstakeTransaction},
+		{"stake reward", IsNFTStakeRewardTransaction},
+	}
+	entries := map[string][]byte{
+		"mint":          NFTArbitraryData(NFTMintTag, nft),
+		"transfer":      NFTArbitraryData(NFTTransferTag, nft),
+		"liquidation":   NFTArbitraryData(NFTLiquidationTag, nft),
+		"reclaim":       NFTArbitraryData(NFTReclaimTag, nft),
+		"bridge lock":   NFTBridgeLockArbitraryData(nft, NFTBridgeClaim{Chain: "eth", Recipient: "0x01"}),
+		"bridge unlock": NFTArbitraryData(NFTBridgeUnlockTag, nft),
+		"stake":         NFTArbitraryData(NFTStakeTag, nft),
+		"unstake":       NFTArbitraryData(NFTUnstakeTag, nft),
+		"stake reward":  NFTStakeRewardArbitraryData(NFTStakeRewardClaim{Root: root, NumSegments: 1, Segment: []byte{1}}),
+	}
+	for name, arb := range entries {
+		txn := Transaction{ArbitraryData: [][]byte{arb}}
+		if !IsNFTTransaction(txn) {
+			t.Errorf("%v: not recognized as nft transaction", name)
+		}
+		for _, check := range checks {
+			if check.is(txn) != (check.name == name) {
+				t.Errorf("%v: recognized as %v: %v", name, check.name, check.is(txn))
+			}
+		}
+	}
+
+	// Malformed entries are not recognized as any kind of NFT transaction.
+	mint := entries["mint"]
+	badTag := append([]byte{}, mint...)
+	badTag[SpecifierLen+NFTVersionLen] = 'X'
+	malformed := map[string][][]byte{
+		"no data":       nil,
+		"empty":         {{}},
+		"short prefix":  {[]byte("NFT")},
+		"prefix only":   {PrefixNFTCustody[:]},
+		"version only":  {mint[:SpecifierLen+NFTVersionLen]},
+		"half tag":      {mint[:SpecifierLen+NFTVersionLen+1]},
+		"truncated":     {mint[:len(mint)-1]},
+		"unknown tag":   {badTag},
+		"wrong prefix":  {append(SpecifierFoundation[:], mint[SpecifierLen:]...)},
+		"second entry":  {{}, mint},
+		"legacy reward": {newTestNFTArbitraryData(NFTStakeRewardTag, root)},
+	}
+	for name, arbs := range malformed {
+		txn := Transaction{ArbitraryData: arbs}
+		for _, check := range checks {
+			if check.is(txn) {
+				t.Errorf("%v: recognized as %v", name, check.name)
+			}
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package types
+
+// nftapproval.go contains operator approvals, which let the owner of an NFT
+// authorize a single transfer of the NFT by someone else, e.g. a marketplace
+// settling a sale on behalf of the seller. An approval keeps the NFT at its
+// owner's address and pays one base unit to the operator's address. That
+// output is the operator's ticket: the operator transfers the NFT with an
+// ordinary transfer transaction which spends the ticket instead of the custody
+// output. Any transfer of the NFT clears its approval, so every approval can
+// be used at most once. An approval without a ticket revokes the approval of
+// the NFT.
+
+var (
+	// NFTApproveTag marks transactions which approve an operator to transfer
+	// an NFT, or revoke the approval. Like reclaims they always carry a
+	// version byte.
+	NFTApproveTag = []byte{'A', 'P'}
+)
+
+// NFTApproval is the approval of an NFT. Operator is the address which is
+// allowed to transfer the NFT and Height the height of the approval.
+type NFTApproval struct {
+	Operator UnlockHash  `json:"operator"`
+	Height   BlockHeight `json:"height"`
+}
+
+// IsNFTApproveTransaction returns true if the transaction approves an operator
+// to transfer an NFT or revokes the approval of an NFT.
+func IsNFTApproveTransaction(t Transaction) bool {
+	return isNFTTransactionWithTag(t, NFTApproveTag)
+}
+
+// IsNFTRevokeApprovalTransaction returns true if the transaction revokes the
+// approval of an NFT. Revocations are approvals without a ticket output.
+func IsNFTRevokeApprovalTransaction(t Transaction) bool {
+	return IsNFTApproveTransaction(t) && len(t.SiacoinOutputs) == 1
+}
@@ -0,0 +1,213 @@
+package types
+
+import (
+	"bytes"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+)
+
+// nftbridge.go contains the transactions used by cross-chain bridges which
+// mirror NFTs on other chains. A bridge lock moves the custody of an NFT to the
+// address of a bridge and embeds the claim on the foreign chain. While an NFT
+// is locked it can't be transferred, liquidated or reclaimed. It is released by
+// a bridge unlock, which spends the bridge's custody output and therefore
+// requires the signatures of the bridge's attestors.
+
+const (
+	// NFTMaxBridgeChainLength is the maximum length of the identifier of the
+	// foreign chain a bridge lock can claim an NFT on.
+	NFTMaxBridgeChainLength = 32
+	// NFTMaxBridgeRecipientLength is the maximum length of the recipient on
+	// the foreign chain a bridge lock can claim an NFT for.
+	NFTMaxBridgeRecipientLength = 128
+	// NFTMinBridgeAttestations is the minimum number of signatures the
+	// address of a bridge needs to require to unlock an NFT.
+	NFTMinBridgeAttestations = 2
+)
+
+var (
+	// ErrNFTBadBridgeClaim is returned if the claim of a bridge lock is
+	// empty, too long or contains characters other than printable ASCII.
+	ErrNFTBadBridgeClaim = errors.New("nft bridge claim is malformed")
+	// ErrNFTClaimNotBridgeLock is returned if a transaction other than a
+	// bridge lock embeds a bridge claim.
+	ErrNFTClaimNotBridgeLock = errors.New("only nft bridge locks can embed a bridge claim")
+	// ErrNFTNotBridgeLock is returned when parsing the claim of arbitrary
+	// data which is not a bridge lock.
+	ErrNFTNotBridgeLock = errors.New("nft arbitrary data is not a bridge lock")
+)
+
+type (
+	// NFTBridgeClaim describes the asset a bridge mirrors a locked NFT as.
+	// Chain identifies the foreign chain and Recipient the address on that
+	// chain, both in the format used by the bridge.
+	NFTBridgeClaim struct {
+		Chain     string `json:"chain"`
+		Recipient string `json:"recipient"`
+	}
+
+	// NFTBridgeLock is the state of an NFT which is locked by a bridge.
+	NFTBridgeLock struct {
+		Claim  NFTBridgeClaim `json:"claim"`
+		Bridge UnlockHash     `json:"bridge"`
+		Height BlockHeight    `json:"height"`
+	}
+)
+
+// isPrintableASCII returns true if s only contains printable ASCII characters
+// other than space.
+func isPrintableASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] <= ' ' || s[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate checks that the claim can be embedded in a bridge lock.
+func (c NFTBridgeClaim) Validate() error {
+	if len(c.Chain) == 0 || len(c.Chain) > NFTMaxBridgeChainLength || !isPrintableASCII(c.Chain) {
+		return errors.AddContext(ErrNFTBadBridgeClaim, "invalid chain")
+	}
+	if len(c.Recipient) == 0 || len(c.Recipient) > NFTMaxBridgeRecipientLength || !isPrintableASCII(c.Recipient) {
+		return errors.AddContext(ErrNFTBadBridgeClaim, "invalid recipient")
+	}
+	return nil
+}
+
+// NFTBridgeLockArbitraryData creates the arbitrary data entry of a bridge lock
+// of an NFT which embeds the claim on the foreign chain. NFTs with an NftID are
+// locked with an NFTVersion4 entry.
+func NFTBridgeLockArbitraryData(nft NftCustody, claim NFTBridgeClaim) []byte {
+	version, id := NFTVersion3, nft.FileMerkleRoot.String()
+	if nft.ID != (NftID{}) {
+		version, id = NFTVersion4, nft.ID.String()
+	}
+	arb := make([]byte, 0, SpecifierLen+NFTVersionLen+NFTTagLen+NFTMerkleRootLength+1+len(claim.Chain)+len(claim.Recipient))
+	arb = append(arb, PrefixNFTCustody[:]...)
+	arb = append(arb, version)
+	arb = append(arb, NFTBridgeLockTag...)
+	arb = append(arb, id...)
+	arb = append(arb, byte(len(claim.Chain)))
+	arb = append(arb, claim.Chain...)
+	arb = append(arb, claim.Recipient...)
+	return arb
+}
+
+// parseNFTBridgeClaim parses the body of a bridge lock: a tag and merkle root
+// followed by the length of the chain, the chain and the recipient.
+func parseNFTBridgeClaim(body []byte) ([]byte, NftCustody, NFTBridgeClaim, error) {
+	headerLen := NFTTagLen + NFTMerkleRootLength
+	if len(body) < headerLen+1 {
+		return nil, NftCustody{}, NFTBridgeClaim{}, ErrNFTDataLength
+	}
+	tag, nft, err := parseNFTTagAndRoot(body[:headerLen])
+	if err != nil {
+		return nil, NftCustody{}, NFTBridgeClaim{}, err
+	}
+	if !NFTTagEqual(tag, NFTBridgeLockTag) {
+		return nil, NftCustody{}, NFTBridgeClaim{}, ErrNFTClaimNotBridgeLock
+	}
+	claim := body[headerLen+1:]
+	chainLen := int(body[headerLen])
+	if chainLen > len(claim) {
+		return nil, NftCustody{}, NFTBridgeClaim{}, ErrNFTDataLength
+	}
+	c := NFTBridgeClaim{
+		Chain:     string(claim[:chainLen]),
+		Recipient: string(claim[chainLen:]),
+	}
+	if err := c.Validate(); err != nil {
+		return nil, NftCustody{}, NFTBridgeClaim{}, err
+	}
+	return tag, nft, c, nil
+}
+
+// parseNFTBridgeLock parses the body of a bridge lock and drops its claim.
+func parseNFTBridgeLock(body []byte) ([]byte, NftCustody, error) {
+	tag, nft, _, err := parseNFTBridgeClaim(body)
+	return tag, nft, err
+}
+
+// ParseNFTBridgeClaim parses the NFT and the claim of a bridge lock's
+// arbitrary data entry. ErrNFTNotBridgeLock is returned for entries which
+// don't embed a claim.
+func ParseNFTBridgeClaim(arb []byte) (NftCustody, NFTBridgeClaim, error) {
+	version, body, ok := splitNFTArbitraryData(arb)
+	if !ok || (version != NFTVersion3 && version != NFTVersion4) {
+		return NftCustody{}, NFTBridgeClaim{}, ErrNFTNotBridgeLock
+	}
+	_, nft, claim, err := parseNFTBridgeClaim(body)
+	if err == nil && version == NFTVersion4 {
+		nft = identifiedNFT(nft)
+	}
+	return nft, claim, err
+}
+
+// IsNFTBridgeLockTransaction returns true if the transaction locks an NFT for
+// a bridge.
+func IsNFTBridgeLockTransaction(t Transaction) bool {
+	return isNFTTransactionWithTag(t, NFTBridgeLockTag)
+}
+
+// IsNFTBridgeUnlockTransaction returns true if the transaction releases an NFT
+// which is locked by a bridge.
+func IsNFTBridgeUnlockTransaction(t Transaction) bool {
+	return isNFTTransactionWithTag(t, NFTBridgeUnlockTag)
+}
+
+// NFTBridgeUnlockTransaction creates a transaction which releases an NFT held
+// by the bridge's custody output to dest. The transaction contains an empty
+// signature for every public key of the bridge with an index in signers. The
+// signatures cover the whole transaction and are added by the attestors with
+// SignNFTBridgeUnlock.
+func NFTBridgeUnlockTransaction(nft NftCustody, custody SiacoinOutputID, bridge UnlockConditions, dest UnlockHash, signers []uint64) Transaction {
+	txn := Transaction{
+		SiacoinInputs: []SiacoinInput{{
+			ParentID:         custody,
+			UnlockConditions: bridge,
+		}},
+		SiacoinOutputs: []SiacoinOutput{{
+			UnlockHash: dest,
+			Value:      OneBaseUnit,
+		}},
+		ArbitraryData: [][]byte{NFTArbitraryData(NFTBridgeUnlockTag, nft)},
+	}
+	for _, i := range signers {
+		txn.TransactionSignatures = append(txn.TransactionSignatures, TransactionSignature{
+			ParentID:       crypto.Hash(custody),
+			PublicKeyIndex: i,
+			CoveredFields:  FullCoveredFields,
+		})
+	}
+	return txn
+}
+
+// SignNFTBridgeUnlock adds the attestation of the bridge's public key with the
+// given index to a bridge unlock created by NFTBridgeUnlockTransaction. The
+// height is the current height of the chain the transaction is submitted to.
+func SignNFTBridgeUnlock(txn *Transaction, keyIndex uint64, sk crypto.SecretKey, height BlockHeight) error {
+	if !IsNFTBridgeUnlockTransaction(*txn) || len(txn.SiacoinInputs) != 1 {
+		return errors.New("transaction is not an nft bridge unlock")
+	}
+	uc := txn.SiacoinInputs[0].UnlockConditions
+	if keyIndex >= uint64(len(uc.PublicKeys)) {
+		return errors.New("key index out of range")
+	}
+	pk := sk.PublicKey()
+	if !bytes.Equal(uc.PublicKeys[keyIndex].Key, pk[:]) {
+		return errors.New("secret key doesn't match the bridge's public key")
+	}
+	for i, sig := range txn.TransactionSignatures {
+		if sig.PublicKeyIndex == keyIndex {
+			sigHash := txn.SigHash(i, height)
+			encodedSig := crypto.SignHash(sigHash, sk)
+			txn.TransactionSignatures[i].Signature = encodedSig[:]
+			return nil
+		}
+	}
+	return errors.New("transaction has no signature for the key index")
+}
@@ -0,0 +1,119 @@
+package types
+
+import (
+	"strings"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+)
+
+// TestNFTBridgeClaim tests encoding and parsing the claim of bridge locks.
+func TestNFTBridgeClaim(t *testing.T) {
+	var nft NftCustody
+	fastrand.Read(nft.FileMerkleRoot[:])
+	claim := NFTBridgeClaim{Chain: "eth", Recipient: "0x0123456789abcdef"}
+
+	// A bridge lock is recognized and its claim can be parsed.
+	arb := NFTBridgeLockArbitraryData(nft, claim)
+	txn := Transaction{ArbitraryData: [][]byte{arb}}
+	if err := ValidateNFTTransaction(txn); err != nil {
+		t.Fatal(err)
+	}
+	if !IsNFTBridgeLockTransaction(txn) || IsNFTBridgeUnlockTransaction(txn) || IsNFTTransferTransaction(txn) {
+		t.Fatal("bridge lock wasn't recognized correctly")
+	}
+	parsedNFT, parsedClaim, err := ParseNFTBridgeClaim(arb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsedNFT != nft || parsedClaim != claim {
+		t.Fatal("parsed claim doesn't match", parsedNFT, parsedClaim)
+	}
+
+	// Other entries don't embed a claim.
+	for _, arb := range [][]byte{
+		NFTArbitraryData(NFTBridgeLockTag, nft),
+		NFTArbitraryData(NFTTransferTag, nft),
+		nil,
+	} {
+		if _, _, err := ParseNFTBridgeClaim(arb); !errors.Contains(err, ErrNFTNotBridgeLock) {
+			t.Fatal("expected ErrNFTNotBridgeLock but got", err)
+		}
+	}
+
+	// Malformed claims.
+	notLock := NFTBridgeLockArbitraryData(nft, claim)
+	copy(notLock[SpecifierLen+NFTVersionLen:], NFTMintTag)
+	tooLong := NFTBridgeLockArbitraryData(nft, claim)
+	tooLong[SpecifierLen+NFTVersionLen+NFTTagLen+NFTMerkleRootLength] = byte(len(claim.Chain) + len(claim.Recipient) + 1)
+	tests := []struct {
+		name string
+		arb  []byte
+		err  error
+	}{
+		{"not a lock", notLock, ErrNFTClaimNotBridgeLock},
+		{"missing claim", arb[:SpecifierLen+NFTVersionLen+NFTTagLen+NFTMerkleRootLength], ErrNFTDataLength},
+		{"chain too long", tooLong, ErrNFTDataLength},
+		{"empty chain", NFTBridgeLockArbitraryData(nft, NFTBridgeClaim{Recipient: "a"}), ErrNFTBadBridgeClaim},
+		{"empty recipient", NFTBridgeLockArbitraryData(nft, NFTBridgeClaim{Chain: "a"}), ErrNFTBadBridgeClaim},
+		{"space", NFTBridgeLockArbitraryData(nft, NFTBridgeClaim{Chain: "a", Recipient: "a b"}), ErrNFTBadBridgeClaim},
+		{"long recipient", NFTBridgeLockArbitraryData(nft, NFTBridgeClaim{Chain: "a", Recipient: strings.Repeat("a", NFTMaxBridgeRecipientLength+1)}), ErrNFTBadBridgeClaim},
+	}
+	for _, test := range tests {
+		if _, _, err := ParseNFTBridgeClaim(test.arb); !errors.Contains(err, test.err) {
+			t.Errorf("%v: expected %v but got %v", test.name, test.err, err)
+		}
+		txn := Transaction{ArbitraryData: [][]byte{test.arb}}
+		if err := ValidateNFTTransaction(txn); !errors.Contains(err, test.err) {
+			t.Errorf("%v: expected %v from validation but got %v", test.name, test.err, err)
+		}
+	}
+}
+
+// TestSignNFTBridgeUnlock tests that the attestors of a bridge can sign an
+// unlock.
+func TestSignNFTBridgeUnlock(t *testing.T) {
+	var nft NftCustody
+	fastrand.Read(nft.FileMerkleRoot[:])
+	bridge, keys := GenerateDeterministicMultisig(2, 3, t.Name())
+	dest := UnlockHash{1}
+	custody := SiacoinOutputID{2}
+	height := FoundationHardforkHeight
+
+	txn := NFTBridgeUnlockTransaction(nft, custody, bridge, dest, []uint64{0, 2})
+	if !IsNFTBridgeUnlockTransaction(txn) {
+		t.Fatal("unlock wasn't recognized")
+	}
+	if found, owner := ExtractNFTFromTransaction(txn); found != nft || owner.UnlockHash != dest {
+		t.Fatal("wrong nft or owner", found, owner)
+	}
+
+	// A key which isn't part of the transaction can't sign it.
+	if err := SignNFTBridgeUnlock(&txn, 1, keys[1], height); err == nil {
+		t.Fatal("expected error for missing signature")
+	}
+	if err := SignNFTBridgeUnlock(&txn, 0, keys[2], height); err == nil {
+		t.Fatal("expected error for wrong key")
+	}
+
+	// With only one attestation the transaction is invalid.
+	if err := SignNFTBridgeUnlock(&txn, 0, keys[0], height); err != nil {
+		t.Fatal(err)
+	}
+	if err := txn.StandaloneValid(height); err == nil {
+		t.Fatal("transaction with a missing attestation should be invalid")
+	}
+	if err := SignNFTBridgeUnlock(&txn, 2, keys[2], height); err != nil {
+		t.Fatal(err)
+	}
+	if err := txn.StandaloneValid(height); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only unlocks can be signed.
+	txn.ArbitraryData[0] = NFTArbitraryData(NFTTransferTag, nft)
+	if err := SignNFTBridgeUnlock(&txn, 0, keys[0], height); err == nil {
+		t.Fatal("expected error for transfer")
+	}
+}
@@ -0,0 +1,499 @@
+package types
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"strings"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+)
+
+// nftcid.go contains content references, which let the creator of an NFT
+// record the IPFS CID or the multihash of the NFT's data alongside its Sia
+// merkle root, so that marketplaces bridging ecosystems can cross-reference
+// assets. A mint with a reference wraps a mint of any other version together
+// with the reference. Consensus only checks that the reference is well formed,
+// since it never sees the data. VerifyNFTContent checks that a reference and a
+// merkle root commit to the same data, which is feasible for bare multihashes
+// and raw CIDs using a supported hash function. CIDs of other codecs, like the
+// dag-pb CIDs ipfs creates by default, hash the encoded DAG instead of the data
+// and can't be verified without rebuilding the DAG.
+
+const (
+	// NFTMultihashSHA256 and NFTMultihashBlake2b256 are the multicodec codes
+	// of the hash functions supported by VerifyNFTContent.
+	NFTMultihashSHA256     uint64 = 0x12
+	NFTMultihashBlake2b256 uint64 = 0xb220
+
+	// NFTCIDCodecRaw is the multicodec code of CIDs which address raw data.
+	// NFTCIDCodecDagPB is the code of CIDs which address a UnixFS DAG, which
+	// includes every CIDv0.
+	NFTCIDCodecRaw   uint64 = 0x55
+	NFTCIDCodecDagPB uint64 = 0x70
+
+	// NFTMaxMultihashDigestLength is the maximum length of the digest of a
+	// multihash a mint can reference.
+	NFTMaxMultihashDigestLength = 64
+	// NFTMaxContentReferenceLength is the maximum length of an encoded
+	// content reference.
+	NFTMaxContentReferenceLength = 96
+
+	// cidv1 is the version prefix of a binary CIDv1.
+	cidv1 = 1
+	// base32Lower is the alphabet of lowercase base32 CIDs.
+	base32Lower = "abcdefghijklmnopqrstuvwxyz234567"
+	// base58BTC is the alphabet of base58btc CIDs.
+	base58BTC = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+)
+
+const (
+	// NFTReferenceMultihash is a bare multihash of the NFT's data.
+	NFTReferenceMultihash NFTContentReferenceKind = iota + 1
+	// NFTReferenceCIDv0 is an IPFS CIDv0, the base58btc encoded sha2-256
+	// multihash of a UnixFS DAG.
+	NFTReferenceCIDv0
+	// NFTReferenceCIDv1 is an IPFS CIDv1 with an arbitrary codec.
+	NFTReferenceCIDv1
+)
+
+var (
+	// ErrNFTBadContentReference is returned if a content reference is not a
+	// well formed multihash or CID.
+	ErrNFTBadContentReference = errors.New("nft content reference is malformed")
+	// ErrNFTReferenceNotMint is returned if a transaction other than a mint
+	// embeds a content reference.
+	ErrNFTReferenceNotMint = errors.New("only nft mints can embed a content reference")
+	// ErrNFTNoContentReference is returned when parsing the content
+	// reference of arbitrary data which doesn't embed one.
+	ErrNFTNoContentReference = errors.New("nft arbitrary data doesn't embed a content reference")
+	// ErrNFTReferenceUnverifiable is returned if a content reference can't
+	// be checked against the NFT's data.
+	ErrNFTReferenceUnverifiable = errors.New("nft content reference can't be verified against the data")
+	// ErrNFTContentMismatch is returned if the data doesn't match the merkle
+	// root or the content reference of an NFT.
+	ErrNFTContentMismatch = errors.New("nft data doesn't match its merkle root and content reference")
+
+	// nftReferenceMintParsers maps the versions a mint with a content
+	// reference can wrap to the function parsing their body.
+	nftReferenceMintParsers = map[byte]func(body []byte) ([]byte, NftCustody, error){
+		NFTVersion1: parseNFTTagAndRoot,
+		NFTVersion2: parseNFTContentMint,
+		NFTVersion4: parseNFTIdentified,
+		NFTVersion5: parseNFTEditionMint,
+		NFTVersion6: parseNFTPolicyMint,
+	}
+
+	// nftContentReferenceKindNames are the names of the kinds of content
+	// references used by the API.
+	nftContentReferenceKindNames = map[NFTContentReferenceKind]string{
+		NFTReferenceMultihash: "multihash",
+		NFTReferenceCIDv0:     "cidv0",
+		NFTReferenceCIDv1:     "cidv1",
+	}
+
+	// base32LowerEncoding encodes lowercase base32 CIDs.
+	base32LowerEncoding = base32.NewEncoding(base32Lower).WithPadding(base32.NoPadding)
+)
+
+type (
+	// NFTContentReferenceKind is the kind of identifier a content reference
+	// contains.
+	NFTContentReferenceKind byte
+
+	// NFTContentReference identifies the data of an NFT outside of Sia.
+	// Codec is the content codec of CIDs and zero for bare multihashes.
+	NFTContentReference struct {
+		Kind      NFTContentReferenceKind
+		Codec     uint64
+		Multihash []byte
+	}
+
+	// nftContentReferenceJSON is the JSON encoding of a content reference.
+	nftContentReferenceJSON struct {
+		Kind  NFTContentReferenceKind `json:"kind"`
+		Value string                  `json:"value"`
+	}
+)
+
+// String returns the name of the kind of reference.
+func (k NFTContentReferenceKind) String() string {
+	if name, ok := nftContentReferenceKindNames[k]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// MarshalText marshals the kind of reference as its name.
+func (k NFTContentReferenceKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText unmarshals the kind of reference from its name.
+func (k *NFTContentReferenceKind) UnmarshalText(b []byte) error {
+	for kind, name := range nftContentReferenceKindNames {
+		if name == string(b) {
+			*k = kind
+			return nil
+		}
+	}
+	return errors.AddContext(ErrNFTBadContentReference, "unknown kind "+string(b))
+}
+
+// putUvarint appends the unsigned varint encoding of x to b.
+func putUvarint(b []byte, x uint64) []byte {
+	buf := make([]byte, binary.MaxVarintLen64)
+	return append(b, buf[:binary.PutUvarint(buf, x)]...)
+}
+
+// readUvarint reads an unsigned varint from the start of b and returns it with
+// the number of bytes read. Varints which aren't minimally encoded are
+// rejected, so that every reference has a single encoding.
+func readUvarint(b []byte) (uint64, int, error) {
+	x, n := binary.Uvarint(b)
+	if n <= 0 || n != len(putUvarint(nil, x)) {
+		return 0, 0, errors.AddContext(ErrNFTBadContentReference, "invalid varint")
+	}
+	return x, n, nil
+}
+
+// parseMultihash splits a multihash into the code of its hash function and
+// its digest.
+func parseMultihash(mh []byte) (uint64, []byte, error) {
+	code, n, err := readUvarint(mh)
+	if err != nil {
+		return 0, nil, err
+	}
+	length, m, err := readUvarint(mh[n:])
+	if err != nil {
+		return 0, nil, err
+	}
+	digest := mh[n+m:]
+	if length == 0 || length > NFTMaxMultihashDigestLength || uint64(len(digest)) != length {
+		return 0, nil, errors.AddContext(ErrNFTBadContentReference, "invalid multihash digest length")
+	}
+	return code, digest, nil
+}
+
+// NewNFTMultihash returns the multihash with the given hash function code and
+// digest.
+func NewNFTMultihash(code uint64, digest []byte) []byte {
+	return append(putUvarint(putUvarint(nil, code), uint64(len(digest))), digest...)
+}
+
+// NFTRawCID returns the CIDv1 ipfs assigns to data added with raw leaves which
+// fits into a single block, i.e. the sha2-256 hash of the data with the raw
+// codec.
+func NFTRawCID(data []byte) NFTContentReference {
+	h := sha256.Sum256(data)
+	return NFTContentReference{
+		Kind:      NFTReferenceCIDv1,
+		Codec:     NFTCIDCodecRaw,
+		Multihash: NewNFTMultihash(NFTMultihashSHA256, h[:]),
+	}
+}
+
+// Validate checks that the reference can be embedded in a mint.
+func (r NFTContentReference) Validate() error {
+	code, digest, err := parseMultihash(r.Multihash)
+	if err != nil {
+		return err
+	}
+	switch r.Kind {
+	case NFTReferenceMultihash:
+		if r.Codec != 0 {
+			return errors.AddContext(ErrNFTBadContentReference, "multihashes have no codec")
+		}
+	case NFTReferenceCIDv0:
+		if r.Codec != NFTCIDCodecDagPB || code != NFTMultihashSHA256 || len(digest) != sha256.Size {
+			return errors.AddContext(ErrNFTBadContentReference, "cidv0 must be a dag-pb sha2-256 hash")
+		}
+	case NFTReferenceCIDv1:
+	default:
+		return errors.AddContext(ErrNFTBadContentReference, "unknown kind")
+	}
+	if len(r.Bytes()) > NFTMaxContentReferenceLength {
+		return errors.AddContext(ErrNFTBadContentReference, "reference is too long")
+	}
+	return nil
+}
+
+// Bytes returns the binary form of the reference: the multihash of bare
+// multihashes and CIDv0s, and the version, codec and multihash of CIDv1s.
+func (r NFTContentReference) Bytes() []byte {
+	if r.Kind != NFTReferenceCIDv1 {
+		return append([]byte(nil), r.Multihash...)
+	}
+	return append(putUvarint(putUvarint(nil, cidv1), r.Codec), r.Multihash...)
+}
+
+// String returns the hex encoded multihash of bare multihashes, the base58btc
+// encoding of CIDv0s and the lowercase base32 encoding of CIDv1s.
+func (r NFTContentReference) String() string {
+	switch r.Kind {
+	case NFTReferenceCIDv0:
+		return encodeBase58(r.Multihash)
+	case NFTReferenceCIDv1:
+		return "b" + base32LowerEncoding.EncodeToString(r.Bytes())
+	default:
+		return hex.EncodeToString(r.Multihash)
+	}
+}
+
+// Equals returns true if both references are identical.
+func (r NFTContentReference) Equals(other NFTContentReference) bool {
+	return r.Kind == other.Kind && r.Codec == other.Codec && bytes.Equal(r.Multihash, other.Multihash)
+}
+
+// Verifiable returns true if VerifyNFTContent can check the reference against
+// the NFT's data, which requires the multihash to be the hash of the data
+// itself and a supported hash function.
+func (r NFTContentReference) Verifiable() bool {
+	code, _, err := parseMultihash(r.Multihash)
+	if err != nil || (code != NFTMultihashSHA256 && code != NFTMultihashBlake2b256) {
+		return false
+	}
+	return r.Kind == NFTReferenceMultihash || (r.Kind == NFTReferenceCIDv1 && r.Codec == NFTCIDCodecRaw)
+}
+
+// VerifyNFTContent checks that data matches the merkle root of an NFT and its
+// content reference. ErrNFTReferenceUnverifiable is returned for references
+// which can't be checked against the data.
+func VerifyNFTContent(root crypto.Hash, r NFTContentReference, data []byte) error {
+	if crypto.MerkleRoot(data) != root {
+		return errors.AddContext(ErrNFTContentMismatch, "merkle root doesn't match")
+	}
+	if !r.Verifiable() {
+		return ErrNFTReferenceUnverifiable
+	}
+	code, digest, _ := parseMultihash(r.Multihash)
+	var sum []byte
+	switch code {
+	case NFTMultihashSHA256:
+		h := sha256.Sum256(data)
+		sum = h[:]
+	case NFTMultihashBlake2b256:
+		h := crypto.HashBytes(data)
+		sum = h[:]
+	}
+	if !bytes.Equal(sum, digest) {
+		return errors.AddContext(ErrNFTContentMismatch, "content reference doesn't match")
+	}
+	return nil
+}
+
+// ParseNFTMultihash parses a hex encoded multihash into a content reference.
+func ParseNFTMultihash(s string) (NFTContentReference, error) {
+	mh, err := hex.DecodeString(s)
+	if err != nil {
+		return NFTContentReference{}, errors.Compose(ErrNFTBadContentReference, err)
+	}
+	r := NFTContentReference{Kind: NFTReferenceMultihash, Multihash: mh}
+	return r, r.Validate()
+}
+
+// ParseNFTCID parses an IPFS CID into a content reference. CIDv0s are
+// recognized by their length and prefix, CIDv1s have to be encoded in
+// base32, base58btc or base16.
+func ParseNFTCID(s string) (NFTContentReference, error) {
+	if len(s) == 46 && strings.HasPrefix(s, "Qm") {
+		mh, err := decodeBase58(s)
+		if err != nil {
+			return NFTContentReference{}, err
+		}
+		r := NFTContentReference{Kind: NFTReferenceCIDv0, Codec: NFTCIDCodecDagPB, Multihash: mh}
+		return r, r.Validate()
+	}
+	if len(s) < 2 {
+		return NFTContentReference{}, errors.AddContext(ErrNFTBadContentReference, "cid is too short")
+	}
+	var b []byte
+	var err error
+	switch s[0] {
+	case 'b':
+		b, err = base32LowerEncoding.DecodeString(s[1:])
+	case 'B':
+		b, err = base32LowerEncoding.DecodeString(strings.ToLower(s[1:]))
+	case 'z':
+		b, err = decodeBase58(s[1:])
+	case 'f':
+		b, err = hex.DecodeString(s[1:])
+	default:
+		return NFTContentReference{}, errors.AddContext(ErrNFTBadContentReference, "unsupported multibase")
+	}
+	if err != nil {
+		return NFTContentReference{}, errors.Compose(ErrNFTBadContentReference, err)
+	}
+	return parseNFTCIDv1(b)
+}
+
+// parseNFTCIDv1 parses a binary CIDv1.
+func parseNFTCIDv1(b []byte) (NFTContentReference, error) {
+	version, n, err := readUvarint(b)
+	if err != nil {
+		return NFTContentReference{}, err
+	}
+	if version != cidv1 {
+		return NFTContentReference{}, errors.AddContext(ErrNFTBadContentReference, "unsupported cid version")
+	}
+	codec, m, err := readUvarint(b[n:])
+	if err != nil {
+		return NFTContentReference{}, err
+	}
+	r := NFTContentReference{Kind: NFTReferenceCIDv1, Codec: codec, Multihash: b[n+m:]}
+	return r, r.Validate()
+}
+
+// MarshalJSON marshals a reference as its kind and string encoding.
+func (r NFTContentReference) MarshalJSON() ([]byte, error) {
+	return json.Marshal(nftContentReferenceJSON{Kind: r.Kind, Value: r.String()})
+}
+
+// UnmarshalJSON unmarshals and validates a reference.
+func (r *NFTContentReference) UnmarshalJSON(b []byte) error {
+	var rj nftContentReferenceJSON
+	if err := json.Unmarshal(b, &rj); err != nil {
+		return errors.Compose(ErrNFTBadContentReference, err)
+	}
+	var parsed NFTContentReference
+	var err error
+	if rj.Kind == NFTReferenceMultihash {
+		parsed, err = ParseNFTMultihash(rj.Value)
+	} else {
+		parsed, err = ParseNFTCID(rj.Value)
+	}
+	if err != nil {
+		return err
+	}
+	if parsed.Kind != rj.Kind {
+		return errors.AddContext(ErrNFTBadContentReference, "kind doesn't match the value")
+	}
+	*r = parsed
+	return nil
+}
+
+// encodeBase58 encodes b in base58btc.
+func encodeBase58(b []byte) string {
+	x := new(big.Int).SetBytes(b)
+	base, mod := big.NewInt(58), new(big.Int)
+	var out []byte
+	for x.Sign() > 0 {
+		x.DivMod(x, base, mod)
+		out = append(out, base58BTC[mod.Int64()])
+	}
+	for i := 0; i < len(b) && b[i] == 0; i++ {
+		out = append(out, base58BTC[0])
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
+
+// decodeBase58 decodes a base58btc string.
+func decodeBase58(s string) ([]byte, error) {
+	x, base := new(big.Int), big.NewInt(58)
+	zeros := 0
+	for zeros < len(s) && s[zeros] == base58BTC[0] {
+		zeros++
+	}
+	for i := 0; i < len(s); i++ {
+		digit := strings.IndexByte(base58BTC, s[i])
+		if digit < 0 {
+			return nil, errors.AddContext(ErrNFTBadContentReference, "invalid base58 character")
+		}
+		x.Mul(x, base)
+		x.Add(x, big.NewInt(int64(digit)))
+	}
+	return append(make([]byte, zeros), x.Bytes()...), nil
+}
+
+// NFTContentReferenceArbitraryData encodes the NFTVersion8 entry of a mint
+// with a content reference. The kind and the length of the reference are
+// followed by the reference and the version byte and body of the mint without
+// the reference.
+func NFTContentReferenceArbitraryData(nft NftCustody, r NFTContentReference) []byte {
+	mint := NFTArbitraryData(NFTMintTag, nft)
+	ref := r.Bytes()
+	arb := make([]byte, 0, len(mint)+NFTVersionLen+2+len(ref))
+	arb = append(arb, PrefixNFTCustody[:]...)
+	arb = append(arb, NFTVersion8, byte(r.Kind), byte(len(ref)))
+	arb = append(arb, ref...)
+	return append(arb, mint[SpecifierLen:]...)
+}
+
+// DecodeNFTContentReference decodes a reference of the given kind from its
+// binary form, as returned by Bytes, and validates it.
+func DecodeNFTContentReference(kind NFTContentReferenceKind, b []byte) (NFTContentReference, error) {
+	var r NFTContentReference
+	var err error
+	switch kind {
+	case NFTReferenceMultihash:
+		r = NFTContentReference{Kind: kind, Multihash: b}
+		err = r.Validate()
+	case NFTReferenceCIDv0:
+		r = NFTContentReference{Kind: kind, Codec: NFTCIDCodecDagPB, Multihash: b}
+		err = r.Validate()
+	case NFTReferenceCIDv1:
+		r, err = parseNFTCIDv1(b)
+	default:
+		err = errors.AddContext(ErrNFTBadContentReference, "unknown kind")
+	}
+	if err != nil {
+		return NFTContentReference{}, err
+	}
+	r.Multihash = append([]byte(nil), r.Multihash...)
+	return r, nil
+}
+
+// parseNFTReferenceClaim parses the body of a mint with a content reference:
+// the kind, length and reference followed by the version byte and body of the
+// wrapped mint.
+func parseNFTReferenceClaim(body []byte) ([]byte, NftCustody, NFTContentReference, error) {
+	if len(body) < 2 || len(body) < 2+int(body[1])+NFTVersionLen {
+		return nil, NftCustody{}, NFTContentReference{}, ErrNFTDataLength
+	}
+	ref := body[2 : 2+int(body[1])]
+	r, err := DecodeNFTContentReference(NFTContentReferenceKind(body[0]), ref)
+	if err != nil {
+		return nil, NftCustody{}, NFTContentReference{}, err
+	}
+	mint := body[2+len(ref):]
+	parse, ok := nftReferenceMintParsers[mint[0]]
+	if !ok {
+		return nil, NftCustody{}, NFTContentReference{}, ErrNFTReferenceNotMint
+	}
+	tag, nft, err := parse(mint[NFTVersionLen:])
+	if err != nil {
+		return nil, NftCustody{}, NFTContentReference{}, err
+	}
+	if !NFTTagEqual(tag, NFTMintTag) {
+		return nil, NftCustody{}, NFTContentReference{}, ErrNFTReferenceNotMint
+	}
+	return tag, nft, r, nil
+}
+
+// parseNFTReferenceMint parses the body of a mint with a content reference
+// and drops the reference.
+func parseNFTReferenceMint(body []byte) ([]byte, NftCustody, error) {
+	tag, nft, _, err := parseNFTReferenceClaim(body)
+	return tag, nft, err
+}
+
+// ParseNFTContentReference parses the NFT and the content reference of a
+// mint's arbitrary data entry. ErrNFTNoContentReference is returned for
+// entries which don't embed a reference.
+func ParseNFTContentReference(arb []byte) (NftCustody, NFTContentReference, error) {
+	version, body, ok := splitNFTArbitraryData(arb)
+	if !ok || version != NFTVersion8 {
+		return NftCustody{}, NFTContentReference{}, ErrNFTNoContentReference
+	}
+	_, nft, r, err := parseNFTReferenceClaim(body)
+	return nft, r, err
+}
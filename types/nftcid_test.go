@@ -0,0 +1,183 @@
+package types
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+)
+
+// TestParseNFTContentReference checks parsing and encoding content references
+// against vectors created by ipfs.
+func TestParseNFTContentReference(t *testing.T) {
+	data := []byte("hello world")
+	rawCID := "bafkreifzjut3te2nhyekklss27nh3k72ysco7y32koao5eei66wof36n5e"
+	if cid := NFTRawCID(data); cid.String() != rawCID {
+		t.Fatal("wrong raw cid", cid)
+	}
+
+	tests := []struct {
+		name  string
+		cid   string
+		kind  NFTContentReferenceKind
+		codec uint64
+		mh    string
+		str   string
+	}{
+		{"cidv0", "QmT78zSuBmuS4z925WZfrqQ1qHaJ56DQaTfyMUF7F8ff5o", NFTReferenceCIDv0, NFTCIDCodecDagPB, "122046d44814b9c5af141c3aaab7c05dc5e844ead5f91f12858b021eba45768b4c0e", "QmT78zSuBmuS4z925WZfrqQ1qHaJ56DQaTfyMUF7F8ff5o"},
+		{"base32", rawCID, NFTReferenceCIDv1, NFTCIDCodecRaw, "1220b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9", rawCID},
+		{"base32 upper", "BAFKREIFZJUT3TE2NHYEKKLSS27NH3K72YSCO7Y32KOAO5EEI66WOF36N5E", NFTReferenceCIDv1, NFTCIDCodecRaw, "1220b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9", rawCID},
+		{"base16", "f015512" + "20b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9", NFTReferenceCIDv1, NFTCIDCodecRaw, "1220b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9", rawCID},
+	}
+	for _, test := range tests {
+		r, err := ParseNFTCID(test.cid)
+		if err != nil {
+			t.Fatalf("%v: %v", test.name, err)
+		}
+		if r.Kind != test.kind || r.Codec != test.codec || hex.EncodeToString(r.Multihash) != test.mh {
+			t.Fatalf("%v: wrong reference %v %v %x", test.name, r.Kind, r.Codec, r.Multihash)
+		}
+		if r.String() != test.str {
+			t.Fatalf("%v: wrong string %v", test.name, r)
+		}
+		b, err := json.Marshal(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded NFTContentReference
+		if err := json.Unmarshal(b, &decoded); err != nil || !decoded.Equals(r) {
+			t.Fatalf("%v: json doesn't round trip: %v", test.name, err)
+		}
+	}
+	mh := "b220" + "20" + "256c83b297114d201b30179f3f0ef0cace9783622da5974326b436178aeef610"
+	r, err := ParseNFTMultihash(mh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Kind != NFTReferenceMultihash || r.String() != mh {
+		t.Fatal("wrong multihash", r)
+	}
+
+	// Malformed references are rejected.
+	invalid := []string{
+		"QmT78zSuBmuS4z925WZfrqQ1qHaJ56DQaTfyMUF7F8ff50",
+		"bafkreifzjut3te2nhyekklss27nh3k72ysco7y32koao5eei66wof36n5",
+		"xafkreifzjut3te2nhyekklss27nh3k72ysco7y32koao5eei66wof36n5e",
+		"f025512" + "20b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9",
+		"f01551220",
+		"b",
+	}
+	for _, cid := range invalid {
+		if _, err := ParseNFTCID(cid); !errors.Contains(err, ErrNFTBadContentReference) {
+			t.Errorf("%v: expected ErrNFTBadContentReference but got %v", cid, err)
+		}
+	}
+	for _, mh := range []string{"", "1221" + hex.EncodeToString(make([]byte, 32)), "12" + "80" + "00", "zz"} {
+		if _, err := ParseNFTMultihash(mh); !errors.Contains(err, ErrNFTBadContentReference) {
+			t.Errorf("%v: expected ErrNFTBadContentReference but got %v", mh, err)
+		}
+	}
+	if err := json.Unmarshal([]byte(`{"kind":"cidv1","value":"QmT78zSuBmuS4z925WZfrqQ1qHaJ56DQaTfyMUF7F8ff5o"}`), &r); !errors.Contains(err, ErrNFTBadContentReference) {
+		t.Error("expected ErrNFTBadContentReference but got", err)
+	}
+}
+
+// TestVerifyNFTContent tests verifying data against the merkle root and
+// content reference of an NFT.
+func TestVerifyNFTContent(t *testing.T) {
+	data := []byte("hello world")
+	root := crypto.MerkleRoot(data)
+	blake := crypto.HashBytes(data)
+	cidv0, err := ParseNFTCID("QmT78zSuBmuS4z925WZfrqQ1qHaJ56DQaTfyMUF7F8ff5o")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		root crypto.Hash
+		ref  NFTContentReference
+		data []byte
+		err  error
+	}{
+		{"raw cid", root, NFTRawCID(data), data, nil},
+		{"blake2b multihash", root, NFTContentReference{Kind: NFTReferenceMultihash, Multihash: NewNFTMultihash(NFTMultihashBlake2b256, blake[:])}, data, nil},
+		{"wrong root", crypto.Hash{1}, NFTRawCID(data), data, ErrNFTContentMismatch},
+		{"wrong reference", root, NFTRawCID([]byte("other")), data, ErrNFTContentMismatch},
+		{"dag-pb cid", root, cidv0, data, ErrNFTReferenceUnverifiable},
+		{"unknown hash", root, NFTContentReference{Kind: NFTReferenceMultihash, Multihash: NewNFTMultihash(0x13, make([]byte, 64))}, data, ErrNFTReferenceUnverifiable},
+	}
+	for _, test := range tests {
+		err := VerifyNFTContent(test.root, test.ref, test.data)
+		if (test.err == nil && err != nil) || (test.err != nil && !errors.Contains(err, test.err)) {
+			t.Errorf("%v: expected %v but got %v", test.name, test.err, err)
+		}
+	}
+}
+
+// TestNFTContentReferenceArbitraryData tests encoding and parsing mints with a
+// content reference.
+func TestNFTContentReferenceArbitraryData(t *testing.T) {
+	nft := goldenNFT()
+	nft.Editions = 0
+	nft.ID = DeriveNftID(nft.Creator, nft.Collection, nft.FileMerkleRoot, nft.Nonce)
+	refs := []NFTContentReference{
+		NFTRawCID([]byte("hello world")),
+		{Kind: NFTReferenceMultihash, Multihash: NewNFTMultihash(NFTMultihashBlake2b256, make([]byte, 32))},
+	}
+	for _, mint := range []NftCustody{nft, {FileMerkleRoot: crypto.Hash{9}}} {
+		for _, ref := range refs {
+			arb := NFTContentReferenceArbitraryData(mint, ref)
+			version, tag, parsed, err := ParseNFTArbitraryData(arb)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if version != NFTVersion8 || !bytes.Equal(tag, NFTMintTag) || parsed != mint {
+				t.Fatal("wrong mint", version, string(tag), parsed)
+			}
+			parsed, parsedRef, err := ParseNFTContentReference(arb)
+			if err != nil || parsed != mint || !parsedRef.Equals(ref) {
+				t.Fatal("wrong reference", parsedRef, err)
+			}
+			if err := ValidateNFTTransaction(Transaction{ArbitraryData: [][]byte{arb}}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// Entries without a reference don't have one.
+	if _, _, err := ParseNFTContentReference(NFTArbitraryData(NFTMintTag, nft)); !errors.Contains(err, ErrNFTNoContentReference) {
+		t.Fatal("expected ErrNFTNoContentReference but got", err)
+	}
+
+	// Malformed entries are rejected.
+	valid := NFTContentReferenceArbitraryData(nft, refs[0])
+	refStart := SpecifierLen + NFTVersionLen + 2
+	refEnd := refStart + len(refs[0].Bytes())
+	badKind := append([]byte(nil), valid...)
+	badKind[SpecifierLen+NFTVersionLen] = 0
+	badRef := append([]byte(nil), valid...)
+	badRef[refStart] = 2
+	transfer := append(append([]byte(nil), valid[:refEnd]...), NFTArbitraryData(NFTTransferTag, NftCustody{FileMerkleRoot: crypto.Hash{9}})[SpecifierLen:]...)
+	nested := append(append([]byte(nil), valid[:refEnd]...), valid[SpecifierLen:]...)
+	tests := []struct {
+		name string
+		arb  []byte
+		err  error
+	}{
+		{"truncated", valid[:refEnd], ErrNFTDataLength},
+		{"truncated reference", valid[:refStart+4], ErrNFTDataLength},
+		{"bad kind", badKind, ErrNFTBadContentReference},
+		{"bad reference", badRef, ErrNFTBadContentReference},
+		{"transfer", transfer, ErrNFTReferenceNotMint},
+		{"nested", nested, ErrNFTReferenceNotMint},
+	}
+	for _, test := range tests {
+		if _, _, _, err := ParseNFTArbitraryData(test.arb); !errors.Contains(err, test.err) {
+			t.Errorf("%v: expected %v but got %v", test.name, test.err, err)
+		}
+	}
+}
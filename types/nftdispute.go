@@ -0,0 +1,225 @@
+package types
+
+import (
+	"unicode"
+	"unicode/utf8"
+
+	"gitlab.com/NebulousLabs/errors"
+)
+
+// nftdispute.go contains dispute flags, which let the governance of the lockup
+// pool freeze an NFT whose custody is disputed, e.g. because its content was
+// stolen or a court ordered it. Flags and unflags are authorized like spends of
+// the lockup pool: they spend an output of the governance address, so a quorum
+// of the governance keys has to sign them. They don't touch the NFT, which
+// stays at its address. While an NFT is frozen, consensus rejects every
+// transaction which sets its custody, apart from revocations of approvals.
+// Every flag carries the kind
+// and description of the dispute, so the history of an NFT's disputes can be
+// audited on chain.
+
+var (
+	// NFTFreezeTag marks transactions which flag an NFT as disputed and
+	// NFTUnfreezeTag transactions which resolve the dispute. Dispute flags
+	// use NFTVersion11.
+	NFTFreezeTag   = []byte{'F', 'Z'}
+	NFTUnfreezeTag = []byte{'U', 'F'}
+
+	// ErrNFTNotDisputeFlag is returned when parsing the reason of arbitrary
+	// data which is not a dispute flag.
+	ErrNFTNotDisputeFlag = errors.New("nft arbitrary data is not a dispute flag")
+
+	// ErrNFTBadDisputeReason is returned if a dispute flag has an unknown
+	// kind, a kind which doesn't match its tag or a description which isn't
+	// printable text.
+	ErrNFTBadDisputeReason = errors.New("nft dispute flag has an invalid reason")
+)
+
+const (
+	// NFTMaxDisputeDescriptionLength is the maximum length of the
+	// description of a dispute flag.
+	NFTMaxDisputeDescriptionLength = 255
+)
+
+// NFTDisputeKind is the kind of a dispute flag.
+type NFTDisputeKind uint8
+
+const (
+	// NFTDisputeResolved is the kind of every unflag.
+	NFTDisputeResolved NFTDisputeKind = iota
+	// NFTDisputeStolenContent flags NFTs whose content was minted by someone
+	// who doesn't hold its rights.
+	NFTDisputeStolenContent
+	// NFTDisputeCourtOrder flags NFTs which a court ordered to be frozen.
+	NFTDisputeCourtOrder
+	// NFTDisputeOther flags NFTs for any other reason given by the
+	// description.
+	NFTDisputeOther
+)
+
+// String implements fmt.Stringer.
+func (k NFTDisputeKind) String() string {
+	switch k {
+	case NFTDisputeResolved:
+		return "resolved"
+	case NFTDisputeStolenContent:
+		return "stolencontent"
+	case NFTDisputeCourtOrder:
+		return "courtorder"
+	case NFTDisputeOther:
+		return "other"
+	default:
+		return "unknown"
+	}
+}
+
+// LoadString parses a kind from its string representation.
+func (k *NFTDisputeKind) LoadString(s string) error {
+	for _, kind := range []NFTDisputeKind{NFTDisputeResolved, NFTDisputeStolenContent, NFTDisputeCourtOrder, NFTDisputeOther} {
+		if s == kind.String() {
+			*k = kind
+			return nil
+		}
+	}
+	return errors.AddContext(ErrNFTBadDisputeReason, "unknown kind "+s)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (k NFTDisputeKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (k *NFTDisputeKind) UnmarshalText(b []byte) error {
+	return k.LoadString(string(b))
+}
+
+type (
+	// NFTDisputeReason is the reason of a dispute flag.
+	NFTDisputeReason struct {
+		Kind        NFTDisputeKind `json:"kind"`
+		Description string         `json:"description"`
+	}
+
+	// NFTDisputeFlag is a confirmed flag or unflag of an NFT.
+	NFTDisputeFlag struct {
+		Frozen        bool             `json:"frozen"`
+		Reason        NFTDisputeReason `json:"reason"`
+		Height        BlockHeight      `json:"height"`
+		TransactionID TransactionID    `json:"transactionid"`
+	}
+
+	// NFTDispute is the history of the dispute flags of an NFT, oldest
+	// first.
+	NFTDispute struct {
+		ID    NftID            `json:"id"`
+		Flags []NFTDisputeFlag `json:"flags"`
+	}
+)
+
+// Frozen returns true if the last flag of the NFT froze it.
+func (d NFTDispute) Frozen() bool {
+	return len(d.Flags) > 0 && d.Flags[len(d.Flags)-1].Frozen
+}
+
+// validNFTDisputeReason checks that the kind of a reason matches the tag of
+// its flag and that the description is printable text.
+func validNFTDisputeReason(tag []byte, reason NFTDisputeReason) error {
+	unfreeze := NFTTagEqual(tag, NFTUnfreezeTag)
+	if reason.Kind > NFTDisputeOther || unfreeze != (reason.Kind == NFTDisputeResolved) {
+		return errors.AddContext(ErrNFTBadDisputeReason, "kind "+reason.Kind.String()+" doesn't match the tag")
+	}
+	if len(reason.Description) > NFTMaxDisputeDescriptionLength || !utf8.ValidString(reason.Description) {
+		return errors.AddContext(ErrNFTBadDisputeReason, "invalid description")
+	}
+	for _, r := range reason.Description {
+		if !unicode.IsPrint(r) {
+			return errors.AddContext(ErrNFTBadDisputeReason, "description isn't printable")
+		}
+	}
+	return nil
+}
+
+// NFTDisputeArbitraryData encodes the NFTVersion11 entry of a dispute flag
+// with the given tag. The reason is followed by the version byte and body of
+// the entry referencing the NFT.
+func NFTDisputeArbitraryData(tag []byte, nft NftCustody, reason NFTDisputeReason) []byte {
+	ref := NFTArbitraryData(tag, nft)
+	arb := make([]byte, 0, len(ref)+NFTVersionLen+2+len(reason.Description))
+	arb = append(arb, PrefixNFTCustody[:]...)
+	arb = append(arb, NFTVersion11)
+	arb = append(arb, byte(reason.Kind), byte(len(reason.Description)))
+	arb = append(arb, reason.Description...)
+	return append(arb, ref[SpecifierLen:]...)
+}
+
+// parseNFTDisputeFlagBody parses the body of a dispute flag: the reason
+// followed by the version byte and body of the entry referencing the NFT.
+func parseNFTDisputeFlagBody(body []byte) ([]byte, NftCustody, NFTDisputeReason, error) {
+	if len(body) < 2 || len(body) < 2+int(body[1])+NFTVersionLen+NFTTagLen {
+		return nil, NftCustody{}, NFTDisputeReason{}, ErrNFTDataLength
+	}
+	reason := NFTDisputeReason{
+		Kind:        NFTDisputeKind(body[0]),
+		Description: string(body[2 : 2+int(body[1])]),
+	}
+	ref := body[2+int(body[1]):]
+	tag := ref[NFTVersionLen : NFTVersionLen+NFTTagLen]
+	if !NFTTagEqual(tag, NFTFreezeTag) && !NFTTagEqual(tag, NFTUnfreezeTag) {
+		return nil, NftCustody{}, NFTDisputeReason{}, ErrNFTUnknownTag
+	}
+	nft, err := parseNFTReference(ref, tag)
+	if err != nil {
+		return nil, NftCustody{}, NFTDisputeReason{}, err
+	}
+	if err := validNFTDisputeReason(tag, reason); err != nil {
+		return nil, NftCustody{}, NFTDisputeReason{}, err
+	}
+	return tag, nft, reason, nil
+}
+
+// parseNFTDisputeFlag parses the body of an NFTVersion11 entry.
+func parseNFTDisputeFlag(body []byte) ([]byte, NftCustody, error) {
+	tag, nft, _, err := parseNFTDisputeFlagBody(body)
+	return tag, nft, err
+}
+
+// ParseNFTDisputeFlag returns the NFT and reason of a dispute flag's
+// arbitrary data.
+func ParseNFTDisputeFlag(arb []byte) (NftCustody, NFTDisputeReason, error) {
+	version, body, ok := splitNFTArbitraryData(arb)
+	if !ok {
+		return NftCustody{}, NFTDisputeReason{}, ErrNFTDataLength
+	}
+	if version != NFTVersion11 {
+		return NftCustody{}, NFTDisputeReason{}, ErrNFTNotDisputeFlag
+	}
+	_, nft, reason, err := parseNFTDisputeFlagBody(body)
+	return nft, reason, err
+}
+
+// IsNFTFreezeTransaction returns true if the transaction flags an NFT as
+// disputed.
+func IsNFTFreezeTransaction(t Transaction) bool {
+	return isNFTTransactionWithTag(t, NFTFreezeTag)
+}
+
+// IsNFTUnfreezeTransaction returns true if the transaction resolves the
+// dispute of an NFT.
+func IsNFTUnfreezeTransaction(t Transaction) bool {
+	return isNFTTransactionWithTag(t, NFTUnfreezeTag)
+}
+
+// IsNFTDisputeTransaction returns true if the transaction flags or unflags an
+// NFT.
+func IsNFTDisputeTransaction(t Transaction) bool {
+	return IsNFTFreezeTransaction(t) || IsNFTUnfreezeTransaction(t)
+}
+
+// MovesFrozenNFT returns true if the transaction would be rejected for an NFT
+// which is frozen by a dispute: every transaction which sets the custody of an
+// existing NFT, but not revocations of approvals, which only protect the
+// owner.
+func MovesFrozenNFT(t Transaction) bool {
+	return UpdatesNFTCustody(t) && !IsNFTMintTransaction(t) && !IsNFTRevokeApprovalTransaction(t)
+}
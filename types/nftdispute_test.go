@@ -0,0 +1,126 @@
+package types
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+)
+
+// TestNFTDisputeArbitraryData tests encoding and parsing dispute flags of
+// legacy and identified NFTs.
+func TestNFTDisputeArbitraryData(t *testing.T) {
+	var legacy, identified NftCustody
+	fastrand.Read(legacy.FileMerkleRoot[:])
+	fastrand.Read(identified.ID[:])
+	freeze := NFTDisputeReason{Kind: NFTDisputeCourtOrder, Description: "case 1234/56"}
+	unfreeze := NFTDisputeReason{Kind: NFTDisputeResolved}
+
+	for _, nft := range []NftCustody{legacy, identified} {
+		// Flags round trip through arbitrary data.
+		arb := NFTDisputeArbitraryData(NFTFreezeTag, nft, freeze)
+		version, tag, parsedNFT, err := ParseNFTArbitraryData(arb)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if version != NFTVersion11 || !bytes.Equal(tag, NFTFreezeTag) || parsedNFT.Identifier() != nft.Identifier() {
+			t.Fatal("unexpected entry", version, tag, parsedNFT)
+		}
+		parsedNFT, reason, err := ParseNFTDisputeFlag(arb)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reason != freeze || parsedNFT.Identifier() != nft.Identifier() {
+			t.Fatal("parsed reason doesn't match", reason, parsedNFT)
+		}
+		txn := Transaction{ArbitraryData: [][]byte{arb}}
+		if !IsNFTFreezeTransaction(txn) || IsNFTUnfreezeTransaction(txn) || !IsNFTDisputeTransaction(txn) || MovesFrozenNFT(txn) {
+			t.Fatal("freeze has the wrong kind")
+		}
+
+		// So do unflags.
+		arb = NFTDisputeArbitraryData(NFTUnfreezeTag, nft, unfreeze)
+		parsedNFT, reason, err = ParseNFTDisputeFlag(arb)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reason != unfreeze || parsedNFT.Identifier() != nft.Identifier() {
+			t.Fatal("parsed reason doesn't match", reason, parsedNFT)
+		}
+		txn = Transaction{ArbitraryData: [][]byte{arb}}
+		if IsNFTFreezeTransaction(txn) || !IsNFTUnfreezeTransaction(txn) {
+			t.Fatal("unfreeze has the wrong kind")
+		}
+	}
+
+	// Kinds have to match the tag and descriptions have to be printable.
+	for _, test := range []struct {
+		tag    []byte
+		reason NFTDisputeReason
+	}{
+		{NFTFreezeTag, NFTDisputeReason{Kind: NFTDisputeResolved}},
+		{NFTUnfreezeTag, NFTDisputeReason{Kind: NFTDisputeStolenContent}},
+		{NFTFreezeTag, NFTDisputeReason{Kind: NFTDisputeOther + 1}},
+		{NFTFreezeTag, NFTDisputeReason{Kind: NFTDisputeOther, Description: "line\nbreak"}},
+		{NFTFreezeTag, NFTDisputeReason{Kind: NFTDisputeOther, Description: "\xff"}},
+	} {
+		arb := NFTDisputeArbitraryData(test.tag, legacy, test.reason)
+		if _, _, err := ParseNFTDisputeFlag(arb); !errors.Contains(err, ErrNFTBadDisputeReason) {
+			t.Fatal("expected ErrNFTBadDisputeReason but got", err, test.reason)
+		}
+		if err := ValidateNFTTransaction(Transaction{ArbitraryData: [][]byte{arb}}); err == nil {
+			t.Fatal("invalid flag should be rejected")
+		}
+	}
+
+	// Flags only wrap the dispute tags and are truncated safely.
+	arb := NFTDisputeArbitraryData(NFTFreezeTag, legacy, freeze)
+	transfer := NFTArbitraryData(NFTTransferTag, legacy)
+	ref := len(arb) - len(transfer) + SpecifierLen
+	wrapped := append(append([]byte{}, arb[:ref]...), transfer[SpecifierLen:]...)
+	if _, _, err := ParseNFTDisputeFlag(wrapped); !errors.Contains(err, ErrNFTUnknownTag) {
+		t.Fatal("expected ErrNFTUnknownTag but got", err)
+	}
+	for i := SpecifierLen + NFTVersionLen; i < len(arb); i++ {
+		if _, _, err := ParseNFTDisputeFlag(arb[:i]); err == nil {
+			t.Fatal("truncated flag should be rejected", i)
+		}
+	}
+	if _, _, err := ParseNFTDisputeFlag(NFTArbitraryData(NFTFreezeTag, legacy)); !errors.Contains(err, ErrNFTNotDisputeFlag) {
+		t.Fatal("expected ErrNFTNotDisputeFlag but got", err)
+	}
+	long := NFTDisputeReason{Kind: NFTDisputeOther, Description: strings.Repeat("a", NFTMaxDisputeDescriptionLength)}
+	if _, _, err := ParseNFTDisputeFlag(NFTDisputeArbitraryData(NFTFreezeTag, legacy, long)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Kinds round trip through their string representation.
+	for _, kind := range []NFTDisputeKind{NFTDisputeResolved, NFTDisputeStolenContent, NFTDisputeCourtOrder, NFTDisputeOther} {
+		var parsed NFTDisputeKind
+		if err := parsed.LoadString(kind.String()); err != nil || parsed != kind {
+			t.Fatal("kind doesn't round trip", kind, parsed, err)
+		}
+	}
+	var parsed NFTDisputeKind
+	if err := parsed.LoadString("unknown"); err == nil {
+		t.Fatal("unknown kind should be rejected")
+	}
+}
+
+// TestNFTDisputeFrozen is a unit test for NFTDispute.Frozen.
+func TestNFTDisputeFrozen(t *testing.T) {
+	var d NFTDispute
+	if d.Frozen() {
+		t.Fatal("unflagged NFT shouldn't be frozen")
+	}
+	d.Flags = append(d.Flags, NFTDisputeFlag{Frozen: true})
+	if !d.Frozen() {
+		t.Fatal("flagged NFT should be frozen")
+	}
+	d.Flags = append(d.Flags, NFTDisputeFlag{Frozen: false})
+	if d.Frozen() {
+		t.Fatal("unflagged NFT shouldn't be frozen")
+	}
+}
@@ -0,0 +1,85 @@
+package types
+
+import (
+	"encoding/binary"
+
+	"gitlab.com/NebulousLabs/errors"
+)
+
+// nftedition.go contains edition mints, which mint one numbered edition out of
+// a limited run of NFTs with the same data. An edition mint is an identified
+// mint whose nonce is the index of the edition, from 1 to the number of
+// editions, and which additionally commits to the number of editions. All
+// editions of a run share the creator, collection and merkle root, and
+// consensus ensures that they also agree on the number of editions.
+
+// NFTEditionsLen is the length of the encoded number of editions of an edition
+// mint.
+const NFTEditionsLen = 8
+
+var (
+	// ErrNFTBadEdition is returned if the index of an edition is not between
+	// 1 and the number of editions.
+	ErrNFTBadEdition = errors.New("nft edition must be between 1 and the number of editions")
+	// ErrNFTEditionNotMint is returned if a transaction other than a mint
+	// commits to the number of editions of an NFT.
+	ErrNFTEditionNotMint = errors.New("only nft mints can commit to the number of editions")
+)
+
+// IsEdition returns true if the NFT was minted as an edition.
+func (nft NftCustody) IsEdition() bool {
+	return nft.Editions != 0
+}
+
+// Edition returns the index of an NFT which was minted as an edition.
+func (nft NftCustody) Edition() uint64 {
+	return nft.Nonce
+}
+
+// ValidateNFTEdition checks that edition is a valid index of a run of the given
+// number of editions.
+func ValidateNFTEdition(edition, editions uint64) error {
+	if edition == 0 || edition > editions {
+		return ErrNFTBadEdition
+	}
+	return nil
+}
+
+// nftEditionArbitraryData encodes the NFTVersion5 entry of an edition mint.
+// The number of editions is inserted between the merkle root and the identity
+// of an identified mint.
+func nftEditionArbitraryData(nft NftCustody) []byte {
+	identified := nftIdentifiedArbitraryData(NFTMintTag, nft)
+	headerLen := SpecifierLen + NFTVersionLen + NFTTagLen + NFTMerkleRootLength
+	arb := make([]byte, 0, len(identified)+NFTEditionsLen)
+	arb = append(arb, identified[:headerLen]...)
+	arb[SpecifierLen] = NFTVersion5
+	editions := make([]byte, NFTEditionsLen)
+	binary.BigEndian.PutUint64(editions, nft.Editions)
+	arb = append(arb, editions...)
+	return append(arb, identified[headerLen:]...)
+}
+
+// parseNFTEditionMint parses the body of an edition mint: a tag and merkle
+// root followed by the number of editions and the remainder of an identified
+// mint.
+func parseNFTEditionMint(body []byte) ([]byte, NftCustody, error) {
+	headerLen := NFTTagLen + NFTMerkleRootLength
+	if len(body) < headerLen+NFTEditionsLen {
+		return nil, NftCustody{}, ErrNFTDataLength
+	}
+	if !hasNFTTag(body, NFTMintTag) {
+		return nil, NftCustody{}, ErrNFTEditionNotMint
+	}
+	editions := binary.BigEndian.Uint64(body[headerLen:])
+	identified := append(append([]byte{}, body[:headerLen]...), body[headerLen+NFTEditionsLen:]...)
+	tag, nft, err := parseNFTIdentifiedMint(identified)
+	if err != nil {
+		return nil, NftCustody{}, err
+	}
+	if err := ValidateNFTEdition(nft.Nonce, editions); err != nil {
+		return nil, NftCustody{}, err
+	}
+	nft.Editions = editions
+	return tag, nft, nil
+}
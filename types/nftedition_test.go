@@ -0,0 +1,67 @@
+package types
+
+import (
+	"bytes"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+)
+
+// TestNFTEditionArbitraryData tests encoding and parsing edition mints.
+func TestNFTEditionArbitraryData(t *testing.T) {
+	nft := newTestIdentifiedNFT("prints", 2)
+	nft.Editions = 10
+	content := nft
+	content.ContentType = "image/png"
+	content.ContentLength = 1 << 20
+
+	for _, mint := range []NftCustody{nft, content} {
+		arb := NFTArbitraryData(NFTMintTag, mint)
+		version, tag, parsed, err := ParseNFTArbitraryData(arb)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if version != NFTVersion5 || !bytes.Equal(tag, NFTMintTag) || parsed != mint {
+			t.Fatal("parsed edition doesn't match", version, tag, parsed)
+		}
+		if !parsed.IsEdition() || parsed.Edition() != 2 {
+			t.Fatal("parsed nft isn't edition 2")
+		}
+	}
+
+	// Transactions following the mint reference the edition by its NftID.
+	_, _, parsed, err := ParseNFTArbitraryData(NFTArbitraryData(NFTTransferTag, nft))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed != (NftCustody{ID: nft.ID}) {
+		t.Fatal("parsed transfer doesn't match", parsed)
+	}
+
+	// Malformed editions.
+	zero := nft
+	zero.Nonce = 0
+	zero.ID = DeriveNftID(zero.Creator, zero.Collection, zero.FileMerkleRoot, zero.Nonce)
+	tooHigh := nft
+	tooHigh.Nonce = nft.Editions + 1
+	notMint := nftEditionArbitraryData(nft)
+	copy(notMint[SpecifierLen+NFTVersionLen:], NFTTransferTag)
+	valid := nftEditionArbitraryData(nft)
+	headerLen := SpecifierLen + NFTVersionLen + NFTTagLen + NFTMerkleRootLength
+	tests := []struct {
+		name string
+		arb  []byte
+		err  error
+	}{
+		{"edition zero", nftEditionArbitraryData(zero), ErrNFTBadEdition},
+		{"edition too high", nftEditionArbitraryData(tooHigh), ErrNFTBadEdition},
+		{"not a mint", notMint, ErrNFTEditionNotMint},
+		{"missing editions", valid[:headerLen+NFTEditionsLen-1], ErrNFTDataLength},
+		{"missing identity", valid[:headerLen+NFTEditionsLen], ErrNFTDataLength},
+	}
+	for _, test := range tests {
+		if _, _, _, err := ParseNFTArbitraryData(test.arb); !errors.Contains(err, test.err) {
+			t.Errorf("%v: expected %v but got %v", test.name, test.err, err)
+		}
+	}
+}
@@ -0,0 +1,331 @@
+package types
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+)
+
+// nftencoding.go contains the Sia and JSON encodings of the NFT types. The Sia
+// encodings are hashed into signed provenance documents, which is why they
+// keep the field by field layout the types had when they were encoded by
+// reflection. The JSON encodings are used by the API and by exported
+// provenance documents. NFTs carry the version of their JSON encoding, and all
+// NFT types are decoded strictly: unknown fields are rejected and the decoded
+// values are validated.
+
+const (
+	// NFTJSONVersion is the version of the JSON encoding of NFTs. NFTs
+	// without a version were encoded before the encoding was versioned and
+	// are decoded like the current version.
+	NFTJSONVersion = 1
+)
+
+var (
+	// ErrNFTBadEncoding is returned if an encoded NFT can't be decoded or
+	// describes an NFT which can't exist.
+	ErrNFTBadEncoding = errors.New("nft encoding is malformed")
+	// ErrNFTUnknownJSONVersion is returned if an NFT was encoded by a newer
+	// version of the JSON encoding.
+	ErrNFTUnknownJSONVersion = errors.New("nft json version is unknown")
+)
+
+type (
+	// nftCreatorJSON is the JSON encoding of the creator of an NFT.
+	nftCreatorJSON crypto.PublicKey
+
+	// nftCustodyJSON is the JSON encoding of an NftCustody.
+	nftCustodyJSON struct {
+		Version        uint64            `json:"version"`
+		FileMerkleRoot crypto.Hash       `json:"filemerkleroot"`
+		ContentType    string            `json:"contenttype"`
+		ContentLength  uint64            `json:"contentlength"`
+		ID             NftID             `json:"id"`
+		Creator        nftCreatorJSON    `json:"creator"`
+		Collection     string            `json:"collection"`
+		Nonce          uint64            `json:"nonce"`
+		Editions       uint64            `json:"editions"`
+		TransferPolicy NFTTransferPolicy `json:"transferpolicy"`
+	}
+
+	// nftOwnershipStatsJSON is the JSON encoding of an NftOwnershipStats.
+	nftOwnershipStatsJSON struct {
+		Nft   NftCustody `json:"nftroots"`
+		Owner UnlockHash `json:"nftowner"`
+	}
+
+	// nftTransferPolicyJSON is the JSON encoding of an NFTTransferPolicy.
+	nftTransferPolicyJSON struct {
+		Kind   NFTTransferPolicyKind `json:"kind"`
+		Height BlockHeight           `json:"height"`
+	}
+
+	// nftLockupJSON is the JSON encoding of an NFTLockup.
+	nftLockupJSON struct {
+		MintHeight BlockHeight `json:"mintheight"`
+		Reclaimed  bool        `json:"reclaimed"`
+	}
+)
+
+// unmarshalNFTJSON strictly decodes b into v, rejecting unknown fields.
+func unmarshalNFTJSON(b []byte, v interface{}) error {
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.DisallowUnknownFields()
+	if err := d.Decode(v); err != nil {
+		return errors.Compose(ErrNFTBadEncoding, err)
+	}
+	return nil
+}
+
+// MarshalJSON marshals the creator as a hex string.
+func (c nftCreatorJSON) MarshalJSON() ([]byte, error) {
+	return json.Marshal(hex.EncodeToString(c[:]))
+}
+
+// UnmarshalJSON unmarshals the creator from a hex string. NFTs encoded before
+// the encoding was versioned contain the creator as an array of bytes, which
+// is accepted as well.
+func (c *nftCreatorJSON) UnmarshalJSON(b []byte) error {
+	if len(b) == 0 || b[0] != '"' {
+		return json.Unmarshal(b, (*[crypto.PublicKeySize]byte)(c))
+	}
+	var str string
+	if err := json.Unmarshal(b, &str); err != nil {
+		return err
+	}
+	key, err := hex.DecodeString(str)
+	if err != nil || len(key) != len(c) {
+		return errors.AddContext(ErrNFTBadEncoding, "invalid creator")
+	}
+	copy(c[:], key)
+	return nil
+}
+
+// Validate checks that the fields of an NFT are consistent with each other.
+// The content commitment, identity, editions and transfer policy of an NFT are
+// all optional, but each of them has to be valid if it is set.
+func (nft NftCustody) Validate() error {
+	if nft.ContentType != "" {
+		if err := ValidateNFTContentType(nft.ContentType); err != nil {
+			return err
+		}
+	} else if nft.ContentLength != 0 {
+		return errors.AddContext(ErrNFTBadEncoding, "content length without content type")
+	}
+	if nft.HasIdentity() {
+		if err := ValidateNFTCollection(nft.Collection); err != nil {
+			return err
+		}
+		if nft.ID != DeriveNftID(nft.Creator, nft.Collection, nft.FileMerkleRoot, nft.Nonce) {
+			return errors.AddContext(ErrNFTBadIdentity, "id doesn't match identity")
+		}
+	} else if nft.Collection != "" || nft.Nonce != 0 || nft.Editions != 0 {
+		return errors.AddContext(ErrNFTBadIdentity, "missing creator")
+	}
+	if nft.IsEdition() {
+		if err := ValidateNFTEdition(nft.Edition(), nft.Editions); err != nil {
+			return err
+		}
+	}
+	if nft.HasTransferPolicy() {
+		if err := nft.TransferPolicy.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MarshalSia implements the encoding.SiaMarshaler interface.
+func (nft NftCustody) MarshalSia(w io.Writer) error {
+	e := encoding.NewEncoder(w)
+	e.Write(nft.FileMerkleRoot[:])
+	e.WritePrefixedBytes([]byte(nft.ContentType))
+	e.WriteUint64(nft.ContentLength)
+	e.Write(nft.ID[:])
+	e.Write(nft.Creator[:])
+	e.WritePrefixedBytes([]byte(nft.Collection))
+	e.WriteUint64(nft.Nonce)
+	e.WriteUint64(nft.Editions)
+	nft.TransferPolicy.MarshalSia(e)
+	return e.Err()
+}
+
+// UnmarshalSia implements the encoding.SiaUnmarshaler interface.
+func (nft *NftCustody) UnmarshalSia(r io.Reader) error {
+	d := encoding.NewDecoder(r, encoding.DefaultAllocLimit)
+	d.ReadFull(nft.FileMerkleRoot[:])
+	nft.ContentType = string(d.ReadPrefixedBytes())
+	nft.ContentLength = d.NextUint64()
+	d.ReadFull(nft.ID[:])
+	d.ReadFull(nft.Creator[:])
+	nft.Collection = string(d.ReadPrefixedBytes())
+	nft.Nonce = d.NextUint64()
+	nft.Editions = d.NextUint64()
+	if err := d.Err(); err != nil {
+		return err
+	}
+	if err := nft.TransferPolicy.UnmarshalSia(d); err != nil {
+		return err
+	}
+	return nft.Validate()
+}
+
+// MarshalJSON marshals an NFT together with the version of its encoding.
+func (nft NftCustody) MarshalJSON() ([]byte, error) {
+	return json.Marshal(nftCustodyJSON{
+		Version:        NFTJSONVersion,
+		FileMerkleRoot: nft.FileMerkleRoot,
+		ContentType:    nft.ContentType,
+		ContentLength:  nft.ContentLength,
+		ID:             nft.ID,
+		Creator:        nftCreatorJSON(nft.Creator),
+		Collection:     nft.Collection,
+		Nonce:          nft.Nonce,
+		Editions:       nft.Editions,
+		TransferPolicy: nft.TransferPolicy,
+	})
+}
+
+// UnmarshalJSON strictly unmarshals and validates an NFT.
+func (nft *NftCustody) UnmarshalJSON(b []byte) error {
+	var nj nftCustodyJSON
+	if err := unmarshalNFTJSON(b, &nj); err != nil {
+		return err
+	}
+	if nj.Version > NFTJSONVersion {
+		return ErrNFTUnknownJSONVersion
+	}
+	decoded := NftCustody{
+		FileMerkleRoot: nj.FileMerkleRoot,
+		ContentType:    nj.ContentType,
+		ContentLength:  nj.ContentLength,
+		ID:             nj.ID,
+		Creator:        crypto.PublicKey(nj.Creator),
+		Collection:     nj.Collection,
+		Nonce:          nj.Nonce,
+		Editions:       nj.Editions,
+		TransferPolicy: nj.TransferPolicy,
+	}
+	if err := decoded.Validate(); err != nil {
+		return err
+	}
+	*nft = decoded
+	return nil
+}
+
+// MarshalSia implements the encoding.SiaMarshaler interface.
+func (s NftOwnershipStats) MarshalSia(w io.Writer) error {
+	e := encoding.NewEncoder(w)
+	s.Nft.MarshalSia(e)
+	e.Write(s.Owner[:])
+	return e.Err()
+}
+
+// UnmarshalSia implements the encoding.SiaUnmarshaler interface.
+func (s *NftOwnershipStats) UnmarshalSia(r io.Reader) error {
+	d := encoding.NewDecoder(r, encoding.DefaultAllocLimit)
+	if err := s.Nft.UnmarshalSia(d); err != nil {
+		return err
+	}
+	d.ReadFull(s.Owner[:])
+	return d.Err()
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (s NftOwnershipStats) MarshalJSON() ([]byte, error) {
+	return json.Marshal(nftOwnershipStatsJSON(s))
+}
+
+// UnmarshalJSON strictly unmarshals an NftOwnershipStats.
+func (s *NftOwnershipStats) UnmarshalJSON(b []byte) error {
+	var sj nftOwnershipStatsJSON
+	if err := unmarshalNFTJSON(b, &sj); err != nil {
+		return err
+	}
+	*s = NftOwnershipStats(sj)
+	return nil
+}
+
+// MarshalSia implements the encoding.SiaMarshaler interface. The kind is
+// encoded as a uint64.
+func (p NFTTransferPolicy) MarshalSia(w io.Writer) error {
+	e := encoding.NewEncoder(w)
+	e.WriteUint64(uint64(p.Kind))
+	e.WriteUint64(uint64(p.Height))
+	return e.Err()
+}
+
+// UnmarshalSia implements the encoding.SiaUnmarshaler interface.
+func (p *NFTTransferPolicy) UnmarshalSia(r io.Reader) error {
+	d := encoding.NewDecoder(r, encoding.DefaultAllocLimit)
+	kind := d.NextUint64()
+	p.Height = BlockHeight(d.NextUint64())
+	if err := d.Err(); err != nil {
+		return err
+	}
+	if kind > uint64(^NFTTransferPolicyKind(0)) {
+		return ErrNFTBadTransferPolicy
+	}
+	p.Kind = NFTTransferPolicyKind(kind)
+	if *p != (NFTTransferPolicy{}) {
+		return p.Validate()
+	}
+	return nil
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (p NFTTransferPolicy) MarshalJSON() ([]byte, error) {
+	return json.Marshal(nftTransferPolicyJSON(p))
+}
+
+// UnmarshalJSON strictly unmarshals and validates a transfer policy.
+func (p *NFTTransferPolicy) UnmarshalJSON(b []byte) error {
+	var pj nftTransferPolicyJSON
+	if err := unmarshalNFTJSON(b, &pj); err != nil {
+		return err
+	}
+	if decoded := NFTTransferPolicy(pj); decoded != (NFTTransferPolicy{}) {
+		if err := decoded.Validate(); err != nil {
+			return err
+		}
+	}
+	*p = NFTTransferPolicy(pj)
+	return nil
+}
+
+// MarshalSia implements the encoding.SiaMarshaler interface.
+func (l NFTLockup) MarshalSia(w io.Writer) error {
+	e := encoding.NewEncoder(w)
+	e.WriteUint64(uint64(l.MintHeight))
+	e.WriteBool(l.Reclaimed)
+	return e.Err()
+}
+
+// UnmarshalSia implements the encoding.SiaUnmarshaler interface.
+func (l *NFTLockup) UnmarshalSia(r io.Reader) error {
+	d := encoding.NewDecoder(r, encoding.DefaultAllocLimit)
+	l.MintHeight = BlockHeight(d.NextUint64())
+	l.Reclaimed = d.NextBool()
+	return d.Err()
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (l NFTLockup) MarshalJSON() ([]byte, error) {
+	return json.Marshal(nftLockupJSON(l))
+}
+
+// UnmarshalJSON strictly unmarshals a lockup.
+func (l *NFTLockup) UnmarshalJSON(b []byte) error {
+	var lj nftLockupJSON
+	if err := unmarshalNFTJSON(b, &lj); err != nil {
+		return err
+	}
+	*l = NFTLockup(lj)
+	return nil
+}
@@ -0,0 +1,149 @@
+package types
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+)
+
+// goldenNFT returns the NFT the golden vectors are generated from.
+func goldenNFT() NftCustody {
+	nft := NftCustody{
+		FileMerkleRoot: crypto.Hash{1, 2, 3},
+		ContentType:    "image/png",
+		ContentLength:  1024,
+		Creator:        crypto.PublicKey{4, 5, 6},
+		Collection:     "art",
+		Nonce:          2,
+		Editions:       10,
+		TransferPolicy: NFTTransferPolicy{Kind: NFTTransferAfterHeight, Height: 500},
+	}
+	nft.ID = DeriveNftID(nft.Creator, nft.Collection, nft.FileMerkleRoot, nft.Nonce)
+	return nft
+}
+
+// TestNFTSiaEncodingGolden checks the Sia encodings of the NFT types against
+// golden vectors. The vectors were created with the reflection-based encoding
+// the types used before they implemented their own, and must never change
+// since the encodings are signed as part of provenance documents.
+func TestNFTSiaEncodingGolden(t *testing.T) {
+	nft := goldenNFT()
+	tests := []struct {
+		name    string
+		value   interface{}
+		decoded interface{}
+		golden  string
+	}{
+		{
+			name:    "nft",
+			value:   nft,
+			decoded: new(NftCustody),
+			golden:  "01020300000000000000000000000000000000000000000000000000000000000900000000000000696d6167652f706e670004000000000000c6361c9584cda9bcaa9ae8117d8a39c9299621ba63f1f414d7ed4cb061f9e7bd0405060000000000000000000000000000000000000000000000000000000000030000000000000061727402000000000000000a000000000000000200000000000000f401000000000000",
+		},
+		{
+			name:    "legacy nft",
+			value:   NftCustody{FileMerkleRoot: crypto.Hash{9}},
+			decoded: new(NftCustody),
+			golden:  "0900000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+		},
+		{
+			name:    "ownership stats",
+			value:   NftOwnershipStats{Nft: nft, Owner: UnlockHash{7}},
+			decoded: new(NftOwnershipStats),
+			golden:  "01020300000000000000000000000000000000000000000000000000000000000900000000000000696d6167652f706e670004000000000000c6361c9584cda9bcaa9ae8117d8a39c9299621ba63f1f414d7ed4cb061f9e7bd0405060000000000000000000000000000000000000000000000000000000000030000000000000061727402000000000000000a000000000000000200000000000000f4010000000000000700000000000000000000000000000000000000000000000000000000000000",
+		},
+		{
+			name:    "lockup",
+			value:   NFTLockup{MintHeight: 100, Reclaimed: true},
+			decoded: new(NFTLockup),
+			golden:  "640000000000000001",
+		},
+	}
+	for _, test := range tests {
+		b := encoding.Marshal(test.value)
+		if hex.EncodeToString(b) != test.golden {
+			t.Fatalf("%v: encoding doesn't match golden vector: %x", test.name, b)
+		}
+		if err := encoding.Unmarshal(b, test.decoded); err != nil {
+			t.Fatalf("%v: %v", test.name, err)
+		}
+		if hex.EncodeToString(encoding.Marshal(test.decoded)) != test.golden {
+			t.Fatalf("%v: decoded value doesn't match", test.name)
+		}
+	}
+
+	// Decoding an invalid NFT fails.
+	invalid := nft
+	invalid.Nonce++
+	var decoded NftCustody
+	if err := decoded.UnmarshalSia(bytes.NewReader(encoding.Marshal(invalid))); !errors.Contains(err, ErrNFTBadIdentity) {
+		t.Fatal("expected ErrNFTBadIdentity but got", err)
+	}
+}
+
+// TestNFTJSONEncoding checks the JSON encodings of the NFT types against golden
+// vectors and that they are decoded strictly.
+func TestNFTJSONEncoding(t *testing.T) {
+	nft := goldenNFT()
+	golden := `{"version":1,"filemerkleroot":"0102030000000000000000000000000000000000000000000000000000000000","contenttype":"image/png","contentlength":1024,"id":"c6361c9584cda9bcaa9ae8117d8a39c9299621ba63f1f414d7ed4cb061f9e7bd","creator":"0405060000000000000000000000000000000000000000000000000000000000","collection":"art","nonce":2,"editions":10,"transferpolicy":{"kind":"afterheight","height":500}}`
+	b, err := json.Marshal(nft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != golden {
+		t.Fatal("encoding doesn't match golden vector:", string(b))
+	}
+	var decoded NftCustody
+	if err := json.Unmarshal(b, &decoded); err != nil || decoded != nft {
+		t.Fatal("decoded nft doesn't match", err)
+	}
+
+	stats := NftOwnershipStats{Nft: nft, Owner: UnlockHash{7}}
+	b, err = json.Marshal(stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decodedStats NftOwnershipStats
+	if err := json.Unmarshal(b, &decodedStats); err != nil || decodedStats != stats {
+		t.Fatal("decoded stats don't match", err)
+	}
+
+	// NFTs encoded before the encoding was versioned are still decoded.
+	legacy := `{"FileMerkleRoot":"0102030000000000000000000000000000000000000000000000000000000000","ContentType":"image/png","ContentLength":1024,"ID":"c6361c9584cda9bcaa9ae8117d8a39c9299621ba63f1f414d7ed4cb061f9e7bd","Creator":[4,5,6,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0],"Collection":"art","Nonce":2,"Editions":10,"TransferPolicy":{"kind":"afterheight","height":500}}`
+	decoded = NftCustody{}
+	if err := json.Unmarshal([]byte(legacy), &decoded); err != nil || decoded != nft {
+		t.Fatal("decoded legacy nft doesn't match", err)
+	}
+
+	// Invalid encodings are rejected.
+	tests := []struct {
+		name string
+		json string
+		err  error
+	}{
+		{"unknown field", `{"filemerkleroot":"0102030000000000000000000000000000000000000000000000000000000000","owner":"x"}`, ErrNFTBadEncoding},
+		{"newer version", `{"version":2}`, ErrNFTUnknownJSONVersion},
+		{"bad content type", `{"contenttype":"Image/PNG"}`, ErrNFTBadContentType},
+		{"length without type", `{"contentlength":5}`, ErrNFTBadEncoding},
+		{"identity without creator", `{"collection":"art"}`, ErrNFTBadIdentity},
+		{"bad edition", `{"id":"c6361c9584cda9bcaa9ae8117d8a39c9299621ba63f1f414d7ed4cb061f9e7bd","creator":"0405060000000000000000000000000000000000000000000000000000000000","filemerkleroot":"0102030000000000000000000000000000000000000000000000000000000000","collection":"art","nonce":2,"editions":1}`, ErrNFTBadEdition},
+		{"bad policy", `{"transferpolicy":{"kind":"soulbound","height":5}}`, ErrNFTBadTransferPolicy},
+		{"unknown policy field", `{"transferpolicy":{"kind":"free","expiry":5}}`, ErrNFTBadEncoding},
+	}
+	for _, test := range tests {
+		var nft NftCustody
+		if err := json.Unmarshal([]byte(test.json), &nft); !errors.Contains(err, test.err) {
+			t.Errorf("%v: expected %v but got %v", test.name, test.err, err)
+		}
+	}
+	var lockup NFTLockup
+	if err := json.Unmarshal([]byte(`{"mintheight":5,"vested":true}`), &lockup); !errors.Contains(err, ErrNFTBadEncoding) {
+		t.Error("expected ErrNFTBadEncoding but got", err)
+	}
+}
@@ -0,0 +1,78 @@
+package types
+
+import (
+	"math"
+
+	"go.sia.tech/siad/build"
+)
+
+// nftgovernance.go contains the governance of the lockup pool. The outputs of
+// the lockup pool have zero-signature unlock conditions, so once governance is
+// active, consensus requires every transaction which spends them or which
+// liquidates an NFT and returns its lockup to also spend an output of the
+// governance address. The M-of-N unlock conditions of that address make the
+// governance keys co-sign these transactions.
+
+const (
+	// NFTGovernanceTestingSalt is the salt used to generate the governance
+	// key set of dev and testing builds.
+	NFTGovernanceTestingSalt = "saltgennftgovernance"
+)
+
+var (
+	// NFTGovernanceHeight is the height of the first block which requires
+	// the authorization of the governance.
+	NFTGovernanceHeight = build.Select(build.Var{
+		Dev:      BlockHeight(100),
+		Standard: BlockHeight(math.MaxUint64),
+		Testing:  BlockHeight(0),
+	}).(BlockHeight)
+
+	// NFTGovernanceUnlockConditions are the unlock conditions of the
+	// governance address. The standard network has no governance key set
+	// yet.
+	NFTGovernanceUnlockConditions = nftGovernanceUnlockConditions()
+)
+
+// nftGovernanceUnlockConditions returns the governance unlock conditions of
+// the current build.
+func nftGovernanceUnlockConditions() UnlockConditions {
+	if build.Release == "standard" {
+		return UnlockConditions{}
+	}
+	uc, _ := GenerateDeterministicMultisig(2, 3, NFTGovernanceTestingSalt)
+	return uc
+}
+
+// NFTGovernanceActive returns true if the block at the given height requires
+// the authorization of the governance.
+func NFTGovernanceActive(height BlockHeight) bool {
+	return len(NFTGovernanceUnlockConditions.PublicKeys) > 0 && height >= NFTGovernanceHeight
+}
+
+// IsNFTGovernanceAuthorized returns true if the transaction spends an output
+// of the governance address.
+func IsNFTGovernanceAuthorized(t Transaction) bool {
+	if len(NFTGovernanceUnlockConditions.PublicKeys) == 0 {
+		return false
+	}
+	governance := NFTGovernanceUnlockConditions.UnlockHash()
+	for _, sci := range t.SiacoinInputs {
+		if sci.UnlockConditions.UnlockHash() == governance {
+			return true
+		}
+	}
+	return false
+}
+
+// SpendsNFTLockupPool returns true if the transaction spends an output of the
+// lockup pool.
+func SpendsNFTLockupPool(t Transaction) bool {
+	lockupPool := NFTLockupUnlockConditions.UnlockHash()
+	for _, sci := range t.SiacoinInputs {
+		if sci.UnlockConditions.UnlockHash() == lockupPool {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,183 @@
+package types
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+)
+
+// nftid.go contains the identifiers of NFTs. Legacy NFTs are identified by the
+// merkle root of their data, which means that a file can only be minted once.
+// Mints using NFTVersion4 instead derive an NftID from the creator's public
+// key, a collection, the merkle root and a nonce chosen by the creator, so that
+// the same file can be minted as several distinct NFTs. All following
+// transactions of such an NFT reference it by its NftID.
+
+const (
+	// NFTMaxCollectionLength is the maximum length of the collection an
+	// identified mint can belong to.
+	NFTMaxCollectionLength = 64
+	// NFTNonceLen is the length of the encoded nonce of an identified mint.
+	NFTNonceLen = 8
+)
+
+var (
+	// SpecifierNftID is used as the prefix when deriving an NftID.
+	SpecifierNftID = NewSpecifier("nft id")
+
+	// ErrNFTBadIdentity is returned if an identified mint has no creator or
+	// a collection which is too long or contains characters other than
+	// printable ASCII.
+	ErrNFTBadIdentity = errors.New("nft identity is malformed")
+)
+
+// NftID uniquely identifies an NFT which was minted with an identity.
+type NftID crypto.Hash
+
+// DeriveNftID returns the NftID of the NFT minted by creator in collection
+// with the given merkle root and nonce.
+func DeriveNftID(creator crypto.PublicKey, collection string, root crypto.Hash, nonce uint64) NftID {
+	return NftID(crypto.HashAll(SpecifierNftID, creator, collection, root, nonce))
+}
+
+// MarshalJSON marshals an NftID as a hex string.
+func (id NftID) MarshalJSON() ([]byte, error) {
+	return json.Marshal(id.String())
+}
+
+// String prints the NftID in hex.
+func (id NftID) String() string {
+	return fmt.Sprintf("%x", id[:])
+}
+
+// LoadString loads an NftID from a string.
+func (id *NftID) LoadString(str string) error {
+	return (*crypto.Hash)(id).LoadString(str)
+}
+
+// UnmarshalJSON decodes the json hex string of the NftID.
+func (id *NftID) UnmarshalJSON(b []byte) error {
+	return (*crypto.Hash)(id).UnmarshalJSON(b)
+}
+
+// HasIdentity returns true if the NFT carries the creator, collection and
+// nonce its NftID is derived from. This is only the case for identified mints.
+func (nft NftCustody) HasIdentity() bool {
+	return nft.Creator != (crypto.PublicKey{})
+}
+
+// Identifier returns the key the NFT is tracked by. NFTs with an NftID are
+// tracked by it, legacy NFTs by their merkle root.
+func (nft NftCustody) Identifier() NftID {
+	if nft.ID != (NftID{}) {
+		return nft.ID
+	}
+	return NftID(nft.FileMerkleRoot)
+}
+
+// CreatorUnlockHash returns the address of the creator of an identified mint.
+// Identified mints have to be minted to this address.
+func (nft NftCustody) CreatorUnlockHash() UnlockHash {
+	return UnlockConditions{
+		PublicKeys:         []SiaPublicKey{Ed25519PublicKey(nft.Creator)},
+		SignaturesRequired: 1,
+	}.UnlockHash()
+}
+
+// ValidateNFTCollection checks that a collection can be embedded in an
+// identified mint. Collections may be empty.
+func ValidateNFTCollection(collection string) error {
+	if len(collection) > NFTMaxCollectionLength || !isPrintableASCII(collection) {
+		return errors.AddContext(ErrNFTBadIdentity, "invalid collection")
+	}
+	return nil
+}
+
+// nftIdentifiedArbitraryData encodes an NFTVersion4 entry. Mints embed the
+// merkle root and identity of the NFT, all other entries only its NftID.
+func nftIdentifiedArbitraryData(tag []byte, nft NftCustody) []byte {
+	arb := make([]byte, 0, SpecifierLen+NFTVersionLen+NFTTagLen+NFTMerkleRootLength)
+	arb = append(arb, PrefixNFTCustody[:]...)
+	arb = append(arb, NFTVersion4)
+	arb = append(arb, tag...)
+	if !NFTTagEqual(tag, NFTMintTag) {
+		return append(arb, nft.ID.String()...)
+	}
+	arb = append(arb, nft.FileMerkleRoot.String()...)
+	arb = append(arb, nft.Creator[:]...)
+	nonce := make([]byte, NFTNonceLen)
+	binary.BigEndian.PutUint64(nonce, nft.Nonce)
+	arb = append(arb, nonce...)
+	arb = append(arb, byte(len(nft.Collection)))
+	arb = append(arb, nft.Collection...)
+	if nft.HasContentCommitment() {
+		arb = append(arb, nftContentCommitment(nft)...)
+	}
+	return arb
+}
+
+// parseNFTIdentified parses the body of an NFTVersion4 entry. Mints are parsed
+// by parseNFTIdentifiedMint. All other entries have the layout of their
+// earlier versions with the NftID in place of the merkle root.
+func parseNFTIdentified(body []byte) ([]byte, NftCustody, error) {
+	if hasNFTTag(body, NFTMintTag) {
+		return parseNFTIdentifiedMint(body)
+	}
+	parse := parseNFTTagAndRoot
+	if hasNFTTag(body, NFTBridgeLockTag) {
+		parse = parseNFTBridgeLock
+	}
+	tag, nft, err := parse(body)
+	if err != nil {
+		return nil, NftCustody{}, err
+	}
+	return tag, identifiedNFT(nft), nil
+}
+
+// identifiedNFT moves the hash parsed in place of the merkle root of an
+// NFTVersion4 entry to the NFT's ID.
+func identifiedNFT(nft NftCustody) NftCustody {
+	nft.ID, nft.FileMerkleRoot = NftID(nft.FileMerkleRoot), crypto.Hash{}
+	return nft
+}
+
+// parseNFTIdentifiedMint parses the body of an identified mint: a tag and
+// merkle root followed by the creator's public key, the nonce, the length of
+// the collection and the collection. It may be followed by a content
+// commitment.
+func parseNFTIdentifiedMint(body []byte) ([]byte, NftCustody, error) {
+	headerLen := NFTTagLen + NFTMerkleRootLength
+	identityLen := headerLen + crypto.PublicKeySize + NFTNonceLen + 1
+	if len(body) < identityLen {
+		return nil, NftCustody{}, ErrNFTDataLength
+	}
+	tag, nft, err := parseNFTTagAndRoot(body[:headerLen])
+	if err != nil {
+		return nil, NftCustody{}, err
+	}
+	copy(nft.Creator[:], body[headerLen:])
+	nft.Nonce = binary.BigEndian.Uint64(body[headerLen+crypto.PublicKeySize:])
+	collectionLen := int(body[identityLen-1])
+	rest := body[identityLen:]
+	if collectionLen > len(rest) {
+		return nil, NftCustody{}, ErrNFTDataLength
+	}
+	nft.Collection = string(rest[:collectionLen])
+	if !nft.HasIdentity() {
+		return nil, NftCustody{}, errors.AddContext(ErrNFTBadIdentity, "missing creator")
+	}
+	if err := ValidateNFTCollection(nft.Collection); err != nil {
+		return nil, NftCustody{}, err
+	}
+	if content := rest[collectionLen:]; len(content) > 0 {
+		if err := parseNFTContentCommitment(&nft, content); err != nil {
+			return nil, NftCustody{}, err
+		}
+	}
+	nft.ID = DeriveNftID(nft.Creator, nft.Collection, nft.FileMerkleRoot, nft.Nonce)
+	return tag, nft, nil
+}
@@ -0,0 +1,115 @@
+package types
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+)
+
+// newTestIdentifiedNFT creates an NFT with a random merkle root and creator.
+func newTestIdentifiedNFT(collection string, nonce uint64) NftCustody {
+	var nft NftCustody
+	fastrand.Read(nft.FileMerkleRoot[:])
+	fastrand.Read(nft.Creator[:])
+	nft.Collection = collection
+	nft.Nonce = nonce
+	nft.ID = DeriveNftID(nft.Creator, nft.Collection, nft.FileMerkleRoot, nft.Nonce)
+	return nft
+}
+
+// TestDeriveNftID tests that every input of an NftID changes the ID.
+func TestDeriveNftID(t *testing.T) {
+	nft := newTestIdentifiedNFT("art", 1)
+	ids := map[NftID]struct{}{
+		nft.ID: {},
+		DeriveNftID(nft.Creator, nft.Collection, nft.FileMerkleRoot, 2):        {},
+		DeriveNftID(nft.Creator, "music", nft.FileMerkleRoot, 1):               {},
+		DeriveNftID(nft.Creator, nft.Collection, crypto.Hash{}, 1):             {},
+		DeriveNftID(crypto.PublicKey{}, nft.Collection, nft.FileMerkleRoot, 1): {},
+	}
+	if len(ids) != 5 {
+		t.Fatal("expected 5 distinct ids but got", len(ids))
+	}
+	if nft.Identifier() != nft.ID {
+		t.Fatal("identified nft should be tracked by its id")
+	}
+	legacy := NftCustody{FileMerkleRoot: nft.FileMerkleRoot}
+	if legacy.Identifier() != NftID(nft.FileMerkleRoot) {
+		t.Fatal("legacy nft should be tracked by its merkle root")
+	}
+}
+
+// TestNFTIdentifiedArbitraryData tests encoding and parsing NFTVersion4
+// entries.
+func TestNFTIdentifiedArbitraryData(t *testing.T) {
+	nft := newTestIdentifiedNFT("art", 7)
+	content := nft
+	content.ContentType = "image/png"
+	content.ContentLength = 1 << 20
+
+	// Mints embed the identity and optionally a content commitment.
+	for _, mint := range []NftCustody{nft, content} {
+		arb := NFTArbitraryData(NFTMintTag, mint)
+		version, tag, parsed, err := ParseNFTArbitraryData(arb)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if version != NFTVersion4 || !bytes.Equal(tag, NFTMintTag) || parsed != mint {
+			t.Fatal("parsed mint doesn't match", version, tag, parsed)
+		}
+	}
+
+	// All other entries only carry the NftID.
+	ref := NftCustody{ID: nft.ID}
+	for _, tag := range [][]byte{NFTTransferTag, NFTLiquidationTag, NFTReclaimTag, NFTBridgeUnlockTag} {
+		arb := NFTArbitraryData(tag, nft)
+		version, found, parsed, err := ParseNFTArbitraryData(arb)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if version != NFTVersion4 || !bytes.Equal(found, tag) || parsed != ref {
+			t.Fatal("parsed entry doesn't match", version, found, parsed)
+		}
+	}
+	claim := NFTBridgeClaim{Chain: "eth", Recipient: "0x01"}
+	parsed, parsedClaim, err := ParseNFTBridgeClaim(NFTBridgeLockArbitraryData(nft, claim))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed != ref || parsedClaim != claim {
+		t.Fatal("parsed bridge lock doesn't match", parsed, parsedClaim)
+	}
+
+	// Malformed mints.
+	valid := NFTArbitraryData(NFTMintTag, nft)
+	transfer := NFTArbitraryData(NFTTransferTag, nft)
+	noCreator := nft
+	noCreator.Creator = crypto.PublicKey{}
+	collectionLen := SpecifierLen + NFTVersionLen + NFTTagLen + NFTMerkleRootLength + crypto.PublicKeySize + NFTNonceLen
+	longCollection := append([]byte{}, valid...)
+	longCollection[collectionLen] = byte(len(nft.Collection) + 1)
+	badCollection := newTestIdentifiedNFT(strings.Repeat("a", NFTMaxCollectionLength+1), 0)
+	tests := []struct {
+		name string
+		arb  []byte
+		err  error
+	}{
+		{"truncated identity", valid[:collectionLen], ErrNFTDataLength},
+		{"collection too long for data", longCollection, ErrNFTDataLength},
+		{"no creator", nftIdentifiedArbitraryData(NFTMintTag, noCreator), ErrNFTBadIdentity},
+		{"long collection", nftIdentifiedArbitraryData(NFTMintTag, badCollection), ErrNFTBadIdentity},
+		{"space in collection", nftIdentifiedArbitraryData(NFTMintTag, newTestIdentifiedNFT("a b", 0)), ErrNFTBadIdentity},
+		{"truncated content", append(append([]byte{}, valid...), 0), ErrNFTDataLength},
+		{"truncated id", transfer[:len(transfer)-1], ErrNFTDataLength},
+	}
+	for _, test := range tests {
+		if _, _, _, err := ParseNFTArbitraryData(test.arb); !errors.Contains(err, test.err) {
+			t.Errorf("%v: expected %v but got %v", test.name, test.err, err)
+		}
+	}
+}
@@ -0,0 +1,357 @@
+package types
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+)
+
+// nftinsurance.go contains NFT insurance, which lets a third party guarantee
+// the availability of an NFT's data, e.g. a marketplace which hosts it. The
+// insurer burns collateral against the NFT until an expiry height and consensus
+// records the insurance. Until the insurance expires the owner of the NFT can
+// challenge the insurer to prove that the data is still available. A response
+// proves the storage of a segment of the data chosen by the block containing
+// the challenge. If no response is confirmed within NFTInsuranceResponseWindow
+// blocks of the challenge, the owner claims the collateral, which is minted to
+// the owner. Otherwise the collateral is released to the insurer once the
+// insurance expired.
+//
+// Challenges and claims spend the NFT's custody output like stakes, so only the
+// owner can submit them, and keep the NFT at its address. Insurances, responses
+// and releases don't touch the NFT and can be submitted by anyone. Releases
+// always pay out to the insurer's address.
+
+var (
+	// NFTInsureTag marks transactions which insure an NFT. Like stakes,
+	// insurance transactions always carry a version byte. Insurances use
+	// NFTVersion9 and responses use NFTVersion10, challenges, claims and
+	// releases use the layout of transfers.
+	NFTInsureTag             = []byte{'I', 'N'}
+	NFTInsuranceChallengeTag = []byte{'I', 'C'}
+	NFTInsuranceResponseTag  = []byte{'I', 'R'}
+	NFTInsuranceClaimTag     = []byte{'I', 'P'}
+	NFTInsuranceReleaseTag   = []byte{'I', 'E'}
+
+	// NFTInsurancePoolUnlockHash receives the collateral of insurances. No
+	// unlock conditions hash to it, so collateral is burned and minted again
+	// by claims and releases.
+	NFTInsurancePoolUnlockHash = UnlockHash{'I', 'N'}
+
+	// NFTMinInsuranceCollateral is the smallest collateral an NFT can be
+	// insured with.
+	NFTMinInsuranceCollateral = MustCurrency("100SC")
+
+	// NFTInsuranceResponseWindow is the number of blocks following the
+	// block of a challenge in which a response has to be confirmed.
+	NFTInsuranceResponseWindow = build.Select(build.Var{
+		Dev:      BlockHeight(10),
+		Standard: 3 * BlocksPerDay,
+		Testing:  BlockHeight(3),
+	}).(BlockHeight)
+
+	// ErrNFTNotInsurance is returned when parsing the terms of arbitrary
+	// data which doesn't insure an NFT.
+	ErrNFTNotInsurance = errors.New("nft arbitrary data is not an insurance")
+
+	// ErrNFTNotInsuranceResponse is returned when parsing the proof of
+	// arbitrary data which is not an insurance response.
+	ErrNFTNotInsuranceResponse = errors.New("nft arbitrary data is not an insurance response")
+
+	// nftReferenceParsers maps the versions an insurance, response or
+	// dispute flag can wrap to the function parsing their body.
+	nftReferenceParsers = map[byte]func(body []byte) ([]byte, NftCustody, error){
+		NFTVersion1: parseNFTTagAndRoot,
+		NFTVersion4: parseNFTIdentified,
+	}
+)
+
+const (
+	// NFTInsuranceTermsLen is the length of the encoded terms of an
+	// insurance: the big endian expiry height followed by the insurer's
+	// address.
+	NFTInsuranceTermsLen = 8 + crypto.HashSize
+)
+
+type (
+	// NFTInsuranceTerms are the terms an insurer sets when insuring an NFT.
+	// The insurance can be challenged in blocks below Expiry and the
+	// collateral is released to Insurer afterwards.
+	NFTInsuranceTerms struct {
+		Expiry  BlockHeight `json:"expiry"`
+		Insurer UnlockHash  `json:"insurer"`
+	}
+
+	// NFTInsurance is the insurance of an NFT. Root is the merkle root of
+	// the NFT's data, which responses have to prove. ChallengeHeight is the
+	// height of the block containing the open challenge, or zero if the
+	// insurance isn't challenged. Collateral is zero once the insurance was
+	// claimed or released. Paid is the sum of the collateral minted for all
+	// insurances of the NFT.
+	NFTInsurance struct {
+		Root            crypto.Hash `json:"root"`
+		Insurer         UnlockHash  `json:"insurer"`
+		Collateral      Currency    `json:"collateral"`
+		Height          BlockHeight `json:"height"`
+		Expiry          BlockHeight `json:"expiry"`
+		ChallengeHeight BlockHeight `json:"challengeheight"`
+		Paid            Currency    `json:"paid"`
+	}
+
+	// NFTInsuranceResponse proves the storage of the segment of an insured
+	// NFT's data chosen by a challenge. Segment is the segment and HashSet
+	// its merkle proof.
+	NFTInsuranceResponse struct {
+		NumSegments uint64
+		Segment     []byte
+		HashSet     []crypto.Hash
+	}
+)
+
+// Active returns true if the insurance wasn't claimed or released yet.
+func (i NFTInsurance) Active() bool {
+	return !i.Collateral.IsZero()
+}
+
+// Challenged returns true if the insurance has an open challenge.
+func (i NFTInsurance) Challenged() bool {
+	return i.Active() && i.ChallengeHeight != 0
+}
+
+// ResponseDeadline returns the first height at which the open challenge can
+// no longer be answered.
+func (i NFTInsurance) ResponseDeadline() BlockHeight {
+	return i.ChallengeHeight + NFTInsuranceResponseWindow
+}
+
+// CanChallenge returns true if the insurance can be challenged in the block at
+// the given height.
+func (i NFTInsurance) CanChallenge(height BlockHeight) bool {
+	return i.Active() && !i.Challenged() && height < i.Expiry
+}
+
+// CanRespond returns true if the open challenge can be answered in the block
+// at the given height.
+func (i NFTInsurance) CanRespond(height BlockHeight) bool {
+	return i.Challenged() && height > i.ChallengeHeight && height < i.ResponseDeadline()
+}
+
+// CanClaim returns true if the owner of the NFT can claim the collateral in
+// the block at the given height because the open challenge wasn't answered.
+func (i NFTInsurance) CanClaim(height BlockHeight) bool {
+	return i.Challenged() && height >= i.ResponseDeadline()
+}
+
+// CanRelease returns true if the collateral can be released to the insurer in
+// the block at the given height.
+func (i NFTInsurance) CanRelease(height BlockHeight) bool {
+	return i.Active() && !i.Challenged() && height >= i.Expiry
+}
+
+// NFTInsuranceSegmentIndex returns the index of the segment a response to a
+// challenge in the block challengeID has to prove.
+func NFTInsuranceSegmentIndex(challengeID BlockID, id NftID, numSegments uint64) uint64 {
+	if numSegments == 0 {
+		return 0
+	}
+	seed := crypto.HashAll(challengeID, id)
+	seedInt := new(big.Int).SetBytes(seed[:])
+	return seedInt.Mod(seedInt, new(big.Int).SetUint64(numSegments)).Uint64()
+}
+
+// NewNFTInsuranceResponse creates the response for data to a challenge of the
+// insurance of nft in the block challengeID.
+func NewNFTInsuranceResponse(data []byte, challengeID BlockID, nft NftCustody) NFTInsuranceResponse {
+	numSegments := crypto.CalculateLeaves(uint64(len(data)))
+	index := NFTInsuranceSegmentIndex(challengeID, nft.Identifier(), numSegments)
+	base, hashSet := crypto.MerkleProof(data, index)
+	return NFTInsuranceResponse{
+		NumSegments: numSegments,
+		Segment:     base,
+		HashSet:     hashSet,
+	}
+}
+
+// Verify checks that the response proves the segment of the data with the
+// given merkle root chosen by a challenge of the insurance of the NFT id in
+// the block challengeID.
+func (r NFTInsuranceResponse) Verify(challengeID BlockID, id NftID, root crypto.Hash) bool {
+	index := NFTInsuranceSegmentIndex(challengeID, id, r.NumSegments)
+	return r.NumSegments != 0 && crypto.VerifySegment(r.Segment, r.HashSet, r.NumSegments, index, root)
+}
+
+// NFTInsureArbitraryData encodes the NFTVersion9 entry of an insurance. The
+// terms are followed by the version byte and body of the entry referencing
+// the NFT.
+func NFTInsureArbitraryData(nft NftCustody, terms NFTInsuranceTerms) []byte {
+	ref := NFTArbitraryData(NFTInsureTag, nft)
+	arb := make([]byte, 0, len(ref)+NFTVersionLen+NFTInsuranceTermsLen)
+	arb = append(arb, PrefixNFTCustody[:]...)
+	arb = append(arb, NFTVersion9)
+	expiry := make([]byte, 8)
+	binary.BigEndian.PutUint64(expiry, uint64(terms.Expiry))
+	arb = append(arb, expiry...)
+	arb = append(arb, terms.Insurer[:]...)
+	return append(arb, ref[SpecifierLen:]...)
+}
+
+// NFTInsuranceResponseArbitraryData encodes the NFTVersion10 entry of a
+// response. The proof is followed by the version byte and body of the entry
+// referencing the NFT.
+func NFTInsuranceResponseArbitraryData(nft NftCustody, r NFTInsuranceResponse) []byte {
+	ref := NFTArbitraryData(NFTInsuranceResponseTag, nft)
+	arb := make([]byte, 0, len(ref)+NFTVersionLen+8+2+len(r.Segment)+len(r.HashSet)*crypto.HashSize)
+	arb = append(arb, PrefixNFTCustody[:]...)
+	arb = append(arb, NFTVersion10)
+	arb = appendNFTSegmentProof(arb, r.NumSegments, r.Segment, r.HashSet)
+	return append(arb, ref[SpecifierLen:]...)
+}
+
+// parseNFTReference parses the version byte and body of the entry an
+// insurance, response or dispute flag wraps and checks that it carries tag.
+func parseNFTReference(b []byte, tag []byte) (NftCustody, error) {
+	if len(b) < NFTVersionLen {
+		return NftCustody{}, ErrNFTDataLength
+	}
+	parse, ok := nftReferenceParsers[b[0]]
+	if !ok {
+		return NftCustody{}, ErrNFTUnsupportedVersion
+	}
+	found, nft, err := parse(b[NFTVersionLen:])
+	if err != nil {
+		return NftCustody{}, err
+	}
+	if !NFTTagEqual(found, tag) {
+		return NftCustody{}, ErrNFTUnknownTag
+	}
+	return nft, nil
+}
+
+// parseNFTInsuranceTerms parses the body of an insurance: the terms followed
+// by the version byte and body of the entry referencing the NFT.
+func parseNFTInsuranceTerms(body []byte) (NftCustody, NFTInsuranceTerms, error) {
+	if len(body) < NFTInsuranceTermsLen {
+		return NftCustody{}, NFTInsuranceTerms{}, ErrNFTDataLength
+	}
+	terms := NFTInsuranceTerms{
+		Expiry: BlockHeight(binary.BigEndian.Uint64(body)),
+	}
+	copy(terms.Insurer[:], body[8:])
+	nft, err := parseNFTReference(body[NFTInsuranceTermsLen:], NFTInsureTag)
+	if err != nil {
+		return NftCustody{}, NFTInsuranceTerms{}, err
+	}
+	return nft, terms, nil
+}
+
+// parseNFTInsure parses the body of an NFTVersion9 entry.
+func parseNFTInsure(body []byte) ([]byte, NftCustody, error) {
+	nft, _, err := parseNFTInsuranceTerms(body)
+	if err != nil {
+		return nil, NftCustody{}, err
+	}
+	return NFTInsureTag, nft, nil
+}
+
+// parseNFTInsuranceResponseBody parses the body of a response: the proof
+// followed by the version byte and body of the entry referencing the NFT.
+func parseNFTInsuranceResponseBody(body []byte) (NftCustody, NFTInsuranceResponse, error) {
+	var r NFTInsuranceResponse
+	var n int
+	var err error
+	r.NumSegments, r.Segment, r.HashSet, n, err = parseNFTSegmentProof(body)
+	if err != nil {
+		return NftCustody{}, NFTInsuranceResponse{}, err
+	}
+	nft, err := parseNFTReference(body[n:], NFTInsuranceResponseTag)
+	if err != nil {
+		return NftCustody{}, NFTInsuranceResponse{}, err
+	}
+	return nft, r, nil
+}
+
+// parseNFTInsuranceResponse parses the body of an NFTVersion10 entry.
+func parseNFTInsuranceResponse(body []byte) ([]byte, NftCustody, error) {
+	nft, _, err := parseNFTInsuranceResponseBody(body)
+	if err != nil {
+		return nil, NftCustody{}, err
+	}
+	return NFTInsuranceResponseTag, nft, nil
+}
+
+// ParseNFTInsuranceTerms returns the NFT and terms of an insurance's arbitrary
+// data.
+func ParseNFTInsuranceTerms(arb []byte) (NftCustody, NFTInsuranceTerms, error) {
+	version, body, ok := splitNFTArbitraryData(arb)
+	if !ok {
+		return NftCustody{}, NFTInsuranceTerms{}, ErrNFTDataLength
+	}
+	if version != NFTVersion9 {
+		return NftCustody{}, NFTInsuranceTerms{}, ErrNFTNotInsurance
+	}
+	return parseNFTInsuranceTerms(body)
+}
+
+// ParseNFTInsuranceResponse returns the NFT and proof of a response's
+// arbitrary data.
+func ParseNFTInsuranceResponse(arb []byte) (NftCustody, NFTInsuranceResponse, error) {
+	version, body, ok := splitNFTArbitraryData(arb)
+	if !ok {
+		return NftCustody{}, NFTInsuranceResponse{}, ErrNFTDataLength
+	}
+	if version != NFTVersion10 {
+		return NftCustody{}, NFTInsuranceResponse{}, ErrNFTNotInsuranceResponse
+	}
+	return parseNFTInsuranceResponseBody(body)
+}
+
+// IsNFTInsuranceTransaction returns true if the transaction insures an NFT or
+// challenges, answers, claims or releases its insurance.
+func IsNFTInsuranceTransaction(t Transaction) bool {
+	if len(t.ArbitraryData) == 0 {
+		return false
+	}
+	_, tag, _, err := ParseNFTArbitraryData(t.ArbitraryData[0])
+	if err != nil {
+		return false
+	}
+	for _, insuranceTag := range [][]byte{NFTInsureTag, NFTInsuranceChallengeTag, NFTInsuranceResponseTag, NFTInsuranceClaimTag, NFTInsuranceReleaseTag} {
+		if NFTTagEqual(tag, insuranceTag) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsNFTInsureTransaction returns true if the transaction insures an NFT.
+func IsNFTInsureTransaction(t Transaction) bool {
+	return isNFTTransactionWithTag(t, NFTInsureTag)
+}
+
+// IsNFTInsuranceChallengeTransaction returns true if the transaction
+// challenges the insurance of an NFT.
+func IsNFTInsuranceChallengeTransaction(t Transaction) bool {
+	return isNFTTransactionWithTag(t, NFTInsuranceChallengeTag)
+}
+
+// IsNFTInsuranceResponseTransaction returns true if the transaction answers
+// the challenge of an insurance.
+func IsNFTInsuranceResponseTransaction(t Transaction) bool {
+	return isNFTTransactionWithTag(t, NFTInsuranceResponseTag)
+}
+
+// IsNFTInsuranceClaimTransaction returns true if the transaction claims the
+// collateral of an insurance whose challenge wasn't answered.
+func IsNFTInsuranceClaimTransaction(t Transaction) bool {
+	return isNFTTransactionWithTag(t, NFTInsuranceClaimTag)
+}
+
+// IsNFTInsuranceReleaseTransaction returns true if the transaction releases
+// the collateral of an expired insurance to the insurer.
+func IsNFTInsuranceReleaseTransaction(t Transaction) bool {
+	return isNFTTransactionWithTag(t, NFTInsuranceReleaseTag)
+}
@@ -0,0 +1,141 @@
+package types
+
+import (
+	"bytes"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+)
+
+// TestNFTInsurance is a unit test for the state of an insurance.
+func TestNFTInsurance(t *testing.T) {
+	var i NFTInsurance
+	if i.Active() || i.CanChallenge(1) || i.CanRelease(1) {
+		t.Fatal("missing insurance shouldn't be usable")
+	}
+	i.Collateral = NFTMinInsuranceCollateral
+	i.Expiry = 20
+	if !i.CanChallenge(19) || i.CanChallenge(20) {
+		t.Fatal("insurance should be challengeable until it expires")
+	}
+	if i.CanRelease(19) || !i.CanRelease(20) {
+		t.Fatal("insurance should be releasable once it expired")
+	}
+	if i.CanRespond(15) || i.CanClaim(15+NFTInsuranceResponseWindow) {
+		t.Fatal("unchallenged insurance can't be answered or claimed")
+	}
+
+	// Challenge the insurance right before it expires.
+	i.ChallengeHeight = 19
+	if !i.Challenged() || i.CanChallenge(19) || i.CanRelease(20) {
+		t.Fatal("challenged insurance can't be challenged or released")
+	}
+	if i.CanRespond(19) || !i.CanRespond(20) || i.CanRespond(19+NFTInsuranceResponseWindow) {
+		t.Fatal("challenge should be answerable in the response window")
+	}
+	if i.CanClaim(19+NFTInsuranceResponseWindow-1) || !i.CanClaim(19+NFTInsuranceResponseWindow) {
+		t.Fatal("challenge should be claimable after the response window")
+	}
+
+	// Claimed insurances are inactive.
+	i.Collateral = ZeroCurrency
+	if i.Active() || i.Challenged() || i.CanClaim(100) {
+		t.Fatal("claimed insurance should be inactive")
+	}
+}
+
+// TestNFTInsuranceArbitraryData tests encoding and parsing insurances and
+// responses of legacy and identified NFTs.
+func TestNFTInsuranceArbitraryData(t *testing.T) {
+	data := fastrand.Bytes(crypto.SegmentSize*5 + 3)
+	legacy := NftCustody{FileMerkleRoot: crypto.MerkleRoot(data)}
+	var identified NftCustody
+	fastrand.Read(identified.ID[:])
+	terms := NFTInsuranceTerms{Expiry: 1234, Insurer: UnlockHash{1, 2, 3}}
+	var challengeID BlockID
+	fastrand.Read(challengeID[:])
+
+	for _, nft := range []NftCustody{legacy, identified} {
+		// Insurances round trip through arbitrary data.
+		arb := NFTInsureArbitraryData(nft, terms)
+		version, tag, parsedNFT, err := ParseNFTArbitraryData(arb)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if version != NFTVersion9 || !bytes.Equal(tag, NFTInsureTag) || parsedNFT.Identifier() != nft.Identifier() {
+			t.Fatal("unexpected entry", version, tag, parsedNFT)
+		}
+		parsedNFT, parsedTerms, err := ParseNFTInsuranceTerms(arb)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parsedTerms != terms || parsedNFT.Identifier() != nft.Identifier() {
+			t.Fatal("parsed terms don't match", parsedTerms, parsedNFT)
+		}
+		txn := Transaction{ArbitraryData: [][]byte{arb}}
+		if !IsNFTInsureTransaction(txn) || !IsNFTInsuranceTransaction(txn) || IsNFTInsuranceResponseTransaction(txn) {
+			t.Fatal("insurance has the wrong kind")
+		}
+
+		// Responses round trip through arbitrary data.
+		response := NewNFTInsuranceResponse(data, challengeID, nft)
+		arb = NFTInsuranceResponseArbitraryData(nft, response)
+		version, tag, parsedNFT, err = ParseNFTArbitraryData(arb)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if version != NFTVersion10 || !bytes.Equal(tag, NFTInsuranceResponseTag) || parsedNFT.Identifier() != nft.Identifier() {
+			t.Fatal("unexpected entry", version, tag, parsedNFT)
+		}
+		parsedNFT, parsed, err := ParseNFTInsuranceResponse(arb)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parsedNFT.Identifier() != nft.Identifier() || !parsed.Verify(challengeID, nft.Identifier(), legacy.FileMerkleRoot) {
+			t.Fatal("parsed response doesn't verify", parsed)
+		}
+		if parsed.Verify(BlockID{}, nft.Identifier(), legacy.FileMerkleRoot) && NFTInsuranceSegmentIndex(BlockID{}, nft.Identifier(), parsed.NumSegments) != NFTInsuranceSegmentIndex(challengeID, nft.Identifier(), parsed.NumSegments) {
+			t.Fatal("response shouldn't verify for a different challenge")
+		}
+		txn = Transaction{ArbitraryData: [][]byte{arb}}
+		if !IsNFTInsuranceResponseTransaction(txn) || !IsNFTInsuranceTransaction(txn) || IsNFTInsureTransaction(txn) {
+			t.Fatal("response has the wrong kind")
+		}
+
+		// Challenges, claims and releases use the layout of transfers.
+		for _, tag := range [][]byte{NFTInsuranceChallengeTag, NFTInsuranceClaimTag, NFTInsuranceReleaseTag} {
+			txn := Transaction{ArbitraryData: [][]byte{NFTArbitraryData(tag, nft)}}
+			if !IsNFTInsuranceTransaction(txn) {
+				t.Fatalf("%s should be an insurance transaction", tag)
+			}
+		}
+	}
+	if IsNFTInsuranceTransaction(Transaction{ArbitraryData: [][]byte{NFTArbitraryData(NFTStakeTag, legacy)}}) {
+		t.Fatal("stake shouldn't be an insurance transaction")
+	}
+
+	// Terms and proofs can only be parsed from their own versions.
+	if _, _, err := ParseNFTInsuranceTerms(NFTArbitraryData(NFTInsureTag, legacy)); !errors.Contains(err, ErrNFTNotInsurance) {
+		t.Fatal("expected ErrNFTNotInsurance but got", err)
+	}
+	if _, _, err := ParseNFTInsuranceResponse(NFTArbitraryData(NFTInsuranceResponseTag, legacy)); !errors.Contains(err, ErrNFTNotInsuranceResponse) {
+		t.Fatal("expected ErrNFTNotInsuranceResponse but got", err)
+	}
+
+	// The wrapped entry needs to carry the tag of its wrapper.
+	arb := NFTInsureArbitraryData(legacy, terms)
+	wrongTag := append([]byte{}, arb...)
+	copy(wrongTag[SpecifierLen+NFTVersionLen+NFTInsuranceTermsLen+NFTVersionLen:], NFTTransferTag)
+	if _, _, err := ParseNFTInsuranceTerms(wrongTag); !errors.Contains(err, ErrNFTUnknownTag) {
+		t.Fatal("expected ErrNFTUnknownTag but got", err)
+	}
+	if _, _, err := ParseNFTInsuranceTerms(arb[:len(arb)-1]); !errors.Contains(err, ErrNFTDataLength) {
+		t.Fatal("expected ErrNFTDataLength but got", err)
+	}
+	if _, _, err := ParseNFTInsuranceTerms(arb[:SpecifierLen+NFTVersionLen+NFTInsuranceTermsLen]); !errors.Contains(err, ErrNFTDataLength) {
+		t.Fatal("expected ErrNFTDataLength but got", err)
+	}
+}
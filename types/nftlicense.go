@@ -0,0 +1,277 @@
+package types
+
+import (
+	"encoding/json"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+)
+
+// nftlicense.go contains licenses, which let the creator of an NFT commit to
+// the terms the NFT's data may be used under at mint time, so that galleries
+// can display the usage rights of every NFT. A license is either the
+// identifier of a standard license from the SPDX license list, like "CC-BY-4.0",
+// or the merkle root of a document with custom terms. Like the data of an NFT,
+// custom terms can be uploaded and pinned by the renter and are then served by
+// the NFT gateway under their merkle root. A mint with a license wraps a mint
+// of any other version, except a mint with a content reference, together with
+// the license. Consensus records the license of every NFT minted with one.
+
+const (
+	// NFTMaxLicenseIdentifierLength is the maximum length of the SPDX
+	// identifier a mint can commit to.
+	NFTMaxLicenseIdentifierLength = 64
+)
+
+const (
+	// NFTLicenseNone is the kind of the license of NFTs minted without one.
+	NFTLicenseNone NFTLicenseKind = iota
+	// NFTLicenseSPDX is a license from the SPDX license list, identified by
+	// its short identifier.
+	NFTLicenseSPDX
+	// NFTLicenseCustom is a license with custom terms, identified by the
+	// merkle root of the document containing the terms.
+	NFTLicenseCustom
+)
+
+var (
+	// ErrNFTBadLicense is returned if a license is unknown or its
+	// identifier doesn't match its kind.
+	ErrNFTBadLicense = errors.New("nft license is malformed")
+	// ErrNFTLicenseNotMint is returned if a transaction other than a mint
+	// embeds a license.
+	ErrNFTLicenseNotMint = errors.New("only nft mints can embed a license")
+	// ErrNFTNoLicense is returned when parsing the license of arbitrary data
+	// which doesn't embed one.
+	ErrNFTNoLicense = errors.New("nft arbitrary data doesn't embed a license")
+
+	// nftLicenseMintParsers maps the versions a mint with a license can
+	// wrap to the function parsing their body.
+	nftLicenseMintParsers = map[byte]func(body []byte) ([]byte, NftCustody, error){
+		NFTVersion1: parseNFTTagAndRoot,
+		NFTVersion2: parseNFTContentMint,
+		NFTVersion4: parseNFTIdentified,
+		NFTVersion5: parseNFTEditionMint,
+		NFTVersion6: parseNFTPolicyMint,
+	}
+
+	// nftLicenseKindNames are the names of the kinds of licenses used by the
+	// API.
+	nftLicenseKindNames = map[NFTLicenseKind]string{
+		NFTLicenseNone:   "none",
+		NFTLicenseSPDX:   "spdx",
+		NFTLicenseCustom: "custom",
+	}
+)
+
+type (
+	// NFTLicenseKind is the kind of identifier a license contains.
+	NFTLicenseKind byte
+
+	// NFTLicense is the license an NFT was minted under. Identifier is only
+	// used by NFTLicenseSPDX licenses and Terms only by NFTLicenseCustom
+	// licenses.
+	NFTLicense struct {
+		Kind       NFTLicenseKind
+		Identifier string
+		Terms      crypto.Hash
+	}
+
+	// nftLicenseJSON is the JSON encoding of a license.
+	nftLicenseJSON struct {
+		Kind       NFTLicenseKind `json:"kind"`
+		Identifier string         `json:"identifier,omitempty"`
+		Terms      *crypto.Hash   `json:"terms,omitempty"`
+	}
+)
+
+// String returns the name of the kind of license.
+func (k NFTLicenseKind) String() string {
+	if name, ok := nftLicenseKindNames[k]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// MarshalText marshals the kind of license as its name.
+func (k NFTLicenseKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText unmarshals the kind of license from its name.
+func (k *NFTLicenseKind) UnmarshalText(b []byte) error {
+	for kind, name := range nftLicenseKindNames {
+		if name == string(b) {
+			*k = kind
+			return nil
+		}
+	}
+	return errors.AddContext(ErrNFTBadLicense, "unknown kind "+string(b))
+}
+
+// NFTSPDXLicense returns the license with the given SPDX identifier.
+func NFTSPDXLicense(identifier string) NFTLicense {
+	return NFTLicense{Kind: NFTLicenseSPDX, Identifier: identifier}
+}
+
+// NFTCustomLicense returns the license whose terms are the given document.
+func NFTCustomLicense(terms []byte) NFTLicense {
+	return NFTLicense{Kind: NFTLicenseCustom, Terms: crypto.MerkleRoot(terms)}
+}
+
+// Validate checks that the license can be committed to by a mint. SPDX
+// identifiers consist of letters, digits, dots, dashes and plus signs.
+func (l NFTLicense) Validate() error {
+	switch l.Kind {
+	case NFTLicenseSPDX:
+		if l.Terms != (crypto.Hash{}) {
+			return errors.AddContext(ErrNFTBadLicense, "spdx license with terms")
+		}
+		if len(l.Identifier) == 0 || len(l.Identifier) > NFTMaxLicenseIdentifierLength {
+			return errors.AddContext(ErrNFTBadLicense, "invalid identifier length")
+		}
+		for _, c := range l.Identifier {
+			if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '.' || c == '-' || c == '+') {
+				return errors.AddContext(ErrNFTBadLicense, "invalid character in identifier")
+			}
+		}
+	case NFTLicenseCustom:
+		if l.Identifier != "" {
+			return errors.AddContext(ErrNFTBadLicense, "custom license with identifier")
+		}
+		if l.Terms == (crypto.Hash{}) {
+			return errors.AddContext(ErrNFTBadLicense, "custom license without terms")
+		}
+	default:
+		return ErrNFTBadLicense
+	}
+	return nil
+}
+
+// String returns the SPDX identifier of the license or the merkle root of its
+// custom terms.
+func (l NFTLicense) String() string {
+	switch l.Kind {
+	case NFTLicenseSPDX:
+		return l.Identifier
+	case NFTLicenseCustom:
+		return l.Terms.String()
+	default:
+		return l.Kind.String()
+	}
+}
+
+// Bytes returns the binary form of the license's identifier.
+func (l NFTLicense) Bytes() []byte {
+	if l.Kind == NFTLicenseCustom {
+		return append([]byte(nil), l.Terms[:]...)
+	}
+	return []byte(l.Identifier)
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (l NFTLicense) MarshalJSON() ([]byte, error) {
+	lj := nftLicenseJSON{Kind: l.Kind, Identifier: l.Identifier}
+	if l.Kind == NFTLicenseCustom {
+		lj.Terms = &l.Terms
+	}
+	return json.Marshal(lj)
+}
+
+// UnmarshalJSON strictly unmarshals and validates a license.
+func (l *NFTLicense) UnmarshalJSON(b []byte) error {
+	var lj nftLicenseJSON
+	if err := unmarshalNFTJSON(b, &lj); err != nil {
+		return err
+	}
+	decoded := NFTLicense{Kind: lj.Kind, Identifier: lj.Identifier}
+	if lj.Terms != nil {
+		decoded.Terms = *lj.Terms
+	}
+	if decoded != (NFTLicense{}) {
+		if err := decoded.Validate(); err != nil {
+			return err
+		}
+	}
+	*l = decoded
+	return nil
+}
+
+// NFTLicenseArbitraryData encodes the NFTVersion12 entry of a mint with a
+// license. The kind and the length of the license's identifier are followed by
+// the identifier and the version byte and body of the mint without the
+// license.
+func NFTLicenseArbitraryData(nft NftCustody, l NFTLicense) []byte {
+	mint := NFTArbitraryData(NFTMintTag, nft)
+	id := l.Bytes()
+	arb := make([]byte, 0, len(mint)+NFTVersionLen+2+len(id))
+	arb = append(arb, PrefixNFTCustody[:]...)
+	arb = append(arb, NFTVersion12, byte(l.Kind), byte(len(id)))
+	arb = append(arb, id...)
+	return append(arb, mint[SpecifierLen:]...)
+}
+
+// decodeNFTLicense decodes a license of the given kind from the binary form of
+// its identifier, as returned by Bytes, and validates it.
+func decodeNFTLicense(kind NFTLicenseKind, b []byte) (NFTLicense, error) {
+	l := NFTLicense{Kind: kind}
+	switch kind {
+	case NFTLicenseSPDX:
+		l.Identifier = string(b)
+	case NFTLicenseCustom:
+		if len(b) != len(l.Terms) {
+			return NFTLicense{}, errors.AddContext(ErrNFTBadLicense, "invalid terms length")
+		}
+		copy(l.Terms[:], b)
+	}
+	if err := l.Validate(); err != nil {
+		return NFTLicense{}, err
+	}
+	return l, nil
+}
+
+// parseNFTLicenseClaim parses the body of a mint with a license: the kind,
+// length and identifier of the license followed by the version byte and body
+// of the wrapped mint.
+func parseNFTLicenseClaim(body []byte) ([]byte, NftCustody, NFTLicense, error) {
+	if len(body) < 2 || len(body) < 2+int(body[1])+NFTVersionLen {
+		return nil, NftCustody{}, NFTLicense{}, ErrNFTDataLength
+	}
+	id := body[2 : 2+int(body[1])]
+	l, err := decodeNFTLicense(NFTLicenseKind(body[0]), id)
+	if err != nil {
+		return nil, NftCustody{}, NFTLicense{}, err
+	}
+	mint := body[2+len(id):]
+	parse, ok := nftLicenseMintParsers[mint[0]]
+	if !ok {
+		return nil, NftCustody{}, NFTLicense{}, ErrNFTLicenseNotMint
+	}
+	tag, nft, err := parse(mint[NFTVersionLen:])
+	if err != nil {
+		return nil, NftCustody{}, NFTLicense{}, err
+	}
+	if !NFTTagEqual(tag, NFTMintTag) {
+		return nil, NftCustody{}, NFTLicense{}, ErrNFTLicenseNotMint
+	}
+	return tag, nft, l, nil
+}
+
+// parseNFTLicenseMint parses the body of a mint with a license and drops the
+// license.
+func parseNFTLicenseMint(body []byte) ([]byte, NftCustody, error) {
+	tag, nft, _, err := parseNFTLicenseClaim(body)
+	return tag, nft, err
+}
+
+// ParseNFTLicense parses the NFT and the license of a mint's arbitrary data
+// entry. ErrNFTNoLicense is returned for entries which don't embed a license.
+func ParseNFTLicense(arb []byte) (NftCustody, NFTLicense, error) {
+	version, body, ok := splitNFTArbitraryData(arb)
+	if !ok || version != NFTVersion12 {
+		return NftCustody{}, NFTLicense{}, ErrNFTNoLicense
+	}
+	_, nft, l, err := parseNFTLicenseClaim(body)
+	return nft, l, err
+}
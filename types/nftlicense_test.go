@@ -0,0 +1,133 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+)
+
+// TestNFTLicense tests validating licenses and their JSON encoding.
+func TestNFTLicense(t *testing.T) {
+	custom := NFTCustomLicense([]byte("all rights reserved"))
+	for _, l := range []NFTLicense{NFTSPDXLicense("CC-BY-4.0"), NFTSPDXLicense("GPL-3.0+"), custom} {
+		if err := l.Validate(); err != nil {
+			t.Fatalf("%v: %v", l, err)
+		}
+		b, err := json.Marshal(l)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded NFTLicense
+		if err := json.Unmarshal(b, &decoded); err != nil || decoded != l {
+			t.Fatalf("%v: json doesn't round trip: %v %s", l, err, b)
+		}
+	}
+	if custom.Terms != crypto.MerkleRoot([]byte("all rights reserved")) {
+		t.Fatal("custom terms aren't identified by their merkle root")
+	}
+
+	// The zero license is encoded as a license of kind none.
+	var none NFTLicense
+	b, err := json.Marshal(none)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"kind":"none"}` {
+		t.Fatal("wrong encoding of the zero license", string(b))
+	}
+	if err := json.Unmarshal(b, &none); err != nil || none != (NFTLicense{}) {
+		t.Fatal("zero license doesn't round trip", err)
+	}
+
+	invalid := []NFTLicense{
+		{},
+		NFTSPDXLicense(""),
+		NFTSPDXLicense("MIT OR Apache-2.0"),
+		NFTSPDXLicense(strings.Repeat("a", NFTMaxLicenseIdentifierLength+1)),
+		{Kind: NFTLicenseSPDX, Identifier: "MIT", Terms: crypto.Hash{1}},
+		{Kind: NFTLicenseCustom},
+		{Kind: NFTLicenseCustom, Identifier: "MIT", Terms: crypto.Hash{1}},
+		{Kind: 7, Identifier: "MIT"},
+	}
+	for _, l := range invalid {
+		if err := l.Validate(); !errors.Contains(err, ErrNFTBadLicense) {
+			t.Errorf("%+v: expected ErrNFTBadLicense but got %v", l, err)
+		}
+	}
+	if err := json.Unmarshal([]byte(`{"kind":"spdx","identifier":""}`), &none); !errors.Contains(err, ErrNFTBadLicense) {
+		t.Fatal("expected ErrNFTBadLicense but got", err)
+	}
+}
+
+// TestNFTLicenseArbitraryData tests encoding and parsing mints with a license.
+func TestNFTLicenseArbitraryData(t *testing.T) {
+	var legacy NftCustody
+	fastrand.Read(legacy.FileMerkleRoot[:])
+	content := legacy
+	content.ContentType = "text/plain"
+	content.ContentLength = 10
+	policy := legacy
+	policy.TransferPolicy = NFTTransferPolicy{Kind: NFTTransferSoulbound}
+	identified := newTestIdentifiedNFT("members", 1)
+	edition := newTestIdentifiedNFT("badges", 3)
+	edition.Editions = 5
+
+	// The license can wrap every kind of mint.
+	licenses := []NFTLicense{NFTSPDXLicense("MIT"), NFTCustomLicense([]byte("terms"))}
+	for i, mint := range []NftCustody{legacy, content, policy, identified, edition} {
+		l := licenses[i%len(licenses)]
+		arb := NFTLicenseArbitraryData(mint, l)
+		version, tag, parsed, err := ParseNFTArbitraryData(arb)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if version != NFTVersion12 || !bytes.Equal(tag, NFTMintTag) || parsed != mint {
+			t.Fatal("parsed mint doesn't match", version, tag, parsed)
+		}
+		parsed, parsedLicense, err := ParseNFTLicense(arb)
+		if err != nil || parsed != mint || parsedLicense != l {
+			t.Fatal("parsed license doesn't match", parsedLicense, err)
+		}
+	}
+
+	// Other entries don't embed a license.
+	if _, _, err := ParseNFTLicense(NFTArbitraryData(NFTMintTag, legacy)); !errors.Contains(err, ErrNFTNoLicense) {
+		t.Fatal("expected ErrNFTNoLicense but got", err)
+	}
+
+	// Malformed mints.
+	valid := NFTLicenseArbitraryData(legacy, NFTSPDXLicense("MIT"))
+	mintStart := SpecifierLen + NFTVersionLen + 2 + len("MIT")
+	transfer := append([]byte{}, valid...)
+	copy(transfer[mintStart+NFTVersionLen:], NFTTransferTag)
+	referenced := append([]byte{}, valid...)
+	referenced[mintStart] = NFTVersion8
+	nested := append([]byte{}, valid...)
+	nested[mintStart] = NFTVersion12
+	badTerms := NFTLicenseArbitraryData(legacy, NFTSPDXLicense("MIT"))
+	badTerms[SpecifierLen+NFTVersionLen] = byte(NFTLicenseCustom)
+	tests := []struct {
+		name string
+		arb  []byte
+		err  error
+	}{
+		{"truncated license", valid[:mintStart], ErrNFTDataLength},
+		{"truncated mint", valid[:len(valid)-1], ErrNFTDataLength},
+		{"invalid identifier", NFTLicenseArbitraryData(legacy, NFTSPDXLicense("M I T")), ErrNFTBadLicense},
+		{"invalid terms", badTerms, ErrNFTBadLicense},
+		{"transfer", transfer, ErrNFTLicenseNotMint},
+		{"content reference", referenced, ErrNFTLicenseNotMint},
+		{"nested license", nested, ErrNFTLicenseNotMint},
+	}
+	for _, test := range tests {
+		if _, _, _, err := ParseNFTArbitraryData(test.arb); !errors.Contains(err, test.err) {
+			t.Errorf("%v: expected %v but got %v", test.name, test.err, err)
+		}
+	}
+}
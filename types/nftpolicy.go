@@ -0,0 +1,185 @@
+package types
+
+import (
+	"encoding/binary"
+
+	"gitlab.com/NebulousLabs/errors"
+)
+
+// nftpolicy.go contains transfer policies, which let the creator of an NFT
+// restrict its transfers at mint time. An NFT is either freely transferable,
+// soulbound to the address it was minted to, or only transferable from a
+// certain height on. Soulbound NFTs are used for credentials and membership
+// tokens. A mint with a policy wraps a mint of any other version together with
+// the policy, and consensus records the policy and rejects transfers and
+// bridge locks which violate it. Liquidations and reclaims are not transfers
+// and are always allowed.
+
+const (
+	// NFTTransferPolicyLen is the length of an encoded transfer policy: the
+	// kind of the policy followed by the big endian height.
+	NFTTransferPolicyLen = 1 + 8
+)
+
+const (
+	// NFTTransferFree is the policy of NFTs which can always be transferred.
+	// It is the policy of every NFT minted without a policy.
+	NFTTransferFree NFTTransferPolicyKind = iota
+	// NFTTransferSoulbound is the policy of NFTs which can never leave the
+	// address they were minted to.
+	NFTTransferSoulbound
+	// NFTTransferAfterHeight is the policy of NFTs which can only be
+	// transferred in blocks at or above the height of the policy.
+	NFTTransferAfterHeight
+)
+
+var (
+	// ErrNFTBadTransferPolicy is returned if a transfer policy is unknown,
+	// or if its height doesn't match its kind.
+	ErrNFTBadTransferPolicy = errors.New("nft transfer policy is malformed")
+	// ErrNFTPolicyNotMint is returned if a transaction other than a mint
+	// sets the transfer policy of an NFT.
+	ErrNFTPolicyNotMint = errors.New("only nft mints can set a transfer policy")
+
+	// nftPolicyMintParsers maps the versions a mint with a policy can wrap
+	// to the function parsing their body. Versions which can't encode a mint
+	// and NFTVersion6 itself are missing.
+	nftPolicyMintParsers = map[byte]func(body []byte) ([]byte, NftCustody, error){
+		NFTVersion1: parseNFTTagAndRoot,
+		NFTVersion2: parseNFTContentMint,
+		NFTVersion4: parseNFTIdentified,
+		NFTVersion5: parseNFTEditionMint,
+	}
+
+	// nftTransferPolicyNames are the names of the kinds of transfer
+	// policies used by the API.
+	nftTransferPolicyNames = map[NFTTransferPolicyKind]string{
+		NFTTransferFree:        "free",
+		NFTTransferSoulbound:   "soulbound",
+		NFTTransferAfterHeight: "afterheight",
+	}
+)
+
+type (
+	// NFTTransferPolicyKind is the kind of restriction a transfer policy
+	// places on the transfers of an NFT.
+	NFTTransferPolicyKind byte
+
+	// NFTTransferPolicy restricts the transfers of an NFT. Height is only
+	// used by NFTTransferAfterHeight policies and is zero otherwise.
+	NFTTransferPolicy struct {
+		Kind   NFTTransferPolicyKind `json:"kind"`
+		Height BlockHeight           `json:"height"`
+	}
+)
+
+// String returns the name of the kind of policy.
+func (k NFTTransferPolicyKind) String() string {
+	if name, ok := nftTransferPolicyNames[k]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// LoadString loads the kind of policy from its name.
+func (k *NFTTransferPolicyKind) LoadString(str string) error {
+	for kind, name := range nftTransferPolicyNames {
+		if name == str {
+			*k = kind
+			return nil
+		}
+	}
+	return errors.AddContext(ErrNFTBadTransferPolicy, "unknown policy "+str)
+}
+
+// MarshalText marshals the kind of policy as its name.
+func (k NFTTransferPolicyKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText unmarshals the kind of policy from its name.
+func (k *NFTTransferPolicyKind) UnmarshalText(b []byte) error {
+	return k.LoadString(string(b))
+}
+
+// Validate checks that the policy can be set by a mint.
+func (p NFTTransferPolicy) Validate() error {
+	switch p.Kind {
+	case NFTTransferFree, NFTTransferSoulbound:
+		if p.Height != 0 {
+			return errors.AddContext(ErrNFTBadTransferPolicy, "only afterheight policies have a height")
+		}
+	case NFTTransferAfterHeight:
+		if p.Height == 0 {
+			return errors.AddContext(ErrNFTBadTransferPolicy, "afterheight policy needs a height")
+		}
+	default:
+		return ErrNFTBadTransferPolicy
+	}
+	return nil
+}
+
+// AllowsTransfer returns true if the policy allows transferring the NFT in the
+// block at the given height.
+func (p NFTTransferPolicy) AllowsTransfer(height BlockHeight) bool {
+	switch p.Kind {
+	case NFTTransferFree:
+		return true
+	case NFTTransferAfterHeight:
+		return height >= p.Height
+	default:
+		return false
+	}
+}
+
+// HasTransferPolicy returns true if the NFT restricts its transfers.
+func (nft NftCustody) HasTransferPolicy() bool {
+	return nft.TransferPolicy != (NFTTransferPolicy{})
+}
+
+// nftPolicyArbitraryData encodes the NFTVersion6 entry of a mint with a
+// transfer policy. The policy is followed by the version byte and body of the
+// mint without the policy.
+func nftPolicyArbitraryData(nft NftCustody) []byte {
+	policy := nft.TransferPolicy
+	nft.TransferPolicy = NFTTransferPolicy{}
+	mint := NFTArbitraryData(NFTMintTag, nft)
+	arb := make([]byte, 0, len(mint)+NFTVersionLen+NFTTransferPolicyLen)
+	arb = append(arb, PrefixNFTCustody[:]...)
+	arb = append(arb, NFTVersion6, byte(policy.Kind))
+	height := make([]byte, 8)
+	binary.BigEndian.PutUint64(height, uint64(policy.Height))
+	arb = append(arb, height...)
+	return append(arb, mint[SpecifierLen:]...)
+}
+
+// parseNFTPolicyMint parses the body of a mint with a transfer policy: the
+// policy followed by the version byte and body of the wrapped mint.
+func parseNFTPolicyMint(body []byte) ([]byte, NftCustody, error) {
+	if len(body) < NFTTransferPolicyLen+NFTVersionLen {
+		return nil, NftCustody{}, ErrNFTDataLength
+	}
+	policy := NFTTransferPolicy{
+		Kind:   NFTTransferPolicyKind(body[0]),
+		Height: BlockHeight(binary.BigEndian.Uint64(body[1:])),
+	}
+	parse, ok := nftPolicyMintParsers[body[NFTTransferPolicyLen]]
+	if !ok {
+		return nil, NftCustody{}, ErrNFTPolicyNotMint
+	}
+	tag, nft, err := parse(body[NFTTransferPolicyLen+NFTVersionLen:])
+	if err != nil {
+		return nil, NftCustody{}, err
+	}
+	if !NFTTagEqual(tag, NFTMintTag) {
+		return nil, NftCustody{}, ErrNFTPolicyNotMint
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, NftCustody{}, err
+	}
+	if policy.Kind == NFTTransferFree {
+		return nil, NftCustody{}, errors.AddContext(ErrNFTBadTransferPolicy, "free nfts are minted without a policy")
+	}
+	nft.TransferPolicy = policy
+	return tag, nft, nil
+}
@@ -0,0 +1,127 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+)
+
+// TestNFTTransferPolicy is a unit test for the transfer policies.
+func TestNFTTransferPolicy(t *testing.T) {
+	tests := []struct {
+		policy  NFTTransferPolicy
+		valid   bool
+		allowed []bool // at heights 9, 10 and 11
+	}{
+		{NFTTransferPolicy{}, true, []bool{true, true, true}},
+		{NFTTransferPolicy{Kind: NFTTransferSoulbound}, true, []bool{false, false, false}},
+		{NFTTransferPolicy{Kind: NFTTransferAfterHeight, Height: 10}, true, []bool{false, true, true}},
+		{NFTTransferPolicy{Kind: NFTTransferAfterHeight}, false, nil},
+		{NFTTransferPolicy{Kind: NFTTransferSoulbound, Height: 10}, false, nil},
+		{NFTTransferPolicy{Kind: NFTTransferAfterHeight + 1}, false, nil},
+	}
+	for _, test := range tests {
+		err := test.policy.Validate()
+		if test.valid != (err == nil) {
+			t.Errorf("%+v: unexpected validation result %v", test.policy, err)
+		}
+		if !test.valid && !errors.Contains(err, ErrNFTBadTransferPolicy) {
+			t.Errorf("%+v: expected ErrNFTBadTransferPolicy but got %v", test.policy, err)
+		}
+		for i, allowed := range test.allowed {
+			if test.policy.AllowsTransfer(BlockHeight(9+i)) != allowed {
+				t.Errorf("%+v: expected transfer at height %v to be allowed: %v", test.policy, 9+i, allowed)
+			}
+		}
+	}
+
+	// Kinds are marshaled by name.
+	policy := NFTTransferPolicy{Kind: NFTTransferAfterHeight, Height: 10}
+	b, err := json.Marshal(policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"kind":"afterheight","height":10}` {
+		t.Fatal("unexpected json", string(b))
+	}
+	var decoded NFTTransferPolicy
+	if err := json.Unmarshal(b, &decoded); err != nil || decoded != policy {
+		t.Fatal("policy doesn't round trip", decoded, err)
+	}
+	if err := json.Unmarshal([]byte(`{"kind":"never"}`), &decoded); err == nil {
+		t.Fatal("unknown kind should be rejected")
+	}
+}
+
+// TestNFTPolicyArbitraryData tests encoding and parsing mints with a transfer
+// policy.
+func TestNFTPolicyArbitraryData(t *testing.T) {
+	soulbound := NFTTransferPolicy{Kind: NFTTransferSoulbound}
+	var legacy NftCustody
+	fastrand.Read(legacy.FileMerkleRoot[:])
+	legacy.TransferPolicy = soulbound
+	content := legacy
+	content.ContentType = "text/plain"
+	content.ContentLength = 10
+	identified := newTestIdentifiedNFT("members", 1)
+	identified.TransferPolicy = NFTTransferPolicy{Kind: NFTTransferAfterHeight, Height: 100}
+	edition := newTestIdentifiedNFT("badges", 3)
+	edition.Editions = 5
+	edition.TransferPolicy = soulbound
+
+	// The policy can wrap every kind of mint.
+	for _, mint := range []NftCustody{legacy, content, identified, edition} {
+		arb := NFTArbitraryData(NFTMintTag, mint)
+		version, tag, parsed, err := ParseNFTArbitraryData(arb)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if version != NFTVersion6 || !bytes.Equal(tag, NFTMintTag) || parsed != mint {
+			t.Fatal("parsed mint doesn't match", version, tag, parsed)
+		}
+	}
+
+	// The policy is not part of other entries.
+	arb := NFTArbitraryData(NFTTransferTag, legacy)
+	if version, _, parsed, err := ParseNFTArbitraryData(arb); err != nil || version != NFTVersion1 || parsed.HasTransferPolicy() {
+		t.Fatal("transfer shouldn't carry the policy", version, parsed, err)
+	}
+
+	// Malformed mints.
+	valid := NFTArbitraryData(NFTMintTag, legacy)
+	policyStart := SpecifierLen + NFTVersionLen
+	withPolicy := func(policy NFTTransferPolicy) []byte {
+		nft := legacy
+		nft.TransferPolicy = policy
+		return nftPolicyArbitraryData(nft)
+	}
+	transfer := append([]byte{}, valid...)
+	copy(transfer[policyStart+NFTTransferPolicyLen+NFTVersionLen:], NFTTransferTag)
+	bridgeLock := append([]byte{}, valid...)
+	bridgeLock[policyStart+NFTTransferPolicyLen] = NFTVersion3
+	nested := append([]byte{}, valid...)
+	nested[policyStart+NFTTransferPolicyLen] = NFTVersion6
+	tests := []struct {
+		name string
+		arb  []byte
+		err  error
+	}{
+		{"truncated policy", valid[:policyStart+NFTTransferPolicyLen], ErrNFTDataLength},
+		{"truncated mint", valid[:len(valid)-1], ErrNFTDataLength},
+		{"free policy", withPolicy(NFTTransferPolicy{}), ErrNFTBadTransferPolicy},
+		{"free policy with height", withPolicy(NFTTransferPolicy{Height: 1}), ErrNFTBadTransferPolicy},
+		{"unknown policy", withPolicy(NFTTransferPolicy{Kind: 7}), ErrNFTBadTransferPolicy},
+		{"afterheight without height", withPolicy(NFTTransferPolicy{Kind: NFTTransferAfterHeight}), ErrNFTBadTransferPolicy},
+		{"transfer", transfer, ErrNFTPolicyNotMint},
+		{"bridge lock", bridgeLock, ErrNFTPolicyNotMint},
+		{"nested policy", nested, ErrNFTPolicyNotMint},
+	}
+	for _, test := range tests {
+		if _, _, _, err := ParseNFTArbitraryData(test.arb); !errors.Contains(err, test.err) {
+			t.Errorf("%v: expected %v but got %v", test.name, test.err, err)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package types
+
+import (
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+)
+
+// nftprovenance.go contains the canonical provenance document of an NFT. A
+// provenance document contains every on-chain transaction of an NFT's chain of
+// custody together with the blocks they were confirmed in, and is signed by the
+// NFT's creator. It can be shared off-chain and verified without access to the
+// blockchain. The license the NFT was minted under is part of the mint and is
+// repeated in the document for consumers which don't parse transactions.
+
+var (
+	// ErrNFTProvenanceBadMint is returned if the provenance document's mint
+	// transaction is not a valid mint of the document's NFT.
+	ErrNFTProvenanceBadMint = errors.New("provenance mint is not a valid mint transaction of the nft")
+	// ErrNFTProvenanceBadTransfer is returned if one of the provenance
+	// document's transfers is not a valid transfer of the document's NFT.
+	ErrNFTProvenanceBadTransfer = errors.New("provenance transfer is not a valid transfer transaction of the nft")
+	// ErrNFTProvenanceBrokenChain is returned if a transfer doesn't spend the
+	// custody output of the previous transaction in the chain of custody.
+	ErrNFTProvenanceBrokenChain = errors.New("provenance transfer doesn't spend the previous custody output")
+	// ErrNFTProvenanceBadOrder is returned if the transactions of a
+	// provenance document are not ordered by block height.
+	ErrNFTProvenanceBadOrder = errors.New("provenance transactions are not ordered by block height")
+	// ErrNFTProvenanceBadCreator is returned if the provenance document's
+	// creator key doesn't belong to the address the NFT was minted to.
+	ErrNFTProvenanceBadCreator = errors.New("provenance creator key doesn't match the minted address")
+	// ErrNFTProvenanceBadLicense is returned if the provenance document's
+	// license doesn't match the license embedded in its mint.
+	ErrNFTProvenanceBadLicense = errors.New("provenance license doesn't match the mint")
+)
+
+type (
+	// NFTProvenanceEntry is a single transaction of an NFT's chain of
+	// custody together with a reference to the block it was confirmed in.
+	NFTProvenanceEntry struct {
+		Transaction Transaction `json:"transaction"`
+		BlockID     BlockID     `json:"blockid"`
+		BlockHeight BlockHeight `json:"blockheight"`
+	}
+
+	// NFTProvenance is the signed provenance document of an NFT. Transfers
+	// are ordered from the oldest to the newest transfer and may end with the
+	// NFT's liquidation. Lockup reclaims and bridge locks and unlocks move the
+	// NFT's custody output and are part of the transfers too.
+	//
+	// License is the license embedded in the mint, if any. It isn't covered
+	// by SigHash since the signed mint already commits to it.
+	NFTProvenance struct {
+		NFT       NftCustody           `json:"nft"`
+		Mint      NFTProvenanceEntry   `json:"mint"`
+		Transfers []NFTProvenanceEntry `json:"transfers"`
+		License   NFTLicense           `json:"license"`
+
+		// CreatorPublicKey is the key of the address the NFT was minted to
+		// and CreatorSignature its signature of the document's SigHash.
+		CreatorPublicKey SiaPublicKey     `json:"creatorpublickey"`
+		CreatorSignature crypto.Signature `json:"creatorsignature"`
+	}
+)
+
+// SigHash returns the hash of the provenance document which is signed by the
+// NFT's creator.
+func (p NFTProvenance) SigHash() crypto.Hash {
+	return crypto.HashAll(p.NFT, p.Mint, p.Transfers, p.CreatorPublicKey)
+}
+
+// NFTCustodyOutputIndex returns the index of the output which holds the
+// custody of the NFT after a mint or transfer transaction. The second return
+// value is false if the transaction has no custody output.
+func NFTCustodyOutputIndex(t Transaction) (uint64, bool) {
+	lockup := NFTLockupUnlockConditions.UnlockHash()
+	storagePool := NFTStoragePoolUnlockConditions.UnlockHash()
+	for i, sco := range t.SiacoinOutputs {
+		if sco.UnlockHash != lockup && sco.UnlockHash != storagePool {
+			return uint64(i), true
+		}
+	}
+	return 0, false
+}
+
+// NFTProvenanceLicense returns the license embedded in a mint transaction. The
+// license of mints without one has kind NFTLicenseNone.
+func NFTProvenanceLicense(mint Transaction) NFTLicense {
+	if len(mint.ArbitraryData) == 0 {
+		return NFTLicense{}
+	}
+	_, l, err := ParseNFTLicense(mint.ArbitraryData[0])
+	if err != nil {
+		return NFTLicense{}
+	}
+	return l
+}
+
+// nftProvenanceValidationHeight returns the height at which consensus
+// validated the transactions of the block at the given height. Transactions
+// are validated against the height of the block's parent.
+func nftProvenanceValidationHeight(entry NFTProvenanceEntry) BlockHeight {
+	if entry.BlockHeight == 0 {
+		return 0
+	}
+	return entry.BlockHeight - 1
+}
+
+// VerifyNFTProvenance verifies that a provenance document describes a valid
+// and continuous chain of custody for its NFT and that it was signed by the
+// NFT's creator. The block references can't be checked without access to the
+// blockchain and are only checked for being ordered.
+func VerifyNFTProvenance(p NFTProvenance) error {
+	// Check the mint.
+	mint := p.Mint.Transaction
+	if !IsNFTMintTransaction(mint) {
+		return ErrNFTProvenanceBadMint
+	}
+	// The document's NFT, including any content commitment, has to match
+	// the mint exactly.
+	nft, owner := ExtractNFTFromTransaction(mint)
+	if nft != p.NFT {
+		return ErrNFTProvenanceBadMint
+	}
+	if err := mint.StandaloneValid(nftProvenanceValidationHeight(p.Mint)); err != nil {
+		return errors.Compose(ErrNFTProvenanceBadMint, err)
+	}
+	if p.License != NFTProvenanceLicense(mint) {
+		return ErrNFTProvenanceBadLicense
+	}
+	custodyIndex, ok := NFTCustodyOutputIndex(mint)
+	if !ok {
+		return ErrNFTProvenanceBadMint
+	}
+	custody := mint.SiacoinOutputID(custodyIndex)
+
+	// Check the creator's signature. Only ed25519 keys can be converted for
+	// the signature check.
+	if p.CreatorPublicKey.Algorithm != SignatureEd25519 || len(p.CreatorPublicKey.Key) != crypto.PublicKeySize {
+		return errors.AddContext(ErrNFTProvenanceBadCreator, "creator key is not an ed25519 key")
+	}
+	uc := UnlockConditions{
+		PublicKeys:         []SiaPublicKey{p.CreatorPublicKey},
+		SignaturesRequired: 1,
+	}
+	if uc.UnlockHash() != owner.UnlockHash {
+		return ErrNFTProvenanceBadCreator
+	}
+	if err := crypto.VerifyHash(p.SigHash(), p.CreatorPublicKey.ToPublicKey(), p.CreatorSignature); err != nil {
+		return errors.AddContext(err, "invalid creator signature")
+	}
+
+	// Check the chain of custody. operator is the address of the operator
+	// approved by the current approval of the NFT, if any.
+	height := p.Mint.BlockHeight
+	var operator UnlockHash
+	var approved bool
+	for i, entry := range p.Transfers {
+		txn := entry.Transaction
+		liquidation := IsNFTLiquidationTransaction(txn)
+		if !IsNFTTransferTransaction(txn) && !IsNFTReclaimTransaction(txn) && !IsNFTBridgeLockTransaction(txn) && !IsNFTBridgeUnlockTransaction(txn) &&
+			!IsNFTStakeTransaction(txn) && !IsNFTUnstakeTransaction(txn) && !IsNFTInsuranceChallengeTransaction(txn) && !IsNFTInsuranceClaimTransaction(txn) &&
+			!IsNFTApproveTransaction(txn) && !liquidation {
+			return errors.AddContext(ErrNFTProvenanceBadTransfer, "not an nft transfer")
+		}
+		if liquidation && i != len(p.Transfers)-1 {
+			return errors.AddContext(ErrNFTProvenanceBadTransfer, "nft was transferred after its liquidation")
+		}
+		if nft, _ := ExtractNFTFromTransaction(txn); nft.Identifier() != p.NFT.Identifier() {
+			return errors.AddContext(ErrNFTProvenanceBadTransfer, "transfer of a different nft")
+		}
+		if err := txn.StandaloneValid(nftProvenanceValidationHeight(entry)); err != nil {
+			return errors.Compose(ErrNFTProvenanceBadTransfer, err)
+		}
+		if entry.BlockHeight < height {
+			return ErrNFTProvenanceBadOrder
+		}
+		height = entry.BlockHeight
+
+		// The transfer needs to spend the current custody output. Transfers
+		// by an approved operator spend a ticket of the operator instead.
+		// Like consensus, tickets are recognized by the operator's address,
+		// whose signature is checked by StandaloneValid.
+		spent := false
+		for _, sci := range txn.SiacoinInputs {
+			if sci.ParentID == custody || (approved && IsNFTTransferTransaction(txn) && sci.UnlockConditions.UnlockHash() == operator) {
+				spent = true
+				break
+			}
+		}
+		if !spent {
+			return ErrNFTProvenanceBrokenChain
+		}
+		if liquidation {
+			break
+		}
+		custodyIndex, ok := NFTCustodyOutputIndex(txn)
+		if !ok {
+			return errors.AddContext(ErrNFTProvenanceBadTransfer, "transfer has no custody output")
+		}
+		custody = txn.SiacoinOutputID(custodyIndex)
+		switch {
+		case IsNFTRevokeApprovalTransaction(txn), IsNFTTransferTransaction(txn), IsNFTBridgeLockTransaction(txn):
+			approved = false
+		case IsNFTApproveTransaction(txn):
+			operator = txn.SiacoinOutputs[1].UnlockHash
+			approved = true
+		}
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package types
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+)
+
+// testNFTKey is a key that can be used to receive and spend NFTs in tests.
+type testNFTKey struct {
+	sk crypto.SecretKey
+	pk SiaPublicKey
+	uc UnlockConditions
+}
+
+// newTestNFTKey creates a new testNFTKey.
+func newTestNFTKey() testNFTKey {
+	sk, pk := crypto.GenerateKeyPair()
+	spk := Ed25519PublicKey(pk)
+	return testNFTKey{
+		sk: sk,
+		pk: spk,
+		uc: UnlockConditions{
+			PublicKeys:         []SiaPublicKey{spk},
+			SignaturesRequired: 1,
+		},
+	}
+}
+
+// newTestNFTTransaction creates a signed NFT transaction with the given tag
+// which spends the output parent with key and creates the given outputs.
+func newTestNFTTransaction(tag []byte, nft NftCustody, parent SiacoinOutputID, key testNFTKey, outputs []SiacoinOutput, height BlockHeight) Transaction {
+	txn := Transaction{
+		SiacoinInputs:  []SiacoinInput{{ParentID: parent, UnlockConditions: key.uc}},
+		SiacoinOutputs: outputs,
+		ArbitraryData:  [][]byte{NFTArbitraryData(tag, nft)},
+		TransactionSignatures: []TransactionSignature{{
+			ParentID:      crypto.Hash(parent),
+			CoveredFields: CoveredFields{WholeTransaction: true},
+		}},
+	}
+	sig := crypto.SignHash(txn.SigHash(0, height), key.sk)
+	txn.TransactionSignatures[0].Signature = sig[:]
+	return txn
+}
+
+// newTestNFTProvenance creates a valid provenance document of an NFT which
+// was minted, transferred twice and then liquidated. The creator's secret key
+// is returned alongside the document.
+func newTestNFTProvenance() (NFTProvenance, crypto.SecretKey) {
+	nft := NftCustody{
+		ContentType:   "image/png",
+		ContentLength: 1 << 20,
+	}
+	fastrand.Read(nft.FileMerkleRoot[:])
+	creator, owner1, owner2 := newTestNFTKey(), newTestNFTKey(), newTestNFTKey()
+
+	// Mint the NFT to the creator.
+	var funding SiacoinOutputID
+	fastrand.Read(funding[:])
+	mint := newTestNFTTransaction(NFTMintTag, nft, funding, creator, []SiacoinOutput{
+		{UnlockHash: NFTLockupUnlockConditions.UnlockHash(), Value: NFTLockupAmount},
+		{UnlockHash: NFTStoragePoolUnlockConditions.UnlockHash(), Value: NFTLockupAmount},
+		{UnlockHash: creator.uc.UnlockHash(), Value: OneBaseUnit},
+	}, 10)
+
+	// Transfer it twice and liquidate it.
+	transfer1 := newTestNFTTransaction(NFTTransferTag, nft, mint.SiacoinOutputID(2), creator, []SiacoinOutput{
+		{UnlockHash: NFTStoragePoolUnlockConditions.UnlockHash(), Value: NFTTransferCost},
+		{UnlockHash: owner1.uc.UnlockHash(), Value: OneBaseUnit},
+	}, 20)
+	transfer2 := newTestNFTTransaction(NFTTransferTag, nft, transfer1.SiacoinOutputID(1), owner1, []SiacoinOutput{
+		{UnlockHash: NFTStoragePoolUnlockConditions.UnlockHash(), Value: NFTTransferCost},
+		{UnlockHash: owner2.uc.UnlockHash(), Value: OneBaseUnit},
+	}, 20)
+	liquidation := newTestNFTTransaction(NFTLiquidationTag, nft, transfer2.SiacoinOutputID(1), owner2, []SiacoinOutput{
+		{UnlockHash: owner2.uc.UnlockHash(), Value: NFTLockupAmount},
+	}, 30)
+
+	p := NFTProvenance{
+		NFT:  nft,
+		Mint: NFTProvenanceEntry{Transaction: mint, BlockHeight: 10},
+		Transfers: []NFTProvenanceEntry{
+			{Transaction: transfer1, BlockHeight: 20},
+			{Transaction: transfer2, BlockHeight: 20},
+			{Transaction: liquidation, BlockHeight: 30},
+		},
+		CreatorPublicKey: creator.pk,
+	}
+	p.CreatorSignature = crypto.SignHash(p.SigHash(), creator.sk)
+	return p, creator.sk
+}
+
+// TestVerifyNFTProvenance tests verifying NFT provenance documents.
+func TestVerifyNFTProvenance(t *testing.T) {
+	p, sk := newTestNFTProvenance()
+	if err := VerifyNFTProvenance(p); err != nil {
+		t.Fatal(err)
+	}
+
+	// resign signs a modified document with the creator's key.
+	resign := func(modified NFTProvenance) NFTProvenance {
+		modified.CreatorSignature = crypto.SignHash(modified.SigHash(), sk)
+		return modified
+	}
+
+	// Modifying the document without resigning it should fail.
+	unsigned := p
+	unsigned.Transfers = p.Transfers[:1]
+	if err := VerifyNFTProvenance(unsigned); err == nil {
+		t.Fatal("expected modified document to fail")
+	}
+
+	// A partial chain of custody is still valid.
+	if err := VerifyNFTProvenance(resign(unsigned)); err != nil {
+		t.Fatal(err)
+	}
+
+	// A corrupted signature should fail.
+	badSig := p
+	badSig.CreatorSignature[0]++
+	if err := VerifyNFTProvenance(badSig); err == nil {
+		t.Fatal("expected corrupted signature to fail")
+	}
+
+	// Changing the NFT should fail.
+	wrongNFT := p
+	wrongNFT.NFT.FileMerkleRoot[0]++
+	if err := VerifyNFTProvenance(resign(wrongNFT)); !errors.Contains(err, ErrNFTProvenanceBadMint) {
+		t.Fatal("expected ErrNFTProvenanceBadMint but got", err)
+	}
+
+	// Using a transfer as the mint should fail.
+	badMint := p
+	badMint.Mint = p.Transfers[0]
+	if err := VerifyNFTProvenance(resign(badMint)); !errors.Contains(err, ErrNFTProvenanceBadMint) {
+		t.Fatal("expected ErrNFTProvenanceBadMint but got", err)
+	}
+
+	// A different creator key should fail.
+	badCreator := p
+	badCreator.CreatorPublicKey = newTestNFTKey().pk
+	if err := VerifyNFTProvenance(resign(badCreator)); !errors.Contains(err, ErrNFTProvenanceBadCreator) {
+		t.Fatal("expected ErrNFTProvenanceBadCreator but got", err)
+	}
+
+	// A creator key which isn't an ed25519 key should fail instead of
+	// panicking, even if the NFT was minted to its address.
+	shortKey := SiaPublicKey{Algorithm: SignatureEd25519, Key: []byte{1, 2, 3}}
+	shortKeyUC := UnlockConditions{PublicKeys: []SiaPublicKey{shortKey}, SignaturesRequired: 1}
+	var funding SiacoinOutputID
+	fastrand.Read(funding[:])
+	shortKeyMint := newTestNFTTransaction(NFTMintTag, p.NFT, funding, newTestNFTKey(), []SiacoinOutput{
+		{UnlockHash: NFTLockupUnlockConditions.UnlockHash(), Value: NFTLockupAmount},
+		{UnlockHash: NFTStoragePoolUnlockConditions.UnlockHash(), Value: NFTLockupAmount},
+		{UnlockHash: shortKeyUC.UnlockHash(), Value: OneBaseUnit},
+	}, 10)
+	shortKeyDoc := NFTProvenance{
+		NFT:              p.NFT,
+		Mint:             NFTProvenanceEntry{Transaction: shortKeyMint, BlockHeight: 10},
+		CreatorPublicKey: shortKey,
+	}
+	if err := VerifyNFTProvenance(shortKeyDoc); !errors.Contains(err, ErrNFTProvenanceBadCreator) {
+		t.Fatal("expected ErrNFTProvenanceBadCreator but got", err)
+	}
+
+	// Skipping a transfer breaks the chain of custody.
+	skipped := p
+	skipped.Transfers = []NFTProvenanceEntry{p.Transfers[0], p.Transfers[2]}
+	if err := VerifyNFTProvenance(resign(skipped)); !errors.Contains(err, ErrNFTProvenanceBrokenChain) {
+		t.Fatal("expected ErrNFTProvenanceBrokenChain but got", err)
+	}
+
+	// Transfers need to be ordered by height.
+	unordered := p
+	unordered.Transfers = append([]NFTProvenanceEntry{}, p.Transfers...)
+	unordered.Transfers[1].BlockHeight = 15
+	if err := VerifyNFTProvenance(resign(unordered)); !errors.Contains(err, ErrNFTProvenanceBadOrder) {
+		t.Fatal("expected ErrNFTProvenanceBadOrder but got", err)
+	}
+
+	// Transfers after the liquidation should fail.
+	afterLiquidation := p
+	afterLiquidation.Transfers = append(append([]NFTProvenanceEntry{}, p.Transfers...), p.Transfers[0])
+	if err := VerifyNFTProvenance(resign(afterLiquidation)); !errors.Contains(err, ErrNFTProvenanceBadTransfer) {
+		t.Fatal("expected ErrNFTProvenanceBadTransfer but got", err)
+	}
+
+	// The license has to match the mint.
+	badLicense := p
+	badLicense.License = NFTSPDXLicense("MIT")
+	if err := VerifyNFTProvenance(resign(badLicense)); !errors.Contains(err, ErrNFTProvenanceBadLicense) {
+		t.Fatal("expected ErrNFTProvenanceBadLicense but got", err)
+	}
+
+	// A transfer with an invalid signature should fail.
+	badTransfer := p
+	badTransfer.Transfers = append([]NFTProvenanceEntry{}, p.Transfers...)
+	badTransfer.Transfers[0].Transaction.TransactionSignatures = []TransactionSignature{p.Transfers[0].Transaction.TransactionSignatures[0]}
+	badTransfer.Transfers[0].Transaction.TransactionSignatures[0].Signature = make([]byte, crypto.SignatureSize)
+	if err := VerifyNFTProvenance(resign(badTransfer)); !errors.Contains(err, ErrNFTProvenanceBadTransfer) {
+		t.Fatal("expected ErrNFTProvenanceBadTransfer but got", err)
+	}
+}
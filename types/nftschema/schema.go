@@ -0,0 +1,191 @@
+// Package nftschema defines the canonical metadata document of an NFT. The
+// metadata describes the data an NFT commits to with a title, its creator,
+// a list of attributes, the merkle root of the data and the license it is
+// published under. Documents carry a schema version, so the schema can evolve
+// without breaking the documents of existing NFTs. The wallet API validates
+// metadata at mint time and the NFT gateway validates it before rendering it.
+package nftschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+)
+
+const (
+	// Version1 is the first version of the metadata schema.
+	Version1 = 1
+
+	// CurrentVersion is the version of the schema new documents should use.
+	CurrentVersion = Version1
+)
+
+const (
+	// MaxMetadataSize is the maximum size of an encoded metadata document.
+	MaxMetadataSize = 1 << 16
+
+	// MaxTitleLength is the maximum length of the title of an NFT.
+	MaxTitleLength = 256
+
+	// MaxAttributes is the maximum number of attributes of an NFT.
+	MaxAttributes = 256
+)
+
+var (
+	// ErrUnsupportedVersion is returned for documents of an unknown schema
+	// version.
+	ErrUnsupportedVersion = errors.New("unsupported metadata schema version")
+
+	// ErrMissingVersion is returned in strict mode for documents without a
+	// schema version.
+	ErrMissingVersion = errors.New("metadata has no schema version")
+
+	// ErrMissingTitle is returned for documents without a title.
+	ErrMissingTitle = errors.New("metadata has no title")
+
+	// ErrTitleTooLong is returned for titles longer than MaxTitleLength.
+	ErrTitleTooLong = fmt.Errorf("metadata title is longer than %v bytes", MaxTitleLength)
+
+	// ErrMissingCreator is returned for documents without a creator.
+	ErrMissingCreator = errors.New("metadata has no creator")
+
+	// ErrMissingContentHash is returned for documents without a content hash.
+	ErrMissingContentHash = errors.New("metadata has no content hash")
+
+	// ErrContentHashMismatch is returned if the content hash of a document
+	// doesn't match the merkle root of the NFT it describes.
+	ErrContentHashMismatch = errors.New("metadata content hash doesn't match the nft's merkle root")
+
+	// ErrMissingLicense is returned in strict mode for documents without a
+	// license.
+	ErrMissingLicense = errors.New("metadata has no license")
+
+	// ErrTooManyAttributes is returned for documents with more than
+	// MaxAttributes attributes.
+	ErrTooManyAttributes = fmt.Errorf("metadata has more than %v attributes", MaxAttributes)
+
+	// ErrInvalidAttribute is returned for attributes without a trait type,
+	// with a duplicate trait type or with a value which isn't a string, a
+	// number or a boolean.
+	ErrInvalidAttribute = errors.New("invalid metadata attribute")
+
+	// ErrMetadataTooLarge is returned for documents larger than
+	// MaxMetadataSize.
+	ErrMetadataTooLarge = fmt.Errorf("metadata is larger than %v bytes", MaxMetadataSize)
+)
+
+type (
+	// Metadata is the canonical metadata document of an NFT. ContentHash is
+	// the merkle root of the data the NFT commits to and License is an SPDX
+	// license identifier or a reference to custom terms.
+	Metadata struct {
+		Version     int         `json:"version"`
+		Title       string      `json:"title"`
+		Creator     string      `json:"creator"`
+		Description string      `json:"description,omitempty"`
+		Attributes  []Attribute `json:"attributes,omitempty"`
+		ContentHash crypto.Hash `json:"contenthash"`
+		License     string      `json:"license,omitempty"`
+	}
+
+	// Attribute is a trait of an NFT. Value is a string, a number or a
+	// boolean.
+	Attribute struct {
+		TraitType string      `json:"trait_type"`
+		Value     interface{} `json:"value"`
+	}
+)
+
+// validators contains the validation of every supported schema version.
+var validators = map[int]func(Metadata, bool) error{
+	Version1: validateV1,
+}
+
+// Parse decodes and validates a metadata document. In strict mode, the
+// document must declare its schema version and a license, and unknown fields
+// are rejected. Otherwise unknown fields are ignored and a document without a
+// version is validated as the current version.
+func Parse(b []byte, strict bool) (Metadata, error) {
+	if len(b) > MaxMetadataSize {
+		return Metadata{}, ErrMetadataTooLarge
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if strict {
+		dec.DisallowUnknownFields()
+	}
+	var md Metadata
+	if err := dec.Decode(&md); err != nil {
+		return Metadata{}, errors.AddContext(err, "unable to decode metadata")
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return Metadata{}, errors.New("unable to decode metadata: unexpected data after the document")
+	}
+	if md.Version == 0 && !strict {
+		md.Version = CurrentVersion
+	}
+	return md, md.Validate(strict)
+}
+
+// Validate checks that the metadata is valid for its schema version.
+func (md Metadata) Validate(strict bool) error {
+	if md.Version == 0 {
+		return ErrMissingVersion
+	}
+	validate, exists := validators[md.Version]
+	if !exists {
+		return errors.AddContext(ErrUnsupportedVersion, fmt.Sprint(md.Version))
+	}
+	return validate(md, strict)
+}
+
+// ValidateFor checks that the metadata is valid and describes the data with
+// the provided merkle root.
+func (md Metadata) ValidateFor(root crypto.Hash, strict bool) error {
+	if err := md.Validate(strict); err != nil {
+		return err
+	}
+	if md.ContentHash != root {
+		return ErrContentHashMismatch
+	}
+	return nil
+}
+
+// validateV1 validates a document of version 1 of the schema.
+func validateV1(md Metadata, strict bool) error {
+	switch {
+	case md.Title == "":
+		return ErrMissingTitle
+	case len(md.Title) > MaxTitleLength:
+		return ErrTitleTooLong
+	case md.Creator == "":
+		return ErrMissingCreator
+	case md.ContentHash == crypto.Hash{}:
+		return ErrMissingContentHash
+	case strict && md.License == "":
+		return ErrMissingLicense
+	case len(md.Attributes) > MaxAttributes:
+		return ErrTooManyAttributes
+	}
+	traits := make(map[string]struct{}, len(md.Attributes))
+	for i, a := range md.Attributes {
+		if a.TraitType == "" {
+			return errors.AddContext(ErrInvalidAttribute, fmt.Sprintf("attribute %v has no trait type", i))
+		}
+		if _, exists := traits[a.TraitType]; exists {
+			return errors.AddContext(ErrInvalidAttribute, fmt.Sprintf("duplicate trait type %q", a.TraitType))
+		}
+		traits[a.TraitType] = struct{}{}
+		switch a.Value.(type) {
+		case string, bool, json.Number, float64, int, int64, uint64:
+		default:
+			return errors.AddContext(ErrInvalidAttribute, fmt.Sprintf("value of trait type %q isn't a string, number or boolean", a.TraitType))
+		}
+	}
+	return nil
+}
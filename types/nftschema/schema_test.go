@@ -0,0 +1,101 @@
+package nftschema
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+)
+
+// TestParse tests parsing and validating metadata documents.
+func TestParse(t *testing.T) {
+	root := crypto.MerkleRoot([]byte("nft"))
+	valid := Metadata{
+		Version:     CurrentVersion,
+		Title:       "nft",
+		Creator:     "creator",
+		Attributes:  []Attribute{{TraitType: "color", Value: "red"}, {TraitType: "level", Value: 3}},
+		ContentHash: root,
+		License:     "CC-BY-4.0",
+	}
+	encode := func(md Metadata) string {
+		b, err := json.Marshal(md)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+	with := func(f func(*Metadata)) string {
+		md := valid
+		md.Attributes = append([]Attribute(nil), valid.Attributes...)
+		f(&md)
+		return encode(md)
+	}
+	unknownField := strings.TrimSuffix(encode(valid), "}") + `,"foo":1}`
+
+	tests := []struct {
+		name   string
+		doc    string
+		strict bool
+		err    error
+	}{
+		{"valid", encode(valid), true, nil},
+		{"no version", with(func(md *Metadata) { md.Version = 0 }), false, nil},
+		{"no version strict", with(func(md *Metadata) { md.Version = 0 }), true, ErrMissingVersion},
+		{"unknown version", with(func(md *Metadata) { md.Version = 99 }), false, ErrUnsupportedVersion},
+		{"no title", with(func(md *Metadata) { md.Title = "" }), false, ErrMissingTitle},
+		{"long title", with(func(md *Metadata) { md.Title = strings.Repeat("a", MaxTitleLength+1) }), false, ErrTitleTooLong},
+		{"no creator", with(func(md *Metadata) { md.Creator = "" }), false, ErrMissingCreator},
+		{"no content hash", with(func(md *Metadata) { md.ContentHash = crypto.Hash{} }), false, ErrMissingContentHash},
+		{"no license", with(func(md *Metadata) { md.License = "" }), false, nil},
+		{"no license strict", with(func(md *Metadata) { md.License = "" }), true, ErrMissingLicense},
+		{"no trait type", with(func(md *Metadata) { md.Attributes[0].TraitType = "" }), false, ErrInvalidAttribute},
+		{"duplicate trait type", with(func(md *Metadata) { md.Attributes[1].TraitType = "color" }), false, ErrInvalidAttribute},
+		{"object value", with(func(md *Metadata) { md.Attributes[0].Value = map[string]string{} }), false, ErrInvalidAttribute},
+		{"unknown field", unknownField, false, nil},
+		{"unknown field strict", unknownField, true, errors.New("")},
+		{"trailing data", encode(valid) + "{}", false, errors.New("")},
+	}
+	for _, test := range tests {
+		_, err := Parse([]byte(test.doc), test.strict)
+		switch {
+		case test.err == nil && err != nil:
+			t.Errorf("%v: unexpected error: %v", test.name, err)
+		case test.err != nil && err == nil:
+			t.Errorf("%v: expected error", test.name)
+		case test.err != nil && test.err.Error() != "" && !errors.Contains(err, test.err):
+			t.Errorf("%v: expected %v but got %v", test.name, test.err, err)
+		}
+	}
+
+	// Parsing a valid document returns it.
+	md, err := Parse([]byte(encode(valid)), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md.Title != valid.Title || md.ContentHash != root || len(md.Attributes) != 2 {
+		t.Fatal("wrong metadata", md)
+	}
+	if _, err := Parse(make([]byte, MaxMetadataSize+1), false); !errors.Contains(err, ErrMetadataTooLarge) {
+		t.Fatal("expected ErrMetadataTooLarge but got", err)
+	}
+}
+
+// TestValidateFor tests that the content hash of a document must match the
+// merkle root of the NFT.
+func TestValidateFor(t *testing.T) {
+	root := crypto.MerkleRoot([]byte("nft"))
+	md := Metadata{Version: Version1, Title: "nft", Creator: "creator", ContentHash: root}
+	if err := md.ValidateFor(root, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := md.ValidateFor(crypto.Hash{1}, false); !errors.Contains(err, ErrContentHashMismatch) {
+		t.Fatal("expected ErrContentHashMismatch but got", err)
+	}
+	if err := md.ValidateFor(root, true); !errors.Contains(err, ErrMissingLicense) {
+		t.Fatal("expected ErrMissingLicense but got", err)
+	}
+}
@@ -0,0 +1,267 @@
+package types
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+)
+
+// nftstake.go contains NFT staking, which lets the owner of an NFT signal
+// demand for hosting the NFT's data. A stake transaction keeps the NFT at its
+// owner's address and burns the stake, which consensus accounts for per merkle
+// root. While an NFT is staked its custody output is locked: it can only be
+// spent by an unstake transaction, which mints the NFT's remaining share of the
+// staked coins back to its owner.
+//
+// Hosts storing the data of a merkle root with stake earn bonus payouts from
+// its stake. A reward claim proves the storage of a segment of the data chosen
+// by the current block and the payout address, and mints a fraction of the
+// remaining stake of the root. Each root pays out at most one reward per reward
+// period.
+
+const (
+	// NFTStakeRewardDivisor is the fraction of the remaining stake of a
+	// merkle root which is paid out by a reward claim.
+	NFTStakeRewardDivisor = 100
+
+	// NFTMaxStakeRewardProofLen is the maximum number of hashes of the
+	// merkle proof of a reward claim or an insurance response.
+	NFTMaxStakeRewardProofLen = 64
+)
+
+var (
+	// NFTStakeTag and NFTUnstakeTag mark transactions which stake an NFT and
+	// release its stake again. NFTStakeRewardTag marks reward claims. Like
+	// reclaims they always carry a version byte.
+	NFTStakeTag       = []byte{'S', 'K'}
+	NFTUnstakeTag     = []byte{'U', 'S'}
+	NFTStakeRewardTag = []byte{'S', 'W'}
+
+	// NFTStakingPoolUnlockHash receives the stake of staked NFTs. No unlock
+	// conditions hash to it, so staked coins are burned and minted again by
+	// unstakes and reward claims.
+	NFTStakingPoolUnlockHash = UnlockHash{'S', 'K'}
+
+	// NFTMinStake is the smallest stake an NFT can be staked with.
+	NFTMinStake = MustCurrency("100SC")
+
+	// NFTStakeRewardPeriod is the number of blocks which have to pass
+	// between two reward claims of the same merkle root.
+	NFTStakeRewardPeriod = build.Select(build.Var{
+		Dev:      BlockHeight(10),
+		Standard: BlocksPerDay,
+		Testing:  BlockHeight(2),
+	}).(BlockHeight)
+
+	// ErrNFTNotStakeReward is returned when parsing the claim of arbitrary
+	// data which is not a reward claim.
+	ErrNFTNotStakeReward = errors.New("nft arbitrary data is not a stake reward claim")
+)
+
+type (
+	// NFTStake is the stake of a staked NFT. Root is the merkle root of the
+	// NFT's data, which the stake is accounted to.
+	NFTStake struct {
+		Root   crypto.Hash `json:"root"`
+		Amount Currency    `json:"amount"`
+		Height BlockHeight `json:"height"`
+	}
+
+	// NFTRootStake is the stake of all NFTs with the same merkle root.
+	// Staked is the sum of the stakes of all staked NFTs and Remaining what
+	// is left of it after paying out rewards. Paid is the sum of all rewards
+	// and shares minted for the root. LastReward is the height of the last
+	// reward claim.
+	NFTRootStake struct {
+		Staked     Currency    `json:"staked"`
+		Remaining  Currency    `json:"remaining"`
+		Paid       Currency    `json:"paid"`
+		LastReward BlockHeight `json:"lastreward"`
+	}
+
+	// NFTStakeRewardClaim proves the storage of a segment of the data with
+	// the merkle root Root. Segment is the segment and HashSet its merkle
+	// proof.
+	NFTStakeRewardClaim struct {
+		Root        crypto.Hash
+		NumSegments uint64
+		Segment     []byte
+		HashSet     []crypto.Hash
+	}
+)
+
+// Reward returns the payout of the next reward claim of the merkle root.
+func (s NFTRootStake) Reward() Currency {
+	return s.Remaining.Div64(NFTStakeRewardDivisor)
+}
+
+// CanReward returns true if a reward can be claimed in the block at the given
+// height.
+func (s NFTRootStake) CanReward(height BlockHeight) bool {
+	return !s.Reward().IsZero() && (s.LastReward == 0 || height >= s.LastReward+NFTStakeRewardPeriod)
+}
+
+// Share returns the part of the remaining stake which is returned when
+// unstaking an NFT with the given stake.
+func (s NFTRootStake) Share(amount Currency) Currency {
+	if s.Staked.IsZero() {
+		return ZeroCurrency
+	}
+	return amount.Mul(s.Remaining).Div(s.Staked)
+}
+
+// NFTStakeRewardSegmentIndex returns the index of the segment a reward claim
+// in the block following parentID has to prove. The index depends on the
+// address the reward is paid to, so that a claim can't be copied to pay out
+// to a different address without storing the data.
+func NFTStakeRewardSegmentIndex(parentID BlockID, root crypto.Hash, payout UnlockHash, numSegments uint64) uint64 {
+	if numSegments == 0 {
+		return 0
+	}
+	seed := crypto.HashAll(parentID, root, payout)
+	seedInt := new(big.Int).SetBytes(seed[:])
+	return seedInt.Mod(seedInt, new(big.Int).SetUint64(numSegments)).Uint64()
+}
+
+// NewNFTStakeRewardClaim creates the reward claim for data in the block
+// following parentID which pays out to payout.
+func NewNFTStakeRewardClaim(data []byte, parentID BlockID, payout UnlockHash) NFTStakeRewardClaim {
+	root := crypto.MerkleRoot(data)
+	numSegments := crypto.CalculateLeaves(uint64(len(data)))
+	index := NFTStakeRewardSegmentIndex(parentID, root, payout, numSegments)
+	base, hashSet := crypto.MerkleProof(data, index)
+	return NFTStakeRewardClaim{
+		Root:        root,
+		NumSegments: numSegments,
+		Segment:     base,
+		HashSet:     hashSet,
+	}
+}
+
+// Verify checks that the claim proves the segment chosen for a claim in the
+// block following parentID which pays out to payout.
+func (c NFTStakeRewardClaim) Verify(parentID BlockID, payout UnlockHash) bool {
+	index := NFTStakeRewardSegmentIndex(parentID, c.Root, payout, c.NumSegments)
+	return c.NumSegments != 0 && crypto.VerifySegment(c.Segment, c.HashSet, c.NumSegments, index, c.Root)
+}
+
+// NFTStakeRewardArbitraryData encodes the NFTVersion7 entry of a reward claim.
+func NFTStakeRewardArbitraryData(c NFTStakeRewardClaim) []byte {
+	arb := make([]byte, 0, SpecifierLen+NFTVersionLen+NFTTagLen+NFTMerkleRootLength+8+2+len(c.Segment)+len(c.HashSet)*crypto.HashSize)
+	arb = append(arb, PrefixNFTCustody[:]...)
+	arb = append(arb, NFTVersion7)
+	arb = append(arb, NFTStakeRewardTag...)
+	arb = append(arb, c.Root.String()...)
+	return appendNFTSegmentProof(arb, c.NumSegments, c.Segment, c.HashSet)
+}
+
+// appendNFTSegmentProof appends the encoding of the merkle proof of a segment
+// to b: the big endian number of segments of the data, the length of the
+// segment, the segment, the number of hashes of the proof and the hashes.
+func appendNFTSegmentProof(b []byte, numSegments uint64, segment []byte, hashSet []crypto.Hash) []byte {
+	n := make([]byte, 8)
+	binary.BigEndian.PutUint64(n, numSegments)
+	b = append(b, n...)
+	b = append(b, byte(len(segment)))
+	b = append(b, segment...)
+	b = append(b, byte(len(hashSet)))
+	for _, h := range hashSet {
+		b = append(b, h[:]...)
+	}
+	return b
+}
+
+// parseNFTSegmentProof parses a merkle proof encoded by appendNFTSegmentProof
+// from the start of b. n is the length of the encoded proof.
+func parseNFTSegmentProof(b []byte) (numSegments uint64, segment []byte, hashSet []crypto.Hash, n int, err error) {
+	if len(b) < 8+1 {
+		return 0, nil, nil, 0, ErrNFTDataLength
+	}
+	numSegments = binary.BigEndian.Uint64(b)
+	rest := b[8:]
+	segmentLen := int(rest[0])
+	if segmentLen == 0 || segmentLen > crypto.SegmentSize || len(rest) < 1+segmentLen+1 {
+		return 0, nil, nil, 0, ErrNFTDataLength
+	}
+	segment = append([]byte{}, rest[1:1+segmentLen]...)
+	rest = rest[1+segmentLen:]
+	numHashes := int(rest[0])
+	if numHashes > NFTMaxStakeRewardProofLen || len(rest) < 1+numHashes*crypto.HashSize {
+		return 0, nil, nil, 0, ErrNFTDataLength
+	}
+	for i := 0; i < numHashes; i++ {
+		var h crypto.Hash
+		copy(h[:], rest[1+i*crypto.HashSize:])
+		hashSet = append(hashSet, h)
+	}
+	return numSegments, segment, hashSet, 8 + 1 + segmentLen + 1 + numHashes*crypto.HashSize, nil
+}
+
+// parseNFTStakeRewardClaim parses the body of a reward claim.
+func parseNFTStakeRewardClaim(body []byte) (NFTStakeRewardClaim, error) {
+	headerLen := NFTTagLen + NFTMerkleRootLength
+	if len(body) < headerLen+8+1 {
+		return NFTStakeRewardClaim{}, ErrNFTDataLength
+	}
+	if !hasNFTTag(body, NFTStakeRewardTag) {
+		return NFTStakeRewardClaim{}, ErrNFTNotStakeReward
+	}
+	var c NFTStakeRewardClaim
+	if err := c.Root.LoadString(string(body[NFTTagLen:headerLen])); err != nil {
+		return NFTStakeRewardClaim{}, errors.Compose(ErrNFTBadMerkleRoot, err)
+	}
+	var n int
+	var err error
+	c.NumSegments, c.Segment, c.HashSet, n, err = parseNFTSegmentProof(body[headerLen:])
+	if err != nil {
+		return NFTStakeRewardClaim{}, err
+	}
+	if n != len(body)-headerLen {
+		return NFTStakeRewardClaim{}, ErrNFTDataLength
+	}
+	return c, nil
+}
+
+// parseNFTStakeReward parses the body of an NFTVersion7 entry. The merkle root
+// the reward is claimed for is returned as the NFT's merkle root.
+func parseNFTStakeReward(body []byte) ([]byte, NftCustody, error) {
+	c, err := parseNFTStakeRewardClaim(body)
+	if err != nil {
+		return nil, NftCustody{}, err
+	}
+	return NFTStakeRewardTag, NftCustody{FileMerkleRoot: c.Root}, nil
+}
+
+// ParseNFTStakeRewardClaim returns the claim of a reward claim's arbitrary
+// data.
+func ParseNFTStakeRewardClaim(arb []byte) (NFTStakeRewardClaim, error) {
+	version, body, ok := splitNFTArbitraryData(arb)
+	if !ok {
+		return NFTStakeRewardClaim{}, ErrNFTDataLength
+	}
+	if version != NFTVersion7 {
+		return NFTStakeRewardClaim{}, ErrNFTNotStakeReward
+	}
+	return parseNFTStakeRewardClaim(body)
+}
+
+// IsNFTStakeTransaction returns true if the transaction stakes an NFT.
+func IsNFTStakeTransaction(t Transaction) bool {
+	return isNFTTransactionWithTag(t, NFTStakeTag)
+}
+
+// IsNFTUnstakeTransaction returns true if the transaction releases the stake
+// of an NFT.
+func IsNFTUnstakeTransaction(t Transaction) bool {
+	return isNFTTransactionWithTag(t, NFTUnstakeTag)
+}
+
+// IsNFTStakeRewardTransaction returns true if the transaction claims a stake
+// reward.
+func IsNFTStakeRewardTransaction(t Transaction) bool {
+	return isNFTTransactionWithTag(t, NFTStakeRewardTag)
+}
@@ -0,0 +1,112 @@
+package types
+
+import (
+	"bytes"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+)
+
+// TestNFTRootStake is a unit test for the stake accounting of a merkle root.
+func TestNFTRootStake(t *testing.T) {
+	var s NFTRootStake
+	if s.CanReward(1) || !s.Share(NFTMinStake).IsZero() {
+		t.Fatal("root without stake shouldn't pay out")
+	}
+	s.Staked = NFTMinStake.Mul64(2)
+	s.Remaining = NFTMinStake
+	if !s.Reward().Equals(NFTMinStake.Div64(NFTStakeRewardDivisor)) {
+		t.Fatal("unexpected reward", s.Reward())
+	}
+	if !s.Share(NFTMinStake).Equals(NFTMinStake.Div64(2)) {
+		t.Fatal("unexpected share", s.Share(NFTMinStake))
+	}
+	if !s.CanReward(1) {
+		t.Fatal("first reward should be available")
+	}
+	s.LastReward = 10
+	if s.CanReward(10+NFTStakeRewardPeriod-1) || !s.CanReward(10+NFTStakeRewardPeriod) {
+		t.Fatal("rewards should be available once per reward period")
+	}
+}
+
+// TestNFTStakeRewardClaim tests creating, encoding and verifying reward claims.
+func TestNFTStakeRewardClaim(t *testing.T) {
+	data := fastrand.Bytes(crypto.SegmentSize*7 + 10)
+	var parentID BlockID
+	fastrand.Read(parentID[:])
+	payout := UnlockHash{1}
+
+	claim := NewNFTStakeRewardClaim(data, parentID, payout)
+	if claim.Root != crypto.MerkleRoot(data) {
+		t.Fatal("claim has the wrong root")
+	}
+	if !claim.Verify(parentID, payout) {
+		t.Fatal("claim should be valid")
+	}
+	if NFTStakeRewardSegmentIndex(BlockID{}, claim.Root, payout, claim.NumSegments) != NFTStakeRewardSegmentIndex(parentID, claim.Root, payout, claim.NumSegments) && claim.Verify(BlockID{}, payout) {
+		t.Fatal("claim shouldn't be valid in a different block")
+	}
+	for i := byte(2); i < 10; i++ {
+		other := UnlockHash{i}
+		if NFTStakeRewardSegmentIndex(parentID, claim.Root, other, claim.NumSegments) != NFTStakeRewardSegmentIndex(parentID, claim.Root, payout, claim.NumSegments) && claim.Verify(parentID, other) {
+			t.Fatal("claim shouldn't be valid for a different payout address")
+		}
+	}
+
+	// Claims round trip through arbitrary data.
+	arb := NFTStakeRewardArbitraryData(claim)
+	version, tag, nft, err := ParseNFTArbitraryData(arb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != NFTVersion7 || !bytes.Equal(tag, NFTStakeRewardTag) || nft.FileMerkleRoot != claim.Root {
+		t.Fatal("unexpected entry", version, tag, nft)
+	}
+	parsed, err := ParseNFTStakeRewardClaim(arb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parsed.Verify(parentID, payout) || !bytes.Equal(parsed.Segment, claim.Segment) || len(parsed.HashSet) != len(claim.HashSet) {
+		t.Fatal("parsed claim doesn't match", parsed)
+	}
+	txn := Transaction{ArbitraryData: [][]byte{arb}}
+	if !IsNFTStakeRewardTransaction(txn) || IsNFTStakeTransaction(txn) || IsNFTUnstakeTransaction(txn) {
+		t.Fatal("claim has the wrong kind")
+	}
+
+	// Reward claims can only be made with NFTVersion7.
+	var root NftCustody
+	fastrand.Read(root.FileMerkleRoot[:])
+	if _, _, _, err := ParseNFTArbitraryData(NFTArbitraryData(NFTStakeRewardTag, root)); err == nil {
+		t.Fatal("reward tag shouldn't parse without a claim")
+	}
+	if _, err := ParseNFTStakeRewardClaim(NFTArbitraryData(NFTStakeTag, root)); !errors.Contains(err, ErrNFTNotStakeReward) {
+		t.Fatal("expected ErrNFTNotStakeReward but got", err)
+	}
+
+	// Malformed claims.
+	segmentStart := SpecifierLen + NFTVersionLen + NFTTagLen + NFTMerkleRootLength + 8
+	emptySegment := append([]byte{}, arb...)
+	emptySegment[segmentStart] = 0
+	longProof := claim
+	longProof.HashSet = make([]crypto.Hash, NFTMaxStakeRewardProofLen+1)
+	tests := []struct {
+		name string
+		arb  []byte
+	}{
+		{"truncated header", arb[:segmentStart]},
+		{"truncated proof", arb[:len(arb)-1]},
+		{"trailing bytes", append(append([]byte{}, arb...), 0)},
+		{"empty segment", emptySegment},
+		{"long proof", NFTStakeRewardArbitraryData(longProof)},
+	}
+	for _, test := range tests {
+		if _, err := ParseNFTStakeRewardClaim(test.arb); !errors.Contains(err, ErrNFTDataLength) {
+			t.Errorf("%v: expected ErrNFTDataLength but got %v", test.name, err)
+		}
+	}
+}
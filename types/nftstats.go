@@ -0,0 +1,62 @@
+package types
+
+// NFTStats contains aggregate statistics about the NFTs on the blockchain up
+// to and including the block at Height.
+type NFTStats struct {
+	Height BlockHeight `json:"height"`
+
+	// Minted is the number of NFTs minted, counting every edition. Active is
+	// the number of minted NFTs which haven't been liquidated.
+	Minted     uint64 `json:"minted"`
+	Active     uint64 `json:"active"`
+	Liquidated uint64 `json:"liquidated"`
+
+	// Transfers is the total number of NFT transfers and BlockTransfers the
+	// number of transfers in the block at Height.
+	Transfers      uint64 `json:"transfers"`
+	BlockTransfers uint64 `json:"blocktransfers"`
+
+	// LockupPoolSiacoins is the amount of siacoins paid into the lockup pool
+	// which haven't been returned by reclaims or liquidations.
+	// StoragePoolSiacoins is the balance of the storage pool, the siacoins
+	// paid into it minus the siacoins paid out of it.
+	LockupPoolSiacoins  Currency `json:"lockuppoolsiacoins"`
+	StoragePoolSiacoins Currency `json:"storagepoolsiacoins"`
+
+	// The inflows of the storage pool are split by their source. Mints and
+	// transfers pay a fixed amount into the pool, bridge locks count as
+	// transfers. Every other payment into the pool is a top-up. The outflows
+	// of the pool are the siacoins paid out of it to hosts.
+	StoragePoolMintInflows     Currency `json:"storagepoolmintinflows"`
+	StoragePoolTransferInflows Currency `json:"storagepooltransferinflows"`
+	StoragePoolTopUpInflows    Currency `json:"storagepooltopupinflows"`
+	StoragePoolOutflows        Currency `json:"storagepooloutflows"`
+}
+
+// StoragePoolInflows returns the total amount of siacoins paid into the
+// storage pool.
+func (s NFTStats) StoragePoolInflows() Currency {
+	return s.StoragePoolMintInflows.Add(s.StoragePoolTransferInflows).Add(s.StoragePoolTopUpInflows)
+}
+
+// StoragePoolSolvent returns true if the storage pool never paid out more
+// siacoins than were paid into it.
+func (s NFTStats) StoragePoolSolvent() bool {
+	return s.StoragePoolInflows().Cmp(s.StoragePoolOutflows) >= 0
+}
+
+// NFTStorageEarmark is the part of the storage pool earmarked for storing
+// the data of an NFT. It sums the payments into the pool made by the NFT's
+// mint, by its transfers and bridge locks, and by other transactions of the
+// NFT which topped up the pool. Height is the height of the last payment.
+type NFTStorageEarmark struct {
+	Mint      Currency    `json:"mint"`
+	Transfers Currency    `json:"transfers"`
+	TopUps    Currency    `json:"topups"`
+	Height    BlockHeight `json:"height"`
+}
+
+// Total returns the total amount of siacoins earmarked for the NFT.
+func (e NFTStorageEarmark) Total() Currency {
+	return e.Mint.Add(e.Transfers).Add(e.TopUps)
+}
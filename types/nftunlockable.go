@@ -0,0 +1,236 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"golang.org/x/crypto/chacha20poly1305"
+
+	"go.sia.tech/siad/crypto"
+)
+
+// nftunlockable.go contains unlockable content, an encrypted secondary payload
+// of an NFT which only its current owner can decrypt. The creator encrypts the
+// payload with a random content key when minting the NFT and stores the
+// ciphertext on hosts or in the registry. The content key never appears on the
+// blockchain. Instead it travels off-chain in an envelope, which wraps the key
+// to an X25519 key of the address holding the NFT. When the NFT is
+// transferred, the recipient's wallet proves which X25519 key belongs to the
+// destination address in a handshake and the sender's wallet re-wraps the
+// content key to it.
+
+const (
+	// NFTUnlockableStorageHost means that the ciphertext of the unlockable
+	// content is stored on hosts under its merkle root.
+	NFTUnlockableStorageHost NFTUnlockableStorage = "host"
+	// NFTUnlockableStorageRegistry means that the ciphertext of the
+	// unlockable content is stored in a registry entry.
+	NFTUnlockableStorageRegistry NFTUnlockableStorage = "registry"
+)
+
+var (
+	// ErrNFTUnlockableBadStorage is returned if the location of unlockable
+	// content is unknown or incomplete.
+	ErrNFTUnlockableBadStorage = errors.New("invalid nft unlockable content storage")
+	// ErrNFTUnlockableBadCiphertext is returned when decrypting a ciphertext
+	// which doesn't match the unlockable content.
+	ErrNFTUnlockableBadCiphertext = errors.New("ciphertext doesn't match the nft unlockable content")
+	// ErrNFTUnlockableBadHandshake is returned if the key of a handshake
+	// doesn't belong to the address it was made for or its signature is
+	// invalid.
+	ErrNFTUnlockableBadHandshake = errors.New("invalid nft unlockable content handshake")
+	// ErrNFTUnlockableBadEnvelope is returned if the content key of an
+	// envelope can't be unwrapped.
+	ErrNFTUnlockableBadEnvelope = errors.New("failed to unwrap nft unlockable content key")
+
+	// specifierNFTUnlockableHandshake is used as the prefix when hashing a
+	// handshake for its signature.
+	specifierNFTUnlockableHandshake = NewSpecifier("nft unlock hs")
+	// specifierNFTUnlockableWrap is used as the prefix when deriving the key
+	// which wraps a content key.
+	specifierNFTUnlockableWrap = NewSpecifier("nft unlock wrap")
+)
+
+type (
+	// NFTUnlockableStorage describes where the ciphertext of unlockable
+	// content is stored.
+	NFTUnlockableStorage string
+
+	// NFTUnlockableKey is the key the payload of unlockable content is
+	// encrypted with.
+	NFTUnlockableKey crypto.Hash
+
+	// NFTUnlockable describes the unlockable content of an NFT. Root is the
+	// merkle root of the ciphertext if it is stored on hosts, RegistryKey and
+	// RegistryTweak identify the registry entry if it is stored in the
+	// registry. CiphertextHash is the hash of the ciphertext, so that a
+	// ciphertext can be checked before decrypting it.
+	NFTUnlockable struct {
+		Storage        NFTUnlockableStorage `json:"storage"`
+		Root           crypto.Hash          `json:"root"`
+		RegistryKey    SiaPublicKey         `json:"registrykey"`
+		RegistryTweak  crypto.Hash          `json:"registrytweak"`
+		CiphertextHash crypto.Hash          `json:"ciphertexthash"`
+	}
+
+	// NFTUnlockableHandshake is created by the wallet which is about to
+	// receive an NFT. It contains the X25519 key the content key of the
+	// NFT's unlockable content should be wrapped to, and is signed by the
+	// key of the single-key address the NFT is sent to.
+	NFTUnlockableHandshake struct {
+		Address   UnlockHash             `json:"address"`
+		PublicKey SiaPublicKey           `json:"publickey"`
+		UnlockKey crypto.X25519PublicKey `json:"unlockkey"`
+		Signature crypto.Signature       `json:"signature"`
+	}
+
+	// NFTUnlockableEnvelope contains the unlockable content of an NFT and its
+	// content key wrapped to the X25519 key of Owner, the address holding
+	// the NFT. The key is wrapped with a secret derived from EphemeralKey and
+	// the owner's key, which also authenticates the rest of the envelope.
+	NFTUnlockableEnvelope struct {
+		NFT          NftCustody             `json:"nft"`
+		Unlockable   NFTUnlockable          `json:"unlockable"`
+		Owner        UnlockHash             `json:"owner"`
+		EphemeralKey crypto.X25519PublicKey `json:"ephemeralkey"`
+		WrappedKey   []byte                 `json:"wrappedkey"`
+	}
+)
+
+// MarshalJSON marshals an NFTUnlockableKey as a hex string.
+func (k NFTUnlockableKey) MarshalJSON() ([]byte, error) {
+	return json.Marshal(k.String())
+}
+
+// String prints the NFTUnlockableKey in hex.
+func (k NFTUnlockableKey) String() string {
+	return fmt.Sprintf("%x", k[:])
+}
+
+// LoadString loads an NFTUnlockableKey from a string.
+func (k *NFTUnlockableKey) LoadString(str string) error {
+	return (*crypto.Hash)(k).LoadString(str)
+}
+
+// UnmarshalJSON decodes the json hex string of the NFTUnlockableKey.
+func (k *NFTUnlockableKey) UnmarshalJSON(b []byte) error {
+	return (*crypto.Hash)(k).UnmarshalJSON(b)
+}
+
+// Validate checks that the location of the unlockable content is complete.
+func (u NFTUnlockable) Validate() error {
+	switch u.Storage {
+	case NFTUnlockableStorageHost:
+		if u.Root == (crypto.Hash{}) {
+			return errors.AddContext(ErrNFTUnlockableBadStorage, "missing merkle root")
+		}
+	case NFTUnlockableStorageRegistry:
+		if u.RegistryKey.Algorithm != SignatureEd25519 || len(u.RegistryKey.Key) != crypto.PublicKeySize {
+			return errors.AddContext(ErrNFTUnlockableBadStorage, "registry key must be an ed25519 key")
+		}
+	default:
+		return ErrNFTUnlockableBadStorage
+	}
+	return nil
+}
+
+// EncryptNFTUnlockable encrypts the payload of unlockable content with a new
+// random content key. The ciphertext is authenticated, so that tampering is
+// detected when decrypting it. The CiphertextHash of the unlockable content is
+// the crypto.HashBytes of the returned ciphertext.
+func EncryptNFTUnlockable(payload []byte) ([]byte, NFTUnlockableKey) {
+	var key NFTUnlockableKey
+	fastrand.Read(key[:])
+	aead, err := chacha20poly1305.NewX(key[:])
+	if err != nil {
+		panic(err) // the key has the right size
+	}
+	return crypto.EncryptWithNonce(payload, aead), key
+}
+
+// DecryptNFTUnlockable decrypts the ciphertext of unlockable content with its
+// content key after checking that it is the ciphertext u refers to.
+func DecryptNFTUnlockable(u NFTUnlockable, ciphertext []byte, key NFTUnlockableKey) ([]byte, error) {
+	if crypto.HashBytes(ciphertext) != u.CiphertextHash {
+		return nil, ErrNFTUnlockableBadCiphertext
+	}
+	aead, err := chacha20poly1305.NewX(key[:])
+	if err != nil {
+		return nil, err
+	}
+	payload, err := crypto.DecryptWithNonce(ciphertext, aead)
+	if err != nil {
+		return nil, errors.Compose(ErrNFTUnlockableBadCiphertext, err)
+	}
+	return payload, nil
+}
+
+// SigHash returns the hash of the handshake which is signed by the key of its
+// address.
+func (h NFTUnlockableHandshake) SigHash() crypto.Hash {
+	return crypto.HashAll(specifierNFTUnlockableHandshake, h.Address, h.PublicKey, h.UnlockKey)
+}
+
+// Verify checks that the handshake's public key is the key of its single-key
+// address and that the key signed the handshake.
+func (h NFTUnlockableHandshake) Verify() error {
+	uc := UnlockConditions{
+		PublicKeys:         []SiaPublicKey{h.PublicKey},
+		SignaturesRequired: 1,
+	}
+	if uc.UnlockHash() != h.Address {
+		return errors.AddContext(ErrNFTUnlockableBadHandshake, "public key doesn't match the address")
+	}
+	if h.PublicKey.Algorithm != SignatureEd25519 || len(h.PublicKey.Key) != crypto.PublicKeySize {
+		return errors.AddContext(ErrNFTUnlockableBadHandshake, "public key must be an ed25519 key")
+	}
+	if err := crypto.VerifyHash(h.SigHash(), h.PublicKey.ToPublicKey(), h.Signature); err != nil {
+		return errors.Compose(ErrNFTUnlockableBadHandshake, err)
+	}
+	return nil
+}
+
+// wrapKey returns the key which wraps the content key of the envelope for the
+// given shared secret. It commits to the NFT, its unlockable content and the
+// owner, so that the wrapped key can't be moved to another envelope.
+func (e NFTUnlockableEnvelope) wrapKey(secret [32]byte) crypto.Hash {
+	return crypto.HashAll(specifierNFTUnlockableWrap, secret, e.NFT.Identifier(), e.Unlockable, e.Owner, e.EphemeralKey)
+}
+
+// WrapNFTUnlockable creates the envelope which wraps the content key of an
+// NFT's unlockable content to the X25519 key of the NFT's owner.
+func WrapNFTUnlockable(nft NftCustody, u NFTUnlockable, key NFTUnlockableKey, owner UnlockHash, ownerKey crypto.X25519PublicKey) NFTUnlockableEnvelope {
+	xsk, xpk := crypto.GenerateX25519KeyPair()
+	e := NFTUnlockableEnvelope{
+		NFT:          nft,
+		Unlockable:   u,
+		Owner:        owner,
+		EphemeralKey: xpk,
+	}
+	wrapKey := e.wrapKey(crypto.DeriveSharedSecret(xsk, ownerKey))
+	aead, err := chacha20poly1305.NewX(wrapKey[:])
+	if err != nil {
+		panic(err) // the key has the right size
+	}
+	e.WrappedKey = crypto.EncryptWithNonce(key[:], aead)
+	return e
+}
+
+// Unwrap returns the content key of the envelope using the secret X25519 key
+// of its owner.
+func (e NFTUnlockableEnvelope) Unwrap(ownerKey crypto.X25519SecretKey) (NFTUnlockableKey, error) {
+	wrapKey := e.wrapKey(crypto.DeriveSharedSecret(ownerKey, e.EphemeralKey))
+	aead, err := chacha20poly1305.NewX(wrapKey[:])
+	if err != nil {
+		return NFTUnlockableKey{}, err
+	}
+	b, err := crypto.DecryptWithNonce(e.WrappedKey, aead)
+	if err != nil || len(b) != len(NFTUnlockableKey{}) {
+		return NFTUnlockableKey{}, errors.Compose(ErrNFTUnlockableBadEnvelope, err)
+	}
+	var key NFTUnlockableKey
+	copy(key[:], b)
+	return key, nil
+}
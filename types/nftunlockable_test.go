@@ -0,0 +1,107 @@
+package types
+
+import (
+	"bytes"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+)
+
+// TestNFTUnlockable tests encrypting and decrypting unlockable content.
+func TestNFTUnlockable(t *testing.T) {
+	payload := fastrand.Bytes(100)
+	ciphertext, key := EncryptNFTUnlockable(payload)
+	if bytes.Contains(ciphertext, payload) {
+		t.Fatal("payload wasn't encrypted")
+	}
+	u := NFTUnlockable{
+		Storage:        NFTUnlockableStorageHost,
+		Root:           crypto.MerkleRoot(ciphertext),
+		CiphertextHash: crypto.HashBytes(ciphertext),
+	}
+	if err := u.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	decrypted, err := DecryptNFTUnlockable(u, ciphertext, key)
+	if err != nil || !bytes.Equal(decrypted, payload) {
+		t.Fatal("payload doesn't round trip", err)
+	}
+
+	// Other ciphertexts and keys are rejected.
+	if _, err := DecryptNFTUnlockable(u, append(ciphertext, 0), key); !errors.Contains(err, ErrNFTUnlockableBadCiphertext) {
+		t.Fatal("expected ErrNFTUnlockableBadCiphertext but got", err)
+	}
+	if _, err := DecryptNFTUnlockable(u, ciphertext, NFTUnlockableKey{1}); !errors.Contains(err, ErrNFTUnlockableBadCiphertext) {
+		t.Fatal("expected ErrNFTUnlockableBadCiphertext but got", err)
+	}
+
+	// Unlockable content needs a complete location.
+	_, pk := crypto.GenerateKeyPair()
+	for _, test := range []struct {
+		u     NFTUnlockable
+		valid bool
+	}{
+		{NFTUnlockable{}, false},
+		{NFTUnlockable{Storage: NFTUnlockableStorageHost}, false},
+		{NFTUnlockable{Storage: NFTUnlockableStorageRegistry}, false},
+		{NFTUnlockable{Storage: NFTUnlockableStorageRegistry, RegistryKey: Ed25519PublicKey(pk)}, true},
+	} {
+		if err := test.u.Validate(); (err == nil) != test.valid {
+			t.Errorf("%v: expected valid %v but got %v", test.u, test.valid, err)
+		}
+	}
+}
+
+// TestNFTUnlockableEnvelope tests re-wrapping the content key of unlockable
+// content after a handshake.
+func TestNFTUnlockableEnvelope(t *testing.T) {
+	nft := NftCustody{FileMerkleRoot: crypto.Hash{1}}
+	u := NFTUnlockable{Storage: NFTUnlockableStorageHost, Root: crypto.Hash{2}}
+	var key NFTUnlockableKey
+	fastrand.Read(key[:])
+
+	// The recipient signs a handshake for its address.
+	sk, pk := crypto.GenerateKeyPair()
+	uc := UnlockConditions{PublicKeys: []SiaPublicKey{Ed25519PublicKey(pk)}, SignaturesRequired: 1}
+	xsk, xpk := crypto.GenerateX25519KeyPair()
+	h := NFTUnlockableHandshake{
+		Address:   uc.UnlockHash(),
+		PublicKey: Ed25519PublicKey(pk),
+		UnlockKey: xpk,
+	}
+	h.Signature = crypto.SignHash(h.SigHash(), sk)
+	if err := h.Verify(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The sender wraps the key to the recipient.
+	e := WrapNFTUnlockable(nft, u, key, h.Address, h.UnlockKey)
+	if unwrapped, err := e.Unwrap(xsk); err != nil || unwrapped != key {
+		t.Fatal("key doesn't round trip", err)
+	}
+	other, _ := crypto.GenerateX25519KeyPair()
+	if _, err := e.Unwrap(other); !errors.Contains(err, ErrNFTUnlockableBadEnvelope) {
+		t.Fatal("expected ErrNFTUnlockableBadEnvelope but got", err)
+	}
+	// The wrapped key is bound to the rest of the envelope.
+	moved := e
+	moved.NFT = NftCustody{FileMerkleRoot: crypto.Hash{3}}
+	if _, err := moved.Unwrap(xsk); !errors.Contains(err, ErrNFTUnlockableBadEnvelope) {
+		t.Fatal("expected ErrNFTUnlockableBadEnvelope but got", err)
+	}
+
+	// Handshakes for another address or with another key are rejected.
+	bad := h
+	bad.Address = UnlockHash{1}
+	if err := bad.Verify(); !errors.Contains(err, ErrNFTUnlockableBadHandshake) {
+		t.Fatal("expected ErrNFTUnlockableBadHandshake but got", err)
+	}
+	bad = h
+	bad.UnlockKey = other.PublicKey()
+	if err := bad.Verify(); !errors.Contains(err, ErrNFTUnlockableBadHandshake) {
+		t.Fatal("expected ErrNFTUnlockableBadHandshake but got", err)
+	}
+}
@@ -0,0 +1,135 @@
+package types
+
+import (
+	"fmt"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+)
+
+// reserves.go contains proofs of reserves, which let custodians demonstrate
+// the NFTs and siacoins they hold without exposing their keys. A proof lists
+// the NFTs and unspent siacoin outputs held by a set of addresses at a block
+// height and is signed by the keys of every address. The signatures cover a
+// challenge chosen by the verifier, so that an old proof can't be replayed.
+// The holdings can be checked against the custody index and the unspent
+// outputs of the consensus set.
+
+var (
+	// ErrProofOfReservesUnsigned is returned if a proof of reserves lists
+	// holdings of an address which didn't sign the proof.
+	ErrProofOfReservesUnsigned = errors.New("proof of reserves lists holdings of an address which didn't sign it")
+	// ErrProofOfReservesBadSignature is returned if a signature of a proof
+	// of reserves is invalid.
+	ErrProofOfReservesBadSignature = errors.New("invalid proof of reserves signature")
+	// ErrProofOfReservesBadTotal is returned if the siacoins of a proof of
+	// reserves don't match the sum of its outputs.
+	ErrProofOfReservesBadTotal = errors.New("proof of reserves siacoins don't match its outputs")
+	// ErrProofOfReservesDuplicate is returned if a proof of reserves lists
+	// an NFT, an output or a signer more than once.
+	ErrProofOfReservesDuplicate = errors.New("proof of reserves lists a holding or signer more than once")
+
+	// specifierProofOfReserves is used as the prefix when hashing a proof of
+	// reserves for its signatures.
+	specifierProofOfReserves = NewSpecifier("reserves")
+)
+
+type (
+	// ProofOfReservesNFT is an NFT held by Address.
+	ProofOfReservesNFT struct {
+		NFT     NftCustody `json:"nft"`
+		Address UnlockHash `json:"address"`
+	}
+
+	// ProofOfReservesOutput is an unspent siacoin output.
+	ProofOfReservesOutput struct {
+		ID     SiacoinOutputID `json:"id"`
+		Output SiacoinOutput   `json:"output"`
+	}
+
+	// ProofOfReservesSigner is an address which signed a proof of reserves.
+	// The i-th signature is made by the i-th public key of the unlock
+	// conditions, and there are as many signatures as the unlock conditions
+	// require.
+	ProofOfReservesSigner struct {
+		UnlockConditions UnlockConditions   `json:"unlockconditions"`
+		Signatures       []crypto.Signature `json:"signatures"`
+	}
+
+	// ProofOfReserves is a signed statement of the NFTs and siacoins held by
+	// a set of addresses after the block with id BlockID at BlockHeight.
+	// Siacoins is the sum of the values of Outputs.
+	ProofOfReserves struct {
+		Challenge   crypto.Hash             `json:"challenge"`
+		BlockHeight BlockHeight             `json:"blockheight"`
+		BlockID     BlockID                 `json:"blockid"`
+		NFTs        []ProofOfReservesNFT    `json:"nfts"`
+		Outputs     []ProofOfReservesOutput `json:"outputs"`
+		Siacoins    Currency                `json:"siacoins"`
+		Signers     []ProofOfReservesSigner `json:"signers"`
+	}
+)
+
+// SigHash returns the hash of the proof of reserves which is signed by its
+// signers.
+func (p ProofOfReserves) SigHash() crypto.Hash {
+	return crypto.HashAll(specifierProofOfReserves, p.Challenge, p.BlockHeight, p.BlockID, p.NFTs, p.Outputs, p.Siacoins)
+}
+
+// VerifyProofOfReserves checks that a proof of reserves is consistent and that
+// every address holding one of its NFTs or outputs signed it. The holdings
+// themselves can't be checked without access to the consensus set.
+func VerifyProofOfReserves(p ProofOfReserves) error {
+	// Check the signatures.
+	sigHash := p.SigHash()
+	signed := make(map[UnlockHash]struct{})
+	for _, signer := range p.Signers {
+		uc := signer.UnlockConditions
+		addr := uc.UnlockHash()
+		if _, exists := signed[addr]; exists {
+			return errors.AddContext(ErrProofOfReservesDuplicate, fmt.Sprintf("signer %v", addr))
+		}
+		if uc.SignaturesRequired == 0 || uint64(len(signer.Signatures)) != uc.SignaturesRequired || len(signer.Signatures) > len(uc.PublicKeys) {
+			return errors.AddContext(ErrProofOfReservesBadSignature, fmt.Sprintf("wrong number of signatures for %v", addr))
+		}
+		for i, sig := range signer.Signatures {
+			pk := uc.PublicKeys[i]
+			if pk.Algorithm != SignatureEd25519 || len(pk.Key) != crypto.PublicKeySize {
+				return errors.AddContext(ErrProofOfReservesBadSignature, fmt.Sprintf("unsupported key of %v", addr))
+			}
+			if err := crypto.VerifyHash(sigHash, pk.ToPublicKey(), sig); err != nil {
+				return errors.Compose(ErrProofOfReservesBadSignature, err)
+			}
+		}
+		signed[addr] = struct{}{}
+	}
+
+	// Check that every holding is listed once and signed for.
+	nfts := make(map[NftID]struct{})
+	for _, held := range p.NFTs {
+		if _, exists := nfts[held.NFT.Identifier()]; exists {
+			return errors.AddContext(ErrProofOfReservesDuplicate, fmt.Sprintf("nft %v", held.NFT.Identifier()))
+		}
+		nfts[held.NFT.Identifier()] = struct{}{}
+		if _, exists := signed[held.Address]; !exists {
+			return errors.AddContext(ErrProofOfReservesUnsigned, held.Address.String())
+		}
+	}
+	outputs := make(map[SiacoinOutputID]struct{})
+	var total Currency
+	for _, held := range p.Outputs {
+		if _, exists := outputs[held.ID]; exists {
+			return errors.AddContext(ErrProofOfReservesDuplicate, fmt.Sprintf("output %v", held.ID))
+		}
+		outputs[held.ID] = struct{}{}
+		if _, exists := signed[held.Output.UnlockHash]; !exists {
+			return errors.AddContext(ErrProofOfReservesUnsigned, held.Output.UnlockHash.String())
+		}
+		total = total.Add(held.Output.Value)
+	}
+	if !total.Equals(p.Siacoins) {
+		return ErrProofOfReservesBadTotal
+	}
+	return nil
+}
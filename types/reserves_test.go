@@ -0,0 +1,80 @@
+package types
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+)
+
+// TestVerifyProofOfReserves tests verifying the signatures and consistency of
+// proofs of reserves.
+func TestVerifyProofOfReserves(t *testing.T) {
+	sk, pk := crypto.GenerateKeyPair()
+	uc := UnlockConditions{PublicKeys: []SiaPublicKey{Ed25519PublicKey(pk)}, SignaturesRequired: 1}
+	addr := uc.UnlockHash()
+	sign := func(p ProofOfReserves) ProofOfReserves {
+		p.Signers = []ProofOfReservesSigner{{
+			UnlockConditions: uc,
+			Signatures:       []crypto.Signature{crypto.SignHash(p.SigHash(), sk)},
+		}}
+		return p
+	}
+	p := sign(ProofOfReserves{
+		Challenge:   crypto.Hash{1},
+		BlockHeight: 10,
+		NFTs:        []ProofOfReservesNFT{{NFT: NftCustody{FileMerkleRoot: crypto.Hash{2}}, Address: addr}},
+		Outputs: []ProofOfReservesOutput{
+			{ID: SiacoinOutputID{1}, Output: SiacoinOutput{Value: NewCurrency64(5), UnlockHash: addr}},
+			{ID: SiacoinOutputID{2}, Output: SiacoinOutput{Value: NewCurrency64(7), UnlockHash: addr}},
+		},
+		Siacoins: NewCurrency64(12),
+	})
+	if err := VerifyProofOfReserves(p); err != nil {
+		t.Fatal(err)
+	}
+
+	// Changing the proof after signing it invalidates the signature.
+	bad := p
+	bad.Challenge = crypto.Hash{2}
+	if err := VerifyProofOfReserves(bad); !errors.Contains(err, ErrProofOfReservesBadSignature) {
+		t.Fatal("expected ErrProofOfReservesBadSignature but got", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(p *ProofOfReserves)
+		err    error
+	}{
+		{"unsigned nft", func(p *ProofOfReserves) { p.NFTs[0].Address = UnlockHash{1} }, ErrProofOfReservesUnsigned},
+		{"unsigned output", func(p *ProofOfReserves) { p.Outputs[0].Output.UnlockHash = UnlockHash{1} }, ErrProofOfReservesUnsigned},
+		{"wrong total", func(p *ProofOfReserves) { p.Siacoins = NewCurrency64(13) }, ErrProofOfReservesBadTotal},
+		{"duplicate nft", func(p *ProofOfReserves) { p.NFTs = append(p.NFTs, p.NFTs[0]) }, ErrProofOfReservesDuplicate},
+		{"duplicate output", func(p *ProofOfReserves) { p.Outputs[1].ID = p.Outputs[0].ID }, ErrProofOfReservesDuplicate},
+	}
+	for _, test := range tests {
+		modified := ProofOfReserves{
+			Challenge:   p.Challenge,
+			BlockHeight: p.BlockHeight,
+			NFTs:        append([]ProofOfReservesNFT(nil), p.NFTs...),
+			Outputs:     append([]ProofOfReservesOutput(nil), p.Outputs...),
+			Siacoins:    p.Siacoins,
+		}
+		test.modify(&modified)
+		if err := VerifyProofOfReserves(sign(modified)); !errors.Contains(err, test.err) {
+			t.Errorf("%v: expected %v but got %v", test.name, test.err, err)
+		}
+	}
+
+	// Signers need to provide the required number of signatures.
+	bad = p
+	bad.Signers = []ProofOfReservesSigner{{UnlockConditions: uc}}
+	if err := VerifyProofOfReserves(bad); !errors.Contains(err, ErrProofOfReservesBadSignature) {
+		t.Fatal("expected ErrProofOfReservesBadSignature but got", err)
+	}
+	bad.Signers = append(p.Signers, p.Signers...)
+	if err := VerifyProofOfReserves(bad); !errors.Contains(err, ErrProofOfReservesDuplicate) {
+		t.Fatal("expected ErrProofOfReservesDuplicate but got", err)
+	}
+}